@@ -68,11 +68,22 @@ func WalletPassword() string {
 	return os.Getenv(siaWalletPassword)
 }
 
+// IdentityPassword returns the siaIdentityPassword environment variable.
+func IdentityPassword() string {
+	return os.Getenv(siaIdentityPassword)
+}
+
 // ExchangeRate returns the siaExchangeRate environment variable.
 func ExchangeRate() string {
 	return os.Getenv(siaExchangeRate)
 }
 
+// RenterStreamPoolSize returns the siaRenterStreamPoolSize environment
+// variable.
+func RenterStreamPoolSize() string {
+	return os.Getenv(siaRenterStreamPoolSize)
+}
+
 // apiPasswordFilePath returns the path to the API's password file. The password
 // file is stored in the Sia data directory.
 func apiPasswordFilePath() string {
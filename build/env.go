@@ -17,7 +17,15 @@ var (
 	// auto unlocking the wallet
 	siaWalletPassword = "SIA_WALLET_PASSWORD"
 
+	// siaIdentityPassword is the environment variable that sets the password
+	// the node's identity keystore is encrypted with
+	siaIdentityPassword = "SIA_IDENTITY_PASSWORD"
+
 	// siaExchangeRate is the environment variable that can be set to
 	// show amounts (additionally) in a different currency
 	siaExchangeRate = "SIA_EXCHANGE_RATE"
+
+	// siaRenterStreamPoolSize is the environment variable that can be set to
+	// change the number of connections the renter opens to each host
+	siaRenterStreamPoolSize = "SIA_RENTER_STREAM_POOL_SIZE"
 )
@@ -17,6 +17,17 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusSnapshotCmd = &cobra.Command{
+		Use:   "snapshot [path]",
+		Short: "Write a snapshot of the consensus database",
+		Long: `Write a consistent copy of the consensus database to the specified path without stopping siad.
+The path is relative to the working directory of siac and needs to be accessible by siad.
+Other programs can open the snapshot read-only while siad keeps running.
+The printed checkpoint lets new nodes verify the snapshot before syncing from it with
+siad --consensus-snapshot and --consensus-checkpoint.`,
+		Run: wrap(consensussnapshotcmd),
+	}
 )
 
 // consensuscmd is the handler for the command `siac consensus`.
@@ -58,5 +69,19 @@ Progress (estimated): %.1f%%
 		fmt.Println("Block Size Limit:", cg.BlockSizeLimit)
 		fmt.Println("Maturity Delay:", cg.MaturityDelay)
 		fmt.Println("Genesis Timestamp:", time.Unix(int64(cg.GenesisTimestamp), 0))
+		fmt.Println("State Commitment:", cg.StateCommitment)
+	}
+}
+
+// consensussnapshotcmd is the handler for the command `siac consensus
+// snapshot [path]`. Writes a snapshot of the consensus database to path and
+// prints its checkpoint.
+func consensussnapshotcmd(path string) {
+	path = abs(path)
+	csp, err := httpClient.ConsensusSnapshotPost(path)
+	if err != nil {
+		die("Could not create consensus snapshot:", err)
 	}
+	fmt.Println("Wrote consensus snapshot to", path)
+	fmt.Println("Checkpoint:", csp.Checkpoint)
 }
@@ -35,6 +35,41 @@ and the duration of the bandwidth tracking.`,
 		Run:   wrap(gatewaycmd),
 	}
 
+	gatewayAllowlistCmd = &cobra.Command{
+		Use:   "allowlist",
+		Short: "View and manage the gateway's allowlisted peers",
+		Long: `Display the peers on the gateway allowlist and whether the gateway only
+connects to them.`,
+		Run: wrap(gatewayallowlistcmd),
+	}
+
+	gatewayAllowlistDisableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Allow the gateway to connect to any peer",
+		Long: `Disable the allowlist-only mode. The gateway connects to any peer which
+isn't blocklisted again. The allowlist is kept.`,
+		Run: wrap(gatewayallowlistdisablecmd),
+	}
+
+	gatewayAllowlistEnableCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Only connect to the peers on the allowlist",
+		Long: `Enable the allowlist-only mode. The gateway disconnects from all peers which
+aren't on the allowlist and only dials and accepts allowlisted peers.`,
+		Run: wrap(gatewayallowlistenablecmd),
+	}
+
+	gatewayAllowlistSetCmd = &cobra.Command{
+		Use:   "set [ip] [ip] [ip] [ip]...",
+		Short: "Set the gateway's allowlist",
+		Long: `Set the gateway's allowlist.
+Accepts a list of ip addresses as individual inputs. Submit no addresses to
+clear the allowlist.
+
+For example: siac gateway allowlist set 10.0.0.1 10.0.0.2`,
+		Run: gatewayallowlistsetcmd,
+	}
+
 	gatewayBlocklistCmd = &cobra.Command{
 		Use:   "blocklist",
 		Short: "View and manage the gateway's blocklisted peers",
@@ -102,6 +137,14 @@ For example: siac gateway blocklist set 123.123.123.123 111.222.111.222 mysiahos
 		Run:   wrap(gatewaylistcmd),
 	}
 
+	gatewayPreferFamilyCmd = &cobra.Command{
+		Use:   "preferfamily [ipv4|ipv6|any]",
+		Short: "set the address family the gateway prefers when dialing peers",
+		Long: `Set the address family the gateway prefers when dialing peers that are
+reachable over both IPv4 and IPv6. Use 'any' to remove the preference.`,
+		Run: wrap(gatewaypreferfamilycmd),
+	}
+
 	gatewayRatelimitCmd = &cobra.Command{
 		Use:   "ratelimit [maxdownloadspeed] [maxuploadspeed]",
 		Short: "set maxdownloadspeed and maxuploadspeed",
@@ -166,11 +209,86 @@ func gatewaycmd() {
 		die("Could not get gateway address:", err)
 	}
 	fmt.Println("Address:", info.NetAddress)
+	if len(info.Addresses) > 1 {
+		fmt.Println("Alternate addresses:", info.Addresses[1:])
+	}
+	if info.PreferredAddressFamily != modules.AddressFamilyAny {
+		fmt.Println("Preferred address family:", info.PreferredAddressFamily)
+	}
 	fmt.Println("Active peers:", len(info.Peers))
+	if info.RequireEncryption {
+		fmt.Println("Plaintext sessions: refused")
+	}
 	fmt.Println("Max download speed:", info.MaxDownloadSpeed)
 	fmt.Println("Max upload speed:", info.MaxUploadSpeed)
 }
 
+// gatewayallowlistcmd is the handler for the command `siac gateway allowlist`
+// Prints the ip addresses on the gateway allowlist
+func gatewayallowlistcmd() {
+	gag, err := httpClient.GatewayAllowlistGet()
+	if err != nil {
+		die("Could not get gateway allowlist", err)
+	}
+	if gag.Enabled {
+		fmt.Println("Allowlist-only mode is enabled, the gateway only connects to allowlisted peers")
+	} else {
+		fmt.Println("Allowlist-only mode is disabled")
+	}
+	fmt.Println(len(gag.Allowlist), "ip addresses currently on the gateway allowlist")
+	for _, ip := range gag.Allowlist {
+		fmt.Println(ip)
+	}
+}
+
+// gatewayallowlistdisablecmd is the handler for the command
+// `siac gateway allowlist disable`
+// Disables the allowlist-only mode
+func gatewayallowlistdisablecmd() {
+	gag, err := httpClient.GatewayAllowlistGet()
+	if err != nil {
+		die("Could not get gateway allowlist", err)
+	}
+	err = httpClient.GatewayAllowlistPost(false, gag.Allowlist)
+	if err != nil {
+		die("Could not disable the allowlist-only mode", err)
+	}
+	fmt.Println("successfully disabled the allowlist-only mode")
+}
+
+// gatewayallowlistenablecmd is the handler for the command
+// `siac gateway allowlist enable`
+// Enables the allowlist-only mode
+func gatewayallowlistenablecmd() {
+	gag, err := httpClient.GatewayAllowlistGet()
+	if err != nil {
+		die("Could not get gateway allowlist", err)
+	}
+	if len(gag.Allowlist) == 0 {
+		fmt.Println("Warning: the allowlist is empty, the gateway won't connect to any peers")
+	}
+	err = httpClient.GatewayAllowlistPost(true, gag.Allowlist)
+	if err != nil {
+		die("Could not enable the allowlist-only mode", err)
+	}
+	fmt.Println("successfully enabled the allowlist-only mode")
+}
+
+// gatewayallowlistsetcmd is the handler for the command
+// `siac gateway allowlist set`
+// Sets the gateway allowlist to the ip addresses passed in
+func gatewayallowlistsetcmd(cmd *cobra.Command, addresses []string) {
+	gag, err := httpClient.GatewayAllowlistGet()
+	if err != nil {
+		die("Could not get gateway allowlist", err)
+	}
+	err = httpClient.GatewayAllowlistPost(gag.Enabled, addresses)
+	if err != nil {
+		die("Could not set the gateway allowlist", err)
+	}
+	fmt.Println(addresses, "was successfully set as the gateway allowlist")
+}
+
 // gatewayblocklistcmd is the handler for the command `siac gateway blocklist`
 // Prints the ip addresses on the gateway blocklist
 func gatewayblocklistcmd() {
@@ -256,15 +374,33 @@ func gatewaylistcmd() {
 	}
 	fmt.Println(len(info.Peers), "active peers:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Version\tOutbound\tAddress")
+	fmt.Fprintln(w, "Version\tOutbound\tEncrypted\tAddress")
 	for _, peer := range info.Peers {
-		fmt.Fprintf(w, "%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.NetAddress)
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), yesNo(peer.Encrypted), peer.NetAddress)
 	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
 	}
 }
 
+// gatewaypreferfamilycmd is the handler for the command
+// `siac gateway preferfamily`. Sets the address family the gateway prefers
+// when dialing peers.
+func gatewaypreferfamilycmd(family string) {
+	if family == "any" {
+		family = string(modules.AddressFamilyAny)
+	}
+	err := httpClient.GatewayPreferredAddressFamilyPost(modules.AddressFamily(family))
+	if err != nil {
+		die("Could not set the preferred address family:", err)
+	}
+	if family == string(modules.AddressFamilyAny) {
+		fmt.Println("Removed the preferred address family")
+		return
+	}
+	fmt.Println("Set the preferred address family to", family)
+}
+
 // gatewayratelimitcmd is the handler for the command `siac gateway ratelimit`.
 // sets the maximum upload & download bandwidth the gateway module is permitted
 // to use.
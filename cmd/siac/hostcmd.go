@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -32,6 +33,15 @@ Doing so will override the standard connectivity checks.`,
 		Run: hostannouncecmd,
 	}
 
+	hostCheckConnectivityCmd = &cobra.Command{
+		Use:   "checkconnectivity",
+		Short: "Check whether renters can reach the host",
+		Long: `Ask gateway peers to connect to the address the host announces and to open a
+session with the host. Problems with the host's port forwarding, firewall or DNS
+configuration are reported together with hints on how to fix them.`,
+		Run: wrap(hostcheckconnectivitycmd),
+	}
+
 	hostCmd = &cobra.Command{
 		Use:   "host",
 		Short: "Perform host actions",
@@ -66,13 +76,20 @@ Available settings:
      ephemeralaccountexpiry:     seconds
      maxephemeralaccountbalance: currency
      maxephemeralaccountrisk:    currency
+     maxephemeralaccountsupply:  currency
 	 
      registrysize:       filesize
      customregistrypath: string
 
+     renewalhintwindow: blocks
+
+     sectorauditlog: boolean
+
+     ingressprotection: boolean
+
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
-Durations (maxduration and windowsize) must be specified in either blocks (b),
+Durations (maxduration, windowsize and renewalhintwindow) must be specified in either blocks (b),
 hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
 hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
 
@@ -131,6 +148,23 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostSectorAuditCmd = &cobra.Command{
+		Use:   "sectoraudit [contractid]",
+		Short: "Show the sector accesses of a contract",
+		Long: `Show the sector reads and writes of a contract recorded in the host's sector
+audit log. The sector audit log needs to be enabled using the sectorauditlog
+setting of 'siac host config'.`,
+		Run: wrap(hostsectorauditcmd),
+	}
+
+	hostSectorAuditPurgeCmd = &cobra.Command{
+		Use:   "purge [contractid]",
+		Short: "Purge the sector accesses of a contract",
+		Long: `Remove the sector reads and writes of a contract from the host's sector audit
+log. This does not delete the contract's sectors.`,
+		Run: wrap(hostsectorauditpurgecmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -138,6 +172,15 @@ other storage folders.`,
 deleting a sector may impact host revenue.`,
 	}
 
+	hostSectorCompactCmd = &cobra.Command{
+		Use:   "compact",
+		Short: "Reclaim the space of sectors which are no longer used",
+		Long: `Remove the stale references renters left behind when trimming sectors from
+their contracts and delete the sectors which aren't used by any unresolved
+contract anymore.`,
+		Run: wrap(hostsectorcompactcmd),
+	}
+
 	hostSectorDeleteCmd = &cobra.Command{
 		Use:   "delete [root]",
 		Short: "Delete a sector",
@@ -234,15 +277,23 @@ Host Internal Settings:
 	ephemeralaccountexpiry:     %vs
 	maxephemeralaccountbalance: %v
 	maxephemeralaccountrisk:    %v
+	maxephemeralaccountsupply:  %v
 
 	registrysize:       %v
 	customregistrypath: %v
 
+	renewalhintwindow: %v Hours
+
+	sectorauditlog: %v
+
+	ingressprotection: %v
+
 Host Financials:
 	Contract Count:               %v
 	Transaction Fee Compensation: %v
 	Potential Fee Compensation:   %v
 	Transaction Fee Expenses:     %v
+	Maturing Payouts:             %v
 
 	Storage Revenue:           %v
 	Potential Storage Revenue: %v
@@ -289,12 +340,20 @@ RPC Stats:
 			is.EphemeralAccountExpiry.Seconds(),
 			currencyUnits(is.MaxEphemeralAccountBalance),
 			currencyUnits(is.MaxEphemeralAccountRisk),
+			currencyUnits(is.MaxEphemeralAccountSupply),
 			modules.FilesizeUnits(is.RegistrySize),
 			is.CustomRegistryPath,
 
+			is.RenewalHintWindow/6,
+
+			yesNo(is.SectorAuditLog),
+
+			yesNo(is.IngressProtection),
+
 			fm.ContractCount, currencyUnits(fm.ContractCompensation),
 			currencyUnits(fm.PotentialContractCompensation),
 			currencyUnits(fm.TransactionFeeExpenses),
+			currencyUnits(fm.MaturingPayouts),
 
 			currencyUnits(fm.StorageRevenue),
 			currencyUnits(fm.PotentialStorageRevenue),
@@ -344,6 +403,15 @@ RPC Stats:
 		fmt.Println("\nWarning:\n	Your wallet is locked. You must unlock your wallet for the host to function properly.")
 	}
 
+	// display the capacity forecast
+	cfg, err := httpClient.HostCapacityForecastGet()
+	if err != nil {
+		fmt.Print("\nWarning:\n	Could not get the capacity forecast. Error: ")
+		fmt.Println(err)
+	} else {
+		printCapacityForecast(cfg.HostCapacityForecast)
+	}
+
 	fmt.Println("\nStorage Folders:")
 
 	// display storage folder info
@@ -366,13 +434,55 @@ RPC Stats:
 	}
 }
 
+// printCapacityForecast prints a summary of the host's capacity forecast. In
+// verbose mode all the upcoming expirations are listed.
+func printCapacityForecast(cf modules.HostCapacityForecast) {
+	growth := "not enough samples yet"
+	if cf.SampledBlocks > 0 {
+		rate := cf.GrowthRate
+		sign := "+"
+		if rate < 0 {
+			rate, sign = -rate, "-"
+		}
+		growth = fmt.Sprintf("%v%v / day", sign, modules.FilesizeUnits(uint64(rate)*uint64(types.BlocksPerDay)))
+	}
+	full := "not running out of storage at the current growth rate"
+	if cf.FullHeight > 0 {
+		full = fmt.Sprintf("in ~%v days (height %v)", (cf.FullHeight-cf.Height)/types.BlocksPerDay, cf.FullHeight)
+	}
+	next := "no contracts expiring"
+	if len(cf.Expirations) > 0 {
+		e := cf.Expirations[0]
+		next = fmt.Sprintf("%v freed in %v blocks (height %v)", modules.FilesizeUnits(e.FreedStorage), e.Height-cf.Height, e.Height)
+	}
+	fmt.Printf(`
+Capacity Forecast:
+	Committed Storage: %v
+	Growth Rate:       %v
+	Full:              %v
+	Next Expiration:   %v
+`, modules.FilesizeUnits(cf.CommittedStorage), growth, full, next)
+
+	if !verbose || len(cf.Expirations) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "\tHeight\tContracts\tFreed\tRemaining\n")
+	for _, e := range cf.Expirations {
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\n", e.Height, e.Contracts, modules.FilesizeUnits(e.FreedStorage), modules.FilesizeUnits(e.RemainingStorage))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
 // hostconfigcmd is the handler for the command `siac host config [setting] [value]`.
 // Modifies host settings.
 func hostconfigcmd(param, value string) {
 	var err error
 	switch param {
 	// currency (convert to hastings)
-	case "collateralbudget", "maxcollateral", "minbaserpcprice", "mincontractprice", "minsectoraccessprice", "maxephemeralaccountbalance", "maxephemeralaccountrisk":
+	case "collateralbudget", "maxcollateral", "minbaserpcprice", "mincontractprice", "minsectoraccessprice", "maxephemeralaccountbalance", "maxephemeralaccountrisk", "maxephemeralaccountsupply":
 		value, err = types.ParseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -399,7 +509,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "sectorauditlog", "ingressprotection":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -408,7 +518,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// duration (convert to blocks)
-	case "maxduration", "windowsize":
+	case "maxduration", "windowsize", "renewalhintwindow":
 		value, err = parsePeriod(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -582,3 +692,99 @@ func hostsectordeletecmd(root string) {
 	}
 	fmt.Println("Deleted sector", root)
 }
+
+// hostsectorcompactcmd compacts the sectors of the host.
+func hostsectorcompactcmd() {
+	hsc, err := httpClient.HostStorageCompactPost()
+	if err != nil {
+		die("Could not compact sectors:", err)
+	}
+	fmt.Printf(`Rewritten Contracts: %v
+Dropped Roots:       %v
+Removed References:  %v
+Reclaimed Sectors:   %v
+Reclaimed Space:     %v
+`, hsc.ObligationsRewritten, hsc.RootsDropped, hsc.ReferencesRemoved, hsc.SectorsReclaimed, modules.FilesizeUnits(hsc.BytesReclaimed))
+	if hsc.MissingReferences > 0 {
+		fmt.Printf("\nWarning: %v sector references of unresolved contracts are missing from the host's storage.\n", hsc.MissingReferences)
+	}
+}
+
+// hostsectorauditcmd prints the recorded sector accesses of a contract.
+func hostsectorauditcmd(id string) {
+	var fcid types.FileContractID
+	err := fcid.LoadString(id)
+	if err != nil {
+		die("Could not parse contract id:", err)
+	}
+	hsag, err := httpClient.HostSectorAuditGet(fcid)
+	if err != nil {
+		die("Could not get sector audit:", err)
+	}
+	if len(hsag.Entries) == 0 {
+		fmt.Println("No sector accesses recorded for contract", id)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "Time\tHeight\tType\tSector Root\tRenter")
+	for _, e := range hsag.Entries {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", e.Timestamp.Format(time.RFC3339), e.Height, e.Type, e.SectorRoot, e.Renter)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// hostsectorauditpurgecmd removes the recorded sector accesses of a contract.
+func hostsectorauditpurgecmd(id string) {
+	var fcid types.FileContractID
+	err := fcid.LoadString(id)
+	if err != nil {
+		die("Could not parse contract id:", err)
+	}
+	err = httpClient.HostSectorAuditPurgePost(fcid)
+	if err != nil {
+		die("Could not purge sector audit:", err)
+	}
+	fmt.Println("Purged sector accesses of contract", id)
+}
+
+// hostcheckconnectivitycmd checks whether the host is reachable at the address
+// it announces.
+func hostcheckconnectivitycmd() {
+	hccg, err := httpClient.HostCheckConnectivityGet()
+	if err != nil {
+		die("Could not check host connectivity:", err)
+	}
+	fmt.Println("Address:", hccg.NetAddress)
+	switch {
+	case !hccg.Conclusive:
+		fmt.Println("Status:  unknown, not enough peers were able to check the address")
+	case hccg.Reachable:
+		fmt.Println("Status:  reachable")
+	default:
+		fmt.Println("Status:  unreachable")
+	}
+	if len(hccg.Reports) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+		fmt.Fprintln(w, "Peer\tReachable\tLatency\tError")
+		for _, r := range hccg.Reports {
+			latency := "-"
+			if r.Reachable {
+				latency = r.Latency.Round(time.Millisecond).String()
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", r.Peer, yesNo(r.Reachable), latency, r.Error)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+	if len(hccg.Problems) > 0 {
+		fmt.Println()
+		fmt.Println("Problems:")
+		for _, p := range hccg.Problems {
+			fmt.Println("  -", p)
+		}
+	}
+}
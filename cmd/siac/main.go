@@ -38,11 +38,16 @@ var (
 	dataPieces                string // the number of data pieces a file should be uploaded with
 	parityPieces              string // the number of parity pieces a file should be uploaded with
 	renterAllContracts        bool   // Show all active and expired contracts
+	renterBatchContinue       bool   // Continue a batch after a failed operation.
+	renterBatchDryRun         bool   // Only validate the operations of a batch.
+	renterBatchFile           string // File containing the operations of a batch.
 	renterBubbleAll           bool   // Bubble the entire directory tree
 	renterDeleteRoot          bool   // Delete path start from root instead of the UserFolder.
 	renterDownloadAsync       bool   // Downloads files asynchronously
 	renterDownloadRecursive   bool   // Downloads folders recursively.
 	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
+	renterFsckQuarantine      bool   // Quarantine inconsistencies which can't be repaired.
+	renterFsckRepair          bool   // Repair inconsistencies of the filesystem.
 	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
 	renterListRecursive       bool   // List files of folder recursively.
 	renterListRoot            bool   // List path start from root instead of the UserFolder.
@@ -290,16 +295,19 @@ func initCmds() *cobra.Command {
 
 	// create command tree (alphabetized by root command)
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusSnapshotCmd)
 	root.AddCommand(jsonCmd)
 
 	root.AddCommand(gatewayCmd)
-	gatewayCmd.AddCommand(gatewayAddressCmd, gatewayBandwidthCmd, gatewayBlocklistCmd, gatewayConnectCmd, gatewayDisconnectCmd, gatewayListCmd, gatewayRatelimitCmd)
+	gatewayCmd.AddCommand(gatewayAddressCmd, gatewayAllowlistCmd, gatewayBandwidthCmd, gatewayBlocklistCmd, gatewayConnectCmd, gatewayDisconnectCmd, gatewayListCmd, gatewayPreferFamilyCmd, gatewayRatelimitCmd)
+	gatewayAllowlistCmd.AddCommand(gatewayAllowlistDisableCmd, gatewayAllowlistEnableCmd, gatewayAllowlistSetCmd)
 	gatewayBlocklistCmd.AddCommand(gatewayBlocklistAppendCmd, gatewayBlocklistClearCmd, gatewayBlocklistRemoveCmd, gatewayBlocklistSetCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorCmd)
+	hostCmd.AddCommand(hostAnnounceCmd, hostCheckConnectivityCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorAuditCmd, hostSectorCmd)
 	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
-	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
+	hostSectorCmd.AddCommand(hostSectorCompactCmd, hostSectorDeleteCmd)
+	hostSectorAuditCmd.AddCommand(hostSectorAuditPurgeCmd)
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
 	hostFolderRemoveCmd.Flags().BoolVarP(&hostFolderRemoveForce, "force", "f", false, "Force the removal of the folder and its data")
 
@@ -311,18 +319,25 @@ func initCmds() *cobra.Command {
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
 
 	root.AddCommand(renterCmd)
-	renterCmd.AddCommand(renterAllowanceCmd, renterBubbleCmd, renterBackupCreateCmd, renterBackupListCmd, renterBackupLoadCmd,
+	renterCmd.AddCommand(renterAllowanceCmd, renterBatchCmd, renterBubbleCmd, renterBackupCreateCmd, renterBackupFilesCmd, renterBackupListCmd, renterBackupLoadCmd,
+		renterBackupLoadFilesCmd,
 		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
-		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
+		renterFsckCmd, renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterReadOnlyCmd, renterReplicationCmd, renterSetAllowanceCmd,
 		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
-		renterHealthSummaryCmd)
+		renterHealthSummaryCmd, renterLegacyImportCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
+	renterBatchCmd.Flags().StringVarP(&renterBatchFile, "file", "f", "", "JSON file containing the operations of the batch")
+	renterBatchCmd.Flags().BoolVar(&renterBatchContinue, "continue", false, "Continue with the remaining operations after an operation failed")
+	renterBatchCmd.Flags().BoolVar(&renterBatchDryRun, "dry-run", false, "Only validate the operations without applying them")
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
+	renterReplicationCmd.AddCommand(renterReplicationPromoteCmd, renterReplicationStandbyCmd)
+	renterFsckCmd.Flags().BoolVar(&renterFsckRepair, "repair", false, "Repair inconsistencies of the directory metadata")
+	renterFsckCmd.Flags().BoolVar(&renterFsckQuarantine, "quarantine", false, "Move files and directories which can't be repaired into a quarantine folder")
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
 
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
@@ -367,7 +382,7 @@ func initCmds() *cobra.Command {
 	updateCmd.AddCommand(updateCheckCmd)
 
 	root.AddCommand(utilsCmd)
-	utilsCmd.AddCommand(bashcomplCmd, mangenCmd, utilsBruteForceSeedCmd, utilsCheckSigCmd,
+	utilsCmd.AddCommand(bashcomplCmd, completionCmd, mangenCmd, utilsBruteForceSeedCmd, utilsCheckSigCmd,
 		utilsDecodeRawTxnCmd, utilsDisplayAPIPasswordCmd, utilsEncodeRawTxnCmd, utilsHastingsCmd,
 		utilsSigHashCmd, utilsUploadedsizeCmd, utilsVerifySeedCmd)
 
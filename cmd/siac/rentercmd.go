@@ -56,6 +56,26 @@ var (
 		Run:   wrap(renterallowancecmd),
 	}
 
+	renterBatchCmd = &cobra.Command{
+		Use:   "batch",
+		Short: "Apply a batch of filesystem operations",
+		Long: `Apply a batch of filesystem operations read from a JSON file. The file contains
+an array of operations which are applied in order, e.g.
+
+  [
+    {"op": "mkdir", "siapath": "backups/2021"},
+    {"op": "rename", "siapath": "a.txt", "newsiapath": "backups/2021/a.txt"},
+    {"op": "renamedir", "siapath": "old", "newsiapath": "backups/old"},
+    {"op": "delete", "siapath": "b.txt"},
+    {"op": "deletedir", "siapath": "tmp"}
+  ]
+
+The whole file is validated before any operation is applied. By default the
+batch stops at the first failed operation, use --continue to apply the
+remaining operations anyway.`,
+		Run: wrap(renterbatchcmd),
+	}
+
 	renterBubbleCmd = &cobra.Command{
 		Use:   "bubble [directory]",
 		Short: "Call bubble on a directory.",
@@ -64,6 +84,20 @@ To bubble the root directory pass in '.' as the directory.`,
 		Run: wrap(renterbubblecmd),
 	}
 
+	renterFsckCmd = &cobra.Command{
+		Use:   "fsck",
+		Short: "Check the consistency of the renter's filesystem.",
+		Long: `Check the consistency of the renter's filesystem. The aggregates stored in the
+metadata of every directory are compared with the directory's contents and
+siafiles which aren't reachable from the root, invalid siapaths and temporary
+files of interrupted writes are reported.
+
+Use --repair to create missing directory metadata and to bubble directories
+with wrong aggregates. Use --quarantine to move files and directories which
+can't be repaired out of the filesystem into a quarantine folder.`,
+		Run: wrap(renterfsckcmd),
+	}
+
 	renterBackupCreateCmd = &cobra.Command{
 		Use:   "createbackup [name]",
 		Short: "Create a backup of the renter's siafiles",
@@ -78,6 +112,31 @@ To bubble the root directory pass in '.' as the directory.`,
 		Run:   wrap(renterbackuprestorecmd),
 	}
 
+	renterBackupFilesCmd = &cobra.Command{
+		Use:   "listbackupfiles [name]",
+		Short: "List the files of a backup",
+		Long:  "List the files contained in the backup with the given name without restoring it.",
+		Run:   wrap(renterbackupfilescmd),
+	}
+
+	renterBackupLoadFilesCmd = &cobra.Command{
+		Use:   "restorebackupfiles [name] [siapaths]",
+		Short: "Restore individual files from a backup",
+		Long: `Restore individual files or directories from the backup with the given name.
+Multiple siapaths are separated by commas. Files which already exist are not
+overwritten but restored with a suffix.`,
+		Run: wrap(renterbackuprestorefilescmd),
+	}
+
+	renterLegacyImportCmd = &cobra.Command{
+		Use:   "legacyimport [path] [directory]",
+		Short: "Import a legacy .sia file",
+		Long: `Import the files of a legacy shared .sia file into a directory. Pieces stored on hosts
+which are unknown or offline are dropped. To import into the root directory pass in '.' as the
+directory.`,
+		Run: wrap(renterlegacyimportcmd),
+	}
+
 	renterBackupListCmd = &cobra.Command{
 		Use:   "listbackups",
 		Short: "List backups stored on hosts",
@@ -244,6 +303,43 @@ allowance of 500SC, 12w period, 50 hosts, and 4w renew window will be used.`,
 		Run: renterpricescmd,
 	}
 
+	renterReadOnlyCmd = &cobra.Command{
+		Use:   "readonly [true/false]",
+		Short: "Enable or disable the renter's read-only mode",
+		Long: `Enable or disable the renter's read-only mode. While in read-only mode, uploads, deletions,
+renames and allowance changes are rejected but files can still be downloaded and streamed.`,
+		Run: wrap(renterreadonlycmd),
+	}
+
+	renterReplicationCmd = &cobra.Command{
+		Use:   "replication",
+		Short: "Show the status of the metadata replication",
+		Long: `Show whether the renter is a standby of a primary renter and the status of the
+replication of the primary's filesystem metadata and contracts.`,
+		Run: wrap(renterreplicationcmd),
+	}
+
+	renterReplicationPromoteCmd = &cobra.Command{
+		Use:   "promote",
+		Short: "Promote a standby renter",
+		Long: `Stop replicating the primary renter and take over its filesystem metadata and
+contracts. The renter leaves read-only mode afterwards. The primary must not be
+used anymore once the standby is promoted.`,
+		Run: wrap(renterreplicationpromotecmd),
+	}
+
+	renterReplicationStandbyCmd = &cobra.Command{
+		Use:   "standby [address]",
+		Short: "Make the renter a standby of a primary renter",
+		Long: `Continuously replicate the filesystem metadata and contracts of the primary
+renter whose API is reachable at address. The API password of the primary is
+read from stdin. The primary's API must be reachable over https unless it runs
+on the loopback interface. The password is not persisted, so the command needs
+to be run again after the standby restarts. The renter must not have any
+contracts and stays read-only until it is promoted.`,
+		Run: wrap(renterreplicationstandbycmd),
+	}
+
 	renterRatelimitCmd = &cobra.Command{
 		Use:   "ratelimit [maxdownloadspeed] [maxuploadspeed]",
 		Short: "Set maxdownloadspeed and maxuploadspeed",
@@ -407,6 +503,11 @@ func rentercmd() {
 		die("Could not get renter info:", err)
 	}
 
+	if rg.Settings.ReadOnly {
+		fmt.Println()
+		fmt.Println("Read-only mode is enabled")
+	}
+
 	// Print Allowance info
 	rate, err := types.ParseExchangeRate(build.ExchangeRate())
 	if err != nil {
@@ -485,6 +586,73 @@ func rentercmd() {
 	rateLimitSummary(rg.Settings.MaxDownloadSpeed, rg.Settings.MaxUploadSpeed)
 }
 
+// renterreadonlycmd is the handler for the command `siac renter readonly
+// [true/false]`. It enables or disables the renter's read-only mode.
+func renterreadonlycmd(enabled string) {
+	readOnly, err := strconv.ParseBool(enabled)
+	if err != nil {
+		die("Could not parse read-only mode:", err)
+	}
+	err = httpClient.RenterReadOnlyPost(readOnly)
+	if err != nil {
+		die("Could not set read-only mode:", err)
+	}
+	if readOnly {
+		fmt.Println("Read-only mode enabled")
+	} else {
+		fmt.Println("Read-only mode disabled")
+	}
+}
+
+// renterreplicationcmd is the handler for the command `siac renter
+// replication`.
+func renterreplicationcmd() {
+	rrg, err := httpClient.RenterReplicationGet()
+	if err != nil {
+		die("Could not get the replication status:", err)
+	}
+	if !rrg.Standby {
+		fmt.Println("The renter is not a standby.")
+		return
+	}
+	lastSync := "never"
+	if !rrg.LastSync.IsZero() {
+		lastSync = rrg.LastSync.Format(time.RFC1123)
+	}
+	fmt.Printf(`Primary:    %v
+Last Sync:  %v
+Files:      %v
+Contracts:  %v
+`, rrg.PrimaryAddress, lastSync, rrg.Files, rrg.Contracts)
+	if rrg.LastError != "" {
+		fmt.Println("Last Error:", rrg.LastError)
+	}
+}
+
+// renterreplicationpromotecmd is the handler for the command `siac renter
+// replication promote`.
+func renterreplicationpromotecmd() {
+	err := httpClient.RenterReplicationPromotePost()
+	if err != nil {
+		die("Could not promote the renter:", err)
+	}
+	fmt.Println("Renter promoted")
+}
+
+// renterreplicationstandbycmd is the handler for the command `siac renter
+// replication standby [address]`.
+func renterreplicationstandbycmd(address string) {
+	password, err := passwordPrompt("API password of the primary: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+	err = httpClient.RenterReplicationStandbyPost(address, password)
+	if err != nil {
+		die("Could not start the replication:", err)
+	}
+	fmt.Println("Replicating", address)
+}
+
 // renterlostcmd is the handler for displaying the renter's lost files.
 func renterlostcmd() {
 	// Print out the lost files of the renter
@@ -1333,6 +1501,47 @@ how large the files are.`)
 	return req
 }
 
+// renterbatchcmd is the handler for the command `siac renter batch`.
+// Validates and applies the filesystem operations of a batch file.
+func renterbatchcmd() {
+	if renterBatchFile == "" {
+		die("No batch file specified, use --file")
+	}
+	// Validate the whole batch before applying any operation.
+	n, err := readBatchOperations(abs(renterBatchFile), func(batchOperation) error { return nil })
+	if err != nil {
+		die("Invalid batch file:", err)
+	}
+	if renterBatchDryRun {
+		fmt.Printf("Batch contains %v valid operations.\n", n)
+		return
+	}
+
+	var applied, failed int
+	_, err = readBatchOperations(abs(renterBatchFile), func(op batchOperation) error {
+		err := op.apply(&httpClient)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "Operation %v failed: %v\n", applied+failed, err)
+			if !renterBatchContinue {
+				return err
+			}
+			return nil
+		}
+		applied++
+		if verbose {
+			fmt.Printf("Applied operation %v: %v\n", applied+failed, op)
+		} else if applied%1000 == 0 {
+			fmt.Printf("Applied %v of %v operations\n", applied, n)
+		}
+		return nil
+	})
+	fmt.Printf("Applied %v of %v operations, %v failed.\n", applied, n, failed)
+	if err != nil || failed > 0 {
+		die("Batch did not complete successfully")
+	}
+}
+
 // renterbubblecmd is the handler for the command `siac renter
 // bubble`.
 func renterbubblecmd(directory string) {
@@ -1357,6 +1566,36 @@ func renterbubblecmd(directory string) {
 	fmt.Println("Bubble successful!")
 }
 
+// renterfsckcmd is the handler for the command `siac renter fsck`.
+func renterfsckcmd() {
+	rfp, err := httpClient.RenterFsckPost(renterFsckRepair, renterFsckQuarantine)
+	if err != nil {
+		die("Unable to check the filesystem:", err)
+	}
+	report := rfp.Report
+	fmt.Printf("Checked %v directories and %v files in %v.\n", report.DirsChecked, report.FilesChecked, report.EndTime.Sub(report.StartTime).Round(time.Millisecond))
+	if len(report.Issues) == 0 {
+		fmt.Println("No issues found.")
+		return
+	}
+	fmt.Printf("Found %v issues:\n", len(report.Issues))
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Type\tPath\tAction\tDetails")
+	for _, issue := range report.Issues {
+		action := issue.Action
+		if issue.ActionError != "" {
+			action = "failed: " + issue.ActionError
+		}
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", issue.Type, issue.Path, action, issue.Details)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	if report.QuarantineDir != "" {
+		fmt.Println("Quarantined files and directories were moved to", report.QuarantineDir)
+	}
+}
+
 // renterbackcreatecmd is the handler for the command `siac renter
 // createbackup`.
 func renterbackupcreatecmd(name string) {
@@ -1377,6 +1616,75 @@ func renterbackuprestorecmd(name string) {
 	}
 }
 
+// renterbackupfilescmd is the handler for the command `siac renter
+// listbackupfiles`.
+func renterbackupfilescmd(name string) {
+	rbcg, err := httpClient.RenterBackupContentsGet(name)
+	if err != nil {
+		die("Failed to read backup", err)
+	}
+	if len(rbcg.Files) == 0 {
+		fmt.Println("Backup contains no files.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Size\tSiaPath")
+	for _, f := range rbcg.Files {
+		fmt.Fprintf(w, "  %v\t%v\n", modules.FilesizeUnits(f.Filesize), f.SiaPath)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterbackuprestorefilescmd is the handler for the command `siac renter
+// restorebackupfiles`.
+func renterbackuprestorefilescmd(name, siaPaths string) {
+	var sps []modules.SiaPath
+	for _, str := range strings.Split(siaPaths, ",") {
+		siaPath, err := modules.NewSiaPath(str)
+		if err != nil {
+			die("Unable to load siapath:", err)
+		}
+		sps = append(sps, siaPath)
+	}
+	err := httpClient.RenterRecoverBackupFilesPost(name, sps)
+	if err != nil {
+		die("Failed to restore files from backup", err)
+	}
+	fmt.Println("Files restored.")
+}
+
+// renterlegacyimportcmd is the handler for the command `siac renter
+// legacyimport`.
+func renterlegacyimportcmd(path, directory string) {
+	// Parse the siapath
+	siaPath := modules.RootSiaPath()
+	if directory != "." {
+		err := siaPath.LoadString(directory)
+		if err != nil {
+			die("Unable to load siapath:", err)
+		}
+	}
+
+	rlip, err := httpClient.RenterLegacyImportPost(abs(path), siaPath)
+	if err != nil {
+		die("Failed to import legacy .sia file", err)
+	}
+	if len(rlip.Files) == 0 {
+		fmt.Println("No files were imported.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  SiaPath\tAvailable Pieces\tUnavailable Pieces")
+	for _, f := range rlip.Files {
+		fmt.Fprintf(w, "  %v\t%v\t%v\n", f.SiaPath, f.AvailablePieces, f.UnavailablePieces)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
 // renterbackuplistcmd is the handler for the command `siac renter listbackups`.
 func renterbackuplistcmd() {
 	ubs, err := httpClient.RenterBackups()
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -12,9 +14,125 @@ import (
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/node/api/client"
 	"go.sia.tech/siad/types"
 )
 
+// Operations supported by `siac renter batch`.
+const (
+	batchOpDelete    = "delete"
+	batchOpDeleteDir = "deletedir"
+	batchOpMkdir     = "mkdir"
+	batchOpRename    = "rename"
+	batchOpRenameDir = "renamedir"
+)
+
+// batchOperation is a single filesystem operation of a batch file.
+type batchOperation struct {
+	Op         string `json:"op"`
+	SiaPath    string `json:"siapath"`
+	NewSiaPath string `json:"newsiapath,omitempty"`
+
+	siaPath    modules.SiaPath
+	newSiaPath modules.SiaPath
+}
+
+// String returns a human readable description of the operation.
+func (op batchOperation) String() string {
+	if op.NewSiaPath != "" {
+		return fmt.Sprintf("%v %v -> %v", op.Op, op.SiaPath, op.NewSiaPath)
+	}
+	return fmt.Sprintf("%v %v", op.Op, op.SiaPath)
+}
+
+// parse validates the operation and parses its siapaths.
+func (op *batchOperation) parse() error {
+	needsNewPath := op.Op == batchOpRename || op.Op == batchOpRenameDir
+	switch op.Op {
+	case batchOpDelete, batchOpDeleteDir, batchOpMkdir, batchOpRename, batchOpRenameDir:
+	default:
+		return fmt.Errorf("unknown operation '%v'", op.Op)
+	}
+	err := op.siaPath.LoadString(op.SiaPath)
+	if err != nil {
+		return errors.AddContext(err, "invalid siapath")
+	}
+	if !needsNewPath {
+		if op.NewSiaPath != "" {
+			return fmt.Errorf("operation '%v' doesn't accept a newsiapath", op.Op)
+		}
+		return nil
+	}
+	err = op.newSiaPath.LoadString(op.NewSiaPath)
+	if err != nil {
+		return errors.AddContext(err, "invalid newsiapath")
+	}
+	return nil
+}
+
+// apply applies the operation using the provided client.
+func (op batchOperation) apply(c *client.Client) error {
+	switch op.Op {
+	case batchOpDelete:
+		return c.RenterFileDeletePost(op.siaPath)
+	case batchOpDeleteDir:
+		return c.RenterDirDeletePost(op.siaPath)
+	case batchOpMkdir:
+		return c.RenterDirCreatePost(op.siaPath)
+	case batchOpRename:
+		return c.RenterRenamePost(op.siaPath, op.newSiaPath, false)
+	case batchOpRenameDir:
+		return c.RenterDirRenamePost(op.siaPath, op.newSiaPath)
+	}
+	return fmt.Errorf("unknown operation '%v'", op.Op)
+}
+
+// decodeBatchOperations decodes the JSON array of operations from r one by
+// one and calls fn for every valid operation. This avoids loading batches of
+// millions of operations into memory at once. The number of operations
+// processed is returned.
+func decodeBatchOperations(r io.Reader, fn func(batchOperation) error) (int, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to read start of batch")
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return 0, errors.New("batch must be a JSON array of operations")
+	}
+	n := 0
+	for dec.More() {
+		var op batchOperation
+		if err := dec.Decode(&op); err != nil {
+			return n, errors.AddContext(err, fmt.Sprintf("failed to decode operation %v", n+1))
+		}
+		if err := op.parse(); err != nil {
+			return n, errors.AddContext(err, fmt.Sprintf("operation %v", n+1))
+		}
+		n++
+		if err := fn(op); err != nil {
+			return n, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return n, errors.AddContext(err, "failed to read end of batch")
+	}
+	return n, nil
+}
+
+// readBatchOperations opens the batch file at path and calls fn for every
+// operation in the file.
+func readBatchOperations(path string, fn func(batchOperation) error) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to open batch file")
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return decodeBatchOperations(f, fn)
+}
+
 // byDirectoryInfo implements sort.Interface for []directoryInfo based on the
 // SiaPath field.
 type byDirectoryInfo []directoryInfo
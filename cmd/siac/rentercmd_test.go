@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
@@ -99,3 +102,62 @@ func fileContractID() types.FileContractID {
 	h.Sum(id[:0])
 	return id
 }
+
+// TestDecodeBatchOperations probes decodeBatchOperations.
+func TestDecodeBatchOperations(t *testing.T) {
+	t.Parallel()
+
+	// A valid batch is decoded in order.
+	batch := `[
+		{"op": "mkdir", "siapath": "dir"},
+		{"op": "rename", "siapath": "a", "newsiapath": "dir/a"},
+		{"op": "renamedir", "siapath": "old", "newsiapath": "dir/old"},
+		{"op": "delete", "siapath": "b"},
+		{"op": "deletedir", "siapath": "tmp"}
+	]`
+	var ops []batchOperation
+	n, err := decodeBatchOperations(strings.NewReader(batch), func(op batchOperation) error {
+		ops = append(ops, op)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || len(ops) != 5 {
+		t.Fatalf("expected 5 operations but got %v", n)
+	}
+	expected := []string{batchOpMkdir, batchOpRename, batchOpRenameDir, batchOpDelete, batchOpDeleteDir}
+	for i, op := range ops {
+		if op.Op != expected[i] {
+			t.Fatalf("operation %v: expected %v but was %v", i, expected[i], op.Op)
+		}
+	}
+	if ops[1].siaPath.String() != "a" || ops[1].newSiaPath.String() != "dir/a" {
+		t.Fatal("siapaths weren't parsed", ops[1].siaPath, ops[1].newSiaPath)
+	}
+
+	// An error returned by the callback stops decoding.
+	errStop := errors.New("stop")
+	n, err = decodeBatchOperations(strings.NewReader(batch), func(op batchOperation) error {
+		return errStop
+	})
+	if !errors.Contains(err, errStop) || n != 1 {
+		t.Fatal("expected decoding to stop after the first operation", n, err)
+	}
+
+	// Invalid batches are rejected.
+	invalid := []string{
+		`{"op": "delete", "siapath": "a"}`,
+		`[{"op": "copy", "siapath": "a"}]`,
+		`[{"op": "delete", "siapath": ""}]`,
+		`[{"op": "delete", "siapath": "a", "newsiapath": "b"}]`,
+		`[{"op": "rename", "siapath": "a"}]`,
+		`[{"op": "delete", "siapath": "a"}`,
+	}
+	for _, b := range invalid {
+		_, err := decodeBatchOperations(strings.NewReader(b), func(batchOperation) error { return nil })
+		if err == nil {
+			t.Fatalf("expected batch %v to be rejected", b)
+		}
+	}
+}
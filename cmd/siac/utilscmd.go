@@ -46,6 +46,20 @@ usually /etc/bash_completion.d/`,
 		Run: wrap(bashcomplcmd),
 	}
 
+	completionCmd = &cobra.Command{
+		Use:   "completion [shell] [path]",
+		Short: "Creates a shell completion file.",
+		Long: `Creates a completion file for the specified shell at the specified location.
+Supported shells are bash, zsh, fish and powershell.
+
+Note: Completions will only work with the prefix with which the script is
+created (e.g. ./siac or siac).
+
+Once created, the file has to be moved to the completion folder of the shell,
+e.g. /etc/bash_completion.d/ for bash or a directory in $fpath for zsh.`,
+		Run: wrap(completioncmd),
+	}
+
 	mangenCmd = &cobra.Command{
 		Use:   "man-generation [path]",
 		Short: "Creates unix style manpages.",
@@ -139,6 +153,27 @@ func bashcomplcmd(path string) {
 	rootCmd.GenBashCompletionFile(path)
 }
 
+// completioncmd is the handler for the command `siac utils completion`.
+// generates a completion file for the specified shell.
+func completioncmd(shell, path string) {
+	var err error
+	switch shell {
+	case "bash":
+		err = rootCmd.GenBashCompletionFile(path)
+	case "zsh":
+		err = rootCmd.GenZshCompletionFile(path)
+	case "fish":
+		err = rootCmd.GenFishCompletionFile(path, true)
+	case "powershell":
+		err = rootCmd.GenPowerShellCompletionFile(path)
+	default:
+		die("Unsupported shell, must be one of bash, zsh, fish or powershell:", shell)
+	}
+	if err != nil {
+		die("Failed to create completion file:", err)
+	}
+}
+
 // mangencmd is the handler for the command `siac utils man-generation`.
 // generates siac man pages
 func mangencmd(path string) {
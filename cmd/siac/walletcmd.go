@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -491,13 +492,36 @@ Height:              %v
 Confirmed Balance:   %v
 Unconfirmed Delta:   %v
 Exact:               %v H
+Immature Balance:    %v
 Siafunds:            %v SF
 Siafund Claims:      %v H
 
 Estimated Fee:       %v / KB
 `, encStatus, status.Height, currencyUnits(status.ConfirmedSiacoinBalance), delta,
-		status.ConfirmedSiacoinBalance, status.SiafundBalance, status.SiacoinClaimBalance,
-		fees.Maximum.Mul64(1e3).HumanString())
+		status.ConfirmedSiacoinBalance, currencyUnits(status.ImmatureSiacoinBalance),
+		status.SiafundBalance, status.SiacoinClaimBalance, fees.Maximum.Mul64(1e3).HumanString())
+
+	if status.ImmatureSiacoinBalance.IsZero() {
+		return
+	}
+	immature, err := httpClient.WalletImmatureGet()
+	if err != nil {
+		die("Could not get immature outputs:", err)
+	}
+	fmt.Println()
+	fmt.Println("Maturing Payouts:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Height\tBlocks\tMaturing\tSpendable")
+	for _, p := range immature.Timeline {
+		var blocks types.BlockHeight
+		if p.Height > status.Height {
+			blocks = p.Height - status.Height
+		}
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", p.Height, blocks, currencyUnits(p.MaturingSiacoins), currencyUnits(p.SpendableSiacoins))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
 }
 
 // walletbroadcastcmd broadcasts a transaction.
@@ -16,8 +16,10 @@ import (
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/consensus"
 	"go.sia.tech/siad/node/api/server"
 	"go.sia.tech/siad/profile"
+	"go.sia.tech/siad/types"
 )
 
 // passwordPrompt securely reads a password from stdin.
@@ -52,6 +54,46 @@ func verifyAPISecurity(config Config) error {
 	return nil
 }
 
+// verifyPrivateNetwork checks that the private network settings are
+// consistent.
+func verifyPrivateNetwork(config Config) error {
+	if !config.Siad.PrivateNetwork {
+		if config.Siad.NetworkID != "" || config.Siad.GenesisTimestamp != 0 {
+			return errors.New("--network-id and --genesis-timestamp can only be used with --private-network")
+		}
+		return nil
+	}
+	if config.Siad.NetworkID == "" {
+		return errors.New("--private-network requires a --network-id")
+	}
+	if config.Siad.GenesisTimestamp < 0 {
+		return errors.New("--genesis-timestamp can't be negative")
+	}
+	if config.Siad.DNSSeeds != "" {
+		return errors.New("--dns-seeds can't be used with --private-network")
+	}
+	return nil
+}
+
+// verifyConsensusSnapshot checks that the consensus snapshot settings are
+// consistent.
+func verifyConsensusSnapshot(config Config) error {
+	if config.Siad.ConsensusSnapshot == "" && config.Siad.ConsensusCheckpoint == "" {
+		return nil
+	}
+	if config.Siad.ConsensusSnapshot == "" {
+		return errors.New("--consensus-checkpoint can only be used with --consensus-snapshot")
+	}
+	if config.Siad.ConsensusCheckpoint == "" {
+		return errors.New("--consensus-snapshot requires --consensus-checkpoint")
+	}
+	var cp modules.ConsensusCheckpoint
+	if err := cp.LoadString(config.Siad.ConsensusCheckpoint); err != nil {
+		return errors.AddContext(err, "unable to parse --consensus-checkpoint")
+	}
+	return nil
+}
+
 // processNetAddr adds a ':' to a bare integer, so that it is a proper port
 // number.
 func processNetAddr(addr string) string {
@@ -91,10 +133,12 @@ func processModules(modules string) (string, error) {
 		return "gctwaf", nil
 	case "explorer":
 		return "gce", nil
+	case "light":
+		return "gl", nil
 	}
 
 	// Check module letters provided
-	validModules := "acghmrtwef"
+	validModules := "acghmrtwefl"
 	invalidModules := modules
 	for _, m := range validModules {
 		invalidModules = strings.Replace(invalidModules, string(m), "", 1)
@@ -117,7 +161,12 @@ func processConfig(config Config) (Config, error) {
 		config.Siad.Profile, err2 = profile.ProcessProfileFlags(config.Siad.Profile)
 	}
 	err3 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2, err3}, ", and ")
+	err4 := verifyPrivateNetwork(config)
+	err5 := verifyConsensusSnapshot(config)
+	err6 := verifyRateLimits(config)
+	err7 := verifyRestoreBackups(config)
+	err8 := verifyCORSSettings(config)
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5, err6, err7, err8}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
@@ -216,6 +265,16 @@ func startDaemon(config Config) (err error) {
 	// Print a startup message.
 	fmt.Println("Loading...")
 
+	// Replace the genesis block before any modules are created when running
+	// a private network.
+	if config.Siad.PrivateNetwork {
+		err = types.SetPrivateNetwork(config.Siad.NetworkID, types.Timestamp(config.Siad.GenesisTimestamp))
+		if err != nil {
+			return errors.AddContext(err, "failed to set up private network")
+		}
+		fmt.Printf("Running private network %q with genesis ID %v\n", types.NetworkID, types.GenesisID)
+	}
+
 	// Create the node params by parsing the modules specified in the config.
 	nodeParams := parseModules(config)
 
@@ -224,6 +283,15 @@ func startDaemon(config Config) (err error) {
 	if err != nil {
 		return err
 	}
+	// The CORS settings were already verified by verifyCORSSettings.
+	if cors, err := parseCORSSettings(config); err == nil {
+		srv.EnableCORS(cors)
+	}
+
+	// The rate limits were already verified by verifyRateLimits.
+	if rateLimits, err := parseRateLimits(config); err == nil {
+		srv.SetRateLimits(rateLimits)
+	}
 
 	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
 	tryAutoUnlock(srv)
@@ -255,6 +323,69 @@ func startDaemon(config Config) (err error) {
 	return nil
 }
 
+// verifyConsensus checks the integrity of the consensus database in the sia
+// directory and prints the result. siad must not be running.
+func verifyConsensus(config Config) error {
+	filename := filepath.Join(config.Siad.SiaDir, modules.ConsensusDir, consensus.DatabaseFilename)
+	fmt.Println("Verifying consensus database", filename)
+	cv, err := consensus.VerifyDB(filename)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Height:              %v\n", cv.Height)
+	fmt.Printf("Block ID:            %v\n", cv.BlockID)
+	fmt.Printf("State hash:          %v\n", cv.StateHash)
+	fmt.Printf("Replayed state hash: %v\n", cv.ReplayedStateHash)
+	if !cv.Consistent {
+		fmt.Println("Diverged buckets:   ", strings.Join(cv.DivergedBuckets, ", "))
+		return errors.New("consensus database is inconsistent, resync by removing the consensus directory")
+	}
+	fmt.Println("Consensus database is consistent.")
+	return nil
+}
+
+// consensusExportCmd writes an archive of the consensus database in the sia
+// directory to the file provided as the first argument. siad must not be
+// running.
+func consensusExportCmd(_ *cobra.Command, args []string) {
+	dbFilename := filepath.Join(globalConfig.Siad.SiaDir, modules.ConsensusDir, consensus.DatabaseFilename)
+	tmp := args[0] + "_temp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		die("Could not create archive:", err)
+	}
+	fmt.Println("Exporting consensus database", dbFilename)
+	cp, err := consensus.ExportArchive(dbFilename, f)
+	err = errors.Compose(err, f.Sync(), f.Close())
+	if err == nil {
+		err = os.Rename(tmp, args[0])
+	}
+	if err != nil {
+		die(errors.Compose(err, os.RemoveAll(tmp)))
+	}
+	fmt.Printf("Exported consensus archive to %v\n", args[0])
+	fmt.Printf("Checkpoint: %v:%v:%v\n", cp.Height, cp.BlockID, cp.StateHash)
+}
+
+// consensusImportCmd creates the consensus database in the sia directory from
+// the archive provided as the first argument. Every block of the archive is
+// validated.
+func consensusImportCmd(_ *cobra.Command, args []string) {
+	f, err := os.Open(args[0])
+	if err != nil {
+		die("Could not open archive:", err)
+	}
+	defer f.Close()
+	persistDir := filepath.Join(globalConfig.Siad.SiaDir, modules.ConsensusDir)
+	fmt.Println("Importing consensus archive", args[0], "into", persistDir)
+	cp, err := consensus.ImportArchive(persistDir, f)
+	if err != nil {
+		die(err)
+	}
+	fmt.Println("Imported and validated consensus archive.")
+	fmt.Printf("Checkpoint: %v:%v:%v\n", cp.Height, cp.BlockID, cp.StateHash)
+}
+
 // startDaemonCmd is a passthrough function for startDaemon.
 func startDaemonCmd(cmd *cobra.Command, _ []string) {
 	// Process the config variables after they are parsed by cobra.
@@ -263,6 +394,22 @@ func startDaemonCmd(cmd *cobra.Command, _ []string) {
 		die(errors.AddContext(err, "failed to parse input parameter"))
 	}
 
+	// Check the consensus database instead of starting siad if requested.
+	if config.Siad.VerifyConsensus {
+		if err := verifyConsensus(config); err != nil {
+			die(err)
+		}
+		return
+	}
+
+	// Check the integrity of the sia directory before starting siad if
+	// requested.
+	if config.Siad.VerifyOnStart {
+		if err := verifyOnStart(config); err != nil {
+			die(err)
+		}
+	}
+
 	// Parse profile flags
 	profileCPU := strings.Contains(config.Siad.Profile, "c")
 	profileMem := strings.Contains(config.Siad.Profile, "m")
@@ -2,6 +2,11 @@ package main
 
 import (
 	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
 )
 
 // TestUnitProcessNetAddr probes the 'processNetAddr' function.
@@ -43,6 +48,8 @@ func TestUnitProcessModules(t *testing.T) {
 		{"G", "g"},
 		{"h", "h"},
 		{"H", "h"},
+		{"l", "l"},
+		{"L", "l"},
 		{"m", "m"},
 		{"M", "m"},
 		{"r", "r"},
@@ -61,6 +68,7 @@ func TestUnitProcessModules(t *testing.T) {
 		{"feemanager", "gctwaf"},
 		{"accounting", "gctwaf"},
 		{"explorer", "gce"},
+		{"light", "gl"},
 	}
 	for _, testVal := range testVals {
 		out, err := processModules(testVal.in)
@@ -207,3 +215,113 @@ func TestVerifyAPISecurity(t *testing.T) {
 		t.Error("public + securityOff with authentication was rejected:", err)
 	}
 }
+
+// TestVerifyPrivateNetwork probes verifyPrivateNetwork.
+func TestVerifyPrivateNetwork(t *testing.T) {
+	// The public network is fine.
+	var config Config
+	if err := verifyPrivateNetwork(config); err != nil {
+		t.Error("public network was rejected:", err)
+	}
+
+	// The network settings require a private network.
+	config.Siad.NetworkID = "staging"
+	if err := verifyPrivateNetwork(config); err == nil {
+		t.Error("network id was accepted without a private network")
+	}
+	config.Siad.NetworkID = ""
+	config.Siad.GenesisTimestamp = 1
+	if err := verifyPrivateNetwork(config); err == nil {
+		t.Error("genesis timestamp was accepted without a private network")
+	}
+
+	// A private network requires a network id.
+	config.Siad.PrivateNetwork = true
+	if err := verifyPrivateNetwork(config); err == nil {
+		t.Error("private network was accepted without a network id")
+	}
+	config.Siad.NetworkID = "staging"
+	if err := verifyPrivateNetwork(config); err != nil {
+		t.Error("private network was rejected:", err)
+	}
+
+	// Private networks don't use DNS seeds.
+	config.Siad.DNSSeeds = "seed.example.com"
+	if err := verifyPrivateNetwork(config); err == nil {
+		t.Error("private network was accepted with DNS seeds")
+	}
+}
+
+// TestVerifyConsensusSnapshot probes verifyConsensusSnapshot.
+func TestVerifyConsensusSnapshot(t *testing.T) {
+	// No snapshot is fine.
+	var config Config
+	if err := verifyConsensusSnapshot(config); err != nil {
+		t.Error("config without snapshot was rejected:", err)
+	}
+
+	// A snapshot requires a checkpoint.
+	config.Siad.ConsensusSnapshot = "https://example.com/consensus.db"
+	if err := verifyConsensusSnapshot(config); err == nil {
+		t.Error("snapshot was accepted without a checkpoint")
+	}
+
+	// The checkpoint needs to be valid.
+	cp := modules.ConsensusCheckpoint{Height: 10, BlockID: types.GenesisID}
+	config.Siad.ConsensusCheckpoint = cp.String()
+	if err := verifyConsensusSnapshot(config); err != nil {
+		t.Error("valid checkpoint was rejected:", err)
+	}
+	config.Siad.ConsensusCheckpoint = "10:" + types.GenesisID.String()
+	if err := verifyConsensusSnapshot(config); err == nil {
+		t.Error("invalid checkpoint was accepted")
+	}
+
+	// A checkpoint requires a snapshot.
+	config.Siad.ConsensusSnapshot = ""
+	config.Siad.ConsensusCheckpoint = cp.String()
+	if err := verifyConsensusSnapshot(config); err == nil {
+		t.Error("checkpoint was accepted without a snapshot")
+	}
+}
+
+// TestVerifyCORSSettings probes verifyCORSSettings.
+func TestVerifyCORSSettings(t *testing.T) {
+	var config Config
+	config.Siad.CORSOrigins = "https://example.com"
+	config.Siad.CORSCredentials = true
+	if err := verifyCORSSettings(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// Credentials can't be allowed for any origin.
+	config.Siad.CORSOrigins = "https://example.com, *"
+	if err := verifyCORSSettings(config); !errors.Contains(err, api.ErrCORSWildcardCredentials) {
+		t.Fatal("expected ErrCORSWildcardCredentials, got", err)
+	}
+	config.Siad.CORSCredentials = false
+	if err := verifyCORSSettings(config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestParseRateLimits probes parseRateLimits.
+func TestParseRateLimits(t *testing.T) {
+	var config Config
+	config.Siad.RateLimits = "renter-download=5:20, wallet=0.5"
+	settings, err := parseRateLimits(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings) != 2 || settings[api.RateLimitRenterDownload] != (api.RateLimit{Rate: 5, Burst: 20}) || settings[api.RateLimitWallet] != (api.RateLimit{Rate: 0.5, Burst: 1}) {
+		t.Fatal("unexpected settings", settings)
+	}
+
+	// Invalid entries are rejected.
+	for _, limits := range []string{"wallet", "foo=1", "wallet=0", "wallet=-1", "wallet=x", "wallet=1:0", "wallet=1:x", "wallet=1,wallet=2"} {
+		config.Siad.RateLimits = limits
+		if err := verifyRateLimits(config); err == nil {
+			t.Errorf("%q was accepted", limits)
+		}
+	}
+}
@@ -41,10 +41,30 @@ type Config struct {
 
 		Modules           string
 		NoBootstrap       bool
+		DNSSeeds          string
+		NoDNSSeeds        bool
+		PrivateNetwork    bool
+		NetworkID         string
+		GenesisTimestamp  int64
 		RequiredUserAgent string
 		AuthenticateAPI   bool
 		TempPassword      bool
 
+		ConsensusSnapshot   string
+		ConsensusCheckpoint string
+		VerifyConsensus     bool
+
+		VerifyOnStart  bool
+		RestoreBackups bool
+
+		WalletSeedCommand string
+
+		CORSOrigins     string
+		CORSHeaders     string
+		CORSCredentials bool
+
+		RateLimits string
+
 		Profile    string
 		ProfileDir string
 
@@ -162,7 +182,18 @@ Explorer (e):
 	The explorer requires the consensus set.
 	Example:
 		siad -M gce
-		siad -M explorer`)
+		siad -M explorer
+
+Light Consensus Set (l):
+	The light consensus set only tracks the block headers and fetches the
+	transactions of watched addresses together with proofs of their inclusion
+	from full nodes. It powers a watch-only wallet and fee estimation on
+	devices that can't store the full blockchain.
+	The light consensus set requires the gateway and can't be combined with
+	the consensus set.
+	Example:
+		siad -M gl
+		siad -M light`)
 }
 
 // main establishes a set of commands and flags using the cobra package.
@@ -191,6 +222,28 @@ func main() {
 		Run:   modulesCmd,
 	})
 
+	consensusCmd := &cobra.Command{
+		Use:   "consensus",
+		Short: "Export or import a consensus archive",
+		Long:  "Export or import a portable archive of the blockchain. siad must not be running.",
+	}
+	consensusCmd.AddCommand(&cobra.Command{
+		Use:   "export [file]",
+		Short: "Export the consensus database to an archive",
+		Long:  "Export the blocks of the current path and their diffs to a compressed, versioned archive which other nodes can import.",
+		Args:  cobra.ExactArgs(1),
+		Run:   consensusExportCmd,
+	})
+	consensusCmd.AddCommand(&cobra.Command{
+		Use:   "import [file]",
+		Short: "Create the consensus database from an archive",
+		Long:  "Create the consensus database from an archive. Every block of the archive is validated, so the archive doesn't need to come from a trusted source. An existing consensus database is never replaced.",
+		Args:  cobra.ExactArgs(1),
+		Run:   consensusImportCmd,
+	})
+	consensusCmd.PersistentFlags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	root.AddCommand(consensusCmd)
+
 	// Set default values, which have the lowest priority.
 	root.Flags().StringVarP(&globalConfig.Siad.RequiredUserAgent, "agent", "", "Sia-Agent", "required substring for the user agent")
 	root.Flags().StringVarP(&globalConfig.Siad.HostAddr, "host-addr", "", ":9982", "which port the host listens on")
@@ -198,6 +251,17 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().StringVarP(&globalConfig.Siad.DNSSeeds, "dns-seeds", "", "", "comma-separated list of DNS seeds to query for nodes when bootstrapping, overrides the default seeds")
+	root.Flags().BoolVarP(&globalConfig.Siad.NoDNSSeeds, "no-dns-seeds", "", false, "disable querying DNS seeds for nodes when bootstrapping, useful for private networks")
+	root.Flags().BoolVarP(&globalConfig.Siad.PrivateNetwork, "private-network", "", false, "run a private network identified by --network-id without contacting the public network")
+	root.Flags().StringVarP(&globalConfig.Siad.NetworkID, "network-id", "", "", "id of the private network, which is part of its genesis block")
+	root.Flags().Int64VarP(&globalConfig.Siad.GenesisTimestamp, "genesis-timestamp", "", 0, "unix timestamp of the private network's genesis block, defaults to the timestamp of the public genesis block")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusSnapshot, "consensus-snapshot", "", "", "URL or path of a consensus snapshot to start syncing from if there is no consensus database yet")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusCheckpoint, "consensus-checkpoint", "", "", "checkpoint in the format height:blockid:statehash the consensus snapshot is verified against, required with --consensus-snapshot")
+	root.Flags().BoolVarP(&globalConfig.Siad.VerifyConsensus, "verify-consensus", "", false, "check the integrity of the consensus database by replaying its diffs and exit")
+	root.Flags().BoolVarP(&globalConfig.Siad.VerifyOnStart, "verify-on-start", "", false, "check the persist files, WALs and databases of all modules before starting siad")
+	root.Flags().BoolVarP(&globalConfig.Siad.RestoreBackups, "restore-backups", "", false, "restore corrupted persist files found by --verify-on-start from their most recent valid backup")
+	root.Flags().StringVarP(&globalConfig.Siad.WalletSeedCommand, "wallet-seed-command", "", "", "command which loads ('<command> load') and stores ('<command> store') the wallet's primary seed instead of the wallet database, e.g. to use a KMS or HSM")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaMuxTCPAddr, "siamux-addr", "", ":9983", "which port the SiaMux listens on")
@@ -206,6 +270,10 @@ func main() {
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
+	root.Flags().StringVarP(&globalConfig.Siad.CORSOrigins, "api-cors-origins", "", "", "comma-separated list of browser origins which may access the API, '*' allows any origin")
+	root.Flags().StringVarP(&globalConfig.Siad.CORSHeaders, "api-cors-headers", "", "", "comma-separated list of additional request headers browsers may send to the API")
+	root.Flags().BoolVarP(&globalConfig.Siad.CORSCredentials, "api-cors-credentials", "", false, "allow browsers to send the API password along with cross-origin requests, can't be combined with the '*' origin")
+	root.Flags().StringVarP(&globalConfig.Siad.RateLimits, "api-rate-limits", "", "", "comma-separated list of group=rate[:burst] API rate limits in requests per second, groups are renter-download, renter-upload, renter-admin and wallet")
 
 	// If globalConfig.Siad.SiaDir is not set, use the environment variable provided.
 	if globalConfig.Siad.SiaDir == "" {
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/wallet"
 	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/node/api"
 )
 
 // createNodeParams parses the provided config and creates the corresponding
@@ -26,6 +34,9 @@ func parseModules(config Config) node.NodeParams {
 	if strings.Contains(config.Siad.Modules, "h") {
 		params.CreateHost = true
 	}
+	if strings.Contains(config.Siad.Modules, "l") {
+		params.CreateLightConsensusSet = true
+	}
 	if strings.Contains(config.Siad.Modules, "m") {
 		params.CreateMiner = true
 	}
@@ -43,6 +54,24 @@ func parseModules(config Config) node.NodeParams {
 	}
 	// Parse remaining fields.
 	params.Bootstrap = !config.Siad.NoBootstrap
+	params.DNSSeeds = parseList(config.Siad.DNSSeeds)
+	params.DisableDNSSeeds = config.Siad.NoDNSSeeds
+	if config.Siad.PrivateNetwork {
+		params.Bootstrap = false
+		params.DisableDNSSeeds = true
+		params.PrivateNetwork = true
+	}
+	params.ConsensusSnapshot = config.Siad.ConsensusSnapshot
+	if config.Siad.ConsensusCheckpoint != "" {
+		// The checkpoint was already verified by verifyConsensusSnapshot.
+		var cp modules.ConsensusCheckpoint
+		if err := cp.LoadString(config.Siad.ConsensusCheckpoint); err == nil {
+			params.ConsensusCheckpoint = &cp
+		}
+	}
+	if fields := strings.Fields(config.Siad.WalletSeedCommand); len(fields) > 0 {
+		params.WalletSeedProvider = wallet.NewCommandSeedProvider(fields[0], fields[1:]...)
+	}
 	params.HostAddress = config.Siad.HostAddr
 	params.RPCAddress = config.Siad.RPCaddr
 	params.SiaMuxTCPAddress = config.Siad.SiaMuxTCPAddr
@@ -50,3 +79,76 @@ func parseModules(config Config) node.NodeParams {
 	params.Dir = config.Siad.SiaDir
 	return params
 }
+
+// parseCORSSettings parses the CORS settings of the API from the provided
+// config.
+func parseCORSSettings(config Config) (api.CORSSettings, error) {
+	settings := api.CORSSettings{
+		AllowedOrigins:   parseList(config.Siad.CORSOrigins),
+		AllowedHeaders:   parseList(config.Siad.CORSHeaders),
+		AllowCredentials: config.Siad.CORSCredentials,
+	}
+	return settings, settings.Validate()
+}
+
+// parseRateLimits parses the rate limits of the API from the provided config.
+// Rate limits are specified as a comma-separated list of group=rate[:burst]
+// entries. The burst defaults to the rate rounded up.
+func parseRateLimits(config Config) (api.RateLimitSettings, error) {
+	settings := make(api.RateLimitSettings)
+	for _, entry := range parseList(config.Siad.RateLimits) {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rate limit %q, expected group=rate[:burst]", entry)
+		}
+		group := api.RateLimitGroup(strings.TrimSpace(kv[0]))
+		known := false
+		for _, g := range api.RateLimitGroups {
+			known = known || g == group
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown rate limit group %q", group)
+		}
+		if _, exists := settings[group]; exists {
+			return nil, fmt.Errorf("rate limit group %q specified more than once", group)
+		}
+
+		rateBurst := strings.SplitN(strings.TrimSpace(kv[1]), ":", 2)
+		rate, err := strconv.ParseFloat(rateBurst[0], 64)
+		if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("invalid rate %q for rate limit group %q", rateBurst[0], group)
+		}
+		burst := int(math.Ceil(rate))
+		if len(rateBurst) == 2 {
+			burst, err = strconv.Atoi(rateBurst[1])
+			if err != nil || burst < 1 {
+				return nil, fmt.Errorf("invalid burst %q for rate limit group %q", rateBurst[1], group)
+			}
+		}
+		settings[group] = api.RateLimit{Rate: rate, Burst: burst}
+	}
+	return settings, nil
+}
+
+// verifyCORSSettings checks that the CORS settings of the API are valid.
+func verifyCORSSettings(config Config) error {
+	_, err := parseCORSSettings(config)
+	return errors.AddContext(err, "invalid --api-cors-credentials")
+}
+
+// verifyRateLimits checks that the rate limits of the API can be parsed.
+func verifyRateLimits(config Config) error {
+	_, err := parseRateLimits(config)
+	return errors.AddContext(err, "unable to parse --api-rate-limits")
+}
+
+// parseList splits a comma-separated list into its non-empty elements.
+func parseList(list string) []string {
+	var elems []string
+	for _, elem := range strings.Split(list, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// walFiles are the names of the files written by the writeaheadlog package.
+// Other modules, like the contract manager, use their own WAL format which
+// is validated when it is recovered.
+var walFiles = map[string]struct{}{
+	modules.RenterDir + ".wal": {},
+	"contractset.wal":          {},
+	modules.HostWALFile:        {},
+}
+
+// fileVerification is the result of verifying a single persist file.
+type fileVerification struct {
+	path     string
+	err      error
+	restored bool
+	unclean  bool
+}
+
+// moduleVerification contains the results of verifying the persist files of
+// a module.
+type moduleVerification struct {
+	module string
+	files  []fileVerification
+}
+
+// failed returns true if any of the module's files failed verification and
+// couldn't be restored.
+func (mv moduleVerification) failed() bool {
+	for _, fv := range mv.files {
+		if fv.err != nil && !fv.restored {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyRestoreBackups checks that --restore-backups is only used together
+// with --verify-on-start.
+func verifyRestoreBackups(config Config) error {
+	if config.Siad.RestoreBackups && !config.Siad.VerifyOnStart {
+		return errors.New("--restore-backups requires --verify-on-start")
+	}
+	return nil
+}
+
+// verifyFile verifies a single file based on its name. ok is false if the
+// file is not a persist file which can be verified.
+func verifyFile(path string, restore bool) (fv fileVerification, ok bool) {
+	fv.path = path
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, ".json") || name == modules.ConfigName:
+		fv.err = persist.VerifyJSONFile(path)
+		if fv.err != nil && restore {
+			restoreErr := persist.RestoreJSONFile(path)
+			fv.restored = restoreErr == nil
+			fv.err = errors.Compose(fv.err, restoreErr)
+		}
+	case strings.HasSuffix(name, ".db"):
+		fv.err = persist.VerifyBoltFile(path)
+	case strings.HasSuffix(name, ".wal"):
+		if _, known := walFiles[name]; !known {
+			return fv, false
+		}
+		clean, err := persist.VerifyWALFile(path)
+		fv.err, fv.unclean = err, !clean && err == nil
+	default:
+		return fv, false
+	}
+	return fv, true
+}
+
+// verifyIntegrity verifies the persist files of every module in the sia
+// directory. The json persist files are checked against their checksums,
+// the bolt databases are checked for consistency and the metadata of the WALs
+// is validated. If restore is true, corrupted json files are replaced with
+// their most recent valid backup. siad must not be running.
+func verifyIntegrity(siaDir string, restore bool) ([]moduleVerification, error) {
+	entries, err := ioutil.ReadDir(siaDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read sia directory")
+	}
+	siad := moduleVerification{module: "siad"}
+	var results []moduleVerification
+	for _, entry := range entries {
+		path := filepath.Join(siaDir, entry.Name())
+		if !entry.IsDir() {
+			if fv, ok := verifyFile(path, restore); ok {
+				siad.files = append(siad.files, fv)
+			}
+			continue
+		}
+		mv := moduleVerification{module: entry.Name()}
+		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if fv, ok := verifyFile(path, restore); ok {
+				mv.files = append(mv.files, fv)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to walk module directory")
+		}
+		results = append(results, mv)
+	}
+	results = append(results, siad)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].module < results[j].module
+	})
+	return results, nil
+}
+
+// verifyOnStart verifies the integrity of the sia directory and prints the
+// results per module. An error is returned if any file is corrupted and
+// couldn't be restored.
+func verifyOnStart(config Config) error {
+	fmt.Println("Verifying the integrity of", config.Siad.SiaDir)
+	results, err := verifyIntegrity(config.Siad.SiaDir, config.Siad.RestoreBackups)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, mv := range results {
+		if len(mv.files) == 0 {
+			continue
+		}
+		if mv.failed() {
+			failed = append(failed, mv.module)
+			fmt.Printf("%-16v FAILED\n", mv.module+":")
+		} else {
+			fmt.Printf("%-16v OK (%v files)\n", mv.module+":", len(mv.files))
+		}
+		for _, fv := range mv.files {
+			switch {
+			case fv.restored:
+				fmt.Printf("  %v: restored from backup (%v)\n", fv.path, fv.err)
+			case fv.err != nil:
+				fmt.Printf("  %v: %v\n", fv.path, fv.err)
+			case fv.unclean:
+				fmt.Printf("  %v: not closed cleanly, will be recovered on startup\n", fv.path)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		msg := "integrity verification failed for " + strings.Join(failed, ", ")
+		if !config.Siad.RestoreBackups {
+			msg += ", use --restore-backups to restore corrupted files from their backups where available"
+		}
+		return errors.New(msg)
+	}
+	fmt.Println("Integrity verification succeeded.")
+	return nil
+}
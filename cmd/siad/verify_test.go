@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// TestVerifyIntegrity probes verifyIntegrity.
+func TestVerifyIntegrity(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	siaDir := build.TempDir("siad", t.Name())
+	if err := os.RemoveAll(siaDir); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{modules.GatewayDir, modules.ConsensusDir, modules.RenterDir} {
+		if err := os.MkdirAll(filepath.Join(siaDir, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Create a json file with a backup, a database and a WAL.
+	meta := persist.Metadata{Header: "Test", Version: "v1.0"}
+	jsonFile := filepath.Join(siaDir, modules.GatewayDir, "gateway.json")
+	for i := 0; i < 2; i++ {
+		if err := persist.SaveJSON(meta, "dog", jsonFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := persist.OpenDatabase(meta, filepath.Join(siaDir, modules.ConsensusDir, "consensus.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, wal, err := writeaheadlog.New(filepath.Join(siaDir, modules.RenterDir, modules.RenterDir+".wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := errors.Compose(db.Close(), wal.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// All modules should pass.
+	results, err := verifyIntegrity(siaDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modulesFailed := func(results []moduleVerification) (failed []string) {
+		for _, mv := range results {
+			if mv.failed() {
+				failed = append(failed, mv.module)
+			}
+		}
+		return
+	}
+	if len(results) != 4 {
+		t.Fatal("expected results of 4 modules, got", len(results))
+	}
+	if failed := modulesFailed(results); len(failed) != 0 {
+		t.Fatal("modules shouldn't fail", failed)
+	}
+
+	// Corrupt the json file.
+	data, err := ioutil.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-2]++
+	if err := ioutil.WriteFile(jsonFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	results, err = verifyIntegrity(siaDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := modulesFailed(results); len(failed) != 1 || failed[0] != modules.GatewayDir {
+		t.Fatal("gateway should fail", failed)
+	}
+
+	// Restore the backup.
+	results, err = verifyIntegrity(siaDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := modulesFailed(results); len(failed) != 0 {
+		t.Fatal("modules shouldn't fail after restoring", failed)
+	}
+	if err := persist.VerifyJSONFile(jsonFile); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -21,6 +21,12 @@ func GenerateX25519KeyPair() (xsk X25519SecretKey, xpk X25519PublicKey) {
 	return
 }
 
+// PublicKey returns the public key that corresponds to an X25519 secret key.
+func (xsk X25519SecretKey) PublicKey() (xpk X25519PublicKey) {
+	curve25519.ScalarBaseMult((*[32]byte)(&xpk), (*[32]byte)(&xsk))
+	return
+}
+
 // DeriveSharedSecret derives 32 bytes of entropy from a secret key and public
 // key. Derivation is via ScalarMult of the private and public keys, followed
 // by a 256-bit unkeyed blake2b hash.
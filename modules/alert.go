@@ -42,10 +42,25 @@ const (
 	// AlertIDRenterContractRenewalError is the id of the alert that is
 	// registered if at least once contract renewal or refresh failed
 	AlertIDRenterContractRenewalError = "contract-renewal-error"
+	// AlertIDRenterContractDoubleSpent is the id of the alert that is
+	// registered if the inputs of a contract's formation transaction set were
+	// double-spent. It is unregistered once the contractor formed enough
+	// contracts again.
+	AlertIDRenterContractDoubleSpent = "contract-double-spent"
+	// AlertIDRenterContractsOverpriced is the id of the alert that is
+	// registered if renewing some of the renter's contracts is projected to
+	// cost significantly more than the market rate.
+	AlertIDRenterContractsOverpriced = "contracts-overpriced"
 	// AlertIDGatewayOffline is the id of the alert that is registered upon a
 	// call to 'gateway.Offline' if the value returned is 'false' and
 	// unregistered when it returns 'true'.
 	AlertIDGatewayOffline = "gateway-offline"
+	// AlertIDGatewayNoOutboundPeers is the id of the alert that is registered
+	// when the gateway had no outbound peers for a while.
+	AlertIDGatewayNoOutboundPeers = "gateway-no-outbound-peers"
+	// AlertIDGatewayFewPeers is the id of the alert that is registered when
+	// the gateway was connected to too few peers for a while.
+	AlertIDGatewayFewPeers = "gateway-few-peers"
 	// AlertIDHostDiskTrouble is the id of the alert that is registered when the
 	// host is encountering problems interacting with one or more of his disks
 	AlertIDHostDiskTrouble = "host-disk-trouble"
@@ -53,8 +68,40 @@ const (
 	// registered if the host has insufficient collateral budget left to form or
 	// renew a contract
 	AlertIDHostInsufficientCollateral = "host-insufficient-collateral"
+	// AlertIDHostCollateralForecast is the id of the alert that is registered
+	// if the host's wallet is not expected to cover the collateral of the
+	// contracts the host forms within the next period.
+	AlertIDHostCollateralForecast = "host-collateral-forecast"
+	// AlertIDConsensusDeepReorg is the id of the alert that is registered if
+	// the consensus set reverted more blocks than the deep reorg threshold.
+	AlertIDConsensusDeepReorg = "consensus-deep-reorg"
 )
 
+// AlertIDConsensusSubscriberLag creates a unique AlertID for a consensus set
+// subscriber which falls behind the consensus set.
+func AlertIDConsensusSubscriberLag(module string) AlertID {
+	return AlertID(fmt.Sprintf("consensus-subscriber-lag:%v", module))
+}
+
+// AlertIDFilesystemMetadataRepaired uses the path of a file or folder to
+// create a unique AlertID for an alert about its repaired metadata.
+func AlertIDFilesystemMetadataRepaired(path string) AlertID {
+	return AlertID(fmt.Sprintf("metadata-repaired:%v", path))
+}
+
+// AlertIDFilesystemMetadataCorrupted uses the path of a file to create a
+// unique AlertID for an alert about its unrepairable metadata.
+func AlertIDFilesystemMetadataCorrupted(path string) AlertID {
+	return AlertID(fmt.Sprintf("metadata-corrupted:%v", path))
+}
+
+// AlertIDRenterAccountSpendingCap uses a host's public key to create a unique
+// AlertID for an alert about the host's ephemeral account reaching its
+// spending cap.
+func AlertIDRenterAccountSpendingCap(hostKey string) AlertID {
+	return AlertID(fmt.Sprintf("account-spending-cap:%v", hostKey))
+}
+
 // AlertIDSiafileLowRedundancy uses a Siafile's UID to create a unique AlertID
 // for a low redundancy alert.
 func AlertIDSiafileLowRedundancy(uid string) AlertID {
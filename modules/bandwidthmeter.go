@@ -0,0 +1,398 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// RenterBandwidthFilename is the name of the file in the renter's persist
+	// directory which contains its bandwidth usage.
+	RenterBandwidthFilename = "bandwidth.json"
+
+	// RenterBandwidthDownload is the traffic of user initiated downloads.
+	RenterBandwidthDownload RenterBandwidthCategory = "download"
+
+	// RenterBandwidthStream is the traffic of streams.
+	RenterBandwidthStream RenterBandwidthCategory = "stream"
+
+	// RenterBandwidthRepairDownload is the traffic of downloads which fetch
+	// the data of chunks that are repaired from hosts.
+	RenterBandwidthRepairDownload RenterBandwidthCategory = "repairdownload"
+
+	// RenterBandwidthUpload is the traffic of uploads and repairs.
+	RenterBandwidthUpload RenterBandwidthCategory = "upload"
+
+	// RenterBandwidthHostDBScan is the traffic of the hostdb's scans.
+	RenterBandwidthHostDBScan RenterBandwidthCategory = "hostdbscan"
+
+	// RenterBandwidthContractFormation is the traffic of forming and renewing
+	// contracts.
+	RenterBandwidthContractFormation RenterBandwidthCategory = "contractformation"
+
+	// RenterBandwidthWindowHour is the rolling window of the last hour.
+	RenterBandwidthWindowHour RenterBandwidthWindow = "hour"
+
+	// RenterBandwidthWindowDay is the rolling window of the last 24 hours.
+	RenterBandwidthWindowDay RenterBandwidthWindow = "day"
+
+	// RenterBandwidthWindowMonth is the rolling window of the last 30 days.
+	RenterBandwidthWindowMonth RenterBandwidthWindow = "month"
+)
+
+const (
+	// bandwidthMinuteBuckets is the number of one minute buckets the meter
+	// keeps for the hour window.
+	bandwidthMinuteBuckets = 60
+
+	// bandwidthHourBuckets is the number of one hour buckets the meter keeps
+	// for the day and month windows.
+	bandwidthHourBuckets = 24 * 30
+)
+
+var (
+	// ErrBandwidthCapReached is returned when a category of the renter's
+	// traffic used up its bandwidth cap.
+	ErrBandwidthCapReached = errors.New("bandwidth cap reached")
+
+	// errUnknownBandwidthCategory is returned when setting the cap of an
+	// unknown category.
+	errUnknownBandwidthCategory = errors.New("unknown bandwidth category")
+
+	// errUnknownBandwidthWindow is returned when setting a cap with an
+	// unknown window.
+	errUnknownBandwidthWindow = errors.New("unknown bandwidth window")
+
+	// RenterBandwidthCategories are the categories of the renter's traffic.
+	RenterBandwidthCategories = []RenterBandwidthCategory{
+		RenterBandwidthDownload,
+		RenterBandwidthStream,
+		RenterBandwidthRepairDownload,
+		RenterBandwidthUpload,
+		RenterBandwidthHostDBScan,
+		RenterBandwidthContractFormation,
+	}
+
+	// bandwidthMeterMetadata is the metadata of the bandwidth meter's persist
+	// file.
+	bandwidthMeterMetadata = persist.Metadata{
+		Header:  "Bandwidth Meter",
+		Version: "1.0.0",
+	}
+
+	// bandwidthMeterSaveInterval is the minimum amount of time between two
+	// saves of the bandwidth meter which are triggered by recorded traffic.
+	bandwidthMeterSaveInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// BandwidthMeter records the bandwidth used by the categories of the
+	// renter's traffic in rolling windows and enforces the caps of the
+	// categories. The usage is persisted so that the windows survive
+	// restarts. A nil BandwidthMeter ignores all recorded traffic and never
+	// reaches a cap.
+	BandwidthMeter struct {
+		categories map[RenterBandwidthCategory]*bandwidthCategoryMeter
+		caps       map[RenterBandwidthCategory]RenterBandwidthCap
+		lastSave   time.Time
+		mu         sync.Mutex
+
+		staticPath string
+		saveMu     sync.Mutex
+	}
+
+	// bandwidthCategoryMeter contains the bandwidth used by a single category
+	// in one minute buckets for the last hour and one hour buckets for the
+	// last 30 days.
+	bandwidthCategoryMeter struct {
+		Minutes bandwidthBuckets      `json:"minutes"`
+		Hours   bandwidthBuckets      `json:"hours"`
+		Total   RenterBandwidthTotals `json:"total"`
+	}
+
+	// bandwidthBuckets is a sparse list of buckets which each sum up the
+	// bandwidth used within an interval, sorted by the index of the interval.
+	bandwidthBuckets []bandwidthBucket
+
+	// bandwidthBucket sums up the bandwidth used within the interval with the
+	// provided index.
+	bandwidthBucket struct {
+		Index      int64  `json:"index"`
+		Uploaded   uint64 `json:"uploaded"`
+		Downloaded uint64 `json:"downloaded"`
+	}
+
+	// meteredConn is a net.Conn which records the transferred bytes with a
+	// BandwidthMeter.
+	meteredConn struct {
+		net.Conn
+		staticCategory RenterBandwidthCategory
+		staticMeter    *BandwidthMeter
+	}
+)
+
+// NewBandwidthMeter loads the bandwidth meter from the provided path. If the
+// file doesn't exist yet, the meter starts without any usage.
+func NewBandwidthMeter(path string) (*BandwidthMeter, error) {
+	bm := &BandwidthMeter{
+		categories: make(map[RenterBandwidthCategory]*bandwidthCategoryMeter),
+		caps:       make(map[RenterBandwidthCategory]RenterBandwidthCap),
+		lastSave:   time.Now(),
+		staticPath: path,
+	}
+	err := persist.LoadJSON(bandwidthMeterMetadata, &bm.categories, path)
+	if os.IsNotExist(err) {
+		return bm, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load bandwidth meter")
+	}
+	if bm.categories == nil {
+		bm.categories = make(map[RenterBandwidthCategory]*bandwidthCategoryMeter)
+	}
+	return bm, nil
+}
+
+// bandwidthWindowBuckets returns the number of buckets the window spans and
+// whether it uses the minute buckets.
+func bandwidthWindowBuckets(window RenterBandwidthWindow) (n int64, minutes bool, err error) {
+	switch window {
+	case RenterBandwidthWindowHour:
+		return bandwidthMinuteBuckets, true, nil
+	case RenterBandwidthWindowDay:
+		return 24, false, nil
+	case RenterBandwidthWindowMonth:
+		return bandwidthHourBuckets, false, nil
+	}
+	return 0, false, errUnknownBandwidthWindow
+}
+
+// add adds the bandwidth to the bucket of the provided index and drops the
+// buckets which are older than the n most recent intervals.
+func (bb bandwidthBuckets) add(index, n int64, uploaded, downloaded uint64) bandwidthBuckets {
+	if len(bb) == 0 || bb[len(bb)-1].Index < index {
+		bb = append(bb, bandwidthBucket{Index: index})
+	}
+	bb[len(bb)-1].Uploaded += uploaded
+	bb[len(bb)-1].Downloaded += downloaded
+
+	i := 0
+	for i < len(bb) && bb[i].Index <= index-n {
+		i++
+	}
+	return bb[i:]
+}
+
+// sum returns the bandwidth used within the n most recent intervals.
+func (bb bandwidthBuckets) sum(index, n int64) (totals RenterBandwidthTotals) {
+	for _, b := range bb {
+		if b.Index > index-n && b.Index <= index {
+			totals.Uploaded += b.Uploaded
+			totals.Downloaded += b.Downloaded
+		}
+	}
+	return
+}
+
+// window returns the bandwidth used by the category within the window.
+func (cm *bandwidthCategoryMeter) window(window RenterBandwidthWindow, now time.Time) RenterBandwidthTotals {
+	n, minutes, err := bandwidthWindowBuckets(window)
+	if err != nil {
+		build.Critical(err)
+		return RenterBandwidthTotals{}
+	}
+	if minutes {
+		return cm.Minutes.sum(now.Unix()/60, n)
+	}
+	return cm.Hours.sum(now.Unix()/3600, n)
+}
+
+// capReached returns whether the category used up its cap.
+func (bm *BandwidthMeter) capReached(category RenterBandwidthCategory, now time.Time) bool {
+	c, exists := bm.caps[category]
+	cm, used := bm.categories[category]
+	if !exists || c.Bytes == 0 || !used {
+		return false
+	}
+	totals := cm.window(c.Window, now)
+	return totals.Uploaded+totals.Downloaded >= c.Bytes
+}
+
+// record adds the transferred bytes to the category.
+func (bm *BandwidthMeter) record(category RenterBandwidthCategory, uploaded, downloaded uint64, now time.Time) {
+	cm, exists := bm.categories[category]
+	if !exists {
+		cm = &bandwidthCategoryMeter{}
+		bm.categories[category] = cm
+	}
+	cm.Minutes = cm.Minutes.add(now.Unix()/60, bandwidthMinuteBuckets, uploaded, downloaded)
+	cm.Hours = cm.Hours.add(now.Unix()/3600, bandwidthHourBuckets, uploaded, downloaded)
+	cm.Total.Uploaded += uploaded
+	cm.Total.Downloaded += downloaded
+}
+
+// Check returns ErrBandwidthCapReached if the category used up its cap.
+func (bm *BandwidthMeter) Check(category RenterBandwidthCategory) error {
+	if bm == nil {
+		return nil
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	if bm.capReached(category, time.Now()) {
+		return errors.AddContext(ErrBandwidthCapReached, fmt.Sprintf("%v traffic is paused", category))
+	}
+	return nil
+}
+
+// Record records the bytes the category transferred.
+func (bm *BandwidthMeter) Record(category RenterBandwidthCategory, uploaded, downloaded uint64) {
+	if bm == nil || uploaded == 0 && downloaded == 0 {
+		return
+	}
+	bm.mu.Lock()
+	bm.record(category, uploaded, downloaded, time.Now())
+	save := time.Since(bm.lastSave) >= bandwidthMeterSaveInterval
+	if save {
+		bm.lastSave = time.Now()
+	}
+	bm.mu.Unlock()
+
+	if save {
+		_ = bm.Save()
+	}
+}
+
+// Caps returns the caps of the categories.
+func (bm *BandwidthMeter) Caps() map[RenterBandwidthCategory]RenterBandwidthCap {
+	caps := make(map[RenterBandwidthCategory]RenterBandwidthCap)
+	if bm == nil {
+		return caps
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	for category, c := range bm.caps {
+		caps[category] = c
+	}
+	return caps
+}
+
+// SetCaps replaces the caps of the categories. Categories without a cap or
+// with a cap of 0 bytes are unlimited.
+func (bm *BandwidthMeter) SetCaps(caps map[RenterBandwidthCategory]RenterBandwidthCap) error {
+	if err := ValidateBandwidthCaps(caps); err != nil {
+		return err
+	}
+	if bm == nil {
+		return nil
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.caps = make(map[RenterBandwidthCategory]RenterBandwidthCap)
+	for category, c := range caps {
+		if c.Bytes > 0 {
+			bm.caps[category] = c
+		}
+	}
+	return nil
+}
+
+// Usage returns the bandwidth used by each category.
+func (bm *BandwidthMeter) Usage() []RenterBandwidthUsage {
+	usage := make([]RenterBandwidthUsage, 0, len(RenterBandwidthCategories))
+	for _, category := range RenterBandwidthCategories {
+		usage = append(usage, RenterBandwidthUsage{Category: category})
+	}
+	if bm == nil {
+		return usage
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	now := time.Now()
+	for i := range usage {
+		u := &usage[i]
+		u.Cap = bm.caps[u.Category]
+		u.CapReached = bm.capReached(u.Category, now)
+		cm, exists := bm.categories[u.Category]
+		if !exists {
+			continue
+		}
+		u.Hour = cm.window(RenterBandwidthWindowHour, now)
+		u.Day = cm.window(RenterBandwidthWindowDay, now)
+		u.Month = cm.window(RenterBandwidthWindowMonth, now)
+		u.Total = cm.Total
+	}
+	return usage
+}
+
+// Save writes the usage to disk.
+func (bm *BandwidthMeter) Save() error {
+	if bm == nil {
+		return nil
+	}
+	bm.saveMu.Lock()
+	defer bm.saveMu.Unlock()
+	bm.mu.Lock()
+	data, err := json.Marshal(bm.categories)
+	bm.lastSave = time.Now()
+	bm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return persist.SaveJSON(bandwidthMeterMetadata, json.RawMessage(data), bm.staticPath)
+}
+
+// WrapConn wraps the conn to record the transferred bytes with the category.
+func (bm *BandwidthMeter) WrapConn(conn net.Conn, category RenterBandwidthCategory) net.Conn {
+	if bm == nil {
+		return conn
+	}
+	return &meteredConn{
+		Conn:           conn,
+		staticCategory: category,
+		staticMeter:    bm,
+	}
+}
+
+// Read reads from the underlying conn and records the read bytes.
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.staticMeter.Record(c.staticCategory, 0, uint64(n))
+	return n, err
+}
+
+// Write writes to the underlying conn and records the written bytes.
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.staticMeter.Record(c.staticCategory, uint64(n), 0)
+	return n, err
+}
+
+// ValidateBandwidthCaps checks that the caps refer to known categories and
+// windows.
+func ValidateBandwidthCaps(caps map[RenterBandwidthCategory]RenterBandwidthCap) error {
+	for category, c := range caps {
+		known := false
+		for _, rbc := range RenterBandwidthCategories {
+			known = known || rbc == category
+		}
+		if !known {
+			return errors.AddContext(errUnknownBandwidthCategory, string(category))
+		}
+		if _, _, err := bandwidthWindowBuckets(c.Window); err != nil && c.Bytes > 0 {
+			return errors.AddContext(err, string(c.Window))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+)
+
+// TestBandwidthBuckets probes the rolling windows of the bandwidth buckets.
+func TestBandwidthBuckets(t *testing.T) {
+	t.Parallel()
+
+	var bb bandwidthBuckets
+	bb = bb.add(10, 3, 1, 2)
+	bb = bb.add(10, 3, 1, 2)
+	bb = bb.add(11, 3, 4, 0)
+	if totals := bb.sum(11, 3); totals != (RenterBandwidthTotals{Uploaded: 6, Downloaded: 4}) {
+		t.Fatal("unexpected totals", totals)
+	}
+	// Bucket 10 leaves the window of bucket 13.
+	if totals := bb.sum(13, 3); totals != (RenterBandwidthTotals{Uploaded: 4}) {
+		t.Fatal("unexpected totals", totals)
+	}
+	// Adding bucket 14 drops buckets 10 and 11.
+	bb = bb.add(14, 3, 0, 8)
+	if len(bb) != 1 || bb[0].Index != 14 {
+		t.Fatal("old buckets weren't dropped", bb)
+	}
+
+	// Check the windows of a category.
+	now := time.Unix(100*3600, 0)
+	var cm bandwidthCategoryMeter
+	cm.Minutes = cm.Minutes.add(now.Unix()/60-30, bandwidthMinuteBuckets, 1, 0)
+	cm.Hours = cm.Hours.add(now.Unix()/3600-48, bandwidthHourBuckets, 10, 0)
+	cm.Hours = cm.Hours.add(now.Unix()/3600-1, bandwidthHourBuckets, 1, 0)
+	if hour := cm.window(RenterBandwidthWindowHour, now); hour.Uploaded != 1 {
+		t.Fatal("unexpected hour window", hour)
+	}
+	if day := cm.window(RenterBandwidthWindowDay, now); day.Uploaded != 1 {
+		t.Fatal("unexpected day window", day)
+	}
+	if month := cm.window(RenterBandwidthWindowMonth, now); month.Uploaded != 11 {
+		t.Fatal("unexpected month window", month)
+	}
+}
+
+// TestBandwidthMeter checks that the bandwidth meter records the usage per
+// category, enforces the caps and persists the usage.
+func TestBandwidthMeter(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("modules", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, RenterBandwidthFilename)
+	bm, err := NewBandwidthMeter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid caps are rejected.
+	err = bm.SetCaps(map[RenterBandwidthCategory]RenterBandwidthCap{"foo": {Window: RenterBandwidthWindowDay, Bytes: 1}})
+	if !errors.Contains(err, errUnknownBandwidthCategory) {
+		t.Fatal("expected errUnknownBandwidthCategory", err)
+	}
+	err = bm.SetCaps(map[RenterBandwidthCategory]RenterBandwidthCap{RenterBandwidthUpload: {Window: "week", Bytes: 1}})
+	if !errors.Contains(err, errUnknownBandwidthWindow) {
+		t.Fatal("expected errUnknownBandwidthWindow", err)
+	}
+
+	// Cap uploads and record some traffic.
+	caps := map[RenterBandwidthCategory]RenterBandwidthCap{
+		RenterBandwidthUpload: {Window: RenterBandwidthWindowDay, Bytes: 100},
+	}
+	if err := bm.SetCaps(caps); err != nil {
+		t.Fatal(err)
+	}
+	bm.Record(RenterBandwidthUpload, 60, 10)
+	bm.Record(RenterBandwidthDownload, 5, 50)
+	if err := bm.Check(RenterBandwidthUpload); err != nil {
+		t.Fatal(err)
+	}
+	bm.Record(RenterBandwidthUpload, 30, 0)
+	if err := bm.Check(RenterBandwidthUpload); !errors.Contains(err, ErrBandwidthCapReached) {
+		t.Fatal("expected ErrBandwidthCapReached", err)
+	}
+	if err := bm.Check(RenterBandwidthDownload); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := bm.Usage()
+	if len(usage) != len(RenterBandwidthCategories) {
+		t.Fatal("unexpected number of categories", len(usage))
+	}
+	upload := RenterBandwidthTotals{Uploaded: 90, Downloaded: 10}
+	expected := RenterBandwidthUsage{
+		Category:   RenterBandwidthUpload,
+		Hour:       upload,
+		Day:        upload,
+		Month:      upload,
+		Total:      upload,
+		Cap:        caps[RenterBandwidthUpload],
+		CapReached: true,
+	}
+	if usage[3] != expected {
+		t.Fatal("unexpected upload usage", usage[3])
+	}
+
+	// The usage survives a restart.
+	if err := bm.Save(); err != nil {
+		t.Fatal(err)
+	}
+	bm, err = NewBandwidthMeter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bm.SetCaps(caps); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded := bm.Usage(); !reflect.DeepEqual(reloaded, usage) {
+		t.Fatal("usage wasn't persisted", reloaded)
+	}
+
+	// Removing the cap resumes uploads.
+	if err := bm.SetCaps(map[RenterBandwidthCategory]RenterBandwidthCap{RenterBandwidthUpload: {}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bm.Check(RenterBandwidthUpload); err != nil {
+		t.Fatal(err)
+	}
+
+	// A nil meter ignores all traffic.
+	var nilMeter *BandwidthMeter
+	nilMeter.Record(RenterBandwidthUpload, 1, 1)
+	if err := nilMeter.Check(RenterBandwidthUpload); err != nil {
+		t.Fatal(err)
+	}
+}
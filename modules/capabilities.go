@@ -0,0 +1,148 @@
+package modules
+
+import (
+	"strings"
+
+	"go.sia.tech/siad/build"
+)
+
+const (
+	// RPCProtocolVersion is the version of the RPC protocol negotiated by
+	// RPCNegotiate. It needs to be increased whenever a change to the protocol
+	// can't be expressed by a new capability.
+	RPCProtocolVersion = 1
+
+	// UnrecognizedRPCErrString is the string used by hosts to indicate that
+	// they don't know the RPC a stream was opened for.
+	//
+	// Any update to this string needs to be done by making a new variable. This
+	// variable should not be changed. IsUnrecognizedRPCErr() needs to be
+	// updated to include the new string while also still checking the old
+	// string as well to preserve compatibility.
+	UnrecognizedRPCErrString = "Unrecognized RPC id"
+)
+
+// HostCapabilities is a set of flags describing the optional parts of the
+// renter-host protocol a host supports.
+type HostCapabilities uint64
+
+const (
+	// CapabilityRegistry indicates that the host supports the ReadRegistry and
+	// UpdateRegistry instructions.
+	CapabilityRegistry HostCapabilities = 1 << iota
+
+	// CapabilityRegistryPricing indicates that the host charges for the
+	// registry instructions according to the pricing introduced in v1.5.5
+	// instead of the pricing of earlier versions.
+	CapabilityRegistryPricing
+
+	// CapabilityRegistrySubscription indicates that the host supports
+	// RPCRegistrySubscription.
+	CapabilityRegistrySubscription
+
+	// CapabilityRenewContract indicates that the host supports
+	// RPCRenewContract.
+	CapabilityRenewContract
+
+	// CapabilityTrim indicates that the host supports the DropSectors
+	// instruction to trim a contract.
+	CapabilityTrim
+
+	// CapabilityRefunds indicates that the host refunds payments which exceed
+	// the cost of an RPC to the renter's ephemeral account.
+	CapabilityRefunds
+
+	// CapabilityPrefetchHints indicates that the host supports
+	// RPCPrefetchHint.
+	CapabilityPrefetchHints
+
+	// CapabilitySectorRoots indicates that the host supports RPCSectorRoots.
+	CapabilitySectorRoots
+
+	// CapabilityDelegatedReads indicates that the host supports
+	// RPCDelegatedRead.
+	CapabilityDelegatedReads
+)
+
+// SupportedHostCapabilities are the capabilities implemented by this version
+// of siad.
+const SupportedHostCapabilities = CapabilityRegistry |
+	CapabilityRegistryPricing |
+	CapabilityRegistrySubscription |
+	CapabilityRenewContract |
+	CapabilityTrim |
+	CapabilityRefunds |
+	CapabilityPrefetchHints |
+	CapabilitySectorRoots |
+	CapabilityDelegatedReads
+
+// capabilityNames maps the capabilities to their human readable names.
+var capabilityNames = []struct {
+	capability HostCapabilities
+	name       string
+}{
+	{CapabilityRegistry, "registry"},
+	{CapabilityRegistryPricing, "registrypricing"},
+	{CapabilityRegistrySubscription, "registrysubscription"},
+	{CapabilityRenewContract, "renewcontract"},
+	{CapabilityTrim, "trim"},
+	{CapabilityRefunds, "refunds"},
+	{CapabilityPrefetchHints, "prefetchhints"},
+	{CapabilitySectorRoots, "sectorroots"},
+	{CapabilityDelegatedReads, "delegatedreads"},
+}
+
+// HostCapabilitiesFromVersion returns the capabilities of a host which doesn't
+// support RPCNegotiate, based on the version it reports in its settings. Hosts
+// are not assumed to issue refunds, support prefetch hints, serve sector roots
+// or serve delegated reads unless they negotiate it explicitly.
+func HostCapabilitiesFromVersion(version string) HostCapabilities {
+	var c HostCapabilities
+	if build.VersionCmp(version, "1.5.0") >= 0 {
+		c |= CapabilityTrim
+	}
+	if build.VersionCmp(version, "1.5.1") >= 0 {
+		c |= CapabilityRegistry
+	}
+	if build.VersionCmp(version, "1.5.4") >= 0 {
+		c |= CapabilityRenewContract
+	}
+	if build.VersionCmp(version, "1.5.5") >= 0 {
+		c |= CapabilityRegistryPricing | CapabilityRegistrySubscription
+	}
+	return c
+}
+
+// Has returns whether all of the provided capabilities are set.
+func (c HostCapabilities) Has(capabilities HostCapabilities) bool {
+	return c&capabilities == capabilities
+}
+
+// Names returns the human readable names of the capabilities.
+func (c HostCapabilities) Names() []string {
+	names := []string{}
+	for _, cn := range capabilityNames {
+		if c.Has(cn.capability) {
+			names = append(names, cn.name)
+		}
+	}
+	return names
+}
+
+// String implements the fmt.Stringer interface.
+func (c HostCapabilities) String() string {
+	return strings.Join(c.Names(), ",")
+}
+
+// IsUnrecognizedRPCErr is a helper function to determine whether an error from
+// a host indicates that it doesn't know the RPC.
+//
+// Note: To preserve compatibility, this function needs to be extended
+// exclusively by adding more checks, the existing checks should not be altered
+// or removed.
+func IsUnrecognizedRPCErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), UnrecognizedRPCErrString)
+}
@@ -0,0 +1,63 @@
+package modules
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestHostCapabilitiesFromVersion probes HostCapabilitiesFromVersion.
+func TestHostCapabilitiesFromVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version  string
+		expected HostCapabilities
+	}{
+		{"1.4.12", 0},
+		{"1.5.0", CapabilityTrim},
+		{"1.5.1", CapabilityTrim | CapabilityRegistry},
+		{"1.5.4", CapabilityTrim | CapabilityRegistry | CapabilityRenewContract},
+		{"1.5.5", SupportedHostCapabilities &^ (CapabilityRefunds | CapabilityPrefetchHints | CapabilitySectorRoots | CapabilityDelegatedReads)},
+		{RHPVersion, SupportedHostCapabilities &^ (CapabilityRefunds | CapabilityPrefetchHints | CapabilitySectorRoots | CapabilityDelegatedReads)},
+	}
+	for _, test := range tests {
+		if c := HostCapabilitiesFromVersion(test.version); c != test.expected {
+			t.Errorf("%v: expected %v but got %v", test.version, test.expected, c)
+		}
+	}
+}
+
+// TestHostCapabilitiesHas probes the Has and String methods of
+// HostCapabilities.
+func TestHostCapabilitiesHas(t *testing.T) {
+	t.Parallel()
+
+	c := CapabilityRegistry | CapabilityTrim
+	if !c.Has(CapabilityRegistry) || !c.Has(CapabilityRegistry|CapabilityTrim) {
+		t.Fatal("capabilities should be set")
+	}
+	if c.Has(CapabilityRefunds) || c.Has(CapabilityRegistry|CapabilityRefunds) {
+		t.Fatal("capabilities shouldn't be set")
+	}
+	if s := c.String(); s != "registry,trim" {
+		t.Fatal("unexpected string", s)
+	}
+	if s := HostCapabilities(0).String(); s != "" {
+		t.Fatal("unexpected string", s)
+	}
+	if len(SupportedHostCapabilities.Names()) != len(capabilityNames) {
+		t.Fatal("every supported capability needs a name")
+	}
+}
+
+// TestIsUnrecognizedRPCErr probes IsUnrecognizedRPCErr.
+func TestIsUnrecognizedRPCErr(t *testing.T) {
+	t.Parallel()
+
+	if IsUnrecognizedRPCErr(nil) || IsUnrecognizedRPCErr(errors.New("foo")) {
+		t.Fatal("unexpected unrecognized RPC error")
+	}
+	if !IsUnrecognizedRPCErr(errors.New("Unrecognized RPC id Negotiate")) {
+		t.Fatal("expected unrecognized RPC error")
+	}
+}
@@ -2,7 +2,10 @@ package modules
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/crypto"
@@ -14,6 +17,10 @@ const (
 	// persistence files.
 	ConsensusDir = "consensus"
 
+	// LightConsensusDir is the name of the directory used for the persistence
+	// files of the light consensus set.
+	LightConsensusDir = "lightconsensus"
+
 	// DiffApply indicates that a diff is being applied to the consensus set.
 	DiffApply DiffDirection = true
 
@@ -22,6 +29,34 @@ const (
 	DiffRevert DiffDirection = false
 )
 
+const (
+	// ForkActivationHeight indicates that a fork rule activates at a fixed
+	// height.
+	ForkActivationHeight = "height"
+
+	// ForkActivationSignaling indicates that a fork rule activates once
+	// enough blocks signaled it.
+	ForkActivationSignaling = "signaling"
+
+	// ForkStateDefined is the state of a fork rule before it can activate.
+	ForkStateDefined ForkState = "defined"
+
+	// ForkStateStarted is the state of a signaling-based fork rule while
+	// blocks can signal it.
+	ForkStateStarted ForkState = "started"
+
+	// ForkStateLockedIn is the state of a signaling-based fork rule during
+	// the period after enough blocks signaled it.
+	ForkStateLockedIn ForkState = "lockedin"
+
+	// ForkStateActive is the state of a fork rule which is enforced.
+	ForkStateActive ForkState = "active"
+
+	// ForkStateFailed is the state of a signaling-based fork rule which
+	// wasn't signaled by enough blocks before its timeout.
+	ForkStateFailed ForkState = "failed"
+)
+
 var (
 	// ConsensusChangeBeginning is a special consensus change id that tells the
 	// consensus set to provide all consensus changes starting from the very
@@ -52,6 +87,10 @@ var (
 	// in a fork that is the heaviest known fork - the consensus set has not
 	// changed as a result of seeing the block.
 	ErrNonExtendingBlock = errors.New("block does not extend the longest fork")
+
+	// ErrNotSynced is returned by modules which refuse to perform an action
+	// until the consensus set is synced with the network.
+	ErrNotSynced = errors.New("consensus set is not synced")
 )
 
 type (
@@ -72,6 +111,23 @@ type (
 		ProcessConsensusChange(ConsensusChange)
 	}
 
+	// A ReorgSubscriber is an object that is notified every time the
+	// consensus set reverts blocks of its current path.
+	ReorgSubscriber interface {
+		// ProcessReorg sends a reorg event to a subscriber through a function
+		// call. It is called while the consensus set is locked, so it must not
+		// block or call back into the consensus set.
+		ProcessReorg(ReorgEvent)
+	}
+
+	// A TransactionSource provides the unconfirmed transactions which the
+	// consensus set uses to reconstruct compact blocks relayed by peers.
+	TransactionSource interface {
+		// Transactions returns the unconfirmed transactions of the source. It
+		// is called without holding the consensus set's lock.
+		Transactions() []types.Transaction
+	}
+
 	// ConsensusChangeDiffs is a collection of diffs caused by a single block.
 	// If the block was reverted, the individual diff directions are inverted.
 	// For example, a block that spends an output and creates a miner payout
@@ -87,6 +143,17 @@ type (
 		SiafundPoolDiffs          []SiafundPoolDiff
 	}
 
+	// A ConsensusChangeBatch is a batch of consensus changes returned by
+	// ConsensusChangesSince. Cursor is the ID of the last change in the batch,
+	// or the requested ID if the batch is empty, and can be passed to
+	// ConsensusChangesSince to request the next batch. More indicates whether
+	// there are changes after the batch.
+	ConsensusChangeBatch struct {
+		Changes []ConsensusChange
+		Cursor  ConsensusChangeID
+		More    bool
+	}
+
 	// A ConsensusChange enumerates a set of changes that occurred to the consensus set.
 	ConsensusChange struct {
 		// ID is a unique id for the consensus change derived from the reverted
@@ -117,6 +184,11 @@ type (
 		// AppliedDiffs.
 		ConsensusChangeDiffs
 
+		// AppliedStateCommitments are the state commitments after each block
+		// of AppliedBlocks was applied. Blocks which were applied before the
+		// node started tracking the state commitment have a zero commitment.
+		AppliedStateCommitments []crypto.Hash
+
 		// ChildTarget defines the target of any block that would be the child
 		// of the block most recently appended to the consensus set.
 		ChildTarget types.Target
@@ -181,10 +253,267 @@ type (
 		Adjusted  types.Currency
 	}
 
+	// A ReorgEvent describes a reorg of the consensus set. The blocks in
+	// RevertedBlocks are ordered from the old tip down to the child of the
+	// common ancestor, Depth is the number of reverted blocks.
+	ReorgEvent struct {
+		OldTip         types.BlockID     `json:"oldtip"`
+		OldHeight      types.BlockHeight `json:"oldheight"`
+		NewTip         types.BlockID     `json:"newtip"`
+		NewHeight      types.BlockHeight `json:"newheight"`
+		CommonAncestor types.BlockID     `json:"commonancestor"`
+		Depth          types.BlockHeight `json:"depth"`
+		RevertedBlocks []types.BlockID   `json:"revertedblocks"`
+		AppliedBlocks  []types.BlockID   `json:"appliedblocks"`
+		Time           time.Time         `json:"time"`
+	}
+
+	// ConsensusSubscriberStats describes how well a subscriber of the
+	// consensus set keeps up with the consensus changes. Subscribers are
+	// updated one after another, so a slow subscriber delays the updates of
+	// all other subscribers.
+	ConsensusSubscriberStats struct {
+		// Module is the name of the package that implements the subscriber.
+		Module string `json:"module"`
+
+		// Height is the height of the last consensus change the subscriber
+		// processed and BlocksBehind the number of blocks between that height
+		// and the current height of the consensus set.
+		Height       types.BlockHeight `json:"height"`
+		BlocksBehind types.BlockHeight `json:"blocksbehind"`
+
+		// Processing indicates whether the subscriber is currently processing
+		// a consensus change and ProcessingTime for how long it has been.
+		Processing     bool          `json:"processing"`
+		ProcessingTime time.Duration `json:"processingtime"`
+
+		// Latency statistics of the processed consensus changes.
+		Updates        uint64        `json:"updates"`
+		LastLatency    time.Duration `json:"lastlatency"`
+		AverageLatency time.Duration `json:"averagelatency"`
+		MaxLatency     time.Duration `json:"maxlatency"`
+	}
+
+	// ConsensusFeePercentiles contains percentiles of the fee per byte paid
+	// by a set of transactions. The fee per byte of a transaction is the sum
+	// of its miner fees divided by its encoded size.
+	ConsensusFeePercentiles struct {
+		P10 types.Currency `json:"p10"`
+		P25 types.Currency `json:"p25"`
+		P50 types.Currency `json:"p50"`
+		P75 types.Currency `json:"p75"`
+		P90 types.Currency `json:"p90"`
+	}
+
+	// ConsensusBlockStats contains the subsidy and fee statistics of the
+	// blocks between StartHeight and EndHeight, both inclusive.
+	ConsensusBlockStats struct {
+		StartHeight types.BlockHeight `json:"startheight"`
+		EndHeight   types.BlockHeight `json:"endheight"`
+
+		// Coinbase is the block reward without fees and Fees the sum of the
+		// miner fees of all transactions.
+		Coinbase types.Currency `json:"coinbase"`
+		Fees     types.Currency `json:"fees"`
+
+		// ContractFees are the fees paid by transactions which form, revise
+		// or prove file contracts and ContractFeeShare is their share of
+		// Fees.
+		ContractFees     types.Currency `json:"contractfees"`
+		ContractFeeShare float64        `json:"contractfeeshare"`
+
+		Transactions     uint64                  `json:"transactions"`
+		TransactionBytes uint64                  `json:"transactionbytes"`
+		FeePerByte       ConsensusFeePercentiles `json:"feeperbyte"`
+	}
+
+	// ConsensusStats contains the statistics of every block within a window
+	// of blocks as well as the statistics of the window as a whole.
+	ConsensusStats struct {
+		Window ConsensusBlockStats   `json:"window"`
+		Blocks []ConsensusBlockStats `json:"blocks"`
+	}
+
+	// ConsensusFileContractStats contains the statistics of the active file
+	// contracts at Height.
+	ConsensusFileContractStats struct {
+		Height types.BlockHeight `json:"height"`
+
+		// Contracts is the number of active file contracts and TotalSize the
+		// sum of their file sizes.
+		Contracts uint64 `json:"contracts"`
+		TotalSize uint64 `json:"totalsize"`
+
+		// LockedCollateral is the sum of the valid proof outputs of the
+		// active file contracts, i.e. the funds renters and hosts locked in
+		// them.
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+
+		// Expirations contains the number of active file contracts whose
+		// proof window ends at a height, sorted by height.
+		Expirations []ConsensusContractExpirations `json:"expirations"`
+	}
+
+	// ConsensusContractExpirations contains the number of file contracts
+	// whose proof window ends at Height.
+	ConsensusContractExpirations struct {
+		Height    types.BlockHeight `json:"height"`
+		Contracts uint64            `json:"contracts"`
+	}
+
+	// ConsensusBlockDiffs contains the diffs which were created when the
+	// block at Height of the current path was applied.
+	ConsensusBlockDiffs struct {
+		Height  types.BlockHeight
+		BlockID types.BlockID
+		ConsensusChangeDiffs
+	}
+
+	// ForkState is the activation state of a fork rule.
+	ForkState string
+
+	// ForkRuleStatus describes a fork rule and its activation state for the
+	// next block of the current path. Height-based rules activate at
+	// ActivationHeight. Signaling-based rules activate one period after
+	// Threshold blocks of a period of Period blocks signaled Bit between
+	// StartHeight and TimeoutHeight.
+	ForkRuleStatus struct {
+		Name       string    `json:"name"`
+		Activation string    `json:"activation"`
+		State      ForkState `json:"state"`
+
+		// ActivationHeight is the height at which the rule becomes active. For
+		// signaling-based rules it is only known once the rule is locked in.
+		ActivationHeight types.BlockHeight `json:"activationheight"`
+
+		Bit           uint8             `json:"bit"`
+		StartHeight   types.BlockHeight `json:"startheight"`
+		TimeoutHeight types.BlockHeight `json:"timeoutheight"`
+		Period        types.BlockHeight `json:"period"`
+		Threshold     uint64            `json:"threshold"`
+
+		// Signals is the number of blocks of the current period which
+		// signaled the rule so far.
+		Signals uint64 `json:"signals"`
+	}
+
+	// ConsensusCheckpoint identifies the state of the consensus set at a
+	// height. StateHash commits to the block path, the siacoin, siafund,
+	// file contract and delayed output sets and the processed blocks of the
+	// path, including their diffs. A snapshot of the consensus database which
+	// matches a trusted checkpoint can be used to skip validating the blocks
+	// below the checkpoint.
+	ConsensusCheckpoint struct {
+		Height    types.BlockHeight `json:"height"`
+		BlockID   types.BlockID     `json:"blockid"`
+		StateHash crypto.Hash       `json:"statehash"`
+	}
+
+	// ConsensusVerification is the result of replaying the stored diffs of
+	// the current path into an empty state. StateHash is the checksum of the
+	// stored consensus state and ReplayedStateHash the checksum of the
+	// replayed state. DivergedBuckets lists the database buckets whose
+	// contents differ between the two states.
+	ConsensusVerification struct {
+		Height            types.BlockHeight `json:"height"`
+		BlockID           types.BlockID     `json:"blockid"`
+		Consistent        bool              `json:"consistent"`
+		StateHash         crypto.Hash       `json:"statehash"`
+		ReplayedStateHash crypto.Hash       `json:"replayedstatehash"`
+		DivergedBuckets   []string          `json:"divergedbuckets"`
+	}
+
+	// TransactionProof proves that a transaction is part of the block with
+	// BlockID at Height. The leaves of a block's Merkle tree are its miner
+	// payouts followed by its transactions and Proof is the Merkle proof of
+	// the leaf at LeafIndex, which doesn't include the hash of the leaf.
+	TransactionProof struct {
+		BlockID     types.BlockID     `json:"blockid"`
+		Height      types.BlockHeight `json:"height"`
+		Transaction types.Transaction `json:"transaction"`
+		LeafIndex   uint64            `json:"leafindex"`
+		NumLeaves   uint64            `json:"numleaves"`
+		Proof       []crypto.Hash     `json:"proof"`
+	}
+
+	// LightSiacoinOutput is a siacoin output of a watched address which
+	// isn't spent by any of the proven transactions of the watched
+	// addresses.
+	LightSiacoinOutput struct {
+		ID         types.SiacoinOutputID `json:"id"`
+		Value      types.Currency        `json:"value"`
+		UnlockHash types.UnlockHash      `json:"unlockhash"`
+		Height     types.BlockHeight     `json:"height"`
+	}
+
+	// LightConsensusStatus describes the state of the light consensus set.
+	// ScanHeight is the lowest height whose transactions haven't been
+	// fetched for all of the watched addresses yet.
+	LightConsensusStatus struct {
+		Height           types.BlockHeight `json:"height"`
+		CurrentBlock     types.BlockID     `json:"currentblock"`
+		Target           types.Target      `json:"target"`
+		Synced           bool              `json:"synced"`
+		WatchedAddresses int               `json:"watchedaddresses"`
+		ScanHeight       types.BlockHeight `json:"scanheight"`
+	}
+
+	// A LightConsensusSet only tracks the block headers of the heaviest
+	// chain. Transactions of watched addresses are fetched from full nodes
+	// together with proofs of their inclusion in the tracked blocks. Like
+	// every SPV client, it trusts the full nodes not to withhold
+	// transactions and doesn't validate the transactions themselves.
+	LightConsensusSet interface {
+		// Close shuts down the light consensus set.
+		Close() error
+
+		// CurrentHeader returns the header of the current block.
+		CurrentHeader() types.BlockHeader
+
+		// HeaderAtHeight returns the header of the block at the provided
+		// height of the current path.
+		HeaderAtHeight(types.BlockHeight) (types.BlockHeader, bool)
+
+		// Height returns the height of the current block.
+		Height() types.BlockHeight
+
+		// Status returns the status of the light consensus set.
+		Status() LightConsensusStatus
+
+		// Synced returns true if the light consensus set is synced with its
+		// full node peers.
+		Synced() bool
+
+		// FeeEstimation returns an estimation of the minimum and maximum fee
+		// per byte required to get a transaction into a block. The estimation
+		// is based on recent blocks fetched from full nodes.
+		FeeEstimation() (min, max types.Currency, err error)
+
+		// WatchAddresses adds addresses to the set of watched addresses. Their
+		// transactions are fetched starting at the provided height.
+		WatchAddresses(addrs []types.UnlockHash, startHeight types.BlockHeight) error
+
+		// UnwatchAddresses removes addresses from the set of watched
+		// addresses together with their transactions.
+		UnwatchAddresses([]types.UnlockHash) error
+
+		// WatchedAddresses returns the watched addresses.
+		WatchedAddresses() ([]types.UnlockHash, error)
+
+		// Transactions returns the proven transactions of the watched
+		// addresses ordered by height.
+		Transactions() ([]TransactionProof, error)
+
+		// UnspentOutputs returns the siacoin outputs of the watched addresses
+		// which aren't spent by any of their transactions.
+		UnspentOutputs() ([]LightSiacoinOutput, error)
+	}
+
 	// A ConsensusSet accepts blocks and builds an understanding of network
 	// consensus.
 	ConsensusSet interface {
 		Alerter
+		OperationCounter
 
 		// AcceptBlock adds a block to consensus. An error will be returned if the
 		// block is invalid, has been seen before, is an orphan, or doesn't
@@ -211,6 +540,10 @@ type (
 		// run any required closing routines.
 		Close() error
 
+		// CommonAncestor returns the ID and height of the latest common
+		// ancestor of the two provided blocks.
+		CommonAncestor(a, b types.BlockID) (types.BlockID, types.BlockHeight, error)
+
 		// ConsensusSetSubscribe adds a subscriber to the list of subscribers
 		// and gives them every consensus change that has occurred since the
 		// change with the provided id. There are a few special cases,
@@ -218,6 +551,12 @@ type (
 		// A channel can be provided to abort the subscription process.
 		ConsensusSetSubscribe(ConsensusSetSubscriber, ConsensusChangeID, <-chan struct{}) error
 
+		// ConsensusChangesSince returns up to limit consensus changes which
+		// occurred after the change with the provided id. It supports the
+		// same special cases as ConsensusSetSubscribe and can be used to
+		// replay the consensus history in batches without subscribing.
+		ConsensusChangesSince(ccid ConsensusChangeID, limit int) (ConsensusChangeBatch, error)
+
 		// CurrentBlock returns the latest block in the heaviest known
 		// blockchain.
 		CurrentBlock() types.Block
@@ -232,12 +571,56 @@ type (
 		// current path, false otherwise.
 		InCurrentPath(types.BlockID) bool
 
+		// IsAncestor returns true if the first block is an ancestor of the
+		// second block. Every block is considered an ancestor of itself.
+		IsAncestor(ancestor, descendant types.BlockID) (bool, error)
+
 		// MinimumValidChildTimestamp returns the earliest timestamp that is
 		// valid on the current longest fork according to the consensus set. This is
 		// a required piece of information for the miner, who could otherwise be at
 		// risk of mining invalid blocks.
 		MinimumValidChildTimestamp(types.BlockID) (types.Timestamp, bool)
 
+		// BlockStats returns the subsidy and fee statistics of the window
+		// blocks of the current path which end at the provided height.
+		BlockStats(height, window types.BlockHeight) (ConsensusStats, error)
+
+		// FileContractStats returns the statistics of the active file
+		// contracts of the current path.
+		FileContractStats() (ConsensusFileContractStats, error)
+
+		// BlockDiffs returns the diffs of the block at the provided height of
+		// the current path.
+		BlockDiffs(height types.BlockHeight) (ConsensusBlockDiffs, error)
+
+		// StateCommitment returns the commitment to the siacoin output,
+		// siafund output and file contract sets of the current block.
+		StateCommitment() (crypto.Hash, error)
+
+		// ForkRules returns the status of all fork rules known to the
+		// consensus set.
+		ForkRules() ([]ForkRuleStatus, error)
+
+		// ForkRule returns the status of the fork rule with the provided name.
+		ForkRule(name string) (ForkRuleStatus, error)
+
+		// SubscriberStats returns the processing statistics of the consensus
+		// set's subscribers in the order in which they subscribed.
+		SubscriberStats() []ConsensusSubscriberStats
+
+		// ReorgSubscribe adds a subscriber which is notified of every
+		// future reorg of the consensus set.
+		ReorgSubscribe(ReorgSubscriber) error
+
+		// ReorgUnsubscribe removes a reorg subscriber. If the subscriber is
+		// not subscribed, no action is taken.
+		ReorgUnsubscribe(ReorgSubscriber)
+
+		// SetTransactionSource sets the source of the unconfirmed
+		// transactions which are used to reconstruct compact blocks. A nil
+		// source makes the consensus set download relayed blocks in full.
+		SetTransactionSource(TransactionSource)
+
 		// StorageProofSegment returns the segment to be used in the storage proof for
 		// a given file contract.
 		StorageProofSegment(types.FileContractID) (uint64, error)
@@ -256,6 +639,15 @@ type (
 		// allowing for garbage collection and rescanning. If the subscriber is
 		// not found in the subscriber database, no action is taken.
 		Unsubscribe(ConsensusSetSubscriber)
+
+		// SnapshotDB writes a consistent copy of the consensus database to
+		// the provided path without interrupting the consensus set. It
+		// returns the checkpoint of the snapshot.
+		SnapshotDB(dst string) (ConsensusCheckpoint, error)
+
+		// VerifyConsensus replays the stored diffs of the current path and
+		// compares the result with the stored consensus state.
+		VerifyConsensus() (ConsensusVerification, error)
 	}
 )
 
@@ -279,6 +671,7 @@ func (cc ConsensusChange) MarshalSia(w io.Writer) error {
 		cc.ChildTarget,
 		cc.MinimumValidChildTimestamp,
 		cc.Synced,
+		cc.AppliedStateCommitments,
 	)
 }
 
@@ -294,6 +687,7 @@ func (cc *ConsensusChange) UnmarshalSia(r io.Reader) error {
 		&cc.ChildTarget,
 		&cc.MinimumValidChildTimestamp,
 		&cc.Synced,
+		&cc.AppliedStateCommitments,
 	)
 	if err != nil {
 		return err
@@ -312,3 +706,30 @@ func (cc *ConsensusChange) UnmarshalSia(r io.Reader) error {
 func (ccID ConsensusChangeID) String() string {
 	return crypto.Hash(ccID).String()
 }
+
+// String returns the checkpoint as "height:blockid:statehash".
+func (cp ConsensusCheckpoint) String() string {
+	return fmt.Sprintf("%v:%v:%v", cp.Height, cp.BlockID, cp.StateHash)
+}
+
+// LoadString loads a checkpoint from a string created by String.
+func (cp *ConsensusCheckpoint) LoadString(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return errors.New("checkpoint must have the format height:blockid:statehash")
+	}
+	var height types.BlockHeight
+	if _, err := fmt.Sscan(parts[0], &height); err != nil {
+		return fmt.Errorf("unable to parse checkpoint height: %v", err)
+	}
+	var id types.BlockID
+	if err := id.LoadString(parts[1]); err != nil {
+		return fmt.Errorf("unable to parse checkpoint block id: %v", err)
+	}
+	var stateHash crypto.Hash
+	if err := stateHash.LoadString(parts[2]); err != nil {
+		return fmt.Errorf("unable to parse checkpoint state hash: %v", err)
+	}
+	cp.Height, cp.BlockID, cp.StateHash = height, id, stateHash
+	return nil
+}
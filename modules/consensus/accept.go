@@ -108,7 +108,7 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 	}
 
 	// Check that the nonce is a legal nonce.
-	if parent.Height+1 >= types.ASICHardforkHeight && binary.LittleEndian.Uint64(h.Nonce[:])%types.ASICHardforkFactor != 0 {
+	if forkRuleASIC.activeAtHeight(parent.Height+1) && binary.LittleEndian.Uint64(h.Nonce[:])%types.ASICHardforkFactor != 0 {
 		return errors.New("block does not meet nonce requirements")
 	}
 	// Check that the target of the new block is sufficient.
@@ -125,11 +125,10 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 		return ErrEarlyTimestamp
 	}
 
-	// Check if the block is in the extreme future. We make a distinction between
-	// future and extreme future because there is an assumption that by the time
-	// the extreme future arrives, this block will no longer be a part of the
-	// longest fork because it will have been ignored by all of the miners.
-	if h.Timestamp > types.CurrentTimestamp()+types.ExtremeFutureThreshold {
+	// Check if the block is too far in the future to be queued. Blocks in the
+	// extreme future are still downloaded as long as they are within the
+	// future block horizon, since our clock might be behind the network's.
+	if h.Timestamp > types.CurrentTimestamp()+types.ExtremeFutureThreshold+futureBlockHorizon {
 		return ErrExtremeFutureTimestamp
 	}
 
@@ -182,35 +181,6 @@ func (cs *ConsensusSet) addBlockToTree(tx *bolt.Tx, b types.Block, parent *proce
 	return ce, nil
 }
 
-// threadedSleepOnFutureBlock will sleep until the timestamp of a future block
-// has arrived.
-//
-// TODO: An attacker can broadcast a future block multiple times, resulting in a
-// goroutine spinup for each future block.  Need to prevent that.
-//
-// TODO: An attacker could produce a very large number of future blocks,
-// consuming memory. Need to prevent that.
-func (cs *ConsensusSet) threadedSleepOnFutureBlock(b types.Block) {
-	// Add this thread to the threadgroup.
-	err := cs.tg.Add()
-	if err != nil {
-		return
-	}
-	defer cs.tg.Done()
-
-	// Perform a soft-sleep while we wait for the block to become valid.
-	select {
-	case <-cs.tg.StopChan():
-		return
-	case <-time.After(time.Duration(b.Timestamp-(types.CurrentTimestamp()+types.FutureThreshold)) * time.Second):
-		_, err := cs.managedAcceptBlocks([]types.Block{b})
-		if err != nil {
-			cs.log.Debugln("WARN: failed to accept a future block:", err)
-		}
-		cs.managedBroadcastBlock(b)
-	}
-}
-
 // managedAcceptBlocks will try to add blocks to the consensus set. If the
 // blocks do not extend the longest currently known chain, an error is
 // returned but the blocks are still kept in memory. If the blocks extend a fork
@@ -227,6 +197,17 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
+	// Count the operation. Blocks which are valid but don't extend the longest
+	// chain are not considered a failure.
+	start := time.Now()
+	defer func() {
+		opErr := err
+		if errors.Contains(opErr, modules.ErrNonExtendingBlock) {
+			opErr = nil
+		}
+		cs.staticOperationCounters.Record(operationAcceptBlocks, time.Since(start), opErr)
+	}()
+
 	// Make sure that blocks are consecutive. Though this isn't a strict
 	// requirement, if blocks are not consecutive then it becomes a lot harder
 	// to maintain correcetness when adding multiple blocks in a single tx.
@@ -244,20 +225,29 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	// invalid blocks (which includes the children of invalid blocks).
 	chainExtended := false
 	changes := make([]changeEntry, 0, len(blocks))
+	var commitStart time.Time
 	setErr := cs.db.Update(func(tx *bolt.Tx) error {
 		for i := 0; i < len(blocks); i++ {
 			// Start by checking the header of the block.
 			startTime := time.Now()
 			parent, err := cs.validateHeaderAndBlock(boltTxWrapper{tx}, blocks[i], blockIDs[i])
 			cs.log.Debugf("validateHeaderAndBlock time: %v", time.Since(startTime).Round(time.Millisecond))
+			if !errors.Contains(err, modules.ErrBlockKnown) {
+				cs.staticOperationCounters.Record(operationValidateBlock, time.Since(startTime), err)
+			}
 
 			if errors.Contains(err, modules.ErrBlockKnown) {
 				// Skip over known blocks.
 				continue
 			}
-			if errors.Contains(err, ErrFutureTimestamp) {
-				// Queue the block to be tried again if it is a future block.
-				go cs.threadedSleepOnFutureBlock(blocks[i])
+			if errors.Contains(err, ErrFutureTimestamp) || errors.Contains(err, ErrExtremeFutureTimestamp) {
+				// Queue the block to be tried again once its timestamp is
+				// acceptable. Blocks which can't be queued are discarded.
+				if qErr := cs.staticFutureBlocks.callAdd(blocks[i], types.CurrentTimestamp()); qErr != nil {
+					err = errors.Compose(ErrExtremeFutureTimestamp, qErr)
+				} else {
+					err = ErrFutureTimestamp
+				}
 			}
 			if err != nil {
 				return err
@@ -293,8 +283,13 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 				return err
 			}
 		}
+		// The transaction is committed after the function returns.
+		commitStart = time.Now()
 		return nil
 	})
+	if !commitStart.IsZero() {
+		cs.staticOperationCounters.Record(operationDBCommit, time.Since(commitStart), setErr)
+	}
 	if _, ok := setErr.(bolt.MmapError); ok {
 		cs.log.Println("ERROR: Bolt mmap failed:", setErr)
 		fmt.Println("Blockchain database has run out of disk space!")
@@ -316,6 +311,7 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	// Send any changes to subscribers.
 	for i := 0; i < len(changes); i++ {
 		cs.updateSubscribers(changes[i])
+		cs.updateReorgSubscribers(changes[i])
 	}
 	return chainExtended, nil
 }
@@ -10,6 +10,7 @@ import (
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
@@ -419,17 +420,30 @@ func TestUnitValidateHeader(t *testing.T) {
 			errWant:                ErrEarlyTimestamp,
 			msg:                    "validateHeader should fail when the header's timestamp is too early",
 		},
-		// Test that headers in the extreme future are rejected.
+		// Test that headers beyond the future block horizon are rejected.
 		{
 			header: types.BlockHeader{
-				Timestamp: types.CurrentTimestamp() + types.ExtremeFutureThreshold + 2,
+				Timestamp: types.CurrentTimestamp() + types.ExtremeFutureThreshold + futureBlockHorizon + 2,
 				ParentID:  mockParentID(),
 			},
 			dosBlocks:     make(map[types.BlockID]struct{}),
 			blockMapPairs: serializedParentBlockMap,
 			marshaler:     parentBlockHighTargetUnmarshaler,
 			errWant:       ErrExtremeFutureTimestamp,
-			msg:           "validateHeader should fail when the header's timestamp is in the extreme future",
+			msg:           "validateHeader should fail when the header's timestamp is beyond the future block horizon",
+		},
+		// Test that headers in the extreme future but within the future block
+		// horizon are not rejected.
+		{
+			header: types.BlockHeader{
+				Timestamp: types.CurrentTimestamp() + types.ExtremeFutureThreshold + 2,
+				ParentID:  mockParentID(),
+			},
+			dosBlocks:     make(map[types.BlockID]struct{}),
+			blockMapPairs: serializedParentBlockMap,
+			marshaler:     parentBlockHighTargetUnmarshaler,
+			errWant:       nil,
+			msg:           "validateHeader should not reject headers within the future block horizon",
 		},
 		// Test that headers in the near future are not rejected.
 		{
@@ -786,8 +800,9 @@ func TestFutureTimestampHandling(t *testing.T) {
 	}
 }
 
-// TestExtremeFutureTimestampHandling checks that blocks in the extreme future
-// are rejected.
+// TestExtremeFutureTimestampHandling checks that blocks beyond the future
+// block horizon are rejected while blocks in the extreme future within the
+// horizon are queued until their timestamps are acceptable.
 func TestExtremeFutureTimestampHandling(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -803,17 +818,36 @@ func TestExtremeFutureTimestampHandling(t *testing.T) {
 		}
 	}()
 
-	// Submit a block with a timestamp in the extreme future.
+	// Submit a block with a timestamp beyond the future block horizon.
 	block, target, err := cst.miner.BlockForWork()
 	if err != nil {
 		t.Fatal(err)
 	}
-	block.Timestamp = types.CurrentTimestamp() + 2 + types.ExtremeFutureThreshold
+	block.Timestamp = types.CurrentTimestamp() + 2 + types.ExtremeFutureThreshold + futureBlockHorizon
 	solvedBlock, _ := cst.miner.SolveBlock(block, target)
 	err = cst.cs.AcceptBlock(solvedBlock)
-	if !errors.Contains(err, ErrExtremeFutureTimestamp) {
+	if !errors.Contains(err, ErrExtremeFutureTimestamp) || !errors.Contains(err, errFutureBlockTooFar) {
+		t.Fatalf("expected %v, got %v", ErrExtremeFutureTimestamp, err)
+	}
+	if cst.cs.staticFutureBlocks.callLen() != 0 {
+		t.Fatal("block beyond the horizon was queued")
+	}
+
+	// Submit a block with a timestamp in the extreme future within the
+	// horizon. It is queued and accepted once it is due.
+	block.Timestamp = types.CurrentTimestamp() + 2 + types.ExtremeFutureThreshold
+	solvedBlock, _ = cst.miner.SolveBlock(block, target)
+	err = cst.cs.AcceptBlock(solvedBlock)
+	if !errors.Contains(err, ErrFutureTimestamp) {
 		t.Fatalf("expected %v, got %v", ErrFutureTimestamp, err)
 	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, err := cst.cs.dbGetBlockMap(solvedBlock.ID())
+		return err
+	})
+	if err != nil {
+		t.Fatal("queued block wasn't accepted", err)
+	}
 }
 
 // TestBuriedBadTransaction tries submitting a block with a bad transaction
@@ -6,5 +6,5 @@ import (
 
 // Alerts implements the Alerter interface for the consensusset.
 func (c *ConsensusSet) Alerts() (crit, err, warn []modules.Alert) {
-	return []modules.Alert{}, []modules.Alert{}, []modules.Alert{}
+	return c.staticAlerter.Alerts()
 }
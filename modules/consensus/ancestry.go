@@ -0,0 +1,136 @@
+package consensus
+
+import (
+	"errors"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errUnknownBlock is returned if an ancestry query references a block
+	// which isn't in the block map.
+	errUnknownBlock = errors.New("block is not known to the consensus set")
+)
+
+// inCurrentPath returns whether the processed block is part of the current
+// path.
+func inCurrentPath(tx *bolt.Tx, pb *processedBlock) bool {
+	pathID, err := getPath(tx, pb.Height)
+	return err == nil && pathID == pb.Block.ID()
+}
+
+// ancestorAtHeight returns the ancestor of pb at the provided height, which
+// needs to be lower than or equal to the height of pb. Parents are only
+// followed one by one until the current path is reached, after that the
+// ancestor is looked up in the path directly.
+func ancestorAtHeight(tx *bolt.Tx, pb *processedBlock, height types.BlockHeight) (*processedBlock, error) {
+	for pb.Height > height {
+		if inCurrentPath(tx, pb) {
+			id, err := getPath(tx, height)
+			if err != nil {
+				return nil, err
+			}
+			return getBlockMap(tx, id)
+		}
+		parent, err := getBlockMap(tx, pb.Block.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		pb = parent
+	}
+	return pb, nil
+}
+
+// commonAncestor returns the latest common ancestor of a and b.
+func commonAncestor(tx *bolt.Tx, a, b *processedBlock) (*processedBlock, error) {
+	// Bring both blocks to the same height.
+	var err error
+	if a.Height > b.Height {
+		a, err = ancestorAtHeight(tx, a, b.Height)
+	} else {
+		b, err = ancestorAtHeight(tx, b, a.Height)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Step back until the blocks match. Since two different blocks in the
+	// current path can't have the same height, this only iterates while at
+	// least one of the blocks is on a side chain.
+	for a.Block.ID() != b.Block.ID() {
+		if a.Height == 0 {
+			return nil, errUnknownBlock
+		}
+		a, err = ancestorAtHeight(tx, a, a.Height-1)
+		if err != nil {
+			return nil, err
+		}
+		b, err = ancestorAtHeight(tx, b, b.Height-1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// IsAncestor returns whether the block with the ID ancestor is an ancestor of
+// the block with the ID descendant. Every block is considered an ancestor of
+// itself.
+func (cs *ConsensusSet) IsAncestor(ancestor, descendant types.BlockID) (isAncestor bool, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return false, err
+	}
+	defer cs.tg.Done()
+
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		apb, err := getBlockMap(tx, ancestor)
+		if err != nil {
+			return errUnknownBlock
+		}
+		dpb, err := getBlockMap(tx, descendant)
+		if err != nil {
+			return errUnknownBlock
+		}
+		if apb.Height > dpb.Height {
+			return nil
+		}
+		pb, err := ancestorAtHeight(tx, dpb, apb.Height)
+		if err != nil {
+			return err
+		}
+		isAncestor = pb.Block.ID() == ancestor
+		return nil
+	})
+	return isAncestor, err
+}
+
+// CommonAncestor returns the ID and height of the latest common ancestor of
+// the blocks with the IDs a and b.
+func (cs *ConsensusSet) CommonAncestor(a, b types.BlockID) (id types.BlockID, height types.BlockHeight, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.BlockID{}, 0, err
+	}
+	defer cs.tg.Done()
+
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		apb, err := getBlockMap(tx, a)
+		if err != nil {
+			return errUnknownBlock
+		}
+		bpb, err := getBlockMap(tx, b)
+		if err != nil {
+			return errUnknownBlock
+		}
+		pb, err := commonAncestor(tx, apb, bpb)
+		if err != nil {
+			return err
+		}
+		id = pb.Block.ID()
+		height = pb.Height
+		return nil
+	})
+	return id, height, err
+}
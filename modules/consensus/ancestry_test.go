@@ -0,0 +1,128 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAncestry probes IsAncestor and CommonAncestor for blocks in the current
+// path as well as blocks on side chains.
+func TestAncestry(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create three consensus sets which only share the genesis block.
+	csts := make([]*consensusSetTester, 3)
+	for i := range csts {
+		cst, err := blankConsensusSetTester(t.Name()+string(rune('A'+i)), modules.ProdDependencies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		csts[i] = cst
+		defer func() {
+			if err := cst.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+	}
+	main, alt, alt2 := csts[0], csts[1], csts[2]
+	mine := func(cst *consensusSetTester, n int) []types.Block {
+		var blocks []types.Block
+		for i := 0; i < n; i++ {
+			b, err := cst.miner.AddBlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			blocks = append(blocks, b)
+		}
+		return blocks
+	}
+	share := func(cst *consensusSetTester, blocks []types.Block) {
+		for _, b := range blocks {
+			// Errors are ignored since side chain blocks return an error.
+			_ = cst.cs.AcceptBlock(b)
+		}
+	}
+
+	// Create a shared prefix P0-P4, then extend the main chain with M0-M2 and
+	// a lighter side chain with S0-S1. T0 forks off the side chain after S0.
+	prefix := mine(alt, 5)
+	share(main, prefix)
+	share(alt2, prefix)
+	m := mine(main, 3)
+	s := mine(alt, 2)
+	share(alt2, s[:1])
+	tt := mine(alt2, 1)
+	share(main, s)
+	share(main, tt)
+	for _, b := range append(s, tt...) {
+		if main.cs.InCurrentPath(b.ID()) {
+			t.Fatal("side chain block shouldn't be in the current path")
+		}
+	}
+	genesis := types.GenesisID
+
+	ancestorTests := []struct {
+		ancestor, descendant types.BlockID
+		isAncestor           bool
+	}{
+		{genesis, m[2].ID(), true},
+		{genesis, s[1].ID(), true},
+		{m[2].ID(), m[2].ID(), true},
+		{prefix[4].ID(), m[0].ID(), true},
+		{prefix[4].ID(), s[1].ID(), true},
+		{prefix[2].ID(), tt[0].ID(), true},
+		{s[0].ID(), s[1].ID(), true},
+		{s[0].ID(), tt[0].ID(), true},
+		{m[0].ID(), s[1].ID(), false},
+		{s[1].ID(), s[0].ID(), false},
+		{s[1].ID(), tt[0].ID(), false},
+		{m[2].ID(), m[0].ID(), false},
+		{s[0].ID(), m[2].ID(), false},
+	}
+	for i, test := range ancestorTests {
+		isAncestor, err := main.cs.IsAncestor(test.ancestor, test.descendant)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if isAncestor != test.isAncestor {
+			t.Errorf("%v: expected %v but got %v", i, test.isAncestor, isAncestor)
+		}
+	}
+
+	commonTests := []struct {
+		a, b, ancestor types.BlockID
+		height         types.BlockHeight
+	}{
+		{m[2].ID(), m[0].ID(), m[0].ID(), 6},
+		{m[2].ID(), s[1].ID(), prefix[4].ID(), 5},
+		{s[1].ID(), m[2].ID(), prefix[4].ID(), 5},
+		{s[1].ID(), tt[0].ID(), s[0].ID(), 6},
+		{s[1].ID(), prefix[1].ID(), prefix[1].ID(), 2},
+		{genesis, s[1].ID(), genesis, 0},
+		{tt[0].ID(), tt[0].ID(), tt[0].ID(), 7},
+	}
+	for i, test := range commonTests {
+		id, height, err := main.cs.CommonAncestor(test.a, test.b)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if id != test.ancestor || height != test.height {
+			t.Errorf("%v: expected %v at %v but got %v at %v", i, test.ancestor, test.height, id, height)
+		}
+	}
+
+	// Unknown blocks return an error.
+	if _, err := main.cs.IsAncestor(types.BlockID{1}, m[0].ID()); !errors.Contains(err, errUnknownBlock) {
+		t.Fatal("expected errUnknownBlock but got", err)
+	}
+	if _, _, err := main.cs.CommonAncestor(m[0].ID(), types.BlockID{1}); !errors.Contains(err, errUnknownBlock) {
+		t.Fatal("expected errUnknownBlock but got", err)
+	}
+}
@@ -214,7 +214,7 @@ func applySiafundOutputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 // is the only recognized value.
 func applyArbitraryData(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 	// No ArbitraryData values were recognized prior to the Foundation hardfork.
-	if pb.Height < types.FoundationHardforkHeight {
+	if !forkRuleFoundation.activeAtHeight(pb.Height) {
 		return
 	}
 	for _, arb := range t.ArbitraryData {
@@ -0,0 +1,280 @@
+package consensus
+
+// archive.go contains the portable consensus archive. An archive is a gzipped
+// stream of the blocks of the current path and their diffs, which doesn't
+// depend on the layout of the consensus database. Unlike a snapshot, an
+// archive doesn't have to be trusted: importing it validates every block like
+// blocks received from peers and checks that the generated diffs match the
+// archived ones.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// archiveImportPrefix is the prefix of the directory an archive is
+	// imported into before the database is moved into place.
+	archiveImportPrefix = "import_"
+)
+
+var (
+	// archiveMaxEntrySize is the maximum size of an encoded archive entry.
+	// The diffs of a block are bounded by the size of the block, so an entry
+	// is at most a small multiple of the block size limit.
+	archiveMaxEntrySize = 10 * types.BlockSizeLimit
+
+	// archiveMetadata is the header of a consensus archive. The version
+	// needs to be increased whenever the format of the archive changes.
+	archiveMetadata = persist.Metadata{
+		Header:  "Sia Consensus Archive",
+		Version: "1.0",
+	}
+
+	// archiveBatchSize is the number of blocks which are imported within a
+	// single transaction.
+	archiveBatchSize = build.Select(build.Var{
+		Standard: types.BlockHeight(1000),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// errArchiveDatabaseExists is returned if an archive is imported into a
+	// directory which already contains a consensus database.
+	errArchiveDatabaseExists = errors.New("consensus database already exists")
+
+	// errArchiveDiffMismatch is returned if the archived diffs of a block
+	// don't match the diffs generated during the import.
+	errArchiveDiffMismatch = errors.New("archived diffs don't match the generated diffs")
+
+	// errArchiveFutureBlock is returned if an archive contains a block with a
+	// timestamp in the future.
+	errArchiveFutureBlock = errors.New("archive contains a block from the future")
+
+	// errArchiveGenesisMismatch is returned if an archive was exported from a
+	// different network.
+	errArchiveGenesisMismatch = errors.New("archive has a different genesis block")
+
+	// errArchiveMismatch is returned if the state after importing an archive
+	// doesn't match the checkpoint of the archive.
+	errArchiveMismatch = errors.New("imported state doesn't match the archive's checkpoint")
+)
+
+type (
+	// archiveHeader follows the metadata of an archive. It identifies the
+	// network and the state the archive ends at.
+	archiveHeader struct {
+		GenesisID  types.BlockID
+		Checkpoint modules.ConsensusCheckpoint
+	}
+
+	// archivedBlock is an entry of an archive. The height, depth and target
+	// of a block are derived from its parents during the import.
+	archivedBlock struct {
+		Block                     types.Block
+		SiacoinOutputDiffs        []modules.SiacoinOutputDiff
+		FileContractDiffs         []modules.FileContractDiff
+		SiafundOutputDiffs        []modules.SiafundOutputDiff
+		DelayedSiacoinOutputDiffs []modules.DelayedSiacoinOutputDiff
+		SiafundPoolDiffs          []modules.SiafundPoolDiff
+	}
+)
+
+// newArchivedBlock returns the archive entry of a processed block.
+func newArchivedBlock(pb *processedBlock) archivedBlock {
+	return archivedBlock{
+		Block:                     pb.Block,
+		SiacoinOutputDiffs:        pb.SiacoinOutputDiffs,
+		FileContractDiffs:         pb.FileContractDiffs,
+		SiafundOutputDiffs:        pb.SiafundOutputDiffs,
+		DelayedSiacoinOutputDiffs: pb.DelayedSiacoinOutputDiffs,
+		SiafundPoolDiffs:          pb.SiafundPoolDiffs,
+	}
+}
+
+// exportArchiveTx writes the archive of the current path of the database to
+// w and returns the checkpoint the archive ends at.
+func exportArchiveTx(tx *bolt.Tx, w io.Writer) (modules.ConsensusCheckpoint, error) {
+	genesisID, err := getPath(tx, 0)
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to find genesis block")
+	}
+	header := archiveHeader{
+		GenesisID:  genesisID,
+		Checkpoint: dbCheckpoint(tx),
+	}
+
+	gzw := gzip.NewWriter(w)
+	if err := encoding.WriteObject(gzw, archiveMetadata); err != nil {
+		return modules.ConsensusCheckpoint{}, err
+	}
+	if err := encoding.WriteObject(gzw, header); err != nil {
+		return modules.ConsensusCheckpoint{}, err
+	}
+	for h := types.BlockHeight(1); h <= header.Checkpoint.Height; h++ {
+		id, err := getPath(tx, h)
+		if err != nil {
+			return modules.ConsensusCheckpoint{}, fmt.Errorf("unable to find block at height %v: %v", h, err)
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return modules.ConsensusCheckpoint{}, fmt.Errorf("unable to decode block at height %v: %v", h, err)
+		}
+		if !pb.DiffsGenerated {
+			return modules.ConsensusCheckpoint{}, errDiffsNotGenerated
+		}
+		if err := encoding.WriteObject(gzw, newArchivedBlock(pb)); err != nil {
+			return modules.ConsensusCheckpoint{}, err
+		}
+	}
+	return header.Checkpoint, gzw.Close()
+}
+
+// ExportArchive writes an archive of the consensus database at filename to w
+// and returns the checkpoint the archive ends at. The database must not be in
+// use by a running consensus set.
+func ExportArchive(filename string, w io.Writer) (cp modules.ConsensusCheckpoint, err error) {
+	db, err := OpenDBReadOnly(filename)
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, db.Close())
+	}()
+
+	err = db.View(func(tx *bolt.Tx) (err error) {
+		cp, err = exportArchiveTx(tx, w)
+		return err
+	})
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to export archive")
+	}
+	return cp, nil
+}
+
+// managedImportArchiveBatch validates and applies a batch of archived blocks
+// and checks that the generated diffs match the archived diffs.
+func (cs *ConsensusSet) managedImportArchiveBatch(batch []archivedBlock) error {
+	blocks := make([]types.Block, 0, len(batch))
+	for _, ab := range batch {
+		if ab.Block.Timestamp > types.CurrentTimestamp()+types.FutureThreshold {
+			return errArchiveFutureBlock
+		}
+		blocks = append(blocks, ab.Block)
+	}
+	if _, err := cs.managedAcceptBlocks(blocks); err != nil {
+		return err
+	}
+	return cs.db.View(func(tx *bolt.Tx) error {
+		for _, ab := range batch {
+			pb, err := getBlockMap(tx, ab.Block.ID())
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(encoding.Marshal(newArchivedBlock(pb)), encoding.Marshal(ab)) {
+				return errors.Compose(errArchiveDiffMismatch, fmt.Errorf("block %v at height %v", ab.Block.ID(), pb.Height))
+			}
+		}
+		return nil
+	})
+}
+
+// importArchive validates the blocks of the archive read from r and applies
+// them to the consensus database in persistDir.
+func importArchive(persistDir string, r io.Reader) (_ modules.ConsensusCheckpoint, err error) {
+	cs, err := newConsensusSet(persistDir, modules.ProdDependencies)
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, cs.Close())
+	}()
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to read archive")
+	}
+	var metadata persist.Metadata
+	if err := encoding.ReadObject(gzr, &metadata, archiveMaxEntrySize); err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to read archive metadata")
+	}
+	if metadata.Header != archiveMetadata.Header {
+		return modules.ConsensusCheckpoint{}, persist.ErrBadHeader
+	} else if metadata.Version != archiveMetadata.Version {
+		return modules.ConsensusCheckpoint{}, persist.ErrBadVersion
+	}
+	var header archiveHeader
+	if err := encoding.ReadObject(gzr, &header, archiveMaxEntrySize); err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to read archive header")
+	}
+	if header.GenesisID != cs.blockRoot.Block.ID() {
+		return modules.ConsensusCheckpoint{}, errArchiveGenesisMismatch
+	}
+
+	batch := make([]archivedBlock, 0, archiveBatchSize)
+	for h := types.BlockHeight(1); h <= header.Checkpoint.Height; h++ {
+		var ab archivedBlock
+		if err := encoding.ReadObject(gzr, &ab, archiveMaxEntrySize); err != nil {
+			return modules.ConsensusCheckpoint{}, fmt.Errorf("unable to read block at height %v: %v", h, err)
+		}
+		batch = append(batch, ab)
+		if types.BlockHeight(len(batch)) < archiveBatchSize && h < header.Checkpoint.Height {
+			continue
+		}
+		if err := cs.managedImportArchiveBatch(batch); err != nil {
+			return modules.ConsensusCheckpoint{}, errors.AddContext(err, fmt.Sprintf("unable to import blocks up to height %v", h))
+		}
+		batch = batch[:0]
+	}
+
+	var cp modules.ConsensusCheckpoint
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		cp = dbCheckpoint(tx)
+		return nil
+	})
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, err
+	}
+	if cp != header.Checkpoint {
+		return modules.ConsensusCheckpoint{}, errors.Compose(errArchiveMismatch, fmt.Errorf("imported state is at %v, expected %v", cp, header.Checkpoint))
+	}
+	return cp, nil
+}
+
+// ImportArchive creates the consensus database in persistDir from the archive
+// read from r and returns the checkpoint of the imported state. Every block
+// of the archive is validated, so the archive doesn't need to come from a
+// trusted source. The blocks are imported into a temporary directory first to
+// make sure that persistDir never contains a partial database. An existing
+// database is never replaced.
+func ImportArchive(persistDir string, r io.Reader) (_ modules.ConsensusCheckpoint, err error) {
+	dbFilename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(dbFilename); err == nil {
+		return modules.ConsensusCheckpoint{}, errArchiveDatabaseExists
+	} else if !os.IsNotExist(err) {
+		return modules.ConsensusCheckpoint{}, err
+	}
+
+	tmpDir := filepath.Join(persistDir, archiveImportPrefix+persist.RandomSuffix())
+	defer func() {
+		err = errors.Compose(err, os.RemoveAll(tmpDir))
+	}()
+	cp, err := importArchive(tmpDir, r)
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to import archive")
+	}
+	return cp, os.Rename(filepath.Join(tmpDir, DatabaseFilename), dbFilename)
+}
@@ -0,0 +1,142 @@
+package consensus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// tamperArchive returns a copy of an archive where the siacoin output diffs
+// of the block at the provided height contain an additional diff.
+func tamperArchive(archive []byte, height types.BlockHeight) ([]byte, error) {
+	gzr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	var metadata persist.Metadata
+	var header archiveHeader
+	if err := encoding.ReadObject(gzr, &metadata, archiveMaxEntrySize); err != nil {
+		return nil, err
+	}
+	if err := encoding.ReadObject(gzr, &header, archiveMaxEntrySize); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	if err := encoding.WriteObject(gzw, metadata); err != nil {
+		return nil, err
+	}
+	if err := encoding.WriteObject(gzw, header); err != nil {
+		return nil, err
+	}
+	for h := types.BlockHeight(1); h <= header.Checkpoint.Height; h++ {
+		var ab archivedBlock
+		if err := encoding.ReadObject(gzr, &ab, archiveMaxEntrySize); err != nil {
+			return nil, err
+		}
+		if h == height {
+			ab.SiacoinOutputDiffs = append(ab.SiacoinOutputDiffs, modules.SiacoinOutputDiff{Direction: modules.DiffApply})
+		}
+		if err := encoding.WriteObject(gzw, ab); err != nil {
+			return nil, err
+		}
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// TestConsensusArchive checks that an exported archive can be imported into a
+// new consensus database and that tampered archives are rejected.
+func TestConsensusArchive(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// Make sure that there are multiple batches and a file contract.
+	cst.testFileContractRevision()
+
+	var buf bytes.Buffer
+	var cp modules.ConsensusCheckpoint
+	var checksum crypto.Hash
+	err = cst.cs.db.View(func(tx *bolt.Tx) (err error) {
+		checksum = consensusChecksum(tx)
+		cp, err = exportArchiveTx(tx, &buf)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Height != cst.cs.Height() || cp.BlockID != cst.cs.CurrentBlock().ID() {
+		t.Fatal("unexpected checkpoint", cp)
+	}
+	archive := buf.Bytes()
+
+	// Import the archive and compare the imported state.
+	persistDir := filepath.Join(cst.persistDir, "imported")
+	imported, err := ImportArchive(persistDir, bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != cp {
+		t.Fatalf("imported state is at %v, expected %v", imported, cp)
+	}
+	cv, err := VerifyDB(filepath.Join(persistDir, DatabaseFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cv.Consistent || cv.StateHash != checksum {
+		t.Fatal("imported database is inconsistent", cv)
+	}
+
+	// An existing database isn't replaced.
+	_, err = ImportArchive(persistDir, bytes.NewReader(archive))
+	if !errors.Contains(err, errArchiveDatabaseExists) {
+		t.Fatal("expected errArchiveDatabaseExists but got", err)
+	}
+
+	// Archives with tampered diffs are rejected.
+	tampered, err := tamperArchive(archive, cp.Height/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ImportArchive(filepath.Join(cst.persistDir, "tampered"), bytes.NewReader(tampered))
+	if !errors.Contains(err, errArchiveDiffMismatch) {
+		t.Fatal("expected errArchiveDiffMismatch but got", err)
+	}
+
+	// Archives from another network are rejected.
+	invalid, err := func() ([]byte, error) {
+		var buf bytes.Buffer
+		gzw := gzip.NewWriter(&buf)
+		err := errors.Compose(encoding.WriteObject(gzw, archiveMetadata), encoding.WriteObject(gzw, archiveHeader{}), gzw.Close())
+		return buf.Bytes(), err
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ImportArchive(filepath.Join(cst.persistDir, "invalid"), bytes.NewReader(invalid))
+	if !errors.Contains(err, errArchiveGenesisMismatch) {
+		t.Fatal("expected errArchiveGenesisMismatch but got", err)
+	}
+}
@@ -17,13 +17,16 @@ var (
 	// ErrEarlyTimestamp is returned when the block's timestamp is too early
 	ErrEarlyTimestamp = errors.New("block timestamp is too early")
 	// ErrExtremeFutureTimestamp is returned when the block's timestamp is too
-	// far in the future
+	// far in the future for the block to be queued
 	ErrExtremeFutureTimestamp = errors.New("block timestamp too far in future, discarded")
 	// ErrFutureTimestamp is returned when the block's timestamp is too far in
 	// the future to be used now but it's saved for future use
 	ErrFutureTimestamp = errors.New("block timestamp too far in future, but saved for later use")
 	// ErrLargeBlock is returned when the block is too large to be accepted
 	ErrLargeBlock = errors.New("block is too large to be accepted")
+	// ErrMisplacedForkSignal is returned when a transaction other than the
+	// first transaction of a block signals fork rules.
+	ErrMisplacedForkSignal = errors.New("fork signals are only allowed in the first transaction of a block")
 )
 
 // blockValidator validates a Block against a set of block validity rules.
@@ -79,7 +82,7 @@ func (bv stdBlockValidator) ValidateBlock(b types.Block, id types.BlockID, minTi
 	}
 
 	// Check that the nonce is a legal nonce.
-	if height >= types.ASICHardforkHeight && binary.LittleEndian.Uint64(b.Nonce[:])%types.ASICHardforkFactor != 0 {
+	if forkRuleASIC.activeAtHeight(height) && binary.LittleEndian.Uint64(b.Nonce[:])%types.ASICHardforkFactor != 0 {
 		return errors.New("block does not meet nonce requirements")
 	}
 	// Check that the target of the new block is sufficient.
@@ -106,6 +109,12 @@ func (bv stdBlockValidator) ValidateBlock(b types.Block, id types.BlockID, minTi
 		return ErrBadMinerPayouts
 	}
 
+	// Only the miner may signal fork rules, so signals are only allowed in
+	// the first transaction.
+	if b.MisplacedForkSignals() {
+		return ErrMisplacedForkSignal
+	}
+
 	// Check if the block is in the near future, but too far to be acceptable.
 	// This is the last check because it's an expensive check, and not worth
 	// performing if the payouts are incorrect.
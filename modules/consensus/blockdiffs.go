@@ -0,0 +1,48 @@
+package consensus
+
+import (
+	"errors"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errBlockDiffsHeight is returned if block diffs are requested for a height
+// above the current height of the consensus set.
+var errBlockDiffsHeight = errors.New("block diffs requested for a height above the current height")
+
+// BlockDiffs returns the diffs of the block at the provided height of the
+// current path.
+func (cs *ConsensusSet) BlockDiffs(height types.BlockHeight) (diffs modules.ConsensusBlockDiffs, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusBlockDiffs{}, err
+	}
+	defer cs.tg.Done()
+
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		if height > blockHeight(tx) {
+			return errBlockDiffsHeight
+		}
+		id, err := getPath(tx, height)
+		if err != nil {
+			return err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		diffs = modules.ConsensusBlockDiffs{
+			Height:               height,
+			BlockID:              id,
+			ConsensusChangeDiffs: computeConsensusChangeDiffs(pb, true),
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.ConsensusBlockDiffs{}, err
+	}
+	return diffs, nil
+}
@@ -0,0 +1,71 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBlockDiffs checks that BlockDiffs returns the diffs of blocks in the
+// current path.
+func TestBlockDiffs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block containing a transaction which spends and creates siacoin
+	// outputs.
+	_, err = cst.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	height := cst.cs.Height()
+	diffs, err := cst.cs.BlockDiffs(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs.Height != height || diffs.BlockID != b.ID() {
+		t.Fatal("wrong block", diffs.Height, height)
+	}
+	pb, err := cst.cs.dbGetBlockMap(b.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs.SiacoinOutputDiffs) != len(pb.SiacoinOutputDiffs) || len(diffs.DelayedSiacoinOutputDiffs) != len(pb.DelayedSiacoinOutputDiffs) {
+		t.Fatal("wrong number of diffs")
+	}
+	var created, spent bool
+	for _, d := range diffs.SiacoinOutputDiffs {
+		created = created || d.Direction == modules.DiffApply
+		spent = spent || d.Direction == modules.DiffRevert
+	}
+	if !created || !spent {
+		t.Fatal("expected the transaction to spend and create siacoin outputs")
+	}
+	if len(diffs.DelayedSiacoinOutputDiffs) == 0 {
+		t.Fatal("expected a delayed siacoin output for the miner payout")
+	}
+
+	// Heights above the current height are rejected.
+	if _, err := cst.cs.BlockDiffs(height + 1); !errors.Contains(err, errBlockDiffsHeight) {
+		t.Fatal("expected errBlockDiffsHeight", err)
+	}
+}
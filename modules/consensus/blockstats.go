@@ -0,0 +1,171 @@
+package consensus
+
+import (
+	"errors"
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxBlockStatsWindow is the maximum number of blocks the statistics can
+	// be requested for at once. It corresponds to roughly one week of blocks.
+	maxBlockStatsWindow = 1008
+)
+
+var (
+	// errBlockStatsHeight is returned if block statistics are requested for
+	// a height above the current height of the consensus set.
+	errBlockStatsHeight = errors.New("block statistics requested for a height above the current height")
+
+	// errBlockStatsWindow is returned if block statistics are requested for
+	// an empty window or a window which exceeds maxBlockStatsWindow.
+	errBlockStatsWindow = errors.New("block statistics window must contain between 1 and 1008 blocks")
+)
+
+// blockStatsBuilder accumulates the statistics of a range of blocks.
+type blockStatsBuilder struct {
+	stats     modules.ConsensusBlockStats
+	feeRates  []types.Currency
+	hasBlocks bool
+}
+
+// isContractTransaction returns whether the transaction forms, revises or
+// proves a file contract.
+func isContractTransaction(txn types.Transaction) bool {
+	return len(txn.FileContracts) > 0 || len(txn.FileContractRevisions) > 0 || len(txn.StorageProofs) > 0
+}
+
+// feePercentiles returns the percentiles of the provided fee rates. The fee
+// rates are sorted in place.
+func feePercentiles(rates []types.Currency) (fp modules.ConsensusFeePercentiles) {
+	if len(rates) == 0 {
+		return
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		return rates[i].Cmp(rates[j]) < 0
+	})
+	// Use the nearest-rank method.
+	percentile := func(p int) types.Currency {
+		rank := (p*len(rates) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return rates[rank-1]
+	}
+	fp.P10 = percentile(10)
+	fp.P25 = percentile(25)
+	fp.P50 = percentile(50)
+	fp.P75 = percentile(75)
+	fp.P90 = percentile(90)
+	return
+}
+
+// addBlock adds the block at the provided height to the statistics.
+func (bsb *blockStatsBuilder) addBlock(b types.Block, height types.BlockHeight) {
+	if !bsb.hasBlocks || height < bsb.stats.StartHeight {
+		bsb.stats.StartHeight = height
+	}
+	if !bsb.hasBlocks || height > bsb.stats.EndHeight {
+		bsb.stats.EndHeight = height
+	}
+	bsb.hasBlocks = true
+
+	bsb.stats.Coinbase = bsb.stats.Coinbase.Add(types.CalculateCoinbase(height))
+	for _, txn := range b.Transactions {
+		var fees types.Currency
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+		size := uint64(txn.MarshalSiaSize())
+		bsb.stats.Fees = bsb.stats.Fees.Add(fees)
+		if isContractTransaction(txn) {
+			bsb.stats.ContractFees = bsb.stats.ContractFees.Add(fees)
+		}
+		bsb.stats.Transactions++
+		bsb.stats.TransactionBytes += size
+		bsb.feeRates = append(bsb.feeRates, fees.Div64(size))
+	}
+}
+
+// addStats merges the statistics accumulated by another builder.
+func (bsb *blockStatsBuilder) addStats(other *blockStatsBuilder) {
+	if !other.hasBlocks {
+		return
+	}
+	if !bsb.hasBlocks || other.stats.StartHeight < bsb.stats.StartHeight {
+		bsb.stats.StartHeight = other.stats.StartHeight
+	}
+	if !bsb.hasBlocks || other.stats.EndHeight > bsb.stats.EndHeight {
+		bsb.stats.EndHeight = other.stats.EndHeight
+	}
+	bsb.hasBlocks = true
+
+	bsb.stats.Coinbase = bsb.stats.Coinbase.Add(other.stats.Coinbase)
+	bsb.stats.Fees = bsb.stats.Fees.Add(other.stats.Fees)
+	bsb.stats.ContractFees = bsb.stats.ContractFees.Add(other.stats.ContractFees)
+	bsb.stats.Transactions += other.stats.Transactions
+	bsb.stats.TransactionBytes += other.stats.TransactionBytes
+	bsb.feeRates = append(bsb.feeRates, other.feeRates...)
+}
+
+// finalize computes the derived statistics and returns the result.
+func (bsb *blockStatsBuilder) finalize() modules.ConsensusBlockStats {
+	stats := bsb.stats
+	if !stats.Fees.IsZero() {
+		stats.ContractFeeShare, _ = stats.ContractFees.Float64()
+		fees, _ := stats.Fees.Float64()
+		stats.ContractFeeShare /= fees
+	}
+	rates := append([]types.Currency(nil), bsb.feeRates...)
+	stats.FeePerByte = feePercentiles(rates)
+	return stats
+}
+
+// BlockStats returns the subsidy and fee statistics of the window blocks of
+// the current path which end at the provided height.
+func (cs *ConsensusSet) BlockStats(height, window types.BlockHeight) (stats modules.ConsensusStats, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusStats{}, err
+	}
+	defer cs.tg.Done()
+
+	if window == 0 || window > maxBlockStatsWindow {
+		return modules.ConsensusStats{}, errBlockStatsWindow
+	}
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		if height > blockHeight(tx) {
+			return errBlockStatsHeight
+		}
+		start := types.BlockHeight(0)
+		if height+1 > window {
+			start = height + 1 - window
+		}
+		var total blockStatsBuilder
+		stats.Blocks = make([]modules.ConsensusBlockStats, 0, height-start+1)
+		for h := start; h <= height; h++ {
+			id, err := getPath(tx, h)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			var bsb blockStatsBuilder
+			bsb.addBlock(pb.Block, h)
+			stats.Blocks = append(stats.Blocks, bsb.finalize())
+			total.addStats(&bsb)
+		}
+		stats.Window = total.finalize()
+		return nil
+	})
+	if err != nil {
+		return modules.ConsensusStats{}, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,126 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestFeePercentiles probes the nearest-rank percentiles of feePercentiles.
+func TestFeePercentiles(t *testing.T) {
+	// No fee rates result in zero percentiles.
+	fp := feePercentiles(nil)
+	if !fp.P10.IsZero() || !fp.P90.IsZero() {
+		t.Fatal("expected zero percentiles", fp)
+	}
+
+	// Fee rates 1 to 10 in reverse order.
+	var rates []types.Currency
+	for i := 10; i > 0; i-- {
+		rates = append(rates, types.NewCurrency64(uint64(i)))
+	}
+	fp = feePercentiles(rates)
+	expected := []uint64{1, 3, 5, 8, 9}
+	for i, c := range []types.Currency{fp.P10, fp.P25, fp.P50, fp.P75, fp.P90} {
+		if !c.Equals64(expected[i]) {
+			t.Fatalf("percentile %v: expected %v but was %v", i, expected[i], c)
+		}
+	}
+}
+
+// TestBlockStats checks that BlockStats reports the coinbase and fees of the
+// blocks in the current path.
+func TestBlockStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block containing a transaction which pays a fee.
+	_, err = cst.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fees types.Currency
+	var txnBytes uint64
+	for _, txn := range b.Transactions {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+		txnBytes += uint64(txn.MarshalSiaSize())
+	}
+	if fees.IsZero() {
+		t.Fatal("expected block to contain fees")
+	}
+	height := cst.cs.Height()
+
+	// Check the statistics of the last block.
+	stats, err := cst.cs.BlockStats(height, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Blocks) != 1 {
+		t.Fatalf("expected 1 block but got %v", len(stats.Blocks))
+	}
+	bs := stats.Blocks[0]
+	if bs.StartHeight != height || bs.EndHeight != height {
+		t.Fatal("wrong heights", bs.StartHeight, bs.EndHeight)
+	}
+	if !bs.Coinbase.Equals(types.CalculateCoinbase(height)) {
+		t.Fatal("wrong coinbase", bs.Coinbase)
+	}
+	if !bs.Fees.Equals(fees) || bs.TransactionBytes != txnBytes {
+		t.Fatal("wrong fees", bs.Fees, fees, bs.TransactionBytes, txnBytes)
+	}
+	if !bs.ContractFees.IsZero() || bs.ContractFeeShare != 0 {
+		t.Fatal("expected no contract fees", bs.ContractFees)
+	}
+	if bs.FeePerByte.P90.IsZero() {
+		t.Fatal("expected non-zero fee per byte")
+	}
+
+	// The window should cover all blocks including the genesis block.
+	stats, err = cst.cs.BlockStats(height, height+10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types.BlockHeight(len(stats.Blocks)) != height+1 {
+		t.Fatalf("expected %v blocks but got %v", height+1, len(stats.Blocks))
+	}
+	var coinbase types.Currency
+	for h := types.BlockHeight(0); h <= height; h++ {
+		coinbase = coinbase.Add(types.CalculateCoinbase(h))
+	}
+	if stats.Window.StartHeight != 0 || stats.Window.EndHeight != height {
+		t.Fatal("wrong window heights", stats.Window.StartHeight, stats.Window.EndHeight)
+	}
+	if !stats.Window.Coinbase.Equals(coinbase) || !stats.Window.Fees.Equals(fees) {
+		t.Fatal("wrong window statistics", stats.Window.Coinbase, stats.Window.Fees)
+	}
+
+	// Invalid heights and windows are rejected.
+	if _, err := cst.cs.BlockStats(height+1, 1); !errors.Contains(err, errBlockStatsHeight) {
+		t.Fatal("expected errBlockStatsHeight", err)
+	}
+	if _, err := cst.cs.BlockStats(height, 0); !errors.Contains(err, errBlockStatsWindow) {
+		t.Fatal("expected errBlockStatsWindow", err)
+	}
+	if _, err := cst.cs.BlockStats(height, maxBlockStatsWindow+1); !errors.Contains(err, errBlockStatsWindow) {
+		t.Fatal("expected errBlockStatsWindow", err)
+	}
+}
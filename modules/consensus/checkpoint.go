@@ -0,0 +1,467 @@
+package consensus
+
+// checkpoint.go contains the trusted-checkpoint sync path. Instead of
+// downloading and validating every block since the genesis block, a new node
+// can start from a snapshot of the consensus database written by SnapshotDB.
+// The snapshot is only used if its header chain links back to the genesis
+// block, the difficulty of every block of the path is correct and its
+// consensus state matches the checkpoint supplied by the user. Everything in
+// the snapshot which isn't covered by the checkpoint is removed or rebuilt
+// before the snapshot is used. The blocks after the checkpoint are downloaded
+// and validated as usual.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errNoCheckpoint is returned if a snapshot should be used without a
+	// checkpoint to verify it against.
+	errNoCheckpoint = errors.New("a checkpoint is required to sync from a snapshot")
+
+	// errSnapshotDifficulty is returned if the depth, child target or Oak
+	// totals of a block of a snapshot's path are wrong.
+	errSnapshotDifficulty = errors.New("snapshot difficulty is invalid")
+
+	// errSnapshotHeaderChain is returned if the header chain of a snapshot
+	// doesn't link back to the genesis block.
+	errSnapshotHeaderChain = errors.New("snapshot header chain is invalid")
+
+	// errSnapshotMismatch is returned if a snapshot doesn't match the
+	// checkpoint it is verified against.
+	errSnapshotMismatch = errors.New("snapshot doesn't match checkpoint")
+
+	// errSnapshotTooLarge is returned if a downloaded snapshot exceeds
+	// snapshotMaxSize.
+	errSnapshotTooLarge = errors.New("snapshot exceeds the maximum size")
+)
+
+var (
+	// snapshotDialTimeout is the timeout for connecting to the server of a
+	// snapshot.
+	snapshotDialTimeout = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// snapshotResponseTimeout is the time the server of a snapshot has to send
+	// the response headers.
+	snapshotResponseTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// snapshotMaxSize is the maximum size of a downloaded snapshot.
+	snapshotMaxSize = build.Select(build.Var{
+		Dev:      int64(1 << 30),  // 1 GiB
+		Standard: int64(64 << 30), // 64 GiB
+		Testing:  int64(1 << 20),  // 1 MiB
+	}).(int64)
+
+	// snapshotClient is the http client used to download snapshots. There is
+	// no overall timeout since downloading a snapshot can take a long time.
+	snapshotClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: snapshotDialTimeout}).DialContext,
+			TLSHandshakeTimeout:   snapshotDialTimeout,
+			ResponseHeaderTimeout: snapshotResponseTimeout,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+)
+
+// checkpointBuckets are the buckets which need to exist in a snapshot to
+// compute its checkpoint.
+var checkpointBuckets = [][]byte{
+	BlockHeight,
+	BlockMap,
+	BlockPath,
+	BucketOak,
+	FileContracts,
+	FoundationUnlockHashes,
+	SiacoinOutputs,
+	SiafundOutputs,
+	SiafundPool,
+}
+
+// snapshotMetadata is the bucket which contains the metadata of the database.
+// It is checked when the snapshot is opened.
+var snapshotMetadata = []byte("Metadata")
+
+// pushCheckpointBlock adds a processed block of the path to the tree of
+// processed blocks which is part of the state hash of a checkpoint. The
+// consensus checksum is only set in debug builds, so it's left out.
+func pushCheckpointBlock(tree *crypto.MerkleTree, pb processedBlock) {
+	pb.ConsensusChecksum = crypto.Hash{}
+	tree.Push(encoding.Marshal(pb))
+}
+
+// checkpointStateHash returns the state hash of a checkpoint, which commits to
+// the consensus checksum and the root of the tree of processed blocks of the
+// path. The processed blocks contain the diffs and child targets of the path.
+func checkpointStateHash(tx *bolt.Tx, blocksRoot crypto.Hash) crypto.Hash {
+	return crypto.HashAll(consensusChecksum(tx), blocksRoot)
+}
+
+// dbCheckpoint returns the checkpoint of the current state of the database.
+func dbCheckpoint(tx *bolt.Tx) modules.ConsensusCheckpoint {
+	height := blockHeight(tx)
+	tree := crypto.NewTree()
+	for h := types.BlockHeight(0); h <= height; h++ {
+		id, err := getPath(tx, h)
+		if err != nil {
+			manageErr(tx, err)
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			manageErr(tx, err)
+		}
+		pushCheckpointBlock(tree, *pb)
+	}
+	return modules.ConsensusCheckpoint{
+		Height:    height,
+		BlockID:   currentBlockID(tx),
+		StateHash: checkpointStateHash(tx, tree.Root()),
+	}
+}
+
+// snapshotChildTarget returns the target of the children of pb before the Oak
+// hardfork. timestamps contains the timestamps of the path up to the parent of
+// pb. See ConsensusSet.setChildTarget.
+func snapshotChildTarget(timestamps []types.Timestamp, pb, parent *processedBlock) types.Target {
+	if pb.Height%(types.TargetWindow/2) != 0 {
+		return parent.ChildTarget
+	}
+
+	// Use the timestamp of the block which was generated 'TargetWindow'
+	// blocks prior to the parent, stopping at the genesis block. See
+	// targetAdjustmentBase.
+	windowSize := types.TargetWindow
+	if pb.Height < windowSize {
+		windowSize = pb.Height
+	}
+	timePassed := pb.Block.Timestamp - timestamps[pb.Height-windowSize]
+	expectedTimePassed := types.BlockFrequency * windowSize
+	adjustment := clampTargetAdjustment(big.NewRat(int64(timePassed), int64(expectedTimePassed)))
+	return types.RatToTarget(new(big.Rat).Mul(parent.ChildTarget.Rat(), adjustment))
+}
+
+// verifySnapshotTx checks that the header chain of a snapshot links back to
+// the genesis block and that the proof of work, depth, child target and Oak
+// totals of every block of the path are correct. It returns the checkpoint of
+// the snapshot. The snapshot isn't trusted yet, so decoding errors are
+// returned instead of panicking.
+func verifySnapshotTx(tx *bolt.Tx) (modules.ConsensusCheckpoint, error) {
+	for _, name := range checkpointBuckets {
+		if tx.Bucket(name) == nil {
+			return modules.ConsensusCheckpoint{}, fmt.Errorf("snapshot is missing bucket %s", name)
+		}
+	}
+	var height types.BlockHeight
+	if err := encoding.Unmarshal(tx.Bucket(BlockHeight).Get(BlockHeight), &height); err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to decode snapshot height")
+	}
+
+	// Every block of the path has to be stored under its own ID, be the
+	// child of the previous block and start at the genesis block. The block
+	// ID commits to the block's transactions, so this verifies the full
+	// history without validating it. The difficulty is recomputed like it is
+	// computed by newChild, so the checkpoint only needs to vouch for the
+	// diffs of the path.
+	var id types.BlockID
+	var parent processedBlock
+	var totalTime int64
+	var totalTarget types.Target
+	timestamps := make([]types.Timestamp, 0, height+1)
+	tree := crypto.NewTree()
+	for h := types.BlockHeight(0); h <= height; h++ {
+		idBytes := tx.Bucket(BlockPath).Get(encoding.Marshal(h))
+		if err := encoding.Unmarshal(idBytes, &id); err != nil {
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotHeaderChain, fmt.Errorf("unable to decode path at height %v: %v", h, err))
+		}
+		var pb processedBlock
+		if err := encoding.Unmarshal(tx.Bucket(BlockMap).Get(id[:]), &pb); err != nil {
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotHeaderChain, fmt.Errorf("unable to decode block at height %v: %v", h, err))
+		}
+		switch {
+		case pb.Block.ID() != id || pb.Height != h:
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotHeaderChain, fmt.Errorf("block at height %v doesn't match the path", h))
+		case h == 0 && id != types.GenesisID:
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotHeaderChain, errors.New("snapshot has wrong genesis block"))
+		case h > 0 && pb.Block.ParentID != parent.Block.ID():
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotHeaderChain, fmt.Errorf("block at height %v isn't the child of the previous block", h))
+		case h > 0 && !checkHeaderTarget(pb.Block.Header(), parent.ChildTarget):
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotHeaderChain, fmt.Errorf("block at height %v doesn't meet its target", h))
+		case h > 0 && forkRuleASIC.activeAtHeight(h) && binary.LittleEndian.Uint64(pb.Block.Nonce[:])%types.ASICHardforkFactor != 0:
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotHeaderChain, fmt.Errorf("block at height %v has an invalid nonce", h))
+		}
+
+		// Compute the depth, child target and Oak totals of the block.
+		var depth, childTarget types.Target
+		if h == 0 {
+			depth, childTarget = types.RootDepth, types.RootTarget
+			totalTime, totalTarget = blockTotals(0, 0, types.GenesisTimestamp, types.GenesisTimestamp, types.RootDepth, types.RootTarget)
+		} else {
+			depth = parent.childDepth()
+			if !forkRuleOak.activeAtHeight(parent.Height) {
+				childTarget = snapshotChildTarget(timestamps, &pb, &parent)
+			} else {
+				childTarget = childTargetOak(totalTime, totalTarget, parent.ChildTarget, parent.Height, parent.Block.Timestamp)
+			}
+			totalTime, totalTarget = blockTotals(h, totalTime, parent.Block.Timestamp, pb.Block.Timestamp, totalTarget, parent.ChildTarget)
+		}
+		totals := tx.Bucket(BucketOak).Get(id[:])
+		switch {
+		case pb.Depth != depth:
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotDifficulty, fmt.Errorf("block at height %v has the wrong depth", h))
+		case pb.ChildTarget != childTarget:
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotDifficulty, fmt.Errorf("block at height %v has the wrong child target", h))
+		case len(totals) != 40 || int64(binary.LittleEndian.Uint64(totals[:8])) != totalTime || !bytes.Equal(totals[8:], totalTarget[:]):
+			return modules.ConsensusCheckpoint{}, errors.Compose(errSnapshotDifficulty, fmt.Errorf("block at height %v has the wrong Oak totals", h))
+		}
+		pushCheckpointBlock(tree, pb)
+		timestamps = append(timestamps, pb.Block.Timestamp)
+		parent = pb
+	}
+	return modules.ConsensusCheckpoint{
+		Height:    height,
+		BlockID:   id,
+		StateHash: checkpointStateHash(tx, tree.Root()),
+	}, nil
+}
+
+// pruneSnapshotTx removes everything from a verified snapshot which isn't
+// covered by its checkpoint. Blocks which aren't part of the path and their
+// Oak totals are deleted, the changelog is rebuilt from the path and all other
+// buckets are dropped so that they are rebuilt when the database is loaded.
+func pruneSnapshotTx(tx *bolt.Tx, height types.BlockHeight) error {
+	path := make(map[types.BlockID]struct{}, height+1)
+	ids := make([]types.BlockID, 0, height+1)
+	for h := types.BlockHeight(0); h <= height; h++ {
+		id, err := getPath(tx, h)
+		if err != nil {
+			return err
+		}
+		path[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	// Delete the blocks and Oak totals of all blocks which aren't part of
+	// the path. The Oak totals of the path were verified.
+	for _, name := range [][]byte{BlockMap, BucketOak} {
+		b := tx.Bucket(name)
+		var stale [][]byte
+		err := b.ForEach(func(k, _ []byte) error {
+			var id types.BlockID
+			copy(id[:], k)
+			if _, exists := path[id]; !exists || len(k) != len(id) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Bucket(BucketOak).Put(FieldOakInit, ValueOakInit); err != nil {
+		return err
+	}
+
+	// Drop every bucket which isn't covered by the checkpoint.
+	var drop [][]byte
+	err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if bytes.HasPrefix(name, prefixDSCO) || bytes.HasPrefix(name, prefixFCEX) || bytes.Equal(name, snapshotMetadata) {
+			return nil
+		}
+		for _, keep := range checkpointBuckets {
+			if bytes.Equal(name, keep) {
+				return nil
+			}
+		}
+		drop = append(drop, append([]byte(nil), name...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range drop {
+		if err := tx.DeleteBucket(name); err != nil {
+			return err
+		}
+	}
+
+	// Recreate the consistency flag and the changelog. Every block of the
+	// path gets its own entry. See createChangeLog.
+	consistency, err := tx.CreateBucket(Consistency)
+	if err != nil {
+		return err
+	}
+	if err := consistency.Put(Consistency, encoding.Marshal(false)); err != nil {
+		return err
+	}
+	cl, err := tx.CreateBucket(ChangeLog)
+	if err != nil {
+		return err
+	}
+	ge := changeEntry{AppliedBlocks: []types.BlockID{ids[0]}}
+	geid := ge.ID()
+	if err := cl.Put(geid[:], encoding.Marshal(changeNode{Entry: ge})); err != nil {
+		return err
+	}
+	if err := cl.Put(ChangeLogTailID, geid[:]); err != nil {
+		return err
+	}
+	for _, id := range ids[1:] {
+		if err := appendChangeLog(tx, changeEntry{AppliedBlocks: []types.BlockID{id}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySnapshot checks that the snapshot at the provided path matches the
+// checkpoint and prunes everything which isn't covered by the checkpoint from
+// it.
+func verifySnapshot(filename string, checkpoint modules.ConsensusCheckpoint) (err error) {
+	db, err := persist.OpenDatabase(dbMetadata, filename)
+	if err != nil {
+		return errors.AddContext(err, "unable to open snapshot")
+	}
+	defer func() {
+		err = errors.Compose(err, db.Close())
+	}()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		cp, err := verifySnapshotTx(tx)
+		if err != nil {
+			return err
+		}
+		if cp != checkpoint {
+			return errors.Compose(errSnapshotMismatch, fmt.Errorf("snapshot is at %v, expected %v", cp, checkpoint))
+		}
+		return errors.AddContext(pruneSnapshotTx(tx, cp.Height), "unable to prune snapshot")
+	})
+}
+
+// fetchSnapshot copies the snapshot from source to dst. source is either an
+// http(s) URL or the path of a local file. Downloaded snapshots may not exceed
+// snapshotMaxSize.
+func fetchSnapshot(source, dst string) (err error) {
+	var r io.ReadCloser
+	limit := int64(-1)
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := snapshotClient.Get(source)
+		if err != nil {
+			return errors.AddContext(err, "unable to download snapshot")
+		}
+		if resp.StatusCode != http.StatusOK {
+			return errors.Compose(fmt.Errorf("unable to download snapshot: %v", resp.Status), resp.Body.Close())
+		}
+		if resp.ContentLength > snapshotMaxSize {
+			return errors.Compose(errSnapshotTooLarge, resp.Body.Close())
+		}
+		r = resp.Body
+		limit = snapshotMaxSize
+	} else {
+		r, err = os.Open(source)
+		if err != nil {
+			return errors.AddContext(err, "unable to open snapshot")
+		}
+	}
+	defer func() {
+		err = errors.Compose(err, r.Close())
+	}()
+
+	f, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, snapshotFilePermissions)
+	if err != nil {
+		return err
+	}
+	var src io.Reader = r
+	if limit >= 0 {
+		// Read one more byte than allowed to detect oversized snapshots.
+		src = io.LimitReader(r, limit+1)
+	}
+	n, err := io.Copy(f, src)
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to copy snapshot"), f.Close())
+	}
+	if limit >= 0 && n > limit {
+		return errors.Compose(errSnapshotTooLarge, f.Close())
+	}
+	return errors.Compose(f.Sync(), f.Close())
+}
+
+// bootstrapFromSnapshot initializes the consensus database in persistDir
+// with the snapshot at source after verifying it against the checkpoint. An
+// existing database is never replaced.
+func bootstrapFromSnapshot(persistDir, source string, checkpoint *modules.ConsensusCheckpoint) (err error) {
+	if checkpoint == nil {
+		return errNoCheckpoint
+	}
+	dbFilename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(dbFilename); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(persistDir, 0700); err != nil {
+		return err
+	}
+
+	tmp := dbFilename + snapshotTempSuffix
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, os.RemoveAll(tmp))
+		}
+	}()
+	if err := fetchSnapshot(source, tmp); err != nil {
+		return err
+	}
+	if err := verifySnapshot(tmp, *checkpoint); err != nil {
+		return errors.AddContext(err, "unable to verify snapshot")
+	}
+	return os.Rename(tmp, dbFilename)
+}
+
+// NewCustomConsensusSetFromSnapshot returns a new ConsensusSet like
+// NewCustomConsensusSet. If there is no block database in the persist
+// directory yet, the snapshot at source is verified against the checkpoint and
+// used as the database, which skips validating the blocks up to the
+// checkpoint. source is either an http(s) URL or the path of a local file. The
+// checkpoint is required, it should be obtained from a trusted node.
+func NewCustomConsensusSetFromSnapshot(gateway modules.Gateway, bootstrap bool, persistDir string, deps modules.Dependencies, source string, checkpoint *modules.ConsensusCheckpoint) (*ConsensusSet, <-chan error) {
+	if err := bootstrapFromSnapshot(persistDir, source, checkpoint); err != nil {
+		errChan := make(chan error, 1)
+		errChan <- errors.AddContext(err, "unable to sync from snapshot")
+		return nil, errChan
+	}
+	return NewCustomConsensusSet(gateway, bootstrap, persistDir, deps)
+}
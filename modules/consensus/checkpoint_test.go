@@ -0,0 +1,245 @@
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestCheckpointSync checks that a new consensus set can be initialized with a
+// snapshot which matches its checkpoint and that mismatching snapshots are
+// rejected.
+func TestCheckpointSync(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Write a snapshot.
+	snapshot := filepath.Join(cst.persistDir, "snapshot.db")
+	cp, err := cst.cs.SnapshotDB(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Height != cst.cs.Height() || cp.BlockID != cst.cs.CurrentBlock().ID() {
+		t.Fatal("wrong checkpoint", cp)
+	}
+
+	// Without a matching checkpoint the snapshot is rejected.
+	if err := bootstrapFromSnapshot(filepath.Join(cst.persistDir, "nocheckpoint"), snapshot, nil); !errors.Contains(err, errNoCheckpoint) {
+		t.Fatal("expected errNoCheckpoint", err)
+	}
+	wrongCP := cp
+	wrongCP.StateHash[0]++
+	if err := verifySnapshot(snapshot, wrongCP); !errors.Contains(err, errSnapshotMismatch) {
+		t.Fatal("expected errSnapshotMismatch", err)
+	}
+
+	// tamper writes a snapshot and modifies it with fn.
+	tamper := func(name string, fn func(tx *bolt.Tx) error) string {
+		filename := filepath.Join(cst.persistDir, name+".db")
+		if _, err := cst.cs.SnapshotDB(filename); err != nil {
+			t.Fatal(err)
+		}
+		db, err := persist.OpenDatabase(dbMetadata, filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := errors.Compose(db.Update(fn), db.Close()); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	// updateCurrentBlock modifies the current block of a snapshot with fn.
+	updateCurrentBlock := func(fn func(pb *processedBlock)) func(tx *bolt.Tx) error {
+		return func(tx *bolt.Tx) error {
+			pb := currentProcessedBlock(tx)
+			fn(pb)
+			id := pb.Block.ID()
+			return tx.Bucket(BlockMap).Put(id[:], encoding.Marshal(*pb))
+		}
+	}
+
+	// A snapshot with a modified state is rejected.
+	tampered := tamper("tampered", func(tx *bolt.Tx) error {
+		b := tx.Bucket(SiacoinOutputs)
+		k, _ := b.Cursor().First()
+		return b.Delete(k)
+	})
+	if err := verifySnapshot(tampered, cp); !errors.Contains(err, errSnapshotMismatch) {
+		t.Fatal("expected errSnapshotMismatch", err)
+	}
+
+	// A snapshot with modified diffs is rejected.
+	diffs := tamper("diffs", updateCurrentBlock(func(pb *processedBlock) {
+		pb.SiacoinOutputDiffs = pb.SiacoinOutputDiffs[1:]
+	}))
+	if err := verifySnapshot(diffs, cp); !errors.Contains(err, errSnapshotMismatch) {
+		t.Fatal("expected errSnapshotMismatch", err)
+	}
+
+	// A snapshot with a modified child target or modified Oak totals is
+	// rejected.
+	target := tamper("target", updateCurrentBlock(func(pb *processedBlock) {
+		pb.ChildTarget[0]++
+	}))
+	if err := verifySnapshot(target, cp); !errors.Contains(err, errSnapshotDifficulty) {
+		t.Fatal("expected errSnapshotDifficulty", err)
+	}
+	totals := tamper("totals", func(tx *bolt.Tx) error {
+		id := currentBlockID(tx)
+		v := append([]byte(nil), tx.Bucket(BucketOak).Get(id[:])...)
+		v[0]++
+		return tx.Bucket(BucketOak).Put(id[:], v)
+	})
+	if err := verifySnapshot(totals, cp); !errors.Contains(err, errSnapshotDifficulty) {
+		t.Fatal("expected errSnapshotDifficulty", err)
+	}
+
+	// Everything which isn't covered by the checkpoint is removed from a
+	// verified snapshot.
+	var sideID types.BlockID
+	sideID[0] = 1
+	extra := tamper("extra", func(tx *bolt.Tx) error {
+		if err := tx.Bucket(BlockMap).Put(sideID[:], encoding.Marshal(processedBlock{})); err != nil {
+			return err
+		}
+		if err := tx.Bucket(BucketOak).Put(sideID[:], make([]byte, 40)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte("Extra"))
+		return err
+	})
+	if err := verifySnapshot(extra, cp); err != nil {
+		t.Fatal(err)
+	}
+	db, err := persist.OpenDatabaseReadOnly(dbMetadata, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(BlockMap).Get(sideID[:]) != nil || tx.Bucket(BucketOak).Get(sideID[:]) != nil {
+			return errors.New("side block wasn't removed")
+		}
+		if tx.Bucket([]byte("Extra")) != nil || tx.Bucket(StateCommitmentTree) != nil {
+			return errors.New("bucket wasn't dropped")
+		}
+		var entries types.BlockHeight
+		for ce, exists := getEntry(tx, (&changeEntry{AppliedBlocks: []types.BlockID{types.GenesisID}}).ID()); exists; ce, exists = ce.NextEntry(tx) {
+			entries++
+		}
+		if entries != cp.Height+1 {
+			return fmt.Errorf("changelog has %v entries, expected %v", entries, cp.Height+1)
+		}
+		return nil
+	})
+	if err := errors.Compose(err, db.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a new consensus set from the snapshot.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-snapshot")
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	persistDir := filepath.Join(testdir, modules.ConsensusDir)
+	cs, errChan := NewCustomConsensusSetFromSnapshot(g, false, persistDir, modules.ProdDependencies, snapshot, &cp)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if cs.Height() != cp.Height || cs.CurrentBlock().ID() != cp.BlockID {
+		t.Fatal("consensus set wasn't initialized from the snapshot", cs.Height(), cp.Height)
+	}
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// An existing database is never replaced.
+	if err := bootstrapFromSnapshot(persistDir, tampered, &wrongCP); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestFetchSnapshot probes downloading snapshots with fetchSnapshot.
+func TestFetchSnapshot(t *testing.T) {
+	t.Parallel()
+	dir := build.TempDir(modules.ConsensusDir, t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server returns a snapshot of the requested size. It only sets the
+	// content length if requested.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		size, err := strconv.Atoi(req.FormValue("size"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.FormValue("length") == "true" {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+		}
+		w.Write(bytes.Repeat([]byte{1}, size))
+	}))
+	defer srv.Close()
+	url := func(size int64, length bool) string {
+		return fmt.Sprintf("%v/snapshot?size=%v&length=%v", srv.URL, size, length)
+	}
+	dst := filepath.Join(dir, "snapshot.db")
+
+	// A snapshot within the size limit is downloaded.
+	if err := fetchSnapshot(url(snapshotMaxSize, true), dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(data)) != snapshotMaxSize {
+		t.Fatal("wrong size", len(data))
+	}
+
+	// Larger snapshots are rejected, with and without a content length.
+	if err := fetchSnapshot(url(snapshotMaxSize+1, true), dst); !errors.Contains(err, errSnapshotTooLarge) {
+		t.Fatal("expected errSnapshotTooLarge", err)
+	}
+	if err := fetchSnapshot(url(snapshotMaxSize+1, false), dst); !errors.Contains(err, errSnapshotTooLarge) {
+		t.Fatal("expected errSnapshotTooLarge", err)
+	}
+
+	// Failed requests are reported.
+	if err := fetchSnapshot(srv.URL+"/snapshot", dst); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,258 @@
+package consensus
+
+// commitment.go contains the state commitment, a Merkle root over the siacoin
+// output, siafund output and file contract sets which is computed for every
+// block. Nodes and light clients can compare the commitments of a block to
+// check that they share the same state without exchanging the sets.
+//
+// Every set is divided into 65536 leaves by the first two bytes of the IDs of
+// its elements. The hash of a leaf is the Merkle root of the IDs and the
+// encoded elements of the leaf in the order of their IDs. 256 leaves which
+// share the first byte form a node, and the 256 nodes form the root of the
+// set. A leaf or node without any elements is represented by the zero hash.
+// The commitment is the Merkle root of the roots of the siacoin output,
+// siafund output and file contract sets. The hashes of the leaves and nodes
+// are stored in the database, so only the leaves which were modified by a
+// block need to be recomputed.
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// StateCommitmentDirty is a database bucket containing the keys of the
+	// leaves of the state commitment tree which were modified since the
+	// commitment was last updated.
+	StateCommitmentDirty = []byte("StateCommitmentDirty")
+
+	// StateCommitmentTree is a database bucket containing the hashes of the
+	// leaves and nodes of the state commitment tree. The key of a leaf is the
+	// index of its set followed by the first two bytes of the IDs of its
+	// elements, the key of a node is the index of its set followed by the
+	// first byte and the key of the root of a set is the index of the set.
+	StateCommitmentTree = []byte("StateCommitmentTree")
+
+	// StateCommitments is a database bucket containing the state commitment
+	// after every applied block, keyed by the block ID.
+	StateCommitments = []byte("StateCommitments")
+)
+
+// Indices of the sets of the state commitment.
+const (
+	commitmentSetSiacoinOutputs byte = iota
+	commitmentSetSiafundOutputs
+	commitmentSetFileContracts
+)
+
+// stateCommitmentSets are the buckets of the sets which are part of the state
+// commitment, in the order of their indices.
+var stateCommitmentSets = [][]byte{
+	SiacoinOutputs,
+	SiafundOutputs,
+	FileContracts,
+}
+
+// markStateCommitmentDirty marks the leaf of the state commitment tree which
+// contains the element with the provided id as modified. Databases without a
+// state commitment tree, like the scratch databases of the verification,
+// don't track modifications.
+func markStateCommitmentDirty(tx *bolt.Tx, set byte, id []byte) {
+	b := tx.Bucket(StateCommitmentDirty)
+	if b == nil {
+		return
+	}
+	err := b.Put([]byte{set, id[0], id[1]}, []byte{})
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// commitmentLeafHash returns the hash of the leaf of the state commitment tree
+// with the provided key.
+func commitmentLeafHash(tx *bolt.Tx, key []byte) crypto.Hash {
+	prefix := key[1:]
+	tree := crypto.NewTree()
+	empty := true
+	c := tx.Bucket(stateCommitmentSets[key[0]]).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		tree.Push(k)
+		tree.Push(v)
+		empty = false
+	}
+	if empty {
+		return crypto.Hash{}
+	}
+	return tree.Root()
+}
+
+// commitmentNodeHash returns the hash of the node of the state commitment tree
+// with the provided key from the stored hashes of its 256 children.
+func commitmentNodeHash(b *bolt.Bucket, key []byte) crypto.Hash {
+	tree := crypto.NewTree()
+	empty := true
+	child := append(append([]byte{}, key...), 0)
+	for i := 0; i < 256; i++ {
+		child[len(key)] = byte(i)
+		var h crypto.Hash
+		if v := b.Get(child); v != nil {
+			copy(h[:], v)
+			empty = false
+		}
+		tree.Push(h[:])
+	}
+	if empty {
+		return crypto.Hash{}
+	}
+	return tree.Root()
+}
+
+// putCommitmentHash stores the hash of a leaf or node of the state commitment
+// tree. Zero hashes aren't stored.
+func putCommitmentHash(b *bolt.Bucket, key []byte, h crypto.Hash) {
+	var err error
+	if h == (crypto.Hash{}) {
+		err = b.Delete(key)
+	} else {
+		err = b.Put(key, h[:])
+	}
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// commitmentRoot returns the state commitment from the stored roots of the
+// sets.
+func commitmentRoot(b *bolt.Bucket) crypto.Hash {
+	tree := crypto.NewTree()
+	for set := range stateCommitmentSets {
+		var h crypto.Hash
+		copy(h[:], b.Get([]byte{byte(set)}))
+		tree.Push(h[:])
+	}
+	return tree.Root()
+}
+
+// updateStateCommitment recomputes the modified leaves of the state commitment
+// tree and the nodes above them and returns the new state commitment.
+func updateStateCommitment(tx *bolt.Tx) crypto.Hash {
+	dirty := tx.Bucket(StateCommitmentDirty)
+	b := tx.Bucket(StateCommitmentTree)
+
+	// Recompute the leaves and collect the nodes above them.
+	var leaves, nodes, roots [][]byte
+	err := dirty.ForEach(func(k, _ []byte) error {
+		key := append([]byte{}, k...)
+		leaves = append(leaves, key)
+		if len(nodes) == 0 || !bytes.Equal(nodes[len(nodes)-1], key[:2]) {
+			nodes = append(nodes, key[:2])
+		}
+		if len(roots) == 0 || !bytes.Equal(roots[len(roots)-1], key[:1]) {
+			roots = append(roots, key[:1])
+		}
+		return nil
+	})
+	if err != nil {
+		manageErr(tx, err)
+	}
+	for _, key := range leaves {
+		putCommitmentHash(b, key, commitmentLeafHash(tx, key))
+		if err := dirty.Delete(key); build.DEBUG && err != nil {
+			panic(err)
+		}
+	}
+	for _, key := range nodes {
+		putCommitmentHash(b, key, commitmentNodeHash(b, key))
+	}
+	for _, key := range roots {
+		putCommitmentHash(b, key, commitmentNodeHash(b, key))
+	}
+	return commitmentRoot(b)
+}
+
+// commitStateCommitment updates the state commitment after a block was
+// applied or reverted and stores it under the ID of the current block.
+func commitStateCommitment(tx *bolt.Tx) {
+	if tx.Bucket(StateCommitmentTree) == nil {
+		return
+	}
+	commitment := updateStateCommitment(tx)
+	id := currentBlockID(tx)
+	err := tx.Bucket(StateCommitments).Put(id[:], commitment[:])
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// getStateCommitment returns the state commitment after the block with the
+// provided ID was applied. Blocks which were applied before the state
+// commitment was introduced don't have a commitment.
+func getStateCommitment(tx *bolt.Tx, id types.BlockID) (commitment crypto.Hash, exists bool) {
+	b := tx.Bucket(StateCommitments)
+	if b == nil {
+		return crypto.Hash{}, false
+	}
+	v := b.Get(id[:])
+	if v == nil {
+		return crypto.Hash{}, false
+	}
+	copy(commitment[:], v)
+	return commitment, true
+}
+
+// initStateCommitments creates the state commitment tree from the current
+// sets if it doesn't exist yet and stores the commitment of the current
+// block. Older consensus databases don't have a tree, so only blocks applied
+// after the upgrade have a commitment.
+func (cs *ConsensusSet) initStateCommitments(tx *bolt.Tx) error {
+	if tx.Bucket(StateCommitmentTree) != nil {
+		return nil
+	}
+	for _, name := range [][]byte{StateCommitmentDirty, StateCommitmentTree, StateCommitments} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+
+	// Mark every leaf which contains an element as modified and compute the
+	// tree like it is computed for a block.
+	dirty := tx.Bucket(StateCommitmentDirty)
+	for set, name := range stateCommitmentSets {
+		var prev []byte
+		err := tx.Bucket(name).ForEach(func(k, _ []byte) error {
+			if prev != nil && bytes.Equal(prev, k[:2]) {
+				return nil
+			}
+			prev = append(prev[:0], k[:2]...)
+			return dirty.Put([]byte{byte(set), k[0], k[1]}, []byte{})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	commitStateCommitment(tx)
+	return nil
+}
+
+// StateCommitment returns the state commitment of the current block.
+func (cs *ConsensusSet) StateCommitment() (commitment crypto.Hash, err error) {
+	err = cs.tg.Add()
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	defer cs.tg.Done()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		var exists bool
+		commitment, exists = getStateCommitment(tx, currentBlockID(tx))
+		if !exists {
+			return errNilItem
+		}
+		return nil
+	})
+	return commitment, err
+}
@@ -0,0 +1,113 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// errRollback is returned to roll back a database transaction of a test.
+var errRollback = errors.New("rollback")
+
+// recomputeStateCommitment computes the state commitment of the current
+// block from scratch without modifying the database.
+func recomputeStateCommitment(cs *ConsensusSet) (commitment crypto.Hash, err error) {
+	err = cs.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{StateCommitmentDirty, StateCommitmentTree, StateCommitments} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if err := cs.initStateCommitments(tx); err != nil {
+			return err
+		}
+		commitment, _ = getStateCommitment(tx, currentBlockID(tx))
+		return errRollback
+	})
+	if errors.Contains(err, errRollback) {
+		err = nil
+	}
+	return
+}
+
+// TestStateCommitment checks that the incrementally updated state commitment
+// matches a commitment computed from scratch and that it is included in
+// consensus changes.
+func TestStateCommitment(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	before, err := cst.cs.StateCommitment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := cst.cs.CurrentBlock().ID()
+
+	// Modify all sets of the commitment.
+	cst.testFileContractRevision()
+	cst.testSpendSiafunds()
+	commitment, err := cst.cs.StateCommitment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitment == before {
+		t.Fatal("commitment didn't change")
+	}
+	recomputed, err := recomputeStateCommitment(cst.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitment != recomputed {
+		t.Fatal("commitment doesn't match the recomputed commitment", commitment, recomputed)
+	}
+
+	// Reverting the blocks restores the previous commitment.
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		pb, err := getBlockMap(tx, parent)
+		if err != nil {
+			return err
+		}
+		cst.cs.revertToBlock(tx, pb)
+		if c, _ := getStateCommitment(tx, parent); c != before {
+			t.Error("reverting didn't restore the commitment", c, before)
+		}
+		return errRollback
+	})
+	if !errors.Contains(err, errRollback) {
+		t.Fatal(err)
+	}
+
+	// The consensus changes contain the commitment of every applied block.
+	batch, err := cst.cs.ConsensusChangesSince(modules.ConsensusChangeBeginning, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for batch.More {
+		next, err := cst.cs.ConsensusChangesSince(batch.Cursor, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch = next
+	}
+	cc := batch.Changes[len(batch.Changes)-1]
+	if len(cc.AppliedStateCommitments) != len(cc.AppliedBlocks) {
+		t.Fatal("wrong number of commitments", len(cc.AppliedStateCommitments), len(cc.AppliedBlocks))
+	}
+	if cc.AppliedStateCommitments[len(cc.AppliedStateCommitments)-1] != commitment {
+		t.Fatal("consensus change doesn't contain the current commitment")
+	}
+}
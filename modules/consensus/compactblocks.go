@@ -0,0 +1,299 @@
+package consensus
+
+// compactblocks.go contains the compact block relay. Blocks are still
+// announced through the RelayHeader RPC, but peers fetch them through the
+// SendCmpctBlk RPC, which sends the block with short ids instead of its
+// transactions. Most transactions of a new block are already in the
+// transaction pool of the peer, so the block can usually be reconstructed
+// without downloading them again. Transactions which are missing from the pool
+// are requested by their index within the block. If the reconstructed block
+// still doesn't match, e.g. because a transaction in the pool was malleated,
+// the full block is sent over the same connection. Peers which don't support
+// the RPC are still sent blocks through the SendBlk RPC.
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errCompactBlockBadIndex is returned if a peer requests a transaction
+	// which isn't part of the block.
+	errCompactBlockBadIndex = errors.New("requested transaction index is out of bounds")
+
+	// errCompactBlockIncomplete is returned if a compact block can't be
+	// reconstructed from the known and the requested transactions.
+	errCompactBlockIncomplete = errors.New("compact block couldn't be reconstructed")
+
+	// errCompactBlockMismatch is returned if a peer sends a block which
+	// doesn't match the requested id.
+	errCompactBlockMismatch = errors.New("peer sent a block which doesn't match the requested id")
+)
+
+const (
+	// operationReconstructBlock is the name of the operation counter of the
+	// reconstruction of compact blocks. Failed reconstructions are followed by
+	// a download of the full block.
+	operationReconstructBlock = "reconstructblock"
+)
+
+// shortTxnID is the short id of a transaction within a compact block. It is
+// salted with the id of the block to make finding collisions for a block
+// infeasible before the block was mined.
+type shortTxnID [8]byte
+
+// compactBlock is a block whose transactions are replaced by their short ids.
+type compactBlock struct {
+	ParentID     types.BlockID
+	Nonce        types.BlockNonce
+	Timestamp    types.Timestamp
+	MinerPayouts []types.SiacoinOutput
+	ShortIDs     []shortTxnID
+}
+
+// shortTransactionID returns the short id of a transaction of the block with
+// the given id.
+func shortTransactionID(blockID types.BlockID, txnID types.TransactionID) (sid shortTxnID) {
+	h := crypto.HashAll(blockID, txnID)
+	copy(sid[:], h[:])
+	return
+}
+
+// newCompactBlock creates the compact block of a block.
+func newCompactBlock(b types.Block) compactBlock {
+	id := b.ID()
+	cb := compactBlock{
+		ParentID:     b.ParentID,
+		Nonce:        b.Nonce,
+		Timestamp:    b.Timestamp,
+		MinerPayouts: b.MinerPayouts,
+		ShortIDs:     make([]shortTxnID, len(b.Transactions)),
+	}
+	for i, txn := range b.Transactions {
+		cb.ShortIDs[i] = shortTransactionID(id, txn.ID())
+	}
+	return cb
+}
+
+// reconstruct reconstructs the block with the given id from the compact block
+// and the provided transactions. It returns the indices of the transactions
+// which couldn't be found. Short ids which match multiple transactions are
+// treated as missing.
+func (cb compactBlock) reconstruct(id types.BlockID, txns []types.Transaction) (types.Block, []uint64) {
+	// Index the transactions by their short ids. Short ids which match more
+	// than one transaction are ambiguous.
+	const ambiguous = -1
+	index := make(map[shortTxnID]int, len(txns))
+	for i, txn := range txns {
+		sid := shortTransactionID(id, txn.ID())
+		if _, exists := index[sid]; exists {
+			index[sid] = ambiguous
+			continue
+		}
+		index[sid] = i
+	}
+
+	b := types.Block{
+		ParentID:     cb.ParentID,
+		Nonce:        cb.Nonce,
+		Timestamp:    cb.Timestamp,
+		MinerPayouts: cb.MinerPayouts,
+		Transactions: make([]types.Transaction, len(cb.ShortIDs)),
+	}
+	var missing []uint64
+	for i, sid := range cb.ShortIDs {
+		j, exists := index[sid]
+		if !exists || j == ambiguous {
+			missing = append(missing, uint64(i))
+			continue
+		}
+		b.Transactions[i] = txns[j]
+	}
+	return b, missing
+}
+
+// SetTransactionSource sets the source of the unconfirmed transactions which
+// are used to reconstruct compact blocks. Without a source, relayed blocks are
+// always downloaded in full.
+func (cs *ConsensusSet) SetTransactionSource(source modules.TransactionSource) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.txnSource = source
+}
+
+// managedTransactionSource returns the consensus set's transaction source.
+func (cs *ConsensusSet) managedTransactionSource() modules.TransactionSource {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.txnSource
+}
+
+// rpcSendCmpctBlk is an RPC that sends the requested block as a compact block
+// to the requesting peer, followed by the full block if the peer couldn't
+// reconstruct it.
+func (cs *ConsensusSet) rpcSendCmpctBlk(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendBlkTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Decode the block id from the connection.
+	var id types.BlockID
+	err = encoding.ReadObject(conn, &id, crypto.HashSize)
+	if err != nil {
+		return err
+	}
+	// Lookup the corresponding block.
+	var b types.Block
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		b = pb.Block
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// Send the compact block followed by the transactions the peer is
+	// missing, and the full block if the peer asks for it.
+	err = encoding.WriteObject(conn, newCompactBlock(b))
+	if err != nil {
+		return err
+	}
+	var missing []uint64
+	err = encoding.ReadObject(conn, &missing, uint64(len(b.Transactions))*8+8)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		txns := make([]types.Transaction, 0, len(missing))
+		for _, i := range missing {
+			if i >= uint64(len(b.Transactions)) {
+				return errCompactBlockBadIndex
+			}
+			txns = append(txns, b.Transactions[i])
+		}
+		err = encoding.WriteObject(conn, txns)
+		if err != nil {
+			return err
+		}
+	}
+	var sendFull bool
+	err = encoding.ReadObject(conn, &sendFull, 1)
+	if err != nil {
+		return err
+	}
+	if !sendFull {
+		return nil
+	}
+	return encoding.WriteObject(conn, b)
+}
+
+// managedReceiveCmpctBlk takes a block id and returns an RPCFunc that requests
+// that block as a compact block, reconstructs it from the transactions of
+// source and then calls AcceptBlock on it. The full block is requested if the
+// block can't be reconstructed. received is set once the block was received.
+// The returned function should be used as the calling end of the SendCmpctBlk
+// RPC.
+func (cs *ConsensusSet) managedReceiveCmpctBlk(id types.BlockID, source modules.TransactionSource, received *bool) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		if err := encoding.WriteObject(conn, id); err != nil {
+			return err
+		}
+		var cb compactBlock
+		if err := encoding.ReadObject(conn, &cb, types.BlockSizeLimit); err != nil {
+			return err
+		}
+
+		// Reconstruct the block and request the missing transactions.
+		start := time.Now()
+		block, missing := cb.reconstruct(id, source.Transactions())
+		if err := encoding.WriteObject(conn, missing); err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			var txns []types.Transaction
+			if err := encoding.ReadObject(conn, &txns, types.BlockSizeLimit); err != nil {
+				return err
+			}
+			if len(txns) != len(missing) {
+				return errCompactBlockMismatch
+			}
+			for i, txn := range txns {
+				block.Transactions[missing[i]] = txn
+			}
+		}
+		ok := block.ID() == id
+		var reconstructErr error
+		if !ok {
+			reconstructErr = errCompactBlockIncomplete
+		}
+		cs.staticOperationCounters.Record(operationReconstructBlock, time.Since(start), reconstructErr)
+
+		// Request the full block if the reconstructed block doesn't match.
+		if err := encoding.WriteObject(conn, !ok); err != nil {
+			return err
+		}
+		if !ok {
+			if err := encoding.ReadObject(conn, &block, types.BlockSizeLimit); err != nil {
+				return err
+			}
+			if block.ID() != id {
+				return errCompactBlockMismatch
+			}
+		}
+
+		*received = true
+		chainExtended, err := cs.managedAcceptBlocks([]types.Block{block})
+		if chainExtended {
+			cs.managedBroadcastBlock(block)
+		}
+		if err != nil {
+			cs.reportInvalidBlock(conn.RPCAddr(), err)
+			return err
+		}
+		return nil
+	}
+}
+
+// managedFetchRelayedBlock fetches the block with the given id, which was
+// announced by the peer at addr, and accepts it. The block is fetched as a
+// compact block if possible. If the peer doesn't support compact blocks or the
+// download fails, the block is fetched through SendBlk instead.
+func (cs *ConsensusSet) managedFetchRelayedBlock(addr modules.NetAddress, id types.BlockID) error {
+	if source := cs.managedTransactionSource(); source != nil {
+		var received bool
+		err := cs.gateway.RPC(addr, "SendCmpctBlk", cs.managedReceiveCmpctBlk(id, source, &received))
+		if err == nil || received {
+			return err
+		}
+		cs.log.Debugln("WARN: failed to fetch compact block, falling back to SendBlk:", err)
+	}
+	return cs.gateway.RPC(addr, "SendBlk", cs.managedReceiveBlock(id))
+}
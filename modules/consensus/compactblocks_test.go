@@ -0,0 +1,167 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errNotRelayed is returned by the retry loops of the compact block tests
+// until the expected state was relayed.
+var errNotRelayed = errors.New("not relayed yet")
+
+// TestCompactBlockReconstruct probes reconstructing compact blocks.
+func TestCompactBlockReconstruct(t *testing.T) {
+	t.Parallel()
+
+	// Create a block with a few transactions.
+	txns := make([]types.Transaction, 5)
+	for i := range txns {
+		txns[i].ArbitraryData = [][]byte{fastrand.Bytes(16)}
+	}
+	b := types.Block{
+		Timestamp:    types.CurrentTimestamp(),
+		MinerPayouts: []types.SiacoinOutput{{Value: types.SiacoinPrecision}},
+		Transactions: txns[:3],
+	}
+	id := b.ID()
+	cb := newCompactBlock(b)
+	if len(cb.ShortIDs) != len(b.Transactions) {
+		t.Fatal("wrong number of short ids", len(cb.ShortIDs))
+	}
+
+	// The block can be reconstructed from a pool which contains additional
+	// transactions in a different order.
+	pool := []types.Transaction{txns[4], txns[2], txns[0], txns[3], txns[1]}
+	rb, missing := cb.reconstruct(id, pool)
+	if len(missing) != 0 || rb.ID() != id {
+		t.Fatal("block wasn't reconstructed")
+	}
+
+	// Missing transactions are reported by their index.
+	if _, missing := cb.reconstruct(id, txns[1:]); len(missing) != 1 || missing[0] != 0 {
+		t.Fatal("expected transaction 0 to be missing", missing)
+	}
+
+	// Ambiguous short ids are reported as missing.
+	if _, missing := cb.reconstruct(id, append(pool, txns[2])); len(missing) != 1 || missing[0] != 2 {
+		t.Fatal("expected transaction 2 to be missing", missing)
+	}
+
+	// A transaction with the same id but different signatures results in a
+	// different block.
+	malleated := append([]types.Transaction(nil), txns...)
+	malleated[1].TransactionSignatures = []types.TransactionSignature{{Signature: fastrand.Bytes(64)}}
+	if rb, missing := cb.reconstruct(id, malleated); len(missing) != 0 || rb.ID() == id {
+		t.Fatal("block with a malleated transaction was reconstructed")
+	}
+
+	// The short ids are salted with the block id.
+	if shortTransactionID(id, txns[0].ID()) == shortTransactionID(types.BlockID{}, txns[0].ID()) {
+		t.Fatal("short ids aren't salted")
+	}
+}
+
+// TestIntegrationCompactBlockRelay tests that relayed blocks whose
+// transactions are in the transaction pool of a peer are reconstructed from
+// compact blocks.
+func TestIntegrationCompactBlockRelay(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := createConsensusSetTester(t.Name() + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cst2, err := blankConsensusSetTester(t.Name()+"2", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Connect the testers and wait for them to sync.
+	err = cst2.gateway.Connect(cst1.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if cst2.cs.CurrentBlock().ID() != cst1.cs.CurrentBlock().ID() {
+			return errNotRelayed
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a transaction and wait for it to reach the second tester.
+	_, err = cst1.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if len(cst2.tpool.Transactions()) == 0 || len(cst2.tpool.Transactions()) != len(cst1.tpool.Transactions()) {
+			return errNotRelayed
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine the transaction. The block is relayed as a compact block and
+	// reconstructed by the second tester.
+	before := cst2.cs.OperationCounters()[operationReconstructBlock]
+	b, err := cst1.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if cst2.cs.CurrentBlock().ID() != b.ID() {
+			return errNotRelayed
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := cst2.cs.OperationCounters()[operationReconstructBlock]
+	if after.Count != before.Count+1 || after.Errors != before.Errors {
+		t.Fatalf("block wasn't reconstructed: %+v -> %+v", before, after)
+	}
+
+	// Without a transaction source, blocks are fetched in full.
+	cst2.cs.SetTransactionSource(nil)
+	b, err = cst1.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if cst2.cs.CurrentBlock().ID() != b.ID() {
+			return errNotRelayed
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := cst2.cs.OperationCounters()[operationReconstructBlock]; stats.Count != after.Count {
+		t.Fatal("block was reconstructed without a transaction source")
+	}
+}
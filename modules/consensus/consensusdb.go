@@ -324,6 +324,7 @@ func addSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, sco types.SiacoinOu
 	if build.DEBUG && err != nil {
 		panic(err)
 	}
+	markStateCommitmentDirty(tx, commitmentSetSiacoinOutputs, id[:])
 }
 
 // removeSiacoinOutput removes a siacoin output from the database. An error is
@@ -338,6 +339,7 @@ func removeSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) {
 	if build.DEBUG && err != nil {
 		panic(err)
 	}
+	markStateCommitmentDirty(tx, commitmentSetSiacoinOutputs, id[:])
 }
 
 // getFileContract fetches a file contract from the database, returning an
@@ -371,6 +373,8 @@ func addFileContract(tx *bolt.Tx, id types.FileContractID, fc types.FileContract
 	if build.DEBUG && err != nil {
 		panic(err)
 	}
+	markStateCommitmentDirty(tx, commitmentSetFileContracts, id[:])
+	updateFileContractStats(tx, fc, true)
 
 	// Add an entry for when the file contract expires.
 	expirationBucketID := append(prefixFCEX, encoding.Marshal(fc.WindowEnd)...)
@@ -397,6 +401,13 @@ func removeFileContract(tx *bolt.Tx, id types.FileContractID) {
 	if build.DEBUG && err != nil {
 		panic(err)
 	}
+	markStateCommitmentDirty(tx, commitmentSetFileContracts, id[:])
+	var fc types.FileContract
+	err = encoding.Unmarshal(fcBytes, &fc)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	updateFileContractStats(tx, fc, false)
 
 	// Delete the entry for the file contract's expiration. The portion of
 	// 'fcBytes' used to determine the expiration bucket id is the
@@ -455,6 +466,7 @@ func addSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, sfo types.SiafundOu
 	if build.DEBUG && err != nil {
 		panic(err)
 	}
+	markStateCommitmentDirty(tx, commitmentSetSiafundOutputs, id[:])
 }
 
 // removeSiafundOutput removes a siafund output from the database. An error is
@@ -468,6 +480,7 @@ func removeSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) {
 	if build.DEBUG && err != nil {
 		panic(err)
 	}
+	markStateCommitmentDirty(tx, commitmentSetSiafundOutputs, id[:])
 }
 
 // getSiafundPool returns the current value of the siafund pool. No error is
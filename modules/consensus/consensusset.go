@@ -56,6 +56,27 @@ type ConsensusSet struct {
 	// the function of adding a subscriber should not be exposed.
 	subscribers []modules.ConsensusSetSubscriber
 
+	// reorgSubscribers are notified every time blocks of the current path
+	// are reverted. blocksSinceDeepReorg counts the blocks applied since the
+	// last deep reorg to clear its alert.
+	reorgSubscribers     []modules.ReorgSubscriber
+	blocksSinceDeepReorg types.BlockHeight
+
+	// txnSource provides the unconfirmed transactions which are used to
+	// reconstruct compact blocks.
+	txnSource modules.TransactionSource
+
+	// staticSubscriberMonitor tracks how well the subscribers keep up with
+	// the consensus changes.
+	staticSubscriberMonitor *subscriberMonitor
+
+	// staticForkStates caches the states of the signaling-based fork rules.
+	staticForkStates *forkStateCache
+
+	// staticFutureBlocks holds the blocks whose timestamps are too far in the
+	// future to be accepted yet.
+	staticFutureBlocks *futureBlockQueue
+
 	// dosBlocks are blocks that are invalid, but the invalidity is only
 	// discoverable during an expensive step of validation. These blocks are
 	// recorded to eliminate a DoS vector where an expensive-to-validate block
@@ -87,12 +108,14 @@ type ConsensusSet struct {
 	blockValidator  blockValidator
 
 	// Utilities
-	db         *persist.BoltDatabase
-	staticDeps modules.Dependencies
-	log        *persist.Logger
-	mu         demotemutex.DemoteMutex
-	persistDir string
-	tg         threadgroup.ThreadGroup
+	db                      *persist.BoltDatabase
+	staticAlerter           *modules.GenericAlerter
+	staticDeps              modules.Dependencies
+	staticOperationCounters *modules.OperationCounters
+	log                     *persist.Logger
+	mu                      demotemutex.DemoteMutex
+	persistDir              string
+	tg                      threadgroup.ThreadGroup
 }
 
 // consensusSetBlockingStartup handles the blocking portion of NewCustomConsensusSet.
@@ -101,10 +124,19 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 	if gateway == nil {
 		return nil, errNilGateway
 	}
+	cs, err := newConsensusSet(persistDir, deps)
+	if err != nil {
+		return nil, err
+	}
+	cs.gateway = gateway
+	return cs, nil
+}
+
+// newConsensusSet creates a ConsensusSet and loads its database without
+// connecting it to the network.
+func newConsensusSet(persistDir string, deps modules.Dependencies) (*ConsensusSet, error) {
 	// Create the ConsensusSet object.
 	cs := &ConsensusSet{
-		gateway: gateway,
-
 		blockRoot: processedBlock{
 			Block:       types.GenesisBlock,
 			ChildTarget: types.RootTarget,
@@ -115,13 +147,18 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 
 		dosBlocks: make(map[types.BlockID]struct{}),
 
+		staticForkStates:   newForkStateCache(),
+		staticFutureBlocks: newFutureBlockQueue(),
+
 		marshaler:       stdMarshaler{},
 		blockRuleHelper: stdBlockRuleHelper{},
 		blockValidator:  NewBlockValidator(),
 
-		staticDeps: deps,
-		persistDir: persistDir,
+		staticAlerter: modules.NewAlerter("consensus"),
+		staticDeps:    deps,
+		persistDir:    persistDir,
 	}
+	cs.staticSubscriberMonitor = newSubscriberMonitor(cs.staticAlerter)
 	// Create the diffs for the genesis transaction outputs
 	for _, transaction := range types.GenesisBlock.Transactions {
 		// Create the diffs for the genesis siacoin outputs.
@@ -172,11 +209,19 @@ func consensusSetAsyncStartup(cs *ConsensusSet, bootstrap bool) error {
 	cs.gateway.RegisterRPC("SendBlocks", cs.rpcSendBlocks)
 	cs.gateway.RegisterRPC("RelayHeader", cs.threadedRPCRelayHeader)
 	cs.gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
+	cs.gateway.RegisterRPC("SendCmpctBlk", cs.rpcSendCmpctBlk)
+	cs.gateway.RegisterRPC("SendHeaders", cs.rpcSendHeaders)
+	cs.gateway.RegisterRPC("SendBlks", cs.rpcSendBlks)
+	cs.gateway.RegisterRPC("SendTxnProofs", cs.rpcSendTxnProofs)
 	cs.gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
 	err := cs.tg.OnStop(func() error {
 		cs.gateway.UnregisterRPC("SendBlocks")
 		cs.gateway.UnregisterRPC("RelayHeader")
 		cs.gateway.UnregisterRPC("SendBlk")
+		cs.gateway.UnregisterRPC("SendCmpctBlk")
+		cs.gateway.UnregisterRPC("SendHeaders")
+		cs.gateway.UnregisterRPC("SendBlks")
+		cs.gateway.UnregisterRPC("SendTxnProofs")
 		cs.gateway.UnregisterConnectCall("SendBlocks")
 		return nil
 	})
@@ -210,6 +255,12 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 		return nil, errChan
 	}
 
+	// Keep an eye on the subscribers.
+	go cs.threadedMonitorSubscribers()
+
+	// Accept queued future blocks once they are due.
+	go cs.threadedProcessFutureBlocks()
+
 	// non-blocking consensus startup.
 	go func() {
 		defer close(errChan)
@@ -24,6 +24,17 @@ func manageErr(tx *bolt.Tx, err error) {
 	}
 }
 
+// checksumBuckets are the constant buckets which are part of the consensus
+// checksum, in the order in which they are pushed into the checksum tree.
+var checksumBuckets = [][]byte{
+	BlockPath,
+	SiacoinOutputs,
+	FileContracts,
+	SiafundOutputs,
+	SiafundPool,
+	FoundationUnlockHashes,
+}
+
 // consensusChecksum grabs a checksum of the consensus set by pushing all of
 // the elements in sorted order into a merkle tree and taking the root. All
 // consensus sets with the same current block should have identical consensus
@@ -34,16 +45,8 @@ func consensusChecksum(tx *bolt.Tx) crypto.Hash {
 
 	// For all of the constant buckets, push every key and every value. Buckets
 	// are sorted in byte-order, therefore this operation is deterministic.
-	consensusSetBuckets := []*bolt.Bucket{
-		tx.Bucket(BlockPath),
-		tx.Bucket(SiacoinOutputs),
-		tx.Bucket(FileContracts),
-		tx.Bucket(SiafundOutputs),
-		tx.Bucket(SiafundPool),
-		tx.Bucket(FoundationUnlockHashes),
-	}
-	for i := range consensusSetBuckets {
-		err := consensusSetBuckets[i].ForEach(func(k, v []byte) error {
+	for _, name := range checksumBuckets {
+		err := tx.Bucket(name).ForEach(func(k, v []byte) error {
 			tree.Push(k)
 			tree.Push(v)
 			return nil
@@ -322,6 +325,7 @@ func (cs *ConsensusSet) checkConsistency(tx *bolt.Tx) {
 	checkDSCOs(tx)
 	checkSiacoinCount(tx)
 	checkSiafundCount(tx)
+	checkFileContractStats(tx)
 	if build.DEBUG {
 		cs.checkRevertApply(tx)
 	}
@@ -0,0 +1,236 @@
+package consensus
+
+// contractstats.go contains the file contract statistics, an index of the
+// number, size and locked funds of the active file contracts as well as the
+// number of contracts expiring at every height. The index is updated whenever
+// a file contract is added to or removed from the database, so it follows the
+// current path through reorgs without scanning the file contract set.
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// FileContractStats is a database bucket containing the totals of the
+	// active file contracts.
+	FileContractStats = []byte("FileContractStats")
+
+	// FileContractExpirationCounts is a database bucket containing the number
+	// of active file contracts whose proof window ends at a height, keyed by
+	// the big-endian height.
+	FileContractExpirationCounts = []byte("FileContractExpirationCounts")
+
+	// fileContractTotalsKey is the key of the totals within the
+	// FileContractStats bucket.
+	fileContractTotalsKey = []byte("totals")
+
+	// errFileContractStatsMismatch is returned by the consistency checks if
+	// the file contract statistics don't match the file contract set.
+	errFileContractStatsMismatch = errors.New("file contract statistics don't match the file contract set")
+)
+
+// fileContractTotals are the totals of the active file contracts which are
+// stored in the database.
+type fileContractTotals struct {
+	Contracts        uint64
+	Size             uint64
+	LockedCollateral types.Currency
+}
+
+// lockedCollateral returns the funds which are locked in the file contract,
+// which is the sum of its valid proof outputs. The siafund fee isn't locked
+// since it is added to the siafund pool when the contract is formed.
+func lockedCollateral(fc types.FileContract) (total types.Currency) {
+	for _, sco := range fc.ValidProofOutputs {
+		total = total.Add(sco.Value)
+	}
+	return
+}
+
+// expirationCountKey returns the key of the expiration count of the provided
+// height.
+func expirationCountKey(height types.BlockHeight) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	return key
+}
+
+// getFileContractTotals returns the totals of the active file contracts.
+func getFileContractTotals(b *bolt.Bucket) (totals fileContractTotals) {
+	totalsBytes := b.Get(fileContractTotalsKey)
+	if totalsBytes == nil {
+		return
+	}
+	err := encoding.Unmarshal(totalsBytes, &totals)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return
+}
+
+// updateFileContractStats adds the file contract to or removes it from the
+// file contract statistics. Databases without statistics, like the scratch
+// databases of the verification, aren't updated.
+func updateFileContractStats(tx *bolt.Tx, fc types.FileContract, add bool) {
+	statsBucket := tx.Bucket(FileContractStats)
+	expirationBucket := tx.Bucket(FileContractExpirationCounts)
+	if statsBucket == nil || expirationBucket == nil {
+		return
+	}
+
+	totals := getFileContractTotals(statsBucket)
+	key := expirationCountKey(fc.WindowEnd)
+	var expirations uint64
+	if expirationBytes := expirationBucket.Get(key); expirationBytes != nil {
+		err := encoding.Unmarshal(expirationBytes, &expirations)
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+	}
+	if add {
+		totals.Contracts++
+		totals.Size += fc.FileSize
+		totals.LockedCollateral = totals.LockedCollateral.Add(lockedCollateral(fc))
+		expirations++
+	} else {
+		// Sanity check - the contract should be part of the statistics.
+		if build.DEBUG && (totals.Contracts == 0 || totals.Size < fc.FileSize || expirations == 0) {
+			panic("removing file contract which isn't part of the statistics")
+		}
+		totals.Contracts--
+		totals.Size -= fc.FileSize
+		totals.LockedCollateral = totals.LockedCollateral.Sub(lockedCollateral(fc))
+		expirations--
+	}
+
+	err := statsBucket.Put(fileContractTotalsKey, encoding.Marshal(totals))
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	if expirations == 0 {
+		err = expirationBucket.Delete(key)
+	} else {
+		err = expirationBucket.Put(key, encoding.Marshal(expirations))
+	}
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// initFileContractStats creates the file contract statistics from the current
+// file contract set if they don't exist yet.
+func (cs *ConsensusSet) initFileContractStats(tx *bolt.Tx) error {
+	if tx.Bucket(FileContractStats) != nil {
+		return nil
+	}
+	for _, name := range [][]byte{FileContractStats, FileContractExpirationCounts} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(FileContracts).ForEach(func(_, fcBytes []byte) error {
+		var fc types.FileContract
+		if err := encoding.Unmarshal(fcBytes, &fc); err != nil {
+			return err
+		}
+		updateFileContractStats(tx, fc, true)
+		return nil
+	})
+}
+
+// fileContractStats reads the file contract statistics from the database.
+func fileContractStats(tx *bolt.Tx) (modules.ConsensusFileContractStats, error) {
+	statsBucket := tx.Bucket(FileContractStats)
+	expirationBucket := tx.Bucket(FileContractExpirationCounts)
+	if statsBucket == nil || expirationBucket == nil {
+		return modules.ConsensusFileContractStats{}, errNilBucket
+	}
+	totals := getFileContractTotals(statsBucket)
+	stats := modules.ConsensusFileContractStats{
+		Height:           blockHeight(tx),
+		Contracts:        totals.Contracts,
+		TotalSize:        totals.Size,
+		LockedCollateral: totals.LockedCollateral,
+		Expirations:      make([]modules.ConsensusContractExpirations, 0, expirationBucket.Stats().KeyN),
+	}
+	err := expirationBucket.ForEach(func(k, v []byte) error {
+		var count uint64
+		if err := encoding.Unmarshal(v, &count); err != nil {
+			return err
+		}
+		stats.Expirations = append(stats.Expirations, modules.ConsensusContractExpirations{
+			Height:    types.BlockHeight(binary.BigEndian.Uint64(k)),
+			Contracts: count,
+		})
+		return nil
+	})
+	if err != nil {
+		return modules.ConsensusFileContractStats{}, err
+	}
+	return stats, nil
+}
+
+// checkFileContractStats checks that the file contract statistics match the
+// file contract set.
+func checkFileContractStats(tx *bolt.Tx) {
+	stored, err := fileContractStats(tx)
+	if errors.Is(err, errNilBucket) {
+		return
+	} else if err != nil {
+		manageErr(tx, err)
+		return
+	}
+
+	var totals fileContractTotals
+	expirations := make(map[types.BlockHeight]uint64)
+	err = tx.Bucket(FileContracts).ForEach(func(_, fcBytes []byte) error {
+		var fc types.FileContract
+		if err := encoding.Unmarshal(fcBytes, &fc); err != nil {
+			return err
+		}
+		totals.Contracts++
+		totals.Size += fc.FileSize
+		totals.LockedCollateral = totals.LockedCollateral.Add(lockedCollateral(fc))
+		expirations[fc.WindowEnd]++
+		return nil
+	})
+	if err != nil {
+		manageErr(tx, err)
+		return
+	}
+
+	if stored.Contracts != totals.Contracts || stored.TotalSize != totals.Size || !stored.LockedCollateral.Equals(totals.LockedCollateral) || len(stored.Expirations) != len(expirations) {
+		manageErr(tx, errFileContractStatsMismatch)
+		return
+	}
+	for _, e := range stored.Expirations {
+		if expirations[e.Height] != e.Contracts {
+			manageErr(tx, errFileContractStatsMismatch)
+			return
+		}
+	}
+}
+
+// FileContractStats returns the statistics of the active file contracts of
+// the current path.
+func (cs *ConsensusSet) FileContractStats() (stats modules.ConsensusFileContractStats, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusFileContractStats{}, err
+	}
+	defer cs.tg.Done()
+
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		stats, err = fileContractStats(tx)
+		return err
+	})
+	return stats, err
+}
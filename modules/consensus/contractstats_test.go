@@ -0,0 +1,161 @@
+package consensus
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// addFileContracts mines a block with a transaction that forms a file contract
+// of the provided size for every window end.
+func (cst *consensusSetTester) addFileContracts(size uint64, windowEnds ...types.BlockHeight) error {
+	txnBuilder, err := cst.wallet.StartTransaction()
+	if err != nil {
+		return err
+	}
+	payout := types.NewCurrency64(400e6)
+	err = txnBuilder.FundSiacoins(payout.Mul64(uint64(len(windowEnds))))
+	if err != nil {
+		return err
+	}
+	for _, windowEnd := range windowEnds {
+		txnBuilder.AddFileContract(types.FileContract{
+			FileSize:           size,
+			WindowStart:        windowEnd - 1,
+			WindowEnd:          windowEnd,
+			Payout:             payout,
+			ValidProofOutputs:  []types.SiacoinOutput{{Value: types.PostTax(cst.cs.dbBlockHeight(), payout)}},
+			MissedProofOutputs: []types.SiacoinOutput{{Value: types.PostTax(cst.cs.dbBlockHeight(), payout)}},
+		})
+	}
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		return err
+	}
+	err = cst.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		return err
+	}
+	_, err = cst.miner.AddBlock()
+	return err
+}
+
+// recomputeFileContractStats computes the file contract statistics of the
+// current block from scratch without modifying the database.
+func recomputeFileContractStats(cs *ConsensusSet) (stats modules.ConsensusFileContractStats, err error) {
+	err = cs.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{FileContractStats, FileContractExpirationCounts} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if err := cs.initFileContractStats(tx); err != nil {
+			return err
+		}
+		stats, err = fileContractStats(tx)
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if errors.Contains(err, errRollback) {
+		err = nil
+	}
+	return
+}
+
+// TestFileContractStats checks that the incrementally updated file contract
+// statistics match statistics computed from scratch.
+func TestFileContractStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	before, err := cst.cs.FileContractStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := cst.cs.CurrentBlock().ID()
+
+	// Every step is compared to the statistics computed from scratch.
+	check := func(step string) modules.ConsensusFileContractStats {
+		stats, err := cst.cs.FileContractStats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		recomputed, err := recomputeFileContractStats(cst.cs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(stats, recomputed) {
+			t.Fatalf("%v: statistics don't match the recomputed statistics: %+v != %+v", step, stats, recomputed)
+		}
+		if stats.Height != cst.cs.Height() {
+			t.Fatalf("%v: wrong height %v", step, stats.Height)
+		}
+		return stats
+	}
+
+	// Form three contracts, two of which expire at the same height.
+	height := cst.cs.Height()
+	err = cst.addFileContracts(1e3, height+5, height+10, height+5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := check("formation")
+	expected := []modules.ConsensusContractExpirations{{Height: height + 5, Contracts: 2}, {Height: height + 10, Contracts: 1}}
+	if stats.Contracts != before.Contracts+3 || stats.TotalSize != before.TotalSize+3e3 || stats.LockedCollateral.IsZero() || !reflect.DeepEqual(stats.Expirations, expected) {
+		t.Fatalf("contracts weren't added to the statistics: %+v", stats)
+	}
+
+	// Mine until the first two contracts expired.
+	for cst.cs.Height() < height+5 {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats = check("expiration")
+	if stats.Contracts != before.Contracts+1 || !reflect.DeepEqual(stats.Expirations, expected[1:]) {
+		t.Fatalf("contracts weren't removed from the statistics: %+v", stats)
+	}
+
+	// Revise and prove contracts.
+	cst.testFileContractRevision()
+	check("revision")
+	cst.testValidStorageProofBlocks()
+	check("valid proof")
+
+	// Reverting the blocks restores the previous statistics.
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		pb, err := getBlockMap(tx, parent)
+		if err != nil {
+			return err
+		}
+		cst.cs.revertToBlock(tx, pb)
+		reverted, err := fileContractStats(tx)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(reverted, before) {
+			t.Errorf("reverting didn't restore the statistics: %+v != %+v", reverted, before)
+		}
+		return errRollback
+	})
+	if !errors.Contains(err, errRollback) {
+		t.Fatal(err)
+	}
+}
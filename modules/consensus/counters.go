@@ -0,0 +1,39 @@
+package consensus
+
+import (
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// operationAcceptBlocks is the name of the operation counter of the
+	// consensus set's block acceptance.
+	operationAcceptBlocks = "acceptblocks"
+
+	// operationValidateBlock is the name of the operation counter of the
+	// checks of a block's header and contents which don't need the consensus
+	// state.
+	operationValidateBlock = "validateblock"
+
+	// operationGenerateDiff is the name of the operation counter of the
+	// validation and application of blocks whose diffs weren't generated yet.
+	operationGenerateDiff = "generatediff"
+
+	// operationVerifySignatures is the name of the operation counter of the
+	// standalone validation of a block's transactions, which is dominated by
+	// the verification of their signatures. It is recorded per transaction.
+	operationVerifySignatures = "verifysignatures"
+
+	// operationCommitDiff is the name of the operation counter of applying or
+	// reverting the previously generated diffs of a block.
+	operationCommitDiff = "commitdiff"
+
+	// operationDBCommit is the name of the operation counter of committing
+	// the database transaction of a set of accepted blocks.
+	operationDBCommit = "dbcommit"
+)
+
+// OperationCounters implements the modules.OperationCounter interface for the
+// consensus set.
+func (cs *ConsensusSet) OperationCounters() map[string]modules.OperationStats {
+	return cs.staticOperationCounters.Stats()
+}
@@ -0,0 +1,52 @@
+package consensus
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestValidationCounters checks that accepting a block records the timings of
+// the individual validation steps.
+func TestValidationCounters(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block containing a transaction.
+	_, err = cst.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := cst.cs.OperationCounters()
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	after := cst.cs.OperationCounters()
+
+	operations := []string{
+		operationAcceptBlocks,
+		operationValidateBlock,
+		operationGenerateDiff,
+		operationVerifySignatures,
+		operationDBCommit,
+	}
+	for _, op := range operations {
+		if after[op].Count <= before[op].Count {
+			t.Errorf("%v wasn't counted", op)
+		}
+		if after[op].Errors != before[op].Errors {
+			t.Errorf("%v failed", op)
+		}
+	}
+}
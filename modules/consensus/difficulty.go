@@ -68,12 +68,12 @@ var (
 // however we do not use the child block deltas because that would allow the
 // child block to influence the target of the following block, which makes abuse
 // easier in selfish mining scenarios.
-func (cs *ConsensusSet) childTargetOak(parentTotalTime int64, parentTotalTarget, currentTarget types.Target, parentHeight types.BlockHeight, parentTimestamp types.Timestamp) types.Target {
+func childTargetOak(parentTotalTime int64, parentTotalTarget, currentTarget types.Target, parentHeight types.BlockHeight, parentTimestamp types.Timestamp) types.Target {
 	// Determine the delta of the current total time vs. the desired total time.
 	// The desired total time is the difference between the genesis block
 	// timestamp and the current block timestamp.
 	var delta int64
-	if parentHeight < types.OakHardforkFixBlock {
+	if !forkRuleOakFix.activeAtHeight(parentHeight) {
 		// This is the original code. It is incorrect, because it is comparing
 		// 'expectedTime', an absolute value, to 'parentTotalTime', a value
 		// which gets compressed every block. The result is that 'expectedTime'
@@ -155,6 +155,23 @@ func (cs *ConsensusSet) getBlockTotals(tx *bolt.Tx, id types.BlockID) (totalTime
 // block and stores that new time in the database. It also returns the new
 // totals.
 func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockHeight, currentBlockID types.BlockID, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target, err error) {
+	newTotalTime, newTotalTarget = blockTotals(currentHeight, prevTotalTime, parentTimestamp, currentTimestamp, prevTotalTarget, targetOfCurrentBlock)
+
+	// Store the new total time and total target in the database at the
+	// appropriate id.
+	bytes := make([]byte, 40)
+	binary.LittleEndian.PutUint64(bytes[:8], uint64(newTotalTime))
+	copy(bytes[8:], newTotalTarget[:])
+	err = tx.Bucket(BucketOak).Put(currentBlockID[:], bytes)
+	if err != nil {
+		return 0, types.Target{}, errors.Extend(errors.New("unable to store total time values"), err)
+	}
+	return newTotalTime, newTotalTarget, nil
+}
+
+// blockTotals computes the new total time and total target for the current
+// block from the totals of its parent.
+func blockTotals(currentHeight types.BlockHeight, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target) {
 	// Reset the prevTotalTime to a delta of zero just before the hardfork.
 	//
 	// NOTICE: This code is broken, an incorrectly executed hardfork. The
@@ -186,17 +203,7 @@ func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockH
 		newTotalTime = types.ASICHardforkTotalTime
 		newTotalTarget = types.ASICHardforkTotalTarget
 	}
-
-	// Store the new total time and total target in the database at the
-	// appropriate id.
-	bytes := make([]byte, 40)
-	binary.LittleEndian.PutUint64(bytes[:8], uint64(newTotalTime))
-	copy(bytes[8:], newTotalTarget[:])
-	err = tx.Bucket(BucketOak).Put(currentBlockID[:], bytes)
-	if err != nil {
-		return 0, types.Target{}, errors.Extend(errors.New("unable to store total time values"), err)
-	}
-	return newTotalTime, newTotalTarget, nil
+	return newTotalTime, newTotalTarget
 }
 
 // initOak will initialize all of the oak difficulty adjustment related fields.
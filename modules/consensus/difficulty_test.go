@@ -26,7 +26,6 @@ func TestChildTargetOak(t *testing.T) {
 			t.Fatal(err)
 		}
 	}()
-	cs := cst.cs
 	// NOTE: Test must not be run in parallel.
 	//
 	// Set the constants to match the real-network constants, and then make sure
@@ -58,7 +57,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTarget := types.RootTarget
 	// newTarget should match the root target, as the hashrate and blocktime all
 	// match the existing target - there should be no reason for adjustment.
-	newTarget := cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget := childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// New target should be barely moving. Some imprecision may cause slight
 	// adjustments, but the total difference should be less than 0.01%.
 	maxNewTarget := parentTarget.MulDifficulty(big.NewRat(10e3, 10001))
@@ -82,7 +81,7 @@ func TestChildTargetOak(t *testing.T) {
 	// Set the target to types.RootTarget, causing the max difficulty adjustment
 	// clamp to be in effect.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) <= 0 {
 		t.Error("Difficulty did not decrease in response to increased total time")
 	}
@@ -104,7 +103,7 @@ func TestChildTargetOak(t *testing.T) {
 	// Set the target to types.RootTarget, causing the max difficulty adjustment
 	// clamp to be in effect.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) >= 0 {
 		t.Error("Difficulty did not increase in response to decreased total time")
 	}
@@ -125,7 +124,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) + 5e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty decreased, but not by the max amount.
 	minNewTarget = parentTarget.MulDifficulty(types.OakMaxDrop)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) <= 0 {
@@ -146,7 +145,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) - 5e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty increased, but not by the max amount.
 	maxNewTarget = parentTarget.MulDifficulty(types.OakMaxRise)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) >= 0 {
@@ -167,7 +166,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) + 10e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty decreased, but not by the max amount.
 	minNewTarget = parentTarget.MulDifficulty(types.OakMaxDrop)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) <= 0 {
@@ -188,7 +187,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) - 10e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty increased, but not by the max amount.
 	maxNewTarget = parentTarget.MulDifficulty(types.OakMaxRise)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) >= 0 {
@@ -209,7 +208,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) + 500e6
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget.MulDifficulty(big.NewRat(1, types.OakMaxBlockShift))
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// New target should be barely moving. Some imprecision may cause slight
 	// adjustments, but the total difference should be less than 0.01%.
 	maxNewTarget = parentTarget.MulDifficulty(big.NewRat(10e3, 10001))
@@ -232,7 +231,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) - 500e6
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget.MulDifficulty(big.NewRat(types.OakMaxBlockShift, 1))
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// New target should be barely moving. Some imprecision may cause slight
 	// adjustments, but the total difference should be less than 0.01%.
 	maxNewTarget = parentTarget.MulDifficulty(big.NewRat(10e3, 10001))
@@ -216,6 +216,7 @@ func commitDiffSet(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
 	deleteObsoleteDelayedOutputMaps(tx, pb, dir)
 	commitFoundationUpdate(tx, pb, dir)
 	updateCurrentPath(tx, pb, dir)
+	commitStateCommitment(tx)
 }
 
 // generateAndApplyDiff will verify the block and then integrate it into the
@@ -223,7 +224,7 @@ func commitDiffSet(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
 // transactions are allowed to depend on each other. We can't be sure that a
 // transaction is valid unless we have applied all of the previous transactions
 // in the block, which means we need to apply while we verify.
-func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
+func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock, oc *modules.OperationCounters) error {
 	// Sanity check - the block being applied should have the current block as
 	// a parent.
 	if build.DEBUG && pb.Block.ParentID != currentBlockID(tx) {
@@ -235,11 +236,18 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	// applied.
 	createDSCOBucket(tx, pb.Height+types.MaturityDelay)
 
-	// Validate and apply each transaction in the block. They cannot be
-	// validated all at once because some transactions may not be valid until
-	// previous transactions have been applied.
-	for _, txn := range pb.Block.Transactions {
-		err := validTransaction(tx, txn)
+	// Validate and apply each transaction in the block. The signatures and
+	// other standalone properties are checked in parallel up front, but the
+	// state checks cannot be done all at once because some transactions may
+	// not be valid until previous transactions have been applied. The errors
+	// are still reported in the order of the transactions.
+	height := blockHeight(tx)
+	standaloneErrs := validTransactionsStandalone(pb.Block.Transactions, height, oc)
+	for i, txn := range pb.Block.Transactions {
+		if standaloneErrs[i] != nil {
+			return standaloneErrs[i]
+		}
+		err := validTransactionState(tx, txn, height)
 		if err != nil {
 			return err
 		}
@@ -265,6 +273,7 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	bid := pb.Block.ID()
 	blockMap := tx.Bucket(BlockMap)
 	updateCurrentPath(tx, pb, modules.DiffApply)
+	commitStateCommitment(tx)
 
 	// Sanity check preparation - set the consensus hash at this height so that
 	// during reverting a check can be performed to assure consistency when
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"errors"
+	"time"
 
 	"gitlab.com/NebulousLabs/bolt"
 
@@ -60,7 +61,9 @@ func (cs *ConsensusSet) revertToBlock(tx *bolt.Tx, pb *processedBlock) (reverted
 	// Rewind blocks until 'pb' is the current block.
 	for currentBlockID(tx) != pb.Block.ID() {
 		block := currentProcessedBlock(tx)
+		start := time.Now()
 		commitDiffSet(tx, block, modules.DiffRevert)
+		cs.staticOperationCounters.Record(operationCommitDiff, time.Since(start), nil)
 		revertedBlocks = append(revertedBlocks, block)
 
 		// Sanity check - after removing a block, check that the consensus set
@@ -82,10 +85,13 @@ func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (applie
 	for _, block := range newPath[1:] {
 		// If the diffs for this block have already been generated, apply diffs
 		// directly instead of generating them. This is much faster.
+		start := time.Now()
 		if block.DiffsGenerated {
 			commitDiffSet(tx, block, modules.DiffApply)
+			cs.staticOperationCounters.Record(operationCommitDiff, time.Since(start), nil)
 		} else {
-			err := generateAndApplyDiff(tx, block)
+			err := generateAndApplyDiff(tx, block, cs.staticOperationCounters)
+			cs.staticOperationCounters.Record(operationGenerateDiff, time.Since(start), err)
 			if err != nil {
 				// Mark the block as invalid.
 				cs.dosBlocks[block.Block.ID()] = struct{}{}
@@ -0,0 +1,321 @@
+package consensus
+
+import (
+	"errors"
+	"sync"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errUnknownForkRule is returned if the status of a fork rule which doesn't
+// exist is requested.
+var errUnknownForkRule = errors.New("unknown fork rule")
+
+var (
+	// forkRuleTax is the hardfork which changed the siafund tax.
+	forkRuleTax = forkRule{name: "tax", activationHeight: types.TaxHardforkHeight}
+
+	// forkRuleOak is the hardfork which switched to the oak difficulty
+	// adjustment algorithm.
+	forkRuleOak = forkRule{name: "oak", activationHeight: types.OakHardforkBlock}
+
+	// forkRuleOakFix is the hardfork which fixed the oak difficulty
+	// adjustment algorithm.
+	forkRuleOakFix = forkRule{name: "oakfix", activationHeight: types.OakHardforkFixBlock}
+
+	// forkRuleASIC is the hardfork which targeted the Sia1 ASICs.
+	forkRuleASIC = forkRule{name: "asic", activationHeight: types.ASICHardforkHeight}
+
+	// forkRuleFoundation is the hardfork which introduced the Foundation
+	// subsidy.
+	forkRuleFoundation = forkRule{name: "foundation", activationHeight: types.FoundationHardforkHeight}
+
+	// forkRules are all fork rules known to the consensus set. New protocol
+	// changes add a rule here and check whether it is active with
+	// activeAtHeight or forkActive instead of comparing heights.
+	forkRules = []forkRule{
+		forkRuleTax,
+		forkRuleOak,
+		forkRuleOakFix,
+		forkRuleASIC,
+		forkRuleFoundation,
+	}
+)
+
+type (
+	// forkRule is a change of the consensus rules. It activates either at a
+	// fixed height or, if it is signaling-based, one period after threshold
+	// blocks of a period signaled its bit. Signaling starts with the first
+	// period which starts at or after startHeight and the rule fails if it
+	// isn't locked in by the first period which starts at or after
+	// timeoutHeight.
+	forkRule struct {
+		name string
+
+		// activationHeight is the height at which a height-based rule
+		// activates.
+		activationHeight types.BlockHeight
+
+		signaling     bool
+		bit           uint8
+		startHeight   types.BlockHeight
+		timeoutHeight types.BlockHeight
+		period        types.BlockHeight
+		threshold     uint64
+	}
+
+	// forkStateCache caches the states of signaling-based fork rules. The
+	// state of a rule only changes between periods, so it is cached by the
+	// last block before each period, which makes the cache safe across
+	// reorgs.
+	forkStateCache struct {
+		states map[forkStateKey]forkStateEntry
+		mu     sync.Mutex
+	}
+
+	// forkStateKey identifies the state of a fork rule in the period after
+	// the boundary block.
+	forkStateKey struct {
+		name     string
+		boundary types.BlockID
+	}
+
+	// forkStateEntry is the cached state of a fork rule. activationHeight is
+	// only set once the rule is locked in.
+	forkStateEntry struct {
+		state            modules.ForkState
+		activationHeight types.BlockHeight
+	}
+)
+
+// newForkStateCache creates a new forkStateCache.
+func newForkStateCache() *forkStateCache {
+	return &forkStateCache{
+		states: make(map[forkStateKey]forkStateEntry),
+	}
+}
+
+// callGet returns the cached state of the rule in the period after the
+// boundary block.
+func (fsc *forkStateCache) callGet(name string, boundary types.BlockID) (forkStateEntry, bool) {
+	fsc.mu.Lock()
+	defer fsc.mu.Unlock()
+	entry, exists := fsc.states[forkStateKey{name: name, boundary: boundary}]
+	return entry, exists
+}
+
+// callSet caches the state of the rule in the period after the boundary
+// block.
+func (fsc *forkStateCache) callSet(name string, boundary types.BlockID, entry forkStateEntry) {
+	fsc.mu.Lock()
+	defer fsc.mu.Unlock()
+	fsc.states[forkStateKey{name: name, boundary: boundary}] = entry
+}
+
+// activeAtHeight returns whether the height-based rule applies to the block
+// at the provided height.
+func (fr forkRule) activeAtHeight(height types.BlockHeight) bool {
+	if fr.signaling {
+		build.Critical("activeAtHeight called for signaling-based fork rule", fr.name)
+		return false
+	}
+	return height >= fr.activationHeight
+}
+
+// nextState returns the state of the signaling-based rule in the period
+// starting at the provided height given its state in the previous period and
+// the number of blocks which signaled it in that period. A rule which was
+// signaled by enough blocks in the last period before its timeout still locks
+// in.
+func (fr forkRule) nextState(state modules.ForkState, height types.BlockHeight, signals uint64) modules.ForkState {
+	switch state {
+	case modules.ForkStateDefined:
+		if height >= fr.timeoutHeight {
+			return modules.ForkStateFailed
+		} else if height >= fr.startHeight {
+			return modules.ForkStateStarted
+		}
+	case modules.ForkStateStarted:
+		if signals >= fr.threshold {
+			return modules.ForkStateLockedIn
+		} else if height >= fr.timeoutHeight {
+			return modules.ForkStateFailed
+		}
+	case modules.ForkStateLockedIn:
+		return modules.ForkStateActive
+	}
+	return state
+}
+
+// countForkSignals returns how many of the n blocks ending with pb signaled
+// the bit.
+func countForkSignals(tx *bolt.Tx, bit uint8, pb *processedBlock, n types.BlockHeight) (signals uint64, err error) {
+	for i := types.BlockHeight(0); i < n; i++ {
+		if pb.Block.ForkSignals()&(1<<bit) != 0 {
+			signals++
+		}
+		if i+1 == n || pb.Height == 0 {
+			break
+		}
+		pb, err = getBlockMap(tx, pb.Block.ParentID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return signals, nil
+}
+
+// signalingForkState returns the state of the signaling-based rule for the
+// child of parent.
+func (cs *ConsensusSet) signalingForkState(tx *bolt.Tx, fr forkRule, parent *processedBlock) (forkStateEntry, error) {
+	// The state only changes at the start of a period. Nothing happens before
+	// the first period which starts at or after startHeight.
+	periodStart := (parent.Height + 1) / fr.period * fr.period
+	if periodStart < fr.startHeight && periodStart < fr.timeoutHeight {
+		return forkStateEntry{state: modules.ForkStateDefined}, nil
+	}
+
+	// Walk back to the most recent period with a cached state, collecting the
+	// last block before each period on the way.
+	entry := forkStateEntry{state: modules.ForkStateDefined}
+	var boundaries []*processedBlock
+	pb := parent
+	for start := periodStart; start > 0; start -= fr.period {
+		boundary, err := ancestorAtHeight(tx, pb, start-1)
+		if err != nil {
+			return forkStateEntry{}, err
+		}
+		if cached, exists := cs.staticForkStates.callGet(fr.name, boundary.Block.ID()); exists {
+			entry = cached
+			break
+		}
+		boundaries = append(boundaries, boundary)
+		pb = boundary
+	}
+
+	// Compute and cache the states of the following periods.
+	for i := len(boundaries) - 1; i >= 0; i-- {
+		boundary := boundaries[i]
+		var signals uint64
+		if entry.state == modules.ForkStateStarted {
+			var err error
+			signals, err = countForkSignals(tx, fr.bit, boundary, fr.period)
+			if err != nil {
+				return forkStateEntry{}, err
+			}
+		}
+		next := fr.nextState(entry.state, boundary.Height+1, signals)
+		if next == modules.ForkStateLockedIn && entry.state != next {
+			entry.activationHeight = boundary.Height + 1 + fr.period
+		}
+		entry.state = next
+		cs.staticForkStates.callSet(fr.name, boundary.Block.ID(), entry)
+	}
+	return entry, nil
+}
+
+// forkActive returns whether the rule applies to the child of parent.
+func (cs *ConsensusSet) forkActive(tx *bolt.Tx, fr forkRule, parent *processedBlock) (bool, error) {
+	if !fr.signaling {
+		return fr.activeAtHeight(parent.Height + 1), nil
+	}
+	entry, err := cs.signalingForkState(tx, fr, parent)
+	if err != nil {
+		return false, err
+	}
+	return entry.state == modules.ForkStateActive, nil
+}
+
+// forkRuleStatus returns the status of the rule for the child of parent.
+func (cs *ConsensusSet) forkRuleStatus(tx *bolt.Tx, fr forkRule, parent *processedBlock) (modules.ForkRuleStatus, error) {
+	if !fr.signaling {
+		state := modules.ForkStateDefined
+		if fr.activeAtHeight(parent.Height + 1) {
+			state = modules.ForkStateActive
+		}
+		return modules.ForkRuleStatus{
+			Name:             fr.name,
+			Activation:       modules.ForkActivationHeight,
+			State:            state,
+			ActivationHeight: fr.activationHeight,
+		}, nil
+	}
+
+	entry, err := cs.signalingForkState(tx, fr, parent)
+	if err != nil {
+		return modules.ForkRuleStatus{}, err
+	}
+	// Count the signals of the blocks of the current period.
+	var signals uint64
+	periodStart := (parent.Height + 1) / fr.period * fr.period
+	if entry.state == modules.ForkStateStarted && parent.Height >= periodStart {
+		signals, err = countForkSignals(tx, fr.bit, parent, parent.Height+1-periodStart)
+		if err != nil {
+			return modules.ForkRuleStatus{}, err
+		}
+	}
+	return modules.ForkRuleStatus{
+		Name:             fr.name,
+		Activation:       modules.ForkActivationSignaling,
+		State:            entry.state,
+		ActivationHeight: entry.activationHeight,
+		Bit:              fr.bit,
+		StartHeight:      fr.startHeight,
+		TimeoutHeight:    fr.timeoutHeight,
+		Period:           fr.period,
+		Threshold:        fr.threshold,
+		Signals:          signals,
+	}, nil
+}
+
+// ForkRules returns the status of all fork rules known to the consensus set
+// for the next block of the current path.
+func (cs *ConsensusSet) ForkRules() (statuses []modules.ForkRuleStatus, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer cs.tg.Done()
+
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		parent := currentProcessedBlock(tx)
+		for _, fr := range forkRules {
+			status, err := cs.forkRuleStatus(tx, fr, parent)
+			if err != nil {
+				return err
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// ForkRule returns the status of the fork rule with the provided name for the
+// next block of the current path.
+func (cs *ConsensusSet) ForkRule(name string) (status modules.ForkRuleStatus, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return modules.ForkRuleStatus{}, err
+	}
+	defer cs.tg.Done()
+
+	for _, fr := range forkRules {
+		if fr.name != name {
+			continue
+		}
+		err = cs.db.View(func(tx *bolt.Tx) error {
+			status, err = cs.forkRuleStatus(tx, fr, currentProcessedBlock(tx))
+			return err
+		})
+		return status, err
+	}
+	return modules.ForkRuleStatus{}, errUnknownForkRule
+}
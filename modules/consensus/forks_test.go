@@ -0,0 +1,268 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestForkRuleNextState tests the state transitions of signaling-based fork
+// rules.
+func TestForkRuleNextState(t *testing.T) {
+	t.Parallel()
+
+	fr := forkRule{
+		signaling:     true,
+		startHeight:   10,
+		timeoutHeight: 30,
+		period:        5,
+		threshold:     4,
+	}
+	tests := []struct {
+		state   modules.ForkState
+		height  types.BlockHeight
+		signals uint64
+		next    modules.ForkState
+	}{
+		{modules.ForkStateDefined, 5, 5, modules.ForkStateDefined},
+		{modules.ForkStateDefined, 10, 0, modules.ForkStateStarted},
+		{modules.ForkStateDefined, 30, 0, modules.ForkStateFailed},
+		{modules.ForkStateStarted, 15, 3, modules.ForkStateStarted},
+		{modules.ForkStateStarted, 15, 4, modules.ForkStateLockedIn},
+		{modules.ForkStateStarted, 30, 4, modules.ForkStateLockedIn},
+		{modules.ForkStateStarted, 30, 3, modules.ForkStateFailed},
+		{modules.ForkStateLockedIn, 20, 0, modules.ForkStateActive},
+		{modules.ForkStateActive, 35, 0, modules.ForkStateActive},
+		{modules.ForkStateFailed, 35, 5, modules.ForkStateFailed},
+	}
+	for _, test := range tests {
+		if next := fr.nextState(test.state, test.height, test.signals); next != test.next {
+			t.Errorf("%v at height %v with %v signals: expected %v, got %v", test.state, test.height, test.signals, test.next, next)
+		}
+	}
+}
+
+// TestForkRulesHeight tests that the height-based fork rules report the
+// same activation as their heights.
+func TestForkRulesHeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	for i := types.BlockHeight(0); i < types.OakHardforkBlock; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	statuses, err := cst.cs.ForkRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(forkRules) {
+		t.Fatal("wrong number of fork rules", len(statuses))
+	}
+	next := cst.cs.Height() + 1
+	for _, status := range statuses {
+		if status.Activation != modules.ForkActivationHeight {
+			t.Fatal("wrong activation", status)
+		}
+		active := next >= status.ActivationHeight
+		if active != (status.State == modules.ForkStateActive) {
+			t.Fatalf("rule %v has state %v at height %v", status.Name, status.State, next)
+		}
+	}
+	oak, err := cst.cs.ForkRule(forkRuleOak.name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oak.State != modules.ForkStateActive || oak.ActivationHeight != types.OakHardforkBlock {
+		t.Fatal("oak rule should be active", oak)
+	}
+	if _, err := cst.cs.ForkRule("unknown"); err != errUnknownForkRule {
+		t.Fatal("expected errUnknownForkRule, got", err)
+	}
+}
+
+// TestSignalingForkState tests that signaling-based fork rules lock in and
+// activate once enough blocks signaled them and fail otherwise.
+func TestSignalingForkState(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	signaled := forkRule{
+		name:          "signaled",
+		signaling:     true,
+		bit:           3,
+		startHeight:   4,
+		timeoutHeight: 20,
+		period:        4,
+		threshold:     3,
+	}
+	unsignaled := signaled
+	unsignaled.name = "unsignaled"
+	unsignaled.bit = 4
+	unsignaled.timeoutHeight = 12
+
+	// addBlock mines a block which signals the bit of the signaled rule if
+	// signal is set.
+	addBlock := func(signal bool) {
+		block, target, err := cst.miner.BlockForWork()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signal {
+			block.Transactions = append([]types.Transaction{{
+				ArbitraryData: [][]byte{types.ForkSignalData(1 << signaled.bit)},
+			}}, block.Transactions...)
+		}
+		block, _ = cst.miner.SolveBlock(block, target)
+		if err := cst.cs.AcceptBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// status returns the status of the rule for the next block.
+	status := func(fr forkRule) (status modules.ForkRuleStatus) {
+		err := cst.cs.db.View(func(tx *bolt.Tx) (err error) {
+			status, err = cst.cs.forkRuleStatus(tx, fr, currentProcessedBlock(tx))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	// Mine the first period. Signals before the start height don't count.
+	for cst.cs.Height() < 3 {
+		addBlock(true)
+	}
+	if s := status(signaled); s.State != modules.ForkStateStarted || s.Signals != 0 {
+		t.Fatal("rule should have started", s)
+	}
+
+	// Signal in 2 of the blocks of the second period, which isn't enough.
+	addBlock(true)
+	addBlock(true)
+	if s := status(signaled); s.State != modules.ForkStateStarted || s.Signals != 2 {
+		t.Fatal("expected 2 signals", s)
+	}
+	addBlock(false)
+	addBlock(false)
+	if s := status(signaled); s.State != modules.ForkStateStarted || s.Signals != 0 {
+		t.Fatal("rule shouldn't have locked in", s)
+	}
+
+	// Signal in 3 of the blocks of the third period to lock the rule in.
+	addBlock(true)
+	addBlock(false)
+	addBlock(true)
+	addBlock(true)
+	if s := status(signaled); s.State != modules.ForkStateLockedIn || s.ActivationHeight != 16 {
+		t.Fatal("rule should have locked in", s)
+	}
+	if s := status(unsignaled); s.State != modules.ForkStateFailed {
+		t.Fatal("rule should have failed", s)
+	}
+
+	// The rule activates after the locked in period.
+	for cst.cs.Height() < 14 {
+		addBlock(false)
+	}
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		active, err := cst.cs.forkActive(tx, signaled, currentProcessedBlock(tx))
+		if err != nil || active {
+			t.Fatal("rule shouldn't be active yet", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addBlock(false)
+	if s := status(signaled); s.State != modules.ForkStateActive || s.ActivationHeight != 16 {
+		t.Fatal("rule should be active", s)
+	}
+
+	// The states don't depend on the cache.
+	cst.cs.staticForkStates = newForkStateCache()
+	if s := status(signaled); s.State != modules.ForkStateActive || s.ActivationHeight != 16 {
+		t.Fatal("rule should be active without cache", s)
+	}
+	if s := status(unsignaled); s.State != modules.ForkStateFailed {
+		t.Fatal("rule should have failed without cache", s)
+	}
+}
+
+// TestForkSignalPlacement checks that a transaction which isn't the first
+// transaction of a block can't signal fork rules.
+func TestForkSignalPlacement(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// A third party's transaction with a signal after the miner's first
+	// transaction makes the block invalid.
+	block, target, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions,
+		types.Transaction{ArbitraryData: [][]byte{[]byte("miner")}},
+		types.Transaction{ArbitraryData: [][]byte{types.ForkSignalData(1 << 3)}},
+	)
+	block, _ = cst.miner.SolveBlock(block, target)
+	if err := cst.cs.AcceptBlock(block); !errors.Contains(err, ErrMisplacedForkSignal) {
+		t.Fatal("expected ErrMisplacedForkSignal, got", err)
+	}
+
+	// The signal of the first transaction is counted.
+	block, target, err = cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append([]types.Transaction{{
+		ArbitraryData: [][]byte{types.ForkSignalData(1 << 3)},
+	}}, block.Transactions...)
+	block, _ = cst.miner.SolveBlock(block, target)
+	if err := cst.cs.AcceptBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if cst.cs.CurrentBlock().ForkSignals() != 1<<3 {
+		t.Fatal("signal of the first transaction wasn't counted")
+	}
+}
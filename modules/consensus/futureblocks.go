@@ -0,0 +1,197 @@
+package consensus
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errFutureBlockQueueFull is returned if a future block can't be queued
+	// because the queue is full of blocks which are due earlier.
+	errFutureBlockQueueFull = errors.New("future block queue is full")
+
+	// errFutureBlockTooFar is returned if a future block's timestamp is too far
+	// in the future for the block to be queued.
+	errFutureBlockTooFar = errors.New("block timestamp is beyond the future block horizon")
+)
+
+var (
+	// futureBlockHorizon is how far beyond the extreme future threshold the
+	// timestamp of a block may be for the block to be queued instead of
+	// discarded. It gives nodes with a skewed clock time to catch up with the
+	// network.
+	futureBlockHorizon = build.Select(build.Var{
+		Standard: types.Timestamp(2 * 60 * 60),
+		Dev:      types.Timestamp(4 * 60),
+		Testing:  types.Timestamp(6),
+	}).(types.Timestamp)
+
+	// maxFutureBlocks is the maximum number of blocks in the future block
+	// queue. Once it is full, the blocks which are due last are discarded
+	// first.
+	maxFutureBlocks = build.Select(build.Var{
+		Standard: 50,
+		Dev:      20,
+		Testing:  5,
+	}).(int)
+)
+
+// futureBlockQueue holds blocks whose timestamps are too far in the future to
+// be accepted until the clock allows accepting them. Blocks are only queued
+// after their proof of work was checked, so filling the queue is expensive.
+type futureBlockQueue struct {
+	blocks map[types.BlockID]types.Block
+
+	// wake is signaled whenever a block is added to the queue.
+	wake chan struct{}
+	mu   sync.Mutex
+}
+
+// newFutureBlockQueue creates a new futureBlockQueue.
+func newFutureBlockQueue() *futureBlockQueue {
+	return &futureBlockQueue{
+		blocks: make(map[types.BlockID]types.Block),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// futureBlockDue returns the time at which a block's timestamp no longer
+// exceeds the future threshold.
+func futureBlockDue(b types.Block) types.Timestamp {
+	return b.Timestamp - types.FutureThreshold
+}
+
+// callAdd adds a block to the queue. If the queue is full, the block which is
+// due last is discarded, which might be the new block.
+func (q *futureBlockQueue) callAdd(b types.Block, now types.Timestamp) error {
+	if b.Timestamp > now+types.ExtremeFutureThreshold+futureBlockHorizon {
+		return errFutureBlockTooFar
+	}
+	id := b.ID()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, exists := q.blocks[id]; exists {
+		return nil
+	}
+	if len(q.blocks) >= maxFutureBlocks {
+		var lastID types.BlockID
+		var last types.Block
+		for qid, qb := range q.blocks {
+			if qb.Timestamp >= last.Timestamp {
+				lastID, last = qid, qb
+			}
+		}
+		if b.Timestamp >= last.Timestamp {
+			return errFutureBlockQueueFull
+		}
+		delete(q.blocks, lastID)
+	}
+	q.blocks[id] = b
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// callLen returns the number of queued blocks.
+func (q *futureBlockQueue) callLen() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.blocks)
+}
+
+// callNextDue returns the time at which the next block is due. It returns
+// false if the queue is empty.
+func (q *futureBlockQueue) callNextDue() (types.Timestamp, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var next types.Timestamp
+	found := false
+	for _, b := range q.blocks {
+		if due := futureBlockDue(b); !found || due < next {
+			next, found = due, true
+		}
+	}
+	return next, found
+}
+
+// callPopDue removes the blocks which are due from the queue and returns them
+// ordered by their timestamps.
+func (q *futureBlockQueue) callPopDue(now types.Timestamp) []types.Block {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var due []types.Block
+	for id, b := range q.blocks {
+		if futureBlockDue(b) <= now {
+			due = append(due, b)
+			delete(q.blocks, id)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].Timestamp < due[j].Timestamp
+	})
+	return due
+}
+
+// managedAcceptFutureBlocks accepts blocks which were queued. Blocks whose
+// parents are among the blocks are retried after their parents were accepted.
+func (cs *ConsensusSet) managedAcceptFutureBlocks(blocks []types.Block) {
+	for len(blocks) > 0 {
+		var orphans []types.Block
+		for _, b := range blocks {
+			_, err := cs.managedAcceptBlocks([]types.Block{b})
+			if errors.Contains(err, errOrphan) {
+				orphans = append(orphans, b)
+				continue
+			} else if err != nil {
+				cs.log.Debugln("WARN: failed to accept a future block:", err)
+				continue
+			}
+			cs.managedBroadcastBlock(b)
+		}
+		if len(orphans) == len(blocks) {
+			cs.log.Debugln("WARN: discarding", len(orphans), "orphaned future blocks")
+			return
+		}
+		blocks = orphans
+	}
+}
+
+// threadedProcessFutureBlocks accepts the blocks of the future block queue
+// once they are due.
+func (cs *ConsensusSet) threadedProcessFutureBlocks() {
+	err := cs.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cs.tg.Done()
+
+	for {
+		// Sleep until the next block is due or a block is added.
+		var timer <-chan time.Time
+		if next, ok := cs.staticFutureBlocks.callNextDue(); ok {
+			wait := time.Duration(next-types.CurrentTimestamp()) * time.Second
+			if next < types.CurrentTimestamp() {
+				wait = 0
+			}
+			timer = time.After(wait)
+		}
+		select {
+		case <-cs.tg.StopChan():
+			return
+		case <-cs.staticFutureBlocks.wake:
+			continue
+		case <-timer:
+		}
+		cs.managedAcceptFutureBlocks(cs.staticFutureBlocks.callPopDue(types.CurrentTimestamp()))
+	}
+}
@@ -0,0 +1,60 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestFutureBlockQueue tests that the future block queue is bounded and
+// returns the blocks once they are due.
+func TestFutureBlockQueue(t *testing.T) {
+	t.Parallel()
+
+	now := types.Timestamp(1000)
+	q := newFutureBlockQueue()
+	block := func(i int) types.Block {
+		return types.Block{Timestamp: now + types.FutureThreshold + types.Timestamp(i)}
+	}
+
+	// Blocks beyond the horizon aren't queued.
+	tooFar := types.Block{Timestamp: now + types.ExtremeFutureThreshold + futureBlockHorizon + 1}
+	if err := q.callAdd(tooFar, now); !errors.Contains(err, errFutureBlockTooFar) {
+		t.Fatal("expected errFutureBlockTooFar, got", err)
+	}
+
+	// Fill the queue in reverse order. Adding a block twice doesn't count.
+	for i := maxFutureBlocks; i > 0; i-- {
+		if err := q.callAdd(block(i), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.callAdd(block(1), now); err != nil || q.callLen() != maxFutureBlocks {
+		t.Fatal("duplicate block was queued", err, q.callLen())
+	}
+
+	// A full queue discards the block which is due last.
+	if err := q.callAdd(block(maxFutureBlocks+1), now); !errors.Contains(err, errFutureBlockQueueFull) {
+		t.Fatal("expected errFutureBlockQueueFull, got", err)
+	}
+	if err := q.callAdd(block(0), now); err != nil || q.callLen() != maxFutureBlocks {
+		t.Fatal("earlier block should replace the last block", err, q.callLen())
+	}
+	if next, ok := q.callNextDue(); !ok || next != now {
+		t.Fatal("wrong next due time", next, ok)
+	}
+
+	// Blocks are returned in order once they are due.
+	if due := q.callPopDue(now - 1); len(due) != 0 {
+		t.Fatal("blocks returned before they are due", due)
+	}
+	due := q.callPopDue(now + 2)
+	if len(due) != 3 || due[0].Timestamp != block(0).Timestamp || due[2].Timestamp != block(2).Timestamp {
+		t.Fatal("unexpected due blocks", due)
+	}
+	if q.callLen() != maxFutureBlocks-3 {
+		t.Fatal("due blocks weren't removed", q.callLen())
+	}
+}
@@ -0,0 +1,774 @@
+package consensus
+
+// light.go contains the light consensus set. It only stores the headers of the
+// blocks and checks them with the same rules as the consensus set: the proof
+// of work, the difficulty adjustment and the timestamp rules. The contents of
+// the blocks are never validated. Instead, the transactions of the watched
+// addresses are fetched from full nodes together with proofs of their
+// inclusion in the blocks of the current path, which is enough to follow the
+// balance of a watch-only wallet on devices that can't store the full chain.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/threadgroup"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	lightDatabaseFilename = modules.LightConsensusDir + ".db"
+	lightLogFile          = modules.LightConsensusDir + ".log"
+)
+
+var (
+	lightDBMetadata = persist.Metadata{
+		Header:  "Light Consensus Set Database",
+		Version: "1.0.0",
+	}
+
+	// lightHeaders maps the ids of all known headers to their lightHeader.
+	lightHeaders = []byte("LightHeaders")
+
+	// lightPath maps the heights of the current path to the ids of its
+	// headers.
+	lightPath = []byte("LightPath")
+
+	// lightMetadata contains the height of the current path.
+	lightMetadata = []byte("LightMetadata")
+
+	// lightWatched maps the watched addresses to the height from which their
+	// transactions still need to be fetched.
+	lightWatched = []byte("LightWatched")
+
+	// lightTransactions maps the block id and transaction id of the proven
+	// transactions of the watched addresses to their proofs.
+	lightTransactions = []byte("LightTransactions")
+
+	// fieldLightHeight is the key of the height in the lightMetadata bucket.
+	fieldLightHeight = []byte("Height")
+)
+
+// lightHeader is the light consensus set's equivalent of a processedBlock. It
+// contains the values of a header which are needed to check its children.
+type lightHeader struct {
+	Header      types.BlockHeader
+	Height      types.BlockHeight
+	Depth       types.Target
+	ChildTarget types.Target
+	TotalTime   int64
+	TotalTarget types.Target
+}
+
+// heavierThan returns true if lh is sufficiently heavier than 'cmp'. See
+// processedBlock.heavierThan.
+func (lh *lightHeader) heavierThan(cmp *lightHeader) bool {
+	requirement := cmp.Depth.AddDifficulties(cmp.ChildTarget.MulDifficulty(SurpassThreshold))
+	return requirement.Cmp(lh.Depth) > 0 // Inversed, because the smaller target is actually heavier.
+}
+
+// LightConsensusSet tracks the headers of the heaviest chain and the proven
+// transactions of the watched addresses.
+type LightConsensusSet struct {
+	// The gateway connects the light consensus set to the full nodes which
+	// provide the headers, blocks and transaction proofs.
+	gateway modules.Gateway
+
+	// synced is true once the headers were synchronized with a full node
+	// which had no more headers to offer.
+	synced bool
+
+	// syncChan triggers the synchronization of the headers and the
+	// transactions of the watched addresses.
+	syncChan chan struct{}
+
+	// feeCache caches the fee estimation of the current block.
+	feeCache struct {
+		id       types.BlockID
+		min, max types.Currency
+	}
+
+	// Utilities
+	db         *persist.BoltDatabase
+	log        *persist.Logger
+	mu         sync.RWMutex
+	persistDir string
+	tg         threadgroup.ThreadGroup
+}
+
+// NewLightConsensusSet creates a new light consensus set which synchronizes
+// its headers with the full nodes the gateway is connected to.
+func NewLightConsensusSet(gateway modules.Gateway, persistDir string) (*LightConsensusSet, error) {
+	if gateway == nil {
+		return nil, errNilGateway
+	}
+	ls := &LightConsensusSet{
+		gateway:    gateway,
+		syncChan:   make(chan struct{}, 1),
+		persistDir: persistDir,
+	}
+	if err := ls.initPersist(); err != nil {
+		return nil, err
+	}
+
+	// Register RPCs. The light consensus set accepts relayed headers and asks
+	// new peers for the headers it is missing.
+	ls.gateway.RegisterRPC("RelayHeader", ls.threadedRPCRelayHeader)
+	ls.gateway.RegisterConnectCall("SendHeaders", ls.threadedReceiveHeaders)
+	err := ls.tg.OnStop(func() error {
+		ls.gateway.UnregisterRPC("RelayHeader")
+		ls.gateway.UnregisterConnectCall("SendHeaders")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go ls.threadedSynchronize()
+	return ls, nil
+}
+
+// initPersist opens the database of the light consensus set and initializes
+// it with the genesis header if it is new.
+func (ls *LightConsensusSet) initPersist() error {
+	err := os.MkdirAll(ls.persistDir, 0700)
+	if err != nil {
+		return err
+	}
+	ls.log, err = persist.NewFileLogger(filepath.Join(ls.persistDir, lightLogFile))
+	if err != nil {
+		return err
+	}
+	err = ls.tg.AfterStop(func() error {
+		err := ls.log.Close()
+		if err != nil {
+			// State of the logger is unknown, a println will suffice.
+			fmt.Println("Error shutting down light consensus set logger:", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ls.db, err = persist.OpenDatabase(lightDBMetadata, filepath.Join(ls.persistDir, lightDatabaseFilename))
+	if err != nil {
+		return errors.AddContext(err, "error opening light consensus database")
+	}
+	err = ls.tg.AfterStop(func() error {
+		err := ls.db.Close()
+		if err != nil {
+			ls.log.Println("ERROR: Unable to close light consensus set database at shutdown:", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return ls.db.Update(initLightDB)
+}
+
+// initLightDB creates the buckets of the light consensus database and adds
+// the genesis header if the database is new.
+func initLightDB(tx *bolt.Tx) error {
+	for _, bucket := range [][]byte{lightHeaders, lightPath, lightMetadata, lightWatched, lightTransactions} {
+		if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+			return err
+		}
+	}
+	if tx.Bucket(lightMetadata).Get(fieldLightHeight) != nil {
+		genesisID, err := getLightPath(tx, 0)
+		if err != nil {
+			return err
+		}
+		if genesisID != types.GenesisID {
+			return errors.New("light consensus database has wrong genesis block")
+		}
+		return nil
+	}
+	genesis := &lightHeader{
+		Header:      types.GenesisBlock.Header(),
+		Depth:       types.RootDepth,
+		ChildTarget: types.RootTarget,
+	}
+	genesis.TotalTime, genesis.TotalTarget = blockTotals(0, 0, types.GenesisTimestamp, types.GenesisTimestamp, types.RootDepth, types.RootTarget)
+	if err := putLightHeader(tx, genesis); err != nil {
+		return err
+	}
+	if err := tx.Bucket(lightPath).Put(encoding.Marshal(types.BlockHeight(0)), encoding.Marshal(types.GenesisID)); err != nil {
+		return err
+	}
+	return setLightHeight(tx, 0)
+}
+
+// getLightHeader returns the header with the provided id.
+func getLightHeader(tx *bolt.Tx, id types.BlockID) (*lightHeader, error) {
+	lhBytes := tx.Bucket(lightHeaders).Get(id[:])
+	if lhBytes == nil {
+		return nil, errNilItem
+	}
+	var lh lightHeader
+	if err := encoding.Unmarshal(lhBytes, &lh); err != nil {
+		return nil, err
+	}
+	return &lh, nil
+}
+
+// putLightHeader stores the header.
+func putLightHeader(tx *bolt.Tx, lh *lightHeader) error {
+	id := lh.Header.ID()
+	return tx.Bucket(lightHeaders).Put(id[:], encoding.Marshal(*lh))
+}
+
+// lightHeight returns the height of the current path.
+func lightHeight(tx *bolt.Tx) types.BlockHeight {
+	var height types.BlockHeight
+	err := encoding.Unmarshal(tx.Bucket(lightMetadata).Get(fieldLightHeight), &height)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return height
+}
+
+// setLightHeight sets the height of the current path.
+func setLightHeight(tx *bolt.Tx, height types.BlockHeight) error {
+	return tx.Bucket(lightMetadata).Put(fieldLightHeight, encoding.Marshal(height))
+}
+
+// getLightPath returns the id of the header at the provided height of the
+// current path.
+func getLightPath(tx *bolt.Tx, height types.BlockHeight) (id types.BlockID, err error) {
+	idBytes := tx.Bucket(lightPath).Get(encoding.Marshal(height))
+	if idBytes == nil {
+		return types.BlockID{}, errNilItem
+	}
+	err = encoding.Unmarshal(idBytes, &id)
+	return id, err
+}
+
+// currentLightHeader returns the header of the current block.
+func currentLightHeader(tx *bolt.Tx) (*lightHeader, error) {
+	id, err := getLightPath(tx, lightHeight(tx))
+	if err != nil {
+		return nil, err
+	}
+	return getLightHeader(tx, id)
+}
+
+// lightHistory returns the ids of the current path which are sent to a peer to
+// find the common parent. See blockHistory.
+func lightHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID, err error) {
+	height := lightHeight(tx)
+	step := types.BlockHeight(1)
+	for i := 0; i < 31; i++ {
+		blockIDs[i], err = getLightPath(tx, height)
+		if err != nil {
+			return
+		}
+		if i >= 9 {
+			step *= 2
+		}
+		if height <= step {
+			break
+		}
+		height -= step
+	}
+	blockIDs[31], err = getLightPath(tx, 0)
+	return
+}
+
+// lightMinimumValidChildTimestamp returns the earliest timestamp that a child
+// of the header can have. See stdBlockRuleHelper.minimumValidChildTimestamp.
+func lightMinimumValidChildTimestamp(tx *bolt.Tx, lh *lightHeader) (types.Timestamp, error) {
+	windowTimes := make(types.TimestampSlice, types.MedianTimestampWindow)
+	windowTimes[0] = lh.Header.Timestamp
+	parent := lh.Header.ParentID
+	for i := uint64(1); i < types.MedianTimestampWindow; i++ {
+		if parent == (types.BlockID{}) {
+			windowTimes[i] = windowTimes[i-1]
+			continue
+		}
+		plh, err := getLightHeader(tx, parent)
+		if err != nil {
+			return 0, err
+		}
+		parent = plh.Header.ParentID
+		windowTimes[i] = plh.Header.Timestamp
+	}
+	sort.Sort(windowTimes)
+	return windowTimes[len(windowTimes)/2], nil
+}
+
+// lightChildTarget returns the target of the children of the header child,
+// whose parent is parent. See ConsensusSet.newChild.
+func lightChildTarget(tx *bolt.Tx, parent, child *lightHeader) (types.Target, error) {
+	if forkRuleOak.activeAtHeight(parent.Height) {
+		return childTargetOak(parent.TotalTime, parent.TotalTarget, parent.ChildTarget, parent.Height, parent.Header.Timestamp), nil
+	}
+	if child.Height%(types.TargetWindow/2) != 0 {
+		return parent.ChildTarget, nil
+	}
+
+	// Find the header which was generated 'TargetWindow' blocks prior to
+	// the parent, stopping at the genesis block. See targetAdjustmentBase.
+	windowSize := types.TargetWindow
+	if child.Height < windowSize {
+		windowSize = child.Height
+	}
+	current := parent
+	for i := types.BlockHeight(1); i < windowSize; i++ {
+		var err error
+		current, err = getLightHeader(tx, current.Header.ParentID)
+		if err != nil {
+			return types.Target{}, err
+		}
+	}
+	timePassed := child.Header.Timestamp - current.Header.Timestamp
+	expectedTimePassed := types.BlockFrequency * windowSize
+	adjustment := clampTargetAdjustment(big.NewRat(int64(timePassed), int64(expectedTimePassed)))
+	return types.RatToTarget(new(big.Rat).Mul(parent.ChildTarget.Rat(), adjustment)), nil
+}
+
+// validateLightHeader checks the header against its parent and returns its
+// lightHeader. The checks match ConsensusSet.validateHeader, except that
+// headers in the near future are rejected instead of being kept for later.
+func validateLightHeader(tx *bolt.Tx, h types.BlockHeader) (*lightHeader, error) {
+	id := h.ID()
+	if tx.Bucket(lightHeaders).Get(id[:]) != nil {
+		return nil, modules.ErrBlockKnown
+	}
+	parent, err := getLightHeader(tx, h.ParentID)
+	if errors.Contains(err, errNilItem) {
+		return nil, errOrphan
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Check the nonce and the proof of work.
+	if forkRuleASIC.activeAtHeight(parent.Height+1) && binary.LittleEndian.Uint64(h.Nonce[:])%types.ASICHardforkFactor != 0 {
+		return nil, errors.New("block does not meet nonce requirements")
+	}
+	if !checkHeaderTarget(h, parent.ChildTarget) {
+		return nil, modules.ErrBlockUnsolved
+	}
+
+	// Check the timestamp.
+	minTimestamp, err := lightMinimumValidChildTimestamp(tx, parent)
+	if err != nil {
+		return nil, err
+	}
+	if minTimestamp > h.Timestamp {
+		return nil, ErrEarlyTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.ExtremeFutureThreshold {
+		return nil, ErrExtremeFutureTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.FutureThreshold {
+		return nil, ErrFutureTimestamp
+	}
+
+	child := &lightHeader{
+		Header: h,
+		Height: parent.Height + 1,
+		Depth:  parent.Depth.AddDifficulties(parent.ChildTarget),
+	}
+	child.TotalTime, child.TotalTarget = blockTotals(child.Height, parent.TotalTime, parent.Header.Timestamp, h.Timestamp, parent.TotalTarget, parent.ChildTarget)
+	child.ChildTarget, err = lightChildTarget(tx, parent, child)
+	if err != nil {
+		return nil, err
+	}
+	return child, nil
+}
+
+// setLightPath makes the header the tip of the current path and returns the
+// height of the common parent of the old and the new path. The transactions
+// of the reverted blocks are removed and their heights are fetched again.
+func setLightPath(tx *bolt.Tx, lh *lightHeader) (types.BlockHeight, error) {
+	oldHeight := lightHeight(tx)
+	path := tx.Bucket(lightPath)
+
+	// Rewrite the path until it meets the new header's ancestors.
+	current := lh
+	for {
+		id := current.Header.ID()
+		pathID, err := getLightPath(tx, current.Height)
+		if err == nil && pathID == id {
+			break
+		}
+		if err := path.Put(encoding.Marshal(current.Height), encoding.Marshal(id)); err != nil {
+			return 0, err
+		}
+		current, err = getLightHeader(tx, current.Header.ParentID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	forkHeight := current.Height
+	for height := lh.Height + 1; height <= oldHeight; height++ {
+		if err := path.Delete(encoding.Marshal(height)); err != nil {
+			return 0, err
+		}
+	}
+	if err := setLightHeight(tx, lh.Height); err != nil {
+		return 0, err
+	}
+	if forkHeight < oldHeight {
+		if err := revertLightTransactions(tx, forkHeight); err != nil {
+			return 0, err
+		}
+	}
+	return forkHeight, nil
+}
+
+// revertLightTransactions removes the transactions above the provided height
+// and resets the scan heights of the watched addresses to fetch them again.
+func revertLightTransactions(tx *bolt.Tx, height types.BlockHeight) error {
+	txns := tx.Bucket(lightTransactions)
+	var stale [][]byte
+	err := txns.ForEach(func(k, v []byte) error {
+		var tp modules.TransactionProof
+		if err := encoding.Unmarshal(v, &tp); err != nil {
+			return err
+		}
+		if tp.Height > height {
+			stale = append(stale, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range stale {
+		if err := txns.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	watched := tx.Bucket(lightWatched)
+	var addrs []types.UnlockHash
+	err = watched.ForEach(func(k, v []byte) error {
+		var uh types.UnlockHash
+		copy(uh[:], k)
+		if types.BlockHeight(encoding.DecUint64(v)) > height+1 {
+			addrs = append(addrs, uh)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, uh := range addrs {
+		if err := watched.Put(uh[:], encoding.Marshal(height+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptLightHeader validates and stores the header. If it is heavier than
+// the current block, it becomes the tip of the current path.
+func acceptLightHeader(tx *bolt.Tx, h types.BlockHeader) error {
+	lh, err := validateLightHeader(tx, h)
+	if err != nil {
+		return err
+	}
+	if err := putLightHeader(tx, lh); err != nil {
+		return err
+	}
+	current, err := currentLightHeader(tx)
+	if err != nil {
+		return err
+	}
+	if !lh.heavierThan(current) {
+		return modules.ErrNonExtendingBlock
+	}
+	_, err = setLightPath(tx, lh)
+	return err
+}
+
+// managedAcceptHeaders accepts the headers in order. The headers in front of
+// an invalid header are kept. true is returned if the current path changed.
+func (ls *LightConsensusSet) managedAcceptHeaders(headers []types.BlockHeader) (changed bool, err error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	updateErr := ls.db.Update(func(tx *bolt.Tx) error {
+		for _, h := range headers {
+			acceptErr := acceptLightHeader(tx, h)
+			if acceptErr == nil {
+				changed = true
+			} else if !errors.Contains(acceptErr, modules.ErrBlockKnown) && !errors.Contains(acceptErr, modules.ErrNonExtendingBlock) {
+				// Commit the headers in front of the invalid header.
+				err = acceptErr
+				break
+			}
+		}
+		return nil
+	})
+	return changed, errors.Compose(updateErr, err)
+}
+
+// Close shuts down the light consensus set.
+func (ls *LightConsensusSet) Close() error {
+	return ls.tg.Stop()
+}
+
+// CurrentHeader returns the header of the current block.
+func (ls *LightConsensusSet) CurrentHeader() (h types.BlockHeader) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	err := ls.db.View(func(tx *bolt.Tx) error {
+		lh, err := currentLightHeader(tx)
+		if err != nil {
+			return err
+		}
+		h = lh.Header
+		return nil
+	})
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// HeaderAtHeight returns the header of the block at the provided height of
+// the current path.
+func (ls *LightConsensusSet) HeaderAtHeight(height types.BlockHeight) (h types.BlockHeader, exists bool) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	_ = ls.db.View(func(tx *bolt.Tx) error {
+		id, err := getLightPath(tx, height)
+		if err != nil {
+			return err
+		}
+		lh, err := getLightHeader(tx, id)
+		if err != nil {
+			return err
+		}
+		h, exists = lh.Header, true
+		return nil
+	})
+	return h, exists
+}
+
+// Height returns the height of the current block.
+func (ls *LightConsensusSet) Height() (height types.BlockHeight) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	_ = ls.db.View(func(tx *bolt.Tx) error {
+		height = lightHeight(tx)
+		return nil
+	})
+	return height
+}
+
+// Synced returns true if the light consensus set is synced with its full node
+// peers.
+func (ls *LightConsensusSet) Synced() bool {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.synced
+}
+
+// Status returns the status of the light consensus set.
+func (ls *LightConsensusSet) Status() (status modules.LightConsensusStatus) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	status.Synced = ls.synced
+	err := ls.db.View(func(tx *bolt.Tx) error {
+		lh, err := currentLightHeader(tx)
+		if err != nil {
+			return err
+		}
+		status.Height = lh.Height
+		status.CurrentBlock = lh.Header.ID()
+		status.Target = lh.ChildTarget
+		status.ScanHeight = lh.Height + 1
+		return tx.Bucket(lightWatched).ForEach(func(_, v []byte) error {
+			status.WatchedAddresses++
+			if next := types.BlockHeight(encoding.DecUint64(v)); next < status.ScanHeight {
+				status.ScanHeight = next
+			}
+			return nil
+		})
+	})
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return status
+}
+
+// WatchAddresses adds addresses to the set of watched addresses. Their
+// transactions are fetched starting at the provided height. Addresses which
+// are already watched are scanned again from the provided height if it is
+// lower than the height they were scanned to.
+func (ls *LightConsensusSet) WatchAddresses(addrs []types.UnlockHash, startHeight types.BlockHeight) error {
+	if err := ls.tg.Add(); err != nil {
+		return err
+	}
+	defer ls.tg.Done()
+	ls.mu.Lock()
+	err := ls.db.Update(func(tx *bolt.Tx) error {
+		watched := tx.Bucket(lightWatched)
+		for _, uh := range addrs {
+			if v := watched.Get(uh[:]); v != nil && types.BlockHeight(encoding.DecUint64(v)) <= startHeight {
+				continue
+			}
+			if err := watched.Put(uh[:], encoding.Marshal(startHeight)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	ls.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	ls.triggerSync()
+	return nil
+}
+
+// UnwatchAddresses removes addresses from the set of watched addresses
+// together with the transactions which aren't relevant to any of the remaining
+// addresses.
+func (ls *LightConsensusSet) UnwatchAddresses(addrs []types.UnlockHash) error {
+	if err := ls.tg.Add(); err != nil {
+		return err
+	}
+	defer ls.tg.Done()
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		watched := tx.Bucket(lightWatched)
+		for _, uh := range addrs {
+			if err := watched.Delete(uh[:]); err != nil {
+				return err
+			}
+		}
+		remaining := make(map[types.UnlockHash]struct{})
+		err := watched.ForEach(func(k, _ []byte) error {
+			var uh types.UnlockHash
+			copy(uh[:], k)
+			remaining[uh] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		txns := tx.Bucket(lightTransactions)
+		var stale [][]byte
+		err = txns.ForEach(func(k, v []byte) error {
+			var tp modules.TransactionProof
+			if err := encoding.Unmarshal(v, &tp); err != nil {
+				return err
+			}
+			if !relevantTransaction(tp.Transaction, remaining) {
+				stale = append(stale, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := txns.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WatchedAddresses returns the watched addresses.
+func (ls *LightConsensusSet) WatchedAddresses() (addrs []types.UnlockHash, err error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	err = ls.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(lightWatched).ForEach(func(k, _ []byte) error {
+			var uh types.UnlockHash
+			copy(uh[:], k)
+			addrs = append(addrs, uh)
+			return nil
+		})
+	})
+	return addrs, err
+}
+
+// Transactions returns the proven transactions of the watched addresses
+// ordered by height.
+func (ls *LightConsensusSet) Transactions() (txns []modules.TransactionProof, err error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	err = ls.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(lightTransactions).ForEach(func(_, v []byte) error {
+			var tp modules.TransactionProof
+			if err := encoding.Unmarshal(v, &tp); err != nil {
+				return err
+			}
+			txns = append(txns, tp)
+			return nil
+		})
+	})
+	sort.Slice(txns, func(i, j int) bool {
+		if txns[i].Height != txns[j].Height {
+			return txns[i].Height < txns[j].Height
+		}
+		return txns[i].LeafIndex < txns[j].LeafIndex
+	})
+	return txns, err
+}
+
+// UnspentOutputs returns the siacoin outputs of the watched addresses which
+// aren't spent by any of their proven transactions.
+func (ls *LightConsensusSet) UnspentOutputs() ([]modules.LightSiacoinOutput, error) {
+	addrs, err := ls.WatchedAddresses()
+	if err != nil {
+		return nil, err
+	}
+	txns, err := ls.Transactions()
+	if err != nil {
+		return nil, err
+	}
+	watched := make(map[types.UnlockHash]struct{}, len(addrs))
+	for _, uh := range addrs {
+		watched[uh] = struct{}{}
+	}
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for _, tp := range txns {
+		for _, sci := range tp.Transaction.SiacoinInputs {
+			spent[sci.ParentID] = struct{}{}
+		}
+	}
+	var outputs []modules.LightSiacoinOutput
+	for _, tp := range txns {
+		for i, sco := range tp.Transaction.SiacoinOutputs {
+			id := tp.Transaction.SiacoinOutputID(uint64(i))
+			if _, exists := watched[sco.UnlockHash]; !exists {
+				continue
+			} else if _, exists := spent[id]; exists {
+				continue
+			}
+			outputs = append(outputs, modules.LightSiacoinOutput{
+				ID:         id,
+				Value:      sco.Value,
+				UnlockHash: sco.UnlockHash,
+				Height:     tp.Height,
+			})
+		}
+	}
+	return outputs, nil
+}
@@ -0,0 +1,214 @@
+package consensus
+
+// light_proofs.go contains the SendTxnProofs RPC. Full nodes use it to provide
+// light consensus sets with the transactions of their watched addresses
+// together with proofs that the transactions are part of the blocks of the
+// current path.
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxTxnProofAddresses is the maximum number of addresses which can be
+	// requested in a single SendTxnProofs RPC.
+	maxTxnProofAddresses = 1000
+)
+
+var (
+	errTxnProofsRequestTooLarge = errors.New("too many addresses requested")
+	errTxnProofInvalid          = errors.New("transaction proof doesn't match the block header")
+
+	// maxTxnProofBlocks is the maximum number of blocks which are scanned in
+	// response to a single SendTxnProofs RPC.
+	maxTxnProofBlocks = build.Select(build.Var{
+		Standard: types.BlockHeight(144),
+		Dev:      types.BlockHeight(50),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+
+	// maxTxnProofsResponseSize is the maximum size of a SendTxnProofs
+	// response. It allows the proofs to be twice as large as the scanned
+	// blocks, fewer blocks are scanned if they would be larger.
+	maxTxnProofsResponseSize = uint64(maxTxnProofBlocks) * (2*types.BlockSizeLimit + crypto.HashSize)
+
+	// sendTxnProofsTimeout is the timeout for the SendTxnProofs RPC.
+	sendTxnProofsTimeout = build.Select(build.Var{
+		Standard: 120 * time.Second,
+		Dev:      30 * time.Second,
+		Testing:  4 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// txnProofsRequest requests the proofs of the transactions of the
+	// addresses within the blocks of the heights [StartHeight, EndHeight).
+	txnProofsRequest struct {
+		Addresses   []types.UnlockHash
+		StartHeight types.BlockHeight
+		EndHeight   types.BlockHeight
+	}
+
+	// txnProofsResponse contains the ids of the scanned blocks of the current
+	// path, starting at the requested start height, and the proofs of the
+	// relevant transactions within these blocks.
+	txnProofsResponse struct {
+		BlockIDs []types.BlockID
+		Proofs   []modules.TransactionProof
+	}
+)
+
+// relevantTransaction returns true if one of the siacoin inputs or outputs of
+// the transaction belongs to one of the addresses.
+func relevantTransaction(txn types.Transaction, addrs map[types.UnlockHash]struct{}) bool {
+	for _, sci := range txn.SiacoinInputs {
+		if _, exists := addrs[sci.UnlockConditions.UnlockHash()]; exists {
+			return true
+		}
+	}
+	for _, sco := range txn.SiacoinOutputs {
+		if _, exists := addrs[sco.UnlockHash]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// blockLeaves returns the leaves of the block's Merkle tree. See
+// types.Block.MerkleRoot.
+func blockLeaves(b types.Block) [][]byte {
+	leaves := make([][]byte, 0, len(b.MinerPayouts)+len(b.Transactions))
+	for _, payout := range b.MinerPayouts {
+		leaves = append(leaves, encoding.Marshal(payout))
+	}
+	for _, txn := range b.Transactions {
+		leaves = append(leaves, encoding.Marshal(txn))
+	}
+	return leaves
+}
+
+// transactionProofs returns the proofs of the transactions of the block which
+// are relevant to the addresses.
+func transactionProofs(b types.Block, height types.BlockHeight, addrs map[types.UnlockHash]struct{}) []modules.TransactionProof {
+	var leaves [][]byte
+	var proofs []modules.TransactionProof
+	for i, txn := range b.Transactions {
+		if !relevantTransaction(txn, addrs) {
+			continue
+		}
+		if leaves == nil {
+			leaves = blockLeaves(b)
+		}
+		leafIndex := uint64(len(b.MinerPayouts) + i)
+		tree := crypto.NewTree()
+		if err := tree.SetIndex(leafIndex); err != nil {
+			build.Critical("unable to set index of block tree:", err)
+		}
+		for _, leaf := range leaves {
+			tree.Push(leaf)
+		}
+		_, _, proofSet, _, numLeaves := tree.Prove()
+		proof := make([]crypto.Hash, len(proofSet)-1)
+		for j := range proof {
+			proof[j] = crypto.Hash(proofSet[j+1])
+		}
+		proofs = append(proofs, modules.TransactionProof{
+			BlockID:     b.ID(),
+			Height:      height,
+			Transaction: txn,
+			LeafIndex:   leafIndex,
+			NumLeaves:   numLeaves,
+			Proof:       proof,
+		})
+	}
+	return proofs
+}
+
+// verifyTransactionProof checks that the proof proves the inclusion of its
+// transaction in the block of the header.
+func verifyTransactionProof(tp modules.TransactionProof, h types.BlockHeader) error {
+	if tp.BlockID != h.ID() {
+		return errTxnProofInvalid
+	}
+	if !crypto.VerifySegment(encoding.Marshal(tp.Transaction), tp.Proof, tp.NumLeaves, tp.LeafIndex, h.MerkleRoot) {
+		return errTxnProofInvalid
+	}
+	return nil
+}
+
+// rpcSendTxnProofs is the receiving end of the SendTxnProofs RPC. It scans up
+// to 'maxTxnProofBlocks' blocks of the current path starting at the requested
+// height and responds with the ids of the scanned blocks and the proofs of the
+// transactions of the requested addresses.
+func (cs *ConsensusSet) rpcSendTxnProofs(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendTxnProofsTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	var req txnProofsRequest
+	err = encoding.ReadObject(conn, &req, maxTxnProofAddresses*crypto.HashSize+24)
+	if err != nil {
+		return err
+	}
+	if len(req.Addresses) > maxTxnProofAddresses {
+		return errTxnProofsRequestTooLarge
+	}
+	addrs := make(map[types.UnlockHash]struct{}, len(req.Addresses))
+	for _, addr := range req.Addresses {
+		addrs[addr] = struct{}{}
+	}
+
+	var resp txnProofsResponse
+	size := uint64(16)
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		height := blockHeight(tx)
+		for h := req.StartHeight; h < req.EndHeight && h <= height && h < req.StartHeight+maxTxnProofBlocks; h++ {
+			id, err := getPath(tx, h)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			proofs := transactionProofs(pb.Block, h, addrs)
+			size += crypto.HashSize + uint64(len(encoding.Marshal(proofs))) - 8
+			if size > maxTxnProofsResponseSize && len(resp.BlockIDs) > 0 {
+				break
+			}
+			resp.BlockIDs = append(resp.BlockIDs, id)
+			resp.Proofs = append(resp.Proofs, proofs...)
+		}
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, resp)
+}
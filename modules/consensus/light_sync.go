@@ -0,0 +1,495 @@
+package consensus
+
+// light_sync.go contains the synchronization of the light consensus set with
+// full nodes. The headers are fetched with the SendHeaders RPC, the
+// transactions of the watched addresses with the SendTxnProofs RPC and the
+// recent blocks used for fee estimation with the SendBlks RPC. Every response
+// is checked against the headers of the current path.
+
+import (
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// lightFeeEstimationDepth is the number of recent blocks the fee
+	// estimation is based on. See the transaction pool's
+	// blockFeeEstimationDepth.
+	lightFeeEstimationDepth = 6
+
+	// lightFeeMaxMultiplier is the gap between the maximum and the minimum
+	// estimated fee.
+	lightFeeMaxMultiplier = 3
+)
+
+var (
+	errNoFullNodes      = errors.New("not connected to any full nodes")
+	errTxnProofsNoMatch = errors.New("peer's blocks don't match the current path")
+
+	// lightMinFeeEstimation is the minimum estimated fee per byte.
+	lightMinFeeEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
+
+	// lightSyncInterval is the interval in which the light consensus set
+	// synchronizes with its full node peers in addition to the
+	// synchronization triggered by relayed headers.
+	lightSyncInterval = build.Select(build.Var{
+		Standard: 2 * time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+// triggerSync wakes up threadedSynchronize.
+func (ls *LightConsensusSet) triggerSync() {
+	select {
+	case ls.syncChan <- struct{}{}:
+	default:
+	}
+}
+
+// managedFullNodePeers returns the addresses of the connected full nodes. If
+// there are none, the gateway tries to connect to one.
+func (ls *LightConsensusSet) managedFullNodePeers() []modules.NetAddress {
+	var addrs []modules.NetAddress
+	for _, p := range ls.gateway.Peers() {
+		if p.Services.Has(modules.ServiceFullNode) {
+			addrs = append(addrs, p.NetAddress)
+		}
+	}
+	if len(addrs) > 0 {
+		return addrs
+	}
+	peers, _ := ls.gateway.ConnectServices(modules.ServiceFullNode, 1)
+	for _, p := range peers {
+		addrs = append(addrs, p.NetAddress)
+	}
+	return addrs
+}
+
+// threadedSynchronize synchronizes the headers and the transactions of the
+// watched addresses whenever it is triggered and in regular intervals.
+func (ls *LightConsensusSet) threadedSynchronize() {
+	if err := ls.tg.Add(); err != nil {
+		return
+	}
+	defer ls.tg.Done()
+
+	for {
+		peers := ls.managedFullNodePeers()
+		for _, addr := range peers {
+			err := ls.managedSyncHeaders(addr)
+			if err != nil {
+				ls.log.Debugf("WARN: failed to synchronize headers with %v: %v", addr, err)
+				continue
+			}
+			ls.mu.Lock()
+			ls.synced = true
+			ls.mu.Unlock()
+			break
+		}
+		for _, addr := range peers {
+			err := ls.managedScanAddresses(addr)
+			if err != nil {
+				ls.log.Debugf("WARN: failed to fetch transactions from %v: %v", addr, err)
+				continue
+			}
+			break
+		}
+
+		select {
+		case <-ls.tg.StopChan():
+			return
+		case <-ls.syncChan:
+		case <-time.After(lightSyncInterval):
+		}
+	}
+}
+
+// managedHeaderLocator returns the locator which is sent to request the
+// headers following the current block.
+func (ls *LightConsensusSet) managedHeaderLocator() (locator [32]types.BlockID, err error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	err = ls.db.View(func(tx *bolt.Tx) error {
+		locator, err = lightHistory(tx)
+		return err
+	})
+	return locator, err
+}
+
+// managedSyncHeaders fetches and accepts headers from the peer until it has
+// no more headers to offer.
+func (ls *LightConsensusSet) managedSyncHeaders(addr modules.NetAddress) error {
+	moreAvailable := true
+	for moreAvailable {
+		locator, err := ls.managedHeaderLocator()
+		if err != nil {
+			return err
+		}
+		var headers []types.BlockHeader
+		err = ls.gateway.RPC(addr, "SendHeaders", receiveHeaders(locator, func(hs []types.BlockHeader, more bool) {
+			headers, moreAvailable = hs, more
+		}))
+		if err != nil {
+			return err
+		}
+		if len(headers) == 0 {
+			return nil
+		}
+		changed, err := ls.managedAcceptHeaders(headers)
+		if err != nil {
+			return errors.AddContext(err, "peer sent invalid headers")
+		}
+		if !changed {
+			// The headers belong to a lighter fork.
+			return nil
+		}
+	}
+	ls.log.Debugf("Synchronized headers with %v, height is now %v", addr, ls.Height())
+	return nil
+}
+
+// threadedReceiveHeaders is the connect call of the light consensus set. It
+// requests the headers following the current block from new peers and
+// triggers a full synchronization if the peer has more headers.
+func (ls *LightConsensusSet) threadedReceiveHeaders(conn modules.PeerConn) error {
+	if err := ls.tg.Add(); err != nil {
+		return err
+	}
+	defer ls.tg.Done()
+
+	locator, err := ls.managedHeaderLocator()
+	if err != nil {
+		return err
+	}
+	var headers []types.BlockHeader
+	var moreAvailable bool
+	err = receiveHeaders(locator, func(hs []types.BlockHeader, more bool) {
+		headers, moreAvailable = hs, more
+	})(conn)
+	if err != nil {
+		return err
+	}
+	changed, err := ls.managedAcceptHeaders(headers)
+	if changed || moreAvailable {
+		ls.triggerSync()
+	}
+	return err
+}
+
+// threadedRPCRelayHeader accepts a block header relayed by a peer. If the
+// parent of the header is unknown, a synchronization is triggered.
+func (ls *LightConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(relayHeaderTimeout))
+	if err != nil {
+		return err
+	}
+	if err := ls.tg.Add(); err != nil {
+		return err
+	}
+	defer ls.tg.Done()
+
+	var h types.BlockHeader
+	err = encoding.ReadObject(conn, &h, types.BlockHeaderSize)
+	if err != nil {
+		return err
+	}
+	changed, err := ls.managedAcceptHeaders([]types.BlockHeader{h})
+	if errors.Contains(err, errOrphan) {
+		ls.triggerSync()
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if changed {
+		// Fetch the transactions of the new block.
+		ls.triggerSync()
+	}
+	return nil
+}
+
+// receiveTxnProofs returns an RPCFunc which is the calling end of the
+// SendTxnProofs RPC.
+func receiveTxnProofs(req txnProofsRequest, fn func(txnProofsResponse)) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		err := conn.SetDeadline(time.Now().Add(sendTxnProofsTimeout))
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, req); err != nil {
+			return err
+		}
+		var resp txnProofsResponse
+		if err := encoding.ReadObject(conn, &resp, maxTxnProofsResponseSize); err != nil {
+			return err
+		}
+		fn(resp)
+		return nil
+	}
+}
+
+// managedNextScan returns the lowest height from which transactions still
+// need to be fetched and the addresses whose transactions need to be fetched
+// from that height. No addresses are returned if all transactions were
+// fetched.
+func (ls *LightConsensusSet) managedNextScan() (start, height types.BlockHeight, addrs []types.UnlockHash, err error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	err = ls.db.View(func(tx *bolt.Tx) error {
+		height = lightHeight(tx)
+		start = height + 1
+		return tx.Bucket(lightWatched).ForEach(func(k, v []byte) error {
+			var uh types.UnlockHash
+			copy(uh[:], k)
+			next := types.BlockHeight(encoding.DecUint64(v))
+			if next < start {
+				start, addrs = next, nil
+			}
+			if next == start && len(addrs) < maxTxnProofAddresses {
+				addrs = append(addrs, uh)
+			}
+			return nil
+		})
+	})
+	return
+}
+
+// managedAcceptTxnProofs stores the proven transactions of a SendTxnProofs
+// response. Only the blocks which match the current path are considered
+// scanned.
+func (ls *LightConsensusSet) managedAcceptTxnProofs(start types.BlockHeight, addrs []types.UnlockHash, resp txnProofsResponse) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		var matched types.BlockHeight
+		for i, id := range resp.BlockIDs {
+			pathID, err := getLightPath(tx, start+types.BlockHeight(i))
+			if err != nil || pathID != id {
+				break
+			}
+			matched++
+		}
+		if matched == 0 {
+			return errTxnProofsNoMatch
+		}
+
+		// Verify all proofs before storing them.
+		var proofs []modules.TransactionProof
+		for _, tp := range resp.Proofs {
+			if tp.Height < start || tp.Height >= start+types.BlockHeight(len(resp.BlockIDs)) {
+				return errTxnProofInvalid
+			}
+			if tp.Height >= start+matched {
+				continue
+			}
+			lh, err := getLightHeader(tx, resp.BlockIDs[tp.Height-start])
+			if err != nil {
+				return err
+			}
+			if err := verifyTransactionProof(tp, lh.Header); err != nil {
+				return err
+			}
+			proofs = append(proofs, tp)
+		}
+		txns := tx.Bucket(lightTransactions)
+		for _, tp := range proofs {
+			txid := tp.Transaction.ID()
+			key := append(tp.BlockID[:], txid[:]...)
+			if err := txns.Put(key, encoding.Marshal(tp)); err != nil {
+				return err
+			}
+		}
+
+		// Advance the addresses which weren't changed in the meantime.
+		watched := tx.Bucket(lightWatched)
+		for _, uh := range addrs {
+			v := watched.Get(uh[:])
+			if v == nil || types.BlockHeight(encoding.DecUint64(v)) != start {
+				continue
+			}
+			if err := watched.Put(uh[:], encoding.Marshal(start+matched)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// managedScanAddresses fetches the transactions of the watched addresses
+// from the peer until they are up to date with the current path.
+func (ls *LightConsensusSet) managedScanAddresses(addr modules.NetAddress) error {
+	for {
+		select {
+		case <-ls.tg.StopChan():
+			return errors.New("light consensus set is shutting down")
+		default:
+		}
+		start, height, addrs, err := ls.managedNextScan()
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return nil
+		}
+		req := txnProofsRequest{
+			Addresses:   addrs,
+			StartHeight: start,
+			EndHeight:   height + 1,
+		}
+		var resp txnProofsResponse
+		err = ls.gateway.RPC(addr, "SendTxnProofs", receiveTxnProofs(req, func(r txnProofsResponse) {
+			resp = r
+		}))
+		if err != nil {
+			return err
+		}
+		if err := ls.managedAcceptTxnProofs(start, addrs, resp); err != nil {
+			return err
+		}
+	}
+}
+
+// managedFetchBlocks fetches the blocks with the provided ids from the full
+// node peers in chunks of up to 'MaxCatchUpBlocks' blocks.
+func (ls *LightConsensusSet) managedFetchBlocks(ids []types.BlockID) ([]types.Block, error) {
+	peers := ls.managedFullNodePeers()
+	if len(peers) == 0 {
+		return nil, errNoFullNodes
+	}
+	var blocks []types.Block
+	for i := 0; i < len(ids); i += int(MaxCatchUpBlocks) {
+		end := i + int(MaxCatchUpBlocks)
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var err error
+		for _, addr := range peers {
+			err = ls.gateway.RPC(addr, "SendBlks", receiveBlks(ids[i:end], func(bs []types.Block) {
+				blocks = append(blocks, bs...)
+			}))
+			if err == nil {
+				break
+			}
+			ls.log.Debugf("WARN: failed to fetch blocks from %v: %v", addr, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// blockFeeEstimation returns the fee per byte required to get into the
+// cheapest 75% of the block. Unused space counts as space without fees. See
+// the transaction pool's fee estimation.
+func blockFeeEstimation(b types.Block) types.Currency {
+	type feeSummary struct {
+		fee  types.Currency
+		size int
+	}
+	var fees []feeSummary
+	var totalSize int
+	for _, txn := range b.Transactions {
+		size := len(encoding.Marshal(txn))
+		var feeSum types.Currency
+		for _, fee := range txn.MinerFees {
+			feeSum = feeSum.Add(fee)
+		}
+		fees = append(fees, feeSummary{
+			fee:  feeSum.Div64(uint64(size)),
+			size: size,
+		})
+		totalSize += size
+	}
+	fees = append(fees, feeSummary{
+		fee:  types.ZeroCurrency,
+		size: int(types.BlockSizeLimit) - totalSize,
+	})
+	sort.Slice(fees, func(i, j int) bool {
+		return fees[i].fee.Cmp(fees[j].fee) < 0
+	})
+	var progress int
+	for _, fs := range fees {
+		progress += fs.size
+		if uint64(progress) > types.BlockSizeLimit/4 {
+			return fs.fee
+		}
+	}
+	return types.ZeroCurrency
+}
+
+// FeeEstimation returns an estimation of the minimum and maximum fee per byte
+// required to get a transaction into a block. The estimation is the median of
+// the fee estimations of the recent blocks, which are fetched from a full
+// node and checked against their headers.
+func (ls *LightConsensusSet) FeeEstimation() (min, max types.Currency, err error) {
+	if err := ls.tg.Add(); err != nil {
+		return types.Currency{}, types.Currency{}, err
+	}
+	defer ls.tg.Done()
+
+	// Collect the ids of the recent blocks.
+	var ids []types.BlockID
+	ls.mu.RLock()
+	err = ls.db.View(func(tx *bolt.Tx) error {
+		height := lightHeight(tx)
+		for i := 0; i < lightFeeEstimationDepth && types.BlockHeight(i) < height; i++ {
+			id, err := getLightPath(tx, height-types.BlockHeight(i))
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	cached := len(ids) > 0 && ls.feeCache.id == ids[0]
+	min, max = ls.feeCache.min, ls.feeCache.max
+	ls.mu.RUnlock()
+	if err != nil {
+		return types.Currency{}, types.Currency{}, err
+	}
+	if cached {
+		return min, max, nil
+	}
+
+	// Fetch the blocks. receiveBlks checks that the blocks match their ids
+	// and therefore their headers.
+	var blocks []types.Block
+	if len(ids) > 0 {
+		blocks, err = ls.managedFetchBlocks(ids)
+		if err != nil {
+			return types.Currency{}, types.Currency{}, errors.AddContext(err, "unable to fetch recent blocks")
+		}
+	}
+
+	min = lightMinFeeEstimation
+	if len(blocks) > 0 {
+		medians := make([]types.Currency, len(blocks))
+		for i, b := range blocks {
+			medians[i] = blockFeeEstimation(b)
+		}
+		sort.Slice(medians, func(i, j int) bool {
+			return medians[i].Cmp(medians[j]) < 0
+		})
+		if median := medians[len(medians)/2]; median.Cmp(min) > 0 {
+			min = median
+		}
+	}
+	max = min.Mul64(lightFeeMaxMultiplier)
+
+	if len(ids) > 0 {
+		ls.mu.Lock()
+		ls.feeCache.id = ids[0]
+		ls.feeCache.min, ls.feeCache.max = min, max
+		ls.mu.Unlock()
+	}
+	return min, max, nil
+}
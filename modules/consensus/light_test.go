@@ -0,0 +1,158 @@
+package consensus
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/types"
+)
+
+// TestLightConsensusSet checks that a light consensus set follows the headers
+// of a full node and fetches the proven transactions of its watched addresses.
+func TestLightConsensusSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cst.gateway.SetServices(modules.ServiceFullNode)
+
+	// Send coins to a watched address and mine past the Oak hardfork.
+	uc, err := cst.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uh := uc.UnlockHash()
+	txns, err := cst.wallet.SendSiacoins(types.SiacoinPrecision, uh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cst.cs.Height() <= types.OakHardforkBlock+maxHeadersPerBatch {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Create the light consensus set and connect it to the full node.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-light")
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g.SetServices(modules.ServiceLightClient)
+	lsDir := filepath.Join(testdir, modules.LightConsensusDir)
+	ls, err := NewLightConsensusSet(g, lsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.WatchAddresses([]types.UnlockHash{uh}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Connect(cst.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The light consensus set should catch up and compute the same target.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		status := ls.Status()
+		if status.CurrentBlock != cst.cs.CurrentBlock().ID() {
+			return errors.New("light consensus set didn't catch up")
+		}
+		if status.ScanHeight != status.Height+1 {
+			return errors.New("transactions weren't fetched")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := ls.Status()
+	target, _ := cst.cs.ChildTarget(status.CurrentBlock)
+	if status.Height != cst.cs.Height() || status.Target != target || !status.Synced {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	// The transaction sending the coins should be proven.
+	proofs, err := ls.Transactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proofs) != 1 || proofs[0].Transaction.ID() != txns[len(txns)-1].ID() {
+		t.Fatalf("unexpected transactions: %+v", proofs)
+	}
+	outputs, err := ls.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || !outputs[0].Value.Equals(types.SiacoinPrecision) || outputs[0].UnlockHash != uh {
+		t.Fatalf("unexpected outputs: %+v", outputs)
+	}
+
+	// A tampered proof is rejected.
+	h, _ := ls.HeaderAtHeight(proofs[0].Height)
+	if err := verifyTransactionProof(proofs[0], h); err != nil {
+		t.Fatal(err)
+	}
+	tampered := proofs[0]
+	tampered.Proof = append([]crypto.Hash(nil), tampered.Proof...)
+	tampered.Proof[0][0] ^= 1
+	if err := verifyTransactionProof(tampered, h); !errors.Contains(err, errTxnProofInvalid) {
+		t.Fatal("expected errTxnProofInvalid but got", err)
+	}
+
+	// A header which doesn't meet the target is rejected.
+	invalid := ls.CurrentHeader()
+	invalid.ParentID = invalid.ID()
+	for nonce := uint64(0); checkHeaderTarget(invalid, types.RootTarget); nonce += types.ASICHardforkFactor {
+		binary.LittleEndian.PutUint64(invalid.Nonce[:], nonce)
+	}
+	if _, err := ls.managedAcceptHeaders([]types.BlockHeader{invalid}); !errors.Contains(err, modules.ErrBlockUnsolved) {
+		t.Fatal("expected ErrBlockUnsolved but got", err)
+	}
+
+	min, max, err := ls.FeeEstimation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min.Cmp(lightMinFeeEstimation) < 0 || !max.Equals(min.Mul64(lightFeeMaxMultiplier)) {
+		t.Fatal("unexpected fee estimation", min, max)
+	}
+
+	// The state is persisted.
+	if err := ls.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ls, err = NewLightConsensusSet(g, lsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ls.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if reloaded := ls.Status(); reloaded.CurrentBlock != status.CurrentBlock || reloaded.WatchedAddresses != 1 {
+		t.Fatalf("unexpected status after reload: %+v", reloaded)
+	}
+}
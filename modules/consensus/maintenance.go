@@ -22,7 +22,7 @@ var (
 // added.
 func applyFoundationSubsidy(tx *bolt.Tx, pb *processedBlock) {
 	// NOTE: this conditional is split up to better visualize test coverage
-	if pb.Height < types.FoundationHardforkHeight {
+	if !forkRuleFoundation.activeAtHeight(pb.Height) {
 		return
 	} else if (pb.Height-types.FoundationHardforkHeight)%types.FoundationSubsidyFrequency != 0 {
 		return
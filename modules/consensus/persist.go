@@ -60,6 +60,18 @@ func (cs *ConsensusSet) loadDB() error {
 			return err
 		}
 
+		// Create the state commitment tree, if necessary.
+		err = cs.initStateCommitments(tx)
+		if err != nil {
+			return err
+		}
+
+		// Create the file contract statistics, if necessary.
+		err = cs.initFileContractStats(tx)
+		if err != nil {
+			return err
+		}
+
 		// Check that the genesis block is correct - typically only incorrect
 		// in the event of developer binaries vs. release binaires.
 		genesisID, err := getPath(tx, 0)
@@ -93,7 +105,7 @@ func (cs *ConsensusSet) initFoundation(tx *bolt.Tx) error {
 	// If the current height is greater than the hardfork trigger date, return
 	// an error and refuse to initialize.
 	height := blockHeight(tx)
-	if height >= types.FoundationHardforkHeight {
+	if forkRuleFoundation.activeAtHeight(height) {
 		return errFoundationHardforkIncompatibility
 	}
 	// Set the initial Foundation addresses.
@@ -129,6 +141,16 @@ func (cs *ConsensusSet) initPersist() error {
 		return err
 	}
 
+	// Load the operation counters and save them on shutdown.
+	cs.staticOperationCounters, err = modules.NewOperationCounters(filepath.Join(cs.persistDir, modules.OperationCountersFilename))
+	if err != nil {
+		return err
+	}
+	err = cs.tg.AfterStop(cs.staticOperationCounters.Save)
+	if err != nil {
+		return err
+	}
+
 	// Try to load an existing database from disk - a new one will be created
 	// if one does not exist.
 	err = cs.loadDB()
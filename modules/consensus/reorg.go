@@ -0,0 +1,138 @@
+package consensus
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// deepReorgDepth is the number of reverted blocks at which a reorg is
+	// considered deep and an alert is registered.
+	deepReorgDepth = build.Select(build.Var{
+		Standard: types.BlockHeight(6),
+		Dev:      types.BlockHeight(6),
+		Testing:  types.BlockHeight(3),
+	}).(types.BlockHeight)
+
+	// deepReorgAlertBlocks is the number of blocks which need to be applied
+	// after a deep reorg before its alert is unregistered.
+	deepReorgAlertBlocks = build.Select(build.Var{
+		Standard: types.BlockHeight(144),
+		Dev:      types.BlockHeight(20),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+)
+
+// computeReorgEvent computes the reorg event of a change entry which reverted
+// blocks.
+func computeReorgEvent(tx *bolt.Tx, ce changeEntry) (modules.ReorgEvent, error) {
+	oldTip, err := getBlockMap(tx, ce.RevertedBlocks[0])
+	if err != nil {
+		return modules.ReorgEvent{}, err
+	}
+	lastReverted, err := getBlockMap(tx, ce.RevertedBlocks[len(ce.RevertedBlocks)-1])
+	if err != nil {
+		return modules.ReorgEvent{}, err
+	}
+	newTip, err := getBlockMap(tx, ce.AppliedBlocks[len(ce.AppliedBlocks)-1])
+	if err != nil {
+		return modules.ReorgEvent{}, err
+	}
+	return modules.ReorgEvent{
+		OldTip:         oldTip.Block.ID(),
+		OldHeight:      oldTip.Height,
+		NewTip:         newTip.Block.ID(),
+		NewHeight:      newTip.Height,
+		CommonAncestor: lastReverted.Block.ParentID,
+		Depth:          types.BlockHeight(len(ce.RevertedBlocks)),
+		RevertedBlocks: append([]types.BlockID(nil), ce.RevertedBlocks...),
+		AppliedBlocks:  append([]types.BlockID(nil), ce.AppliedBlocks...),
+		Time:           time.Now(),
+	}, nil
+}
+
+// updateReorgSubscribers informs the reorg subscribers about a change entry
+// which reverted blocks and registers an alert if the reorg was deep. The
+// reverted blocks are the blocks whose diffs were committed with DiffRevert
+// while processing the change entry.
+func (cs *ConsensusSet) updateReorgSubscribers(ce changeEntry) {
+	if len(ce.RevertedBlocks) == 0 {
+		// Unregister the alert of the last deep reorg once enough blocks
+		// were applied on top of it.
+		if cs.blocksSinceDeepReorg > 0 {
+			cs.blocksSinceDeepReorg += types.BlockHeight(len(ce.AppliedBlocks))
+			if cs.blocksSinceDeepReorg > deepReorgAlertBlocks {
+				cs.blocksSinceDeepReorg = 0
+				cs.staticAlerter.UnregisterAlert(modules.AlertIDConsensusDeepReorg)
+			}
+		}
+		return
+	}
+
+	var event modules.ReorgEvent
+	err := cs.db.View(func(tx *bolt.Tx) (err error) {
+		event, err = computeReorgEvent(tx, ce)
+		return err
+	})
+	if err != nil {
+		cs.log.Critical("computeReorgEvent failed:", err)
+		return
+	}
+	cs.log.Printf("Reorg from %v at height %v to %v at height %v, reverted %v blocks", event.OldTip, event.OldHeight, event.NewTip, event.NewHeight, event.Depth)
+
+	if event.Depth >= deepReorgDepth {
+		msg := fmt.Sprintf("consensus reverted %v blocks in a reorg from height %v to %v", event.Depth, event.OldHeight, event.NewHeight)
+		cause := "Transactions, storage proofs and payouts of the reverted blocks might not be confirmed anymore."
+		cs.staticAlerter.RegisterAlert(modules.AlertIDConsensusDeepReorg, msg, cause, modules.SeverityWarning)
+		cs.blocksSinceDeepReorg = 1
+	}
+
+	for _, subscriber := range cs.reorgSubscribers {
+		subscriber.ProcessReorg(event)
+	}
+}
+
+// ReorgSubscribe adds a subscriber which is notified of every future reorg of
+// the consensus set.
+func (cs *ConsensusSet) ReorgSubscribe(subscriber modules.ReorgSubscriber) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	// Sanity check - subscriber should not be already subscribed.
+	for _, s := range cs.reorgSubscribers {
+		if s == subscriber {
+			build.Critical("refusing to double-subscribe reorg subscriber")
+		}
+	}
+	cs.reorgSubscribers = append(cs.reorgSubscribers, subscriber)
+	return nil
+}
+
+// ReorgUnsubscribe removes a reorg subscriber. If the subscriber is not
+// subscribed, no action is taken.
+func (cs *ConsensusSet) ReorgUnsubscribe(subscriber modules.ReorgSubscriber) {
+	if cs.tg.Add() != nil {
+		return
+	}
+	defer cs.tg.Done()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for i := range cs.reorgSubscribers {
+		if cs.reorgSubscribers[i] == subscriber {
+			cs.reorgSubscribers[i] = nil
+			cs.reorgSubscribers = append(cs.reorgSubscribers[:i], cs.reorgSubscribers[i+1:]...)
+			break
+		}
+	}
+}
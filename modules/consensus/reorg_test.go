@@ -0,0 +1,95 @@
+package consensus
+
+import (
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// reorgRecorder is a reorg subscriber which records the events it receives.
+type reorgRecorder struct {
+	events []modules.ReorgEvent
+}
+
+// ProcessReorg implements modules.ReorgSubscriber.
+func (rr *reorgRecorder) ProcessReorg(event modules.ReorgEvent) {
+	rr.events = append(rr.events, event)
+}
+
+// hasDeepReorgAlert returns whether the consensus set registered a deep reorg
+// alert.
+func hasDeepReorgAlert(cs *ConsensusSet) bool {
+	_, _, warn := cs.Alerts()
+	for _, alert := range warn {
+		if strings.Contains(alert.Msg, "in a reorg") {
+			return true
+		}
+	}
+	return false
+}
+
+// TestReorgEvents checks that reorg subscribers are notified of reorgs and
+// that deep reorgs register an alert.
+func TestReorgEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rs := createReorgSets(t.Name())
+	defer rs.Close()
+
+	var rr reorgRecorder
+	if err := rs.cstMain.cs.ReorgSubscribe(&rr); err != nil {
+		t.Fatal(err)
+	}
+
+	// Extending the chain doesn't cause a reorg.
+	if _, err := rs.cstMain.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.events) != 0 {
+		t.Fatal("unexpected reorg events", rr.events)
+	}
+
+	// Reorg to the alternative chain, which reverts the whole chain of the
+	// main consensus set.
+	oldTip := rs.cstMain.cs.CurrentBlock().ID()
+	oldHeight := rs.cstMain.cs.Height()
+	rs.extend()
+	if len(rr.events) != 1 {
+		t.Fatal("expected a single reorg event but got", len(rr.events))
+	}
+	event := rr.events[0]
+	if event.OldTip != oldTip || event.OldHeight != oldHeight || event.Depth != oldHeight || len(event.RevertedBlocks) != int(oldHeight) {
+		t.Fatal("unexpected old tip", event)
+	}
+	if event.RevertedBlocks[0] != oldTip || event.CommonAncestor != types.GenesisID {
+		t.Fatal("unexpected reverted blocks", event)
+	}
+	if event.NewTip != event.AppliedBlocks[len(event.AppliedBlocks)-1] || event.NewHeight > rs.cstMain.cs.Height() {
+		t.Fatal("unexpected new tip", event)
+	}
+	if !hasDeepReorgAlert(rs.cstMain.cs) {
+		t.Fatal("expected a deep reorg alert")
+	}
+
+	// The alert is unregistered once enough blocks are applied on top of the
+	// new chain.
+	for i := types.BlockHeight(0); i <= deepReorgAlertBlocks; i++ {
+		if _, err := rs.cstMain.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hasDeepReorgAlert(rs.cstMain.cs) {
+		t.Fatal("deep reorg alert wasn't unregistered")
+	}
+
+	// Unsubscribed subscribers are not notified anymore.
+	rs.cstMain.cs.ReorgUnsubscribe(&rr)
+	rs.restore()
+	if len(rr.events) != 1 {
+		t.Fatal("unsubscribed subscriber was notified", len(rr.events))
+	}
+}
@@ -0,0 +1,69 @@
+package consensus
+
+// snapshot.go contains functions to access the consensus database from
+// outside of the running consensus set. bolt locks the database file while it
+// is open for writing, so other processes can't open the database of a
+// running node. Instead, they can query a snapshot of the database which is
+// written without interrupting the consensus set.
+
+import (
+	"os"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// snapshotFilePermissions are the permissions of database snapshots.
+	snapshotFilePermissions = 0600
+
+	// snapshotTempSuffix is the suffix of the temporary file a snapshot is
+	// written to.
+	snapshotTempSuffix = "_temp"
+)
+
+// SnapshotDB writes a consistent copy of the consensus database to dst. The
+// copy is written within a single read transaction, so blocks are processed
+// while the snapshot is written. The database can't grow until the snapshot is
+// complete though. The snapshot is written to a temporary file first to make
+// sure that dst never contains a partial snapshot. The returned checkpoint can
+// be used by other nodes to verify the snapshot before syncing from it.
+func (cs *ConsensusSet) SnapshotDB(dst string) (cp modules.ConsensusCheckpoint, err error) {
+	err = cs.tg.Add()
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, err
+	}
+	defer cs.tg.Done()
+
+	tmp := dst + snapshotTempSuffix
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, os.RemoveAll(tmp))
+		}
+	}()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		if err := tx.CopyFile(tmp, snapshotFilePermissions); err != nil {
+			return err
+		}
+		cp = dbCheckpoint(tx)
+		return nil
+	})
+	if err != nil {
+		return modules.ConsensusCheckpoint{}, errors.AddContext(err, "unable to write snapshot")
+	}
+	return cp, os.Rename(tmp, dst)
+}
+
+// OpenDBReadOnly opens a consensus database in read-only mode. The database
+// must not be in use by a running consensus set. It is meant for tools which
+// query a snapshot written by SnapshotDB or the database of a stopped
+// node.
+func OpenDBReadOnly(filename string) (*persist.BoltDatabase, error) {
+	db, err := persist.OpenDatabaseReadOnly(dbMetadata, filename)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open consensus database")
+	}
+	return db, nil
+}
@@ -0,0 +1,78 @@
+package consensus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSnapshotDB checks that a snapshot of the database can be opened
+// read-only while the consensus set keeps processing blocks.
+func TestSnapshotDB(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The database of the running consensus set can't be opened.
+	_, err = OpenDBReadOnly(filepath.Join(cst.persistDir, modules.ConsensusDir, DatabaseFilename))
+	if err == nil {
+		t.Fatal("expected error opening database of running consensus set")
+	}
+
+	// Write a snapshot and open it.
+	dst := filepath.Join(cst.persistDir, "snapshot.db")
+	if _, err := cst.cs.SnapshotDB(dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dst + snapshotTempSuffix); !os.IsNotExist(err) {
+		t.Fatal("temporary snapshot file wasn't removed", err)
+	}
+	db, err := OpenDBReadOnly(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block. The snapshot should still be at the previous height.
+	height := cst.cs.Height()
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if cst.cs.Height() != height+1 {
+		t.Fatal("block wasn't mined")
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		if snapshotHeight := blockHeight(tx); snapshotHeight != height {
+			t.Errorf("snapshot has height %v, expected %v", snapshotHeight, height)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The snapshot can't be modified.
+	err = db.Update(func(tx *bolt.Tx) error {
+		return nil
+	})
+	if err != bolt.ErrDatabaseReadOnly {
+		t.Fatal("expected read-only error", err)
+	}
+}
@@ -8,10 +8,17 @@ import (
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 
 	siasync "go.sia.tech/siad/sync"
 )
 
+const (
+	// maxConsensusChangeBatch is the maximum number of consensus changes
+	// returned by a single call to ConsensusChangesSince.
+	maxConsensusChangeBatch = 1000
+)
+
 // computeConsensusChangeDiffs computes the ConsensusChangeDiffs for the
 // provided block.
 func computeConsensusChangeDiffs(pb *processedBlock, apply bool) modules.ConsensusChangeDiffs {
@@ -83,6 +90,8 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 		diffs := computeConsensusChangeDiffs(appliedBlock, true)
 		cc.AppliedDiffs = append(cc.AppliedDiffs, diffs)
 		cc.AppendDiffs(diffs)
+		commitment, _ := getStateCommitment(tx, appliedBlockID)
+		cc.AppliedStateCommitments = append(cc.AppliedStateCommitments, commitment)
 	}
 
 	// Grab the child target and the minimum valid child timestamp.
@@ -105,6 +114,16 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 	return cc, nil
 }
 
+// changeEntryHeight returns the height of the last block applied by the change
+// entry.
+func changeEntryHeight(tx *bolt.Tx, ce changeEntry) (types.BlockHeight, error) {
+	pb, err := getBlockMap(tx, ce.AppliedBlocks[len(ce.AppliedBlocks)-1])
+	if err != nil {
+		return 0, err
+	}
+	return pb.Height, nil
+}
+
 // updateSubscribers will inform all subscribers of a new update to the
 // consensus set. updateSubscribers does not alter the changelog, the changelog
 // must be updated beforehand.
@@ -114,10 +133,12 @@ func (cs *ConsensusSet) updateSubscribers(ce changeEntry) {
 	}
 	// Get the consensus change and send it to all subscribers.
 	var cc modules.ConsensusChange
+	var height types.BlockHeight
 	err := cs.db.View(func(tx *bolt.Tx) error {
 		// Compute the consensus change so it can be sent to subscribers.
 		var err error
 		cc, err = cs.computeConsensusChange(tx, ce)
+		height = blockHeight(tx)
 		return err
 	})
 	if err != nil {
@@ -130,8 +151,9 @@ func (cs *ConsensusSet) updateSubscribers(ce changeEntry) {
 		cs.log.Println("ConsensusChange with re-org detected: ", cc.ID, len(cc.RevertedBlocks))
 	}
 
+	cs.staticSubscriberMonitor.callSetTipHeight(height)
 	for _, subscriber := range cs.subscribers {
-		subscriber.ProcessConsensusChange(cc)
+		cs.processConsensusChange(subscriber, cc, height)
 	}
 }
 
@@ -145,6 +167,12 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 	if start == modules.ConsensusChangeRecent {
 		cs.mu.Lock()
 		defer cs.mu.Unlock()
+		_ = cs.db.View(func(tx *bolt.Tx) error {
+			height := blockHeight(tx)
+			cs.staticSubscriberMonitor.callSetTipHeight(height)
+			cs.staticSubscriberMonitor.callAdd(subscriber, height)
+			return nil
+		})
 		return cs.recentConsensusChangeID()
 	}
 
@@ -161,6 +189,7 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 			// the genesis block.
 			entry = cs.genesisEntry()
 			exists = true
+			cs.staticSubscriberMonitor.callAdd(subscriber, 0)
 		} else {
 			// The subscriber has provided an existing consensus change.
 			// Because the subscriber already has this consensus change,
@@ -175,6 +204,11 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 				// perform a rescan of the consensus set.
 				return modules.ErrInvalidConsensusChangeID
 			}
+			height, err := changeEntryHeight(tx, entry)
+			if err != nil {
+				return err
+			}
+			cs.staticSubscriberMonitor.callAdd(subscriber, height)
 			entry, exists = entry.NextEntry(tx)
 		}
 		return nil
@@ -196,6 +230,7 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 		// lock for too long.
 		cs.mu.RLock()
 		err = cs.db.View(func(tx *bolt.Tx) error {
+			cs.staticSubscriberMonitor.callSetTipHeight(blockHeight(tx))
 			for i := 0; i < 100 && exists; i++ {
 				latestChangeID = entry.ID()
 				select {
@@ -207,7 +242,11 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 				if err != nil {
 					return err
 				}
-				subscriber.ProcessConsensusChange(cc)
+				height, err := changeEntryHeight(tx, entry)
+				if err != nil {
+					return err
+				}
+				cs.processConsensusChange(subscriber, cc, height)
 				entry, exists = entry.NextEntry(tx)
 			}
 			return nil
@@ -242,13 +281,20 @@ func (cs *ConsensusSet) recentConsensusChangeID() (cid modules.ConsensusChangeID
 // As a special case, using an empty id as the start will have all the changes
 // sent to the modules starting with the genesis block.
 func (cs *ConsensusSet) ConsensusSetSubscribe(subscriber modules.ConsensusSetSubscriber, start modules.ConsensusChangeID,
-	cancel <-chan struct{}) error {
-	err := cs.tg.Add()
+	cancel <-chan struct{}) (err error) {
+	err = cs.tg.Add()
 	if err != nil {
 		return err
 	}
 	defer cs.tg.Done()
 
+	// Stop monitoring the subscriber if subscribing fails.
+	defer func() {
+		if err != nil {
+			cs.staticSubscriberMonitor.callRemove(subscriber)
+		}
+	}()
+
 	// Call managedInitializeSubscribe until the new module is up-to-date.
 	for {
 		start, err = cs.managedInitializeSubscribe(subscriber, start, cancel)
@@ -318,4 +364,61 @@ func (cs *ConsensusSet) Unsubscribe(subscriber modules.ConsensusSetSubscriber) {
 			break
 		}
 	}
+	cs.staticSubscriberMonitor.callRemove(subscriber)
+}
+
+// ConsensusChangesSince returns up to limit consensus changes which occurred
+// after the change with the provided id, together with a cursor to request the
+// next batch. If limit is not positive or exceeds maxConsensusChangeBatch,
+// maxConsensusChangeBatch is used instead.
+//
+// As a special case, using an empty id as the start will return the changes
+// starting with the genesis block. Using modules.ConsensusChangeRecent returns
+// an empty batch whose cursor is the most recent change.
+func (cs *ConsensusSet) ConsensusChangesSince(start modules.ConsensusChangeID, limit int) (modules.ConsensusChangeBatch, error) {
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusChangeBatch{}, err
+	}
+	defer cs.tg.Done()
+	if limit <= 0 || limit > maxConsensusChangeBatch {
+		limit = maxConsensusChangeBatch
+	}
+
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if start == modules.ConsensusChangeRecent {
+		recentID, err := cs.recentConsensusChangeID()
+		return modules.ConsensusChangeBatch{Cursor: recentID}, err
+	}
+
+	batch := modules.ConsensusChangeBatch{Cursor: start}
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		// Point 'entry' at the first change after start.
+		var entry changeEntry
+		var exists bool
+		if start == modules.ConsensusChangeBeginning {
+			entry, exists = cs.genesisEntry(), true
+		} else {
+			entry, exists = getEntry(tx, start)
+			if !exists {
+				return modules.ErrInvalidConsensusChangeID
+			}
+			entry, exists = entry.NextEntry(tx)
+		}
+
+		for ; exists && len(batch.Changes) < limit; entry, exists = entry.NextEntry(tx) {
+			cc, err := cs.computeConsensusChange(tx, entry)
+			if err != nil {
+				return err
+			}
+			batch.Changes = append(batch.Changes, cc)
+			batch.Cursor = cc.ID
+		}
+		batch.More = exists
+		return nil
+	})
+	if err != nil {
+		return modules.ConsensusChangeBatch{}, err
+	}
+	return batch, nil
 }
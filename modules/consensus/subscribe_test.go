@@ -312,3 +312,87 @@ func TestPerBlockDiffs(t *testing.T) {
 		}
 	}
 }
+
+// TestConsensusChangesSince checks that paging through the consensus changes
+// returns the same changes a subscriber receives.
+func TestConsensusChangesSince(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	ms := newMockSubscriber()
+	if err := cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, cst.cs.tg.StopChan()); err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.Unsubscribe(&ms)
+
+	// Page through the changes in batches of 3.
+	var changes []modules.ConsensusChange
+	cursor := modules.ConsensusChangeBeginning
+	for {
+		batch, err := cst.cs.ConsensusChangesSince(cursor, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(batch.Changes) > 3 {
+			t.Fatal("batch exceeds limit", len(batch.Changes))
+		}
+		changes = append(changes, batch.Changes...)
+		cursor = batch.Cursor
+		if !batch.More {
+			break
+		}
+	}
+	if len(changes) != len(ms.updates) {
+		t.Fatalf("expected %v changes, got %v", len(ms.updates), len(changes))
+	}
+	for i := range changes {
+		if changes[i].ID != ms.updates[i].ID || !reflect.DeepEqual(changes[i].AppliedBlocks, ms.updates[i].AppliedBlocks) {
+			t.Fatal("change mismatch at index", i)
+		}
+	}
+
+	// The last cursor is the most recent change and returns an empty batch.
+	recent, err := cst.cs.ConsensusChangesSince(modules.ConsensusChangeRecent, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recent.Cursor != cursor || len(recent.Changes) != 0 || recent.More {
+		t.Fatal("unexpected batch for the most recent change", recent)
+	}
+	batch, err := cst.cs.ConsensusChangesSince(cursor, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Cursor != cursor || len(batch.Changes) != 0 || batch.More {
+		t.Fatal("unexpected batch after the most recent change", batch)
+	}
+
+	// A new block is returned after the cursor.
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	batch, err = cst.cs.ConsensusChangesSince(cursor, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Changes) != 1 || batch.Changes[0].AppliedBlocks[0].ID() != cst.cs.CurrentBlock().ID() {
+		t.Fatal("new block wasn't returned", batch)
+	}
+
+	// Unknown IDs are rejected.
+	_, err = cst.cs.ConsensusChangesSince(modules.ConsensusChangeID{255, 255, 255}, 0)
+	if !errors.Contains(err, modules.ErrInvalidConsensusChangeID) {
+		t.Fatal("expected ErrInvalidConsensusChangeID, got", err)
+	}
+}
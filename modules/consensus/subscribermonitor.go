@@ -0,0 +1,269 @@
+package consensus
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// subscriberLagAlertThreshold is the number of blocks a subscriber can
+	// fall behind the consensus set before an alert is registered.
+	subscriberLagAlertThreshold = build.Select(build.Var{
+		Standard: types.BlockHeight(6),
+		Dev:      types.BlockHeight(6),
+		Testing:  types.BlockHeight(3),
+	}).(types.BlockHeight)
+
+	// subscriberStallAlertThreshold is the amount of time a subscriber can
+	// spend on a single consensus change before an alert is registered.
+	subscriberStallAlertThreshold = build.Select(build.Var{
+		Standard: 2 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// subscriberMonitorInterval is the interval at which the subscribers are
+	// checked for stalls.
+	subscriberMonitorInterval = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// subscriberMonitor tracks how well the subscribers of the consensus set
+	// keep up with the consensus changes. It uses its own lock since it needs
+	// to be accessible while the consensus set is updating its subscribers.
+	subscriberMonitor struct {
+		subscribers []*subscriberStats
+		tipHeight   types.BlockHeight
+
+		mu            sync.Mutex
+		staticAlerter *modules.GenericAlerter
+	}
+
+	// subscriberStats are the statistics of a single subscriber.
+	subscriberStats struct {
+		subscriber modules.ConsensusSetSubscriber
+		module     string
+
+		height          types.BlockHeight
+		processingSince time.Time
+
+		updates      uint64
+		lastLatency  time.Duration
+		maxLatency   time.Duration
+		totalLatency time.Duration
+	}
+)
+
+// newSubscriberMonitor creates a new subscriberMonitor which registers its
+// alerts with the provided alerter.
+func newSubscriberMonitor(alerter *modules.GenericAlerter) *subscriberMonitor {
+	return &subscriberMonitor{
+		staticAlerter: alerter,
+	}
+}
+
+// subscriberModule returns the name of the package that implements the
+// subscriber.
+func subscriberModule(subscriber modules.ConsensusSetSubscriber) string {
+	t := reflect.TypeOf(subscriber)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return path.Base(t.PkgPath())
+}
+
+// stats returns the stats of the subscriber or nil if the subscriber is not
+// monitored.
+func (sm *subscriberMonitor) stats(subscriber modules.ConsensusSetSubscriber) *subscriberStats {
+	for _, s := range sm.subscribers {
+		if s.subscriber == subscriber {
+			return s
+		}
+	}
+	return nil
+}
+
+// callAdd starts monitoring the subscriber, which has processed all changes up
+// to the provided height. Subscribers are only added once.
+func (sm *subscriberMonitor) callAdd(subscriber modules.ConsensusSetSubscriber, height types.BlockHeight) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.stats(subscriber) != nil {
+		return
+	}
+	// Make sure the module names are unique since they are used for the
+	// alert ids.
+	module := subscriberModule(subscriber)
+	name := module
+	for i := 2; ; i++ {
+		unique := true
+		for _, s := range sm.subscribers {
+			unique = unique && s.module != name
+		}
+		if unique {
+			break
+		}
+		name = fmt.Sprintf("%v#%v", module, i)
+	}
+	sm.subscribers = append(sm.subscribers, &subscriberStats{
+		subscriber: subscriber,
+		module:     name,
+		height:     height,
+	})
+}
+
+// callRemove stops monitoring the subscriber and removes its alert.
+func (sm *subscriberMonitor) callRemove(subscriber modules.ConsensusSetSubscriber) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for i, s := range sm.subscribers {
+		if s.subscriber == subscriber {
+			sm.staticAlerter.UnregisterAlert(modules.AlertIDConsensusSubscriberLag(s.module))
+			sm.subscribers = append(sm.subscribers[:i], sm.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// callSetTipHeight updates the current height of the consensus set.
+func (sm *subscriberMonitor) callSetTipHeight(height types.BlockHeight) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.tipHeight = height
+}
+
+// callStartProcessing marks the subscriber as processing a consensus change.
+func (sm *subscriberMonitor) callStartProcessing(subscriber modules.ConsensusSetSubscriber, now time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if s := sm.stats(subscriber); s != nil {
+		s.processingSince = now
+	}
+}
+
+// callFinishProcessing records that the subscriber processed the consensus
+// change at the provided height.
+func (sm *subscriberMonitor) callFinishProcessing(subscriber modules.ConsensusSetSubscriber, height types.BlockHeight, now time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	s := sm.stats(subscriber)
+	if s == nil {
+		return
+	}
+	latency := now.Sub(s.processingSince)
+	s.height = height
+	s.processingSince = time.Time{}
+	s.updates++
+	s.lastLatency = latency
+	s.totalLatency += latency
+	if latency > s.maxLatency {
+		s.maxLatency = latency
+	}
+	sm.updateAlert(s, now)
+}
+
+// callCheckAlerts updates the alerts of all subscribers.
+func (sm *subscriberMonitor) callCheckAlerts(now time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, s := range sm.subscribers {
+		sm.updateAlert(s, now)
+	}
+}
+
+// callStats returns the stats of all monitored subscribers.
+func (sm *subscriberMonitor) callStats(now time.Time) []modules.ConsensusSubscriberStats {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	stats := make([]modules.ConsensusSubscriberStats, 0, len(sm.subscribers))
+	for _, s := range sm.subscribers {
+		css := modules.ConsensusSubscriberStats{
+			Module:       s.module,
+			Height:       s.height,
+			BlocksBehind: sm.blocksBehind(s),
+			Processing:   !s.processingSince.IsZero(),
+			Updates:      s.updates,
+			LastLatency:  s.lastLatency,
+			MaxLatency:   s.maxLatency,
+		}
+		if css.Processing {
+			css.ProcessingTime = now.Sub(s.processingSince)
+		}
+		if s.updates > 0 {
+			css.AverageLatency = s.totalLatency / time.Duration(s.updates)
+		}
+		stats = append(stats, css)
+	}
+	return stats
+}
+
+// blocksBehind returns the number of blocks the subscriber is behind the
+// consensus set.
+func (sm *subscriberMonitor) blocksBehind(s *subscriberStats) types.BlockHeight {
+	if s.height >= sm.tipHeight {
+		return 0
+	}
+	return sm.tipHeight - s.height
+}
+
+// updateAlert registers an alert for the subscriber if it fell too far behind
+// or spent too much time on a single consensus change and unregisters it
+// otherwise.
+func (sm *subscriberMonitor) updateAlert(s *subscriberStats, now time.Time) {
+	id := modules.AlertIDConsensusSubscriberLag(s.module)
+	cause := "Subscribers are updated one after another, so all modules wait for the slow subscriber."
+	if behind := sm.blocksBehind(s); behind > subscriberLagAlertThreshold {
+		msg := fmt.Sprintf("consensus subscriber '%v' is %v blocks behind", s.module, behind)
+		sm.staticAlerter.RegisterAlert(id, msg, cause, modules.SeverityWarning)
+		return
+	}
+	if !s.processingSince.IsZero() && now.Sub(s.processingSince) > subscriberStallAlertThreshold {
+		msg := fmt.Sprintf("consensus subscriber '%v' has been processing a consensus change for %v", s.module, now.Sub(s.processingSince).Round(time.Second))
+		sm.staticAlerter.RegisterAlert(id, msg, cause, modules.SeverityWarning)
+		return
+	}
+	sm.staticAlerter.UnregisterAlert(id)
+}
+
+// processConsensusChange sends the consensus change, which brings the
+// subscriber to the provided height, to the subscriber and records how long
+// it took.
+func (cs *ConsensusSet) processConsensusChange(subscriber modules.ConsensusSetSubscriber, cc modules.ConsensusChange, height types.BlockHeight) {
+	cs.staticSubscriberMonitor.callStartProcessing(subscriber, time.Now())
+	subscriber.ProcessConsensusChange(cc)
+	cs.staticSubscriberMonitor.callFinishProcessing(subscriber, height, time.Now())
+}
+
+// threadedMonitorSubscribers periodically checks the subscribers for stalls.
+// This is necessary since a stalled subscriber never finishes processing and
+// therefore never triggers a check by itself.
+func (cs *ConsensusSet) threadedMonitorSubscribers() {
+	for {
+		select {
+		case <-cs.tg.StopChan():
+			return
+		case <-time.After(subscriberMonitorInterval):
+		}
+		cs.staticSubscriberMonitor.callCheckAlerts(time.Now())
+	}
+}
+
+// SubscriberStats returns the processing statistics of the consensus set's
+// subscribers in the order in which they subscribed.
+func (cs *ConsensusSet) SubscriberStats() []modules.ConsensusSubscriberStats {
+	return cs.staticSubscriberMonitor.callStats(time.Now())
+}
@@ -0,0 +1,153 @@
+package consensus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// lagAlerts returns the subscriber alerts registered with the alerter.
+func lagAlerts(alerter *modules.GenericAlerter) []modules.Alert {
+	var alerts []modules.Alert
+	_, _, warn := alerter.Alerts()
+	for _, a := range warn {
+		if strings.HasPrefix(a.Msg, "consensus subscriber") {
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts
+}
+
+// TestSubscriberMonitor probes the stats and alerts of the subscriberMonitor.
+func TestSubscriberMonitor(t *testing.T) {
+	alerter := modules.NewAlerter("consensus")
+	sm := newSubscriberMonitor(alerter)
+	ms1, ms2 := newMockSubscriber(), newMockSubscriber()
+	sm.callSetTipHeight(10)
+	sm.callAdd(&ms1, 10)
+	sm.callAdd(&ms2, 0)
+	sm.callAdd(&ms1, 0)
+
+	// Duplicate subscribers are only added once and module names are unique.
+	stats := sm.callStats(time.Now())
+	if len(stats) != 2 || stats[0].Module != "consensus" || stats[1].Module != "consensus#2" {
+		t.Fatal("unexpected stats", stats)
+	}
+	if stats[0].BlocksBehind != 0 || stats[1].BlocksBehind != 10 {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// The second subscriber is too far behind.
+	now := time.Now()
+	sm.callCheckAlerts(now)
+	warn := lagAlerts(alerter)
+	if len(warn) != 1 || warn[0].Module != "consensus" || !strings.Contains(warn[0].Msg, "'consensus#2' is 10 blocks behind") {
+		t.Fatal("unexpected alerts", warn)
+	}
+
+	// Catching up removes the alert.
+	sm.callStartProcessing(&ms2, now)
+	sm.callFinishProcessing(&ms2, 10, now.Add(time.Second))
+	if warn := lagAlerts(alerter); len(warn) != 0 {
+		t.Fatal("unexpected alerts", warn)
+	}
+
+	// A subscriber which takes too long to process a change is reported
+	// while it is still processing.
+	sm.callSetTipHeight(11)
+	sm.callStartProcessing(&ms1, now)
+	sm.callCheckAlerts(now.Add(subscriberStallAlertThreshold + time.Second))
+	warn = lagAlerts(alerter)
+	if len(warn) != 1 || !strings.Contains(warn[0].Msg, "'consensus' has been processing") {
+		t.Fatal("unexpected alerts", warn)
+	}
+	stats = sm.callStats(now.Add(time.Minute))
+	if !stats[0].Processing || stats[0].ProcessingTime != time.Minute || stats[0].BlocksBehind != 1 {
+		t.Fatal("unexpected stats", stats[0])
+	}
+	sm.callFinishProcessing(&ms1, 11, now.Add(3*time.Second))
+	if warn := lagAlerts(alerter); len(warn) != 0 {
+		t.Fatal("unexpected alerts", warn)
+	}
+
+	// Check the latencies.
+	sm.callStartProcessing(&ms1, now)
+	sm.callFinishProcessing(&ms1, 11, now.Add(time.Second))
+	stats = sm.callStats(now)
+	if s := stats[0]; s.Processing || s.Updates != 2 || s.LastLatency != time.Second || s.MaxLatency != 3*time.Second || s.AverageLatency != 2*time.Second {
+		t.Fatal("unexpected stats", s)
+	}
+
+	// Removing a subscriber removes its stats.
+	sm.callRemove(&ms1)
+	stats = sm.callStats(now)
+	if len(stats) != 1 || stats[0].Module != "consensus#2" {
+		t.Fatal("unexpected stats", stats)
+	}
+}
+
+// TestSubscriberStats checks that the consensus set reports the stats of its
+// subscribers.
+func TestSubscriberStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Subscribe a mock subscriber from the beginning.
+	ms := newMockSubscriber()
+	err = cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, cst.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := func() (modules.ConsensusSubscriberStats, bool) {
+		for _, s := range cst.cs.SubscriberStats() {
+			if s.Module == "consensus" {
+				return s, true
+			}
+		}
+		return modules.ConsensusSubscriberStats{}, false
+	}
+	s, ok := find()
+	if !ok {
+		t.Fatal("mock subscriber not found", cst.cs.SubscriberStats())
+	}
+	if s.Updates != uint64(len(ms.updates)) || s.Height != cst.cs.Height() || s.BlocksBehind != 0 || s.Processing {
+		t.Fatal("unexpected stats", s)
+	}
+
+	// The other modules of the tester are monitored as well.
+	modulesFound := make(map[string]bool)
+	for _, s := range cst.cs.SubscriberStats() {
+		modulesFound[s.Module] = true
+	}
+	if !modulesFound["wallet"] || !modulesFound["transactionpool"] {
+		t.Fatal("expected wallet and transactionpool to be monitored", modulesFound)
+	}
+
+	// Mining a block updates the stats.
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	s2, _ := find()
+	if s2.Updates != s.Updates+1 || s2.Height != s.Height+1 {
+		t.Fatal("unexpected stats", s2)
+	}
+
+	// Unsubscribing removes the stats.
+	cst.cs.Unsubscribe(&ms)
+	if _, ok := find(); ok {
+		t.Fatal("stats weren't removed")
+	}
+}
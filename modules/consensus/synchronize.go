@@ -90,6 +90,30 @@ func isTimeoutErr(err error) bool {
 	return (err.Error() == "Read timeout" || err.Error() == "Write timeout")
 }
 
+// isInvalidBlockErr returns true if err, which was returned by the validation
+// of a block or header, means that the block is invalid. Blocks which are
+// known, orphaned or from the future are not considered invalid since honest
+// peers relay them too.
+func isInvalidBlockErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errors.Contains(err, modules.ErrBlockKnown) &&
+		!errors.Contains(err, modules.ErrNonExtendingBlock) &&
+		!errors.Contains(err, errOrphan) &&
+		!errors.Contains(err, ErrFutureTimestamp) &&
+		!errors.Contains(err, ErrExtremeFutureTimestamp) &&
+		!errors.Contains(err, errNoBlockMap)
+}
+
+// reportInvalidBlock reports the peer at addr to the gateway if err means that
+// the peer relayed an invalid block.
+func (cs *ConsensusSet) reportInvalidBlock(addr modules.NetAddress, err error) {
+	if isInvalidBlockErr(err) {
+		cs.gateway.RecordPeerMisbehavior(addr, modules.PeerMisbehaviorInvalidBlock)
+	}
+}
+
 // blockHistory returns up to 32 block ids, starting with recent blocks and
 // then proving exponentially increasingly less recent blocks. The genesis
 // block is always included as the last block. This block history can be used
@@ -132,6 +156,33 @@ func blockHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID) {
 	return blockIDs
 }
 
+// missingBlocksStart finds the most recent block of knownBlocks in the current
+// path and returns the height of its child. false is returned if none of the
+// blocks is in the current path or if the caller already has the current
+// block.
+func missingBlocksStart(tx *bolt.Tx, knownBlocks [32]types.BlockID) (types.BlockHeight, bool) {
+	csHeight := blockHeight(tx)
+	for _, id := range knownBlocks {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			continue
+		}
+		pathID, err := getPath(tx, pb.Height)
+		if err != nil {
+			continue
+		}
+		if pathID != pb.Block.ID() {
+			continue
+		}
+		if pb.Height == csHeight {
+			return 0, false
+		}
+		// Start from the child of the common block.
+		return pb.Height + 1, true
+	}
+	return 0, false
+}
+
 // managedReceiveBlocks is the calling end of the SendBlocks RPC, without the
 // threadgroup wrapping.
 func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr error) {
@@ -154,10 +205,10 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 
 	// Check whether this RPC has timed out with the remote peer at the end of
 	// the fuction, and if so, return a custom error to signal that a new peer
-	// needs to be chosen.
+	// needs to be chosen. A stall detected by the gateway counts as a timeout.
 	stalled := true
 	defer func() {
-		if isTimeoutErr(returnErr) && stalled {
+		if (isTimeoutErr(returnErr) || errors.Contains(returnErr, modules.ErrRPCStalled)) && stalled {
 			returnErr = errSendBlocksStalled
 		}
 	}()
@@ -230,6 +281,7 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 		// sharing is implemented, block already in database should also be
 		// ignored.
 		if acceptErr != nil && !errors.Contains(acceptErr, modules.ErrNonExtendingBlock) && !errors.Contains(acceptErr, modules.ErrBlockKnown) {
+			cs.reportInvalidBlock(conn.RPCAddr(), acceptErr)
 			return acceptErr
 		}
 	}
@@ -293,32 +345,11 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 	}
 
 	// Find the most recent block from knownBlocks in the current path.
-	found := false
 	var start types.BlockHeight
-	var csHeight types.BlockHeight
+	var found bool
 	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
-		csHeight = blockHeight(tx)
-		for _, id := range knownBlocks {
-			pb, err := getBlockMap(tx, id)
-			if err != nil {
-				continue
-			}
-			pathID, err := getPath(tx, pb.Height)
-			if err != nil {
-				continue
-			}
-			if pathID != pb.Block.ID() {
-				continue
-			}
-			if pb.Height == csHeight {
-				break
-			}
-			found = true
-			// Start from the child of the common block.
-			start = pb.Height + 1
-			break
-		}
+		start, found = missingBlocksStart(tx, knownBlocks)
 		return nil
 	})
 	cs.mu.RUnlock()
@@ -449,6 +480,7 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 		}()
 		return nil
 	} else if err != nil {
+		cs.reportInvalidBlock(conn.RPCAddr(), err)
 		return err
 	}
 
@@ -456,7 +488,7 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err = cs.gateway.RPC(conn.RPCAddr(), "SendBlk", cs.managedReceiveBlock(h.ID()))
+		err = cs.managedFetchRelayedBlock(conn.RPCAddr(), h.ID())
 		if err != nil {
 			cs.log.Debugln("WARN: failed to get header's corresponding block:", err)
 		}
@@ -531,15 +563,18 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 			cs.managedBroadcastBlock(block)
 		}
 		if err != nil {
+			cs.reportInvalidBlock(conn.RPCAddr(), err)
 			return err
 		}
 		return nil
 	}
 }
 
-// managedInitialBlockchainDownload performs the IBD on outbound peers. Blocks
-// are downloaded from one peer at a time in 5 minute intervals, so as to
-// prevent any one peer from significantly slowing down IBD.
+// managedInitialBlockchainDownload performs the IBD on outbound peers. The
+// missing blocks are downloaded headers-first from all outbound peers in
+// parallel. Afterwards, blocks are requested from one peer at a time in 5
+// minute intervals, which syncs with peers that don't support headers-first
+// and determines whether the peers consider the consensus set synced.
 //
 // NOTE: IBD will succeed right now when each peer has a different blockchain.
 // The height and the block id of the remote peers' current blocks are not
@@ -559,6 +594,30 @@ func (cs *ConsensusSet) managedInitialBlockchainDownload() error {
 	for {
 		numOutboundSynced = 0
 		numOutboundNotSynced = 0
+
+		// We only sync on outbound peers, see below.
+		var outbound []modules.NetAddress
+		for _, p := range cs.gateway.Peers() {
+			if !p.Inbound {
+				outbound = append(outbound, p.NetAddress)
+			}
+		}
+		err := func() error {
+			err := cs.tg.Add()
+			if err != nil {
+				return err
+			}
+			defer cs.tg.Done()
+			err = cs.managedHeadersFirstSync(outbound)
+			if err != nil {
+				cs.log.Printf("WARN: headers-first sync failed, falling back to SendBlocks: %v", err)
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+
 		for _, p := range cs.gateway.Peers() {
 			// We only sync on outbound peers at first to make IBD less susceptible to
 			// fast-mining and other attacks, as outbound peers are more difficult to
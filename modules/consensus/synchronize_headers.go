@@ -0,0 +1,597 @@
+package consensus
+
+// synchronize_headers.go contains the headers-first initial blockchain
+// download. The headers of the missing blocks are fetched from a single peer
+// and checked before any block is downloaded. The blocks are then downloaded
+// in chunks from multiple peers in parallel and applied in order. Peers which
+// don't support the SendHeaders and SendBlks RPCs are still synchronized with
+// through the SendBlocks RPC.
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	errHeaderChainBroken   = errors.New("headers don't form a chain")
+	errHeaderParentUnknown = errors.New("parent of the first header is unknown")
+	errHeaderTarget        = errors.New("header doesn't meet the target bound of its height")
+	errBlksMismatch        = errors.New("peer sent blocks which don't match the requested ids")
+	errBlksRequestTooLarge = errors.New("too many blocks requested")
+	errBlockDownloadFailed = errors.New("blocks couldn't be downloaded from any peer")
+
+	// maxHeadersPerBatch is the maximum number of headers sent in response
+	// to a single SendHeaders RPC.
+	maxHeadersPerBatch = build.Select(build.Var{
+		Standard: types.BlockHeight(2000),
+		Dev:      types.BlockHeight(200),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+
+	// maxHeadersPerRound is the maximum number of headers which are fetched
+	// before the corresponding blocks are downloaded.
+	maxHeadersPerRound = build.Select(build.Var{
+		Standard: 20000,
+		Dev:      2000,
+		Testing:  20,
+	}).(int)
+
+	// maxParallelBlockDownloads is the maximum number of peers blocks are
+	// downloaded from in parallel.
+	maxParallelBlockDownloads = build.Select(build.Var{
+		Standard: 8,
+		Dev:      4,
+		Testing:  3,
+	}).(int)
+
+	// blockDownloadWindow is the maximum number of chunks which are
+	// downloaded ahead of the next chunk that is applied. It limits the
+	// number of blocks that are kept in memory.
+	blockDownloadWindow = build.Select(build.Var{
+		Standard: 32,
+		Dev:      16,
+		Testing:  4,
+	}).(int)
+
+	// ibdCommitBlocks is the number of downloaded blocks whose diffs are
+	// committed in a single database transaction. Committing the diffs of
+	// many blocks at once is a lot faster than committing every chunk on its
+	// own. Since a transaction is committed atomically, a crash only loses the
+	// blocks of the current batch and the next synchronization resumes from
+	// the last committed block.
+	ibdCommitBlocks = build.Select(build.Var{
+		Standard: 500,
+		Dev:      100,
+		Testing:  6,
+	}).(int)
+
+	// sendHeadersTimeout is the timeout for the SendHeaders RPC.
+	sendHeadersTimeout = build.Select(build.Var{
+		Standard: 60 * time.Second,
+		Dev:      20 * time.Second,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// sendBlksTimeout is the timeout for the SendBlks RPC.
+	sendBlksTimeout = build.Select(build.Var{
+		Standard: 120 * time.Second,
+		Dev:      30 * time.Second,
+		Testing:  4 * time.Second,
+	}).(time.Duration)
+)
+
+// headerLocator returns the block ids sent to a peer to request the headers
+// following tip. The ids of the block history are used to find a common
+// parent if tip isn't known to the peer.
+func headerLocator(tip types.BlockID, history [32]types.BlockID) [32]types.BlockID {
+	locator := history
+	if tip != history[0] {
+		copy(locator[1:31], history[:30])
+		locator[0] = tip
+	}
+	return locator
+}
+
+// easiestChildTarget returns the easiest target that the block at height can
+// have if the block at height-1 had the provided target. The bound considers
+// the maximum adjustment of both difficulty algorithms and is lifted at the
+// ASIC hardfork where the target isn't clamped.
+func easiestChildTarget(target types.Target, height types.BlockHeight) types.Target {
+	if height >= types.ASICHardforkHeight && height <= types.ASICHardforkHeight+2 {
+		return types.RootTarget
+	}
+	if height <= types.OakHardforkBlock+2 && (height-1)%(types.TargetWindow/2) == 0 {
+		target = types.RatToTarget(new(big.Rat).Mul(target.Rat(), types.MaxTargetAdjustmentUp))
+	}
+	target = target.MulDifficulty(types.OakMaxDrop)
+	if target.Cmp(types.RootTarget) > 0 {
+		return types.RootTarget
+	}
+	return target
+}
+
+// checkHeaderChain checks that the headers form a chain which starts at the
+// provided parent and that every header meets a bound of the target at its
+// height. The bound starts at the exact target of the first header and is
+// loosened by the maximum difficulty adjustment for every following header.
+// The headers are checked fully once their blocks are accepted.
+func checkHeaderChain(parent *processedBlock, headers []types.BlockHeader) error {
+	parentID := parent.Block.ID()
+	target := parent.ChildTarget
+	for i, h := range headers {
+		if h.ParentID != parentID {
+			return errHeaderChainBroken
+		}
+		height := parent.Height + types.BlockHeight(i) + 1
+		if i > 0 {
+			target = easiestChildTarget(target, height)
+		}
+		if !checkHeaderTarget(h, target) {
+			return errHeaderTarget
+		}
+		parentID = h.ID()
+	}
+	return nil
+}
+
+// rpcSendHeaders is the receiving end of the SendHeaders RPC. Like
+// SendBlocks, it receives 32 block ids and uses the most recent known id as
+// the starting point. It responds with up to 'maxHeadersPerBatch' headers of
+// the current path and a boolean indicating whether more headers are
+// available.
+func (cs *ConsensusSet) rpcSendHeaders(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	var knownBlocks [32]types.BlockID
+	err = encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+
+	var headers []types.BlockHeader
+	var moreAvailable bool
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		start, found := missingBlocksStart(tx, knownBlocks)
+		if !found {
+			return nil
+		}
+		height := blockHeight(tx)
+		for i := start; i <= height && i < start+maxHeadersPerBatch; i++ {
+			id, err := getPath(tx, i)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			headers = append(headers, pb.Block.Header())
+		}
+		moreAvailable = start+maxHeadersPerBatch <= height
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := encoding.WriteObject(conn, headers); err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, moreAvailable)
+}
+
+// rpcSendBlks is the receiving end of the SendBlks RPC. It receives up to
+// 'MaxCatchUpBlocks' block ids and responds with the corresponding blocks.
+func (cs *ConsensusSet) rpcSendBlks(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendBlksTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	var ids []types.BlockID
+	err = encoding.ReadObject(conn, &ids, uint64(MaxCatchUpBlocks)*crypto.HashSize+8)
+	if err != nil {
+		return err
+	}
+	if types.BlockHeight(len(ids)) > MaxCatchUpBlocks {
+		return errBlksRequestTooLarge
+	}
+
+	blocks := make([]types.Block, 0, len(ids))
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, pb.Block)
+		}
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, blocks)
+}
+
+// receiveHeaders returns an RPCFunc which is the calling end of the
+// SendHeaders RPC. It requests the headers following tip and passes them to
+// fn.
+func receiveHeaders(locator [32]types.BlockID, fn func([]types.BlockHeader, bool)) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		err := conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, locator); err != nil {
+			return err
+		}
+		var headers []types.BlockHeader
+		var moreAvailable bool
+		err = encoding.ReadObject(conn, &headers, uint64(maxHeadersPerBatch)*types.BlockHeaderSize+8)
+		if err != nil {
+			return err
+		}
+		if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
+			return err
+		}
+		fn(headers, moreAvailable)
+		return nil
+	}
+}
+
+// receiveBlks returns an RPCFunc which is the calling end of the SendBlks
+// RPC. It requests the blocks with the provided ids and passes them to fn
+// after checking that they match the ids.
+func receiveBlks(ids []types.BlockID, fn func([]types.Block)) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		err := conn.SetDeadline(time.Now().Add(sendBlksTimeout))
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, ids); err != nil {
+			return err
+		}
+		var blocks []types.Block
+		err = encoding.ReadObject(conn, &blocks, uint64(len(ids))*types.BlockSizeLimit+8)
+		if err != nil {
+			return err
+		}
+		if len(blocks) != len(ids) {
+			return errBlksMismatch
+		}
+		for i := range blocks {
+			if blocks[i].ID() != ids[i] {
+				return errBlksMismatch
+			}
+		}
+		fn(blocks)
+		return nil
+	}
+}
+
+// managedFetchHeaders fetches up to 'maxHeadersPerRound' headers following
+// the current block from the peer and checks them.
+func (cs *ConsensusSet) managedFetchHeaders(addr modules.NetAddress) ([]types.BlockHeader, error) {
+	var history [32]types.BlockID
+	cs.mu.RLock()
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		history = blockHistory(tx)
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []types.BlockHeader
+	moreAvailable := true
+	for moreAvailable && len(headers) < maxHeadersPerRound {
+		tip := history[0]
+		if len(headers) > 0 {
+			tip = headers[len(headers)-1].ID()
+		}
+		var batch []types.BlockHeader
+		err := cs.gateway.RPC(addr, "SendHeaders", receiveHeaders(headerLocator(tip, history), func(hs []types.BlockHeader, more bool) {
+			batch, moreAvailable = hs, more
+		}))
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if len(headers) > 0 && batch[0].ParentID != tip {
+			return nil, errHeaderChainBroken
+		}
+		headers = append(headers, batch...)
+	}
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	// Check the headers against their known parent.
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		parent, err := getBlockMap(tx, headers[0].ParentID)
+		if err != nil {
+			return errHeaderParentUnknown
+		}
+		return checkHeaderChain(parent, headers)
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// blockChunk is a chunk of consecutive blocks which is downloaded from a
+// single peer.
+type blockChunk struct {
+	index    int
+	ids      []types.BlockID
+	blocks   []types.Block
+	failures int
+}
+
+// managedDownloadBlocks downloads the blocks of the headers from the peers in
+// parallel and applies them in order. Every peer downloads one chunk at a
+// time. A chunk which fails to download is retried with another peer and a
+// peer whose download fails isn't used again. true is returned if the blocks
+// extended the current path.
+func (cs *ConsensusSet) managedDownloadBlocks(peers []modules.NetAddress, headers []types.BlockHeader) (extended bool, _ error) {
+	if len(peers) > maxParallelBlockDownloads {
+		peers = peers[:maxParallelBlockDownloads]
+	}
+
+	var pending []*blockChunk
+	for i := 0; i < len(headers); i += int(MaxCatchUpBlocks) {
+		end := i + int(MaxCatchUpBlocks)
+		if end > len(headers) {
+			end = len(headers)
+		}
+		chunk := &blockChunk{index: len(pending)}
+		for _, h := range headers[i:end] {
+			chunk.ids = append(chunk.ids, h.ID())
+		}
+		pending = append(pending, chunk)
+	}
+	numChunks := len(pending)
+
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	downloaded := make(map[int]*blockChunk)
+	next := 0
+	done := false
+	activeWorkers := len(peers)
+
+	worker := func(addr modules.NetAddress) {
+		defer func() {
+			mu.Lock()
+			activeWorkers--
+			cond.Broadcast()
+			mu.Unlock()
+		}()
+		if err := cs.tg.Add(); err != nil {
+			return
+		}
+		defer cs.tg.Done()
+
+		for {
+			// Wait for a chunk within the download window.
+			mu.Lock()
+			for !done && (len(pending) == 0 || pending[0].index >= next+blockDownloadWindow) {
+				cond.Wait()
+			}
+			if done {
+				mu.Unlock()
+				return
+			}
+			chunk := pending[0]
+			pending = pending[1:]
+			mu.Unlock()
+
+			err := cs.gateway.RPC(addr, "SendBlks", receiveBlks(chunk.ids, func(blocks []types.Block) {
+				chunk.blocks = blocks
+			}))
+
+			mu.Lock()
+			if err != nil {
+				cs.log.Debugf("WARN: failed to download blocks from %v: %v", addr, err)
+				chunk.failures++
+				if chunk.failures >= len(peers) {
+					done = true
+				}
+				pending = append(pending, chunk)
+				sort.Slice(pending, func(i, j int) bool {
+					return pending[i].index < pending[j].index
+				})
+				cond.Broadcast()
+				mu.Unlock()
+				return
+			}
+			downloaded[chunk.index] = chunk
+			cond.Broadcast()
+			mu.Unlock()
+		}
+	}
+	for _, addr := range peers {
+		go worker(addr)
+	}
+	defer func() {
+		mu.Lock()
+		done = true
+		cond.Broadcast()
+		mu.Unlock()
+	}()
+
+	// Apply the chunks in order, committing up to ibdCommitBlocks blocks at
+	// once.
+	for next < numChunks {
+		var batch []*blockChunk
+		numBlocks := 0
+		downloadFailed := false
+		for next < numChunks && numBlocks < ibdCommitBlocks {
+			mu.Lock()
+			chunk, ok := downloaded[next]
+			for !ok && !done && activeWorkers > 0 {
+				cond.Wait()
+				chunk, ok = downloaded[next]
+			}
+			if !ok {
+				mu.Unlock()
+				downloadFailed = true
+				break
+			}
+			delete(downloaded, next)
+			next++
+			cond.Broadcast()
+			mu.Unlock()
+
+			batch = append(batch, chunk)
+			numBlocks += len(chunk.blocks)
+		}
+
+		// Apply the chunks which were downloaded before a download failed
+		// to not waste them.
+		chainExtended, err := cs.managedApplyBlockChunks(batch)
+		if chainExtended {
+			extended = true
+		}
+		if err != nil {
+			return extended, errors.AddContext(err, "failed to accept downloaded blocks")
+		}
+		if downloadFailed {
+			return extended, errBlockDownloadFailed
+		}
+	}
+	return extended, nil
+}
+
+// managedApplyBlockChunks applies the blocks of consecutive chunks in a single
+// database transaction. If the transaction fails because of an invalid block,
+// the chunks are applied one at a time to keep the valid blocks in front of
+// the invalid one. true is returned if the blocks extended the current path.
+func (cs *ConsensusSet) managedApplyBlockChunks(chunks []*blockChunk) (bool, error) {
+	var blocks []types.Block
+	for _, chunk := range chunks {
+		blocks = append(blocks, chunk.blocks...)
+	}
+	if len(blocks) == 0 {
+		return false, nil
+	}
+	extended, err := cs.managedAcceptBlocks(blocks)
+	if err == nil || errors.Contains(err, modules.ErrNonExtendingBlock) || errors.Contains(err, modules.ErrBlockKnown) {
+		return extended, nil
+	}
+	if len(chunks) == 1 {
+		return false, err
+	}
+
+	// The transaction was rolled back, retry chunk by chunk.
+	cs.log.Debugln("WARN: failed to apply batch of downloaded blocks, applying chunks one at a time:", err)
+	extended = false
+	for _, chunk := range chunks {
+		chainExtended, err := cs.managedAcceptBlocks(chunk.blocks)
+		if chainExtended {
+			extended = true
+		}
+		if err != nil && !errors.Contains(err, modules.ErrNonExtendingBlock) && !errors.Contains(err, modules.ErrBlockKnown) {
+			return extended, err
+		}
+	}
+	return extended, nil
+}
+
+// managedHeadersFirstSync synchronizes with the provided outbound peers by
+// fetching the headers of the missing blocks first and downloading the blocks
+// from all of the peers in parallel afterwards. It returns once no peer has
+// any more headers to offer or once the offered blocks don't extend the
+// current path, e.g. because they belong to a lighter fork.
+func (cs *ConsensusSet) managedHeadersFirstSync(peers []modules.NetAddress) error {
+	for {
+		// Fetch the headers from the first peer which has them. An error is
+		// only returned if no peer responded.
+		var headers []types.BlockHeader
+		var headersErr error
+		responded := false
+		for _, addr := range peers {
+			hs, err := cs.managedFetchHeaders(addr)
+			if errors.Contains(err, errHeaderChainBroken) || errors.Contains(err, errHeaderTarget) {
+				cs.gateway.RecordPeerMisbehavior(addr, modules.PeerMisbehaviorInvalidBlock)
+			}
+			if err != nil {
+				cs.log.Debugf("WARN: failed to fetch headers from %v: %v", addr, err)
+				headersErr = err
+				continue
+			}
+			responded = true
+			if len(hs) > 0 {
+				headers = hs
+				break
+			}
+		}
+		if len(headers) == 0 {
+			if responded {
+				return nil
+			}
+			return headersErr
+		}
+
+		extended, err := cs.managedDownloadBlocks(peers, headers)
+		if err != nil {
+			return err
+		}
+		if !extended {
+			return nil
+		}
+		cs.log.Debugf("Headers-first sync applied %v blocks, height is now %v", len(headers), cs.Height())
+	}
+}
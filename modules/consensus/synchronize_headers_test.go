@@ -0,0 +1,187 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCheckHeaderChain checks that header chains are validated against their
+// parent.
+func TestCheckHeaderChain(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	genesis, err := cst.cs.dbGetBlockMap(types.GenesisID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headers []types.BlockHeader
+	for h := types.BlockHeight(1); h <= cst.cs.Height(); h++ {
+		b, exists := cst.cs.BlockAtHeight(h)
+		if !exists {
+			t.Fatal("block missing at height", h)
+		}
+		headers = append(headers, b.Header())
+	}
+	if err := checkHeaderChain(genesis, headers); err != nil {
+		t.Fatal(err)
+	}
+
+	// A gap in the headers breaks the chain.
+	if err := checkHeaderChain(genesis, headers[1:]); !errors.Contains(err, errHeaderChainBroken) {
+		t.Fatal("expected errHeaderChainBroken but got", err)
+	}
+
+	// Headers which don't meet the target are rejected.
+	parent := *genesis
+	parent.ChildTarget = types.Target{}
+	if err := checkHeaderChain(&parent, headers); !errors.Contains(err, errHeaderTarget) {
+		t.Fatal("expected errHeaderTarget but got", err)
+	}
+}
+
+// TestHeadersFirstSync checks that a blank consensus set catches up with its
+// peers by downloading the headers first and the blocks in parallel, skipping
+// peers which don't support the new RPCs.
+func TestHeadersFirstSync(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remote1, err := createConsensusSetTester(t.Name() + "-remote1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote1.Close()
+	for i := 0; i < 2*maxHeadersPerRound; i++ {
+		b, err := remote1.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := remote1.cs.managedAcceptBlocks([]types.Block{b}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second remote has the same blockchain.
+	remote2, err := blankConsensusSetTester(t.Name()+"-remote2", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote2.Close()
+	var blocks []types.Block
+	for h := types.BlockHeight(1); h <= remote1.cs.Height(); h++ {
+		b, _ := remote1.cs.BlockAtHeight(h)
+		blocks = append(blocks, b)
+	}
+	if _, err := remote2.cs.managedAcceptBlocks(blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	// The legacy peer only supports SendBlocks.
+	legacy, err := blankConsensusSetTester(t.Name()+"-legacy", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer legacy.Close()
+	legacy.gateway.UnregisterRPC("SendHeaders")
+	legacy.gateway.UnregisterRPC("SendBlks")
+	// Restore the RPCs before closing since Close unregisters them.
+	defer legacy.gateway.RegisterRPC("SendBlks", legacy.cs.rpcSendBlks)
+	defer legacy.gateway.RegisterRPC("SendHeaders", legacy.cs.rpcSendHeaders)
+
+	// Connect the local peer without triggering the legacy synchronization.
+	local, err := blankConsensusSetTester(t.Name()+"-local", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	local.gateway.UnregisterConnectCall("SendBlocks")
+	defer local.gateway.RegisterConnectCall("SendBlocks", local.cs.threadedReceiveBlocks)
+	peers := []modules.NetAddress{legacy.gateway.Address(), remote1.gateway.Address(), remote2.gateway.Address()}
+	for _, addr := range peers {
+		if err := local.gateway.Connect(addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := local.cs.managedHeadersFirstSync(peers); err != nil {
+		t.Fatal(err)
+	}
+	if local.cs.dbCurrentBlockID() != remote1.cs.dbCurrentBlockID() {
+		t.Fatal("local consensus set didn't catch up", local.cs.Height(), remote1.cs.Height())
+	}
+}
+
+// TestApplyBlockChunks checks that downloaded chunks are applied in a single
+// batch and that the valid blocks in front of an invalid block are kept if
+// the batch fails.
+func TestApplyBlockChunks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remote, err := createConsensusSetTester(t.Name() + "-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+	for i := 0; i < ibdCommitBlocks; i++ {
+		if _, err := remote.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	local, err := blankConsensusSetTester(t.Name()+"-local", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+
+	// Split the remote chain into chunks of two blocks.
+	var chunks []*blockChunk
+	for h := types.BlockHeight(1); h+1 <= remote.cs.Height(); h += 2 {
+		b1, _ := remote.cs.BlockAtHeight(h)
+		b2, _ := remote.cs.BlockAtHeight(h + 1)
+		chunks = append(chunks, &blockChunk{index: len(chunks), blocks: []types.Block{b1, b2}})
+	}
+	if len(chunks) < 4 {
+		t.Fatal("not enough chunks", len(chunks))
+	}
+
+	// Apply the first half of the chunks as a single batch.
+	half := len(chunks) / 2
+	extended, err := local.cs.managedApplyBlockChunks(chunks[:half])
+	if err != nil || !extended {
+		t.Fatal("failed to apply chunks", extended, err)
+	}
+	if local.cs.Height() != types.BlockHeight(2*half) {
+		t.Fatal("wrong height after applying chunks", local.cs.Height())
+	}
+
+	// Invalidate the last block of the remaining chunks. The chunks in front
+	// of its chunk are still applied.
+	last := chunks[len(chunks)-1]
+	last.blocks[1].Nonce[0]++
+	extended, err = local.cs.managedApplyBlockChunks(chunks[half:])
+	if err == nil || !extended {
+		t.Fatal("expected the invalid block to be rejected", extended, err)
+	}
+	if local.cs.Height() != types.BlockHeight(2*len(chunks)-2) {
+		t.Fatal("valid chunks weren't applied", local.cs.Height(), 2*len(chunks)-2)
+	}
+}
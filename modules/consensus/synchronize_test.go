@@ -1117,16 +1117,16 @@ func TestRelayHeader(t *testing.T) {
 			header:  validBlock.Header(),
 			errWant: nil,
 			errMSG:  "rpcRelayHeader should accept a valid header",
-			rpcWant: "SendBlk",
-			rpcMSG:  "rpcRelayHeader should request the block of a valid header",
+			rpcWant: "SendCmpctBlk",
+			rpcMSG:  "rpcRelayHeader should request the compact block of a valid header",
 		},
 		// Test that rpcRelayHeader requests a future, but otherwise valid block.
 		{
 			header:  futureBlock.Header(),
 			errWant: nil,
 			errMSG:  "rpcRelayHeader should not return an error for a future header",
-			rpcWant: "SendBlk",
-			rpcMSG:  "rpcRelayHeader should request the corresponding block to a future, but otherwise valid header",
+			rpcWant: "SendCmpctBlk",
+			rpcMSG:  "rpcRelayHeader should request the corresponding compact block to a future, but otherwise valid header",
 		},
 	}
 	errChan := make(chan error)
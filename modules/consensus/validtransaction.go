@@ -3,6 +3,9 @@ package consensus
 import (
 	"bytes"
 	"math/big"
+	"runtime"
+	"sync"
+	"time"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
@@ -282,7 +285,7 @@ func validSiafunds(tx *bolt.Tx, t types.Transaction) (err error) {
 // valid in the context of the consensus set. Currently, only ArbitraryData with
 // the types.SpecifierFoundation prefix is examined.
 func validArbitraryData(tx *bolt.Tx, t types.Transaction, currentHeight types.BlockHeight) error {
-	if currentHeight < types.FoundationHardforkHeight {
+	if !forkRuleFoundation.activeAtHeight(currentHeight) {
 		return nil
 	}
 	for _, arb := range t.ArbitraryData {
@@ -332,10 +335,42 @@ func validTransaction(tx *bolt.Tx, t types.Transaction) error {
 	if err != nil {
 		return err
 	}
+	return validTransactionState(tx, t, currentHeight)
+}
+
+// validTransactionsStandalone runs the standalone validation of the
+// transactions in parallel, one goroutine per core, and returns the error of
+// each transaction at its index. Standalone validation doesn't depend on the
+// consensus state or on the other transactions, so the transactions of a block
+// can be checked before any of them are applied.
+func validTransactionsStandalone(txns []types.Transaction, currentHeight types.BlockHeight, oc *modules.OperationCounters) []error {
+	errs := make([]error, len(txns))
+	workers := runtime.NumCPU()
+	if workers > len(txns) {
+		workers = len(txns)
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for worker := 0; worker < workers; worker++ {
+		go func(offset int) {
+			defer wg.Done()
+			for i := offset; i < len(txns); i += workers {
+				start := time.Now()
+				errs[i] = txns[i].StandaloneValid(currentHeight)
+				oc.Record(operationVerifySignatures, time.Since(start), errs[i])
+			}
+		}(worker)
+	}
+	wg.Wait()
+	return errs
+}
 
+// validTransactionState checks that all fields of a transaction which passed
+// the standalone validation are valid within the current consensus state.
+func validTransactionState(tx *bolt.Tx, t types.Transaction, currentHeight types.BlockHeight) error {
 	// Check that each portion of the transaction is legal given the current
 	// consensus set.
-	err = validSiacoins(tx, t)
+	err := validSiacoins(tx, t)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"runtime"
 	"testing"
 
 	"gitlab.com/NebulousLabs/bolt"
@@ -968,3 +969,32 @@ func TestValidArbitraryData(t *testing.T) {
 		t.Error("expected errUnsignedFoundationUpdate, got", err)
 	}
 }
+
+// TestValidTransactionsStandalone checks that the parallel standalone
+// validation reports the error of each transaction at its index.
+func TestValidTransactionsStandalone(t *testing.T) {
+	if errs := validTransactionsStandalone(nil, 0, nil); len(errs) != 0 {
+		t.Fatal("expected no errors, got", errs)
+	}
+
+	// Create more transactions than there are workers, every third of them
+	// having a zero value output.
+	txns := make([]types.Transaction, 10*runtime.NumCPU()+1)
+	for i := range txns {
+		txns[i].ArbitraryData = [][]byte{fastrand.Bytes(8)}
+		if i%3 == 0 {
+			txns[i].SiacoinOutputs = []types.SiacoinOutput{{Value: types.ZeroCurrency}}
+		}
+	}
+	errs := validTransactionsStandalone(txns, 0, nil)
+	if len(errs) != len(txns) {
+		t.Fatalf("expected %v errors, got %v", len(txns), len(errs))
+	}
+	for i, err := range errs {
+		if i%3 == 0 && !errors.Contains(err, types.ErrZeroOutput) {
+			t.Errorf("expected ErrZeroOutput for transaction %v, got %v", i, err)
+		} else if i%3 != 0 && err != nil {
+			t.Errorf("transaction %v should be valid: %v", i, err)
+		}
+	}
+}
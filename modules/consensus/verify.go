@@ -0,0 +1,267 @@
+package consensus
+
+// verify.go contains the consensus integrity check. The stored diffs of the
+// current path are re-applied block by block to an empty scratch database and
+// the replayed state is compared with the stored consensus state. A database
+// which was corrupted on disk diverges from the replayed state, and the
+// diverged buckets point at the corruption without requiring a full resync.
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// verifyScratchPrefix is the prefix of the scratch database the diffs
+	// are replayed into.
+	verifyScratchPrefix = modules.ConsensusDir + "_verify_"
+)
+
+var (
+	// verifyBatchSize is the number of blocks which are replayed within a
+	// single transaction of the scratch database.
+	verifyBatchSize = build.Select(build.Var{
+		Standard: types.BlockHeight(1000),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// errReplayFailed is returned if the stored diffs of a block can't be
+	// applied to the replayed state.
+	errReplayFailed = errors.New("unable to replay the stored diffs of the current path")
+)
+
+// bucketDisplayName returns the name of a bucket which is part of the
+// consensus checksum in a readable form. The delayed siacoin output and file
+// contract expiration buckets are suffixed with their height. An empty string
+// is returned for all other buckets.
+func bucketDisplayName(name []byte) string {
+	for _, prefix := range [][]byte{prefixDSCO, prefixFCEX} {
+		if bytes.HasPrefix(name, prefix) && len(name) == len(prefix)+8 {
+			return fmt.Sprintf("%s%d", prefix, encoding.DecUint64(name[len(prefix):]))
+		}
+	}
+	for _, bucket := range checksumBuckets {
+		if bytes.Equal(name, bucket) {
+			return string(name)
+		}
+	}
+	return ""
+}
+
+// bucketChecksums returns the checksums of the buckets which are part of the
+// consensus checksum, keyed by their display name.
+func bucketChecksums(tx *bolt.Tx) (map[string]crypto.Hash, error) {
+	checksums := make(map[string]crypto.Hash)
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		displayName := bucketDisplayName(name)
+		if displayName == "" {
+			return nil
+		}
+		tree := crypto.NewTree()
+		err := b.ForEach(func(k, v []byte) error {
+			tree.Push(k)
+			tree.Push(v)
+			return nil
+		})
+		checksums[displayName] = tree.Root()
+		return err
+	})
+	return checksums, err
+}
+
+// divergedBuckets returns the sorted names of the buckets which are missing
+// from either set of checksums or have different checksums.
+func divergedBuckets(stored, replayed map[string]crypto.Hash) []string {
+	diverged := []string{}
+	for name, checksum := range stored {
+		if replayedChecksum, exists := replayed[name]; !exists || replayedChecksum != checksum {
+			diverged = append(diverged, name)
+		}
+	}
+	for name := range replayed {
+		if _, exists := stored[name]; !exists {
+			diverged = append(diverged, name)
+		}
+	}
+	sort.Strings(diverged)
+	return diverged
+}
+
+// replayBlock applies the stored diffs of a block to the scratch database.
+// It matches commitDiffSet without the sanity checks, which would need the
+// blocks of the path in the scratch database. The diffs of a corrupted
+// database can trip the checks of the diff code, so panics are returned as
+// errors.
+func replayBlock(tx *bolt.Tx, pb *processedBlock) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	if !pb.DiffsGenerated {
+		return errDiffsNotGenerated
+	}
+	createUpcomingDelayedOutputMaps(tx, pb, modules.DiffApply)
+	commitNodeDiffs(tx, pb, modules.DiffApply)
+	deleteObsoleteDelayedOutputMaps(tx, pb, modules.DiffApply)
+	commitFoundationUpdate(tx, pb, modules.DiffApply)
+	updateCurrentPath(tx, pb, modules.DiffApply)
+	return nil
+}
+
+// verifyConsensusTx replays the stored diffs of the current path into a
+// scratch database at scratchFilename and compares the replayed state with
+// the stored state. The scratch database is removed afterwards.
+func verifyConsensusTx(tx *bolt.Tx, scratchFilename string) (cv modules.ConsensusVerification, err error) {
+	for _, name := range checkpointBuckets {
+		if tx.Bucket(name) == nil {
+			return modules.ConsensusVerification{}, fmt.Errorf("consensus database is missing bucket %s", name)
+		}
+	}
+	height := blockHeight(tx)
+	genesisID, err := getPath(tx, 0)
+	if err != nil {
+		return modules.ConsensusVerification{}, errors.AddContext(err, "unable to find genesis block")
+	}
+	genesis, err := getBlockMap(tx, genesisID)
+	if err != nil {
+		return modules.ConsensusVerification{}, errors.AddContext(err, "unable to find genesis block")
+	}
+
+	scratch, err := persist.OpenDatabase(dbMetadata, scratchFilename)
+	if err != nil {
+		return modules.ConsensusVerification{}, errors.AddContext(err, "unable to create scratch database")
+	}
+	defer func() {
+		err = errors.Compose(err, scratch.Close(), os.RemoveAll(scratchFilename))
+	}()
+	// The scratch database is thrown away, so there is no need to sync it.
+	scratch.NoSync = true
+
+	// Initialize the scratch database like a new consensus database, which
+	// applies the outputs of the genesis block.
+	root := &ConsensusSet{blockRoot: *genesis}
+	err = scratch.Update(func(stx *bolt.Tx) error {
+		if err := root.createConsensusDB(stx); err != nil {
+			return err
+		}
+		return root.initFoundation(stx)
+	})
+	if err != nil {
+		return modules.ConsensusVerification{}, errors.AddContext(err, "unable to initialize scratch database")
+	}
+
+	// Replay the path in batches to limit the size of the scratch
+	// transactions.
+	parentID := genesisID
+	for start := types.BlockHeight(1); start <= height; start += verifyBatchSize {
+		end := start + verifyBatchSize
+		if end > height+1 {
+			end = height + 1
+		}
+		err = scratch.Update(func(stx *bolt.Tx) error {
+			for h := start; h < end; h++ {
+				id, err := getPath(tx, h)
+				if err != nil {
+					return errors.Compose(errReplayFailed, fmt.Errorf("unable to find block at height %v: %v", h, err))
+				}
+				pb, err := getBlockMap(tx, id)
+				if err != nil {
+					return errors.Compose(errReplayFailed, fmt.Errorf("unable to decode block at height %v: %v", h, err))
+				}
+				if pb.Height != h || pb.Block.ParentID != parentID {
+					return errors.Compose(errReplayFailed, fmt.Errorf("block at height %v isn't the child of the previous block", h))
+				}
+				if err := replayBlock(stx, pb); err != nil {
+					return errors.Compose(errReplayFailed, fmt.Errorf("unable to apply diffs of block at height %v: %v", h, err))
+				}
+				parentID = id
+			}
+			return nil
+		})
+		if err != nil {
+			return modules.ConsensusVerification{}, err
+		}
+	}
+
+	// Compare the replayed state with the stored state.
+	stored, err := bucketChecksums(tx)
+	if err != nil {
+		return modules.ConsensusVerification{}, err
+	}
+	var replayed map[string]crypto.Hash
+	err = scratch.View(func(stx *bolt.Tx) (err error) {
+		cv.ReplayedStateHash = consensusChecksum(stx)
+		replayed, err = bucketChecksums(stx)
+		return err
+	})
+	if err != nil {
+		return modules.ConsensusVerification{}, err
+	}
+	cv.Height = height
+	cv.BlockID = currentBlockID(tx)
+	cv.StateHash = consensusChecksum(tx)
+	cv.DivergedBuckets = divergedBuckets(stored, replayed)
+	cv.Consistent = cv.StateHash == cv.ReplayedStateHash && len(cv.DivergedBuckets) == 0
+	return cv, nil
+}
+
+// VerifyConsensus replays the stored diffs of the current path into a
+// scratch database and compares the replayed state with the consensus state.
+// The database is read within a single transaction, so blocks are processed
+// while the verification runs, but the database can't reuse freed pages until
+// it is complete.
+func (cs *ConsensusSet) VerifyConsensus() (cv modules.ConsensusVerification, err error) {
+	err = cs.tg.Add()
+	if err != nil {
+		return modules.ConsensusVerification{}, err
+	}
+	defer cs.tg.Done()
+
+	scratchFilename := filepath.Join(cs.persistDir, verifyScratchPrefix+persist.RandomSuffix())
+	err = cs.db.View(func(tx *bolt.Tx) (err error) {
+		cv, err = verifyConsensusTx(tx, scratchFilename)
+		return err
+	})
+	if err != nil {
+		return modules.ConsensusVerification{}, errors.AddContext(err, "unable to verify consensus")
+	}
+	return cv, nil
+}
+
+// VerifyDB verifies the consensus database at filename like VerifyConsensus.
+// The database must not be in use by a running consensus set. The scratch
+// database is created next to it.
+func VerifyDB(filename string) (cv modules.ConsensusVerification, err error) {
+	db, err := OpenDBReadOnly(filename)
+	if err != nil {
+		return modules.ConsensusVerification{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, db.Close())
+	}()
+
+	scratchFilename := filepath.Join(filepath.Dir(filename), verifyScratchPrefix+persist.RandomSuffix())
+	err = db.View(func(tx *bolt.Tx) (err error) {
+		cv, err = verifyConsensusTx(tx, scratchFilename)
+		return err
+	})
+	if err != nil {
+		return modules.ConsensusVerification{}, errors.AddContext(err, "unable to verify consensus")
+	}
+	return cv, nil
+}
@@ -0,0 +1,84 @@
+package consensus
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestVerifyConsensus checks that replaying the stored diffs matches the
+// consensus state and that corrupted buckets are reported.
+func TestVerifyConsensus(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			if err := cst.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}()
+	// Make sure that there are multiple batches and a file contract.
+	cst.testFileContractRevision()
+
+	cv, err := cst.cs.VerifyConsensus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cv.Consistent || len(cv.DivergedBuckets) != 0 {
+		t.Fatal("consensus should be consistent", cv)
+	}
+	if cv.Height != cst.cs.Height() || cv.BlockID != cst.cs.CurrentBlock().ID() || cv.StateHash != cv.ReplayedStateHash {
+		t.Fatal("unexpected verification", cv)
+	}
+
+	// Corrupt the siacoin outputs and the delayed outputs of the next
+	// height, bypassing the diffs.
+	var scoid types.SiacoinOutputID
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(SiacoinOutputs).Cursor().First()
+		copy(scoid[:], k)
+		if err := tx.Bucket(SiacoinOutputs).Delete(k); err != nil {
+			return err
+		}
+		dscoBucket := append(prefixDSCO, encoding.Marshal(blockHeight(tx)+1)...)
+		return tx.Bucket(dscoBucket).Put(scoid[:], []byte{1})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv, err = cst.cs.VerifyConsensus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"SiacoinOutputs", fmt.Sprint("dsco_", cst.cs.Height()+1)}
+	if cv.Consistent || cv.StateHash == cv.ReplayedStateHash || !reflect.DeepEqual(cv.DivergedBuckets, expected) {
+		t.Fatal("corruption wasn't detected", cv)
+	}
+
+	// The database of a stopped node can be verified as well.
+	closed = true
+	if err := cst.Close(); err != nil {
+		t.Fatal(err)
+	}
+	offline, err := VerifyDB(filepath.Join(cst.cs.persistDir, DatabaseFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(offline, cv) {
+		t.Fatal("offline verification doesn't match", offline, cv)
+	}
+}
@@ -0,0 +1,169 @@
+package modules
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// OperationCountersFilename is the name of the file in a module's persist
+	// directory which contains its operation counters.
+	OperationCountersFilename = "counters.json"
+
+	// recentLatencyDecay determines how quickly the recent latency of an
+	// operation follows changes of its latency. Every new sample contributes
+	// 1/recentLatencyDecay to the recent latency.
+	recentLatencyDecay = 20
+)
+
+var (
+	// operationCountersMetadata is the metadata of the operation counters'
+	// persist file.
+	operationCountersMetadata = persist.Metadata{
+		Header:  "Operation Counters",
+		Version: "1.0.0",
+	}
+
+	// operationCountersSaveInterval is the minimum amount of time between two
+	// saves of the operation counters which are triggered by a recorded
+	// operation.
+	operationCountersSaveInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// OperationCounter is the interface implemented by modules which keep
+	// operation counters.
+	OperationCounter interface {
+		// OperationCounters returns the module's operation counters.
+		OperationCounters() map[string]OperationStats
+	}
+
+	// OperationStats are the cumulative statistics of a type of operation.
+	// RecentLatency is a moving average of the latency which is dominated by
+	// the most recent operations. Comparing it to the lifetime average makes
+	// it easy to spot degradations, e.g. after an upgrade.
+	OperationStats struct {
+		Count         uint64        `json:"count"`
+		Errors        uint64        `json:"errors"`
+		TotalLatency  time.Duration `json:"totallatency"`
+		RecentLatency time.Duration `json:"recentlatency"`
+	}
+
+	// OperationCounters keeps track of the number of operations, the number
+	// of failed operations and the cumulative latency of the operations a
+	// module performs. The counters are persisted so that they can be compared
+	// over the lifetime of a node. A nil OperationCounters ignores all
+	// recorded operations.
+	OperationCounters struct {
+		counters map[string]OperationStats
+		lastSave time.Time
+		mu       sync.Mutex
+
+		staticPath string
+		saveMu     sync.Mutex
+	}
+)
+
+// NewOperationCounters loads the operation counters from the provided path. If
+// the file doesn't exist yet, the counters start at zero.
+func NewOperationCounters(path string) (*OperationCounters, error) {
+	oc := &OperationCounters{
+		counters:   make(map[string]OperationStats),
+		lastSave:   time.Now(),
+		staticPath: path,
+	}
+	err := persist.LoadJSON(operationCountersMetadata, &oc.counters, path)
+	if os.IsNotExist(err) {
+		return oc, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load operation counters")
+	}
+	if oc.counters == nil {
+		oc.counters = make(map[string]OperationStats)
+	}
+	return oc, nil
+}
+
+// Record records a completed operation which took the provided amount of time.
+// A non-nil error marks the operation as failed.
+func (oc *OperationCounters) Record(name string, latency time.Duration, err error) {
+	if oc == nil {
+		return
+	}
+	oc.mu.Lock()
+	stats := oc.counters[name]
+	stats.Count++
+	stats.TotalLatency += latency
+	if stats.RecentLatency == 0 {
+		// Counters which were persisted before the recent latency was tracked
+		// start with the first new operation as well.
+		stats.RecentLatency = latency
+	} else {
+		stats.RecentLatency += (latency - stats.RecentLatency) / recentLatencyDecay
+	}
+	if err != nil {
+		stats.Errors++
+	}
+	oc.counters[name] = stats
+	save := time.Since(oc.lastSave) >= operationCountersSaveInterval
+	if save {
+		oc.lastSave = time.Now()
+	}
+	oc.mu.Unlock()
+
+	if save {
+		_ = oc.Save()
+	}
+}
+
+// RecordError records a failed operation without counting it as an
+// additional operation. This is useful for operations which report their
+// failure separately from their completion.
+func (oc *OperationCounters) RecordError(name string) {
+	if oc == nil {
+		return
+	}
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	stats := oc.counters[name]
+	stats.Errors++
+	oc.counters[name] = stats
+}
+
+// Stats returns a copy of the counters.
+func (oc *OperationCounters) Stats() map[string]OperationStats {
+	stats := make(map[string]OperationStats)
+	if oc == nil {
+		return stats
+	}
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	for name, s := range oc.counters {
+		stats[name] = s
+	}
+	return stats
+}
+
+// Save writes the counters to disk.
+func (oc *OperationCounters) Save() error {
+	if oc == nil {
+		return nil
+	}
+	oc.saveMu.Lock()
+	defer oc.saveMu.Unlock()
+	stats := oc.Stats()
+	oc.mu.Lock()
+	oc.lastSave = time.Now()
+	oc.mu.Unlock()
+	return persist.SaveJSON(operationCountersMetadata, stats, oc.staticPath)
+}
@@ -0,0 +1,71 @@
+package modules
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+)
+
+// TestOperationCounters checks that operations are counted correctly and that
+// the counters are persisted.
+func TestOperationCounters(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("modules", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, OperationCountersFilename)
+	oc, err := NewOperationCounters(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oc.Stats()) != 0 {
+		t.Fatal("expected no counters", oc.Stats())
+	}
+
+	// Record a few operations.
+	oc.Record("a", time.Second, nil)
+	oc.Record("a", 2*time.Second, errors.New("failed"))
+	oc.Record("b", time.Millisecond, nil)
+	oc.RecordError("b")
+	expected := map[string]OperationStats{
+		"a": {Count: 2, Errors: 1, TotalLatency: 3 * time.Second, RecentLatency: time.Second + time.Second/recentLatencyDecay},
+		"b": {Count: 1, Errors: 1, TotalLatency: time.Millisecond, RecentLatency: time.Millisecond},
+	}
+	if stats := oc.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// Save and reload the counters.
+	if err := oc.Save(); err != nil {
+		t.Fatal(err)
+	}
+	oc, err = NewOperationCounters(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := oc.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Fatal("counters weren't persisted", stats)
+	}
+
+	// A nil OperationCounters ignores all operations.
+	var nilCounters *OperationCounters
+	nilCounters.Record("a", time.Second, nil)
+	nilCounters.RecordError("a")
+	if len(nilCounters.Stats()) != 0 {
+		t.Fatal("expected no counters")
+	}
+	if err := nilCounters.Save(); err != nil {
+		t.Fatal(err)
+	}
+}
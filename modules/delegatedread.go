@@ -0,0 +1,122 @@
+package modules
+
+import (
+	"errors"
+	"math/bits"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// MaxDelegatedReadSections is the maximum number of sections a delegate
+	// can request in a single RPCDelegatedRead request.
+	MaxDelegatedReadSections = 16
+)
+
+var (
+	// RPCDelegatedRead specifier
+	RPCDelegatedRead = types.NewSpecifier("DelegatedRead")
+
+	// DelegatedReadTokenPrefix is the prefix of the hash the renter signs to
+	// create a DelegatedReadToken.
+	DelegatedReadTokenPrefix = types.NewSpecifier("DelegatedToken")
+
+	// DelegatedReadRequestPrefix is the prefix of the hash the delegate signs
+	// to authenticate an RPCDelegatedReadRequest.
+	DelegatedReadRequestPrefix = types.NewSpecifier("DelegatedRequest")
+)
+
+var (
+	// ErrDelegatedReadTokenExpired is returned by the host if a delegate uses
+	// a token after its expiry height.
+	ErrDelegatedReadTokenExpired = errors.New("delegated read token expired")
+
+	// ErrInvalidDelegatedReadToken is returned by the host if a token isn't
+	// signed by the renter of the contract it grants access to.
+	ErrInvalidDelegatedReadToken = errors.New("invalid delegated read token")
+)
+
+type (
+	// DelegatedReadToken authorizes the holder of the Delegate key to download
+	// the sectors of a contract from its host with RPCDelegatedRead until the
+	// host reaches the Expiry height. It's signed with the renter's key of the
+	// contract, so it doesn't reveal any of the renter's keys to the delegate.
+	DelegatedReadToken struct {
+		FileContractID types.FileContractID `json:"filecontractid"`
+		Delegate       types.SiaPublicKey   `json:"delegate"`
+		Expiry         types.BlockHeight    `json:"expiry"`
+		Signature      crypto.Signature     `json:"signature"`
+	}
+
+	// RPCDelegatedReadRequest requests sections of sectors of the contract
+	// the token grants access to. The request is signed by the delegate to
+	// prove that it holds the key the token was issued to.
+	RPCDelegatedReadRequest struct {
+		Token     DelegatedReadToken
+		Sections  []LoopReadRequestSection
+		Signature crypto.Signature
+	}
+
+	// RPCDelegatedReadResponse contains the data of a requested section and a
+	// Merkle range proof which proves that the data is part of the sector.
+	// The host sends one response per requested section.
+	RPCDelegatedReadResponse struct {
+		Data        []byte
+		MerkleProof []crypto.Hash
+	}
+)
+
+// Hash returns the hash of the token which is signed by the renter.
+func (t DelegatedReadToken) Hash() crypto.Hash {
+	return crypto.HashAll(DelegatedReadTokenPrefix, t.FileContractID, t.Delegate, t.Expiry)
+}
+
+// VerifySignature verifies that the token was signed with the renter's key of
+// the contract it grants access to.
+func (t DelegatedReadToken) VerifySignature(renterKey types.SiaPublicKey) error {
+	var pk crypto.PublicKey
+	if renterKey.Algorithm != types.SignatureEd25519 || len(renterKey.Key) != len(pk) {
+		return ErrInvalidDelegatedReadToken
+	}
+	copy(pk[:], renterKey.Key)
+	if crypto.VerifyHash(t.Hash(), pk, t.Signature) != nil {
+		return ErrInvalidDelegatedReadToken
+	}
+	return nil
+}
+
+// Hash returns the hash of the request which is signed by the delegate. It
+// includes the host's key and the UID of the price table the delegate pays
+// with to prevent the signature from being replayed to other hosts or after
+// the price table expired.
+func (req RPCDelegatedReadRequest) Hash(hostKey types.SiaPublicKey, ptUID UniqueID) crypto.Hash {
+	return crypto.HashAll(DelegatedReadRequestPrefix, req.Token, req.Sections, hostKey, ptUID)
+}
+
+// VerifySignature verifies that the request was signed with the delegate key
+// of its token.
+func (req RPCDelegatedReadRequest) VerifySignature(hostKey types.SiaPublicKey, ptUID UniqueID) error {
+	delegate := req.Token.Delegate
+	var pk crypto.PublicKey
+	if delegate.Algorithm != types.SignatureEd25519 || len(delegate.Key) != len(pk) {
+		return errors.New("delegate key must be an ed25519 key")
+	}
+	copy(pk[:], delegate.Key)
+	return crypto.VerifyHash(req.Hash(hostKey, ptUID), pk, req.Signature)
+}
+
+// DelegatedReadCost returns the cost of downloading the sections using
+// RPCDelegatedRead. It consists of the cost of reading the sections from disk
+// and the bandwidth of the data and its Merkle proofs.
+func DelegatedReadCost(pt *RPCPriceTable, sections []LoopReadRequestSection) types.Currency {
+	// use the worst-case proof size of 2*tree depth (this occurs when proving
+	// across the two leaves in the center of the tree)
+	proofSize := uint64(2 * bits.Len64(SectorSize/crypto.SegmentSize) * crypto.HashSize)
+	cost := types.ZeroCurrency
+	for _, sec := range sections {
+		cost = cost.Add(MDMReadCost(pt, uint64(sec.Length)))
+		cost = cost.Add(pt.DownloadBandwidthCost.Mul64(uint64(sec.Length) + proofSize))
+	}
+	return cost
+}
@@ -1,10 +1,13 @@
 package modules
 
 import (
+	"errors"
 	"net"
+	"strings"
 	"time"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -13,6 +16,30 @@ const (
 	GatewayDir = "gateway"
 )
 
+const (
+	// ServiceHost is advertised by nodes which run a host.
+	ServiceHost GatewayServices = 1 << iota
+	// ServiceExplorer is advertised by nodes which run an explorer.
+	ServiceExplorer
+	// ServiceSnapshotServer is advertised by nodes which serve consensus
+	// snapshots.
+	ServiceSnapshotServer
+	// ServiceSkynetPortal is advertised by nodes which run a skynet portal.
+	ServiceSkynetPortal
+	// ServiceArchivalConsensus is advertised by nodes which store the full
+	// consensus history and can serve any block.
+	ServiceArchivalConsensus
+	// ServiceFullNode is advertised by nodes which validate and relay full
+	// blocks.
+	ServiceFullNode
+	// ServiceLightClient is advertised by clients which only follow the
+	// block headers and don't relay blocks.
+	ServiceLightClient
+)
+
+// serviceNames are the names of the services in the order of their bits.
+var serviceNames = []string{"host", "explorer", "snapshot-server", "skynet-portal", "archival-consensus", "full-node", "light-client"}
+
 var (
 	// BootstrapPeers is a list of peers that can be used to find other peers -
 	// when a client first connects to the network, the only options for
@@ -98,6 +125,78 @@ var (
 		Dev:     []NetAddress(nil),
 		Testing: []NetAddress(nil),
 	}).([]NetAddress)
+
+	// DNSSeeds is a list of hostnames that are queried for A and AAAA records
+	// of long-running nodes when the gateway bootstraps. A seed may optionally
+	// specify a port using the 'host:port' format, otherwise
+	// DefaultDNSSeedPort is used. The addresses that are returned are added
+	// to the node list alongside the BootstrapPeers.
+	DNSSeeds = build.Select(build.Var{
+		Standard: []string(nil),
+		Dev:      []string(nil),
+		Testing:  []string(nil),
+	}).([]string)
+)
+
+var (
+	// ErrReachabilityAddressMismatch is returned by peers which refuse to
+	// check the reachability of an address that doesn't resolve to the IP of
+	// the requesting node.
+	ErrReachabilityAddressMismatch = errors.New("address doesn't resolve to the IP of the requesting peer")
+
+	// ErrReachabilityWrongHost is returned by peers if a host answered at the
+	// checked address but it didn't prove ownership of the expected key.
+	ErrReachabilityWrongHost = errors.New("host at the address didn't prove ownership of the expected key")
+
+	// ErrRPCDeadline is returned by the reads and writes of an RPC which
+	// exceeded its deadline.
+	ErrRPCDeadline = errors.New("RPC exceeded its deadline")
+
+	// ErrRPCStalled is returned by the reads and writes of an RPC whose peer
+	// transferred data slower than the minimum rate.
+	ErrRPCStalled = errors.New("RPC stalled below the minimum transfer rate")
+)
+
+const (
+	// PeerDisconnectRPCDeadline means that the peer was disconnected because
+	// an RPC with the peer exceeded its deadline.
+	PeerDisconnectRPCDeadline PeerDisconnectReason = "rpc-deadline"
+
+	// PeerDisconnectRPCStalled means that the peer was disconnected because
+	// it transferred the data of an RPC slower than the minimum rate.
+	PeerDisconnectRPCStalled PeerDisconnectReason = "rpc-stalled"
+)
+
+const (
+	// PeerMisbehaviorInvalidBlock means that the peer relayed an invalid
+	// block or block header.
+	PeerMisbehaviorInvalidBlock PeerMisbehavior = "invalid-block"
+
+	// PeerMisbehaviorMalformedRPC means that the peer sent a malformed RPC
+	// request, e.g. a request for an RPC which doesn't exist.
+	PeerMisbehaviorMalformedRPC PeerMisbehavior = "malformed-rpc"
+
+	// PeerMisbehaviorStall means that an RPC with the peer stalled or exceeded
+	// its deadline.
+	PeerMisbehaviorStall PeerMisbehavior = "stall"
+)
+
+const (
+	// AddressFamilyAny indicates that the gateway doesn't prefer any address
+	// family when dialing peers.
+	AddressFamilyAny AddressFamily = ""
+	// AddressFamilyIPv4 indicates that the gateway prefers dialing the IPv4
+	// addresses of its peers.
+	AddressFamilyIPv4 AddressFamily = "ipv4"
+	// AddressFamilyIPv6 indicates that the gateway prefers dialing the IPv6
+	// addresses of its peers.
+	AddressFamilyIPv6 AddressFamily = "ipv6"
+)
+
+const (
+	// DefaultDNSSeedPort is the port that is assumed for the addresses
+	// returned by a DNS seed that doesn't specify a port.
+	DefaultDNSSeedPort = "9981"
 )
 
 type (
@@ -107,6 +206,95 @@ type (
 		Local      bool       `json:"local"`
 		NetAddress NetAddress `json:"netaddress"`
 		Version    string     `json:"version"`
+
+		// Services are the services the peer advertised during the handshake.
+		// Peers running older versions don't advertise any services.
+		Services GatewayServices `json:"services"`
+
+		// Addresses are the addresses of the other address family the peer
+		// advertised during the handshake, e.g. the IPv6 address of a peer
+		// which is connected over IPv4.
+		Addresses []NetAddress `json:"addresses"`
+
+		// Encrypted indicates that the session with the peer is encrypted
+		// and authenticated. Peers running older versions use plaintext
+		// sessions.
+		Encrypted bool `json:"encrypted"`
+	}
+
+	// PeerStats contains the metrics of the connection to a peer. The latency
+	// is the round-trip time measured during the handshake.
+	PeerStats struct {
+		Peer
+		ConnectedSince time.Time     `json:"connectedsince"`
+		ConnectionAge  time.Duration `json:"connectionage"`
+		Latency        time.Duration `json:"latency"`
+		RPCs           uint64        `json:"rpcs"`
+		RPCErrors      uint64        `json:"rpcerrors"`
+		RPCErrorRate   float64       `json:"rpcerrorrate"`
+		Download       uint64        `json:"download"`
+		Upload         uint64        `json:"upload"`
+	}
+
+	// AddressFamily is an IP address family the gateway can prefer when
+	// dialing peers.
+	AddressFamily string
+
+	// GatewayServices is a bitmask of the services a node offers to its peers.
+	GatewayServices uint64
+
+	// PeerDisconnectReason is the reason why the gateway disconnected from a
+	// peer on its own.
+	PeerDisconnectReason string
+
+	// PeerDisconnect records a peer which the gateway disconnected from on
+	// its own.
+	PeerDisconnect struct {
+		NetAddress NetAddress           `json:"netaddress"`
+		RPC        string               `json:"rpc"`
+		Reason     PeerDisconnectReason `json:"reason"`
+		Time       time.Time            `json:"time"`
+	}
+
+	// PeerMisbehavior is a kind of misbehavior which counts towards the score
+	// of a peer.
+	PeerMisbehavior string
+
+	// PeerScore is the misbehavior score of a peer. The score decays over
+	// time and the peer is banned once it reaches the ban threshold.
+	PeerScore struct {
+		Host            string                     `json:"host"`
+		Score           float64                    `json:"score"`
+		Misbehavior     map[PeerMisbehavior]uint64 `json:"misbehavior"`
+		LastMisbehavior time.Time                  `json:"lastmisbehavior"`
+		Bans            int                        `json:"bans"`
+	}
+
+	// PeerBan is a temporary ban of a peer. Bans is the number of times the
+	// peer was banned in a row, which determines the duration of the ban.
+	PeerBan struct {
+		Host   string          `json:"host"`
+		Reason PeerMisbehavior `json:"reason"`
+		Bans   int             `json:"bans"`
+		Start  time.Time       `json:"start"`
+		Expiry time.Time       `json:"expiry"`
+	}
+
+	// ReachabilityReport is the result of a peer trying to connect to an
+	// address on behalf of the node.
+	ReachabilityReport struct {
+		// Peer is the peer that performed the check.
+		Peer NetAddress `json:"peer"`
+
+		// Checked is false if the peer couldn't be asked or refused to
+		// perform the check.
+		Checked bool `json:"checked"`
+
+		// Reachable indicates whether the peer was able to open a session
+		// with the host at the address.
+		Reachable bool          `json:"reachable"`
+		Latency   time.Duration `json:"latency"`
+		Error     string        `json:"error"`
 	}
 
 	// A PeerConn is the connection type used when communicating with peers during
@@ -136,6 +324,10 @@ type (
 		// BandwidthCounters returns the Gateway's upload and download bandwidth
 		BandwidthCounters() (uint64, uint64, time.Time, error)
 
+		// CheckReachability asks connected peers to connect to the given
+		// address and to open a session with the host with the given key.
+		CheckReachability(addr NetAddress, hostKey types.SiaPublicKey) []ReachabilityReport
+
 		// Connect establishes a persistent connection to a peer.
 		Connect(NetAddress) error
 
@@ -172,13 +364,65 @@ type (
 		// SetBlocklist sets the blocklist of the gateway
 		SetBlocklist(addresses []string) error
 
+		// Allowlist returns whether the gateway is in allowlist-only mode
+		// and the IPs on the allowlist.
+		Allowlist() (enabled bool, addresses []string, err error)
+
+		// SetAllowlist sets the allowlist of the gateway and whether the
+		// gateway only connects to peers on the allowlist.
+		SetAllowlist(enabled bool, addresses []string) error
+
 		// Address returns the Gateway's address.
 		Address() NetAddress
 
+		// Addresses returns all addresses the Gateway advertises to its
+		// peers. The first address is the one returned by Address, followed
+		// by the Gateway's address of the other address family if it has
+		// one.
+		Addresses() []NetAddress
+
+		// PreferredAddressFamily returns the address family the Gateway
+		// prefers when dialing peers.
+		PreferredAddressFamily() AddressFamily
+
+		// SetPreferredAddressFamily sets the address family the Gateway
+		// prefers when dialing peers.
+		SetPreferredAddressFamily(AddressFamily) error
+
+		// RequireEncryption returns true if the Gateway refuses plaintext
+		// sessions with peers which don't support encryption.
+		RequireEncryption() bool
+
+		// SetRequireEncryption sets whether the Gateway refuses plaintext
+		// sessions with peers which don't support encryption.
+		SetRequireEncryption(bool) error
+
 		// Peers returns the addresses that the Gateway is currently connected
 		// to.
 		Peers() []Peer
 
+		// PeerStats returns the connection metrics of the peers the Gateway
+		// is connected to.
+		PeerStats() []PeerStats
+
+		// PeerDisconnects returns the most recent peers the Gateway
+		// disconnected from on its own, e.g. because an RPC stalled.
+		PeerDisconnects() []PeerDisconnect
+
+		// PeerBans returns the peers which are currently banned because of
+		// misbehavior.
+		PeerBans() []PeerBan
+
+		// PeerScores returns the misbehavior scores of the peers.
+		PeerScores() []PeerScore
+
+		// RecordPeerMisbehavior adds the misbehavior to the score of the peer
+		// and bans the peer if its score reached the ban threshold.
+		RecordPeerMisbehavior(addr NetAddress, misbehavior PeerMisbehavior)
+
+		// UnbanPeer lifts the ban of the host and resets its score.
+		UnbanPeer(host string) error
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
@@ -218,7 +462,45 @@ type (
 		// Online returns true if the gateway is connected to remote hosts
 		Online() bool
 
+		// Services returns the services the gateway advertises to its peers.
+		Services() GatewayServices
+
+		// SetServices sets the services the gateway advertises to its peers.
+		// Only new connections learn about the change.
+		SetServices(GatewayServices)
+
+		// ConnectServices connects to known nodes until the gateway is
+		// connected to at least n peers which advertise all of the provided
+		// services and returns those peers.
+		ConnectServices(services GatewayServices, n int) ([]Peer, error)
+
 		// Close safely stops the Gateway's listener process.
 		Close() error
 	}
 )
+
+// Has returns true if s contains all of the provided services.
+func (s GatewayServices) Has(services GatewayServices) bool {
+	return s&services == services
+}
+
+// Valid returns an error if the address family is unknown.
+func (af AddressFamily) Valid() error {
+	switch af {
+	case AddressFamilyAny, AddressFamilyIPv4, AddressFamilyIPv6:
+		return nil
+	default:
+		return errors.New("unknown address family: " + string(af))
+	}
+}
+
+// String returns a comma separated list of the services.
+func (s GatewayServices) String() string {
+	var names []string
+	for i, name := range serviceNames {
+		if s.Has(1 << uint(i)) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"net"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errNotAllowlisted is returned when connecting to or accepting a
+	// connection from a peer which isn't on the allowlist while the gateway
+	// is in allowlist-only mode.
+	errNotAllowlisted = errors.New("peer is not on the allowlist")
+
+	// errInvalidAllowlistAddress is returned when setting an allowlist which
+	// contains an entry that isn't an IP address.
+	errInvalidAllowlistAddress = errors.New("allowlist entries must be IP addresses")
+)
+
+// allowlistHost returns the host of an allowlist entry. Entries can either be
+// IP addresses or net addresses with an IP address as their host, in which
+// case the port is ignored.
+func allowlistHost(addr string) (string, error) {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", errors.AddContext(errInvalidAllowlistAddress, addr)
+	}
+	return ip.String(), nil
+}
+
+// allowed returns whether the gateway may connect to the address. If the
+// gateway isn't in allowlist-only mode, every address is allowed.
+func (g *Gateway) allowed(addr modules.NetAddress) bool {
+	if !g.allowlistOnly {
+		return true
+	}
+	ip := net.ParseIP(addr.Host())
+	if ip == nil {
+		return false
+	}
+	_, exists := g.allowlist[ip.String()]
+	return exists
+}
+
+// managedAllowed returns whether the gateway may connect to the address.
+func (g *Gateway) managedAllowed(addr modules.NetAddress) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.allowed(addr)
+}
+
+// Allowlist returns whether the gateway is in allowlist-only mode and the
+// hosts on the allowlist.
+func (g *Gateway) Allowlist() (bool, []string, error) {
+	if err := g.threads.Add(); err != nil {
+		return false, nil, err
+	}
+	defer g.threads.Done()
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	allowlist := make([]string, 0, len(g.allowlist))
+	for host := range g.allowlist {
+		allowlist = append(allowlist, host)
+	}
+	return g.allowlistOnly, allowlist, nil
+}
+
+// SetAllowlist replaces the allowlist of the gateway and sets whether the
+// gateway is in allowlist-only mode. In allowlist-only mode, the gateway only
+// dials and accepts peers whose host is on the allowlist, and it disconnects
+// from all other peers.
+func (g *Gateway) SetAllowlist(enabled bool, addresses []string) error {
+	allowlist := make(map[string]struct{}, len(addresses))
+	for _, addr := range addresses {
+		host, err := allowlistHost(addr)
+		if err != nil {
+			return err
+		}
+		allowlist[host] = struct{}{}
+	}
+
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.allowlist = allowlist
+	g.allowlistOnly = enabled
+
+	// Disconnect from the peers which are no longer allowed.
+	var err error
+	for addr, peer := range g.peers {
+		if !g.allowed(addr) {
+			g.log.Println("INFO: disconnecting from peer", addr, "because it isn't on the allowlist")
+			err = errors.Compose(err, peer.sess.Close())
+			delete(g.peers, addr)
+		}
+	}
+	return errors.Compose(err, g.saveSync())
+}
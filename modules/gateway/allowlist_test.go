@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+// TestAllowlistHost probes the normalization of allowlist entries.
+func TestAllowlistHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr string
+		host string
+		err  error
+	}{
+		{"10.0.0.1", "10.0.0.1", nil},
+		{"10.0.0.1:9981", "10.0.0.1", nil},
+		{"[::1]:9981", "::1", nil},
+		{"0:0::1", "::1", nil},
+		{"example.com", "", errInvalidAllowlistAddress},
+		{"example.com:9981", "", errInvalidAllowlistAddress},
+		{"", "", errInvalidAllowlistAddress},
+	}
+	for _, test := range tests {
+		host, err := allowlistHost(test.addr)
+		if (test.err == nil && err != nil) || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Errorf("%q: expected error %v, got %v", test.addr, test.err, err)
+		}
+		if host != test.host {
+			t.Errorf("%q: expected host %q, got %q", test.addr, test.host, host)
+		}
+	}
+}
+
+// TestAllowlist checks that a gateway in allowlist-only mode only dials and
+// accepts allowlisted peers and that the mode is persisted.
+func TestAllowlist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newNamedTestingGateway(t, "1")
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Enabling the allowlist-only mode disconnects all peers which aren't
+	// allowlisted.
+	if err := g1.SetAllowlist(true, []string{"10.0.0.1:9981"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("peer wasn't disconnected", g1.Peers())
+	}
+	if err := g1.Connect(g2.Address()); !errors.Contains(err, errNotAllowlisted) {
+		t.Fatal("expected errNotAllowlisted", err)
+	}
+
+	// Inbound connections are rejected as well.
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		if len(g2.Peers()) != 0 {
+			return errors.New("g2 still has peers")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Connect(g1.Address()); err == nil {
+		t.Fatal("g1 accepted a peer which isn't allowlisted")
+	}
+
+	// Invalid entries are rejected.
+	if err := g1.SetAllowlist(true, []string{"example.com"}); !errors.Contains(err, errInvalidAllowlistAddress) {
+		t.Fatal("expected errInvalidAllowlistAddress", err)
+	}
+
+	// Allowlist the local peers.
+	if err := g1.SetAllowlist(true, []string{"127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The allowlist survives a restart.
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err = New(string(g1.myAddr), false, g1.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	enabled, allowlist, err := g1.Allowlist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled || len(allowlist) != 1 || allowlist[0] != "127.0.0.1" {
+		t.Fatal("allowlist wasn't persisted", enabled, allowlist)
+	}
+
+	// Disabling the mode allows all peers again.
+	if err := g1.SetAllowlist(false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !g1.managedAllowed("10.0.0.2:9981") {
+		t.Fatal("peer should be allowed")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/monitor"
+	"gitlab.com/NebulousLabs/threadgroup"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -35,13 +36,18 @@ func newLocalAddr(addr modules.NetAddress) *net.TCPAddr {
 // staticDial appropriately handles things like clean shutdown, fast shutdown,
 // and chooses the correct communication protocol.
 func (g *Gateway) staticDial(addr modules.NetAddress) (net.Conn, error) {
+	// Wait until the global dial rate of the gateway allows another dial.
+	if !g.staticDialScheduler.callWaitForDial(g.threads.StopChan()) {
+		return nil, threadgroup.ErrStopped
+	}
 	dialer := &net.Dialer{
 		Cancel:  g.threads.StopChan(),
 		Timeout: dialTimeout,
 	}
 	// For testing set the local address to the gateway address. This is to
-	// prevent all the test nodes from having the same address
-	if build.Release == "testing" {
+	// prevent all the test nodes from having the same address. The local
+	// address can only be used to dial addresses of the same family.
+	if build.Release == "testing" && addr.Family() == g.myAddr.Family() {
 		dialer.LocalAddr = newLocalAddr(g.myAddr)
 	}
 
@@ -12,6 +12,14 @@ var (
 	// AlertMSGGatewayOffline indicates that the last time the gateway checked
 	// the network status it was offline.
 	AlertMSGGatewayOffline = "not connected to the internet"
+
+	// AlertMSGGatewayNoOutboundPeers indicates that the gateway hasn't been
+	// able to connect to any peers on its own for a while.
+	AlertMSGGatewayNoOutboundPeers = "no outbound peers"
+
+	// AlertMSGGatewayFewPeers indicates that the gateway has been connected
+	// to too few peers for a while.
+	AlertMSGGatewayFewPeers = "connected to too few peers"
 )
 
 const (
@@ -19,6 +27,19 @@ const (
 	// was altered to include additional information transfer.
 	handshakeUpgradeVersion = "1.0.0"
 
+	// servicesUpgradeVersion is the version where the gateway handshake was
+	// extended to advertise the services of the peers.
+	servicesUpgradeVersion = "1.5.5"
+
+	// addressesUpgradeVersion is the version where the gateway handshake was
+	// extended to advertise the addresses of the other address family.
+	addressesUpgradeVersion = "1.5.6"
+
+	// encryptionUpgradeVersion is the version where the gateway started to
+	// encrypt and authenticate its sessions. Older peers use plaintext
+	// sessions unless the gateway requires encryption.
+	encryptionUpgradeVersion = "1.5.7"
+
 	// maxEncodedSessionHeaderSize is the maximum allowed size of an encoded
 	// sessionHeader object.
 	maxEncodedSessionHeaderSize = 40 + modules.MaxEncodedNetAddressLength
@@ -92,6 +113,32 @@ var (
 		Testing:  5 * time.Second,
 	}).(time.Duration)
 
+	// connectivityAlertDelay is the amount of time the gateway has to have
+	// no outbound peers or fewer than minPeersAlertThreshold peers before
+	// the corresponding alert is registered.
+	connectivityAlertDelay = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// minPeersAlertThreshold is the number of peers below which the gateway
+	// registers an alert after connectivityAlertDelay.
+	minPeersAlertThreshold = build.Select(build.Var{
+		Standard: 3,
+		Dev:      2,
+		Testing:  2,
+	}).(int)
+
+	// encryptionPinExpiry is the amount of time after the last encrypted
+	// session with a node after which its pinned static key can be replaced.
+	// It allows nodes to rotate their keys and addresses to be reused.
+	encryptionPinExpiry = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
 	// peerRPCDelay defines the amount of time waited between each RPC accepted
 	// from a peer. Without this delay, a peer can force us to spin up thousands
 	// of goroutines per second.
@@ -158,6 +205,33 @@ var (
 		Testing:  2,
 	}).(int)
 
+	// maxInboundFullNodes is the maximum number of inbound connections from
+	// peers which advertised that they are full nodes.
+	maxInboundFullNodes = build.Select(build.Var{
+		Standard: 128,
+		Dev:      20,
+		Testing:  10,
+	}).(int)
+
+	// maxInboundLightClients is the maximum number of inbound connections
+	// from peers which advertised that they are light clients. It is kept
+	// low since light clients don't relay blocks.
+	maxInboundLightClients = build.Select(build.Var{
+		Standard: 32,
+		Dev:      5,
+		Testing:  3,
+	}).(int)
+
+	// maxInboundUnknownPeers is the maximum number of inbound connections
+	// from peers which didn't advertise whether they are full nodes or light
+	// clients, e.g. because they run a version older than
+	// servicesUpgradeVersion.
+	maxInboundUnknownPeers = build.Select(build.Var{
+		Standard: 128,
+		Dev:      20,
+		Testing:  10,
+	}).(int)
+
 	// noNodesDelay defines the amount of time that is waited between
 	// iterations of the peer acquisition loop if the gateway does not have any
 	// nodes in the nodelist.
@@ -213,6 +287,41 @@ var (
 		Testing:  500 * time.Millisecond,
 	}).(time.Duration)
 
+	// dialBackoffBase is the time the gateway waits before dialing an address
+	// again after it failed to connect once. The wait doubles with every
+	// further failure.
+	dialBackoffBase = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Dev:      5 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// dialBackoffMax is the longest time the gateway waits before dialing an
+	// address which failed to connect again.
+	dialBackoffMax = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      5 * time.Minute,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+
+	// maxDialFailures is the retry budget of an address. After that many
+	// failed connection attempts in a row, the address is removed from the
+	// node list if the node list is large enough.
+	maxDialFailures = build.Select(build.Var{
+		Standard: 5,
+		Dev:      3,
+		Testing:  2,
+	}).(int)
+
+	// minDialInterval is the minimum amount of time between two outbound
+	// dials of the gateway. It caps the rate at which the gateway dials out,
+	// e.g. when it tries to replace many peers at once after a network outage.
+	minDialInterval = build.Select(build.Var{
+		Standard: 250 * time.Millisecond,
+		Dev:      100 * time.Millisecond,
+		Testing:  10 * time.Millisecond,
+	}).(time.Duration)
+
 	// rpcStdDeadline defines the standard deadline that should be used for all
 	// incoming RPC calls.
 	rpcStdDeadline = build.Select(build.Var{
@@ -220,6 +329,81 @@ var (
 		Dev:      3 * time.Minute,
 		Testing:  5 * time.Second,
 	}).(time.Duration)
+
+	// rpcMaxDeadline is the time after which an RPC is aborted and the peer is
+	// disconnected. It exceeds the deadlines RPCs set on their own, so it only
+	// catches RPCs which don't limit themselves.
+	rpcMaxDeadline = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      5 * time.Minute,
+		Testing:  30 * time.Second,
+	}).(time.Duration)
+
+	// rpcStallWindow is the interval over which the transfer rate of an RPC is
+	// measured to detect stalled peers.
+	rpcStallWindow = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// rpcMinBytesPerSecond is the minimum rate at which a peer has to transfer
+	// the data of an RPC while the RPC waits for it. Slower peers are
+	// disconnected.
+	rpcMinBytesPerSecond = build.Select(build.Var{
+		Standard: uint64(1 << 10),
+		Dev:      uint64(1 << 10),
+		Testing:  uint64(16),
+	}).(uint64)
+)
+
+const (
+	// maxPeerDisconnects is the number of peer disconnects the gateway
+	// remembers.
+	maxPeerDisconnects = 100
+
+	// peerBanThreshold is the misbehavior score at which a peer is banned.
+	peerBanThreshold = 100
+
+	// maxPeerScores is the number of peer scores at which the scores of peers
+	// which have been forgiven are pruned.
+	maxPeerScores = 1000
+)
+
+var (
+	// peerMisbehaviorPenalties are the points which are added to the score
+	// of a peer for each kind of misbehavior. A single invalid block is enough
+	// to get a peer banned.
+	peerMisbehaviorPenalties = map[modules.PeerMisbehavior]float64{
+		modules.PeerMisbehaviorInvalidBlock: peerBanThreshold,
+		modules.PeerMisbehaviorMalformedRPC: 25,
+		modules.PeerMisbehaviorStall:        20,
+	}
+
+	// peerScoreDecayPeriod is the time it takes for the score of a peer to
+	// decay from the ban threshold to zero.
+	peerScoreDecayPeriod = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// peerBanBase is the duration of the first ban of a peer. The duration
+	// doubles with every further ban.
+	peerBanBase = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// peerBanMax is the longest duration of a ban. Peers which haven't been
+	// banned for that long after their last ban ended start over with a ban
+	// of peerBanBase.
+	peerBanMax = build.Select(build.Var{
+		Standard: 7 * 24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  30 * time.Second,
+	}).(time.Duration)
 )
 
 var (
@@ -264,4 +448,20 @@ var (
 		Dev:      1 * time.Second,
 		Testing:  100 * time.Millisecond,
 	}).(time.Duration)
+
+	// maxReachabilityPeers is the maximum number of peers which are asked to
+	// check the reachability of an address.
+	maxReachabilityPeers = build.Select(build.Var{
+		Standard: 5,
+		Dev:      3,
+		Testing:  3,
+	}).(int)
+
+	// reachabilityTimeout is the time a peer spends connecting to an address
+	// and opening a session with the host when checking its reachability.
+	reachabilityTimeout = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
 )
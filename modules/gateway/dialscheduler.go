@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// dialScheduler decides when the gateway may dial out. It spaces out all
+	// outbound dials of the gateway to enforce a global dial rate and backs
+	// off from addresses which failed to connect, giving each address a
+	// budget of failed attempts before it is given up on.
+	dialScheduler struct {
+		// backoffs contains the addresses which failed to connect since they
+		// last connected successfully.
+		backoffs map[modules.NetAddress]dialBackoff
+
+		// nextDial is the earliest time the next dial may start.
+		nextDial time.Time
+
+		staticDialInterval time.Duration
+		mu                 sync.Mutex
+	}
+
+	// dialBackoff tracks the failed connection attempts to a single address.
+	dialBackoff struct {
+		failures    int
+		nextAttempt time.Time
+	}
+)
+
+// newDialScheduler creates a new dialScheduler.
+func newDialScheduler() *dialScheduler {
+	return &dialScheduler{
+		backoffs:           make(map[modules.NetAddress]dialBackoff),
+		staticDialInterval: minDialInterval,
+	}
+}
+
+// jitter randomizes the duration d by up to 50% in either direction to prevent
+// loops which were started at the same time from staying in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	return d/2 + time.Duration(fastrand.Uint64n(uint64(d)))
+}
+
+// dialBackoffDelay returns the time to wait before dialing an address again
+// after it failed to connect the provided number of times in a row.
+func dialBackoffDelay(failures int) time.Duration {
+	delay := dialBackoffBase
+	for i := 1; i < failures && delay < dialBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > dialBackoffMax {
+		delay = dialBackoffMax
+	}
+	return jitter(delay)
+}
+
+// callDialFailed records a failed connection attempt to the address. It
+// returns the time until the address may be dialed again and whether the
+// address has exhausted its retry budget.
+func (ds *dialScheduler) callDialFailed(addr modules.NetAddress) (time.Duration, bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	b := ds.backoffs[addr]
+	b.failures++
+	delay := dialBackoffDelay(b.failures)
+	b.nextAttempt = time.Now().Add(delay)
+	ds.backoffs[addr] = b
+	return delay, b.failures >= maxDialFailures
+}
+
+// callDialSucceeded resets the backoff of the address after a successful
+// connection.
+func (ds *dialScheduler) callDialSucceeded(addr modules.NetAddress) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	delete(ds.backoffs, addr)
+}
+
+// callForget removes the backoff of an address which is no longer a
+// candidate for connections.
+func (ds *dialScheduler) callForget(addr modules.NetAddress) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	delete(ds.backoffs, addr)
+}
+
+// callReadyAddresses returns the addresses which are not backing off, keeping
+// their order.
+func (ds *dialScheduler) callReadyAddresses(addrs []modules.NetAddress) []modules.NetAddress {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	now := time.Now()
+	var ready []modules.NetAddress
+	for _, addr := range addrs {
+		if b, exists := ds.backoffs[addr]; exists && now.Before(b.nextAttempt) {
+			continue
+		}
+		ready = append(ready, addr)
+	}
+	return ready
+}
+
+// callWaitForDial blocks until the global dial rate allows another dial. It
+// returns false if the stop channel was closed before that.
+func (ds *dialScheduler) callWaitForDial(stop <-chan struct{}) bool {
+	ds.mu.Lock()
+	now := time.Now()
+	slot := ds.nextDial
+	if slot.Before(now) {
+		slot = now
+	}
+	ds.nextDial = slot.Add(ds.staticDialInterval)
+	ds.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return true
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestDialBackoffDelay tests that the backoff grows exponentially up to
+// dialBackoffMax and stays within the jitter bounds.
+func TestDialBackoffDelay(t *testing.T) {
+	t.Parallel()
+
+	expected := dialBackoffBase
+	for failures := 1; failures < 64; failures++ {
+		delay := dialBackoffDelay(failures)
+		if delay < expected/2 || delay >= expected*3/2 {
+			t.Fatalf("delay %v for %v failures is not within the jitter of %v", delay, failures, expected)
+		}
+		if expected < dialBackoffMax {
+			expected *= 2
+		}
+		if expected > dialBackoffMax {
+			expected = dialBackoffMax
+		}
+	}
+}
+
+// TestDialSchedulerBackoff tests that addresses which failed to connect are
+// skipped until their backoff expires and that they exhaust their retry
+// budget.
+func TestDialSchedulerBackoff(t *testing.T) {
+	t.Parallel()
+
+	ds := newDialScheduler()
+	addrs := []modules.NetAddress{"1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3"}
+
+	// A failed address is skipped while the other addresses keep their order.
+	retryIn, exhausted := ds.callDialFailed(addrs[1])
+	if exhausted && maxDialFailures > 1 {
+		t.Fatal("retry budget exhausted after a single failure")
+	}
+	if retryIn < dialBackoffBase/2 {
+		t.Fatal("backoff too short", retryIn)
+	}
+	ready := ds.callReadyAddresses(addrs)
+	if len(ready) != 2 || ready[0] != addrs[0] || ready[1] != addrs[2] {
+		t.Fatal("unexpected ready addresses", ready)
+	}
+	if len(addrs) != 3 || addrs[1] != "2.2.2.2:2" {
+		t.Fatal("input addresses were modified")
+	}
+
+	// The address exhausts its budget after maxDialFailures failures.
+	for i := 1; i < maxDialFailures; i++ {
+		_, exhausted = ds.callDialFailed(addrs[1])
+	}
+	if !exhausted {
+		t.Fatal("retry budget not exhausted")
+	}
+
+	// A successful connection resets the backoff.
+	ds.callDialSucceeded(addrs[1])
+	if len(ds.callReadyAddresses(addrs)) != len(addrs) {
+		t.Fatal("backoff not reset")
+	}
+	_, exhausted = ds.callDialFailed(addrs[1])
+	if exhausted && maxDialFailures > 1 {
+		t.Fatal("retry budget not reset")
+	}
+
+	// Forgetting an address removes its backoff.
+	ds.callForget(addrs[1])
+	if len(ds.backoffs) != 0 {
+		t.Fatal("backoff not forgotten")
+	}
+}
+
+// TestDialSchedulerRate tests that dials are spaced out by the dial interval
+// and that waiting for a dial can be interrupted.
+func TestDialSchedulerRate(t *testing.T) {
+	t.Parallel()
+
+	ds := newDialScheduler()
+	ds.staticDialInterval = 50 * time.Millisecond
+	stop := make(chan struct{})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if !ds.callWaitForDial(stop) {
+			t.Fatal("wait was interrupted")
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 3*ds.staticDialInterval {
+		t.Fatal("dials weren't spaced out", elapsed)
+	}
+
+	// Reserve a slot far in the future and interrupt the wait for it.
+	ds.staticDialInterval = time.Hour
+	ds.callWaitForDial(stop)
+	close(stop)
+	if ds.callWaitForDial(stop) {
+		t.Fatal("wait wasn't interrupted")
+	}
+}
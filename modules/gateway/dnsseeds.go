@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"net"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// splitDNSSeed splits a DNS seed into its hostname and port. If the seed
+// doesn't specify a port, modules.DefaultDNSSeedPort is used.
+func splitDNSSeed(seed string) (host, port string) {
+	host, port, err := net.SplitHostPort(seed)
+	if err != nil {
+		return seed, modules.DefaultDNSSeedPort
+	}
+	return host, port
+}
+
+// managedQueryDNSSeed resolves a single DNS seed and returns the addresses of
+// the nodes it points to.
+func (g *Gateway) managedQueryDNSSeed(seed string) ([]modules.NetAddress, error) {
+	host, port := splitDNSSeed(seed)
+	ips, err := g.staticDeps.Resolver().LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]modules.NetAddress, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, modules.NetAddress(net.JoinHostPort(ip.String(), port)))
+	}
+	return addrs, nil
+}
+
+// threadedQueryDNSSeeds queries the gateway's DNS seeds for the A and AAAA
+// records of long-running nodes and merges them into the node list. Since the
+// records of a seed might be stale, only the nodes which are reachable and
+// run a compatible version are added.
+func (g *Gateway) threadedQueryDNSSeeds() {
+	if err := g.threads.Add(); err != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	// In allowlist-only mode, the gateway doesn't look for public nodes.
+	g.mu.RLock()
+	allowlistOnly := g.allowlistOnly
+	g.mu.RUnlock()
+	if allowlistOnly {
+		return
+	}
+
+	// Resolve the seeds. Nodes which are already known don't need to be
+	// pinged again.
+	seen := make(map[modules.NetAddress]struct{})
+	var addrs []modules.NetAddress
+	for _, seed := range g.staticDNSSeeds {
+		seedAddrs, err := g.managedQueryDNSSeed(seed)
+		if err != nil {
+			g.log.Printf("WARN: failed to query DNS seed '%v': %v", seed, err)
+			continue
+		}
+		g.mu.RLock()
+		for _, addr := range seedAddrs {
+			_, known := g.nodes[addr]
+			_, dup := seen[addr]
+			if !known && !dup {
+				seen[addr] = struct{}{}
+				addrs = append(addrs, addr)
+			}
+		}
+		g.mu.RUnlock()
+		g.log.Debugf("INFO: DNS seed '%v' returned %v nodes", seed, len(seedAddrs))
+	}
+
+	// Ping the nodes concurrently and add the ones which completed the
+	// version handshake.
+	var added int
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	limiter := make(chan struct{}, maxConcurrentOutboundPeerRequests)
+	for _, addr := range addrs {
+		select {
+		case limiter <- struct{}{}:
+		case <-g.threads.StopChan():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(addr modules.NetAddress) {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+			if err := g.staticPingNode(addr); err != nil {
+				g.log.Debugf("INFO: ignoring node '%v' from the DNS seeds: %v", addr, err)
+				return
+			}
+			g.mu.Lock()
+			err := g.addNode(addr)
+			g.mu.Unlock()
+			if err != nil {
+				if !errors.Contains(err, errNodeExists) {
+					g.log.Debugf("WARN: failed to add node '%v' from the DNS seeds: %v", addr, err)
+				}
+				return
+			}
+			mu.Lock()
+			added++
+			mu.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+	g.log.Printf("INFO: added %v of %v nodes from the DNS seeds", added, len(addrs))
+}
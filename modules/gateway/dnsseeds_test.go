@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// dependencyDNSSeeds is a dependency that resolves the hostnames of DNS
+	// seeds using a static map.
+	dependencyDNSSeeds struct {
+		modules.ProductionDependencies
+		records map[string][]net.IP
+	}
+
+	// testDNSSeedResolver is the resolver returned by dependencyDNSSeeds.
+	testDNSSeedResolver struct {
+		records map[string][]net.IP
+	}
+)
+
+// LookupIP returns the static records for a host.
+func (r testDNSSeedResolver) LookupIP(host string) ([]net.IP, error) {
+	ips, ok := r.records[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return ips, nil
+}
+
+// Resolver returns a testDNSSeedResolver.
+func (d *dependencyDNSSeeds) Resolver() modules.Resolver {
+	return testDNSSeedResolver{records: d.records}
+}
+
+// TestSplitDNSSeed is a unit test for splitDNSSeed.
+func TestSplitDNSSeed(t *testing.T) {
+	tests := []struct {
+		seed string
+		host string
+		port string
+	}{
+		{"seed.example.com", "seed.example.com", modules.DefaultDNSSeedPort},
+		{"seed.example.com:9991", "seed.example.com", "9991"},
+		{"[::1]:9991", "::1", "9991"},
+	}
+	for _, test := range tests {
+		host, port := splitDNSSeed(test.seed)
+		if host != test.host || port != test.port {
+			t.Errorf("splitDNSSeed(%v): expected %v %v but got %v %v", test.seed, test.host, test.port, host, port)
+		}
+	}
+}
+
+// TestQueryDNSSeeds checks that the gateway adds the reachable nodes returned
+// by its DNS seeds to the node list when bootstrapping.
+func TestQueryDNSSeeds(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a node for the seeds to point to.
+	seedNode := newNamedTestingGateway(t, "seednode")
+	defer func() {
+		if err := seedNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Get a port nobody listens on for a stale record.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, stalePort, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &dependencyDNSSeeds{
+		records: map[string][]net.IP{
+			"seed1.example.com": {net.ParseIP("127.0.0.1")},
+			"seed2.example.com": {net.ParseIP("127.0.0.1")},
+		},
+	}
+	seeds := []string{
+		"seed1.example.com:" + seedNode.Address().Port(),
+		"seed2.example.com:" + stalePort,
+		"unknown.example.com",
+	}
+	g, err := NewCustomGatewayWithDNSSeeds("localhost:0", true, seeds, nil, build.TempDir("gateway", t.Name()), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The reachable node is added.
+	reachable := modules.NetAddress(net.JoinHostPort("127.0.0.1", seedNode.Address().Port()))
+	err = build.Retry(100, 50*time.Millisecond, func() error {
+		g.mu.RLock()
+		defer g.mu.RUnlock()
+		if _, exists := g.nodes[reachable]; !exists {
+			return errors.New("node missing from node list: " + string(reachable))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The stale record is ignored.
+	time.Sleep(2 * dialTimeout)
+	stale := modules.NetAddress(net.JoinHostPort("127.0.0.1", stalePort))
+	g.mu.RLock()
+	_, exists := g.nodes[stale]
+	g.mu.RUnlock()
+	if exists {
+		t.Fatal("unreachable node from a DNS seed was added to the node list")
+	}
+}
+
+// TestQueryDNSSeedsNoBootstrap checks that the DNS seeds are not queried if
+// the gateway doesn't bootstrap.
+func TestQueryDNSSeedsNoBootstrap(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	deps := &dependencyDNSSeeds{
+		records: map[string][]net.IP{
+			"seed.example.com": {net.ParseIP("1.2.3.4")},
+		},
+	}
+	g, err := NewCustomGatewayWithDNSSeeds("localhost:0", false, []string{"seed.example.com"}, nil, build.TempDir("gateway", t.Name()), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	time.Sleep(time.Second)
+	g.mu.RLock()
+	_, exists := g.nodes["1.2.3.4:9981"]
+	g.mu.RUnlock()
+	if exists {
+		t.Fatal("DNS seed was queried despite bootstrapping being disabled")
+	}
+}
@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+
+	"gitlab.com/NebulousLabs/encoding"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxAdvertisedAddresses is the maximum number of alternate addresses a
+	// peer may advertise during the handshake.
+	maxAdvertisedAddresses = 2
+
+	// maxEncodedAddressesSize is the maximum allowed size of the encoded
+	// alternate addresses of a peer.
+	maxEncodedAddressesSize = 8 + maxAdvertisedAddresses*(8+modules.MaxEncodedNetAddressLength)
+)
+
+// nonPublicNetworks are the IPv4 and IPv6 ranges of addresses which can't be
+// reached from the public internet, in addition to the loopback, link-local
+// and unspecified addresses.
+var nonPublicNetworks = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"10.0.0.0/8",     // RFC 1918
+		"100.64.0.0/10",  // RFC 6598, carrier-grade NAT
+		"172.16.0.0/12",  // RFC 1918
+		"192.168.0.0/16", // RFC 1918
+		"fc00::/7",       // RFC 4193, unique local addresses
+	} {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			build.Critical("invalid non-public network", cidr, err)
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets
+}()
+
+// isPublicIP returns true if the IP can be reached from the public internet.
+func isPublicIP(ip net.IP) bool {
+	if ip == nil || !ip.IsGlobalUnicast() {
+		return false
+	}
+	for _, ipnet := range nonPublicNetworks {
+		if ipnet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// alternateIP returns the first public IP of the interface addresses which
+// belongs to the other address family than ip. If there is no such IP, nil
+// is returned.
+func alternateIP(ip net.IP, ifaceAddrs []net.Addr) net.IP {
+	ipv4 := ip.To4() != nil
+	for _, addr := range ifaceAddrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || !isPublicIP(ipnet.IP) {
+			continue
+		}
+		if (ipnet.IP.To4() != nil) != ipv4 {
+			return ipnet.IP
+		}
+	}
+	return nil
+}
+
+// validAdvertisedAddresses returns the addresses advertised by a peer which
+// are valid, of the other family than the address the peer is connected on
+// and not blocklisted.
+func (g *Gateway) validAdvertisedAddresses(peerAddr modules.NetAddress, addrs []modules.NetAddress) []modules.NetAddress {
+	var valid []modules.NetAddress
+	for _, addr := range addrs {
+		if addr.IsStdValid() != nil || net.ParseIP(addr.Host()) == nil {
+			continue
+		}
+		if addr.Family() == peerAddr.Family() {
+			continue
+		}
+		if _, blocked := g.blocklist[addr.Host()]; blocked {
+			continue
+		}
+		valid = append(valid, addr)
+	}
+	return valid
+}
+
+// connectAddressesHandshake sends our alternate addresses and reads the
+// remote alternate addresses. It should be called on the side making the
+// connection request after the services were exchanged. Peers older than
+// addressesUpgradeVersion don't advertise any alternate addresses.
+func connectAddressesHandshake(conn net.Conn, remoteVersion string, ourAddrs []modules.NetAddress) (remoteAddrs []modules.NetAddress, err error) {
+	if build.VersionCmp(remoteVersion, addressesUpgradeVersion) < 0 {
+		return nil, nil
+	}
+	if err := encoding.WriteObject(conn, ourAddrs); err != nil {
+		return nil, fmt.Errorf("failed to write addresses: %v", err)
+	}
+	if err := encoding.ReadObject(conn, &remoteAddrs, maxEncodedAddressesSize); err != nil {
+		return nil, fmt.Errorf("failed to read remote addresses: %v", err)
+	}
+	if len(remoteAddrs) > maxAdvertisedAddresses {
+		return nil, fmt.Errorf("peer advertised too many addresses: %v", len(remoteAddrs))
+	}
+	return remoteAddrs, nil
+}
+
+// acceptAddressesHandshake reads the remote alternate addresses and sends our
+// alternate addresses. It should be called on the side accepting a
+// connection request after the services were exchanged. Peers older than
+// addressesUpgradeVersion don't advertise any alternate addresses.
+func acceptAddressesHandshake(conn net.Conn, remoteVersion string, ourAddrs []modules.NetAddress) (remoteAddrs []modules.NetAddress, err error) {
+	if build.VersionCmp(remoteVersion, addressesUpgradeVersion) < 0 {
+		return nil, nil
+	}
+	if err := encoding.ReadObject(conn, &remoteAddrs, maxEncodedAddressesSize); err != nil {
+		return nil, fmt.Errorf("failed to read remote addresses: %v", err)
+	}
+	if len(remoteAddrs) > maxAdvertisedAddresses {
+		return nil, fmt.Errorf("peer advertised too many addresses: %v", len(remoteAddrs))
+	}
+	if err := encoding.WriteObject(conn, ourAddrs); err != nil {
+		return nil, fmt.Errorf("failed to write addresses: %v", err)
+	}
+	return remoteAddrs, nil
+}
+
+// alternateAddresses returns the addresses of the gateway which are
+// advertised to peers in addition to the address of the session header.
+func (g *Gateway) alternateAddresses() []modules.NetAddress {
+	if g.altAddr == "" {
+		return nil
+	}
+	return []modules.NetAddress{g.altAddr}
+}
+
+// connectedPeer returns the peer which is connected on the address or
+// advertised it as an alternate address.
+func (g *Gateway) connectedPeer(addr modules.NetAddress) (*peer, bool) {
+	if p, exists := g.peers[addr]; exists {
+		return p, true
+	}
+	for _, p := range g.peers {
+		for _, alt := range p.Addresses {
+			if alt == addr {
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// duplicatePeer returns true if the gateway is already connected to the
+// gateway with the id on another address than addr, e.g. over the other
+// address family.
+func (g *Gateway) duplicatePeer(id gatewayID, addr modules.NetAddress) bool {
+	for peerAddr, p := range g.peers {
+		if p.id == id && peerAddr != addr {
+			return true
+		}
+	}
+	return false
+}
+
+// threadedAddAdvertisedAddresses pings the alternate addresses a peer
+// advertised and adds the reachable ones to the node list.
+func (g *Gateway) threadedAddAdvertisedAddresses(addrs []modules.NetAddress, services modules.GatewayServices) {
+	if err := g.threads.Add(); err != nil {
+		return
+	}
+	defer g.threads.Done()
+	for _, addr := range addrs {
+		if !g.managedAllowed(addr) || g.staticPingNode(addr) != nil {
+			continue
+		}
+		g.mu.Lock()
+		g.addNode(addr)
+		if n, exists := g.nodes[addr]; exists {
+			n.Services = services
+		}
+		g.mu.Unlock()
+	}
+}
+
+// preferFamily moves the addresses of the family to the front of the list
+// while preserving the order of the addresses within both groups.
+func preferFamily(addrs []modules.NetAddress, family modules.AddressFamily) []modules.NetAddress {
+	if family == modules.AddressFamilyAny {
+		return addrs
+	}
+	sorted := make([]modules.NetAddress, 0, len(addrs))
+	for _, addr := range addrs {
+		if addr.Family() == family {
+			sorted = append(sorted, addr)
+		}
+	}
+	for _, addr := range addrs {
+		if addr.Family() != family {
+			sorted = append(sorted, addr)
+		}
+	}
+	return sorted
+}
+
+// Addresses returns the address of the gateway followed by its address of the
+// other address family if it has one.
+func (g *Gateway) Addresses() []modules.NetAddress {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]modules.NetAddress{g.myAddr}, g.alternateAddresses()...)
+}
+
+// PreferredAddressFamily returns the address family the gateway prefers when
+// dialing peers.
+func (g *Gateway) PreferredAddressFamily() modules.AddressFamily {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.preferredFamily
+}
+
+// SetPreferredAddressFamily sets the address family the gateway prefers when
+// dialing peers and persists it.
+func (g *Gateway) SetPreferredAddressFamily(family modules.AddressFamily) error {
+	if err := family.Valid(); err != nil {
+		return err
+	}
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.preferredFamily = family
+	return g.saveSync()
+}
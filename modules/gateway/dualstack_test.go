@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestAddressesHandshake checks that alternate addresses are only exchanged
+// with peers which support it.
+func TestAddressesHandshake(t *testing.T) {
+	connectAddrs := []modules.NetAddress{"[2001:db8::1]:9981"}
+	acceptAddrs := []modules.NetAddress{"[2001:db8::2]:9981"}
+	tests := []struct {
+		version           string
+		expectedAddresses bool
+	}{
+		{ProtocolVersion, true},
+		{addressesUpgradeVersion, true},
+		{servicesUpgradeVersion, false},
+	}
+	for _, test := range tests {
+		c1, c2 := net.Pipe()
+		var accepted []modules.NetAddress
+		var acceptErr error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			accepted, acceptErr = acceptAddressesHandshake(c2, test.version, acceptAddrs)
+		}()
+		connected, err := connectAddressesHandshake(c1, test.version, connectAddrs)
+		<-done
+		if err := errors.Compose(err, acceptErr, c1.Close(), c2.Close()); err != nil {
+			t.Fatal(err)
+		}
+		if !test.expectedAddresses {
+			if connected != nil || accepted != nil {
+				t.Fatalf("%v: addresses shouldn't be exchanged: %v %v", test.version, connected, accepted)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(connected, acceptAddrs) || !reflect.DeepEqual(accepted, connectAddrs) {
+			t.Fatalf("%v: wrong addresses: %v %v", test.version, connected, accepted)
+		}
+	}
+}
+
+// TestAlternateIP probes alternateIP.
+func TestAlternateIP(t *testing.T) {
+	ifaceAddrs := func(cidrs ...string) []net.Addr {
+		var addrs []net.Addr
+		for _, cidr := range cidrs {
+			ip, ipnet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ipnet.IP = ip
+			addrs = append(addrs, ipnet)
+		}
+		return addrs
+	}
+	tests := []struct {
+		ip       string
+		ifaces   []net.Addr
+		expected string
+	}{
+		// IPv4 gateway with a public IPv6 address.
+		{"1.2.3.4", ifaceAddrs("127.0.0.1/8", "192.168.1.2/24", "::1/128", "fe80::1/64", "2001:db8::1/64"), "2001:db8::1"},
+		// IPv4 gateway with only a unique local IPv6 address.
+		{"1.2.3.4", ifaceAddrs("192.168.1.2/24", "fd00::1/64"), ""},
+		// IPv6 gateway behind carrier-grade NAT.
+		{"2001:db8::1", ifaceAddrs("100.64.1.2/10", "2001:db8::1/64"), ""},
+		// IPv6 gateway with a public IPv4 address.
+		{"2001:db8::1", ifaceAddrs("2001:db8::1/64", "1.2.3.4/24"), "1.2.3.4"},
+	}
+	for _, test := range tests {
+		ip := alternateIP(net.ParseIP(test.ip), test.ifaces)
+		if test.expected == "" && ip != nil {
+			t.Errorf("%v: expected no alternate IP but got %v", test.ip, ip)
+		} else if test.expected != "" && !ip.Equal(net.ParseIP(test.expected)) {
+			t.Errorf("%v: expected %v but got %v", test.ip, test.expected, ip)
+		}
+	}
+}
+
+// TestPreferFamily probes preferFamily.
+func TestPreferFamily(t *testing.T) {
+	addrs := []modules.NetAddress{"1.1.1.1:9981", "[2001:db8::1]:9981", "2.2.2.2:9981", "[2001:db8::2]:9981"}
+	if sorted := preferFamily(addrs, modules.AddressFamilyAny); !reflect.DeepEqual(sorted, addrs) {
+		t.Fatal("addresses shouldn't be reordered", sorted)
+	}
+	expected := []modules.NetAddress{"[2001:db8::1]:9981", "[2001:db8::2]:9981", "1.1.1.1:9981", "2.2.2.2:9981"}
+	if sorted := preferFamily(addrs, modules.AddressFamilyIPv6); !reflect.DeepEqual(sorted, expected) {
+		t.Fatal("wrong order", sorted)
+	}
+	expected = []modules.NetAddress{"1.1.1.1:9981", "2.2.2.2:9981", "[2001:db8::1]:9981", "[2001:db8::2]:9981"}
+	if sorted := preferFamily(addrs, modules.AddressFamilyIPv4); !reflect.DeepEqual(sorted, expected) {
+		t.Fatal("wrong order", sorted)
+	}
+}
+
+// TestDualStackPeers checks that the gateway learns the alternate addresses
+// of its peers and doesn't connect to the same peer twice.
+func TestDualStackPeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newNamedTestingGateway(t, "1")
+	g2 := newNamedTestingGateway(t, "2")
+	g3 := newNamedTestingGateway(t, "3")
+	defer func() {
+		if err := errors.Compose(g1.Close(), g2.Close(), g3.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Give g1 an IPv6 address and make g3 pretend to be g1 on another address.
+	altAddr := modules.NetAddress(net.JoinHostPort("::1", g1.port))
+	g1.mu.Lock()
+	g1.altAddr = altAddr
+	g1.mu.Unlock()
+	g3.staticID = g1.staticID
+	if addrs := g1.Addresses(); !reflect.DeepEqual(addrs, []modules.NetAddress{g1.Address(), altAddr}) {
+		t.Fatal("wrong addresses", addrs)
+	}
+
+	// g2 should learn the alternate address of g1 when connecting.
+	if err := connectToNode(g2, g1, false); err != nil {
+		t.Fatal(err)
+	}
+	g2.mu.RLock()
+	p, exists := g2.peers[g1.Address()]
+	g2.mu.RUnlock()
+	if !exists || !reflect.DeepEqual(p.Addresses, []modules.NetAddress{altAddr}) {
+		t.Fatal("g2 didn't learn g1's alternate address", p)
+	}
+	g2.mu.RLock()
+	_, connected := g2.connectedPeer(altAddr)
+	g2.mu.RUnlock()
+	if !connected {
+		t.Fatal("g2 should be connected to g1's alternate address")
+	}
+	if err := g2.Connect(altAddr); !errors.Contains(err, errPeerExists) {
+		t.Fatal("expected errPeerExists, got", err)
+	}
+
+	// g2 shouldn't connect to g3 since it has the same id as g1.
+	if err := g2.Connect(g3.Address()); !errors.Contains(err, errPeerExists) {
+		t.Fatal("expected errPeerExists, got", err)
+	}
+	if peers := g2.Peers(); len(peers) != 1 {
+		t.Fatal("expected 1 peer, got", len(peers))
+	}
+}
+
+// TestPreferredAddressFamilyPersist checks that the preferred address family
+// is validated and persisted.
+func TestPreferredAddressFamilyPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g := newTestingGateway(t)
+	if err := g.SetPreferredAddressFamily("ipv5"); err == nil {
+		t.Fatal("expected invalid address family to be rejected")
+	}
+	if err := g.SetPreferredAddressFamily(modules.AddressFamilyIPv6); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New("localhost:0", false, g.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if family := g.PreferredAddressFamily(); family != modules.AddressFamilyIPv6 {
+		t.Fatal("preferred address family wasn't persisted", family)
+	}
+}
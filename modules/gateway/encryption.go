@@ -0,0 +1,361 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxEncryptedFramePayload is the maximum number of plaintext bytes sent
+	// in a single frame of an encrypted session.
+	maxEncryptedFramePayload = 1 << 14
+
+	// encryptedFrameHeaderSize is the size of the length prefix of a frame
+	// of an encrypted session.
+	encryptedFrameHeaderSize = 4
+)
+
+var (
+	// errEncryptionRequired is returned when a peer doesn't support encrypted
+	// sessions while the gateway requires them.
+	errEncryptionRequired = errors.New("peer doesn't support encrypted sessions")
+
+	// errEncryptionDowngrade is returned when a node which previously
+	// established an encrypted session tries to establish a plaintext one.
+	errEncryptionDowngrade = errors.New("peer previously supported encrypted sessions")
+
+	// errEncryptionKeyMismatch is returned when a node's static key doesn't
+	// match the key it used before. A manual connection accepts the new key.
+	errEncryptionKeyMismatch = errors.New("peer's static key doesn't match its pinned key")
+
+	// errEncryptionConfirmation is returned when the key confirmation of the
+	// encryption handshake fails, e.g. because the peer doesn't own the static
+	// key it advertised or the handshake was tampered with.
+	errEncryptionConfirmation = errors.New("encryption handshake confirmation failed")
+
+	// encryptionKeySpecifiers are used to derive the keys of both directions
+	// of an encrypted session.
+	encryptionKeySpecifierConnect = []byte("gateway connect key")
+	encryptionKeySpecifierAccept  = []byte("gateway accept key")
+
+	// sessionKeySpecifier is used to derive the static key of the encrypted
+	// sessions from the gateway key of the identity.
+	sessionKeySpecifier = []byte("gateway session key")
+)
+
+// encryptionHandshake is the message the peers exchange to establish an
+// encrypted session. The ephemeral key provides forward secrecy and the
+// static key authenticates the gateway.
+type encryptionHandshake struct {
+	EphemeralKey crypto.X25519PublicKey
+	StaticKey    crypto.X25519PublicKey
+}
+
+// deriveSessionKey derives the static X25519 key of the gateway's encrypted
+// sessions from the ed25519 gateway key of the identity.
+func deriveSessionKey(sk crypto.SecretKey) (xsk crypto.X25519SecretKey) {
+	h := crypto.HashAll(sessionKeySpecifier, sk)
+	copy(xsk[:], h[:])
+	return
+}
+
+// encryptionKeys derives the keys of an encrypted session and the transcript
+// hash which is confirmed by both peers. The transcript includes the version
+// strings of the peers to detect a tampered version handshake.
+//
+// The key material combines the ephemeral-ephemeral, the
+// ephemeral-static and the static-ephemeral shared secrets, which means that
+// only the owners of both static keys can derive it.
+func encryptionKeys(connectVersion, acceptVersion string, connectHandshake, acceptHandshake encryptionHandshake, ee, es, se [32]byte) (connectKey, acceptKey, transcript crypto.Hash) {
+	transcript = crypto.HashAll(connectVersion, acceptVersion, connectHandshake, acceptHandshake)
+	secret := crypto.HashAll(transcript, ee, es, se)
+	connectKey = crypto.HashAll(encryptionKeySpecifierConnect, secret)
+	acceptKey = crypto.HashAll(encryptionKeySpecifierAccept, secret)
+	return
+}
+
+// confirmEncryption exchanges the transcript hash over the encrypted conn to
+// verify that both peers derived the same keys. The side making the
+// connection request writes first.
+func confirmEncryption(conn net.Conn, transcript crypto.Hash, connectSide bool) error {
+	write := func() error {
+		return encoding.WriteObject(conn, transcript)
+	}
+	read := func() error {
+		var remoteTranscript crypto.Hash
+		if err := encoding.ReadObject(conn, &remoteTranscript, crypto.HashSize); err != nil {
+			return errors.Compose(errEncryptionConfirmation, err)
+		}
+		if remoteTranscript != transcript {
+			return errEncryptionConfirmation
+		}
+		return nil
+	}
+	if connectSide {
+		if err := write(); err != nil {
+			return err
+		}
+		return read()
+	}
+	if err := read(); err != nil {
+		return err
+	}
+	return write()
+}
+
+// connectEncryptionHandshake establishes an encrypted session with a peer
+// which supports it. It should be called on the side making the connection
+// request right after the version handshake. Peers older than
+// encryptionUpgradeVersion use plaintext sessions, in which case the conn is
+// returned unmodified and the remote key is nil.
+func connectEncryptionHandshake(conn net.Conn, ourVersion, remoteVersion string, staticKey crypto.X25519SecretKey) (net.Conn, *crypto.X25519PublicKey, error) {
+	if build.VersionCmp(remoteVersion, encryptionUpgradeVersion) < 0 {
+		return conn, nil, nil
+	}
+	ephemeralKey, ephemeralPK := crypto.GenerateX25519KeyPair()
+	ourHandshake := encryptionHandshake{
+		EphemeralKey: ephemeralPK,
+		StaticKey:    staticKey.PublicKey(),
+	}
+	if err := encoding.WriteObject(conn, ourHandshake); err != nil {
+		return nil, nil, fmt.Errorf("failed to write encryption handshake: %v", err)
+	}
+	var remoteHandshake encryptionHandshake
+	if err := encoding.ReadObject(conn, &remoteHandshake, 64); err != nil {
+		return nil, nil, fmt.Errorf("failed to read remote encryption handshake: %v", err)
+	}
+	ee := crypto.DeriveSharedSecret(ephemeralKey, remoteHandshake.EphemeralKey)
+	es := crypto.DeriveSharedSecret(ephemeralKey, remoteHandshake.StaticKey)
+	se := crypto.DeriveSharedSecret(staticKey, remoteHandshake.EphemeralKey)
+	connectKey, acceptKey, transcript := encryptionKeys(ourVersion, remoteVersion, ourHandshake, remoteHandshake, ee, es, se)
+	econn, err := newEncryptedConn(conn, acceptKey, connectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := confirmEncryption(econn, transcript, true); err != nil {
+		return nil, nil, err
+	}
+	return econn, &remoteHandshake.StaticKey, nil
+}
+
+// acceptEncryptionHandshake establishes an encrypted session with a peer
+// which supports it. It should be called on the side accepting a connection
+// request right after the version handshake. Peers older than
+// encryptionUpgradeVersion use plaintext sessions, in which case the conn is
+// returned unmodified and the remote key is nil.
+func acceptEncryptionHandshake(conn net.Conn, ourVersion, remoteVersion string, staticKey crypto.X25519SecretKey) (net.Conn, *crypto.X25519PublicKey, error) {
+	if build.VersionCmp(remoteVersion, encryptionUpgradeVersion) < 0 {
+		return conn, nil, nil
+	}
+	var remoteHandshake encryptionHandshake
+	if err := encoding.ReadObject(conn, &remoteHandshake, 64); err != nil {
+		return nil, nil, fmt.Errorf("failed to read remote encryption handshake: %v", err)
+	}
+	ephemeralKey, ephemeralPK := crypto.GenerateX25519KeyPair()
+	ourHandshake := encryptionHandshake{
+		EphemeralKey: ephemeralPK,
+		StaticKey:    staticKey.PublicKey(),
+	}
+	if err := encoding.WriteObject(conn, ourHandshake); err != nil {
+		return nil, nil, fmt.Errorf("failed to write encryption handshake: %v", err)
+	}
+	ee := crypto.DeriveSharedSecret(ephemeralKey, remoteHandshake.EphemeralKey)
+	es := crypto.DeriveSharedSecret(staticKey, remoteHandshake.EphemeralKey)
+	se := crypto.DeriveSharedSecret(ephemeralKey, remoteHandshake.StaticKey)
+	connectKey, acceptKey, transcript := encryptionKeys(remoteVersion, ourVersion, remoteHandshake, ourHandshake, ee, es, se)
+	econn, err := newEncryptedConn(conn, connectKey, acceptKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := confirmEncryption(econn, transcript, false); err != nil {
+		return nil, nil, err
+	}
+	return econn, &remoteHandshake.StaticKey, nil
+}
+
+// encryptedConn is a net.Conn which encrypts and authenticates the data sent
+// over the wrapped conn. The data is split into frames which are prefixed
+// with their length. The nonces of the frames are counters, which means that
+// dropped, replayed or reordered frames fail to decrypt.
+type encryptedConn struct {
+	net.Conn
+
+	staticReadAEAD  cipher.AEAD
+	staticWriteAEAD cipher.AEAD
+
+	readBuf   bytes.Buffer
+	readNonce uint64
+	readMu    sync.Mutex
+
+	writeNonce uint64
+	writeMu    sync.Mutex
+}
+
+// newEncryptedConn wraps the conn to decrypt the data read from it with the
+// readKey and to encrypt the data written to it with the writeKey.
+func newEncryptedConn(conn net.Conn, readKey, writeKey crypto.Hash) (*encryptedConn, error) {
+	readAEAD, err := chacha20poly1305.New(readKey[:])
+	if err != nil {
+		return nil, err
+	}
+	writeAEAD, err := chacha20poly1305.New(writeKey[:])
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedConn{
+		Conn:            conn,
+		staticReadAEAD:  readAEAD,
+		staticWriteAEAD: writeAEAD,
+	}, nil
+}
+
+// frameNonce returns the nonce of the frame with the counter.
+func frameNonce(counter uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce, counter)
+	return nonce
+}
+
+// Read reads and decrypts data from the conn.
+func (c *encryptedConn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if c.readBuf.Len() == 0 {
+		if err := c.readFrame(); err != nil {
+			return 0, err
+		}
+	}
+	return c.readBuf.Read(b)
+}
+
+// readFrame reads the next frame from the conn and decrypts it into the read
+// buffer.
+func (c *encryptedConn) readFrame() error {
+	var header [encryptedFrameHeaderSize]byte
+	if _, err := io.ReadFull(c.Conn, header[:]); err != nil {
+		return err
+	}
+	length := binary.LittleEndian.Uint32(header[:])
+	if length > maxEncryptedFramePayload+uint32(c.staticReadAEAD.Overhead()) {
+		return fmt.Errorf("encrypted frame too large: %v", length)
+	}
+	ciphertext := make([]byte, length)
+	if _, err := io.ReadFull(c.Conn, ciphertext); err != nil {
+		return err
+	}
+	plaintext, err := c.staticReadAEAD.Open(ciphertext[:0], frameNonce(c.readNonce), ciphertext, header[:])
+	if err != nil {
+		return errors.AddContext(err, "failed to decrypt frame")
+	}
+	c.readNonce++
+	c.readBuf.Write(plaintext)
+	return nil
+}
+
+// Write encrypts data and writes it to the conn.
+func (c *encryptedConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	var n int
+	for len(b) > 0 {
+		payload := b
+		if len(payload) > maxEncryptedFramePayload {
+			payload = payload[:maxEncryptedFramePayload]
+		}
+		frame := make([]byte, encryptedFrameHeaderSize, encryptedFrameHeaderSize+len(payload)+c.staticWriteAEAD.Overhead())
+		binary.LittleEndian.PutUint32(frame, uint32(len(payload)+c.staticWriteAEAD.Overhead()))
+		frame = c.staticWriteAEAD.Seal(frame, frameNonce(c.writeNonce), payload, frame[:encryptedFrameHeaderSize])
+		if _, err := c.Conn.Write(frame); err != nil {
+			return n, err
+		}
+		c.writeNonce++
+		n += len(payload)
+		b = b[len(payload):]
+	}
+	return n, nil
+}
+
+// checkEncryption returns an error if the session with the node at addr must
+// be encrypted but isn't or if the static key of the node doesn't match the
+// key pinned by a previous encrypted session. A pin which expired can be
+// replaced. remoteKey is nil for plaintext sessions.
+func (g *Gateway) checkEncryption(addr modules.NetAddress, remoteKey *crypto.X25519PublicKey) error {
+	n, exists := g.nodes[addr]
+	pinned := exists && n.EncryptionKey != nil && time.Since(n.EncryptionKeySeen) < encryptionPinExpiry
+	switch {
+	case remoteKey == nil && g.requireEncryption:
+		return errEncryptionRequired
+	case remoteKey == nil && pinned:
+		return errEncryptionDowngrade
+	case remoteKey != nil && pinned && *n.EncryptionKey != *remoteKey:
+		return errEncryptionKeyMismatch
+	}
+	return nil
+}
+
+// pinEncryptionKey pins the static key of the node at addr. Future sessions
+// with the node must be encrypted with the same key until the pin expires.
+func (g *Gateway) pinEncryptionKey(addr modules.NetAddress, remoteKey *crypto.X25519PublicKey) {
+	if n, exists := g.nodes[addr]; exists && remoteKey != nil {
+		key := *remoteKey
+		n.EncryptionKey = &key
+		n.EncryptionKeySeen = time.Now()
+	}
+}
+
+// refreshEncryptionPins marks the pinned keys of the peers with encrypted
+// sessions as seen, so that the pins of connected peers don't expire.
+func (g *Gateway) refreshEncryptionPins() {
+	now := time.Now()
+	for addr, p := range g.peers {
+		if n, exists := g.nodes[addr]; exists && p.Encrypted && n.EncryptionKey != nil {
+			n.EncryptionKeySeen = now
+		}
+	}
+}
+
+// RequireEncryption returns true if the gateway refuses plaintext sessions
+// with peers which don't support encryption.
+func (g *Gateway) RequireEncryption() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.requireEncryption
+}
+
+// SetRequireEncryption sets whether the gateway refuses plaintext sessions
+// and persists the setting. Enabling it disconnects the peers with plaintext
+// sessions.
+func (g *Gateway) SetRequireEncryption(require bool) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requireEncryption = require
+	if require {
+		for addr, p := range g.peers {
+			if p.Encrypted {
+				continue
+			}
+			p.sess.Close()
+			delete(g.peers, addr)
+			g.log.Println("INFO: disconnected from peer without encryption", addr)
+		}
+	}
+	return g.saveSync()
+}
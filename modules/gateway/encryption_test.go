@@ -0,0 +1,349 @@
+package gateway
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/identity"
+)
+
+// encryptionHandshakePipe performs the encryption handshake over a pipe with
+// the provided versions and returns both ends of the session.
+func encryptionHandshakePipe(connectVersions, acceptVersions [2]string) (connectConn, acceptConn net.Conn, connectKey, acceptKey *crypto.X25519PublicKey, err error) {
+	c1, c2 := net.Pipe()
+	sk1, _ := crypto.GenerateX25519KeyPair()
+	sk2, _ := crypto.GenerateX25519KeyPair()
+	var acceptErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		acceptConn, connectKey, acceptErr = acceptEncryptionHandshake(c2, acceptVersions[0], acceptVersions[1], sk2)
+		if acceptErr != nil {
+			c2.Close()
+		}
+	}()
+	connectConn, acceptKey, err = connectEncryptionHandshake(c1, connectVersions[0], connectVersions[1], sk1)
+	if err != nil {
+		c1.Close()
+	}
+	<-done
+	if err := errors.Compose(err, acceptErr); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if *connectKey != sk1.PublicKey() || *acceptKey != sk2.PublicKey() {
+		return nil, nil, nil, nil, errors.New("wrong static keys")
+	}
+	return connectConn, acceptConn, connectKey, acceptKey, nil
+}
+
+// TestEncryptionHandshake checks that peers establish an encrypted session
+// if both support it.
+func TestEncryptionHandshake(t *testing.T) {
+	// Old peers use plaintext sessions.
+	c1, c2 := net.Pipe()
+	conn, remoteKey, err := connectEncryptionHandshake(c1, ProtocolVersion, addressesUpgradeVersion, crypto.X25519SecretKey{})
+	if err != nil || conn != c1 || remoteKey != nil {
+		t.Fatal("old peer shouldn't be encrypted", err)
+	}
+	conn, remoteKey, err = acceptEncryptionHandshake(c2, ProtocolVersion, addressesUpgradeVersion, crypto.X25519SecretKey{})
+	if err != nil || conn != c2 || remoteKey != nil {
+		t.Fatal("old peer shouldn't be encrypted", err)
+	}
+	if err := errors.Compose(c1.Close(), c2.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// New peers establish an encrypted session.
+	versions := [2]string{ProtocolVersion, ProtocolVersion}
+	connectConn, acceptConn, _, _, err := encryptionHandshakePipe(versions, versions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := errors.Compose(connectConn.Close(), acceptConn.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Send data which spans multiple frames in both directions.
+	for _, conns := range [][2]net.Conn{{connectConn, acceptConn}, {acceptConn, connectConn}} {
+		data := fastrand.Bytes(3*maxEncryptedFramePayload + 10)
+		errChan := make(chan error, 1)
+		go func() {
+			_, err := conns[0].Write(data)
+			errChan <- err
+		}()
+		received := make([]byte, len(data))
+		if _, err := io.ReadFull(conns[1], received); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errChan; err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(received, data) {
+			t.Fatal("received data doesn't match sent data")
+		}
+	}
+}
+
+// TestEncryptionHandshakeTamperedVersion checks that the encryption
+// handshake fails if the version handshake was tampered with.
+func TestEncryptionHandshakeTamperedVersion(t *testing.T) {
+	_, _, _, _, err := encryptionHandshakePipe([2]string{ProtocolVersion, ProtocolVersion}, [2]string{"9.9.9", ProtocolVersion})
+	if err == nil {
+		t.Fatal("expected handshake with tampered version to fail")
+	}
+}
+
+// TestEncryptedConnTampered checks that a modified frame fails to decrypt.
+func TestEncryptedConnTampered(t *testing.T) {
+	c1, c2 := net.Pipe()
+	c3, c4 := net.Pipe()
+	defer func() {
+		if err := errors.Compose(c1.Close(), c2.Close(), c3.Close(), c4.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	key := crypto.HashBytes(fastrand.Bytes(32))
+	writer, err := newEncryptedConn(c1, key, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := newEncryptedConn(c4, key, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Capture a frame, flip a bit of its ciphertext and forward it to the
+	// reader.
+	msg := []byte("hello")
+	go writer.Write(msg)
+	frame := make([]byte, encryptedFrameHeaderSize+len(msg)+writer.staticWriteAEAD.Overhead())
+	if _, err := io.ReadFull(c2, frame); err != nil {
+		t.Fatal(err)
+	}
+	frame[encryptedFrameHeaderSize] ^= 1
+	go c3.Write(frame)
+	if _, err := reader.Read(make([]byte, len(msg))); err == nil {
+		t.Fatal("expected tampered frame to fail")
+	}
+}
+
+// TestEncryptedPeers checks that gateways establish encrypted sessions, pin
+// the keys of their peers and enforce the encryption requirements.
+func TestEncryptedPeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newNamedTestingGateway(t, "1")
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := errors.Compose(g1.Close(), g2.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []*Gateway{g1, g2} {
+		peers := g.Peers()
+		if len(peers) != 1 || !peers[0].Encrypted {
+			t.Fatal("session should be encrypted", peers)
+		}
+	}
+	g1.mu.RLock()
+	n, exists := g1.nodes[g2.Address()]
+	g1.mu.RUnlock()
+	if !exists || n.EncryptionKey == nil || *n.EncryptionKey != g2.staticSecretKey.PublicKey() {
+		t.Fatal("g1 should pin the key of g2", n)
+	}
+
+	// A node which changed its key is only accepted manually.
+	g1.mu.Lock()
+	fastrand.Read(n.EncryptionKey[:])
+	p := g1.peers[g2.Address()]
+	delete(g1.peers, g2.Address())
+	g1.mu.Unlock()
+	p.sess.Close()
+	if err := g1.Connect(g2.Address()); !errors.Contains(err, errEncryptionKeyMismatch) {
+		t.Fatal("expected errEncryptionKeyMismatch, got", err)
+	}
+	if err := g1.ConnectManual(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A node with a pinned key can't downgrade to a plaintext session.
+	g1.mu.RLock()
+	err := g1.checkEncryption(g2.Address(), nil)
+	g1.mu.RUnlock()
+	if !errors.Contains(err, errEncryptionDowngrade) {
+		t.Fatal("expected errEncryptionDowngrade, got", err)
+	}
+
+	// Requiring encryption rejects plaintext sessions with unknown nodes and
+	// is persisted.
+	if err := g1.SetRequireEncryption(true); err != nil {
+		t.Fatal(err)
+	}
+	g1.mu.RLock()
+	err = g1.checkEncryption("1.2.3.4:9981", nil)
+	g1.mu.RUnlock()
+	if !errors.Contains(err, errEncryptionRequired) {
+		t.Fatal("expected errEncryptionRequired, got", err)
+	}
+	if len(g1.Peers()) != 1 {
+		t.Fatal("encrypted peer shouldn't be disconnected")
+	}
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err = New("localhost:0", false, g1.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g1.RequireEncryption() {
+		t.Fatal("encryption settings weren't persisted")
+	}
+
+	// With an identity, the session key is derived from the identity's
+	// gateway key and stays the same across restarts.
+	id, err := identity.New(filepath.Join(g1.persistDir, modules.IdentityDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := id.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	idSK, _, err := id.KeyPair(modules.KeyPurposeGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+		g1, err = NewCustomGatewayWithDNSSeeds("localhost:0", false, nil, id, g1.persistDir, modules.ProdDependencies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g1.staticSecretKey != deriveSessionKey(idSK) {
+			t.Fatal("session key wasn't derived from the identity")
+		}
+	}
+}
+
+// TestEncryptionPinRestartAndRotation checks that the pinned key of a node
+// stays valid across restarts and that a rotated key replaces the pin once it
+// expired.
+func TestEncryptionPinRestartAndRotation(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newNamedTestingGateway(t, "1")
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := errors.Compose(g1.Close(), g2.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	addr := g2.Address()
+
+	// restartG2 disconnects g1 from g2 without forgetting the node and
+	// restarts g2 at the same address.
+	restartG2 := func(id modules.Identity) {
+		t.Helper()
+		g1.mu.Lock()
+		p := g1.peers[addr]
+		delete(g1.peers, addr)
+		g1.mu.Unlock()
+		p.sess.Close()
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		g2, err = NewCustomGatewayWithDNSSeeds(string(addr), false, nil, id, g2.persistDir, modules.ProdDependencies)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// pinnedKey returns the key g1 pinned for g2.
+	pinnedKey := func() crypto.X25519PublicKey {
+		t.Helper()
+		g1.mu.RLock()
+		defer g1.mu.RUnlock()
+		n, exists := g1.nodes[addr]
+		if !exists || n.EncryptionKey == nil {
+			t.Fatal("g1 didn't pin the key of g2")
+		}
+		return *n.EncryptionKey
+	}
+
+	// A gateway without an identity keeps its key across restarts.
+	if err := g1.Connect(addr); err != nil {
+		t.Fatal(err)
+	}
+	key := g2.staticSecretKey.PublicKey()
+	restartG2(nil)
+	if g2.staticSecretKey.PublicKey() != key {
+		t.Fatal("key changed after a restart")
+	}
+	if err := g1.Connect(addr); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pins of connected peers are kept fresh.
+	g1.mu.Lock()
+	g1.nodes[addr].EncryptionKeySeen = time.Now().Add(-encryptionPinExpiry)
+	g1.refreshEncryptionPins()
+	seen := g1.nodes[addr].EncryptionKeySeen
+	g1.mu.Unlock()
+	if time.Since(seen) > time.Minute {
+		t.Fatal("pin of a connected peer wasn't refreshed")
+	}
+
+	// Switch g2 to an identity and rotate its gateway key. g1 rejects the
+	// new key until the pin expired.
+	id, err := identity.New(filepath.Join(g2.persistDir, modules.IdentityDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := id.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if _, err := id.RotateKey(modules.KeyPurposeGateway); err != nil {
+		t.Fatal(err)
+	}
+	restartG2(id)
+	if err := g1.Connect(addr); !errors.Contains(err, errEncryptionKeyMismatch) {
+		t.Fatal("expected errEncryptionKeyMismatch, got", err)
+	}
+	if pinnedKey() != key {
+		t.Fatal("pin was replaced")
+	}
+	g1.mu.Lock()
+	g1.nodes[addr].EncryptionKeySeen = time.Now().Add(-encryptionPinExpiry)
+	g1.mu.Unlock()
+	if err := g1.Connect(addr); err != nil {
+		t.Fatal(err)
+	}
+	if pinnedKey() != g2.staticSecretKey.PublicKey() {
+		t.Fatal("expired pin wasn't replaced")
+	}
+}
@@ -102,6 +102,8 @@ import (
 	"gitlab.com/NebulousLabs/ratelimit"
 	"gitlab.com/NebulousLabs/threadgroup"
 
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
 
@@ -111,7 +113,7 @@ import (
 )
 
 // ProtocolVersion is the current version of the gateway p2p protocol.
-const ProtocolVersion = "1.5.4"
+const ProtocolVersion = "1.5.7"
 
 var errNoPeers = errors.New("no peers")
 
@@ -123,6 +125,18 @@ type Gateway struct {
 	port     string
 	rl       *ratelimit.RateLimit
 
+	// altAddr is the address of the gateway of the other address family
+	// than myAddr, e.g. the IPv6 address of a gateway which learned an IPv4
+	// address. It is advertised to peers in addition to myAddr.
+	//
+	// preferredFamily is the address family the gateway prefers when
+	// dialing peers.
+	altAddr         modules.NetAddress
+	preferredFamily modules.AddressFamily
+
+	// services are the services the gateway advertises to its peers.
+	services modules.GatewayServices
+
 	// handlers are the RPCs that the Gateway can handle.
 	//
 	// initRPCs are the RPCs that the Gateway calls upon connecting to a peer.
@@ -151,6 +165,30 @@ type Gateway struct {
 	peers     map[modules.NetAddress]*peer
 	peerTG    threadgroup.ThreadGroup
 
+	// allowlist are the IPs of the only peers the gateway dials and accepts
+	// while allowlistOnly is set.
+	allowlist     map[string]struct{}
+	allowlistOnly bool
+
+	// staticDialScheduler enforces the global dial rate of the gateway and
+	// the backoffs of the addresses which failed to connect.
+	staticDialScheduler *dialScheduler
+
+	// staticPeerScorer tracks the misbehavior of peers and bans the peers
+	// which misbehaved too often.
+	staticPeerScorer *peerScorer
+
+	// peerDisconnects are the most recent peers the gateway disconnected
+	// from because of a failed RPC.
+	peerDisconnects []modules.PeerDisconnect
+
+	// noOutboundPeersSince and fewPeersSince are the times since when the
+	// gateway has had no outbound peers and fewer than
+	// minPeersAlertThreshold peers. They are zero if the gateway has enough
+	// peers.
+	noOutboundPeersSince time.Time
+	fewPeersSince        time.Time
+
 	// Utilities.
 	log           *persist.Logger
 	mu            sync.RWMutex
@@ -160,6 +198,24 @@ type Gateway struct {
 	staticAlerter *modules.GenericAlerter
 	staticDeps    modules.Dependencies
 
+	// staticDNSSeeds are the hostnames that are queried for nodes when the
+	// gateway bootstraps.
+	staticDNSSeeds []string
+
+	// staticPrivateNetwork indicates that the gateway is part of a private
+	// network. It doesn't contact any public services or routers to learn its
+	// address or to forward its port.
+	staticPrivateNetwork bool
+
+	// requireEncryption indicates that the gateway refuses plaintext sessions
+	// with peers which don't support encryption.
+	requireEncryption bool
+
+	// staticSecretKey is the key which authenticates the gateway when
+	// establishing encrypted sessions with peers. It is derived from the
+	// gateway key of the node's identity.
+	staticSecretKey crypto.X25519SecretKey
+
 	// Unique ID
 	staticID gatewayID
 }
@@ -357,13 +413,37 @@ func (g *Gateway) SetRateLimits(downloadSpeed, uploadSpeed int64) error {
 	return g.saveSync()
 }
 
-// New returns an initialized Gateway.
+// New returns an initialized Gateway. A gateway without an identity
+// authenticates its encrypted sessions with a key which changes every time
+// it is created.
 func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 	return NewCustomGateway(addr, bootstrap, persistDir, modules.ProdDependencies)
 }
 
 // NewCustomGateway returns an initialized Gateway with custom dependencies.
 func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modules.Dependencies) (*Gateway, error) {
+	return NewCustomGatewayWithDNSSeeds(addr, bootstrap, modules.DNSSeeds, nil, persistDir, deps)
+}
+
+// NewCustomGatewayWithDNSSeeds returns an initialized Gateway with custom
+// dependencies which queries the provided DNS seeds for nodes when
+// bootstrapping. Passing no seeds disables the DNS seed lookup. The encrypted
+// sessions of the gateway are authenticated with the gateway key of the
+// identity.
+func NewCustomGatewayWithDNSSeeds(addr string, bootstrap bool, dnsSeeds []string, id modules.Identity, persistDir string, deps modules.Dependencies) (*Gateway, error) {
+	return newGateway(addr, bootstrap, dnsSeeds, false, id, persistDir, deps)
+}
+
+// NewPrivateGateway returns an initialized Gateway for a private network. The
+// gateway doesn't bootstrap from the public network or query any DNS seeds.
+// It also neither forwards its port using UPnP nor learns its address from
+// public services. Only peers are asked for the gateway's address.
+func NewPrivateGateway(addr string, id modules.Identity, persistDir string, deps modules.Dependencies) (*Gateway, error) {
+	return newGateway(addr, false, nil, true, id, persistDir, deps)
+}
+
+// newGateway returns an initialized Gateway.
+func newGateway(addr string, bootstrap bool, dnsSeeds []string, private bool, id modules.Identity, persistDir string, deps modules.Dependencies) (*Gateway, error) {
 	// Create the directory if it doesn't exist.
 	err := os.MkdirAll(persistDir, 0700)
 	if err != nil {
@@ -374,13 +454,18 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 		handlers: make(map[rpcID]modules.RPCFunc),
 		initRPCs: make(map[string]modules.RPCFunc),
 
+		allowlist: make(map[string]struct{}),
 		blocklist: make(map[string]struct{}),
 		nodes:     make(map[modules.NetAddress]*node),
 		peers:     make(map[modules.NetAddress]*peer),
 
-		persistDir:    persistDir,
-		staticAlerter: modules.NewAlerter("gateway"),
-		staticDeps:    deps,
+		persistDir:           persistDir,
+		staticAlerter:        modules.NewAlerter("gateway"),
+		staticDialScheduler:  newDialScheduler(),
+		staticPeerScorer:     newPeerScorer(),
+		staticDeps:           deps,
+		staticDNSSeeds:       dnsSeeds,
+		staticPrivateNetwork: private,
 	}
 
 	// Set Unique GatewayID
@@ -417,11 +502,13 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 	// Register RPCs.
 	g.RegisterRPC("ShareNodes", g.shareNodes)
 	g.RegisterRPC("DiscoverIP", g.discoverPeerIP)
+	g.RegisterRPC("CheckReachability", g.checkReachability)
 	g.RegisterConnectCall("ShareNodes", g.requestNodes)
 	// Establish the de-registration of the RPCs.
 	g.threads.OnStop(func() error {
 		g.UnregisterRPC("ShareNodes")
 		g.UnregisterRPC("DiscoverIP")
+		g.UnregisterRPC("CheckReachability")
 		g.UnregisterConnectCall("ShareNodes")
 		return nil
 	})
@@ -432,6 +519,25 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 	if loadErr := g.load(); loadErr != nil && !os.IsNotExist(loadErr) {
 		return nil, errors.AddContext(loadErr, "unable to load gateway")
 	}
+	// Derive the key which authenticates the encrypted sessions of the
+	// gateway from the identity's gateway key. Without an identity, a key is
+	// generated once and persisted so that peers keep recognizing the
+	// gateway after a restart.
+	if id != nil {
+		sk, _, err := id.KeyPair(modules.KeyPurposeGateway)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to get the gateway key of the identity")
+		}
+		g.staticSecretKey = deriveSessionKey(sk)
+	} else {
+		if g.persist.SessionKey == (crypto.X25519SecretKey{}) {
+			g.persist.SessionKey, _ = crypto.GenerateX25519KeyPair()
+			if err := g.saveSync(); err != nil {
+				return nil, errors.AddContext(err, "unable to save the session key of the gateway")
+			}
+		}
+		g.staticSecretKey = g.persist.SessionKey
+	}
 	// Create the ratelimiter and set it to the persisted limits.
 	g.rl = ratelimit.NewRateLimit(0, 0, 0)
 	if err := setRateLimits(g.rl, g.persist.MaxDownloadSpeed, g.persist.MaxUploadSpeed); err != nil {
@@ -449,6 +555,7 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 			g.log.Println("ERROR: Unable to save gateway:", err)
 			return err
 		}
+		g.refreshEncryptionPins()
 		if err := g.saveSyncNodes(); err != nil {
 			g.log.Println("ERROR: Unable to save gateway nodes:", err)
 			return err
@@ -527,12 +634,20 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 	go g.permanentNodePurger(nodePurgerClosedChan)
 
 	// Spawn threads to take care of port forwarding and hostname discovery.
-	go g.threadedForwardPort(g.port)
+	// Private networks don't forward their port to the public internet.
+	if !g.staticPrivateNetwork {
+		go g.threadedForwardPort(g.port)
+	}
 	go g.threadedLearnHostname()
 
 	// Spawn thread to periodically check if the gateway is online.
 	go g.threadedOnlineCheck()
 
+	// Query the DNS seeds for additional bootstrap nodes.
+	if bootstrap {
+		go g.threadedQueryDNSSeeds()
+	}
+
 	return g, nil
 }
 
@@ -550,6 +665,11 @@ func (g *Gateway) threadedOnlineCheck() {
 		case <-time.After(onlineCheckFrequency):
 		}
 		_ = g.Online()
+		// Test gateways are rarely well connected, so the connectivity
+		// alerts are only registered outside of testing.
+		if build.Release != "testing" {
+			g.managedCheckConnectivity(time.Now())
+		}
 	}
 }
 
@@ -364,3 +364,45 @@ func TestManualConnectDisconnectPersist(t *testing.T) {
 		t.Fatal("shouldn't be able to connect")
 	}
 }
+
+// TestNewPrivateGateway checks that a private gateway doesn't bootstrap and
+// only asks its peers for its address.
+func TestNewPrivateGateway(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g, err := NewPrivateGateway("localhost:0", nil, build.TempDir("gateway", t.Name()), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if !g.staticPrivateNetwork {
+		t.Fatal("gateway should be private")
+	}
+	g.mu.RLock()
+	numNodes := len(g.nodes)
+	g.mu.RUnlock()
+	if numNodes != 0 {
+		t.Fatal("private gateway shouldn't add bootstrap nodes", numNodes)
+	}
+
+	// Without peers the gateway can't learn its address. It shouldn't try
+	// UPnP.
+	cancel := make(chan struct{})
+	close(cancel)
+	if _, err := g.managedLearnHostname(cancel); err == nil {
+		t.Fatal("private gateway shouldn't learn its address without peers")
+	}
+	g.mu.RLock()
+	routerURL := g.persist.RouterURL
+	g.mu.RUnlock()
+	if routerURL != "" {
+		t.Fatal("private gateway shouldn't look for a router", routerURL)
+	}
+}
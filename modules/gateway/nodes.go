@@ -9,6 +9,7 @@ import (
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -24,6 +25,20 @@ var (
 type node struct {
 	NetAddress      modules.NetAddress `json:"netaddress"`
 	WasOutboundPeer bool               `json:"wasoutboundpeer"`
+
+	// Services are the services the node advertised the last time the
+	// gateway was connected to it.
+	Services modules.GatewayServices `json:"services"`
+
+	// EncryptionKey is the static key the node used for its first encrypted
+	// session. It is nil if the gateway never established an encrypted
+	// session with the node.
+	EncryptionKey *crypto.X25519PublicKey `json:"encryptionkey,omitempty"`
+
+	// EncryptionKeySeen is the last time the gateway had an encrypted
+	// session with the node using the pinned key. The pin expires
+	// encryptionPinExpiry after that.
+	EncryptionKeySeen time.Time `json:"encryptionkeyseen"`
 }
 
 // addNode adds an address to the set of nodes on the network.
@@ -68,6 +83,12 @@ func (g *Gateway) staticPingNode(addr modules.NetAddress) (err error) {
 		return err
 	}
 
+	// Establish an encrypted session if the node supports it.
+	sessionConn, _, err := connectEncryptionHandshake(conn, ProtocolVersion, remoteVersion, g.staticSecretKey)
+	if err != nil {
+		return err
+	}
+
 	// Send our header.
 	// NOTE: since we don't intend to complete the connection, we can send an
 	// inaccurate NetAddress.
@@ -76,20 +97,20 @@ func (g *Gateway) staticPingNode(addr modules.NetAddress) (err error) {
 		UniqueID:   g.staticID,
 		NetAddress: modules.NetAddress(conn.LocalAddr().String()),
 	}
-	if err := exchangeOurHeader(conn, ourHeader); err != nil {
+	if err := exchangeOurHeader(sessionConn, ourHeader); err != nil {
 		return err
 	}
 
 	// Read remote header.
 	var remoteHeader sessionHeader
-	if err := encoding.ReadObject(conn, &remoteHeader, maxEncodedSessionHeaderSize); err != nil {
+	if err := encoding.ReadObject(sessionConn, &remoteHeader, maxEncodedSessionHeaderSize); err != nil {
 		return fmt.Errorf("failed to read remote header: %v", err)
 	} else if err := acceptableSessionHeader(ourHeader, remoteHeader, conn.RemoteAddr().String()); err != nil {
 		return err
 	}
 
 	// Send special rejection string.
-	if err := encoding.WriteObject(conn, modules.StopResponse); err != nil {
+	if err := encoding.WriteObject(sessionConn, modules.StopResponse); err != nil {
 		return fmt.Errorf("failed to write header rejection: %v", err)
 	}
 	return nil
@@ -101,6 +122,7 @@ func (g *Gateway) removeNode(addr modules.NetAddress) error {
 		return errors.New("no record of that node")
 	}
 	delete(g.nodes, addr)
+	g.staticDialScheduler.callForget(addr)
 	return nil
 }
 
@@ -258,8 +280,9 @@ func (g *Gateway) permanentNodePurger(closeChan chan struct{}) {
 		// them.
 		g.mu.RLock()
 		_, exists := g.peers[node]
+		allowed := g.allowed(node)
 		g.mu.RUnlock()
-		if exists {
+		if exists || !allowed {
 			continue
 		}
 
@@ -0,0 +1,134 @@
+package gateway
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	connmonitor "gitlab.com/NebulousLabs/monitor"
+
+	"go.sia.tech/siad/modules"
+)
+
+// peerMetrics tracks the quality of the connection to a peer.
+type peerMetrics struct {
+	// staticConnectedAt is the time the connection was established and
+	// staticLatency is the round-trip time of the handshake.
+	staticConnectedAt time.Time
+	staticLatency     time.Duration
+
+	// staticMonitor counts the bytes sent to and received from the peer.
+	staticMonitor *connmonitor.Monitor
+
+	// rpcs is the number of RPCs called on and by the peer and rpcErrors is
+	// the number of those RPCs that failed.
+	rpcs      uint64
+	rpcErrors uint64
+	mu        sync.Mutex
+}
+
+// newPeerMetrics creates the metrics of a connection which was established
+// with the provided handshake latency.
+func newPeerMetrics(latency time.Duration) *peerMetrics {
+	return &peerMetrics{
+		staticConnectedAt: time.Now(),
+		staticLatency:     latency,
+		staticMonitor:     connmonitor.NewMonitor(),
+	}
+}
+
+// staticMonitorConn wraps the conn to count the bytes sent and received over
+// it.
+func (pm *peerMetrics) staticMonitorConn(conn net.Conn) net.Conn {
+	return connmonitor.NewMonitoredConn(conn, pm.staticMonitor)
+}
+
+// callRecordRPC records the outcome of an RPC with the peer.
+func (pm *peerMetrics) callRecordRPC(err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.rpcs++
+	if err != nil {
+		pm.rpcErrors++
+	}
+}
+
+// callStats returns the metrics of the connection to the peer.
+func (pm *peerMetrics) callStats(p modules.Peer, now time.Time) modules.PeerStats {
+	pm.mu.Lock()
+	rpcs, rpcErrors := pm.rpcs, pm.rpcErrors
+	pm.mu.Unlock()
+
+	var errorRate float64
+	if rpcs > 0 {
+		errorRate = float64(rpcErrors) / float64(rpcs)
+	}
+	download, upload := pm.staticMonitor.Counts()
+	return modules.PeerStats{
+		Peer:           p,
+		ConnectedSince: pm.staticConnectedAt,
+		ConnectionAge:  now.Sub(pm.staticConnectedAt),
+		Latency:        pm.staticLatency,
+		RPCs:           rpcs,
+		RPCErrors:      rpcErrors,
+		RPCErrorRate:   errorRate,
+		Download:       download,
+		Upload:         upload,
+	}
+}
+
+// managedRecordPeerRPC records the outcome of an RPC with the peer at addr. If
+// the gateway isn't connected to the peer anymore, nothing is recorded.
+func (g *Gateway) managedRecordPeerRPC(addr modules.NetAddress, err error) {
+	g.mu.RLock()
+	p, exists := g.peers[addr]
+	g.mu.RUnlock()
+	if exists {
+		p.staticMetrics.callRecordRPC(err)
+	}
+}
+
+// managedCheckConnectivity registers alerts if the gateway had no outbound
+// peers or too few peers for longer than connectivityAlertDelay and
+// unregisters them once the gateway recovered.
+func (g *Gateway) managedCheckConnectivity(now time.Time) {
+	g.mu.Lock()
+	numPeers, numOutbound := len(g.peers), g.numOutboundPeers()
+	if numOutbound > 0 {
+		g.noOutboundPeersSince = time.Time{}
+	} else if g.noOutboundPeersSince.IsZero() {
+		g.noOutboundPeersSince = now
+	}
+	if numPeers >= minPeersAlertThreshold {
+		g.fewPeersSince = time.Time{}
+	} else if g.fewPeersSince.IsZero() {
+		g.fewPeersSince = now
+	}
+	noOutboundPeers := !g.noOutboundPeersSince.IsZero() && now.Sub(g.noOutboundPeersSince) >= connectivityAlertDelay
+	fewPeers := !g.fewPeersSince.IsZero() && now.Sub(g.fewPeersSince) >= connectivityAlertDelay
+	g.mu.Unlock()
+
+	if noOutboundPeers {
+		g.staticAlerter.RegisterAlert(modules.AlertIDGatewayNoOutboundPeers, AlertMSGGatewayNoOutboundPeers, "", modules.SeverityWarning)
+	} else {
+		g.staticAlerter.UnregisterAlert(modules.AlertIDGatewayNoOutboundPeers)
+	}
+	if fewPeers {
+		g.staticAlerter.RegisterAlert(modules.AlertIDGatewayFewPeers, AlertMSGGatewayFewPeers, "", modules.SeverityWarning)
+	} else {
+		g.staticAlerter.UnregisterAlert(modules.AlertIDGatewayFewPeers)
+	}
+}
+
+// PeerStats returns the connection metrics of the peers the gateway is
+// connected to.
+func (g *Gateway) PeerStats() []modules.PeerStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	now := time.Now()
+	stats := make([]modules.PeerStats, 0, len(g.peers))
+	for _, p := range g.peers {
+		stats = append(stats, p.staticMetrics.callStats(p.Peer, now))
+	}
+	return stats
+}
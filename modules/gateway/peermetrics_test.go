@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestPeerStats checks that the gateway tracks the metrics of the
+// connections to its peers.
+func TestPeerStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newNamedTestingGateway(t, "1")
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := errors.Compose(g1.Close(), g2.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2.RegisterRPC("Succeed", func(conn modules.PeerConn) error {
+		return encoding.WriteObject(conn, "pong")
+	})
+	g2.RegisterRPC("Fail", func(conn modules.PeerConn) error { return nil })
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Call an RPC which succeeds and one which fails.
+	err := g1.RPC(g2.Address(), "Succeed", func(conn modules.PeerConn) error {
+		var pong string
+		return encoding.ReadObject(conn, &pong, 100)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFail := errors.New("failed")
+	err = g1.RPC(g2.Address(), "Fail", func(conn modules.PeerConn) error { return errFail })
+	if !errors.Contains(err, errFail) {
+		t.Fatal("expected errFail, got", err)
+	}
+
+	stats := g1.PeerStats()
+	if len(stats) != 1 {
+		t.Fatal("expected stats of 1 peer, got", len(stats))
+	}
+	s := stats[0]
+	if s.NetAddress != g2.Address() || s.Inbound {
+		t.Fatal("wrong peer", s.Peer)
+	}
+	if s.Latency <= 0 || s.ConnectionAge <= 0 || s.ConnectedSince.After(time.Now()) {
+		t.Fatal("latency and age should be set", s.Latency, s.ConnectionAge, s.ConnectedSince)
+	}
+	// The gateways call their init RPCs in the background, so there might be
+	// more RPCs than the ones called above.
+	if s.RPCs < 2 || s.RPCErrors < 1 || s.RPCErrorRate <= 0 {
+		t.Fatal("RPCs weren't recorded", s.RPCs, s.RPCErrors, s.RPCErrorRate)
+	}
+	if s.Upload == 0 || s.Download == 0 {
+		t.Fatal("bandwidth wasn't recorded", s.Upload, s.Download)
+	}
+}
+
+// TestConnectivityAlerts checks that the gateway registers alerts if it has
+// too few peers for too long.
+func TestConnectivityAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g := newNamedTestingGateway(t, "gateway")
+	peers := []*Gateway{newNamedTestingGateway(t, "1"), newNamedTestingGateway(t, "2")}
+	defer func() {
+		if err := errors.Compose(g.Close(), peers[0].Close(), peers[1].Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	hasAlert := func(msg string) bool {
+		_, _, warn := g.Alerts()
+		for _, alert := range warn {
+			if alert.Module == "gateway" && alert.Msg == msg {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The alerts are only registered once the gateway had too few peers for
+	// connectivityAlertDelay.
+	now := time.Now()
+	g.managedCheckConnectivity(now)
+	if hasAlert(AlertMSGGatewayNoOutboundPeers) || hasAlert(AlertMSGGatewayFewPeers) {
+		t.Fatal("alerts shouldn't be registered yet")
+	}
+	now = now.Add(connectivityAlertDelay)
+	g.managedCheckConnectivity(now)
+	if !hasAlert(AlertMSGGatewayNoOutboundPeers) || !hasAlert(AlertMSGGatewayFewPeers) {
+		t.Fatal("alerts should be registered")
+	}
+
+	// An inbound peer doesn't help with the outbound peers.
+	if err := connectToNode(peers[0], g, false); err != nil {
+		t.Fatal(err)
+	}
+	g.managedCheckConnectivity(now)
+	if !hasAlert(AlertMSGGatewayNoOutboundPeers) || !hasAlert(AlertMSGGatewayFewPeers) {
+		t.Fatal("alerts should still be registered")
+	}
+
+	// With enough peers, both alerts are unregistered.
+	if err := connectToNode(g, peers[1], false); err != nil {
+		t.Fatal(err)
+	}
+	g.managedCheckConnectivity(now)
+	if hasAlert(AlertMSGGatewayNoOutboundPeers) || hasAlert(AlertMSGGatewayFewPeers) {
+		t.Fatal("alerts should be unregistered")
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -39,9 +40,13 @@ func (s invalidVersionError) Error() string {
 
 type peer struct {
 	modules.Peer
+	id   gatewayID
 	m    *connmonitor.Monitor
 	rl   *ratelimit.RateLimit
 	sess streamSession
+
+	// staticMetrics tracks the quality of the connection to the peer.
+	staticMetrics *peerMetrics
 }
 
 // sessionHeader is sent after the initial version exchange. It prevents peers
@@ -76,6 +81,9 @@ func (p *peer) accept() (modules.PeerConn, error) {
 // addPeer adds a peer to the Gateway's peer list, spawns a listener thread to
 // handle its requests and increments the remotePeers accordingly
 func (g *Gateway) addPeer(p *peer) {
+	if p.staticMetrics == nil {
+		p.staticMetrics = newPeerMetrics(0)
+	}
 	g.peers[p.NetAddress] = p
 	go g.threadedListenPeer(p)
 }
@@ -159,12 +167,23 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 
 	g.mu.RLock()
 	_, exists := g.blocklist[addr.Host()]
+	allowed := g.allowed(addr)
 	g.mu.RUnlock()
 	if exists {
 		g.log.Debugf("INFO: %v was rejected. (blocklisted)", addr)
 		conn.Close()
 		return
 	}
+	if !allowed {
+		g.log.Debugf("INFO: %v was rejected. (not allowlisted)", addr)
+		conn.Close()
+		return
+	}
+	if g.staticPeerScorer.callBanned(addr.Host(), time.Now()) {
+		g.log.Debugf("INFO: %v was rejected. (banned)", addr)
+		conn.Close()
+		return
+	}
 	remoteVersion, err := acceptVersionHandshake(conn, ProtocolVersion)
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect but version handshake failed: %v", addr, err)
@@ -172,8 +191,15 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 		return
 	}
 
+	// The session is established over sessionConn, which encrypts the data
+	// sent over conn if the peer supports it.
+	sessionConn := conn
+	var remoteKey *crypto.X25519PublicKey
 	if err = acceptableVersion(remoteVersion); err == nil {
-		err = g.managedAcceptConnPeer(conn, remoteVersion)
+		sessionConn, remoteKey, err = acceptEncryptionHandshake(conn, ProtocolVersion, remoteVersion, g.staticSecretKey)
+	}
+	if err == nil {
+		err = g.managedAcceptConnPeer(sessionConn, remoteVersion, remoteKey)
 	}
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect, but failed: %v", addr, err)
@@ -202,8 +228,9 @@ func acceptableSessionHeader(ourHeader, remoteHeader sessionHeader, remoteAddr s
 
 // managedAcceptConnPeer accepts connection requests from peers >= v1.3.1.
 // The requesting peer is added as a node and a peer. The peer is only added if
-// a nil error is returned.
-func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) error {
+// a nil error is returned. remoteKey is the static key of the peer if the
+// session is encrypted.
+func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string, remoteKey *crypto.X25519PublicKey) error {
 	g.log.Debugln("Attempting to Accept Connection from Peer; Sending sessionHeader with address", g.myAddr, g.myAddr.IsLocal())
 	// Perform header handshake.
 	g.mu.RLock()
@@ -212,6 +239,8 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 		UniqueID:   g.staticID,
 		NetAddress: g.myAddr,
 	}
+	ourServices := g.services
+	ourAddrs := g.alternateAddresses()
 	rl := g.rl
 	g.mu.RUnlock()
 
@@ -220,10 +249,24 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 		g.log.Debugln("Unable to Accept Connection with Peer. Conn, err:", conn.RemoteAddr(), conn.LocalAddr(), err)
 		return err
 	}
+	// Sending our header and reading the acceptance takes a single round
+	// trip which is used as the latency of the connection.
+	start := time.Now()
 	if err := exchangeOurHeader(conn, ourHeader); err != nil {
 		g.log.Debugln("Unable to Accept Connection with Peer. Conn, err:", conn.RemoteAddr(), conn.LocalAddr(), err)
 		return err
 	}
+	metrics := newPeerMetrics(time.Since(start))
+	remoteServices, err := acceptServicesHandshake(conn, remoteVersion, ourServices)
+	if err != nil {
+		g.log.Debugln("Unable to Accept Connection with Peer. Conn, err:", conn.RemoteAddr(), conn.LocalAddr(), err)
+		return err
+	}
+	remoteAddrs, err := acceptAddressesHandshake(conn, remoteVersion, ourAddrs)
+	if err != nil {
+		g.log.Debugln("Unable to Accept Connection with Peer. Conn, err:", conn.RemoteAddr(), conn.LocalAddr(), err)
+		return err
+	}
 
 	// Get the remote address on which the connecting peer is listening on.
 	// This means we need to combine the incoming connections ip address with
@@ -244,12 +287,29 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 			// by the host but keeping note of the port number so we can call back
 			NetAddress: remoteAddr,
 			Version:    remoteVersion,
+			Services:   remoteServices,
+			Encrypted:  remoteKey != nil,
 		},
+		id:   remoteHeader.UniqueID,
 		m:    g.m,
 		rl:   rl,
-		sess: newServerStream(conn, remoteVersion),
+		sess: newServerStream(metrics.staticMonitorConn(conn), remoteVersion),
+
+		staticMetrics: metrics,
 	}
 	g.mu.Lock()
+	// Don't accept a second connection from a peer which is already
+	// connected over another address, e.g. over the other address family.
+	if g.duplicatePeer(peer.id, remoteAddr) {
+		g.mu.Unlock()
+		return errPeerExists
+	}
+	if err := g.checkEncryption(remoteAddr, remoteKey); err != nil {
+		g.mu.Unlock()
+		return err
+	}
+	g.pinEncryptionKey(remoteAddr, remoteKey)
+	peer.Addresses = g.validAdvertisedAddresses(remoteAddr, remoteAddrs)
 	g.acceptPeer(peer)
 	g.mu.Unlock()
 
@@ -258,59 +318,128 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 	// do this in a goroutine so that we can begin communicating with the peer
 	// immediately.
 	go func() {
+		if !g.managedAllowed(remoteAddr) {
+			return
+		}
 		err := g.staticPingNode(remoteAddr)
 		if err == nil {
 			g.mu.Lock()
 			g.addNode(remoteAddr)
+			if n, exists := g.nodes[remoteAddr]; exists {
+				n.Services = remoteServices
+			}
+			g.pinEncryptionKey(remoteAddr, remoteKey)
 			g.mu.Unlock()
 		}
 	}()
+	go g.threadedAddAdvertisedAddresses(peer.Addresses, remoteServices)
 
 	return nil
 }
 
+// peerClass is the class of an inbound peer. Each class has its own limit of
+// inbound connections. When the gateway needs to make room for a new peer, it
+// kicks peers of lower classes first.
+type peerClass int
+
+const (
+	// peerClassLightClient are peers which advertised ServiceLightClient.
+	peerClassLightClient peerClass = iota
+	// peerClassUnknown are peers which advertised neither ServiceFullNode nor
+	// ServiceLightClient.
+	peerClassUnknown
+	// peerClassFullNode are peers which advertised ServiceFullNode.
+	peerClassFullNode
+
+	// numPeerClasses is the number of peer classes.
+	numPeerClasses
+)
+
+// maxInbound returns the maximum number of inbound connections of the class.
+func (c peerClass) maxInbound() int {
+	switch c {
+	case peerClassLightClient:
+		return maxInboundLightClients
+	case peerClassFullNode:
+		return maxInboundFullNodes
+	default:
+		return maxInboundUnknownPeers
+	}
+}
+
+// class returns the class of the peer based on its advertised services.
+func (p *peer) class() peerClass {
+	switch {
+	case p.Services.Has(modules.ServiceFullNode):
+		return peerClassFullNode
+	case p.Services.Has(modules.ServiceLightClient):
+		return peerClassLightClient
+	default:
+		return peerClassUnknown
+	}
+}
+
+// kickable returns whether the peer may be kicked to make room for another
+// peer. Outbound peers and local peers are not available to be kicked.
+func (p *peer) kickable() bool {
+	return p.Inbound && !p.Local
+}
+
 // acceptPeer makes room for the peer if necessary by kicking out existing
-// peers, then adds the peer to the peer list.
+// peers, then adds the peer to the peer list. If the peer's class reached its
+// limit of inbound connections, a peer of the same class is kicked. Otherwise,
+// if the gateway is fully connected, a peer of the same or a lower class is
+// kicked. That way a flood of light clients can't push out full nodes.
 func (g *Gateway) acceptPeer(p *peer) {
-	// If we are not fully connected, add the peer without kicking any out.
-	if len(g.peers) < fullyConnectedThreshold {
-		g.addPeer(p)
-		return
+	class := p.class()
+	var numInbound [numPeerClasses]int
+	for _, peer := range g.peers {
+		if peer.kickable() {
+			numInbound[peer.class()]++
+		}
 	}
 
-	// Select a peer to kick. Outbound peers and local peers are not
-	// available to be kicked.
-	var addrs, preferredAddrs []modules.NetAddress
-	for addr, peer := range g.peers {
-		// Do not kick outbound peers or local peers.
-		if !peer.Inbound || peer.Local {
-			continue
-		}
+	if p.kickable() && numInbound[class] >= class.maxInbound() {
+		g.kickPeer(p, class, class)
+	} else if len(g.peers) >= fullyConnectedThreshold {
+		g.kickPeer(p, peerClassLightClient, class)
+	}
+	g.addPeer(p)
+}
 
-		// Prefer kicking a peer with the same hostname.
-		if addr.Host() == p.NetAddress.Host() {
-			preferredAddrs = append(preferredAddrs, addr)
+// kickPeer kicks a random kickable peer to make room for p. Peers of the
+// lowest class within [minClass, maxClass] are kicked first. If there is
+// nobody suitable to kick, no peer is kicked.
+func (g *Gateway) kickPeer(p *peer, minClass, maxClass peerClass) {
+	for class := minClass; class <= maxClass; class++ {
+		var addrs, preferredAddrs []modules.NetAddress
+		for addr, peer := range g.peers {
+			if !peer.kickable() || peer.class() != class {
+				continue
+			}
+			// Prefer kicking a peer with the same hostname.
+			if addr.Host() == p.NetAddress.Host() {
+				preferredAddrs = append(preferredAddrs, addr)
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+		if len(preferredAddrs) > 0 {
+			// If there are preferredAddrs we choose randomly from them.
+			addrs = preferredAddrs
+		}
+		if len(addrs) == 0 {
 			continue
 		}
-		addrs = append(addrs, addr)
-	}
-	if len(preferredAddrs) > 0 {
-		// If there are preferredAddrs we choose randomly from them.
-		addrs = preferredAddrs
-	}
-	if len(addrs) == 0 {
-		// There is nobody suitable to kick, therefore do not kick anyone.
-		g.addPeer(p)
-		return
-	}
 
-	// Of the remaining options, select one at random.
-	kick := addrs[fastrand.Intn(len(addrs))]
+		// Of the remaining options, select one at random.
+		kick := addrs[fastrand.Intn(len(addrs))]
 
-	g.peers[kick].sess.Close()
-	delete(g.peers, kick)
-	g.log.Printf("INFO: disconnected from %v to make room for %v\n", kick, p.NetAddress)
-	g.addPeer(p)
+		g.peers[kick].sess.Close()
+		delete(g.peers, kick)
+		g.log.Printf("INFO: disconnected from %v to make room for %v\n", kick, p.NetAddress)
+		return
+	}
 }
 
 // acceptableVersion returns an error if the version is unacceptable.
@@ -408,8 +537,10 @@ func exchangeRemoteHeader(conn net.Conn, ourHeader sessionHeader) (sessionHeader
 }
 
 // managedConnectPeer connects to peers >= v1.3.1. The peer is added as a
-// node and a peer. The peer is only added if a nil error is returned.
-func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remoteAddr modules.NetAddress) error {
+// node and a peer. The peer is only added if a nil error is returned. The
+// header, services and alternate addresses advertised by the peer are
+// returned.
+func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remoteAddr modules.NetAddress) (sessionHeader, modules.GatewayServices, []modules.NetAddress, error) {
 	g.log.Debugln("Sending sessionHeader with address", g.myAddr, g.myAddr.IsLocal())
 	// Perform header handshake.
 	g.mu.RLock()
@@ -418,14 +549,44 @@ func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remote
 		UniqueID:   g.staticID,
 		NetAddress: g.myAddr,
 	}
+	ourServices := g.services
+	ourAddrs := g.alternateAddresses()
 	g.mu.RUnlock()
 
 	if err := exchangeOurHeader(conn, ourHeader); err != nil {
-		return err
-	} else if _, err := exchangeRemoteHeader(conn, ourHeader); err != nil {
-		return err
+		return sessionHeader{}, 0, nil, err
 	}
-	return nil
+	remoteHeader, err := exchangeRemoteHeader(conn, ourHeader)
+	if err != nil {
+		return sessionHeader{}, 0, nil, err
+	}
+	remoteServices, err := connectServicesHandshake(conn, remoteVersion, ourServices)
+	if err != nil {
+		return sessionHeader{}, 0, nil, err
+	}
+	remoteAddrs, err := connectAddressesHandshake(conn, remoteVersion, ourAddrs)
+	if err != nil {
+		return sessionHeader{}, 0, nil, err
+	}
+	return remoteHeader, remoteServices, remoteAddrs, nil
+}
+
+// managedConnectEncryption establishes an encrypted session with the peer at
+// addr if it supports it and checks that the session satisfies the
+// encryption requirements of the gateway. The static key of the peer is returned
+// if the session is encrypted.
+func (g *Gateway) managedConnectEncryption(conn net.Conn, remoteVersion string, addr modules.NetAddress) (net.Conn, *crypto.X25519PublicKey, error) {
+	conn, remoteKey, err := connectEncryptionHandshake(conn, ProtocolVersion, remoteVersion, g.staticSecretKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.mu.RLock()
+	err = g.checkEncryption(addr, remoteKey)
+	g.mu.RUnlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, remoteKey, nil
 }
 
 // managedConnect establishes a persistent connection to a peer, and adds it to
@@ -456,8 +617,16 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
 		return err
 	}
+	if !g.managedAllowed(addr) {
+		g.log.Debugln("Unable to connect to", addr, "error:", errNotAllowlisted)
+		return errNotAllowlisted
+	}
+	if g.staticPeerScorer.callBanned(addr.Host(), time.Now()) {
+		g.log.Debugln("Unable to connect to", addr, "error:", errPeerBanned)
+		return errPeerBanned
+	}
 	g.mu.RLock()
-	_, exists := g.peers[addr]
+	_, exists := g.connectedPeer(addr)
 	g.mu.RUnlock()
 	if exists {
 		g.log.Debugln("Unable to connect to", addr, "error:", errPeerExists)
@@ -472,16 +641,29 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	}
 	g.log.Debugln("Created conn; remote and local addr", conn.RemoteAddr(), conn.LocalAddr())
 
-	// Perform peer initialization.
+	// Perform peer initialization. The version handshake takes a single
+	// round trip which is used as the latency of the connection.
+	start := time.Now()
 	remoteVersion, err := connectVersionHandshake(conn, ProtocolVersion)
 	if err != nil {
 		conn.Close()
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
 		return err
 	}
+	metrics := newPeerMetrics(time.Since(start))
 
+	var remoteHeader sessionHeader
+	var remoteServices modules.GatewayServices
+	var remoteAddrs []modules.NetAddress
+	var remoteKey *crypto.X25519PublicKey
+	// The session is established over sessionConn, which encrypts the data
+	// sent over conn if the peer supports it.
+	sessionConn := conn
 	if err = acceptableVersion(remoteVersion); err == nil {
-		err = g.managedConnectPeer(conn, remoteVersion, addr)
+		sessionConn, remoteKey, err = g.managedConnectEncryption(conn, remoteVersion, addr)
+	}
+	if err == nil {
+		remoteHeader, remoteServices, remoteAddrs, err = g.managedConnectPeer(sessionConn, remoteVersion, addr)
 	}
 	if err != nil {
 		conn.Close()
@@ -497,19 +679,35 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	// Drop the connection if we are already connected to the peer over
+	// another address, e.g. over the other address family.
+	if g.duplicatePeer(remoteHeader.UniqueID, addr) {
+		conn.Close()
+		g.log.Debugln("Unable to connect to", addr, "error:", errPeerExists)
+		return errPeerExists
+	}
+	remoteAddrs = g.validAdvertisedAddresses(addr, remoteAddrs)
 	g.addPeer(&peer{
 		Peer: modules.Peer{
 			Inbound:    false,
 			Local:      addr.IsLocal(),
 			NetAddress: addr,
 			Version:    remoteVersion,
+			Services:   remoteServices,
+			Addresses:  remoteAddrs,
+			Encrypted:  remoteKey != nil,
 		},
+		id:   remoteHeader.UniqueID,
 		m:    g.m,
 		rl:   g.rl,
-		sess: newClientStream(conn, remoteVersion),
+		sess: newClientStream(metrics.staticMonitorConn(sessionConn), remoteVersion),
+
+		staticMetrics: metrics,
 	})
 	g.addNode(addr)
 	g.nodes[addr].WasOutboundPeer = true
+	g.nodes[addr].Services = remoteServices
+	g.pinEncryptionKey(addr, remoteKey)
 
 	if err := g.saveSyncNodes(); err != nil {
 		g.log.Println("ERROR: Unable to save new outbound peer to gateway:", err)
@@ -519,6 +717,7 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 
 	// call initRPCs
 	g.callInitRPCs(addr)
+	go g.threadedAddAdvertisedAddresses(remoteAddrs, remoteServices)
 
 	return nil
 }
@@ -565,9 +764,12 @@ func (g *Gateway) Disconnect(addr modules.NetAddress) error {
 
 // ConnectManual is a wrapper for the Connect function. It is specifically used
 // if a user wants to connect to a node manually. This also removes the node
-// from the blocklist.
+// from the blocklist and lifts its ban.
 func (g *Gateway) ConnectManual(addr modules.NetAddress) error {
 	g.log.Debugln("Attempting to Manually Connect to", addr)
+	if g.staticPeerScorer.callUnban(addr.Host(), time.Now()) == nil {
+		g.log.Debugln("Lifted the ban of", addr, "due to Manually trying to Connect")
+	}
 	g.mu.Lock()
 	var err error
 	if _, exists := g.blocklist[addr.Host()]; exists {
@@ -575,6 +777,12 @@ func (g *Gateway) ConnectManual(addr modules.NetAddress) error {
 		delete(g.blocklist, addr.Host())
 		err = g.saveSync()
 	}
+	// A manual connection accepts a new static key of the node, e.g. after it
+	// lost its persist data.
+	if n, exists := g.nodes[addr]; exists && n.EncryptionKey != nil {
+		g.log.Debugln("Unpinning the encryption key of", addr, "due to Manually trying to Connect")
+		n.EncryptionKey = nil
+	}
 	g.mu.Unlock()
 	return build.ComposeErrors(err, g.Connect(addr))
 }
@@ -13,6 +13,7 @@ import (
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -202,6 +203,98 @@ func TestAcceptPeerSameHost(t *testing.T) {
 	}
 }
 
+// TestAcceptPeerClasses tests that acceptPeer enforces the inbound limits of
+// the peer classes and that light clients can't kick full nodes.
+func TestAcceptPeerClasses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	i := 0
+	newPeer := func(services modules.GatewayServices) *peer {
+		i++
+		return &peer{
+			Peer: modules.Peer{
+				NetAddress: modules.NetAddress(fmt.Sprintf("1.2.%d.%d:1234", i/256, i%256)),
+				Inbound:    true,
+				Services:   services,
+			},
+			sess: newClientStream(new(dummyConn), ProtocolVersion),
+		}
+	}
+	numPeers := func(class peerClass) (n int) {
+		for _, p := range g.peers {
+			if p.class() == class {
+				n++
+			}
+		}
+		return
+	}
+
+	// Flood the gateway with light clients. They should be capped.
+	for j := 0; j < 5*fullyConnectedThreshold; j++ {
+		g.acceptPeer(newPeer(modules.ServiceLightClient))
+	}
+	if n := numPeers(peerClassLightClient); n != maxInboundLightClients {
+		t.Fatalf("expected %v light clients but got %v", maxInboundLightClients, n)
+	}
+
+	// Flood the gateway with unknown peers. They should be capped and kick
+	// the light clients once the gateway is fully connected.
+	for j := 0; j < 5*fullyConnectedThreshold; j++ {
+		g.acceptPeer(newPeer(0))
+	}
+	if n := numPeers(peerClassUnknown); n != maxInboundUnknownPeers {
+		t.Fatalf("expected %v unknown peers but got %v", maxInboundUnknownPeers, n)
+	}
+	if n := numPeers(peerClassLightClient); n != 0 {
+		t.Fatal("expected light clients to be kicked", n)
+	}
+
+	// Flood the gateway with full nodes. They should kick the unknown peers.
+	for j := 0; j < 5*fullyConnectedThreshold; j++ {
+		g.acceptPeer(newPeer(modules.ServiceFullNode))
+	}
+	if n := numPeers(peerClassFullNode); n != maxInboundFullNodes {
+		t.Fatalf("expected %v full nodes but got %v", maxInboundFullNodes, n)
+	}
+	if n := numPeers(peerClassUnknown); n != 0 {
+		t.Fatal("expected unknown peers to be kicked", n)
+	}
+
+	// Flood the gateway with light clients and unknown peers again. They
+	// shouldn't kick any of the full nodes.
+	var fullNodes []modules.NetAddress
+	for addr := range g.peers {
+		fullNodes = append(fullNodes, addr)
+	}
+	for j := 0; j < 5*fullyConnectedThreshold; j++ {
+		g.acceptPeer(newPeer(modules.ServiceLightClient))
+		g.acceptPeer(newPeer(0))
+	}
+	for _, addr := range fullNodes {
+		if _, exists := g.peers[addr]; !exists {
+			t.Fatal("full node was kicked")
+		}
+	}
+	if n := numPeers(peerClassLightClient); n > maxInboundLightClients {
+		t.Fatalf("expected at most %v light clients but got %v", maxInboundLightClients, n)
+	}
+	if n := numPeers(peerClassUnknown); n > maxInboundUnknownPeers {
+		t.Fatalf("expected at most %v unknown peers but got %v", maxInboundUnknownPeers, n)
+	}
+}
+
 // TestRandomInbountPeer checks that randomOutboundPeer returns the correct
 // peer.
 func TestRandomOutboundPeer(t *testing.T) {
@@ -291,6 +384,11 @@ func TestListen(t *testing.T) {
 	if ack != ProtocolVersion {
 		t.Fatal("gateway should have given ack")
 	}
+	sk, _ := crypto.GenerateX25519KeyPair()
+	sessionConn, _, err := connectEncryptionHandshake(conn, ProtocolVersion, ack, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	header := sessionHeader{
 		GenesisID:  types.GenesisID,
@@ -298,7 +396,7 @@ func TestListen(t *testing.T) {
 		NetAddress: "fake",
 	}
 
-	err = exchangeOurHeader(conn, header)
+	err = exchangeOurHeader(sessionConn, header)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -317,13 +415,25 @@ func TestListen(t *testing.T) {
 	if ack != ProtocolVersion {
 		t.Fatal("gateway should have given ack")
 	}
+	sessionConn, _, err = connectEncryptionHandshake(conn, ProtocolVersion, ack, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	header.NetAddress = modules.NetAddress(conn.LocalAddr().String())
-	err = exchangeOurHeader(conn, header)
+	err = exchangeOurHeader(sessionConn, header)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = exchangeRemoteHeader(conn, header)
+	_, err = exchangeRemoteHeader(sessionConn, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = connectServicesHandshake(sessionConn, ack, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = connectAddressesHandshake(sessionConn, ack, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -344,7 +454,7 @@ func TestListen(t *testing.T) {
 
 	// Disconnect. Now that connection has been established, need to shutdown
 	// via the stream multiplexer.
-	newClientStream(conn, ProtocolVersion).Close()
+	newClientStream(sessionConn, ProtocolVersion).Close()
 
 	// g should remove the peer
 	err = build.Retry(50, 100*time.Millisecond, func() error {
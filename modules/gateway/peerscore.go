@@ -0,0 +1,261 @@
+package gateway
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errPeerBanned is returned when connecting to or accepting a connection
+	// from a banned peer.
+	errPeerBanned = errors.New("peer is banned because of misbehavior")
+
+	// errPeerNotBanned is returned when lifting the ban of a peer which isn't
+	// banned.
+	errPeerNotBanned = errors.New("peer is not banned")
+)
+
+type (
+	// peerScorer tracks the misbehavior of the gateway's peers and bans
+	// peers whose score reaches the ban threshold. Peers are identified by
+	// their host, like in the blocklist. The duration of a ban doubles with
+	// every ban of the same peer until the peer behaved for long enough to be
+	// forgiven.
+	peerScorer struct {
+		scores map[string]*peerScore
+		mu     sync.Mutex
+	}
+
+	// peerScore is the misbehavior score of a single peer.
+	peerScore struct {
+		score           float64
+		lastUpdate      time.Time
+		misbehavior     map[modules.PeerMisbehavior]uint64
+		lastMisbehavior time.Time
+
+		bans        int
+		banReason   modules.PeerMisbehavior
+		bannedSince time.Time
+		bannedUntil time.Time
+	}
+)
+
+// newPeerScorer creates a new peerScorer.
+func newPeerScorer() *peerScorer {
+	return &peerScorer{
+		scores: make(map[string]*peerScore),
+	}
+}
+
+// peerBanDuration returns the duration of a peer's ban after it was banned the
+// provided number of times in a row.
+func peerBanDuration(bans int) time.Duration {
+	d := peerBanBase
+	for i := 1; i < bans && d < peerBanMax; i++ {
+		d *= 2
+	}
+	if d > peerBanMax {
+		d = peerBanMax
+	}
+	return d
+}
+
+// banned returns whether the peer is banned at the provided time.
+func (ps *peerScore) banned(now time.Time) bool {
+	return now.Before(ps.bannedUntil)
+}
+
+// decay lets the score of the peer decay up to the provided time and forgives
+// the previous bans of peers which behaved for long enough since.
+func (ps *peerScore) decay(now time.Time) {
+	if elapsed := now.Sub(ps.lastUpdate); elapsed > 0 {
+		ps.score -= peerBanThreshold * elapsed.Seconds() / peerScoreDecayPeriod.Seconds()
+		if ps.score < 0 {
+			ps.score = 0
+		}
+		ps.lastUpdate = now
+	}
+	if ps.bans > 0 && now.Sub(ps.bannedUntil) >= peerBanMax {
+		ps.bans = 0
+	}
+}
+
+// forgiven returns whether the score holds no information anymore and can be
+// pruned.
+func (ps *peerScore) forgiven(now time.Time) bool {
+	ps.decay(now)
+	return ps.score == 0 && ps.bans == 0 && !ps.banned(now)
+}
+
+// callBanned returns whether the host is banned.
+func (s *peerScorer) callBanned(host string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, exists := s.scores[host]
+	return exists && ps.banned(now)
+}
+
+// callRecord adds the misbehavior to the score of the host. It returns the
+// duration of the ban if the score reached the ban threshold. Misbehavior of
+// peers which are banned already is ignored since their connections are
+// being closed.
+func (s *peerScorer) callRecord(host string, misbehavior modules.PeerMisbehavior, now time.Time) (time.Duration, bool) {
+	penalty, known := peerMisbehaviorPenalties[misbehavior]
+	if !known {
+		build.Critical("unknown peer misbehavior", misbehavior)
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, exists := s.scores[host]
+	if !exists {
+		// Prune the scores which don't hold any information anymore.
+		if len(s.scores) >= maxPeerScores {
+			for h, score := range s.scores {
+				if score.forgiven(now) {
+					delete(s.scores, h)
+				}
+			}
+		}
+		ps = &peerScore{
+			lastUpdate:  now,
+			misbehavior: make(map[modules.PeerMisbehavior]uint64),
+		}
+		s.scores[host] = ps
+	}
+	if ps.banned(now) {
+		return 0, false
+	}
+	ps.decay(now)
+	ps.score += penalty
+	ps.misbehavior[misbehavior]++
+	ps.lastMisbehavior = now
+	if ps.score < peerBanThreshold {
+		return 0, false
+	}
+
+	// Ban the peer and reset its score, the next ban is longer anyway.
+	ps.bans++
+	d := peerBanDuration(ps.bans)
+	ps.score = 0
+	ps.banReason = misbehavior
+	ps.bannedSince = now
+	ps.bannedUntil = now.Add(d)
+	return d, true
+}
+
+// callUnban lifts the ban of the host and forgets its score.
+func (s *peerScorer) callUnban(host string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, exists := s.scores[host]
+	if !exists || !ps.banned(now) {
+		return errPeerNotBanned
+	}
+	delete(s.scores, host)
+	return nil
+}
+
+// callBans returns the active bans, sorted by host.
+func (s *peerScorer) callBans(now time.Time) []modules.PeerBan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var bans []modules.PeerBan
+	for host, ps := range s.scores {
+		if !ps.banned(now) {
+			continue
+		}
+		bans = append(bans, modules.PeerBan{
+			Host:   host,
+			Reason: ps.banReason,
+			Bans:   ps.bans,
+			Start:  ps.bannedSince,
+			Expiry: ps.bannedUntil,
+		})
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Host < bans[j].Host
+	})
+	return bans
+}
+
+// callScores returns the scores of the peers which weren't forgiven yet,
+// sorted by host.
+func (s *peerScorer) callScores(now time.Time) []modules.PeerScore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var scores []modules.PeerScore
+	for host, ps := range s.scores {
+		if ps.forgiven(now) {
+			continue
+		}
+		misbehavior := make(map[modules.PeerMisbehavior]uint64, len(ps.misbehavior))
+		for m, n := range ps.misbehavior {
+			misbehavior[m] = n
+		}
+		scores = append(scores, modules.PeerScore{
+			Host:            host,
+			Score:           ps.score,
+			Misbehavior:     misbehavior,
+			LastMisbehavior: ps.lastMisbehavior,
+			Bans:            ps.bans,
+		})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Host < scores[j].Host
+	})
+	return scores
+}
+
+// managedDisconnectHost disconnects from all peers at the host.
+func (g *Gateway) managedDisconnectHost(host string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for addr, p := range g.peers {
+		if addr.Host() == host {
+			_ = p.sess.Close()
+			delete(g.peers, addr)
+		}
+	}
+}
+
+// PeerBans returns the peers which are currently banned because of
+// misbehavior.
+func (g *Gateway) PeerBans() []modules.PeerBan {
+	return g.staticPeerScorer.callBans(time.Now())
+}
+
+// PeerScores returns the misbehavior scores of the peers.
+func (g *Gateway) PeerScores() []modules.PeerScore {
+	return g.staticPeerScorer.callScores(time.Now())
+}
+
+// RecordPeerMisbehavior adds the misbehavior to the score of the peer and bans
+// the peer if its score reached the ban threshold. Banned peers are
+// disconnected and can't connect to the gateway until the ban expires.
+func (g *Gateway) RecordPeerMisbehavior(addr modules.NetAddress, misbehavior modules.PeerMisbehavior) {
+	host := addr.Host()
+	d, banned := g.staticPeerScorer.callRecord(host, misbehavior, time.Now())
+	if !banned {
+		g.log.Debugf("Peer %v misbehaved: %v", addr, misbehavior)
+		return
+	}
+	g.log.Printf("Banned peer %v for %v because of misbehavior: %v", host, d, misbehavior)
+	g.managedDisconnectHost(host)
+}
+
+// UnbanPeer lifts the ban of the host and resets its score.
+func (g *Gateway) UnbanPeer(host string) error {
+	if err := g.staticPeerScorer.callUnban(host, time.Now()); err != nil {
+		return err
+	}
+	g.log.Println("Lifted the ban of peer", host)
+	return nil
+}
@@ -0,0 +1,167 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestPeerBanDuration tests that the ban duration doubles with every ban up
+// to peerBanMax.
+func TestPeerBanDuration(t *testing.T) {
+	t.Parallel()
+
+	expected := peerBanBase
+	for bans := 1; bans < 64; bans++ {
+		if d := peerBanDuration(bans); d != expected {
+			t.Fatalf("ban %v lasts %v, expected %v", bans, d, expected)
+		}
+		expected *= 2
+		if expected > peerBanMax {
+			expected = peerBanMax
+		}
+	}
+}
+
+// TestPeerScorer probes the scores and bans of the peerScorer.
+func TestPeerScorer(t *testing.T) {
+	t.Parallel()
+
+	s := newPeerScorer()
+	now := time.Now()
+	host := "1.2.3.4"
+
+	// Stalls add up until the peer is banned.
+	stallPenalty := peerMisbehaviorPenalties[modules.PeerMisbehaviorStall]
+	stalls := int(peerBanThreshold / stallPenalty)
+	for i := 1; i < stalls; i++ {
+		if _, banned := s.callRecord(host, modules.PeerMisbehaviorStall, now); banned {
+			t.Fatal("peer banned after", i, "stalls")
+		}
+	}
+	scores := s.callScores(now)
+	if len(scores) != 1 || scores[0].Host != host || scores[0].Score != float64(stalls-1)*stallPenalty || scores[0].Misbehavior[modules.PeerMisbehaviorStall] != uint64(stalls-1) {
+		t.Fatal("unexpected scores", scores)
+	}
+
+	// The score decays over time.
+	later := now.Add(peerScoreDecayPeriod / 10)
+	if scores := s.callScores(later); scores[0].Score != float64(stalls-1)*stallPenalty-peerBanThreshold/10 {
+		t.Fatal("score didn't decay", scores[0].Score)
+	}
+	if d, banned := s.callRecord(host, modules.PeerMisbehaviorStall, later); banned {
+		t.Fatal("peer banned after its score decayed", d)
+	}
+	if d, banned := s.callRecord(host, modules.PeerMisbehaviorStall, later); !banned || d != peerBanBase {
+		t.Fatal("peer wasn't banned", d, banned)
+	}
+	if !s.callBanned(host, later) || s.callBanned("5.6.7.8", later) {
+		t.Fatal("wrong hosts are banned")
+	}
+	bans := s.callBans(later)
+	if len(bans) != 1 || bans[0].Host != host || bans[0].Reason != modules.PeerMisbehaviorStall || bans[0].Bans != 1 || !bans[0].Expiry.Equal(later.Add(peerBanBase)) {
+		t.Fatal("unexpected bans", bans)
+	}
+
+	// Misbehavior of banned peers is ignored. Once the ban expired, the next
+	// ban lasts twice as long.
+	if _, banned := s.callRecord(host, modules.PeerMisbehaviorInvalidBlock, later); banned {
+		t.Fatal("banned peer was banned again")
+	}
+	expired := later.Add(peerBanBase)
+	if s.callBanned(host, expired) || len(s.callBans(expired)) != 0 {
+		t.Fatal("ban didn't expire")
+	}
+	if d, banned := s.callRecord(host, modules.PeerMisbehaviorInvalidBlock, expired); !banned || d != peerBanDuration(2) {
+		t.Fatal("repeated ban wasn't longer", d, banned)
+	}
+
+	// Lifting the ban forgets the peer.
+	if err := s.callUnban(host, expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.callUnban(host, expired); !errors.Contains(err, errPeerNotBanned) {
+		t.Fatal("expected errPeerNotBanned, got", err)
+	}
+	if s.callBanned(host, expired) || len(s.callScores(expired)) != 0 {
+		t.Fatal("peer wasn't forgotten")
+	}
+
+	// Peers which behaved for long enough after their last ban are forgiven.
+	if _, banned := s.callRecord(host, modules.PeerMisbehaviorInvalidBlock, now); !banned {
+		t.Fatal("peer wasn't banned")
+	}
+	forgiven := now.Add(peerBanBase + peerBanMax)
+	if len(s.callScores(forgiven)) != 0 {
+		t.Fatal("peer wasn't forgiven")
+	}
+	if d, _ := s.callRecord(host, modules.PeerMisbehaviorInvalidBlock, forgiven); d != peerBanBase {
+		t.Fatal("forgiven peer didn't start over", d)
+	}
+}
+
+// TestPeerBan tests that banned peers are disconnected and can't connect until
+// their ban is lifted.
+func TestPeerBan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// An invalid block gets the peer banned right away.
+	g1.RecordPeerMisbehavior(g2.Address(), modules.PeerMisbehaviorInvalidBlock)
+	if len(g1.Peers()) != 0 {
+		t.Fatal("gateway didn't disconnect from the banned peer")
+	}
+	bans := g1.PeerBans()
+	if len(bans) != 1 || bans[0].Host != g2.Address().Host() || bans[0].Reason != modules.PeerMisbehaviorInvalidBlock {
+		t.Fatal("unexpected bans", bans)
+	}
+
+	// Neither side can connect while the peer is banned.
+	if err := g1.Connect(g2.Address()); !errors.Contains(err, errPeerBanned) {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+	err := build.Retry(100, 10*time.Millisecond, func() error {
+		if len(g2.Peers()) != 0 {
+			return errors.New("banned peer is still connected")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Connect(g1.Address()); err == nil {
+		t.Fatal("banned peer was able to connect")
+	}
+
+	// After lifting the ban, the peers can connect again.
+	if err := g1.UnbanPeer(g2.Address().Host()); err != nil {
+		t.Fatal(err)
+	}
+	if len(g1.PeerBans()) != 0 {
+		t.Fatal("ban wasn't lifted")
+	}
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -13,6 +13,9 @@ import (
 func (g *Gateway) managedPeerManagerConnect(addr modules.NetAddress) {
 	g.log.Debugf("[PMC] [%v] Attempting connection", addr)
 	err := g.managedConnect(addr)
+	if err == nil || errors.Contains(err, errPeerExists) {
+		g.staticDialScheduler.callDialSucceeded(addr)
+	}
 	if errors.Contains(err, errPeerExists) {
 		// This peer is already connected to us. Safety around the
 		// outbound peers relates to the fact that we have picked out
@@ -27,7 +30,7 @@ func (g *Gateway) managedPeerManagerConnect(addr modules.NetAddress) {
 		// we can hold off making attacker nodes 'outbound' peers until
 		// our nodelist has had time to fill up naturally.
 		g.mu.Lock()
-		p, exists := g.peers[addr]
+		p, exists := g.connectedPeer(addr)
 		if exists {
 			// Have to check it exists because we released the lock, a
 			// race condition could mean that the peer was disconnected
@@ -42,14 +45,19 @@ func (g *Gateway) managedPeerManagerConnect(addr modules.NetAddress) {
 		}
 		g.mu.Unlock()
 	} else if err != nil {
-		g.log.Debugf("[PMC] [ERROR] [%v] WARN: removing peer because automatic connect failed: %v\n", addr, err)
-
-		// Remove the node, but only if there are enough nodes in the node list.
-		g.mu.Lock()
-		if len(g.nodes) > pruneNodeListLen {
-			g.removeNode(addr)
+		// Back off from the node. Once it has exhausted its retry budget,
+		// remove it, but only if there are enough nodes in the node list.
+		retryIn, exhausted := g.staticDialScheduler.callDialFailed(addr)
+		if !exhausted {
+			g.log.Debugf("[PMC] [ERROR] [%v] automatic connect failed, retrying in %v: %v", addr, retryIn, err)
+			return
 		}
+		g.mu.Lock()
+		removed := len(g.nodes) > pruneNodeListLen && g.removeNode(addr) == nil
 		g.mu.Unlock()
+		if removed {
+			g.log.Debugf("[PMC] [ERROR] [%v] WARN: removing peer because automatic connect failed %v times: %v", addr, maxDialFailures, err)
+		}
 	} else {
 		g.log.Debugf("[PMC] [SUCCESS] [%v] peer successfully added", addr)
 	}
@@ -87,7 +95,17 @@ func (g *Gateway) permanentPeerManager(closedChan chan struct{}) {
 		g.mu.RUnlock()
 		if len(nodes) == 0 {
 			g.log.Debugln("[PPM] Node list is empty, sleeping")
-			if !g.managedSleep(noNodesDelay) {
+			if !g.managedSleep(jitter(noNodesDelay)) {
+				return
+			}
+			continue
+		}
+
+		// Skip the nodes which recently failed to connect.
+		nodes = g.staticDialScheduler.callReadyAddresses(nodes)
+		if len(nodes) == 0 {
+			g.log.Debugln("[PPM] All nodes are backing off, sleeping")
+			if !g.managedSleep(jitter(noNodesDelay)) {
 				return
 			}
 			continue
@@ -97,11 +115,12 @@ func (g *Gateway) permanentPeerManager(closedChan chan struct{}) {
 			// Break as soon as we have enough outbound peers.
 			g.mu.RLock()
 			numOutboundPeers := g.numOutboundPeers()
-			isOutboundPeer := g.peers[addr] != nil && !g.peers[addr].Inbound
+			p, connected := g.connectedPeer(addr)
+			isOutboundPeer := connected && !p.Inbound
 			g.mu.RUnlock()
 			if numOutboundPeers >= wellConnectedThreshold {
 				g.log.Debugln("INFO: [PPM] Gateway has enough peers, sleeping.")
-				if !g.managedSleep(wellConnectedDelay) {
+				if !g.managedSleep(jitter(wellConnectedDelay)) {
 					return
 				}
 				break
@@ -152,8 +171,10 @@ func (g *Gateway) permanentPeerManager(closedChan chan struct{}) {
 
 			// Wait a bit before trying the next peer. The peer connections are
 			// non-blocking, so they should be spaced out to avoid spinning up an
-			// uncontrolled number of threads and therefore peer connections.
-			if !g.managedSleep(acquiringPeersDelay) {
+			// uncontrolled number of threads and therefore peer connections. The
+			// wait is jittered so that gateways which lost their peers at the
+			// same time don't redial in lockstep.
+			if !g.managedSleep(jitter(acquiringPeersDelay)) {
 				return
 			}
 		}
@@ -179,5 +200,19 @@ func (g *Gateway) buildPeerManagerNodeList() []modules.NetAddress {
 			numOutbound++
 		}
 	}
+
+	// move the nodes of the preferred address family to the front of the list
+	nodes = preferFamily(nodes, g.preferredFamily)
+
+	// In allowlist-only mode, only the allowlisted nodes are tried.
+	if g.allowlistOnly {
+		allowed := nodes[:0]
+		for _, node := range nodes {
+			if g.allowed(node) {
+				allowed = append(allowed, node)
+			}
+		}
+		nodes = allowed
+	}
 	return nodes
 }
@@ -7,6 +7,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
 )
@@ -37,6 +38,20 @@ type (
 
 		// blocklisted IPs
 		Blocklist []string
+
+		// allowlisted IPs and whether the gateway only connects to them
+		Allowlist     []string
+		AllowlistOnly bool
+
+		// the address family the gateway prefers when dialing peers
+		PreferredAddressFamily modules.AddressFamily
+
+		// whether the gateway refuses plaintext sessions
+		RequireEncryption bool
+
+		// the static key of the encrypted sessions of a gateway without an
+		// identity
+		SessionKey crypto.X25519SecretKey
 	}
 )
 
@@ -63,6 +78,11 @@ func (g *Gateway) load() error {
 		v130 = true
 	}
 	for i := range nodes {
+		// Pins which were persisted before they had a last seen time are
+		// treated as seen at startup.
+		if nodes[i].EncryptionKey != nil && nodes[i].EncryptionKeySeen.IsZero() {
+			nodes[i].EncryptionKeySeen = time.Now()
+		}
 		g.nodes[nodes[i].NetAddress] = nodes[i]
 	}
 
@@ -94,6 +114,13 @@ func (g *Gateway) load() error {
 	for _, ip := range g.persist.Blocklist {
 		g.blocklist[ip] = struct{}{}
 	}
+	// create map from allowlist
+	for _, ip := range g.persist.Allowlist {
+		g.allowlist[ip] = struct{}{}
+	}
+	g.allowlistOnly = g.persist.AllowlistOnly
+	g.preferredFamily = g.persist.PreferredAddressFamily
+	g.requireEncryption = g.persist.RequireEncryption
 	return nil
 }
 
@@ -104,6 +131,13 @@ func (g *Gateway) saveSync() error {
 	for ip := range g.blocklist {
 		g.persist.Blocklist = append(g.persist.Blocklist, ip)
 	}
+	g.persist.Allowlist = make([]string, 0, len(g.allowlist))
+	for ip := range g.allowlist {
+		g.persist.Allowlist = append(g.persist.Allowlist, ip)
+	}
+	g.persist.AllowlistOnly = g.allowlistOnly
+	g.persist.PreferredAddressFamily = g.preferredFamily
+	g.persist.RequireEncryption = g.requireEncryption
 	return persist.SaveJSON(persistMetadata, g.persist, filepath.Join(g.persistDir, persistFilename))
 }
 
@@ -130,6 +164,7 @@ func (g *Gateway) threadedSaveLoop() {
 			defer g.threads.Done()
 
 			g.mu.Lock()
+			g.refreshEncryptionPins()
 			err = g.saveSyncNodes()
 			g.mu.Unlock()
 			if err != nil {
@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxReachabilityObjectLen is the maximum length of the request and
+	// response of the CheckReachability RPC.
+	maxReachabilityObjectLen = 1 << 10
+)
+
+type (
+	// reachabilityRequest is sent to a peer to ask it to connect to a host.
+	reachabilityRequest struct {
+		Address modules.NetAddress
+		HostKey types.SiaPublicKey
+	}
+
+	// reachabilityResponse is the peer's answer to a reachabilityRequest.
+	reachabilityResponse struct {
+		Checked   bool
+		Reachable bool
+		Latency   time.Duration
+		Error     string
+	}
+)
+
+// checkReachability is the handler for the CheckReachability RPC. It connects
+// to the requested address and opens a session with the host to verify that
+// renters can reach the caller's host. Only addresses which resolve to the
+// caller's IP are checked to prevent the RPC from being used to make the
+// gateway connect to arbitrary addresses.
+func (g *Gateway) checkReachability(conn modules.PeerConn) error {
+	conn.SetDeadline(time.Now().Add(connStdDeadline + reachabilityTimeout))
+	var req reachabilityRequest
+	if err := encoding.ReadObject(conn, &req, maxReachabilityObjectLen); err != nil {
+		return errors.AddContext(err, "failed to read reachability request")
+	}
+	callerHost, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return errors.AddContext(err, "failed to split host from port")
+	}
+	resp := g.staticCheckAddress(req.Address, req.HostKey, net.ParseIP(callerHost))
+	return encoding.WriteObject(conn, resp)
+}
+
+// staticCheckAddress connects to the address and opens a session with the host
+// at the address.
+func (g *Gateway) staticCheckAddress(addr modules.NetAddress, hostKey types.SiaPublicKey, callerIP net.IP) reachabilityResponse {
+	// Check that the address belongs to the caller.
+	if err := addr.IsStdValid(); err != nil {
+		return reachabilityResponse{Error: err.Error()}
+	}
+	ips := []net.IP{net.ParseIP(addr.Host())}
+	if ips[0] == nil {
+		var err error
+		ips, err = g.staticDeps.LookupIP(addr.Host())
+		if err != nil {
+			return reachabilityResponse{Checked: true, Error: err.Error()}
+		}
+	}
+	callerAddress := false
+	for _, ip := range ips {
+		if ip.Equal(callerIP) || (ip.IsLoopback() && callerIP.IsLoopback()) {
+			callerAddress = true
+			break
+		}
+	}
+	if !callerAddress {
+		return reachabilityResponse{Error: modules.ErrReachabilityAddressMismatch.Error()}
+	}
+
+	// Connect to the host and open a session.
+	start := time.Now()
+	dialer := &net.Dialer{
+		Cancel:  g.threads.StopChan(),
+		Timeout: reachabilityTimeout,
+	}
+	hostConn, err := dialer.Dial("tcp", string(addr))
+	if err != nil {
+		return reachabilityResponse{Checked: true, Error: err.Error()}
+	}
+	defer hostConn.Close()
+	hostConn.SetDeadline(start.Add(reachabilityTimeout))
+	s, _, err := modules.NewRenterSession(hostConn, hostKey)
+	if errors.Contains(err, crypto.ErrInvalidSignature) {
+		return reachabilityResponse{Checked: true, Error: modules.ErrReachabilityWrongHost.Error()}
+	} else if err != nil {
+		return reachabilityResponse{Checked: true, Error: errors.AddContext(err, "failed to open session with host").Error()}
+	}
+	latency := time.Since(start)
+	_ = s.WriteRequest(modules.RPCLoopExit, nil)
+	return reachabilityResponse{
+		Checked:   true,
+		Reachable: true,
+		Latency:   latency,
+	}
+}
+
+// CheckReachability asks up to maxReachabilityPeers connected peers to connect
+// to the given address and to open a session with the host with the given key.
+func (g *Gateway) CheckReachability(addr modules.NetAddress, hostKey types.SiaPublicKey) []modules.ReachabilityReport {
+	if err := g.threads.Add(); err != nil {
+		return nil
+	}
+	defer g.threads.Done()
+
+	// Pick random peers.
+	peers := g.Peers()
+	fastrand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	if len(peers) > maxReachabilityPeers {
+		peers = peers[:maxReachabilityPeers]
+	}
+
+	// Ask them in parallel.
+	reports := make([]modules.ReachabilityReport, len(peers))
+	var wg sync.WaitGroup
+	for i := range peers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i] = g.managedRequestReachability(peers[i].NetAddress, addr, hostKey)
+		}(i)
+	}
+	wg.Wait()
+	return reports
+}
+
+// managedRequestReachability asks a single peer to check the reachability of
+// the address.
+func (g *Gateway) managedRequestReachability(peer, addr modules.NetAddress, hostKey types.SiaPublicKey) modules.ReachabilityReport {
+	report := modules.ReachabilityReport{Peer: peer}
+	err := g.managedRPC(peer, "CheckReachability", func(conn modules.PeerConn) error {
+		conn.SetDeadline(time.Now().Add(connStdDeadline + reachabilityTimeout))
+		req := reachabilityRequest{
+			Address: addr,
+			HostKey: hostKey,
+		}
+		if err := encoding.WriteObject(conn, req); err != nil {
+			return err
+		}
+		var resp reachabilityResponse
+		if err := encoding.ReadObject(conn, &resp, maxReachabilityObjectLen); err != nil {
+			return err
+		}
+		report.Checked = resp.Checked
+		report.Reachable = resp.Reachable
+		report.Latency = resp.Latency
+		report.Error = resp.Error
+		return nil
+	})
+	if err != nil {
+		report.Error = errors.AddContext(err, "failed to ask peer").Error()
+	}
+	return report
+}
@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCheckReachability tests the CheckReachability RPC.
+func TestCheckReachability(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	// Without peers there are no reports.
+	if reports := g1.CheckReachability("127.0.0.1:1234", types.SiaPublicKey{}); len(reports) != 0 {
+		t.Fatal("expected no reports", reports)
+	}
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Peers refuse to check addresses that don't belong to the caller.
+	reports := g1.CheckReachability("1.2.3.4:1234", types.SiaPublicKey{})
+	if len(reports) != 1 {
+		t.Fatal("expected 1 report, got", len(reports))
+	}
+	if r := reports[0]; r.Peer != g2.Address() || r.Checked || r.Reachable || r.Error != modules.ErrReachabilityAddressMismatch.Error() {
+		t.Fatal("unexpected report", r)
+	}
+
+	// A listener which closes incoming connections isn't a reachable host.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	reports = g1.CheckReachability(modules.NetAddress(l.Addr().String()), types.SiaPublicKey{})
+	if len(reports) != 1 {
+		t.Fatal("expected 1 report, got", len(reports))
+	}
+	if r := reports[0]; !r.Checked || r.Reachable || !strings.Contains(r.Error, "failed to open session") {
+		t.Fatal("unexpected report", r)
+	}
+}
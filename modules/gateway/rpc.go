@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -65,9 +66,13 @@ func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RP
 	}
 	conn.SetDeadline(time.Time{})
 
-	// call fn
+	// call fn while enforcing the RPC's limits
+	rc := newRPCConn(conn, outgoingRPCLimits(name))
+	go g.threadedWatchRPC(rc, addr, name)
 	startRPCTime := time.Now()
-	err = fn(conn)
+	err = fn(rc)
+	close(rc.staticDone)
+	peer.staticMetrics.callRecordRPC(err)
 	// Log the amount of time it took to do the RPC.
 	g.log.Debugf("%s RPC time: %v, err: %v", name, time.Since(startRPCTime).Round(time.Millisecond), err)
 	return err
@@ -221,17 +226,22 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 	g.mu.RUnlock()
 	if !ok {
 		g.log.Debugf("WARN: incoming conn %v requested unknown RPC \"%v\"", conn.RPCAddr(), id)
+		g.RecordPeerMisbehavior(conn.RPCAddr(), modules.PeerMisbehaviorMalformedRPC)
 		return
 	}
 	g.log.Debugf("INFO: incoming conn %v requested RPC \"%v\"", conn.RPCAddr(), id)
 
-	// call fn
+	// call fn while enforcing the RPC's deadline
+	rc := newRPCConn(conn, incomingRPCLimits())
+	go g.threadedWatchRPC(rc, conn.RPCAddr(), strings.TrimSpace(id.String()))
 	startRPCTime := time.Now()
-	err = fn(conn)
+	err = fn(rc)
+	close(rc.staticDone)
 	// don't log benign errors
 	if errors.Contains(err, modules.ErrDuplicateTransactionSet) || errors.Contains(err, modules.ErrBlockKnown) {
 		err = nil
 	}
+	g.managedRecordPeerRPC(conn.RPCAddr(), err)
 	if err != nil {
 		g.log.Debugf("WARN: incoming RPC \"%v\" from conn %v failed: %v", id, conn.RPCAddr(), err)
 	}
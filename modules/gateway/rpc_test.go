@@ -714,3 +714,88 @@ func TestRPCRatelimit(t *testing.T) {
 		t.Error("ratelimit does not seem to be effective", expected, elapsed)
 	}
 }
+
+// TestRPCStalled tests that an RPC which stalls is aborted and that the
+// gateway disconnects from the peer.
+func TestRPCStalled(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The handler never replies.
+	g2.RegisterRPC("Stall", func(conn modules.PeerConn) error {
+		_, err := conn.Read(make([]byte, 1))
+		return err
+	})
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := g1.RPC(g2.Address(), "Stall", func(conn modules.PeerConn) error {
+		var s string
+		return encoding.ReadObject(conn, &s, 100)
+	})
+	if !errors.Contains(err, modules.ErrRPCStalled) {
+		t.Fatal("expected ErrRPCStalled, got", err)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("gateway didn't disconnect from the stalling peer")
+	}
+	disconnects := g1.PeerDisconnects()
+	if len(disconnects) != 1 || disconnects[0].NetAddress != g2.Address() || disconnects[0].RPC != "Stall" || disconnects[0].Reason != modules.PeerDisconnectRPCStalled {
+		t.Fatal("unexpected disconnects", disconnects)
+	}
+	scores := g1.PeerScores()
+	if len(scores) != 1 || scores[0].Host != g2.Address().Host() || scores[0].Misbehavior[modules.PeerMisbehaviorStall] != 1 {
+		t.Fatal("stall wasn't added to the peer's score", scores)
+	}
+}
+
+// TestRPCConnStalled probes the stall detection of rpcConn.
+func TestRPCConnStalled(t *testing.T) {
+	t.Parallel()
+	rc := newRPCConn(peerConn{}, rpcLimits{minBytesPerSecond: 100})
+
+	// Time which isn't spent waiting for the peer doesn't count.
+	rc.busy = rpcStallWindow / 4
+	if rc.managedStalled() {
+		t.Fatal("rpc without enough I/O time shouldn't be stalled")
+	}
+
+	// Enough data was transferred.
+	rc.busy = rpcStallWindow
+	rc.bytes = uint64(100 * rpcStallWindow.Seconds())
+	if rc.managedStalled() {
+		t.Fatal("rpc with enough throughput shouldn't be stalled")
+	}
+	if rc.busy != 0 || rc.bytes != 0 {
+		t.Fatal("statistics weren't reset")
+	}
+
+	// Not enough data was transferred.
+	rc.busy = rpcStallWindow
+	rc.bytes = uint64(50 * rpcStallWindow.Seconds())
+	if !rc.managedStalled() {
+		t.Fatal("rpc with too little throughput should be stalled")
+	}
+
+	// Stall detection is disabled without a minimum rate.
+	rc = newRPCConn(peerConn{}, incomingRPCLimits())
+	rc.busy = rpcStallWindow
+	if rc.managedStalled() {
+		t.Fatal("rpc without a minimum rate shouldn't be stalled")
+	}
+}
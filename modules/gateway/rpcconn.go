@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// rpcLimits are the limits the gateway enforces for a single RPC.
+type rpcLimits struct {
+	// deadline is the maximum duration of the RPC.
+	deadline time.Duration
+
+	// minBytesPerSecond is the minimum rate at which the peer has to
+	// transfer data while the RPC waits for it. 0 disables stall detection.
+	minBytesPerSecond uint64
+}
+
+// rpcConn wraps the stream of an RPC to enforce its limits. The transfer rate
+// is only measured while a read or write is in progress, so time spent on
+// local processing between reads and writes doesn't count against the peer.
+type rpcConn struct {
+	modules.PeerConn
+
+	staticDone   chan struct{}
+	staticLimits rpcLimits
+
+	// The I/O statistics of the current stall window.
+	busy      time.Duration
+	busySince time.Time
+	bytes     uint64
+	inFlight  int
+
+	// abortErr is returned by reads and writes once the RPC was aborted.
+	abortErr error
+	mu       sync.Mutex
+}
+
+// outgoingRPCLimits returns the limits of an RPC the gateway calls on a
+// peer.
+func outgoingRPCLimits(name string) rpcLimits {
+	limits := rpcLimits{
+		deadline:          rpcMaxDeadline,
+		minBytesPerSecond: rpcMinBytesPerSecond,
+	}
+	if handlerName(name) == handlerName("CheckReachability") {
+		// The peer dials the checked address before it replies.
+		limits.minBytesPerSecond = 0
+	}
+	return limits
+}
+
+// incomingRPCLimits returns the limits of an RPC a peer calls on the
+// gateway. Stall detection is disabled because the peer might wait on local
+// processing while it doesn't read.
+func incomingRPCLimits() rpcLimits {
+	return rpcLimits{
+		deadline: rpcMaxDeadline,
+	}
+}
+
+// newRPCConn wraps the stream of an RPC.
+func newRPCConn(conn modules.PeerConn, limits rpcLimits) *rpcConn {
+	return &rpcConn{
+		PeerConn:     conn,
+		staticDone:   make(chan struct{}),
+		staticLimits: limits,
+	}
+}
+
+// managedBeginIO marks the start of a read or write.
+func (rc *rpcConn) managedBeginIO() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.inFlight == 0 {
+		rc.busySince = time.Now()
+	}
+	rc.inFlight++
+}
+
+// managedEndIO marks the end of a read or write which transferred n bytes. If
+// the RPC was aborted, the reason is returned instead of err.
+func (rc *rpcConn) managedEndIO(n int, err error) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.inFlight--
+	if rc.inFlight == 0 {
+		rc.busy += time.Since(rc.busySince)
+	}
+	rc.bytes += uint64(n)
+	if err != nil && rc.abortErr != nil {
+		return rc.abortErr
+	}
+	return err
+}
+
+// Read implements io.Reader.
+func (rc *rpcConn) Read(b []byte) (int, error) {
+	rc.managedBeginIO()
+	n, err := rc.PeerConn.Read(b)
+	return n, rc.managedEndIO(n, err)
+}
+
+// Write implements io.Writer.
+func (rc *rpcConn) Write(b []byte) (int, error) {
+	rc.managedBeginIO()
+	n, err := rc.PeerConn.Write(b)
+	return n, rc.managedEndIO(n, err)
+}
+
+// managedStalled returns whether the peer transferred data slower than the
+// minimum rate during the last stall window and resets the statistics. A
+// window in which the RPC waited for the peer less than half of the time
+// doesn't count as stalled.
+func (rc *rpcConn) managedStalled() bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.inFlight > 0 {
+		now := time.Now()
+		rc.busy += now.Sub(rc.busySince)
+		rc.busySince = now
+	}
+	busy, bytes := rc.busy, rc.bytes
+	rc.busy, rc.bytes = 0, 0
+
+	if rc.staticLimits.minBytesPerSecond == 0 || busy < rpcStallWindow/2 {
+		return false
+	}
+	return float64(bytes) < float64(rc.staticLimits.minBytesPerSecond)*busy.Seconds()
+}
+
+// managedAbort aborts the RPC by closing its stream.
+func (rc *rpcConn) managedAbort(err error) {
+	rc.mu.Lock()
+	if rc.abortErr == nil {
+		rc.abortErr = err
+	}
+	rc.mu.Unlock()
+	_ = rc.PeerConn.Close()
+}
+
+// managedDisconnectPeer disconnects from a peer because an RPC with the peer
+// failed and records the reason.
+func (g *Gateway) managedDisconnectPeer(addr modules.NetAddress, rpc string, reason modules.PeerDisconnectReason) {
+	g.mu.Lock()
+	if p, ok := g.peers[addr]; ok {
+		_ = p.sess.Close()
+		delete(g.peers, addr)
+	}
+	g.peerDisconnects = append(g.peerDisconnects, modules.PeerDisconnect{
+		NetAddress: addr,
+		RPC:        rpc,
+		Reason:     reason,
+		Time:       time.Now(),
+	})
+	if len(g.peerDisconnects) > maxPeerDisconnects {
+		g.peerDisconnects = g.peerDisconnects[len(g.peerDisconnects)-maxPeerDisconnects:]
+	}
+	g.mu.Unlock()
+	g.log.Printf("Disconnected from peer %v because of %v RPC: %v", addr, rpc, reason)
+	g.RecordPeerMisbehavior(addr, modules.PeerMisbehaviorStall)
+}
+
+// threadedWatchRPC enforces the limits of an RPC until it is done. If the RPC
+// exceeds its deadline or stalls, it is aborted and the peer is disconnected.
+func (g *Gateway) threadedWatchRPC(rc *rpcConn, addr modules.NetAddress, rpc string) {
+	deadline := time.NewTimer(rc.staticLimits.deadline)
+	defer deadline.Stop()
+	stallCheck := time.NewTicker(rpcStallWindow)
+	defer stallCheck.Stop()
+
+	for {
+		select {
+		case <-rc.staticDone:
+			return
+		case <-g.threads.StopChan():
+			return
+		case <-deadline.C:
+			rc.managedAbort(modules.ErrRPCDeadline)
+			g.managedDisconnectPeer(addr, rpc, modules.PeerDisconnectRPCDeadline)
+			return
+		case <-stallCheck.C:
+			if rc.managedStalled() {
+				rc.managedAbort(modules.ErrRPCStalled)
+				g.managedDisconnectPeer(addr, rpc, modules.PeerDisconnectRPCStalled)
+				return
+			}
+		}
+	}
+}
+
+// PeerDisconnects returns the most recent peers the gateway disconnected from
+// because of a failed RPC.
+func (g *Gateway) PeerDisconnects() []modules.PeerDisconnect {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]modules.PeerDisconnect(nil), g.peerDisconnects...)
+}
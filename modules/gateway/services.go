@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errNotEnoughServicePeers is returned by ConnectServices if the gateway
+	// couldn't connect to enough peers with the requested services.
+	errNotEnoughServicePeers = errors.New("not enough peers with the requested services")
+
+	// maxServiceConnectAttempts is the maximum number of nodes ConnectServices
+	// tries to connect to.
+	maxServiceConnectAttempts = build.Select(build.Var{
+		Standard: 20,
+		Dev:      10,
+		Testing:  5,
+	}).(int)
+)
+
+// connectServicesHandshake sends our services and reads the remote services.
+// It should be called on the side making the connection request after the
+// session headers were exchanged. Peers older than servicesUpgradeVersion
+// don't advertise any services.
+func connectServicesHandshake(conn net.Conn, remoteVersion string, ourServices modules.GatewayServices) (remoteServices modules.GatewayServices, err error) {
+	if build.VersionCmp(remoteVersion, servicesUpgradeVersion) < 0 {
+		return 0, nil
+	}
+	if err := encoding.WriteObject(conn, ourServices); err != nil {
+		return 0, fmt.Errorf("failed to write services: %v", err)
+	}
+	if err := encoding.ReadObject(conn, &remoteServices, 8); err != nil {
+		return 0, fmt.Errorf("failed to read remote services: %v", err)
+	}
+	return remoteServices, nil
+}
+
+// acceptServicesHandshake reads the remote services and sends our services.
+// It should be called on the side accepting a connection request after the
+// session headers were exchanged. Peers older than servicesUpgradeVersion
+// don't advertise any services.
+func acceptServicesHandshake(conn net.Conn, remoteVersion string, ourServices modules.GatewayServices) (remoteServices modules.GatewayServices, err error) {
+	if build.VersionCmp(remoteVersion, servicesUpgradeVersion) < 0 {
+		return 0, nil
+	}
+	if err := encoding.ReadObject(conn, &remoteServices, 8); err != nil {
+		return 0, fmt.Errorf("failed to read remote services: %v", err)
+	}
+	if err := encoding.WriteObject(conn, ourServices); err != nil {
+		return 0, fmt.Errorf("failed to write services: %v", err)
+	}
+	return remoteServices, nil
+}
+
+// peersWithServices returns the peers which advertised all of the provided
+// services.
+func (g *Gateway) peersWithServices(services modules.GatewayServices) []modules.Peer {
+	var peers []modules.Peer
+	for _, p := range g.peers {
+		if p.Services.Has(services) {
+			peers = append(peers, p.Peer)
+		}
+	}
+	return peers
+}
+
+// serviceCandidates returns the known nodes which the gateway isn't connected
+// to and which might offer the provided services. Nodes which advertised the
+// services before come first, followed by nodes whose services are unknown.
+func (g *Gateway) serviceCandidates(services modules.GatewayServices) []modules.NetAddress {
+	var known, unknown []modules.NetAddress
+	for addr, n := range g.nodes {
+		if _, connected := g.peers[addr]; connected {
+			continue
+		}
+		if n.Services == 0 {
+			unknown = append(unknown, addr)
+		} else if n.Services.Has(services) {
+			known = append(known, addr)
+		}
+	}
+	fastrand.Shuffle(len(known), func(i, j int) { known[i], known[j] = known[j], known[i] })
+	fastrand.Shuffle(len(unknown), func(i, j int) { unknown[i], unknown[j] = unknown[j], unknown[i] })
+	return append(known, unknown...)
+}
+
+// Services returns the services the gateway advertises to its peers.
+func (g *Gateway) Services() modules.GatewayServices {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.services
+}
+
+// SetServices sets the services the gateway advertises to its peers. Only new
+// connections learn about the change.
+func (g *Gateway) SetServices(services modules.GatewayServices) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.services = services
+}
+
+// ConnectServices connects to known nodes until the gateway is connected to at
+// least n peers which advertise all of the provided services and returns those
+// peers.
+func (g *Gateway) ConnectServices(services modules.GatewayServices, n int) ([]modules.Peer, error) {
+	if err := g.threads.Add(); err != nil {
+		return nil, err
+	}
+	defer g.threads.Done()
+
+	g.mu.RLock()
+	peers := g.peersWithServices(services)
+	candidates := g.serviceCandidates(services)
+	g.mu.RUnlock()
+
+	for i := 0; i < len(candidates) && i < maxServiceConnectAttempts && len(peers) < n; i++ {
+		addr := candidates[i]
+		if err := g.managedConnect(addr); err != nil {
+			g.log.Debugf("INFO: failed to connect to %v for services %v: %v", addr, services, err)
+			continue
+		}
+		g.mu.RLock()
+		p, exists := g.peers[addr]
+		if exists && p.Services.Has(services) {
+			peers = append(peers, p.Peer)
+		}
+		g.mu.RUnlock()
+	}
+	if len(peers) < n {
+		return peers, errNotEnoughServicePeers
+	}
+	return peers, nil
+}
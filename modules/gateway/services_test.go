@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"net"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestServicesHandshake checks that services are only exchanged with peers
+// which support it.
+func TestServicesHandshake(t *testing.T) {
+	tests := []struct {
+		version          string
+		connect, accept  modules.GatewayServices
+		expectedServices bool
+	}{
+		{ProtocolVersion, modules.ServiceHost, modules.ServiceExplorer | modules.ServiceArchivalConsensus, true},
+		{servicesUpgradeVersion, 0, modules.ServiceSkynetPortal, true},
+		{minimumAcceptablePeerVersion, modules.ServiceHost, modules.ServiceExplorer, false},
+	}
+	for _, test := range tests {
+		c1, c2 := net.Pipe()
+		var accepted modules.GatewayServices
+		var acceptErr error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			accepted, acceptErr = acceptServicesHandshake(c2, test.version, test.accept)
+		}()
+		connected, err := connectServicesHandshake(c1, test.version, test.connect)
+		<-done
+		if err := errors.Compose(err, acceptErr, c1.Close(), c2.Close()); err != nil {
+			t.Fatal(err)
+		}
+		if !test.expectedServices {
+			if connected != 0 || accepted != 0 {
+				t.Fatalf("%v: services shouldn't be exchanged: %v %v", test.version, connected, accepted)
+			}
+			continue
+		}
+		if connected != test.accept || accepted != test.connect {
+			t.Fatalf("%v: wrong services: %v %v", test.version, connected, accepted)
+		}
+	}
+}
+
+// TestGatewayServicesString probes the String method of GatewayServices.
+func TestGatewayServicesString(t *testing.T) {
+	if s := modules.GatewayServices(0).String(); s != "" {
+		t.Fatal("unexpected string", s)
+	}
+	services := modules.ServiceHost | modules.ServiceSnapshotServer | modules.ServiceArchivalConsensus
+	if s := services.String(); s != "host,snapshot-server,archival-consensus" {
+		t.Fatal("unexpected string", s)
+	}
+	if !services.Has(modules.ServiceHost|modules.ServiceArchivalConsensus) || services.Has(modules.ServiceHost|modules.ServiceExplorer) {
+		t.Fatal("Has returned the wrong result")
+	}
+}
+
+// TestConnectServices checks that the gateway connects to peers with the
+// requested services.
+func TestConnectServices(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	host := newNamedTestingGateway(t, "host")
+	explorer := newNamedTestingGateway(t, "explorer")
+	g := newNamedTestingGateway(t, "gateway")
+	defer func() {
+		if err := errors.Compose(host.Close(), explorer.Close(), g.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	host.SetServices(modules.ServiceHost | modules.ServiceArchivalConsensus)
+	explorer.SetServices(modules.ServiceExplorer)
+	g.SetServices(modules.ServiceArchivalConsensus)
+
+	// Without any known nodes, the gateway can't find any peers.
+	peers, err := g.ConnectServices(modules.ServiceHost, 1)
+	if !errors.Contains(err, errNotEnoughServicePeers) || len(peers) != 0 {
+		t.Fatal("expected no peers", peers, err)
+	}
+
+	// Add the nodes and connect to a host.
+	g.mu.Lock()
+	err = errors.Compose(g.addNode(host.Address()), g.addNode(explorer.Address()))
+	g.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers, err = g.ConnectServices(modules.ServiceHost, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].NetAddress != host.Address() || peers[0].Services != host.Services() {
+		t.Fatal("wrong peers", peers)
+	}
+
+	// There is only one host.
+	peers, err = g.ConnectServices(modules.ServiceHost, 2)
+	if !errors.Contains(err, errNotEnoughServicePeers) || len(peers) != 1 {
+		t.Fatal("expected a single peer", peers, err)
+	}
+
+	// The services of the nodes are remembered.
+	g.mu.RLock()
+	hostServices := g.nodes[host.Address()].Services
+	g.mu.RUnlock()
+	if hostServices != host.Services() {
+		t.Fatal("wrong node services", hostServices)
+	}
+
+	// The host learned about the gateway's services.
+	for _, p := range host.Peers() {
+		if p.Services != modules.ServiceArchivalConsensus {
+			t.Fatal("wrong peer services", p)
+		}
+	}
+}
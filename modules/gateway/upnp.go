@@ -61,6 +61,19 @@ func (g *Gateway) managedLearnHostname(cancel <-chan struct{}) (net.IP, error) {
 		}
 	}()
 
+	// Private networks only ask their peers.
+	if g.staticPrivateNetwork {
+		host, err := g.managedIPFromPeers(ctx.Done())
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to discover external IP")
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return nil, fmt.Errorf("%v is not a valid IP", host)
+		}
+		return ip, nil
+	}
+
 	// try UPnP first, then fallback to myexternalip.com and peer-to-peer
 	// discovery.
 	var host string
@@ -128,11 +141,24 @@ func (g *Gateway) threadedLearnHostname() {
 			continue
 		}
 
+		// Look for an address of the other address family on our interfaces
+		// to advertise to our peers as well.
+		var altAddr modules.NetAddress
+		if ifaceAddrs, err := net.InterfaceAddrs(); err != nil {
+			g.log.Println("WARN: failed to get interface addresses:", err)
+		} else if ip := alternateIP(host, ifaceAddrs); ip != nil {
+			altAddr = modules.NetAddress(net.JoinHostPort(ip.String(), g.port))
+		}
+
 		g.mu.Lock()
 		g.myAddr = addr
+		g.altAddr = altAddr
 		g.mu.Unlock()
 
 		g.log.Println("INFO: our address is", addr)
+		if altAddr != "" {
+			g.log.Println("INFO: our alternate address is", altAddr)
+		}
 
 		// Rediscover the IP later in case it changed.
 		if !g.managedSleep(rediscoverIPIntervalSuccess) {
@@ -1,6 +1,9 @@
 package modules
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"go.sia.tech/siad/build"
@@ -26,6 +29,33 @@ const (
 	// HostRegistryFile is the name of the file the host's registry is stored
 	// in.
 	HostRegistryFile = "registry.dat"
+
+	// HostSectorAuditFile is the name of the file the host's sector audit log
+	// is stored in.
+	HostSectorAuditFile = "sectoraudit.log"
+
+	// HostSectorAuditRead is the type of an audit entry for a sector that was
+	// read by a renter.
+	HostSectorAuditRead = "read"
+
+	// HostSectorAuditWrite is the type of an audit entry for a sector that was
+	// written by a renter.
+	HostSectorAuditWrite = "write"
+
+	// HostSectorAuditDelegatedRead is the type of an audit entry for a sector
+	// that was read by a third party the renter authorized with a
+	// DelegatedReadToken.
+	HostSectorAuditDelegatedRead = "delegatedread"
+)
+
+const (
+	// HostPayoutPolicyRotate sends the payout of every new contract to the
+	// next of the host's payout addresses.
+	HostPayoutPolicyRotate HostPayoutPolicy = "rotate"
+
+	// HostPayoutPolicySplit splits the value of the host's payouts among its
+	// payout addresses according to their percentages.
+	HostPayoutPolicySplit HostPayoutPolicy = "split"
 )
 
 var (
@@ -256,6 +286,12 @@ type (
 		StorageRevenue          types.Currency `json:"storagerevenue"`
 		TransactionFeeExpenses  types.Currency `json:"transactionfeeexpenses"`
 
+		// MaturingPayouts is the sum of the payouts of succeeded storage
+		// obligations which are locked in delayed siacoin outputs that
+		// haven't reached their maturity height yet. These funds count as
+		// revenue but don't show up in the wallet's confirmed balance yet.
+		MaturingPayouts types.Currency `json:"maturingpayouts"`
+
 		// Bandwidth financial metrics.
 		DownloadBandwidthRevenue          types.Currency `json:"downloadbandwidthrevenue"`
 		PotentialDownloadBandwidthRevenue types.Currency `json:"potentialdownloadbandwidthrevenue"`
@@ -286,9 +322,244 @@ type (
 		EphemeralAccountExpiry     time.Duration  `json:"ephemeralaccountexpiry"`
 		MaxEphemeralAccountBalance types.Currency `json:"maxephemeralaccountbalance"`
 		MaxEphemeralAccountRisk    types.Currency `json:"maxephemeralaccountrisk"`
+		MaxEphemeralAccountSupply  types.Currency `json:"maxephemeralaccountsupply"`
 
 		CustomRegistryPath string `json:"customregistrypath"`
 		RegistrySize       uint64 `json:"registrysize"`
+
+		RenewalHintWindow types.BlockHeight `json:"renewalhintwindow"`
+
+		SectorAuditLog bool `json:"sectorauditlog"`
+
+		IngressProtection bool `json:"ingressprotection"`
+	}
+
+	// HostSettingsChange records a change to the host's internal settings.
+	HostSettingsChange struct {
+		Timestamp time.Time         `json:"timestamp"`
+		Height    types.BlockHeight `json:"height"`
+		Source    string            `json:"source"`
+
+		// Fields contains the json names of the fields that were changed and
+		// Settings the internal settings after the change.
+		Fields   []string             `json:"fields"`
+		Settings HostInternalSettings `json:"settings"`
+	}
+
+	// HostSectorAuditEntry records a single read or write of a sector by a
+	// renter. For accesses through the MDM, Renter is the key of the
+	// ephemeral account that paid for the access and ContractID is only set
+	// if the program was executed on a contract. For delegated reads, Renter
+	// is the key of the delegate.
+	HostSectorAuditEntry struct {
+		Timestamp  time.Time            `json:"timestamp"`
+		Height     types.BlockHeight    `json:"height"`
+		Type       string               `json:"type"`
+		SectorRoot crypto.Hash          `json:"sectorroot"`
+		ContractID types.FileContractID `json:"contractid"`
+		Renter     types.SiaPublicKey   `json:"renter"`
+	}
+
+	// HostObligationRecovery is the result of rebuilding the host's storage
+	// obligations from the blockchain. Recovered contains the contracts the
+	// host services again. Unrecoverable contains the unresolved contracts of
+	// the host which can't be serviced because the sectors of their latest
+	// revision on the blockchain can't be determined.
+	HostObligationRecovery struct {
+		StartHeight   types.BlockHeight      `json:"startheight"`
+		EndHeight     types.BlockHeight      `json:"endheight"`
+		Recovered     []types.FileContractID `json:"recovered"`
+		Unrecoverable []types.FileContractID `json:"unrecoverable"`
+	}
+
+	// HostSectorCompaction is the result of compacting the host's sectors.
+	// ObligationsRewritten is the number of storage obligations whose sector
+	// lists contained roots which are no longer part of the contract and
+	// RootsDropped is the number of roots removed from them. The embedded
+	// SectorCompaction reports the virtual sectors and the space reclaimed
+	// by the storage manager afterwards.
+	HostSectorCompaction struct {
+		ObligationsRewritten uint64 `json:"obligationsrewritten"`
+		RootsDropped         uint64 `json:"rootsdropped"`
+		SectorCompaction
+	}
+
+	// HostScheduledSettings is a change to the host's internal settings which
+	// is applied once the host reaches a certain block height. Only the
+	// fields listed in Fields are taken from Settings, all other settings
+	// remain untouched.
+	HostScheduledSettings struct {
+		ID        string            `json:"id"`
+		Height    types.BlockHeight `json:"height"`
+		Source    string            `json:"source"`
+		Timestamp time.Time         `json:"timestamp"`
+
+		Fields   []string             `json:"fields"`
+		Settings HostInternalSettings `json:"settings"`
+	}
+
+	// HostBandwidthSettings limits the bandwidth the host uses to serve
+	// renters. Upload refers to data sent by the host and download to data
+	// received by the host. A limit of 0 means unlimited.
+	//
+	// The sustained limits can be exceeded for a short time by up to
+	// BurstSize bytes per direction at the burst rates. The burst allowance
+	// is replenished whenever the host uses less than its sustained limit.
+	HostBandwidthSettings struct {
+		UploadBPS   int64 `json:"uploadbps"`
+		DownloadBPS int64 `json:"downloadbps"`
+
+		BurstUploadBPS   int64 `json:"burstuploadbps"`
+		BurstDownloadBPS int64 `json:"burstdownloadbps"`
+		BurstSize        int64 `json:"burstsize"`
+
+		// Schedule contains time-of-day windows which replace the sustained
+		// limits while they are active. If windows overlap, the first one
+		// wins.
+		Schedule []HostBandwidthWindow `json:"schedule"`
+	}
+
+	// HostBandwidthWindow is a daily time window during which the host uses
+	// different sustained bandwidth limits. The hours refer to the local time
+	// of the host and EndHour is exclusive. Windows with an EndHour smaller
+	// than the StartHour wrap around midnight.
+	HostBandwidthWindow struct {
+		StartHour   uint64 `json:"starthour"`
+		EndHour     uint64 `json:"endhour"`
+		UploadBPS   int64  `json:"uploadbps"`
+		DownloadBPS int64  `json:"downloadbps"`
+	}
+
+	// HostBandwidthStatus reports the bandwidth limits the host currently
+	// enforces and the remaining burst allowance in bytes.
+	HostBandwidthStatus struct {
+		UploadBPS            int64 `json:"uploadbps"`
+		DownloadBPS          int64 `json:"downloadbps"`
+		UploadBurstBalance   int64 `json:"uploadburstbalance"`
+		DownloadBurstBalance int64 `json:"downloadburstbalance"`
+	}
+
+	// HostCapacityForecast combines the host's storage utilization, the rate
+	// at which the utilization grows and the expirations of the host's
+	// storage obligations to estimate when the host runs out of storage and
+	// when storage frees up again.
+	HostCapacityForecast struct {
+		Height           types.BlockHeight `json:"height"`
+		TotalStorage     uint64            `json:"totalstorage"`
+		RemainingStorage uint64            `json:"remainingstorage"`
+
+		// CommittedStorage is the amount of data stored in the host's
+		// unresolved storage obligations.
+		CommittedStorage uint64 `json:"committedstorage"`
+
+		// GrowthRate is the average number of bytes per block by which the
+		// used storage changed over the last SampledBlocks blocks. It is
+		// negative if the used storage shrank.
+		GrowthRate    int64             `json:"growthrate"`
+		SampledBlocks types.BlockHeight `json:"sampledblocks"`
+
+		// FullHeight is the estimated height at which the host runs out of
+		// storage if the used storage keeps growing at GrowthRate. It is 0 if
+		// the used storage isn't growing.
+		FullHeight types.BlockHeight `json:"fullheight"`
+
+		// Expirations contains the storage which frees up at future heights
+		// due to storage obligations ending, sorted by height.
+		Expirations []HostCapacityExpiration `json:"expirations"`
+	}
+
+	// HostCapacityExpiration is the storage freed up at a certain height by
+	// storage obligations whose proof deadline is reached. RemainingStorage is
+	// the host's remaining storage after the expiration if no new data is
+	// stored until then.
+	HostCapacityExpiration struct {
+		Height           types.BlockHeight `json:"height"`
+		Contracts        uint64            `json:"contracts"`
+		FreedStorage     uint64            `json:"freedstorage"`
+		RemainingStorage uint64            `json:"remainingstorage"`
+	}
+
+	// HostMaturingPayout is the payout of a succeeded storage obligation
+	// which is locked in a delayed siacoin output until MaturityHeight.
+	HostMaturingPayout struct {
+		ContractID     types.FileContractID `json:"contractid"`
+		Value          types.Currency       `json:"value"`
+		MaturityHeight types.BlockHeight    `json:"maturityheight"`
+	}
+
+	// HostPayoutSchedule contains the payouts of the host's succeeded storage
+	// obligations which are yet to mature, sorted by maturity height.
+	HostPayoutSchedule struct {
+		Height          types.BlockHeight    `json:"height"`
+		MaturingPayouts types.Currency       `json:"maturingpayouts"`
+		Payouts         []HostMaturingPayout `json:"payouts"`
+	}
+
+	// HostPayoutPolicy determines how the host picks the payout address of a
+	// new contract from its configured payout addresses.
+	HostPayoutPolicy string
+
+	// HostPayoutSettings contains the addresses the host's contract payouts
+	// are sent to. If no addresses are configured, all payouts go to the
+	// host's wallet address.
+	HostPayoutSettings struct {
+		Policy    HostPayoutPolicy    `json:"policy"`
+		Addresses []HostPayoutAddress `json:"addresses"`
+	}
+
+	// HostPayoutAddress is an address the host's contract payouts are sent
+	// to. Percentage is the share of the payouts the address receives and is
+	// only used by the split policy.
+	HostPayoutAddress struct {
+		Address    types.UnlockHash `json:"address"`
+		Percentage uint64           `json:"percentage"`
+	}
+
+	// HostPayoutRecord records the address the host's payout of a contract is
+	// sent to. Value is the host's payout if the contract succeeds at the
+	// time it was formed or renewed.
+	HostPayoutRecord struct {
+		ContractID types.FileContractID `json:"contractid"`
+		Address    types.UnlockHash     `json:"address"`
+		Value      types.Currency       `json:"value"`
+		Height     types.BlockHeight    `json:"height"`
+		Timestamp  time.Time            `json:"timestamp"`
+	}
+
+	// HostPayoutTotal is the sum of the payouts sent to an address since the
+	// host's payout settings were last changed.
+	HostPayoutTotal struct {
+		Address   types.UnlockHash `json:"address"`
+		Value     types.Currency   `json:"value"`
+		Contracts uint64           `json:"contracts"`
+	}
+
+	// HostPayoutHistory contains the address the host currently advertises
+	// for new contracts, the payout totals per address and the most recent
+	// payout records, oldest first.
+	HostPayoutHistory struct {
+		NextAddress types.UnlockHash   `json:"nextaddress"`
+		Totals      []HostPayoutTotal  `json:"totals"`
+		Payouts     []HostPayoutRecord `json:"payouts"`
+	}
+
+	// HostUtilizationSample records the host's used storage at a certain
+	// block height.
+	HostUtilizationSample struct {
+		Height      types.BlockHeight `json:"height"`
+		UsedStorage uint64            `json:"usedstorage"`
+	}
+
+	// HostConnectivity is the result of asking gateway peers to connect to
+	// the host's address. Conclusive is false if not enough peers were able
+	// to perform the check. Problems contains a description of the detected
+	// issues and how to fix them.
+	HostConnectivity struct {
+		NetAddress NetAddress           `json:"netaddress"`
+		Reachable  bool                 `json:"reachable"`
+		Conclusive bool                 `json:"conclusive"`
+		Reports    []ReachabilityReport `json:"reports"`
+		Problems   []string             `json:"problems"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -356,6 +627,7 @@ type (
 	// of the host protocol.
 	Host interface {
 		Alerter
+		OperationCounter
 
 		// AddSector will add a sector on the host. If the sector already
 		// exists, a virtual sector will be added, meaning that the 'sectorData'
@@ -390,9 +662,28 @@ type (
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// CheckConnectivity asks gateway peers to connect to the address the
+		// host would announce and reports whether renters can reach the host.
+		CheckConnectivity() (HostConnectivity, error)
+
 		// The host needs to be able to shut down.
 		Close() error
 
+		// CompactSectors removes the roots which are no longer part of their
+		// contracts from the sector lists of the storage obligations and
+		// reduces the references of the stored sectors to the references of
+		// the unresolved storage obligations. Sectors without references are
+		// removed and their space is reclaimed.
+		CompactSectors() (HostSectorCompaction, error)
+
+		// PayoutSchedule returns the payouts of succeeded storage obligations
+		// which are yet to mature.
+		PayoutSchedule() HostPayoutSchedule
+
+		// CapacityForecast returns a forecast of when the host runs out of
+		// storage and when storage frees up.
+		CapacityForecast() HostCapacityForecast
+
 		// ConnectabilityStatus returns the connectability status of the host,
 		// that is, if it can connect to itself on the configured NetAddress.
 		ConnectabilityStatus() HostConnectabilityStatus
@@ -464,6 +755,16 @@ type (
 		// operation will be completed, meaning that data will be lost.
 		RemoveStorageFolder(index uint16, force bool) error
 
+		// RecoverStorageFolder adds an existing storage folder whose
+		// metadata was lost to the host and returns the number of recovered
+		// sectors.
+		RecoverStorageFolder(path string) (uint64, error)
+
+		// RecoverStorageObligations rebuilds the storage obligations of the
+		// unresolved contracts of the host by scanning the blockchain from
+		// the provided height.
+		RecoverStorageObligations(startHeight types.BlockHeight) (HostObligationRecovery, error)
+
 		// ResetStorageFolderHealth will reset the health statistics on a
 		// storage folder.
 		ResetStorageFolderHealth(index uint16) error
@@ -482,6 +783,54 @@ type (
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetInternalSettingsFromSource sets the hosting parameters of the
+		// host and records the source of the change in the settings history.
+		SetInternalSettingsFromSource(settings HostInternalSettings, source string) error
+
+		// SettingsHistory returns the recorded changes to the host's internal
+		// settings, oldest first.
+		SettingsHistory() []HostSettingsChange
+
+		// SectorAudit returns the recorded sector accesses of the contract with
+		// the provided id, oldest first.
+		SectorAudit(id types.FileContractID) ([]HostSectorAuditEntry, error)
+
+		// PurgeSectorAudit removes all recorded sector accesses of the
+		// contract with the provided id from the sector audit log.
+		PurgeSectorAudit(id types.FileContractID) error
+
+		// ScheduleSettings schedules a change of the provided fields of the
+		// internal settings for the given block height.
+		ScheduleSettings(height types.BlockHeight, settings HostInternalSettings, fields []string, source string) (HostScheduledSettings, error)
+
+		// ScheduledSettings returns the pending scheduled settings changes,
+		// sorted by height.
+		ScheduledSettings() []HostScheduledSettings
+
+		// CancelScheduledSettings removes a pending scheduled settings change.
+		CancelScheduledSettings(id string) error
+
+		// BandwidthSettings returns the host's bandwidth settings.
+		BandwidthSettings() HostBandwidthSettings
+
+		// BandwidthStatus returns the bandwidth limits the host currently
+		// enforces.
+		BandwidthStatus() HostBandwidthStatus
+
+		// SetBandwidthSettings sets the host's bandwidth settings.
+		SetBandwidthSettings(HostBandwidthSettings) error
+
+		// PayoutSettings returns the host's payout address settings.
+		PayoutSettings() HostPayoutSettings
+
+		// SetPayoutSettings sets the addresses the host's contract payouts
+		// are sent to and the policy used to pick between them.
+		SetPayoutSettings(HostPayoutSettings) error
+
+		// PayoutHistory returns the addresses the host's contract payouts
+		// were sent to.
+		PayoutHistory() HostPayoutHistory
+
 		// StorageObligation returns the storage obligation matching the id or
 		// an error if it does not exist
 		StorageObligation(obligationID types.FileContractID) (StorageObligation, error)
@@ -512,6 +861,53 @@ func (his HostInternalSettings) MaxSectorAccessPrice() types.Currency {
 	return his.MinDownloadBandwidthPrice.Mul64(MaxSectorAccessPriceVsBandwidth)
 }
 
+// hostInternalSettingsFieldIndex maps the json names of the fields of the
+// HostInternalSettings to their index within the struct.
+var hostInternalSettingsFieldIndex = func() map[string]int {
+	t := reflect.TypeOf(HostInternalSettings{})
+	m := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		m[name] = i
+	}
+	return m
+}()
+
+// IsHostInternalSettingsField returns true if name is the json name of a field
+// of the HostInternalSettings.
+func IsHostInternalSettingsField(name string) bool {
+	_, exists := hostInternalSettingsFieldIndex[name]
+	return exists
+}
+
+// Diff returns the json names of the fields which differ between his and
+// other.
+func (his HostInternalSettings) Diff(other HostInternalSettings) []string {
+	a, b := reflect.ValueOf(his), reflect.ValueOf(other)
+	var fields []string
+	for i := 0; i < a.NumField(); i++ {
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			fields = append(fields, strings.Split(a.Type().Field(i).Tag.Get("json"), ",")[0])
+		}
+	}
+	return fields
+}
+
+// WithFields returns a copy of his with the fields of the provided json names
+// replaced by the corresponding fields of src.
+func (his HostInternalSettings) WithFields(src HostInternalSettings, fields []string) (HostInternalSettings, error) {
+	dst := reflect.ValueOf(&his).Elem()
+	srcVal := reflect.ValueOf(src)
+	for _, name := range fields {
+		i, exists := hostInternalSettingsFieldIndex[name]
+		if !exists {
+			return HostInternalSettings{}, fmt.Errorf("unknown host setting '%v'", name)
+		}
+		dst.Field(i).Set(srcVal.Field(i))
+	}
+	return his, nil
+}
+
 // DefaultHostExternalSettings returns HostExternalSettings with certain default
 // fields set. NetAddress, RemainingStorage, TotalStorage, UnlockHash, RevisionNumber and SiaMuxPort are not set.
 func DefaultHostExternalSettings() HostExternalSettings {
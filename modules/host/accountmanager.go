@@ -121,6 +121,11 @@ type (
 		// they will get processed in a FIFO fashion when risk is lowered.
 		blockedDeposits []*blockedDeposit
 
+		// totalBalance is the aggregate balance of all ephemeral accounts. The
+		// host can cap it using the maxephemeralaccountsupply to limit the
+		// amount of money it holds in custody.
+		totalBalance types.Currency
+
 		// withdrawalsInactive indicates whether the account manager allows
 		// withdrawals or not. Withdrawals are inactive as long as the host is
 		// not fully synced, or when it goes out of sync.
@@ -269,6 +274,9 @@ func (h *Host) newAccountManager() (_ *accountManager, err error) {
 	}
 	am.accounts = data.accounts
 	am.fingerprints.next = data.fingerprints
+	for _, acc := range am.accounts {
+		am.totalBalance = am.totalBalance.Add(acc.balance)
+	}
 
 	// Build the account index
 	am.accountBitfield.buildIndex(am.accounts)
@@ -361,13 +369,14 @@ func (am *accountManager) managedDeposit(id modules.AccountID, amount types.Curr
 	his := am.h.managedInternalSettings()
 	maxRisk := his.MaxEphemeralAccountRisk
 	maxBalance := his.MaxEphemeralAccountBalance
+	maxSupply := his.MaxEphemeralAccountSupply
 
 	// Initiate the deposit.
 	pr := &persistResult{
 		errAvail: make(chan struct{}),
 	}
 	am.mu.Lock()
-	err := am.deposit(id, amount, maxRisk, maxBalance, bh, refund, pr, syncChan)
+	err := am.deposit(id, amount, maxRisk, maxBalance, maxSupply, bh, refund, pr, syncChan)
 	am.mu.Unlock()
 	if err != nil {
 		return errors.AddContext(err, "Deposit failed")
@@ -455,7 +464,7 @@ func (am *accountManager) callConsensusChanged(cc modules.ConsensusChange, oldHe
 
 // deposit performs a couple of steps in preparation of the
 // deposit. If everything checks out it will commit the deposit.
-func (am *accountManager) deposit(id modules.AccountID, amount, maxRisk, maxBalance types.Currency, blockHeight types.BlockHeight, refund bool, pr *persistResult, syncChan chan struct{}) error {
+func (am *accountManager) deposit(id modules.AccountID, amount, maxRisk, maxBalance, maxSupply types.Currency, blockHeight types.BlockHeight, refund bool, pr *persistResult, syncChan chan struct{}) error {
 	// Open the account, if the account does not exist yet, it will be created.
 	acc, err := am.openAccount(id)
 	if err != nil {
@@ -472,6 +481,13 @@ func (am *accountManager) deposit(id modules.AccountID, amount, maxRisk, maxBala
 		return ErrBalanceMaxExceeded
 	}
 
+	// Verify if the deposit does not exceed the maximum supply
+	if !refund && am.depositExceedsMaxSupply(amount, maxSupply) {
+		pr.externErr = modules.ErrEphemeralAccountSupplyReached
+		close(pr.errAvail)
+		return modules.ErrEphemeralAccountSupplyReached
+	}
+
 	// If current risk exceeds the max risk, add the deposit to the
 	// blockedDeposits queue. These deposits will get dequeued by processes that
 	// lower the current risk, such as FC fsyncs or account persists.
@@ -665,6 +681,7 @@ func (am *accountManager) commitDeposit(a *account, amount types.Currency, block
 	// Update the account details
 	a.balance = a.balance.Add(amount)
 	a.lastTxnTime = time.Now().Unix()
+	am.totalBalance = am.totalBalance.Add(amount)
 
 	// As soon as the account balance has been updated in memory, we want to
 	// increase the host's current risk by the deposit amount. When the file
@@ -702,6 +719,7 @@ func (am *accountManager) commitWithdrawal(a *account, amount types.Currency, bl
 	// Update the account details
 	a.balance = a.balance.Sub(amount)
 	a.lastTxnTime = time.Now().Unix()
+	am.totalBalance = am.totalBalance.Sub(amount)
 	close(commitResultChan)
 
 	// Update the current risk and the account's pending risk. By allowing money
@@ -940,11 +958,19 @@ func (am *accountManager) managedExpireAccounts(threshold int64) []uint32 {
 			}
 			delete(am.accounts, id)
 			deleted = append(deleted, acc.index)
+			am.totalBalance = am.totalBalance.Sub(acc.balance)
 		}
 	}
 	return deleted
 }
 
+// callTotalBalance returns the aggregate balance of all ephemeral accounts.
+func (am *accountManager) callTotalBalance() types.Currency {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	return am.totalBalance
+}
+
 // callAccountBalance will return the balance of an account.
 func (am *accountManager) callAccountBalance(id modules.AccountID) types.Currency {
 	am.mu.Lock()
@@ -1075,6 +1101,21 @@ func (a *account) depositExceedsMaxBalance(deposit, maxBalance types.Currency) b
 	return updatedBalance.Cmp(maxBalance) > 0
 }
 
+// depositExceedsMaxSupply returns whether or not the deposit would push the
+// aggregate balance of all accounts, including the deposits that are blocked
+// on risk, over the maxephemeralaccountsupply. A maxSupply of zero means the
+// supply is unlimited.
+func (am *accountManager) depositExceedsMaxSupply(deposit, maxSupply types.Currency) bool {
+	if maxSupply.IsZero() {
+		return false
+	}
+	supply := am.totalBalance.Add(deposit)
+	for _, bd := range am.blockedDeposits {
+		supply = supply.Add(bd.amount)
+	}
+	return supply.Cmp(maxSupply) > 0
+}
+
 // withdrawalExceedsBalance returns true if withdrawal is larger than the
 // account balance.
 func (a *account) withdrawalExceedsBalance(withdrawal types.Currency) bool {
@@ -96,6 +96,77 @@ func TestAccountMaxBalance(t *testing.T) {
 	}
 }
 
+// TestAccountMaxSupply verifies the aggregate balance of all ephemeral accounts
+// can never exceed the host's max ephemeral account supply through deposits.
+func TestAccountMaxSupply(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	am := ht.host.staticAccountManager
+
+	// Cap the supply
+	his := ht.host.InternalSettings()
+	his.MaxEphemeralAccountSupply = types.NewCurrency64(100)
+	err = ht.host.SetInternalSettings(his)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Prepare two accounts
+	sk, accountA := prepareAccount()
+	_, accountB := prepareAccount()
+
+	// Fill the first account and verify the second one can't push the supply
+	// over the cap.
+	err = callDeposit(am, accountA, types.NewCurrency64(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = callDeposit(am, accountB, types.NewCurrency64(50))
+	if !errors.Contains(err, modules.ErrEphemeralAccountSupplyReached) {
+		t.Fatal("expected ErrEphemeralAccountSupplyReached but got", err)
+	}
+
+	// A refund should ignore the max supply.
+	err = am.callRefund(accountB, types.NewCurrency64(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total := am.callTotalBalance(); !total.Equals64(110) {
+		t.Fatal("wrong total balance", total)
+	}
+
+	// Drain the first account, this should make room for new deposits.
+	msg, sig := prepareWithdrawal(accountA, types.NewCurrency64(60), am.h.BlockHeight(), sk)
+	err = callWithdraw(am, msg, sig, am.h.BlockHeight())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = callDeposit(am, accountB, types.NewCurrency64(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total := am.callTotalBalance(); !total.Equals64(100) {
+		t.Fatal("wrong total balance", total)
+	}
+	err = callDeposit(am, accountA, types.NewCurrency64(1))
+	if !errors.Contains(err, modules.ErrEphemeralAccountSupplyReached) {
+		t.Fatal("expected ErrEphemeralAccountSupplyReached but got", err)
+	}
+}
+
 // TestAccountCallWithdraw verifies we can withdraw from an ephemeral account.
 func TestAccountCallWithdraw(t *testing.T) {
 	if testing.Short() {
@@ -3,6 +3,7 @@ package host
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"gitlab.com/NebulousLabs/errors"
 
@@ -56,6 +57,18 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 		return err
 	}
 
+	// Make sure that renters can reach the host at the address. The check is
+	// skipped in testing since test hosts are often announced with addresses
+	// that only resolve through custom resolvers.
+	if build.Release != "testing" || h.dependencies.Disrupt("CheckConnectivityBeforeAnnounce") {
+		hc := h.managedCheckConnectivity(addr)
+		if hc.Conclusive && !hc.Reachable {
+			return errors.AddContext(errAnnUnreachable, strings.Join(hc.Problems, "; "))
+		} else if !hc.Conclusive {
+			h.log.Printf("WARN: unable to verify that %v is reachable before announcing: %v", addr, strings.Join(hc.Problems, "; "))
+		}
+	}
+
 	// The wallet needs to be unlocked to add fees to the transaction, and the
 	// host needs to have an active unlock hash that renters can make payment
 	// to.
@@ -127,6 +140,18 @@ func (h *Host) Announce() error {
 	}
 	defer h.tg.Done()
 
+	annAddr, err := h.managedAnnouncementAddress()
+	if err != nil {
+		return err
+	}
+
+	// Address has cleared inspection, perform the announcement.
+	return h.managedAnnounce(annAddr)
+}
+
+// managedAnnouncementAddress returns the address the host announces if no
+// address is specified.
+func (h *Host) managedAnnouncementAddress() (modules.NetAddress, error) {
 	// Grab the internal net address and internal auto address, and compare
 	// them.
 	h.mu.RLock()
@@ -136,19 +161,14 @@ func (h *Host) Announce() error {
 
 	// Check that we have at least one address to work with.
 	if userSet == "" && autoSet == "" {
-		return errors.New("cannot announce because address could not be determined")
+		return "", errors.New("cannot announce because address could not be determined")
 	}
 
 	// Prefer using the userSet address, otherwise use the automatic address.
-	var annAddr modules.NetAddress
 	if userSet != "" {
-		annAddr = userSet
-	} else {
-		annAddr = autoSet
+		return userSet, nil
 	}
-
-	// Address has cleared inspection, perform the announcement.
-	return h.managedAnnounce(annAddr)
+	return autoSet, nil
 }
 
 // AnnounceAddress submits a host announcement to the blockchain to announce a
@@ -0,0 +1,274 @@
+package host
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/ratelimit"
+	"gitlab.com/NebulousLabs/siamux"
+
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errBandwidthNegative is returned when trying to set a negative bandwidth
+	// limit or burst size.
+	errBandwidthNegative = errors.New("bandwidth limits and burst size can't be negative")
+
+	// errBandwidthWindowHour is returned when a bandwidth window uses an hour
+	// outside of the range 0-23.
+	errBandwidthWindowHour = errors.New("bandwidth window hours need to be between 0 and 23")
+
+	// errBandwidthWindowEmpty is returned when a bandwidth window starts and
+	// ends at the same hour.
+	errBandwidthWindowEmpty = errors.New("bandwidth window can't start and end at the same hour")
+)
+
+type (
+	// bandwidthShaper enforces the host's bandwidth settings on all the
+	// connections and streams it wraps. It counts the transferred bytes to
+	// keep track of the burst allowance and periodically updates the limits
+	// of the shared ratelimit.
+	bandwidthShaper struct {
+		atomicDownloaded uint64
+		atomicUploaded   uint64
+
+		// lastDownloaded and lastUploaded are the counters at the time of the
+		// last update.
+		lastDownloaded uint64
+		lastUploaded   uint64
+		lastUpdate     time.Time
+
+		settings modules.HostBandwidthSettings
+		status   modules.HostBandwidthStatus
+
+		mu       sync.Mutex
+		staticRL *ratelimit.RateLimit
+	}
+
+	// shapedConn is a net.Conn which reports the transferred bytes to a
+	// bandwidthShaper.
+	shapedConn struct {
+		net.Conn
+		staticShaper *bandwidthShaper
+	}
+
+	// shapedStream is a siamux.Stream which reports the transferred bytes to
+	// a bandwidthShaper.
+	shapedStream struct {
+		siamux.Stream
+		staticShaper *bandwidthShaper
+	}
+)
+
+// newBandwidthShaper creates a new bandwidthShaper without any limits.
+func newBandwidthShaper() *bandwidthShaper {
+	return &bandwidthShaper{
+		lastUpdate: time.Now(),
+		staticRL:   ratelimit.NewRateLimit(0, 0, 0),
+	}
+}
+
+// Read reads from the underlying conn and counts the read bytes.
+func (c *shapedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddUint64(&c.staticShaper.atomicDownloaded, uint64(n))
+	return n, err
+}
+
+// Write writes to the underlying conn and counts the written bytes.
+func (c *shapedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(&c.staticShaper.atomicUploaded, uint64(n))
+	return n, err
+}
+
+// Read reads from the underlying stream and counts the read bytes.
+func (s *shapedStream) Read(b []byte) (int, error) {
+	n, err := s.Stream.Read(b)
+	atomic.AddUint64(&s.staticShaper.atomicDownloaded, uint64(n))
+	return n, err
+}
+
+// Write writes to the underlying stream and counts the written bytes.
+func (s *shapedStream) Write(b []byte) (int, error) {
+	n, err := s.Stream.Write(b)
+	atomic.AddUint64(&s.staticShaper.atomicUploaded, uint64(n))
+	return n, err
+}
+
+// staticWrapConn wraps a conn to be limited by the shaper.
+func (bs *bandwidthShaper) staticWrapConn(conn net.Conn, cancel <-chan struct{}) net.Conn {
+	return ratelimit.NewRLConn(&shapedConn{conn, bs}, bs.staticRL, cancel)
+}
+
+// staticWrapStream wraps a stream to be limited by the shaper.
+func (bs *bandwidthShaper) staticWrapStream(stream siamux.Stream, cancel <-chan struct{}) siamux.Stream {
+	return ratelimit.NewRLStream(&shapedStream{stream, bs}, bs.staticRL, cancel)
+}
+
+// managedSettings returns the shaper's settings.
+func (bs *bandwidthShaper) managedSettings() modules.HostBandwidthSettings {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	settings := bs.settings
+	settings.Schedule = append([]modules.HostBandwidthWindow(nil), bs.settings.Schedule...)
+	return settings
+}
+
+// managedStatus returns the currently enforced limits.
+func (bs *bandwidthShaper) managedStatus() modules.HostBandwidthStatus {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.status
+}
+
+// managedSetSettings updates the shaper's settings and applies them right
+// away. The burst allowance is capped at the new burst size.
+func (bs *bandwidthShaper) managedSetSettings(settings modules.HostBandwidthSettings, now time.Time) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.settings = settings
+	if bs.status.UploadBurstBalance > settings.BurstSize {
+		bs.status.UploadBurstBalance = settings.BurstSize
+	}
+	if bs.status.DownloadBurstBalance > settings.BurstSize {
+		bs.status.DownloadBurstBalance = settings.BurstSize
+	}
+	bs.update(now)
+}
+
+// managedUpdate updates the burst allowance and the enforced limits.
+func (bs *bandwidthShaper) managedUpdate(now time.Time) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.update(now)
+}
+
+// update updates the burst allowance according to the bytes transferred since
+// the last update and applies the resulting limits to the ratelimit.
+func (bs *bandwidthShaper) update(now time.Time) {
+	downloaded := atomic.LoadUint64(&bs.atomicDownloaded)
+	uploaded := atomic.LoadUint64(&bs.atomicUploaded)
+	elapsed := now.Sub(bs.lastUpdate)
+
+	uploadBPS, downloadBPS := sustainedLimits(bs.settings, now)
+	bs.status.UploadBurstBalance = nextBurstBalance(bs.status.UploadBurstBalance, uploaded-bs.lastUploaded, uploadBPS, elapsed, bs.settings.BurstSize)
+	bs.status.DownloadBurstBalance = nextBurstBalance(bs.status.DownloadBurstBalance, downloaded-bs.lastDownloaded, downloadBPS, elapsed, bs.settings.BurstSize)
+	bs.status.UploadBPS = enforcedLimit(uploadBPS, bs.settings.BurstUploadBPS, bs.status.UploadBurstBalance)
+	bs.status.DownloadBPS = enforcedLimit(downloadBPS, bs.settings.BurstDownloadBPS, bs.status.DownloadBurstBalance)
+	bs.staticRL.SetLimits(bs.status.DownloadBPS, bs.status.UploadBPS, 0)
+
+	bs.lastDownloaded = downloaded
+	bs.lastUploaded = uploaded
+	bs.lastUpdate = now
+}
+
+// sustainedLimits returns the sustained upload and download limits at the
+// provided time. The first window of the schedule that contains the time wins.
+func sustainedLimits(settings modules.HostBandwidthSettings, t time.Time) (uploadBPS, downloadBPS int64) {
+	hour := uint64(t.Hour())
+	for _, w := range settings.Schedule {
+		inWindow := w.StartHour <= hour && hour < w.EndHour
+		if w.EndHour < w.StartHour {
+			inWindow = hour >= w.StartHour || hour < w.EndHour
+		}
+		if inWindow {
+			return w.UploadBPS, w.DownloadBPS
+		}
+	}
+	return settings.UploadBPS, settings.DownloadBPS
+}
+
+// nextBurstBalance returns the burst allowance after transferring used bytes
+// within elapsed at a sustained limit of bps. Transferring less than the
+// sustained limit replenishes the allowance up to burstSize, transferring
+// more drains it.
+func nextBurstBalance(balance int64, used uint64, bps int64, elapsed time.Duration, burstSize int64) int64 {
+	if bps == 0 {
+		return burstSize // unlimited
+	}
+	balance += int64(float64(bps)*elapsed.Seconds()) - int64(used)
+	if balance < 0 {
+		return 0
+	}
+	if balance > burstSize {
+		return burstSize
+	}
+	return balance
+}
+
+// enforcedLimit returns the limit to enforce given the sustained and burst
+// limits and the remaining burst allowance. A burst limit of 0 means that
+// bursts are not limited.
+func enforcedLimit(sustained, burst, balance int64) int64 {
+	if sustained == 0 || balance == 0 {
+		return sustained
+	}
+	if burst != 0 && burst < sustained {
+		return sustained
+	}
+	return burst
+}
+
+// validateBandwidthSettings checks the provided settings for invalid values.
+func validateBandwidthSettings(settings modules.HostBandwidthSettings) error {
+	limits := []int64{settings.UploadBPS, settings.DownloadBPS, settings.BurstUploadBPS, settings.BurstDownloadBPS, settings.BurstSize}
+	for _, w := range settings.Schedule {
+		if w.StartHour > 23 || w.EndHour > 23 {
+			return errBandwidthWindowHour
+		}
+		if w.StartHour == w.EndHour {
+			return errBandwidthWindowEmpty
+		}
+		limits = append(limits, w.UploadBPS, w.DownloadBPS)
+	}
+	for _, limit := range limits {
+		if limit < 0 {
+			return errBandwidthNegative
+		}
+	}
+	return nil
+}
+
+// threadedShapeBandwidth periodically updates the host's bandwidth limits.
+func (h *Host) threadedShapeBandwidth() {
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(bandwidthShapingInterval):
+		}
+		h.staticBandwidthShaper.managedUpdate(time.Now())
+	}
+}
+
+// BandwidthSettings returns the host's bandwidth settings.
+func (h *Host) BandwidthSettings() modules.HostBandwidthSettings {
+	return h.staticBandwidthShaper.managedSettings()
+}
+
+// BandwidthStatus returns the bandwidth limits the host currently enforces
+// and the remaining burst allowance.
+func (h *Host) BandwidthStatus() modules.HostBandwidthStatus {
+	return h.staticBandwidthShaper.managedStatus()
+}
+
+// SetBandwidthSettings sets the host's bandwidth settings.
+func (h *Host) SetBandwidthSettings(settings modules.HostBandwidthSettings) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	if err := validateBandwidthSettings(settings); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.staticBandwidthShaper.managedSetSettings(settings, time.Now())
+	return errors.AddContext(h.saveSync(), "failed to save bandwidth settings")
+}
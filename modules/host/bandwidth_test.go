@@ -0,0 +1,166 @@
+package host
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestSustainedLimits checks that the schedule windows are applied at the
+// right hours.
+func TestSustainedLimits(t *testing.T) {
+	settings := modules.HostBandwidthSettings{
+		UploadBPS:   100,
+		DownloadBPS: 200,
+		Schedule: []modules.HostBandwidthWindow{
+			{StartHour: 22, EndHour: 6, UploadBPS: 0, DownloadBPS: 0},
+			{StartHour: 9, EndHour: 17, UploadBPS: 10, DownloadBPS: 20},
+			{StartHour: 16, EndHour: 18, UploadBPS: 30, DownloadBPS: 40},
+		},
+	}
+	tests := []struct {
+		hour        int
+		up, down    int64
+		description string
+	}{
+		{23, 0, 0, "night before midnight"},
+		{0, 0, 0, "midnight"},
+		{5, 0, 0, "night after midnight"},
+		{6, 100, 200, "morning"},
+		{9, 10, 20, "business hours"},
+		{16, 10, 20, "overlapping windows"},
+		{17, 30, 40, "after first window"},
+		{18, 100, 200, "evening"},
+	}
+	for _, test := range tests {
+		now := time.Date(2021, 1, 1, test.hour, 30, 0, 0, time.Local)
+		up, down := sustainedLimits(settings, now)
+		if up != test.up || down != test.down {
+			t.Errorf("%v: expected %v/%v but got %v/%v", test.description, test.up, test.down, up, down)
+		}
+	}
+}
+
+// TestBandwidthShaper checks that the shaper enforces the burst limits until
+// the burst allowance is used up.
+func TestBandwidthShaper(t *testing.T) {
+	bs := newBandwidthShaper()
+	now := bs.lastUpdate
+	bs.managedSetSettings(modules.HostBandwidthSettings{
+		UploadBPS:      100,
+		BurstUploadBPS: 1000,
+		BurstSize:      500,
+	}, now)
+
+	// Without a burst allowance the sustained limit is enforced.
+	if status := bs.managedStatus(); status.UploadBPS != 100 || status.DownloadBPS != 0 {
+		t.Fatal("unexpected status", status)
+	}
+	if down, up, _ := bs.staticRL.Limits(); down != 0 || up != 100 {
+		t.Fatal("unexpected limits", down, up)
+	}
+
+	// Staying idle replenishes the allowance up to the burst size.
+	for i := 0; i < 10; i++ {
+		now = now.Add(time.Second)
+		bs.managedUpdate(now)
+	}
+	status := bs.managedStatus()
+	if status.UploadBurstBalance != 500 || status.UploadBPS != 1000 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Using 300 bytes more than the sustained limit drains the allowance.
+	atomic.AddUint64(&bs.atomicUploaded, 400)
+	now = now.Add(time.Second)
+	bs.managedUpdate(now)
+	status = bs.managedStatus()
+	if status.UploadBurstBalance != 200 || status.UploadBPS != 1000 {
+		t.Fatal("unexpected status", status)
+	}
+	atomic.AddUint64(&bs.atomicUploaded, 1000)
+	now = now.Add(time.Second)
+	bs.managedUpdate(now)
+	status = bs.managedStatus()
+	if status.UploadBurstBalance != 0 || status.UploadBPS != 100 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Reducing the burst size caps the balance.
+	now = now.Add(10 * time.Second)
+	bs.managedUpdate(now)
+	bs.managedSetSettings(modules.HostBandwidthSettings{
+		UploadBPS:      100,
+		BurstUploadBPS: 1000,
+		BurstSize:      50,
+	}, now)
+	if status := bs.managedStatus(); status.UploadBurstBalance != 50 {
+		t.Fatal("unexpected status", status)
+	}
+}
+
+// TestHostBandwidthSettings checks that the host validates and persists its
+// bandwidth settings.
+func TestHostBandwidthSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid settings are rejected.
+	invalid := []struct {
+		settings modules.HostBandwidthSettings
+		err      error
+	}{
+		{modules.HostBandwidthSettings{UploadBPS: -1}, errBandwidthNegative},
+		{modules.HostBandwidthSettings{Schedule: []modules.HostBandwidthWindow{{StartHour: 1, EndHour: 24}}}, errBandwidthWindowHour},
+		{modules.HostBandwidthSettings{Schedule: []modules.HostBandwidthWindow{{StartHour: 1, EndHour: 1}}}, errBandwidthWindowEmpty},
+		{modules.HostBandwidthSettings{Schedule: []modules.HostBandwidthWindow{{StartHour: 1, EndHour: 2, DownloadBPS: -1}}}, errBandwidthNegative},
+	}
+	for _, test := range invalid {
+		if err := ht.host.SetBandwidthSettings(test.settings); !errors.Contains(err, test.err) {
+			t.Fatalf("expected %v but got %v", test.err, err)
+		}
+	}
+
+	// Set valid settings and reload the host.
+	settings := modules.HostBandwidthSettings{
+		UploadBPS:        1 << 20,
+		DownloadBPS:      1 << 21,
+		BurstUploadBPS:   1 << 22,
+		BurstDownloadBPS: 1 << 23,
+		BurstSize:        1 << 24,
+		Schedule: []modules.HostBandwidthWindow{
+			{StartHour: 0, EndHour: 23, UploadBPS: 1 << 10, DownloadBPS: 1 << 11},
+		},
+	}
+	if err := ht.host.SetBandwidthSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ht.host.BandwidthSettings(), settings) {
+		t.Fatal("settings weren't persisted", ht.host.BandwidthSettings())
+	}
+}
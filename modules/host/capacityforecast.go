@@ -0,0 +1,141 @@
+package host
+
+import (
+	"sort"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// utilizationSamplesLimit is the maximum number of utilization samples
+	// the host keeps. Together with the utilizationSampleInterval it defines
+	// the period over which the growth rate of the used storage is computed.
+	utilizationSamplesLimit = 30
+)
+
+var (
+	// utilizationSampleInterval is the number of blocks between two samples of
+	// the host's used storage.
+	utilizationSampleInterval = build.Select(build.Var{
+		Dev:      types.BlockHeight(12),
+		Standard: types.BlockHeight(144), // 1 day
+		Testing:  types.BlockHeight(1),
+	}).(types.BlockHeight)
+)
+
+// usedStorage returns the total and the used storage of the host.
+func (h *Host) usedStorage() (total, used uint64) {
+	total, remaining := h.capacity()
+	if remaining > total {
+		return total, 0
+	}
+	return total, total - remaining
+}
+
+// managedRecordUtilizationSample adds a sample of the used storage at the
+// given height to the host's utilization samples if at least
+// utilizationSampleInterval blocks passed since the last sample. Samples of
+// blocks that were reverted are dropped.
+func (h *Host) managedRecordUtilizationSample(height types.BlockHeight) {
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	h.mu.RLock()
+	n := len(h.utilizationSamples)
+	due := n == 0 || height < h.utilizationSamples[n-1].Height || height >= h.utilizationSamples[n-1].Height+utilizationSampleInterval
+	h.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	// Fetch the used storage without holding the host's lock since the
+	// contract manager might be busy.
+	_, used := h.usedStorage()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for len(h.utilizationSamples) > 0 && h.utilizationSamples[len(h.utilizationSamples)-1].Height > height {
+		h.utilizationSamples = h.utilizationSamples[:len(h.utilizationSamples)-1]
+	}
+	if len(h.utilizationSamples) > 0 && height < h.utilizationSamples[len(h.utilizationSamples)-1].Height+utilizationSampleInterval {
+		return
+	}
+	h.utilizationSamples = append(h.utilizationSamples, modules.HostUtilizationSample{
+		Height:      height,
+		UsedStorage: used,
+	})
+	if len(h.utilizationSamples) > utilizationSamplesLimit {
+		h.utilizationSamples = h.utilizationSamples[len(h.utilizationSamples)-utilizationSamplesLimit:]
+	}
+}
+
+// CapacityForecast returns a forecast of when the host runs out of storage and
+// when storage frees up. The growth rate of the used storage is computed from
+// the oldest utilization sample and the current utilization.
+func (h *Host) CapacityForecast() modules.HostCapacityForecast {
+	h.mu.RLock()
+	height := h.blockHeight
+	var oldest modules.HostUtilizationSample
+	sampled := len(h.utilizationSamples) > 0
+	if sampled {
+		oldest = h.utilizationSamples[0]
+	}
+	h.mu.RUnlock()
+
+	total, used := h.usedStorage()
+	cf := modules.HostCapacityForecast{
+		Height:           height,
+		TotalStorage:     total,
+		RemainingStorage: total - used,
+	}
+
+	// Compute the growth rate and the height at which the host is full.
+	if sampled && height > oldest.Height {
+		cf.SampledBlocks = height - oldest.Height
+		cf.GrowthRate = (int64(used) - int64(oldest.UsedStorage)) / int64(cf.SampledBlocks)
+	}
+	if cf.GrowthRate > 0 {
+		cf.FullHeight = height + types.BlockHeight(cf.RemainingStorage/uint64(cf.GrowthRate))
+	}
+
+	// Group the data of the unresolved obligations by the height at which
+	// it frees up. Obligations past their proof deadline free up their data
+	// with the next block.
+	expirations := make(map[types.BlockHeight]*modules.HostCapacityExpiration)
+	for _, so := range h.StorageObligations() {
+		if so.ObligationStatus != obligationUnresolved.String() {
+			continue
+		}
+		cf.CommittedStorage += so.DataSize
+		expiration := so.ProofDeadLine
+		if expiration <= height {
+			expiration = height + 1
+		}
+		e, exists := expirations[expiration]
+		if !exists {
+			e = &modules.HostCapacityExpiration{Height: expiration}
+			expirations[expiration] = e
+		}
+		e.Contracts++
+		e.FreedStorage += so.DataSize
+	}
+	for _, e := range expirations {
+		cf.Expirations = append(cf.Expirations, *e)
+	}
+	sort.Slice(cf.Expirations, func(i, j int) bool {
+		return cf.Expirations[i].Height < cf.Expirations[j].Height
+	})
+	remaining := cf.RemainingStorage
+	for i := range cf.Expirations {
+		remaining += cf.Expirations[i].FreedStorage
+		if remaining > total {
+			remaining = total
+		}
+		cf.Expirations[i].RemainingStorage = remaining
+	}
+	return cf
+}
@@ -0,0 +1,154 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCapacityForecast probes the host's capacity forecast.
+func TestCapacityForecast(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Initially there is no data and no obligation.
+	cf := ht.host.CapacityForecast()
+	if cf.TotalStorage == 0 || cf.RemainingStorage != cf.TotalStorage {
+		t.Fatal("unexpected storage", cf.TotalStorage, cf.RemainingStorage)
+	}
+	if cf.GrowthRate != 0 || cf.FullHeight != 0 {
+		t.Fatal("host shouldn't be growing", cf.GrowthRate, cf.FullHeight)
+	}
+	if cf.CommittedStorage != 0 || len(cf.Expirations) != 0 {
+		t.Fatal("host shouldn't have any obligations", cf.CommittedStorage, cf.Expirations)
+	}
+
+	// Store some data and mine a block to take a new sample. The host should
+	// be growing now.
+	for i := 0; i < 2; i++ {
+		root, data := randSector()
+		if err := ht.host.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	cf = ht.host.CapacityForecast()
+	if cf.RemainingStorage != cf.TotalStorage-2*modules.SectorSize {
+		t.Fatal("unexpected remaining storage", cf.RemainingStorage)
+	}
+	if cf.SampledBlocks == 0 || cf.GrowthRate <= 0 {
+		t.Fatal("host should be growing", cf.SampledBlocks, cf.GrowthRate)
+	}
+	if cf.FullHeight <= cf.Height {
+		t.Fatal("unexpected full height", cf.FullHeight, cf.Height)
+	}
+
+	// Add an obligation. Its data should free up at its proof deadline.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := randSector()
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:              so.id(),
+			NewRevisionNumber:     1,
+			NewFileSize:           modules.SectorSize,
+			NewFileMerkleRoot:     root,
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf = ht.host.CapacityForecast()
+	if cf.CommittedStorage != modules.SectorSize {
+		t.Fatal("unexpected committed storage", cf.CommittedStorage)
+	}
+	if len(cf.Expirations) != 1 {
+		t.Fatal("expected 1 expiration but got", len(cf.Expirations))
+	}
+	e := cf.Expirations[0]
+	if e.Height != so.proofDeadline() || e.Contracts != 1 || e.FreedStorage != modules.SectorSize {
+		t.Fatal("unexpected expiration", e)
+	}
+	if e.RemainingStorage != cf.RemainingStorage+modules.SectorSize {
+		t.Fatal("unexpected remaining storage after expiration", e.RemainingStorage)
+	}
+}
+
+// TestRecordUtilizationSample is a unit test for
+// managedRecordUtilizationSample.
+func TestRecordUtilizationSample(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	h := ht.host
+	h.mu.Lock()
+	h.utilizationSamples = nil
+	h.mu.Unlock()
+
+	// Samples are taken every utilizationSampleInterval blocks and limited to
+	// utilizationSamplesLimit.
+	var height types.BlockHeight
+	for i := 0; i < 2*utilizationSamplesLimit; i++ {
+		height = types.BlockHeight(i) * utilizationSampleInterval
+		h.managedRecordUtilizationSample(height)
+		h.managedRecordUtilizationSample(height)
+	}
+	h.mu.RLock()
+	samples := append([]modules.HostUtilizationSample{}, h.utilizationSamples...)
+	h.mu.RUnlock()
+	if len(samples) != utilizationSamplesLimit {
+		t.Fatalf("expected %v samples but got %v", utilizationSamplesLimit, len(samples))
+	}
+	if samples[len(samples)-1].Height != height {
+		t.Fatal("last sample should be at the current height")
+	}
+
+	// Samples of reverted blocks are dropped.
+	height -= 5 * utilizationSampleInterval
+	h.managedRecordUtilizationSample(height)
+	h.mu.RLock()
+	samples = append([]modules.HostUtilizationSample{}, h.utilizationSamples...)
+	h.mu.RUnlock()
+	if len(samples) != utilizationSamplesLimit-5 {
+		t.Fatalf("expected %v samples but got %v", utilizationSamplesLimit-5, len(samples))
+	}
+	if samples[len(samples)-1].Height != height {
+		t.Fatal("last sample should be at the current height")
+	}
+}
@@ -0,0 +1,117 @@
+package host
+
+import (
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// collateralForecastPeriod is the number of blocks the host looks back to
+	// estimate the demand for collateral and also the number of blocks it
+	// expects to see the same demand for in the future.
+	collateralForecastPeriod = build.Select(build.Var{
+		Dev:      types.BlockHeight(72),
+		Standard: types.BlockHeight(1008), // 1 week
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+)
+
+// collateralForecast is the host's forecast of whether its wallet can cover
+// the collateral of the contracts it expects to form within the next
+// collateralForecastPeriod blocks.
+type collateralForecast struct {
+	// available is the wallet's confirmed balance which is available for
+	// collateral.
+	available types.Currency
+
+	// demand is the collateral the host expects to lock up within the next
+	// period. It never exceeds the remaining collateral budget since the
+	// host doesn't lock up more collateral than that anyway.
+	demand types.Currency
+
+	// paused indicates that the available collateral doesn't cover the
+	// demand and isn't sufficient to collateralize a single sector for the
+	// forecast period either. A paused host stops accepting contracts until
+	// its wallet is funded again.
+	paused bool
+}
+
+// forecastCollateralDemand estimates the collateral the host locks up within
+// the next collateralForecastPeriod blocks. The estimate is the collateral
+// locked up by the obligations which were negotiated within the last period
+// but at least the max collateral of a single contract.
+func forecastCollateralDemand(sos []modules.StorageObligation, height types.BlockHeight, maxCollateral types.Currency) types.Currency {
+	demand := types.ZeroCurrency
+	for _, so := range sos {
+		if so.NegotiationHeight+collateralForecastPeriod <= height {
+			continue
+		}
+		demand = demand.Add(so.LockedCollateral)
+	}
+	if demand.Cmp(maxCollateral) < 0 {
+		demand = maxCollateral
+	}
+	return demand
+}
+
+// forecastMaxCollateral returns the max collateral the host advertises given
+// its collateral forecast. If the available collateral doesn't cover the
+// forecast demand, the max collateral is reduced proportionally to spread the
+// available collateral across the expected contracts.
+func (cf collateralForecast) forecastMaxCollateral(maxCollateral types.Currency) types.Currency {
+	if cf.paused {
+		return types.ZeroCurrency
+	}
+	if cf.demand.IsZero() || cf.available.Cmp(cf.demand) >= 0 {
+		return maxCollateral
+	}
+	return maxCollateral.Mul(cf.available).Div(cf.demand)
+}
+
+// managedUpdateCollateralForecast updates the host's collateral forecast and
+// registers an alert if the host's wallet is not expected to be able to
+// cover the collateral of its future contracts.
+func (h *Host) managedUpdateCollateralForecast(height types.BlockHeight) {
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	// Fetch the balance and obligations without holding the host's lock.
+	balance, _, _, err := h.wallet.ConfirmedBalance()
+	if err != nil {
+		h.log.Debugln("unable to fetch balance for collateral forecast:", err)
+		return
+	}
+	sos := h.StorageObligations()
+
+	h.mu.Lock()
+	settings := h.settings
+	remainingBudget := types.ZeroCurrency
+	if settings.CollateralBudget.Cmp(h.financialMetrics.LockedStorageCollateral) > 0 {
+		remainingBudget = settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
+	}
+	demand := forecastCollateralDemand(sos, height, settings.MaxCollateral)
+	if demand.Cmp(remainingBudget) > 0 {
+		demand = remainingBudget
+	}
+	minCollateral := settings.Collateral.Mul64(modules.SectorSize).Mul64(uint64(collateralForecastPeriod))
+	cf := collateralForecast{
+		available: balance,
+		demand:    demand,
+	}
+	cf.paused = cf.available.Cmp(cf.demand) < 0 && cf.available.Cmp(minCollateral) < 0
+	h.collateralForecast = cf
+	h.mu.Unlock()
+
+	// Only alert if the host is actually accepting contracts.
+	switch {
+	case !settings.AcceptingContracts || cf.available.Cmp(cf.demand) >= 0:
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostCollateralForecast)
+	case cf.paused:
+		h.staticAlerter.RegisterAlert(modules.AlertIDHostCollateralForecast, AlertMSGHostCollateralForecastPaused, "", modules.SeverityError)
+	default:
+		h.staticAlerter.RegisterAlert(modules.AlertIDHostCollateralForecast, AlertMSGHostCollateralForecastReduced, "", modules.SeverityWarning)
+	}
+}
@@ -0,0 +1,145 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestForecastCollateralDemand is a unit test for forecastCollateralDemand.
+func TestForecastCollateralDemand(t *testing.T) {
+	height := 2 * collateralForecastPeriod
+	sos := []modules.StorageObligation{
+		{NegotiationHeight: height - collateralForecastPeriod, LockedCollateral: types.NewCurrency64(100)}, // too old
+		{NegotiationHeight: height - collateralForecastPeriod + 1, LockedCollateral: types.NewCurrency64(20)},
+		{NegotiationHeight: height, LockedCollateral: types.NewCurrency64(30)},
+	}
+	if demand := forecastCollateralDemand(sos, height, types.NewCurrency64(10)); !demand.Equals64(50) {
+		t.Fatal("unexpected demand", demand)
+	}
+	// The demand is at least the max collateral of a single contract.
+	if demand := forecastCollateralDemand(sos, height, types.NewCurrency64(60)); !demand.Equals64(60) {
+		t.Fatal("unexpected demand", demand)
+	}
+	if demand := forecastCollateralDemand(nil, height, types.NewCurrency64(60)); !demand.Equals64(60) {
+		t.Fatal("unexpected demand", demand)
+	}
+}
+
+// TestForecastMaxCollateral is a unit test for forecastMaxCollateral.
+func TestForecastMaxCollateral(t *testing.T) {
+	maxCollateral := types.NewCurrency64(100)
+	tests := []struct {
+		cf     collateralForecast
+		result uint64
+	}{
+		{collateralForecast{}, 100},
+		{collateralForecast{available: types.NewCurrency64(50), demand: types.NewCurrency64(50)}, 100},
+		{collateralForecast{available: types.NewCurrency64(50), demand: types.NewCurrency64(200)}, 25},
+		{collateralForecast{available: types.NewCurrency64(50), demand: types.NewCurrency64(200), paused: true}, 0},
+	}
+	for i, test := range tests {
+		if mc := test.cf.forecastMaxCollateral(maxCollateral); !mc.Equals64(test.result) {
+			t.Errorf("%v: expected %v but got %v", i, test.result, mc)
+		}
+	}
+}
+
+// TestManagedUpdateCollateralForecast checks that the host reduces its
+// advertised max collateral and eventually stops accepting contracts when its
+// wallet can't cover the collateral of its future contracts.
+func TestManagedUpdateCollateralForecast(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// hasAlert returns the severity of the collateral forecast alert.
+	hasAlert := func() (modules.AlertSeverity, bool) {
+		crit, err, warn := ht.host.Alerts()
+		for _, alert := range append(append(crit, err...), warn...) {
+			if alert.Msg == AlertMSGHostCollateralForecastReduced || alert.Msg == AlertMSGHostCollateralForecastPaused {
+				return alert.Severity, true
+			}
+		}
+		return 0, false
+	}
+
+	// With the default settings the wallet covers the demand.
+	is := ht.host.InternalSettings()
+	is.AcceptingContracts = true
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	height := ht.host.BlockHeight()
+	ht.host.managedUpdateCollateralForecast(height)
+	if _, found := hasAlert(); found {
+		t.Fatal("there shouldn't be an alert")
+	}
+	es := ht.host.managedExternalSettings()
+	if !es.AcceptingContracts || !es.MaxCollateral.Equals(is.MaxCollateral) {
+		t.Fatal("unexpected settings", es.AcceptingContracts, es.MaxCollateral)
+	}
+
+	// Raise the max collateral above the wallet's balance. The host can still
+	// cover a contract with a reduced max collateral but warns about the
+	// shortfall.
+	balance, _, _, err := ht.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultCollateral := is.Collateral
+	is.MaxCollateral = balance.Mul64(2)
+	is.CollateralBudget = balance.Mul64(10)
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUpdateCollateralForecast(height)
+	if severity, found := hasAlert(); !found || severity != modules.SeverityWarning {
+		t.Fatal("expected warning", severity, found)
+	}
+	es = ht.host.managedExternalSettings()
+	if !es.AcceptingContracts || es.MaxCollateral.Cmp(balance) > 0 || es.MaxCollateral.IsZero() {
+		t.Fatal("unexpected settings", es.AcceptingContracts, es.MaxCollateral, balance)
+	}
+
+	// Raise the collateral so that the balance doesn't cover a single sector
+	// for the forecast period. The host stops accepting contracts.
+	is.Collateral = balance.Div64(modules.SectorSize).Div64(uint64(collateralForecastPeriod)).Add64(1)
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUpdateCollateralForecast(height)
+	if severity, found := hasAlert(); !found || severity != modules.SeverityError {
+		t.Fatal("expected error", severity, found)
+	}
+	es = ht.host.managedExternalSettings()
+	if es.AcceptingContracts || !es.MaxCollateral.IsZero() {
+		t.Fatal("unexpected settings", es.AcceptingContracts, es.MaxCollateral)
+	}
+
+	// Lowering the max collateral below the balance resumes accepting
+	// contracts.
+	is.Collateral = defaultCollateral
+	is.MaxCollateral = balance.Div64(2)
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUpdateCollateralForecast(height)
+	if _, found := hasAlert(); found {
+		t.Fatal("alert should have been unregistered")
+	}
+	if es = ht.host.managedExternalSettings(); !es.AcceptingContracts || !es.MaxCollateral.Equals(is.MaxCollateral) {
+		t.Fatal("host should accept contracts again", es.AcceptingContracts, es.MaxCollateral)
+	}
+}
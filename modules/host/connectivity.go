@@ -0,0 +1,103 @@
+package host
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errAnnUnreachable is returned during a host announcement if the peers
+	// were unable to reach the host at the announced address.
+	errAnnUnreachable = errors.New("peers were unable to reach the host at the announced address")
+
+	// minReachabilityChecks is the minimum number of peers which need to
+	// check the host's address for the result to be conclusive.
+	minReachabilityChecks = build.Select(build.Var{
+		Standard: 2,
+		Dev:      1,
+		Testing:  1,
+	}).(int)
+)
+
+// CheckConnectivity asks gateway peers to connect to the address the host
+// would announce and reports whether renters can reach the host.
+func (h *Host) CheckConnectivity() (modules.HostConnectivity, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostConnectivity{}, err
+	}
+	defer h.tg.Done()
+	addr, err := h.managedAnnouncementAddress()
+	if err != nil {
+		return modules.HostConnectivity{}, err
+	}
+	return h.managedCheckConnectivity(addr), nil
+}
+
+// managedCheckConnectivity asks gateway peers to open a session with the host
+// at the given address.
+func (h *Host) managedCheckConnectivity(addr modules.NetAddress) modules.HostConnectivity {
+	reports := h.g.CheckReachability(addr, h.PublicKey())
+	var checked, reachable int
+	for _, r := range reports {
+		if r.Checked {
+			checked++
+		}
+		if r.Reachable {
+			reachable++
+		}
+	}
+	hc := modules.HostConnectivity{
+		NetAddress: addr,
+		Reachable:  reachable > 0,
+		Conclusive: reachable > 0 || checked >= minReachabilityChecks,
+		Reports:    reports,
+		Problems:   connectivityProblems(addr, reports),
+	}
+	if addr.IsLocal() {
+		hc.Problems = append(hc.Problems, fmt.Sprintf("%v is a local address which renters outside of this network can't reach", addr))
+	}
+	return hc
+}
+
+// connectivityProblems turns the errors reported by peers into a list of
+// problems with instructions on how to fix them.
+func connectivityProblems(addr modules.NetAddress, reports []modules.ReachabilityReport) []string {
+	if len(reports) == 0 {
+		return []string{"no gateway peers are connected, connect to peers to check the host's connectivity"}
+	}
+	var problems []string
+	seen := make(map[string]struct{})
+	add := func(problem string) {
+		if _, exists := seen[problem]; !exists {
+			seen[problem] = struct{}{}
+			problems = append(problems, problem)
+		}
+	}
+	for _, r := range reports {
+		if r.Reachable {
+			continue
+		}
+		switch {
+		case strings.Contains(r.Error, modules.ErrReachabilityAddressMismatch.Error()):
+			add(fmt.Sprintf("%v doesn't resolve to the public IP of this node, update the DNS record of the address or the host's netaddress setting", addr.Host()))
+		case strings.Contains(r.Error, modules.ErrReachabilityWrongHost.Error()):
+			add(fmt.Sprintf("a different service answered at %v, make sure port %v is forwarded to this machine", addr, addr.Port()))
+		case strings.Contains(r.Error, "connection refused"):
+			add(fmt.Sprintf("connections to %v were refused, make sure the host is listening on port %v and that the port is forwarded to this machine", addr, addr.Port()))
+		case strings.Contains(r.Error, "timeout"):
+			add(fmt.Sprintf("connections to %v timed out, a firewall or a missing port forwarding rule is likely dropping incoming connections on port %v", addr, addr.Port()))
+		case strings.Contains(r.Error, "no such host"):
+			add(fmt.Sprintf("%v couldn't be resolved, check the DNS record of the address", addr.Host()))
+		case !r.Checked:
+			add(fmt.Sprintf("peer %v was unable to check the address: %v", r.Peer, r.Error))
+		default:
+			add(fmt.Sprintf("peer %v couldn't open a session with the host: %v", r.Peer, r.Error))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,138 @@
+package host
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+)
+
+// dependencyCheckConnectivity is a dependency that enables the connectivity
+// check before announcing in testing.
+type dependencyCheckConnectivity struct {
+	modules.ProductionDependencies
+}
+
+// Disrupt returns true for the "CheckConnectivityBeforeAnnounce" string.
+func (*dependencyCheckConnectivity) Disrupt(s string) bool {
+	return s == "CheckConnectivityBeforeAnnounce"
+}
+
+// TestHostCheckConnectivity checks that the host reports whether its peers can
+// reach it and refuses to announce unreachable addresses.
+func TestHostCheckConnectivity(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newMockHostTester(&dependencyCheckConnectivity{}, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without peers the check is inconclusive.
+	hc, err := ht.host.CheckConnectivity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hc.Conclusive || len(hc.Reports) != 0 || len(hc.Problems) == 0 {
+		t.Fatal("check without peers should be inconclusive", hc)
+	}
+
+	// Connect a peer which can check the host.
+	g, err := gateway.New("localhost:0", false, filepath.Join(ht.persistDir, "peer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := ht.gateway.Connect(g.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The host should be reachable at its IP.
+	hostAddr := modules.NetAddress(net.JoinHostPort("127.0.0.1", ht.host.ExternalSettings().NetAddress.Port()))
+	settings := ht.host.InternalSettings()
+	settings.NetAddress = hostAddr
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	hc, err = ht.host.CheckConnectivity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hc.Conclusive || !hc.Reachable || len(hc.Reports) != 1 {
+		t.Fatal("host should be reachable", hc)
+	}
+	if r := hc.Reports[0]; r.Peer != g.Address() || !r.Checked || r.Latency <= 0 || r.Error != "" {
+		t.Fatal("unexpected report", r)
+	}
+
+	// Point the host's netaddress to a closed port.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := modules.NetAddress(l.Addr().String())
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	settings.NetAddress = closedAddr
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	hc, err = ht.host.CheckConnectivity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hc.Conclusive || hc.Reachable || hc.NetAddress != closedAddr {
+		t.Fatal("host shouldn't be reachable", hc)
+	}
+	if len(hc.Problems) == 0 || !strings.Contains(hc.Problems[0], "refused") {
+		t.Fatal("expected problem about refused connections", hc.Problems)
+	}
+
+	// Announcing the unreachable address should fail.
+	err = ht.host.Announce()
+	if !errors.Contains(err, errAnnUnreachable) {
+		t.Fatal("expected errAnnUnreachable, got", err)
+	}
+}
+
+// TestConnectivityProblems is a unit test for connectivityProblems.
+func TestConnectivityProblems(t *testing.T) {
+	addr := modules.NetAddress("example.com:9982")
+	reports := []modules.ReachabilityReport{
+		{Checked: true, Reachable: true},
+		{Checked: true, Error: "dial tcp 1.2.3.4:9982: connect: connection refused"},
+		{Checked: true, Error: "dial tcp 1.2.3.4:9982: connect: connection refused"},
+		{Checked: true, Error: "dial tcp 1.2.3.4:9982: i/o timeout"},
+		{Error: modules.ErrReachabilityAddressMismatch.Error()},
+		{Checked: true, Error: modules.ErrReachabilityWrongHost.Error()},
+	}
+	problems := connectivityProblems(addr, reports)
+	if len(problems) != 4 {
+		t.Fatal("expected 4 problems, got", problems)
+	}
+	for i, substr := range []string{"refused", "timed out", "doesn't resolve", "different service"} {
+		if !strings.Contains(problems[i], substr) {
+			t.Errorf("problem %v should contain %q: %v", i, substr, problems[i])
+		}
+	}
+	if problems := connectivityProblems(addr, nil); len(problems) != 1 {
+		t.Fatal("expected a problem without reports", problems)
+	}
+}
@@ -12,9 +12,22 @@ const (
 	// AlertMSGHostInsufficientCollateral indicates that a host has insufficient
 	// collateral budget remaining
 	AlertMSGHostInsufficientCollateral = "host has insufficient collateral budget"
+
+	// AlertMSGHostCollateralForecastReduced indicates that a host reduced its
+	// advertised max collateral since its wallet is not expected to cover the
+	// collateral of its future contracts.
+	AlertMSGHostCollateralForecastReduced = "host reduced its max collateral since its wallet is running low on funds"
+
+	// AlertMSGHostCollateralForecastPaused indicates that a host stopped
+	// accepting contracts since its wallet can't cover any more collateral.
+	AlertMSGHostCollateralForecastPaused = "host stopped accepting contracts since its wallet can't cover any more collateral"
 )
 
 const (
+	// bandwidthShapingInterval is the interval at which the host updates its
+	// bandwidth limits and burst allowance.
+	bandwidthShapingInterval = time.Second
+
 	// iteratedConnectionTime is the amount of time that is allowed to pass
 	// before the host will stop accepting new iterations on an iterated
 	// connection.
@@ -0,0 +1,144 @@
+package contractmanager
+
+import (
+	"sync/atomic"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// managedCompactSector reduces the number of virtual sectors of a sector to
+// the provided count. If the count is zero, the physical sector is removed.
+// Sectors which already have at most count virtual sectors aren't changed.
+// The number of removed virtual sectors is returned.
+func (wal *writeAheadLog) managedCompactSector(id sectorID, count uint64) (removed uint64, err error) {
+	var location sectorLocation
+	var su sectorUpdate
+	var sf *storageFolder
+	var syncChan chan struct{}
+	err = func() error {
+		wal.mu.Lock()
+		defer wal.mu.Unlock()
+
+		var exists bool
+		location, exists = wal.cm.sectorLocations[id]
+		if !exists {
+			return ErrSectorNotFound
+		}
+		if location.count <= count {
+			return nil
+		}
+		sf, exists = wal.cm.storageFolders[location.storageFolder]
+		if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+			return errStorageFolderNotFound
+		}
+
+		// Inform the WAL of the sector update.
+		removed = location.count - count
+		location.count = count
+		su = sectorUpdate{
+			Count:  location.count,
+			ID:     id,
+			Folder: location.storageFolder,
+			Index:  location.index,
+		}
+		wal.appendChange(stateChange{
+			SectorUpdates: []sectorUpdate{su},
+		})
+
+		// Update the in-memory representation of the sector.
+		if location.count == 0 {
+			delete(wal.cm.sectorLocations, id)
+			sf.availableSectors[id] = location.index
+		} else {
+			wal.cm.sectorLocations[id] = location
+		}
+		syncChan = wal.syncChan
+		return nil
+	}()
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+	// Synchronize before updating the metadata or clearing the usage.
+	<-syncChan
+
+	// Update the metadata or, if the sector was removed, the usage. Like in
+	// managedRemoveSector, the usage is only cleared after the removal was
+	// committed to prevent the sector data from being overwritten in the
+	// event of an unclean shutdown.
+	if location.count != 0 {
+		err = wal.writeSectorMetadata(sf, su)
+		if err != nil {
+			// Revert the previous change.
+			wal.mu.Lock()
+			su.Count += removed
+			location.count += removed
+			wal.appendChange(stateChange{
+				SectorUpdates: []sectorUpdate{su},
+			})
+			wal.cm.sectorLocations[id] = location
+			wal.mu.Unlock()
+			return 0, build.ExtendErr("failed to write sector metadata", err)
+		}
+		return removed, nil
+	}
+	wal.mu.Lock()
+	sf.clearUsage(location.index)
+	delete(sf.availableSectors, id)
+	wal.mu.Unlock()
+	return removed, nil
+}
+
+// CompactSectors reduces the number of virtual sectors of every stored sector
+// to the number of references provided for its root and removes the sectors
+// without any references.
+func (cm *ContractManager) CompactSectors(references map[crypto.Hash]uint64) (sc modules.SectorCompaction, err error) {
+	err = cm.tg.Add()
+	if err != nil {
+		return modules.SectorCompaction{}, err
+	}
+	defer cm.tg.Done()
+
+	expected := make(map[sectorID]uint64, len(references))
+	for root, count := range references {
+		expected[cm.managedSectorID(root)] += count
+	}
+
+	// Find the sectors which have more virtual sectors than references.
+	var stale []sectorID
+	cm.wal.mu.Lock()
+	for id, location := range cm.sectorLocations {
+		if location.count > expected[id] {
+			stale = append(stale, id)
+		} else {
+			sc.MissingReferences += expected[id] - location.count
+		}
+	}
+	for id, count := range expected {
+		if _, exists := cm.sectorLocations[id]; !exists {
+			sc.MissingReferences += count
+		}
+	}
+	cm.wal.mu.Unlock()
+
+	// Compact the sectors one at a time. The counts are checked again under
+	// the sector lock.
+	for _, id := range stale {
+		removed, err := func() (uint64, error) {
+			cm.wal.managedLockSector(id)
+			defer cm.wal.managedUnlockSector(id)
+			return cm.wal.managedCompactSector(id, expected[id])
+		}()
+		if err != nil && err != ErrSectorNotFound {
+			cm.log.Println("ERROR: unable to compact sector:", err)
+			return sc, err
+		}
+		sc.ReferencesRemoved += removed
+		if removed > 0 && expected[id] == 0 {
+			sc.SectorsReclaimed++
+			sc.BytesReclaimed += modules.SectorSize
+		}
+	}
+	return sc, nil
+}
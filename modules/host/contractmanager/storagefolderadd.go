@@ -83,6 +83,26 @@ func (wal *writeAheadLog) cleanupUnfinishedStorageFolderAdditions(scs []stateCha
 	}
 }
 
+// freeStorageFolderIndex returns an index which isn't used by any storage
+// folder yet. freeStorageFolderIndex must be called while holding the WAL
+// lock.
+func (wal *writeAheadLog) freeStorageFolderIndex() (uint16, error) {
+	// Determine the index of the storage folder by scanning for an empty spot
+	// in the folderLocations map. A random starting place is chosen to keep
+	// good average and worst-case runtime.
+	index := uint16(fastrand.Intn(65536))
+	for iterator := 0; iterator < 65536; iterator++ {
+		// check the list of unique folders we created earlier.
+		_, exists := wal.cm.storageFolders[index]
+		if !exists {
+			return index, nil
+		}
+		index++
+	}
+	wal.cm.log.Critical("Previous check indicated that there was room to add another storage folder, but folderLocations set is full.")
+	return 0, errMaxStorageFolders
+}
+
 // managedAddStorageFolder will add a storage folder to the contract manager.
 // The parent function, contractmanager.AddStorageFolder, has already performed
 // any error checking that can be performed without accessing the contract
@@ -130,28 +150,14 @@ func (wal *writeAheadLog) managedAddStorageFolder(sf *storageFolder) error {
 			return errMaxStorageFolders
 		}
 
-		// Determine the index of the storage folder by scanning for an empty
-		// spot in the folderLocations map. A random starting place is chosen
-		// to keep good average and worst-case runtime.
-		var iterator int
-		index := uint16(fastrand.Intn(65536))
-		for iterator = 0; iterator < 65536; iterator++ {
-			// check the list of unique folders we created earlier.
-			_, exists := wal.cm.storageFolders[index]
-			if !exists {
-				break
-			}
-			index++
-		}
-		if iterator == 65536 {
-			wal.cm.log.Critical("Previous check indicated that there was room to add another storage folder, but folderLocations set is full.")
-			return errMaxStorageFolders
+		// Assign an empty index to the storage folder.
+		index, err := wal.freeStorageFolderIndex()
+		if err != nil {
+			return err
 		}
-		// Assign the empty index to the storage folder.
 		sf.index = index
 
 		// Create the files that get used with the storage folder.
-		var err error
 		sf.metadataFile, err = wal.cm.dependencies.CreateFile(sectorLookupName)
 		if err != nil {
 			return build.ExtendErr("could not create storage folder file", err)
@@ -0,0 +1,197 @@
+package contractmanager
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// errNoStorageFolderFiles is returned if a storage folder is recovered from a
+// folder which doesn't contain the files of a storage folder.
+var errNoStorageFolderFiles = errors.New("folder doesn't contain the sector and metadata files of a storage folder")
+
+// recoverableSectors returns the number of sectors a storage folder with the
+// provided files can hold.
+func recoverableSectors(metadata, sectors modules.File) (uint64, error) {
+	metadataInfo, err := metadata.Stat()
+	if err != nil {
+		return 0, err
+	}
+	sectorInfo, err := sectors.Stat()
+	if err != nil {
+		return 0, err
+	}
+	numSectors := uint64(sectorInfo.Size()) / modules.SectorSize
+	if n := uint64(metadataInfo.Size()) / sectorMetadataDiskSize; n < numSectors {
+		numSectors = n
+	}
+	numSectors -= numSectors % storageFolderGranularity
+	if numSectors > MaximumSectorsPerStorageFolder {
+		return 0, ErrLargeStorageFolder
+	}
+	if numSectors < MinimumSectorsPerStorageFolder {
+		return 0, ErrSmallStorageFolder
+	}
+	return numSectors, nil
+}
+
+// managedRecoverSectorLocations rebuilds the sector locations of a storage
+// folder from its files. The contract manager's settings might have been lost
+// together with the sector salt, so the metadata can't be trusted. Instead,
+// every slot with a non-zero counter is read and its sector ID is derived from
+// the Merkle root of the data. The metadata is updated to match the derived
+// IDs. Removing the last instance of a sector doesn't reset its counter, so
+// sectors which were removed but not overwritten yet are recovered as well.
+func (cm *ContractManager) managedRecoverSectorLocations(sf *storageFolder) (map[sectorID]sectorLocation, error) {
+	numSectors := len(sf.usage) * storageFolderGranularity
+	sectorLookupBytes, err := readFullMetadata(sf.metadataFile, numSectors)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[sectorID]sectorLocation)
+	for index := 0; index < numSectors; index++ {
+		readHead := sectorMetadataDiskSize * index
+		count := binary.LittleEndian.Uint16(sectorLookupBytes[readHead+12 : readHead+14])
+		if count == 0 {
+			continue
+		}
+		data, err := readSector(sf.sectorFile, uint32(index))
+		if err != nil {
+			return nil, build.ExtendErr("unable to read sector", err)
+		}
+		id := cm.managedSectorID(crypto.MerkleRoot(data))
+		if _, exists := locations[id]; exists {
+			// A stale copy of a sector which was removed and added again.
+			continue
+		}
+		var storedID sectorID
+		copy(storedID[:], sectorLookupBytes[readHead:readHead+12])
+		if storedID != id {
+			err = writeSectorMetadata(sf.metadataFile, uint32(index), id, count)
+			if err != nil {
+				return nil, err
+			}
+		}
+		sf.setUsage(uint32(index))
+		locations[id] = sectorLocation{
+			index: uint32(index),
+			count: uint64(count),
+		}
+	}
+	return locations, sf.metadataFile.Sync()
+}
+
+// RecoverStorageFolder adds an existing storage folder, e.g. one which was in
+// use before the contract manager's persist files were lost, to the contract
+// manager. The sectors of the folder are recovered from its files and
+// available afterwards. The number of recovered sectors is returned.
+func (cm *ContractManager) RecoverStorageFolder(path string) (recovered uint64, err error) {
+	err = cm.tg.Add()
+	if err != nil {
+		return 0, err
+	}
+	defer cm.tg.Done()
+
+	// Check that the path is an absolute path to a folder.
+	if !filepath.IsAbs(path) {
+		return 0, errRelativePath
+	}
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !pathInfo.Mode().IsDir() {
+		return 0, errStorageFolderNotFolder
+	}
+
+	// Open the files of the storage folder.
+	sf := &storageFolder{
+		path:             path,
+		availableSectors: make(map[sectorID]uint32),
+	}
+	sf.metadataFile, err = cm.dependencies.OpenFile(filepath.Join(path, metadataFile), os.O_RDWR, 0700)
+	if os.IsNotExist(err) {
+		return 0, errNoStorageFolderFiles
+	} else if err != nil {
+		return 0, build.ExtendErr("unable to open the sector metadata file", err)
+	}
+	sf.sectorFile, err = cm.dependencies.OpenFile(filepath.Join(path, sectorFile), os.O_RDWR, 0700)
+	if os.IsNotExist(err) {
+		return 0, errors.Compose(errNoStorageFolderFiles, sf.metadataFile.Close())
+	} else if err != nil {
+		err = build.ExtendErr("unable to open the sector file", err)
+		return 0, errors.Compose(err, sf.metadataFile.Close())
+	}
+	var added bool
+	defer func() {
+		if !added {
+			err = errors.Compose(err, sf.metadataFile.Close(), sf.sectorFile.Close())
+		}
+	}()
+
+	numSectors, err := recoverableSectors(sf.metadataFile, sf.sectorFile)
+	if err != nil {
+		return 0, err
+	}
+	sf.usage = make([]uint64, numSectors/storageFolderGranularity)
+	locations, err := cm.managedRecoverSectorLocations(sf)
+	if err != nil {
+		cm.log.Printf("ERROR: unable to recover the sectors of storage folder %v: %v\n", path, err)
+		return 0, err
+	}
+
+	// Add the storage folder and its sectors to the contract manager.
+	var syncChan chan struct{}
+	err = func() error {
+		cm.wal.mu.Lock()
+		defer cm.wal.mu.Unlock()
+
+		for _, csf := range cm.storageFolders {
+			if sf.path == csf.path {
+				return ErrRepeatFolder
+			}
+		}
+		if uint64(len(cm.storageFolders)) > maximumStorageFolders {
+			return errMaxStorageFolders
+		}
+		index, err := cm.wal.freeStorageFolderIndex()
+		if err != nil {
+			return err
+		}
+		sf.index = index
+
+		for id, location := range locations {
+			// Sectors which are already stored in another storage folder are
+			// dropped from the recovered folder.
+			if _, exists := cm.sectorLocations[id]; exists {
+				sf.clearUsage(location.index)
+				continue
+			}
+			location.storageFolder = sf.index
+			cm.sectorLocations[id] = location
+		}
+		recovered = sf.sectors
+		cm.storageFolders[sf.index] = sf
+		cm.wal.appendChange(stateChange{
+			StorageFolderAdditions: []savedStorageFolder{sf.savedStorageFolder()},
+		})
+		syncChan = cm.wal.syncChan
+		return nil
+	}()
+	if err != nil {
+		return 0, err
+	}
+	added = true
+
+	// Wait until the storage folder addition has been committed.
+	<-syncChan
+	cm.log.Printf("Recovered storage folder %v with %v sectors\n", path, recovered)
+	return recovered, nil
+}
@@ -0,0 +1,120 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestRecoverStorageFolder checks that a storage folder can be recovered by a
+// contract manager which lost its persist files.
+func TestRecoverStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a storage folder with a physical sector, a virtual sector and a
+	// removed sector.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	if err := os.MkdirAll(storageFolderDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*storageFolderGranularity); err != nil {
+		t.Fatal(err)
+	}
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	root3, data3 := randSector()
+	for _, err := range []error{
+		cmt.cm.AddSector(root1, data1),
+		cmt.cm.AddSector(root2, data2),
+		cmt.cm.AddSector(root2, data2),
+		cmt.cm.AddSector(root3, data3),
+		cmt.cm.RemoveSector(root3),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Start a new contract manager without the persist files.
+	cm, err := New(filepath.Join(cmt.persistDir, "recovered"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.RecoverStorageFolder("relative"); !errors.Contains(err, errRelativePath) {
+		t.Fatal("expected errRelativePath", err)
+	}
+	if _, err := cm.RecoverStorageFolder(cmt.persistDir); !errors.Contains(err, errNoStorageFolderFiles) {
+		t.Fatal("expected errNoStorageFolderFiles", err)
+	}
+	recovered, err := cm.RecoverStorageFolder(storageFolderDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The removed sector wasn't overwritten, so it is recovered too.
+	if recovered != 3 {
+		t.Fatal("wrong number of recovered sectors", recovered)
+	}
+	if _, err := cm.RecoverStorageFolder(storageFolderDir); !errors.Contains(err, ErrRepeatFolder) {
+		t.Fatal("expected ErrRepeatFolder", err)
+	}
+	sfs := cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Capacity != modules.SectorSize*storageFolderGranularity || sfs[0].CapacityRemaining != sfs[0].Capacity-3*modules.SectorSize {
+		t.Fatal("wrong storage folders", sfs)
+	}
+
+	// The recovered folder should survive a restart.
+	if err := cm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cm, err = New(filepath.Join(cmt.persistDir, "recovered"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cm.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for _, sector := range []struct {
+		root crypto.Hash
+		data []byte
+	}{{root1, data1}, {root2, data2}} {
+		data, err := cm.ReadSector(sector.root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, sector.data) {
+			t.Fatal("recovered sector has wrong data")
+		}
+	}
+
+	// The virtual sector has to be removed twice.
+	if err := cm.RemoveSector(root2); err != nil {
+		t.Fatal(err)
+	}
+	if !cm.HasSector(root2) {
+		t.Fatal("virtual sector count wasn't recovered")
+	}
+	if err := cm.RemoveSector(root2); err != nil {
+		t.Fatal(err)
+	}
+	if cm.HasSector(root2) {
+		t.Fatal("sector wasn't removed")
+	}
+}
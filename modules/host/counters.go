@@ -0,0 +1,17 @@
+package host
+
+import (
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// operationRPC returns the name of the operation counter of an RPC.
+func operationRPC(id types.Specifier) string {
+	return "rpc/" + id.String()
+}
+
+// OperationCounters implements the modules.OperationCounter interface for the
+// host.
+func (h *Host) OperationCounters() map[string]modules.OperationStats {
+	return h.staticOperationCounters.Stats()
+}
@@ -155,6 +155,10 @@ type Host struct {
 	staticAlerter *modules.GenericAlerter
 	staticMux     *siamux.SiaMux
 	dependencies  modules.Dependencies
+
+	// staticIdentity is the identity of the node which manages the host key.
+	// It is nil if the host manages its own key.
+	staticIdentity modules.Identity
 	modules.StorageManager
 
 	// Subsystems
@@ -162,6 +166,7 @@ type Host struct {
 	staticMDM                   *mdm.MDM
 	staticRegistry              *registry.Registry
 	staticRegistrySubscriptions *registrySubscriptions
+	staticIngressProofs         *ingressProofSet
 
 	// Host ACID fields - these fields need to be updated in serial, ACID
 	// transactions.
@@ -181,11 +186,48 @@ type Host struct {
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
 
+	// settingsHistory records the changes to the internal settings and
+	// scheduledSettings contains the settings changes which will be applied
+	// at a future block height. applyingScheduledSettings is set while due
+	// changes are being applied.
+	settingsHistory           []modules.HostSettingsChange
+	scheduledSettings         []modules.HostScheduledSettings
+	applyingScheduledSettings bool
+
+	// utilizationSamples contains periodic samples of the used storage which
+	// are used to forecast the host's capacity.
+	utilizationSamples []modules.HostUtilizationSample
+
+	// maturingPayouts contains the payouts of succeeded storage obligations
+	// which are locked in delayed siacoin outputs that haven't matured yet.
+	maturingPayouts []modules.HostMaturingPayout
+
+	// payoutSettings contains the addresses the host's contract payouts are
+	// sent to. payoutRotation is the number of contracts which paid the
+	// advertised address of the rotate policy, payoutTotals contains the
+	// payouts per address since the settings were changed and payoutHistory
+	// records the most recent payouts.
+	payoutSettings modules.HostPayoutSettings
+	payoutRotation uint64
+	payoutTotals   []modules.HostPayoutTotal
+	payoutHistory  []modules.HostPayoutRecord
+
+	// collateralForecast is the forecast of whether the host's wallet can
+	// cover the collateral of its future contracts.
+	collateralForecast collateralForecast
+
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*lockedObligation
 
+	// compactMu is held for reading while the sectors of a storage
+	// obligation are added to or removed from the storage manager and the
+	// obligation is updated in the database. Compacting the sectors holds it
+	// for writing to see a consistent view of the database and the storage
+	// manager. It has to be acquired before the host's mutex.
+	compactMu sync.RWMutex
+
 	// A collection of rpc price tables, covered by its own RW mutex. It
 	// contains the host's current price table and the set of price tables the
 	// host has communicated to all renters, thus guaranteeing a set of prices
@@ -194,6 +236,21 @@ type Host struct {
 	// of such conditions are congestion, load, liquidity, etc.
 	staticPriceTables *hostPrices
 
+	// staticBandwidthShaper limits the bandwidth used by the host's
+	// connections and streams.
+	staticBandwidthShaper *bandwidthShaper
+
+	// staticSectorAudit records the sector accesses of renters if the sector
+	// audit log is enabled in the internal settings.
+	staticSectorAudit *sectorAuditLog
+
+	// staticSectorCache contains the sectors which were prefetched after
+	// renters sent prefetch hints.
+	staticSectorCache *sectorCache
+
+	// staticOperationCounters counts the RPCs the host handles.
+	staticOperationCounters *modules.OperationCounters
+
 	// Fields related to RHP3 bandwidhth.
 	atomicStreamUpload   uint64
 	atomicStreamDownload uint64
@@ -424,7 +481,7 @@ func (h *Host) threadedPruneExpiredPriceTables() {
 // mocked such that the dependencies can return unexpected errors or unique
 // behaviors during testing, enabling easier testing of the failure modes of
 // the Host.
-func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPool, wallet modules.Wallet, mux *siamux.SiaMux, listenerAddress string, persistDir string) (_ *Host, err error) {
+func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPool, wallet modules.Wallet, mux *siamux.SiaMux, id modules.Identity, listenerAddress string, persistDir string) (_ *Host, err error) {
 	// Check that all the dependencies were provided.
 	if cs == nil {
 		return nil, errNilCS
@@ -447,6 +504,7 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		wallet:                   wallet,
 		staticAlerter:            modules.NewAlerter("host"),
 		staticMux:                mux,
+		staticIdentity:           id,
 		dependencies:             dependencies,
 		lockedStorageObligations: make(map[types.FileContractID]*lockedObligation),
 		staticPriceTables: &hostPrices{
@@ -455,7 +513,11 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 				heap: make([]*hostRPCPriceTable, 0),
 			},
 		},
+		staticBandwidthShaper:       newBandwidthShaper(),
+		staticSectorAudit:           newSectorAuditLog(filepath.Join(persistDir, modules.HostSectorAuditFile)),
+		staticSectorCache:           newSectorCache(sectorCacheSize, maxConcurrentPrefetches),
 		staticRegistrySubscriptions: newRegistrySubscriptions(),
+		staticIngressProofs:         newIngressProofSet(),
 		persistDir:                  persistDir,
 	}
 
@@ -505,12 +567,30 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		}
 	})
 
+	// Load the operation counters and save them on shutdown.
+	h.staticOperationCounters, err = modules.NewOperationCounters(filepath.Join(h.persistDir, modules.OperationCountersFilename))
+	if err != nil {
+		return nil, err
+	}
+	h.tg.AfterStop(func() {
+		err := h.staticOperationCounters.Save()
+		if err != nil {
+			h.log.Println("Could not save operation counters upon shutdown:", err)
+		}
+	})
+
 	// Load the prior persistence structures, and configure the host to save
 	// before shutting down.
 	err = h.load()
 	if err != nil {
 		return nil, err
 	}
+	if h.staticIdentity != nil {
+		err = h.loadIdentityKey()
+		if err != nil {
+			return nil, err
+		}
+	}
 	h.tg.AfterStop(func() {
 		err := h.saveSync()
 		if err != nil {
@@ -561,24 +641,28 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Periodically apply the bandwidth schedule and burst allowance.
+	go h.threadedShapeBandwidth()
+
 	return h, nil
 }
 
 // New returns an initialized Host.
 func New(cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPool, wallet modules.Wallet, mux *siamux.SiaMux, address string, persistDir string) (*Host, error) {
-	return newHost(modules.ProdDependencies, new(modules.ProductionDependencies), cs, g, tpool, wallet, mux, address, persistDir)
+	return newHost(modules.ProdDependencies, new(modules.ProductionDependencies), cs, g, tpool, wallet, mux, nil, address, persistDir)
 }
 
 // NewCustomHost returns an initialized Host using the provided dependencies.
 func NewCustomHost(deps modules.Dependencies, cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPool, wallet modules.Wallet, mux *siamux.SiaMux, address string, persistDir string) (*Host, error) {
-	return newHost(deps, new(modules.ProductionDependencies), cs, g, tpool, wallet, mux, address, persistDir)
+	return newHost(deps, new(modules.ProductionDependencies), cs, g, tpool, wallet, mux, nil, address, persistDir)
 }
 
 // NewCustomTestHost allows passing in both host dependencies and storage
 // manager dependencies. Used solely for testing purposes, to allow dependency
-// injection into the host's submodules.
-func NewCustomTestHost(deps modules.Dependencies, smDeps modules.Dependencies, cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPool, wallet modules.Wallet, mux *siamux.SiaMux, address string, persistDir string) (*Host, error) {
-	return newHost(deps, smDeps, cs, g, tpool, wallet, mux, address, persistDir)
+// injection into the host's submodules. If an identity is provided, the host
+// uses its host key.
+func NewCustomTestHost(deps modules.Dependencies, smDeps modules.Dependencies, cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPool, wallet modules.Wallet, mux *siamux.SiaMux, id modules.Identity, address string, persistDir string) (*Host, error) {
+	return newHost(deps, smDeps, cs, g, tpool, wallet, mux, id, address, persistDir)
 }
 
 // Close shuts down the host.
@@ -668,6 +752,13 @@ func (h *Host) PublicKey() types.SiaPublicKey {
 
 // SetInternalSettings updates the host's internal HostInternalSettings object.
 func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error {
+	return h.SetInternalSettingsFromSource(settings, settingsSourceInternal)
+}
+
+// SetInternalSettingsFromSource updates the host's internal HostSettings
+// object and records the change together with its source in the settings
+// history.
+func (h *Host) SetInternalSettingsFromSource(settings modules.HostInternalSettings, source string) error {
 	err := h.tg.Add()
 	if err != nil {
 		return err
@@ -728,6 +819,7 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	h.recordSettingsChange(h.settings, settings, source)
 	h.settings = settings
 	h.revisionNumber++
 
@@ -0,0 +1,148 @@
+package host
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errIngressAccountNotFunded is returned if an account proof is signed
+	// by an account without a balance.
+	errIngressAccountNotFunded = errors.New("account of ingress proof is not funded")
+
+	// errIngressContractInactive is returned if a contract proof is signed
+	// for a contract which is not active.
+	errIngressContractInactive = errors.New("contract of ingress proof is not active")
+
+	// errIngressProofReplayed is returned if a proof was already used.
+	errIngressProofReplayed = errors.New("ingress proof was already used")
+)
+
+type (
+	// ingressProofSet contains the hashes of the ingress proofs the host
+	// accepted. A proof is kept until its timestamp is outside of the
+	// IngressProofWindow, after which it is rejected as expired anyway.
+	ingressProofSet struct {
+		proofs    map[crypto.Hash]time.Time
+		lastPrune time.Time
+		mu        sync.Mutex
+	}
+)
+
+// newIngressProofSet creates an empty ingressProofSet.
+func newIngressProofSet() *ingressProofSet {
+	return &ingressProofSet{
+		proofs: make(map[crypto.Hash]time.Time),
+	}
+}
+
+// managedAdd adds the proof with the given hash and timestamp to the set. It
+// returns false if the proof is already in the set. Proofs which expired are
+// pruned once per IngressProofWindow.
+func (ps *ingressProofSet) managedAdd(h crypto.Hash, timestamp, now time.Time) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if now.Sub(ps.lastPrune) > modules.IngressProofWindow {
+		for ph, ts := range ps.proofs {
+			if now.Sub(ts) > modules.IngressProofWindow {
+				delete(ps.proofs, ph)
+			}
+		}
+		ps.lastPrune = now
+	}
+	if _, exists := ps.proofs[h]; exists {
+		return false
+	}
+	ps.proofs[h] = timestamp
+	return true
+}
+
+// managedIngressProtection returns whether the host requires ingress proofs
+// for expensive RPCs.
+func (h *Host) managedIngressProtection() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.settings.IngressProtection
+}
+
+// managedVerifyIngressProof reads an ingress proof for the RPC with the given
+// id from the stream and verifies it.
+func (h *Host) managedVerifyIngressProof(stream siamux.Stream, rpcID types.Specifier) error {
+	var proof modules.RPCIngressProof
+	if err := modules.RPCRead(stream, &proof); err != nil {
+		return errors.AddContext(err, "failed to read ingress proof")
+	}
+	err := h.managedCheckIngressProof(proof, rpcID, time.Now())
+	if err != nil {
+		return errors.Compose(modules.ErrInvalidIngressProof, err)
+	}
+	return nil
+}
+
+// managedCheckIngressProof checks that the proof is valid for the RPC with the
+// given id and wasn't used before.
+func (h *Host) managedCheckIngressProof(proof modules.RPCIngressProof, rpcID types.Specifier, now time.Time) error {
+	if err := proof.VerifyTimestamp(now); err != nil {
+		return err
+	}
+	hostKey := h.PublicKey()
+	if err := h.managedVerifyIngressProofType(proof, hostKey, rpcID); err != nil {
+		return err
+	}
+	if !h.staticIngressProofs.managedAdd(proof.Hash(hostKey, rpcID), time.Unix(proof.Timestamp, 0), now) {
+		return errIngressProofReplayed
+	}
+	return nil
+}
+
+// managedVerifyIngressProofType verifies the signature or the puzzle solution
+// of the proof depending on its type.
+func (h *Host) managedVerifyIngressProofType(proof modules.RPCIngressProof, hostKey types.SiaPublicKey, rpcID types.Specifier) error {
+	switch proof.Type {
+	case modules.IngressProofAccount:
+		if proof.AccountID.IsZeroAccount() {
+			return ErrZeroAccountID
+		}
+		if h.staticAccountManager.callAccountBalance(proof.AccountID).IsZero() {
+			return errIngressAccountNotFunded
+		}
+		return proof.VerifySignature(proof.AccountID.PK(), hostKey, rpcID)
+	case modules.IngressProofContract:
+		so, err := h.managedGetStorageObligation(proof.ContractID)
+		if err != nil {
+			return errors.AddContext(err, "failed to get contract of ingress proof")
+		}
+		if so.ObligationStatus != obligationUnresolved {
+			return errIngressContractInactive
+		}
+		rev, err := so.recentRevision()
+		if err != nil {
+			return err
+		}
+		if len(rev.UnlockConditions.PublicKeys) == 0 {
+			return errors.New("contract of ingress proof has no renter key")
+		}
+		var pk crypto.PublicKey
+		renterKey := rev.UnlockConditions.PublicKeys[0]
+		if renterKey.Algorithm != types.SignatureEd25519 || len(renterKey.Key) != len(pk) {
+			return errors.New("unsupported renter key")
+		}
+		copy(pk[:], renterKey.Key)
+		return proof.VerifySignature(pk, hostKey, rpcID)
+	case modules.IngressProofPuzzle:
+		if !proof.VerifyPuzzle(hostKey, rpcID, modules.IngressPuzzleDifficulty) {
+			return errors.New("ingress puzzle not solved")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown ingress proof type %v", proof.Type)
+	}
+}
@@ -0,0 +1,166 @@
+package host
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestIngressProtection verifies that the host only serves expensive RPCs to
+// renters with a valid ingress proof if ingress protection is enabled.
+func TestIngressProtection(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	pair, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := pair.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	h := pair.staticHT.host
+	hostKey := h.PublicKey()
+	rpcID := modules.RPCUpdatePriceTable
+	now := time.Now()
+
+	// An account proof requires a funded account.
+	proof := modules.NewIngressAccountProof(pair.staticAccountID, pair.staticAccountKey, hostKey, rpcID)
+	err = h.managedCheckIngressProof(proof, rpcID, now)
+	if !errors.Contains(err, errIngressAccountNotFunded) {
+		t.Fatal("expected errIngressAccountNotFunded, got", err)
+	}
+	err = h.staticAccountManager.callDeposit(pair.staticAccountID, types.SiacoinPrecision, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedCheckIngressProof(proof, rpcID, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// A proof can only be used once.
+	if err := h.managedCheckIngressProof(proof, rpcID, now); !errors.Contains(err, errIngressProofReplayed) {
+		t.Fatal("expected errIngressProofReplayed, got", err)
+	}
+
+	// Proofs are only valid for the RPC they were created for and only within
+	// the proof window.
+	if err := h.managedCheckIngressProof(proof, modules.RPCExecuteProgram, now); err == nil {
+		t.Fatal("proof for a different RPC was accepted")
+	}
+	err = h.managedCheckIngressProof(proof, rpcID, now.Add(2*modules.IngressProofWindow))
+	if !errors.Contains(err, modules.ErrIngressProofExpired) {
+		t.Fatal("expected ErrIngressProofExpired, got", err)
+	}
+
+	// A contract proof needs to be signed by the renter of the contract.
+	proof = modules.NewIngressContractProof(pair.staticFCID, pair.staticRenterSK, hostKey, rpcID)
+	if err := h.managedCheckIngressProof(proof, rpcID, now); err != nil {
+		t.Fatal(err)
+	}
+	sk, _ := crypto.GenerateKeyPair()
+	proof = modules.NewIngressContractProof(pair.staticFCID, sk, hostKey, rpcID)
+	if err := h.managedCheckIngressProof(proof, rpcID, now); err == nil {
+		t.Fatal("contract proof with the wrong key was accepted")
+	}
+
+	// A solved puzzle is accepted.
+	proof = modules.NewIngressPuzzleProof(hostKey, rpcID, modules.IngressPuzzleDifficulty)
+	if err := h.managedCheckIngressProof(proof, rpcID, now); err != nil {
+		t.Fatal(err)
+	}
+	proof.Type = types.NewSpecifier("unknown")
+	if err := h.managedCheckIngressProof(proof, rpcID, now); err == nil {
+		t.Fatal("unknown proof type was accepted")
+	}
+
+	// Enable ingress protection. The host advertises it in its settings.
+	is := h.InternalSettings()
+	is.IngressProtection = true
+	if err := h.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	if !h.ExternalSettings().IngressProtection {
+		t.Fatal("host should advertise ingress protection")
+	}
+
+	// fetchPriceTable is a helper which requests a price table using the
+	// provided proof.
+	fetchPriceTable := func(proof modules.RPCIngressProof) error {
+		stream := pair.managedNewStream()
+		defer func() {
+			if err := stream.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		err := modules.RPCWriteAll(stream, rpcID, proof)
+		if err != nil {
+			return err
+		}
+		var uptr modules.RPCUpdatePriceTableResponse
+		err = modules.RPCRead(stream, &uptr)
+		if err != nil {
+			return err
+		}
+		var pt modules.RPCPriceTable
+		return json.Unmarshal(uptr.PriceTableJSON, &pt)
+	}
+
+	// The host refuses to send the price table without a valid proof.
+	proof = modules.RPCIngressProof{Type: modules.IngressProofAccount, Timestamp: time.Now().Unix(), AccountID: pair.staticAccountID}
+	err = fetchPriceTable(proof)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInvalidIngressProof.Error()) {
+		t.Fatal("expected ErrInvalidIngressProof, got", err)
+	}
+
+	// Valid proofs are accepted, but only once.
+	proof = modules.NewIngressAccountProof(pair.staticAccountID, pair.staticAccountKey, hostKey, rpcID)
+	err = fetchPriceTable(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fetchPriceTable(proof)
+	if err == nil || !strings.Contains(err.Error(), errIngressProofReplayed.Error()) {
+		t.Fatal("expected errIngressProofReplayed, got", err)
+	}
+	err = fetchPriceTable(modules.NewIngressPuzzleProof(hostKey, rpcID, modules.IngressPuzzleDifficulty))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestIngressProofSet probes the pruning of the ingressProofSet.
+func TestIngressProofSet(t *testing.T) {
+	t.Parallel()
+	ps := newIngressProofSet()
+	now := time.Now()
+	h1, h2 := crypto.HashObject(1), crypto.HashObject(2)
+	if !ps.managedAdd(h1, now, now) || ps.managedAdd(h1, now, now) {
+		t.Fatal("proof should only be added once")
+	}
+	if !ps.managedAdd(h2, now.Add(modules.IngressProofWindow), now) {
+		t.Fatal("proof should be added")
+	}
+
+	// Once the first proof expired, it is pruned. The second one is still
+	// within the window.
+	later := now.Add(modules.IngressProofWindow + time.Second)
+	if ps.managedAdd(h2, now.Add(modules.IngressProofWindow), later) {
+		t.Fatal("proof within the window shouldn't be pruned")
+	}
+	if _, exists := ps.proofs[h1]; exists {
+		t.Fatal("expired proof wasn't pruned")
+	}
+}
@@ -76,6 +76,11 @@ type output struct {
 	// requested by the caller. Using only the proof, the caller will be able to
 	// compute the next Merkle root and size of the contract.
 	Proof []crypto.Hash
+
+	// ReadSectorRoot is the root of the sector an instruction read from. It is
+	// only set by instructions which read sector data, which allows the host
+	// to audit the accessed sectors.
+	ReadSectorRoot crypto.Hash
 }
 
 // commonInstruction contains all the fields shared by every instruction.
@@ -89,10 +89,11 @@ func executeReadSector(previousOutput output, ps *programState, length, offset u
 
 	// Return the output.
 	return output{
-		NewSize:       previousOutput.NewSize,       // size stays the same
-		NewMerkleRoot: previousOutput.NewMerkleRoot, // root stays the same
-		Output:        readData,
-		Proof:         proof,
+		NewSize:        previousOutput.NewSize,       // size stays the same
+		NewMerkleRoot:  previousOutput.NewMerkleRoot, // root stays the same
+		Output:         readData,
+		Proof:          proof,
+		ReadSectorRoot: sectorRoot,
 	}, sectorData
 }
 
@@ -330,6 +330,7 @@ func (h *Host) managedFinalizeContract(args finalizeContractArgs) ([]types.Trans
 	if err != nil {
 		return nil, types.TransactionSignature{}, types.FileContractID{}, err
 	}
+	h.managedRecordPayout(so)
 
 	// Get the host's transaction signatures from the builder.
 	var hostTxnSignatures []types.TransactionSignature
@@ -7,6 +7,7 @@ import (
 
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -103,6 +104,13 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 		modules.WriteNegotiationRejection(conn, err) // Error not reported to preserve type in extendErr
 		return extendErr("download request rejected: ", err)
 	}
+	// Record the served sectors in the sector audit log.
+	roots := make([]crypto.Hash, 0, len(requests))
+	for _, request := range requests {
+		roots = append(roots, request.MerkleRoot)
+	}
+	h.managedRecordSectorAccesses(modules.HostSectorAuditRead, so.id(), so.renterKey(), roots)
+
 	// Revision is acceptable, write acceptance.
 	err = modules.WriteNegotiationAcceptance(conn)
 	if err != nil {
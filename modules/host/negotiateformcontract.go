@@ -242,9 +242,9 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 	lockedStorageCollateral := h.financialMetrics.LockedStorageCollateral
 	publicKey := h.publicKey
 	iSettings := h.settings
-	unlockHash := h.unlockHash
-	h.mu.RUnlock()
 	fc := txnSet[len(txnSet)-1].FileContracts[0]
+	unlockHash := h.payoutUnlockHash(fc)
+	h.mu.RUnlock()
 
 	// A new file contract should have a file size of zero.
 	if fc.FileSize != 0 {
@@ -276,8 +276,8 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 		return ErrBadContractOutputCounts
 	}
 	// The unlock hashes of the valid and missed proof outputs for the host
-	// must match the host's unlock hash. The third missed output should point
-	// to the void.
+	// must match the host's unlock hash or one of its payout addresses. The
+	// third missed output should point to the void.
 	voidOutput, err := fc.MissedVoidOutput()
 	if err != nil {
 		return err
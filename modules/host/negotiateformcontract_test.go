@@ -196,6 +196,20 @@ func TestVerifyNewContract(t *testing.T) {
 		t.Fatal("should fail", err)
 	}
 
+	// unlock hashes paying one of the host's payout addresses
+	h.payoutSettings = modules.HostPayoutSettings{
+		Policy:    modules.HostPayoutPolicyRotate,
+		Addresses: []modules.HostPayoutAddress{{Address: types.UnlockHash{2}}, {Address: types.UnlockHash{1}}},
+	}
+	goodSet := deepCopy(curr)
+	goodSet[len(goodSet)-1].FileContracts[0].ValidProofOutputs[1].UnlockHash = types.UnlockHash{1}
+	goodSet[len(goodSet)-1].FileContracts[0].MissedProofOutputs[1].UnlockHash = types.UnlockHash{1}
+	err = h.managedVerifyNewContract(goodSet, renterPK, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.payoutSettings = modules.HostPayoutSettings{}
+
 	// valid / missed host outputs are not the same.
 	badSet = deepCopy(curr)
 	fc = &badSet[len(badSet)-1].FileContracts[0]
@@ -123,9 +123,9 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 	internalSettings := h.settings
 	lockedStorageCollateral := h.financialMetrics.LockedStorageCollateral
 	publicKey := h.publicKey
-	unlockHash := h.unlockHash
-	h.mu.Unlock()
 	fc := txnSet[len(txnSet)-1].FileContracts[0]
+	unlockHash := h.payoutUnlockHash(fc)
+	h.mu.Unlock()
 
 	// The file size and merkle root must match the file size and merkle root
 	// from the previous file contract.
@@ -156,8 +156,8 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 		return types.Currency{}, ErrBadContractOutputCounts
 	}
 	// The unlock hashes of the valid and missed proof outputs for the host
-	// must match the host's unlock hash. The third missed output should point
-	// to the void.
+	// must match the host's unlock hash or one of its payout addresses. The
+	// third missed output should point to the void.
 	voidOutput, err := fc.MissedVoidOutput()
 	if err != nil {
 		return types.Currency{}, err
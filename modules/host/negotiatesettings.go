@@ -63,6 +63,15 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 	} else if h.financialMetrics.LockedStorageCollateral.Add(maxCollateral).Cmp(h.settings.CollateralBudget) > 0 {
 		maxCollateral = h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
 	}
+	// If the wallet is not expected to cover the collateral of the contracts
+	// within the next period, reduce the advertised MaxCollateral further or
+	// stop accepting contracts altogether.
+	if forecastMax := h.collateralForecast.forecastMaxCollateral(h.settings.MaxCollateral); forecastMax.Cmp(maxCollateral) < 0 {
+		maxCollateral = forecastMax
+	}
+	if h.collateralForecast.paused {
+		acceptingContracts = false
+	}
 
 	// Extract the port from the SiaMux's address
 	_, port, err := net.SplitHostPort(h.staticMux.Address().String())
@@ -79,7 +88,7 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		RemainingStorage:     remainingStorage,
 		SectorSize:           modules.SectorSize,
 		TotalStorage:         totalStorage,
-		UnlockHash:           h.unlockHash,
+		UnlockHash:           h.nextPayoutAddress(),
 		WindowSize:           h.settings.WindowSize,
 
 		Collateral:    h.settings.Collateral,
@@ -99,6 +108,8 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		Version:        modules.RHPVersion,
 
 		SiaMuxPort: port,
+
+		IngressProtection: h.settings.IngressProtection,
 	}
 }
 
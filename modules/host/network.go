@@ -315,9 +315,14 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		}
 	}
 
+	// Unknown RPCs are not counted to keep the number of counters bounded. The
+	// RPCs of the RPC loop are counted individually.
+	start := time.Now()
+	counted := true
 	switch id {
 	// new RPCs: enter an infinite request/response loop
 	case modules.RPCLoopEnter:
+		counted = false
 		err = extendErr("incoming RPCLoopEnter failed: ", h.managedRPCLoop(conn))
 	// old RPCs: handle a single request/response
 	case modules.RPCDownload:
@@ -333,11 +338,16 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		atomic.AddUint64(&h.atomicSettingsCalls, 1)
 		err = extendErr("incoming RPCSettings failed: ", h.managedRPCSettings(conn))
 	case rpcSettingsDeprecated:
+		counted = false
 		h.log.Debugln("Received deprecated settings call")
 	default:
+		counted = false
 		h.log.Debugf("WARN: incoming conn %v requested unknown RPC \"%v\"", conn.RemoteAddr(), id)
 		atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
 	}
+	if counted {
+		h.staticOperationCounters.Record(operationRPC(id), time.Since(start), err)
+	}
 	if err != nil {
 		atomic.AddUint64(&h.atomicErroredCalls, 1)
 		err = extendErr("error with "+conn.RemoteAddr().String()+": ", err)
@@ -386,6 +396,9 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 	}
 	defer h.tg.Done()
 
+	// Apply the host's bandwidth limits to the stream.
+	stream = h.staticBandwidthShaper.staticWrapStream(stream, h.tg.StopChan())
+
 	// set an initial duration that is generous, but finite. RPCs can extend
 	// this if desired
 	err = stream.SetDeadline(time.Now().Add(defaultConnectionDeadline))
@@ -406,6 +419,21 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		return
 	}
 
+	// Expensive RPCs require an ingress proof if the host enabled ingress
+	// protection.
+	if modules.RPCRequiresIngressProof(rpcID) && h.managedIngressProtection() {
+		if err := h.managedVerifyIngressProof(stream, rpcID); err != nil {
+			if wErr := modules.RPCWriteError(stream, err); wErr != nil {
+				h.managedLogError(wErr)
+			}
+			atomic.AddUint64(&h.atomicErroredCalls, 1)
+			return
+		}
+	}
+
+	// Unknown RPCs are not counted to keep the number of counters bounded.
+	start := time.Now()
+	counted := true
 	switch rpcID {
 	case modules.RPCAccountBalance:
 		err = h.managedRPCAccountBalance(stream)
@@ -421,11 +449,23 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		cleanup, err = h.managedRPCRegistrySubscribe(stream)
 	case modules.RPCRenewContract:
 		err = h.managedRPCRenewContract(stream)
+	case modules.RPCNegotiate:
+		err = h.managedRPCNegotiate(stream)
+	case modules.RPCPrefetchHint:
+		err = h.managedRPCPrefetchHint(stream)
+	case modules.RPCSectorRoots:
+		err = h.managedRPCSectorRoots(stream)
+	case modules.RPCDelegatedRead:
+		err = h.managedRPCDelegatedRead(stream)
 	default:
+		counted = false
 		h.log.Debugf("WARN: incoming stream %v requested unknown RPC \"%v\"", stream.RemoteAddr().String(), rpcID)
-		err = errors.New(fmt.Sprintf("Unrecognized RPC id %v", rpcID))
+		err = fmt.Errorf("%v %v", modules.UnrecognizedRPCErrString, rpcID)
 		atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
 	}
+	if counted {
+		h.staticOperationCounters.Record(operationRPC(rpcID), time.Since(start), err)
+	}
 
 	if err != nil {
 		err = errors.Compose(err, modules.RPCWriteError(stream, err))
@@ -448,6 +488,7 @@ func (h *Host) threadedListen(closeChan chan struct{}) {
 		}
 
 		conn = connmonitor.NewMonitoredConn(conn, h.staticMonitor)
+		conn = h.staticBandwidthShaper.staticWrapConn(conn, h.tg.StopChan())
 
 		go h.threadedHandleConn(conn)
 
@@ -193,7 +193,7 @@ func TestUnrecognizedRPCID(t *testing.T) {
 		t.Fatal(err)
 	}
 	err = modules.RPCRead(stream, struct{}{})
-	if err == nil || !strings.Contains(err.Error(), randomRPCID.String()) {
+	if err == nil || !strings.Contains(err.Error(), randomRPCID.String()) || !modules.IsUnrecognizedRPCErr(err) {
 		t.Fatalf("Expected err '%v', but received '%v'", fmt.Sprintf("Unrecognized RPC id %v", randomRPCID), err)
 	}
 }
@@ -542,6 +542,7 @@ func (h *Host) managedRPCLoopRead(s *rpcSession) error {
 			err = errors.Compose(err, s.writeError(err))
 			return err
 		}
+		h.managedRecordSectorAccesses(modules.HostSectorAuditRead, s.so.id(), s.so.renterKey(), []crypto.Hash{sec.MerkleRoot})
 		data := sectorData[sec.Offset : sec.Offset+sec.Length]
 
 		// Construct the Merkle proof, if requested.
@@ -0,0 +1,229 @@
+package host
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxPayoutAddresses is the maximum number of payout addresses the host
+	// can be configured with.
+	maxPayoutAddresses = 100
+
+	// payoutHistoryLimit is the maximum number of payout records the host
+	// keeps. Once the limit is reached, the oldest records are dropped.
+	payoutHistoryLimit = 1000
+)
+
+var (
+	// errPayoutPolicyUnknown is returned when setting an unknown payout
+	// policy.
+	errPayoutPolicyUnknown = errors.New("unknown payout policy")
+
+	// errPayoutPolicyMissing is returned when setting payout addresses
+	// without a policy.
+	errPayoutPolicyMissing = errors.New("payout addresses need a payout policy")
+
+	// errPayoutAddressesMissing is returned when setting a payout policy
+	// without any payout addresses.
+	errPayoutAddressesMissing = errors.New("payout policy needs at least one payout address")
+
+	// errPayoutAddressesTooMany is returned when setting more than
+	// maxPayoutAddresses payout addresses.
+	errPayoutAddressesTooMany = errors.New("too many payout addresses")
+
+	// errPayoutAddressVoid is returned when setting the void address as a
+	// payout address.
+	errPayoutAddressVoid = errors.New("payout address can't be the void address")
+
+	// errPayoutAddressDuplicate is returned when setting the same payout
+	// address twice.
+	errPayoutAddressDuplicate = errors.New("payout addresses need to be unique")
+
+	// errPayoutPercentages is returned when the percentages of a split don't
+	// add up to 100 or an address of the split has no share.
+	errPayoutPercentages = errors.New("payout percentages of a split need to be positive and add up to 100")
+)
+
+// validatePayoutSettings checks that the payout settings can be applied.
+func validatePayoutSettings(settings modules.HostPayoutSettings) error {
+	switch settings.Policy {
+	case "":
+		if len(settings.Addresses) != 0 {
+			return errPayoutPolicyMissing
+		}
+		return nil
+	case modules.HostPayoutPolicyRotate, modules.HostPayoutPolicySplit:
+	default:
+		return errPayoutPolicyUnknown
+	}
+	if len(settings.Addresses) == 0 {
+		return errPayoutAddressesMissing
+	}
+	if len(settings.Addresses) > maxPayoutAddresses {
+		return errPayoutAddressesTooMany
+	}
+	seen := make(map[types.UnlockHash]struct{}, len(settings.Addresses))
+	var total uint64
+	for _, pa := range settings.Addresses {
+		if pa.Address == (types.UnlockHash{}) {
+			return errPayoutAddressVoid
+		}
+		if _, exists := seen[pa.Address]; exists {
+			return errPayoutAddressDuplicate
+		}
+		seen[pa.Address] = struct{}{}
+		if settings.Policy == modules.HostPayoutPolicySplit && (pa.Percentage == 0 || pa.Percentage > 100) {
+			return errPayoutPercentages
+		}
+		total += pa.Percentage
+	}
+	if settings.Policy == modules.HostPayoutPolicySplit && total != 100 {
+		return errPayoutPercentages
+	}
+	return nil
+}
+
+// payoutTotal returns the total of the address, or an empty total if the
+// address didn't receive any payouts yet.
+func (h *Host) payoutTotal(addr types.UnlockHash) modules.HostPayoutTotal {
+	for _, pt := range h.payoutTotals {
+		if pt.Address == addr {
+			return pt
+		}
+	}
+	return modules.HostPayoutTotal{Address: addr}
+}
+
+// nextPayoutAddress returns the address the host advertises to renters for
+// the payout of the next contract. The split policy picks the address whose
+// share of the payouts is furthest below its percentage.
+func (h *Host) nextPayoutAddress() types.UnlockHash {
+	addrs := h.payoutSettings.Addresses
+	if len(addrs) == 0 {
+		return h.unlockHash
+	}
+	if h.payoutSettings.Policy == modules.HostPayoutPolicyRotate {
+		return addrs[h.payoutRotation%uint64(len(addrs))].Address
+	}
+	next := addrs[0]
+	nextValue := h.payoutTotal(next.Address).Value
+	for _, pa := range addrs[1:] {
+		// An address is further below its share if value/percentage is
+		// smaller.
+		value := h.payoutTotal(pa.Address).Value
+		if value.Mul64(next.Percentage).Cmp(nextValue.Mul64(pa.Percentage)) < 0 {
+			next, nextValue = pa, value
+		}
+	}
+	return next.Address
+}
+
+// payoutUnlockHash returns the address the host's outputs of the file
+// contract have to pay to. Renters might use an address the host advertised
+// earlier, so contracts paying any of the configured payout addresses are
+// accepted.
+func (h *Host) payoutUnlockHash(fc types.FileContract) types.UnlockHash {
+	if len(fc.ValidProofOutputs) != 2 {
+		return h.unlockHash
+	}
+	addr := fc.ValidHostOutput().UnlockHash
+	for _, pa := range h.payoutSettings.Addresses {
+		if pa.Address == addr {
+			return addr
+		}
+	}
+	return h.unlockHash
+}
+
+// recordPayout records the address the host's payout of the storage
+// obligation is sent to. The rotation only advances if the contract pays the
+// currently advertised address.
+func (h *Host) recordPayout(so storageObligation) {
+	valid, _ := so.payouts()
+	addr, value := valid[1].UnlockHash, valid[1].Value
+	if h.payoutSettings.Policy == modules.HostPayoutPolicyRotate && addr == h.nextPayoutAddress() {
+		h.payoutRotation++
+	}
+
+	h.payoutHistory = append(h.payoutHistory, modules.HostPayoutRecord{
+		ContractID: so.id(),
+		Address:    addr,
+		Value:      value,
+		Height:     h.blockHeight,
+		Timestamp:  time.Now(),
+	})
+	if len(h.payoutHistory) > payoutHistoryLimit {
+		h.payoutHistory = h.payoutHistory[len(h.payoutHistory)-payoutHistoryLimit:]
+	}
+
+	for i := range h.payoutTotals {
+		if h.payoutTotals[i].Address == addr {
+			h.payoutTotals[i].Value = h.payoutTotals[i].Value.Add(value)
+			h.payoutTotals[i].Contracts++
+			return
+		}
+	}
+	h.payoutTotals = append(h.payoutTotals, modules.HostPayoutTotal{
+		Address:   addr,
+		Value:     value,
+		Contracts: 1,
+	})
+}
+
+// managedRecordPayout records the address the host's payout of the storage
+// obligation is sent to.
+func (h *Host) managedRecordPayout(so storageObligation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recordPayout(so)
+}
+
+// PayoutSettings returns the host's payout address settings.
+func (h *Host) PayoutSettings() modules.HostPayoutSettings {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	settings := h.payoutSettings
+	settings.Addresses = append([]modules.HostPayoutAddress(nil), h.payoutSettings.Addresses...)
+	return settings
+}
+
+// SetPayoutSettings sets the addresses the host's contract payouts are sent to
+// and the policy used to pick between them. The rotation and the payout
+// totals start over, the payout history is kept.
+func (h *Host) SetPayoutSettings(settings modules.HostPayoutSettings) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	if err := validatePayoutSettings(settings); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.payoutSettings = modules.HostPayoutSettings{
+		Policy:    settings.Policy,
+		Addresses: append([]modules.HostPayoutAddress(nil), settings.Addresses...),
+	}
+	h.payoutRotation = 0
+	h.payoutTotals = nil
+	return errors.AddContext(h.saveSync(), "failed to save payout settings")
+}
+
+// PayoutHistory returns the address the host advertises for the next
+// contract, the payout totals per address and the most recent payout
+// records.
+func (h *Host) PayoutHistory() modules.HostPayoutHistory {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return modules.HostPayoutHistory{
+		NextAddress: h.nextPayoutAddress(),
+		Totals:      append([]modules.HostPayoutTotal{}, h.payoutTotals...),
+		Payouts:     append([]modules.HostPayoutRecord{}, h.payoutHistory...),
+	}
+}
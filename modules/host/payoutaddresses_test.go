@@ -0,0 +1,164 @@
+package host
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newPayoutObligation creates an obligation which pays the host value to
+// addr if the contract succeeds.
+func newPayoutObligation(addr types.UnlockHash, value uint64) storageObligation {
+	return storageObligation{
+		OriginTransactionSet: []types.Transaction{{
+			FileContracts: []types.FileContract{{
+				ValidProofOutputs: []types.SiacoinOutput{
+					{}, {UnlockHash: addr, Value: types.NewCurrency64(value)},
+				},
+				MissedProofOutputs: []types.SiacoinOutput{
+					{}, {UnlockHash: addr, Value: types.NewCurrency64(value)}, {},
+				},
+			}},
+			ArbitraryData: [][]byte{addr[:]},
+		}},
+	}
+}
+
+// TestPayoutPolicies probes picking the payout addresses of new contracts.
+func TestPayoutPolicies(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := types.UnlockHash{1}, types.UnlockHash{2}, types.UnlockHash{3}
+	h := &Host{unlockHash: c}
+
+	// Without payout addresses, the host's unlock hash is used.
+	if addr := h.nextPayoutAddress(); addr != c {
+		t.Fatal("wrong address", addr)
+	}
+	if addr := h.payoutUnlockHash(newPayoutObligation(a, 1).OriginTransactionSet[0].FileContracts[0]); addr != c {
+		t.Fatal("unconfigured address was accepted", addr)
+	}
+
+	// The rotation only advances if the advertised address is paid.
+	h.payoutSettings = modules.HostPayoutSettings{
+		Policy:    modules.HostPayoutPolicyRotate,
+		Addresses: []modules.HostPayoutAddress{{Address: a}, {Address: b}},
+	}
+	for i, expected := range []types.UnlockHash{a, b, a} {
+		if addr := h.nextPayoutAddress(); addr != expected {
+			t.Fatalf("%v: expected %v, got %v", i, expected, addr)
+		}
+		h.recordPayout(newPayoutObligation(expected, 10))
+	}
+	h.recordPayout(newPayoutObligation(a, 10))
+	if addr := h.nextPayoutAddress(); addr != b {
+		t.Fatal("rotation advanced for a stale address", addr)
+	}
+	if addr := h.payoutUnlockHash(newPayoutObligation(a, 1).OriginTransactionSet[0].FileContracts[0]); addr != a {
+		t.Fatal("configured address wasn't accepted", addr)
+	}
+
+	ph := h.PayoutHistory()
+	expectedTotals := []modules.HostPayoutTotal{
+		{Address: a, Value: types.NewCurrency64(30), Contracts: 3},
+		{Address: b, Value: types.NewCurrency64(10), Contracts: 1},
+	}
+	if ph.NextAddress != b || len(ph.Payouts) != 4 || !reflect.DeepEqual(ph.Totals, expectedTotals) {
+		t.Fatal("unexpected payout history", ph)
+	}
+
+	// The split picks the address which is furthest below its share of the
+	// payout value.
+	h.payoutSettings = modules.HostPayoutSettings{
+		Policy:    modules.HostPayoutPolicySplit,
+		Addresses: []modules.HostPayoutAddress{{Address: a, Percentage: 75}, {Address: b, Percentage: 25}},
+	}
+	h.payoutTotals = nil
+	var picked []types.UnlockHash
+	for i := 0; i < 8; i++ {
+		addr := h.nextPayoutAddress()
+		picked = append(picked, addr)
+		h.recordPayout(newPayoutObligation(addr, 10))
+	}
+	if expected := []types.UnlockHash{a, b, a, a, a, b, a, a}; !reflect.DeepEqual(picked, expected) {
+		t.Fatal("unexpected split", picked)
+	}
+	if total := h.payoutTotal(a); !total.Value.Equals64(60) || total.Contracts != 6 {
+		t.Fatal("unexpected total", total)
+	}
+}
+
+// TestHostPayoutSettings checks that the host validates, advertises and
+// persists its payout settings.
+func TestHostPayoutSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid settings are rejected.
+	a, b := types.UnlockHash{1}, types.UnlockHash{2}
+	invalid := []struct {
+		settings modules.HostPayoutSettings
+		err      error
+	}{
+		{modules.HostPayoutSettings{Policy: "foo", Addresses: []modules.HostPayoutAddress{{Address: a}}}, errPayoutPolicyUnknown},
+		{modules.HostPayoutSettings{Addresses: []modules.HostPayoutAddress{{Address: a}}}, errPayoutPolicyMissing},
+		{modules.HostPayoutSettings{Policy: modules.HostPayoutPolicyRotate}, errPayoutAddressesMissing},
+		{modules.HostPayoutSettings{Policy: modules.HostPayoutPolicyRotate, Addresses: []modules.HostPayoutAddress{{}}}, errPayoutAddressVoid},
+		{modules.HostPayoutSettings{Policy: modules.HostPayoutPolicyRotate, Addresses: []modules.HostPayoutAddress{{Address: a}, {Address: a}}}, errPayoutAddressDuplicate},
+		{modules.HostPayoutSettings{Policy: modules.HostPayoutPolicySplit, Addresses: []modules.HostPayoutAddress{{Address: a, Percentage: 50}, {Address: b, Percentage: 40}}}, errPayoutPercentages},
+		{modules.HostPayoutSettings{Policy: modules.HostPayoutPolicySplit, Addresses: []modules.HostPayoutAddress{{Address: a, Percentage: 100}, {Address: b}}}, errPayoutPercentages},
+	}
+	for _, test := range invalid {
+		if err := ht.host.SetPayoutSettings(test.settings); !errors.Contains(err, test.err) {
+			t.Fatalf("expected %v but got %v", test.err, err)
+		}
+	}
+
+	// Set valid settings. The host advertises the first address and keeps
+	// it after a reload.
+	settings := modules.HostPayoutSettings{
+		Policy:    modules.HostPayoutPolicySplit,
+		Addresses: []modules.HostPayoutAddress{{Address: a, Percentage: 60}, {Address: b, Percentage: 40}},
+	}
+	if err := ht.host.SetPayoutSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if addr := ht.host.ExternalSettings().UnlockHash; addr != a {
+		t.Fatal("host doesn't advertise the payout address", addr)
+	}
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ht.host.PayoutSettings(), settings) {
+		t.Fatal("settings weren't persisted", ht.host.PayoutSettings())
+	}
+
+	// Clearing the settings advertises the host's wallet address again.
+	if err := ht.host.SetPayoutSettings(modules.HostPayoutSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if addr := ht.host.ExternalSettings().UnlockHash; addr != ht.host.unlockHash {
+		t.Fatal("host doesn't advertise its unlock hash", addr)
+	}
+}
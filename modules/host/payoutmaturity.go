@@ -0,0 +1,78 @@
+package host
+
+import (
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// payoutMaturity returns the host's payout of a succeeded storage obligation
+// and the height at which the delayed siacoin output containing it matures.
+// If the obligation required a storage proof, the host is paid by the valid
+// proof outputs once the proof is confirmed. Otherwise the host is paid by the
+// missed proof outputs at the proof deadline.
+func (so storageObligation) payoutMaturity() (types.Currency, types.BlockHeight) {
+	valid, missed := so.payouts()
+	if !so.ProofConfirmed {
+		return missed[1].Value, so.proofDeadline() + types.MaturityDelay
+	}
+	// Obligations which were confirmed before the proof height was tracked
+	// use the earliest height at which the proof could have been confirmed.
+	proofHeight := so.ProofConfirmedHeight
+	if proofHeight == 0 {
+		proofHeight = so.expiration()
+	}
+	return valid[1].Value, proofHeight + types.MaturityDelay
+}
+
+// addMaturingPayout adds the payout of a succeeded storage obligation to the
+// host's maturing payouts unless it already matured.
+func (h *Host) addMaturingPayout(so storageObligation) {
+	value, maturityHeight := so.payoutMaturity()
+	if value.IsZero() || maturityHeight <= h.blockHeight {
+		return
+	}
+	h.maturingPayouts = append(h.maturingPayouts, modules.HostMaturingPayout{
+		ContractID:     so.id(),
+		Value:          value,
+		MaturityHeight: maturityHeight,
+	})
+	h.financialMetrics.MaturingPayouts = h.financialMetrics.MaturingPayouts.Add(value)
+}
+
+// pruneMaturedPayouts removes the payouts which matured at or below the
+// host's current block height.
+func (h *Host) pruneMaturedPayouts() {
+	remaining := h.maturingPayouts[:0]
+	for _, mp := range h.maturingPayouts {
+		if mp.MaturityHeight > h.blockHeight {
+			remaining = append(remaining, mp)
+			continue
+		}
+		if h.financialMetrics.MaturingPayouts.Cmp(mp.Value) >= 0 {
+			h.financialMetrics.MaturingPayouts = h.financialMetrics.MaturingPayouts.Sub(mp.Value)
+		} else {
+			h.financialMetrics.MaturingPayouts = types.ZeroCurrency
+		}
+	}
+	h.maturingPayouts = remaining
+}
+
+// PayoutSchedule returns the payouts of succeeded storage obligations which
+// are yet to mature, sorted by maturity height.
+func (h *Host) PayoutSchedule() modules.HostPayoutSchedule {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ps := modules.HostPayoutSchedule{
+		Height:  h.blockHeight,
+		Payouts: append([]modules.HostMaturingPayout{}, h.maturingPayouts...),
+	}
+	for _, mp := range ps.Payouts {
+		ps.MaturingPayouts = ps.MaturingPayouts.Add(mp.Value)
+	}
+	sort.SliceStable(ps.Payouts, func(i, j int) bool {
+		return ps.Payouts[i].MaturityHeight < ps.Payouts[j].MaturityHeight
+	})
+	return ps
+}
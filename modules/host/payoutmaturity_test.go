@@ -0,0 +1,79 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestMaturingPayouts probes tracking the payouts of succeeded storage
+// obligations until they mature.
+func TestMaturingPayouts(t *testing.T) {
+	t.Parallel()
+
+	// newObligation creates an obligation which pays the host validPayout if
+	// a proof is confirmed and missedPayout otherwise.
+	newObligation := func(windowStart types.BlockHeight, validPayout, missedPayout uint64) storageObligation {
+		return storageObligation{
+			OriginTransactionSet: []types.Transaction{{
+				FileContracts: []types.FileContract{{
+					WindowStart: windowStart,
+					WindowEnd:   windowStart + 10,
+					ValidProofOutputs: []types.SiacoinOutput{
+						{}, {Value: types.NewCurrency64(validPayout)},
+					},
+					MissedProofOutputs: []types.SiacoinOutput{
+						{}, {Value: types.NewCurrency64(missedPayout)}, {},
+					},
+				}},
+				ArbitraryData: [][]byte{{byte(windowStart)}},
+			}},
+		}
+	}
+	h := &Host{blockHeight: 120}
+
+	// An obligation with a confirmed proof matures MaturityDelay blocks after
+	// the proof.
+	proven := newObligation(100, 50, 10)
+	proven.ProofConfirmed = true
+	proven.ProofConfirmedHeight = 118
+	value, maturity := proven.payoutMaturity()
+	if !value.Equals64(50) || maturity != 118+types.MaturityDelay {
+		t.Fatal("wrong payout for proven obligation", value, maturity)
+	}
+	h.addMaturingPayout(proven)
+
+	// An obligation without a proof is paid by the missed outputs at the
+	// proof deadline.
+	unproven := newObligation(115, 30, 20)
+	value, maturity = unproven.payoutMaturity()
+	if !value.Equals64(20) || maturity != 125+types.MaturityDelay {
+		t.Fatal("wrong payout for unproven obligation", value, maturity)
+	}
+	h.addMaturingPayout(unproven)
+
+	// Payouts which already matured aren't tracked.
+	matured := newObligation(50, 40, 40)
+	matured.ProofConfirmed = true
+	matured.ProofConfirmedHeight = 55
+	h.addMaturingPayout(matured)
+
+	ps := h.PayoutSchedule()
+	if len(ps.Payouts) != 2 || !ps.MaturingPayouts.Equals64(70) || !h.financialMetrics.MaturingPayouts.Equals64(70) {
+		t.Fatal("unexpected payout schedule", ps, h.financialMetrics.MaturingPayouts)
+	}
+	if ps.Payouts[0].ContractID != proven.id() || ps.Payouts[1].ContractID != unproven.id() {
+		t.Fatal("payouts aren't sorted by maturity height", ps.Payouts)
+	}
+
+	// Once the first payout matured it is pruned.
+	h.blockHeight = 118 + types.MaturityDelay
+	h.pruneMaturedPayouts()
+	ps = h.PayoutSchedule()
+	if len(ps.Payouts) != 1 || ps.Payouts[0].ContractID != unproven.id() {
+		t.Fatal("matured payout wasn't pruned", ps.Payouts)
+	}
+	if !h.financialMetrics.MaturingPayouts.Equals64(20) {
+		t.Fatal("wrong maturing payouts", h.financialMetrics.MaturingPayouts)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
@@ -30,6 +31,20 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Settings history and scheduled settings changes.
+	ScheduledSettings []modules.HostScheduledSettings `json:"scheduledsettings"`
+	SettingsHistory   []modules.HostSettingsChange    `json:"settingshistory"`
+
+	UtilizationSamples []modules.HostUtilizationSample `json:"utilizationsamples"`
+	MaturingPayouts    []modules.HostMaturingPayout    `json:"maturingpayouts"`
+
+	BandwidthSettings modules.HostBandwidthSettings `json:"bandwidthsettings"`
+
+	PayoutSettings modules.HostPayoutSettings `json:"payoutsettings"`
+	PayoutRotation uint64                     `json:"payoutrotation"`
+	PayoutTotals   []modules.HostPayoutTotal  `json:"payouttotals"`
+	PayoutHistory  []modules.HostPayoutRecord `json:"payouthistory"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -48,6 +63,19 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		ScheduledSettings: h.scheduledSettings,
+		SettingsHistory:   h.settingsHistory,
+
+		UtilizationSamples: h.utilizationSamples,
+		MaturingPayouts:    h.maturingPayouts,
+
+		BandwidthSettings: h.staticBandwidthShaper.managedSettings(),
+
+		PayoutSettings: h.payoutSettings,
+		PayoutRotation: h.payoutRotation,
+		PayoutTotals:   h.payoutTotals,
+		PayoutHistory:  h.payoutHistory,
 	}
 }
 
@@ -97,6 +125,23 @@ func (h *Host) establishDefaults() error {
 	return nil
 }
 
+// loadIdentityKey replaces the host's key pair with the host key of the node's
+// identity. The identity is the source of truth for the host key since the
+// siamux uses the same key to authenticate the host to renters.
+func (h *Host) loadIdentityKey() error {
+	sk, pk, err := h.staticIdentity.KeyPair(modules.KeyPurposeHost)
+	if err != nil {
+		return errors.AddContext(err, "unable to get the host key from the identity")
+	}
+	if sk == h.secretKey {
+		return nil
+	}
+	h.log.Printf("Replacing host key %v with the host key %v of the node's identity", h.publicKey, types.Ed25519PublicKey(pk))
+	h.publicKey = types.Ed25519PublicKey(pk)
+	h.secretKey = sk
+	return h.saveSync()
+}
+
 // loadPersistObject will take a persist object and copy the data into the
 // host.
 func (h *Host) loadPersistObject(p *persistence) {
@@ -121,6 +166,33 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.settings.NetAddress = ""
 	}
 	h.unlockHash = p.UnlockHash
+
+	// Copy over the settings history and scheduled settings.
+	h.scheduledSettings = p.ScheduledSettings
+	h.settingsHistory = p.SettingsHistory
+
+	// Copy over the utilization samples.
+	h.utilizationSamples = p.UtilizationSamples
+
+	// Copy over the payouts which are yet to mature.
+	h.maturingPayouts = p.MaturingPayouts
+
+	// Copy over the bandwidth settings.
+	if err := validateBandwidthSettings(p.BandwidthSettings); err != nil {
+		h.log.Printf("WARN: bandwidth settings loaded from persist are invalid: %v", err)
+	} else {
+		h.staticBandwidthShaper.managedSetSettings(p.BandwidthSettings, time.Now())
+	}
+
+	// Copy over the payout settings and history.
+	if err := validatePayoutSettings(p.PayoutSettings); err != nil {
+		h.log.Printf("WARN: payout settings loaded from persist are invalid: %v", err)
+	} else {
+		h.payoutSettings = p.PayoutSettings
+		h.payoutRotation = p.PayoutRotation
+		h.payoutTotals = p.PayoutTotals
+	}
+	h.payoutHistory = p.PayoutHistory
 }
 
 // initDB will check that the database has been initialized and if not, will
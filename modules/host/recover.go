@@ -0,0 +1,228 @@
+package host
+
+// recover.go contains the recovery of the host's storage obligations after the
+// host database was lost. The contracts of the host are found by scanning the
+// blockchain for file contracts which pay the host's wallet. A contract can
+// only be serviced again if the sectors of its latest revision on the
+// blockchain are known, which is the case if the revision is empty or consists
+// of a single sector that the host still stores, e.g. after its storage folders
+// were recovered. The order of the sectors of larger contracts was only stored
+// in the lost database, so these contracts are reported as unrecoverable.
+
+import (
+	"bytes"
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errRecoveryStartHeight is returned if the recovery is requested to
+	// start above the host's current height.
+	errRecoveryStartHeight = errors.New("recovery start height is above the current height")
+
+	// errRecoveryWalletLocked is returned if storage obligations are
+	// recovered while the wallet is locked. The wallet's addresses are
+	// required to find the host's contracts.
+	errRecoveryWalletLocked = errors.New("wallet must be unlocked to recover storage obligations")
+)
+
+// recoveryCandidate is a file contract of the host found on the blockchain.
+type recoveryCandidate struct {
+	origin    types.Transaction
+	revision  []types.Transaction
+	height    types.BlockHeight
+	windowEnd types.BlockHeight
+}
+
+// recoveredStorageObligation builds the storage obligation of a contract from
+// its latest state on the blockchain. false is returned if the contract can't
+// be serviced.
+func (h *Host) recoveredStorageObligation(c recoveryCandidate) (storageObligation, bool) {
+	if len(c.origin.FileContracts) != 1 {
+		return storageObligation{}, false
+	}
+	if len(c.revision) > 0 && len(c.revision[0].FileContractRevisions) != 1 {
+		return storageObligation{}, false
+	}
+	so := storageObligation{
+		NegotiationHeight:      c.height,
+		OriginTransactionSet:   []types.Transaction{c.origin},
+		RevisionTransactionSet: c.revision,
+		OriginConfirmed:        true,
+		RevisionConfirmed:      len(c.revision) > 0,
+		h:                      h,
+	}
+
+	// The host doesn't submit storage proofs for obligations without a
+	// revision, so these can only be serviced if they are empty.
+	switch {
+	case so.fileSize() == 0:
+	case len(c.revision) > 0 && so.fileSize() == modules.SectorSize && h.HasSector(so.merkleRoot()):
+		// The Merkle root of a single sector is the sector's root.
+		so.SectorRoots = []crypto.Hash{so.merkleRoot()}
+	default:
+		return storageObligation{}, false
+	}
+
+	// The split of the host's payouts into revenue and collateral was lost.
+	// The payout the host receives either way is accounted as contract
+	// compensation and the part which depends on the storage proof as risked
+	// collateral.
+	valid, missed := so.payouts()
+	so.ContractCost = missed[1].Value
+	if valid[1].Value.Cmp(missed[1].Value) > 0 {
+		so.RiskedCollateral = valid[1].Value.Sub(missed[1].Value)
+		so.LockedCollateral = so.RiskedCollateral
+	}
+	return so, true
+}
+
+// managedAddRecoveredStorageObligation adds a recovered storage obligation to
+// the host and queues the action items which submit its storage proof. false
+// is returned if the host already has an obligation for the contract.
+func (h *Host) managedAddRecoveredStorageObligation(so storageObligation) (bool, error) {
+	soid := so.id()
+	h.managedLockStorageObligation(soid)
+	defer h.managedUnlockStorageObligation(soid)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var exists bool
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		if _, err := h.getStorageObligation(tx, soid); err == nil {
+			exists = true
+			return nil
+		}
+		return putStorageObligation(tx, so)
+	})
+	if err != nil || exists {
+		return false, err
+	}
+	h.updateFinancialMetricsAddSO(so)
+
+	// The proof window might already be open.
+	proofHeight := so.expiration() + resubmissionTimeout
+	if proofHeight <= h.blockHeight {
+		proofHeight = h.blockHeight + 1
+	}
+	err1 := h.queueActionItem(proofHeight, soid)
+	err2 := h.queueActionItem(proofHeight+resubmissionTimeout, soid) // Paranoia
+	return true, composeErrors(err1, err2)
+}
+
+// RecoverStorageObligations rebuilds the storage obligations of the host's
+// unresolved contracts after the host database was lost. The blockchain is
+// scanned from startHeight for file contracts which pay one of the wallet's
+// addresses. Contracts which already have a storage obligation are skipped.
+func (h *Host) RecoverStorageObligations(startHeight types.BlockHeight) (modules.HostObligationRecovery, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostObligationRecovery{}, err
+	}
+	defer h.tg.Done()
+
+	unlocked, err := h.wallet.Unlocked()
+	if err != nil {
+		return modules.HostObligationRecovery{}, err
+	}
+	if !unlocked {
+		return modules.HostObligationRecovery{}, errRecoveryWalletLocked
+	}
+	addrs, err := h.wallet.AllAddresses()
+	if err != nil {
+		return modules.HostObligationRecovery{}, errors.AddContext(err, "failed to get wallet addresses")
+	}
+	owned := make(map[types.UnlockHash]struct{}, len(addrs)+1)
+	for _, addr := range addrs {
+		owned[addr] = struct{}{}
+	}
+	h.mu.RLock()
+	owned[h.unlockHash] = struct{}{}
+	for _, pa := range h.payoutSettings.Addresses {
+		owned[pa.Address] = struct{}{}
+	}
+	height := h.blockHeight
+	h.mu.RUnlock()
+	if startHeight > height {
+		return modules.HostObligationRecovery{}, errRecoveryStartHeight
+	}
+
+	// Find the host's contracts which weren't resolved by a storage proof.
+	candidates := make(map[types.FileContractID]recoveryCandidate)
+	for bh := startHeight; bh <= height; bh++ {
+		b, exists := h.cs.BlockAtHeight(bh)
+		if !exists {
+			return modules.HostObligationRecovery{}, errors.New("block not found in the consensus set")
+		}
+		for _, txn := range b.Transactions {
+			for i, fc := range txn.FileContracts {
+				if len(fc.ValidProofOutputs) != 2 || len(fc.MissedProofOutputs) < 2 {
+					continue
+				}
+				if _, ok := owned[fc.ValidHostOutput().UnlockHash]; ok {
+					candidates[txn.FileContractID(uint64(i))] = recoveryCandidate{
+						origin:    txn,
+						height:    bh,
+						windowEnd: fc.WindowEnd,
+					}
+				}
+			}
+			for _, fcr := range txn.FileContractRevisions {
+				if c, ok := candidates[fcr.ParentID]; ok {
+					c.revision = []types.Transaction{txn}
+					c.windowEnd = fcr.NewWindowEnd
+					candidates[fcr.ParentID] = c
+				}
+			}
+			for _, sp := range txn.StorageProofs {
+				delete(candidates, sp.ParentID)
+			}
+		}
+	}
+
+	recovery := modules.HostObligationRecovery{
+		StartHeight:   startHeight,
+		EndHeight:     height,
+		Recovered:     []types.FileContractID{},
+		Unrecoverable: []types.FileContractID{},
+	}
+	for id, c := range candidates {
+		if c.windowEnd <= height {
+			// The proof window has closed already.
+			continue
+		}
+		so, ok := h.recoveredStorageObligation(c)
+		if !ok {
+			if _, err := h.managedGetStorageObligation(id); err != nil {
+				recovery.Unrecoverable = append(recovery.Unrecoverable, id)
+			}
+			continue
+		}
+		added, err := h.managedAddRecoveredStorageObligation(so)
+		if err != nil {
+			return modules.HostObligationRecovery{}, errors.AddContext(err, "failed to add recovered storage obligation")
+		}
+		if added {
+			recovery.Recovered = append(recovery.Recovered, id)
+		}
+	}
+	for _, ids := range [][]types.FileContractID{recovery.Recovered, recovery.Unrecoverable} {
+		sort.Slice(ids, func(i, j int) bool {
+			return bytes.Compare(ids[i][:], ids[j][:]) < 0
+		})
+	}
+
+	h.mu.Lock()
+	err = h.saveSync()
+	h.mu.Unlock()
+	if err != nil {
+		return modules.HostObligationRecovery{}, err
+	}
+	h.log.Printf("Recovered %v storage obligations, %v contracts are unrecoverable\n", len(recovery.Recovered), len(recovery.Unrecoverable))
+	return recovery, nil
+}
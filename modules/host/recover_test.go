@@ -0,0 +1,108 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRecoverStorageObligations checks that the host recovers the storage
+// obligations of its contracts from the blockchain.
+func TestRecoverStorageObligations(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	startHeight := ht.host.BlockHeight()
+
+	// Form an empty contract which pays the host without adding a storage
+	// obligation, emulating a host which lost its database.
+	builder, err := ht.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payout := types.SiacoinPrecision.Mul64(10e3)
+	if err := builder.FundSiacoins(payout); err != nil {
+		t.Fatal(err)
+	}
+	hostPayout := types.SiacoinPrecision
+	renterPayout := types.PostTax(startHeight, payout).Sub(hostPayout)
+	_ = builder.AddFileContract(types.FileContract{
+		WindowStart: startHeight + revisionSubmissionBuffer + 2,
+		WindowEnd:   startHeight + revisionSubmissionBuffer + modules.DefaultWindowSize + 2,
+		Payout:      payout,
+		ValidProofOutputs: []types.SiacoinOutput{
+			{Value: renterPayout},
+			{Value: hostPayout, UnlockHash: ht.host.unlockHash},
+		},
+		MissedProofOutputs: []types.SiacoinOutput{
+			{Value: renterPayout},
+			{Value: hostPayout, UnlockHash: ht.host.unlockHash},
+			{Value: types.ZeroCurrency},
+		},
+		UnlockHash: (types.UnlockConditions{}).UnlockHash(),
+	})
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	fcid := txnSet[len(txnSet)-1].FileContractID(0)
+
+	// The recovery can't start in the future.
+	if _, err := ht.host.RecoverStorageObligations(ht.host.BlockHeight() + 1); !errors.Contains(err, errRecoveryStartHeight) {
+		t.Fatal("expected errRecoveryStartHeight", err)
+	}
+
+	recovery, err := ht.host.RecoverStorageObligations(startHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovery.Recovered) != 1 || recovery.Recovered[0] != fcid || len(recovery.Unrecoverable) != 0 {
+		t.Fatal("unexpected recovery", recovery)
+	}
+	so, err := ht.host.managedGetStorageObligation(fcid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !so.OriginConfirmed || !so.ContractCost.Equals(hostPayout) {
+		t.Fatal("recovered storage obligation is wrong", so.OriginConfirmed, so.ContractCost)
+	}
+
+	// Recovering again shouldn't add the obligation twice.
+	recovery, err = ht.host.RecoverStorageObligations(startHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovery.Recovered) != 0 || len(recovery.Unrecoverable) != 0 {
+		t.Fatal("obligation was recovered twice", recovery)
+	}
+	if n := ht.host.FinancialMetrics().ContractCount; n != 1 {
+		t.Fatal("wrong contract count", n)
+	}
+
+	// The wallet is required to find the host's contracts.
+	if err := ht.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.host.RecoverStorageObligations(startHeight); !errors.Contains(err, errRecoveryWalletLocked) {
+		t.Fatal("expected errRecoveryWalletLocked", err)
+	}
+}
@@ -0,0 +1,103 @@
+package host
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// renewalHintInterval is the number of blocks between two attempts of the
+	// host to publish renewal hints for its contracts.
+	renewalHintInterval = build.Select(build.Var{
+		Dev:      types.BlockHeight(6),
+		Standard: types.BlockHeight(36), // 6 hours
+		Testing:  types.BlockHeight(1),
+	}).(types.BlockHeight)
+)
+
+// renewalHint returns the renewal hint the host publishes for a contract with
+// the given expiration height. 'false' is returned if the host doesn't publish
+// hints.
+func renewalHint(fcid types.FileContractID, expiration, window types.BlockHeight) (modules.RenewalHint, bool) {
+	if window == 0 {
+		return modules.RenewalHint{}, false
+	}
+	var renewBefore types.BlockHeight
+	if expiration > window {
+		renewBefore = expiration - window
+	}
+	return modules.RenewalHint{
+		ContractID:        fcid,
+		ExpirationHeight:  expiration,
+		RenewBeforeHeight: renewBefore,
+	}, true
+}
+
+// threadedPublishRenewalHints publishes a renewal hint for every unresolved
+// storage obligation of the host in its registry. Renters check these hints to
+// learn when the host would like a contract to be renewed.
+func (h *Host) threadedPublishRenewalHints(height types.BlockHeight) {
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	h.mu.RLock()
+	window := h.settings.RenewalHintWindow
+	pk := h.publicKey
+	sk := h.secretKey
+	h.mu.RUnlock()
+	if window == 0 || height%renewalHintInterval != 0 {
+		return
+	}
+
+	var published int
+	for _, so := range h.StorageObligations() {
+		if so.ObligationStatus != obligationUnresolved.String() {
+			continue
+		}
+		hint, ok := renewalHint(so.ObligationId, so.ExpirationHeight, window)
+		if !ok {
+			continue
+		}
+		updated, err := h.managedPublishRenewalHint(hint, pk, sk)
+		if err != nil {
+			h.log.Debugf("failed to publish renewal hint for contract %v: %v", so.ObligationId, err)
+			continue
+		}
+		if updated {
+			published++
+		}
+	}
+	if published > 0 {
+		h.log.Debugf("published %v renewal hints", published)
+	}
+}
+
+// managedPublishRenewalHint stores the hint in the host's registry unless the
+// same hint was already published. It returns whether the registry was
+// updated.
+func (h *Host) managedPublishRenewalHint(hint modules.RenewalHint, pk types.SiaPublicKey, sk crypto.SecretKey) (bool, error) {
+	tweak := modules.RenewalHintTweak(hint.ContractID)
+	data := encoding.Marshal(hint)
+
+	var rev uint64
+	_, existing, found := h.RegistryGet(modules.DeriveRegistryEntryID(pk, tweak))
+	if found {
+		if bytes.Equal(existing.Data, data) {
+			return false, nil
+		}
+		rev = existing.Revision + 1
+	}
+	srv := modules.NewRegistryValue(tweak, data, rev).Sign(sk)
+	_, err := h.RegistryUpdate(srv, pk, hint.ExpirationHeight)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
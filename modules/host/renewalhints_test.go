@@ -0,0 +1,115 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRenewalHint is a unit test for renewalHint.
+func TestRenewalHint(t *testing.T) {
+	var fcid types.FileContractID
+	fcid[0] = 1
+
+	// No window means no hint.
+	if _, ok := renewalHint(fcid, 100, 0); ok {
+		t.Fatal("expected no hint")
+	}
+	hint, ok := renewalHint(fcid, 100, 10)
+	if !ok {
+		t.Fatal("expected hint")
+	}
+	if hint.ContractID != fcid || hint.ExpirationHeight != 100 || hint.RenewBeforeHeight != 90 {
+		t.Fatal("unexpected hint", hint)
+	}
+	// A window larger than the expiration shouldn't underflow.
+	hint, _ = renewalHint(fcid, 5, 10)
+	if hint.RenewBeforeHeight != 0 {
+		t.Fatal("unexpected renew before height", hint.RenewBeforeHeight)
+	}
+}
+
+// TestPublishRenewalHints probes publishing renewal hints to the host's
+// registry.
+func TestPublishRenewalHints(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Enable the registry and the renewal hints.
+	is := ht.host.InternalSettings()
+	is.RegistrySize = 64 * modules.RegistryEntrySize
+	is.RenewalHintWindow = 10
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add an obligation.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish the hints.
+	ht.host.threadedPublishRenewalHints(0)
+	ht.host.mu.RLock()
+	pk := ht.host.publicKey
+	ht.host.mu.RUnlock()
+	eid := modules.DeriveRegistryEntryID(pk, modules.RenewalHintTweak(so.id()))
+	_, srv, found := ht.host.RegistryGet(eid)
+	if !found {
+		t.Fatal("hint wasn't published")
+	}
+	if err := srv.Verify(pk.ToPublicKey()); err != nil {
+		t.Fatal(err)
+	}
+	var hint modules.RenewalHint
+	if err := encoding.Unmarshal(srv.Data, &hint); err != nil {
+		t.Fatal(err)
+	}
+	if hint.ContractID != so.id() || hint.ExpirationHeight != so.expiration() || hint.RenewBeforeHeight != so.expiration()-10 {
+		t.Fatal("unexpected hint", hint)
+	}
+
+	// Publishing the same hint again shouldn't bump the revision.
+	ht.host.threadedPublishRenewalHints(0)
+	_, srv2, _ := ht.host.RegistryGet(eid)
+	if srv2.Revision != srv.Revision {
+		t.Fatal("revision shouldn't change", srv.Revision, srv2.Revision)
+	}
+
+	// Changing the window should update the hint.
+	is.RenewalHintWindow = 5
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	ht.host.threadedPublishRenewalHints(0)
+	_, srv2, _ = ht.host.RegistryGet(eid)
+	if srv2.Revision != srv.Revision+1 {
+		t.Fatal("revision should have been bumped", srv.Revision, srv2.Revision)
+	}
+	if err := encoding.Unmarshal(srv2.Data, &hint); err != nil {
+		t.Fatal(err)
+	}
+	if hint.RenewBeforeHeight != so.expiration()-5 {
+		t.Fatal("unexpected renew before height", hint.RenewBeforeHeight)
+	}
+}
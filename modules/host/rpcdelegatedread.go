@@ -0,0 +1,123 @@
+package host
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errDelegatedReadSectorNotInContract is returned if a delegate requests a
+	// sector which isn't part of the contract its token grants access to.
+	errDelegatedReadSectorNotInContract = errors.New("sector is not part of the contract")
+
+	// errTooManyDelegatedReadSections is returned if a delegate requests more
+	// than modules.MaxDelegatedReadSections sections at once.
+	errTooManyDelegatedReadSections = errors.New("too many sections requested")
+)
+
+// managedRPCDelegatedRead handles the RPC which third parties use to download
+// sectors of a contract the renter of the contract authorized them to access
+// with a DelegatedReadToken. The delegate pays for the download itself.
+func (h *Host) managedRPCDelegatedRead(stream siamux.Stream) error {
+	// Read request
+	var req modules.RPCDelegatedReadRequest
+	err := modules.RPCRead(stream, &req)
+	if err != nil {
+		return errors.AddContext(err, "failed to read DelegatedReadRequest")
+	}
+	if len(req.Sections) > modules.MaxDelegatedReadSections {
+		return errTooManyDelegatedReadSections
+	}
+	for _, sec := range req.Sections {
+		switch {
+		case uint64(sec.Offset)+uint64(sec.Length) > modules.SectorSize:
+			return errRequestOutOfBounds
+		case sec.Length == 0:
+			return errors.New("length cannot be zero")
+		case sec.Offset%crypto.SegmentSize != 0 || sec.Length%crypto.SegmentSize != 0:
+			return errors.New("offset and length must be multiples of SegmentSize")
+		}
+	}
+
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Verify that the request was sent by the delegate and that the token is
+	// valid for the contract.
+	if err := req.VerifySignature(h.PublicKey(), pt.UID); err != nil {
+		return errors.AddContext(err, "invalid request signature")
+	}
+	token := req.Token
+	so, err := h.managedGetStorageObligationSnapshot(token.FileContractID)
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("failed to get storage obligation for contract with id %v", token.FileContractID))
+	}
+	rev := so.RecentRevision()
+	if len(rev.UnlockConditions.PublicKeys) == 0 {
+		return modules.ErrInvalidDelegatedReadToken
+	}
+	if err := token.VerifySignature(rev.UnlockConditions.PublicKeys[0]); err != nil {
+		return err
+	}
+	if h.BlockHeight() >= token.Expiry {
+		return modules.ErrDelegatedReadTokenExpired
+	}
+	contractRoots := make(map[crypto.Hash]struct{}, len(so.SectorRoots()))
+	for _, root := range so.SectorRoots() {
+		contractRoots[root] = struct{}{}
+	}
+	for _, sec := range req.Sections {
+		if _, exists := contractRoots[sec.MerkleRoot]; !exists {
+			return errDelegatedReadSectorNotInContract
+		}
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Check payment.
+	cost := modules.DelegatedReadCost(pt, req.Sections)
+	if pd.Amount().Cmp(cost) < 0 {
+		return modules.ErrInsufficientPaymentForRPC
+	}
+
+	// Refund excessive payment.
+	refund := pd.Amount().Sub(cost)
+	if !refund.IsZero() {
+		err = h.staticAccountManager.callRefund(pd.AccountID(), refund)
+		if err != nil {
+			return errors.AddContext(err, "failed to refund excessive payment")
+		}
+	}
+
+	// Send a response for every section.
+	var roots []crypto.Hash
+	for _, sec := range req.Sections {
+		sectorData, err := h.ReadSector(sec.MerkleRoot)
+		if err != nil {
+			return errors.AddContext(err, "failed to read sector")
+		}
+		roots = append(roots, sec.MerkleRoot)
+		proofStart := int(sec.Offset) / crypto.SegmentSize
+		proofEnd := int(sec.Offset+sec.Length) / crypto.SegmentSize
+		err = modules.RPCWrite(stream, modules.RPCDelegatedReadResponse{
+			Data:        sectorData[sec.Offset : sec.Offset+sec.Length],
+			MerkleProof: crypto.MerkleRangeProof(sectorData, proofStart, proofEnd),
+		})
+		if err != nil {
+			return errors.AddContext(err, "failed to send DelegatedReadResponse")
+		}
+	}
+	h.managedRecordSectorAccesses(modules.HostSectorAuditDelegatedRead, token.FileContractID, token.Delegate, roots)
+	return nil
+}
@@ -0,0 +1,150 @@
+package host
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// managedDelegatedRead downloads sections of sectors using RPCDelegatedRead.
+// The request is signed with the provided delegate key and paid for by
+// ephemeral account.
+func (p *renterHostPair) managedDelegatedRead(token modules.DelegatedReadToken, delegateSK crypto.SecretKey, sections []modules.LoopReadRequestSection) (_ []modules.RPCDelegatedReadResponse, err error) {
+	stream := p.managedNewStream()
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	pt := p.managedPriceTable()
+	req := modules.RPCDelegatedReadRequest{
+		Token:    token,
+		Sections: sections,
+	}
+	req.Signature = crypto.SignHash(req.Hash(p.staticHT.host.PublicKey(), pt.UID), delegateSK)
+	err = modules.RPCWriteAll(stream, modules.RPCDelegatedRead, req, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+	err = p.managedPayByEphemeralAccount(stream, modules.DelegatedReadCost(pt, sections))
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]modules.RPCDelegatedReadResponse, len(sections))
+	for i := range responses {
+		err = modules.RPCRead(stream, &responses[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return responses, nil
+}
+
+// TestRPCDelegatedRead tests downloading the sectors of a contract as a
+// delegate using RPCDelegatedRead.
+func TestRPCDelegatedRead(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rhp.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := rhp.staticHT.host
+
+	// Add a sector to the contract.
+	so, err := h.managedGetStorageObligation(rhp.staticFCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	so.SectorRoots = append(so.SectorRoots, root)
+	so, err = rhp.staticHT.addNewRevision(so, rhp.staticRenterPK, modules.SectorSize, cachedMerkleRoot(so.SectorRoots))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.managedLockStorageObligation(rhp.staticFCID)
+	err = h.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{root: data})
+	h.managedUnlockStorageObligation(rhp.staticFCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund the account.
+	pt := rhp.managedPriceTable()
+	maxBalance := h.managedInternalSettings().MaxEphemeralAccountBalance
+	_, err = rhp.managedFundEphemeralAccount(pt.FundAccountCost.Add(maxBalance), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Authorize a delegate.
+	delegateSK, delegatePK := crypto.GenerateKeyPair()
+	token := modules.DelegatedReadToken{
+		FileContractID: rhp.staticFCID,
+		Delegate:       types.Ed25519PublicKey(delegatePK),
+		Expiry:         h.BlockHeight() + 10,
+	}
+	token.Signature = crypto.SignHash(token.Hash(), rhp.staticRenterSK)
+
+	// Download a section of the sector and verify it.
+	offset, length := uint32(crypto.SegmentSize), uint32(3*crypto.SegmentSize)
+	sections := []modules.LoopReadRequestSection{{MerkleRoot: root, Offset: offset, Length: length}}
+	responses, err := rhp.managedDelegatedRead(token, delegateSK, sections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := responses[0]
+	if !bytes.Equal(resp.Data, data[offset:offset+length]) {
+		t.Fatal("wrong data")
+	}
+	if !crypto.VerifyRangeProof(resp.Data, resp.MerkleProof, int(offset/crypto.SegmentSize), int((offset+length)/crypto.SegmentSize), root) {
+		t.Fatal("invalid proof")
+	}
+
+	// The request has to be signed by the delegate.
+	otherSK, _ := crypto.GenerateKeyPair()
+	_, err = rhp.managedDelegatedRead(token, otherSK, sections)
+	if err == nil || !strings.Contains(err.Error(), "invalid request signature") {
+		t.Fatal("expected invalid request signature, got", err)
+	}
+
+	// The token has to be signed by the renter.
+	forged := token
+	forged.Signature = crypto.SignHash(forged.Hash(), delegateSK)
+	_, err = rhp.managedDelegatedRead(forged, delegateSK, sections)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInvalidDelegatedReadToken.Error()) {
+		t.Fatal("expected ErrInvalidDelegatedReadToken, got", err)
+	}
+
+	// Expired tokens are rejected.
+	expired := token
+	expired.Expiry = h.BlockHeight()
+	expired.Signature = crypto.SignHash(expired.Hash(), rhp.staticRenterSK)
+	_, err = rhp.managedDelegatedRead(expired, delegateSK, sections)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrDelegatedReadTokenExpired.Error()) {
+		t.Fatal("expected ErrDelegatedReadTokenExpired, got", err)
+	}
+
+	// Only sectors of the contract can be downloaded.
+	otherSections := []modules.LoopReadRequestSection{{MerkleRoot: crypto.Hash{1}, Length: crypto.SegmentSize}}
+	_, err = rhp.managedDelegatedRead(token, delegateSK, otherSections)
+	if err == nil || !strings.Contains(err.Error(), errDelegatedReadSectorNotInContract.Error()) {
+		t.Fatal("expected errDelegatedReadSectorNotInContract, got", err)
+	}
+}
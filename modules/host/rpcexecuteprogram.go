@@ -69,6 +69,13 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 
 	// Extract the arguments.
 	fcid, instructions, dataLength := epr.FileContractID, epr.Program, epr.ProgramDataLength
+
+	// The renter is identified by the account paying for the program in the
+	// sector audit log.
+	var renter types.SiaPublicKey
+	if !refundAccount.IsZeroAccount() {
+		renter = refundAccount.SPK()
+	}
 	program := modules.Program(instructions)
 
 	// If the program isn't readonly we need to acquire a lock on the storage
@@ -190,6 +197,9 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		instructionSpecifier := program[numOutputs-1].Specifier
 		readInstruction := instructionSpecifier == modules.SpecifierReadOffset || instructionSpecifier == modules.SpecifierReadSector
 		updateRegistryInstruction := instructionSpecifier == modules.SpecifierUpdateRegistry
+		if readInstruction && output.Error == nil {
+			h.managedRecordSectorAccesses(modules.HostSectorAuditRead, fcid, renter, []crypto.Hash{output.ReadSectorRoot})
+		}
 		if (readInstruction || updateRegistryInstruction) && h.dependencies.Disrupt("CorruptMDMOutput") {
 			// Replace output with same amount of random data.
 			fastrand.Read(output.Output)
@@ -140,9 +140,14 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		} else if id == modules.RPCLoopExit {
 			return nil
 		}
-		if rpcFn, ok := rpcs[id]; !ok {
+		rpcFn, ok := rpcs[id]
+		if !ok {
 			return errors.New("invalid or unknown RPC ID: " + id.String())
-		} else if err := rpcFn(s); err != nil {
+		}
+		start := time.Now()
+		err = rpcFn(s)
+		h.staticOperationCounters.Record(operationRPC(id), time.Since(start), err)
+		if err != nil {
 			return extendErr("incoming RPC"+id.String()+" failed: ", err)
 		}
 	}
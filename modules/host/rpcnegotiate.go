@@ -0,0 +1,41 @@
+package host
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/modules"
+)
+
+// managedRPCNegotiate handles the RPC that negotiates the protocol version and
+// the capabilities used by the renter when talking to the host. The host picks
+// the lower of both protocol versions and the capabilities both sides support.
+func (h *Host) managedRPCNegotiate(stream siamux.Stream) error {
+	// Act like a host which doesn't support the RPC.
+	if h.dependencies.Disrupt("DisableRPCNegotiate") {
+		return fmt.Errorf("%v %v", modules.UnrecognizedRPCErrString, modules.RPCNegotiate)
+	}
+
+	// Read request
+	var req modules.RPCNegotiateRequest
+	err := modules.RPCRead(stream, &req)
+	if err != nil {
+		return errors.AddContext(err, "failed to read NegotiateRequest")
+	}
+
+	version := uint64(modules.RPCProtocolVersion)
+	if req.ProtocolVersion < version {
+		version = req.ProtocolVersion
+	}
+
+	// Send response.
+	err = modules.RPCWrite(stream, modules.RPCNegotiateResponse{
+		ProtocolVersion: version,
+		Capabilities:    req.Capabilities & modules.SupportedHostCapabilities,
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to send NegotiateResponse")
+	}
+	return nil
+}
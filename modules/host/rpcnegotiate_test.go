@@ -0,0 +1,85 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestRPCNegotiate verifies that the host negotiates the lower protocol
+// version and the capabilities supported by both sides.
+func TestRPCNegotiate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	pair, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := pair.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// negotiate is a helper to run the RPC.
+	negotiate := func(req modules.RPCNegotiateRequest) modules.RPCNegotiateResponse {
+		stream := pair.managedNewStream()
+		defer func() {
+			err := stream.Close()
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+		err := modules.RPCWriteAll(stream, modules.RPCNegotiate, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp modules.RPCNegotiateResponse
+		err = modules.RPCRead(stream, &resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// A renter which supports everything gets everything.
+	resp := negotiate(modules.RPCNegotiateRequest{
+		ProtocolVersion: modules.RPCProtocolVersion,
+		Capabilities:    modules.SupportedHostCapabilities,
+	})
+	if resp.ProtocolVersion != modules.RPCProtocolVersion {
+		t.Fatal("unexpected protocol version", resp.ProtocolVersion)
+	}
+	if resp.Capabilities != modules.SupportedHostCapabilities {
+		t.Fatal("unexpected capabilities", resp.Capabilities)
+	}
+
+	// A newer renter with capabilities unknown to the host gets the host's
+	// version and only the known capabilities.
+	resp = negotiate(modules.RPCNegotiateRequest{
+		ProtocolVersion: modules.RPCProtocolVersion + 1,
+		Capabilities:    modules.SupportedHostCapabilities | modules.SupportedHostCapabilities<<1,
+	})
+	if resp.ProtocolVersion != modules.RPCProtocolVersion {
+		t.Fatal("unexpected protocol version", resp.ProtocolVersion)
+	}
+	if resp.Capabilities != modules.SupportedHostCapabilities {
+		t.Fatal("unexpected capabilities", resp.Capabilities)
+	}
+
+	// An older renter gets its own version and capabilities.
+	resp = negotiate(modules.RPCNegotiateRequest{
+		ProtocolVersion: 0,
+		Capabilities:    modules.CapabilityRegistry,
+	})
+	if resp.ProtocolVersion != 0 {
+		t.Fatal("unexpected protocol version", resp.ProtocolVersion)
+	}
+	if resp.Capabilities != modules.CapabilityRegistry {
+		t.Fatal("unexpected capabilities", resp.Capabilities)
+	}
+}
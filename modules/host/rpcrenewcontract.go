@@ -59,7 +59,6 @@ func (h *Host) managedRPCRenewContract(stream siamux.Stream) error {
 	is := h.settings // internal settings
 	ac := is.AcceptingContracts
 	lockedCollateral := h.financialMetrics.LockedStorageCollateral
-	unlockHash := h.unlockHash
 	h.mu.RUnlock()
 
 	// Read request
@@ -130,6 +129,9 @@ func (h *Host) managedRPCRenewContract(stream siamux.Stream) error {
 	// Verify the new contract against the old revision. The final one doesn't
 	// have the size set anymore which we need for collateral and base price
 	// calculations.
+	h.mu.RLock()
+	unlockHash := h.payoutUnlockHash(newContract)
+	h.mu.RUnlock()
 	hostCollateral, err := verifyRenewedContract(so, newContract, currentRevision, bh, is, unlockHash, pt, rpk, hpk, lockedCollateral)
 	if errors.Contains(err, errCollateralBudgetExceeded) {
 		h.staticAlerter.RegisterAlert(modules.AlertIDHostInsufficientCollateral, AlertMSGHostInsufficientCollateral, "", modules.SeverityWarning)
@@ -0,0 +1,95 @@
+package host
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errSectorRootsOffsetOutOfBounds is returned if a renter requests sector
+	// roots starting beyond the end of the contract.
+	errSectorRootsOffsetOutOfBounds = errors.New("sector roots offset is out of bounds")
+
+	// errTooManySectorRoots is returned if a renter requests more than
+	// modules.MaxSectorRootsPerRequest sector roots at once.
+	errTooManySectorRoots = errors.New("too many sector roots requested")
+)
+
+// managedRPCSectorRoots handles the RPC which renters use to fetch the sector
+// roots of a contract without revising it. The renter pays for the requested
+// number of roots and is refunded for the roots it requested beyond the end of
+// the contract.
+func (h *Host) managedRPCSectorRoots(stream siamux.Stream) error {
+	// Read request
+	var req modules.RPCSectorRootsRequest
+	err := modules.RPCRead(stream, &req)
+	if err != nil {
+		return errors.AddContext(err, "failed to read SectorRootsRequest")
+	}
+	if req.NumRoots > modules.MaxSectorRootsPerRequest {
+		return errTooManySectorRoots
+	}
+
+	// Read storage obligation.
+	so, err := h.managedGetStorageObligationSnapshot(req.FileContractID)
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("failed to get storage obligation for contract with id %v", req.FileContractID))
+	}
+	roots := so.SectorRoots()
+	if req.RootOffset > uint64(len(roots)) {
+		return errSectorRootsOffsetOutOfBounds
+	}
+	numRoots := req.NumRoots
+	if remaining := uint64(len(roots)) - req.RootOffset; numRoots > remaining {
+		numRoots = remaining
+	}
+
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Check payment.
+	if pd.Amount().Cmp(modules.SectorRootsCost(pt, req.NumRoots)) < 0 {
+		return modules.ErrInsufficientPaymentForRPC
+	}
+
+	// Refund excessive payment, including the payment for roots beyond the
+	// end of the contract.
+	refund := pd.Amount().Sub(modules.SectorRootsCost(pt, numRoots))
+	if !refund.IsZero() {
+		err = h.staticAccountManager.callRefund(pd.AccountID(), refund)
+		if err != nil {
+			return errors.AddContext(err, "failed to refund excessive payment")
+		}
+	}
+
+	// Build the proof. An empty range doesn't need one.
+	start, end := int(req.RootOffset), int(req.RootOffset+numRoots)
+	var proof []crypto.Hash
+	if numRoots > 0 {
+		proof = crypto.MerkleSectorRangeProof(roots, start, end)
+	}
+
+	// Send response.
+	err = modules.RPCWrite(stream, modules.RPCSectorRootsResponse{
+		Revision:    so.RecentRevision(),
+		SectorRoots: roots[start:end],
+		MerkleProof: proof,
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to send SectorRootsResponse")
+	}
+	return nil
+}
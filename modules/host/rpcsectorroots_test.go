@@ -0,0 +1,146 @@
+package host
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// managedSectorRoots fetches sector roots of the pair's contract using
+// RPCSectorRoots and pays for them by ephemeral account.
+func (p *renterHostPair) managedSectorRoots(offset, numRoots uint64, payment types.Currency) (_ modules.RPCSectorRootsResponse, err error) {
+	stream := p.managedNewStream()
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	pt := p.managedPriceTable()
+	err = modules.RPCWriteAll(stream, modules.RPCSectorRoots, modules.RPCSectorRootsRequest{
+		FileContractID: p.staticFCID,
+		RootOffset:     offset,
+		NumRoots:       numRoots,
+	}, pt.UID)
+	if err != nil {
+		return modules.RPCSectorRootsResponse{}, err
+	}
+	err = p.managedPayByEphemeralAccount(stream, payment)
+	if err != nil {
+		return modules.RPCSectorRootsResponse{}, err
+	}
+
+	var resp modules.RPCSectorRootsResponse
+	err = modules.RPCRead(stream, &resp)
+	return resp, err
+}
+
+// TestRPCSectorRoots tests fetching the sector roots of a contract using
+// RPCSectorRoots.
+func TestRPCSectorRoots(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rhp.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := rhp.staticHT.host
+
+	// Add a few sectors to the contract.
+	so, err := h.managedGetStorageObligation(rhp.staticFCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectors := make(map[crypto.Hash][]byte)
+	for i := 0; i < 7; i++ {
+		data := fastrand.Bytes(int(modules.SectorSize))
+		root := crypto.MerkleRoot(data)
+		so.SectorRoots = append(so.SectorRoots, root)
+		sectors[root] = data
+	}
+	roots := so.SectorRoots
+	so, err = rhp.staticHT.addNewRevision(so, rhp.staticRenterPK, uint64(len(roots))*modules.SectorSize, cachedMerkleRoot(roots))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.managedLockStorageObligation(rhp.staticFCID)
+	err = h.managedModifyStorageObligation(so, nil, sectors)
+	h.managedUnlockStorageObligation(rhp.staticFCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund the account.
+	pt := rhp.managedPriceTable()
+	maxBalance := h.managedInternalSettings().MaxEphemeralAccountBalance
+	_, err = rhp.managedFundEphemeralAccount(pt.FundAccountCost.Add(maxBalance), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fetch the roots in pages and verify them against the revision.
+	var fetched []crypto.Hash
+	for offset := uint64(0); offset < uint64(len(roots)); offset += 3 {
+		resp, err := rhp.managedSectorRoots(offset, 3, modules.SectorRootsCost(pt, 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		start, end := int(offset), int(offset)+len(resp.SectorRoots)
+		if !crypto.VerifySectorRangeProof(resp.SectorRoots, resp.MerkleProof, start, end, resp.Revision.NewFileMerkleRoot) {
+			t.Fatal("invalid proof for page at offset", offset)
+		}
+		fetched = append(fetched, resp.SectorRoots...)
+	}
+	if len(fetched) != len(roots) {
+		t.Fatalf("expected %v roots, got %v", len(roots), len(fetched))
+	}
+	for i := range roots {
+		if fetched[i] != roots[i] {
+			t.Fatal("root mismatch at index", i)
+		}
+	}
+
+	// The last page was clamped and the unused payment was refunded.
+	balanceBefore := h.staticAccountManager.callAccountBalance(rhp.staticAccountID)
+	_, err = rhp.managedSectorRoots(6, 3, modules.SectorRootsCost(pt, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := h.staticAccountManager.callAccountBalance(rhp.staticAccountID)
+	if expected := balanceBefore.Sub(modules.SectorRootsCost(pt, 1)); !balance.Equals(expected) {
+		t.Fatalf("expected balance %v, got %v", expected, balance)
+	}
+
+	// An offset at the end of the contract returns no roots, an offset beyond
+	// it fails.
+	resp, err := rhp.managedSectorRoots(uint64(len(roots)), 1, modules.SectorRootsCost(pt, 1))
+	if err != nil || len(resp.SectorRoots) != 0 {
+		t.Fatal("expected empty page", err, len(resp.SectorRoots))
+	}
+	_, err = rhp.managedSectorRoots(uint64(len(roots))+1, 1, modules.SectorRootsCost(pt, 1))
+	if err == nil || !strings.Contains(err.Error(), errSectorRootsOffsetOutOfBounds.Error()) {
+		t.Fatal("expected errSectorRootsOffsetOutOfBounds, got", err)
+	}
+	_, err = rhp.managedSectorRoots(0, modules.MaxSectorRootsPerRequest+1, modules.SectorRootsCost(pt, 1))
+	if err == nil || !strings.Contains(err.Error(), errTooManySectorRoots.Error()) {
+		t.Fatal("expected errTooManySectorRoots, got", err)
+	}
+
+	// The payment has to cover all requested roots.
+	_, err = rhp.managedSectorRoots(0, 3, modules.SectorRootsCost(pt, 3).Sub64(1))
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInsufficientPaymentForRPC.Error()) {
+		t.Fatal("expected ErrInsufficientPaymentForRPC, got", err)
+	}
+}
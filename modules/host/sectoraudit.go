@@ -0,0 +1,276 @@
+package host
+
+// sectoraudit.go contains the host's sector audit log. If enabled through the
+// internal settings, the host records which renter read or wrote which sector
+// of which contract. The log covers a rolling window and is bounded in size so
+// that it can't grow indefinitely on busy hosts. Hosts can export the accesses
+// of a contract to answer abuse complaints and purge them on request.
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// sectorAuditFilePermissions are the permissions of the sector audit log.
+	sectorAuditFilePermissions = 0600
+
+	// sectorAuditTempSuffix is the suffix of the temporary file the sector
+	// audit log is written to when it is compacted or purged.
+	sectorAuditTempSuffix = "_temp"
+)
+
+var (
+	// sectorAuditWindow is the duration for which entries are kept in the
+	// sector audit log.
+	sectorAuditWindow = build.Select(build.Var{
+		Standard: 90 * 24 * time.Hour,
+		Dev:      24 * time.Hour,
+		Testing:  time.Hour,
+	}).(time.Duration)
+
+	// sectorAuditMaxSize is the size in bytes at which the sector audit log is
+	// compacted. Compacting drops the expired entries and then the oldest
+	// entries until the log is at most half that size.
+	sectorAuditMaxSize = build.Select(build.Var{
+		Standard: int64(64 << 20), // 64 MiB
+		Dev:      int64(4 << 20),  // 4 MiB
+		Testing:  int64(16 << 10), // 16 KiB
+	}).(int64)
+)
+
+// sectorAuditLog is a size-bounded log of sector accesses which are persisted
+// as JSON lines.
+type sectorAuditLog struct {
+	staticPath string
+	mu         sync.Mutex
+}
+
+// newSectorAuditLog creates a sector audit log which is persisted at the
+// provided path.
+func newSectorAuditLog(path string) *sectorAuditLog {
+	return &sectorAuditLog{
+		staticPath: path,
+	}
+}
+
+// renterKey returns the public key of the renter of the storage obligation.
+// The zero key is returned if the obligation has no revision yet.
+func (so storageObligation) renterKey() types.SiaPublicKey {
+	rev, err := so.recentRevision()
+	if err != nil || len(rev.UnlockConditions.PublicKeys) == 0 {
+		return types.SiaPublicKey{}
+	}
+	return rev.UnlockConditions.PublicKeys[0]
+}
+
+// readEntries reads all entries of the log, oldest first. Lines which can't be
+// decoded, e.g. because the host crashed while writing them, are skipped.
+func (sal *sectorAuditLog) readEntries() ([]modules.HostSectorAuditEntry, error) {
+	f, err := os.Open(sal.staticPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.AddContext(err, "failed to open sector audit log")
+	}
+	defer f.Close()
+
+	var entries []modules.HostSectorAuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry modules.HostSectorAuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, errors.AddContext(scanner.Err(), "failed to read sector audit log")
+}
+
+// writeEntries atomically replaces the log with the provided entries.
+func (sal *sectorAuditLog) writeEntries(entries []modules.HostSectorAuditEntry) (err error) {
+	tmp := sal.staticPath + sectorAuditTempSuffix
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, sectorAuditFilePermissions)
+	if err != nil {
+		return errors.AddContext(err, "failed to create sector audit log")
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, os.RemoveAll(tmp))
+		}
+	}()
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return errors.Compose(err, f.Close())
+		}
+	}
+	err = errors.Compose(w.Flush(), f.Sync())
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return errors.AddContext(err, "failed to write sector audit log")
+	}
+	return os.Rename(tmp, sal.staticPath)
+}
+
+// compact drops the expired entries from the log and then the oldest entries
+// until the log is at most half of sectorAuditMaxSize.
+func (sal *sectorAuditLog) compact() error {
+	entries, err := sal.readEntries()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-sectorAuditWindow)
+	var size int64
+	start := len(entries)
+	for start > 0 {
+		entry := entries[start-1]
+		if entry.Timestamp.Before(cutoff) {
+			break
+		}
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return errors.AddContext(err, "failed to marshal sector audit entry")
+		}
+		if size+int64(len(b))+1 > sectorAuditMaxSize/2 {
+			break
+		}
+		size += int64(len(b)) + 1
+		start--
+	}
+	return sal.writeEntries(entries[start:])
+}
+
+// managedAppend appends entries to the log and compacts the log if it grew
+// too large.
+func (sal *sectorAuditLog) managedAppend(entries []modules.HostSectorAuditEntry) error {
+	var buf []byte
+	for _, entry := range entries {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return errors.AddContext(err, "failed to marshal sector audit entry")
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+
+	sal.mu.Lock()
+	defer sal.mu.Unlock()
+	f, err := os.OpenFile(sal.staticPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, sectorAuditFilePermissions)
+	if err != nil {
+		return errors.AddContext(err, "failed to open sector audit log")
+	}
+	_, err = f.Write(buf)
+	err = errors.Compose(err, f.Sync())
+	if err != nil {
+		return errors.Compose(err, f.Close())
+	}
+	fi, err := f.Stat()
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return err
+	}
+	if fi.Size() < sectorAuditMaxSize {
+		return nil
+	}
+	return errors.AddContext(sal.compact(), "failed to compact sector audit log")
+}
+
+// managedEntries returns the unexpired entries of the contract with the
+// provided id, oldest first.
+func (sal *sectorAuditLog) managedEntries(id types.FileContractID) ([]modules.HostSectorAuditEntry, error) {
+	sal.mu.Lock()
+	entries, err := sal.readEntries()
+	sal.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-sectorAuditWindow)
+	matches := []modules.HostSectorAuditEntry{}
+	for _, entry := range entries {
+		if entry.ContractID == id && !entry.Timestamp.Before(cutoff) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches, nil
+}
+
+// managedPurge removes all entries of the contract with the provided id from
+// the log.
+func (sal *sectorAuditLog) managedPurge(id types.FileContractID) error {
+	sal.mu.Lock()
+	defer sal.mu.Unlock()
+	entries, err := sal.readEntries()
+	if err != nil {
+		return err
+	}
+	remaining := entries[:0]
+	for _, entry := range entries {
+		if entry.ContractID != id {
+			remaining = append(remaining, entry)
+		}
+	}
+	return sal.writeEntries(remaining)
+}
+
+// managedRecordSectorAccesses adds an entry for each of the provided sector
+// roots to the sector audit log if the log is enabled. Errors are only logged
+// since a failure to audit an access shouldn't fail the access itself.
+func (h *Host) managedRecordSectorAccesses(accessType string, id types.FileContractID, renter types.SiaPublicKey, roots []crypto.Hash) {
+	if len(roots) == 0 {
+		return
+	}
+	h.mu.RLock()
+	enabled := h.settings.SectorAuditLog
+	height := h.blockHeight
+	h.mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	now := time.Now()
+	entries := make([]modules.HostSectorAuditEntry, 0, len(roots))
+	for _, root := range roots {
+		entries = append(entries, modules.HostSectorAuditEntry{
+			Timestamp:  now,
+			Height:     height,
+			Type:       accessType,
+			SectorRoot: root,
+			ContractID: id,
+			Renter:     renter,
+		})
+	}
+	if err := h.staticSectorAudit.managedAppend(entries); err != nil {
+		h.log.Print("WARN: failed to record sector accesses:", err)
+	}
+}
+
+// SectorAudit returns the recorded sector accesses of the contract with the
+// provided id, oldest first.
+func (h *Host) SectorAudit(id types.FileContractID) ([]modules.HostSectorAuditEntry, error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	return h.staticSectorAudit.managedEntries(id)
+}
+
+// PurgeSectorAudit removes all recorded sector accesses of the contract with
+// the provided id from the sector audit log.
+func (h *Host) PurgeSectorAudit(id types.FileContractID) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	return h.staticSectorAudit.managedPurge(id)
+}
@@ -0,0 +1,181 @@
+package host
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestSectorAudit checks that the host records sector accesses in the sector
+// audit log once it is enabled and that the accesses of a contract can be
+// exported and purged.
+func TestSectorAudit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a storage obligation with a revision signed by a renter.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, renterPK := crypto.GenerateKeyPair()
+	renterKey := types.Ed25519PublicKey(renterPK)
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID: so.id(),
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{renterKey, ht.host.publicKey},
+			},
+			NewRevisionNumber:     1,
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// addSector adds a sector to the obligation.
+	addSector := func() crypto.Hash {
+		sectorRoot, sectorData := randSector()
+		so.SectorRoots = append(so.SectorRoots, sectorRoot)
+		ht.host.managedLockStorageObligation(so.id())
+		err := ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+		ht.host.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sectorRoot
+	}
+
+	// The sector audit log is disabled by default.
+	addSector()
+	entries, err := ht.host.SectorAudit(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatal("expected no entries while the log is disabled", entries)
+	}
+
+	// Enable the log.
+	settings := ht.host.InternalSettings()
+	settings.SectorAuditLog = true
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a sector and read it.
+	root := addSector()
+	ht.host.managedRecordSectorAccesses(modules.HostSectorAuditRead, so.id(), so.renterKey(), []crypto.Hash{root})
+
+	// Record an access of another contract.
+	var otherID types.FileContractID
+	fastrand.Read(otherID[:])
+	ht.host.managedRecordSectorAccesses(modules.HostSectorAuditRead, otherID, types.SiaPublicKey{}, []crypto.Hash{root})
+
+	// Both accesses of the obligation should be exported.
+	entries, err = ht.host.SectorAudit(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries but got %v", len(entries))
+	}
+	for i, typ := range []string{modules.HostSectorAuditWrite, modules.HostSectorAuditRead} {
+		e := entries[i]
+		if e.Type != typ || e.SectorRoot != root || e.ContractID != so.id() || !e.Renter.Equals(renterKey) {
+			t.Fatal("unexpected entry", e)
+		}
+	}
+
+	// Purge the obligation's entries. The other contract's entry remains.
+	err = ht.host.PurgeSectorAudit(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ht.host.SectorAudit(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatal("expected no entries after purging", entries)
+	}
+	entries, err = ht.host.SectorAudit(otherID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry but got %v", len(entries))
+	}
+
+	// Expired entries are not exported.
+	expired := modules.HostSectorAuditEntry{
+		Timestamp:  time.Now().Add(-sectorAuditWindow - time.Minute),
+		Type:       modules.HostSectorAuditRead,
+		SectorRoot: root,
+		ContractID: so.id(),
+	}
+	err = ht.host.staticSectorAudit.managedAppend([]modules.HostSectorAuditEntry{expired})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ht.host.SectorAudit(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatal("expected expired entry to be skipped", entries)
+	}
+
+	// Record accesses until the log is compacted. The log should never exceed
+	// its max size and keep the most recent entries.
+	var last crypto.Hash
+	for i := 0; i < 200; i++ {
+		fastrand.Read(last[:])
+		ht.host.managedRecordSectorAccesses(modules.HostSectorAuditWrite, so.id(), renterKey, []crypto.Hash{last})
+		fi, err := os.Stat(ht.host.staticSectorAudit.staticPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() >= sectorAuditMaxSize {
+			t.Fatalf("log exceeds max size: %v >= %v", fi.Size(), sectorAuditMaxSize)
+		}
+	}
+	entries, err = ht.host.SectorAudit(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || len(entries) == 200 {
+		t.Fatalf("expected log to be compacted but got %v entries", len(entries))
+	}
+	if entries[len(entries)-1].SectorRoot != last {
+		t.Fatal("most recent entry should be kept")
+	}
+}
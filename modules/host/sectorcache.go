@@ -0,0 +1,225 @@
+package host
+
+// sectorcache.go contains the host's sector cache. Renters can send the roots
+// of the sectors they are going to download soon using RPCPrefetchHint, e.g.
+// while streaming a file. The host reads these sectors from disk ahead of time
+// and keeps them in a small LRU cache so that the following reads don't have
+// to wait for the disk.
+
+import (
+	"container/list"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errTooManyPrefetchRoots is returned if a renter sends more than
+	// modules.MaxPrefetchHintRoots roots in a single prefetch hint.
+	errTooManyPrefetchRoots = errors.New("prefetch hint contains too many sector roots")
+
+	// sectorCacheSize is the number of sectors the sector cache holds.
+	sectorCacheSize = build.Select(build.Var{
+		Standard: 32, // 128 MiB
+		Dev:      16,
+		Testing:  4,
+	}).(int)
+
+	// maxConcurrentPrefetches is the maximum number of prefetch hints the
+	// host processes at the same time. Hints which arrive while the host is
+	// busy are dropped.
+	maxConcurrentPrefetches = build.Select(build.Var{
+		Standard: 4,
+		Dev:      2,
+		Testing:  2,
+	}).(int)
+)
+
+type (
+	// sectorCache is an LRU cache of prefetched sectors.
+	sectorCache struct {
+		entries map[crypto.Hash]*list.Element
+		lru     *list.List
+
+		// pending contains the roots of the sectors which are currently
+		// being prefetched.
+		pending map[crypto.Hash]struct{}
+
+		staticCapacity            int
+		staticPrefetchConcurrency chan struct{}
+		mu                        sync.Mutex
+	}
+
+	// sectorCacheEntry is an entry of the sector cache.
+	sectorCacheEntry struct {
+		root crypto.Hash
+		data []byte
+	}
+)
+
+// newSectorCache creates a sector cache which holds up to capacity sectors.
+func newSectorCache(capacity, maxPrefetches int) *sectorCache {
+	return &sectorCache{
+		entries:                   make(map[crypto.Hash]*list.Element),
+		lru:                       list.New(),
+		pending:                   make(map[crypto.Hash]struct{}),
+		staticCapacity:            capacity,
+		staticPrefetchConcurrency: make(chan struct{}, maxPrefetches),
+	}
+}
+
+// add adds a sector to the cache, evicting the least recently used sector if
+// the cache is full.
+func (sc *sectorCache) add(root crypto.Hash, data []byte) {
+	if e, exists := sc.entries[root]; exists {
+		sc.lru.MoveToFront(e)
+		return
+	}
+	sc.entries[root] = sc.lru.PushFront(&sectorCacheEntry{root: root, data: data})
+	for sc.lru.Len() > sc.staticCapacity {
+		oldest := sc.lru.Back()
+		sc.lru.Remove(oldest)
+		delete(sc.entries, oldest.Value.(*sectorCacheEntry).root)
+	}
+}
+
+// managedGet returns a copy of the cached sector with the given root.
+func (sc *sectorCache) managedGet(root crypto.Hash) ([]byte, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	e, exists := sc.entries[root]
+	if !exists {
+		return nil, false
+	}
+	sc.lru.MoveToFront(e)
+	return append([]byte(nil), e.Value.(*sectorCacheEntry).data...), true
+}
+
+// managedLen returns the number of cached sectors.
+func (sc *sectorCache) managedLen() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.lru.Len()
+}
+
+// ReadSector reads a sector from the sector cache or, if it isn't cached,
+// from the storage manager. It shadows the method of the embedded storage
+// manager so that every read of the host benefits from prefetched sectors.
+func (h *Host) ReadSector(root crypto.Hash) ([]byte, error) {
+	// Sectors which were removed since they were prefetched are not served.
+	if data, ok := h.staticSectorCache.managedGet(root); ok && h.StorageManager.HasSector(root) {
+		return data, nil
+	}
+	return h.StorageManager.ReadSector(root)
+}
+
+// managedPrefetch starts reading the sectors of the provided roots in the
+// background and adds them to the sector cache. Sectors which are already
+// cached or which the host doesn't store are skipped. It returns the number of
+// sectors which are going to be prefetched.
+func (h *Host) managedPrefetch(roots []crypto.Hash) int {
+	sc := h.staticSectorCache
+
+	// Prefetching more sectors than fit into the cache would evict the first
+	// prefetched sectors before they are read.
+	var stored []crypto.Hash
+	for _, root := range roots {
+		if len(stored) == sc.staticCapacity {
+			break
+		}
+		if h.StorageManager.HasSector(root) {
+			stored = append(stored, root)
+		}
+	}
+
+	sc.mu.Lock()
+	var toFetch []crypto.Hash
+	for _, root := range stored {
+		_, cached := sc.entries[root]
+		_, pending := sc.pending[root]
+		if cached || pending {
+			continue
+		}
+		toFetch = append(toFetch, root)
+	}
+	if len(toFetch) == 0 {
+		sc.mu.Unlock()
+		return 0
+	}
+	select {
+	case sc.staticPrefetchConcurrency <- struct{}{}:
+	default:
+		// Too many hints are being processed already.
+		sc.mu.Unlock()
+		return 0
+	}
+	for _, root := range toFetch {
+		sc.pending[root] = struct{}{}
+	}
+	sc.mu.Unlock()
+
+	go h.threadedPrefetch(toFetch)
+	return len(toFetch)
+}
+
+// threadedPrefetch reads the sectors of the provided roots in order and adds
+// them to the sector cache.
+func (h *Host) threadedPrefetch(roots []crypto.Hash) {
+	sc := h.staticSectorCache
+	defer func() {
+		sc.mu.Lock()
+		for _, root := range roots {
+			delete(sc.pending, root)
+		}
+		sc.mu.Unlock()
+		<-sc.staticPrefetchConcurrency
+	}()
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	for _, root := range roots {
+		select {
+		case <-h.tg.StopChan():
+			return
+		default:
+		}
+		data, err := h.StorageManager.ReadSector(root)
+		if err != nil {
+			h.log.Debugf("failed to prefetch sector %v: %v", root, err)
+			continue
+		}
+		sc.mu.Lock()
+		sc.add(root, data)
+		delete(sc.pending, root)
+		sc.mu.Unlock()
+	}
+}
+
+// managedRPCPrefetchHint handles the RPC which renters use to tell the host
+// which sectors they are going to download soon.
+func (h *Host) managedRPCPrefetchHint(stream siamux.Stream) error {
+	var req modules.RPCPrefetchHintRequest
+	err := modules.RPCRead(stream, &req)
+	if err != nil {
+		return errors.AddContext(err, "failed to read PrefetchHintRequest")
+	}
+	if len(req.Roots) > modules.MaxPrefetchHintRoots {
+		return errTooManyPrefetchRoots
+	}
+
+	prefetching := h.managedPrefetch(req.Roots)
+	err = modules.RPCWrite(stream, modules.RPCPrefetchHintResponse{
+		Prefetching: uint64(prefetching),
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to send PrefetchHintResponse")
+	}
+	return nil
+}
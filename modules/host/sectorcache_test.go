@@ -0,0 +1,137 @@
+package host
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorCacheLRU checks that the sector cache evicts the least recently
+// used sectors.
+func TestSectorCacheLRU(t *testing.T) {
+	t.Parallel()
+	sc := newSectorCache(2, 1)
+	r1, r2, r3 := crypto.Hash{1}, crypto.Hash{2}, crypto.Hash{3}
+	sc.add(r1, []byte{1})
+	sc.add(r2, []byte{2})
+
+	// Reading r1 makes r2 the least recently used sector.
+	if data, ok := sc.managedGet(r1); !ok || !bytes.Equal(data, []byte{1}) {
+		t.Fatal("unexpected data", data, ok)
+	}
+	sc.add(r3, []byte{3})
+	if _, ok := sc.managedGet(r2); ok {
+		t.Fatal("least recently used sector wasn't evicted")
+	}
+	if _, ok := sc.managedGet(r1); !ok {
+		t.Fatal("recently used sector was evicted")
+	}
+	if sc.managedLen() != 2 {
+		t.Fatal("unexpected cache size", sc.managedLen())
+	}
+
+	// Modifying returned data doesn't modify the cache.
+	data, _ := sc.managedGet(r3)
+	data[0] = 0
+	if data, _ := sc.managedGet(r3); data[0] != 3 {
+		t.Fatal("cached data was modified")
+	}
+}
+
+// TestRPCPrefetchHint checks that the host prefetches the sectors of a
+// prefetch hint.
+func TestRPCPrefetchHint(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rhp.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := rhp.staticHT.host
+
+	// Store a few sectors.
+	var roots []crypto.Hash
+	for i := 0; i < sectorCacheSize; i++ {
+		data := fastrand.Bytes(int(modules.SectorSize))
+		root := crypto.MerkleRoot(data)
+		if err := h.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	// sendHint sends a prefetch hint and returns the number of sectors the
+	// host is prefetching.
+	sendHint := func(roots []crypto.Hash) (uint64, error) {
+		stream := rhp.managedNewStream()
+		defer stream.Close()
+		err := modules.RPCWriteAll(stream, modules.RPCPrefetchHint, modules.RPCPrefetchHintRequest{Roots: roots})
+		if err != nil {
+			return 0, err
+		}
+		var resp modules.RPCPrefetchHintResponse
+		err = modules.RPCRead(stream, &resp)
+		return resp.Prefetching, err
+	}
+
+	// Unknown sectors are not prefetched.
+	unknown := append([]crypto.Hash{{1}}, roots...)
+	prefetching, err := sendHint(unknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefetching != uint64(len(roots)) {
+		t.Fatal("unexpected number of prefetched sectors", prefetching)
+	}
+	err = build.Retry(100, 50*time.Millisecond, func() error {
+		if n := h.staticSectorCache.managedLen(); n != len(roots) {
+			return errors.New("sectors weren't prefetched")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached sectors are not prefetched again and are served from the
+	// cache.
+	prefetching, err = sendHint(roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefetching != 0 {
+		t.Fatal("cached sectors were prefetched again", prefetching)
+	}
+	if _, err := h.ReadSector(roots[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removed sectors are not served from the cache.
+	if err := h.RemoveSector(roots[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.ReadSector(roots[0]); err == nil {
+		t.Fatal("removed sector was served from the cache")
+	}
+
+	// Hints with too many roots are rejected.
+	_, err = sendHint(make([]crypto.Hash, modules.MaxPrefetchHintRoots+1))
+	if err == nil || !strings.Contains(err.Error(), errTooManyPrefetchRoots.Error()) {
+		t.Fatal("expected errTooManyPrefetchRoots but got", err)
+	}
+}
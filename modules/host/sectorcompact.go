@@ -0,0 +1,162 @@
+package host
+
+import (
+	"encoding/json"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// sectorcompact.go contains the compaction of the host's sectors. Renters
+// which trim sectors from their contracts, stale obligations which are pruned
+// and failed updates can leave the storage manager with more references to a
+// sector than there are storage obligations using it. These sectors are never
+// removed, so the used storage of the host only grows until the contracts
+// expire. Compacting the sectors reduces the references of every sector to the
+// number of times it appears in the unresolved storage obligations.
+
+// staleSectorRoots returns the number of sector roots at the end of the
+// sector list of a storage obligation which are no longer needed. Resolved
+// obligations don't need any roots. The roots of unresolved obligations
+// which extend beyond the size of the latest revision are only stale if the
+// remaining roots match the Merkle root of the revision.
+func (so storageObligation) staleSectorRoots() uint64 {
+	if so.ObligationStatus != obligationUnresolved {
+		return uint64(len(so.SectorRoots))
+	}
+	needed := (so.fileSize() + modules.SectorSize - 1) / modules.SectorSize
+	if uint64(len(so.SectorRoots)) <= needed {
+		return 0
+	}
+	if needed == 0 {
+		if so.merkleRoot() != (crypto.Hash{}) {
+			return 0
+		}
+	} else if cachedMerkleRoot(so.SectorRoots[:needed]) != so.merkleRoot() {
+		return 0
+	}
+	return uint64(len(so.SectorRoots)) - needed
+}
+
+// managedCompactObligation removes the stale sector roots from the sector
+// list of a storage obligation. The number of removed roots is returned.
+func (h *Host) managedCompactObligation(id types.FileContractID) (uint64, error) {
+	err := h.managedTryLockStorageObligation(id, obligationLockTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer h.managedUnlockStorageObligation(id)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stale uint64
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		so, err := h.getStorageObligation(tx, id)
+		if err != nil {
+			return err
+		}
+		stale = so.staleSectorRoots()
+		if stale == 0 {
+			return nil
+		}
+		so.SectorRoots = so.SectorRoots[:uint64(len(so.SectorRoots))-stale]
+		if len(so.SectorRoots) == 0 {
+			so.SectorRoots = nil
+		}
+		return putStorageObligation(tx, so)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return stale, nil
+}
+
+// sectorReferences returns the number of times every sector root is
+// referenced by the unresolved storage obligations of the host.
+func (h *Host) sectorReferences() (map[crypto.Hash]uint64, error) {
+	references := make(map[crypto.Hash]uint64)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return errors.AddContext(err, "unable to unmarshal storage obligation")
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			for _, root := range so.SectorRoots {
+				references[root]++
+			}
+			return nil
+		})
+	})
+	return references, err
+}
+
+// CompactSectors removes the stale roots from the sector lists of the
+// storage obligations and afterwards reduces the references of the stored
+// sectors to the references of the unresolved storage obligations. Sectors
+// which aren't referenced anymore are removed from the storage manager.
+func (h *Host) CompactSectors() (modules.HostSectorCompaction, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostSectorCompaction{}, err
+	}
+	defer h.tg.Done()
+
+	// Find the obligations with stale sector roots.
+	var ids []types.FileContractID
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return errors.AddContext(err, "unable to unmarshal storage obligation")
+			}
+			if so.staleSectorRoots() > 0 {
+				ids = append(ids, so.id())
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.HostSectorCompaction{}, err
+	}
+
+	// Rewrite their sector lists. Obligations which are locked for too long
+	// are skipped, their roots are still counted as references below.
+	var hsc modules.HostSectorCompaction
+	for _, id := range ids {
+		dropped, err := h.managedCompactObligation(id)
+		if errors.Contains(err, ErrObligationLocked) {
+			continue
+		} else if err != nil {
+			return hsc, errors.AddContext(err, "unable to rewrite storage obligation")
+		}
+		if dropped > 0 {
+			hsc.ObligationsRewritten++
+			hsc.RootsDropped += dropped
+		}
+	}
+
+	// Compact the sectors while no obligation is updated.
+	h.compactMu.Lock()
+	defer h.compactMu.Unlock()
+	references, err := h.sectorReferences()
+	if err != nil {
+		return hsc, err
+	}
+	hsc.SectorCompaction, err = h.StorageManager.CompactSectors(references)
+	if err != nil {
+		return hsc, errors.AddContext(err, "unable to compact sectors")
+	}
+	h.log.Printf("Compacted sectors: dropped %v roots from %v obligations, removed %v references and reclaimed %v sectors\n", hsc.RootsDropped, hsc.ObligationsRewritten, hsc.ReferencesRemoved, hsc.SectorsReclaimed)
+	if hsc.MissingReferences > 0 {
+		h.log.Printf("WARN: %v sector references of unresolved obligations are missing from the storage manager\n", hsc.MissingReferences)
+	}
+	return hsc, nil
+}
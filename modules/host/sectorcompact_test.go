@@ -0,0 +1,118 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCompactSectors checks that compacting the sectors removes the stale
+// roots of storage obligations and reclaims the sectors which aren't used by
+// any storage obligation.
+func TestCompactSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add an obligation storing two sectors whose revision only covers the
+	// first one, as if the renter trimmed the second sector.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	so.SectorRoots = []crypto.Hash{root1, root2}
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:              so.id(),
+			NewRevisionNumber:     1,
+			NewFileSize:           modules.SectorSize,
+			NewFileMerkleRoot:     cachedMerkleRoot([]crypto.Hash{root1}),
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	err = ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{root1: data1, root2: data2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	// Add a sector which isn't used by any obligation twice.
+	root3, data3 := randSector()
+	for i := 0; i < 2; i++ {
+		if err := ht.host.AddSector(root3, data3); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hsc, err := ht.host.CompactSectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := modules.HostSectorCompaction{
+		ObligationsRewritten: 1,
+		RootsDropped:         1,
+		SectorCompaction: modules.SectorCompaction{
+			ReferencesRemoved: 3,
+			SectorsReclaimed:  2,
+			BytesReclaimed:    2 * modules.SectorSize,
+		},
+	}
+	if hsc != expected {
+		t.Fatalf("unexpected compaction: %+v", hsc)
+	}
+
+	// Only the sector of the obligation is left.
+	if _, err := ht.host.ReadSector(root1); err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range []crypto.Hash{root2, root3} {
+		if ht.host.HasSector(root) {
+			t.Fatal("stale sector wasn't reclaimed")
+		}
+	}
+	err = ht.host.db.View(func(tx *bolt.Tx) error {
+		so, err = ht.host.getStorageObligation(tx, so.id())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(so.SectorRoots) != 1 || so.SectorRoots[0] != root1 {
+		t.Fatal("stale root wasn't removed from the obligation", so.SectorRoots)
+	}
+
+	// Compacting again doesn't change anything.
+	hsc, err = ht.host.CompactSectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hsc != (modules.HostSectorCompaction{}) {
+		t.Fatalf("unexpected compaction: %+v", hsc)
+	}
+}
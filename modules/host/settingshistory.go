@@ -0,0 +1,210 @@
+package host
+
+import (
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// settingsHistoryLimit is the maximum number of settings changes the host
+	// keeps in its history. Once the limit is reached, the oldest changes are
+	// dropped.
+	settingsHistoryLimit = 1000
+
+	// settingsSourceInternal is the source recorded for settings changes that
+	// are made through SetInternalSettings.
+	settingsSourceInternal = "internal"
+
+	// settingsSourceSchedulePrefix is the prefix of the source recorded for
+	// settings changes that were applied from the schedule.
+	settingsSourceSchedulePrefix = "schedule:"
+)
+
+var (
+	// errScheduledSettingsNotFound is returned when trying to cancel a
+	// scheduled settings change that doesn't exist.
+	errScheduledSettingsNotFound = errors.New("scheduled settings change not found")
+
+	// errScheduledSettingsNoFields is returned when trying to schedule a
+	// settings change without any fields.
+	errScheduledSettingsNoFields = errors.New("scheduled settings change needs to change at least one field")
+
+	// errScheduledSettingsHeight is returned when trying to schedule a settings
+	// change for a height that was already reached.
+	errScheduledSettingsHeight = errors.New("scheduled settings change needs to be scheduled for a future block height")
+)
+
+// recordSettingsChange adds a change from the old to the new settings to the
+// settings history. Changes that don't modify any fields are not recorded.
+func (h *Host) recordSettingsChange(oldSettings, newSettings modules.HostInternalSettings, source string) {
+	fields := oldSettings.Diff(newSettings)
+	if len(fields) == 0 {
+		return
+	}
+	h.settingsHistory = append(h.settingsHistory, modules.HostSettingsChange{
+		Timestamp: time.Now(),
+		Height:    h.blockHeight,
+		Source:    source,
+		Fields:    fields,
+		Settings:  newSettings,
+	})
+	if len(h.settingsHistory) > settingsHistoryLimit {
+		h.settingsHistory = h.settingsHistory[len(h.settingsHistory)-settingsHistoryLimit:]
+	}
+}
+
+// dueScheduledSettings returns the scheduled settings changes which are due
+// at the current block height. The changes stay in the schedule until they
+// have been applied.
+func (h *Host) dueScheduledSettings() []modules.HostScheduledSettings {
+	var due []modules.HostScheduledSettings
+	for _, ss := range h.scheduledSettings {
+		if ss.Height <= h.blockHeight {
+			due = append(due, ss)
+		}
+	}
+	return due
+}
+
+// removeScheduledSettings removes the scheduled settings change with the
+// provided id from the schedule. It returns false if the change doesn't exist.
+func (h *Host) removeScheduledSettings(id string) bool {
+	for i, ss := range h.scheduledSettings {
+		if ss.ID == id {
+			h.scheduledSettings = append(h.scheduledSettings[:i], h.scheduledSettings[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// threadedApplyScheduledSettings applies the provided scheduled settings
+// changes in order. A change is only removed from the schedule once it was
+// applied and persisted. Changes which fail to apply are kept and retried at
+// the next block.
+func (h *Host) threadedApplyScheduledSettings(scheduled []modules.HostScheduledSettings) {
+	defer func() {
+		h.mu.Lock()
+		h.applyingScheduledSettings = false
+		h.mu.Unlock()
+	}()
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	for _, ss := range scheduled {
+		// Skip changes which were cancelled in the meantime.
+		h.mu.RLock()
+		pending := false
+		for _, pss := range h.scheduledSettings {
+			pending = pending || pss.ID == ss.ID
+		}
+		h.mu.RUnlock()
+		if !pending {
+			continue
+		}
+
+		settings, err := h.InternalSettings().WithFields(ss.Settings, ss.Fields)
+		if err == nil {
+			err = h.SetInternalSettingsFromSource(settings, settingsSourceSchedulePrefix+ss.Source)
+		}
+		if err != nil {
+			h.log.Printf("WARN: failed to apply scheduled settings change %v, will retry at the next block: %v", ss.ID, err)
+			continue
+		}
+		h.mu.Lock()
+		if h.removeScheduledSettings(ss.ID) {
+			err = h.saveSync()
+		}
+		h.mu.Unlock()
+		if err != nil {
+			h.log.Printf("WARN: failed to save schedule after applying scheduled settings change %v: %v", ss.ID, err)
+			continue
+		}
+		h.log.Printf("Applied scheduled settings change %v, changed fields: %v", ss.ID, ss.Fields)
+	}
+}
+
+// SettingsHistory returns the recorded changes to the host's internal
+// settings, oldest first.
+func (h *Host) SettingsHistory() []modules.HostSettingsChange {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	history := make([]modules.HostSettingsChange, len(h.settingsHistory))
+	copy(history, h.settingsHistory)
+	return history
+}
+
+// ScheduleSettings schedules a change of the provided fields of the internal
+// settings for the given block height. All fields which are not listed in
+// fields are ignored and the current value is kept once the change is
+// applied.
+func (h *Host) ScheduleSettings(height types.BlockHeight, settings modules.HostInternalSettings, fields []string, source string) (modules.HostScheduledSettings, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostScheduledSettings{}, err
+	}
+	defer h.tg.Done()
+
+	if len(fields) == 0 {
+		return modules.HostScheduledSettings{}, errScheduledSettingsNoFields
+	}
+	// Check that all fields are known.
+	if _, err := settings.WithFields(settings, fields); err != nil {
+		return modules.HostScheduledSettings{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if height <= h.blockHeight {
+		return modules.HostScheduledSettings{}, errScheduledSettingsHeight
+	}
+	ss := modules.HostScheduledSettings{
+		ID:        hex.EncodeToString(fastrand.Bytes(8)),
+		Height:    height,
+		Source:    source,
+		Timestamp: time.Now(),
+		Fields:    fields,
+		Settings:  settings,
+	}
+	h.scheduledSettings = append(h.scheduledSettings, ss)
+	sort.SliceStable(h.scheduledSettings, func(i, j int) bool {
+		return h.scheduledSettings[i].Height < h.scheduledSettings[j].Height
+	})
+	if err := h.saveSync(); err != nil {
+		return modules.HostScheduledSettings{}, errors.AddContext(err, "failed to save scheduled settings")
+	}
+	return ss, nil
+}
+
+// ScheduledSettings returns the pending scheduled settings changes, sorted by
+// height.
+func (h *Host) ScheduledSettings() []modules.HostScheduledSettings {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	scheduled := make([]modules.HostScheduledSettings, len(h.scheduledSettings))
+	copy(scheduled, h.scheduledSettings)
+	return scheduled
+}
+
+// CancelScheduledSettings removes a pending scheduled settings change.
+func (h *Host) CancelScheduledSettings(id string) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.removeScheduledSettings(id) {
+		return errScheduledSettingsNotFound
+	}
+	return h.saveSync()
+}
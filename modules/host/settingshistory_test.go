@@ -0,0 +1,140 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
+)
+
+// TestHostSettingsHistoryAndSchedule checks that settings changes are recorded
+// and that scheduled settings changes are applied at the right height.
+func TestHostSettingsHistoryAndSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Change a setting and check that it is recorded.
+	numChanges := len(ht.host.SettingsHistory())
+	settings := ht.host.InternalSettings()
+	settings.WindowSize++
+	err = ht.host.SetInternalSettingsFromSource(settings, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := ht.host.SettingsHistory()
+	if len(history) != numChanges+1 {
+		t.Fatalf("expected %v changes but got %v", numChanges+1, len(history))
+	}
+	change := history[len(history)-1]
+	if change.Source != "test" || len(change.Fields) != 1 || change.Fields[0] != "windowsize" {
+		t.Fatal("unexpected change", change)
+	}
+
+	// Setting the same settings again is not recorded.
+	err = ht.host.SetInternalSettingsFromSource(settings, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ht.host.SettingsHistory()) != numChanges+1 {
+		t.Fatal("unchanged settings shouldn't be recorded")
+	}
+
+	// Scheduling a change for the current height fails.
+	height := ht.cs.Height()
+	scheduled := ht.host.InternalSettings()
+	scheduled.MinStoragePrice = scheduled.MinStoragePrice.Add(types.NewCurrency64(1))
+	_, err = ht.host.ScheduleSettings(height, scheduled, []string{"minstorageprice"}, "test")
+	if !errors.Contains(err, errScheduledSettingsHeight) {
+		t.Fatal("expected errScheduledSettingsHeight but got", err)
+	}
+
+	// Schedule a change for the next two blocks and cancel one of them.
+	ss, err := ht.host.ScheduleSettings(height+2, scheduled, []string{"minstorageprice"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := ht.host.ScheduleSettings(height+1, scheduled, []string{"windowsize"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ht.host.ScheduledSettings()) != 2 {
+		t.Fatal("expected 2 scheduled changes")
+	}
+	if err := ht.host.CancelScheduledSettings(cancelled.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.CancelScheduledSettings(cancelled.ID); !errors.Contains(err, errScheduledSettingsNotFound) {
+		t.Fatal("expected errScheduledSettingsNotFound but got", err)
+	}
+
+	// Change another setting before the scheduled change is applied. It
+	// shouldn't be overwritten by the scheduled change.
+	settings = ht.host.InternalSettings()
+	settings.MaxDuration++
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine blocks until the change is applied.
+	for i := 0; i < 2; i++ {
+		if _, err := ht.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !ht.host.InternalSettings().MinStoragePrice.Equals(scheduled.MinStoragePrice) {
+			return errors.New("scheduled change not applied")
+		}
+		if len(ht.host.ScheduledSettings()) != 0 {
+			return errors.New("scheduled change should have been removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := ht.host.InternalSettings()
+	if current.MaxDuration != settings.MaxDuration || current.WindowSize != settings.WindowSize {
+		t.Fatal("scheduled change modified unrelated settings")
+	}
+	history = ht.host.SettingsHistory()
+	if last := history[len(history)-1]; last.Source != settingsSourceSchedulePrefix+ss.Source {
+		t.Fatal("unexpected source", last.Source)
+	}
+
+	// A change which fails to apply stays scheduled.
+	invalid := ht.host.InternalSettings()
+	invalid.NetAddress = "invalid"
+	failing, err := ht.host.ScheduleSettings(ht.cs.Height()+1, invalid, []string{"netaddress"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := ht.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if pending := ht.host.ScheduledSettings(); len(pending) != 1 || pending[0].ID != failing.ID {
+		t.Fatal("failed change should stay scheduled", pending)
+	}
+	if ht.host.InternalSettings().NetAddress == invalid.NetAddress {
+		t.Fatal("invalid change shouldn't be applied")
+	}
+}
@@ -176,6 +176,11 @@ type storageObligation struct {
 	RevisionConfirmed   bool
 	RevisionConstructed bool
 
+	// ProofConfirmedHeight is the height of the block which contained the
+	// confirmed storage proof. The host's payout matures MaturityDelay blocks
+	// later.
+	ProofConfirmedHeight types.BlockHeight
+
 	h *Host
 }
 
@@ -600,6 +605,8 @@ func (h *Host) queueActionItem(height types.BlockHeight, id types.FileContractID
 func (h *Host) managedAddStorageObligation(so storageObligation) error {
 	var soid types.FileContractID
 	err := func() error {
+		h.compactMu.RLock()
+		defer h.compactMu.RUnlock()
 		h.mu.Lock()
 		defer h.mu.Unlock()
 
@@ -842,7 +849,7 @@ func (h *Host) updateFinancialMetricsUpdateSO(oldSO, newSO storageObligation) {
 // remove multiple instances of the same virtual sector, the virtual sector
 // will need to appear in 'sectorsRemoved' multiple times. Same with
 // 'sectorsGained'.
-func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
+func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) (err error) {
 	// Sanity check - all of the sector data should be modules.SectorSize
 	for _, data := range sectorsGained {
 		if uint64(len(data)) != modules.SectorSize {
@@ -858,6 +865,11 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 		time.Sleep(largeContractUpdateDelay)
 	}
 
+	// Prevent the sectors from being compacted until the sectors were updated
+	// and the obligation was stored.
+	h.compactMu.RLock()
+	defer h.compactMu.RUnlock()
+
 	// Grab a couple of host state facts for sanity checks.
 	soid := so.id()
 	h.mu.Lock()
@@ -884,7 +896,6 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 	// capacity, but will not inhibit the host's ability to submit storage
 	// proofs)
 	var added []crypto.Hash
-	var err error
 	for sectorRoot, data := range sectorsGained {
 		err = h.AddSector(sectorRoot, data)
 		if err != nil {
@@ -903,6 +914,14 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 		return err
 	}
 
+	// Record the gained sectors in the sector audit log once the host is
+	// unlocked again.
+	defer func() {
+		if err == nil {
+			h.managedRecordSectorAccesses(modules.HostSectorAuditWrite, soid, so.renterKey(), added)
+		}
+	}()
+
 	// Lock the host while we update storage obligation and financial metrics.
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -1026,6 +1045,9 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.financialMetrics.PotentialUploadBandwidthRevenue = h.financialMetrics.PotentialUploadBandwidthRevenue.Sub(so.PotentialUploadRevenue)
 		h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Sub(so.RiskedCollateral)
 
+		// Track the payout until it matures.
+		h.addMaturingPayout(so)
+
 		// Add the obligation statistics as actual income.
 		h.financialMetrics.AccountFunding = h.financialMetrics.AccountFunding.Add(so.PotentialAccountFunding)
 		h.financialMetrics.ContractCompensation = h.financialMetrics.ContractCompensation.Add(so.ContractCost)
@@ -1120,6 +1142,9 @@ func (h *Host) resetFinancialMetrics() error {
 		}
 		return nil
 	})
+	for _, mp := range h.maturingPayouts {
+		fm.MaturingPayouts = fm.MaturingPayouts.Add(mp.Value)
+	}
 	if err != nil {
 		h.log.Println(build.ExtendErr("unable to reset host financial metrics:", err))
 		return err
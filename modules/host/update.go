@@ -193,6 +193,7 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 							continue
 						}
 						so.ProofConfirmed = false
+						so.ProofConfirmedHeight = 0
 						err = putStorageObligation(tx, so)
 						if err != nil {
 							continue
@@ -263,6 +264,7 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 							continue
 						}
 						so.ProofConfirmed = true
+						so.ProofConfirmedHeight = h.blockHeight + 1
 						err = putStorageObligation(tx, so)
 						if err != nil {
 							continue
@@ -308,6 +310,25 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 		go h.threadedHandleActionItem(actionItems[i])
 	}
 
+	// Drop the payouts which matured.
+	h.pruneMaturedPayouts()
+
+	// Sample the used storage for the capacity forecast.
+	go h.managedRecordUtilizationSample(h.blockHeight)
+
+	// Forecast whether the wallet can cover the collateral of future
+	// contracts.
+	go h.managedUpdateCollateralForecast(h.blockHeight)
+
+	// Publish renewal hints for the host's contracts.
+	go h.threadedPublishRenewalHints(h.blockHeight)
+
+	// Apply the scheduled settings changes which are due.
+	if due := h.dueScheduledSettings(); len(due) > 0 && !h.applyingScheduledSettings {
+		h.applyingScheduledSettings = true
+		go h.threadedApplyScheduledSettings(due)
+	}
+
 	// Update the host's recent change pointer to point to the most recent
 	// change.
 	h.recentChange = cc.ID
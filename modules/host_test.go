@@ -1,7 +1,10 @@
 package modules
 
 import (
+	"reflect"
 	"testing"
+
+	"go.sia.tech/siad/types"
 )
 
 // TestUnitMaxFileContractSetLenSanity checks that a sensible value for
@@ -19,3 +22,47 @@ func TestUnitMaxFileContractSetLenSanity(t *testing.T) {
 		t.Fatal("MaxfileContractSetLen does not have a sensible value - should be smaller than the TransactionSetSizeLimit")
 	}
 }
+
+// TestHostInternalSettingsDiffWithFields probes the Diff and WithFields
+// methods of the HostInternalSettings.
+func TestHostInternalSettingsDiffWithFields(t *testing.T) {
+	t.Parallel()
+
+	a := HostInternalSettings{
+		AcceptingContracts: true,
+		MinStoragePrice:    types.NewCurrency64(1),
+		NetAddress:         "localhost:9982",
+	}
+	b := a
+	b.MinStoragePrice = types.NewCurrency64(2)
+	b.WindowSize = 10
+
+	// Diff should return the changed fields in the order of the struct.
+	diff := a.Diff(b)
+	if !reflect.DeepEqual(diff, []string{"windowsize", "minstorageprice"}) {
+		t.Fatal("unexpected diff", diff)
+	}
+	if len(a.Diff(a)) != 0 {
+		t.Fatal("settings should not differ from themselves")
+	}
+
+	// Only copy over the storage price.
+	c, err := a.WithFields(b, []string{"minstorageprice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.MinStoragePrice.Equals(b.MinStoragePrice) || c.WindowSize != a.WindowSize {
+		t.Fatal("wrong fields copied", c)
+	}
+	if !reflect.DeepEqual(a.Diff(c), []string{"minstorageprice"}) {
+		t.Fatal("unexpected diff", a.Diff(c))
+	}
+
+	// Unknown fields are rejected.
+	if _, err := a.WithFields(b, []string{"foo"}); err == nil {
+		t.Fatal("expected unknown field to be rejected")
+	}
+	if IsHostInternalSettingsField("foo") || !IsHostInternalSettingsField("windowsize") {
+		t.Fatal("IsHostInternalSettingsField returned wrong result")
+	}
+}
@@ -0,0 +1,103 @@
+package modules
+
+import (
+	"errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// IdentityDir is the name of the directory that is used to store the
+	// identity's keystore.
+	IdentityDir = "identity"
+)
+
+const (
+	// KeyPurposeGateway is the purpose of the key which authenticates the
+	// gateway to its peers.
+	KeyPurposeGateway KeyPurpose = "gateway"
+
+	// KeyPurposeHost is the purpose of the key which the host signs contracts,
+	// announcements and price tables with. It is also the key of the siamux.
+	KeyPurposeHost KeyPurpose = "host"
+)
+
+var (
+	// ErrNoIdentityKey is returned if the identity doesn't have a key for a
+	// purpose yet.
+	ErrNoIdentityKey = errors.New("the identity has no key for this purpose")
+
+	// ErrUnknownKeyPurpose is returned if a key purpose is not known.
+	ErrUnknownKeyPurpose = errors.New("unknown key purpose")
+
+	// KeyPurposes are all purposes the identity manages keys for.
+	KeyPurposes = []KeyPurpose{
+		KeyPurposeGateway,
+		KeyPurposeHost,
+	}
+)
+
+type (
+	// KeyPurpose is the purpose a key of the identity is used for. Every
+	// purpose has its own key so that keys can be rotated independently.
+	KeyPurpose string
+
+	// IdentityKey is the public information about a key of the identity.
+	IdentityKey struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Created   types.Timestamp    `json:"created"`
+
+		// Retired is the time at which the key was replaced. It is zero for
+		// the current key.
+		Retired types.Timestamp `json:"retired"`
+	}
+
+	// IdentityKeys are the keys of the identity for a single purpose.
+	IdentityKeys struct {
+		Purpose KeyPurpose    `json:"purpose"`
+		Current IdentityKey   `json:"current"`
+		Retired []IdentityKey `json:"retired"`
+	}
+
+	// Identity manages the long-term keys of a node in a single encrypted
+	// keystore. Keys which are replaced through rotation or import are kept as
+	// retired keys so that previously signed data can still be verified.
+	Identity interface {
+		// KeyPair returns the current key pair for the purpose.
+		KeyPair(purpose KeyPurpose) (crypto.SecretKey, crypto.PublicKey, error)
+
+		// Keys returns the public information about the keys of all purposes
+		// which have a key.
+		Keys() ([]IdentityKeys, error)
+
+		// SetKeyPair makes the provided key the current key for the purpose,
+		// retiring the previous key. An existing host key can't be replaced.
+		SetKeyPair(purpose KeyPurpose, sk crypto.SecretKey) error
+
+		// RotateKey replaces the current key for the purpose with a newly
+		// generated key and returns its public key.
+		RotateKey(purpose KeyPurpose) (types.SiaPublicKey, error)
+
+		// Export returns the keystore encrypted with the provided password.
+		Export(password string) ([]byte, error)
+
+		// Import adds the keys of an exported keystore to the identity. The
+		// imported current keys replace the current keys of the identity. A
+		// different host key is only imported if replaceHostKey is set.
+		Import(data []byte, password string, replaceHostKey bool) error
+
+		// Close closes the identity.
+		Close() error
+	}
+)
+
+// IsValid returns an error if the purpose is not known.
+func (kp KeyPurpose) IsValid() error {
+	for _, purpose := range KeyPurposes {
+		if kp == purpose {
+			return nil
+		}
+	}
+	return ErrUnknownKeyPurpose
+}
@@ -0,0 +1,348 @@
+// Package identity manages the long-term keys of a Sia node. The keys for
+// gateway authentication and host signing are stored in a single encrypted
+// keystore which supports rotation, export and import.
+package identity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/threadgroup"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errHostKeyRotation is returned when trying to rotate the host key.
+	errHostKeyRotation = errors.New("the host key can't be rotated since the host's contracts and announcements are bound to it")
+
+	// errHostKeyReplacement is returned when trying to replace the host key
+	// without confirming it.
+	errHostKeyReplacement = errors.New("the host key can't be replaced since the host's contracts and announcements are bound to it")
+
+	// errNilPersistDir is returned if no persist dir is provided.
+	errNilPersistDir = errors.New("persistDir cannot be blank")
+)
+
+type (
+	// Identity manages the long-term keys of a node in an encrypted keystore.
+	Identity struct {
+		keys map[modules.KeyPurpose]*purposeKeys
+
+		// staticSecret is the secret the keystore is encrypted with. It is
+		// either the identity password or the content of the key file.
+		staticSecret []byte
+
+		staticPersistDir string
+		staticLog        *persist.Logger
+		staticTG         threadgroup.ThreadGroup
+		mu               sync.Mutex
+	}
+
+	// purposeKeys are the current and the retired keys for a single purpose.
+	purposeKeys struct {
+		Current storedKey   `json:"current"`
+		Retired []storedKey `json:"retired"`
+	}
+
+	// storedKey is a key of the identity as it is stored in the keystore.
+	storedKey struct {
+		SecretKey crypto.SecretKey `json:"secretkey"`
+		Created   types.Timestamp  `json:"created"`
+		Retired   types.Timestamp  `json:"retired"`
+	}
+)
+
+// New creates a new identity which stores its keystore in persistDir. The
+// keystore is encrypted with the SIA_IDENTITY_PASSWORD environment variable
+// or, if it is not set, with a random key stored next to the keystore.
+func New(persistDir string) (*Identity, error) {
+	return newIdentity(persistDir, build.IdentityPassword())
+}
+
+// newIdentity creates a new identity which encrypts its keystore with the
+// provided password.
+func newIdentity(persistDir, password string) (_ *Identity, err error) {
+	if persistDir == "" {
+		return nil, errNilPersistDir
+	}
+	id := &Identity{
+		keys:             make(map[modules.KeyPurpose]*purposeKeys),
+		staticPersistDir: persistDir,
+	}
+
+	// Call stop in the event of a partial startup.
+	defer func() {
+		if err != nil {
+			err = errors.Compose(id.staticTG.Stop(), err)
+		}
+	}()
+
+	// Create the persist directory and the logger.
+	err = os.MkdirAll(persistDir, 0700)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create the persist directory")
+	}
+	id.staticLog, err = persist.NewFileLogger(filepath.Join(persistDir, logFile))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create the logger")
+	}
+	err = id.staticTG.AfterStop(id.staticLog.Close)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to add log close to threadgroup AfterStop")
+	}
+
+	// Load the keystore and make sure that there is a key for the gateway.
+	// The host key is set by the node since it needs to match the key of the
+	// siamux.
+	err = id.load(password)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load the keystore")
+	}
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	if _, exists := id.keys[modules.KeyPurposeGateway]; !exists {
+		sk, _ := crypto.GenerateKeyPair()
+		id.keys[modules.KeyPurposeGateway] = &purposeKeys{Current: newStoredKey(sk)}
+		err = id.saveSync()
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to save the generated gateway key")
+		}
+	}
+	return id, nil
+}
+
+// newStoredKey returns a storedKey for the secret key which was created now.
+func newStoredKey(sk crypto.SecretKey) storedKey {
+	return storedKey{
+		SecretKey: sk,
+		Created:   types.CurrentTimestamp(),
+	}
+}
+
+// info returns the public information about the key.
+func (sk storedKey) info() modules.IdentityKey {
+	return modules.IdentityKey{
+		PublicKey: types.Ed25519PublicKey(sk.SecretKey.PublicKey()),
+		Created:   sk.Created,
+		Retired:   sk.Retired,
+	}
+}
+
+// contains returns whether the secret key is one of the keys.
+func (pk *purposeKeys) contains(sk crypto.SecretKey) bool {
+	if pk.Current.SecretKey == sk {
+		return true
+	}
+	for _, retired := range pk.Retired {
+		if retired.SecretKey == sk {
+			return true
+		}
+	}
+	return false
+}
+
+// replace makes key the current key and retires the previous current key. A
+// retired key which becomes the current key again is no longer retired.
+func (pk *purposeKeys) replace(key storedKey) {
+	previous := pk.Current
+	previous.Retired = types.CurrentTimestamp()
+	pk.Retired = append(removeKey(pk.Retired, key.SecretKey), previous)
+	key.Retired = 0
+	pk.Current = key
+}
+
+// managedSetKey makes key the current key for the purpose, retiring the
+// previous key, and saves the keystore.
+func (id *Identity) managedSetKey(purpose modules.KeyPurpose, key storedKey) error {
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	pk, exists := id.keys[purpose]
+	if !exists {
+		id.keys[purpose] = &purposeKeys{Current: key}
+	} else if pk.Current.SecretKey != key.SecretKey {
+		pk.replace(key)
+	} else {
+		return nil
+	}
+	id.staticLog.Printf("Set the %v key to %v", purpose, types.Ed25519PublicKey(key.SecretKey.PublicKey()))
+	return id.saveSync()
+}
+
+// Close closes the identity.
+func (id *Identity) Close() error {
+	return id.staticTG.Stop()
+}
+
+// KeyPair returns the current key pair for the purpose.
+func (id *Identity) KeyPair(purpose modules.KeyPurpose) (crypto.SecretKey, crypto.PublicKey, error) {
+	if err := id.staticTG.Add(); err != nil {
+		return crypto.SecretKey{}, crypto.PublicKey{}, err
+	}
+	defer id.staticTG.Done()
+	if err := purpose.IsValid(); err != nil {
+		return crypto.SecretKey{}, crypto.PublicKey{}, err
+	}
+
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	pk, exists := id.keys[purpose]
+	if !exists {
+		return crypto.SecretKey{}, crypto.PublicKey{}, modules.ErrNoIdentityKey
+	}
+	return pk.Current.SecretKey, pk.Current.SecretKey.PublicKey(), nil
+}
+
+// Keys returns the public information about the keys of all purposes which
+// have a key.
+func (id *Identity) Keys() ([]modules.IdentityKeys, error) {
+	if err := id.staticTG.Add(); err != nil {
+		return nil, err
+	}
+	defer id.staticTG.Done()
+
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	var keys []modules.IdentityKeys
+	for _, purpose := range modules.KeyPurposes {
+		pk, exists := id.keys[purpose]
+		if !exists {
+			continue
+		}
+		ik := modules.IdentityKeys{
+			Purpose: purpose,
+			Current: pk.Current.info(),
+			Retired: make([]modules.IdentityKey, 0, len(pk.Retired)),
+		}
+		for _, retired := range pk.Retired {
+			ik.Retired = append(ik.Retired, retired.info())
+		}
+		keys = append(keys, ik)
+	}
+	return keys, nil
+}
+
+// SetKeyPair makes the provided key the current key for the purpose, retiring
+// the previous key. The host key can only be set if the identity doesn't have
+// one yet.
+func (id *Identity) SetKeyPair(purpose modules.KeyPurpose, sk crypto.SecretKey) error {
+	if err := id.staticTG.Add(); err != nil {
+		return err
+	}
+	defer id.staticTG.Done()
+	if err := purpose.IsValid(); err != nil {
+		return err
+	}
+	if purpose == modules.KeyPurposeHost {
+		id.mu.Lock()
+		pk, exists := id.keys[purpose]
+		replaced := exists && pk.Current.SecretKey != sk
+		id.mu.Unlock()
+		if replaced {
+			return errHostKeyReplacement
+		}
+	}
+	return id.managedSetKey(purpose, newStoredKey(sk))
+}
+
+// RotateKey replaces the current key for the purpose with a newly generated
+// key and returns its public key. The host key can't be rotated.
+func (id *Identity) RotateKey(purpose modules.KeyPurpose) (types.SiaPublicKey, error) {
+	if err := id.staticTG.Add(); err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	defer id.staticTG.Done()
+	if err := purpose.IsValid(); err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	if purpose == modules.KeyPurposeHost {
+		return types.SiaPublicKey{}, errHostKeyRotation
+	}
+
+	sk, pk := crypto.GenerateKeyPair()
+	err := id.managedSetKey(purpose, newStoredKey(sk))
+	if err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	return types.Ed25519PublicKey(pk), nil
+}
+
+// Export returns the keystore encrypted with the provided password.
+func (id *Identity) Export(password string) ([]byte, error) {
+	if err := id.staticTG.Add(); err != nil {
+		return nil, err
+	}
+	defer id.staticTG.Done()
+	if password == "" {
+		return nil, errEmptyPassword
+	}
+
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	return exportKeystore(id.keys, []byte(password))
+}
+
+// Import adds the keys of an exported keystore to the identity. The imported
+// current keys replace the current keys of the identity, which are retired.
+// Replacing the host key requires replaceHostKey to be set since the host's
+// contracts and announcements are bound to it. A new host key only takes
+// effect once the node is restarted.
+func (id *Identity) Import(data []byte, password string, replaceHostKey bool) error {
+	if err := id.staticTG.Add(); err != nil {
+		return err
+	}
+	defer id.staticTG.Done()
+
+	imported, err := importKeystore(data, []byte(password))
+	if err != nil {
+		return err
+	}
+	for purpose := range imported {
+		if err := purpose.IsValid(); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("invalid purpose '%v'", purpose))
+		}
+	}
+
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	current, hasHostKey := id.keys[modules.KeyPurposeHost]
+	ipk, importsHostKey := imported[modules.KeyPurposeHost]
+	if hasHostKey && importsHostKey && current.Current.SecretKey != ipk.Current.SecretKey && !replaceHostKey {
+		return errHostKeyReplacement
+	}
+	for purpose, ipk := range imported {
+		pk, exists := id.keys[purpose]
+		if !exists {
+			id.keys[purpose] = ipk
+			continue
+		}
+		for _, retired := range ipk.Retired {
+			if !pk.contains(retired.SecretKey) {
+				pk.Retired = append(pk.Retired, retired)
+			}
+		}
+		if pk.Current.SecretKey != ipk.Current.SecretKey {
+			pk.replace(ipk.Current)
+		}
+	}
+	id.staticLog.Println("Imported keystore with keys for", len(imported), "purposes")
+	return id.saveSync()
+}
+
+// removeKey returns the keys without the provided secret key.
+func removeKey(keys []storedKey, sk crypto.SecretKey) []storedKey {
+	filtered := keys[:0]
+	for _, key := range keys {
+		if key.SecretKey != sk {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,251 @@
+package identity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// identityTestDir returns a clean testing directory for the test.
+func identityTestDir(name string) string {
+	path := build.TempDir(modules.IdentityDir, name)
+	if err := os.RemoveAll(path); err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// publicKey returns the public key of the current key for the purpose.
+func publicKey(t *testing.T, id *Identity, purpose modules.KeyPurpose) types.SiaPublicKey {
+	t.Helper()
+	_, pk, err := id.KeyPair(purpose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.Ed25519PublicKey(pk)
+}
+
+// TestIdentityKeys tests setting, rotating and persisting the keys of an
+// identity.
+func TestIdentityKeys(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := identityTestDir(t.Name())
+
+	id, err := newIdentity(dir, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The gateway key is generated, the host key is not.
+	if _, _, err := id.KeyPair(modules.KeyPurposeHost); !errors.Contains(err, modules.ErrNoIdentityKey) {
+		t.Fatal("expected ErrNoIdentityKey, got", err)
+	}
+	if _, _, err := id.KeyPair("unknown"); !errors.Contains(err, modules.ErrUnknownKeyPurpose) {
+		t.Fatal("expected ErrUnknownKeyPurpose, got", err)
+	}
+	gatewayKey := publicKey(t, id, modules.KeyPurposeGateway)
+
+	// Set the host key. It can't be rotated or replaced.
+	hostSK, hostPK := crypto.GenerateKeyPair()
+	if err := id.SetKeyPair(modules.KeyPurposeHost, hostSK); err != nil {
+		t.Fatal(err)
+	}
+	if err := id.SetKeyPair(modules.KeyPurposeHost, hostSK); err != nil {
+		t.Fatal(err)
+	}
+	otherSK, _ := crypto.GenerateKeyPair()
+	if err := id.SetKeyPair(modules.KeyPurposeHost, otherSK); !errors.Contains(err, errHostKeyReplacement) {
+		t.Fatal("expected errHostKeyReplacement, got", err)
+	}
+	if _, err := id.RotateKey(modules.KeyPurposeHost); !errors.Contains(err, errHostKeyRotation) {
+		t.Fatal("expected errHostKeyRotation, got", err)
+	}
+
+	// Rotate the gateway key. The previous key is retired.
+	rotated, err := id.RotateKey(modules.KeyPurposeGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Equals(gatewayKey) || !publicKey(t, id, modules.KeyPurposeGateway).Equals(rotated) {
+		t.Fatal("gateway key wasn't rotated")
+	}
+	keys, err := id.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != len(modules.KeyPurposes) || keys[0].Purpose != modules.KeyPurposeGateway {
+		t.Fatal("unexpected keys", keys)
+	}
+	if len(keys[0].Retired) != 1 || !keys[0].Retired[0].PublicKey.Equals(gatewayKey) || keys[0].Retired[0].Retired == 0 {
+		t.Fatal("previous gateway key wasn't retired", keys[0])
+	}
+	if err := id.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The keys are persisted and can't be decrypted with the wrong password.
+	if _, err := newIdentity(dir, "wrong"); !errors.Contains(err, errWrongPassword) {
+		t.Fatal("expected errWrongPassword, got", err)
+	}
+	id, err = newIdentity(dir, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := id.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if !publicKey(t, id, modules.KeyPurposeGateway).Equals(rotated) {
+		t.Fatal("rotated gateway key wasn't persisted")
+	}
+	if !publicKey(t, id, modules.KeyPurposeHost).Equals(types.Ed25519PublicKey(hostPK)) {
+		t.Fatal("host key wasn't persisted")
+	}
+}
+
+// TestIdentityKeyFile tests that an identity without a password uses a key
+// file which is replaced once a password is set.
+func TestIdentityKeyFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := identityTestDir(t.Name())
+
+	id, err := newIdentity(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gatewayKey := publicKey(t, id, modules.KeyPurposeGateway)
+	if err := id.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, keyFile)); err != nil {
+		t.Fatal("key file wasn't created", err)
+	}
+
+	// Setting a password encrypts the keystore with it and removes the key
+	// file.
+	id, err = newIdentity(dir, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !publicKey(t, id, modules.KeyPurposeGateway).Equals(gatewayKey) {
+		t.Fatal("keys changed")
+	}
+	if err := id.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, keyFile)); !os.IsNotExist(err) {
+		t.Fatal("key file wasn't removed", err)
+	}
+	if _, err := newIdentity(dir, ""); !errors.Contains(err, errWrongPassword) {
+		t.Fatal("expected errWrongPassword, got", err)
+	}
+}
+
+// TestIdentityExportImport tests moving keys between identities.
+func TestIdentityExportImport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := identityTestDir(t.Name())
+
+	src, err := newIdentity(filepath.Join(dir, "src"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := src.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dst, err := newIdentity(filepath.Join(dir, "dst"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := dst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	hostSK, hostPK := crypto.GenerateKeyPair()
+	if err := src.SetKeyPair(modules.KeyPurposeHost, hostSK); err != nil {
+		t.Fatal(err)
+	}
+	dstHostSK, dstHostPK := crypto.GenerateKeyPair()
+	if err := dst.SetKeyPair(modules.KeyPurposeHost, dstHostSK); err != nil {
+		t.Fatal(err)
+	}
+	dstGatewayKey := publicKey(t, dst, modules.KeyPurposeGateway)
+
+	if _, err := src.Export(""); !errors.Contains(err, errEmptyPassword) {
+		t.Fatal("expected errEmptyPassword, got", err)
+	}
+	data, err := src.Export("export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Import(data, "wrong", true); !errors.Contains(err, errWrongPassword) {
+		t.Fatal("expected errWrongPassword, got", err)
+	}
+	if err := dst.Import([]byte("{}"), "export", true); !errors.Contains(err, errBadExport) {
+		t.Fatal("expected errBadExport, got", err)
+	}
+
+	// The host key is only replaced if the replacement is confirmed.
+	if err := dst.Import(data, "export", false); !errors.Contains(err, errHostKeyReplacement) {
+		t.Fatal("expected errHostKeyReplacement, got", err)
+	}
+	if !publicKey(t, dst, modules.KeyPurposeGateway).Equals(dstGatewayKey) {
+		t.Fatal("keys were imported without replacing the host key")
+	}
+	if err := dst.Import(data, "export", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The imported keys are the current keys and the replaced keys are
+	// retired.
+	for _, purpose := range modules.KeyPurposes {
+		if !publicKey(t, dst, purpose).Equals(publicKey(t, src, purpose)) {
+			t.Fatalf("%v key wasn't imported", purpose)
+		}
+	}
+	if !publicKey(t, dst, modules.KeyPurposeHost).Equals(types.Ed25519PublicKey(hostPK)) {
+		t.Fatal("host key wasn't imported")
+	}
+	keys, err := dst.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys[0].Retired) != 1 || !keys[0].Retired[0].PublicKey.Equals(dstGatewayKey) {
+		t.Fatal("replaced gateway key wasn't retired", keys[0])
+	}
+	if len(keys[1].Retired) != 1 || !keys[1].Retired[0].PublicKey.Equals(types.Ed25519PublicKey(dstHostPK)) {
+		t.Fatal("replaced host key wasn't retired", keys[1])
+	}
+
+	// Importing the same keystore again doesn't change anything and doesn't
+	// require a confirmation since the host key is already the current key.
+	if err := dst.Import(data, "export", false); err != nil {
+		t.Fatal(err)
+	}
+	keys, err = dst.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys[0].Retired) != 1 || len(keys[1].Retired) != 1 {
+		t.Fatal("import isn't idempotent", keys)
+	}
+}
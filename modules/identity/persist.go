@@ -0,0 +1,208 @@
+package identity
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// logFile is the name of the log file of the identity.
+	logFile = modules.IdentityDir + ".log"
+
+	// keystoreFile is the name of the file which contains the encrypted
+	// keystore.
+	keystoreFile = "keystore.json"
+
+	// keyFile is the name of the file which contains the random secret the
+	// keystore is encrypted with if no identity password is set.
+	keyFile = "keystore.key"
+
+	// saltSize is the size of the salt which is combined with the secret to
+	// derive the encryption key of a keystore.
+	saltSize = 32
+)
+
+var (
+	// errBadExport is returned when importing data which isn't an exported
+	// keystore.
+	errBadExport = errors.New("data is not an exported keystore")
+
+	// errEmptyPassword is returned when exporting or importing a keystore
+	// without a password.
+	errEmptyPassword = errors.New("password cannot be empty")
+
+	// errWrongPassword is returned if a keystore can't be decrypted.
+	errWrongPassword = errors.New("unable to decrypt the keystore with the provided password")
+
+	// exportMetadata is the metadata of exported keystores.
+	exportMetadata = persist.Metadata{
+		Header:  "Sia Identity Export",
+		Version: "1.5.6",
+	}
+
+	// keystoreMetadata is the metadata of the keystore file.
+	keystoreMetadata = persist.Metadata{
+		Header:  "Sia Identity Keystore",
+		Version: "1.5.6",
+	}
+)
+
+type (
+	// sealedKeystore is an encrypted keystore as it is stored on disk.
+	sealedKeystore struct {
+		Salt       []byte            `json:"salt"`
+		Ciphertext crypto.Ciphertext `json:"ciphertext"`
+	}
+
+	// exportedKeystore is an encrypted keystore as it is exported.
+	exportedKeystore struct {
+		Header     string            `json:"header"`
+		Version    string            `json:"version"`
+		Salt       []byte            `json:"salt"`
+		Ciphertext crypto.Ciphertext `json:"ciphertext"`
+	}
+)
+
+// sealKeys encrypts the keys with a key derived from the secret and a random
+// salt.
+func sealKeys(keys map[modules.KeyPurpose]*purposeKeys, secret []byte) (sealedKeystore, error) {
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return sealedKeystore{}, err
+	}
+	salt := fastrand.Bytes(saltSize)
+	key := crypto.NewWalletKey(crypto.HashAll(salt, secret))
+	return sealedKeystore{
+		Salt:       salt,
+		Ciphertext: key.EncryptBytes(plaintext),
+	}, nil
+}
+
+// openKeys decrypts the keys of a sealed keystore.
+func openKeys(sealed sealedKeystore, secret []byte) (map[modules.KeyPurpose]*purposeKeys, error) {
+	key := crypto.NewWalletKey(crypto.HashAll(sealed.Salt, secret))
+	plaintext, err := key.DecryptBytes(sealed.Ciphertext)
+	if err != nil {
+		return nil, errWrongPassword
+	}
+	keys := make(map[modules.KeyPurpose]*purposeKeys)
+	err = json.Unmarshal(plaintext, &keys)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to decode the keystore")
+	}
+	return keys, nil
+}
+
+// exportKeystore returns the keys encrypted with the password.
+func exportKeystore(keys map[modules.KeyPurpose]*purposeKeys, password []byte) ([]byte, error) {
+	sealed, err := sealKeys(keys, password)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(exportedKeystore{
+		Header:     exportMetadata.Header,
+		Version:    exportMetadata.Version,
+		Salt:       sealed.Salt,
+		Ciphertext: sealed.Ciphertext,
+	})
+}
+
+// importKeystore decrypts the keys of an exported keystore.
+func importKeystore(data []byte, password []byte) (map[modules.KeyPurpose]*purposeKeys, error) {
+	if len(password) == 0 {
+		return nil, errEmptyPassword
+	}
+	var exported exportedKeystore
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, errors.Compose(errBadExport, err)
+	}
+	if exported.Header != exportMetadata.Header {
+		return nil, errBadExport
+	} else if exported.Version != exportMetadata.Version {
+		return nil, errors.AddContext(persist.ErrBadVersion, "unable to import keystore")
+	}
+	return openKeys(sealedKeystore{
+		Salt:       exported.Salt,
+		Ciphertext: exported.Ciphertext,
+	}, password)
+}
+
+// keyFilePath returns the path of the key file.
+func (id *Identity) keyFilePath() string {
+	return filepath.Join(id.staticPersistDir, keyFile)
+}
+
+// load loads the keystore from disk. It is decrypted with the password or,
+// if that fails, with the key file. A keystore which was encrypted with the
+// key file is encrypted with the password instead once a password is set.
+func (id *Identity) load(password string) error {
+	// Collect the secrets the keystore might be encrypted with.
+	var secrets [][]byte
+	if password != "" {
+		secrets = append(secrets, []byte(password))
+	}
+	keyFileSecret, err := ioutil.ReadFile(id.keyFilePath())
+	if err == nil {
+		secrets = append(secrets, keyFileSecret)
+	} else if !os.IsNotExist(err) {
+		return errors.AddContext(err, "unable to read the key file")
+	}
+
+	// Start a new keystore if there isn't one yet. Without a password it is
+	// encrypted with a random secret stored in the key file.
+	var sealed sealedKeystore
+	err = persist.LoadJSON(keystoreMetadata, &sealed, filepath.Join(id.staticPersistDir, keystoreFile))
+	if os.IsNotExist(err) {
+		if len(secrets) > 0 {
+			id.staticSecret = secrets[0]
+			return nil
+		}
+		id.staticSecret = fastrand.Bytes(32)
+		err = ioutil.WriteFile(id.keyFilePath(), id.staticSecret, 0600)
+		return errors.AddContext(err, "unable to write the key file")
+	} else if err != nil {
+		return err
+	}
+
+	for i, secret := range secrets {
+		keys, err := openKeys(sealed, secret)
+		if errors.Contains(err, errWrongPassword) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		id.keys = keys
+		id.staticSecret = secrets[0]
+		if i == 0 {
+			return nil
+		}
+		id.staticLog.Println("Encrypting the keystore with the identity password instead of the key file")
+		err = id.saveSync()
+		if err != nil {
+			return err
+		}
+		return os.Remove(id.keyFilePath())
+	}
+	if password == "" {
+		return errors.AddContext(errWrongPassword, "the keystore is encrypted with a password, set it using SIA_IDENTITY_PASSWORD")
+	}
+	return errWrongPassword
+}
+
+// saveSync encrypts the keystore and saves it to disk.
+func (id *Identity) saveSync() error {
+	sealed, err := sealKeys(id.keys, id.staticSecret)
+	if err != nil {
+		return err
+	}
+	return persist.SaveJSON(keystoreMetadata, sealed, filepath.Join(id.staticPersistDir, keystoreFile))
+}
@@ -0,0 +1,158 @@
+package modules
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// IngressProofAccount is the type of an ingress proof which is signed by
+	// the key of an ephemeral account with a positive balance.
+	IngressProofAccount = types.NewSpecifier("IngressAccount")
+
+	// IngressProofContract is the type of an ingress proof which is signed by
+	// the renter's key of an active contract.
+	IngressProofContract = types.NewSpecifier("IngressContract")
+
+	// IngressProofPuzzle is the type of an ingress proof which contains the
+	// solution of a small proof of work puzzle. It allows renters without a
+	// contract or a funded account to bootstrap.
+	IngressProofPuzzle = types.NewSpecifier("IngressPuzzle")
+)
+
+var (
+	// IngressProofWindow is the maximum difference between the timestamp of
+	// an ingress proof and the host's time.
+	IngressProofWindow = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// IngressPuzzleDifficulty is the number of leading zero bits the hash of
+	// a puzzle proof needs to have.
+	IngressPuzzleDifficulty = build.Select(build.Var{
+		Dev:      12,
+		Standard: 16,
+		Testing:  4,
+	}).(int)
+)
+
+var (
+	// ErrInvalidIngressProof is returned by the host if a renter sends an
+	// invalid ingress proof.
+	ErrInvalidIngressProof = errors.New("invalid ingress proof")
+
+	// ErrIngressProofExpired is returned by the host if the timestamp of an
+	// ingress proof is outside of the IngressProofWindow.
+	ErrIngressProofExpired = errors.New("ingress proof expired")
+)
+
+// RPCIngressProof is sent by the renter after the RPC id of an expensive RPC
+// if the host enabled ingress protection. It proves that the renter either
+// has a funded ephemeral account or an active contract with the host, or it
+// contains the solution of a small puzzle. The host accepts every proof only
+// once, so the Nonce of signed proofs is random and puzzles start searching
+// for a solution at a random Nonce.
+type RPCIngressProof struct {
+	Type       types.Specifier
+	Timestamp  int64
+	AccountID  AccountID
+	ContractID types.FileContractID
+	Nonce      uint64
+	Signature  crypto.Signature
+}
+
+// RPCRequiresIngressProof returns whether the host requires an ingress proof
+// for the RPC with the given id if it enabled ingress protection.
+func RPCRequiresIngressProof(rpcID types.Specifier) bool {
+	switch rpcID {
+	case RPCDelegatedRead, RPCExecuteProgram, RPCLatestRevision, RPCPrefetchHint, RPCSectorRoots, RPCUpdatePriceTable:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewIngressAccountProof creates an ingress proof signed by the key of an
+// ephemeral account.
+func NewIngressAccountProof(id AccountID, sk crypto.SecretKey, hostKey types.SiaPublicKey, rpcID types.Specifier) RPCIngressProof {
+	p := RPCIngressProof{
+		Type:      IngressProofAccount,
+		Timestamp: time.Now().Unix(),
+		AccountID: id,
+		Nonce:     fastrand.Uint64n(math.MaxUint64),
+	}
+	p.Signature = crypto.SignHash(p.Hash(hostKey, rpcID), sk)
+	return p
+}
+
+// NewIngressContractProof creates an ingress proof signed by the renter's key
+// of a contract.
+func NewIngressContractProof(fcid types.FileContractID, sk crypto.SecretKey, hostKey types.SiaPublicKey, rpcID types.Specifier) RPCIngressProof {
+	p := RPCIngressProof{
+		Type:       IngressProofContract,
+		Timestamp:  time.Now().Unix(),
+		ContractID: fcid,
+		Nonce:      fastrand.Uint64n(math.MaxUint64),
+	}
+	p.Signature = crypto.SignHash(p.Hash(hostKey, rpcID), sk)
+	return p
+}
+
+// NewIngressPuzzleProof solves the ingress puzzle with the given difficulty.
+func NewIngressPuzzleProof(hostKey types.SiaPublicKey, rpcID types.Specifier, difficulty int) RPCIngressProof {
+	p := RPCIngressProof{
+		Type:      IngressProofPuzzle,
+		Timestamp: time.Now().Unix(),
+		Nonce:     fastrand.Uint64n(math.MaxUint64),
+	}
+	for !p.VerifyPuzzle(hostKey, rpcID, difficulty) {
+		p.Nonce++
+	}
+	return p
+}
+
+// Hash returns the hash of the proof which is signed by account and contract
+// proofs. It includes the host's key and the RPC id to prevent the proof from
+// being used for other hosts or RPCs.
+func (p RPCIngressProof) Hash(hostKey types.SiaPublicKey, rpcID types.Specifier) crypto.Hash {
+	return crypto.HashAll(p.Type, p.Timestamp, p.AccountID, p.ContractID, p.Nonce, hostKey, rpcID)
+}
+
+// VerifyPuzzle returns whether the hash of the proof has at least difficulty
+// leading zero bits.
+func (p RPCIngressProof) VerifyPuzzle(hostKey types.SiaPublicKey, rpcID types.Specifier, difficulty int) bool {
+	h := p.Hash(hostKey, rpcID)
+	zeros := 0
+	for _, b := range h {
+		zeros += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return zeros >= difficulty
+}
+
+// VerifySignature verifies the signature of an account or contract proof.
+func (p RPCIngressProof) VerifySignature(pk crypto.PublicKey, hostKey types.SiaPublicKey, rpcID types.Specifier) error {
+	return crypto.VerifyHash(p.Hash(hostKey, rpcID), pk, p.Signature)
+}
+
+// VerifyTimestamp checks that the proof's timestamp is within the
+// IngressProofWindow of now.
+func (p RPCIngressProof) VerifyTimestamp(now time.Time) error {
+	ts := time.Unix(p.Timestamp, 0)
+	if ts.Before(now.Add(-IngressProofWindow)) || ts.After(now.Add(IngressProofWindow)) {
+		return ErrIngressProofExpired
+	}
+	return nil
+}
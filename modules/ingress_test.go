@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestIngressProof tests creating and verifying ingress proofs.
+func TestIngressProof(t *testing.T) {
+	_, hpk := crypto.GenerateKeyPair()
+	hostKey := types.Ed25519PublicKey(hpk)
+	rpcID := RPCUpdatePriceTable
+
+	// Account proofs are signed by the account's key for a specific RPC.
+	id, sk := NewAccountID()
+	proof := NewIngressAccountProof(id, sk, hostKey, rpcID)
+	if err := proof.VerifySignature(id.PK(), hostKey, rpcID); err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.VerifySignature(id.PK(), hostKey, RPCExecuteProgram); err == nil {
+		t.Fatal("proof shouldn't be valid for a different RPC")
+	}
+	_, otherHPK := crypto.GenerateKeyPair()
+	if err := proof.VerifySignature(id.PK(), types.Ed25519PublicKey(otherHPK), rpcID); err == nil {
+		t.Fatal("proof shouldn't be valid for a different host")
+	}
+
+	// The timestamp needs to be within the window.
+	now := time.Unix(proof.Timestamp, 0)
+	if err := proof.VerifyTimestamp(now); err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.VerifyTimestamp(now.Add(IngressProofWindow + time.Second)); err != ErrIngressProofExpired {
+		t.Fatal("expected ErrIngressProofExpired, got", err)
+	}
+	if err := proof.VerifyTimestamp(now.Add(-IngressProofWindow - time.Second)); err != ErrIngressProofExpired {
+		t.Fatal("expected ErrIngressProofExpired, got", err)
+	}
+
+	// Solved puzzles have the required number of leading zero bits.
+	difficulty := 8
+	proof = NewIngressPuzzleProof(hostKey, rpcID, difficulty)
+	if !proof.VerifyPuzzle(hostKey, rpcID, difficulty) {
+		t.Fatal("puzzle wasn't solved")
+	}
+	if h := proof.Hash(hostKey, rpcID); h[0] != 0 {
+		t.Fatal("hash of solved puzzle should start with a zero byte", h)
+	}
+	if proof.VerifyPuzzle(hostKey, rpcID, 256+1) {
+		t.Fatal("puzzle shouldn't be solved for an impossible difficulty")
+	}
+}
+
+// TestRPCRequiresIngressProof probes RPCRequiresIngressProof.
+func TestRPCRequiresIngressProof(t *testing.T) {
+	for _, rpcID := range []types.Specifier{RPCDelegatedRead, RPCExecuteProgram, RPCLatestRevision, RPCPrefetchHint, RPCSectorRoots, RPCUpdatePriceTable} {
+		if !RPCRequiresIngressProof(rpcID) {
+			t.Fatal("expected RPC to require an ingress proof", rpcID)
+		}
+	}
+	for _, rpcID := range []types.Specifier{RPCAccountBalance, RPCFundAccount, RPCNegotiate, RPCRenewContract} {
+		if RPCRequiresIngressProof(rpcID) {
+			t.Fatal("expected RPC to not require an ingress proof", rpcID)
+		}
+	}
+}
@@ -343,6 +343,10 @@ type (
 		Version        string `json:"version"`
 
 		SiaMuxPort string `json:"siamuxport"`
+
+		// IngressProtection indicates that the host requires an ingress proof
+		// for expensive RPCs. See RPCIngressProof.
+		IngressProtection bool `json:"ingressprotection"`
 	}
 
 	// HostOldExternalSettings are the pre-v1.4.0 host settings.
@@ -45,6 +45,20 @@ func (na NetAddress) Port() string {
 	return port
 }
 
+// Family returns the address family of the address, or AddressFamilyAny if
+// the host of the address is not an IP address.
+func (na NetAddress) Family() AddressFamily {
+	ip := net.ParseIP(na.Host())
+	switch {
+	case ip.To4() != nil:
+		return AddressFamilyIPv4
+	case ip.To16() != nil:
+		return AddressFamilyIPv6
+	default:
+		return AddressFamilyAny
+	}
+}
+
 // IsLoopback returns true for IP addresses that are on the same machine.
 func (na NetAddress) IsLoopback() bool {
 	host, _, err := net.SplitHostPort(string(na))
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"io"
+	"strings"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
@@ -35,6 +36,12 @@ var (
 	// price table.
 	ErrExpiredRPCPriceTable = errors.New("Expired RPC price table, ensure you have the latest prices by calling the updatePriceTable RPC.")
 
+	// ErrEphemeralAccountSupplyReached occurs when a deposit would push the
+	// aggregate balance of all of the host's ephemeral accounts over the
+	// host's maxephemeralaccountsupply. The deposit can be retried once other
+	// accounts were drained or expired.
+	ErrEphemeralAccountSupplyReached = errors.New("ephemeral account supply cap reached, try again later")
+
 	// ErrWithdrawalsInactive occurs when the host is not synced yet. If that is
 	// the case the account manager does not allow trading money from the
 	// ephemeral accounts.
@@ -268,3 +275,11 @@ func NewPayByEphemeralAccountRequest(account AccountID, expiry types.BlockHeight
 		Signature: sig,
 	}
 }
+
+// IsEphemeralAccountSupplyReachedErr is a helper function that verifies
+// whether the given error indicates the host rejected a deposit because its
+// ephemeral account supply cap was reached. Since the error is returned by the
+// host over the wire, it is matched by its message.
+func IsEphemeralAccountSupplyReachedErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), ErrEphemeralAccountSupplyReached.Error())
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
@@ -183,3 +184,28 @@ func TestAccountIDCompatSiaMarhsal(t *testing.T) {
 		t.Fatal("persistence doesn't match")
 	}
 }
+
+// TestIsEphemeralAccountSupplyReachedErr is a small unit test that verifies
+// the functionality of the `IsEphemeralAccountSupplyReachedErr` helper.
+func TestIsEphemeralAccountSupplyReachedErr(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("err"), false},
+		{ErrWithdrawalsInactive, false},
+		{ErrEphemeralAccountSupplyReached, true},
+		{errors.AddContext(ErrEphemeralAccountSupplyReached, "err"), true},
+		{errors.Compose(errors.New("err"), ErrEphemeralAccountSupplyReached), true},
+		{errors.New(ErrEphemeralAccountSupplyReached.Error()), true},
+	}
+	for _, test := range tests {
+		actual := IsEphemeralAccountSupplyReachedErr(test.err)
+		if actual != test.expected {
+			t.Fatal("unexpected", test.err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -26,6 +27,12 @@ const (
 	FileIDVersion = 1
 )
 
+var (
+	// RenewalHintSpecifier is the specifier used to derive the tweaks of the
+	// registry entries hosts use to publish renewal hints.
+	RenewalHintSpecifier = types.NewSpecifier("RenewalHint")
+)
+
 // RoundRegistrySize is a helper to correctly round up the size of a registry to
 // the closest valid one.
 func RoundRegistrySize(size uint64) uint64 {
@@ -96,3 +103,17 @@ func (entry SignedRegistryValue) Verify(pk crypto.PublicKey) error {
 func (entry RegistryValue) hash() crypto.Hash {
 	return crypto.HashAll(entry.Tweak, entry.Data, entry.Revision)
 }
+
+// RenewalHint is a hint published by a host in its own registry to remind a
+// renter that one of its contracts with the host should be renewed.
+type RenewalHint struct {
+	ContractID        types.FileContractID
+	ExpirationHeight  types.BlockHeight
+	RenewBeforeHeight types.BlockHeight
+}
+
+// RenewalHintTweak returns the tweak of the registry entry a host uses to
+// publish the renewal hint for the contract with the given id.
+func RenewalHintTweak(fcid types.FileContractID) crypto.Hash {
+	return crypto.HashAll(RenewalHintSpecifier, fcid)
+}
@@ -2,13 +2,16 @@ package modules
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/crypto/blake2b"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -39,6 +42,7 @@ type WorkerPool interface {
 // Worker is a minimal interface for a single worker. It's used to be able to
 // use workers within the contractor.
 type Worker interface {
+	ReadRegistry(ctx context.Context, spk types.SiaPublicKey, tweak crypto.Hash) (*SignedRegistryValue, error)
 	RenewContract(ctx context.Context, fcid types.FileContractID, params ContractParams, txnBuilder TransactionBuilder) (RenterContract, []types.Transaction, error)
 }
 
@@ -69,6 +73,10 @@ var (
 	// available.
 	ErrNotEnoughWorkersInWorkerPool = errors.New("not enough workers in worker pool")
 
+	// ErrRenterReadOnly is returned when trying to modify the renter's files
+	// or allowance while the renter is in read-only mode.
+	ErrRenterReadOnly = errors.New("renter is in read-only mode")
+
 	// PriceEstimationScope is the number of hosts that get queried by the
 	// renter when providing price estimates. Especially for the 'Standard'
 	// variable, there should be congruence with the number of contracts being
@@ -159,6 +167,18 @@ const (
 	DefaultFilePerm = 0644
 )
 
+// Stream cache eviction policies.
+const (
+	// StreamCachePolicyAffinity keeps the cached data of a recently streamed
+	// file for a grace period after its stream was closed, even if that
+	// exceeds the stream cache's size.
+	StreamCachePolicyAffinity = "affinity"
+
+	// StreamCachePolicyLRU evicts the cached data of closed streams, least
+	// recently closed first, as soon as the stream cache exceeds its size.
+	StreamCachePolicyLRU = "lru"
+)
+
 // String returns the string value for the FilterMode
 func (fm FilterMode) String() string {
 	switch fm {
@@ -228,6 +248,10 @@ const (
 	// StreamUploadSize is the size of downloaded in a single streaming upload
 	// request.
 	StreamUploadSize = uint64(1 << 16) // 64 KiB
+
+	// DefaultURLUploadMaxSize is the default limit on the amount of data the
+	// renter fetches from a remote URL for a single upload.
+	DefaultURLUploadMaxSize = uint64(1 << 36) // 64 GiB
 )
 
 type (
@@ -235,6 +259,10 @@ type (
 	// download history.
 	DownloadID string
 
+	// URLUploadID is a unique identifier used to identify uploads from a
+	// remote URL within the url upload history.
+	URLUploadID string
+
 	// CombinedChunkID is a unique identifier for a combined chunk which makes up
 	// part of its filename on disk.
 	CombinedChunkID string
@@ -309,6 +337,48 @@ func (a Allowance) Active() bool {
 	return a.Period != 0
 }
 
+// AllowanceProfile is a named allowance preset which can be activated to
+// replace the renter's allowance.
+type AllowanceProfile struct {
+	Name      string    `json:"name"`
+	Allowance Allowance `json:"allowance"`
+
+	// Active indicates whether the renter's allowance was activated from the
+	// profile and hasn't been changed since.
+	Active bool `json:"active"`
+}
+
+// AllowanceProfileDiff is a preview of the changes to the renter's contracts
+// which activating an allowance profile would cause.
+type AllowanceProfileDiff struct {
+	Profile string `json:"profile"`
+
+	// ChangedFields are the json names of the allowance fields which differ
+	// between the current allowance and the profile.
+	ChangedFields []string `json:"changedfields"`
+
+	// GoodForUploadContracts is the number of contracts which are currently
+	// good for upload.
+	GoodForUploadContracts uint64 `json:"goodforuploadcontracts"`
+
+	// ContractsToForm is the number of contracts which would be formed to
+	// reach the profile's number of hosts.
+	ContractsToForm uint64 `json:"contractstoform"`
+
+	// ContractsToDisable is the number of good for upload contracts which
+	// would no longer be used for uploads because they exceed the profile's
+	// number of hosts.
+	ContractsToDisable uint64 `json:"contractstodisable"`
+
+	// ContractsToRenew are the contracts which would be renewed by the next
+	// contract maintenance because they are within the profile's renew window.
+	ContractsToRenew []types.FileContractID `json:"contractstorenew"`
+
+	// EndHeight is the end height of contracts which would be formed or
+	// renewed after activating the profile.
+	EndHeight types.BlockHeight `json:"endheight"`
+}
+
 // ContractUtility contains metrics internal to the contractor that reflect the
 // utility of a given contract.
 type ContractUtility struct {
@@ -413,6 +483,87 @@ type DownloadInfo struct {
 	StartTime            time.Time `json:"starttime"`            // The time when the download was started.
 	StartTimeUnix        int64     `json:"starttimeunix"`        // The time when the download was started in unix format.
 	TotalDataTransferred uint64    `json:"totaldatatransferred"` // Total amount of data transferred, including negotiation, etc.
+
+	// Manifest is only set for completed downloads which requested a
+	// manifest.
+	Manifest *DownloadManifest `json:"manifest,omitempty"`
+}
+
+// DownloadManifest contains the hashes of the data recovered by a download. It
+// is computed while the chunks are reconstructed, which allows for verifying
+// the downloaded data without reading it again.
+type DownloadManifest struct {
+	Chunks []DownloadChunkHash `json:"chunks"` // The hashes of the chunks, ordered by offset.
+
+	// Hash is the hash of the concatenated chunk hashes and covers the whole
+	// downloaded range.
+	Hash crypto.Hash `json:"hash"`
+}
+
+// DownloadChunkHash is the hash of the data recovered from a single chunk of a
+// download.
+type DownloadChunkHash struct {
+	Index  uint64      `json:"index"`  // The index of the chunk within the file.
+	Offset uint64      `json:"offset"` // The offset of the data within the download.
+	Length uint64      `json:"length"` // The length of the data.
+	Hash   crypto.Hash `json:"hash"`   // The hash of the data.
+}
+
+// HostMigration tracks the migration of the data stored on a host which was
+// blocklisted or removed from the hostdb.
+type HostMigration struct {
+	HostPubKey types.SiaPublicKey `json:"hostpubkey"`
+	Reason     string             `json:"reason"`    // Why the data is migrated away from the host.
+	StartTime  time.Time          `json:"starttime"` // When the migration was scheduled.
+	Completed  bool               `json:"completed"` // Whether all affected chunks were migrated.
+
+	// Files contains the progress of every file with pieces on the host.
+	Files []FileMigration `json:"files"`
+}
+
+// FileMigration is the migration progress of a single file.
+type FileMigration struct {
+	SiaPath        SiaPath `json:"siapath"`
+	Chunks         uint64  `json:"chunks"`         // The number of chunks with pieces on the host.
+	MigratedChunks uint64  `json:"migratedchunks"` // The number of chunks which no longer depend on the host.
+}
+
+// ContractPriceReport compares the projected renewal costs of the renter's
+// contracts at their hosts' current prices against the costs at the median
+// prices of the active hosts in the hostdb.
+type ContractPriceReport struct {
+	LastCheck time.Time `json:"lastcheck"` // When the prices were last compared.
+	NumHosts  int       `json:"numhosts"`  // The number of hosts the median prices are based on.
+
+	// MedianPrices are the median prices of the active hosts in the hostdb.
+	MedianPrices HostPrices `json:"medianprices"`
+
+	// Contracts contains the comparison of every contract which is going to
+	// be renewed.
+	Contracts []ContractPriceComparison `json:"contracts"`
+}
+
+// ContractPriceComparison compares the projected cost of renewing a contract at
+// its host's prices against the cost at the median prices.
+type ContractPriceComparison struct {
+	ID            types.FileContractID `json:"id"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	NetAddress    NetAddress           `json:"netaddress"`
+	HostPrices    HostPrices           `json:"hostprices"`
+
+	ProjectedRenewCost types.Currency `json:"projectedrenewcost"` // The cost at the host's prices.
+	MarketRenewCost    types.Currency `json:"marketrenewcost"`    // The cost at the median prices.
+	CostRatio          float64        `json:"costratio"`          // ProjectedRenewCost divided by MarketRenewCost.
+	Overpriced         bool           `json:"overpriced"`         // Whether the ratio exceeds the alert threshold.
+}
+
+// HostPrices are the prices of a host which affect the cost of renewing a
+// contract.
+type HostPrices struct {
+	ContractPrice          types.Currency `json:"contractprice"`
+	StoragePrice           types.Currency `json:"storageprice"`
+	UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+	DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
 }
 
 // FileUploadParams contains the information used by the Renter to upload a
@@ -433,37 +584,105 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// ChecksumAlgorithm is the algorithm used to compute the checksum of the
+	// plaintext data of a streamed upload. If it is left blank, no checksum
+	// is computed.
+	ChecksumAlgorithm ChecksumAlgorithm
+}
+
+// ChecksumAlgorithm is a hash function which can be used to compute the
+// checksum of a file's plaintext data.
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumSHA256 computes checksums using SHA-256.
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+
+	// ChecksumBLAKE2b computes checksums using the 256-bit version of
+	// BLAKE2b.
+	ChecksumBLAKE2b ChecksumAlgorithm = "blake2b"
+)
+
+// ErrUnknownChecksumAlgorithm is returned if a checksum algorithm isn't
+// supported.
+var ErrUnknownChecksumAlgorithm = errors.New("unknown checksum algorithm")
+
+// NewHash returns a new hash.Hash which computes checksums using the
+// algorithm.
+func (ca ChecksumAlgorithm) NewHash() (hash.Hash, error) {
+	switch ca {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumBLAKE2b:
+		return blake2b.New256(nil)
+	default:
+		return nil, errors.AddContext(ErrUnknownChecksumAlgorithm, string(ca))
+	}
+}
+
+// URLUploadParams contains the information used by the Renter to fetch a file
+// from a remote URL before uploading it.
+type URLUploadParams struct {
+	// URL is the http or https URL the data is fetched from.
+	URL string
+
+	// MaxSize is the maximum number of bytes the renter is willing to fetch
+	// from the URL. If it is 0, DefaultURLUploadMaxSize is used.
+	MaxSize uint64
+
+	// SHA256 is an optional checksum of the fetched data. If it is set, the
+	// uploaded file is deleted again if the fetched data doesn't match it.
+	SHA256 []byte
+}
+
+// URLUploadInfo provides information about an upload from a remote URL.
+type URLUploadInfo struct {
+	ID            URLUploadID `json:"id"`
+	URL           string      `json:"url"`
+	SiaPath       SiaPath     `json:"siapath"`
+	ContentLength int64       `json:"contentlength"` // -1 if unknown
+	Fetched       uint64      `json:"fetched"`
+	SHA256        string      `json:"sha256"` // set once the fetch is complete
+	Completed     bool        `json:"completed"`
+	Error         string      `json:"error"`
+	StartTime     time.Time   `json:"starttime"`
+	EndTime       time.Time   `json:"endtime"`
 }
 
 // FileInfo provides information about a file.
 type FileInfo struct {
-	AccessTime       time.Time         `json:"accesstime"`
-	Available        bool              `json:"available"`
-	ChangeTime       time.Time         `json:"changetime"`
-	CipherType       string            `json:"ciphertype"`
-	CreateTime       time.Time         `json:"createtime"`
-	Expiration       types.BlockHeight `json:"expiration"`
-	Filesize         uint64            `json:"filesize"`
-	Health           float64           `json:"health"`
-	LocalPath        string            `json:"localpath"`
-	MaxHealth        float64           `json:"maxhealth"`
-	MaxHealthPercent float64           `json:"maxhealthpercent"`
-	ModificationTime time.Time         `json:"modtime,siamismatch"` // Stays as 'modtime' in json for compatibility
-	FileMode         os.FileMode       `json:"mode,siamismatch"`    // Field is called FileMode for fuse compatibility
-	NumStuckChunks   uint64            `json:"numstuckchunks"`
-	OnDisk           bool              `json:"ondisk"`
-	Recoverable      bool              `json:"recoverable"`
-	Redundancy       float64           `json:"redundancy"`
-	Renewing         bool              `json:"renewing"`
-	RepairBytes      uint64            `json:"repairbytes"`
-	Skylinks         []string          `json:"skylinks"`
-	SiaPath          SiaPath           `json:"siapath"`
-	Stuck            bool              `json:"stuck"`
-	StuckBytes       uint64            `json:"stuckbytes"`
-	StuckHealth      float64           `json:"stuckhealth"`
-	UID              uint64            `json:"uid"`
-	UploadedBytes    uint64            `json:"uploadedbytes"`
-	UploadProgress   float64           `json:"uploadprogress"`
+	AccessTime        time.Time         `json:"accesstime"`
+	Available         bool              `json:"available"`
+	ChangeTime        time.Time         `json:"changetime"`
+	Checksum          string            `json:"checksum"`
+	ChecksumAlgorithm ChecksumAlgorithm `json:"checksumalgorithm"`
+	CipherType        string            `json:"ciphertype"`
+	CreateTime        time.Time         `json:"createtime"`
+	Expiration        types.BlockHeight `json:"expiration"`
+	Filesize          uint64            `json:"filesize"`
+	Health            float64           `json:"health"`
+	LocalPath         string            `json:"localpath"`
+	MaxHealth         float64           `json:"maxhealth"`
+	MaxHealthPercent  float64           `json:"maxhealthpercent"`
+	ModificationTime  time.Time         `json:"modtime,siamismatch"` // Stays as 'modtime' in json for compatibility
+	FileMode          os.FileMode       `json:"mode,siamismatch"`    // Field is called FileMode for fuse compatibility
+	NumStuckChunks    uint64            `json:"numstuckchunks"`
+	OnDisk            bool              `json:"ondisk"`
+	Recoverable       bool              `json:"recoverable"`
+	Redundancy        float64           `json:"redundancy"`
+	Renewing          bool              `json:"renewing"`
+	RepairBytes       uint64            `json:"repairbytes"`
+	RepairThreshold   float64           `json:"repairthreshold"`
+	RepairUrgency     float64           `json:"repairurgency"`
+	Skylinks          []string          `json:"skylinks"`
+	SiaPath           SiaPath           `json:"siapath"`
+	Stuck             bool              `json:"stuck"`
+	StuckBytes        uint64            `json:"stuckbytes"`
+	StuckHealth       float64           `json:"stuckhealth"`
+	UID               uint64            `json:"uid"`
+	UploadedBytes     uint64            `json:"uploadedbytes"`
+	UploadProgress    float64           `json:"uploadprogress"`
 }
 
 // Name implements os.FileInfo.
@@ -484,6 +703,42 @@ func (f FileInfo) IsDir() bool { return false }
 // Sys implements os.FileInfo.
 func (f FileInfo) Sys() interface{} { return nil }
 
+// FileLayout describes which hosts store the pieces of a file's chunks. The
+// pieces refer to their hosts by index into Hosts to keep the layout compact
+// for files with many chunks.
+type FileLayout struct {
+	SiaPath      SiaPath           `json:"siapath"`
+	DataPieces   int               `json:"datapieces"`
+	ParityPieces int               `json:"paritypieces"`
+	Hosts        []FileLayoutHost  `json:"hosts"`
+	Chunks       []FileLayoutChunk `json:"chunks"`
+}
+
+// FileLayoutHost is a host which stores at least one piece of a file.
+type FileLayoutHost struct {
+	PublicKey    types.SiaPublicKey `json:"publickey"`
+	Online       bool               `json:"online"`
+	GoodForRenew bool               `json:"goodforrenew"`
+}
+
+// FileLayoutChunk describes the pieces of a single chunk of a file. Pieces
+// contains the stored copies of each piece index of the chunk. LastRepair is
+// the zero time if the chunk hasn't been repaired since the time was recorded.
+type FileLayoutChunk struct {
+	Health     float64             `json:"health"`
+	Stuck      bool                `json:"stuck"`
+	LastRepair time.Time           `json:"lastrepair"`
+	Pieces     [][]FileLayoutPiece `json:"pieces"`
+}
+
+// FileLayoutPiece is a stored copy of a piece. Host is the index of the host
+// in FileLayout.Hosts. A piece is good if it counts towards the health of its
+// chunk.
+type FileLayoutPiece struct {
+	Host int  `json:"host"`
+	Good bool `json:"good"`
+}
+
 // A HostDBEntry represents one host entry in the Renter's host DB. It
 // aggregates the host's external settings and metrics with its public key.
 type HostDBEntry struct {
@@ -617,6 +872,7 @@ type RenterSettings struct {
 	IPViolationCheck bool          `json:"ipviolationcheck"`
 	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
+	ReadOnly         bool          `json:"readonly"`
 	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
 }
 
@@ -626,6 +882,167 @@ type UploadsStatus struct {
 	PauseEndTime time.Time `json:"pauseendtime"`
 }
 
+// RestoreModeStatus contains information about the Renter's restore mode.
+type RestoreModeStatus struct {
+	Active          bool      `json:"active"`
+	SiaPath         SiaPath   `json:"siapath"`
+	StartTime       time.Time `json:"starttime"`
+	ActiveDownloads uint64    `json:"activedownloads"`
+}
+
+// AccountSpendingCaps contains the caps on the money the Renter's workers may
+// spend from the ephemeral accounts of hosts within a rolling window. A zero
+// cap means that spending is unlimited.
+type AccountSpendingCaps struct {
+	// Default is the cap of hosts without a cap of their own.
+	Default types.Currency `json:"default"`
+
+	// Hosts maps the public keys of hosts to their caps.
+	Hosts map[string]types.Currency `json:"hosts"`
+
+	// Window is the duration of the rolling window.
+	Window time.Duration `json:"window"`
+}
+
+// StreamCacheSettings contains the settings of the Renter's stream cache.
+type StreamCacheSettings struct {
+	// MaxBytes is the total amount of data the stream cache may hold. 0
+	// means that the cache size is unlimited.
+	MaxBytes uint64 `json:"maxbytes"`
+
+	// ChunksPerStream is the maximum number of chunks cached for every
+	// stream. 0 means that the default limit is used.
+	ChunksPerStream uint64 `json:"chunksperstream"`
+
+	// Policy is the eviction policy of the stream cache.
+	Policy string `json:"policy"`
+}
+
+// StreamCacheStats contains the settings and the statistics of the Renter's
+// stream cache.
+type StreamCacheStats struct {
+	StreamCacheSettings
+
+	CachedBytes     uint64 `json:"cachedbytes"`
+	OpenStreams     uint64 `json:"openstreams"`
+	RetainedStreams uint64 `json:"retainedstreams"`
+	Hits            uint64 `json:"hits"`
+	Misses          uint64 `json:"misses"`
+}
+
+// RenterConcurrencySettings contains the runtime-tunable concurrency limits
+// of the Renter. A limit of 0 means that the default limit is used.
+type RenterConcurrencySettings struct {
+	// RepairUploadChunks is the maximum number of chunks in the upload heap.
+	RepairUploadChunks uint64 `json:"repairuploadchunks"`
+
+	// RepairDownloads is the maximum number of repair downloads which fetch
+	// chunks that aren't available locally from hosts at the same time.
+	RepairDownloads uint64 `json:"repairdownloads"`
+
+	// HealthCheckThreads is the number of threads used to update the health
+	// of files and directories.
+	HealthCheckThreads uint64 `json:"healthcheckthreads"`
+
+	// HostDBScanThreads is the maximum number of threads the hostdb uses to
+	// scan hosts.
+	HostDBScanThreads uint64 `json:"hostdbscanthreads"`
+}
+
+// RenterConcurrency contains the configured concurrency limits of the Renter
+// and the limits which are in effect.
+type RenterConcurrency struct {
+	Settings  RenterConcurrencySettings `json:"settings"`
+	Effective RenterConcurrencySettings `json:"effective"`
+}
+
+// RenterPlacementSettings contains the anti-affinity constraints of the
+// Renter's piece placement. No two pieces of a chunk are uploaded to hosts
+// which share a subnet of the configured prefix length, which prevents the
+// outage of a single network from taking a chunk below its minimum pieces. A
+// prefix length of 0 disables the constraint for the address family.
+type RenterPlacementSettings struct {
+	// IPv4PrefixLength is the prefix length of the IPv4 subnets which may
+	// only hold a single piece of a chunk.
+	IPv4PrefixLength uint64 `json:"ipv4prefixlength"`
+
+	// IPv6PrefixLength is the prefix length of the IPv6 subnets which may
+	// only hold a single piece of a chunk.
+	IPv6PrefixLength uint64 `json:"ipv6prefixlength"`
+}
+
+// RenterBandwidthCategory is a category of the Renter's traffic with hosts.
+type RenterBandwidthCategory string
+
+// RenterBandwidthWindow is a rolling window over which the Renter's bandwidth
+// usage is summed up.
+type RenterBandwidthWindow string
+
+// RenterBandwidthCap caps the bytes a category of the Renter's traffic may
+// transfer in both directions within a rolling window. A cap of 0 bytes
+// disables the cap.
+type RenterBandwidthCap struct {
+	Window RenterBandwidthWindow `json:"window"`
+	Bytes  uint64                `json:"bytes"`
+}
+
+// RenterBandwidthTotals contains the bytes transferred by the Renter.
+type RenterBandwidthTotals struct {
+	Uploaded   uint64 `json:"uploaded"`
+	Downloaded uint64 `json:"downloaded"`
+}
+
+// RenterBandwidthUsage contains the bandwidth used by a category of the
+// Renter's traffic within the rolling windows, the lifetime total and the
+// category's cap.
+type RenterBandwidthUsage struct {
+	Category RenterBandwidthCategory `json:"category"`
+
+	Hour  RenterBandwidthTotals `json:"hour"`
+	Day   RenterBandwidthTotals `json:"day"`
+	Month RenterBandwidthTotals `json:"month"`
+	Total RenterBandwidthTotals `json:"total"`
+
+	Cap        RenterBandwidthCap `json:"cap"`
+	CapReached bool               `json:"capreached"`
+}
+
+// RenterOperationTimeouts contains the runtime-tunable timeouts of one class
+// of operations of the Renter. A timeout of 0 means that the default timeout
+// is used.
+type RenterOperationTimeouts struct {
+	// ChunkDownload is the maximum amount of time the download of a single
+	// chunk may take.
+	ChunkDownload time.Duration `json:"chunkdownload"`
+
+	// Job is the maximum amount of time a single worker job may take.
+	Job time.Duration `json:"job"`
+
+	// Dial is the maximum amount of time dialing a host may take.
+	Dial time.Duration `json:"dial"`
+}
+
+// RenterTimeoutSettings contains the runtime-tunable timeouts of the Renter
+// grouped by the class of the operations they apply to.
+type RenterTimeoutSettings struct {
+	// Interactive contains the timeouts of user downloads and streams.
+	Interactive RenterOperationTimeouts `json:"interactive"`
+
+	// Repair contains the timeouts of repairs and uploads.
+	Repair RenterOperationTimeouts `json:"repair"`
+
+	// Background contains the timeouts of backups, benchmarks and host
+	// scans.
+	Background RenterOperationTimeouts `json:"background"`
+}
+
+// RenterTimeouts contains the configured timeouts of the Renter and the
+// timeouts which are in effect.
+type RenterTimeouts struct {
+	Settings  RenterTimeoutSettings `json:"settings"`
+	Effective RenterTimeoutSettings `json:"effective"`
+}
+
 // HostDBScans represents a sortable slice of scans.
 type HostDBScans []HostDBScan
 
@@ -864,6 +1281,262 @@ type UploadedBackup struct {
 	UploadProgress float64
 }
 
+// BackupFile describes a file contained in a backup.
+type BackupFile struct {
+	SiaPath   SiaPath `json:"siapath"`
+	Name      string  `json:"name"`
+	Filesize  uint64  `json:"filesize"`
+	LocalPath string  `json:"localpath"`
+}
+
+// LegacySiaFileImport describes a file which was imported from a legacy shared
+// .sia file.
+type LegacySiaFileImport struct {
+	SiaPath SiaPath `json:"siapath"`
+
+	// AvailablePieces is the number of pieces stored on hosts which are
+	// known to the renter and were online during their most recent scan.
+	// UnavailablePieces is the number of pieces which were dropped since
+	// their hosts are unknown or offline.
+	AvailablePieces   uint64 `json:"availablepieces"`
+	UnavailablePieces uint64 `json:"unavailablepieces"`
+}
+
+// The roots of the replicated metadata of a renter.
+const (
+	// ReplicationRootFileSystem contains the siafiles and siadir metadata
+	// of the renter's filesystem.
+	ReplicationRootFileSystem = "fs"
+
+	// ReplicationRootContracts contains the files of the renter's contract
+	// set.
+	ReplicationRootContracts = "contracts"
+)
+
+// The types of inconsistencies found by a check of the renter's filesystem.
+const (
+	// FsckIssueAggregateMismatch indicates that the number of files, the
+	// number of subdirectories or the size stored in a directory's metadata
+	// don't match the directory's contents.
+	FsckIssueAggregateMismatch = "aggregatemismatch"
+
+	// FsckIssueMissingDirMetadata indicates that a directory has no .siadir
+	// file. The directory and everything within it is not reachable from the
+	// root of the filesystem.
+	FsckIssueMissingDirMetadata = "missingdirmetadata"
+
+	// FsckIssueOrphanedFile indicates a siafile which is not reachable from
+	// the root of the filesystem because one of its parent directories has no
+	// metadata.
+	FsckIssueOrphanedFile = "orphanedfile"
+
+	// FsckIssueInvalidSiaPath indicates a file or directory whose name doesn't
+	// form a valid SiaPath.
+	FsckIssueInvalidSiaPath = "invalidsiapath"
+
+	// FsckIssueUnreadableFile indicates a siafile whose metadata can't be
+	// read.
+	FsckIssueUnreadableFile = "unreadablefile"
+
+	// FsckIssueWALRemnant indicates a temporary file left behind by an
+	// interrupted write.
+	FsckIssueWALRemnant = "walremnant"
+)
+
+// The actions taken to resolve an inconsistency of the renter's filesystem.
+const (
+	// FsckActionNone indicates that the inconsistency was only reported.
+	FsckActionNone = "none"
+
+	// FsckActionRepaired indicates that the inconsistency was repaired in
+	// place.
+	FsckActionRepaired = "repaired"
+
+	// FsckActionQuarantined indicates that the affected file or directory was
+	// moved out of the filesystem into the quarantine folder.
+	FsckActionQuarantined = "quarantined"
+)
+
+// FsckIssue is an inconsistency found by a check of the renter's filesystem.
+type FsckIssue struct {
+	Type string `json:"type"`
+
+	// Path is the path of the affected file or directory relative to the root
+	// of the filesystem. It is not a SiaPath since the paths of invalid
+	// SiaPaths are reported as well.
+	Path    string `json:"path"`
+	Details string `json:"details"`
+
+	// Action is the action which was taken to resolve the inconsistency. If
+	// the action failed, ActionError contains the reason.
+	Action      string `json:"action"`
+	ActionError string `json:"actionerror,omitempty"`
+}
+
+// FsckReport is the report of a check of the renter's filesystem.
+type FsckReport struct {
+	Repair     bool `json:"repair"`
+	Quarantine bool `json:"quarantine"`
+
+	DirsChecked  uint64      `json:"dirschecked"`
+	FilesChecked uint64      `json:"fileschecked"`
+	Issues       []FsckIssue `json:"issues"`
+
+	// QuarantineDir is the folder quarantined files and directories were
+	// moved to. It is empty if nothing was quarantined.
+	QuarantineDir string `json:"quarantinedir"`
+
+	StartTime time.Time `json:"starttime"`
+	EndTime   time.Time `json:"endtime"`
+}
+
+// ReplicationEntry is a file or directory of a renter's metadata. Path is
+// relative to the replicated metadata and starts with either
+// ReplicationRootFileSystem or ReplicationRootContracts.
+type ReplicationEntry struct {
+	Path    string    `json:"path"`
+	Dir     bool      `json:"dir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modtime"`
+}
+
+// ReplicationListing is the complete list of entries of a directory.
+type ReplicationListing struct {
+	Path    string             `json:"path"`
+	Entries []ReplicationEntry `json:"entries"`
+}
+
+// ReplicationManifest describes how a renter's metadata changed since a
+// point in time. Files contains the files which were modified and Listings
+// the full contents of the directories whose entries changed, which reveals
+// removed and renamed entries. Time is the time the manifest was created at,
+// which is passed as the start of the next manifest.
+type ReplicationManifest struct {
+	Time      time.Time            `json:"time"`
+	Files     []ReplicationEntry   `json:"files"`
+	Listings  []ReplicationListing `json:"listings"`
+	Allowance Allowance            `json:"allowance"`
+}
+
+// ReplicationStatus is the status of the replication of a primary renter's
+// metadata to a standby renter.
+type ReplicationStatus struct {
+	// Standby indicates that the renter replicates the metadata of the
+	// primary at PrimaryAddress. The renter is read-only until it is
+	// promoted.
+	Standby        bool   `json:"standby"`
+	PrimaryAddress string `json:"primaryaddress"`
+
+	// LastSync is the time of the last complete synchronization with the
+	// primary and LastError the error of the last attempt, if it failed.
+	LastSync  time.Time `json:"lastsync"`
+	LastError string    `json:"lasterror"`
+
+	// Files and Contracts are the number of replicated files of the
+	// filesystem and the contract set.
+	Files     uint64 `json:"files"`
+	Contracts uint64 `json:"contracts"`
+}
+
+// DirConversion is the progress of a conversion of all files within a
+// directory to new erasure coding settings. Once the conversion is done, it
+// serves as a report of the conversion.
+type DirConversion struct {
+	SiaPath      SiaPath `json:"siapath"`
+	DataPieces   int     `json:"datapieces"`
+	ParityPieces int     `json:"paritypieces"`
+	MaxSpeed     int64   `json:"maxspeed"`
+
+	// Cursor is the last file which was processed. Files are processed in
+	// lexicographical order, so the conversion resumes after the cursor.
+	Cursor SiaPath `json:"cursor"`
+
+	FilesConverted uint64    `json:"filesconverted"`
+	FilesSkipped   uint64    `json:"filesskipped"`
+	FilesFailed    uint64    `json:"filesfailed"`
+	BytesConverted uint64    `json:"bytesconverted"`
+	FailedFiles    []SiaPath `json:"failedfiles"`
+	LastError      string    `json:"lasterror"`
+
+	// Cost is the amount of money the renter spent while converting the
+	// files. It includes the spending of concurrent uploads and downloads.
+	Cost types.Currency `json:"cost"`
+
+	StartTime time.Time `json:"starttime"`
+	EndTime   time.Time `json:"endtime"`
+	Cancelled bool      `json:"cancelled"`
+	Completed bool      `json:"completed"`
+}
+
+// SiaFileImport describes a file which was imported from an exported siafile.
+type SiaFileImport struct {
+	SiaPath SiaPath `json:"siapath"`
+
+	// AvailablePieces is the number of pieces stored on hosts the renter has
+	// a good for renew contract with and which are online. UnavailablePieces
+	// is the number of the remaining pieces.
+	AvailablePieces   uint64 `json:"availablepieces"`
+	UnavailablePieces uint64 `json:"unavailablepieces"`
+
+	// Health is the health of the file given the renter's contracts.
+	// Recoverable indicates whether enough pieces of every chunk are available
+	// to download the file.
+	Health      float64 `json:"health"`
+	Recoverable bool    `json:"recoverable"`
+
+	// RepairQueued indicates whether the chunks of the file were queued for
+	// repair.
+	RepairQueued bool `json:"repairqueued"`
+}
+
+// AliasInfo describes an alias in the renter's filesystem. An alias points at
+// another file or directory, which allows for accessing the same content under
+// multiple SiaPaths.
+type AliasInfo struct {
+	SiaPath SiaPath `json:"siapath"`
+	Target  SiaPath `json:"target"`
+
+	// Resolved is the SiaPath of the file or directory the alias points at
+	// after following all aliases on the way.
+	Resolved SiaPath `json:"resolved"`
+}
+
+// HostBenchmark contains the results of benchmarking a single host using
+// small paid downloads.
+type HostBenchmark struct {
+	HostPubKey types.SiaPublicKey `json:"hostpubkey"`
+
+	// Latency is the round-trip time of a minimal program.
+	Latency time.Duration `json:"latency"`
+
+	// TTFB is the time it took to download a single small piece of data from
+	// the host.
+	TTFB time.Duration `json:"ttfb"`
+
+	// Throughput is the sustained download speed in bytes per second measured
+	// by downloading DownloadSize bytes from the host.
+	Throughput   uint64 `json:"throughput"`
+	DownloadSize uint64 `json:"downloadsize"`
+
+	// Error is set if the benchmark couldn't be completed.
+	Error string `json:"error,omitempty"`
+}
+
+// ContractSectorRoots contains the sector roots of a contract fetched from its
+// host and the revision they were verified against. Every sector of a contract
+// has a size of SectorSize.
+type ContractSectorRoots struct {
+	Revision    types.FileContractRevision `json:"revision"`
+	SectorRoots []crypto.Hash              `json:"sectorroots"`
+}
+
+// DelegatedReadAuthorization authorizes a third party to download the sectors
+// of a contract from the host with the given key.
+type DelegatedReadAuthorization struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	Token         DelegatedReadToken `json:"token"`
+}
+
 type (
 	// WorkerPoolStatus contains information about the status of the workerPool
 	// and the workers
@@ -882,6 +1555,10 @@ type (
 		ContractUtility ContractUtility      `json:"contractutility"`
 		HostPubKey      types.SiaPublicKey   `json:"hostpubkey"`
 
+		// Negotiated protocol information
+		HostCapabilities []string `json:"hostcapabilities"`
+		ProtocolVersion  uint64   `json:"protocolversion"`
+
 		// Download status information
 		DownloadCoolDownError string        `json:"downloadcooldownerror"`
 		DownloadCoolDownTime  time.Duration `json:"downloadcooldowntime"`
@@ -940,6 +1617,10 @@ type (
 		AvailableBalance types.Currency `json:"availablebalance"`
 		NegativeBalance  types.Currency `json:"negativebalance"`
 
+		SpendingCap              types.Currency `json:"spendingcap"`
+		WindowSpending           types.Currency `json:"windowspending"`
+		SpendingCapCooldownUntil time.Time      `json:"spendingcapcooldownuntil"`
+
 		RecentErr         string    `json:"recenterr"`
 		RecentErrTime     time.Time `json:"recenterrtime"`
 		RecentSuccessTime time.Time `json:"recentsuccesstime"`
@@ -1001,6 +1682,11 @@ type (
 // user.
 type Renter interface {
 	Alerter
+	OperationCounter
+
+	// AccountSpendingCaps returns the caps on the money the renter's workers
+	// may spend from the ephemeral accounts of hosts.
+	AccountSpendingCaps() (AccountSpendingCaps, error)
 
 	// ActiveHosts provides the list of hosts that the renter is selecting,
 	// sorted by preference.
@@ -1035,6 +1721,71 @@ type Renter interface {
 	// use.
 	LoadBackup(src string, secret []byte) error
 
+	// BackupContents lists the files contained in a previously created
+	// backup without loading them into the renter.
+	BackupContents(src string, secret []byte) ([]BackupFile, error)
+
+	// LoadBackupFiles loads only the given siafiles of a previously created
+	// backup into the renter. A siapath of a directory loads all files
+	// within the directory. Existing files are handled like in LoadBackup.
+	LoadBackupFiles(src string, secret []byte, siaPaths []SiaPath) error
+
+	// ImportLegacySiaFiles imports the files of a legacy shared .sia file
+	// into the given directory of the user folder. Pieces are only imported if their hosts are
+	// still available. Files which would have the same path as an already
+	// existing file get a suffix of the form _[num] like in LoadBackup.
+	ImportLegacySiaFiles(src string, dir SiaPath) ([]LegacySiaFileImport, error)
+
+	// ExportSiaFile writes the siafile at the given siapath to dst. The
+	// exported siafile contains the metadata and the key of the file and can
+	// be imported by another renter using ImportSiaFile.
+	ExportSiaFile(siaPath SiaPath, dst string) error
+
+	// ImportSiaFile adds an exported siafile to the filesystem at the given
+	// siapath without uploading it again. The availability of its pieces is
+	// checked against the renter's contracts. If repair is true and the file
+	// needs to be repaired, its chunks are queued for repair.
+	ImportSiaFile(src string, siaPath SiaPath, repair bool) (SiaFileImport, error)
+
+	// ConvertDir starts converting all files within a directory to the
+	// provided erasure coding settings. The files are re-uploaded at no more
+	// than maxSpeed bytes per second if maxSpeed is not 0. The conversion
+	// continues after a restart.
+	ConvertDir(siaPath SiaPath, dataPieces, parityPieces int, maxSpeed int64) error
+
+	// DirConversion returns the progress of the current or last directory
+	// conversion.
+	DirConversion() (DirConversion, error)
+
+	// CancelDirConversion cancels the current directory conversion.
+	CancelDirConversion() error
+
+	// Fsck checks the consistency of the renter's filesystem. If repair is
+	// true, inconsistencies which can be fixed in place are repaired. If
+	// quarantine is true, files and directories which can't be repaired are
+	// moved out of the filesystem into a quarantine folder.
+	Fsck(repair, quarantine bool) (FsckReport, error)
+
+	// PromoteReplicationStandby stops replicating the metadata of the
+	// primary and takes over its files and contracts.
+	PromoteReplicationStandby() error
+
+	// ReplicationManifest returns the changes of the renter's metadata since
+	// the provided time.
+	ReplicationManifest(since time.Time) (ReplicationManifest, error)
+
+	// ReplicationStatus returns the status of the replication.
+	ReplicationStatus() ReplicationStatus
+
+	// StartReplicationStandby makes the renter a read-only standby which
+	// continuously replicates the metadata of the primary renter at
+	// address.
+	StartReplicationStandby(address, password string) error
+
+	// WriteReplicationFiles writes a tar archive of the metadata files at
+	// the provided paths to w.
+	WriteReplicationFiles(w io.Writer, paths []string) error
+
 	// InitRecoveryScan starts scanning the whole blockchain for recoverable
 	// contracts within a separate thread.
 	InitRecoveryScan() error
@@ -1045,6 +1796,23 @@ type Renter interface {
 	// ContractorChurnStatus returns contract churn stats for the current period.
 	ContractorChurnStatus() ContractorChurnStatus
 
+	// AllowanceProfiles returns the renter's allowance profiles sorted by name.
+	AllowanceProfiles() []AllowanceProfile
+
+	// ActivateAllowanceProfile replaces the renter's allowance with the
+	// allowance of the profile.
+	ActivateAllowanceProfile(name string) error
+
+	// AllowanceProfileDiff previews the changes to the renter's contracts
+	// which activating the profile would cause.
+	AllowanceProfileDiff(name string) (AllowanceProfileDiff, error)
+
+	// DeleteAllowanceProfile deletes an allowance profile.
+	DeleteAllowanceProfile(name string) error
+
+	// SaveAllowanceProfile creates or replaces an allowance profile.
+	SaveAllowanceProfile(name string, a Allowance) error
+
 	// ContractUtility provides the contract utility for a given host key.
 	ContractUtility(pk types.SiaPublicKey) (ContractUtility, bool)
 
@@ -1127,6 +1895,9 @@ type Renter interface {
 	// File returns information on specific file queried by user
 	File(siaPath SiaPath) (FileInfo, error)
 
+	// FileLayout returns which hosts store which pieces of the file's chunks.
+	FileLayout(siaPath SiaPath) (FileLayout, error)
+
 	// FileList returns information on all of the files stored by the renter at the
 	// specified folder. The 'cached' argument specifies whether cached values
 	// should be returned or not.
@@ -1141,10 +1912,34 @@ type Renter interface {
 	// Host provides the DB entry and score breakdown for the requested host.
 	Host(pk types.SiaPublicKey) (HostDBEntry, bool, error)
 
+	// HostMigrations returns the migrations of data away from blocklisted
+	// hosts and hosts which were removed from the hostdb.
+	HostMigrations() []HostMigration
+
+	// ContractPriceReport returns the latest comparison of the projected
+	// renewal costs of the renter's contracts against the market rate.
+	ContractPriceReport() ContractPriceReport
+
+	// ConcurrencySettings returns the configured and the effective
+	// concurrency limits of the Renter.
+	ConcurrencySettings() (RenterConcurrency, error)
+
+	// TimeoutSettings returns the configured and the effective timeouts of
+	// the Renter.
+	TimeoutSettings() (RenterTimeouts, error)
+
 	// InitialScanComplete returns a boolean indicating if the initial scan of the
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
 
+	// BandwidthUsage returns the bandwidth used by each category of the
+	// Renter's traffic.
+	BandwidthUsage() ([]RenterBandwidthUsage, error)
+
+	// PlacementSettings returns the anti-affinity constraints of the
+	// Renter's piece placement.
+	PlacementSettings() (RenterPlacementSettings, error)
+
 	// PriceEstimation estimates the cost in siacoins of performing various
 	// storage and data operations.
 	PriceEstimation(allowance Allowance) (RenterPriceEstimation, Allowance, error)
@@ -1190,6 +1985,50 @@ type Renter interface {
 	// ResumeRepairsAndUploads resumes the renter's repairs and uploads
 	ResumeRepairsAndUploads() error
 
+	// ReadOnly returns whether the renter is in read-only mode. While in
+	// read-only mode, the renter's files and allowance can't be modified
+	// through the API but files can still be downloaded and streamed.
+	ReadOnly() bool
+
+	// RestoreModeStart puts the renter into restore mode. While in restore
+	// mode, the renter's resources are prioritized towards downloading the
+	// files within the provided dir.
+	RestoreModeStart(siaPath SiaPath) error
+
+	// RestoreModeStatus returns the status of the renter's restore mode.
+	RestoreModeStatus() (RestoreModeStatus, error)
+
+	// RestoreModeStop takes the renter out of restore mode.
+	RestoreModeStop() error
+
+	// SetReadOnly enables or disables the renter's read-only mode.
+	SetReadOnly(readOnly bool) error
+
+	// SetAccountSpendingCaps sets the caps on the money the renter's workers
+	// may spend from the ephemeral accounts of hosts.
+	SetAccountSpendingCaps(AccountSpendingCaps) error
+
+	// SetBandwidthCaps sets the caps of the categories of the Renter's
+	// traffic. Categories without a cap are unlimited.
+	SetBandwidthCaps(map[RenterBandwidthCategory]RenterBandwidthCap) error
+
+	// SetConcurrencySettings sets the concurrency limits of the Renter.
+	SetConcurrencySettings(RenterConcurrencySettings) error
+
+	// SetPlacementSettings sets the anti-affinity constraints of the
+	// Renter's piece placement.
+	SetPlacementSettings(RenterPlacementSettings) error
+
+	// SetStreamCacheSettings sets the settings of the Renter's stream cache.
+	SetStreamCacheSettings(StreamCacheSettings) error
+
+	// SetTimeoutSettings sets the timeouts of the Renter.
+	SetTimeoutSettings(RenterTimeoutSettings) error
+
+	// StreamCacheStats returns the settings and statistics of the Renter's
+	// stream cache.
+	StreamCacheStats() (StreamCacheStats, error)
+
 	// Streamer creates a io.ReadSeeker that can be used to stream downloads
 	// from the Sia network and also returns the fileName of the streamed
 	// resource.
@@ -1202,6 +2041,18 @@ type Renter interface {
 	// reached and upload the data to the Sia network.
 	UploadStreamFromReader(up FileUploadParams, reader io.Reader) error
 
+	// UploadFromURL fetches the data at the provided URL and uploads it to the
+	// Sia network in the background. The returned ID can be used to track the
+	// progress of the upload.
+	UploadFromURL(up FileUploadParams, params URLUploadParams) (URLUploadID, error)
+
+	// URLUploadByID returns an upload from a URL given its id.
+	URLUploadByID(id URLUploadID) (URLUploadInfo, bool)
+
+	// URLUploadHistory lists all the uploads from a URL since the renter was
+	// started.
+	URLUploadHistory() []URLUploadInfo
+
 	// CreateDir creates a directory for the renter
 	CreateDir(siaPath SiaPath, mode os.FileMode) error
 
@@ -1211,9 +2062,43 @@ type Renter interface {
 	// DirList lists the directories in a siadir
 	DirList(siaPath SiaPath) ([]DirectoryInfo, error)
 
+	// Alias returns information about the alias at siaPath.
+	Alias(siaPath SiaPath) (AliasInfo, error)
+
+	// CreateAlias creates an alias at siaPath which points at the file or
+	// directory at target.
+	CreateAlias(siaPath, target SiaPath) error
+
+	// DeleteAlias deletes the alias at siaPath.
+	DeleteAlias(siaPath SiaPath) error
+
 	// WorkerPoolStatus returns the current status of the Renter's worker pool
 	WorkerPoolStatus() (WorkerPoolStatus, error)
 
+	// BenchmarkHosts measures the latency, time to first byte and throughput
+	// of the provided hosts using small paid downloads. If no hosts are
+	// provided, all hosts the renter has workers for are benchmarked. The
+	// results are sorted by throughput, fastest first.
+	BenchmarkHosts(hosts []types.SiaPublicKey) ([]HostBenchmark, error)
+
+	// ContractSectorRoots fetches the sector roots of the contract with the
+	// given id from its host and verifies them against the contract's latest
+	// revision.
+	ContractSectorRoots(fcid types.FileContractID) (ContractSectorRoots, error)
+
+	// AuthorizeDelegatedRead issues a token which authorizes the delegate to
+	// download the sectors of a contract from its host for the given number
+	// of blocks.
+	AuthorizeDelegatedRead(fcid types.FileContractID, delegate types.SiaPublicKey, duration types.BlockHeight) (DelegatedReadAuthorization, error)
+
+	// DelegateKey returns the public key which identifies the renter as a
+	// delegate of other renters.
+	DelegateKey() (types.SiaPublicKey, error)
+
+	// DelegatedReadSector downloads a sector of another renter's contract
+	// using an authorization that renter issued to the renter's delegate key.
+	DelegatedReadSector(auth DelegatedReadAuthorization, root crypto.Hash) ([]byte, error)
+
 	// BubbleMetadata calculates the updated values of a directory's metadata and
 	// updates the siadir metadata on disk then calls callThreadedBubbleMetadata
 	// on the parent directory so that it is only blocking for the current
@@ -1250,6 +2135,16 @@ type RenterDownloadParameters struct {
 	SiaPath          SiaPath
 	Destination      string
 	DisableDiskFetch bool
+
+	// Manifest requests a manifest of the hashes of the downloaded data. For
+	// downloads to a file, it is stored next to the file with a ".manifest"
+	// extension.
+	Manifest bool
+
+	// VerifyChecksum requests that the downloaded data is verified against
+	// the checksum computed during the upload. It can only be set for
+	// downloads of a full file with a checksum.
+	VerifyChecksum bool
 }
 
 // HealthPercentage returns the health in a more human understandable format out
@@ -1279,6 +2174,36 @@ var (
 		Standard: 0.25,
 		Testing:  0.25,
 	}).(float64)
+
+	// MinRepairThreshold and MaxRepairThreshold bound the adaptive repair
+	// threshold of a file. Files stored on unreliable hosts are repaired once
+	// their health reaches MinRepairThreshold, files stored on reliable hosts
+	// not before their health reaches MaxRepairThreshold.
+	MinRepairThreshold = build.Select(build.Var{
+		Dev:      0.15,
+		Standard: 0.15,
+		Testing:  0.15,
+	}).(float64)
+	MaxRepairThreshold = build.Select(build.Var{
+		Dev:      0.35,
+		Standard: 0.35,
+		Testing:  0.25,
+	}).(float64)
+)
+
+const (
+	// reliableHostFailureRate is the host failure rate at or below which a
+	// file is repaired at MaxRepairThreshold.
+	reliableHostFailureRate = 0.01
+
+	// neutralHostFailureRate is the host failure rate up to which a file is
+	// repaired at RepairThreshold. A host without any interactions has a
+	// failure rate of 1/31 due to the baseline of the hostdb.
+	neutralHostFailureRate = 0.05
+
+	// unreliableHostFailureRate is the host failure rate at or above which a
+	// file is repaired at MinRepairThreshold.
+	unreliableHostFailureRate = 0.25
 )
 
 // NeedsRepair is a helper to ensure consistent comparison with the
@@ -1287,6 +2212,62 @@ func NeedsRepair(health float64) bool {
 	return health >= RepairThreshold
 }
 
+// MayNeedRepair returns whether a directory with the given health might
+// contain files which need to be repaired at their adaptive repair threshold.
+func MayNeedRepair(health float64) bool {
+	return health >= MinRepairThreshold
+}
+
+// HostFailureRate returns the fraction of failed interactions with a host,
+// using the same baseline of 30 successful and 1 failed interaction as the
+// hostdb.
+func HostFailureRate(entry HostDBEntry) float64 {
+	successful := entry.HistoricSuccessfulInteractions + entry.RecentSuccessfulInteractions + 30
+	failed := entry.HistoricFailedInteractions + entry.RecentFailedInteractions + 1
+	return failed / (successful + failed)
+}
+
+// AdaptiveRepairThreshold returns the repair threshold of a file whose pieces
+// are stored on hosts with the given average failure rate. The threshold
+// decreases linearly from MaxRepairThreshold for reliable hosts to
+// RepairThreshold for hosts with a neutral failure rate, and further down to
+// MinRepairThreshold for unreliable hosts.
+func AdaptiveRepairThreshold(failureRate float64) float64 {
+	switch {
+	case failureRate <= reliableHostFailureRate:
+		return MaxRepairThreshold
+	case failureRate <= neutralHostFailureRate:
+		frac := (failureRate - reliableHostFailureRate) / (neutralHostFailureRate - reliableHostFailureRate)
+		return MaxRepairThreshold - frac*(MaxRepairThreshold-RepairThreshold)
+	case failureRate < unreliableHostFailureRate:
+		frac := (failureRate - neutralHostFailureRate) / (unreliableHostFailureRate - neutralHostFailureRate)
+		return RepairThreshold - frac*(RepairThreshold-MinRepairThreshold)
+	default:
+		return MinRepairThreshold
+	}
+}
+
+// RepairUrgency returns the repair urgency of a file or chunk with the given
+// health and repair threshold. It needs to be repaired once the urgency
+// reaches 1. A threshold of 0 means that the file's threshold hasn't been
+// computed yet, in which case RepairThreshold is used.
+func RepairUrgency(health, threshold float64) float64 {
+	if threshold <= 0 {
+		threshold = RepairThreshold
+	}
+	return health / threshold
+}
+
+// NeedsAdaptiveRepair returns whether a file or chunk with the given health
+// needs to be repaired at the given repair threshold. A threshold of 0 falls
+// back to RepairThreshold.
+func NeedsAdaptiveRepair(health, threshold float64) bool {
+	if threshold <= 0 {
+		threshold = RepairThreshold
+	}
+	return health >= threshold
+}
+
 // A HostDB is a database of hosts that the renter can use for figuring out who
 // to upload to, and download from.
 type HostDB interface {
@@ -1359,6 +2340,26 @@ type HostDB interface {
 	// hostdb.
 	SetIPViolationCheck(enabled bool) error
 
+	// SetMaxScanningThreads sets the maximum number of threads used to scan
+	// hosts. 0 restores the default.
+	SetMaxScanningThreads(int) error
+
+	// MaxScanningThreads returns the maximum number of threads used to scan
+	// hosts.
+	MaxScanningThreads() (int, error)
+
+	// SetScanDialTimeout sets the maximum amount of time dialing a host
+	// during a scan may take. 0 restores the default.
+	SetScanDialTimeout(time.Duration) error
+
+	// ScanDialTimeout returns the maximum amount of time dialing a host
+	// during a scan may take.
+	ScanDialTimeout() (time.Duration, error)
+
+	// SetBandwidthMeter sets the meter which records the bandwidth used by
+	// scans and caps it.
+	SetBandwidthMeter(*BandwidthMeter)
+
 	// UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 	// contracts.
 	UpdateContracts([]RenterContract) error
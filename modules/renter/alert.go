@@ -10,8 +10,9 @@ func (r *Renter) Alerts() (crit, err, warn []modules.Alert) {
 	renterCrit, renterErr, renterWarn := r.staticAlerter.Alerts()
 	contractorCrit, contractorErr, contractorWarn := r.hostContractor.Alerts()
 	hostdbCrit, hostdbErr, hostdbWarn := r.hostDB.Alerts()
-	crit = append(append(renterCrit, contractorCrit...), hostdbCrit...)
-	err = append(append(renterErr, contractorErr...), hostdbErr...)
-	warn = append(append(renterWarn, contractorWarn...), hostdbWarn...)
+	fsCrit, fsErr, fsWarn := r.staticFileSystem.Alerts()
+	crit = append(append(append(renterCrit, contractorCrit...), hostdbCrit...), fsCrit...)
+	err = append(append(append(renterErr, contractorErr...), hostdbErr...), fsErr...)
+	warn = append(append(append(renterWarn, contractorWarn...), hostdbWarn...), fsWarn...)
 	return crit, err, warn
 }
@@ -0,0 +1,155 @@
+package renter
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// Alias returns information about the alias at siaPath.
+func (r *Renter) Alias(siaPath modules.SiaPath) (modules.AliasInfo, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.AliasInfo{}, err
+	}
+	defer r.tg.Done()
+	target, err := r.staticFileSystem.AliasTarget(siaPath)
+	if err != nil {
+		return modules.AliasInfo{}, err
+	}
+	resolved, err := r.staticFileSystem.ResolveSiaPath(siaPath)
+	if err != nil {
+		return modules.AliasInfo{}, err
+	}
+	return modules.AliasInfo{
+		SiaPath:  siaPath,
+		Target:   target,
+		Resolved: resolved,
+	}, nil
+}
+
+// CreateAlias creates an alias at siaPath which points at the file or
+// directory at target.
+func (r *Renter) CreateAlias(siaPath, target modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticFileSystem.NewAlias(siaPath, target)
+}
+
+// DeleteAlias deletes the alias at siaPath.
+func (r *Renter) DeleteAlias(siaPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticFileSystem.DeleteAlias(siaPath)
+}
+
+// managedAliasesInDir returns the aliases within the directory at resolved,
+// which is the resolved version of siaPath. The SiaPaths of the aliases are
+// relative to siaPath. Aliases which can't be resolved are skipped.
+func (r *Renter) managedAliasesInDir(siaPath, resolved modules.SiaPath) ([]modules.AliasInfo, error) {
+	aliases, err := r.staticFileSystem.Aliases(resolved)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]modules.AliasInfo, 0, len(aliases))
+	for name, target := range aliases {
+		aliasPath, err := siaPath.Join(name)
+		if err != nil {
+			return nil, err
+		}
+		aliasResolved, err := r.staticFileSystem.ResolveSiaPath(target)
+		if errors.Contains(err, filesystem.ErrAliasLoop) {
+			r.log.Debugf("Skipping alias '%v': %v", aliasPath, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, modules.AliasInfo{
+			SiaPath:  aliasPath,
+			Target:   target,
+			Resolved: aliasResolved,
+		})
+	}
+	return infos, nil
+}
+
+// managedAliasDirInfos returns the DirectoryInfos of the aliases to
+// directories within the directory at resolved, which is the resolved version
+// of siaPath.
+func (r *Renter) managedAliasDirInfos(siaPath, resolved modules.SiaPath) ([]modules.DirectoryInfo, error) {
+	aliases, err := r.managedAliasesInDir(siaPath, resolved)
+	if err != nil {
+		return nil, err
+	}
+	var dis []modules.DirectoryInfo
+	for _, alias := range aliases {
+		dir, err := r.staticFileSystem.OpenSiaDir(alias.Resolved)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue // dangling or alias to a file
+		}
+		if err != nil {
+			return nil, err
+		}
+		di, err := r.staticFileSystem.DirNodeInfo(dir)
+		err = errors.Compose(err, dir.Close())
+		if err != nil {
+			return nil, err
+		}
+		di.SiaPath = alias.SiaPath
+		dis = append(dis, di)
+	}
+	return dis, nil
+}
+
+// managedListAliasFiles calls flf for the aliases to files within the
+// directory at resolved, which is the resolved version of siaPath.
+func (r *Renter) managedListAliasFiles(siaPath, resolved modules.SiaPath, cached bool, offlineMap, goodForRenewMap map[string]bool, contractsMap map[string]modules.RenterContract, flf modules.FileListFunc) error {
+	aliases, err := r.managedAliasesInDir(siaPath, resolved)
+	if err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		var fi modules.FileInfo
+		if cached {
+			fi, err = r.staticFileSystem.CachedFileInfo(alias.Resolved)
+		} else {
+			fi, err = r.staticFileSystem.FileInfo(alias.Resolved, offlineMap, goodForRenewMap, contractsMap)
+		}
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue // dangling or alias to a dir
+		}
+		if err != nil {
+			return err
+		}
+		fi.SiaPath = alias.SiaPath
+		flf(fi)
+	}
+	return nil
+}
+
+// rebaseListing changes the base of the SiaPaths passed to the listing
+// functions from resolved to siaPath. This makes listings of aliased
+// directories appear as if they were listings of the alias itself.
+func rebaseListing(siaPath, resolved modules.SiaPath, flf modules.FileListFunc, dlf modules.DirListFunc) (modules.FileListFunc, modules.DirListFunc) {
+	if siaPath.Equals(resolved) {
+		return flf, dlf
+	}
+	rebase := func(sp modules.SiaPath) modules.SiaPath {
+		rebased, err := sp.Rebase(resolved, siaPath)
+		if err != nil {
+			return sp
+		}
+		return rebased
+	}
+	return func(fi modules.FileInfo) {
+			fi.SiaPath = rebase(fi.SiaPath)
+			flf(fi)
+		}, func(di modules.DirectoryInfo) {
+			di.SiaPath = rebase(di.SiaPath)
+			dlf(di)
+		}
+}
@@ -22,6 +22,7 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/modules/renter/filesystem/siadir"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 )
 
 // backupHeader defines the structure of the backup's JSON header.
@@ -144,7 +145,7 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 		err = errors.Compose(err, root.Close())
 	}()
 
-	// Open the gzip file.
+	// Open the backup.
 	f, err := os.Open(src)
 	if err != nil {
 		return err
@@ -152,57 +153,7 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 	defer func() {
 		err = errors.Compose(err, f.Close())
 	}()
-	archive := io.Reader(f)
-
-	// Read the checksum.
-	var chks crypto.Hash
-	_, err = io.ReadFull(f, chks[:])
-	if err != nil {
-		return err
-	}
-	// Read the header.
-	dec := json.NewDecoder(archive)
-	var bh backupHeader
-	if err := dec.Decode(&bh); err != nil {
-		return err
-	}
-	// Check the version number.
-	if bh.Version != encryptionVersion {
-		return errors.New("unknown version")
-	}
-	// Wrap the file in the correct streamcipher. Consider the data remaining in
-	// the decoder's buffer by using a multireader.
-	archive = io.MultiReader(dec.Buffered(), archive)
-	_, err = archive.Read(make([]byte, 1)) // Ignore first byte of buffer to get to the body of the backup
-	if err != nil {
-		return err
-	}
-	archive, err = wrapReaderInCipher(io.MultiReader(archive, f), bh, secret)
-	if err != nil {
-		return err
-	}
-	// Pipe the remaining file into the hasher to verify that the hash is
-	// correct.
-	h := crypto.NewHash()
-	n, err := io.Copy(h, archive)
-	if err != nil {
-		return err
-	}
-	// Verify the hash.
-	if !bytes.Equal(h.Sum(nil), chks[:]) {
-		return errors.New("checksum doesn't match")
-	}
-	// Seek back to the beginning of the body.
-	if _, err := f.Seek(-n, io.SeekCurrent); err != nil {
-		return err
-	}
-	// Wrap the file again.
-	archive, err = wrapReaderInCipher(f, bh, secret)
-	if err != nil {
-		return err
-	}
-	// Wrap the potentially encrypted reader in a gzip reader.
-	gzr, err := gzip.NewReader(archive)
+	gzr, err := openBackup(f, secret)
 	if err != nil {
 		return err
 	}
@@ -217,7 +168,7 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 	}
 	// Unmarshal the allowance if available. This needs to happen after adding
 	// decryption and confirming the hash but before adding decompression.
-	dec = json.NewDecoder(gzr)
+	dec := json.NewDecoder(gzr)
 	var allowance modules.Allowance
 	if err := dec.Decode(&allowance); err != nil {
 		// legacy backup without allowance
@@ -234,6 +185,123 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 	return nil
 }
 
+// BackupContents lists the files contained in a previously created backup
+// without loading them into the renter.
+func (r *Renter) BackupContents(src string, secret []byte) (_ []modules.BackupFile, err error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	// Open the backup.
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	gzr, err := openBackup(f, secret)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+
+	// Load the metadata of the siafiles.
+	var files []modules.BackupFile
+	err = readBackupSiaFiles(tar.NewReader(gzr), func(siaPath modules.SiaPath, b []byte) error {
+		sf, err := siafile.LoadSiaFileFromReader(bytes.NewReader(b), "", nil)
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("could not load siafile %v", siaPath))
+		}
+		files = append(files, modules.BackupFile{
+			SiaPath:   siaPath,
+			Name:      siaPath.Name(),
+			Filesize:  sf.Size(),
+			LocalPath: sf.LocalPath(),
+		})
+		return nil
+	})
+	return files, err
+}
+
+// LoadBackupFiles loads only the given siafiles of a previously created backup
+// into the renter. A siapath of a directory loads all files within the
+// directory. If one of the siapaths is not part of the backup, no files are
+// loaded.
+func (r *Renter) LoadBackupFiles(src string, secret []byte, siaPaths []modules.SiaPath) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if len(siaPaths) == 0 {
+		return errors.New("no siapaths specified")
+	}
+
+	// Open the backup.
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	gzr, err := openBackup(f, secret)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+
+	// Collect the requested siafiles.
+	found := make([]bool, len(siaPaths))
+	var files []modules.SiaPath
+	var data [][]byte
+	err = readBackupSiaFiles(tar.NewReader(gzr), func(siaPath modules.SiaPath, b []byte) error {
+		requested := false
+		for i, sp := range siaPaths {
+			if sp.Equals(siaPath) || isWithinDir(sp, siaPath) {
+				found[i] = true
+				requested = true
+			}
+		}
+		if requested {
+			files = append(files, siaPath)
+			data = append(data, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, sp := range siaPaths {
+		if !found[i] {
+			return fmt.Errorf("%v is not part of the backup", sp)
+		}
+	}
+
+	// Add the siafiles to the filesystem and update the directories they were
+	// added to.
+	dirsToUpdate := r.newUniqueRefreshPaths()
+	defer func() {
+		err = errors.Compose(err, dirsToUpdate.callRefreshAll())
+	}()
+	for i, siaPath := range files {
+		err = r.staticFileSystem.AddSiaFileFromReader(bytes.NewReader(data[i]), siaPath)
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("could not add siafile %v", siaPath))
+		}
+		err = dirsToUpdate.callAdd(siaPath)
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("could not add directory %v to the list of directories to be updated", siaPath))
+		}
+	}
+	return nil
+}
+
 // managedTarSiaFiles creates a tarball from the renter's siafiles and writes
 // it to dst.
 func (r *Renter) managedTarSiaFiles(tw *tar.Writer) error {
@@ -383,21 +451,9 @@ func (r *Renter) managedUntarDir(tr *tar.Reader) (err error) {
 			return errors.AddContext(err, "could not load the new file in memory")
 		}
 		if name := filepath.Base(info.Name()); name == modules.SiaDirExtension {
-			// Verify there is enough data for a checksum
-			if len(b) < crypto.HashSize {
-				return siadir.ErrCorruptFile
-			}
-
-			// Verify checksum
-			checksum := b[:crypto.HashSize]
-			mdBytes := b[crypto.HashSize:]
-			fileChecksum := crypto.HashBytes(mdBytes)
-			if !bytes.Equal(checksum, fileChecksum[:]) {
-				return siadir.ErrInvalidChecksum
-			}
-			// Load the file as a .siadir
+			// Verify the checksum and load the file as a .siadir
 			var md siadir.Metadata
-			err = json.Unmarshal(mdBytes, &md)
+			md, _, err = siadir.UnmarshalMetadata(b)
 			if err != nil {
 				return errors.AddContext(err, "could not unmarshal")
 			}
@@ -456,6 +512,90 @@ func (r *Renter) managedUntarDir(tr *tar.Reader) (err error) {
 	return nil
 }
 
+// openBackup verifies the checksum of the backup in f and returns a reader for
+// its decrypted and decompressed body.
+func openBackup(f *os.File, secret []byte) (*gzip.Reader, error) {
+	archive := io.Reader(f)
+
+	// Read the checksum.
+	var chks crypto.Hash
+	_, err := io.ReadFull(f, chks[:])
+	if err != nil {
+		return nil, err
+	}
+	// Read the header.
+	dec := json.NewDecoder(archive)
+	var bh backupHeader
+	if err := dec.Decode(&bh); err != nil {
+		return nil, err
+	}
+	// Check the version number.
+	if bh.Version != encryptionVersion {
+		return nil, errors.New("unknown version")
+	}
+	// Wrap the file in the correct streamcipher. Consider the data remaining in
+	// the decoder's buffer by using a multireader.
+	archive = io.MultiReader(dec.Buffered(), archive)
+	_, err = archive.Read(make([]byte, 1)) // Ignore first byte of buffer to get to the body of the backup
+	if err != nil {
+		return nil, err
+	}
+	archive, err = wrapReaderInCipher(io.MultiReader(archive, f), bh, secret)
+	if err != nil {
+		return nil, err
+	}
+	// Pipe the remaining file into the hasher to verify that the hash is
+	// correct.
+	h := crypto.NewHash()
+	n, err := io.Copy(h, archive)
+	if err != nil {
+		return nil, err
+	}
+	// Verify the hash.
+	if !bytes.Equal(h.Sum(nil), chks[:]) {
+		return nil, errors.New("checksum doesn't match")
+	}
+	// Seek back to the beginning of the body.
+	if _, err := f.Seek(-n, io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	// Wrap the file again.
+	archive, err = wrapReaderInCipher(f, bh, secret)
+	if err != nil {
+		return nil, err
+	}
+	// Wrap the potentially encrypted reader in a gzip reader.
+	return gzip.NewReader(archive)
+}
+
+// readBackupSiaFiles calls fn for every siafile within the archive of a
+// backup. The siapaths passed to fn are within the user folder.
+func readBackupSiaFiles(tr *tar.Reader, fn func(siaPath modules.SiaPath, b []byte) error) error {
+	for {
+		header, err := tr.Next()
+		if errors.Contains(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return errors.AddContext(err, "could not get next entry in the tar archive")
+		}
+		info := header.FileInfo()
+		if info.IsDir() || filepath.Ext(info.Name()) != modules.SiaFileExtension {
+			continue
+		}
+		siaPath, err := modules.UserFolder.Join(strings.TrimSuffix(header.Name, modules.SiaFileExtension))
+		if err != nil {
+			return errors.AddContext(err, "could not join folders")
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errors.AddContext(err, "could not load the file in memory")
+		}
+		if err := fn(siaPath, b); err != nil {
+			return err
+		}
+	}
+}
+
 // wrapReaderInCipher wraps the reader r into another reader according to the
 // used encryption specified in the backupHeader.
 func wrapReaderInCipher(r io.Reader, bh backupHeader, secret []byte) (io.Reader, error) {
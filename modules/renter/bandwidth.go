@@ -0,0 +1,53 @@
+package renter
+
+import (
+	"go.sia.tech/siad/modules"
+)
+
+// bandwidthCategory returns the category of the renter's bandwidth usage the
+// traffic of the spending category is recorded in. Traffic which doesn't belong
+// to any category of the bandwidth meter isn't recorded.
+func (category spendingCategory) bandwidthCategory() modules.RenterBandwidthCategory {
+	switch category {
+	case categoryDownload:
+		return modules.RenterBandwidthDownload
+	case categoryStreamDownload:
+		return modules.RenterBandwidthStream
+	case categoryRepairDownload:
+		return modules.RenterBandwidthRepairDownload
+	case categoryUpload, categoryRepairUpload:
+		return modules.RenterBandwidthUpload
+	}
+	return ""
+}
+
+// BandwidthUsage returns the bandwidth the renter used per category of
+// traffic within the rolling windows, together with the caps of the
+// categories.
+func (r *Renter) BandwidthUsage() ([]modules.RenterBandwidthUsage, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticBandwidthMeter.Usage(), nil
+}
+
+// SetBandwidthCaps replaces the bandwidth caps of the renter's categories of
+// traffic and persists them. Traffic of a category which reached its cap is
+// paused until the usage within the cap's window drops below the cap again.
+func (r *Renter) SetBandwidthCaps(caps map[modules.RenterBandwidthCategory]modules.RenterBandwidthCap) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if err := r.staticBandwidthMeter.SetCaps(caps); err != nil {
+		return err
+	}
+
+	id := r.mu.Lock()
+	r.persist.BandwidthCaps = r.staticBandwidthMeter.Caps()
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	return err
+}
@@ -192,8 +192,9 @@ func (bs *bubbleScheduler) callThreadedProcessBubbleUpdates() {
 		}
 
 		// Launch a group of bubble workers
-		bubbleChan := make(chan modules.SiaPath, numBubbleWorkerThreads)
-		for i := 0; i < numBubbleWorkerThreads; i++ {
+		numThreads := bs.staticRenter.staticConcurrency.callHealthCheckThreads()
+		bubbleChan := make(chan modules.SiaPath, numThreads)
+		for i := 0; i < numThreads; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
@@ -0,0 +1,217 @@
+package renter
+
+import (
+	"fmt"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxConcurrencyLimit is the highest value any of the renter's
+	// concurrency limits can be set to.
+	maxConcurrencyLimit = 10000
+)
+
+var (
+	// repairDownloadsDefault is the default number of repair downloads which
+	// fetch the data of chunks that aren't available locally at the same
+	// time.
+	repairDownloadsDefault = build.Select(build.Var{
+		Dev:      20,
+		Standard: 100,
+		Testing:  10,
+	}).(int)
+
+	// errConcurrencyLimitTooHigh is returned if a concurrency limit exceeds
+	// maxConcurrencyLimit.
+	errConcurrencyLimitTooHigh = fmt.Errorf("concurrency limits can't exceed %v", maxConcurrencyLimit)
+
+	// errRepairDownloadInterrupted is returned if the renter is stopped while
+	// a repair download waits for a free slot.
+	errRepairDownloadInterrupted = errors.New("repair download interrupted by stop call")
+)
+
+type (
+	// concurrencyLimits contains the runtime-tunable concurrency limits of
+	// the renter.
+	concurrencyLimits struct {
+		settings modules.RenterConcurrencySettings
+
+		// staticRepairDownloads limits the number of concurrent repair
+		// downloads.
+		staticRepairDownloads *resizableSemaphore
+
+		mu sync.Mutex
+	}
+
+	// resizableSemaphore is a counting semaphore whose limit can be changed
+	// while it is in use. Lowering the limit doesn't interrupt holders of the
+	// semaphore, it only delays new acquisitions until enough holders
+	// released it.
+	resizableSemaphore struct {
+		active int
+		limit  int
+		wake   chan struct{}
+		mu     sync.Mutex
+	}
+)
+
+// newResizableSemaphore returns a semaphore with the provided limit.
+func newResizableSemaphore(limit int) *resizableSemaphore {
+	return &resizableSemaphore{
+		limit: limit,
+		wake:  make(chan struct{}),
+	}
+}
+
+// notify wakes up all threads waiting for the semaphore. It must be called
+// while holding the lock.
+func (s *resizableSemaphore) notify() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// managedAcquire blocks until the semaphore can be acquired or stop is
+// closed. It returns false if the semaphore wasn't acquired.
+func (s *resizableSemaphore) managedAcquire(stop <-chan struct{}) bool {
+	for {
+		s.mu.Lock()
+		if s.active < s.limit {
+			s.active++
+			s.mu.Unlock()
+			return true
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-stop:
+			return false
+		}
+	}
+}
+
+// managedRelease releases the semaphore.
+func (s *resizableSemaphore) managedRelease() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	s.notify()
+}
+
+// managedSetLimit changes the limit of the semaphore.
+func (s *resizableSemaphore) managedSetLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.notify()
+}
+
+// newConcurrencyLimits returns the concurrency limits initialized with their
+// defaults.
+func newConcurrencyLimits() *concurrencyLimits {
+	return &concurrencyLimits{
+		staticRepairDownloads: newResizableSemaphore(repairDownloadsDefault),
+	}
+}
+
+// validateConcurrencySettings checks that none of the limits is too high.
+func validateConcurrencySettings(settings modules.RenterConcurrencySettings) error {
+	for _, limit := range []uint64{settings.RepairUploadChunks, settings.RepairDownloads, settings.HealthCheckThreads, settings.HostDBScanThreads} {
+		if limit > maxConcurrencyLimit {
+			return errConcurrencyLimitTooHigh
+		}
+	}
+	return nil
+}
+
+// limitOrDefault returns the limit or the default if the limit isn't set.
+func limitOrDefault(limit uint64, def int) int {
+	if limit == 0 {
+		return def
+	}
+	return int(limit)
+}
+
+// callSetSettings updates the concurrency limits.
+func (cl *concurrencyLimits) callSetSettings(settings modules.RenterConcurrencySettings) {
+	cl.mu.Lock()
+	cl.settings = settings
+	cl.mu.Unlock()
+	cl.staticRepairDownloads.managedSetLimit(limitOrDefault(settings.RepairDownloads, repairDownloadsDefault))
+}
+
+// callSettings returns the configured concurrency limits.
+func (cl *concurrencyLimits) callSettings() modules.RenterConcurrencySettings {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.settings
+}
+
+// callRepairUploadChunks returns the maximum number of chunks in the upload
+// heap.
+func (cl *concurrencyLimits) callRepairUploadChunks() int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return limitOrDefault(cl.settings.RepairUploadChunks, maxUploadHeapChunks)
+}
+
+// callHealthCheckThreads returns the number of threads used to update the
+// health of files and directories.
+func (cl *concurrencyLimits) callHealthCheckThreads() int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return limitOrDefault(cl.settings.HealthCheckThreads, numBubbleWorkerThreads)
+}
+
+// ConcurrencySettings returns the configured and the effective concurrency
+// limits of the renter.
+func (r *Renter) ConcurrencySettings() (modules.RenterConcurrency, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterConcurrency{}, err
+	}
+	defer r.tg.Done()
+
+	settings := r.staticConcurrency.callSettings()
+	scanThreads, err := r.hostDB.MaxScanningThreads()
+	if err != nil {
+		return modules.RenterConcurrency{}, errors.AddContext(err, "failed to get hostdb scanning threads")
+	}
+	return modules.RenterConcurrency{
+		Settings: settings,
+		Effective: modules.RenterConcurrencySettings{
+			RepairUploadChunks: uint64(r.staticConcurrency.callRepairUploadChunks()),
+			RepairDownloads:    uint64(limitOrDefault(settings.RepairDownloads, repairDownloadsDefault)),
+			HealthCheckThreads: uint64(r.staticConcurrency.callHealthCheckThreads()),
+			HostDBScanThreads:  uint64(scanThreads),
+		},
+	}, nil
+}
+
+// SetConcurrencySettings updates the concurrency limits of the renter and
+// persists them. A limit of 0 restores the default.
+func (r *Renter) SetConcurrencySettings(settings modules.RenterConcurrencySettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if err := validateConcurrencySettings(settings); err != nil {
+		return err
+	}
+	if err := r.hostDB.SetMaxScanningThreads(int(settings.HostDBScanThreads)); err != nil {
+		return errors.AddContext(err, "failed to set hostdb scanning threads")
+	}
+	r.staticConcurrency.callSetSettings(settings)
+
+	id := r.mu.Lock()
+	r.persist.Concurrency = settings
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	return err
+}
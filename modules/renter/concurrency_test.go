@@ -0,0 +1,91 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestResizableSemaphore probes acquiring a resizableSemaphore while its limit
+// changes.
+func TestResizableSemaphore(t *testing.T) {
+	t.Parallel()
+
+	s := newResizableSemaphore(1)
+	stop := make(chan struct{})
+	if !s.managedAcquire(stop) {
+		t.Fatal("failed to acquire semaphore")
+	}
+
+	// A second acquisition blocks until the limit is raised.
+	acquired := make(chan bool)
+	go func() {
+		acquired <- s.managedAcquire(stop)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("semaphore acquired above its limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+	s.managedSetLimit(2)
+	if !<-acquired {
+		t.Fatal("failed to acquire semaphore after raising its limit")
+	}
+
+	// Lowering the limit delays new acquisitions until enough holders
+	// released the semaphore.
+	s.managedSetLimit(1)
+	go func() {
+		acquired <- s.managedAcquire(stop)
+	}()
+	s.managedRelease()
+	select {
+	case <-acquired:
+		t.Fatal("semaphore acquired above its limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+	s.managedRelease()
+	if !<-acquired {
+		t.Fatal("failed to acquire semaphore after it was released")
+	}
+
+	// Closing stop interrupts a blocked acquisition.
+	go func() {
+		acquired <- s.managedAcquire(stop)
+	}()
+	close(stop)
+	if <-acquired {
+		t.Fatal("semaphore acquired after stop was closed")
+	}
+}
+
+// TestConcurrencyLimits checks that the concurrency limits fall back to their
+// defaults and that limits which are too high are rejected.
+func TestConcurrencyLimits(t *testing.T) {
+	t.Parallel()
+
+	cl := newConcurrencyLimits()
+	if cl.callRepairUploadChunks() != maxUploadHeapChunks || cl.callHealthCheckThreads() != numBubbleWorkerThreads {
+		t.Fatal("defaults not used")
+	}
+	cl.callSetSettings(modules.RenterConcurrencySettings{
+		RepairUploadChunks: 7,
+		HealthCheckThreads: 3,
+		RepairDownloads:    2,
+	})
+	if cl.callRepairUploadChunks() != 7 || cl.callHealthCheckThreads() != 3 || cl.staticRepairDownloads.limit != 2 {
+		t.Fatal("limits not applied")
+	}
+	cl.callSetSettings(modules.RenterConcurrencySettings{})
+	if cl.staticRepairDownloads.limit != repairDownloadsDefault {
+		t.Fatal("default repair downloads limit not restored")
+	}
+
+	if err := validateConcurrencySettings(modules.RenterConcurrencySettings{HostDBScanThreads: maxConcurrencyLimit}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateConcurrencySettings(modules.RenterConcurrencySettings{RepairDownloads: maxConcurrencyLimit + 1}); err != errConcurrencyLimitTooHigh {
+		t.Fatal("expected errConcurrencyLimitTooHigh", err)
+	}
+}
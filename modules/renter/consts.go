@@ -21,6 +21,18 @@ const (
 	// AlertSiafileLowRedundancyThreshold is the health threshold at which we start
 	// registering the LowRedundancy alert for a Siafile.
 	AlertSiafileLowRedundancyThreshold = 0.75
+
+	// AlertMSGAccountSpendingCap indicates that the renter spent as much
+	// money from a host's ephemeral account as its spending cap allows.
+	AlertMSGAccountSpendingCap = "A host's ephemeral account reached its spending cap, the renter stops paying the host from the account until older spending leaves the window"
+
+	// AlertMSGContractsOverpriced indicates that renewing some contracts is
+	// projected to cost significantly more than the market rate.
+	AlertMSGContractsOverpriced = "Renewing some contracts is projected to cost significantly more than the market rate, consider adjusting the allowance or blocklisting the overpriced hosts"
+	// AlertContractsOverpricedRatio is the ratio between the projected
+	// renewal cost of a contract and the cost at the market rate at which the
+	// contract is considered overpriced.
+	AlertContractsOverpricedRatio = 1.5
 )
 
 // AlertCauseSiafileLowRedundancy creates a customized "cause" for a siafile
@@ -142,6 +154,15 @@ var (
 		Standard: int64(1 << 25), // 32 MiB
 		Testing:  int64(1 << 13), // 8 KiB
 	}).(int64)
+
+	// streamPrefetchChunks is the number of chunks following the data a
+	// streamer fetches for which the streamer sends prefetch hints to the
+	// hosts.
+	streamPrefetchChunks = build.Select(build.Var{
+		Dev:      uint64(2),
+		Standard: uint64(4),
+		Testing:  uint64(2),
+	}).(uint64)
 )
 
 // Default bandwidth usage parameters.
@@ -251,6 +272,31 @@ var (
 		Testing:  5,
 	}).(int)
 
+	// migrationCheckInterval is how often the renter checks for hosts which
+	// were blocklisted or removed from the hostdb and pushes the chunks stored
+	// on them onto the upload heap.
+	migrationCheckInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// priceCheckInterval is how often the renter compares the prices of the
+	// hosts it has contracts with against the market rate.
+	priceCheckInterval = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: time.Hour,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// priceCheckMinHosts is the minimum number of active hosts in the hostdb
+	// required to compute a meaningful market rate.
+	priceCheckMinHosts = build.Select(build.Var{
+		Dev:      3,
+		Standard: 10,
+		Testing:  1,
+	}).(int)
+
 	// minUploadHeapSize is the minimum number of chunks we want in the upload
 	// heap before trying to add more in order to maintain back pressure on the
 	// workers, repairs, and uploads.
@@ -347,6 +393,15 @@ var (
 		Testing:  time.Second,
 	}).(time.Duration)
 
+	// uploadVerificationTimeout is the amount of time a worker waits for the
+	// verification read of a repaired piece before giving up on it. It is the
+	// default of the repair job timeout.
+	uploadVerificationTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  30 * time.Second,
+	}).(time.Duration)
+
 	// workerPoolUpdateTimeout is the amount of time that can pass before the
 	// worker pool should be updated.
 	workerPoolUpdateTimeout = build.Select(build.Var{
@@ -1,15 +1,16 @@
 package contractor
 
 import (
-	"errors"
 	"reflect"
 
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
 var (
-	errAllowanceNotSynced = errors.New("you must be synced to set an allowance")
+	errAllowanceNotSynced = errors.AddContext(modules.ErrNotSynced, "you must be synced to set an allowance")
 
 	// ErrAllowanceZeroFunds is returned if the allowance funds are being set to
 	// zero when not cancelling the allowance
@@ -40,6 +41,31 @@ var (
 	ErrAllowanceZeroMaxPeriodChurn = errors.New("max period churn must be non-zero")
 )
 
+// checkAllowance returns an error if any of the required fields of a non-empty
+// allowance are not set.
+func checkAllowance(a modules.Allowance) error {
+	if a.Funds.Cmp(types.ZeroCurrency) <= 0 {
+		return ErrAllowanceZeroFunds
+	} else if a.Hosts == 0 {
+		return ErrAllowanceNoHosts
+	} else if a.Period == 0 {
+		return ErrAllowanceZeroPeriod
+	} else if a.RenewWindow == 0 {
+		return ErrAllowanceZeroWindow
+	} else if a.ExpectedStorage == 0 {
+		return ErrAllowanceZeroExpectedStorage
+	} else if a.ExpectedUpload == 0 {
+		return ErrAllowanceZeroExpectedUpload
+	} else if a.ExpectedDownload == 0 {
+		return ErrAllowanceZeroExpectedDownload
+	} else if a.ExpectedRedundancy == 0 {
+		return ErrAllowanceZeroExpectedRedundancy
+	} else if a.MaxPeriodChurn == 0 {
+		return ErrAllowanceZeroMaxPeriodChurn
+	}
+	return nil
+}
+
 // SetAllowance sets the amount of money the Contractor is allowed to spend on
 // contracts over a given time period, divided among the number of hosts
 // specified. Note that Contractor can start forming contracts as soon as
@@ -66,25 +92,9 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 	}
 
 	// sanity checks
-	if a.Funds.Cmp(types.ZeroCurrency) <= 0 {
-		return ErrAllowanceZeroFunds
-	} else if a.Hosts == 0 {
-		return ErrAllowanceNoHosts
-	} else if a.Period == 0 {
-		return ErrAllowanceZeroPeriod
-	} else if a.RenewWindow == 0 {
-		return ErrAllowanceZeroWindow
-	} else if a.ExpectedStorage == 0 {
-		return ErrAllowanceZeroExpectedStorage
-	} else if a.ExpectedUpload == 0 {
-		return ErrAllowanceZeroExpectedUpload
-	} else if a.ExpectedDownload == 0 {
-		return ErrAllowanceZeroExpectedDownload
-	} else if a.ExpectedRedundancy == 0 {
-		return ErrAllowanceZeroExpectedRedundancy
-	} else if a.MaxPeriodChurn == 0 {
-		return ErrAllowanceZeroMaxPeriodChurn
-	} else if !c.cs.Synced() {
+	if err := checkAllowance(a); err != nil {
+		return err
+	} else if !c.cs.Synced() || c.staticDeps.Disrupt("UnsyncedConsensus") {
 		return errAllowanceNotSynced
 	}
 	c.log.Println("INFO: setting allowance to", a)
@@ -117,6 +127,7 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 		unlockContracts = true
 	}
 	c.allowance = a
+	c.activeAllowanceProfile = ""
 	err := c.save()
 	c.mu.Unlock()
 	if err != nil {
@@ -200,6 +211,7 @@ func (c *Contractor) managedCancelAllowance() error {
 	// Clear out the allowance and save.
 	c.mu.Lock()
 	c.allowance = modules.Allowance{}
+	c.activeAllowanceProfile = ""
 	c.currentPeriod = 0
 	err := c.save()
 	c.mu.Unlock()
@@ -0,0 +1,155 @@
+package contractor
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrAllowanceProfileNotFound is returned if an allowance profile with
+	// the provided name doesn't exist.
+	ErrAllowanceProfileNotFound = errors.New("allowance profile not found")
+
+	// errAllowanceProfileNoName is returned when trying to save an allowance
+	// profile without a name.
+	errAllowanceProfileNoName = errors.New("allowance profile name must be non-empty")
+)
+
+// allowanceChangedFields returns the json names of the fields which differ
+// between two allowances.
+func allowanceChangedFields(old, new modules.Allowance) []string {
+	changed := []string{}
+	oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(new)
+	t := oldValue.Type()
+	for i := 0; i < t.NumField(); i++ {
+		o, n := oldValue.Field(i).Interface(), newValue.Field(i).Interface()
+		if oc, ok := o.(types.Currency); ok && oc.Equals(n.(types.Currency)) {
+			continue
+		} else if !ok && reflect.DeepEqual(o, n) {
+			continue
+		}
+		changed = append(changed, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return changed
+}
+
+// AllowanceProfiles returns the allowance profiles sorted by name.
+func (c *Contractor) AllowanceProfiles() []modules.AllowanceProfile {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	profiles := make([]modules.AllowanceProfile, 0, len(c.allowanceProfiles))
+	for name, a := range c.allowanceProfiles {
+		profiles = append(profiles, modules.AllowanceProfile{
+			Name:      name,
+			Allowance: a,
+			Active:    name == c.activeAllowanceProfile,
+		})
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// ActivateAllowanceProfile sets the allowance to the allowance of the profile
+// with the provided name.
+func (c *Contractor) ActivateAllowanceProfile(name string) error {
+	c.mu.RLock()
+	a, exists := c.allowanceProfiles[name]
+	c.mu.RUnlock()
+	if !exists {
+		return ErrAllowanceProfileNotFound
+	}
+	err := c.SetAllowance(a)
+	if err != nil {
+		return errors.AddContext(err, "failed to set allowance of profile")
+	}
+	c.log.Println("INFO: activated allowance profile", name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.activeAllowanceProfile = name
+	return c.save()
+}
+
+// AllowanceProfileDiff previews how the contracts would change if the profile
+// with the provided name was activated.
+func (c *Contractor) AllowanceProfileDiff(name string) (modules.AllowanceProfileDiff, error) {
+	c.mu.RLock()
+	profile, exists := c.allowanceProfiles[name]
+	current := c.allowance
+	blockHeight := c.blockHeight
+	currentPeriod := c.currentPeriod
+	c.mu.RUnlock()
+	if !exists {
+		return modules.AllowanceProfileDiff{}, ErrAllowanceProfileNotFound
+	}
+
+	// A new period starts if there is no allowance yet. See SetAllowance.
+	if reflect.DeepEqual(current, modules.Allowance{}) {
+		currentPeriod = blockHeight
+		if profile.Period > profile.RenewWindow {
+			currentPeriod -= profile.RenewWindow
+		}
+	}
+	diff := modules.AllowanceProfileDiff{
+		Profile:          name,
+		ChangedFields:    allowanceChangedFields(current, profile),
+		ContractsToRenew: []types.FileContractID{},
+		EndHeight:        currentPeriod + profile.Period + profile.RenewWindow,
+	}
+	for _, contract := range c.staticContracts.ViewAll() {
+		if contract.Utility.GoodForUpload {
+			diff.GoodForUploadContracts++
+		}
+		if contract.Utility.GoodForRenew && blockHeight+profile.RenewWindow >= contract.EndHeight {
+			diff.ContractsToRenew = append(diff.ContractsToRenew, contract.ID)
+		}
+	}
+	if profile.Hosts > diff.GoodForUploadContracts {
+		diff.ContractsToForm = profile.Hosts - diff.GoodForUploadContracts
+	} else {
+		diff.ContractsToDisable = diff.GoodForUploadContracts - profile.Hosts
+	}
+	return diff, nil
+}
+
+// DeleteAllowanceProfile deletes the profile with the provided name. The
+// allowance is not changed.
+func (c *Contractor) DeleteAllowanceProfile(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.allowanceProfiles[name]; !exists {
+		return ErrAllowanceProfileNotFound
+	}
+	delete(c.allowanceProfiles, name)
+	if c.activeAllowanceProfile == name {
+		c.activeAllowanceProfile = ""
+	}
+	return c.save()
+}
+
+// SaveAllowanceProfile creates or replaces the profile with the provided name.
+// The allowance is not changed. If the profile is active and its allowance
+// changes, it is no longer considered active.
+func (c *Contractor) SaveAllowanceProfile(name string, a modules.Allowance) error {
+	if name == "" {
+		return errAllowanceProfileNoName
+	}
+	if err := checkAllowance(a); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.allowanceProfiles[name] = a
+	if c.activeAllowanceProfile == name && len(allowanceChangedFields(c.allowance, a)) > 0 {
+		c.activeAllowanceProfile = ""
+	}
+	return c.save()
+}
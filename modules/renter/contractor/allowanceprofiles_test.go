@@ -0,0 +1,168 @@
+package contractor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/proto"
+	"go.sia.tech/siad/types"
+)
+
+// TestAllowanceChangedFields probes allowanceChangedFields.
+func TestAllowanceChangedFields(t *testing.T) {
+	a := modules.DefaultAllowance
+	if changed := allowanceChangedFields(a, a); len(changed) != 0 {
+		t.Fatal("expected no changed fields", changed)
+	}
+
+	// Currencies with the same value but a different representation are
+	// equal.
+	b := a
+	b.Funds = a.Funds.Add(types.ZeroCurrency)
+	if changed := allowanceChangedFields(a, b); len(changed) != 0 {
+		t.Fatal("expected no changed fields", changed)
+	}
+
+	b.Funds = a.Funds.Mul64(2)
+	b.Hosts = a.Hosts + 1
+	expected := []string{"funds", "hosts"}
+	if changed := allowanceChangedFields(a, b); !reflect.DeepEqual(changed, expected) {
+		t.Fatal("unexpected changed fields", changed)
+	}
+}
+
+// TestAllowanceProfiles tests saving, listing, diffing and deleting allowance
+// profiles as well as their persistence.
+func TestAllowanceProfiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	persistDir := build.TempDir("contractor", t.Name())
+	if err := os.MkdirAll(persistDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := proto.NewContractSet(filepath.Join(persistDir, "contracts"), ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		persistDir:        persistDir,
+		synced:            make(chan struct{}),
+		staticContracts:   cs,
+		allowanceProfiles: make(map[string]modules.Allowance),
+		blockHeight:       100,
+	}
+	c.staticWatchdog = newWatchdog(c)
+	c.staticChurnLimiter = newChurnLimiter(c)
+
+	// Profiles need a name and a valid allowance.
+	if err := c.SaveAllowanceProfile("", modules.DefaultAllowance); !errors.Contains(err, errAllowanceProfileNoName) {
+		t.Fatal("expected errAllowanceProfileNoName, got", err)
+	}
+	invalid := modules.DefaultAllowance
+	invalid.Hosts = 0
+	if err := c.SaveAllowanceProfile("invalid", invalid); !errors.Contains(err, ErrAllowanceNoHosts) {
+		t.Fatal("expected ErrAllowanceNoHosts, got", err)
+	}
+
+	// Save two profiles.
+	big := modules.DefaultAllowance
+	big.Hosts *= 2
+	if err := c.SaveAllowanceProfile("default", modules.DefaultAllowance); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveAllowanceProfile("big", big); err != nil {
+		t.Fatal(err)
+	}
+	profiles := c.AllowanceProfiles()
+	if len(profiles) != 2 || profiles[0].Name != "big" || profiles[1].Name != "default" {
+		t.Fatal("unexpected profiles", profiles)
+	}
+	if profiles[0].Active || profiles[1].Active {
+		t.Fatal("no profile should be active")
+	}
+
+	// Diff the big profile. Without an allowance, all set fields change and a
+	// new period starts.
+	diff, err := c.AllowanceProfileDiff("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(diff.ChangedFields, allowanceChangedFields(modules.Allowance{}, big)) {
+		t.Fatal("unexpected changed fields", diff.ChangedFields)
+	}
+	if diff.ContractsToForm != big.Hosts || diff.ContractsToDisable != 0 || len(diff.ContractsToRenew) != 0 {
+		t.Fatal("unexpected contract changes", diff)
+	}
+	expectedEndHeight := c.blockHeight - big.RenewWindow + big.Period + big.RenewWindow
+	if diff.EndHeight != expectedEndHeight {
+		t.Fatalf("expected end height %v, got %v", expectedEndHeight, diff.EndHeight)
+	}
+	if _, err := c.AllowanceProfileDiff("missing"); !errors.Contains(err, ErrAllowanceProfileNotFound) {
+		t.Fatal("expected ErrAllowanceProfileNotFound, got", err)
+	}
+
+	// Pretend that the default profile is active. Changing it deactivates it.
+	c.allowance = modules.DefaultAllowance
+	c.activeAllowanceProfile = "default"
+	diff, err = c.AllowanceProfileDiff("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(diff.ChangedFields, []string{"hosts"}) {
+		t.Fatal("unexpected changed fields", diff.ChangedFields)
+	}
+	if err := c.SaveAllowanceProfile("default", big); err != nil {
+		t.Fatal(err)
+	}
+	if c.AllowanceProfiles()[1].Active {
+		t.Fatal("changed profile shouldn't be active")
+	}
+	if err := c.SaveAllowanceProfile("default", modules.DefaultAllowance); err != nil {
+		t.Fatal(err)
+	}
+	c.activeAllowanceProfile = "default"
+
+	// The profiles are persisted.
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+	c.allowanceProfiles = nil
+	c.activeAllowanceProfile = ""
+	if err := c.load(); err != nil {
+		t.Fatal(err)
+	}
+	profiles = c.AllowanceProfiles()
+	if len(profiles) != 2 || profiles[0].Name != "big" || profiles[1].Name != "default" {
+		t.Fatal("profiles weren't persisted", profiles)
+	}
+	if profiles[0].Active || !profiles[1].Active {
+		t.Fatal("active profile wasn't persisted", profiles)
+	}
+	if len(allowanceChangedFields(profiles[0].Allowance, big)) != 0 || len(allowanceChangedFields(profiles[1].Allowance, modules.DefaultAllowance)) != 0 {
+		t.Fatal("profile allowances weren't persisted", profiles)
+	}
+
+	// Delete the active profile.
+	if err := c.DeleteAllowanceProfile("default"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteAllowanceProfile("default"); !errors.Contains(err, ErrAllowanceProfileNotFound) {
+		t.Fatal("expected ErrAllowanceProfileNotFound, got", err)
+	}
+	if c.activeAllowanceProfile != "" {
+		t.Fatal("deleted profile is still active")
+	}
+	if profiles := c.AllowanceProfiles(); len(profiles) != 1 || profiles[0].Name != "big" {
+		t.Fatal("unexpected profiles", profiles)
+	}
+}
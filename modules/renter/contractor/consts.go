@@ -17,6 +17,11 @@ var (
 	// funds.
 	AlertMSGAllowanceLowFunds = "At least one contract formation/renewal failed due to the allowance being low on funds"
 
+	// AlertMSGContractDoubleSpent indicates that the inputs of a contract's
+	// formation transaction set were double-spent and the contractor is
+	// forming a replacement.
+	AlertMSGContractDoubleSpent = "The formation transaction of a contract was double-spent, forming a replacement contract"
+
 	// AlertMSGFailedContractRenewal indicates that the contract renewal failed
 	AlertMSGFailedContractRenewal = "Contractor is attempting to renew/refresh contracts but failed"
 
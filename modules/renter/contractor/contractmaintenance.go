@@ -54,10 +54,14 @@ type (
 
 // callNotifyDoubleSpend is used by the watchdog to alert the contractor
 // whenever a monitored file contract input is double-spent. This function
-// marks down the host score, and marks the contract as !GoodForRenew and
-// !GoodForUpload.
+// registers an alert, marks down the host score, and marks the contract as
+// !GoodForRenew and !GoodForUpload. The contract is then archived and contract
+// maintenance is run to form a replacement. It blocks until maintenance is
+// done and should therefore be called in a goroutine.
 func (c *Contractor) callNotifyDoubleSpend(fcID types.FileContractID, blockHeight types.BlockHeight) {
 	c.log.Println("Watchdog found a double-spend: ", fcID, blockHeight)
+	cause := fmt.Sprintf("contract %v was double-spent at height %v", fcID, blockHeight)
+	c.staticAlerter.RegisterAlert(modules.AlertIDRenterContractDoubleSpent, AlertMSGContractDoubleSpent, cause, modules.SeverityWarning)
 
 	// Mark the contract as double-spent. This will cause the contract to be
 	// excluded in period spending.
@@ -69,6 +73,26 @@ func (c *Contractor) callNotifyDoubleSpend(fcID types.FileContractID, blockHeigh
 	if err != nil {
 		c.log.Println("callNotifyDoubleSpend error in MarkContractBad", err)
 	}
+
+	// The contract will never appear on-chain. Move it to the old contracts
+	// right away, otherwise it keeps counting towards the active contracts
+	// and its host is excluded from forming a replacement until the contract
+	// expires.
+	sc, ok := c.staticContracts.Acquire(fcID)
+	if ok {
+		c.mu.Lock()
+		c.oldContracts[fcID] = sc.Metadata()
+		err = c.save()
+		c.mu.Unlock()
+		if err != nil {
+			c.log.Println("callNotifyDoubleSpend failed to save the contractor:", err)
+		}
+		c.staticContracts.Delete(sc)
+	}
+
+	// Form a replacement with fresh inputs instead of waiting for the next
+	// block to trigger maintenance.
+	c.threadedContractMaintenance()
 }
 
 // managedCheckForDuplicates checks for static contracts that have the same host
@@ -1090,7 +1114,9 @@ func (c *Contractor) threadedContractMaintenance() {
 
 	// Iterate through the contracts again, figuring out which contracts to
 	// renew and how much extra funds to renew them with.
-	for _, contract := range c.staticContracts.ViewAll() {
+	contracts := c.staticContracts.ViewAll()
+	c.managedPruneRenewalHints(contracts)
+	for _, contract := range contracts {
 		c.log.Debugln("Examining a contract:", contract.HostPublicKey, contract.ID)
 		// Skip any host that does not match our whitelist/blacklist filter
 		// settings.
@@ -1120,11 +1146,20 @@ func (c *Contractor) threadedContractMaintenance() {
 			continue
 		}
 
-		// If the contract needs to be renewed because it is about to expire,
+		// If the contract needs to be renewed because it is about to expire or
+		// because the host published a hint asking for it to be renewed,
 		// calculate a spending for the contract that is proportional to how
 		// much money was spend on the contract throughout this billing cycle
 		// (which is now ending).
-		if blockHeight+allowance.RenewWindow >= contract.EndHeight && !c.staticDeps.Disrupt("disableRenew") {
+		renew := blockHeight+allowance.RenewWindow >= contract.EndHeight
+		if !renew && renewalHintPossible(contract, blockHeight) {
+			hint, found := c.managedRenewalHint(contract)
+			renew = found && renewalHintApplies(hint, contract, blockHeight)
+			if renew {
+				c.log.Debugln("Host asked for contract to be renewed", contract.ID, hint.RenewBeforeHeight)
+			}
+		}
+		if renew && !c.staticDeps.Disrupt("disableRenew") {
 			renewAmount, err := c.managedEstimateRenewFundingRequirements(contract, blockHeight, allowance)
 			if err != nil {
 				c.log.Debugln("Contract skipped because there was an error estimating renew funding requirements", renewAmount, err)
@@ -1487,4 +1522,10 @@ func (c *Contractor) threadedContractMaintenance() {
 			c.log.Println("Unable to save the contractor:", err)
 		}
 	}
+
+	// Any double-spent contracts have been replaced once the contractor has
+	// enough contracts again.
+	if neededContracts <= 0 {
+		c.staticAlerter.UnregisterAlert(modules.AlertIDRenterContractDoubleSpent)
+	}
 }
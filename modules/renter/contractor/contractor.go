@@ -3,14 +3,17 @@ package contractor
 import (
 	"bytes"
 	"io"
+	"math"
 	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/ratelimit"
 	"gitlab.com/NebulousLabs/threadgroup"
 
@@ -89,6 +92,16 @@ type Contractor struct {
 	numFailedRenews map[types.FileContractID]types.BlockHeight
 	renewing        map[types.FileContractID]bool // prevent revising during renewal
 
+	// renewalHints caches the renewal hints hosts published for the
+	// contractor's contracts in their registries.
+	renewalHints map[types.FileContractID]renewalHintEntry
+
+	// allowanceProfiles are the named allowance presets of the user.
+	// activeAllowanceProfile is the name of the profile the current allowance
+	// was activated from. It is reset when the allowance is changed directly.
+	allowanceProfiles      map[string]modules.Allowance
+	activeAllowanceProfile string
+
 	// pubKeysToContractID is a map of host pubkeys to the latest contract ID
 	// that is formed with the host. The contract also has to have an end height
 	// in the future
@@ -144,6 +157,44 @@ func (c *Contractor) ContractPublicKey(pk types.SiaPublicKey) (crypto.PublicKey,
 	return c.staticContracts.PublicKey(id)
 }
 
+// IngressProof creates an ingress proof for the RPC with the given id which is
+// signed with the renter's key of the contract with the given id. The
+// contract is not locked since the proof is sent within RPCs which might use
+// the contract.
+func (c *Contractor) IngressProof(id types.FileContractID, hostKey types.SiaPublicKey, rpcID types.Specifier) (modules.RPCIngressProof, error) {
+	proof := modules.RPCIngressProof{
+		Type:       modules.IngressProofContract,
+		Timestamp:  time.Now().Unix(),
+		ContractID: id,
+		Nonce:      fastrand.Uint64n(math.MaxUint64),
+	}
+	sig, exists := c.staticContracts.Sign(id, proof.Hash(hostKey, rpcID))
+	if !exists {
+		return modules.RPCIngressProof{}, errContractNotFound
+	}
+	proof.Signature = sig
+	return proof, nil
+}
+
+// DelegatedReadToken creates a token signed with the renter's key of the
+// contract with the given id, which authorizes the delegate to download the
+// contract's sectors from its host until the expiry height.
+func (c *Contractor) DelegatedReadToken(id types.FileContractID, delegate types.SiaPublicKey, expiry types.BlockHeight) (modules.DelegatedReadToken, error) {
+	sc, exists := c.staticContracts.Acquire(id)
+	if !exists {
+		return modules.DelegatedReadToken{}, errContractNotFound
+	}
+	defer c.staticContracts.Return(sc)
+
+	token := modules.DelegatedReadToken{
+		FileContractID: id,
+		Delegate:       delegate,
+		Expiry:         expiry,
+	}
+	token.Signature = sc.Sign(token.Hash())
+	return token, nil
+}
+
 // InitRecoveryScan starts scanning the whole blockchain for recoverable
 // contracts within a separate thread.
 func (c *Contractor) InitRecoveryScan() (err error) {
@@ -244,6 +295,24 @@ func (c *Contractor) CurrentPeriod() types.BlockHeight {
 	return c.currentPeriod
 }
 
+// SessionDialTimeout returns the maximum amount of time dialing a host when
+// creating a session may take.
+func (c *Contractor) SessionDialTimeout() time.Duration {
+	return c.staticContracts.SessionDialTimeout()
+}
+
+// SetSessionDialTimeout sets the maximum amount of time dialing a host when
+// creating a session may take. A timeout of 0 restores the default.
+func (c *Contractor) SetSessionDialTimeout(timeout time.Duration) {
+	c.staticContracts.SetSessionDialTimeout(timeout)
+}
+
+// SetBandwidthMeter sets the meter which records the traffic of forming and
+// renewing contracts.
+func (c *Contractor) SetBandwidthMeter(bm *modules.BandwidthMeter) {
+	c.staticContracts.SetBandwidthMeter(bm)
+}
+
 // UpdateWorkerPool updates the workerpool currently in use by the contractor.
 func (c *Contractor) UpdateWorkerPool(wp modules.WorkerPool) {
 	c.mu.Lock()
@@ -489,6 +558,8 @@ func contractorBlockingStartup(cs modules.ConsensusSet, w modules.Wallet, tp mod
 		oldContracts:         make(map[types.FileContractID]modules.RenterContract),
 		doubleSpentContracts: make(map[types.FileContractID]types.BlockHeight),
 		recoverableContracts: make(map[types.FileContractID]modules.RecoverableContract),
+		allowanceProfiles:    make(map[string]modules.Allowance),
+		renewalHints:         make(map[types.FileContractID]renewalHintEntry),
 		renewing:             make(map[types.FileContractID]bool),
 		renewedFrom:          make(map[types.FileContractID]types.FileContractID),
 		renewedTo:            make(map[types.FileContractID]types.FileContractID),
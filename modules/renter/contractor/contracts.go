@@ -15,6 +15,39 @@ func (c *Contractor) contractEndHeight() types.BlockHeight {
 	return c.currentPeriod + c.allowance.Period + c.allowance.RenewWindow
 }
 
+// AdoptContract loads a contract whose files were placed in the contract
+// set's directory after the contractor was started, e.g. by replicating the
+// metadata of another renter. The contract is watched like a recovered
+// contract.
+func (c *Contractor) AdoptContract(id types.FileContractID) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+	contract, err := c.staticContracts.LoadContract(id)
+	if err != nil {
+		return errors.AddContext(err, "failed to load contract")
+	}
+
+	// Add a mapping from the host's public key to the contract unless there
+	// is a contract with the host already.
+	c.mu.Lock()
+	if _, exists := c.pubKeysToContractID[contract.HostPublicKey.String()]; !exists {
+		c.pubKeysToContractID[contract.HostPublicKey.String()] = contract.ID
+	}
+	c.mu.Unlock()
+
+	err = c.staticWatchdog.callMonitorContract(monitorContractArgs{
+		recovered:   true,
+		fcID:        contract.ID,
+		revisionTxn: contract.Transaction,
+	})
+	if errors.Contains(err, errAlreadyWatchingContract) {
+		err = nil
+	}
+	return err
+}
+
 // managedCancelContract cancels a contract by setting its utility fields to
 // false and locking the utilities. The contract can still be used for
 // downloads after this but it won't be used for uploads or renewals.
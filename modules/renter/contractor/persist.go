@@ -39,6 +39,9 @@ type contractorPersist struct {
 	RenewedTo            map[string]types.FileContractID `json:"renewedto"`
 	Synced               bool                            `json:"synced"`
 
+	AllowanceProfiles      map[string]modules.Allowance `json:"allowanceprofiles"`
+	ActiveAllowanceProfile string                       `json:"activeallowanceprofile"`
+
 	// Subsystem persistence:
 	ChurnLimiter churnLimiterPersist `json:"churnlimiter"`
 	WatchdogData watchdogPersist     `json:"watchdogdata"`
@@ -62,6 +65,12 @@ func (c *Contractor) persistData() contractorPersist {
 		RenewedTo:            make(map[string]types.FileContractID),
 		DoubleSpentContracts: make(map[string]types.BlockHeight),
 		Synced:               synced,
+
+		AllowanceProfiles:      make(map[string]modules.Allowance),
+		ActiveAllowanceProfile: c.activeAllowanceProfile,
+	}
+	for name, a := range c.allowanceProfiles {
+		data.AllowanceProfiles[name] = a
 	}
 	for k, v := range c.renewedFrom {
 		data.RenewedFrom[k.String()] = v
@@ -147,6 +156,11 @@ func (c *Contractor) load() error {
 	for _, contract := range data.RecoverableContracts {
 		c.recoverableContracts[contract.ID] = contract
 	}
+	c.allowanceProfiles = make(map[string]modules.Allowance)
+	for name, a := range data.AllowanceProfiles {
+		c.allowanceProfiles[name] = a
+	}
+	c.activeAllowanceProfile = data.ActiveAllowanceProfile
 
 	c.staticChurnLimiter = newChurnLimiterFromPersist(c, data.ChurnLimiter)
 
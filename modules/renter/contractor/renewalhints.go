@@ -0,0 +1,147 @@
+package contractor
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// renewalHintCheckInterval is the amount of time the contractor waits
+	// before checking a host's registry for a contract's renewal hint again.
+	renewalHintCheckInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 3 * time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// renewalHintTimeout is the maximum amount of time the contractor waits
+	// for a host to return a renewal hint.
+	renewalHintTimeout = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+var (
+	// errRenewalHintMismatch is returned if a host returns a renewal hint for
+	// a different contract.
+	errRenewalHintMismatch = errors.New("renewal hint doesn't match the contract")
+)
+
+// renewalHintEntry is a cached renewal hint of a contract.
+type renewalHintEntry struct {
+	hint      modules.RenewalHint
+	found     bool
+	lastCheck time.Time
+}
+
+// decodeRenewalHint decodes the renewal hint stored in a registry value and
+// checks that it belongs to the contract with the given id.
+func decodeRenewalHint(srv modules.SignedRegistryValue, fcid types.FileContractID) (modules.RenewalHint, error) {
+	var hint modules.RenewalHint
+	err := encoding.Unmarshal(srv.Data, &hint)
+	if err != nil {
+		return modules.RenewalHint{}, errors.AddContext(err, "failed to decode renewal hint")
+	}
+	if hint.ContractID != fcid {
+		return modules.RenewalHint{}, errRenewalHintMismatch
+	}
+	return hint, nil
+}
+
+// renewalHintApplies returns whether a hint asks for the contract to be
+// renewed at the given height. Hints which ask for a contract to be renewed
+// within the first half of its duration are ignored to prevent hosts from
+// making the renter renew excessively.
+func renewalHintApplies(hint modules.RenewalHint, contract modules.RenterContract, blockHeight types.BlockHeight) bool {
+	if hint.RenewBeforeHeight > contract.EndHeight {
+		return false
+	}
+	if contract.EndHeight < contract.StartHeight || contract.EndHeight-hint.RenewBeforeHeight > (contract.EndHeight-contract.StartHeight)/2 {
+		return false
+	}
+	return blockHeight >= hint.RenewBeforeHeight
+}
+
+// renewalHintPossible returns whether a hint could ask for the contract to be
+// renewed at the given height. It allows for skipping the registry lookup for
+// contracts which are still in the first half of their duration.
+func renewalHintPossible(contract modules.RenterContract, blockHeight types.BlockHeight) bool {
+	if contract.EndHeight < contract.StartHeight {
+		return false
+	}
+	return blockHeight+(contract.EndHeight-contract.StartHeight)/2 >= contract.EndHeight
+}
+
+// managedRenewalHint returns the renewal hint the host published for a
+// contract. Hints are cached for renewalHintCheckInterval to avoid querying
+// the host's registry during every contract maintenance.
+func (c *Contractor) managedRenewalHint(contract modules.RenterContract) (modules.RenewalHint, bool) {
+	c.mu.RLock()
+	entry, exists := c.renewalHints[contract.ID]
+	c.mu.RUnlock()
+	if exists && time.Since(entry.lastCheck) < renewalHintCheckInterval {
+		return entry.hint, entry.found
+	}
+
+	entry = renewalHintEntry{lastCheck: time.Now()}
+	hint, found, err := c.managedFetchRenewalHint(contract)
+	if err != nil {
+		c.log.Debugf("failed to fetch renewal hint for contract %v: %v", contract.ID, err)
+	} else {
+		entry.hint, entry.found = hint, found
+	}
+	c.mu.Lock()
+	c.renewalHints[contract.ID] = entry
+	c.mu.Unlock()
+	return entry.hint, entry.found
+}
+
+// managedFetchRenewalHint reads the renewal hint for a contract from the
+// host's registry.
+func (c *Contractor) managedFetchRenewalHint(contract modules.RenterContract) (modules.RenewalHint, bool, error) {
+	c.mu.RLock()
+	wp := c.workerPool
+	c.mu.RUnlock()
+	w, err := wp.Worker(contract.HostPublicKey)
+	if err != nil {
+		return modules.RenewalHint{}, false, err
+	}
+	ctx, cancel := context.WithTimeout(c.tg.StopCtx(), renewalHintTimeout)
+	defer cancel()
+	srv, err := w.ReadRegistry(ctx, contract.HostPublicKey, modules.RenewalHintTweak(contract.ID))
+	if err != nil {
+		return modules.RenewalHint{}, false, err
+	}
+	if srv == nil {
+		return modules.RenewalHint{}, false, nil
+	}
+	hint, err := decodeRenewalHint(*srv, contract.ID)
+	if err != nil {
+		return modules.RenewalHint{}, false, err
+	}
+	return hint, true, nil
+}
+
+// managedPruneRenewalHints removes the cached renewal hints of contracts which
+// are no longer active.
+func (c *Contractor) managedPruneRenewalHints(active []modules.RenterContract) {
+	ids := make(map[types.FileContractID]struct{}, len(active))
+	for _, contract := range active {
+		ids[contract.ID] = struct{}{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id := range c.renewalHints {
+		if _, exists := ids[id]; !exists {
+			delete(c.renewalHints, id)
+		}
+	}
+}
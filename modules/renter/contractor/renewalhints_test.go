@@ -0,0 +1,133 @@
+package contractor
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// testRenewalHintWorker is a worker which only supports reading renewal hints
+// from a fixed registry value.
+type testRenewalHintWorker struct {
+	srv   *modules.SignedRegistryValue
+	reads int
+}
+
+// Worker implements the WorkerPool interface.
+func (w *testRenewalHintWorker) Worker(_ types.SiaPublicKey) (modules.Worker, error) {
+	return w, nil
+}
+
+// ReadRegistry implements the Worker interface.
+func (w *testRenewalHintWorker) ReadRegistry(_ context.Context, _ types.SiaPublicKey, _ crypto.Hash) (*modules.SignedRegistryValue, error) {
+	w.reads++
+	return w.srv, nil
+}
+
+// RenewContract implements the Worker interface.
+func (w *testRenewalHintWorker) RenewContract(_ context.Context, _ types.FileContractID, _ modules.ContractParams, _ modules.TransactionBuilder) (modules.RenterContract, []types.Transaction, error) {
+	return modules.RenterContract{}, nil, errors.New("not implemented")
+}
+
+// TestRenewalHintApplies is a unit test for renewalHintApplies.
+func TestRenewalHintApplies(t *testing.T) {
+	contract := modules.RenterContract{
+		StartHeight: 100,
+		EndHeight:   200,
+	}
+	tests := []struct {
+		renewBefore types.BlockHeight
+		height      types.BlockHeight
+		applies     bool
+	}{
+		{180, 179, false}, // too early
+		{180, 180, true},  // renew height reached
+		{180, 190, true},  // past renew height
+		{150, 150, true},  // half the duration
+		{149, 160, false}, // more than half the duration
+		{201, 201, false}, // after the end of the contract
+	}
+	for i, test := range tests {
+		hint := modules.RenewalHint{RenewBeforeHeight: test.renewBefore}
+		if applies := renewalHintApplies(hint, contract, test.height); applies != test.applies {
+			t.Errorf("%v: expected %v but got %v", i, test.applies, applies)
+		}
+		// A hint can only apply if one is possible at that height.
+		if test.applies && !renewalHintPossible(contract, test.height) {
+			t.Errorf("%v: hint applies but isn't possible", i)
+		}
+	}
+
+	// No hint is possible during the first half of the contract.
+	if renewalHintPossible(contract, 149) {
+		t.Error("hint shouldn't be possible before half the duration")
+	}
+	if !renewalHintPossible(contract, 150) {
+		t.Error("hint should be possible after half the duration")
+	}
+}
+
+// TestManagedRenewalHint probes fetching and caching renewal hints.
+func TestManagedRenewalHint(t *testing.T) {
+	logger, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &testRenewalHintWorker{}
+	c := &Contractor{
+		log:          logger,
+		renewalHints: make(map[types.FileContractID]renewalHintEntry),
+		workerPool:   w,
+	}
+	contract := modules.RenterContract{ID: types.FileContractID{1}}
+
+	// Without a published hint there is nothing to return.
+	if _, found := c.managedRenewalHint(contract); found {
+		t.Fatal("hint shouldn't exist")
+	}
+
+	// Publish a hint. It shouldn't be fetched until the cache expires.
+	hint := modules.RenewalHint{
+		ContractID:        contract.ID,
+		ExpirationHeight:  200,
+		RenewBeforeHeight: 180,
+	}
+	srv := modules.NewRegistryValue(modules.RenewalHintTweak(contract.ID), encoding.Marshal(hint), 0).Sign(crypto.SecretKey{})
+	w.srv = &srv
+	if _, found := c.managedRenewalHint(contract); found || w.reads != 1 {
+		t.Fatal("hint should have been cached", found, w.reads)
+	}
+	c.mu.Lock()
+	entry := c.renewalHints[contract.ID]
+	entry.lastCheck = time.Now().Add(-renewalHintCheckInterval)
+	c.renewalHints[contract.ID] = entry
+	c.mu.Unlock()
+	fetched, found := c.managedRenewalHint(contract)
+	if !found || fetched != hint || w.reads != 2 {
+		t.Fatal("unexpected hint", fetched, found, w.reads)
+	}
+
+	// A hint for another contract is ignored.
+	other := modules.RenterContract{ID: types.FileContractID{2}}
+	if _, found := c.managedRenewalHint(other); found {
+		t.Fatal("hint for another contract shouldn't be used")
+	}
+
+	// Pruning removes the hints of inactive contracts.
+	c.managedPruneRenewalHints([]modules.RenterContract{contract})
+	c.mu.RLock()
+	_, exists := c.renewalHints[other.ID]
+	n := len(c.renewalHints)
+	c.mu.RUnlock()
+	if exists || n != 1 {
+		t.Fatal("hints weren't pruned", n)
+	}
+}
@@ -961,3 +961,105 @@ func TestWatchdogDependencyAdding(t *testing.T) {
 		t.Fatal("unexpected txn set length", len(updatedTxnSet), len(txnSet)-numRoots)
 	}
 }
+
+// TestWatchdogDoubleSpend checks that the contractor archives a contract and
+// forms a replacement right away once the watchdog finds a block which
+// double-spends an input of the contract's formation transaction set.
+func TestWatchdogDoubleSpend(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create testing trio
+	_, c, _, cf, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tryClose(cf, t)
+
+	// form a contract with the host
+	a := modules.DefaultAllowance
+	a.Hosts = 1
+	err = c.SetAllowance(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 200*time.Millisecond, func() error {
+		if len(c.Contracts()) == 0 {
+			return errors.New("contracts were not formed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := c.Contracts()[0]
+
+	// Get an output the contract's formation depends on.
+	var parentOutput types.SiacoinOutputID
+	c.staticWatchdog.mu.Lock()
+	contractData, ok := c.staticWatchdog.contracts[contract.ID]
+	if ok {
+		for oid := range contractData.parentOutputs {
+			parentOutput = oid
+			break
+		}
+	}
+	c.staticWatchdog.mu.Unlock()
+	if !ok {
+		t.Fatal("contract isn't monitored")
+	}
+	if parentOutput == (types.SiacoinOutputID{}) {
+		t.Fatal("contract has no parent outputs")
+	}
+
+	// Scan a block with a transaction that spends the output without being
+	// part of the formation set.
+	doubleSpend := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: parentOutput}},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.UnlockHash(crypto.HashObject(fastrand.Bytes(32)))},
+		},
+	}
+	c.staticWatchdog.callScanConsensusChange(modules.ConsensusChange{
+		AppliedBlocks: []types.Block{{Transactions: []types.Transaction{doubleSpend}}},
+	})
+
+	// The watchdog should archive the contract as double-spent.
+	status, ok := c.ContractStatus(contract.ID)
+	if !ok || !status.Archived || status.DoubleSpendHeight == 0 {
+		t.Fatal("contract should be archived as double-spent", status)
+	}
+
+	// The contractor should move the contract to the old contracts and form a
+	// replacement.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		c.mu.RLock()
+		_, doubleSpent := c.doubleSpentContracts[contract.ID]
+		_, old := c.oldContracts[contract.ID]
+		c.mu.RUnlock()
+		if !doubleSpent {
+			return errors.New("double-spend not detected")
+		}
+		if !old {
+			return errors.New("contract wasn't archived")
+		}
+		contracts := c.Contracts()
+		if len(contracts) != 1 || contracts[0].ID == contract.ID {
+			return errors.New("replacement contract wasn't formed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The alert should be unregistered again once the contract was replaced.
+	_, _, warn := c.Alerts()
+	for _, alert := range warn {
+		if alert.Msg == AlertMSGContractDoubleSpent {
+			t.Fatal("alert wasn't unregistered", alert)
+		}
+	}
+}
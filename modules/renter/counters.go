@@ -0,0 +1,11 @@
+package renter
+
+import (
+	"go.sia.tech/siad/modules"
+)
+
+// OperationCounters implements the modules.OperationCounter interface for the
+// renter.
+func (r *Renter) OperationCounters() map[string]modules.OperationStats {
+	return r.staticOperationCounters.Stats()
+}
@@ -0,0 +1,490 @@
+package renter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// dirConversionFile is the name of the file the progress of a directory
+	// conversion is persisted to.
+	dirConversionFile = "dirconversion.json"
+)
+
+var (
+	// dirConversionFolder is the folder the converted files are uploaded to
+	// before they replace the original files.
+	dirConversionFolder = modules.NewGlobalSiaPath("/var/conversion")
+
+	// dirConversionMetadata is the metadata of the persisted directory
+	// conversion.
+	dirConversionMetadata = persist.Metadata{
+		Header:  "Directory Conversion",
+		Version: "1.5.6",
+	}
+)
+
+var (
+	// errDirConversionCancelled is returned when the conversion of a file is
+	// interrupted by the cancellation of the directory conversion.
+	errDirConversionCancelled = errors.New("directory conversion was cancelled")
+
+	// errDirConversionInProgress is returned when trying to start a directory
+	// conversion while another one is still in progress.
+	errDirConversionInProgress = errors.New("a directory conversion is already in progress")
+
+	// errNoDirConversion is returned if there is no directory conversion.
+	errNoDirConversion = errors.New("no directory conversion in progress")
+)
+
+type (
+	// dirConverter converts all files within a directory to new erasure
+	// coding settings by re-uploading them one at a time. The progress is
+	// persisted after every file to resume the conversion after a restart.
+	dirConverter struct {
+		// conversion is the current or last conversion and current is the
+		// file which is being converted.
+		conversion *modules.DirConversion
+		current    modules.SiaPath
+		cancel     chan struct{}
+		running    bool
+
+		staticPersistPath string
+		mu                sync.Mutex
+	}
+
+	// dirConversionPersist is the persisted state of the dirConverter.
+	dirConversionPersist struct {
+		Conversion modules.DirConversion `json:"conversion"`
+		Current    modules.SiaPath       `json:"current"`
+	}
+
+	// throttledReader limits the speed at which data is read from the
+	// underlying reader.
+	throttledReader struct {
+		staticReader   io.Reader
+		staticMaxSpeed int64
+		staticStart    time.Time
+		staticCancel   <-chan struct{}
+		staticStop     <-chan struct{}
+		read           int64
+	}
+)
+
+// newDirConverter creates a new dirConverter and loads the persisted
+// conversion from persistDir if there is one.
+func newDirConverter(persistDir string) (*dirConverter, error) {
+	dc := &dirConverter{
+		staticPersistPath: filepath.Join(persistDir, dirConversionFile),
+	}
+	var p dirConversionPersist
+	err := persist.LoadJSON(dirConversionMetadata, &p, dc.staticPersistPath)
+	if os.IsNotExist(err) {
+		return dc, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load directory conversion")
+	}
+	dc.conversion = &p.Conversion
+	dc.current = p.Current
+	return dc, nil
+}
+
+// Read implements io.Reader. It blocks until reading the data doesn't exceed
+// the max speed anymore.
+func (tr *throttledReader) Read(b []byte) (int, error) {
+	n, err := tr.staticReader.Read(b)
+	tr.read += int64(n)
+	if tr.staticMaxSpeed <= 0 {
+		return n, err
+	}
+	expected := time.Duration(float64(tr.read) / float64(tr.staticMaxSpeed) * float64(time.Second))
+	if wait := expected - time.Since(tr.staticStart); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-tr.staticCancel:
+			return n, errDirConversionCancelled
+		case <-tr.staticStop:
+			return n, errDirConversionCancelled
+		}
+	}
+	return n, err
+}
+
+// save persists the dirConverter.
+func (dc *dirConverter) save() error {
+	p := dirConversionPersist{
+		Current: dc.current,
+	}
+	if dc.conversion != nil {
+		p.Conversion = *dc.conversion
+	}
+	return persist.SaveJSON(dirConversionMetadata, p, dc.staticPersistPath)
+}
+
+// managedActive returns whether the persisted conversion needs to be
+// continued.
+func (dc *dirConverter) managedActive() bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.conversion != nil && !dc.conversion.Completed && !dc.conversion.Cancelled
+}
+
+// ConvertDir starts converting all files within a directory to the provided
+// erasure coding settings.
+func (r *Renter) ConvertDir(siaPath modules.SiaPath, dataPieces, parityPieces int, maxSpeed int64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Check the settings.
+	if _, err := modules.NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize); err != nil {
+		return errors.AddContext(err, "invalid erasure coding settings")
+	}
+	if maxSpeed < 0 {
+		return errors.New("max speed can't be negative")
+	}
+	if _, err := r.staticFileSystem.DirInfo(siaPath); err != nil {
+		return errors.AddContext(err, "unable to find directory")
+	}
+
+	dc := r.staticDirConverter
+	dc.mu.Lock()
+	if dc.conversion != nil && !dc.conversion.Completed && !dc.conversion.Cancelled {
+		dc.mu.Unlock()
+		return errDirConversionInProgress
+	}
+	dc.conversion = &modules.DirConversion{
+		SiaPath:      siaPath,
+		DataPieces:   dataPieces,
+		ParityPieces: parityPieces,
+		MaxSpeed:     maxSpeed,
+		StartTime:    time.Now(),
+	}
+	dc.current = modules.SiaPath{}
+	err := dc.save()
+	dc.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "unable to persist directory conversion")
+	}
+	return r.tg.Launch(r.threadedConvertDir)
+}
+
+// DirConversion returns the progress of the current or last directory
+// conversion.
+func (r *Renter) DirConversion() (modules.DirConversion, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DirConversion{}, err
+	}
+	defer r.tg.Done()
+	dc := r.staticDirConverter
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.conversion == nil {
+		return modules.DirConversion{}, errNoDirConversion
+	}
+	conversion := *dc.conversion
+	conversion.FailedFiles = append([]modules.SiaPath{}, dc.conversion.FailedFiles...)
+	return conversion, nil
+}
+
+// CancelDirConversion cancels the current directory conversion. The file
+// which is being converted when the conversion is cancelled keeps its
+// original erasure coding settings.
+func (r *Renter) CancelDirConversion() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	dc := r.staticDirConverter
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.conversion == nil || dc.conversion.Completed || dc.conversion.Cancelled {
+		return errNoDirConversion
+	}
+	dc.conversion.Cancelled = true
+	dc.conversion.EndTime = time.Now()
+	if dc.cancel != nil {
+		close(dc.cancel)
+		dc.cancel = nil
+	}
+	return dc.save()
+}
+
+// threadedConvertDir converts the files of the current directory conversion
+// until all files are converted or the conversion is cancelled.
+func (r *Renter) threadedConvertDir() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	// Make sure only one thread works on the conversion.
+	dc := r.staticDirConverter
+	dc.mu.Lock()
+	if dc.running || dc.conversion == nil || dc.conversion.Completed || dc.conversion.Cancelled {
+		dc.mu.Unlock()
+		return
+	}
+	dc.running = true
+	cancel := make(chan struct{})
+	dc.cancel = cancel
+	conversion := *dc.conversion
+	current := dc.current
+	dc.mu.Unlock()
+	defer func() {
+		dc.mu.Lock()
+		dc.running = false
+		dc.cancel = nil
+		dc.mu.Unlock()
+	}()
+
+	// Finish the replacement of a file which was interrupted by a shutdown.
+	if !current.IsEmpty() {
+		if err := r.managedFinishFileConversion(current); err != nil {
+			r.log.Printf("WARN: failed to finish the conversion of %v: %v", current, err)
+		}
+	}
+
+	// Get the files which still need to be processed.
+	siaPaths, err := r.managedDirConversionFiles(conversion.SiaPath, conversion.Cursor)
+	if err != nil {
+		r.log.Printf("WARN: failed to list the files of directory conversion of %v: %v", conversion.SiaPath, err)
+		dc.mu.Lock()
+		dc.conversion.LastError = err.Error()
+		dc.conversion.Cancelled = true
+		dc.conversion.EndTime = time.Now()
+		err = dc.save()
+		dc.mu.Unlock()
+		if err != nil {
+			r.log.Println("WARN: failed to persist directory conversion:", err)
+		}
+		return
+	}
+
+	for _, siaPath := range siaPaths {
+		// Persist the file we are about to convert in case we are interrupted
+		// while replacing the file.
+		dc.mu.Lock()
+		dc.current = siaPath
+		err = dc.save()
+		dc.mu.Unlock()
+		if err != nil {
+			r.log.Println("WARN: failed to persist directory conversion:", err)
+			return
+		}
+
+		before, spendingErr := r.PeriodSpending()
+		converted, size, err := r.managedConvertFile(siaPath, conversion.DataPieces, conversion.ParityPieces, conversion.MaxSpeed, cancel)
+		after, spendingErr2 := r.PeriodSpending()
+		if errors.Contains(err, errDirConversionCancelled) {
+			return
+		}
+		select {
+		case <-r.tg.StopChan():
+			// The conversion continues with the same file after a restart.
+			return
+		default:
+		}
+
+		dc.mu.Lock()
+		if spendingErr == nil && spendingErr2 == nil {
+			dc.conversion.Cost = dc.conversion.Cost.Add(spendingDelta(before, after))
+		}
+		switch {
+		case err != nil:
+			r.log.Printf("WARN: failed to convert %v: %v", siaPath, err)
+			dc.conversion.FilesFailed++
+			dc.conversion.FailedFiles = append(dc.conversion.FailedFiles, siaPath)
+			dc.conversion.LastError = fmt.Sprintf("%v: %v", siaPath, err)
+		case converted:
+			dc.conversion.FilesConverted++
+			dc.conversion.BytesConverted += size
+		default:
+			dc.conversion.FilesSkipped++
+		}
+		dc.conversion.Cursor = siaPath
+		dc.current = modules.SiaPath{}
+		cancelled := dc.conversion.Cancelled
+		err = dc.save()
+		dc.mu.Unlock()
+		if err != nil {
+			r.log.Println("WARN: failed to persist directory conversion:", err)
+		}
+		if cancelled {
+			return
+		}
+	}
+
+	// The conversion is done.
+	dc.mu.Lock()
+	dc.conversion.Completed = true
+	dc.conversion.EndTime = time.Now()
+	err = dc.save()
+	dc.mu.Unlock()
+	if err != nil {
+		r.log.Println("WARN: failed to persist directory conversion:", err)
+	}
+}
+
+// managedDirConversionFiles returns the files within the directory which come
+// after the cursor in lexicographical order.
+func (r *Renter) managedDirConversionFiles(dir, cursor modules.SiaPath) ([]modules.SiaPath, error) {
+	var mu sync.Mutex
+	var siaPaths []modules.SiaPath
+	err := r.FileList(dir, true, true, func(fi modules.FileInfo) {
+		if fi.SiaPath.String() <= cursor.String() && !cursor.IsEmpty() {
+			return
+		}
+		if dirConversionFolder.Equals(fi.SiaPath) || isDirConversionFile(fi.SiaPath) {
+			return
+		}
+		mu.Lock()
+		siaPaths = append(siaPaths, fi.SiaPath)
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(siaPaths, func(i, j int) bool {
+		return siaPaths[i].String() < siaPaths[j].String()
+	})
+	return siaPaths, nil
+}
+
+// managedConvertFile uploads the file at siaPath again using the provided
+// erasure coding settings and replaces the original file with the new one.
+// Files which already use the provided settings are skipped.
+func (r *Renter) managedConvertFile(siaPath modules.SiaPath, dataPieces, parityPieces int, maxSpeed int64, cancel <-chan struct{}) (converted bool, size uint64, err error) {
+	ec, err := modules.NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
+	if err != nil {
+		return false, 0, err
+	}
+
+	// Check whether the file needs to be converted.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return false, 0, errors.AddContext(err, "unable to open file")
+	}
+	oldEC := entry.ErasureCode()
+	size = entry.Size()
+	localPath := entry.LocalPath()
+	mode := entry.Mode()
+	cipherType := entry.MasterKey().Type()
+	err = entry.Close()
+	if err != nil {
+		return false, 0, err
+	}
+	if oldEC.MinPieces() == dataPieces && oldEC.NumPieces() == dataPieces+parityPieces {
+		return false, size, nil
+	}
+
+	// Upload the file to a temporary location. Uploads which were
+	// interrupted are overwritten.
+	tmpPath, err := dirConversionTempPath(siaPath)
+	if err != nil {
+		return false, 0, err
+	}
+	_, streamer, err := r.Streamer(siaPath, false)
+	if err != nil {
+		return false, 0, errors.AddContext(err, "unable to stream file")
+	}
+	defer func() {
+		err = errors.Compose(err, streamer.Close())
+	}()
+	reader := &throttledReader{
+		staticReader:   streamer,
+		staticMaxSpeed: maxSpeed,
+		staticStart:    time.Now(),
+		staticCancel:   cancel,
+		staticStop:     r.tg.StopChan(),
+	}
+	up := modules.FileUploadParams{
+		Source:              localPath,
+		SiaPath:             tmpPath,
+		ErasureCode:         ec,
+		Force:               true,
+		DisablePartialChunk: true,
+		CipherType:          cipherType,
+	}
+	node, err := r.callUploadStreamFromReader(up, reader)
+	if err != nil {
+		if deleteErr := r.DeleteFile(tmpPath); deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			r.log.Printf("WARN: failed to delete temporary file %v: %v", tmpPath, deleteErr)
+		}
+		return false, 0, errors.AddContext(err, "unable to upload file")
+	}
+	err = errors.Compose(node.SetMode(mode), node.Close())
+	if err != nil {
+		return false, 0, err
+	}
+
+	// Replace the original file.
+	if err := r.DeleteFile(siaPath); err != nil {
+		return false, 0, errors.AddContext(err, "unable to delete original file")
+	}
+	if err := r.RenameFile(tmpPath, siaPath); err != nil {
+		return false, 0, errors.AddContext(err, "unable to replace original file")
+	}
+	return true, size, nil
+}
+
+// managedFinishFileConversion finishes the conversion of a file after an
+// interruption. If the original file was already deleted, the uploaded file
+// replaces it. Otherwise the partially uploaded file is deleted.
+func (r *Renter) managedFinishFileConversion(siaPath modules.SiaPath) error {
+	tmpPath, err := dirConversionTempPath(siaPath)
+	if err != nil {
+		return err
+	}
+	tmpExists, _ := r.staticFileSystem.FileExists(tmpPath)
+	if !tmpExists {
+		return nil
+	}
+	exists, _ := r.staticFileSystem.FileExists(siaPath)
+	if exists {
+		return r.DeleteFile(tmpPath)
+	}
+	return r.RenameFile(tmpPath, siaPath)
+}
+
+// dirConversionTempPath returns the path a file is uploaded to while being
+// converted.
+func dirConversionTempPath(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	return dirConversionFolder.Join(crypto.HashObject(siaPath.String()).String())
+}
+
+// isDirConversionFile returns whether the file is a temporary file of a
+// directory conversion.
+func isDirConversionFile(siaPath modules.SiaPath) bool {
+	dir, err := siaPath.Dir()
+	return err == nil && dir.Equals(dirConversionFolder)
+}
+
+// spendingDelta returns the amount of money spent between two snapshots of
+// the renter's spending. Spending which was reset by the start of a new
+// period is ignored.
+func spendingDelta(before, after modules.ContractorSpending) types.Currency {
+	total := func(s modules.ContractorSpending) types.Currency {
+		return s.ContractFees.Add(s.DownloadSpending).Add(s.FundAccountSpending).Add(s.MaintenanceSpending.Sum()).Add(s.StorageSpending).Add(s.UploadSpending)
+	}
+	b, a := total(before), total(after)
+	if a.Cmp(b) <= 0 {
+		return types.ZeroCurrency
+	}
+	return a.Sub(b)
+}
@@ -0,0 +1,124 @@
+package renter
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestThrottledReader checks that the throttledReader limits the read speed
+// and can be interrupted.
+func TestThrottledReader(t *testing.T) {
+	t.Parallel()
+
+	// Reading 1000 bytes at 10kB/s should take at least 100ms.
+	data := fastrand.Bytes(1000)
+	tr := &throttledReader{
+		staticReader:   bytes.NewReader(data),
+		staticMaxSpeed: 10000,
+		staticStart:    time.Now(),
+	}
+	start := time.Now()
+	read, err := ioutil.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatal("read data doesn't match")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatal("reader wasn't throttled", elapsed)
+	}
+
+	// Closing the cancel channel interrupts the reader.
+	cancel := make(chan struct{})
+	close(cancel)
+	tr = &throttledReader{
+		staticReader:   bytes.NewReader(data),
+		staticMaxSpeed: 1,
+		staticStart:    time.Now(),
+		staticCancel:   cancel,
+	}
+	_, err = io.Copy(ioutil.Discard, tr)
+	if !errors.Contains(err, errDirConversionCancelled) {
+		t.Fatal("expected errDirConversionCancelled, got", err)
+	}
+}
+
+// TestSpendingDelta is a unit test for spendingDelta.
+func TestSpendingDelta(t *testing.T) {
+	before := modules.ContractorSpending{
+		UploadSpending: types.NewCurrency64(10),
+	}
+	after := modules.ContractorSpending{
+		UploadSpending:   types.NewCurrency64(15),
+		DownloadSpending: types.NewCurrency64(5),
+	}
+	if delta := spendingDelta(before, after); !delta.Equals64(10) {
+		t.Fatal("expected delta of 10, got", delta)
+	}
+	// A reset of the spending isn't counted.
+	if delta := spendingDelta(after, before); !delta.IsZero() {
+		t.Fatal("expected delta of 0, got", delta)
+	}
+}
+
+// TestDirConverterPersist checks that the dirConverter loads the persisted
+// conversion.
+func TestDirConverterPersist(t *testing.T) {
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new dirConverter has no conversion.
+	dc, err := newDirConverter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dc.conversion != nil || dc.managedActive() {
+		t.Fatal("new dirConverter shouldn't have a conversion")
+	}
+
+	// Persist a conversion and load it again.
+	cursor := modules.RandomSiaPath()
+	current := modules.RandomSiaPath()
+	dc.conversion = &modules.DirConversion{
+		SiaPath:        modules.RandomSiaPath(),
+		DataPieces:     2,
+		ParityPieces:   3,
+		Cursor:         cursor,
+		FilesConverted: 4,
+		Cost:           types.SiacoinPrecision,
+	}
+	dc.current = current
+	if err := dc.save(); err != nil {
+		t.Fatal(err)
+	}
+	dc2, err := newDirConverter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dc2.managedActive() {
+		t.Fatal("loaded conversion should be active")
+	}
+	if !dc2.conversion.Cursor.Equals(cursor) || !dc2.current.Equals(current) {
+		t.Fatal("cursor wasn't persisted")
+	}
+	if dc2.conversion.FilesConverted != 4 || !dc2.conversion.Cost.Equals(types.SiacoinPrecision) {
+		t.Fatal("progress wasn't persisted", dc2.conversion)
+	}
+}
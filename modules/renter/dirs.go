@@ -29,13 +29,37 @@ func (r *Renter) DeleteDir(siaPath modules.SiaPath) error {
 	return r.staticFileSystem.DeleteDir(siaPath)
 }
 
-// DirList lists the directories in a siadir
+// DirList lists the directories in a siadir. Aliases on the way to the siadir
+// are followed and aliases to directories within the siadir are listed like
+// regular directories.
 func (r *Renter) DirList(siaPath modules.SiaPath) (dis []modules.DirectoryInfo, _ error) {
 	if err := r.tg.Add(); err != nil {
 		return nil, err
 	}
 	defer r.tg.Done()
-	return r.managedDirList(siaPath)
+	resolved, err := r.staticFileSystem.ResolveSiaPath(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	_, dlf := rebaseListing(siaPath, resolved, func(modules.FileInfo) {}, func(di modules.DirectoryInfo) {
+		dis = append(dis, di)
+	})
+	dirs, err := r.managedDirList(resolved)
+	if err != nil {
+		return nil, err
+	}
+	for _, di := range dirs {
+		dlf(di)
+	}
+	aliasDirs, err := r.managedAliasDirInfos(siaPath, resolved)
+	if err != nil {
+		return nil, err
+	}
+	dis = append(dis, aliasDirs...)
+	sort.Slice(dis, func(i, j int) bool {
+		return dis[i].SiaPath.String() < dis[j].SiaPath.String()
+	})
+	return dis, nil
 }
 
 // managedDirList lists the directories in a siadir
@@ -51,6 +51,14 @@ type (
 		staticSiaPath         modules.SiaPath    // The path of the siafile at the time the download started.
 		staticUID             modules.DownloadID // unique identifier for the download
 
+		// staticManifest hashes the recovered data if the download requested
+		// a manifest. Otherwise it is nil.
+		staticManifest *downloadDestinationManifest
+
+		// staticChecksum verifies the recovered data against the checksum of
+		// the file if the download requested it. Otherwise it is nil.
+		staticChecksum *downloadDestinationChecksum
+
 		staticParams downloadParams
 
 		// Retrieval settings for the file.
@@ -65,20 +73,29 @@ type (
 
 	// downloadParams is the set of parameters to use when downloading a file.
 	downloadParams struct {
-		destination       downloadDestination // The place to write the downloaded data.
-		destinationType   string              // "file", "buffer", "http stream", etc.
-		destinationString string              // The string to report to the user for the destination.
-		disableLocalFetch bool                // Whether or not the file can be fetched from disk if available.
-		file              *siafile.Snapshot   // The file to download.
-		latencyTarget     time.Duration       // Workers above this latency will be automatically put on standby initially.
-		length            uint64              // Length of download. Cannot be 0.
-		needsMemory       bool                // Whether new memory needs to be allocated to perform the download.
-		offset            uint64              // Offset within the file to start the download. Must be less than the total filesize.
-		overdrive         int                 // How many extra pieces to download to prevent slow hosts from being a bottleneck.
-		priority          uint64              // Files with a higher priority will be downloaded first.
+		destination       downloadDestination       // The place to write the downloaded data.
+		destinationType   string                    // "file", "buffer", "http stream", etc.
+		destinationString string                    // The string to report to the user for the destination.
+		disableLocalFetch bool                      // Whether or not the file can be fetched from disk if available.
+		file              *siafile.Snapshot         // The file to download.
+		manifest          bool                      // Whether to hash the recovered data for a manifest.
+		checksumAlgorithm modules.ChecksumAlgorithm // The algorithm used to compute the checksum.
+		checksum          []byte                    // The checksum to verify the recovered data against, if any.
+		latencyTarget     time.Duration             // Workers above this latency will be automatically put on standby initially.
+		length            uint64                    // Length of download. Cannot be 0.
+		needsMemory       bool                      // Whether new memory needs to be allocated to perform the download.
+		offset            uint64                    // Offset within the file to start the download. Must be less than the total filesize.
+		overdrive         int                       // How many extra pieces to download to prevent slow hosts from being a bottleneck.
+		priority          uint64                    // Files with a higher priority will be downloaded first.
+		operationClass    operationClass            // The class whose chunk download timeout applies to the download.
 
 		staticMemoryManager *memoryManager
 
+		// staticStreamHostStats are the host stats of the stream the download
+		// belongs to. If set, the chunks of the download are biased towards the
+		// hosts which served the previous chunks of the stream the fastest.
+		staticStreamHostStats *streamHostStats
+
 		// staticSpendingCategory specifies what field to update when we track
 		// the amount of money spent from an ephemeral account
 		staticSpendingCategory spendingCategory
@@ -140,6 +157,18 @@ func (d *download) markComplete() {
 	d.downloadCompleteFuncs = nil
 }
 
+// verifyChecksum fails the download if the recovered data doesn't match the
+// checksum of the file. It must be called while holding the lock before
+// marking a successful download as complete.
+func (d *download) verifyChecksum() {
+	if d.staticChecksum == nil {
+		return
+	}
+	if err := d.staticChecksum.managedVerify(); err != nil {
+		d.err = err
+	}
+}
+
 // onComplete registers a function to be called when the download is completed.
 // This can either mean that the download succeeded or failed. The registered
 // functions are executed in the same order as they are registered and waiting
@@ -246,7 +275,11 @@ func (r *Renter) DownloadAsync(p modules.RenterDownloadParameters, f func(error)
 // setup was successful.
 func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *download, err error) {
 	// Lookup the file associated with the nickname.
-	entry, err := r.staticFileSystem.OpenSiaFile(p.SiaPath)
+	siaPath, err := r.staticFileSystem.ResolveSiaPath(p.SiaPath)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
 	if err != nil {
 		return nil, err
 	}
@@ -283,6 +316,18 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if p.Offset < 0 || p.Offset+p.Length > entry.Size() {
 		return nil, fmt.Errorf("offset and length combination invalid, max byte is at index %d", entry.Size()-1)
 	}
+	// Checksums can only be verified for downloads of whole files.
+	var checksumAlgorithm modules.ChecksumAlgorithm
+	var checksum []byte
+	if p.VerifyChecksum {
+		checksumAlgorithm, checksum = entry.Checksum()
+		if checksumAlgorithm == "" {
+			return nil, errNoChecksum
+		}
+		if p.Offset != 0 || p.Length != entry.Size() {
+			return nil, errChecksumPartialDownload
+		}
+	}
 
 	// Instantiate the correct downloadWriter implementation.
 	var dw downloadDestination
@@ -317,6 +362,12 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if err != nil {
 		return nil, err
 	}
+	// Downloads within the dir of the restore mode are prioritized.
+	priority := uint64(5) // TODO: moderate default until full priority support is added.
+	if r.restoreMode.managedIsBoosted(p.SiaPath) {
+		priority = restoreModeDownloadPriority
+	}
+
 	// Create the download object.
 	d, err := r.managedNewDownload(downloadParams{
 		destination:       dw,
@@ -324,13 +375,16 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		destinationString: p.Destination,
 		disableLocalFetch: p.DisableDiskFetch,
 		file:              snap,
+		manifest:          p.Manifest,
+		checksumAlgorithm: checksumAlgorithm,
+		checksum:          checksum,
 
 		latencyTarget: 25e3 * time.Millisecond, // TODO: high default until full latency support is added.
 		length:        p.Length,
 		needsMemory:   true,
 		offset:        p.Offset,
 		overdrive:     3, // TODO: moderate default until full overdrive support is added.
-		priority:      5, // TODO: moderate default until full priority support is added.
+		priority:      priority,
 
 		staticMemoryManager:    r.userDownloadMemoryManager, // user initiated download
 		staticSpendingCategory: categoryDownload,
@@ -342,6 +396,16 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		return nil, err
 	}
 
+	// Store the manifest next to the downloaded file.
+	if p.Manifest && destinationType == "file" {
+		d.OnComplete(func(err error) error {
+			if err != nil {
+				return nil
+			}
+			return errors.AddContext(d.staticManifest.managedSave(p.Destination+downloadManifestExtension), "failed to save download manifest")
+		})
+	}
+
 	// Register some cleanup for when the download is done.
 	d.OnComplete(func(_ error) error {
 		// close the destination if possible.
@@ -382,6 +446,27 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 	if params.offset+params.length > params.file.Size() {
 		return nil, errors.New("download is requesting data past the boundary of the file")
 	}
+	if err := r.staticBandwidthMeter.Check(params.staticSpendingCategory.bandwidthCategory()); err != nil {
+		return nil, err
+	}
+
+	// Hash the recovered data if a manifest was requested.
+	var manifest *downloadDestinationManifest
+	if params.manifest {
+		manifest = newDownloadDestinationManifest(params.destination, params.file.ChunkSize(), params.offset)
+		params.destination = manifest
+	}
+
+	// Verify the recovered data if a checksum was provided.
+	var checksum *downloadDestinationChecksum
+	if params.checksum != nil {
+		var err error
+		checksum, err = newDownloadDestinationChecksum(params.destination, params.checksumAlgorithm, params.checksum)
+		if err != nil {
+			return nil, err
+		}
+		params.destination = checksum
+	}
 
 	// Create the download object.
 	d := &download{
@@ -393,6 +478,8 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		destinationString:     params.destinationString,
 		staticDestinationType: params.destinationType,
 		staticUID:             modules.DownloadID(hex.EncodeToString(fastrand.Bytes(16))),
+		staticManifest:        manifest,
+		staticChecksum:        checksum,
 		staticLatencyTarget:   params.latencyTarget,
 		staticLength:          params.length,
 		staticOffset:          params.offset,
@@ -422,6 +509,7 @@ func (d *download) Start() error {
 	// Nothing more to do for 0-byte files or 0-length downloads.
 	if d.staticLength == 0 {
 		d.mu.Lock()
+		d.verifyChecksum()
 		d.markComplete()
 		d.mu.Unlock()
 		return nil
@@ -471,6 +559,7 @@ func (d *download) Start() error {
 	// Queue the downloads for each chunk.
 	writeOffset := int64(0) // where to write a chunk within the download destination.
 	d.chunksRemaining += maxChunk - minChunk + 1
+	chunkTimeout := d.r.staticTimeouts.callChunkDownload(params.operationClass)
 	for i := minChunk; i <= maxChunk; i++ {
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
@@ -484,6 +573,7 @@ func (d *download) Start() error {
 			staticPieceSize:  params.file.PieceSize(),
 
 			staticSpendingCategory: d.staticParams.staticSpendingCategory,
+			staticStreamHostStats:  params.staticStreamHostStats,
 
 			// TODO: 25ms is just a guess for a good default. Really, we want to
 			// set the latency target such that slower workers will pick up the
@@ -498,6 +588,7 @@ func (d *download) Start() error {
 			staticLatencyTarget:    d.staticLatencyTarget + (25 * time.Duration(i-minChunk)), // Increase target by 25ms per chunk.
 			staticNeedsMemory:      params.needsMemory,
 			staticPriority:         params.priority,
+			staticTimeout:          chunkTimeout,
 
 			completedPieces:   make([]bool, params.file.ErasureCode().NumPieces()),
 			physicalChunkData: make([][]byte, params.file.ErasureCode().NumPieces()),
@@ -532,6 +623,12 @@ func (d *download) Start() error {
 		// and once we can assign overdrive dynamically.
 		udc.staticOverdrive = params.overdrive
 
+		// If the chunk is downloaded for a stream, prefer the hosts which
+		// served the previous chunks of the stream the fastest.
+		if udc.staticStreamHostStats != nil {
+			udc.staticPreferredHosts = udc.staticStreamHostStats.callPreferredHosts(udc.staticChunkMap, udc.erasureCode.MinPieces()+udc.staticOverdrive)
+		}
+
 		// Add this chunk to the chunk heap, and notify the download loop that
 		// there is work to do.
 		d.r.managedAddChunkToDownloadHeap(udc)
@@ -553,7 +650,7 @@ func (r *Renter) DownloadByUID(uid modules.DownloadID) (modules.DownloadInfo, bo
 	}
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	return modules.DownloadInfo{
+	di := modules.DownloadInfo{
 		Destination:     d.destinationString,
 		DestinationType: d.staticDestinationType,
 		Length:          d.staticLength,
@@ -566,7 +663,12 @@ func (r *Renter) DownloadByUID(uid modules.DownloadID) (modules.DownloadInfo, bo
 		StartTime:            d.staticStartTime,
 		StartTimeUnix:        d.staticStartTime.UnixNano(),
 		TotalDataTransferred: atomic.LoadUint64(&d.atomicTotalDataTransferred),
-	}, true
+	}
+	if d.staticManifest != nil && d.staticComplete() && d.err == nil {
+		manifest := d.staticManifest.managedManifest()
+		di.Manifest = &manifest
+	}
+	return di, true
 }
 
 // DownloadHistory returns the list of downloads that have been performed. Will
@@ -611,6 +713,10 @@ func (r *Renter) DownloadHistory() []modules.DownloadInfo {
 			StartTimeUnix:        d.staticStartTime.UnixNano(),
 			TotalDataTransferred: atomic.LoadUint64(&d.atomicTotalDataTransferred),
 		}
+		if d.staticManifest != nil && d.staticComplete() && d.err == nil {
+			manifest := d.staticManifest.managedManifest()
+			downloads[i].Manifest = &manifest
+		}
 		// Release download lock before calling d.Err(), which will acquire the
 		// lock. The error needs to be checked separately because we need to
 		// know if it's 'nil' before grabbing the error string.
@@ -0,0 +1,105 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errChecksumMismatch is returned if the downloaded data doesn't match
+	// the checksum of the file.
+	errChecksumMismatch = errors.New("downloaded data doesn't match the file's checksum")
+
+	// errChecksumPartialDownload is returned if checksum verification is
+	// requested for a download which doesn't cover the whole file.
+	errChecksumPartialDownload = errors.New("checksums can only be verified for downloads of the whole file")
+
+	// errNoChecksum is returned if checksum verification is requested for a
+	// file without a checksum.
+	errNoChecksum = errors.New("file has no checksum")
+)
+
+// downloadDestinationChecksum is a downloadDestination which hashes the data
+// recovered from every chunk before passing the pieces on to the wrapped
+// destination. Chunks can be recovered out of order, so the data of chunks
+// which can't be hashed yet is kept until the chunks before them were hashed.
+type downloadDestinationChecksum struct {
+	staticDestination downloadDestination
+	staticAlgorithm   modules.ChecksumAlgorithm
+	staticExpected    []byte
+
+	// h contains the data up to nextOffset. pending contains the data of the
+	// chunks after nextOffset by their write offsets.
+	h          hash.Hash
+	nextOffset int64
+	pending    map[int64][]byte
+	mu         sync.Mutex
+}
+
+// newDownloadDestinationChecksum wraps the destination of a download of a
+// whole file which is verified against the provided checksum.
+func newDownloadDestinationChecksum(dst downloadDestination, algorithm modules.ChecksumAlgorithm, expected []byte) (*downloadDestinationChecksum, error) {
+	h, err := algorithm.NewHash()
+	if err != nil {
+		return nil, err
+	}
+	return &downloadDestinationChecksum{
+		staticDestination: dst,
+		staticAlgorithm:   algorithm,
+		staticExpected:    expected,
+		h:                 h,
+		pending:           make(map[int64][]byte),
+	}, nil
+}
+
+// Close closes the wrapped destination if possible.
+func (ddc *downloadDestinationChecksum) Close() error {
+	if closer, ok := ddc.staticDestination.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// WritePieces hashes the recovered data and writes the pieces to the wrapped
+// destination.
+func (ddc *downloadDestinationChecksum) WritePieces(ec modules.ErasureCoder, pieces [][]byte, dataOffset uint64, writeOffset int64, length uint64) error {
+	buf := bytes.NewBuffer(make([]byte, 0, length))
+	err := ec.Recover(pieces, dataOffset+length, &skipWriter{writer: buf, skip: int(dataOffset)})
+	if err != nil {
+		return errors.AddContext(err, "unable to recover data for the checksum")
+	}
+	if err := ddc.staticDestination.WritePieces(ec, pieces, dataOffset, writeOffset, length); err != nil {
+		return err
+	}
+
+	ddc.mu.Lock()
+	defer ddc.mu.Unlock()
+	ddc.pending[writeOffset] = buf.Bytes()
+	for data, ok := ddc.pending[ddc.nextOffset]; ok; data, ok = ddc.pending[ddc.nextOffset] {
+		delete(ddc.pending, ddc.nextOffset)
+		_, _ = ddc.h.Write(data)
+		ddc.nextOffset += int64(len(data))
+	}
+	return nil
+}
+
+// managedVerify checks that the data written so far matches the expected
+// checksum.
+func (ddc *downloadDestinationChecksum) managedVerify() error {
+	ddc.mu.Lock()
+	defer ddc.mu.Unlock()
+	if len(ddc.pending) > 0 {
+		return errors.Compose(errChecksumMismatch, errors.New("not all downloaded data was hashed"))
+	}
+	if sum := ddc.h.Sum(nil); !bytes.Equal(sum, ddc.staticExpected) {
+		return errors.Compose(errChecksumMismatch, fmt.Errorf("%v checksum is %x, expected %x", ddc.staticAlgorithm, sum, ddc.staticExpected))
+	}
+	return nil
+}
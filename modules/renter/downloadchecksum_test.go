@@ -0,0 +1,74 @@
+package renter
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestDownloadDestinationChecksum checks that the recovered data is verified
+// against the checksum even if the chunks are written out of order.
+func TestDownloadDestinationChecksum(t *testing.T) {
+	rsc, err := modules.NewRSCode(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkSize := uint64(128)
+	data := fastrand.Bytes(2*int(chunkSize) + 10)
+	checksum := sha256.Sum256(data)
+
+	// writeChunks writes the chunks of data in reverse order.
+	writeChunks := func(ddc *downloadDestinationChecksum) {
+		for i := 2; i >= 0; i-- {
+			start := uint64(i) * chunkSize
+			end := start + chunkSize
+			if end > uint64(len(data)) {
+				end = uint64(len(data))
+			}
+			chunk := make([]byte, chunkSize)
+			copy(chunk, data[start:end])
+			pieces, err := rsc.Encode(chunk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ddc.WritePieces(rsc, pieces, 0, int64(start), end-start); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The data matches the checksum.
+	dst := NewDownloadDestinationBuffer()
+	ddc, err := newDownloadDestinationChecksum(dst, modules.ChecksumSHA256, checksum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeChunks(ddc)
+	if len(dst.pieces) != rsc.NumPieces() {
+		t.Fatal("pieces weren't written to the wrapped destination")
+	}
+	if err := ddc.managedVerify(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The data doesn't match a different checksum.
+	checksum[0]++
+	ddc, err = newDownloadDestinationChecksum(NewDownloadDestinationBuffer(), modules.ChecksumSHA256, checksum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeChunks(ddc)
+	if err := ddc.managedVerify(); !errors.Contains(err, errChecksumMismatch) {
+		t.Fatal("expected errChecksumMismatch, got", err)
+	}
+
+	// Unknown algorithms are rejected.
+	_, err = newDownloadDestinationChecksum(dst, "md4", checksum[:])
+	if !errors.Contains(err, modules.ErrUnknownChecksumAlgorithm) {
+		t.Fatal("expected ErrUnknownChecksumAlgorithm, got", err)
+	}
+}
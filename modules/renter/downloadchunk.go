@@ -53,6 +53,14 @@ type unfinishedDownloadChunk struct {
 	// Spending details.
 	staticSpendingCategory spendingCategory
 
+	// staticStreamHostStats are the host stats of the stream the chunk is
+	// downloaded for, nil if the chunk isn't downloaded for a stream.
+	// staticPreferredHosts are the hosts which served the previous chunks of
+	// the stream the fastest. As long as it's set, the workers of other hosts
+	// are put on standby.
+	staticStreamHostStats *streamHostStats
+	staticPreferredHosts  map[string]struct{}
+
 	// Fetch + Write instructions - read only or otherwise thread safe.
 	staticDisableDiskFetch bool
 	staticLatencyTarget    time.Duration
@@ -60,6 +68,7 @@ type unfinishedDownloadChunk struct {
 	staticMemoryManager    *memoryManager
 	staticOverdrive        int
 	staticPriority         uint64
+	staticTimeout          time.Duration // Maximum time the chunk may take once it was distributed to the workers.
 
 	// Download chunk state - need mutex to access.
 	completedPieces   []bool    // Which pieces were downloaded successfully.
@@ -71,6 +80,10 @@ type unfinishedDownloadChunk struct {
 	recoveryComplete  bool      // Whether or not the recovery has completed and the chunk memory released.
 	workersRemaining  int       // Number of workers still able to fetch the chunk.
 	workersStandby    []*worker // Set of workers that are able to work on this download, but are not needed unless other workers fail.
+	standbyReleased   bool      // Set once the standby workers were needed, after which workers are no longer put on standby for not being preferred.
+
+	// timeoutTimer fails the chunk once its timeout is reached.
+	timeoutTimer *time.Timer
 
 	// Memory management variables.
 	memoryAllocated uint64
@@ -87,6 +100,9 @@ type unfinishedDownloadChunk struct {
 // wiped and any memory allocation will be returned to the renter. The download
 // as a whole will be failed as well.
 func (udc *unfinishedDownloadChunk) fail(err error) {
+	if udc.timeoutTimer != nil {
+		udc.timeoutTimer.Stop()
+	}
 	udc.failed = true
 	udc.recoveryComplete = true
 	for i := range udc.physicalChunkData {
@@ -134,6 +150,7 @@ func (udc *unfinishedDownloadChunk) managedCleanUp() {
 		standbyWorkers = append(standbyWorkers, udc.workersStandby[i])
 	}
 	udc.workersStandby = udc.workersStandby[:0] // Workers have been taken off of standby.
+	udc.standbyReleased = true
 	udc.mu.Unlock()
 	for i := 0; i < len(standbyWorkers); i++ {
 		go standbyWorkers[i].threadedPerformDownloadChunkJob(udc)
@@ -146,6 +163,9 @@ func (udc *unfinishedDownloadChunk) managedFinalizeRecovery() {
 	// Directly nil out the physical chunk data, it's not going to be used
 	// anymore. Also signal that data recovery has completed.
 	udc.mu.Lock()
+	if udc.timeoutTimer != nil {
+		udc.timeoutTimer.Stop()
+	}
 	udc.physicalChunkData = nil
 	udc.recoveryComplete = true
 	udc.mu.Unlock()
@@ -156,10 +176,25 @@ func (udc *unfinishedDownloadChunk) managedFinalizeRecovery() {
 	udc.download.chunksRemaining--
 	if udc.download.chunksRemaining == 0 {
 		// Download is complete, send out a notification.
+		udc.download.verifyChecksum()
 		udc.download.markComplete()
 	}
 }
 
+// managedTimeout fails the chunk if not enough pieces were downloaded to
+// recover it before its timeout was reached.
+func (udc *unfinishedDownloadChunk) managedTimeout() {
+	udc.mu.Lock()
+	timedOut := !udc.failed && !udc.recoveryComplete && udc.piecesCompleted < udc.erasureCode.MinPieces()
+	if timedOut {
+		udc.fail(errChunkDownloadTimeout)
+	}
+	udc.mu.Unlock()
+	if timedOut {
+		udc.managedCleanUp()
+	}
+}
+
 // managedRemoveWorker will decrement a worker from the set of remaining workers
 // in the udc. After a worker has been removed, the udc needs to be cleaned up.
 func (udc *unfinishedDownloadChunk) managedRemoveWorker() {
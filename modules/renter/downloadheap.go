@@ -71,7 +71,8 @@ func (r *Renter) managedAcquireMemoryForDownloadChunk(udc *unfinishedDownloadChu
 	// go over the memory limits when we decode pieces.
 	memoryRequired := uint64(udc.staticOverdrive+udc.erasureCode.MinPieces()) * udc.staticPieceSize
 	udc.memoryAllocated = memoryRequired
-	return udc.staticMemoryManager.Request(context.Background(), memoryRequired, memoryPriorityHigh)
+	priority := r.restoreMode.managedDownloadMemoryPriority(udc.download.staticSiaPath)
+	return udc.staticMemoryManager.Request(context.Background(), memoryRequired, priority)
 }
 
 // managedAddChunkToDownloadHeap will add a chunk to the download heap in a
@@ -125,10 +126,14 @@ func (r *Renter) managedBlockUntilOnline() bool {
 // all of the workers.
 func (r *Renter) managedDistributeDownloadChunkToWorkers(udc *unfinishedDownloadChunk) {
 	// Distribute the chunk to workers, marking the number of workers
-	// that have received the work.
+	// that have received the work. The chunk is failed if it isn't recovered
+	// within its timeout.
 	r.staticWorkerPool.mu.RLock()
 	udc.mu.Lock()
 	udc.workersRemaining = len(r.staticWorkerPool.workers)
+	if udc.staticTimeout > 0 {
+		udc.timeoutTimer = time.AfterFunc(udc.staticTimeout, udc.managedTimeout)
+	}
 	udc.mu.Unlock()
 	for _, worker := range r.staticWorkerPool.workers {
 		go worker.threadedPerformDownloadChunkJob(udc)
@@ -0,0 +1,105 @@
+package renter
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// downloadManifestExtension is the extension of the manifest file stored next
+// to a file download.
+const downloadManifestExtension = ".manifest"
+
+// downloadDestinationManifest is a downloadDestination which hashes the data
+// recovered from every chunk before passing the pieces on to the wrapped
+// destination.
+type downloadDestinationManifest struct {
+	staticDestination downloadDestination
+	staticChunkSize   uint64
+	staticOffset      uint64
+
+	// chunks maps the write offsets of the chunks to their hashes.
+	chunks map[int64]modules.DownloadChunkHash
+	mu     sync.Mutex
+}
+
+// newDownloadDestinationManifest wraps the destination of a download starting
+// at the provided offset within a file with the provided chunk size.
+func newDownloadDestinationManifest(dst downloadDestination, chunkSize, offset uint64) *downloadDestinationManifest {
+	return &downloadDestinationManifest{
+		staticDestination: dst,
+		staticChunkSize:   chunkSize,
+		staticOffset:      offset,
+		chunks:            make(map[int64]modules.DownloadChunkHash),
+	}
+}
+
+// Close closes the wrapped destination if possible.
+func (ddm *downloadDestinationManifest) Close() error {
+	if closer, ok := ddm.staticDestination.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// WritePieces hashes the recovered data and writes the pieces to the wrapped
+// destination.
+func (ddm *downloadDestinationManifest) WritePieces(ec modules.ErasureCoder, pieces [][]byte, dataOffset uint64, writeOffset int64, length uint64) error {
+	h := crypto.NewHash()
+	err := ec.Recover(pieces, dataOffset+length, &skipWriter{writer: h, skip: int(dataOffset)})
+	if err != nil {
+		return errors.AddContext(err, "unable to recover data for the manifest")
+	}
+	if err := ddm.staticDestination.WritePieces(ec, pieces, dataOffset, writeOffset, length); err != nil {
+		return err
+	}
+
+	var chunk modules.DownloadChunkHash
+	chunk.Index = (ddm.staticOffset + uint64(writeOffset)) / ddm.staticChunkSize
+	chunk.Offset = uint64(writeOffset)
+	chunk.Length = length
+	copy(chunk.Hash[:], h.Sum(nil))
+
+	ddm.mu.Lock()
+	ddm.chunks[writeOffset] = chunk
+	ddm.mu.Unlock()
+	return nil
+}
+
+// managedManifest returns the manifest of the chunks written so far.
+func (ddm *downloadDestinationManifest) managedManifest() modules.DownloadManifest {
+	ddm.mu.Lock()
+	chunks := make([]modules.DownloadChunkHash, 0, len(ddm.chunks))
+	for _, chunk := range ddm.chunks {
+		chunks = append(chunks, chunk)
+	}
+	ddm.mu.Unlock()
+
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Offset < chunks[j].Offset
+	})
+	hashes := make([]byte, 0, len(chunks)*crypto.HashSize)
+	for _, chunk := range chunks {
+		hashes = append(hashes, chunk.Hash[:]...)
+	}
+	return modules.DownloadManifest{
+		Chunks: chunks,
+		Hash:   crypto.HashBytes(hashes),
+	}
+}
+
+// managedSave stores the manifest as json at the provided path.
+func (ddm *downloadDestinationManifest) managedSave(path string) error {
+	b, err := json.MarshalIndent(ddm.managedManifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, modules.DefaultFilePerm)
+}
@@ -0,0 +1,87 @@
+package renter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestDownloadDestinationManifest checks that the manifest contains the hashes
+// of the recovered data even if the chunks are written out of order.
+func TestDownloadDestinationManifest(t *testing.T) {
+	rsc, err := modules.NewRSCode(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkSize := uint64(128)
+	data := fastrand.Bytes(2 * int(chunkSize))
+
+	// Download the range [10, 188) of the file which spans both chunks.
+	offset, length := uint64(10), uint64(178)
+	dst := NewDownloadDestinationBuffer()
+	ddm := newDownloadDestinationManifest(dst, chunkSize, offset)
+
+	// Write the second chunk first. Drop a piece to force a reconstruction.
+	pieces, err := rsc.Encode(append([]byte(nil), data[chunkSize:]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces[0] = nil
+	if err := ddm.WritePieces(rsc, pieces, 0, int64(chunkSize-offset), offset+length-chunkSize); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.pieces) != rsc.NumPieces() {
+		t.Fatal("pieces weren't written to the wrapped destination")
+	}
+	pieces, err = rsc.Encode(append([]byte(nil), data[:chunkSize]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ddm.WritePieces(rsc, pieces, offset, 0, chunkSize-offset); err != nil {
+		t.Fatal(err)
+	}
+
+	first := crypto.HashBytes(data[offset:chunkSize])
+	second := crypto.HashBytes(data[chunkSize : offset+length])
+	expected := modules.DownloadManifest{
+		Chunks: []modules.DownloadChunkHash{
+			{Index: 0, Offset: 0, Length: chunkSize - offset, Hash: first},
+			{Index: 1, Offset: chunkSize - offset, Length: offset + length - chunkSize, Hash: second},
+		},
+		Hash: crypto.HashBytes(append(first[:], second[:]...)),
+	}
+	manifest := ddm.managedManifest()
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Fatal("unexpected manifest", manifest)
+	}
+
+	// Save the manifest and load it again.
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "file"+downloadManifestExtension)
+	if err := ddm.managedSave(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded modules.DownloadManifest
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, expected) {
+		t.Fatal("unexpected manifest", loaded)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
@@ -63,6 +64,19 @@ type (
 		readErr                 error
 		targetCacheSize         int64
 
+		// nextPrefetchChunk is the index of the chunk following the last
+		// chunk the streamer sent prefetch hints for.
+		nextPrefetchChunk uint64
+
+		// staticMaxCacheSize is the size the cache grows to at most. It's
+		// derived from the stream cache settings when the streamer is created.
+		staticMaxCacheSize int64
+
+		// staticHostStats tracks how fast the hosts served the chunks
+		// downloaded by the streamer so that the following chunks are
+		// downloaded from the fastest hosts.
+		staticHostStats *streamHostStats
+
 		// Mutex to protect the offset variable, and all of the cacheing
 		// variables.
 		mu sync.Mutex
@@ -166,6 +180,10 @@ func (s *streamer) managedFillCache() bool {
 		fetchLen = fileSize - fetchOffset
 	}
 
+	// Let the hosts know which sectors will be requested after this download
+	// so that they can read them from disk ahead of time.
+	s.managedSendPrefetchHints(fetchOffset + fetchLen)
+
 	// Perform the actual download.
 	buffer := bytes.NewBuffer([]byte{})
 	ddw := newDownloadDestinationWriter(buffer)
@@ -184,7 +202,8 @@ func (s *streamer) managedFillCache() bool {
 		priority:      1000, // TODO: high default until full priority support is added.
 
 		staticMemoryManager:    s.r.userDownloadMemoryManager, // user initiated download
-		staticSpendingCategory: categoryDownload,
+		staticSpendingCategory: categoryStreamDownload,
+		staticStreamHostStats:  s.staticHostStats,
 	})
 	if err != nil {
 		closeErr := ddw.Close()
@@ -245,11 +264,11 @@ func (s *streamer) managedFillCache() bool {
 	cacheLen = int64(len(s.cache))
 	streamOffsetInCache := s.cacheOffset <= s.offset && s.offset <= s.cacheOffset+cacheLen // NOTE: it's '<=' so that we also count being 1 byte beyond the cache
 	streamOffsetInTail := streamOffsetInCache && s.offset >= s.cacheOffset+(cacheLen/4)+(cacheLen/2)
-	targetCacheUnderLimit := s.targetCacheSize < maxStreamerCacheSize
+	targetCacheUnderLimit := s.targetCacheSize < s.staticMaxCacheSize
 	cacheExists := cacheLen > 0
 	if cacheExists && partialDownloadsSupported && targetCacheUnderLimit && streamOffsetInTail {
-		if s.targetCacheSize*2 > maxStreamerCacheSize {
-			s.targetCacheSize = maxStreamerCacheSize
+		if s.targetCacheSize*2 > s.staticMaxCacheSize {
+			s.targetCacheSize = s.staticMaxCacheSize
 		} else {
 			s.targetCacheSize *= 2
 		}
@@ -268,6 +287,7 @@ func (s *streamer) managedFillCache() bool {
 		s.cache = append(s.cache, buffer.Bytes()...)
 		s.cacheOffset = streamOffset
 	}
+	s.r.staticStreamCache.callUpdate(s, int64(len(s.cache)))
 
 	// Return true, indicating that this function should be called again,
 	// because there may be more cache that has been requested or used since the
@@ -275,6 +295,48 @@ func (s *streamer) managedFillCache() bool {
 	return true
 }
 
+// managedSendPrefetchHints sends prefetch hints for the chunks following the
+// provided offset to the hosts which store them. Chunks which were hinted
+// before are skipped.
+func (s *streamer) managedSendPrefetchHints(offset int64) {
+	if offset >= int64(s.staticFile.Size()) {
+		return
+	}
+	start, _ := s.staticFile.ChunkIndexByOffset(uint64(offset))
+	end := start + streamPrefetchChunks
+	if end > s.staticFile.NumChunks() {
+		end = s.staticFile.NumChunks()
+	}
+	s.mu.Lock()
+	if start < s.nextPrefetchChunk && s.nextPrefetchChunk <= end {
+		start = s.nextPrefetchChunk
+	}
+	if start >= end {
+		s.mu.Unlock()
+		return
+	}
+	s.nextPrefetchChunk = end
+	s.mu.Unlock()
+
+	// Collect the roots of every host in the order they are downloaded.
+	hints := make(map[string][]crypto.Hash)
+	for chunkIndex := start; chunkIndex < end; chunkIndex++ {
+		for _, pieceSet := range s.staticFile.Pieces(chunkIndex) {
+			for _, piece := range pieceSet {
+				hpk := piece.HostPubKey.String()
+				hints[hpk] = append(hints[hpk], piece.MerkleRoot)
+			}
+		}
+	}
+	for _, w := range s.r.staticWorkerPool.callWorkers() {
+		roots, ok := hints[w.staticHostPubKeyStr]
+		if !ok {
+			continue
+		}
+		go w.threadedSendPrefetchHint(roots)
+	}
+}
+
 // threadedFillCache is a background thread that keeps the cache full as data is
 // read out of the cache. The Read and Seek functions have access to a channel
 // that they can use to signal that the cache should be refilled. To ensure that
@@ -314,8 +376,13 @@ func (s *streamer) threadedFillCache() {
 	}
 }
 
-// Close closes the streamer.
+// Close closes the streamer. The streamer's cache is handed to the renter's
+// stream cache which might retain it for a while.
 func (s *streamer) Close() error {
+	s.mu.Lock()
+	cache, cacheOffset := s.cache, s.cacheOffset
+	s.mu.Unlock()
+	s.r.staticStreamCache.callClose(s, s.staticFile.UID(), cacheOffset, cache)
 	return nil
 }
 
@@ -329,6 +396,7 @@ func (s *streamer) Read(p []byte) (int, error) {
 	// the lock that it grabs needs to be held after the loops termination if
 	// the right conditions are met, resulting in an ugly/complex locking
 	// strategy.
+	var recorded bool
 	for {
 		// Grab the lock and check that the cache has data which we want. If the
 		// cache does have data that we want, we will keep the lock and exit the
@@ -357,8 +425,8 @@ func (s *streamer) Read(p []byte) (int, error) {
 		// advance.
 		twiceReadLen := int64(len(p) * 2)
 		if s.targetCacheSize < twiceReadLen {
-			if twiceReadLen > maxStreamerCacheSize {
-				s.targetCacheSize = maxStreamerCacheSize
+			if twiceReadLen > s.staticMaxCacheSize {
+				s.targetCacheSize = s.staticMaxCacheSize
 			} else {
 				s.targetCacheSize = twiceReadLen
 			}
@@ -366,7 +434,12 @@ func (s *streamer) Read(p []byte) (int, error) {
 
 		// Check if the cache contains data that we are interested in. If so,
 		// break out of the cache-fetch loop while still holding the lock.
-		if s.cacheOffset <= s.offset && s.offset < s.cacheOffset+int64(len(s.cache)) {
+		cached := s.cacheOffset <= s.offset && s.offset < s.cacheOffset+int64(len(s.cache))
+		if !recorded {
+			s.r.staticStreamCache.callRecordRead(cached)
+			recorded = true
+		}
+		if cached {
 			break
 		}
 
@@ -479,7 +552,11 @@ func (r *Renter) Streamer(siaPath modules.SiaPath, disableLocalFetch bool) (_ st
 	defer r.tg.Done()
 
 	// Lookup the file associated with the nickname.
-	node, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	resolved, err := r.staticFileSystem.ResolveSiaPath(siaPath)
+	if err != nil {
+		return "", nil, err
+	}
+	node, err := r.staticFileSystem.OpenSiaFile(resolved)
 	if err != nil {
 		return "", nil, err
 	}
@@ -525,8 +602,13 @@ func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch b
 		activateCache:           make(chan struct{}),
 		cacheReady:              make(chan struct{}),
 		staticDisableLocalFetch: disableLocalFetch,
+		staticHostStats:         newStreamHostStats(),
+		staticMaxCacheSize:      r.staticStreamCache.callMaxCacheSize(snapshot.ChunkSize()),
 		targetCacheSize:         initialStreamerCacheSize,
 	}
+	// Seed the cache with the retained cache of a recently closed streamer of
+	// the same file.
+	s.cacheOffset, s.cache = r.staticStreamCache.callOpen(s, snapshot.UID())
 	go s.threadedFillCache()
 	return s
 }
@@ -41,23 +41,34 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 }
 
 // FileList loops over all the files within the directory specified by siaPath
-// and will then call the provided listing function on the file.
+// and will then call the provided listing function on the file. Aliases on the
+// way to the directory are followed. Aliases to files within the directory are
+// only listed if recursive is false.
 func (r *Renter) FileList(siaPath modules.SiaPath, recursive, cached bool, flf modules.FileListFunc) error {
 	if err := r.tg.Add(); err != nil {
 		return err
 	}
 	defer r.tg.Done()
-	var err error
+	resolved, err := r.staticFileSystem.ResolveSiaPath(siaPath)
+	if err != nil {
+		return err
+	}
+	flf, _ = rebaseListing(siaPath, resolved, flf, func(modules.DirectoryInfo) {})
+	var offlineMap, goodForRenewMap map[string]bool
+	var contractsMap map[string]modules.RenterContract
 	if cached {
-		err = r.staticFileSystem.CachedList(siaPath, recursive, flf, func(modules.DirectoryInfo) {})
+		err = r.staticFileSystem.CachedList(resolved, recursive, flf, func(modules.DirectoryInfo) {})
 	} else {
-		offlineMap, goodForRenewMap, contractsMap := r.managedContractUtilityMaps()
-		err = r.staticFileSystem.List(siaPath, recursive, offlineMap, goodForRenewMap, contractsMap, flf, func(modules.DirectoryInfo) {})
+		offlineMap, goodForRenewMap, contractsMap = r.managedContractUtilityMaps()
+		err = r.staticFileSystem.List(resolved, recursive, offlineMap, goodForRenewMap, contractsMap, flf, func(modules.DirectoryInfo) {})
 	}
 	if err != nil {
 		return err
 	}
-	return err
+	if recursive {
+		return nil
+	}
+	return r.managedListAliasFiles(siaPath, resolved, cached, offlineMap, goodForRenewMap, contractsMap, flf)
 }
 
 // File returns file from siaPath queried by user.
@@ -85,6 +96,83 @@ func (r *Renter) FileCached(siaPath modules.SiaPath) (modules.FileInfo, error) {
 	return r.staticFileSystem.CachedFileInfo(siaPath)
 }
 
+// FileLayout returns which hosts store which pieces of the file at siaPath
+// together with the health, stuck status and last repair time of each chunk.
+func (r *Renter) FileLayout(siaPath modules.SiaPath) (_ modules.FileLayout, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.FileLayout{}, err
+	}
+	defer r.tg.Done()
+	sf, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return modules.FileLayout{}, errors.AddContext(err, "unable to open the file")
+	}
+	defer func() {
+		err = errors.Compose(err, sf.Close())
+	}()
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+
+	ec := sf.ErasureCode()
+	layout := modules.FileLayout{
+		SiaPath:      siaPath,
+		DataPieces:   ec.MinPieces(),
+		ParityPieces: ec.NumPieces() - ec.MinPieces(),
+		Hosts:        []modules.FileLayoutHost{},
+		Chunks:       make([]modules.FileLayoutChunk, 0, sf.NumChunks()),
+	}
+	hostIndices := make(map[string]int)
+	for chunkIndex := uint64(0); chunkIndex < sf.NumChunks(); chunkIndex++ {
+		health, _, _, err := sf.ChunkHealth(int(chunkIndex), offline, goodForRenew)
+		if err != nil {
+			return modules.FileLayout{}, errors.AddContext(err, "unable to get chunk health")
+		}
+		stuck, err := sf.StuckChunkByIndex(chunkIndex)
+		if err != nil {
+			return modules.FileLayout{}, errors.AddContext(err, "unable to get chunk stuck status")
+		}
+		lastRepair, err := sf.ChunkLastRepair(chunkIndex)
+		if err != nil {
+			return modules.FileLayout{}, errors.AddContext(err, "unable to get chunk last repair")
+		}
+		pieceSets, err := sf.Pieces(chunkIndex)
+		if err != nil {
+			return modules.FileLayout{}, errors.AddContext(err, "unable to get chunk pieces")
+		}
+		chunk := modules.FileLayoutChunk{
+			Health:     health,
+			Stuck:      stuck,
+			LastRepair: lastRepair,
+			Pieces:     make([][]modules.FileLayoutPiece, len(pieceSets)),
+		}
+		for pieceIndex, pieceSet := range pieceSets {
+			chunk.Pieces[pieceIndex] = make([]modules.FileLayoutPiece, 0, len(pieceSet))
+			for _, piece := range pieceSet {
+				key := piece.HostPubKey.String()
+				hostIndex, exists := hostIndices[key]
+				if !exists {
+					// Hosts without a contract are neither in the offline
+					// nor in the goodForRenew map.
+					_, hasContract := offline[key]
+					hostIndex = len(layout.Hosts)
+					hostIndices[key] = hostIndex
+					layout.Hosts = append(layout.Hosts, modules.FileLayoutHost{
+						PublicKey:    piece.HostPubKey,
+						Online:       hasContract && !offline[key],
+						GoodForRenew: goodForRenew[key],
+					})
+				}
+				host := layout.Hosts[hostIndex]
+				chunk.Pieces[pieceIndex] = append(chunk.Pieces[pieceIndex], modules.FileLayoutPiece{
+					Host: hostIndex,
+					Good: !piece.Unverified && host.Online && host.GoodForRenew,
+				})
+			}
+		}
+		layout.Chunks = append(layout.Chunks, chunk)
+	}
+	return layout, nil
+}
+
 // RenameFile takes an existing file and changes the nickname. The original
 // file must exist, and there must not be any file that already has the
 // replacement nickname.
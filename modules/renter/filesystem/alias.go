@@ -0,0 +1,238 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxAliasDepth is the maximum number of aliases that are followed when
+	// resolving a SiaPath before assuming that the aliases form a loop.
+	maxAliasDepth = 16
+)
+
+var (
+	// ErrAliasLoop is returned when resolving a SiaPath requires following
+	// more than maxAliasDepth aliases.
+	ErrAliasLoop = errors.New("too many levels of aliases")
+
+	// errAliasAtRoot is returned when trying to create an alias at the root of
+	// the filesystem.
+	errAliasAtRoot = errors.New("can't create an alias at the root of the filesystem")
+)
+
+type (
+	// aliasPersist is the on-disk format of an alias. Aliases are immutable,
+	// to change the target the alias is deleted and recreated.
+	aliasPersist struct {
+		Target modules.SiaPath `json:"target"`
+	}
+)
+
+// readAlias reads the target of the alias at the provided system path. If no
+// alias exists at the path, exists is false.
+func readAlias(path string) (target modules.SiaPath, exists bool, err error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return modules.SiaPath{}, false, nil
+	}
+	if err != nil {
+		return modules.SiaPath{}, false, errors.AddContext(err, "failed to read alias")
+	}
+	var ap aliasPersist
+	if err := json.Unmarshal(b, &ap); err != nil {
+		return modules.SiaPath{}, false, errors.AddContext(err, "failed to parse alias")
+	}
+	return ap.Target, true, nil
+}
+
+// writeAlias atomically writes an alias pointing at target to the provided
+// system path.
+func writeAlias(path string, target modules.SiaPath) (err error) {
+	b, err := json.Marshal(aliasPersist{Target: target})
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal alias")
+	}
+	tmpPath := path + "_temp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, modules.DefaultFilePerm)
+	if err != nil {
+		return errors.AddContext(err, "failed to create alias")
+	}
+	_, err = f.Write(b)
+	err = errors.Compose(err, f.Sync(), f.Close())
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "failed to write alias"), os.Remove(tmpPath))
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// firstAlias returns the target of the first alias on the way from the root to
+// siaPath together with the remainder of siaPath after the alias.
+func firstAlias(root string, siaPath modules.SiaPath) (target modules.SiaPath, rest string, found bool, err error) {
+	if siaPath.IsRoot() {
+		return modules.SiaPath{}, "", false, nil
+	}
+	elems := strings.Split(siaPath.String(), "/")
+	for i := range elems {
+		prefix, err := modules.NewSiaPath(strings.Join(elems[:i+1], "/"))
+		if err != nil {
+			return modules.SiaPath{}, "", false, err
+		}
+		target, exists, err := readAlias(prefix.SiaAliasSysPath(root))
+		if err != nil {
+			return modules.SiaPath{}, "", false, err
+		}
+		if exists {
+			return target, strings.Join(elems[i+1:], "/"), true, nil
+		}
+	}
+	return modules.SiaPath{}, "", false, nil
+}
+
+// ResolveSiaPath follows all the aliases on the way from the root to siaPath
+// and returns the resulting SiaPath. SiaPaths without aliases are returned
+// unchanged. The resolved SiaPath is not guaranteed to exist.
+func (fs *FileSystem) ResolveSiaPath(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	root := fs.managedAbsPath()
+	for hops := 0; ; hops++ {
+		target, rest, found, err := firstAlias(root, siaPath)
+		if err != nil {
+			return modules.SiaPath{}, err
+		}
+		if !found {
+			return siaPath, nil
+		}
+		if hops == maxAliasDepth {
+			return modules.SiaPath{}, ErrAliasLoop
+		}
+		siaPath = target
+		if rest != "" {
+			siaPath, err = target.Join(rest)
+			if err != nil {
+				return modules.SiaPath{}, err
+			}
+		}
+	}
+}
+
+// AliasTarget returns the target of the alias at siaPath.
+func (fs *FileSystem) AliasTarget(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	target, exists, err := readAlias(siaPath.SiaAliasSysPath(fs.managedAbsPath()))
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	if !exists {
+		return modules.SiaPath{}, ErrNotExist
+	}
+	return target, nil
+}
+
+// Aliases returns the names of the aliases within the dir at siaPath mapped to
+// their targets.
+func (fs *FileSystem) Aliases(siaPath modules.SiaPath) (map[string]modules.SiaPath, error) {
+	dirPath := siaPath.SiaDirSysPath(fs.managedAbsPath())
+	fis, err := ioutil.ReadDir(dirPath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	aliases := make(map[string]modules.SiaPath)
+	for _, fi := range fis {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != modules.SiaAliasExtension {
+			continue
+		}
+		target, exists, err := readAlias(filepath.Join(dirPath, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			aliases[strings.TrimSuffix(fi.Name(), modules.SiaAliasExtension)] = target
+		}
+	}
+	return aliases, nil
+}
+
+// NewAlias creates an alias at siaPath which points at target. The target
+// needs to exist when the alias is created but the alias isn't updated when
+// the target is renamed or deleted later on.
+func (fs *FileSystem) NewAlias(siaPath, target modules.SiaPath) (err error) {
+	if siaPath.IsRoot() {
+		return errAliasAtRoot
+	}
+	// Make sure the target exists.
+	resolved, err := fs.ResolveSiaPath(target)
+	if err != nil {
+		return errors.AddContext(err, "failed to resolve alias target")
+	}
+	fileExists, err := fs.FileExists(resolved)
+	if err != nil {
+		return err
+	}
+	dirExists, err := fs.DirExists(resolved)
+	if err != nil {
+		return err
+	}
+	if !fileExists && !dirExists {
+		return errors.AddContext(ErrNotExist, "alias target doesn't exist")
+	}
+	// Create the alias within its parent.
+	parentPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	parent, err := fs.OpenSiaDirCustom(parentPath, true)
+	if err != nil {
+		return errors.AddContext(err, "failed to open parent dir of alias")
+	}
+	defer func() {
+		err = errors.Compose(err, parent.Close())
+	}()
+	return parent.managedNewAlias(siaPath.Name(), target)
+}
+
+// DeleteAlias deletes the alias at siaPath. The target of the alias is not
+// affected.
+func (fs *FileSystem) DeleteAlias(siaPath modules.SiaPath) (err error) {
+	parentPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	parent, err := fs.OpenSiaDir(parentPath)
+	if err != nil {
+		return errors.AddContext(err, "failed to open parent dir of alias")
+	}
+	defer func() {
+		err = errors.Compose(err, parent.Close())
+	}()
+	return parent.managedDeleteAlias(siaPath.Name())
+}
+
+// managedNewAlias creates an alias with the given name within the dir.
+func (n *DirNode) managedNewAlias(name string, target modules.SiaPath) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// Make sure we don't have a file, folder or alias with that name already.
+	if n.childExists(name) {
+		return ErrExists
+	}
+	return writeAlias(filepath.Join(n.absPath(), name+modules.SiaAliasExtension), target)
+}
+
+// managedDeleteAlias deletes the alias with the given name within the dir.
+func (n *DirNode) managedDeleteAlias(name string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	err := os.Remove(filepath.Join(n.absPath(), name+modules.SiaAliasExtension))
+	if os.IsNotExist(err) {
+		return ErrNotExist
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package filesystem
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+// TestAliases probes creating, resolving and deleting aliases.
+func TestAliases(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	root := testDir(t.Name())
+	fs := newTestFileSystem(root)
+	fs.addTestSiaFile(newSiaPath("dir/file"))
+
+	// Create an alias for the dir, an alias for the file and an alias which
+	// points at the file through the alias of the dir.
+	if err := fs.NewAlias(newSiaPath("dirAlias"), newSiaPath("dir")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.NewAlias(newSiaPath("other/fileAlias"), newSiaPath("dir/file")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.NewAlias(newSiaPath("chainedAlias"), newSiaPath("dirAlias/file")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the resolution of different paths.
+	tests := []struct {
+		siaPath, resolved string
+	}{
+		{"dir/file", "dir/file"},
+		{"dirAlias", "dir"},
+		{"dirAlias/file", "dir/file"},
+		{"dirAlias/missing", "dir/missing"},
+		{"other/fileAlias", "dir/file"},
+		{"chainedAlias", "dir/file"},
+	}
+	for _, test := range tests {
+		resolved, err := fs.ResolveSiaPath(newSiaPath(test.siaPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resolved.Equals(newSiaPath(test.resolved)) {
+			t.Errorf("%v: expected %v but got %v", test.siaPath, test.resolved, resolved)
+		}
+	}
+	target, err := fs.AliasTarget(newSiaPath("chainedAlias"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !target.Equals(newSiaPath("dirAlias/file")) {
+		t.Fatal("wrong target", target)
+	}
+	aliases, err := fs.Aliases(modules.RootSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 2 || !aliases["dirAlias"].Equals(newSiaPath("dir")) || !aliases["chainedAlias"].Equals(newSiaPath("dirAlias/file")) {
+		t.Fatal("wrong aliases", aliases)
+	}
+
+	// Aliases can't shadow existing files and dirs and vice versa.
+	if err := fs.NewAlias(newSiaPath("dir/file"), newSiaPath("dir")); !errors.Contains(err, ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+	if err := fs.NewAlias(newSiaPath("dirAlias"), newSiaPath("dir")); !errors.Contains(err, ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+	if err := fs.NewSiaDir(newSiaPath("dirAlias"), modules.DefaultDirPerm); !errors.Contains(err, ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+	if err := fs.addTestSiaFileWithErr(newSiaPath("chainedAlias")); !errors.Contains(err, ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+
+	// The target needs to exist.
+	if err := fs.NewAlias(newSiaPath("missingAlias"), newSiaPath("missing")); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+
+	// Create a loop by writing the aliases directly.
+	if err := writeAlias(newSiaPath("loopA").SiaAliasSysPath(root), newSiaPath("loopB")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeAlias(newSiaPath("loopB").SiaAliasSysPath(root), newSiaPath("loopA/file")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.ResolveSiaPath(newSiaPath("loopA")); !errors.Contains(err, ErrAliasLoop) {
+		t.Fatal("expected ErrAliasLoop but got", err)
+	}
+
+	// Delete an alias.
+	if err := fs.DeleteAlias(newSiaPath("dirAlias")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.AliasTarget(newSiaPath("dirAlias")); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+	if err := fs.DeleteAlias(newSiaPath("dirAlias")); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+	resolved, err := fs.ResolveSiaPath(newSiaPath("chainedAlias"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resolved.Equals(newSiaPath("dirAlias/file")) {
+		t.Fatal("expected dangling alias to resolve to its target", resolved)
+	}
+}
@@ -353,7 +353,7 @@ func (n *DirNode) managedNewSiaFileFromExisting(sf *siafile.SiaFile, chunks siaf
 	// Add the node to the dir.
 	fileName := strings.TrimSuffix(filepath.Base(currentPath), modules.SiaFileExtension)
 	fn := &FileNode{
-		node:    newNode(n, currentPath, fileName, 0, n.staticWal, n.staticLog),
+		node:    newNode(n, currentPath, fileName, 0, n.staticWal, n.staticLog, n.staticAlerter),
 		SiaFile: sf,
 	}
 	n.files[fileName] = fn
@@ -382,7 +382,7 @@ func (n *DirNode) managedNewSiaFileFromLegacyData(fileName string, fd siafile.Fi
 	}
 	// Add it to the node.
 	fn := &FileNode{
-		node:    newNode(n, path, key, 0, n.staticWal, n.staticLog),
+		node:    newNode(n, path, key, 0, n.staticWal, n.staticLog, n.staticAlerter),
 		SiaFile: sf,
 	}
 	n.files[key] = fn
@@ -428,6 +428,7 @@ func (n *DirNode) siaDir() (*siadir.SiaDir, error) {
 	if err != nil {
 		return nil, err
 	}
+	n.staticRegisterMetadataAlert(n.absPath(), sd.MetadataRepaired(), nil)
 	*n.lazySiaDir = sd
 	return sd, nil
 }
@@ -622,8 +623,8 @@ func (n *DirNode) childDirs() []*DirNode {
 	return dirs
 }
 
-// managedExists returns 'true' if a file or folder with the given name already
-// exists within the dir.
+// managedExists returns 'true' if a file, folder or alias with the given name
+// already exists within the dir.
 func (n *DirNode) childExists(name string) bool {
 	// Check the ones in memory first.
 	if _, exists := n.files[name]; exists {
@@ -632,10 +633,11 @@ func (n *DirNode) childExists(name string) bool {
 	if _, exists := n.directories[name]; exists {
 		return true
 	}
-	// Check that no dir or file exists on disk.
+	// Check that no dir, file or alias exists on disk.
 	_, errFile := os.Stat(filepath.Join(n.absPath(), name))
 	_, errDir := os.Stat(filepath.Join(n.absPath(), name+modules.SiaFileExtension))
-	return !os.IsNotExist(errFile) || !os.IsNotExist(errDir)
+	_, errAlias := os.Stat(filepath.Join(n.absPath(), name+modules.SiaAliasExtension))
+	return !os.IsNotExist(errFile) || !os.IsNotExist(errDir) || !os.IsNotExist(errAlias)
 }
 
 // childFiles is a convenience method to return the files field of a DNode as a
@@ -671,11 +673,15 @@ func (n *DirNode) managedNewSiaDir(dirName string, rootPath string, mode os.File
 	if _, exists := n.files[dirName]; exists {
 		return ErrExists
 	}
-	// Check that no dir or file exists on disk.
+	// Check that no file or alias exists on disk.
 	_, err := os.Stat(filepath.Join(n.absPath(), dirName+modules.SiaFileExtension))
 	if !os.IsNotExist(err) {
 		return ErrExists
 	}
+	_, err = os.Stat(filepath.Join(n.absPath(), dirName+modules.SiaAliasExtension))
+	if !os.IsNotExist(err) {
+		return ErrExists
+	}
 	_, err = siadir.New(filepath.Join(n.absPath(), dirName), rootPath, mode)
 	if errors.Contains(err, os.ErrExist) {
 		return nil
@@ -717,11 +723,12 @@ func (n *DirNode) readonlyOpenFile(fileName string) (*FileNode, error) {
 	if errors.Contains(err, siafile.ErrUnknownPath) || os.IsNotExist(err) {
 		return nil, ErrNotExist
 	}
+	n.staticRegisterMetadataAlert(filePath, err == nil && sf.MetadataRepaired(), err)
 	if err != nil {
 		return nil, errors.AddContext(err, fmt.Sprintf("failed to load SiaFile '%v' from disk", filePath))
 	}
 	fn = &FileNode{
-		node:    newNode(n, filePath, fileName, 0, n.staticWal, n.staticLog),
+		node:    newNode(n, filePath, fileName, 0, n.staticWal, n.staticLog, n.staticAlerter),
 		SiaFile: sf,
 	}
 	// Clone the node, give it a new UID and return it.
@@ -755,7 +762,7 @@ func (n *DirNode) openDir(dirName string) (*DirNode, error) {
 	}
 	// Add the dir to the opened dirs.
 	dir = &DirNode{
-		node:        newNode(n, dirPath, dirName, 0, n.staticWal, n.staticLog),
+		node:        newNode(n, dirPath, dirName, 0, n.staticWal, n.staticLog, n.staticAlerter),
 		directories: make(map[string]*DirNode),
 		files:       make(map[string]*FileNode),
 		lazySiaDir:  new(*siadir.SiaDir),
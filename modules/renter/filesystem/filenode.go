@@ -1,6 +1,7 @@
 package filesystem
 
 import (
+	"encoding/hex"
 	"math"
 	"os"
 	"path/filepath"
@@ -36,6 +37,19 @@ func (n *FileNode) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64
 	return n.SiaFile.AddPiece(pk, chunkIndex, pieceIndex, merkleRoot)
 }
 
+// AddUnverifiedPiece wraps siafile.AddUnverifiedPiece to guarantee that it's
+// not called when the fileNode was already closed.
+func (n *FileNode) AddUnverifiedPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash) (err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		err := errors.New("AddUnverifiedPiece called on close FileNode")
+		build.Critical(err)
+		return err
+	}
+	return n.SiaFile.AddUnverifiedPiece(pk, chunkIndex, pieceIndex, merkleRoot)
+}
+
 // close closes the file and removes it from the parent if it was the last open
 // instance.
 // NOTE: If the file has a parent, it needs to be already locked when this is
@@ -137,32 +151,38 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		return modules.FileInfo{}, errors.AddContext(err, "failed to get upload progress and bytes")
 	}
 	maxHealth := math.Max(health, stuckHealth)
+	repairThreshold := n.RepairThreshold()
+	checksumAlgorithm, checksum := n.Checksum()
 	fileInfo := modules.FileInfo{
-		AccessTime:       n.AccessTime(),
-		Available:        redundancy >= 1,
-		ChangeTime:       n.ChangeTime(),
-		CipherType:       n.MasterKey().Type().String(),
-		CreateTime:       n.CreateTime(),
-		Expiration:       n.Expiration(contracts),
-		Filesize:         n.Size(),
-		Health:           health,
-		LocalPath:        localPath,
-		MaxHealth:        maxHealth,
-		MaxHealthPercent: modules.HealthPercentage(maxHealth),
-		ModificationTime: n.ModTime(),
-		NumStuckChunks:   numStuckChunks,
-		OnDisk:           onDisk,
-		Recoverable:      onDisk || redundancy >= 1,
-		Redundancy:       redundancy,
-		Renewing:         true,
-		RepairBytes:      repairBytes,
-		SiaPath:          siaPath,
-		Stuck:            numStuckChunks > 0,
-		StuckHealth:      stuckHealth,
-		StuckBytes:       stuckBytes,
-		UID:              n.staticUID,
-		UploadedBytes:    uploadedBytes,
-		UploadProgress:   uploadProgress,
+		AccessTime:        n.AccessTime(),
+		Available:         redundancy >= 1,
+		ChangeTime:        n.ChangeTime(),
+		Checksum:          hex.EncodeToString(checksum),
+		ChecksumAlgorithm: checksumAlgorithm,
+		CipherType:        n.MasterKey().Type().String(),
+		CreateTime:        n.CreateTime(),
+		Expiration:        n.Expiration(contracts),
+		Filesize:          n.Size(),
+		Health:            health,
+		LocalPath:         localPath,
+		MaxHealth:         maxHealth,
+		MaxHealthPercent:  modules.HealthPercentage(maxHealth),
+		ModificationTime:  n.ModTime(),
+		NumStuckChunks:    numStuckChunks,
+		OnDisk:            onDisk,
+		Recoverable:       onDisk || redundancy >= 1,
+		Redundancy:        redundancy,
+		Renewing:          true,
+		RepairBytes:       repairBytes,
+		RepairThreshold:   repairThreshold,
+		RepairUrgency:     modules.RepairUrgency(health, repairThreshold),
+		SiaPath:           siaPath,
+		Stuck:             numStuckChunks > 0,
+		StuckHealth:       stuckHealth,
+		StuckBytes:        stuckBytes,
+		UID:               n.staticUID,
+		UploadedBytes:     uploadedBytes,
+		UploadProgress:    uploadProgress,
 	}
 	return fileInfo, nil
 }
@@ -221,32 +241,40 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		onDisk = err == nil
 	}
 	maxHealth := math.Max(md.CachedHealth, md.CachedStuckHealth)
+	repairThreshold := md.CachedRepairThreshold
+	if repairThreshold <= 0 {
+		repairThreshold = modules.RepairThreshold
+	}
 	fileInfo := modules.FileInfo{
-		AccessTime:       md.AccessTime,
-		Available:        md.CachedUserRedundancy >= 1,
-		ChangeTime:       md.ChangeTime,
-		CipherType:       md.StaticMasterKeyType.String(),
-		CreateTime:       md.CreateTime,
-		Expiration:       md.CachedExpiration,
-		Filesize:         uint64(md.FileSize),
-		Health:           md.CachedHealth,
-		LocalPath:        localPath,
-		MaxHealth:        maxHealth,
-		MaxHealthPercent: modules.HealthPercentage(maxHealth),
-		ModificationTime: md.ModTime,
-		NumStuckChunks:   md.NumStuckChunks,
-		OnDisk:           onDisk,
-		Recoverable:      onDisk || md.CachedUserRedundancy >= 1,
-		Redundancy:       md.CachedUserRedundancy,
-		Renewing:         true,
-		RepairBytes:      md.CachedRepairBytes,
-		SiaPath:          siaPath,
-		Stuck:            md.NumStuckChunks > 0,
-		StuckBytes:       md.CachedStuckBytes,
-		StuckHealth:      md.CachedStuckHealth,
-		UID:              n.staticUID,
-		UploadedBytes:    md.CachedUploadedBytes,
-		UploadProgress:   md.CachedUploadProgress,
+		AccessTime:        md.AccessTime,
+		Available:         md.CachedUserRedundancy >= 1,
+		ChangeTime:        md.ChangeTime,
+		Checksum:          hex.EncodeToString(md.Checksum),
+		ChecksumAlgorithm: md.ChecksumAlgorithm,
+		CipherType:        md.StaticMasterKeyType.String(),
+		CreateTime:        md.CreateTime,
+		Expiration:        md.CachedExpiration,
+		Filesize:          uint64(md.FileSize),
+		Health:            md.CachedHealth,
+		LocalPath:         localPath,
+		MaxHealth:         maxHealth,
+		MaxHealthPercent:  modules.HealthPercentage(maxHealth),
+		ModificationTime:  md.ModTime,
+		NumStuckChunks:    md.NumStuckChunks,
+		OnDisk:            onDisk,
+		Recoverable:       onDisk || md.CachedUserRedundancy >= 1,
+		Redundancy:        md.CachedUserRedundancy,
+		Renewing:          true,
+		RepairBytes:       md.CachedRepairBytes,
+		RepairThreshold:   repairThreshold,
+		RepairUrgency:     modules.RepairUrgency(md.CachedHealth, repairThreshold),
+		SiaPath:           siaPath,
+		Stuck:             md.NumStuckChunks > 0,
+		StuckBytes:        md.CachedStuckBytes,
+		StuckHealth:       md.CachedStuckHealth,
+		UID:               n.staticUID,
+		UploadedBytes:     md.CachedUploadedBytes,
+		UploadProgress:    md.CachedUploadProgress,
 	}
 	return fileInfo, nil
 }
@@ -34,6 +34,17 @@ var (
 	ErrDeleteFileIsDir = errors.New("cannot delete file, file is a directory")
 )
 
+// Constants related to the filesystem's alerts.
+const (
+	// AlertMSGMetadataRepaired indicates that the metadata of a file or folder
+	// was corrupted on disk and has been repaired.
+	AlertMSGMetadataRepaired = "The metadata of the file or folder mentioned in the 'Cause' was corrupted on disk and has been repaired"
+
+	// AlertMSGMetadataCorrupted indicates that the metadata of a file is
+	// corrupted beyond repair.
+	AlertMSGMetadataCorrupted = "The metadata of the file mentioned in the 'Cause' is corrupted beyond repair"
+)
+
 type (
 	// FileSystem implements a thread-safe filesystem for Sia for loading
 	// SiaFiles, SiaDirs and potentially other supported Sia types in the
@@ -54,6 +65,10 @@ type (
 		staticUID uint64
 		mu        *sync.Mutex
 
+		// staticAlerter is the alerter of the filesystem which is shared by
+		// all nodes.
+		staticAlerter *modules.GenericAlerter
+
 		// fields that differ between copies of the same node.
 		threadUID threadUID // unique ID of a copy of a node
 	}
@@ -61,17 +76,31 @@ type (
 )
 
 // newNode is a convenience function to initialize a node.
-func newNode(parent *DirNode, path, name string, uid threadUID, wal *writeaheadlog.WAL, log *persist.Logger) node {
+func newNode(parent *DirNode, path, name string, uid threadUID, wal *writeaheadlog.WAL, log *persist.Logger, alerter *modules.GenericAlerter) node {
 	return node{
-		path:      &path,
-		parent:    parent,
-		name:      &name,
-		staticLog: log,
-		staticUID: newInode(),
-		staticWal: wal,
-		threads:   make(map[threadUID]struct{}),
-		threadUID: uid,
-		mu:        new(sync.Mutex),
+		path:          &path,
+		parent:        parent,
+		name:          &name,
+		staticLog:     log,
+		staticUID:     newInode(),
+		staticWal:     wal,
+		staticAlerter: alerter,
+		threads:       make(map[threadUID]struct{}),
+		threadUID:     uid,
+		mu:            new(sync.Mutex),
+	}
+}
+
+// staticRegisterMetadataAlert registers an alert for the file or folder at
+// the provided path if its metadata was repaired or is corrupted beyond
+// repair. 'err' is the error returned when loading the metadata.
+func (n *node) staticRegisterMetadataAlert(path string, repaired bool, err error) {
+	if errors.Contains(err, siafile.ErrCorruptMetadata) {
+		n.staticLog.Printf("ERROR: metadata of '%v' is corrupted beyond repair: %v", path, err)
+		n.staticAlerter.RegisterAlert(modules.AlertIDFilesystemMetadataCorrupted(path), AlertMSGMetadataCorrupted, path, modules.SeverityError)
+	} else if err == nil && repaired {
+		n.staticLog.Printf("WARN: metadata of '%v' was corrupted on disk and has been repaired", path)
+		n.staticAlerter.RegisterAlert(modules.AlertIDFilesystemMetadataRepaired(path), AlertMSGMetadataRepaired, path, modules.SeverityWarning)
 	}
 }
 
@@ -152,7 +181,7 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 	fs := &FileSystem{
 		DirNode: DirNode{
 			// The root doesn't require a parent, a name or uid.
-			node:        newNode(nil, root, "", 0, wal, log),
+			node:        newNode(nil, root, "", 0, wal, log, modules.NewAlerter("filesystem")),
 			directories: make(map[string]*DirNode),
 			files:       make(map[string]*FileNode),
 			lazySiaDir:  new(*siadir.SiaDir),
@@ -166,6 +195,11 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 	return fs, nil
 }
 
+// Alerts returns the alerts of the filesystem.
+func (fs *FileSystem) Alerts() (crit, err, warn []modules.Alert) {
+	return fs.staticAlerter.Alerts()
+}
+
 // AddSiaFileFromReader adds an existing SiaFile to the set and stores it on
 // disk. If the exact same file already exists, this is a no-op. If a file
 // already exists with a different UID, the UID will be updated and a unique
@@ -2319,3 +2319,44 @@ func TestList(t *testing.T) {
 		t.Fatal("wrong number of dirs", len(dis), len(dirStructure))
 	}
 }
+
+// TestMetadataRepairedAlert checks that the filesystem registers an alert when
+// the metadata of a file was repaired on load.
+func TestMetadataRepairedAlert(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a filesystem with a file.
+	fs := newTestFileSystem(testDir(t.Name()))
+	sp := newSiaPath("file")
+	fs.addTestSiaFile(sp)
+
+	// Flip a bit within the file's metadata.
+	path := fs.FilePath(sp)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[10] ^= 1
+	if err := ioutil.WriteFile(path, b, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+
+	// Open the file. It should be repaired and an alert should be registered.
+	sf, err := fs.OpenSiaFile(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, _, warn := fs.Alerts()
+	for _, alert := range warn {
+		if alert.Msg == AlertMSGMetadataRepaired && alert.Cause == path {
+			return
+		}
+	}
+	t.Fatal("expected metadata repaired alert but got", warn)
+}
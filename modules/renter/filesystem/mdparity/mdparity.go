@@ -0,0 +1,179 @@
+// Package mdparity protects the metadata of the filesystem's files and
+// directories against local disk corruption. The metadata is split into pages
+// and every page is erasure coded into segments. The parity segments and the
+// checksums of all segments are appended to the metadata as a trailer, which
+// allows for detecting and repairing corrupted segments when the metadata is
+// loaded from disk.
+package mdparity
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// pageSize is the size of a metadata page which is protected by its own
+	// parity block.
+	pageSize = 4096
+
+	// segmentSize is the size of the segments a page is split into. A
+	// corrupted segment can be repaired as long as no more than
+	// paritySegments segments of the same page are corrupted.
+	segmentSize = 256
+
+	// dataSegments is the number of data segments per page.
+	dataSegments = pageSize / segmentSize
+
+	// paritySegments is the number of parity segments per page.
+	paritySegments = 2
+
+	// checksumSize is the size of the truncated checksum of a single segment.
+	checksumSize = 8
+
+	// pageParitySize is the size of the parity block of a single page. It
+	// consists of the checksums of all segments followed by the parity
+	// segments.
+	pageParitySize = (dataSegments+paritySegments)*checksumSize + paritySegments*segmentSize
+
+	// magicSize is the size of the magic bytes which mark the beginning of
+	// the parity trailer.
+	magicSize = 8
+)
+
+var (
+	// ErrNoParity is returned by Decode if the data doesn't contain a parity
+	// trailer. This is the case for metadata that was persisted before parity
+	// was introduced.
+	ErrNoParity = errors.New("metadata doesn't contain parity")
+
+	// ErrUnrepairable is returned by Decode if the metadata is corrupted
+	// beyond repair.
+	ErrUnrepairable = errors.New("metadata is corrupted beyond repair")
+)
+
+// magic marks the beginning of the parity trailer. It contains NUL bytes which
+// are never part of JSON encoded metadata. Its position also indicates the
+// length of the data the trailer protects.
+var magic = [magicSize]byte{0, 'M', 'D', 'P', 'A', 'R', 0, 1}
+
+// newErasureCoder returns the erasure coder used to create the parity of a
+// page.
+func newErasureCoder() modules.ErasureCoder {
+	rs, err := modules.NewRSCode(dataSegments, paritySegments)
+	if err != nil {
+		panic(err) // should never happen
+	}
+	return rs
+}
+
+// numPages returns the number of pages required for data of length n.
+func numPages(n int) int {
+	return (n + pageSize - 1) / pageSize
+}
+
+// segmentChecksum returns the truncated checksum of a segment.
+func segmentChecksum(segment []byte) []byte {
+	h := crypto.HashBytes(segment)
+	return h[:checksumSize]
+}
+
+// TrailerSize returns the size of the parity trailer for data of length n.
+func TrailerSize(n int) int {
+	return magicSize + numPages(n)*pageParitySize
+}
+
+// Encode returns the data followed by its parity trailer.
+func Encode(data []byte) ([]byte, error) {
+	rs := newErasureCoder()
+	out := make([]byte, 0, len(data)+TrailerSize(len(data)))
+	out = append(out, data...)
+	out = append(out, magic[:]...)
+	for i := 0; i < numPages(len(data)); i++ {
+		shards, err := rs.Encode(page(data, i))
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to encode page")
+		}
+		for _, shard := range shards {
+			out = append(out, segmentChecksum(shard)...)
+		}
+		for _, shard := range shards[dataSegments:] {
+			out = append(out, shard...)
+		}
+	}
+	return out, nil
+}
+
+// Decode locates the parity trailer within b and uses it to verify the data
+// that precedes it. Corrupted segments are repaired. The returned data doesn't
+// include the trailer and 'repaired' indicates whether any corruption was
+// fixed.
+func Decode(b []byte) (data []byte, repaired bool, err error) {
+	// Find the trailer. The data ends where the trailer begins.
+	n := bytes.Index(b, magic[:])
+	if n < 0 {
+		return nil, false, ErrNoParity
+	}
+	trailer := b[n+magicSize:]
+	if len(trailer) < numPages(n)*pageParitySize {
+		return nil, false, ErrUnrepairable
+	}
+
+	// Verify and repair the data page by page.
+	rs := newErasureCoder()
+	data = append([]byte(nil), b[:n]...)
+	for i := 0; i < numPages(n); i++ {
+		parity := trailer[i*pageParitySize : (i+1)*pageParitySize]
+		checksums := parity[:(dataSegments+paritySegments)*checksumSize]
+		paritySegs := parity[len(checksums):]
+
+		shards := make([][]byte, dataSegments+paritySegments)
+		p := page(data, i)
+		for j := 0; j < dataSegments; j++ {
+			shards[j] = p[j*segmentSize : (j+1)*segmentSize]
+		}
+		for j := 0; j < paritySegments; j++ {
+			shards[dataSegments+j] = append([]byte(nil), paritySegs[j*segmentSize:(j+1)*segmentSize]...)
+		}
+		// Drop all segments that don't match their checksum.
+		var corrupted int
+		for j := range shards {
+			if !bytes.Equal(segmentChecksum(shards[j]), checksums[j*checksumSize:(j+1)*checksumSize]) {
+				shards[j] = nil
+				corrupted++
+			}
+		}
+		if corrupted == 0 {
+			continue
+		}
+		if corrupted > paritySegments {
+			return nil, false, ErrUnrepairable
+		}
+		if err := rs.Reconstruct(shards); err != nil {
+			return nil, false, errors.Compose(err, ErrUnrepairable)
+		}
+		// Copy the repaired segments back into the data.
+		off := i * pageSize
+		for j := 0; j < dataSegments && off < n; j++ {
+			off += copy(data[off:n], shards[j])
+		}
+		repaired = true
+	}
+	return data, repaired, nil
+}
+
+// page returns a copy of the i-th page of data. The last page is padded with
+// zeros.
+func page(data []byte, i int) []byte {
+	p := make([]byte, pageSize)
+	start := i * pageSize
+	end := start + pageSize
+	if end > len(data) {
+		end = len(data)
+	}
+	copy(p, data[start:end])
+	return p
+}
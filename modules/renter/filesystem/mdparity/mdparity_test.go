@@ -0,0 +1,84 @@
+package mdparity
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestEncodeDecode tests encoding and decoding metadata without any
+// corruption.
+func TestEncodeDecode(t *testing.T) {
+	for _, size := range []int{0, 1, segmentSize, pageSize - 1, pageSize, 3*pageSize + 17} {
+		data := fastrand.Bytes(size)
+		encoded, err := Encode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(encoded) != size+TrailerSize(size) {
+			t.Fatalf("expected encoded length %v but was %v", size+TrailerSize(size), len(encoded))
+		}
+		// Append some garbage to make sure it's ignored.
+		decoded, repaired, err := Decode(append(encoded, fastrand.Bytes(100)...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repaired {
+			t.Fatal("uncorrupted data shouldn't be repaired")
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Fatal("decoded data doesn't match original data")
+		}
+	}
+}
+
+// TestDecodeRepair tests that corrupted segments are repaired.
+func TestDecodeRepair(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"key":"value"}`), 700)
+	encoded, err := Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a bit in every page and in the trailer.
+	corrupted := append([]byte(nil), encoded...)
+	for i := 0; i < numPages(len(data)); i++ {
+		off := i*pageSize + fastrand.Intn(pageSize)
+		if off >= len(data) {
+			off = len(data) - 1
+		}
+		corrupted[off] ^= 1 << uint(fastrand.Intn(8))
+	}
+	corrupted[len(corrupted)-1] ^= 1
+	decoded, repaired, err := Decode(corrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !repaired {
+		t.Fatal("corrupted data should be repaired")
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatal("repaired data doesn't match original data")
+	}
+
+	// Corrupt more segments of the first page than there are parity
+	// segments.
+	corrupted = append([]byte(nil), encoded...)
+	for i := 0; i <= paritySegments; i++ {
+		corrupted[i*segmentSize] ^= 1
+	}
+	_, _, err = Decode(corrupted)
+	if !errors.Contains(err, ErrUnrepairable) {
+		t.Fatal("expected ErrUnrepairable but got", err)
+	}
+}
+
+// TestDecodeNoParity tests decoding data without a parity trailer.
+func TestDecodeNoParity(t *testing.T) {
+	_, _, err := Decode([]byte(`{"key":"value"}`))
+	if !errors.Contains(err, ErrNoParity) {
+		t.Fatal("expected ErrNoParity but got", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/mdparity"
 )
 
 const (
@@ -85,18 +86,31 @@ func LoadSiaDir(path string, deps modules.Dependencies) (sd *SiaDir, err error)
 		deps: deps,
 		path: path,
 	}
-	sd.metadata, err = callLoadSiaDirMetadata(filepath.Join(path, modules.SiaDirExtension), modules.ProdDependencies)
+	var repaired bool
+	sd.metadata, repaired, err = callLoadSiaDirMetadata(filepath.Join(path, modules.SiaDirExtension), modules.ProdDependencies)
 	if errors.Contains(err, ErrInvalidChecksum) || errors.Contains(err, ErrCorruptFile) {
 		// If there was an error on load related to the checksum or a corrupt file,
 		// return a newly initialized metadata and try and fix the corruption by
 		// re-saving the metadata. This is OK because siadir persistence is not ACID
 		// and all metadata information can be recalculated.
 		sd.metadata = newMetadata()
+		sd.staticMetadataRepaired = true
+		err = sd.saveDir()
+	} else if err == nil && repaired {
+		// If the metadata was repaired using its parity, persist the repaired
+		// metadata.
+		sd.staticMetadataRepaired = true
 		err = sd.saveDir()
 	}
 	return sd, err
 }
 
+// MetadataRepaired returns whether the metadata of the SiaDir was corrupted on
+// disk and had to be repaired or reset when the SiaDir was loaded.
+func (sd *SiaDir) MetadataRepaired() bool {
+	return sd.staticMetadataRepaired
+}
+
 // Delete removes the directory from disk and marks it as deleted. Once the
 // directory is deleted, attempting to access the directory will return an
 // error.
@@ -250,12 +264,14 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	return sd.saveDir()
 }
 
-// callLoadSiaDirMetadata loads the directory metadata from disk.
-func callLoadSiaDirMetadata(path string, deps modules.Dependencies) (md Metadata, err error) {
+// callLoadSiaDirMetadata loads the directory metadata from disk. If the
+// metadata was persisted with parity, it is verified and repaired if
+// necessary, in which case 'repaired' is true.
+func callLoadSiaDirMetadata(path string, deps modules.Dependencies) (md Metadata, repaired bool, err error) {
 	// Open the file.
 	file, err := deps.Open(path)
 	if err != nil {
-		return Metadata{}, err
+		return Metadata{}, false, err
 	}
 	defer func() {
 		err = errors.Compose(err, file.Close())
@@ -264,26 +280,46 @@ func callLoadSiaDirMetadata(path string, deps modules.Dependencies) (md Metadata
 	// Read the file
 	fileBytes, err := ioutil.ReadAll(file)
 	if err != nil {
-		return Metadata{}, errors.AddContext(err, "unable to read bytes from file")
+		return Metadata{}, false, errors.AddContext(err, "unable to read bytes from file")
 	}
 
+	return UnmarshalMetadata(fileBytes)
+}
+
+// UnmarshalMetadata parses the persisted bytes of a .siadir file. If the
+// metadata was persisted with parity, it is verified and repaired if
+// necessary, in which case 'repaired' is true.
+func UnmarshalMetadata(fileBytes []byte) (md Metadata, repaired bool, err error) {
 	// Verify there is enough data for a checksum
 	if len(fileBytes) < crypto.HashSize {
-		return Metadata{}, ErrCorruptFile
+		return Metadata{}, false, ErrCorruptFile
 	}
 
-	// Verify checksum
+	// Verify and repair the metadata using its parity. Metadata persisted
+	// before parity was introduced only has the checksum.
 	checksum := fileBytes[:crypto.HashSize]
 	mdBytes := fileBytes[crypto.HashSize:]
+	data, repaired, err := mdparity.Decode(mdBytes)
+	hasParity := !errors.Contains(err, mdparity.ErrNoParity)
+	if hasParity && err != nil {
+		return Metadata{}, false, errors.Compose(err, ErrCorruptFile)
+	} else if hasParity {
+		mdBytes = data
+	}
+
+	// Verify checksum. If the metadata was verified using its parity, a
+	// mismatch means that the checksum itself is corrupted.
 	fileChecksum := crypto.HashBytes(mdBytes)
-	if !bytes.Equal(checksum, fileChecksum[:]) {
-		return Metadata{}, ErrInvalidChecksum
+	if !bytes.Equal(checksum, fileChecksum[:]) && !hasParity {
+		return Metadata{}, false, ErrInvalidChecksum
+	} else if !bytes.Equal(checksum, fileChecksum[:]) {
+		repaired = true
 	}
 
 	// Parse the json object.
 	err = json.Unmarshal(mdBytes, &md)
 	if err != nil {
-		return Metadata{}, errors.AddContext(err, "unable to unmarshal metadata")
+		return Metadata{}, false, errors.AddContext(err, "unable to unmarshal metadata")
 	}
 
 	// CompatV1420 check if filemode is set. If not use the default. It's fine
@@ -386,6 +422,12 @@ func saveDir(path string, md Metadata, deps modules.Dependencies) (err error) {
 	// Generate checksum
 	checksum := crypto.HashBytes(data)
 
+	// Append the parity
+	data, err = mdparity.Encode(data)
+	if err != nil {
+		return errors.AddContext(err, "unable to create metadata parity")
+	}
+
 	// Write the checksum to the file
 	_, err = f.WriteAt(checksum[:], 0)
 	if err != nil {
@@ -1,6 +1,7 @@
 package siadir
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -9,6 +10,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
 )
@@ -24,6 +26,7 @@ func TestPersist(t *testing.T) {
 
 	t.Run("CallLoadSiaDirMetadata", testCallLoadSiaDirMetadata)
 	t.Run("CreateDirMetadataAll", testCreateDirMetadataAll)
+	t.Run("MetadataParity", testMetadataParity)
 }
 
 // testCallLoadSiaDirMetadata probes the callLoadSiaDirMetadata function
@@ -34,7 +37,7 @@ func testCallLoadSiaDirMetadata(t *testing.T) {
 	}
 
 	// Calling load on an empty path should return IsNotExist
-	_, err = callLoadSiaDirMetadata("doesntexist", modules.ProdDependencies)
+	_, _, err = callLoadSiaDirMetadata("doesntexist", modules.ProdDependencies)
 	if !errors.IsOSNotExist(err) {
 		t.Error("unexpected error", err)
 	}
@@ -48,7 +51,7 @@ func testCallLoadSiaDirMetadata(t *testing.T) {
 	if err := f.Close(); err != nil {
 		t.Fatal(err)
 	}
-	_, err = callLoadSiaDirMetadata(emptyFile, modules.ProdDependencies)
+	_, _, err = callLoadSiaDirMetadata(emptyFile, modules.ProdDependencies)
 	if !errors.Contains(err, ErrCorruptFile) {
 		t.Error("unexpected error", err)
 	}
@@ -66,7 +69,7 @@ func testCallLoadSiaDirMetadata(t *testing.T) {
 	if err := f.Close(); err != nil {
 		t.Fatal(err)
 	}
-	_, err = callLoadSiaDirMetadata(corruptFile, modules.ProdDependencies)
+	_, _, err = callLoadSiaDirMetadata(corruptFile, modules.ProdDependencies)
 	if !errors.Contains(err, ErrInvalidChecksum) {
 		t.Error("unexpected error", err)
 	}
@@ -84,7 +87,7 @@ func testCallLoadSiaDirMetadata(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = callLoadSiaDirMetadata(happyFile, modules.ProdDependencies)
+	_, _, err = callLoadSiaDirMetadata(happyFile, modules.ProdDependencies)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -123,6 +126,52 @@ func testCreateDirMetadataAll(t *testing.T) {
 	}
 }
 
+// testMetadataParity probes repairing corrupted metadata using its parity.
+func testMetadataParity(t *testing.T) {
+	testDir, err := newSiaDirTestDir(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := newMetadata()
+	md.NumFiles = 42
+	err = saveDir(testDir, md, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a bit within the metadata.
+	path := filepath.Join(testDir, modules.SiaDirExtension)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[crypto.HashSize+10] ^= 1
+	if err := ioutil.WriteFile(path, b, modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading the dir should repair the metadata.
+	sd, err := LoadSiaDir(testDir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sd.MetadataRepaired() {
+		t.Fatal("metadata should have been repaired")
+	}
+	if sd.metadata.NumFiles != md.NumFiles {
+		t.Fatalf("expected NumFiles %v but got %v", md.NumFiles, sd.metadata.NumFiles)
+	}
+
+	// The repaired metadata should have been persisted.
+	_, repaired, err := callLoadSiaDirMetadata(path, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired {
+		t.Fatal("repaired metadata wasn't persisted")
+	}
+}
+
 // testNewMetadata probes the newMetadata function
 func testNewMetadata(t *testing.T) {
 	md := Metadata{
@@ -17,6 +17,10 @@ type (
 		// path is the path of the SiaDir folder.
 		path string
 
+		// staticMetadataRepaired indicates that the metadata was corrupted on
+		// disk when the SiaDir was loaded and has been repaired.
+		staticMetadataRepaired bool
+
 		// Utility fields
 		deleted bool
 		deps    modules.Dependencies
@@ -30,6 +30,11 @@ const (
 	// byte pieceIndex, a 4 byte table offset and a hash.
 	marshaledPieceSize = 4 + 4 + crypto.HashSize
 
+	// pieceUnverifiedFlag is set in the marshaled pieceIndex of unverified
+	// pieces. Since erasure codes can't have more than 256 pieces, the high bit
+	// of the pieceIndex is never used by the index itself.
+	pieceUnverifiedFlag = 1 << 31
+
 	// marshaledChunkOverhead is the size of a marshaled chunk on disk minus the
 	// encoded pieces. It consists of the 16 byte extension info, a 2 byte
 	// length prefix for the pieces, and a 1 byte length for the Stuck field.
@@ -10,6 +10,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/mdparity"
 )
 
 // marshalChunk binary encodes a chunk. It only allocates memory a single time
@@ -59,6 +60,17 @@ func marshalMetadata(md Metadata) ([]byte, error) {
 	return json.Marshal(md)
 }
 
+// marshalMetadataWithParity marshals the metadata of the SiaFile and appends
+// the parity which is used to repair the metadata if it gets corrupted on
+// disk.
+func marshalMetadataWithParity(md Metadata) ([]byte, error) {
+	raw, err := marshalMetadata(md)
+	if err != nil {
+		return nil, err
+	}
+	return mdparity.Encode(raw)
+}
+
 // marshalPubKeyTable marshals the public key table of the SiaFile using Sia
 // encoding.
 func marshalPubKeyTable(pubKeyTable []HostPublicKey) ([]byte, error) {
@@ -88,6 +100,9 @@ func numChunkPagesRequired(numPieces int) uint8 {
 // piece into out. That way when marshaling a chunk, the whole chunk's memory
 // can be allocated with a single allocation.
 func putPiece(out []byte, pieceIndex uint32, piece piece) {
+	if piece.Unverified {
+		pieceIndex |= pieceUnverifiedFlag
+	}
 	binary.LittleEndian.PutUint32(out[:4], pieceIndex)
 	binary.LittleEndian.PutUint32(out[4:8], piece.HostTableOffset)
 	copy(out[8:], piece.MerkleRoot[:])
@@ -173,6 +188,8 @@ func unmarshalPiece(raw []byte) (pieceIndex uint32, piece piece, err error) {
 		return
 	}
 	pieceIndex = binary.LittleEndian.Uint32(raw[:4])
+	piece.Unverified = pieceIndex&pieceUnverifiedFlag != 0
+	pieceIndex &^= pieceUnverifiedFlag
 	piece.HostTableOffset = binary.LittleEndian.Uint32(raw[4:8])
 	copy(piece.MerkleRoot[:], raw[8:])
 	return
@@ -49,6 +49,12 @@ type (
 		StaticPieceSize     uint64   `json:"piecesize"`     // size of a single piece of the file
 		LocalPath           string   `json:"localpath"`     // file to the local copy of the file used for repairing
 
+		// Fields for the checksum of the file's plaintext data. The checksum is
+		// computed while the file is uploaded from a stream and is empty for
+		// files uploaded otherwise.
+		ChecksumAlgorithm modules.ChecksumAlgorithm `json:"checksumalgorithm"`
+		Checksum          []byte                    `json:"checksum"`
+
 		// Fields for encryption
 		StaticMasterKey      []byte            `json:"masterkey"` // masterkey used to encrypt pieces
 		StaticMasterKeyType  crypto.CipherType `json:"masterkeytype"`
@@ -95,16 +101,22 @@ type (
 		//
 		// CachedUploadProgress is the upload progress of the file and is updated
 		// every time a piece is added to the siafile.
-		CachedRedundancy     float64           `json:"cachedredundancy"`
-		CachedRepairBytes    uint64            `json:"cachedrepairbytes"`
-		CachedUserRedundancy float64           `json:"cacheduserredundancy"`
-		CachedHealth         float64           `json:"cachedhealth"`
-		CachedNumStuckChunks uint64            `json:"cachednumstuckchunks"`
-		CachedStuckBytes     uint64            `json:"cachedstuckbytes"`
-		CachedStuckHealth    float64           `json:"cachedstuckhealth"`
-		CachedExpiration     types.BlockHeight `json:"cachedexpiration"`
-		CachedUploadedBytes  uint64            `json:"cacheduploadedbytes"`
-		CachedUploadProgress float64           `json:"cacheduploadprogress"`
+		//
+		// CachedRepairThreshold is the adaptive repair threshold of the file
+		// based on the failure rates of the hosts storing its pieces. It is
+		// updated by the health check loop whenever 'UpdateRepairThreshold' is
+		// called. A value of 0 means that it hasn't been computed yet.
+		CachedRedundancy      float64           `json:"cachedredundancy"`
+		CachedRepairBytes     uint64            `json:"cachedrepairbytes"`
+		CachedUserRedundancy  float64           `json:"cacheduserredundancy"`
+		CachedHealth          float64           `json:"cachedhealth"`
+		CachedNumStuckChunks  uint64            `json:"cachednumstuckchunks"`
+		CachedStuckBytes      uint64            `json:"cachedstuckbytes"`
+		CachedStuckHealth     float64           `json:"cachedstuckhealth"`
+		CachedExpiration      types.BlockHeight `json:"cachedexpiration"`
+		CachedUploadedBytes   uint64            `json:"cacheduploadedbytes"`
+		CachedUploadProgress  float64           `json:"cacheduploadprogress"`
+		CachedRepairThreshold float64           `json:"cachedrepairthreshold"`
 
 		// Repair loop fields
 		//
@@ -210,6 +222,14 @@ func (sf *SiaFile) CreateTime() time.Time {
 	return sf.staticMetadata.CreateTime
 }
 
+// Checksum returns the checksum of the file's plaintext data and the algorithm
+// used to compute it. The algorithm is empty if the file has no checksum.
+func (sf *SiaFile) Checksum() (modules.ChecksumAlgorithm, []byte) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.ChecksumAlgorithm, append([]byte(nil), sf.staticMetadata.Checksum...)
+}
+
 // ChunkSize returns the size of a single chunk of the file.
 func (sf *SiaFile) ChunkSize() uint64 {
 	return sf.staticChunkSize()
@@ -279,6 +299,17 @@ func (sf *SiaFile) PieceSize() uint64 {
 	return sf.staticMetadata.StaticPieceSize
 }
 
+// RepairThreshold returns the cached adaptive repair threshold of the file. If
+// it hasn't been computed yet, RepairThreshold is returned.
+func (sf *SiaFile) RepairThreshold() float64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.staticMetadata.CachedRepairThreshold <= 0 {
+		return modules.RepairThreshold
+	}
+	return sf.staticMetadata.CachedRepairThreshold
+}
+
 // Rename changes the name of the file to a new one. To guarantee that renaming
 // the file is atomic across all operating systems, we create a wal transaction
 // that moves over all the chunks one-by-one and deletes the src file.
@@ -309,6 +340,10 @@ func (md Metadata) backup() (b Metadata) {
 	b.UniqueID = md.UniqueID
 	b.FileSize = md.FileSize
 	b.LocalPath = md.LocalPath
+	b.ChecksumAlgorithm = md.ChecksumAlgorithm
+	if md.Checksum != nil {
+		b.Checksum = append([]byte{}, md.Checksum...)
+	}
 	b.DisablePartialChunk = md.DisablePartialChunk
 	b.HasPartialChunk = md.HasPartialChunk
 	b.ModTime = md.ModTime
@@ -325,6 +360,7 @@ func (md Metadata) backup() (b Metadata) {
 	b.CachedExpiration = md.CachedExpiration
 	b.CachedUploadedBytes = md.CachedUploadedBytes
 	b.CachedUploadProgress = md.CachedUploadProgress
+	b.CachedRepairThreshold = md.CachedRepairThreshold
 	b.Health = md.Health
 	b.LastHealthCheckTime = md.LastHealthCheckTime
 	b.NumStuckChunks = md.NumStuckChunks
@@ -360,6 +396,8 @@ func (md *Metadata) restore(b Metadata) {
 	md.UniqueID = b.UniqueID
 	md.FileSize = b.FileSize
 	md.LocalPath = b.LocalPath
+	md.ChecksumAlgorithm = b.ChecksumAlgorithm
+	md.Checksum = b.Checksum
 	md.DisablePartialChunk = b.DisablePartialChunk
 	md.PartialChunks = b.PartialChunks
 	md.HasPartialChunk = b.HasPartialChunk
@@ -377,6 +415,7 @@ func (md *Metadata) restore(b Metadata) {
 	md.CachedExpiration = b.CachedExpiration
 	md.CachedUploadedBytes = b.CachedUploadedBytes
 	md.CachedUploadProgress = b.CachedUploadProgress
+	md.CachedRepairThreshold = b.CachedRepairThreshold
 	md.Health = b.Health
 	md.LastHealthCheckTime = b.LastHealthCheckTime
 	md.NumStuckChunks = b.NumStuckChunks
@@ -530,6 +569,29 @@ func (sf *SiaFile) SetLocalPath(path string) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetChecksum sets the checksum of the file's plaintext data.
+func (sf *SiaFile) SetChecksum(algorithm modules.ChecksumAlgorithm, checksum []byte) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.ChecksumAlgorithm = algorithm
+	sf.staticMetadata.Checksum = append([]byte(nil), checksum...)
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // Size returns the file's size.
 func (sf *SiaFile) Size() uint64 {
 	sf.mu.RLock()
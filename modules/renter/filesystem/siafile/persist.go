@@ -17,6 +17,7 @@ import (
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/mdparity"
 )
 
 var (
@@ -231,7 +232,20 @@ func loadSiaFile(path string, wal *writeaheadlog.WAL, deps modules.Dependencies)
 		return nil, err
 	}
 	sf, err := loadSiaFileFromReader(f, path, wal, deps)
-	return sf, errors.Compose(err, f.Close())
+	if err := errors.Compose(err, f.Close()); err != nil {
+		return nil, err
+	}
+	// If the metadata was repaired, persist the repaired header.
+	if sf.staticMetadataRepaired && wal != nil {
+		updates, err := sf.saveHeaderUpdates()
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to create updates for repaired header")
+		}
+		if err := sf.createAndApplyTransaction(updates...); err != nil {
+			return nil, errors.AddContext(err, "failed to save repaired header")
+		}
+	}
+	return sf, nil
 }
 
 // loadSiaFileFromReader allows loading a SiaFile from a different location that
@@ -245,10 +259,10 @@ func loadSiaFileFromReader(r io.ReadSeeker, path string, wal *writeaheadlog.WAL,
 		wal:         wal,
 	}
 	// Load the metadata.
-	decoder := json.NewDecoder(r)
-	err := decoder.Decode(&sf.staticMetadata)
+	var err error
+	sf.staticMetadata, sf.staticMetadataRepaired, err = readMetadata(r)
 	if err != nil {
-		return nil, errors.AddContext(err, "failed to decode metadata")
+		return nil, err
 	}
 	// COMPATv137 legacy files might not have a unique id.
 	if sf.staticMetadata.UniqueID == "" {
@@ -323,8 +337,8 @@ func loadSiaFileMetadata(path string, deps modules.Dependencies) (md Metadata, e
 		err = errors.Compose(err, f.Close())
 	}()
 	// Load the metadata.
-	decoder := json.NewDecoder(f)
-	if err = decoder.Decode(&md); err != nil {
+	md, _, err = readMetadata(f)
+	if err != nil {
 		return
 	}
 	// Create the erasure coder.
@@ -335,6 +349,70 @@ func loadSiaFileMetadata(path string, deps modules.Dependencies) (md Metadata, e
 	return
 }
 
+// readMetadata reads the metadata of a SiaFile from r. Metadata that was
+// persisted with parity is verified and repaired if necessary, in which case
+// 'repaired' is true.
+func readMetadata(r io.ReadSeeker) (md Metadata, repaired bool, err error) {
+	// Decode the metadata.
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Metadata{}, false, err
+	}
+	decodeErr := json.NewDecoder(r).Decode(&md)
+
+	// Read the header. If the metadata was decoded, the parity ends before
+	// the pubKeyTable. Otherwise we need to search the whole file for it.
+	var headerLen int64
+	if decodeErr == nil {
+		headerLen = md.PubKeyTableOffset
+	}
+	header, err := readMetadataHeader(r, headerLen)
+	if err != nil {
+		return Metadata{}, false, err
+	}
+	data, repaired, err := mdparity.Decode(header)
+	if errors.Contains(err, mdparity.ErrUnrepairable) && headerLen > 0 {
+		// The decoded PubKeyTableOffset might be corrupted, try again with
+		// the whole file.
+		header, err = readMetadataHeader(r, 0)
+		if err != nil {
+			return Metadata{}, false, err
+		}
+		data, repaired, err = mdparity.Decode(header)
+	}
+	if errors.Contains(err, mdparity.ErrNoParity) {
+		// The metadata was persisted before parity was introduced.
+		return md, false, errors.AddContext(decodeErr, "failed to decode metadata")
+	} else if err != nil {
+		return Metadata{}, false, errors.Compose(err, ErrCorruptMetadata)
+	}
+	if !repaired && decodeErr == nil {
+		return md, false, nil
+	}
+	// Decode the verified metadata.
+	md = Metadata{}
+	if err := json.Unmarshal(data, &md); err != nil {
+		return Metadata{}, false, errors.Compose(err, ErrCorruptMetadata)
+	}
+	return md, repaired, nil
+}
+
+// readMetadataHeader reads the first n bytes of r. If n is 0, r is read until
+// EOF.
+func readMetadataHeader(r io.ReadSeeker, n int64) ([]byte, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	header := make([]byte, n)
+	read, err := io.ReadFull(r, header)
+	if err != nil && !errors.Contains(err, io.ErrUnexpectedEOF) {
+		return nil, errors.AddContext(err, "failed to read metadata header")
+	}
+	return header[:read], nil
+}
+
 // readAndApplyDeleteUpdate reads the delete update and applies it. This helper
 // assumes that the file is not open
 func readAndApplyDeleteUpdate(deps modules.Dependencies, update writeaheadlog.Update) error {
@@ -815,7 +893,7 @@ func (sf *SiaFile) saveHeaderUpdates() (_ []writeaheadlog.Update, err error) {
 	sf.staticMetadata.PubKeyTableOffset = sf.staticMetadata.ChunkOffset - int64(len(pubKeyTable))
 
 	// Marshal the metadata.
-	metadata, err := marshalMetadata(sf.staticMetadata)
+	metadata, err := marshalMetadataWithParity(sf.staticMetadata)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to marshal metadata")
 	}
@@ -833,7 +911,7 @@ func (sf *SiaFile) saveHeaderUpdates() (_ []writeaheadlog.Update, err error) {
 		// Update the PubKeyTableOffset.
 		sf.staticMetadata.PubKeyTableOffset = sf.staticMetadata.ChunkOffset - int64(len(pubKeyTable))
 		// Marshal the metadata again.
-		metadata, err = marshalMetadata(sf.staticMetadata)
+		metadata, err = marshalMetadataWithParity(sf.staticMetadata)
 		if err != nil {
 			return nil, errors.AddContext(err, "failed to marshal metadata again")
 		}
@@ -867,7 +945,7 @@ func (sf *SiaFile) saveMetadataUpdates() ([]writeaheadlog.Update, error) {
 		return sf.saveHeaderUpdates()
 	}
 	// Marshal the metadata.
-	metadata, err := marshalMetadata(sf.staticMetadata)
+	metadata, err := marshalMetadataWithParity(sf.staticMetadata)
 	if err != nil {
 		return nil, err
 	}
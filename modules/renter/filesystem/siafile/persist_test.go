@@ -890,6 +890,74 @@ func TestUniqueIDMissing(t *testing.T) {
 	}
 }
 
+// TestMetadataParity makes sure that a corrupted metadata is repaired when
+// the SiaFile is loaded and that metadata which is corrupted beyond repair is
+// detected.
+func TestMetadataParity(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a new file.
+	sf, wal, _ := newBlankTestFileAndWAL(1)
+	if err := sf.SaveHeader(); err != nil {
+		t.Fatal(err)
+	}
+	md, err := marshalMetadata(sf.staticMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// corrupt flips a bit at the given offsets of the file on disk.
+	corrupt := func(offsets ...int64) {
+		f, err := os.OpenFile(sf.siaFilePath, os.O_RDWR, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer closeFileInTest(t, f)
+		for _, off := range offsets {
+			b := make([]byte, 1)
+			if _, err := f.ReadAt(b, off); err != nil {
+				t.Fatal(err)
+			}
+			b[0] ^= 1
+			if _, err := f.WriteAt(b, off); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Flip a bit in the middle of the metadata. The file should be repaired
+	// on load.
+	corrupt(int64(len(md) / 2))
+	sf2, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf2.MetadataRepaired() {
+		t.Fatal("metadata should have been repaired")
+	}
+	if err := equalFiles(sf, sf2); err != nil {
+		t.Fatal(err)
+	}
+	// The repaired metadata should have been persisted.
+	sf2, err = LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf2.MetadataRepaired() {
+		t.Fatal("repaired metadata wasn't persisted")
+	}
+
+	// Corrupt too many segments of the same page. Loading should fail.
+	corrupt(0, 256, 512)
+	_, err = LoadSiaFile(sf.siaFilePath, wal)
+	if !errors.Contains(err, ErrCorruptMetadata) {
+		t.Fatal("expected ErrCorruptMetadata but got", err)
+	}
+}
+
 // TestSetCombinedChunkSingle tests SetCombinedChunk for a partial chunk with a
 // single combined chunk.
 func TestSetCombinedChunkSingle(t *testing.T) {
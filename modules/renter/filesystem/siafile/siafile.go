@@ -1,6 +1,7 @@
 package siafile
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
 	"math"
@@ -29,6 +30,12 @@ var (
 	// ErrDeleted is returned when an operation failed due to the siafile being
 	// deleted already.
 	ErrDeleted = errors.New("files was deleted")
+	// ErrUnknownPiece is returned by MarkPieceVerified if the file doesn't
+	// contain the unverified piece.
+	ErrUnknownPiece = errors.New("no unverified piece known with that root")
+	// ErrCorruptMetadata is returned when a SiaFile's metadata is corrupted
+	// and can't be repaired using its parity.
+	ErrCorruptMetadata = errors.New("siafile metadata is corrupted beyond repair")
 )
 
 type (
@@ -52,6 +59,10 @@ type (
 		// potential partial chunk at the end.
 		numChunks int
 
+		// staticMetadataRepaired indicates that the metadata was corrupted on
+		// disk and repaired using its parity when the file was loaded.
+		staticMetadataRepaired bool
+
 		// utility fields. These are not persisted.
 		deleted bool
 		deps    modules.Dependencies
@@ -77,7 +88,8 @@ type (
 	// chunk represents a single chunk of a file on disk
 	chunk struct {
 		// ExtensionInfo is some reserved space for each chunk that allows us
-		// to indicate if a chunk is special.
+		// to indicate if a chunk is special. The first 8 bytes contain the
+		// unix timestamp of the chunk's last repair.
 		ExtensionInfo [16]byte
 
 		// Index is the index of the chunk.
@@ -100,6 +112,7 @@ type (
 	piece struct {
 		HostTableOffset uint32      // offset of the host's key within the pubKeyTable
 		MerkleRoot      crypto.Hash // merkle root of the piece
+		Unverified      bool        // the host didn't prove yet that it stores the piece
 	}
 
 	// Piece is an exported piece. It contains a resolved public key instead of
@@ -107,6 +120,7 @@ type (
 	Piece struct {
 		HostPubKey types.SiaPublicKey // public key of the host
 		MerkleRoot crypto.Hash        // merkle root of the piece
+		Unverified bool               // the host didn't prove yet that it stores the piece
 	}
 
 	// HostPublicKey is an entry in the HostPubKey table.
@@ -140,6 +154,12 @@ func (hpk HostPublicKey) MarshalSia(w io.Writer) error {
 	return e.Err()
 }
 
+// MetadataRepaired returns whether the metadata of the SiaFile was corrupted
+// on disk and repaired when the file was loaded.
+func (sf *SiaFile) MetadataRepaired() bool {
+	return sf.staticMetadataRepaired
+}
+
 // SiaFilePath returns the siaFilePath field of the SiaFile.
 func (sf *SiaFile) SiaFilePath() string {
 	sf.mu.RLock()
@@ -187,6 +207,22 @@ func (c *chunk) numPieces() (numPieces int) {
 	return
 }
 
+// lastRepair returns the time at which a piece was last added to the chunk.
+// The zero time is returned for chunks which were last repaired before the
+// time was recorded.
+func (c *chunk) lastRepair() time.Time {
+	unix := int64(binary.LittleEndian.Uint64(c.ExtensionInfo[:8]))
+	if unix == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
+
+// setLastRepair sets the time at which a piece was last added to the chunk.
+func (c *chunk) setLastRepair(t time.Time) {
+	binary.LittleEndian.PutUint64(c.ExtensionInfo[:8], uint64(t.Unix()))
+}
+
 // New create a new SiaFile.
 func New(siaFilePath, source string, wal *writeaheadlog.WAL, erasureCode modules.ErasureCoder, masterKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode, partialsSiaFile *SiaFile, disablePartialUpload bool) (*SiaFile, error) {
 	// TODO remove this
@@ -344,6 +380,55 @@ func (sf *SiaFile) SetFileSize(fileSize uint64) (err error) {
 func (sf *SiaFile) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash) (err error) {
 	sf.mu.Lock()
 	defer sf.mu.Unlock()
+	return sf.addPiece(pk, chunkIndex, pieceIndex, merkleRoot, false)
+}
+
+// AddUnverifiedPiece adds an uploaded piece to the file which doesn't count
+// towards the redundancy of the file until MarkPieceVerified is called for it.
+func (sf *SiaFile) AddUnverifiedPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	return sf.addPiece(pk, chunkIndex, pieceIndex, merkleRoot, true)
+}
+
+// MarkPieceVerified marks a piece which was added using AddUnverifiedPiece as
+// verified.
+func (sf *SiaFile) MarkPieceVerified(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't verify piece of deleted file")
+	}
+	if cci, ok := sf.isIncludedPartialChunk(chunkIndex); ok {
+		return sf.partialsSiaFile.MarkPieceVerified(pk, cci.Index, pieceIndex, merkleRoot)
+	}
+	if chunkIndex >= uint64(sf.numChunks) {
+		return fmt.Errorf("chunkIndex %v out of bounds (%v)", chunkIndex, sf.numChunks)
+	}
+	chunk, err := sf.chunk(int(chunkIndex))
+	if err != nil {
+		return errors.AddContext(err, "failed to get chunk")
+	}
+	if pieceIndex >= uint64(len(chunk.Pieces)) {
+		return fmt.Errorf("pieceIndex %v out of bounds (%v)", pieceIndex, len(chunk.Pieces))
+	}
+	found := false
+	for i, piece := range chunk.Pieces[pieceIndex] {
+		if piece.Unverified && piece.MerkleRoot == merkleRoot && sf.hostKey(piece.HostTableOffset).PublicKey.Equals(pk) {
+			chunk.Pieces[pieceIndex][i].Unverified = false
+			found = true
+		}
+	}
+	if !found {
+		return ErrUnknownPiece
+	}
+	defer sf.uploadProgressAndBytes()
+	return sf.createAndApplyTransaction(sf.saveChunkUpdate(chunk))
+}
+
+// addPiece adds an uploaded piece to the file. It also updates the host table
+// if the public key of the host is not already known.
+func (sf *SiaFile) addPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash, unverified bool) (err error) {
 	// If the file was deleted we can't add a new piece since it would write
 	// the file to disk again.
 	if sf.deleted {
@@ -369,6 +454,9 @@ func (sf *SiaFile) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64
 
 	// Handle piece being added to the partial chunk.
 	if cci, ok := sf.isIncludedPartialChunk(chunkIndex); ok {
+		if unverified {
+			return sf.partialsSiaFile.AddUnverifiedPiece(pk, cci.Index, pieceIndex, merkleRoot)
+		}
 		return sf.partialsSiaFile.AddPiece(pk, cci.Index, pieceIndex, merkleRoot)
 	}
 
@@ -407,12 +495,14 @@ func (sf *SiaFile) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64
 	chunk.Pieces[pieceIndex] = append(chunk.Pieces[pieceIndex], piece{
 		HostTableOffset: uint32(tableIndex),
 		MerkleRoot:      merkleRoot,
+		Unverified:      unverified,
 	})
 
 	// Update the AccessTime, ChangeTime and ModTime.
 	sf.staticMetadata.AccessTime = time.Now()
 	sf.staticMetadata.ChangeTime = sf.staticMetadata.AccessTime
 	sf.staticMetadata.ModTime = sf.staticMetadata.AccessTime
+	chunk.setLastRepair(sf.staticMetadata.ModTime)
 
 	// Defrag the chunk if necessary.
 	chunkSize := marshaledChunkSize(chunk.numPieces())
@@ -636,6 +726,44 @@ func (sf *SiaFile) Expiration(contracts map[string]modules.RenterContract) types
 	return lowest
 }
 
+// UpdateRepairThreshold updates CachedRepairThreshold with the adaptive repair
+// threshold of the file and returns the new value. The threshold is based on
+// the average failure rate of the hosts storing the file's pieces, weighted by
+// the number of pieces they store. Hosts without a known failure rate are
+// ignored. If none of the hosts are known, RepairThreshold is used.
+func (sf *SiaFile) UpdateRepairThreshold(failureRates map[string]float64) float64 {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	var totalRate float64
+	var numPieces int
+	if !sf.deleted {
+		err := sf.iterateChunksReadonly(func(c chunk) error {
+			for _, pieceSet := range c.Pieces {
+				for _, piece := range pieceSet {
+					rate, exists := failureRates[sf.hostKey(piece.HostTableOffset).PublicKey.String()]
+					if !exists {
+						continue
+					}
+					totalRate += rate
+					numPieces++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			numPieces = 0
+		}
+	}
+
+	threshold := modules.RepairThreshold
+	if numPieces > 0 {
+		threshold = modules.AdaptiveRepairThreshold(totalRate / float64(numPieces))
+	}
+	sf.staticMetadata.CachedRepairThreshold = threshold
+	return threshold
+}
+
 // Health calculates the health of the file to be used in determining repair
 // priority. Health of the file is the lowest health of any of the chunks and is
 // defined as the percent of parity pieces remaining. The NumStuckChunks will be
@@ -714,8 +842,8 @@ func (sf *SiaFile) Health(offline map[string]bool, goodForRenew map[string]bool)
 		}
 
 		// If the chunk is not stuck then we only count the remaining repair bytes
-		// if the chunk needs repair.
-		if modules.NeedsRepair(chunkHealth) {
+		// if the chunk needs repair at the file's repair threshold.
+		if modules.NeedsAdaptiveRepair(chunkHealth, sf.staticMetadata.CachedRepairThreshold) {
 			repairBytesRemaing += chunkRepairBytesRemaining
 		}
 
@@ -827,6 +955,7 @@ func (sf *SiaFile) Pieces(chunkIndex uint64) ([][]Piece, error) {
 			pieces[pieceIndex][i] = Piece{
 				HostPubKey: sf.hostKey(piece.HostTableOffset).PublicKey,
 				MerkleRoot: piece.MerkleRoot,
+				Unverified: piece.Unverified,
 			}
 		}
 	}
@@ -1036,6 +1165,21 @@ func (sf *SiaFile) StuckChunkByIndex(index uint64) (bool, error) {
 	return chunk.Stuck, nil
 }
 
+// ChunkLastRepair returns the time at which a piece was last added to the
+// chunk with the provided index, either by the initial upload or by a repair.
+func (sf *SiaFile) ChunkLastRepair(chunkIndex uint64) (time.Time, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if chunkIndex >= uint64(sf.numChunks) {
+		return time.Time{}, fmt.Errorf("index %v out of bounds (%v)", chunkIndex, sf.numChunks)
+	}
+	chunk, err := sf.chunk(int(chunkIndex))
+	if err != nil {
+		return time.Time{}, errors.AddContext(err, "failed to read chunk")
+	}
+	return chunk.lastRepair(), nil
+}
+
 // UID returns a unique identifier for this file.
 func (sf *SiaFile) UID() SiafileUID {
 	sf.mu.RLock()
@@ -1265,6 +1409,10 @@ func (sf *SiaFile) goodPieces(chunk chunk, offlineMap map[string]bool, goodForRe
 		foundGoodForRenew := false
 		foundOnline := false
 		for _, piece := range pieceSet {
+			// Unverified pieces don't count towards the redundancy.
+			if piece.Unverified {
+				continue
+			}
 			offline, exists1 := offlineMap[sf.hostKey(piece.HostTableOffset).PublicKey.String()]
 			goodForRenew, exists2 := goodForRenewMap[sf.hostKey(piece.HostTableOffset).PublicKey.String()]
 			if exists1 != exists2 {
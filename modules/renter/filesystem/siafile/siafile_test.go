@@ -1281,6 +1281,57 @@ func TestFileExpiration(t *testing.T) {
 	}
 }
 
+// TestFileRepairThreshold checks that the repair threshold of a file is based
+// on the failure rates of the hosts storing its pieces.
+func TestFileRepairThreshold(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(1, false)
+	f, _, _ := customTestFileAndWAL(siaFilePath, source, rc, sk, fileSize, numChunks, fileMode)
+	failureRates := make(map[string]float64)
+	if threshold := f.UpdateRepairThreshold(failureRates); threshold != modules.RepairThreshold {
+		t.Fatal("file with no pieces should use the default threshold", threshold)
+	}
+
+	// Add a piece for each of 3 hosts.
+	pk1 := types.SiaPublicKey{Key: []byte{0}}
+	pk2 := types.SiaPublicKey{Key: []byte{1}}
+	pk3 := types.SiaPublicKey{Key: []byte{2}}
+	err1 := f.AddPiece(pk1, 0, 0, crypto.Hash{})
+	err2 := f.AddPiece(pk2, 0, 1, crypto.Hash{})
+	err3 := f.AddPiece(pk3, 0, 2, crypto.Hash{})
+	if err := errors.Compose(err1, err2, err3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only pk1 has a known failure rate. pk2 and pk3 are unknown and ignored,
+	// so the file is rated as if all its pieces were on the unreliable host.
+	failureRates[pk1.String()] = 1
+	if threshold := f.UpdateRepairThreshold(failureRates); threshold != modules.MinRepairThreshold {
+		t.Fatal("file on unreliable host should be repaired early", threshold)
+	}
+	if f.RepairThreshold() != modules.MinRepairThreshold {
+		t.Fatal("threshold wasn't cached", f.RepairThreshold())
+	}
+
+	// The failure rates are averaged across the pieces.
+	failureRates[pk1.String()] = 0
+	failureRates[pk2.String()] = 0
+	failureRates[pk3.String()] = 0
+	if threshold := f.UpdateRepairThreshold(failureRates); threshold != modules.MaxRepairThreshold {
+		t.Fatal("file on reliable hosts should be repaired late", threshold)
+	}
+	failureRates[pk3.String()] = 0.3
+	expected := modules.AdaptiveRepairThreshold(0.1)
+	if threshold := f.UpdateRepairThreshold(failureRates); math.Abs(threshold-expected) > 1e-9 {
+		t.Fatal("unexpected threshold", threshold, expected)
+	}
+	if err := ensureMetadataValid(f.Metadata()); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // BenchmarkLoadSiaFile benchmarks loading an existing siafile's metadata into
 // memory.
 func BenchmarkLoadSiaFile(b *testing.B) {
@@ -1509,3 +1560,110 @@ func TestCalculateHealth(t *testing.T) {
 	}()
 	checkHealth(0, 0, 0, 0)
 }
+
+// TestUnverifiedPieces checks that unverified pieces don't count towards the
+// redundancy of a chunk until they are marked as verified and that their state
+// is persisted.
+func TestUnverifiedPieces(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf, wal, _ := newBlankTestFileAndWAL(2)
+	pk := types.SiaPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+	offlineMap := map[string]bool{pk.String(): false}
+	goodForRenewMap := map[string]bool{pk.String(): true}
+	var root crypto.Hash
+	fastrand.Read(root[:])
+
+	// checkPiece checks the state of the piece after reloading the file.
+	checkPiece := func(unverified bool, goodPieces uint64) {
+		t.Helper()
+		sf2, err := LoadSiaFile(sf.siaFilePath, wal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pieces, err := sf2.Pieces(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pieces[0]) != 1 || pieces[0][0].Unverified != unverified || pieces[0][0].MerkleRoot != root {
+			t.Fatal("unexpected pieces", pieces[0])
+		}
+		chunk, err := sf2.chunk(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := sf2.goodPieces(chunk, offlineMap, goodForRenewMap); n != goodPieces {
+			t.Fatalf("expected %v good pieces but got %v", goodPieces, n)
+		}
+	}
+
+	// Add an unverified piece.
+	if err := sf.AddUnverifiedPiece(pk, 0, 0, root); err != nil {
+		t.Fatal(err)
+	}
+	checkPiece(true, 0)
+
+	// Only the uploaded piece can be marked as verified.
+	if err := sf.MarkPieceVerified(pk, 0, 0, crypto.Hash{}); !errors.Contains(err, ErrUnknownPiece) {
+		t.Fatal("expected ErrUnknownPiece but got", err)
+	}
+	if err := sf.MarkPieceVerified(pk, 0, 1, root); !errors.Contains(err, ErrUnknownPiece) {
+		t.Fatal("expected ErrUnknownPiece but got", err)
+	}
+	if err := sf.MarkPieceVerified(pk, 0, 0, root); err != nil {
+		t.Fatal(err)
+	}
+	checkPiece(false, 1)
+
+	// A verified piece can't be verified again.
+	if err := sf.MarkPieceVerified(pk, 0, 0, root); !errors.Contains(err, ErrUnknownPiece) {
+		t.Fatal("expected ErrUnknownPiece but got", err)
+	}
+}
+
+// TestChunkLastRepair checks that adding a piece to a chunk updates the
+// chunk's last repair time and that the time is persisted.
+func TestChunkLastRepair(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf, wal, _ := newBlankTestFileAndWAL(2)
+	pk := types.SiaPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+
+	// checkLastRepair checks the last repair time of the chunk after reloading
+	// the file.
+	checkLastRepair := func(chunkIndex uint64, expected time.Time) {
+		t.Helper()
+		sf2, err := LoadSiaFile(sf.siaFilePath, wal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lastRepair, err := sf2.ChunkLastRepair(chunkIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !lastRepair.Equal(expected) {
+			t.Fatalf("expected last repair %v but got %v", expected, lastRepair)
+		}
+	}
+
+	// A chunk without pieces was never repaired.
+	checkLastRepair(0, time.Time{})
+
+	// Add a piece to the first chunk.
+	if err := sf.AddPiece(pk, 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	checkLastRepair(0, time.Unix(sf.ModTime().Unix(), 0))
+	checkLastRepair(1, time.Time{})
+
+	// Out of bounds indices return an error.
+	if _, err := sf.ChunkLastRepair(sf.NumChunks()); err == nil {
+		t.Fatal("expected error for out of bounds index")
+	}
+}
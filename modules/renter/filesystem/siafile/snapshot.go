@@ -266,6 +266,7 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 				pieces[pieceIndex][i] = Piece{
 					HostPubKey: sf.hostKey(piece.HostTableOffset).PublicKey,
 					MerkleRoot: piece.MerkleRoot,
+					Unverified: piece.Unverified,
 				}
 			}
 		}
@@ -0,0 +1,367 @@
+package renter
+
+// fsck.go contains the consistency check of the renter's filesystem. The check
+// walks the filesystem on disk and compares the aggregates stored in the
+// metadata of every directory with the directory's actual contents. It also
+// looks for siafiles which can't be reached from the root of the filesystem,
+// names which don't form valid SiaPaths and temporary files which were left
+// behind by interrupted writes.
+//
+// Inconsistencies of the metadata are repaired in place by creating missing
+// metadata and bubbling the affected directories. Everything else can only be
+// moved out of the filesystem into a quarantine folder for manual inspection.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+const (
+	// fsckQuarantineDir is the folder within the renter's persist directory
+	// quarantined files and directories are moved to. Every check which
+	// quarantines something uses a new subfolder.
+	fsckQuarantineDir = "quarantine"
+
+	// fsckTempSuffix is the suffix of the temporary files which are written
+	// before atomically replacing a file.
+	fsckTempSuffix = "_temp"
+)
+
+var (
+	// fsckRemnantAge is the minimum age of a temporary file before it is
+	// considered a remnant of an interrupted write. Younger files might still
+	// be in use.
+	fsckRemnantAge = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      time.Minute,
+		Testing:  time.Duration(0),
+	}).(time.Duration)
+)
+
+type (
+	// fsckChecker checks the consistency of the renter's filesystem.
+	fsckChecker struct {
+		report modules.FsckReport
+
+		// mismatched are the directories whose aggregates don't match their
+		// contents together with the index of their issue in the report.
+		mismatched []fsckMismatch
+
+		staticRenter *Renter
+		staticRoot   string
+	}
+
+	// fsckMismatch is a directory whose aggregates need to be bubbled. The
+	// issue is -1 for parents of mismatched directories.
+	fsckMismatch struct {
+		siaPath modules.SiaPath
+		depth   int
+		issue   int
+	}
+
+	// fsckDirContents are the actual contents of a directory.
+	fsckDirContents struct {
+		numFiles            uint64
+		numSubDirs          uint64
+		size                uint64
+		aggregateNumFiles   uint64
+		aggregateNumSubDirs uint64
+		aggregateSize       uint64
+	}
+)
+
+// Fsck checks the consistency of the renter's filesystem. If repair is true,
+// inconsistencies which can be fixed in place are repaired. If quarantine is
+// true, files and directories which can't be repaired are moved out of the
+// filesystem into a quarantine folder.
+//
+// NOTE: the aggregates of a directory are updated in the background after its
+// contents change. Directories which were modified recently might be reported
+// until they were bubbled.
+func (r *Renter) Fsck(repair, quarantine bool) (modules.FsckReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.FsckReport{}, err
+	}
+	defer r.tg.Done()
+	if (repair || quarantine) && r.ReadOnly() {
+		return modules.FsckReport{}, modules.ErrRenterReadOnly
+	}
+
+	// Only one check runs at a time.
+	r.fsckMu.Lock()
+	defer r.fsckMu.Unlock()
+
+	fc := &fsckChecker{
+		report: modules.FsckReport{
+			Repair:     repair,
+			Quarantine: quarantine,
+			Issues:     []modules.FsckIssue{},
+			StartTime:  time.Now(),
+		},
+		staticRenter: r,
+		staticRoot:   r.staticFileSystem.Root(),
+	}
+	if _, _, err := fc.managedCheckDir(modules.RootSiaPath(), 0, true); err != nil {
+		return modules.FsckReport{}, errors.AddContext(err, "failed to check filesystem")
+	}
+	if repair {
+		fc.managedBubbleMismatched()
+	}
+	fc.report.EndTime = time.Now()
+	return fc.report, nil
+}
+
+// addIssue adds an issue to the report and returns its index.
+func (fc *fsckChecker) addIssue(issueType, path, details string) int {
+	rel, err := filepath.Rel(fc.staticRoot, path)
+	if err != nil || rel == "." {
+		rel = ""
+	}
+	fc.report.Issues = append(fc.report.Issues, modules.FsckIssue{
+		Type:    issueType,
+		Path:    "/" + filepath.ToSlash(rel),
+		Details: details,
+		Action:  modules.FsckActionNone,
+	})
+	return len(fc.report.Issues) - 1
+}
+
+// setAction sets the action of an issue. A non-nil error marks the action as
+// failed.
+func (fc *fsckChecker) setAction(issue int, action string, err error) {
+	if err != nil {
+		fc.report.Issues[issue].ActionError = err.Error()
+		return
+	}
+	fc.report.Issues[issue].Action = action
+}
+
+// quarantine moves the file or directory at path into the quarantine folder
+// of the check if quarantining is enabled. The path relative to the root of
+// the filesystem is preserved.
+func (fc *fsckChecker) quarantine(issue int, path string) {
+	if !fc.report.Quarantine {
+		return
+	}
+	rel, err := filepath.Rel(fc.staticRoot, path)
+	if err != nil {
+		fc.setAction(issue, modules.FsckActionQuarantined, err)
+		return
+	}
+	if fc.report.QuarantineDir == "" {
+		name := fc.report.StartTime.UTC().Format("20060102T150405.000000000")
+		fc.report.QuarantineDir = filepath.Join(fc.staticRenter.persistDir, fsckQuarantineDir, name)
+	}
+	dst := filepath.Join(fc.report.QuarantineDir, rel)
+	err = os.MkdirAll(filepath.Dir(dst), modules.DefaultDirPerm)
+	if err == nil {
+		err = os.Rename(path, dst)
+	}
+	if err != nil {
+		err = errors.AddContext(err, "failed to quarantine")
+	}
+	fc.setAction(issue, modules.FsckActionQuarantined, err)
+}
+
+// managedCheckDir checks the directory at siaPath and everything within it.
+// Directories are only reachable if they and all of their parents have
+// metadata. It returns whether the directory counts as a subdirectory of its
+// parent together with the directory's actual aggregates.
+func (fc *fsckChecker) managedCheckDir(siaPath modules.SiaPath, depth int, reachable bool) (_ fsckDirContents, counted bool, err error) {
+	select {
+	case <-fc.staticRenter.tg.StopChan():
+		return fsckDirContents{}, false, errors.New("renter is shutting down")
+	default:
+	}
+	path := siaPath.SiaDirSysPath(fc.staticRoot)
+	fis, err := ioutil.ReadDir(path)
+	if os.IsNotExist(err) {
+		// The directory was deleted in the meantime.
+		return fsckDirContents{}, false, nil
+	}
+	if err != nil {
+		return fsckDirContents{}, false, errors.AddContext(err, fmt.Sprintf("failed to read directory %v", path))
+	}
+	fc.report.DirsChecked++
+
+	// Check the directory's metadata. Missing metadata is created if the
+	// directory's parent is reachable.
+	_, err = os.Stat(siaPath.SiaDirMetadataSysPath(fc.staticRoot))
+	hasMetadata := err == nil
+	if !hasMetadata {
+		issue := fc.addIssue(modules.FsckIssueMissingDirMetadata, path, "the directory has no metadata")
+		if fc.report.Repair && reachable {
+			err := fc.managedCreateDirMetadata(siaPath)
+			fc.setAction(issue, modules.FsckActionRepaired, err)
+			hasMetadata = err == nil
+		}
+	}
+	reachable = reachable && hasMetadata
+
+	var contents fsckDirContents
+	for _, fi := range fis {
+		name := fi.Name()
+		entryPath := filepath.Join(path, name)
+		switch {
+		case fi.IsDir():
+			child, err := siaPath.Join(name)
+			if err != nil || child.Name() != name {
+				issue := fc.addIssue(modules.FsckIssueInvalidSiaPath, entryPath, "the directory's name is not a valid SiaPath")
+				fc.quarantine(issue, entryPath)
+				continue
+			}
+			childContents, counted, err := fc.managedCheckDir(child, depth+1, reachable)
+			if err != nil {
+				return fsckDirContents{}, false, err
+			}
+			if !counted {
+				continue
+			}
+			contents.numSubDirs++
+			contents.aggregateNumSubDirs += childContents.aggregateNumSubDirs + 1
+			contents.aggregateNumFiles += childContents.aggregateNumFiles
+			contents.aggregateSize += childContents.aggregateSize
+
+		case filepath.Ext(name) == modules.SiaFileExtension:
+			fileName := strings.TrimSuffix(name, modules.SiaFileExtension)
+			if child, err := siaPath.Join(fileName); err != nil || child.Name() != fileName {
+				issue := fc.addIssue(modules.FsckIssueInvalidSiaPath, entryPath, "the file's name is not a valid SiaPath")
+				fc.quarantine(issue, entryPath)
+				continue
+			}
+			if !reachable {
+				issue := fc.addIssue(modules.FsckIssueOrphanedFile, entryPath, "a parent directory of the file has no metadata")
+				fc.quarantine(issue, entryPath)
+				continue
+			}
+			md, err := siafile.LoadSiaFileMetadata(entryPath)
+			if os.IsNotExist(err) {
+				// The file was deleted in the meantime.
+				continue
+			}
+			if err != nil {
+				issue := fc.addIssue(modules.FsckIssueUnreadableFile, entryPath, err.Error())
+				fc.quarantine(issue, entryPath)
+				continue
+			}
+			fc.report.FilesChecked++
+			contents.numFiles++
+			contents.size += uint64(md.FileSize)
+
+		case strings.HasSuffix(name, fsckTempSuffix) && time.Since(fi.ModTime()) >= fsckRemnantAge:
+			issue := fc.addIssue(modules.FsckIssueWALRemnant, entryPath, "temporary file of an interrupted write")
+			fc.quarantine(issue, entryPath)
+		}
+	}
+	contents.aggregateNumFiles += contents.numFiles
+	contents.aggregateSize += contents.size
+	if !reachable {
+		return contents, false, nil
+	}
+
+	// Compare the stored aggregates with the actual ones.
+	dir, err := fc.staticRenter.staticFileSystem.OpenSiaDir(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		// The directory was deleted in the meantime.
+		return contents, false, nil
+	}
+	if err != nil {
+		return fsckDirContents{}, false, errors.AddContext(err, fmt.Sprintf("failed to open directory %v", siaPath))
+	}
+	md, err := dir.Metadata()
+	err = errors.Compose(err, dir.Close())
+	if err != nil {
+		return fsckDirContents{}, false, errors.AddContext(err, fmt.Sprintf("failed to read metadata of directory %v", siaPath))
+	}
+	var mismatches []string
+	compare := func(field string, stored, actual uint64) {
+		if stored != actual {
+			mismatches = append(mismatches, fmt.Sprintf("%v is %v but should be %v", field, stored, actual))
+		}
+	}
+	compare("numfiles", md.NumFiles, contents.numFiles)
+	compare("numsubdirs", md.NumSubDirs, contents.numSubDirs)
+	compare("size", md.Size, contents.size)
+	compare("aggregatenumfiles", md.AggregateNumFiles, contents.aggregateNumFiles)
+	compare("aggregatenumsubdirs", md.AggregateNumSubDirs, contents.aggregateNumSubDirs)
+	compare("aggregatesize", md.AggregateSize, contents.aggregateSize)
+	if len(mismatches) > 0 {
+		issue := fc.addIssue(modules.FsckIssueAggregateMismatch, path, strings.Join(mismatches, ", "))
+		fc.mismatched = append(fc.mismatched, fsckMismatch{
+			siaPath: siaPath,
+			depth:   depth,
+			issue:   issue,
+		})
+	}
+	return contents, true, nil
+}
+
+// managedCreateDirMetadata creates the missing metadata of the directory at
+// siaPath.
+func (fc *fsckChecker) managedCreateDirMetadata(siaPath modules.SiaPath) error {
+	dir, err := fc.staticRenter.staticFileSystem.OpenSiaDirCustom(siaPath, true)
+	if err != nil {
+		return errors.AddContext(err, "failed to create metadata")
+	}
+	return dir.Close()
+}
+
+// managedBubbleMismatched bubbles the directories whose aggregates don't match
+// their contents together with their parents. The deepest directories are
+// bubbled first since the aggregates of a directory are calculated from the
+// aggregates of its subdirectories. The bubbles are performed right away
+// instead of being queued so that the aggregates are correct once the check
+// returns.
+func (fc *fsckChecker) managedBubbleMismatched() {
+	// Add the parents of the mismatched directories. Their aggregates might
+	// have been calculated from the wrong aggregates of their subdirectories in
+	// the meantime.
+	dirs := make(map[modules.SiaPath]fsckMismatch)
+	for _, m := range fc.mismatched {
+		dirs[m.siaPath] = m
+		for siaPath, depth := m.siaPath, m.depth; !siaPath.IsRoot(); depth-- {
+			parent, err := siaPath.Dir()
+			if err != nil {
+				build.Critical("failed to get parent of a checked directory", err)
+				break
+			}
+			if _, exists := dirs[parent]; !exists {
+				dirs[parent] = fsckMismatch{siaPath: parent, depth: depth - 1, issue: -1}
+			}
+			siaPath = parent
+		}
+	}
+	toBubble := make([]fsckMismatch, 0, len(dirs))
+	for _, m := range dirs {
+		toBubble = append(toBubble, m)
+	}
+	sort.Slice(toBubble, func(i, j int) bool {
+		return toBubble[i].depth > toBubble[j].depth
+	})
+
+	for _, m := range toBubble {
+		select {
+		case <-fc.staticRenter.tg.StopChan():
+			return
+		default:
+		}
+		err := fc.staticRenter.staticBubbleScheduler.managedPerformBubbleUpdate(m.siaPath)
+		if m.issue >= 0 {
+			fc.setAction(m.issue, modules.FsckActionRepaired, err)
+		} else if err != nil {
+			fc.staticRenter.log.Printf("WARN: failed to bubble %v after repairing its subdirectories: %v", m.siaPath, err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// fsckIssues returns the issues of a report keyed by their type and path.
+func fsckIssues(report modules.FsckReport) map[string]modules.FsckIssue {
+	issues := make(map[string]modules.FsckIssue)
+	for _, issue := range report.Issues {
+		issues[issue.Type+" "+issue.Path] = issue
+	}
+	return issues
+}
+
+// TestFsck probes the detection, repair and quarantine of inconsistencies of
+// the renter's filesystem.
+func TestFsck(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	root := r.staticFileSystem.Root()
+
+	// Create a few files and bring the metadata up to date.
+	for _, path := range []string{"dir/file1", "dir/sub/file2"} {
+		siaPath, err := modules.NewSiaPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := r.createRenterTestFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.Fsck(true, false); err != nil {
+		t.Fatal(err)
+	}
+	report, err := r.Fsck(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatal("expected no issues after repairing but got", report.Issues)
+	}
+	if report.FilesChecked != 2 {
+		t.Fatal("unexpected number of checked files", report.FilesChecked)
+	}
+
+	// Break the filesystem. Remove the metadata of a directory, corrupt the
+	// aggregates of another one and add a temporary file and a file with an
+	// invalid name. The directory without metadata is not reachable anymore,
+	// so the aggregates of the root don't match either.
+	dirPath := filepath.Join(root, "dir")
+	if err := os.Remove(filepath.Join(dirPath, "sub", modules.SiaDirExtension)); err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := modules.NewSiaPath("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := r.managedDirectoryMetadata(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.AggregateSize += 1000
+	if err := rt.openAndUpdateDir(siaPath, md); err != nil {
+		t.Fatal(err)
+	}
+	remnant := filepath.Join(dirPath, "alias"+modules.SiaAliasExtension+fsckTempSuffix)
+	invalid := filepath.Join(dirPath, "\xff"+modules.SiaFileExtension)
+	for _, path := range []string{remnant, invalid} {
+		if err := ioutil.WriteFile(path, []byte{1, 2, 3}, modules.DefaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Check the filesystem without fixing anything.
+	report, err = r.Fsck(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := fsckIssues(report)
+	expected := []string{
+		modules.FsckIssueAggregateMismatch + " /",
+		modules.FsckIssueAggregateMismatch + " /dir",
+		modules.FsckIssueMissingDirMetadata + " /dir/sub",
+		modules.FsckIssueOrphanedFile + " /dir/sub/file2" + modules.SiaFileExtension,
+		modules.FsckIssueWALRemnant + " /dir/alias" + modules.SiaAliasExtension + fsckTempSuffix,
+		modules.FsckIssueInvalidSiaPath + " /dir/\xff" + modules.SiaFileExtension,
+	}
+	if len(issues) != len(expected) {
+		t.Fatal("unexpected issues", report.Issues)
+	}
+	for _, key := range expected {
+		issue, exists := issues[key]
+		if !exists {
+			t.Fatalf("issue '%v' wasn't found in %v", key, report.Issues)
+		}
+		if issue.Action != modules.FsckActionNone {
+			t.Fatal("unexpected action", issue)
+		}
+	}
+
+	// Repair and quarantine the inconsistencies.
+	report, err = r.Fsck(true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues = fsckIssues(report)
+	if issue := issues[modules.FsckIssueMissingDirMetadata+" /dir/sub"]; issue.Action != modules.FsckActionRepaired {
+		t.Fatal("missing metadata wasn't repaired", issue)
+	}
+	if _, exists := issues[modules.FsckIssueOrphanedFile+" /dir/sub/file2"+modules.SiaFileExtension]; exists {
+		t.Fatal("file is still orphaned after repairing its directory")
+	}
+	if issue := issues[modules.FsckIssueAggregateMismatch+" /dir"]; issue.Action != modules.FsckActionRepaired {
+		t.Fatal("aggregates weren't repaired", issue)
+	}
+	for _, path := range []string{remnant, invalid} {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatal("file wasn't removed from the filesystem", err)
+		}
+		if _, err := os.Stat(filepath.Join(report.QuarantineDir, rel)); err != nil {
+			t.Fatal("file wasn't quarantined", err)
+		}
+	}
+	report, err = r.Fsck(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatal("expected no issues after repairing but got", report.Issues)
+	}
+
+	// Orphaned files can be quarantined too.
+	if err := os.Remove(filepath.Join(dirPath, "sub", modules.SiaDirExtension)); err != nil {
+		t.Fatal(err)
+	}
+	report, err = r.Fsck(false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues = fsckIssues(report)
+	if issue := issues[modules.FsckIssueOrphanedFile+" /dir/sub/file2"+modules.SiaFileExtension]; issue.Action != modules.FsckActionQuarantined {
+		t.Fatal("orphaned file wasn't quarantined", issue)
+	}
+	if _, err := os.Stat(filepath.Join(report.QuarantineDir, "dir", "sub", "file2"+modules.SiaFileExtension)); err != nil {
+		t.Fatal("orphaned file wasn't quarantined", err)
+	}
+}
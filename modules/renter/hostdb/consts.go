@@ -15,7 +15,8 @@ const (
 	// interactions required before decay is applied.
 	historicInteractionDecayLimit = 500
 
-	// hostRequestTimeout indicates how long a host has to respond to a dial.
+	// hostRequestTimeout indicates how long a host has to respond to a dial
+	// unless the scan dial timeout was set.
 	hostRequestTimeout = 2 * time.Minute
 
 	// hostScanDeadline indicates how long a host has to complete an entire
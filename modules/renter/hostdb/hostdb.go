@@ -93,8 +93,14 @@ type HostDB struct {
 	scanMap                 map[string]struct{}
 	scanWait                bool
 	scanningThreads         int
+	scanningThreadsLimit    int
+	scanDialTimeout         time.Duration
 	synced                  bool
 
+	// bandwidthMeter records the traffic of scans and pauses scanning once
+	// the scan traffic reached its cap.
+	bandwidthMeter *modules.BandwidthMeter
+
 	// staticFilteredTree is a hosttree that only contains the hosts that align
 	// with the filterMode. The filteredHosts are the hosts that are submitted
 	// with the filterMode to determine which host should be in the
@@ -690,6 +696,70 @@ func (hdb *HostDB) SetIPViolationCheck(enabled bool) error {
 	return nil
 }
 
+// MaxScanningThreads returns the maximum number of threads used to scan hosts.
+func (hdb *HostDB) MaxScanningThreads() (int, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return 0, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.maxScanningThreads(), nil
+}
+
+// SetMaxScanningThreads sets the maximum number of threads used to scan hosts.
+// Running threads above the new limit finish their current scans before they
+// exit. A limit of 0 restores the default.
+func (hdb *HostDB) SetMaxScanningThreads(limit int) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if limit < 0 {
+		return errors.New("scanning threads can't be negative")
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.scanningThreadsLimit = limit
+	return nil
+}
+
+// ScanDialTimeout returns the maximum amount of time dialing a host during a
+// scan may take.
+func (hdb *HostDB) ScanDialTimeout() (time.Duration, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return 0, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.effectiveScanDialTimeout(), nil
+}
+
+// SetScanDialTimeout sets the maximum amount of time dialing a host during a
+// scan may take. Running scans keep their timeout. A timeout of 0 restores the
+// default.
+func (hdb *HostDB) SetScanDialTimeout(timeout time.Duration) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if timeout < 0 {
+		return errors.New("scan dial timeout can't be negative")
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.scanDialTimeout = timeout
+	return nil
+}
+
+// SetBandwidthMeter sets the meter which records the traffic of scans.
+func (hdb *HostDB) SetBandwidthMeter(bm *modules.BandwidthMeter) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.bandwidthMeter = bm
+}
+
 // UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 // contracts.
 func (hdb *HostDB) UpdateContracts(contracts []modules.RenterContract) error {
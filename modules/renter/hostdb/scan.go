@@ -14,7 +14,6 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/siamux"
-	"gitlab.com/NebulousLabs/siamux/mux"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -91,6 +90,24 @@ func (hdb *HostDB) managedUpdateTxnFees() {
 	hdb.staticLog.Println("Updated the hostdb txnFees to", newTxnFees.HumanString())
 }
 
+// maxScanningThreads returns the maximum number of threads used to scan
+// hosts, falling back to the default if no limit was set.
+func (hdb *HostDB) maxScanningThreads() int {
+	if hdb.scanningThreadsLimit > 0 {
+		return hdb.scanningThreadsLimit
+	}
+	return maxScanningThreads
+}
+
+// effectiveScanDialTimeout returns the maximum amount of time dialing a host
+// during a scan may take, falling back to the default if no timeout was set.
+func (hdb *HostDB) effectiveScanDialTimeout() time.Duration {
+	if hdb.scanDialTimeout > 0 {
+		return hdb.scanDialTimeout
+	}
+	return hostRequestTimeout
+}
+
 // queueScan will add a host to the queue to be scanned. The host will be added
 // at a random position which means that the order in which queueScan is called
 // is not necessarily the order in which the hosts get scanned. That guarantees
@@ -118,8 +135,12 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 
 	// Sanity check - the scan map and the scan list should have the same
 	// length.
-	if build.DEBUG && len(hdb.scanMap) > len(hdb.scanList)+maxScanningThreads {
-		hdb.staticLog.Critical("The hostdb scan map has seemingly grown too large:", len(hdb.scanMap), len(hdb.scanList), maxScanningThreads)
+	threads := hdb.maxScanningThreads()
+	if hdb.scanningThreads > threads {
+		threads = hdb.scanningThreads
+	}
+	if build.DEBUG && len(hdb.scanMap) > len(hdb.scanList)+threads {
+		hdb.staticLog.Critical("The hostdb scan map has seemingly grown too large:", len(hdb.scanMap), len(hdb.scanList), threads)
 	}
 
 	// Nobody is emptying the scan list, create and run a scan thread.
@@ -181,7 +202,7 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 			}
 
 			// Create new worker thread.
-			if hdb.scanningThreads < maxScanningThreads || !starterThread {
+			if hdb.scanningThreads < hdb.maxScanningThreads() || !starterThread {
 				starterThread = true
 				hdb.scanningThreads++
 				if err := hdb.tg.Add(); err != nil {
@@ -369,6 +390,16 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	pubKey := entry.PublicKey
 	hdb.staticLog.Debugf("Scanning host %v at %v", pubKey, netAddr)
 
+	// Skip the scan if the scan traffic reached its cap. The entry isn't
+	// updated to avoid treating the host as offline.
+	hdb.mu.RLock()
+	bm := hdb.bandwidthMeter
+	hdb.mu.RUnlock()
+	if err := bm.Check(modules.RenterBandwidthHostDBScan); err != nil {
+		hdb.staticLog.Debugf("Skipping scan of host %v: %v", pubKey, err)
+		return
+	}
+
 	// If we use a custom resolver for testing, we replace the custom domain
 	// with 127.0.0.1. Otherwise the scan will fail.
 	if hdb.staticDeps.Disrupt("customResolver") {
@@ -396,8 +427,9 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	var settings modules.HostExternalSettings
 	var latency time.Duration
 	err = func() error {
-		timeout := hostRequestTimeout
 		hdb.mu.RLock()
+		maxTimeout := hdb.effectiveScanDialTimeout()
+		timeout := maxTimeout
 		if len(hdb.initialScanLatencies) > minScansForSpeedup {
 			build.Critical("initialScanLatencies should never be greater than minScansForSpeedup")
 		}
@@ -408,8 +440,8 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 			// speedup the scanning process.
 			timeout = hdb.initialScanLatencies[len(hdb.initialScanLatencies)/2]
 			timeout *= scanSpeedupMedianMultiplier
-			if hostRequestTimeout < timeout {
-				timeout = hostRequestTimeout
+			if maxTimeout < timeout {
+				timeout = maxTimeout
 			}
 		}
 		hdb.mu.RUnlock()
@@ -424,6 +456,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 		if err != nil {
 			return err
 		}
+		conn = bm.WrapConn(conn, modules.RenterBandwidthHostDBScan)
 		// Create go routine that will close the channel if the hostdb shuts
 		// down or when this method returns as signalled by closing the
 		// connCloseChan channel
@@ -475,7 +508,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 
 		// Try opening a connection to the siamux, this is a very lightweight
 		// way of checking that RHP3 is supported.
-		_, err = fetchPriceTable(hdb.staticMux, siamuxAddr, timeout, modules.SiaPKToMuxPK(entry.PublicKey))
+		_, err = fetchPriceTable(hdb.staticMux, siamuxAddr, timeout, entry.PublicKey, settings.IngressProtection, bm)
 		if err != nil {
 			hdb.staticLog.Debugf("%v siamux ping not successful: %v\n", entry.PublicKey, err)
 			return err
@@ -677,13 +710,15 @@ func (hdb *HostDB) threadedScan() {
 // uses an ephemeral stream which is a special type of stream that doesn't leak
 // TCP connections. Otherwise we would end up with one TCP connection for every
 // host in the network after scanning the whole network.
-func fetchPriceTable(siamux *siamux.SiaMux, hostAddr string, timeout time.Duration, hpk mux.ED25519PublicKey) (_ *modules.RPCPriceTable, err error) {
-	stream, err := siamux.NewEphemeralStream(modules.HostSiaMuxSubscriberName, hostAddr, timeout, hpk)
+func fetchPriceTable(siamux *siamux.SiaMux, hostAddr string, timeout time.Duration, hostKey types.SiaPublicKey, ingressProtection bool, bm *modules.BandwidthMeter) (_ *modules.RPCPriceTable, err error) {
+	stream, err := siamux.NewEphemeralStream(modules.HostSiaMuxSubscriberName, hostAddr, timeout, modules.SiaPKToMuxPK(hostKey))
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to create ephemeral stream")
 	}
 	defer func() {
 		err = errors.Compose(err, stream.Close())
+		limit := stream.Limit()
+		bm.Record(modules.RenterBandwidthHostDBScan, limit.Uploaded(), limit.Downloaded())
 	}()
 
 	// set a deadline on the stream.
@@ -698,6 +733,15 @@ func fetchPriceTable(siamux *siamux.SiaMux, hostAddr string, timeout time.Durati
 		return nil, errors.AddContext(err, "failed to write price table RPC specifier")
 	}
 
+	// solve the ingress puzzle if the host requires an ingress proof
+	if ingressProtection {
+		proof := modules.NewIngressPuzzleProof(hostKey, modules.RPCUpdatePriceTable, modules.IngressPuzzleDifficulty)
+		err = modules.RPCWrite(stream, proof)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to write ingress proof")
+		}
+	}
+
 	// receive the price table response
 	var update modules.RPCUpdatePriceTableResponse
 	err = modules.RPCRead(stream, &update)
@@ -0,0 +1,134 @@
+package renter
+
+import (
+	"fmt"
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// ImportLegacySiaFiles imports the files of a legacy shared .sia file into the
+// given directory. The contracts of a legacy file are mapped to hosts using
+// the renter's contracts or the address of the host. Pieces are only imported
+// if their host is known to the hostdb and was online during its most recent
+// scan. The repair loop takes care of restoring the redundancy of the imported
+// files if the renter is able to download them.
+func (r *Renter) ImportLegacySiaFiles(src string, dir modules.SiaPath) (_ []modules.LegacySiaFileImport, err error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	// Decode the legacy files.
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open legacy .sia file")
+	}
+	files, err := decodeV137SharedFiles(f)
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to decode legacy .sia file")
+	}
+
+	hostKey, err := r.managedLegacyHostKeys()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to map legacy contracts to hosts")
+	}
+
+	// dirsToUpdate are the directories of the imported files which need their
+	// metadata to be updated.
+	dirsToUpdate := r.newUniqueRefreshPaths()
+	defer func() {
+		err = errors.Compose(err, dirsToUpdate.callRefreshAll())
+	}()
+
+	imports := make([]modules.LegacySiaFileImport, 0, len(files))
+	for _, lf := range files {
+		siaPath, err := r.managedLegacyImportSiaPath(dir, lf.name)
+		if err != nil {
+			return imports, errors.AddContext(err, "unable to determine siapath of legacy file "+lf.name)
+		}
+		fileData, available, unavailable := v137FileData(lf, "", hostKey)
+		fileData.Name = siaPath.String()
+		entry, err := r.staticFileSystem.NewSiaFileFromLegacyData(fileData)
+		if err != nil {
+			return imports, errors.AddContext(err, "unable to import legacy file "+lf.name)
+		}
+		if err := entry.Close(); err != nil {
+			return imports, errors.AddContext(err, "failed to close file")
+		}
+		imports = append(imports, modules.LegacySiaFileImport{
+			SiaPath:           siaPath,
+			AvailablePieces:   available,
+			UnavailablePieces: unavailable,
+		})
+
+		fullPath, err := modules.UserFolder.Join(siaPath.String())
+		if err != nil {
+			return imports, err
+		}
+		if err := dirsToUpdate.callAdd(fullPath); err != nil {
+			return imports, errors.AddContext(err, fmt.Sprintf("could not add directory of %v to the list of directories to be updated", siaPath))
+		}
+	}
+	return imports, nil
+}
+
+// managedLegacyHostKeys returns a function which maps the contracts of legacy
+// files to the public keys of their available hosts.
+func (r *Renter) managedLegacyHostKeys() (func(fileContract) (types.SiaPublicKey, bool), error) {
+	// Map the ids of the renter's contracts to their hosts.
+	idToPk := make(map[types.FileContractID]types.SiaPublicKey)
+	for _, c := range append(r.hostContractor.Contracts(), r.hostContractor.OldContracts()...) {
+		idToPk[c.ID] = c.HostPublicKey
+	}
+
+	// Map the addresses of the hosts in the hostdb to their keys and keep
+	// track of which hosts are online.
+	hosts, err := r.hostDB.AllHosts()
+	if err != nil {
+		return nil, err
+	}
+	addrToPk := make(map[modules.NetAddress]types.SiaPublicKey)
+	online := make(map[string]bool)
+	for _, host := range hosts {
+		addrToPk[host.NetAddress] = host.PublicKey
+		n := len(host.ScanHistory)
+		online[host.PublicKey.String()] = n > 0 && host.ScanHistory[n-1].Success
+	}
+
+	return func(contract fileContract) (types.SiaPublicKey, bool) {
+		pk, exists := idToPk[contract.ID]
+		if !exists {
+			pk, exists = addrToPk[contract.IP]
+		}
+		if !exists || !online[pk.String()] {
+			return types.SiaPublicKey{}, false
+		}
+		return pk, true
+	}, nil
+}
+
+// managedLegacyImportSiaPath returns the siapath of an imported legacy file.
+// If a file already exists at the siapath, a suffix of the form _[num] is
+// appended to its name.
+func (r *Renter) managedLegacyImportSiaPath(dir modules.SiaPath, name string) (modules.SiaPath, error) {
+	origPath, err := dir.Join(name)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	siaPath := origPath
+	for dupCount := uint(1); ; dupCount++ {
+		fullPath, err := modules.UserFolder.Join(siaPath.String())
+		if err != nil {
+			return modules.SiaPath{}, err
+		}
+		exists, _ := r.staticFileSystem.FileExists(fullPath)
+		if !exists {
+			return siaPath, nil
+		}
+		siaPath = origPath.AddSuffix(dupCount)
+	}
+}
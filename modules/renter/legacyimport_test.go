@@ -0,0 +1,179 @@
+package renter
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newTestLegacyFile creates a legacy file with a chunk stored across the
+// given number of contracts.
+func newTestLegacyFile(name string, numContracts int) *file {
+	rsc, _ := modules.NewRSCode(1, numContracts-1)
+	f := &file{
+		name:        name,
+		size:        100,
+		contracts:   make(map[types.FileContractID]fileContract),
+		erasureCode: rsc,
+		pieceSize:   modules.SectorSize - crypto.TypeDefaultRenter.Overhead(),
+		mode:        0644,
+	}
+	fastrand.Read(f.masterKey[:])
+	for i := 0; i < numContracts; i++ {
+		var id types.FileContractID
+		fastrand.Read(id[:])
+		f.contracts[id] = fileContract{
+			ID:     id,
+			IP:     modules.NetAddress("host" + string(rune('a'+i)) + ".com:9982"),
+			Pieces: []pieceData{{Chunk: 0, Piece: uint64(i), MerkleRoot: crypto.Hash{byte(i)}}},
+		}
+	}
+	return f
+}
+
+// writeTestLegacySiaFile writes the given legacy files to a shared .sia file
+// at path.
+func writeTestLegacySiaFile(path string, files ...*file) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	err = encoding.NewEncoder(f).EncodeAll(shareHeader, shareVersion, uint64(len(files)))
+	if err != nil {
+		return err
+	}
+	zip := gzip.NewWriter(f)
+	enc := encoding.NewEncoder(zip)
+	for _, lf := range files {
+		if err := enc.Encode(lf); err != nil {
+			return errors.Compose(err, zip.Close())
+		}
+	}
+	return zip.Close()
+}
+
+// TestImportLegacySiaFiles probes importing legacy shared .sia files.
+func TestImportLegacySiaFiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Write a legacy .sia file.
+	src := filepath.Join(rt.dir, "legacy.sia")
+	if err := writeTestLegacySiaFile(src, newTestLegacyFile("foo/bar", 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Import the legacy file. The renter doesn't know any hosts so none of
+	// the pieces are available.
+	dir, err := modules.NewSiaPath("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imports, err := rt.renter.ImportLegacySiaFiles(src, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imports) != 1 || imports[0].SiaPath.String() != "legacy/foo/bar" {
+		t.Fatal("unexpected imports", imports)
+	}
+	if imports[0].AvailablePieces != 0 || imports[0].UnavailablePieces != 3 {
+		t.Fatal("unexpected pieces", imports[0])
+	}
+	siaPath, err := modules.UserFolder.Join(imports[0].SiaPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := rt.renter.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.NumChunks() < 1 {
+		t.Fatal("invalid number of chunks in siafile:", sf.NumChunks())
+	}
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing the file again appends a suffix.
+	imports, err = rt.renter.ImportLegacySiaFiles(src, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imports) != 1 || imports[0].SiaPath.String() != "legacy/foo/bar_1" {
+		t.Fatal("unexpected imports", imports)
+	}
+
+	// Importing a file which isn't a legacy .sia file fails.
+	badSrc := filepath.Join(rt.dir, "bad.sia")
+	var badHeader [15]byte
+	fastrand.Read(badHeader[:])
+	err = ioutil.WriteFile(badSrc, encoding.MarshalAll(badHeader, shareVersion, uint64(1)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rt.renter.ImportLegacySiaFiles(badSrc, dir)
+	if !errors.Contains(err, ErrBadFile) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// TestV137FileData checks that v137FileData only keeps the pieces of
+// contracts whose hosts are available.
+func TestV137FileData(t *testing.T) {
+	lf := newTestLegacyFile("foo", 3)
+
+	// Make one of the contracts available.
+	var available types.FileContractID
+	var availablePieces, totalPieces uint64
+	for id, contract := range lf.contracts {
+		if availablePieces == 0 {
+			available = id
+			availablePieces = uint64(len(contract.Pieces))
+		}
+		totalPieces += uint64(len(contract.Pieces))
+	}
+	hostKey := types.SiaPublicKey{Key: []byte{1}}
+	fd, converted, dropped := v137FileData(lf, "", func(contract fileContract) (types.SiaPublicKey, bool) {
+		return hostKey, contract.ID == available
+	})
+	if converted != availablePieces || dropped != totalPieces-availablePieces {
+		t.Fatal("unexpected pieces", converted, dropped, availablePieces, totalPieces)
+	}
+	var pieces uint64
+	for _, chunk := range fd.Chunks {
+		for _, pieceSet := range chunk.Pieces {
+			for _, piece := range pieceSet {
+				if !piece.HostPubKey.Equals(hostKey) {
+					t.Fatal("piece of unavailable host was converted")
+				}
+				pieces++
+			}
+		}
+	}
+	if pieces != converted {
+		t.Fatal("unexpected number of pieces", pieces, converted)
+	}
+}
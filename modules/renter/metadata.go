@@ -328,7 +328,8 @@ func (r *Renter) managedCachedFileMetadata(siaPath modules.SiaPath) (bubbledSiaF
 func (r *Renter) managedCachedFileMetadatas(siaPaths []modules.SiaPath) (_ []bubbledSiaFileMetadata, err error) {
 	// Define components
 	mds := make([]bubbledSiaFileMetadata, 0, len(siaPaths))
-	siaPathChan := make(chan modules.SiaPath, numBubbleWorkerThreads)
+	numThreads := r.staticConcurrency.callHealthCheckThreads()
+	siaPathChan := make(chan modules.SiaPath, numThreads)
 	var errs error
 	var errMu, mdMu sync.Mutex
 
@@ -350,7 +351,7 @@ func (r *Renter) managedCachedFileMetadatas(siaPaths []modules.SiaPath) (_ []bub
 
 	// Launch Metadata workers
 	var wg sync.WaitGroup
-	for i := 0; i < numBubbleWorkerThreads; i++ {
+	for i := 0; i < numThreads; i++ {
 		wg.Add(1)
 		go func() {
 			metadataWorker()
@@ -376,7 +377,8 @@ func (r *Renter) managedCachedFileMetadatas(siaPaths []modules.SiaPath) (_ []bub
 func (r *Renter) managedDirectoryMetadatas(siaPaths []modules.SiaPath) ([]bubbledSiaDirMetadata, error) {
 	// Define components
 	mds := make([]bubbledSiaDirMetadata, 0, len(siaPaths))
-	siaPathChan := make(chan modules.SiaPath, numBubbleWorkerThreads)
+	numThreads := r.staticConcurrency.callHealthCheckThreads()
+	siaPathChan := make(chan modules.SiaPath, numThreads)
 	var errs error
 	var errMu, mdMu sync.Mutex
 
@@ -401,7 +403,7 @@ func (r *Renter) managedDirectoryMetadatas(siaPaths []modules.SiaPath) ([]bubble
 
 	// Launch Metadata workers
 	var wg sync.WaitGroup
-	for i := 0; i < numBubbleWorkerThreads; i++ {
+	for i := 0; i < numThreads; i++ {
 		wg.Add(1)
 		go func() {
 			metadataWorker()
@@ -0,0 +1,358 @@
+package renter
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// migrationReasonBlocklisted is the reason of a migration away from a host
+	// which is filtered by the hostdb.
+	migrationReasonBlocklisted = "host was blocklisted"
+
+	// migrationReasonRemoved is the reason of a migration away from a host
+	// which is no longer known to the hostdb.
+	migrationReasonRemoved = "host was removed from the hostdb"
+)
+
+type (
+	// migrationTracker keeps track of the hosts the renter is migrating data
+	// away from.
+	migrationTracker struct {
+		// migrations maps the string representation of a host's public key to
+		// the migration away from that host.
+		migrations map[string]*hostMigration
+
+		// checkNeeded is used to wake the migration thread up before the next
+		// migrationCheckInterval.
+		checkNeeded chan struct{}
+
+		mu sync.Mutex
+	}
+
+	// hostMigration is the migration of the data stored on a single host.
+	hostMigration struct {
+		staticHostPubKey types.SiaPublicKey
+		staticReason     string
+		staticStartTime  time.Time
+
+		completed bool
+		files     []modules.FileMigration
+	}
+)
+
+// newMigrationTracker creates a new migrationTracker.
+func newMigrationTracker() *migrationTracker {
+	return &migrationTracker{
+		migrations:  make(map[string]*hostMigration),
+		checkNeeded: make(chan struct{}, 1),
+	}
+}
+
+// callTriggerCheck wakes up the migration thread.
+func (mt *migrationTracker) callTriggerCheck() {
+	select {
+	case mt.checkNeeded <- struct{}{}:
+	default:
+	}
+}
+
+// managedMigratingHosts returns the hosts of all migrations which are not
+// completed yet.
+func (mt *migrationTracker) managedMigratingHosts() map[string]struct{} {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	hosts := make(map[string]struct{})
+	for hpk, m := range mt.migrations {
+		if !m.completed {
+			hosts[hpk] = struct{}{}
+		}
+	}
+	return hosts
+}
+
+// managedUpdate starts a migration for every bad host which is not migrated
+// yet and removes the migrations of checked hosts which are no longer bad. The
+// badHosts map maps the bad hosts to the reason of their migration. The
+// started migrations are returned.
+func (mt *migrationTracker) managedUpdate(hosts map[string]types.SiaPublicKey, badHosts map[string]string) []types.SiaPublicKey {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	var started []types.SiaPublicKey
+	for hpk, reason := range badHosts {
+		if _, exists := mt.migrations[hpk]; exists {
+			continue
+		}
+		mt.migrations[hpk] = &hostMigration{
+			staticHostPubKey: hosts[hpk],
+			staticReason:     reason,
+			staticStartTime:  time.Now(),
+		}
+		started = append(started, hosts[hpk])
+	}
+	for hpk := range mt.migrations {
+		_, checked := hosts[hpk]
+		if _, bad := badHosts[hpk]; checked && !bad {
+			delete(mt.migrations, hpk)
+		}
+	}
+	return started
+}
+
+// managedUpdateProgress updates the progress of the migrations away from the
+// provided hosts. A migration is completed once none of its files have chunks
+// left which depend on the host.
+func (mt *migrationTracker) managedUpdateProgress(hosts map[string]struct{}, progress map[string][]modules.FileMigration) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	for hpk := range hosts {
+		m, exists := mt.migrations[hpk]
+		if !exists {
+			continue
+		}
+		files := progress[hpk]
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].SiaPath.String() < files[j].SiaPath.String()
+		})
+		m.files = files
+		m.completed = true
+		for _, f := range files {
+			if f.MigratedChunks < f.Chunks {
+				m.completed = false
+				break
+			}
+		}
+	}
+}
+
+// managedMigrations returns the tracked migrations sorted by their start time.
+func (mt *migrationTracker) managedMigrations() []modules.HostMigration {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	migrations := make([]modules.HostMigration, 0, len(mt.migrations))
+	for _, m := range mt.migrations {
+		migrations = append(migrations, modules.HostMigration{
+			HostPubKey: m.staticHostPubKey,
+			Reason:     m.staticReason,
+			StartTime:  m.staticStartTime,
+			Completed:  m.completed,
+			Files:      append([]modules.FileMigration{}, m.files...),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].StartTime.Before(migrations[j].StartTime)
+	})
+	return migrations
+}
+
+// managedBadContractHosts returns the hosts the renter has contracts with or
+// is migrating data away from which are either filtered by the hostdb or not
+// known to it anymore.
+func (r *Renter) managedBadContractHosts() (map[string]types.SiaPublicKey, map[string]string) {
+	hosts := make(map[string]types.SiaPublicKey)
+	for _, contract := range r.hostContractor.Contracts() {
+		hosts[contract.HostPublicKey.String()] = contract.HostPublicKey
+	}
+	for _, m := range r.staticMigrations.managedMigrations() {
+		hosts[m.HostPubKey.String()] = m.HostPubKey
+	}
+
+	badHosts := make(map[string]string)
+	for hpk, pk := range hosts {
+		entry, exists, err := r.hostDB.Host(pk)
+		if err != nil {
+			r.log.Debugln("WARN: unable to get host for migration check:", err)
+			delete(hosts, hpk)
+			continue
+		}
+		if !exists {
+			badHosts[hpk] = migrationReasonRemoved
+		} else if entry.Filtered {
+			badHosts[hpk] = migrationReasonBlocklisted
+		}
+	}
+	return hosts, badHosts
+}
+
+// managedMigrateChunks pushes the chunks which depend on a migrating host onto
+// the upload heap. A chunk depends on a migrating host if it has a piece which
+// is only stored on migrating hosts. While doing so, the progress of the
+// migrations is updated.
+func (r *Renter) managedMigrateChunks() error {
+	migrating := r.staticMigrations.managedMigratingHosts()
+	if len(migrating) == 0 {
+		return nil
+	}
+
+	// Pieces on migrating hosts don't count towards the redundancy of the
+	// chunks pushed by the migration.
+	hosts := r.managedRefreshHostsAndWorkers()
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+	for hpk := range migrating {
+		goodForRenew[hpk] = false
+	}
+
+	// Collect the files.
+	var siaPaths []modules.SiaPath
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		mu.Lock()
+		siaPaths = append(siaPaths, fi.SiaPath)
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(modules.RootSiaPath(), true, flf, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return errors.AddContext(err, "failed to list files")
+	}
+
+	progress := make(map[string][]modules.FileMigration)
+	maxChunks := r.staticConcurrency.callRepairUploadChunks()
+	var pushed int
+	for _, siaPath := range siaPaths {
+		n, err := r.managedMigrateFileChunks(siaPath, migrating, hosts, offline, goodForRenew, maxChunks-pushed, progress)
+		if err != nil {
+			r.log.Debugf("WARN: failed to migrate chunks of %v: %v", siaPath, err)
+		}
+		pushed += n
+	}
+	r.staticMigrations.managedUpdateProgress(migrating, progress)
+
+	// Wake up the repair loop.
+	if pushed > 0 {
+		r.repairLog.Printf("Pushed %v chunks onto the upload heap to migrate them away from %v hosts", pushed, len(migrating))
+		select {
+		case r.uploadHeap.repairNeeded <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// managedMigrateFileChunks adds the migration progress of a single file to the
+// progress map and pushes up to maxChunks of its chunks which depend on a
+// migrating host onto the upload heap. It returns the number of pushed chunks.
+func (r *Renter) managedMigrateFileChunks(siaPath modules.SiaPath, migrating, hosts map[string]struct{}, offline, goodForRenew map[string]bool, maxChunks int, progress map[string][]modules.FileMigration) (int, error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := entry.Close(); err != nil {
+			r.log.Debugln("WARN: failed to close file:", err)
+		}
+	}()
+
+	pks := make(map[string]types.SiaPublicKey)
+	for _, pk := range entry.HostPublicKeys() {
+		pks[string(pk.Key)] = pk
+	}
+
+	files := make(map[string]*modules.FileMigration)
+	var pushed int
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		pieces, err := entry.Pieces(chunkIndex)
+		if err != nil {
+			return pushed, errors.AddContext(err, "failed to get pieces")
+		}
+
+		// Figure out which migrating hosts the chunk has pieces on and which of
+		// them it still depends on.
+		affected := make(map[string]struct{})
+		pending := make(map[string]struct{})
+		for _, pieceSet := range pieces {
+			var onMigrating []string
+			var safe bool
+			for _, piece := range pieceSet {
+				hpk := piece.HostPubKey.String()
+				if _, ok := migrating[hpk]; ok {
+					onMigrating = append(onMigrating, hpk)
+				} else {
+					safe = true
+				}
+			}
+			for _, hpk := range onMigrating {
+				affected[hpk] = struct{}{}
+				if !safe {
+					pending[hpk] = struct{}{}
+				}
+			}
+		}
+		for hpk := range affected {
+			fm, exists := files[hpk]
+			if !exists {
+				fm = &modules.FileMigration{SiaPath: siaPath}
+				files[hpk] = fm
+			}
+			fm.Chunks++
+			if _, ok := pending[hpk]; !ok {
+				fm.MigratedChunks++
+			}
+		}
+		if len(pending) == 0 || pushed >= maxChunks {
+			continue
+		}
+
+		// Push the chunk unless it is already being repaired.
+		id := uploadChunkID{fileUID: entry.UID(), index: chunkIndex}
+		if r.uploadHeap.managedExists(id) {
+			continue
+		}
+		chunk, err := r.managedBuildUnfinishedChunk(entry, chunkIndex, hosts, pks, memoryPriorityLow, offline, goodForRenew, r.repairMemoryManager)
+		if err != nil {
+			return pushed, errors.AddContext(err, "failed to build chunk")
+		}
+		if chunk.health > 1 && !chunk.onDisk {
+			r.log.Debugf("WARN: can't migrate chunk %v of %v since it isn't repairable", chunkIndex, siaPath)
+			chunk.fileEntry.Close()
+			continue
+		}
+		if !r.uploadHeap.managedPush(chunk, chunkTypeLocalChunk) {
+			chunk.fileEntry.Close()
+			continue
+		}
+		pushed++
+	}
+	for hpk, fm := range files {
+		progress[hpk] = append(progress[hpk], *fm)
+	}
+	return pushed, nil
+}
+
+// threadedMigrateHosts periodically checks for hosts which were blocklisted or
+// removed from the hostdb and migrates the chunks stored on them to other
+// hosts without waiting for their health to drop below the repair threshold.
+func (r *Renter) threadedMigrateHosts() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.staticMigrations.checkNeeded:
+		case <-time.After(migrationCheckInterval):
+		}
+
+		hosts, badHosts := r.managedBadContractHosts()
+		for _, pk := range r.staticMigrations.managedUpdate(hosts, badHosts) {
+			r.log.Printf("Migrating data away from host %v: %v", pk, badHosts[pk.String()])
+		}
+		if err := r.managedMigrateChunks(); err != nil {
+			r.log.Println("WARN: failed to migrate chunks:", err)
+		}
+	}
+}
+
+// HostMigrations returns the migrations of data away from blocklisted hosts and
+// hosts which were removed from the hostdb.
+func (r *Renter) HostMigrations() []modules.HostMigration {
+	return r.staticMigrations.managedMigrations()
+}
@@ -0,0 +1,82 @@
+package renter
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMigrationTracker probes the bookkeeping of the migrationTracker.
+func TestMigrationTracker(t *testing.T) {
+	mt := newMigrationTracker()
+	pk1 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	pk2 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	hosts := map[string]types.SiaPublicKey{
+		pk1.String(): pk1,
+		pk2.String(): pk2,
+	}
+
+	// Start migrating away from the first host.
+	started := mt.managedUpdate(hosts, map[string]string{pk1.String(): migrationReasonBlocklisted})
+	if len(started) != 1 || !started[0].Equals(pk1) {
+		t.Fatal("wrong migrations started", started)
+	}
+	started = mt.managedUpdate(hosts, map[string]string{pk1.String(): migrationReasonBlocklisted})
+	if len(started) != 0 {
+		t.Fatal("migration was started twice", started)
+	}
+	migrating := mt.managedMigratingHosts()
+	if _, ok := migrating[pk1.String()]; !ok || len(migrating) != 1 {
+		t.Fatal("wrong migrating hosts", migrating)
+	}
+
+	// Report some progress.
+	sp1, sp2 := modules.RandomSiaPath(), modules.RandomSiaPath()
+	mt.managedUpdateProgress(migrating, map[string][]modules.FileMigration{
+		pk1.String(): {
+			{SiaPath: sp1, Chunks: 2, MigratedChunks: 2},
+			{SiaPath: sp2, Chunks: 3, MigratedChunks: 1},
+		},
+	})
+	migrations := mt.managedMigrations()
+	if len(migrations) != 1 || migrations[0].Completed || len(migrations[0].Files) != 2 {
+		t.Fatal("unexpected migrations", migrations)
+	}
+	if migrations[0].Reason != migrationReasonBlocklisted || !migrations[0].HostPubKey.Equals(pk1) {
+		t.Fatal("unexpected migration", migrations[0])
+	}
+
+	// Complete the migration. Completed migrations are no longer returned by
+	// managedMigratingHosts.
+	mt.managedUpdateProgress(migrating, map[string][]modules.FileMigration{
+		pk1.String(): {
+			{SiaPath: sp1, Chunks: 2, MigratedChunks: 2},
+			{SiaPath: sp2, Chunks: 3, MigratedChunks: 3},
+		},
+	})
+	if migrations := mt.managedMigrations(); !migrations[0].Completed {
+		t.Fatal("migration should be completed")
+	}
+	if migrating := mt.managedMigratingHosts(); len(migrating) != 0 {
+		t.Fatal("completed migration is still migrating", migrating)
+	}
+
+	// A migration without files is completed right away.
+	mt.managedUpdate(hosts, map[string]string{
+		pk1.String(): migrationReasonBlocklisted,
+		pk2.String(): migrationReasonRemoved,
+	})
+	mt.managedUpdateProgress(mt.managedMigratingHosts(), nil)
+	migrations = mt.managedMigrations()
+	if len(migrations) != 2 || !migrations[1].Completed || migrations[1].Reason != migrationReasonRemoved {
+		t.Fatal("unexpected migrations", migrations)
+	}
+
+	// Unchecked hosts keep their migration while good hosts lose it.
+	mt.managedUpdate(map[string]types.SiaPublicKey{pk1.String(): pk1}, nil)
+	migrations = mt.managedMigrations()
+	if len(migrations) != 1 || !migrations[0].HostPubKey.Equals(pk2) {
+		t.Fatal("unexpected migrations", migrations)
+	}
+}
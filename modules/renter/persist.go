@@ -56,10 +56,17 @@ var (
 type (
 	// persist contains all of the persistent renter data.
 	persistence struct {
-		MaxDownloadSpeed int64
-		MaxUploadSpeed   int64
-		UploadedBackups  []modules.UploadedBackup
-		SyncedContracts  []types.FileContractID
+		MaxDownloadSpeed    int64
+		MaxUploadSpeed      int64
+		UploadedBackups     []modules.UploadedBackup
+		SyncedContracts     []types.FileContractID
+		StreamCache         modules.StreamCacheSettings
+		AccountSpendingCaps modules.AccountSpendingCaps
+		Concurrency         modules.RenterConcurrencySettings
+		Timeouts            modules.RenterTimeoutSettings
+		Placement           modules.RenterPlacementSettings
+		BandwidthCaps       map[modules.RenterBandwidthCategory]modules.RenterBandwidthCap
+		ReadOnly            bool
 	}
 )
 
@@ -108,6 +115,45 @@ func (r *Renter) managedLoadSettings() error {
 		return err
 	}
 
+	// Apply the stream cache settings.
+	settings, err := validateStreamCacheSettings(r.persist.StreamCache)
+	if err != nil {
+		return errors.AddContext(err, "invalid stream cache settings")
+	}
+	r.staticStreamCache.callSetSettings(settings)
+
+	// Apply the account spending caps.
+	caps, err := validateAccountSpendingCaps(r.persist.AccountSpendingCaps)
+	if err != nil {
+		return errors.AddContext(err, "invalid account spending caps")
+	}
+	r.staticAccountSpendingCaps.callSetCaps(caps)
+
+	// Apply the concurrency limits.
+	if err := validateConcurrencySettings(r.persist.Concurrency); err != nil {
+		return errors.AddContext(err, "invalid concurrency settings")
+	}
+	if err := r.hostDB.SetMaxScanningThreads(int(r.persist.Concurrency.HostDBScanThreads)); err != nil {
+		return errors.AddContext(err, "failed to set hostdb scanning threads")
+	}
+	r.staticConcurrency.callSetSettings(r.persist.Concurrency)
+
+	// Apply the placement settings.
+	if err := validatePlacementSettings(r.persist.Placement); err != nil {
+		return errors.AddContext(err, "invalid placement settings")
+	}
+	r.staticPiecePlacement.callSetSettings(r.persist.Placement)
+
+	// Apply the bandwidth caps.
+	if err := r.staticBandwidthMeter.SetCaps(r.persist.BandwidthCaps); err != nil {
+		return errors.AddContext(err, "invalid bandwidth caps")
+	}
+
+	// Apply the timeouts.
+	if err := r.applyTimeoutSettings(r.persist.Timeouts); err != nil {
+		return errors.AddContext(err, "invalid timeout settings")
+	}
+
 	// Set the bandwidth limits on the contractor, which was already initialized
 	// without bandwidth limits.
 	return r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
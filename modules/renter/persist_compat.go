@@ -296,6 +296,22 @@ func (r *Renter) v137FileToSiaFile(f *file, repairPath string, oldContracts []mo
 		idToPk[c.ID] = c.HostPublicKey
 	}
 
+	fileData, _, _ := v137FileData(f, repairPath, func(contract fileContract) (types.SiaPublicKey, bool) {
+		pk, exists := idToPk[contract.ID]
+		if !exists {
+			r.log.Printf("Couldn't find pubKey for contract %v with WindowStart %v",
+				contract.ID, contract.WindowStart)
+		}
+		return pk, exists
+	})
+	return r.staticFileSystem.NewSiaFileFromLegacyData(fileData)
+}
+
+// v137FileData converts a legacy file to the data of a SiaFile. The hostKey
+// function returns the public key of the host storing the pieces of a legacy
+// contract. Pieces of contracts for which it returns false are dropped. The
+// number of converted and dropped pieces is returned alongside the data.
+func v137FileData(f *file, repairPath string, hostKey func(fileContract) (types.SiaPublicKey, bool)) (_ siafile.FileData, converted, dropped uint64) {
 	fileData := siafile.FileData{
 		Name:        f.name,
 		FileSize:    f.size,
@@ -312,10 +328,9 @@ func (r *Renter) v137FileToSiaFile(f *file, repairPath string, oldContracts []mo
 		chunks[i].Pieces = make([][]siafile.Piece, f.erasureCode.NumPieces())
 	}
 	for _, contract := range f.contracts {
-		pk, exists := idToPk[contract.ID]
+		pk, exists := hostKey(contract)
 		if !exists {
-			r.log.Printf("Couldn't find pubKey for contract %v with WindowStart %v",
-				contract.ID, contract.WindowStart)
+			dropped += uint64(len(contract.Pieces))
 			continue
 		}
 
@@ -336,49 +351,23 @@ func (r *Renter) v137FileToSiaFile(f *file, repairPath string, oldContracts []mo
 				HostPubKey: pk,
 				MerkleRoot: piece.MerkleRoot,
 			})
+			converted++
 		}
 	}
 	fileData.Chunks = chunks
-	return r.staticFileSystem.NewSiaFileFromLegacyData(fileData)
+	return fileData, converted, dropped
 }
 
 // compatV137LoadSiaFilesFromReader reads .sia data from reader and registers
 // the contained files in the renter. It returns the nicknames of the loaded
 // files.
 func (r *Renter) compatV137loadSiaFilesFromReader(reader io.Reader, tracking map[string]v137TrackedFile, oldContracts []modules.RenterContract) ([]string, error) {
-	// read header
-	var header [15]byte
-	var version string
-	var numFiles uint64
-	err := encoding.NewDecoder(reader, encoding.DefaultAllocLimit).DecodeAll(
-		&header,
-		&version,
-		&numFiles,
-	)
+	files, err := decodeV137SharedFiles(reader)
 	if err != nil {
-		return nil, errors.AddContext(err, "unable to read header")
-	} else if header != shareHeader {
-		return nil, ErrBadFile
-	} else if version != shareVersion {
-		return nil, ErrIncompatible
+		return nil, err
 	}
-
-	// Create decompressor.
-	unzip, err := gzip.NewReader(reader)
-	if err != nil {
-		return nil, errors.AddContext(err, "unable to create gzip decompressor")
-	}
-	dec := encoding.NewDecoder(unzip, 100e6)
-
-	// Read each file.
-	files := make([]*file, numFiles)
+	numFiles := len(files)
 	for i := range files {
-		files[i] = new(file)
-		err := dec.Decode(files[i])
-		if err != nil {
-			return nil, errors.AddContext(err, "unable to decode file")
-		}
-
 		// Make sure the file's name does not conflict with existing files.
 		dupCount := 0
 		origName := files[i].name
@@ -423,6 +412,45 @@ func (r *Renter) compatV137loadSiaFilesFromReader(reader io.Reader, tracking map
 	return names, err
 }
 
+// decodeV137SharedFiles reads the legacy files contained in the .sia data
+// read from reader.
+func decodeV137SharedFiles(reader io.Reader) ([]*file, error) {
+	// read header
+	var header [15]byte
+	var version string
+	var numFiles uint64
+	err := encoding.NewDecoder(reader, encoding.DefaultAllocLimit).DecodeAll(
+		&header,
+		&version,
+		&numFiles,
+	)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read header")
+	} else if header != shareHeader {
+		return nil, ErrBadFile
+	} else if version != shareVersion {
+		return nil, ErrIncompatible
+	}
+
+	// Create decompressor.
+	unzip, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create gzip decompressor")
+	}
+	dec := encoding.NewDecoder(unzip, 100e6)
+
+	// Read each file.
+	files := make([]*file, numFiles)
+	for i := range files {
+		files[i] = new(file)
+		err := dec.Decode(files[i])
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to decode file")
+		}
+	}
+	return files, nil
+}
+
 // convertPersistVersionFrom140To142 upgrades a legacy persist file to the next
 // version, converting the old filesystem to the new one.
 func (r *Renter) convertPersistVersionFrom140To142(path string) error {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/ratelimit"
@@ -100,6 +101,43 @@ func TestRenterSaveLoad(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Update the stream cache settings.
+	streamCacheSettings := modules.StreamCacheSettings{
+		MaxBytes:        1 << 30,
+		ChunksPerStream: 4,
+		Policy:          modules.StreamCachePolicyLRU,
+	}
+	err = rt.renter.SetStreamCacheSettings(streamCacheSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the concurrency limits.
+	concurrencySettings := modules.RenterConcurrencySettings{
+		RepairDownloads:   3,
+		HostDBScanThreads: 2,
+	}
+	err = rt.renter.SetConcurrencySettings(concurrencySettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the timeouts.
+	timeoutSettings := modules.RenterTimeoutSettings{
+		Interactive: modules.RenterOperationTimeouts{ChunkDownload: time.Minute},
+		Repair:      modules.RenterOperationTimeouts{Dial: 10 * time.Second},
+		Background:  modules.RenterOperationTimeouts{Dial: 20 * time.Second},
+	}
+	err = rt.renter.SetTimeoutSettings(timeoutSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Enable the read-only mode.
+	if err := rt.renter.SetReadOnly(true); err != nil {
+		t.Fatal(err)
+	}
+
 	// Add a file to the renter
 	entry, err := rt.renter.newRenterTestFile()
 	if err != nil {
@@ -146,6 +184,39 @@ func TestRenterSaveLoad(t *testing.T) {
 	if newSettings.MaxUploadSpeed != newUpSpeed {
 		t.Error("upload settings not being persisted correctly")
 	}
+	streamCacheStats, err := rt.renter.StreamCacheStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamCacheStats.StreamCacheSettings != streamCacheSettings {
+		t.Error("stream cache settings not being persisted correctly")
+	}
+	concurrency, err := rt.renter.ConcurrencySettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if concurrency.Settings != concurrencySettings {
+		t.Error("concurrency settings not being persisted correctly")
+	}
+	if concurrency.Effective.HostDBScanThreads != 2 || concurrency.Effective.RepairUploadChunks != uint64(maxUploadHeapChunks) {
+		t.Error("concurrency settings not being applied correctly", concurrency.Effective)
+	}
+	timeouts, err := rt.renter.TimeoutSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeouts.Settings != timeoutSettings {
+		t.Error("timeout settings not being persisted correctly")
+	}
+	if timeouts.Effective.Interactive.ChunkDownload != time.Minute || timeouts.Effective.Repair.ChunkDownload != chunkDownloadTimeoutDefault {
+		t.Error("chunk download timeouts not being applied correctly", timeouts.Effective)
+	}
+	if timeouts.Effective.Repair.Dial != 10*time.Second || timeouts.Effective.Background.Dial != 20*time.Second {
+		t.Error("dial timeouts not being applied correctly", timeouts.Effective)
+	}
+	if !rt.renter.ReadOnly() {
+		t.Error("read-only mode not being persisted correctly")
+	}
 
 	// Check that SiaFileSet loaded the renter's file
 	_, err = rt.renter.staticFileSystem.OpenSiaFile(siapath)
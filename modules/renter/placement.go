@@ -0,0 +1,160 @@
+package renter
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/hostdb/hosttree"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errPlacementPrefixTooLong is returned if an anti-affinity prefix length
+	// is longer than the subnets resolved by the hostdb.
+	errPlacementPrefixTooLong = fmt.Errorf("anti-affinity prefix lengths can't exceed /%v for IPv4 and /%v for IPv6", hosttree.IPv4FilterRange, hosttree.IPv6FilterRange)
+)
+
+type (
+	// piecePlacement contains the anti-affinity constraints of the renter's
+	// piece placement and the networks of the hosts the renter has contracts
+	// with.
+	piecePlacement struct {
+		settings modules.RenterPlacementSettings
+
+		// networks maps the hosts to the networks they are part of. The map is
+		// replaced instead of modified whenever it is refreshed, so chunks can
+		// keep using the map they were built with. It is nil if the piece
+		// placement is unconstrained.
+		networks map[string][]string
+
+		mu sync.Mutex
+	}
+)
+
+// newPiecePlacement returns an unconstrained piece placement.
+func newPiecePlacement() *piecePlacement {
+	return &piecePlacement{}
+}
+
+// validatePlacementSettings checks that the prefix lengths can be applied to
+// the subnets resolved by the hostdb.
+func validatePlacementSettings(settings modules.RenterPlacementSettings) error {
+	if settings.IPv4PrefixLength > hosttree.IPv4FilterRange || settings.IPv6PrefixLength > hosttree.IPv6FilterRange {
+		return errPlacementPrefixTooLong
+	}
+	return nil
+}
+
+// placementNetworks returns the networks of the provided subnets which may
+// only hold a single piece of a chunk. Subnets which can't be parsed are
+// ignored.
+func placementNetworks(ipNets []string, settings modules.RenterPlacementSettings) []string {
+	var networks []string
+	for _, ipNet := range ipNets {
+		ip, _, err := net.ParseCIDR(ipNet)
+		if err != nil {
+			continue
+		}
+		bits, prefix := 128, settings.IPv6PrefixLength
+		if ip.To4() != nil {
+			ip, bits, prefix = ip.To4(), 32, settings.IPv4PrefixLength
+		}
+		if prefix == 0 {
+			continue
+		}
+		mask := net.CIDRMask(int(prefix), bits)
+		network := (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+
+		// Hosts may resolve to multiple addresses within a network.
+		duplicate := false
+		for _, n := range networks {
+			duplicate = duplicate || n == network
+		}
+		if !duplicate {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// callSetSettings updates the anti-affinity constraints. The networks of the
+// hosts are resolved on the next refresh.
+func (pp *piecePlacement) callSetSettings(settings modules.RenterPlacementSettings) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.settings = settings
+	pp.networks = nil
+}
+
+// callSettings returns the anti-affinity constraints.
+func (pp *piecePlacement) callSettings() modules.RenterPlacementSettings {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return pp.settings
+}
+
+// callHostNetworks returns the networks of the hosts. The returned map must
+// not be modified.
+func (pp *piecePlacement) callHostNetworks() map[string][]string {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return pp.networks
+}
+
+// managedRefreshHostNetworks resolves the networks of the provided hosts from
+// their subnets in the hostdb.
+func (r *Renter) managedRefreshHostNetworks(hosts []types.SiaPublicKey) {
+	settings := r.staticPiecePlacement.callSettings()
+	if settings.IPv4PrefixLength == 0 && settings.IPv6PrefixLength == 0 {
+		return
+	}
+	networks := make(map[string][]string, len(hosts))
+	for _, hpk := range hosts {
+		entry, exists, err := r.hostDB.Host(hpk)
+		if err != nil || !exists {
+			continue
+		}
+		networks[hpk.String()] = placementNetworks(entry.IPNets, settings)
+	}
+
+	// Don't overwrite the networks if the settings changed in the meantime.
+	pp := r.staticPiecePlacement
+	pp.mu.Lock()
+	if pp.settings == settings {
+		pp.networks = networks
+	}
+	pp.mu.Unlock()
+}
+
+// PlacementSettings returns the anti-affinity constraints of the renter's
+// piece placement.
+func (r *Renter) PlacementSettings() (modules.RenterPlacementSettings, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterPlacementSettings{}, err
+	}
+	defer r.tg.Done()
+	return r.staticPiecePlacement.callSettings(), nil
+}
+
+// SetPlacementSettings updates the anti-affinity constraints of the renter's
+// piece placement and persists them. The constraints apply to pieces which
+// are uploaded afterwards, pieces which were already uploaded aren't moved.
+func (r *Renter) SetPlacementSettings(settings modules.RenterPlacementSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if err := validatePlacementSettings(settings); err != nil {
+		return err
+	}
+	r.staticPiecePlacement.callSetSettings(settings)
+
+	id := r.mu.Lock()
+	r.persist.Placement = settings
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	return err
+}
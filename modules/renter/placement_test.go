@@ -0,0 +1,91 @@
+package renter
+
+import (
+	"reflect"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestPlacementNetworks probes placementNetworks.
+func TestPlacementNetworks(t *testing.T) {
+	t.Parallel()
+
+	settings := modules.RenterPlacementSettings{IPv4PrefixLength: 16, IPv6PrefixLength: 32}
+	tests := []struct {
+		ipNets   []string
+		settings modules.RenterPlacementSettings
+		networks []string
+	}{
+		{[]string{"1.2.3.0/24"}, settings, []string{"1.2.0.0/16"}},
+		{[]string{"1.2.3.0/24", "1.2.4.0/24", "5.6.7.0/24"}, settings, []string{"1.2.0.0/16", "5.6.0.0/16"}},
+		{[]string{"2001:db8:1234::/54"}, settings, []string{"2001:db8::/32"}},
+		{[]string{"1.2.3.0/24", "2001:db8:1234::/54"}, modules.RenterPlacementSettings{IPv4PrefixLength: 24}, []string{"1.2.3.0/24"}},
+		{[]string{"1.2.3.0/24"}, modules.RenterPlacementSettings{}, nil},
+		{[]string{"foo"}, settings, nil},
+		{nil, settings, nil},
+	}
+	for _, tt := range tests {
+		if networks := placementNetworks(tt.ipNets, tt.settings); !reflect.DeepEqual(networks, tt.networks) {
+			t.Errorf("%v with %+v: expected %v, got %v", tt.ipNets, tt.settings, tt.networks, networks)
+		}
+	}
+
+	if err := validatePlacementSettings(modules.RenterPlacementSettings{IPv4PrefixLength: 24, IPv6PrefixLength: 54}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validatePlacementSettings(modules.RenterPlacementSettings{IPv4PrefixLength: 25}); err != errPlacementPrefixTooLong {
+		t.Fatal("expected errPlacementPrefixTooLong", err)
+	}
+}
+
+// TestCandidateHostAntiAffinity checks that hosts within a network which
+// holds a piece of a chunk aren't candidates for another piece.
+func TestCandidateHostAntiAffinity(t *testing.T) {
+	t.Parallel()
+
+	uc := &unfinishedUploadChunk{
+		staticHostNetworks: map[string][]string{
+			"a": {"1.2.0.0/16"},
+			"b": {"1.2.0.0/16", "5.6.0.0/16"},
+			"c": {"5.6.0.0/16"},
+			"d": {"7.8.0.0/16"},
+		},
+		unusedHosts:  map[string]struct{}{"a": {}, "b": {}, "c": {}, "d": {}, "e": {}},
+		usedNetworks: make(map[string]int),
+	}
+	candidates := func() (hosts []string) {
+		for _, host := range []string{"a", "b", "c", "d", "e"} {
+			if uc.candidateHost(host) {
+				hosts = append(hosts, host)
+			}
+		}
+		return
+	}
+	if hosts := candidates(); !reflect.DeepEqual(hosts, []string{"a", "b", "c", "d", "e"}) {
+		t.Fatal("unexpected candidates", hosts)
+	}
+
+	// Host b shares a network with a and c. Host e has no known networks.
+	delete(uc.unusedHosts, "b")
+	uc.updateUsedNetworks("b", 1)
+	if hosts := candidates(); !reflect.DeepEqual(hosts, []string{"d", "e"}) {
+		t.Fatal("unexpected candidates", hosts)
+	}
+
+	// Once the upload to b failed, a and c become candidates again.
+	uc.updateUsedNetworks("b", -1)
+	if len(uc.usedNetworks) != 0 {
+		t.Fatal("networks weren't released", uc.usedNetworks)
+	}
+	if hosts := candidates(); !reflect.DeepEqual(hosts, []string{"a", "c", "d", "e"}) {
+		t.Fatal("unexpected candidates", hosts)
+	}
+
+	// Without networks, only the unused hosts are considered.
+	uc.staticHostNetworks = nil
+	uc.updateUsedNetworks("a", 1)
+	if hosts := candidates(); !reflect.DeepEqual(hosts, []string{"a", "c", "d", "e"}) {
+		t.Fatal("unexpected candidates", hosts)
+	}
+}
@@ -0,0 +1,229 @@
+package renter
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+type (
+	// priceMonitor keeps the latest comparison of the projected renewal costs
+	// of the renter's contracts against the market rate.
+	priceMonitor struct {
+		report modules.ContractPriceReport
+		mu     sync.Mutex
+	}
+
+	// renewWorkload is the expected usage of a contract after it is renewed.
+	renewWorkload struct {
+		duration types.BlockHeight
+		storage  uint64
+		upload   uint64
+		download uint64
+	}
+)
+
+// newPriceMonitor creates a new priceMonitor.
+func newPriceMonitor() *priceMonitor {
+	return &priceMonitor{
+		report: modules.ContractPriceReport{
+			Contracts: []modules.ContractPriceComparison{},
+		},
+	}
+}
+
+// managedReport returns the latest report.
+func (pm *priceMonitor) managedReport() modules.ContractPriceReport {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	report := pm.report
+	report.Contracts = append([]modules.ContractPriceComparison{}, pm.report.Contracts...)
+	return report
+}
+
+// managedSetReport replaces the latest report.
+func (pm *priceMonitor) managedSetReport(report modules.ContractPriceReport) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.report = report
+}
+
+// hostPrices returns the prices of a host which affect the cost of renewing a
+// contract.
+func hostPrices(host modules.HostDBEntry) modules.HostPrices {
+	return modules.HostPrices{
+		ContractPrice:          host.ContractPrice,
+		StoragePrice:           host.StoragePrice,
+		UploadBandwidthPrice:   host.UploadBandwidthPrice,
+		DownloadBandwidthPrice: host.DownloadBandwidthPrice,
+	}
+}
+
+// medianCurrency returns the median of the provided values. The values are
+// sorted in place.
+func medianCurrency(values []types.Currency) types.Currency {
+	if len(values) == 0 {
+		return types.ZeroCurrency
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+	return values[mid-1].Add(values[mid]).Div64(2)
+}
+
+// medianPrices returns the median of each of the hosts' prices.
+func medianPrices(hosts []modules.HostDBEntry) modules.HostPrices {
+	var contract, storage, upload, download []types.Currency
+	for _, host := range hosts {
+		contract = append(contract, host.ContractPrice)
+		storage = append(storage, host.StoragePrice)
+		upload = append(upload, host.UploadBandwidthPrice)
+		download = append(download, host.DownloadBandwidthPrice)
+	}
+	return modules.HostPrices{
+		ContractPrice:          medianCurrency(contract),
+		StoragePrice:           medianCurrency(storage),
+		UploadBandwidthPrice:   medianCurrency(upload),
+		DownloadBandwidthPrice: medianCurrency(download),
+	}
+}
+
+// newRenewWorkload returns the expected usage of a renewed contract. A renewed
+// contract stores at least the data of the contract and its share of the
+// allowance's expected storage. The expected upload and download are split
+// evenly between the allowance's hosts.
+func newRenewWorkload(contract modules.RenterContract, allowance modules.Allowance) renewWorkload {
+	hosts := allowance.Hosts
+	if hosts == 0 {
+		hosts = 1
+	}
+	redundancy := allowance.ExpectedRedundancy
+	if redundancy == 0 {
+		redundancy = 1
+	}
+	storage := uint64(float64(allowance.ExpectedStorage) * redundancy / float64(hosts))
+	if size := contract.Size(); size > storage {
+		storage = size
+	}
+	return renewWorkload{
+		duration: allowance.Period + allowance.RenewWindow,
+		storage:  storage,
+		upload:   uint64(float64(allowance.ExpectedUpload)*redundancy/float64(hosts)) * uint64(allowance.Period),
+		download: allowance.ExpectedDownload / hosts * uint64(allowance.Period),
+	}
+}
+
+// cost returns the cost of the workload at the provided prices.
+func (w renewWorkload) cost(prices modules.HostPrices) types.Currency {
+	cost := prices.ContractPrice
+	cost = cost.Add(prices.StoragePrice.Mul64(w.storage).Mul64(uint64(w.duration)))
+	cost = cost.Add(prices.UploadBandwidthPrice.Mul64(w.upload))
+	cost = cost.Add(prices.DownloadBandwidthPrice.Mul64(w.download))
+	return cost
+}
+
+// costRatio returns projected divided by market. It returns 0 if the market
+// cost is zero.
+func costRatio(projected, market types.Currency) float64 {
+	if market.IsZero() {
+		return 0
+	}
+	ratio, _ := new(big.Rat).SetFrac(projected.Big(), market.Big()).Float64()
+	return ratio
+}
+
+// managedCheckContractPrices compares the projected renewal costs of the
+// contracts which are going to be renewed against the costs at the median
+// prices of the active hosts in the hostdb. The alert for overpriced contracts
+// is updated accordingly.
+func (r *Renter) managedCheckContractPrices() error {
+	hosts, err := r.hostDB.ActiveHosts()
+	if err != nil {
+		return err
+	}
+	report := modules.ContractPriceReport{
+		LastCheck: time.Now(),
+		NumHosts:  len(hosts),
+		Contracts: []modules.ContractPriceComparison{},
+	}
+	if len(hosts) < priceCheckMinHosts {
+		r.staticPriceMonitor.managedSetReport(report)
+		r.staticAlerter.UnregisterAlert(modules.AlertIDRenterContractsOverpriced)
+		return nil
+	}
+	report.MedianPrices = medianPrices(hosts)
+
+	allowance := r.hostContractor.Allowance()
+	var overpriced int
+	for _, contract := range r.hostContractor.Contracts() {
+		if !contract.Utility.GoodForRenew {
+			continue
+		}
+		host, exists, err := r.hostDB.Host(contract.HostPublicKey)
+		if err != nil || !exists {
+			continue
+		}
+		workload := newRenewWorkload(contract, allowance)
+		comparison := modules.ContractPriceComparison{
+			ID:                 contract.ID,
+			HostPublicKey:      contract.HostPublicKey,
+			NetAddress:         host.NetAddress,
+			HostPrices:         hostPrices(host),
+			ProjectedRenewCost: workload.cost(hostPrices(host)),
+			MarketRenewCost:    workload.cost(report.MedianPrices),
+		}
+		comparison.CostRatio = costRatio(comparison.ProjectedRenewCost, comparison.MarketRenewCost)
+		comparison.Overpriced = comparison.CostRatio >= AlertContractsOverpricedRatio
+		if comparison.Overpriced {
+			overpriced++
+		}
+		report.Contracts = append(report.Contracts, comparison)
+	}
+	sort.Slice(report.Contracts, func(i, j int) bool {
+		return report.Contracts[i].CostRatio > report.Contracts[j].CostRatio
+	})
+	r.staticPriceMonitor.managedSetReport(report)
+
+	if overpriced == 0 {
+		r.staticAlerter.UnregisterAlert(modules.AlertIDRenterContractsOverpriced)
+		return nil
+	}
+	cause := fmt.Sprintf("renewing %v of %v contracts is projected to cost at least %vx the market rate", overpriced, len(report.Contracts), AlertContractsOverpricedRatio)
+	r.staticAlerter.RegisterAlert(modules.AlertIDRenterContractsOverpriced, AlertMSGContractsOverpriced, cause, modules.SeverityWarning)
+	return nil
+}
+
+// threadedMonitorContractPrices periodically compares the prices of the hosts
+// the renter has contracts with against the market rate.
+func (r *Renter) threadedMonitorContractPrices() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		if err := r.managedCheckContractPrices(); err != nil {
+			r.log.Println("WARN: failed to check contract prices:", err)
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(priceCheckInterval):
+		}
+	}
+}
+
+// ContractPriceReport returns the latest comparison of the projected renewal
+// costs of the renter's contracts against the market rate.
+func (r *Renter) ContractPriceReport() modules.ContractPriceReport {
+	return r.staticPriceMonitor.managedReport()
+}
@@ -0,0 +1,72 @@
+package renter
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMedianPrices is a unit test for medianPrices.
+func TestMedianPrices(t *testing.T) {
+	if median := medianPrices(nil); !median.StoragePrice.IsZero() {
+		t.Fatal("expected zero median without hosts", median)
+	}
+
+	host := func(storagePrice uint64) modules.HostDBEntry {
+		var entry modules.HostDBEntry
+		entry.StoragePrice = types.NewCurrency64(storagePrice)
+		entry.ContractPrice = types.NewCurrency64(storagePrice * 2)
+		return entry
+	}
+	hosts := []modules.HostDBEntry{host(5), host(1), host(100)}
+	median := medianPrices(hosts)
+	if !median.StoragePrice.Equals64(5) || !median.ContractPrice.Equals64(10) {
+		t.Fatal("wrong median of odd number of hosts", median)
+	}
+	median = medianPrices(append(hosts, host(7)))
+	if !median.StoragePrice.Equals64(6) || !median.ContractPrice.Equals64(12) {
+		t.Fatal("wrong median of even number of hosts", median)
+	}
+}
+
+// TestRenewWorkloadCost probes the projected renewal cost of a contract.
+func TestRenewWorkloadCost(t *testing.T) {
+	allowance := modules.Allowance{
+		Hosts:              10,
+		Period:             100,
+		RenewWindow:        50,
+		ExpectedStorage:    1000,
+		ExpectedUpload:     20,
+		ExpectedDownload:   30,
+		ExpectedRedundancy: 3,
+	}
+	w := newRenewWorkload(modules.RenterContract{}, allowance)
+	if w.duration != 150 || w.storage != 300 || w.upload != 600 || w.download != 300 {
+		t.Fatal("unexpected workload", w)
+	}
+
+	prices := modules.HostPrices{
+		ContractPrice:          types.NewCurrency64(1),
+		StoragePrice:           types.NewCurrency64(2),
+		UploadBandwidthPrice:   types.NewCurrency64(3),
+		DownloadBandwidthPrice: types.NewCurrency64(4),
+	}
+	expected := uint64(1 + 2*300*150 + 3*600 + 4*300)
+	if cost := w.cost(prices); !cost.Equals64(expected) {
+		t.Fatalf("expected cost %v but got %v", expected, cost)
+	}
+
+	// Doubling the prices of a host should double the ratio.
+	expensive := prices
+	expensive.ContractPrice = prices.ContractPrice.Mul64(2)
+	expensive.StoragePrice = prices.StoragePrice.Mul64(2)
+	expensive.UploadBandwidthPrice = prices.UploadBandwidthPrice.Mul64(2)
+	expensive.DownloadBandwidthPrice = prices.DownloadBandwidthPrice.Mul64(2)
+	if ratio := costRatio(w.cost(expensive), w.cost(prices)); ratio != 2 {
+		t.Fatal("expected ratio of 2 but got", ratio)
+	}
+	if ratio := costRatio(w.cost(prices), types.ZeroCurrency); ratio != 0 {
+		t.Fatal("expected ratio of 0 for zero market cost but got", ratio)
+	}
+}
@@ -36,6 +36,7 @@ var (
 	// before giving up on receiving a HasSector response from a single worker.
 	// This value is set as a global timeout because different download queries
 	// that have different timeouts will use the same projectChunkWorkerSet.
+	// It is the default of the interactive job timeout.
 	pcwsHasSectorTimeout = build.Select(build.Var{
 		Dev:      time.Minute * 1,
 		Standard: time.Minute * 3,
@@ -375,7 +376,7 @@ func (pcws *projectChunkWorkerSet) threadedFindWorkers(allWorkersLaunchedChan ch
 
 	// Create a context for finding jobs which has a timeout for waiting on
 	// HasSector requests to return.
-	ctx, cancel := context.WithTimeout(pcws.staticCtx, pcwsHasSectorTimeout)
+	ctx, cancel := context.WithTimeout(pcws.staticCtx, pcws.staticRenter.staticTimeouts.callJob(operationClassInteractive))
 	defer cancel()
 
 	// Launch all of the HasSector jobs for each worker. A channel is needed to
@@ -523,6 +524,11 @@ func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePer
 		return nil, errors.Compose(ErrProjectTimedOut, ErrRootNotFound)
 	}
 
+	// Don't download the chunk if downloads reached their bandwidth cap.
+	if err := pcws.staticRenter.staticBandwidthMeter.Check(modules.RenterBandwidthDownload); err != nil {
+		return nil, err
+	}
+
 	// Convenience variables.
 	ec := pcws.staticErasureCoder
 
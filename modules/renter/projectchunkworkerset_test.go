@@ -301,6 +301,7 @@ func testMultiple(t *testing.T, wt *workerTester) {
 func testNewPCWSByRoots(t *testing.T) {
 	r := new(Renter)
 	r.staticWorkerPool = new(workerPool)
+	r.staticTimeouts = newOperationTimeouts()
 
 	// create random roots
 	var root1 crypto.Hash
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/ratelimit"
@@ -29,6 +30,49 @@ type ContractSet struct {
 	mu         sync.Mutex
 	staticRL   *ratelimit.RateLimit
 	staticWal  *writeaheadlog.WAL
+
+	// sessionDialTimeout overrides the default sessionDialTimeout if it is
+	// set.
+	sessionDialTimeout time.Duration
+
+	// bandwidthMeter records the traffic of sessions which form or renew
+	// contracts.
+	bandwidthMeter *modules.BandwidthMeter
+}
+
+// SessionDialTimeout returns the maximum amount of time dialing a host when
+// creating a Session may take.
+func (cs *ContractSet) SessionDialTimeout() time.Duration {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.sessionDialTimeout > 0 {
+		return cs.sessionDialTimeout
+	}
+	return sessionDialTimeout
+}
+
+// SetSessionDialTimeout sets the maximum amount of time dialing a host when
+// creating a Session may take. A timeout of 0 restores the default.
+func (cs *ContractSet) SetSessionDialTimeout(timeout time.Duration) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.sessionDialTimeout = timeout
+}
+
+// BandwidthMeter returns the meter which records the traffic of sessions
+// which form or renew contracts.
+func (cs *ContractSet) BandwidthMeter() *modules.BandwidthMeter {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.bandwidthMeter
+}
+
+// SetBandwidthMeter sets the meter which records the traffic of sessions
+// which form or renew contracts.
+func (cs *ContractSet) SetBandwidthMeter(bm *modules.BandwidthMeter) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.bandwidthMeter = bm
 }
 
 // Acquire looks up the contract for the specified host key and locks it before
@@ -112,6 +156,29 @@ func (cs *ContractSet) InsertContract(rc modules.RecoverableContract, revTxn typ
 	}, roots)
 }
 
+// LoadContract loads the contract with the provided id from the files in the
+// set's directory. It adopts contracts whose files were placed in the
+// directory after the set was created, e.g. by replicating the metadata of
+// another renter.
+func (cs *ContractSet) LoadContract(id types.FileContractID) (modules.RenterContract, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if _, exists := cs.contracts[id]; exists {
+		return modules.RenterContract{}, errors.New("contract is already part of the set")
+	}
+	headerPath := filepath.Join(cs.staticDir, id.String()+contractHeaderExtension)
+	rootsPath := filepath.Join(cs.staticDir, id.String()+contractRootsExtension)
+	rcPath := filepath.Join(cs.staticDir, id.String()+refCounterExtension)
+	if err := cs.loadSafeContract(headerPath, rootsPath, rcPath, nil); err != nil {
+		return modules.RenterContract{}, err
+	}
+	sc, exists := cs.contracts[id]
+	if !exists {
+		return modules.RenterContract{}, errors.New("contract files belong to a different contract")
+	}
+	return sc.Metadata(), nil
+}
+
 // Len returns the number of contracts in the set.
 func (cs *ContractSet) Len() int {
 	cs.mu.Lock()
@@ -159,6 +226,18 @@ func (cs *ContractSet) PublicKey(id types.FileContractID) (crypto.PublicKey, boo
 	return safeContract.PublicKey(), true
 }
 
+// Sign signs the hash with the renter's key of the contract with the given id.
+// The contract is not locked.
+func (cs *ContractSet) Sign(id types.FileContractID, hash crypto.Hash) (crypto.Signature, bool) {
+	cs.mu.Lock()
+	safeContract, ok := cs.contracts[id]
+	cs.mu.Unlock()
+	if !ok {
+		return crypto.Signature{}, false
+	}
+	return safeContract.Sign(hash), true
+}
+
 // ViewAll returns the metadata of each contract in the set. The contracts are
 // not locked.
 func (cs *ContractSet) ViewAll() []modules.RenterContract {
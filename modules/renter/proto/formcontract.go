@@ -20,6 +20,10 @@ func (cs *ContractSet) FormContract(params modules.ContractParams, txnBuilder tr
 	if build.VersionCmp(params.Host.Version, modules.MinimumSupportedRenterHostProtocolVersion) < 0 {
 		return modules.RenterContract{}, nil, types.Transaction{}, nil, ErrBadHostVersion
 	}
+	// Don't contact the host if contract formation reached its bandwidth cap.
+	if err := cs.BandwidthMeter().Check(modules.RenterBandwidthContractFormation); err != nil {
+		return modules.RenterContract{}, nil, types.Transaction{}, nil, err
+	}
 
 	// Extract vars from params, for convenience.
 	allowance, host, funding, startHeight, endHeight, refundAddress := params.Allowance, params.Host, params.Funding, params.StartHeight, params.EndHeight, params.RefundAddress
@@ -125,7 +129,7 @@ func (cs *ContractSet) FormContract(params modules.ContractParams, txnBuilder tr
 	}()
 
 	// Initiate protocol.
-	s, err := cs.NewRawSession(host, startHeight, hdb, cancel)
+	s, err := cs.managedNewSession(host, startHeight, hdb, cancel, modules.RenterBandwidthContractFormation)
 	if err != nil {
 		return modules.RenterContract{}, nil, types.Transaction{}, nil, err
 	}
@@ -23,6 +23,10 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params modules.ContractP
 	if build.VersionCmp(params.Host.Version, "1.4.4") < 0 {
 		return modules.RenterContract{}, nil, ErrBadHostVersion
 	}
+	// Don't contact the host if contract formation reached its bandwidth cap.
+	if err := cs.BandwidthMeter().Check(modules.RenterBandwidthContractFormation); err != nil {
+		return modules.RenterContract{}, nil, err
+	}
 	return cs.managedNewRenewAndClear(oldContract, params, txnBuilder, tpool, hdb, cancel)
 }
 
@@ -79,7 +83,7 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 	}()
 
 	// Initiate protocol.
-	s, err := cs.NewRawSession(host, startHeight, hdb, cancel)
+	s, err := cs.managedNewSession(host, startHeight, hdb, cancel, modules.RenterBandwidthContractFormation)
 	if err != nil {
 		return modules.RenterContract{}, nil, err
 	}
@@ -23,7 +23,7 @@ import (
 )
 
 // sessionDialTimeout determines how long a Session will try to dial a host
-// before aborting.
+// before aborting, unless the ContractSet overrides it.
 var sessionDialTimeout = build.Select(build.Var{
 	Testing:  5 * time.Second,
 	Dev:      20 * time.Second,
@@ -838,7 +838,7 @@ func (cs *ContractSet) NewSession(host modules.HostDBEntry, id types.FileContrac
 		return nil, errors.New("could not locate contract to create session")
 	}
 	defer cs.Return(sc)
-	s, err := cs.managedNewSession(host, currentHeight, hdb, cancel)
+	s, err := cs.managedNewSession(host, currentHeight, hdb, cancel, "")
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to create a new session with the host")
 	}
@@ -863,11 +863,18 @@ func (cs *ContractSet) NewSession(host modules.HostDBEntry, id types.FileContrac
 
 // NewRawSession creates a new session unassociated with any contract.
 func (cs *ContractSet) NewRawSession(host modules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB, cancel <-chan struct{}) (_ *Session, err error) {
-	return cs.managedNewSession(host, currentHeight, hdb, cancel)
+	return cs.managedNewSession(host, currentHeight, hdb, cancel, "")
 }
 
 // managedNewSession initiates the RPC loop with a host and returns a Session.
-func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB, cancel <-chan struct{}) (_ *Session, err error) {
+// If a bandwidth category is provided, the traffic of the session is recorded
+// with the contract set's bandwidth meter.
+func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB, cancel <-chan struct{}, category modules.RenterBandwidthCategory) (_ *Session, err error) {
+	var bm *modules.BandwidthMeter
+	if category != "" {
+		bm = cs.BandwidthMeter()
+	}
+
 	// Increase Successful/Failed interactions accordingly
 	defer func() {
 		if err != nil {
@@ -887,11 +894,12 @@ func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight
 
 	c, err := (&net.Dialer{
 		Cancel:  cancel,
-		Timeout: sessionDialTimeout,
+		Timeout: cs.SessionDialTimeout(),
 	}).Dial("tcp", string(host.NetAddress))
 	if err != nil {
 		return nil, errors.AddContext(err, "unsuccessful dial when creating a new session")
 	}
+	c = bm.WrapConn(c, category)
 	conn := ratelimit.NewRLConn(c, cs.staticRL, cancel)
 
 	closeChan := make(chan struct{})
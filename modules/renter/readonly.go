@@ -0,0 +1,31 @@
+package renter
+
+// ReadOnly returns whether the renter is in read-only mode. The read-only mode
+// is enforced by the API, which rejects requests that modify the renter's
+// files or allowance while it is enabled.
+func (r *Renter) ReadOnly() bool {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return r.persist.ReadOnly
+}
+
+// SetReadOnly enables or disables the renter's read-only mode.
+func (r *Renter) SetReadOnly(readOnly bool) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// A standby stays read-only until it is promoted.
+	if !readOnly && r.staticReplicator.managedStandby() {
+		return errReplicationStandbyReadOnly
+	}
+
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	if r.persist.ReadOnly == readOnly {
+		return nil
+	}
+	r.persist.ReadOnly = readOnly
+	return r.saveSync()
+}
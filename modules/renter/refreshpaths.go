@@ -109,12 +109,13 @@ func (urp *uniqueRefreshPaths) callRefreshAllBlocking() {
 // refreshAll calls the urp's Renter's managedBubbleMetadata method on all the
 // directories in the childDir map
 func (urp *uniqueRefreshPaths) refreshAll() {
-	// Create a siaPath channel with numBubbleWorkerThreads spaces
-	siaPathChan := make(chan modules.SiaPath, numBubbleWorkerThreads)
+	// Create a siaPath channel with a space for every worker
+	numThreads := urp.r.staticConcurrency.callHealthCheckThreads()
+	siaPathChan := make(chan modules.SiaPath, numThreads)
 
 	// Launch worker groups
 	var wg sync.WaitGroup
-	for i := 0; i < numBubbleWorkerThreads; i++ {
+	for i := 0; i < numThreads; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
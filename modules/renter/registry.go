@@ -304,7 +304,7 @@ func (r *Renter) managedReadRegistry(ctx context.Context, spk types.SiaPublicKey
 	numRegistryWorkers := 0
 	for _, worker := range workers {
 		cache := worker.staticCache()
-		if build.VersionCmp(cache.staticHostVersion, minRegistryVersion) < 0 {
+		if !cache.staticHostCapabilities.Has(modules.CapabilityRegistry) {
 			continue
 		}
 
@@ -455,7 +455,7 @@ func (r *Renter) managedUpdateRegistry(ctx context.Context, spk types.SiaPublicK
 	numRegistryWorkers := 0
 	for _, worker := range workers {
 		cache := worker.staticCache()
-		if build.VersionCmp(cache.staticHostVersion, minRegistryVersion) < 0 {
+		if !cache.staticHostCapabilities.Has(modules.CapabilityRegistry) {
 			continue
 		}
 
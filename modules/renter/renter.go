@@ -74,9 +74,30 @@ type hostContractor interface {
 	// Allowance returns the current allowance
 	Allowance() modules.Allowance
 
+	// AllowanceProfiles returns the allowance profiles sorted by name.
+	AllowanceProfiles() []modules.AllowanceProfile
+
+	// ActivateAllowanceProfile sets the allowance to the allowance of a
+	// profile.
+	ActivateAllowanceProfile(name string) error
+
+	// AllowanceProfileDiff previews how the contracts would change if a
+	// profile was activated.
+	AllowanceProfileDiff(name string) (modules.AllowanceProfileDiff, error)
+
+	// DeleteAllowanceProfile deletes an allowance profile.
+	DeleteAllowanceProfile(name string) error
+
+	// SaveAllowanceProfile creates or replaces an allowance profile.
+	SaveAllowanceProfile(name string, a modules.Allowance) error
+
 	// Close closes the hostContractor.
 	Close() error
 
+	// AdoptContract loads a contract whose files were placed in the contract
+	// set's directory after the contractor was started.
+	AdoptContract(id types.FileContractID) error
+
 	// CancelContract cancels the Renter's contract
 	CancelContract(id types.FileContractID) error
 
@@ -90,6 +111,15 @@ type hostContractor interface {
 	// signature on a contract.
 	ContractPublicKey(pk types.SiaPublicKey) (crypto.PublicKey, bool)
 
+	// DelegatedReadToken creates a token which authorizes the delegate to
+	// download the sectors of a contract from its host until the expiry
+	// height.
+	DelegatedReadToken(id types.FileContractID, delegate types.SiaPublicKey, expiry types.BlockHeight) (modules.DelegatedReadToken, error)
+
+	// IngressProof creates an ingress proof for the RPC with the given id
+	// which is signed with the renter's key of the contract.
+	IngressProof(id types.FileContractID, hostKey types.SiaPublicKey, rpcID types.Specifier) (modules.RPCIngressProof, error)
+
 	// ChurnStatus returns contract churn stats for the current period.
 	ChurnStatus() modules.ContractorChurnStatus
 
@@ -135,6 +165,18 @@ type hostContractor interface {
 	// Session creates a Session from the specified contract ID.
 	Session(types.SiaPublicKey, <-chan struct{}) (contractor.Session, error)
 
+	// SessionDialTimeout returns the maximum amount of time dialing a host
+	// when creating a Session may take.
+	SessionDialTimeout() time.Duration
+
+	// SetSessionDialTimeout sets the maximum amount of time dialing a host
+	// when creating a Session may take. 0 restores the default.
+	SetSessionDialTimeout(time.Duration)
+
+	// SetBandwidthMeter sets the meter which records the traffic of forming
+	// and renewing contracts.
+	SetBandwidthMeter(*modules.BandwidthMeter)
+
 	// RecoverableContracts returns the contracts that the contractor deems
 	// recoverable. That means they are not expired yet and also not part of the
 	// active contracts. Usually this should return an empty slice unless the host
@@ -179,6 +221,7 @@ type cachedUtilities struct {
 	goodForRenew map[string]bool
 	contracts    map[string]modules.RenterContract
 	used         []types.SiaPublicKey
+	failureRates map[string]float64
 }
 
 // A Renter is responsible for tracking all of the files that a user has
@@ -198,11 +241,33 @@ type Renter struct {
 	downloadHistory   map[modules.DownloadID]*download
 	downloadHistoryMu sync.Mutex
 
+	// URL upload history. Uploads from a remote URL are tracked from the time
+	// the remote server responds until the data is available on the network.
+	urlUploadHistory   map[modules.URLUploadID]*urlUpload
+	urlUploadHistoryMu sync.Mutex
+
 	// Upload management.
 	uploadHeap    uploadHeap
 	directoryHeap directoryHeap
 	stuckStack    stuckStack
 
+	// Restore mode prioritizes the renter's resources towards downloads from a
+	// single dir.
+	restoreMode restoreMode
+
+	// Migration of data away from blocklisted hosts and hosts which were
+	// removed from the hostdb.
+	staticMigrations *migrationTracker
+
+	// Comparison of the renter's contract prices against the market rate.
+	staticPriceMonitor *priceMonitor
+
+	// Counters of the jobs executed by the renter's workers.
+	staticOperationCounters *modules.OperationCounters
+
+	// Usage and caps of the renter's bandwidth per category of traffic.
+	staticBandwidthMeter *modules.BandwidthMeter
+
 	// Cache the hosts from the last price estimation result.
 	lastEstimationHosts []modules.HostDBEntry
 
@@ -222,6 +287,12 @@ type Renter struct {
 	statsChan chan struct{}
 	statsMu   sync.Mutex
 
+	// fsckMu ensures that only one check of the filesystem runs at a time.
+	fsckMu sync.Mutex
+
+	// Replication of the metadata of a primary renter.
+	staticReplicator *replicator
+
 	// read registry stats
 	staticRRS *readRegistryStats
 
@@ -254,15 +325,22 @@ type Renter struct {
 	mu                                 *siasync.RWMutex
 	repairLog                          *persist.Logger
 	staticAccountManager               *accountManager
+	staticAccountSpendingCaps          *accountSpendingCaps
 	staticAlerter                      *modules.GenericAlerter
+	staticConcurrency                  *concurrencyLimits
+	staticPiecePlacement               *piecePlacement
+	staticDirConverter                 *dirConverter
 	staticFileSystem                   *filesystem.FileSystem
 	staticFuseManager                  renterFuseManager
 	staticStreamBufferSet              *streamBufferSet
+	staticStreamCache                  *streamCache
+	staticTimeouts                     *operationTimeouts
 	tg                                 threadgroup.ThreadGroup
 	tpool                              modules.TransactionPool
 	wal                                *writeaheadlog.WAL
 	staticWorkerPool                   *workerPool
 	staticMux                          *siamux.SiaMux
+	staticStreamPool                   *streamPool
 	memoryManager                      *memoryManager
 	staticUploadChunkDistributionQueue *uploadChunkDistributionQueue
 }
@@ -528,16 +606,28 @@ func (r *Renter) callRenterContractsAndUtilities() (offline map[string]bool, goo
 	return cu.offline, cu.goodForRenew, cu.contracts, cu.used
 }
 
+// callHostFailureRates returns the cached failure rates of the renter's hosts.
+// They are updated together with the contracts and utilities by calling
+// managedUpdateRenterContractsAndUtilities.
+func (r *Renter) callHostFailureRates() map[string]float64 {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	return r.cachedUtilities.failureRates
+}
+
 // managedUpdateRenterContractsAndUtilities grabs the pubkeys of the hosts that
 // the file(s) have been uploaded to and then generates maps of the contract's
 // utilities showing which hosts are GoodForRenew and which hosts are Offline.
 // Additionally a map of host pubkeys to renter contract is created. The offline
 // and goodforrenew maps are needed for calculating redundancy and other file
-// metrics. All of that information is cached within the renter.
+// metrics. The failure rates of the hosts are used for the adaptive repair
+// thresholds of the files. All of that information is cached within the
+// renter.
 func (r *Renter) managedUpdateRenterContractsAndUtilities() {
 	var used []types.SiaPublicKey
 	goodForRenew := make(map[string]bool)
 	offline := make(map[string]bool)
+	failureRates := make(map[string]float64)
 	allContracts := r.hostContractor.Contracts()
 	contracts := make(map[string]modules.RenterContract)
 	for _, contract := range allContracts {
@@ -546,6 +636,9 @@ func (r *Renter) managedUpdateRenterContractsAndUtilities() {
 		goodForRenew[pk.String()] = cu.GoodForRenew
 		offline[pk.String()] = r.hostContractor.IsOffline(pk)
 		contracts[pk.String()] = contract
+		if host, exists, err := r.hostDB.Host(pk); err == nil && exists {
+			failureRates[pk.String()] = modules.HostFailureRate(host)
+		}
 		if cu.GoodForRenew {
 			used = append(used, pk)
 		}
@@ -566,6 +659,7 @@ func (r *Renter) managedUpdateRenterContractsAndUtilities() {
 		goodForRenew: goodForRenew,
 		contracts:    contracts,
 		used:         used,
+		failureRates: failureRates,
 	}
 	r.mu.Unlock(id)
 }
@@ -614,8 +708,13 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	// Set IPViolationsCheck
 	r.hostDB.SetIPViolationCheck(s.IPViolationCheck)
 
-	// Set the bandwidth limits.
-	err = r.setBandwidthLimits(s.MaxDownloadSpeed, s.MaxUploadSpeed)
+	// Set the bandwidth limits. While in restore mode, the download bandwidth
+	// stays unlimited until restore mode is stopped.
+	downloadSpeed := s.MaxDownloadSpeed
+	if r.restoreMode.managedActive() {
+		downloadSpeed = 0
+	}
+	err = r.setBandwidthLimits(downloadSpeed, s.MaxUploadSpeed)
 	if err != nil {
 		return err
 	}
@@ -712,6 +811,9 @@ func (r *Renter) SetFilterMode(lm modules.FilterMode, hosts []types.SiaPublicKey
 		return err
 	}
 
+	// Start migrating data away from newly filtered hosts right away.
+	r.staticMigrations.callTriggerCheck()
+
 	return nil
 }
 
@@ -772,6 +874,44 @@ func (r *Renter) ContractorChurnStatus() modules.ContractorChurnStatus {
 	return r.hostContractor.ChurnStatus()
 }
 
+// AllowanceProfiles returns the renter's allowance profiles sorted by name.
+func (r *Renter) AllowanceProfiles() []modules.AllowanceProfile {
+	return r.hostContractor.AllowanceProfiles()
+}
+
+// ActivateAllowanceProfile replaces the renter's allowance with the allowance
+// of the profile.
+func (r *Renter) ActivateAllowanceProfile(name string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	err := r.hostContractor.ActivateAllowanceProfile(name)
+	if err != nil {
+		return err
+	}
+	// Update the worker pool so that the changes are immediately apparent to
+	// users.
+	r.staticWorkerPool.callUpdate()
+	return nil
+}
+
+// AllowanceProfileDiff previews the changes to the renter's contracts which
+// activating the profile would cause.
+func (r *Renter) AllowanceProfileDiff(name string) (modules.AllowanceProfileDiff, error) {
+	return r.hostContractor.AllowanceProfileDiff(name)
+}
+
+// DeleteAllowanceProfile deletes an allowance profile.
+func (r *Renter) DeleteAllowanceProfile(name string) error {
+	return r.hostContractor.DeleteAllowanceProfile(name)
+}
+
+// SaveAllowanceProfile creates or replaces an allowance profile.
+func (r *Renter) SaveAllowanceProfile(name string, a modules.Allowance) error {
+	return r.hostContractor.SaveAllowanceProfile(name, a)
+}
+
 // InitRecoveryScan starts scanning the whole blockchain for recoverable
 // contracts within a separate thread.
 func (r *Renter) InitRecoveryScan() error {
@@ -822,6 +962,7 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 		IPViolationCheck: enabled,
 		MaxDownloadSpeed: download,
 		MaxUploadSpeed:   upload,
+		ReadOnly:         r.ReadOnly(),
 		UploadsStatus: modules.UploadsStatus{
 			Paused:       paused,
 			PauseEndTime: endTime,
@@ -898,6 +1039,7 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 			repairingChunks:   make(map[uploadChunkID]*unfinishedUploadChunk),
 			stuckHeapChunks:   make(map[uploadChunkID]*unfinishedUploadChunk),
 			unstuckHeapChunks: make(map[uploadChunkID]*unfinishedUploadChunk),
+			verifyingChunks:   make(map[uploadChunkID]int),
 
 			newUploads:        make(chan struct{}, 1),
 			repairNeeded:      make(chan struct{}, 1),
@@ -910,7 +1052,10 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 			heapDirectories: make(map[modules.SiaPath]*directory),
 		},
 
-		downloadHistory: make(map[modules.DownloadID]*download),
+		downloadHistory:    make(map[modules.DownloadID]*download),
+		urlUploadHistory:   make(map[modules.URLUploadID]*urlUpload),
+		staticMigrations:   newMigrationTracker(),
+		staticPriceMonitor: newPriceMonitor(),
 
 		cs:             cs,
 		deps:           deps,
@@ -927,6 +1072,11 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	}
 	r.staticBubbleScheduler = newBubbleScheduler(r)
 	r.staticStreamBufferSet = newStreamBufferSet(&r.tg)
+	r.staticStreamCache = newStreamCache()
+	r.staticAccountSpendingCaps = newAccountSpendingCaps()
+	r.staticConcurrency = newConcurrencyLimits()
+	r.staticPiecePlacement = newPiecePlacement()
+	r.staticTimeouts = newOperationTimeouts()
 	r.staticUploadChunkDistributionQueue = newUploadChunkDistributionQueue(r)
 	r.staticRRS = newReadRegistryStats(ReadRegistryBackgroundTimeout, readRegistryStatsInterval, readRegistryStatsDecay, readRegistryStatsPercentile)
 	close(r.uploadHeap.pauseChan)
@@ -959,6 +1109,41 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Load the operation counters of the workers.
+	r.staticOperationCounters, err = modules.NewOperationCounters(filepath.Join(r.persistDir, modules.OperationCountersFilename))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.tg.AfterStop(r.staticOperationCounters.Save); err != nil {
+		return nil, err
+	}
+
+	// Load the bandwidth usage and meter the traffic of the hostdb and
+	// contractor.
+	r.staticBandwidthMeter, err = modules.NewBandwidthMeter(filepath.Join(r.persistDir, modules.RenterBandwidthFilename))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.tg.AfterStop(r.staticBandwidthMeter.Save); err != nil {
+		return nil, err
+	}
+	r.hostDB.SetBandwidthMeter(r.staticBandwidthMeter)
+	r.hostContractor.SetBandwidthMeter(r.staticBandwidthMeter)
+
+	// Create the stream pool the workers use to talk to their hosts.
+	poolSize, err := parseStreamPoolSize(build.RenterStreamPoolSize())
+	if err != nil {
+		r.log.Println("WARN: failed to get stream pool size, using the default:", err)
+		poolSize = defaultStreamPoolSize
+	}
+	r.staticStreamPool, err = newStreamPool(mux, poolSize, r.persistDir, r.log)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create stream pool")
+	}
+	if err := r.tg.AfterStop(r.staticStreamPool.Close); err != nil {
+		return nil, err
+	}
+
 	// Initialize some of the components.
 	err = r.newAccountManager()
 	if err != nil {
@@ -979,6 +1164,18 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Load the directory conversion.
+	r.staticDirConverter, err = newDirConverter(r.persistDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the replication.
+	r.staticReplicator, err = newReplicator(r.persistDir)
+	if err != nil {
+		return nil, err
+	}
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
@@ -1038,11 +1235,21 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
 		go r.threadedUploadAndRepair()
 		go r.threadedStuckFileLoop()
+		go r.threadedMigrateHosts()
+	}
+	// Resume an interrupted directory conversion.
+	if r.staticDirConverter.managedActive() {
+		go r.threadedConvertDir()
+	}
+	// Resume replicating the primary's metadata.
+	if r.staticReplicator.managedStandby() {
+		go r.threadedReplicate()
 	}
 	// Spin up the snapshot synchronization thread.
 	if !r.deps.Disrupt("DisableSnapshotSync") {
 		go r.threadedSynchronizeSnapshots()
 	}
+	go r.threadedMonitorContractPrices()
 	return nil
 }
 
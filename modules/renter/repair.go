@@ -715,7 +715,8 @@ func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath modules.SiaPath, of
 	// Define common variables
 	var errs error
 	var errMU sync.Mutex
-	fileSiaPathChan := make(chan modules.SiaPath, numBubbleWorkerThreads)
+	numThreads := r.staticConcurrency.callHealthCheckThreads()
+	fileSiaPathChan := make(chan modules.SiaPath, numThreads)
 
 	// Define the fileWorker
 	fileWorker := func() {
@@ -736,7 +737,7 @@ func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath modules.SiaPath, of
 
 	// Launch file workers
 	var wg sync.WaitGroup
-	for i := 0; i < numBubbleWorkerThreads; i++ {
+	for i := 0; i < numThreads; i++ {
 		wg.Add(1)
 		go func() {
 			fileWorker()
@@ -789,6 +790,8 @@ func (r *Renter) managedUpdateFileMetadata(sf *filesystem.FileNode, offlineMap,
 	sf.SetLastHealthCheckTime()
 	// Update the cached expiration of the siafile.
 	_ = sf.Expiration(contracts)
+	// Update the cached repair threshold of the siafile.
+	_ = sf.UpdateRepairThreshold(r.callHostFailureRates())
 	// Save the metadata.
 	err = sf.SaveMetadata()
 	if err != nil {
@@ -0,0 +1,880 @@
+package renter
+
+// replication.go contains the replication of a renter's metadata to a standby
+// renter. The standby periodically asks the primary's API for the files of
+// the filesystem and the contract set which changed since the last
+// synchronization and mirrors them into a replica within its persist
+// directory. Changes are detected using the modification times of the files
+// and directories on the primary, so the primary doesn't need to keep track
+// of them.
+//
+// The primary's API is reached over https unless it runs on the loopback
+// interface, since the standby sends the primary's API password and receives
+// the contracts' secret keys. The password is only kept in memory and needs to
+// be provided again after the standby restarts.
+//
+// The standby is read-only while it replicates the primary. Promoting it
+// moves the replica into its own filesystem and contract set and activates
+// the primary's allowance. Contract revisions which happened after the last
+// synchronization are recovered from the hosts when the contracts are used.
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// replicationFile is the name of the file the state of the replication is
+	// persisted to.
+	replicationFile = "replication.json"
+
+	// replicationDir is the folder within the renter's persist directory the
+	// replica of the primary's metadata is stored in.
+	replicationDir = "replica"
+
+	// replicationBatchSize is the maximum number of files which are fetched
+	// from the primary with a single request.
+	replicationBatchSize = 1000
+
+	// replicationModTimeSlack is subtracted from the start of a manifest to
+	// account for filesystems which store modification times with a low
+	// resolution.
+	replicationModTimeSlack = 2 * time.Second
+
+	// replicationRequestTimeout is the timeout of a request to the primary.
+	replicationRequestTimeout = 5 * time.Minute
+)
+
+var (
+	// replicationInterval is the time between two synchronizations of a
+	// standby with its primary.
+	replicationInterval = build.Select(build.Var{
+		Standard: 5 * time.Second,
+		Dev:      2 * time.Second,
+		Testing:  250 * time.Millisecond,
+	}).(time.Duration)
+
+	// replicationMetadata is the metadata of the persisted replication.
+	replicationMetadata = persist.Metadata{
+		Header:  "Replication",
+		Version: "1.5.6",
+	}
+)
+
+var (
+	// errInvalidReplicationAddress is returned if the address of the primary
+	// can't be parsed.
+	errInvalidReplicationAddress = errors.New("invalid address of the primary")
+
+	// errInvalidReplicationPath is returned if a path of the replicated
+	// metadata isn't clean or doesn't start with a replication root.
+	errInvalidReplicationPath = errors.New("invalid replication path")
+
+	// errNotReplicationStandby is returned when trying to promote a renter
+	// which isn't a standby.
+	errNotReplicationStandby = errors.New("renter is not a replication standby")
+
+	// errReplicationStandbyReadOnly is returned when trying to leave the
+	// read-only mode of a standby without promoting it.
+	errReplicationStandbyReadOnly = errors.New("replication standby stays read-only until it is promoted")
+
+	// errReplicationHasContracts is returned when trying to make a renter
+	// with contracts a standby.
+	errReplicationHasContracts = errors.New("renter with contracts can't become a replication standby")
+
+	// errReplicationInsecure is returned if the primary's API would be
+	// reached over plain http on a host other than the loopback interface.
+	errReplicationInsecure = errors.New("the primary's API must be reached over https unless it runs on the loopback interface")
+
+	// errReplicationPasswordRequired is returned by a standby which was
+	// restarted until the primary's API password is provided again.
+	errReplicationPasswordRequired = errors.New("the primary's API password is not persisted and needs to be provided again after a restart")
+)
+
+type (
+	// replicator is the standby side of the replication.
+	replicator struct {
+		// primaryPassword is only valid if passwordSet is true. It is not
+		// persisted.
+		primaryAddress  string
+		primaryPassword string
+		passwordSet     bool
+
+		// since is the time passed to the primary for the next manifest and
+		// files are the replicated files with their size and modification
+		// time on the primary.
+		since     time.Time
+		files     map[string]replicatedFile
+		allowance modules.Allowance
+
+		lastSync time.Time
+		lastErr  error
+		running  bool
+
+		// syncMu is held while the replica is synchronized with the
+		// primary.
+		syncMu sync.Mutex
+
+		staticClient      *http.Client
+		staticPersistPath string
+		staticReplicaDir  string
+		mu                sync.Mutex
+	}
+
+	// replicatedFile is a file of the replica.
+	replicatedFile struct {
+		Size    int64     `json:"size"`
+		ModTime time.Time `json:"modtime"`
+	}
+
+	// replicationPersist is the persisted state of the replicator.
+	replicationPersist struct {
+		PrimaryAddress string                    `json:"primaryaddress"`
+		Since          time.Time                 `json:"since"`
+		Files          map[string]replicatedFile `json:"files"`
+		Allowance      modules.Allowance         `json:"allowance"`
+	}
+)
+
+// newReplicator creates a new replicator and loads the persisted replication
+// from persistDir if there is one.
+func newReplicator(persistDir string) (*replicator, error) {
+	rep := &replicator{
+		files: make(map[string]replicatedFile),
+		// Redirects are not followed so that the password is never sent to
+		// another address than the configured one.
+		staticClient: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		staticPersistPath: filepath.Join(persistDir, replicationFile),
+		staticReplicaDir:  filepath.Join(persistDir, replicationDir),
+	}
+	var p replicationPersist
+	err := persist.LoadJSON(replicationMetadata, &p, rep.staticPersistPath)
+	if os.IsNotExist(err) {
+		return rep, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load replication")
+	}
+	rep.primaryAddress = p.PrimaryAddress
+	rep.since = p.Since
+	rep.allowance = p.Allowance
+	if p.Files != nil {
+		rep.files = p.Files
+	}
+	return rep, nil
+}
+
+// save persists the replicator.
+func (rep *replicator) save() error {
+	return persist.SaveJSON(replicationMetadata, replicationPersist{
+		PrimaryAddress: rep.primaryAddress,
+		Since:          rep.since,
+		Files:          rep.files,
+		Allowance:      rep.allowance,
+	}, rep.staticPersistPath)
+}
+
+// managedStandby returns whether the renter is a standby.
+func (rep *replicator) managedStandby() bool {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	return rep.primaryAddress != ""
+}
+
+// replicationRoots returns the directories on disk of the replication roots.
+func (r *Renter) replicationRoots() map[string]string {
+	return map[string]string{
+		modules.ReplicationRootFileSystem: r.staticFileSystem.Root(),
+		modules.ReplicationRootContracts:  filepath.Join(r.persistDir, "contracts"),
+	}
+}
+
+// replicationPath returns the path on disk of a path of the replicated
+// metadata within the provided roots.
+func replicationPath(roots map[string]string, p string) (string, error) {
+	if p == "" || p != path.Clean(p) || path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return "", errors.AddContext(errInvalidReplicationPath, p)
+	}
+	elems := strings.SplitN(p, "/", 2)
+	dir, exists := roots[elems[0]]
+	if !exists {
+		return "", errors.AddContext(errInvalidReplicationPath, p)
+	}
+	if len(elems) == 1 {
+		return dir, nil
+	}
+	return filepath.Join(dir, filepath.FromSlash(elems[1])), nil
+}
+
+// replicationIncluded returns whether a file of a replication root is
+// replicated. Temporary files and the WAL of the contract set are skipped.
+func replicationIncluded(root, name string) bool {
+	if strings.HasSuffix(name, fsckTempSuffix) {
+		return false
+	}
+	if root != modules.ReplicationRootContracts {
+		return true
+	}
+	switch filepath.Ext(name) {
+	case ".header", ".roots", ".rc":
+		return true
+	}
+	return false
+}
+
+// replicationListing returns the listing of the directory at dir.
+func replicationListing(root, rel, dir string) (modules.ReplicationListing, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return modules.ReplicationListing{}, err
+	}
+	listing := modules.ReplicationListing{
+		Path:    rel,
+		Entries: []modules.ReplicationEntry{},
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() && !replicationIncluded(root, fi.Name()) {
+			continue
+		}
+		listing.Entries = append(listing.Entries, modules.ReplicationEntry{
+			Path:    path.Join(rel, fi.Name()),
+			Dir:     fi.IsDir(),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+		})
+	}
+	return listing, nil
+}
+
+// ReplicationManifest returns the changes of the renter's metadata since the
+// provided time. A zero time returns all of the renter's metadata.
+func (r *Renter) ReplicationManifest(since time.Time) (modules.ReplicationManifest, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ReplicationManifest{}, err
+	}
+	defer r.tg.Done()
+
+	manifest := modules.ReplicationManifest{
+		Time:      time.Now(),
+		Files:     []modules.ReplicationEntry{},
+		Listings:  []modules.ReplicationListing{},
+		Allowance: r.hostContractor.Allowance(),
+	}
+	if !since.IsZero() {
+		since = since.Add(-replicationModTimeSlack)
+	}
+	roots := r.replicationRoots()
+	for _, root := range []string{modules.ReplicationRootFileSystem, modules.ReplicationRootContracts} {
+		rootDir := roots[root]
+		err := filepath.Walk(rootDir, func(p string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				// The file was removed during the walk.
+				return nil
+			} else if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(rootDir, p)
+			if err != nil {
+				return err
+			}
+			rel := path.Join(root, filepath.ToSlash(relPath))
+			if info.ModTime().Before(since) {
+				return nil
+			}
+			if !info.IsDir() {
+				if replicationIncluded(root, info.Name()) {
+					manifest.Files = append(manifest.Files, modules.ReplicationEntry{
+						Path:    rel,
+						Size:    info.Size(),
+						ModTime: info.ModTime(),
+					})
+				}
+				return nil
+			}
+			listing, err := replicationListing(root, rel, p)
+			if os.IsNotExist(err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			manifest.Listings = append(manifest.Listings, listing)
+			return nil
+		})
+		if err != nil {
+			return modules.ReplicationManifest{}, errors.AddContext(err, "unable to walk "+root)
+		}
+	}
+	return manifest, nil
+}
+
+// WriteReplicationFiles writes a tar archive of the metadata files at the
+// provided paths to w. Files which don't exist anymore are skipped.
+func (r *Renter) WriteReplicationFiles(w io.Writer, paths []string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	roots := r.replicationRoots()
+	tw := tar.NewWriter(w)
+	for _, p := range paths {
+		filename, err := replicationPath(roots, p)
+		if err != nil {
+			return err
+		}
+		if !replicationIncluded(strings.SplitN(p, "/", 2)[0], filepath.Base(filename)) {
+			return errors.AddContext(errInvalidReplicationPath, p)
+		}
+		// Stat the file before reading it. If it is modified in between, it
+		// is part of the next manifest.
+		info, err := os.Stat(filename)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return errors.AddContext(errInvalidReplicationPath, p)
+		}
+		data, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Name:    p,
+			Mode:    int64(modules.DefaultFilePerm),
+			Size:    int64(len(data)),
+			ModTime: info.ModTime(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// replicationLoopback returns whether connections to host stay on the local
+// machine. An empty or unspecified host, like the address of an API listening
+// on all interfaces, is dialed on the loopback interface.
+func replicationLoopback(host string) bool {
+	if host == "" || host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// replicationURL returns the base URL of the primary's API at address. An
+// address without a scheme uses https, or http if it is on the loopback
+// interface. Plain http is refused for any other host.
+func replicationURL(address string) (string, error) {
+	scheme := ""
+	if i := strings.Index(address, "://"); i >= 0 {
+		scheme, address = address[:i], address[i+len("://"):]
+	}
+	u, err := url.Parse("//" + address)
+	if err != nil {
+		return "", errors.Compose(errInvalidReplicationAddress, err)
+	}
+	if u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.AddContext(errInvalidReplicationAddress, address)
+	}
+	loopback := replicationLoopback(u.Hostname())
+	switch scheme {
+	case "":
+		scheme = "https"
+		if loopback {
+			scheme = "http"
+		}
+	case "https":
+	case "http":
+		if !loopback {
+			return "", errReplicationInsecure
+		}
+	default:
+		return "", errors.AddContext(errInvalidReplicationAddress, "unsupported scheme "+scheme)
+	}
+	u.Scheme = scheme
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// replicationRequest sends a request to the API of the primary.
+func (rep *replicator) replicationRequest(ctx context.Context, method, resource string, body io.Reader) (*http.Response, error) {
+	rep.mu.Lock()
+	address, password, passwordSet := rep.primaryAddress, rep.primaryPassword, rep.passwordSet
+	rep.mu.Unlock()
+	if !passwordSet {
+		return nil, errReplicationPasswordRequired
+	}
+	baseURL, err := replicationURL(address)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+resource, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Sia-Agent")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.SetBasicAuth("", password)
+	resp, err := rep.staticClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return nil, errors.Compose(fmt.Errorf("primary returned %v: %v", resp.Status, apiErr.Message), resp.Body.Close())
+	}
+	return resp, nil
+}
+
+// managedFetchManifest fetches the manifest of the changes since the last
+// synchronization from the primary.
+func (rep *replicator) managedFetchManifest(ctx context.Context) (manifest modules.ReplicationManifest, err error) {
+	rep.mu.Lock()
+	var since int64
+	if !rep.since.IsZero() {
+		since = rep.since.UnixNano()
+	}
+	rep.mu.Unlock()
+	resp, err := rep.replicationRequest(ctx, http.MethodGet, "/renter/replication/manifest?since="+strconv.FormatInt(since, 10), nil)
+	if err != nil {
+		return modules.ReplicationManifest{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, resp.Body.Close())
+	}()
+	err = json.NewDecoder(resp.Body).Decode(&manifest)
+	return manifest, err
+}
+
+// managedFetchFiles fetches the files at the provided paths from the primary
+// and writes them to the replica.
+func (rep *replicator) managedFetchFiles(ctx context.Context, roots map[string]string, paths []string) (err error) {
+	requested := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		requested[p] = struct{}{}
+	}
+	encoded, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("paths", string(encoded))
+	resp, err := rep.replicationRequest(ctx, http.MethodPost, "/renter/replication/files", strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, resp.Body.Close())
+	}()
+
+	tr := tar.NewReader(resp.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, exists := requested[hdr.Name]; !exists {
+			return fmt.Errorf("primary sent unrequested file %v", hdr.Name)
+		}
+		filename, err := replicationPath(roots, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), modules.DefaultDirPerm); err != nil {
+			return err
+		}
+		tmp := filename + fsckTempSuffix
+		f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, modules.DefaultFilePerm)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		err = errors.Compose(err, f.Sync(), f.Close())
+		if err == nil {
+			err = os.Rename(tmp, filename)
+		}
+		if err != nil {
+			return errors.Compose(err, os.RemoveAll(tmp))
+		}
+		rep.mu.Lock()
+		rep.files[hdr.Name] = replicatedFile{Size: hdr.Size, ModTime: hdr.ModTime}
+		rep.mu.Unlock()
+	}
+}
+
+// managedApplyListing removes the entries of a replicated directory which
+// were removed on the primary. It returns the files which need to be fetched
+// and whether the listing contains directories which are new to the replica.
+func (rep *replicator) managedApplyListing(roots map[string]string, listing modules.ReplicationListing) (fetch []string, newDirs bool, err error) {
+	dir, err := replicationPath(roots, listing.Path)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		return nil, false, err
+	}
+	entries := make(map[string]modules.ReplicationEntry, len(listing.Entries))
+	for _, entry := range listing.Entries {
+		if path.Dir(entry.Path) != listing.Path {
+			return nil, false, errors.AddContext(errInvalidReplicationPath, entry.Path)
+		}
+		entries[path.Base(entry.Path)] = entry
+	}
+
+	// Remove the entries which don't exist on the primary anymore.
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	for _, fi := range fis {
+		entry, exists := entries[fi.Name()]
+		if exists && entry.Dir == fi.IsDir() {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, fi.Name())); err != nil {
+			return nil, false, err
+		}
+		removed := path.Join(listing.Path, fi.Name())
+		for p := range rep.files {
+			if p == removed || strings.HasPrefix(p, removed+"/") {
+				delete(rep.files, p)
+			}
+		}
+	}
+
+	// Collect the entries which are missing or outdated.
+	for name, entry := range entries {
+		if entry.Dir {
+			if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+				newDirs = true
+			}
+			continue
+		}
+		if f, exists := rep.files[entry.Path]; !exists || f.Size != entry.Size || !f.ModTime.Equal(entry.ModTime) {
+			fetch = append(fetch, entry.Path)
+		}
+	}
+	return fetch, newDirs, nil
+}
+
+// managedSync synchronizes the replica with the primary.
+func (rep *replicator) managedSync(ctx context.Context) error {
+	rep.syncMu.Lock()
+	defer rep.syncMu.Unlock()
+	if !rep.managedStandby() {
+		return nil
+	}
+
+	manifest, err := rep.managedFetchManifest(ctx)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch manifest")
+	}
+	roots := map[string]string{
+		modules.ReplicationRootFileSystem: filepath.Join(rep.staticReplicaDir, modules.ReplicationRootFileSystem),
+		modules.ReplicationRootContracts:  filepath.Join(rep.staticReplicaDir, modules.ReplicationRootContracts),
+	}
+
+	// Apply the listings first to find out which files are missing. A
+	// directory which was moved on the primary keeps the modification times
+	// of its contents, so the next manifest needs to cover all files.
+	fetch := make(map[string]struct{})
+	fullSync := false
+	for _, listing := range manifest.Listings {
+		paths, newDirs, err := rep.managedApplyListing(roots, listing)
+		if err != nil {
+			return errors.AddContext(err, "unable to apply listing")
+		}
+		for _, p := range paths {
+			fetch[p] = struct{}{}
+		}
+		fullSync = fullSync || newDirs
+	}
+	rep.mu.Lock()
+	for _, entry := range manifest.Files {
+		if f, exists := rep.files[entry.Path]; !exists || f.Size != entry.Size || !f.ModTime.Equal(entry.ModTime) {
+			fetch[entry.Path] = struct{}{}
+		}
+	}
+	rep.mu.Unlock()
+
+	// Fetch the files in batches.
+	paths := make([]string, 0, len(fetch))
+	for p := range fetch {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for len(paths) > 0 {
+		n := len(paths)
+		if n > replicationBatchSize {
+			n = replicationBatchSize
+		}
+		if err := rep.managedFetchFiles(ctx, roots, paths[:n]); err != nil {
+			return errors.AddContext(err, "unable to fetch files")
+		}
+		paths = paths[n:]
+	}
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	rep.allowance = manifest.Allowance
+	rep.since = manifest.Time
+	if fullSync {
+		rep.since = time.Time{}
+	}
+	rep.lastSync = time.Now()
+	return rep.save()
+}
+
+// threadedReplicate synchronizes the replica with the primary until the
+// renter is promoted or shuts down.
+func (r *Renter) threadedReplicate() {
+	rep := r.staticReplicator
+	rep.mu.Lock()
+	if rep.running {
+		rep.mu.Unlock()
+		return
+	}
+	rep.running = true
+	rep.mu.Unlock()
+	defer func() {
+		rep.mu.Lock()
+		rep.running = false
+		rep.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.tg.StopChan():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for rep.managedStandby() {
+		reqCtx, reqCancel := context.WithTimeout(ctx, replicationRequestTimeout)
+		err := rep.managedSync(reqCtx)
+		reqCancel()
+		rep.mu.Lock()
+		rep.lastErr = err
+		rep.mu.Unlock()
+		if err != nil {
+			r.log.Debugln("WARN: failed to synchronize replica:", err)
+		}
+
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(replicationInterval):
+		}
+	}
+}
+
+// ReplicationStatus returns the status of the replication.
+func (r *Renter) ReplicationStatus() modules.ReplicationStatus {
+	rep := r.staticReplicator
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	status := modules.ReplicationStatus{
+		Standby:        rep.primaryAddress != "",
+		PrimaryAddress: rep.primaryAddress,
+		LastSync:       rep.lastSync,
+	}
+	if rep.lastErr != nil {
+		status.LastError = rep.lastErr.Error()
+	}
+	for p := range rep.files {
+		if strings.HasPrefix(p, modules.ReplicationRootContracts+"/") {
+			if filepath.Ext(p) == ".header" {
+				status.Contracts++
+			}
+		} else {
+			status.Files++
+		}
+	}
+	return status
+}
+
+// StartReplicationStandby makes the renter a read-only standby which
+// continuously replicates the metadata of the primary renter at address.
+// password is the API password of the primary. A standby which was restarted
+// resumes the replication once this is called again with the password.
+func (r *Renter) StartReplicationStandby(address, password string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if address == "" {
+		return errors.New("address of the primary is missing")
+	}
+	if _, err := replicationURL(address); err != nil {
+		return err
+	}
+	if len(r.hostContractor.Contracts()) > 0 {
+		return errReplicationHasContracts
+	}
+	if err := r.SetReadOnly(true); err != nil {
+		return err
+	}
+
+	rep := r.staticReplicator
+	rep.mu.Lock()
+	rep.primaryAddress = address
+	rep.primaryPassword = password
+	rep.passwordSet = true
+	err := rep.save()
+	rep.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "unable to persist replication")
+	}
+	return r.tg.Launch(r.threadedReplicate)
+}
+
+// installReplicaFiles moves the replicated files of a root into dst
+// and returns their paths relative to the root.
+func installReplicaFiles(src, dst string) ([]string, error) {
+	var installed []string
+	err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), modules.DefaultDirPerm); err != nil {
+			return err
+		}
+		if err := os.Rename(p, target); err != nil {
+			return err
+		}
+		installed = append(installed, rel)
+		return nil
+	})
+	return installed, err
+}
+
+// PromoteReplicationStandby stops replicating the metadata of the primary
+// and takes over its files, contracts and allowance. The primary must not use
+// its contracts anymore.
+func (r *Renter) PromoteReplicationStandby() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Stop the replication and wait for a running synchronization to finish.
+	rep := r.staticReplicator
+	rep.mu.Lock()
+	if rep.primaryAddress == "" {
+		rep.mu.Unlock()
+		return errNotReplicationStandby
+	}
+	rep.primaryAddress = ""
+	rep.primaryPassword = ""
+	rep.passwordSet = false
+	allowance := rep.allowance
+	rep.mu.Unlock()
+	rep.syncMu.Lock()
+	defer rep.syncMu.Unlock()
+
+	// Adopt the contracts.
+	contracts, err := installReplicaFiles(filepath.Join(rep.staticReplicaDir, modules.ReplicationRootContracts), r.replicationRoots()[modules.ReplicationRootContracts])
+	if err != nil {
+		return errors.AddContext(err, "unable to install contracts")
+	}
+	for _, name := range contracts {
+		if filepath.Ext(name) != ".header" {
+			continue
+		}
+		var id types.FileContractID
+		if err := id.LoadString(strings.TrimSuffix(name, ".header")); err != nil {
+			return errors.AddContext(err, "invalid contract file "+name)
+		}
+		if err := r.hostContractor.AdoptContract(id); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("unable to adopt contract %v", id))
+		}
+	}
+
+	// Move the files into the filesystem.
+	_, err = installReplicaFiles(filepath.Join(rep.staticReplicaDir, modules.ReplicationRootFileSystem), r.replicationRoots()[modules.ReplicationRootFileSystem])
+	if err != nil {
+		return errors.AddContext(err, "unable to install filesystem")
+	}
+
+	// The replica is not needed anymore.
+	rep.mu.Lock()
+	rep.since = time.Time{}
+	rep.files = make(map[string]replicatedFile)
+	rep.allowance = modules.Allowance{}
+	err = errors.Compose(rep.save(), os.RemoveAll(rep.staticReplicaDir))
+	rep.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "unable to clear replica")
+	}
+
+	// Leave the read-only mode, update the metadata of the filesystem and
+	// activate the allowance.
+	if err := r.SetReadOnly(false); err != nil {
+		return err
+	}
+	if _, err := r.Fsck(true, false); err != nil {
+		return errors.AddContext(err, "unable to update filesystem metadata")
+	}
+	if allowance.Active() {
+		if err := r.hostContractor.SetAllowance(allowance); err != nil {
+			return errors.AddContext(err, "unable to set allowance")
+		}
+	}
+	r.staticWorkerPool.callUpdate()
+	return nil
+}
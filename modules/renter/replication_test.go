@@ -0,0 +1,84 @@
+package renter
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+// TestReplicationURL probes the URLs of the primary's API which are derived
+// from the configured address.
+func TestReplicationURL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		address string
+		url     string
+		err     error
+	}{
+		{"localhost:9980", "http://localhost:9980", nil},
+		{"127.0.0.1:9980", "http://127.0.0.1:9980", nil},
+		{"[::1]:9980", "http://[::1]:9980", nil},
+		{"[::]:9980", "http://[::]:9980", nil},
+		{":9980", "http://:9980", nil},
+		{"http://localhost:9980/", "http://localhost:9980", nil},
+		{"primary.example.com:9980", "https://primary.example.com:9980", nil},
+		{"https://primary.example.com:9980", "https://primary.example.com:9980", nil},
+		{"https://10.0.0.1:9980/sia", "https://10.0.0.1:9980/sia", nil},
+		{"http://primary.example.com:9980", "", errReplicationInsecure},
+		{"http://10.0.0.1:9980", "", errReplicationInsecure},
+		{"ftp://primary.example.com", "", errInvalidReplicationAddress},
+		{"https://user@primary.example.com", "", errInvalidReplicationAddress},
+		{"https://", "", errInvalidReplicationAddress},
+	}
+	for _, test := range tests {
+		u, err := replicationURL(test.address)
+		if (test.err == nil && err != nil) || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Fatalf("%v: expected error %v, got %v", test.address, test.err, err)
+		}
+		if u != test.url {
+			t.Fatalf("%v: expected %v, got %v", test.address, test.url, u)
+		}
+	}
+}
+
+// TestReplicationPasswordNotPersisted tests that the primary's API password
+// is not persisted and needs to be provided again after a restart.
+func TestReplicationPasswordNotPersisted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := newReplicator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.primaryAddress = "localhost:9980"
+	rep.primaryPassword = "password"
+	rep.passwordSet = true
+	if err := rep.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err = newReplicator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.managedStandby() || rep.primaryAddress != "localhost:9980" {
+		t.Fatal("replication wasn't persisted")
+	}
+	if rep.passwordSet || rep.primaryPassword != "" {
+		t.Fatal("password was persisted")
+	}
+	if _, err := rep.managedFetchManifest(context.Background()); !errors.Contains(err, errReplicationPasswordRequired) {
+		t.Fatal("expected errReplicationPasswordRequired, got", err)
+	}
+}
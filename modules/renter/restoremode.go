@@ -0,0 +1,305 @@
+package renter
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// The restore mode is meant for disaster recovery. When a user needs their
+// data back as fast as possible, they can put the renter into restore mode for
+// a specific dir. Until restore mode is stopped, or no download from within
+// the dir was active for restoreModeIdleTimeout, the renter prioritizes its
+// resources towards downloads from within the dir:
+//
+//   - uploads and repairs are paused
+//   - the download bandwidth limit is lifted
+//   - downloads within the dir are sorted before other downloads on the
+//     download heap, request high priority memory and use the workers' high
+//     priority read queue
+//   - all other downloads request low priority memory
+
+const (
+	// restoreModeDownloadPriority is the priority of downloads within the dir
+	// of the restore mode. Regular downloads have a priority of 5.
+	restoreModeDownloadPriority = 10
+)
+
+var (
+	// restoreModeCheckInterval is the interval at which the renter checks
+	// whether restore mode is still needed and extends the pause of the
+	// uploads and repairs.
+	restoreModeCheckInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// restoreModeIdleTimeout is the amount of time after which restore mode is
+	// stopped automatically if no download within its dir was active.
+	restoreModeIdleTimeout = build.Select(build.Var{
+		Dev:      2 * time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+)
+
+var (
+	// errRestoreModeInactive is returned when trying to stop the restore mode
+	// while it is not active.
+	errRestoreModeInactive = errors.New("restore mode is not active")
+)
+
+type (
+	// restoreMode contains the state of the renter's restore mode.
+	restoreMode struct {
+		active     bool
+		siaPath    modules.SiaPath
+		startTime  time.Time
+		lastActive time.Time
+		stopChan   chan struct{}
+
+		// userPauseEnd is the time at which the user's pause of the uploads
+		// and repairs ends. It is zero if the user didn't pause them. Restore
+		// mode restores the user's pause once it is stopped.
+		userPauseEnd time.Time
+
+		mu sync.Mutex
+	}
+)
+
+// isWithinDir returns whether the provided siapath is within the dir.
+func isWithinDir(dir, siaPath modules.SiaPath) bool {
+	return dir.IsRoot() || strings.HasPrefix(siaPath.Path, dir.Path+"/")
+}
+
+// managedActive returns whether restore mode is active.
+func (rm *restoreMode) managedActive() bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.active
+}
+
+// managedIsBoosted returns whether restore mode is active and the provided
+// siapath is within the dir of the restore mode.
+func (rm *restoreMode) managedIsBoosted(siaPath modules.SiaPath) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.active && isWithinDir(rm.siaPath, siaPath)
+}
+
+// managedDownloadMemoryPriority returns the priority with which a download of
+// the provided siapath should request memory.
+func (rm *restoreMode) managedDownloadMemoryPriority(siaPath modules.SiaPath) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if rm.active && !isWithinDir(rm.siaPath, siaPath) {
+		return memoryPriorityLow
+	}
+	return memoryPriorityHigh
+}
+
+// managedSetUserPause updates the user's pause of the uploads and repairs if
+// restore mode is active. A zero end means that the user resumed them. It
+// returns whether restore mode is active and for how long it needs the uploads
+// and repairs to be paused.
+func (rm *restoreMode) managedSetUserPause(end time.Time) (time.Duration, bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if !rm.active {
+		return 0, false
+	}
+	rm.userPauseEnd = end
+	return rm.pauseDuration(), true
+}
+
+// pauseDuration returns the duration for which restore mode pauses the uploads
+// and repairs. It never shortens the user's pause.
+func (rm *restoreMode) pauseDuration() time.Duration {
+	duration := 2 * restoreModeCheckInterval
+	if userDuration := time.Until(rm.userPauseEnd); userDuration > duration {
+		duration = userDuration
+	}
+	return duration
+}
+
+// managedActiveRestoreDownloads returns the number of incomplete downloads
+// within the provided dir.
+func (r *Renter) managedActiveRestoreDownloads(dir modules.SiaPath) uint64 {
+	r.downloadHistoryMu.Lock()
+	defer r.downloadHistoryMu.Unlock()
+	var active uint64
+	for _, d := range r.downloadHistory {
+		if !d.staticComplete() && isWithinDir(dir, d.staticSiaPath) {
+			active++
+		}
+	}
+	return active
+}
+
+// managedResetBandwidthLimits resets the renter's bandwidth limits to the
+// persisted settings.
+func (r *Renter) managedResetBandwidthLimits() error {
+	id := r.mu.RLock()
+	downloadSpeed, uploadSpeed := r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed
+	r.mu.RUnlock(id)
+	return r.setBandwidthLimits(downloadSpeed, uploadSpeed)
+}
+
+// managedStopRestoreMode takes the renter out of restore mode, restores the
+// user's pause of the uploads and repairs and resets the bandwidth limits. If
+// stopChan is not nil, restore mode is only stopped if it is still the session
+// of that stopChan.
+func (r *Renter) managedStopRestoreMode(stopChan chan struct{}) error {
+	rm := &r.restoreMode
+	rm.mu.Lock()
+	if !rm.active || (stopChan != nil && rm.stopChan != stopChan) {
+		rm.mu.Unlock()
+		return errRestoreModeInactive
+	}
+	rm.active = false
+	close(rm.stopChan)
+	userPause := time.Until(rm.userPauseEnd)
+	rm.userPauseEnd = time.Time{}
+	rm.mu.Unlock()
+
+	if userPause > 0 {
+		r.uploadHeap.managedPause(userPause)
+	} else {
+		r.uploadHeap.managedResume()
+	}
+	return r.managedResetBandwidthLimits()
+}
+
+// threadedRestoreMode keeps the uploads and repairs paused while restore mode
+// is active and stops restore mode once no download within its dir was active
+// for restoreModeIdleTimeout.
+func (r *Renter) threadedRestoreMode(stopChan chan struct{}) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	ticker := time.NewTicker(restoreModeCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		rm := &r.restoreMode
+		rm.mu.Lock()
+		dir := rm.siaPath
+		rm.mu.Unlock()
+		active := r.managedActiveRestoreDownloads(dir)
+
+		rm.mu.Lock()
+		if !rm.active || rm.stopChan != stopChan {
+			rm.mu.Unlock()
+			return
+		}
+		if active > 0 {
+			rm.lastActive = time.Now()
+		}
+		idle := time.Since(rm.lastActive) > restoreModeIdleTimeout
+		pause := rm.pauseDuration()
+		rm.mu.Unlock()
+		if idle {
+			err := r.managedStopRestoreMode(stopChan)
+			if err != nil && !errors.Contains(err, errRestoreModeInactive) {
+				r.log.Println("WARN: failed to stop restore mode:", err)
+			}
+			return
+		}
+		r.uploadHeap.managedPause(pause)
+	}
+}
+
+// RestoreModeStart puts the renter into restore mode for the provided dir. If
+// restore mode is already active, its dir is replaced.
+func (r *Renter) RestoreModeStart(siaPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Make sure the dir exists.
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "failed to open dir")
+	}
+	err = dir.Close()
+	if err != nil {
+		return errors.AddContext(err, "failed to close dir")
+	}
+
+	rm := &r.restoreMode
+	rm.mu.Lock()
+	rm.siaPath = siaPath
+	rm.lastActive = time.Now()
+	if rm.active {
+		rm.mu.Unlock()
+		return nil
+	}
+	rm.active = true
+	rm.startTime = time.Now()
+	rm.stopChan = make(chan struct{})
+	stopChan := rm.stopChan
+	rm.userPauseEnd = time.Time{}
+	if paused, end := r.uploadHeap.managedPauseStatus(); paused {
+		rm.userPauseEnd = end
+	}
+	pause := rm.pauseDuration()
+	rm.mu.Unlock()
+
+	// Pause uploads and repairs and lift the download bandwidth limit.
+	r.uploadHeap.managedPause(pause)
+	id := r.mu.RLock()
+	uploadSpeed := r.persist.MaxUploadSpeed
+	r.mu.RUnlock(id)
+	err = r.setBandwidthLimits(0, uploadSpeed)
+	if err != nil {
+		return errors.Compose(err, r.managedStopRestoreMode(stopChan))
+	}
+	go r.threadedRestoreMode(stopChan)
+	return nil
+}
+
+// RestoreModeStatus returns the status of the renter's restore mode.
+func (r *Renter) RestoreModeStatus() (modules.RestoreModeStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RestoreModeStatus{}, err
+	}
+	defer r.tg.Done()
+
+	rm := &r.restoreMode
+	rm.mu.Lock()
+	status := modules.RestoreModeStatus{
+		Active:    rm.active,
+		SiaPath:   rm.siaPath,
+		StartTime: rm.startTime,
+	}
+	rm.mu.Unlock()
+	if !status.Active {
+		return modules.RestoreModeStatus{}, nil
+	}
+	status.ActiveDownloads = r.managedActiveRestoreDownloads(status.SiaPath)
+	return status, nil
+}
+
+// RestoreModeStop takes the renter out of restore mode.
+func (r *Renter) RestoreModeStop() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.managedStopRestoreMode(nil)
+}
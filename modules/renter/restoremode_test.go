@@ -0,0 +1,247 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestIsWithinDir is a unit test for isWithinDir.
+func TestIsWithinDir(t *testing.T) {
+	tests := []struct {
+		dir     string
+		siaPath string
+		within  bool
+	}{
+		{"", "file", true},
+		{"", "dir/file", true},
+		{"dir", "dir/file", true},
+		{"dir", "dir/subdir/file", true},
+		{"dir", "file", false},
+		{"dir", "dir2/file", false},
+		{"dir/subdir", "dir/file", false},
+	}
+	for _, test := range tests {
+		dir := modules.RootSiaPath()
+		if test.dir != "" {
+			var err error
+			dir, err = modules.NewSiaPath(test.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		siaPath, err := modules.NewSiaPath(test.siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isWithinDir(dir, siaPath) != test.within {
+			t.Fatalf("%v in %v: expected %v", test.siaPath, test.dir, test.within)
+		}
+	}
+}
+
+// TestRestoreMode probes starting and stopping the renter's restore mode.
+func TestRestoreMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	dir, err := modules.NewSiaPath("restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inDir, err := dir.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outDir, err := modules.NewSiaPath("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stopping restore mode when it's not active should fail.
+	if err := r.RestoreModeStop(); !errors.Contains(err, errRestoreModeInactive) {
+		t.Fatal("expected errRestoreModeInactive but got", err)
+	}
+
+	// Starting restore mode for a dir that doesn't exist should fail.
+	if err := r.RestoreModeStart(dir); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := r.CreateDir(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Start restore mode.
+	if err := r.RestoreModeStart(dir); err != nil {
+		t.Fatal(err)
+	}
+	status, err := r.RestoreModeStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Active || !status.SiaPath.Equals(dir) || status.StartTime.IsZero() {
+		t.Fatal("unexpected status", status)
+	}
+	if !r.uploadHeap.managedIsPaused() {
+		t.Fatal("uploads should be paused")
+	}
+	if !r.restoreMode.managedIsBoosted(inDir) || r.restoreMode.managedIsBoosted(outDir) {
+		t.Fatal("only files within the dir should be boosted")
+	}
+	if r.restoreMode.managedDownloadMemoryPriority(inDir) != memoryPriorityHigh {
+		t.Fatal("files within the dir should use high priority memory")
+	}
+	if r.restoreMode.managedDownloadMemoryPriority(outDir) != memoryPriorityLow {
+		t.Fatal("files outside the dir should use low priority memory")
+	}
+
+	// The uploads should stay paused while restore mode is active.
+	time.Sleep(3 * restoreModeCheckInterval)
+	if !r.uploadHeap.managedIsPaused() {
+		t.Fatal("uploads should be paused")
+	}
+
+	// Stop restore mode.
+	if err := r.RestoreModeStop(); err != nil {
+		t.Fatal(err)
+	}
+	status, err = r.RestoreModeStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Active {
+		t.Fatal("restore mode should be inactive")
+	}
+	if r.uploadHeap.managedIsPaused() {
+		t.Fatal("uploads shouldn't be paused")
+	}
+	if r.restoreMode.managedIsBoosted(inDir) {
+		t.Fatal("files shouldn't be boosted")
+	}
+	if r.restoreMode.managedDownloadMemoryPriority(outDir) != memoryPriorityHigh {
+		t.Fatal("files should use high priority memory")
+	}
+
+	// Without any downloads, restore mode should stop after the idle timeout.
+	if err := r.RestoreModeStart(dir); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		status, err := r.RestoreModeStatus()
+		if err != nil {
+			return err
+		}
+		if status.Active {
+			return errors.New("restore mode still active")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.uploadHeap.managedIsPaused() {
+		t.Fatal("uploads shouldn't be paused")
+	}
+}
+
+// TestRestoreModeUserPause probes that restore mode restores the user's pause
+// of the uploads and repairs and doesn't stop later sessions.
+func TestRestoreModeUserPause(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	dir, err := modules.NewSiaPath("restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateDir(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pause the uploads before starting restore mode. They should stay
+	// paused after stopping it.
+	if err := r.PauseRepairsAndUploads(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RestoreModeStart(dir); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * restoreModeCheckInterval)
+	if err := r.RestoreModeStop(); err != nil {
+		t.Fatal(err)
+	}
+	paused, end := r.uploadHeap.managedPauseStatus()
+	if !paused || time.Until(end) < 50*time.Minute {
+		t.Fatal("the user's pause wasn't restored", paused, end)
+	}
+
+	// Resuming the uploads while restore mode is active keeps them paused
+	// until restore mode is stopped.
+	if err := r.RestoreModeStart(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ResumeRepairsAndUploads(); err != nil {
+		t.Fatal(err)
+	}
+	if !r.uploadHeap.managedIsPaused() {
+		t.Fatal("uploads should be paused")
+	}
+	if err := r.RestoreModeStop(); err != nil {
+		t.Fatal(err)
+	}
+	if r.uploadHeap.managedIsPaused() {
+		t.Fatal("uploads shouldn't be paused")
+	}
+
+	// A session can only be stopped using its own stopChan.
+	if err := r.RestoreModeStart(dir); err != nil {
+		t.Fatal(err)
+	}
+	r.restoreMode.mu.Lock()
+	oldStopChan := r.restoreMode.stopChan
+	r.restoreMode.mu.Unlock()
+	if err := r.RestoreModeStop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RestoreModeStart(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedStopRestoreMode(oldStopChan); !errors.Contains(err, errRestoreModeInactive) {
+		t.Fatal("expected errRestoreModeInactive but got", err)
+	}
+	if !r.restoreMode.managedActive() || !r.uploadHeap.managedIsPaused() {
+		t.Fatal("the new session was stopped")
+	}
+	if err := r.RestoreModeStop(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,164 @@
+package renter
+
+import (
+	"io"
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// errExportPartialChunks is returned when trying to export a siafile
+	// which has partial chunks. The data of partial chunks is stored in a
+	// separate siafile which isn't exported.
+	errExportPartialChunks = errors.New("can't export a siafile with partial chunks")
+)
+
+// ExportSiaFile writes the siafile at the given siapath to dst. The exported
+// siafile contains the metadata and the master key of the file, which allows
+// another renter to import it using ImportSiaFile.
+func (r *Renter) ExportSiaFile(siaPath modules.SiaPath, dst string) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open siafile")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if len(entry.PartialChunks()) > 0 {
+		return errExportPartialChunks
+	}
+
+	// Don't overwrite existing files. The siafile contains the key of the
+	// file, so only the owner may read it.
+	f, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.AddContext(err, "unable to create destination file")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	sr, err := entry.SnapshotReader()
+	if err != nil {
+		return errors.AddContext(err, "unable to read siafile")
+	}
+	defer func() {
+		err = errors.Compose(err, sr.Close())
+	}()
+	_, err = io.Copy(f, sr)
+	if err != nil {
+		return errors.AddContext(err, "unable to write siafile")
+	}
+	return f.Sync()
+}
+
+// ImportSiaFile adds an exported siafile to the filesystem at the given
+// siapath. The pieces of the file are not uploaded again. Instead their
+// availability is checked against the renter's contracts. If repair is true
+// and the file's health is below the repair threshold, its chunks are queued
+// for repair right away instead of waiting for the repair loop to find the
+// file.
+func (r *Renter) ImportSiaFile(src string, siaPath modules.SiaPath, repair bool) (_ modules.SiaFileImport, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.SiaFileImport{}, err
+	}
+	defer r.tg.Done()
+
+	// The filesystem would pick a different path for the file if the siapath
+	// was taken.
+	exists, _ := r.staticFileSystem.FileExists(siaPath)
+	if exists {
+		return modules.SiaFileImport{}, filesystem.ErrExists
+	}
+
+	// Add the file to the filesystem.
+	f, err := os.Open(src)
+	if err != nil {
+		return modules.SiaFileImport{}, errors.AddContext(err, "unable to open siafile")
+	}
+	err = r.staticFileSystem.AddSiaFileFromReader(f, siaPath)
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return modules.SiaFileImport{}, errors.AddContext(err, "unable to import siafile")
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return modules.SiaFileImport{}, errors.AddContext(err, "unable to open imported siafile")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+
+	// The local path and the stuck status of the chunks only apply to the
+	// renter which exported the file.
+	if err := entry.SetLocalPath(""); err != nil {
+		return modules.SiaFileImport{}, errors.AddContext(err, "unable to clear local path")
+	}
+	if err := entry.SetAllStuck(false); err != nil {
+		return modules.SiaFileImport{}, errors.AddContext(err, "unable to clear stuck status")
+	}
+
+	// Check the availability of the pieces and update the file's health.
+	r.managedUpdateRenterContractsAndUtilities()
+	offline, goodForRenew, contracts, used := r.callRenterContractsAndUtilities()
+	imp := modules.SiaFileImport{
+		SiaPath:     siaPath,
+		Recoverable: true,
+	}
+	minPieces := entry.ErasureCode().MinPieces()
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		pieces, err := entry.Pieces(chunkIndex)
+		if err != nil {
+			return modules.SiaFileImport{}, errors.AddContext(err, "unable to get pieces")
+		}
+		availableIndices := 0
+		for _, pieceSet := range pieces {
+			available := false
+			for _, piece := range pieceSet {
+				pk := piece.HostPubKey.String()
+				if goodForRenew[pk] && !offline[pk] {
+					imp.AvailablePieces++
+					available = true
+				} else {
+					imp.UnavailablePieces++
+				}
+			}
+			if available {
+				availableIndices++
+			}
+		}
+		if availableIndices < minPieces && entry.Size() > 0 {
+			imp.Recoverable = false
+		}
+	}
+	if err := r.managedUpdateFileMetadata(entry, offline, goodForRenew, contracts, used); err != nil {
+		return modules.SiaFileImport{}, errors.AddContext(err, "unable to update metadata")
+	}
+	imp.Health = entry.Metadata().CachedHealth
+
+	// Update the health of the file's directory.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return modules.SiaFileImport{}, err
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+
+	// Queue the repair if necessary.
+	if repair && modules.NeedsRepair(imp.Health) {
+		hosts := r.managedRefreshHostsAndWorkers()
+		r.callBuildAndPushChunks([]*filesystem.FileNode{entry}, hosts, targetUnstuckChunks, offline, goodForRenew)
+		select {
+		case r.uploadHeap.newUploads <- struct{}{}:
+		default:
+		}
+		imp.RepairQueued = true
+	}
+	return imp, nil
+}
@@ -500,10 +500,13 @@ func (r *Renter) managedDownloadSnapshot(uid [16]byte) (ub modules.UploadedBacku
 			if entry == nil {
 				return errors.New("entry table does not contain snapshot")
 			}
-			// download the entry
+			// download the entry, each sector within the background chunk
+			// download timeout
 			dotSia = nil
 			for _, root := range entry.DataSectors {
-				data, err := w.ReadSector(r.tg.StopCtx(), categorySnapshotDownload, root, 0, modules.SectorSize)
+				ctx, cancel := context.WithTimeout(r.tg.StopCtx(), r.staticTimeouts.callChunkDownload(operationClassBackground))
+				data, err := w.ReadSector(ctx, categorySnapshotDownload, root, 0, modules.SectorSize)
+				cancel()
 				if err != nil {
 					return err
 				}
@@ -0,0 +1,257 @@
+package renter
+
+// streamcache.go contains the renter's stream cache. The stream cache keeps
+// track of the data cached by the renter's streamers, retains the caches of
+// closed streamers for a while so that a recently streamed file can be reopened
+// without fetching its data again, and enforces the configured cache size.
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+var (
+	// streamCacheRetention is the amount of time the cache of a closed
+	// streamer is retained. This is particularly useful for video players and
+	// web applications which repeatedly open new connections for the same
+	// file.
+	streamCacheRetention = build.Select(build.Var{
+		Dev:      time.Second * 15,
+		Standard: time.Second * 60,
+		Testing:  time.Second * 2,
+	}).(time.Duration)
+)
+
+var (
+	// errInvalidStreamCachePolicy is returned if an unknown eviction policy is
+	// provided for the stream cache.
+	errInvalidStreamCachePolicy = errors.New("invalid stream cache policy")
+)
+
+type (
+	// streamCache tracks the caches of the renter's streamers.
+	streamCache struct {
+		atomicHits   uint64
+		atomicMisses uint64
+
+		// open maps the open streamers to the size of their caches.
+		open map[*streamer]int64
+
+		// retained contains the caches of closed streamers, ordered by the
+		// time the streamers were closed.
+		retained []*retainedStreamCache
+
+		settings modules.StreamCacheSettings
+		mu       sync.Mutex
+	}
+
+	// retainedStreamCache is the cache of a closed streamer.
+	retainedStreamCache struct {
+		staticData   []byte
+		staticExpiry time.Time
+		staticOffset int64
+		staticUID    siafile.SiafileUID
+	}
+)
+
+// newStreamCache creates a new stream cache with the default settings.
+func newStreamCache() *streamCache {
+	return &streamCache{
+		open: make(map[*streamer]int64),
+		settings: modules.StreamCacheSettings{
+			Policy: modules.StreamCachePolicyAffinity,
+		},
+	}
+}
+
+// validateStreamCacheSettings checks the stream cache settings for errors and
+// fills in the default policy if none was provided.
+func validateStreamCacheSettings(settings modules.StreamCacheSettings) (modules.StreamCacheSettings, error) {
+	switch settings.Policy {
+	case "":
+		settings.Policy = modules.StreamCachePolicyAffinity
+	case modules.StreamCachePolicyAffinity, modules.StreamCachePolicyLRU:
+	default:
+		return modules.StreamCacheSettings{}, errors.AddContext(errInvalidStreamCachePolicy, fmt.Sprintf("policy must be either '%v' or '%v'", modules.StreamCachePolicyAffinity, modules.StreamCachePolicyLRU))
+	}
+	return settings, nil
+}
+
+// callMaxCacheSize returns the size the cache of a streamer for a file with
+// the given chunk size grows to at most.
+func (sc *streamCache) callMaxCacheSize(chunkSize uint64) int64 {
+	sc.mu.Lock()
+	settings := sc.settings
+	sc.mu.Unlock()
+
+	size := maxStreamerCacheSize
+	if settings.ChunksPerStream > 0 {
+		size = int64(settings.ChunksPerStream * chunkSize)
+	}
+	if settings.MaxBytes > 0 && size > int64(settings.MaxBytes) {
+		size = int64(settings.MaxBytes)
+	}
+	if size < initialStreamerCacheSize {
+		size = initialStreamerCacheSize
+	}
+	return size
+}
+
+// callOpen registers a new streamer for the file with the given uid. If the
+// cache of a recently closed streamer of the same file is retained, it is
+// returned to seed the new streamer's cache.
+func (sc *streamCache) callOpen(s *streamer, uid siafile.SiafileUID) (int64, []byte) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.pruneRetained()
+
+	var offset int64
+	var data []byte
+	for i := len(sc.retained) - 1; i >= 0; i-- {
+		if sc.retained[i].staticUID != uid {
+			continue
+		}
+		offset, data = sc.retained[i].staticOffset, sc.retained[i].staticData
+		sc.retained = append(sc.retained[:i], sc.retained[i+1:]...)
+		break
+	}
+	sc.open[s] = int64(len(data))
+	return offset, data
+}
+
+// callUpdate updates the size of an open streamer's cache.
+func (sc *streamCache) callUpdate(s *streamer, cacheLen int64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, exists := sc.open[s]; !exists {
+		return
+	}
+	sc.open[s] = cacheLen
+	sc.pruneRetained()
+}
+
+// callClose unregisters a closed streamer and retains its cache.
+func (sc *streamCache) callClose(s *streamer, uid siafile.SiafileUID, offset int64, data []byte) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, exists := sc.open[s]; !exists {
+		return
+	}
+	delete(sc.open, s)
+	if len(data) > 0 {
+		sc.retained = append(sc.retained, &retainedStreamCache{
+			staticData:   data,
+			staticExpiry: time.Now().Add(streamCacheRetention),
+			staticOffset: offset,
+			staticUID:    uid,
+		})
+	}
+	sc.pruneRetained()
+}
+
+// callRecordRead records whether a read of a streamer was served from its
+// cache.
+func (sc *streamCache) callRecordRead(hit bool) {
+	if hit {
+		atomic.AddUint64(&sc.atomicHits, 1)
+	} else {
+		atomic.AddUint64(&sc.atomicMisses, 1)
+	}
+}
+
+// callSetSettings updates the settings of the stream cache. The maximum cache
+// size of a streamer only changes for streamers created afterwards.
+func (sc *streamCache) callSetSettings(settings modules.StreamCacheSettings) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.settings = settings
+	sc.pruneRetained()
+}
+
+// callStats returns the settings and statistics of the stream cache.
+func (sc *streamCache) callStats() modules.StreamCacheStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.pruneRetained()
+	return modules.StreamCacheStats{
+		StreamCacheSettings: sc.settings,
+
+		CachedBytes:     uint64(sc.cachedBytes()),
+		OpenStreams:     uint64(len(sc.open)),
+		RetainedStreams: uint64(len(sc.retained)),
+		Hits:            atomic.LoadUint64(&sc.atomicHits),
+		Misses:          atomic.LoadUint64(&sc.atomicMisses),
+	}
+}
+
+// cachedBytes returns the total amount of data held by the stream cache.
+func (sc *streamCache) cachedBytes() int64 {
+	var total int64
+	for _, cacheLen := range sc.open {
+		total += cacheLen
+	}
+	for _, rsc := range sc.retained {
+		total += int64(len(rsc.staticData))
+	}
+	return total
+}
+
+// pruneRetained drops the expired retained caches. With the LRU policy, the
+// retained caches are also dropped, least recently closed first, while the
+// stream cache exceeds its size.
+func (sc *streamCache) pruneRetained() {
+	now := time.Now()
+	retained := sc.retained[:0]
+	for _, rsc := range sc.retained {
+		if now.Before(rsc.staticExpiry) {
+			retained = append(retained, rsc)
+		}
+	}
+	sc.retained = retained
+
+	if sc.settings.Policy != modules.StreamCachePolicyLRU || sc.settings.MaxBytes == 0 {
+		return
+	}
+	total := sc.cachedBytes()
+	for len(sc.retained) > 0 && total > int64(sc.settings.MaxBytes) {
+		total -= int64(len(sc.retained[0].staticData))
+		sc.retained = sc.retained[1:]
+	}
+}
+
+// SetStreamCacheSettings sets the settings of the renter's stream cache.
+func (r *Renter) SetStreamCacheSettings(settings modules.StreamCacheSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	settings, err := validateStreamCacheSettings(settings)
+	if err != nil {
+		return err
+	}
+	r.staticStreamCache.callSetSettings(settings)
+
+	id := r.mu.Lock()
+	r.persist.StreamCache = settings
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	return err
+}
+
+// StreamCacheStats returns the settings and statistics of the renter's stream
+// cache.
+func (r *Renter) StreamCacheStats() (modules.StreamCacheStats, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.StreamCacheStats{}, err
+	}
+	defer r.tg.Done()
+	return r.staticStreamCache.callStats(), nil
+}
@@ -0,0 +1,160 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+// TestValidateStreamCacheSettings is a unit test for
+// validateStreamCacheSettings.
+func TestValidateStreamCacheSettings(t *testing.T) {
+	settings, err := validateStreamCacheSettings(modules.StreamCacheSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Policy != modules.StreamCachePolicyAffinity {
+		t.Fatal("expected default policy but got", settings.Policy)
+	}
+	settings, err = validateStreamCacheSettings(modules.StreamCacheSettings{Policy: modules.StreamCachePolicyLRU})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Policy != modules.StreamCachePolicyLRU {
+		t.Fatal("unexpected policy", settings.Policy)
+	}
+	_, err = validateStreamCacheSettings(modules.StreamCacheSettings{Policy: "fifo"})
+	if !errors.Contains(err, errInvalidStreamCachePolicy) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// TestStreamCacheMaxCacheSize is a unit test for callMaxCacheSize.
+func TestStreamCacheMaxCacheSize(t *testing.T) {
+	chunkSize := uint64(initialStreamerCacheSize)
+	tests := []struct {
+		settings modules.StreamCacheSettings
+		result   int64
+	}{
+		{modules.StreamCacheSettings{}, maxStreamerCacheSize},
+		{modules.StreamCacheSettings{ChunksPerStream: 3}, 3 * initialStreamerCacheSize},
+		{modules.StreamCacheSettings{ChunksPerStream: 3, MaxBytes: uint64(2 * initialStreamerCacheSize)}, 2 * initialStreamerCacheSize},
+		{modules.StreamCacheSettings{MaxBytes: 1}, initialStreamerCacheSize},
+	}
+	sc := newStreamCache()
+	for i, test := range tests {
+		sc.callSetSettings(test.settings)
+		if size := sc.callMaxCacheSize(chunkSize); size != test.result {
+			t.Errorf("%v: expected %v but got %v", i, test.result, size)
+		}
+	}
+}
+
+// TestStreamCacheRetention probes retaining the caches of closed streamers.
+func TestStreamCacheRetention(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sc := newStreamCache()
+	var uid1, uid2 siafile.SiafileUID = "file1", "file2"
+	data := fastrand.Bytes(100)
+
+	// Open a streamer, fill its cache and close it. Its cache is retained.
+	s1 := &streamer{}
+	if offset, cache := sc.callOpen(s1, uid1); offset != 0 || cache != nil {
+		t.Fatal("there shouldn't be a retained cache", offset, cache)
+	}
+	sc.callUpdate(s1, int64(len(data)))
+	stats := sc.callStats()
+	if stats.OpenStreams != 1 || stats.CachedBytes != uint64(len(data)) {
+		t.Fatal("unexpected stats", stats)
+	}
+	sc.callClose(s1, uid1, 10, data)
+	stats = sc.callStats()
+	if stats.OpenStreams != 0 || stats.RetainedStreams != 1 || stats.CachedBytes != uint64(len(data)) {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// A streamer for another file doesn't get the retained cache.
+	s2 := &streamer{}
+	if _, cache := sc.callOpen(s2, uid2); cache != nil {
+		t.Fatal("streamer shouldn't get the cache of another file")
+	}
+	sc.callClose(s2, uid2, 0, nil)
+
+	// A streamer for the same file gets the retained cache.
+	s3 := &streamer{}
+	offset, cache := sc.callOpen(s3, uid1)
+	if offset != 10 || len(cache) != len(data) {
+		t.Fatal("streamer should have gotten the retained cache", offset, len(cache))
+	}
+	if stats := sc.callStats(); stats.RetainedStreams != 0 || stats.CachedBytes != uint64(len(data)) {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// After closing the streamer, the cache is dropped once the retention
+	// expires.
+	sc.callClose(s3, uid1, offset, cache)
+	time.Sleep(streamCacheRetention)
+	if stats := sc.callStats(); stats.RetainedStreams != 0 || stats.CachedBytes != 0 {
+		t.Fatal("retained cache should have expired", stats)
+	}
+}
+
+// TestStreamCachePolicy checks that the LRU policy drops retained caches once
+// the stream cache exceeds its size while the affinity policy keeps them.
+func TestStreamCachePolicy(t *testing.T) {
+	sc := newStreamCache()
+	sc.callSetSettings(modules.StreamCacheSettings{
+		MaxBytes: 250,
+		Policy:   modules.StreamCachePolicyAffinity,
+	})
+
+	// Close three streamers with 100 bytes of cache each. All of them are
+	// retained.
+	for _, uid := range []siafile.SiafileUID{"file1", "file2", "file3"} {
+		s := &streamer{}
+		sc.callOpen(s, uid)
+		sc.callClose(s, uid, 0, fastrand.Bytes(100))
+	}
+	if stats := sc.callStats(); stats.RetainedStreams != 3 || stats.CachedBytes != 300 {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// Switching to LRU drops the least recently closed cache.
+	sc.callSetSettings(modules.StreamCacheSettings{
+		MaxBytes: 250,
+		Policy:   modules.StreamCachePolicyLRU,
+	})
+	if stats := sc.callStats(); stats.RetainedStreams != 2 || stats.CachedBytes != 200 {
+		t.Fatal("unexpected stats", stats)
+	}
+	if _, cache := sc.callOpen(&streamer{}, "file1"); cache != nil {
+		t.Fatal("cache of file1 should have been dropped")
+	}
+
+	// A growing open streamer causes more caches to be dropped.
+	s := &streamer{}
+	sc.callOpen(s, "file4")
+	sc.callUpdate(s, 100)
+	if stats := sc.callStats(); stats.RetainedStreams != 1 || stats.CachedBytes != 200 {
+		t.Fatal("unexpected stats", stats)
+	}
+	if _, cache := sc.callOpen(&streamer{}, "file3"); cache == nil {
+		t.Fatal("cache of file3 should have been retained")
+	}
+
+	// Reads are recorded.
+	sc.callRecordRead(true)
+	sc.callRecordRead(false)
+	sc.callRecordRead(false)
+	if stats := sc.callStats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Fatal("unexpected stats", stats)
+	}
+}
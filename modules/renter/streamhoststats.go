@@ -0,0 +1,88 @@
+package renter
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// streamHostPerformanceDecay defines how much the average piece download
+	// time of a host is decayed each time a new datapoint is added. The stats
+	// use an exponential weighted average.
+	streamHostPerformanceDecay = 0.5
+)
+
+type (
+	// streamHostStats tracks how fast the hosts served the pieces of the
+	// chunks previously downloaded by a stream. It is shared by all the
+	// downloads of a streamer to bias the download of subsequent chunks
+	// towards the hosts which were the fastest so far.
+	streamHostStats struct {
+		// hosts maps the string representation of a host's public key to an
+		// exponential weighted average of the time it took the host to serve
+		// a piece.
+		hosts map[string]float64
+
+		mu sync.Mutex
+	}
+)
+
+// newStreamHostStats creates new, empty stream host stats.
+func newStreamHostStats() *streamHostStats {
+	return &streamHostStats{
+		hosts: make(map[string]float64),
+	}
+}
+
+// callRecordSuccess adds the time it took a host to serve a piece to the
+// stats.
+func (shs *streamHostStats) callRecordSuccess(host string, jobTime time.Duration) {
+	shs.mu.Lock()
+	defer shs.mu.Unlock()
+	weightedJobTime, exists := shs.hosts[host]
+	if !exists {
+		shs.hosts[host] = float64(jobTime)
+		return
+	}
+	shs.hosts[host] = expMovingAvg(weightedJobTime, float64(jobTime), streamHostPerformanceDecay)
+}
+
+// callRecordFailure forgets about a host which failed to serve a piece. The
+// host won't be preferred for the following chunks until it successfully
+// serves a piece again.
+func (shs *streamHostStats) callRecordFailure(host string) {
+	shs.mu.Lock()
+	defer shs.mu.Unlock()
+	delete(shs.hosts, host)
+}
+
+// callPreferredHosts returns the n fastest hosts out of the candidates. If the
+// stats don't know about at least n of the candidates yet, nil is returned to
+// signal that there is no preference.
+func (shs *streamHostStats) callPreferredHosts(candidates map[string]downloadPieceInfo, n int) map[string]struct{} {
+	shs.mu.Lock()
+	defer shs.mu.Unlock()
+
+	type hostTime struct {
+		host            string
+		weightedJobTime float64
+	}
+	var known []hostTime
+	for host := range candidates {
+		if weightedJobTime, exists := shs.hosts[host]; exists {
+			known = append(known, hostTime{host, weightedJobTime})
+		}
+	}
+	if n <= 0 || len(known) < n {
+		return nil
+	}
+	sort.Slice(known, func(i, j int) bool {
+		return known[i].weightedJobTime < known[j].weightedJobTime
+	})
+	preferred := make(map[string]struct{}, n)
+	for _, ht := range known[:n] {
+		preferred[ht.host] = struct{}{}
+	}
+	return preferred
+}
@@ -0,0 +1,63 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStreamHostStats is a unit test for the streamHostStats.
+func TestStreamHostStats(t *testing.T) {
+	t.Parallel()
+
+	candidates := map[string]downloadPieceInfo{
+		"host1": {index: 0},
+		"host2": {index: 1},
+		"host3": {index: 2},
+		"host4": {index: 3},
+	}
+	shs := newStreamHostStats()
+
+	// Without stats there is no preference.
+	if preferred := shs.callPreferredHosts(candidates, 2); preferred != nil {
+		t.Fatal("expected no preference", preferred)
+	}
+
+	// Knowing fewer hosts than requested doesn't result in a preference
+	// either.
+	shs.callRecordSuccess("host1", 100*time.Millisecond)
+	if preferred := shs.callPreferredHosts(candidates, 2); preferred != nil {
+		t.Fatal("expected no preference", preferred)
+	}
+
+	// Once enough hosts are known, the fastest ones are preferred.
+	shs.callRecordSuccess("host2", 50*time.Millisecond)
+	shs.callRecordSuccess("host3", 10*time.Millisecond)
+	shs.callRecordSuccess("unknown", time.Millisecond)
+	preferred := shs.callPreferredHosts(candidates, 2)
+	if len(preferred) != 2 {
+		t.Fatal("expected 2 preferred hosts", preferred)
+	}
+	for _, host := range []string{"host2", "host3"} {
+		if _, exists := preferred[host]; !exists {
+			t.Fatal("expected host to be preferred", host, preferred)
+		}
+	}
+
+	// A slow piece download increases the weighted time of a host.
+	shs.callRecordSuccess("host3", 300*time.Millisecond)
+	preferred = shs.callPreferredHosts(candidates, 2)
+	for _, host := range []string{"host1", "host2"} {
+		if _, exists := preferred[host]; !exists {
+			t.Fatal("expected host to be preferred", host, preferred)
+		}
+	}
+
+	// A host which fails is no longer preferred.
+	shs.callRecordFailure("host2")
+	preferred = shs.callPreferredHosts(candidates, 2)
+	for _, host := range []string{"host1", "host3"} {
+		if _, exists := preferred[host]; !exists {
+			t.Fatal("expected host to be preferred", host, preferred)
+		}
+	}
+}
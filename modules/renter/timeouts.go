@@ -0,0 +1,230 @@
+package renter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// minOperationTimeout is the lowest value any of the renter's timeouts
+	// can be set to.
+	minOperationTimeout = time.Second
+
+	// maxOperationTimeout is the highest value any of the renter's timeouts
+	// can be set to.
+	maxOperationTimeout = 24 * time.Hour
+)
+
+const (
+	// operationClassInteractive is the class of user downloads and streams.
+	operationClassInteractive operationClass = iota
+
+	// operationClassRepair is the class of repairs and uploads.
+	operationClassRepair
+
+	// operationClassBackground is the class of backups, benchmarks and host
+	// scans.
+	operationClassBackground
+)
+
+var (
+	// chunkDownloadTimeoutDefault is the default amount of time the download
+	// of a single chunk may take before the chunk is failed.
+	chunkDownloadTimeoutDefault = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: 15 * time.Minute,
+		Testing:  2 * time.Minute,
+	}).(time.Duration)
+
+	// errTimeoutTooLow is returned if a timeout is below minOperationTimeout.
+	errTimeoutTooLow = fmt.Errorf("timeouts can't be below %v", minOperationTimeout)
+
+	// errTimeoutTooHigh is returned if a timeout exceeds maxOperationTimeout.
+	errTimeoutTooHigh = fmt.Errorf("timeouts can't exceed %v", maxOperationTimeout)
+
+	// errChunkDownloadTimeout is returned if the download of a chunk takes
+	// longer than the chunk download timeout of its operation class.
+	errChunkDownloadTimeout = errors.New("chunk download timed out")
+)
+
+type (
+	// operationClass groups the operations of the renter which share the
+	// same timeouts.
+	operationClass int
+
+	// operationTimeouts contains the runtime-tunable timeouts of the renter
+	// which are applied by the renter itself. The dial timeouts of the repair
+	// and background classes are applied by the contractor and the hostdb.
+	operationTimeouts struct {
+		settings modules.RenterTimeoutSettings
+		mu       sync.Mutex
+	}
+)
+
+// newOperationTimeouts returns the timeouts initialized with their defaults.
+func newOperationTimeouts() *operationTimeouts {
+	return &operationTimeouts{}
+}
+
+// validateTimeoutSettings checks that all of the timeouts which are set are
+// within the allowed bounds.
+func validateTimeoutSettings(settings modules.RenterTimeoutSettings) error {
+	for _, class := range []modules.RenterOperationTimeouts{settings.Interactive, settings.Repair, settings.Background} {
+		for _, timeout := range []time.Duration{class.ChunkDownload, class.Job, class.Dial} {
+			if timeout == 0 {
+				continue
+			} else if timeout < minOperationTimeout {
+				return errTimeoutTooLow
+			} else if timeout > maxOperationTimeout {
+				return errTimeoutTooHigh
+			}
+		}
+	}
+	return nil
+}
+
+// timeoutOrDefault returns the timeout or the default if the timeout isn't
+// set.
+func timeoutOrDefault(timeout, def time.Duration) time.Duration {
+	if timeout == 0 {
+		return def
+	}
+	return timeout
+}
+
+// class returns the configured timeouts of the operation class. It must be
+// called while holding the lock.
+func (ot *operationTimeouts) class(class operationClass) modules.RenterOperationTimeouts {
+	switch class {
+	case operationClassInteractive:
+		return ot.settings.Interactive
+	case operationClassRepair:
+		return ot.settings.Repair
+	case operationClassBackground:
+		return ot.settings.Background
+	}
+	build.Critical("unknown operation class", class)
+	return modules.RenterOperationTimeouts{}
+}
+
+// callSetSettings updates the timeouts.
+func (ot *operationTimeouts) callSetSettings(settings modules.RenterTimeoutSettings) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.settings = settings
+}
+
+// callSettings returns the configured timeouts.
+func (ot *operationTimeouts) callSettings() modules.RenterTimeoutSettings {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	return ot.settings
+}
+
+// callChunkDownload returns the maximum amount of time the download of a
+// single chunk of the operation class may take.
+func (ot *operationTimeouts) callChunkDownload(class operationClass) time.Duration {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	return timeoutOrDefault(ot.class(class).ChunkDownload, chunkDownloadTimeoutDefault)
+}
+
+// callJob returns the maximum amount of time a single worker job of the
+// operation class may take. Interactive jobs are sector lookups, repair jobs
+// are upload verifications and background jobs are benchmarks.
+func (ot *operationTimeouts) callJob(class operationClass) time.Duration {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	var def time.Duration
+	switch class {
+	case operationClassInteractive:
+		def = pcwsHasSectorTimeout
+	case operationClassRepair:
+		def = uploadVerificationTimeout
+	case operationClassBackground:
+		def = benchmarkTimeout
+	}
+	return timeoutOrDefault(ot.class(class).Job, def)
+}
+
+// callInteractiveDial returns the maximum amount of time opening a stream to a
+// host for an interactive operation may take.
+func (ot *operationTimeouts) callInteractiveDial() time.Duration {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	return timeoutOrDefault(ot.settings.Interactive.Dial, defaultNewStreamTimeout)
+}
+
+// TimeoutSettings returns the configured and the effective timeouts of the
+// renter.
+func (r *Renter) TimeoutSettings() (modules.RenterTimeouts, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterTimeouts{}, err
+	}
+	defer r.tg.Done()
+
+	scanDialTimeout, err := r.hostDB.ScanDialTimeout()
+	if err != nil {
+		return modules.RenterTimeouts{}, errors.AddContext(err, "failed to get hostdb scan dial timeout")
+	}
+	ot := r.staticTimeouts
+	return modules.RenterTimeouts{
+		Settings: ot.callSettings(),
+		Effective: modules.RenterTimeoutSettings{
+			Interactive: modules.RenterOperationTimeouts{
+				ChunkDownload: ot.callChunkDownload(operationClassInteractive),
+				Job:           ot.callJob(operationClassInteractive),
+				Dial:          ot.callInteractiveDial(),
+			},
+			Repair: modules.RenterOperationTimeouts{
+				ChunkDownload: ot.callChunkDownload(operationClassRepair),
+				Job:           ot.callJob(operationClassRepair),
+				Dial:          r.hostContractor.SessionDialTimeout(),
+			},
+			Background: modules.RenterOperationTimeouts{
+				ChunkDownload: ot.callChunkDownload(operationClassBackground),
+				Job:           ot.callJob(operationClassBackground),
+				Dial:          scanDialTimeout,
+			},
+		},
+	}, nil
+}
+
+// SetTimeoutSettings updates the timeouts of the renter and persists them. A
+// timeout of 0 restores the default. Running operations keep their timeouts.
+func (r *Renter) SetTimeoutSettings(settings modules.RenterTimeoutSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if err := r.applyTimeoutSettings(settings); err != nil {
+		return err
+	}
+
+	id := r.mu.Lock()
+	r.persist.Timeouts = settings
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	return err
+}
+
+// applyTimeoutSettings validates the timeouts and applies them to the renter
+// and the modules dialing hosts on its behalf.
+func (r *Renter) applyTimeoutSettings(settings modules.RenterTimeoutSettings) error {
+	if err := validateTimeoutSettings(settings); err != nil {
+		return err
+	}
+	if err := r.hostDB.SetScanDialTimeout(settings.Background.Dial); err != nil {
+		return errors.AddContext(err, "failed to set hostdb scan dial timeout")
+	}
+	r.hostContractor.SetSessionDialTimeout(settings.Repair.Dial)
+	r.staticTimeouts.callSetSettings(settings)
+	return nil
+}
@@ -0,0 +1,96 @@
+package renter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestOperationTimeouts checks that the timeouts fall back to their defaults
+// and that timeouts outside of the bounds are rejected.
+func TestOperationTimeouts(t *testing.T) {
+	t.Parallel()
+
+	ot := newOperationTimeouts()
+	if ot.callChunkDownload(operationClassRepair) != chunkDownloadTimeoutDefault || ot.callInteractiveDial() != defaultNewStreamTimeout {
+		t.Fatal("defaults not used")
+	}
+	if ot.callJob(operationClassInteractive) != pcwsHasSectorTimeout || ot.callJob(operationClassRepair) != uploadVerificationTimeout || ot.callJob(operationClassBackground) != benchmarkTimeout {
+		t.Fatal("default job timeouts not used")
+	}
+	ot.callSetSettings(modules.RenterTimeoutSettings{
+		Interactive: modules.RenterOperationTimeouts{Dial: time.Second},
+		Repair:      modules.RenterOperationTimeouts{ChunkDownload: time.Hour},
+		Background:  modules.RenterOperationTimeouts{Job: time.Minute},
+	})
+	if ot.callInteractiveDial() != time.Second || ot.callChunkDownload(operationClassRepair) != time.Hour || ot.callJob(operationClassBackground) != time.Minute {
+		t.Fatal("timeouts not applied")
+	}
+	if ot.callChunkDownload(operationClassInteractive) != chunkDownloadTimeoutDefault {
+		t.Fatal("timeouts applied to the wrong class")
+	}
+
+	tests := []struct {
+		timeout time.Duration
+		err     error
+	}{
+		{0, nil},
+		{minOperationTimeout, nil},
+		{maxOperationTimeout, nil},
+		{minOperationTimeout - 1, errTimeoutTooLow},
+		{maxOperationTimeout + 1, errTimeoutTooHigh},
+	}
+	for _, test := range tests {
+		settings := modules.RenterTimeoutSettings{
+			Background: modules.RenterOperationTimeouts{Dial: test.timeout},
+		}
+		if err := validateTimeoutSettings(settings); err != test.err {
+			t.Errorf("expected %v for timeout %v, got %v", test.err, test.timeout, err)
+		}
+	}
+}
+
+// TestChunkDownloadTimeout checks that a chunk which isn't recovered before
+// its timeout fails its download.
+func TestChunkDownloadTimeout(t *testing.T) {
+	t.Parallel()
+
+	rc, err := modules.NewRSSubCode(1, 1, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := func() *unfinishedDownloadChunk {
+		return &unfinishedDownloadChunk{
+			erasureCode:      rc,
+			workersRemaining: rc.NumPieces(),
+			download: &download{
+				completeChan: make(chan struct{}),
+			},
+		}
+	}
+
+	// A chunk which doesn't complete any pieces times out.
+	udc := chunk()
+	udc.mu.Lock()
+	udc.timeoutTimer = time.AfterFunc(10*time.Millisecond, udc.managedTimeout)
+	udc.mu.Unlock()
+	select {
+	case <-udc.download.completeChan:
+	case <-time.After(time.Minute):
+		t.Fatal("chunk didn't time out")
+	}
+	if err := udc.download.Err(); err == nil || !strings.Contains(err.Error(), errChunkDownloadTimeout.Error()) {
+		t.Fatal("expected errChunkDownloadTimeout, got", err)
+	}
+
+	// A chunk which was recovered doesn't time out.
+	udc = chunk()
+	udc.recoveryComplete = true
+	udc.managedTimeout()
+	if udc.failed || udc.download.staticComplete() {
+		t.Fatal("recovered chunk timed out")
+	}
+}
@@ -41,6 +41,7 @@ type unfinishedUploadChunk struct {
 	staticPiecesNeeded     int    // number of pieces to achieve a 100% complete upload
 	stuck                  bool   // indicates if the chunk was marked as stuck during last repair
 	stuckRepair            bool   // indicates if the chunk was identified for repair by the stuck loop
+	staticVerifyPieces     bool   // indicates if uploaded pieces need to be verified before they count towards the redundancy
 
 	staticMemoryManager *memoryManager
 
@@ -64,6 +65,11 @@ type unfinishedUploadChunk struct {
 	// and be confident that the data now is the same as what it used to be.
 	staticExpectedPieceRoots []crypto.Hash
 
+	// staticHostNetworks maps the hosts to the networks which may only hold a
+	// single piece of the chunk. It is nil if the piece placement is
+	// unconstrained and must not be modified.
+	staticHostNetworks map[string][]string
+
 	// sourceReader is an optional source for the logical chunk data. If
 	// available it will be tried before the repair path or remote repair.
 	sourceReader io.ReadCloser
@@ -115,6 +121,7 @@ type unfinishedUploadChunk struct {
 	piecesRegistered int                 // number of pieces that are being uploaded, but aren't finished yet (may fail).
 	released         bool                // whether this chunk has been released from the active chunks set.
 	unusedHosts      map[string]struct{} // hosts that aren't yet storing any pieces or performing any work.
+	usedNetworks     map[string]int      // number of pieces stored or being uploaded within each network.
 	workersRemaining int                 // number of inactive workers still able to upload a piece.
 	workersStandby   []*worker           // workers that can be used if other workers fail.
 
@@ -123,6 +130,32 @@ type unfinishedUploadChunk struct {
 	cancelWG sync.WaitGroup // WaitGroup to wait on after canceling the uploadchunk.
 }
 
+// candidateHost returns whether the host may receive a piece of the chunk. The
+// host must not hold a piece of the chunk yet and none of its networks may
+// hold a piece either. The chunk's lock must be held.
+func (uc *unfinishedUploadChunk) candidateHost(hostKey string) bool {
+	if _, unused := uc.unusedHosts[hostKey]; !unused {
+		return false
+	}
+	for _, network := range uc.staticHostNetworks[hostKey] {
+		if uc.usedNetworks[network] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// updateUsedNetworks adds delta to the pieces within the networks of the host.
+// The chunk's lock must be held.
+func (uc *unfinishedUploadChunk) updateUsedNetworks(hostKey string, delta int) {
+	for _, network := range uc.staticHostNetworks[hostKey] {
+		uc.usedNetworks[network] += delta
+		if uc.usedNetworks[network] <= 0 {
+			delete(uc.usedNetworks, network)
+		}
+	}
+}
+
 // managedSetStuckAndClose sets the unfinishedUploadChunk's stuck status and
 // closes the fileEntry.
 func (uc *unfinishedUploadChunk) managedSetStuckAndClose(setStuck bool) error {
@@ -263,6 +296,13 @@ func padAndEncryptPiece(chunkIndex, pieceIndex uint64, logicalChunkData [][]byte
 // download to the renter's downloader, and then using the data that gets
 // returned.
 func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) error {
+	// Wait for a free repair download slot.
+	repairDownloads := r.staticConcurrency.staticRepairDownloads
+	if !repairDownloads.managedAcquire(r.tg.StopChan()) {
+		return errRepairDownloadInterrupted
+	}
+	defer repairDownloads.managedRelease()
+
 	//  Determine what the download length should be. Normally it is just the
 	//  chunk size, but if this is the last chunk we need to download less
 	//  because the file is not that large.
@@ -297,6 +337,8 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 		overdrive:     0, // No need to rush the latency on repair downloads.
 		priority:      0, // Repair downloads are completely de-prioritized.
 
+		operationClass: operationClassRepair,
+
 		staticMemoryManager:    chunk.staticMemoryManager, // Same memory manager as upload chunk
 		staticSpendingCategory: categoryRepairDownload,
 	})
@@ -318,7 +360,7 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 	select {
 	case <-d.completeChan:
 	case <-r.tg.StopChan():
-		return errors.New("repair download interrupted by stop call")
+		return errRepairDownloadInterrupted
 	}
 	if d.Err() != nil {
 		buf.pieces = nil
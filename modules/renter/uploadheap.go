@@ -194,9 +194,15 @@ type uploadHeap struct {
 	//
 	// repairingChunks is a map containing all the chunks are that currently
 	// assigned to workers and are being repaired/worked on.
+	//
+	// verifyingChunks counts the pieces of repaired chunks which are still
+	// being verified. These chunks aren't added to the heap again until the
+	// verification is done since their unverified pieces don't count towards
+	// their health.
 	repairingChunks   map[uploadChunkID]*unfinishedUploadChunk
 	stuckHeapChunks   map[uploadChunkID]*unfinishedUploadChunk
 	unstuckHeapChunks map[uploadChunkID]*unfinishedUploadChunk
+	verifyingChunks   map[uploadChunkID]int
 
 	// Internal control channels
 	newUploads        chan struct{}
@@ -221,7 +227,8 @@ func (uh *uploadHeap) managedExists(id uploadChunkID) bool {
 	_, existsUnstuckHeap := uh.unstuckHeapChunks[id]
 	_, existsRepairing := uh.repairingChunks[id]
 	_, existsStuckHeap := uh.stuckHeapChunks[id]
-	return existsUnstuckHeap || existsRepairing || existsStuckHeap
+	_, existsVerifying := uh.verifyingChunks[id]
+	return existsUnstuckHeap || existsRepairing || existsStuckHeap || existsVerifying
 }
 
 // managedIsPaused returns the boolean indicating whether or not the user
@@ -272,6 +279,25 @@ func (uh *uploadHeap) managedMarkRepairDone(uuc *unfinishedUploadChunk) {
 	//	build.Critical("Chunk is not in the repair map, this means it was removed prematurely or was never added")
 }
 
+// managedAddVerification registers a piece of the chunk which is being
+// verified.
+func (uh *uploadHeap) managedAddVerification(id uploadChunkID) {
+	uh.mu.Lock()
+	defer uh.mu.Unlock()
+	uh.verifyingChunks[id]++
+}
+
+// managedVerificationDone removes a piece of the chunk which was being
+// verified.
+func (uh *uploadHeap) managedVerificationDone(id uploadChunkID) {
+	uh.mu.Lock()
+	defer uh.mu.Unlock()
+	uh.verifyingChunks[id]--
+	if uh.verifyingChunks[id] <= 0 {
+		delete(uh.verifyingChunks, id)
+	}
+}
+
 // managedNumStuckChunks returns total number of stuck chunks in the heap and
 // the number of stuck chunks that were added at random as opposed to being
 // added due to a recently successful file repair
@@ -333,11 +359,12 @@ func (uh *uploadHeap) managedPush(uuc *unfinishedUploadChunk, ct chunkType) bool
 	_, existsUnstuckHeap := uh.unstuckHeapChunks[uuc.id]
 	_, existsRepairing := uh.repairingChunks[uuc.id]
 	_, existsStuckHeap := uh.stuckHeapChunks[uuc.id]
+	_, existsVerifying := uh.verifyingChunks[uuc.id]
 	exists := existsUnstuckHeap || existsRepairing || existsStuckHeap
 
 	// Check if the chunk can be added to the heap
-	canAddStuckChunk := chunkStuck && !exists && len(uh.stuckHeapChunks) < maxStuckChunksInHeap && ct == chunkTypeLocalChunk
-	canAddUnstuckChunk := !chunkStuck && !exists && ct == chunkTypeLocalChunk
+	canAddStuckChunk := chunkStuck && !exists && !existsVerifying && len(uh.stuckHeapChunks) < maxStuckChunksInHeap && ct == chunkTypeLocalChunk
+	canAddUnstuckChunk := !chunkStuck && !exists && !existsVerifying && ct == chunkTypeLocalChunk
 
 	// Add the chunk to the heap
 	if canAddStuckChunk {
@@ -495,16 +522,24 @@ func (r *Renter) PauseRepairsAndUploads(duration time.Duration) error {
 		return err
 	}
 	defer r.tg.Done()
+	// Restore mode might need a longer pause.
+	if pause, active := r.restoreMode.managedSetUserPause(time.Now().Add(duration)); active {
+		duration = pause
+	}
 	r.uploadHeap.managedPause(duration)
 	return nil
 }
 
-// ResumeRepairsAndUploads resumes the renter's repairs and uploads
+// ResumeRepairsAndUploads resumes the renter's repairs and uploads. While
+// restore mode is active, they are resumed once restore mode is stopped.
 func (r *Renter) ResumeRepairsAndUploads() error {
 	if err := r.tg.Add(); err != nil {
 		return err
 	}
 	defer r.tg.Done()
+	if _, active := r.restoreMode.managedSetUserPause(time.Time{}); active {
+		return nil
+	}
 	r.uploadHeap.managedResume()
 	return nil
 }
@@ -555,8 +590,11 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		staticAvailableChan:       make(chan struct{}),
 		staticUploadCompletedChan: make(chan struct{}),
 
-		pieceUsage:  make([]bool, entry.ErasureCode().NumPieces()),
-		unusedHosts: make(map[string]struct{}, len(hosts)),
+		staticHostNetworks: r.staticPiecePlacement.callHostNetworks(),
+
+		pieceUsage:   make([]bool, entry.ErasureCode().NumPieces()),
+		unusedHosts:  make(map[string]struct{}, len(hosts)),
+		usedNetworks: make(map[string]int),
 	}
 
 	// Every chunk can have a different set of unused hosts.
@@ -587,14 +625,16 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 			//   counted (this shouldn't happen under the current code, but
 			//   previous and possibly future bugs have allowed hosts to
 			//   sometimes wind up holding multiple piece of the same chunk)
+			// + The piece must have been verified after it was repaired.
 			hpk := piece.HostPubKey.String()
 			goodForRenew, exists := goodForRenew[hpk]
 			offline, exists2 := offline[hpk]
 			redundantPiece := uuc.pieceUsage[pieceIndex]
 			_, exists3 := uuc.unusedHosts[hpk]
-			if exists && goodForRenew && exists2 && !offline && exists3 && !redundantPiece {
+			if exists && goodForRenew && exists2 && !offline && exists3 && !redundantPiece && !piece.Unverified {
 				uuc.pieceUsage[pieceIndex] = true
 				uuc.piecesCompleted++
+				uuc.updateUsedNetworks(hpk, 1)
 			}
 
 			// In all cases, if this host already has a piece, the host cannot
@@ -605,8 +645,13 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		// roots of the uploaded pieces in order to be able to later perform an
 		// integrity check while repairing if the repair pulls information from
 		// a local (and therefore potentially altered or corrupt) file.
+		//
+		// It also means that the chunk is being repaired or migrated. The
+		// pieces uploaded by a repair are verified before they count towards
+		// the redundancy to catch hosts which acknowledge but drop them.
 		if len(pieceSet) > 0 {
 			uuc.staticExpectedPieceRoots[pieceIndex] = pieceSet[0].MerkleRoot
+			uuc.staticVerifyPieces = true
 		}
 	}
 	// Now that we have calculated the completed pieces for the chunk we can
@@ -695,6 +740,7 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 
 	// Iterate through the set of newUnfinishedChunks and remove any that are
 	// completed or are not downloadable.
+	repairThreshold := entry.RepairThreshold()
 	incompleteChunks := newUnfinishedChunks[:0]
 	for _, chunk := range newUnfinishedChunks {
 		// Check the chunk status. A chunk is repairable if it can be fully
@@ -702,7 +748,8 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		// if the chunk needs repair, which is only true if more than a certain
 		// amount of redundancy is missing. We only repair above a certain
 		// threshold of missing redundancy to minimize the amount of repair work
-		// that gets triggered by host churn.
+		// that gets triggered by host churn. The threshold adapts to the
+		// reliability of the hosts storing the file.
 		//
 		// While a file could be on disk as long as !os.IsNotExist(err), for the
 		// purposes of repairing a file is only considered on disk if it can be
@@ -710,7 +757,7 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		// it is likely that we can not read the file in which case it can not
 		// be used for repair.
 		repairable := chunk.health <= 1 || chunk.onDisk
-		needsRepair := modules.NeedsRepair(chunk.health)
+		needsRepair := modules.NeedsAdaptiveRepair(chunk.health, repairThreshold)
 
 		if r.deps.Disrupt("AddUnrepairableChunks") && needsRepair {
 			incompleteChunks = append(incompleteChunks, chunk)
@@ -771,11 +818,10 @@ func (r *Renter) managedBlockUntilSynced() bool {
 func (r *Renter) managedAddChunksToHeap(hosts map[string]struct{}) (*uniqueRefreshPaths, error) {
 	siaPaths := r.newUniqueRefreshPaths()
 	prevHeapLen := r.uploadHeap.managedLen()
-	// Loop until the upload heap has maxUploadHeapChunks in it or the directory
-	// heap is empty
+	// Loop until the upload heap is full or the directory heap is empty
 	offline, goodForRenew, _ := r.managedContractUtilityMaps()
 	consecutiveDirHeapFailures := 0
-	for r.uploadHeap.managedLen() < maxUploadHeapChunks && r.directoryHeap.managedLen() > 0 {
+	for r.uploadHeap.managedLen() < r.staticConcurrency.callRepairUploadChunks() && r.directoryHeap.managedLen() > 0 {
 		select {
 		case <-r.tg.StopChan():
 			return siaPaths, errors.New("renter shutdown before we could finish adding chunks to heap")
@@ -809,7 +855,7 @@ func (r *Renter) managedAddChunksToHeap(hosts map[string]struct{}) (*uniqueRefre
 		// If the directory that was just popped does not need to be repaired then
 		// return
 		heapHealth, _ := dir.managedHeapHealth()
-		if !modules.NeedsRepair(heapHealth) {
+		if !modules.MayNeedRepair(heapHealth) {
 			r.repairLog.Debugln("no more chunks added to the upload heap because directory popped is healthy")
 			return siaPaths, nil
 		}
@@ -895,6 +941,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 		build.Critical("callBuildAndPushChunks called without providing any files")
 		return
 	}
+	maxChunks := r.staticConcurrency.callRepairUploadChunks()
 
 	// Loop through the set of files, building a temporary heap of chunks that
 	// need repairs. A temporary heap is used because we do not know in advance
@@ -976,7 +1023,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 			// can guarantee that if this directory is 100% full of chunks that
 			// have worse health than the current directory heap, we will still
 			// keep all of them.
-			if len(tempChunkHeap) < maxUploadHeapChunks*2 {
+			if len(tempChunkHeap) < maxChunks*2 {
 				continue
 			}
 
@@ -987,7 +1034,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 			// chunks that will never be put into the full heap because the
 			// health is too poor.
 			var chunksToKeep []*unfinishedUploadChunk
-			for len(tempChunkHeap) > maxUploadHeapChunks {
+			for len(tempChunkHeap) > maxChunks {
 				chunksToKeep = append(chunksToKeep, heap.Pop(&tempChunkHeap).(*unfinishedUploadChunk))
 			}
 
@@ -1022,7 +1069,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 	// We now have a temporary heap of the worst chunks in the directory. Move
 	// the chunks from the temporary heap to the upload heap until either there
 	// are no more temporary chunks or until the upload heap is full.
-	for len(tempChunkHeap) > 0 && (r.uploadHeap.managedLen() < maxUploadHeapChunks || target == targetBackupChunks) {
+	for len(tempChunkHeap) > 0 && (r.uploadHeap.managedLen() < maxChunks || target == targetBackupChunks) {
 		// Add this chunk to the upload heap.
 		chunk := heap.Pop(&tempChunkHeap).(*unfinishedUploadChunk)
 		pushed, err := r.managedPushChunkForRepair(chunk, chunkTypeLocalChunk)
@@ -1073,7 +1120,7 @@ func (r *Renter) callBuildAndPushChunks(files []*filesystem.FileNode, hosts map[
 	// If the worst ignored health is below the repair threshold, ie does not need
 	// to be repaired, there is no need to re-add the directory to the directory
 	// heap.
-	if !modules.NeedsRepair(wh.health) {
+	if !modules.MayNeedRepair(wh.health) {
 		return
 	}
 
@@ -1176,8 +1223,8 @@ func (r *Renter) managedBuildChunkHeap(dirSiaPath modules.SiaPath, hosts map[str
 		// bubble. Since the repair loop operates off of the metadata
 		// information updated by bubble this cached health is accurate enough
 		// to use in order to determine if a file has any chunks that need
-		// repair
-		ignore := file.NumChunks() == file.NumStuckChunks() || !modules.NeedsRepair(file.Metadata().CachedHealth)
+		// repair at the file's adaptive repair threshold
+		ignore := file.NumChunks() == file.NumStuckChunks() || !modules.NeedsAdaptiveRepair(file.Metadata().CachedHealth, file.RepairThreshold())
 		if target == targetUnstuckChunks && ignore {
 			err = file.Close()
 			if err != nil {
@@ -1200,7 +1247,7 @@ func (r *Renter) managedBuildChunkHeap(dirSiaPath modules.SiaPath, hosts map[str
 	// the absolute worst case, each file will be only contributing one chunk to
 	// the heap, so this shortcut will not be missing any important chunks. This
 	// shortcut will also not be used for directories that have fewer than
-	// 'maxUploadHeapChunks' files in them, minimzing the impact of this code in
+	// 'maxChunks' files in them, minimzing the impact of this code in
 	// the typical case.
 	//
 	// This check only applies to normal repairs. Stuck repairs have their own
@@ -1213,20 +1260,21 @@ func (r *Renter) managedBuildChunkHeap(dirSiaPath modules.SiaPath, hosts map[str
 	// the worst case, where we are sorting 251 files with 1 chunk each, there
 	// is not much slowdown compared to skipping the sort, because the sort is
 	// so fast.
-	if len(files) > maxUploadHeapChunks && target == targetUnstuckChunks {
+	maxChunks := r.staticConcurrency.callRepairUploadChunks()
+	if len(files) > maxChunks && target == targetUnstuckChunks {
 		// Sort so that the highest health chunks will be first in the array.
 		// Higher health values equal worse health for the file, and we want to
 		// focus on the worst files.
 		sort.Slice(files, func(i, j int) bool {
 			return files[i].Metadata().CachedHealth > files[j].Metadata().CachedHealth
 		})
-		for i := maxUploadHeapChunks; i < len(files); i++ {
+		for i := maxChunks; i < len(files); i++ {
 			err = files[i].Close()
 			if err != nil {
 				r.log.Println("WARN: Could not close file:", files[i].SiaFilePath(), err)
 			}
 		}
-		files = files[:maxUploadHeapChunks]
+		files = files[:maxChunks]
 	}
 
 	// Build the unfinished upload chunks and add them to the upload heap
@@ -1335,10 +1383,14 @@ func (r *Renter) managedRefreshHostsAndWorkers() map[string]struct{} {
 	// of the hosts they are using, instead of just the FileContractID.
 	currentContracts := r.hostContractor.Contracts()
 	hosts := make(map[string]struct{})
+	hostPublicKeys := make([]types.SiaPublicKey, 0, len(currentContracts))
 	for _, contract := range currentContracts {
 		hosts[contract.HostPublicKey.String()] = struct{}{}
+		hostPublicKeys = append(hostPublicKeys, contract.HostPublicKey)
 	}
-	// Refresh the worker pool as well.
+	// Refresh the networks of the hosts for the piece placement and the worker
+	// pool as well.
+	r.managedRefreshHostNetworks(hostPublicKeys)
 	r.staticWorkerPool.callUpdate()
 	return hosts
 }
@@ -1353,7 +1405,7 @@ func (r *Renter) managedRepairLoop() error {
 	// directory heap is in good health, ie does not need to be repaired, and
 	// there are no more chunks that could be added to the heap.
 	dirHeapHealth, _ := r.directoryHeap.managedPeekHealth()
-	smallRepair := !modules.NeedsRepair(dirHeapHealth)
+	smallRepair := !modules.MayNeedRepair(dirHeapHealth)
 
 	// Limit the amount of time spent in each iteration of the repair loop so
 	// that changes to the directory heap take effect sooner rather than later.
@@ -1558,7 +1610,7 @@ func (r *Renter) threadedUploadAndRepair() {
 		// heap is empty, there is no work to do and the thread should block
 		// until there is work to do.
 		dirHeapHealth, _ := r.directoryHeap.managedPeekHealth()
-		if r.uploadHeap.managedLen() == 0 && !modules.NeedsRepair(dirHeapHealth) {
+		if r.uploadHeap.managedLen() == 0 && !modules.MayNeedRepair(dirHeapHealth) {
 			// TODO: This has a tiny window where it might be dumping out chunks
 			// that need health, if the upload call is appending to the
 			// directory heap because there is a new upload.
@@ -1571,12 +1623,17 @@ func (r *Renter) threadedUploadAndRepair() {
 			r.directoryHeap.managedReset()
 
 			// If the file system is healthy then block until there is a new
-			// upload or there is a repair that is needed.
+			// upload or there is a repair that is needed. The repairNeeded
+			// signal is only sent once a directory reaches the RepairThreshold,
+			// so the file system is also rechecked periodically to catch files
+			// with a lower adaptive repair threshold.
 			select {
 			case <-r.uploadHeap.newUploads:
 				r.repairLog.Debugln("repair loop triggered by new upload channel")
 			case <-r.uploadHeap.repairNeeded:
 				r.repairLog.Debugln("repair loop triggered by repair needed channel")
+			case <-time.After(repairLoopResetFrequency):
+				r.repairLog.Debugln("repair loop triggered by periodic recheck")
 			case <-r.tg.StopChan():
 				return
 			}
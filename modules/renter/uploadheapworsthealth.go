@@ -21,7 +21,7 @@ type (
 // and updates the worst known health to account for this chunk.
 func (wh *worstIgnoredHealth) updateWorstIgnoredHealth(newHealth float64, newHealthRemote bool) {
 	// The new health is not worse if it does not need to be repaired.
-	if !modules.NeedsRepair(newHealth) {
+	if !modules.MayNeedRepair(newHealth) {
 		return
 	}
 	// The new health is not worse if it is not remote, but the worst health is
@@ -54,7 +54,7 @@ func (wh *worstIgnoredHealth) updateWorstIgnoredHealth(newHealth float64, newHea
 // directory.
 func (wh *worstIgnoredHealth) canSkip(chunkHealth float64, chunkRemote bool) bool {
 	// Can skip any chunk that does not need to be repaired.
-	if !modules.NeedsRepair(chunkHealth) {
+	if !modules.MayNeedRepair(chunkHealth) {
 		return true
 	}
 	// Cannot skip any chunks if we are not targeting unstuck chunks. Assuming
@@ -15,8 +15,9 @@ func TestUpdateWorstIgnoredHealth(t *testing.T) {
 	if wh.health != 0 || wh.remote != false {
 		t.Error("bad wh update")
 	}
-	// Try updating with a higher health, but below the repair threshold.
-	wh.updateWorstIgnoredHealth(0.2, false)
+	// Try updating with a higher health, but below the minimum repair
+	// threshold.
+	wh.updateWorstIgnoredHealth(0.1, false)
 	if wh.health != 0 || wh.remote != false {
 		t.Error("bad wh update")
 	}
@@ -30,8 +31,8 @@ func TestUpdateWorstIgnoredHealth(t *testing.T) {
 	if wh.health != 0.4 || wh.remote != false {
 		t.Error("bad wh update")
 	}
-	// Try updating with a lower health and remote set, but below the repair
-	// threshold.
+	// Try updating with a lower health and remote set, but below the minimum
+	// repair threshold.
 	wh.updateWorstIgnoredHealth(0.1, true)
 	if wh.health != 0.4 || wh.remote != false {
 		t.Error("bad wh update")
@@ -73,7 +74,7 @@ func TestWIHCanSkip(t *testing.T) {
 	t.Parallel()
 
 	// The target is not set, nothing should be skippable unless it is below the
-	// repair threshold.
+	// minimum repair threshold.
 	wh := worstIgnoredHealth{
 		health: 0.3,
 		remote: false,
@@ -82,10 +83,10 @@ func TestWIHCanSkip(t *testing.T) {
 		nextDirRemote: false,
 	}
 	// TEST GROUP A
-	if !wh.canSkip(0.2, true) {
+	if !wh.canSkip(0.1, true) {
 		t.Error("Bad skip")
 	}
-	if !wh.canSkip(0.2, true) {
+	if !wh.canSkip(0.1, true) {
 		t.Error("Bad skip")
 	}
 	if wh.canSkip(0.28, false) {
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"fmt"
+	"hash"
 	"io"
 	"sync"
 
@@ -150,6 +151,12 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	if force && repair {
 		return nil, errors.New("'force' and 'repair' can't both be set")
 	}
+	// Make sure that the checksum algorithm is supported.
+	if up.ChecksumAlgorithm != "" {
+		if _, err := up.ChecksumAlgorithm.NewHash(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if force {
@@ -200,12 +207,18 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 // the Sia network, this will happen faster than the entire upload is complete -
 // the streamer may continue uploading in the background after returning while
 // it is boosting redundancy.
-func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (fileNode *filesystem.FileNode, err error) {
+func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (*filesystem.FileNode, error) {
 	// Check the upload params first.
-	fileNode, err = r.managedInitUploadStream(up)
+	fileNode, err := r.managedInitUploadStream(up)
 	if err != nil {
 		return nil, err
 	}
+	return r.callUploadStreamToFile(up, fileNode, reader)
+}
+
+// callUploadStreamToFile uploads the data of the reader to the file created by
+// managedInitUploadStream. See callUploadStreamFromReader.
+func (r *Renter) callUploadStreamToFile(up modules.FileUploadParams, fileNode *filesystem.FileNode, reader io.Reader) (_ *filesystem.FileNode, err error) {
 	// Need to make a copy of this value for the defer statement. Because
 	// 'fileNode' is a named value, if you run the call `return nil, err`, then
 	// 'fileNode' will be set to 'nil' when 'fileNode.Close()' gets called in
@@ -236,6 +249,18 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		return nil, fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
 	}
 
+	// Compute the checksum of the plaintext data while it is read from the
+	// input stream.
+	source := reader
+	var checksum hash.Hash
+	if up.ChecksumAlgorithm != "" {
+		checksum, err = up.ChecksumAlgorithm.NewHash()
+		if err != nil {
+			return nil, err
+		}
+		source = io.TeeReader(reader, checksum)
+	}
+
 	// Read the chunks we want to upload one by one from the input stream using
 	// shards. A shard will signal completion after reading the input but
 	// before the upload is done.
@@ -264,7 +289,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		}
 
 		// Create a new shard set it to be the source reader of the chunk.
-		ss := NewStreamShard(reader, peek)
+		ss := NewStreamShard(source, peek)
 		uuc.sourceReader = ss
 
 		// Check if the chunk needs any work or if we can skip it.
@@ -333,6 +358,13 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		}
 	}
 
+	// Store the checksum now that the whole stream was read.
+	if checksum != nil {
+		if err := fileNode.SetChecksum(up.ChecksumAlgorithm, checksum.Sum(nil)); err != nil {
+			return nil, errors.AddContext(err, "unable to store checksum")
+		}
+	}
+
 	// Disrupt to force an error and ensure the fileNode is being closed
 	// correctly.
 	if r.deps.Disrupt("failUploadStreamFromReader") {
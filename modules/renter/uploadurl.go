@@ -0,0 +1,390 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// urlUploadDialTimeout is the timeout for connecting to the remote server
+	// of an upload from a URL.
+	urlUploadDialTimeout = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// urlUploadResponseTimeout is the time the remote server of an upload from
+	// a URL has to send the response headers.
+	urlUploadResponseTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// urlUploadStallTimeout is the time a transfer from a URL may go without
+	// receiving any data before it is aborted.
+	urlUploadStallTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 2 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// urlUploadHistoryLimit is the maximum number of uploads from a URL which
+	// are kept in the history. Once the limit is reached, the oldest completed
+	// uploads are dropped.
+	urlUploadHistoryLimit = build.Select(build.Var{
+		Dev:      100,
+		Standard: 1000,
+		Testing:  10,
+	}).(int)
+
+	// urlUploadClient is the http client used to fetch the data of uploads
+	// from a URL. A stalled transfer is detected by the urlUploadReader since
+	// an overall timeout would abort large uploads.
+	urlUploadClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: urlUploadDialTimeout}).DialContext,
+			TLSHandshakeTimeout:   urlUploadDialTimeout,
+			ResponseHeaderTimeout: urlUploadResponseTimeout,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+)
+
+var (
+	// errURLUploadTooLarge is returned if the data fetched from a URL exceeds
+	// the size limit of the upload.
+	errURLUploadTooLarge = errors.New("remote file exceeds the maximum upload size")
+
+	// errURLUploadChecksumMismatch is returned if the data fetched from a URL
+	// doesn't match the checksum provided by the caller.
+	errURLUploadChecksumMismatch = errors.New("checksum of the fetched data doesn't match the expected checksum")
+
+	// errURLUploadStalled is returned if the remote server stopped sending
+	// data for longer than urlUploadStallTimeout.
+	errURLUploadStalled = errors.New("transfer from the remote server stalled")
+
+	// errURLUploadUnsupportedScheme is returned if a URL is neither http nor
+	// https.
+	errURLUploadUnsupportedScheme = errors.New("only http and https URLs are supported")
+)
+
+type (
+	// urlUpload tracks the progress of an upload from a remote URL.
+	urlUpload struct {
+		staticID        modules.URLUploadID
+		staticURL       string
+		staticSiaPath   modules.SiaPath
+		staticStartTime time.Time
+
+		contentLength int64
+		fetched       uint64
+		checksum      []byte
+		completed     bool
+		stalled       bool
+		err           error
+		endTime       time.Time
+		mu            sync.Mutex
+	}
+
+	// urlUploadReader wraps the body of a http response. It enforces the
+	// size limit of the upload, hashes the data and keeps track of the fetch
+	// progress. If staticStallTimer is set, it only runs while a Read is
+	// waiting for the remote server, so a consumer which stops reading
+	// because of back-pressure isn't mistaken for a stalled server.
+	urlUploadReader struct {
+		staticBody       io.Reader
+		staticHasher     hash.Hash
+		staticMaxSize    uint64
+		staticStallTimer *time.Timer
+		staticUpload     *urlUpload
+	}
+)
+
+// Read implements the io.Reader interface.
+func (ur *urlUploadReader) Read(b []byte) (int, error) {
+	if ur.staticStallTimer != nil {
+		ur.staticStallTimer.Reset(urlUploadStallTimeout)
+	}
+	n, err := ur.staticBody.Read(b)
+	if ur.staticStallTimer != nil {
+		ur.staticStallTimer.Stop()
+	}
+	_, _ = ur.staticHasher.Write(b[:n])
+
+	ur.staticUpload.mu.Lock()
+	ur.staticUpload.fetched += uint64(n)
+	fetched := ur.staticUpload.fetched
+	ur.staticUpload.mu.Unlock()
+
+	if fetched > ur.staticMaxSize {
+		return n, errURLUploadTooLarge
+	}
+	return n, err
+}
+
+// info returns the URLUploadInfo of the upload.
+func (u *urlUpload) info() modules.URLUploadInfo {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	info := modules.URLUploadInfo{
+		ID:            u.staticID,
+		URL:           u.staticURL,
+		SiaPath:       u.staticSiaPath,
+		ContentLength: u.contentLength,
+		Fetched:       u.fetched,
+		Completed:     u.completed,
+		StartTime:     u.staticStartTime,
+		EndTime:       u.endTime,
+	}
+	if u.checksum != nil {
+		info.SHA256 = hex.EncodeToString(u.checksum)
+	}
+	if u.err != nil {
+		info.Error = u.err.Error()
+	}
+	return info
+}
+
+// managedFinish marks the upload as completed.
+func (u *urlUpload) managedFinish(checksum []byte, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.checksum = checksum
+	u.completed = true
+	u.err = err
+	u.endTime = time.Now()
+}
+
+// managedStall marks the upload as stalled.
+func (u *urlUpload) managedStall() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.stalled = true
+}
+
+// managedStalled returns whether the upload stalled.
+func (u *urlUpload) managedStalled() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.stalled
+}
+
+// validateUploadURL checks that the provided string is an absolute http or
+// https URL.
+func validateUploadURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.AddContext(err, "unable to parse url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errURLUploadUnsupportedScheme
+	}
+	if u.Host == "" {
+		return errors.New("url is missing a host")
+	}
+	return nil
+}
+
+// UploadFromURL fetches the data at the provided URL and uploads it to the
+// Sia network. The remote server is contacted before UploadFromURL returns,
+// the transfer itself happens in the background.
+func (r *Renter) UploadFromURL(up modules.FileUploadParams, params modules.URLUploadParams) (modules.URLUploadID, error) {
+	if err := r.tg.Add(); err != nil {
+		return "", err
+	}
+	defer r.tg.Done()
+
+	// Check the params.
+	if err := validateUploadURL(params.URL); err != nil {
+		return "", err
+	}
+	if params.SHA256 != nil && len(params.SHA256) != sha256.Size {
+		return "", fmt.Errorf("expected a sha256 checksum of %v bytes but got %v bytes", sha256.Size, len(params.SHA256))
+	}
+	if up.Repair {
+		return "", errors.New("can't repair a file from a url")
+	}
+	maxSize := params.MaxSize
+	if maxSize == 0 {
+		maxSize = modules.DefaultURLUploadMaxSize
+	}
+
+	// Start the request. The request is cancelled if the renter shuts down.
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params.URL, nil)
+	if err != nil {
+		cancel()
+		return "", errors.AddContext(err, "unable to create request")
+	}
+	resp, err := urlUploadClient.Do(req)
+	if err != nil {
+		cancel()
+		return "", errors.AddContext(err, "unable to fetch url")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		cancel()
+		return "", errors.Compose(fmt.Errorf("unexpected status code %v when fetching url", resp.StatusCode), resp.Body.Close())
+	}
+	if resp.ContentLength > 0 && uint64(resp.ContentLength) > maxSize {
+		cancel()
+		return "", errors.Compose(errURLUploadTooLarge, resp.Body.Close())
+	}
+
+	// Register the upload.
+	u := &urlUpload{
+		staticID:        modules.URLUploadID(hex.EncodeToString(fastrand.Bytes(16))),
+		staticURL:       params.URL,
+		staticSiaPath:   up.SiaPath,
+		staticStartTime: time.Now(),
+		contentLength:   resp.ContentLength,
+	}
+	r.urlUploadHistoryMu.Lock()
+	r.urlUploadHistory[u.staticID] = u
+	r.pruneURLUploadHistory()
+	r.urlUploadHistoryMu.Unlock()
+
+	// Perform the upload in the background.
+	go func() {
+		defer cancel()
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if err := r.tg.Add(); err != nil {
+			u.managedFinish(nil, err)
+			return
+		}
+		defer r.tg.Done()
+
+		// Abort the request if the renter shuts down.
+		go func() {
+			select {
+			case <-r.tg.StopChan():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		// Abort the request if the remote server stops sending data. The
+		// timer is started by the reader.
+		stallTimer := time.AfterFunc(urlUploadStallTimeout, func() {
+			u.managedStall()
+			cancel()
+		})
+		stallTimer.Stop()
+		defer stallTimer.Stop()
+
+		reader := &urlUploadReader{
+			staticBody:       resp.Body,
+			staticHasher:     sha256.New(),
+			staticMaxSize:    maxSize,
+			staticStallTimer: stallTimer,
+			staticUpload:     u,
+		}
+		err := r.managedUploadFromURLReader(up, reader, params.SHA256)
+		if err != nil && u.managedStalled() {
+			err = errors.Compose(errURLUploadStalled, err)
+		}
+		u.managedFinish(reader.staticHasher.Sum(nil), err)
+		if err != nil {
+			r.log.Printf("WARN: upload of '%v' from url '%v' failed: %v", up.SiaPath, params.URL, err)
+		}
+	}()
+	return u.staticID, nil
+}
+
+// managedUploadFromURLReader uploads the data of the reader and verifies its
+// checksum afterwards. If the upload fails after the file was created, the
+// partially uploaded file is deleted. A file which already existed is never
+// deleted.
+func (r *Renter) managedUploadFromURLReader(up modules.FileUploadParams, reader *urlUploadReader, checksum []byte) error {
+	fileNode, err := r.managedInitUploadStream(up)
+	if err != nil {
+		return err
+	}
+	fileNode, err = r.callUploadStreamToFile(up, fileNode, reader)
+	if err == nil {
+		err = fileNode.Close()
+	}
+	if err == nil && checksum != nil && !bytes.Equal(checksum, reader.staticHasher.Sum(nil)) {
+		err = errURLUploadChecksumMismatch
+	}
+	if err != nil {
+		deleteErr := r.DeleteFile(up.SiaPath)
+		if errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			deleteErr = nil
+		}
+		return errors.Compose(err, deleteErr)
+	}
+	return nil
+}
+
+// pruneURLUploadHistory drops the oldest completed uploads from the history
+// until it contains at most urlUploadHistoryLimit uploads. Uploads which are
+// still in progress are never dropped. It must be called with
+// urlUploadHistoryMu held.
+func (r *Renter) pruneURLUploadHistory() {
+	excess := len(r.urlUploadHistory) - urlUploadHistoryLimit
+	if excess <= 0 {
+		return
+	}
+	var completed []modules.URLUploadInfo
+	for _, u := range r.urlUploadHistory {
+		if info := u.info(); info.Completed {
+			completed = append(completed, info)
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].EndTime.Before(completed[j].EndTime)
+	})
+	for i := 0; i < excess && i < len(completed); i++ {
+		delete(r.urlUploadHistory, completed[i].ID)
+	}
+}
+
+// URLUploadByID returns an upload from a URL given its id.
+func (r *Renter) URLUploadByID(id modules.URLUploadID) (modules.URLUploadInfo, bool) {
+	r.urlUploadHistoryMu.Lock()
+	u, exists := r.urlUploadHistory[id]
+	r.urlUploadHistoryMu.Unlock()
+	if !exists {
+		return modules.URLUploadInfo{}, false
+	}
+	return u.info(), true
+}
+
+// URLUploadHistory returns the list of uploads from a URL, sorted by start
+// time with the most recent upload first.
+func (r *Renter) URLUploadHistory() []modules.URLUploadInfo {
+	r.urlUploadHistoryMu.Lock()
+	uploads := make([]modules.URLUploadInfo, 0, len(r.urlUploadHistory))
+	for _, u := range r.urlUploadHistory {
+		uploads = append(uploads, u.info())
+	}
+	r.urlUploadHistoryMu.Unlock()
+
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].StartTime.After(uploads[j].StartTime)
+	})
+	return uploads
+}
@@ -0,0 +1,202 @@
+package renter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// TestValidateUploadURL probes validateUploadURL.
+func TestValidateUploadURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"http://example.com/file", true},
+		{"https://example.com:8080/file?query=1", true},
+		{"ftp://example.com/file", false},
+		{"file:///etc/passwd", false},
+		{"example.com/file", false},
+		{"http://", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		err := validateUploadURL(test.url)
+		if test.valid && err != nil {
+			t.Errorf("expected '%v' to be valid but got %v", test.url, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("expected '%v' to be invalid", test.url)
+		}
+	}
+}
+
+// TestURLUploadReader probes the urlUploadReader.
+func TestURLUploadReader(t *testing.T) {
+	t.Parallel()
+
+	data := fastrand.Bytes(1000)
+
+	// Read all the data with a sufficient limit.
+	u := &urlUpload{}
+	ur := &urlUploadReader{
+		staticBody:    bytes.NewReader(data),
+		staticHasher:  sha256.New(),
+		staticMaxSize: uint64(len(data)),
+		staticUpload:  u,
+	}
+	read, err := ioutil.ReadAll(ur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatal("data doesn't match")
+	}
+	checksum := sha256.Sum256(data)
+	if !bytes.Equal(ur.staticHasher.Sum(nil), checksum[:]) {
+		t.Fatal("checksum doesn't match")
+	}
+	if info := u.info(); info.Fetched != uint64(len(data)) {
+		t.Fatalf("expected %v bytes to be fetched but got %v", len(data), info.Fetched)
+	}
+
+	// Read the data with a limit that is too small.
+	ur = &urlUploadReader{
+		staticBody:    bytes.NewReader(data),
+		staticHasher:  sha256.New(),
+		staticMaxSize: uint64(len(data) - 1),
+		staticUpload:  &urlUpload{},
+	}
+	_, err = ioutil.ReadAll(ur)
+	if !errors.Contains(err, errURLUploadTooLarge) {
+		t.Fatal("expected errURLUploadTooLarge but got", err)
+	}
+}
+
+// TestPruneURLUploadHistory probes pruneURLUploadHistory.
+func TestPruneURLUploadHistory(t *testing.T) {
+	t.Parallel()
+
+	r := &Renter{urlUploadHistory: make(map[modules.URLUploadID]*urlUpload)}
+	start := time.Now()
+	for i := 0; i < urlUploadHistoryLimit+5; i++ {
+		u := &urlUpload{staticID: modules.URLUploadID(fmt.Sprint(i))}
+		// Keep the oldest upload in progress.
+		if i > 0 {
+			u.completed = true
+			u.endTime = start.Add(time.Duration(i) * time.Second)
+		}
+		r.urlUploadHistory[u.staticID] = u
+		r.pruneURLUploadHistory()
+	}
+	if len(r.urlUploadHistory) != urlUploadHistoryLimit {
+		t.Fatalf("expected %v uploads but got %v", urlUploadHistoryLimit, len(r.urlUploadHistory))
+	}
+	if _, exists := r.urlUploadHistory["0"]; !exists {
+		t.Fatal("upload in progress shouldn't be pruned")
+	}
+	for i := 1; i <= 5; i++ {
+		if _, exists := r.urlUploadHistory[modules.URLUploadID(fmt.Sprint(i))]; exists {
+			t.Fatal("oldest completed upload should have been pruned", i)
+		}
+	}
+}
+
+// TestURLUploadReaderStall checks that the stall timer of a urlUploadReader
+// only runs while the reader waits for the remote server.
+func TestURLUploadReaderStall(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	pr, pw := io.Pipe()
+	stalled := make(chan struct{})
+	stallTimer := time.AfterFunc(urlUploadStallTimeout, func() {
+		close(stalled)
+		pw.CloseWithError(errURLUploadStalled)
+	})
+	stallTimer.Stop()
+	ur := &urlUploadReader{
+		staticBody:       pr,
+		staticHasher:     sha256.New(),
+		staticMaxSize:    10,
+		staticStallTimer: stallTimer,
+		staticUpload:     &urlUpload{},
+	}
+
+	// A consumer which doesn't read for longer than the stall timeout doesn't
+	// cause a stall.
+	go func() {
+		_, _ = pw.Write([]byte{1})
+	}()
+	if _, err := ur.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(urlUploadStallTimeout * 3 / 2)
+	select {
+	case <-stalled:
+		t.Fatal("upload stalled while the consumer wasn't reading")
+	default:
+	}
+
+	// A remote server which doesn't send data causes a stall.
+	if _, err := ur.Read(make([]byte, 1)); !errors.Contains(err, errURLUploadStalled) {
+		t.Fatal("expected errURLUploadStalled but got", err)
+	}
+}
+
+// TestUploadFromURLExistingFile checks that a failed upload from a URL to an
+// existing siapath doesn't delete the existing file.
+func TestUploadFromURLExistingFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	fileNode, err := rt.renter.newRenterTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := rt.renter.staticFileSystem.FileSiaPath(fileNode)
+	if err := fileNode.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload to the existing siapath without force.
+	data := fastrand.Bytes(100)
+	up := modules.FileUploadParams{SiaPath: siaPath, CipherType: crypto.TypeDefaultRenter}
+	ur := &urlUploadReader{
+		staticBody:    bytes.NewReader(data),
+		staticHasher:  sha256.New(),
+		staticMaxSize: uint64(len(data)),
+		staticUpload:  &urlUpload{},
+	}
+	if err := rt.renter.managedUploadFromURLReader(up, ur, nil); !errors.Contains(err, filesystem.ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+	if _, err := rt.renter.File(siaPath); err != nil {
+		t.Fatal("existing file was deleted", err)
+	}
+}
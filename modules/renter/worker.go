@@ -31,10 +31,6 @@ const (
 	// this constant is not used, it is left in for documentation purposes only.
 	minRHP3Version = "1.4.10"
 
-	// minRegistryVersion defines the minimum version that is required for a
-	// host to support the registry.
-	minRegistryVersion = "1.5.1"
-
 	// registryCacheSize is the cache size used by a single worker for the
 	// registry cache.
 	registryCacheSize = 1 << 20 // 1 MiB
@@ -116,6 +112,10 @@ type (
 		// registry entries.
 		staticRegistryCache *registryRevisionCache
 
+		// staticNegotiationState tracks the negotiation of the capabilities
+		// with the worker's host.
+		staticNegotiationState workerNegotiationState
+
 		// staticSetInitialEstimates is an object that ensures the initial queue
 		// estimates of the HS and RJ queues are only set once.
 		staticSetInitialEstimates sync.Once
@@ -48,6 +48,7 @@ const (
 	categoryRepairUpload
 	categorySnapshotDownload
 	categorySnapshotUpload
+	categoryStreamDownload
 	categorySubscription
 	categoryUpload
 )
@@ -93,6 +94,15 @@ var (
 		Standard: 40 * time.Minute,
 		Testing:  5 * time.Minute, // needs to be long even in testing
 	}).(time.Duration)
+
+	// accountRefillSupplyRetryInterval defines how long the worker waits
+	// before retrying a refill that was rejected because the host reached its
+	// ephemeral account supply cap.
+	accountRefillSupplyRetryInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
 )
 
 type (
@@ -124,6 +134,12 @@ type (
 		// actions are downloads, registry reads, registry writes, etc.
 		spending spendingDetails
 
+		// The money withdrawn within the rolling window of the spending cap
+		// and the cooldown after reaching the cap.
+		spendingBuckets          []spendingBucket
+		spendingCapCooldownUntil time.Time
+		spendingCapReached       bool
+
 		// Error tracking.
 		recentErr         error
 		recentErrTime     time.Time
@@ -133,6 +149,11 @@ type (
 		// the host.
 		syncAt time.Time
 
+		// refillRetryAt defines the earliest time the renter should try to
+		// refill the account again after the host rejected a deposit because
+		// its ephemeral account supply cap was reached.
+		refillRetryAt time.Time
+
 		// Variables to manage a race condition around account creation, where
 		// the account must be available in the data structure before it has
 		// been synced to disk successfully (to avoid holding a lock on the
@@ -184,7 +205,7 @@ func (s *spendingDetails) update(category spendingCategory, amount types.Currenc
 	}
 
 	switch category {
-	case categoryDownload:
+	case categoryDownload, categoryStreamDownload:
 		s.downloads = s.downloads.Add(amount)
 	case categorySnapshotDownload:
 		s.snapshotDownloads = s.snapshotDownloads.Add(amount)
@@ -360,6 +381,10 @@ func (a *account) managedCommitWithdrawal(category spendingCategory, withdrawal,
 	// (no need to sanity check - the implementation of 'Sub' does this for us)
 	a.pendingWithdrawals = a.pendingWithdrawals.Sub(withdrawal.Add(refund))
 
+	// failed withdrawals count towards the spending cap as well since the
+	// host might have charged for them
+	a.trackWindowSpending(withdrawal)
+
 	// reflect the successful withdrawal in the balance field
 	if success {
 		if a.balance.Cmp(withdrawal) >= 0 {
@@ -379,6 +404,9 @@ func (a *account) managedCommitWithdrawal(category spendingCategory, withdrawal,
 func (a *account) managedNeedsToRefill(target types.Currency) bool {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	if time.Now().Before(a.refillRetryAt) {
+		return false
+	}
 	return a.availableBalance().Cmp(target) < 0
 }
 
@@ -443,6 +471,9 @@ func (a *account) managedStatus() modules.WorkerAccountStatus {
 		AvailableBalance: a.availableBalance(),
 		NegativeBalance:  a.negativeBalance,
 
+		WindowSpending:           a.windowSpending(),
+		SpendingCapCooldownUntil: a.spendingCapCooldownUntil,
+
 		RecentErr:         recentErrStr,
 		RecentErrTime:     a.recentErrTime,
 		RecentSuccessTime: a.recentSuccessTime,
@@ -658,6 +689,21 @@ func (w *worker) managedRefillAccount() {
 		// account.
 		w.staticAccount.managedCommitDeposit(amount, err == nil)
 
+		// If the host reached its ephemeral account supply cap, the host is
+		// not at fault. Retry the refill later without putting the worker on
+		// a maintenance cooldown, the remaining balance can still be used.
+		if modules.IsEphemeralAccountSupplyReachedErr(err) {
+			w.staticAccount.mu.Lock()
+			w.staticAccount.recentErr = err
+			w.staticAccount.recentErrTime = time.Now()
+			w.staticAccount.refillRetryAt = time.Now().Add(accountRefillSupplyRetryInterval)
+			w.staticAccount.mu.Unlock()
+			w.renter.tg.AfterFunc(accountRefillSupplyRetryInterval, func() {
+				w.staticWake()
+			})
+			return
+		}
+
 		// Track the outcome of the account refill - this ensures a proper
 		// working of the maintenance cooldown mechanism.
 		cd := w.managedTrackAccountRefillErr(err)
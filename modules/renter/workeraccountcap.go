@@ -0,0 +1,277 @@
+package renter
+
+// workeraccountcap.go contains the caps on the money the workers spend from
+// the ephemeral accounts of hosts within a rolling window. The caps bound the
+// damage a malicious or buggy host can do by charging for operations that
+// fail. Failed withdrawals count towards the cap with their full amount since
+// the host might have charged for them anyway. Once a cap is reached, the
+// account is put on cooldown until enough spending has left the window.
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// accountSpendingBucketDuration is the granularity with which the
+	// spending of an account is tracked within the rolling window.
+	accountSpendingBucketDuration = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// defaultAccountSpendingWindow is the default duration of the rolling
+	// window of the account spending caps.
+	defaultAccountSpendingWindow = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: 24 * time.Hour,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+)
+
+var (
+	// errAccountSpendingCapReached is returned when a withdrawal would exceed
+	// the spending cap of an account.
+	errAccountSpendingCapReached = errors.New("ephemeral account spending cap reached")
+
+	// errInvalidAccountSpendingWindow is returned if the window of the
+	// spending caps is too short.
+	errInvalidAccountSpendingWindow = errors.New("spending cap window must be at least as long as the tracking granularity")
+)
+
+type (
+	// accountSpendingCaps contains the renter's account spending caps.
+	accountSpendingCaps struct {
+		caps modules.AccountSpendingCaps
+		mu   sync.Mutex
+	}
+
+	// spendingBucket is the amount of money that was withdrawn from an
+	// account within accountSpendingBucketDuration after start.
+	spendingBucket struct {
+		amount types.Currency
+		start  time.Time
+	}
+)
+
+// newAccountSpendingCaps creates a new accountSpendingCaps object without
+// caps.
+func newAccountSpendingCaps() *accountSpendingCaps {
+	return &accountSpendingCaps{
+		caps: modules.AccountSpendingCaps{
+			Hosts:  make(map[string]types.Currency),
+			Window: defaultAccountSpendingWindow,
+		},
+	}
+}
+
+// validateAccountSpendingCaps checks the caps for errors, normalizes the host
+// keys and fills in the default window if none was provided.
+func validateAccountSpendingCaps(caps modules.AccountSpendingCaps) (modules.AccountSpendingCaps, error) {
+	if caps.Window == 0 {
+		caps.Window = defaultAccountSpendingWindow
+	}
+	if caps.Window < accountSpendingBucketDuration {
+		return modules.AccountSpendingCaps{}, errInvalidAccountSpendingWindow
+	}
+	hosts := make(map[string]types.Currency, len(caps.Hosts))
+	for key, limit := range caps.Hosts {
+		var spk types.SiaPublicKey
+		if err := spk.LoadString(key); err != nil {
+			return modules.AccountSpendingCaps{}, errors.AddContext(err, fmt.Sprintf("invalid host key '%v'", key))
+		}
+		hosts[spk.String()] = limit
+	}
+	caps.Hosts = hosts
+	return caps, nil
+}
+
+// callCap returns the spending cap of the host with the given key and the
+// duration of the window.
+func (asc *accountSpendingCaps) callCap(hostKey types.SiaPublicKey) (types.Currency, time.Duration) {
+	asc.mu.Lock()
+	defer asc.mu.Unlock()
+	if limit, exists := asc.caps.Hosts[hostKey.String()]; exists {
+		return limit, asc.caps.Window
+	}
+	return asc.caps.Default, asc.caps.Window
+}
+
+// callCaps returns a copy of the caps.
+func (asc *accountSpendingCaps) callCaps() modules.AccountSpendingCaps {
+	asc.mu.Lock()
+	defer asc.mu.Unlock()
+	caps := asc.caps
+	caps.Hosts = make(map[string]types.Currency, len(asc.caps.Hosts))
+	for key, limit := range asc.caps.Hosts {
+		caps.Hosts[key] = limit
+	}
+	return caps
+}
+
+// callSetCaps replaces the caps.
+func (asc *accountSpendingCaps) callSetCaps(caps modules.AccountSpendingCaps) {
+	asc.mu.Lock()
+	defer asc.mu.Unlock()
+	asc.caps = caps
+}
+
+// managedCheckSpendingCap checks whether withdrawing the given amount keeps
+// the account's spending within the window below the cap. If the settled
+// spending already reaches the cap, the account is put on cooldown until
+// enough spending has left the window. The returned bool indicates whether
+// the cap was reached or the account recovered from reaching it, meaning the
+// corresponding alert needs to be registered or unregistered.
+func (a *account) managedCheckSpendingCap(amount, limit types.Currency, window time.Duration) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	a.pruneSpendingBuckets(now, window)
+
+	// Check whether the account is still on cooldown.
+	if now.Before(a.spendingCapCooldownUntil) && !limit.IsZero() {
+		return false, errors.AddContext(errAccountSpendingCapReached, fmt.Sprintf("account is on cooldown until %v", a.spendingCapCooldownUntil.Format(time.RFC3339)))
+	}
+	a.spendingCapCooldownUntil = time.Time{}
+
+	// Check whether the withdrawal fits into the cap.
+	settled := a.windowSpending()
+	if limit.IsZero() || settled.Add(a.pendingWithdrawals).Add(amount).Cmp(limit) <= 0 {
+		changed := a.spendingCapReached
+		a.spendingCapReached = false
+		return changed, nil
+	}
+
+	// If only the pending withdrawals push the spending over the cap, the
+	// account isn't put on cooldown since they might be refunded.
+	if settled.Add(amount).Cmp(limit) <= 0 {
+		return false, errors.AddContext(errAccountSpendingCapReached, "pending withdrawals exceed the cap")
+	}
+
+	// Put the account on cooldown until enough spending has left the window.
+	a.spendingCapCooldownUntil = now.Add(window)
+	for _, b := range a.spendingBuckets {
+		settled = settled.Sub(b.amount)
+		if settled.Add(amount).Cmp(limit) <= 0 {
+			a.spendingCapCooldownUntil = b.start.Add(accountSpendingBucketDuration).Add(window)
+			break
+		}
+	}
+	changed := !a.spendingCapReached
+	a.spendingCapReached = true
+	return changed, errors.AddContext(errAccountSpendingCapReached, fmt.Sprintf("spent %v of %v within %v", a.windowSpending().HumanString(), limit.HumanString(), window))
+}
+
+// pruneSpendingBuckets removes the buckets which left the window.
+func (a *account) pruneSpendingBuckets(now time.Time, window time.Duration) {
+	var i int
+	for i < len(a.spendingBuckets) && a.spendingBuckets[i].start.Add(accountSpendingBucketDuration).Add(window).Before(now) {
+		i++
+	}
+	a.spendingBuckets = a.spendingBuckets[i:]
+}
+
+// trackWindowSpending adds the withdrawn amount to the account's spending
+// within the window.
+func (a *account) trackWindowSpending(amount types.Currency) {
+	if amount.IsZero() {
+		return
+	}
+	start := time.Now().Truncate(accountSpendingBucketDuration)
+	if n := len(a.spendingBuckets); n > 0 && a.spendingBuckets[n-1].start.Equal(start) {
+		a.spendingBuckets[n-1].amount = a.spendingBuckets[n-1].amount.Add(amount)
+		return
+	}
+	a.spendingBuckets = append(a.spendingBuckets, spendingBucket{
+		amount: amount,
+		start:  start,
+	})
+}
+
+// windowSpending returns the money withdrawn from the account within the
+// window.
+func (a *account) windowSpending() types.Currency {
+	var spent types.Currency
+	for _, b := range a.spendingBuckets {
+		spent = spent.Add(b.amount)
+	}
+	return spent
+}
+
+// managedCheckAccountSpendingCap checks whether the worker may withdraw the
+// given amount from its account and registers or unregisters the alert for
+// the account's spending cap.
+func (w *worker) managedCheckAccountSpendingCap(amount types.Currency) error {
+	limit, window := w.renter.staticAccountSpendingCaps.callCap(w.staticHostPubKey)
+	changed, err := w.staticAccount.managedCheckSpendingCap(amount, limit, window)
+	if !changed {
+		return err
+	}
+	id := modules.AlertIDRenterAccountSpendingCap(w.staticHostPubKeyStr)
+	if err != nil {
+		w.renter.log.Printf("WARN: worker %v reached its account spending cap: %v", w.staticHostPubKeyStr, err)
+		cause := fmt.Sprintf("host %v: %v", w.staticHostPubKeyStr, err)
+		w.renter.staticAlerter.RegisterAlert(id, AlertMSGAccountSpendingCap, cause, modules.SeverityWarning)
+	} else {
+		w.renter.staticAlerter.UnregisterAlert(id)
+	}
+	return err
+}
+
+// managedResetSpendingCapCooldowns lifts the spending cap cooldowns of all
+// accounts.
+func (am *accountManager) managedResetSpendingCapCooldowns() {
+	am.mu.Lock()
+	accounts := make([]*account, 0, len(am.accounts))
+	for _, acc := range am.accounts {
+		accounts = append(accounts, acc)
+	}
+	am.mu.Unlock()
+	for _, acc := range accounts {
+		acc.mu.Lock()
+		acc.spendingCapCooldownUntil = time.Time{}
+		acc.mu.Unlock()
+	}
+}
+
+// AccountSpendingCaps returns the caps on the money the workers may spend
+// from the ephemeral accounts of hosts.
+func (r *Renter) AccountSpendingCaps() (modules.AccountSpendingCaps, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.AccountSpendingCaps{}, err
+	}
+	defer r.tg.Done()
+	return r.staticAccountSpendingCaps.callCaps(), nil
+}
+
+// SetAccountSpendingCaps sets the caps on the money the workers may spend
+// from the ephemeral accounts of hosts.
+func (r *Renter) SetAccountSpendingCaps(caps modules.AccountSpendingCaps) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	caps, err := validateAccountSpendingCaps(caps)
+	if err != nil {
+		return err
+	}
+	r.staticAccountSpendingCaps.callSetCaps(caps)
+
+	// Lift the cooldowns so that the new caps apply right away.
+	r.staticAccountManager.managedResetSpendingCapCooldowns()
+
+	id := r.mu.Lock()
+	r.persist.AccountSpendingCaps = caps
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	return err
+}
@@ -0,0 +1,120 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAccountSpendingCap is a unit test for the account's spending cap.
+func TestAccountSpendingCap(t *testing.T) {
+	t.Parallel()
+
+	a := &account{}
+	limit := types.NewCurrency64(100)
+	window := time.Second
+
+	// Without a cap there is no limit.
+	changed, err := a.managedCheckSpendingCap(types.NewCurrency64(1000), types.ZeroCurrency, window)
+	if err != nil || changed {
+		t.Fatal("unexpected result", changed, err)
+	}
+
+	// Spend 60 and check that the remaining 40 may be withdrawn.
+	a.trackWindowSpending(types.NewCurrency64(60))
+	if _, err := a.managedCheckSpendingCap(types.NewCurrency64(40), limit, window); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pending withdrawals count towards the cap but don't trigger a cooldown.
+	a.managedTrackWithdrawal(types.NewCurrency64(30))
+	changed, err = a.managedCheckSpendingCap(types.NewCurrency64(20), limit, window)
+	if !errors.Contains(err, errAccountSpendingCapReached) || changed {
+		t.Fatal("expected cap to be reached by pending withdrawal", changed, err)
+	}
+	if !a.spendingCapCooldownUntil.IsZero() {
+		t.Fatal("account shouldn't be on cooldown")
+	}
+
+	// Failed withdrawals count towards the cap as well.
+	a.managedCommitWithdrawal(categoryDownload, types.NewCurrency64(30), types.ZeroCurrency, false)
+	if spent := a.windowSpending(); !spent.Equals64(90) {
+		t.Fatal("expected window spending of 90, got", spent)
+	}
+
+	// Exceeding the cap puts the account on cooldown.
+	changed, err = a.managedCheckSpendingCap(types.NewCurrency64(20), limit, window)
+	if !errors.Contains(err, errAccountSpendingCapReached) || !changed {
+		t.Fatal("expected cap to be reached", changed, err)
+	}
+	if a.spendingCapCooldownUntil.Before(time.Now()) {
+		t.Fatal("account should be on cooldown")
+	}
+
+	// While on cooldown even small withdrawals are rejected.
+	changed, err = a.managedCheckSpendingCap(types.NewCurrency64(1), limit, window)
+	if !errors.Contains(err, errAccountSpendingCapReached) || changed {
+		t.Fatal("expected cooldown", changed, err)
+	}
+
+	// Once the spending left the window, the account recovers.
+	time.Sleep(time.Until(a.spendingCapCooldownUntil))
+	changed, err = a.managedCheckSpendingCap(types.NewCurrency64(20), limit, window)
+	if err != nil || !changed {
+		t.Fatal("account should have recovered", changed, err)
+	}
+	if spent := a.windowSpending(); !spent.IsZero() {
+		t.Fatal("spending should have left the window", spent)
+	}
+}
+
+// TestValidateAccountSpendingCaps is a unit test for
+// validateAccountSpendingCaps.
+func TestValidateAccountSpendingCaps(t *testing.T) {
+	t.Parallel()
+
+	// The default window is filled in.
+	caps, err := validateAccountSpendingCaps(modules.AccountSpendingCaps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Window != defaultAccountSpendingWindow || caps.Hosts == nil {
+		t.Fatal("defaults weren't set", caps)
+	}
+
+	// Windows shorter than the tracking granularity are rejected.
+	_, err = validateAccountSpendingCaps(modules.AccountSpendingCaps{Window: accountSpendingBucketDuration / 2})
+	if !errors.Contains(err, errInvalidAccountSpendingWindow) {
+		t.Fatal("expected errInvalidAccountSpendingWindow, got", err)
+	}
+
+	// Invalid host keys are rejected.
+	_, err = validateAccountSpendingCaps(modules.AccountSpendingCaps{
+		Hosts: map[string]types.Currency{"foo": types.SiacoinPrecision},
+	})
+	if err == nil {
+		t.Fatal("expected invalid host key to be rejected")
+	}
+
+	// Host caps override the default cap.
+	asc := newAccountSpendingCaps()
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: make([]byte, 32)}
+	caps, err = validateAccountSpendingCaps(modules.AccountSpendingCaps{
+		Default: types.SiacoinPrecision,
+		Hosts:   map[string]types.Currency{hostKey.String(): types.SiacoinPrecision.Mul64(2)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	asc.callSetCaps(caps)
+	if limit, _ := asc.callCap(hostKey); !limit.Equals(types.SiacoinPrecision.Mul64(2)) {
+		t.Fatal("wrong host cap", limit)
+	}
+	if limit, _ := asc.callCap(types.SiaPublicKey{}); !limit.Equals(types.SiacoinPrecision) {
+		t.Fatal("wrong default cap", limit)
+	}
+}
@@ -0,0 +1,175 @@
+package renter
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// benchmarkTTFBSize is the size of the download used to measure the time
+	// to first byte of a host.
+	benchmarkTTFBSize = 1 << 12 // 4 KiB
+)
+
+var (
+	// benchmarkThroughputSize is the size of the download used to measure the
+	// throughput of a host. It is capped at the size of a sector. If the
+	// contract with the host contains less data, all of the data is downloaded
+	// instead.
+	benchmarkThroughputSize = build.Select(build.Var{
+		Dev:      uint64(1 << 20), // 1 MiB
+		Standard: uint64(1 << 22), // 4 MiB
+		Testing:  uint64(1 << 18), // 256 KiB
+	}).(uint64)
+
+	// benchmarkTimeout is the maximum amount of time a benchmark of a single
+	// host may take. It is the default of the background job timeout.
+	benchmarkTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  30 * time.Second,
+	}).(time.Duration)
+
+	// errBenchmarkNoData is returned if a host can't be benchmarked because
+	// the renter didn't upload enough data to it yet.
+	errBenchmarkNoData = errors.New("contract doesn't contain enough data to benchmark downloads")
+)
+
+// managedBenchmarkLatency measures the round-trip time of a has sector job.
+func (w *worker) managedBenchmarkLatency(ctx context.Context) (time.Duration, error) {
+	responseChan := make(chan *jobHasSectorResponse)
+	j := w.newJobHasSector(ctx, responseChan, crypto.Hash{})
+	if !w.staticJobHasSectorQueue.callAdd(j) {
+		return 0, errors.New("worker unavailable")
+	}
+	var resp *jobHasSectorResponse
+	select {
+	case <-ctx.Done():
+		return 0, errors.New("benchmark interrupted")
+	case resp = <-responseChan:
+	}
+	return resp.staticJobTime, resp.staticErr
+}
+
+// managedBenchmarkRead measures how long it takes to download length bytes
+// from the host.
+func (w *worker) managedBenchmarkRead(ctx context.Context, length uint64) (time.Duration, error) {
+	start := time.Now()
+	_, err := w.ReadOffset(ctx, categoryDownload, 0, length)
+	return time.Since(start), err
+}
+
+// managedBenchmark benchmarks the worker's host. The results are also recorded
+// as interactions with the host in the hostdb.
+func (w *worker) managedBenchmark(ctx context.Context) (hb modules.HostBenchmark) {
+	hb.HostPubKey = w.staticHostPubKey
+	err := func() error {
+		ctx, cancel := context.WithTimeout(ctx, w.renter.staticTimeouts.callJob(operationClassBackground))
+		defer cancel()
+
+		latency, err := w.managedBenchmarkLatency(ctx)
+		if err != nil {
+			return errors.AddContext(err, "failed to measure latency")
+		}
+		hb.Latency = latency
+
+		// Check how much data is available for downloading. ReadOffset
+		// requires the length to be a multiple of the segment size.
+		rc, ok := w.renter.hostContractor.ContractByPublicKey(w.staticHostPubKey)
+		if !ok {
+			return errors.New("no contract with host")
+		}
+		size := rc.Size()
+		if size < benchmarkTTFBSize {
+			return errBenchmarkNoData
+		}
+		ttfb, err := w.managedBenchmarkRead(ctx, benchmarkTTFBSize)
+		if err != nil {
+			return errors.AddContext(err, "failed to measure time to first byte")
+		}
+		hb.TTFB = ttfb
+
+		// The host only serves reads within a single sector.
+		length := benchmarkThroughputSize
+		if length > modules.SectorSize {
+			length = modules.SectorSize
+		}
+		if size < length {
+			length = size - size%crypto.SegmentSize
+		}
+		elapsed, err := w.managedBenchmarkRead(ctx, length)
+		if err != nil {
+			return errors.AddContext(err, "failed to measure throughput")
+		}
+		hb.DownloadSize = length
+		if elapsed > 0 {
+			hb.Throughput = uint64(float64(length) / elapsed.Seconds())
+		}
+		return nil
+	}()
+
+	// Record the interaction with the host. Missing data isn't the host's
+	// fault.
+	if err == nil {
+		err = w.renter.hostDB.IncrementSuccessfulInteractions(w.staticHostPubKey)
+	} else if !errors.Contains(err, errBenchmarkNoData) {
+		err = errors.Compose(err, w.renter.hostDB.IncrementFailedInteractions(w.staticHostPubKey))
+	}
+	if err != nil {
+		hb.Error = err.Error()
+	}
+	return hb
+}
+
+// BenchmarkHosts measures the latency, time to first byte and throughput of
+// the provided hosts using small paid downloads. If no hosts are provided, all
+// hosts the renter has workers for are benchmarked.
+func (r *Renter) BenchmarkHosts(hosts []types.SiaPublicKey) ([]modules.HostBenchmark, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	// Grab the workers of the hosts.
+	var workers []*worker
+	if len(hosts) == 0 {
+		workers = r.staticWorkerPool.callWorkers()
+	}
+	for _, host := range hosts {
+		w, err := r.staticWorkerPool.callWorker(host)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to benchmark host "+host.String())
+		}
+		workers = append(workers, w)
+	}
+
+	// Benchmark all hosts in parallel.
+	results := make([]modules.HostBenchmark, len(workers))
+	var wg sync.WaitGroup
+	for i, w := range workers {
+		wg.Add(1)
+		go func(i int, w *worker) {
+			defer wg.Done()
+			results[i] = w.managedBenchmark(r.tg.StopCtx())
+		}(i, w)
+	}
+	wg.Wait()
+
+	// Sort the results by throughput. Hosts that failed the benchmark go last.
+	sort.SliceStable(results, func(i, j int) bool {
+		if (results[i].Error == "") != (results[j].Error == "") {
+			return results[i].Error == ""
+		}
+		return results[i].Throughput > results[j].Throughput
+	})
+	return results, nil
+}
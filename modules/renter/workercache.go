@@ -33,6 +33,18 @@ type (
 		staticHostMuxAddress  string
 		staticSynced          bool
 
+		// staticHostCapabilities are the capabilities of the host and
+		// staticProtocolVersion is the negotiated protocol version. Until the
+		// capabilities are negotiated, they are derived from the host's
+		// version.
+		staticHostCapabilities       modules.HostCapabilities
+		staticProtocolVersion        uint64
+		staticCapabilitiesNegotiated bool
+
+		// staticIngressProtection indicates that the host requires an
+		// ingress proof for expensive RPCs.
+		staticIngressProtection bool
+
 		staticLastUpdate time.Time
 	}
 )
@@ -71,20 +83,30 @@ func (w *worker) managedUpdateCache() {
 
 	// Create the cache object.
 	newCache := &workerCache{
-		staticBlockHeight:     w.renter.cs.Height(),
-		staticContractID:      renterContract.ID,
-		staticContractUtility: renterContract.Utility,
-		staticHostMuxAddress:  host.SiaMuxAddress(),
-		staticHostVersion:     host.Version,
-		staticRenterAllowance: w.renter.hostContractor.Allowance(),
-		staticSynced:          w.renter.cs.Synced(),
+		staticBlockHeight:       w.renter.cs.Height(),
+		staticContractID:        renterContract.ID,
+		staticContractUtility:   renterContract.Utility,
+		staticHostMuxAddress:    host.SiaMuxAddress(),
+		staticHostVersion:       host.Version,
+		staticIngressProtection: host.IngressProtection,
+		staticRenterAllowance:   w.renter.hostContractor.Allowance(),
+		staticSynced:            w.renter.cs.Synced(),
 
 		staticLastUpdate: time.Now(),
 	}
 
+	// Use the negotiated capabilities unless the host's version changed since
+	// the negotiation. The capabilities are negotiated by their own job, see
+	// staticTryNegotiateCapabilities.
+	newCache.staticHostCapabilities = modules.HostCapabilitiesFromVersion(host.Version)
+	wns := &w.staticNegotiationState
+	wns.mu.Lock()
+	wns.applyToCache(newCache)
+
 	// Atomically store the cache object in the worker.
 	ptr := unsafe.Pointer(newCache)
 	atomic.StorePointer(&w.atomicCache, ptr)
+	wns.mu.Unlock()
 
 	// Wake the worker when the cache needs to be updated again. Note that we
 	// need to signal the cache update is complete before waking the worker,
@@ -0,0 +1,212 @@
+package renter
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// delegateKeySpecifier is the specifier used for deriving the key which
+	// identifies the renter as a delegate from the RenterSeed.
+	delegateKeySpecifier = types.NewSpecifier("delegate")
+)
+
+var (
+	// errDelegatedReadInvalidProof is returned if the data returned by a host
+	// for a delegated read doesn't match the requested sector.
+	errDelegatedReadInvalidProof = errors.New("host returned data with an invalid proof")
+
+	// errDelegatedReadUnsupported is returned if the worker's host doesn't
+	// support RPCDelegatedRead.
+	errDelegatedReadUnsupported = errors.New("host doesn't support delegated reads")
+
+	// errDelegatedReadWrongDelegate is returned if the renter tries to use a
+	// token which was issued to a different delegate.
+	errDelegatedReadWrongDelegate = errors.New("token was issued to a different delegate")
+)
+
+// managedDelegateKey derives the key pair which identifies the renter when it
+// downloads sectors of another renter's contract as a delegate.
+func (r *Renter) managedDelegateKey() (crypto.SecretKey, crypto.PublicKey, error) {
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return crypto.SecretKey{}, crypto.PublicKey{}, errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	entropy := crypto.HashAll(rs, delegateKeySpecifier)
+	defer fastrand.Read(entropy[:])
+	sk, pk := crypto.GenerateKeyPairDeterministic(entropy)
+	return sk, pk, nil
+}
+
+// managedDelegatedRead executes RPCDelegatedRead on the worker's host to
+// download a full sector of the contract the token grants access to. The
+// request is signed with the delegate key and the data is verified against the
+// sector's root before it is returned.
+func (w *worker) managedDelegatedRead(token modules.DelegatedReadToken, delegateSK crypto.SecretKey, root crypto.Hash) (_ []byte, err error) {
+	if !w.staticCache().staticHostCapabilities.Has(modules.CapabilityDelegatedReads) {
+		return nil, errDelegatedReadUnsupported
+	}
+
+	// Defer a function that schedules a price table update in case we received
+	// an error that indicates the host deems our price table invalid.
+	defer func() {
+		if modules.IsPriceTableInvalidErr(err) {
+			w.staticTryForcePriceTableUpdate()
+		}
+	}()
+
+	// check the spending cap and track the withdrawal
+	pt := w.staticPriceTable().staticPriceTable
+	sections := []modules.LoopReadRequestSection{{
+		MerkleRoot: root,
+		Offset:     0,
+		Length:     uint32(modules.SectorSize),
+	}}
+	cost := modules.DelegatedReadCost(&pt, sections)
+	err = w.managedCheckAccountSpendingCap(cost)
+	if err != nil {
+		return nil, err
+	}
+	w.staticAccount.managedTrackWithdrawal(cost)
+	defer func() {
+		w.staticAccount.managedCommitWithdrawal(categoryDownload, cost, types.ZeroCurrency, err == nil)
+	}()
+
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	// sign the request with the delegate key
+	req := modules.RPCDelegatedReadRequest{
+		Token:    token,
+		Sections: sections,
+	}
+	req.Signature = crypto.SignHash(req.Hash(w.staticHostPubKey, pt.UID), delegateSK)
+
+	// prepare a buffer so we can optimize our writes
+	buffer := bytes.NewBuffer(nil)
+	err = modules.RPCWrite(buffer, modules.RPCDelegatedRead)
+	if err != nil {
+		return nil, err
+	}
+	err = w.staticWriteIngressProof(buffer, modules.RPCDelegatedRead)
+	if err != nil {
+		return nil, err
+	}
+	err = modules.RPCWrite(buffer, req)
+	if err != nil {
+		return nil, err
+	}
+	err = modules.RPCWrite(buffer, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+	err = w.staticAccount.ProvidePayment(buffer, cost, pt.HostBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	_, err = buffer.WriteTo(stream)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to write request")
+	}
+
+	// The proof of a full sector is empty.
+	var resp modules.RPCDelegatedReadResponse
+	err = modules.RPCReadMaxLen(stream, &resp, modules.RPCMinLen+modules.SectorSize)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read response")
+	}
+	if uint64(len(resp.Data)) != modules.SectorSize || crypto.MerkleRoot(resp.Data) != root {
+		return nil, errDelegatedReadInvalidProof
+	}
+	return resp.Data, nil
+}
+
+// DelegateKey returns the public key which identifies the renter as a
+// delegate. Other renters issue DelegatedReadTokens to this key to authorize
+// the renter to download the sectors of their contracts.
+func (r *Renter) DelegateKey() (types.SiaPublicKey, error) {
+	if err := r.tg.Add(); err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	defer r.tg.Done()
+	_, pk, err := r.managedDelegateKey()
+	if err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	return types.Ed25519PublicKey(pk), nil
+}
+
+// AuthorizeDelegatedRead issues a token which authorizes the delegate to
+// download the sectors of the contract with the given id from its host for
+// the given number of blocks. The delegate pays for the downloads itself.
+func (r *Renter) AuthorizeDelegatedRead(fcid types.FileContractID, delegate types.SiaPublicKey, duration types.BlockHeight) (modules.DelegatedReadAuthorization, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DelegatedReadAuthorization{}, err
+	}
+	defer r.tg.Done()
+	if duration == 0 {
+		return modules.DelegatedReadAuthorization{}, errors.New("duration must be greater than zero")
+	}
+
+	// Find the host of the contract.
+	var hostKey types.SiaPublicKey
+	found := false
+	for _, c := range r.hostContractor.Contracts() {
+		if c.ID == fcid {
+			hostKey, found = c.HostPublicKey, true
+			break
+		}
+	}
+	if !found {
+		return modules.DelegatedReadAuthorization{}, errors.New("no contract with id " + fcid.String())
+	}
+
+	token, err := r.hostContractor.DelegatedReadToken(fcid, delegate, r.cs.Height()+duration)
+	if err != nil {
+		return modules.DelegatedReadAuthorization{}, errors.AddContext(err, "unable to sign token")
+	}
+	return modules.DelegatedReadAuthorization{
+		HostPublicKey: hostKey,
+		Token:         token,
+	}, nil
+}
+
+// DelegatedReadSector downloads the sector with the given root from the host
+// of another renter's contract, using a token that renter issued to the
+// renter's delegate key. The renter needs a funded worker for the host.
+func (r *Renter) DelegatedReadSector(auth modules.DelegatedReadAuthorization, root crypto.Hash) ([]byte, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	sk, pk, err := r.managedDelegateKey()
+	if err != nil {
+		return nil, err
+	}
+	defer fastrand.Read(sk[:])
+	if !auth.Token.Delegate.Equals(types.Ed25519PublicKey(pk)) {
+		return nil, errDelegatedReadWrongDelegate
+	}
+	w, err := r.staticWorkerPool.callWorker(auth.HostPublicKey)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to download sector from host "+auth.HostPublicKey.String())
+	}
+	data, err := w.managedDelegatedRead(auth.Token, sk, root)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to download sector")
+	}
+	return data, nil
+}
@@ -7,6 +7,7 @@ package renter
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
@@ -133,12 +134,23 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 	// unregistered with the chunk.
 	fetchOffset, fetchLength := sectorOffsetAndLength(udc.staticFetchOffset, udc.staticFetchLength, udc.erasureCode)
 	root := udc.staticChunkMap[w.staticHostPubKey.String()].root
-	pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	readSector := w.ReadSectorLowPrio
+	if w.renter.restoreMode.managedIsBoosted(udc.download.staticSiaPath) {
+		readSector = w.ReadSector
+	}
+	start := time.Now()
+	pieceData, err := readSector(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
 	if err != nil {
 		w.renter.log.Debugln("worker failed to download sector:", err)
+		if udc.staticStreamHostStats != nil {
+			udc.staticStreamHostStats.callRecordFailure(w.staticHostPubKey.String())
+		}
 		udc.managedUnregisterWorker(w)
 		return
 	}
+	if udc.staticStreamHostStats != nil {
+		udc.staticStreamHostStats.callRecordSuccess(w.staticHostPubKey.String(), time.Since(start))
+	}
 
 	// TODO: Instead of adding the whole sector after the download completes,
 	// have the 'd.Sector' call add to this value ongoing as the sector comes
@@ -256,7 +268,16 @@ func (w *worker) managedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfi
 	// metrics, so that we can avoid holding the worker lock and the udc lock
 	// simultaneously (deadlock risk). The 'owned' variables of the worker are
 	// variables that are only accessed by the master worker thread.
+	//
+	// If the chunk is downloaded for a stream, only the workers of the hosts
+	// which served the previous chunks of the stream the fastest meet the
+	// criteria. Once the standby workers were needed, the criteria are relaxed
+	// so that the workers which are taken off of standby don't immediately go
+	// on standby again.
 	meetsExtraCriteria := true
+	if udc.staticPreferredHosts != nil && !udc.standbyReleased {
+		_, meetsExtraCriteria = udc.staticPreferredHosts[w.staticHostPubKey.String()]
+	}
 
 	// TODO: There's going to need to be some method for relaxing criteria after
 	// the first wave of workers are sent off. If the first waves of workers
@@ -275,6 +275,26 @@ func TestProcessDownloadChunk(t *testing.T) {
 	if len(udc.workersStandby) != 1 {
 		t.Fatalf("expected 1 standby worker but got %v", len(udc.workersStandby))
 	}
+	// worker isn't preferred by the stream
+	udc = chunk()
+	udc.staticPreferredHosts = map[string]struct{}{"otherhost": {}}
+	c = wt.managedProcessDownloadChunk(udc)
+	if c != nil {
+		t.Fatal("c should be nil")
+	}
+	if len(udc.workersStandby) != 1 {
+		t.Fatalf("expected 1 standby worker but got %v", len(udc.workersStandby))
+	}
+
+	// Valid chunk with the worker not preferred by the stream, but the
+	// standby workers were released.
+	udc = chunk()
+	udc.staticPreferredHosts = map[string]struct{}{"otherhost": {}}
+	udc.standbyReleased = true
+	c = wt.managedProcessDownloadChunk(udc)
+	if c == nil {
+		t.Fatal("c shouldn't be nil")
+	}
 
 	// helper to add jobs to the queue.
 	addBlankJobs := func(n int) {
@@ -0,0 +1,36 @@
+package renter
+
+import (
+	"io"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// staticNewIngressProof creates an ingress proof for the RPC with the given
+// id. If the worker's account is funded, the proof is signed by the account.
+// Otherwise it is signed with the renter's key of the worker's contract. Only
+// if the worker has no usable contract either, it solves the ingress puzzle
+// to bootstrap.
+func (w *worker) staticNewIngressProof(rpcID types.Specifier) modules.RPCIngressProof {
+	if !w.staticAccount.managedMinExpectedBalance().IsZero() {
+		return modules.NewIngressAccountProof(w.staticAccount.staticID, w.staticAccount.staticSecretKey, w.staticHostPubKey, rpcID)
+	}
+	if fcid := w.staticCache().staticContractID; fcid != (types.FileContractID{}) {
+		proof, err := w.renter.hostContractor.IngressProof(fcid, w.staticHostPubKey, rpcID)
+		if err == nil {
+			return proof
+		}
+		w.renter.log.Debugf("WARN: worker %v failed to create contract ingress proof: %v", w.staticHostPubKeyStr, err)
+	}
+	return modules.NewIngressPuzzleProof(w.staticHostPubKey, rpcID, modules.IngressPuzzleDifficulty)
+}
+
+// staticWriteIngressProof writes an ingress proof for the RPC with the given
+// id to w if the worker's host requires one.
+func (w *worker) staticWriteIngressProof(wr io.Writer, rpcID types.Specifier) error {
+	if !modules.RPCRequiresIngressProof(rpcID) || !w.staticCache().staticIngressProtection {
+		return nil
+	}
+	return modules.RPCWrite(wr, w.staticNewIngressProof(rpcID))
+}
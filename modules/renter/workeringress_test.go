@@ -0,0 +1,50 @@
+package renter
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// TestWorkerIngressProof verifies that a worker without a funded account
+// signs its ingress proofs with the renter's key of its contract.
+func TestWorkerIngressProof(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	deps := &dependencies.DependencyPreventEARefill{}
+	wt, err := newWorkerTesterCustomDependency(t.Name(), deps, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if !wt.staticAccount.managedMinExpectedBalance().IsZero() {
+		t.Fatal("account shouldn't be funded")
+	}
+
+	// The proof is signed with the renter's key of the worker's contract.
+	rpcID := modules.RPCUpdatePriceTable
+	proof := wt.staticNewIngressProof(rpcID)
+	if proof.Type != modules.IngressProofContract || proof.ContractID != wt.staticCache().staticContractID {
+		t.Fatal("expected a contract proof", proof)
+	}
+	pk, exists := wt.rt.renter.hostContractor.ContractPublicKey(wt.staticHostPubKey)
+	if !exists {
+		t.Fatal("contract not found")
+	}
+	if err := proof.VerifySignature(pk, wt.staticHostPubKey, rpcID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every proof is unique so that the host doesn't reject it as replayed.
+	if other := wt.staticNewIngressProof(rpcID); other.Hash(wt.staticHostPubKey, rpcID) == proof.Hash(wt.staticHostPubKey, rpcID) {
+		t.Fatal("proofs aren't unique")
+	}
+}
@@ -156,7 +156,7 @@ func (w *worker) initJobDownloadSnapshotQueue() {
 	}
 
 	w.staticJobDownloadSnapshotQueue = &jobDownloadSnapshotQueue{
-		jobGenericQueue: newJobGenericQueue(w),
+		jobGenericQueue: newJobGenericQueue(w, "worker/downloadsnapshot"),
 	}
 }
 
@@ -51,6 +51,10 @@ type (
 		recentErr           error
 		recentErrTime       time.Time
 
+		// staticOperationName is the name of the renter's operation counter
+		// for the jobs of the queue.
+		staticOperationName string
+
 		staticWorkerObj *worker // name conflict with staticWorker method
 		mu              sync.Mutex
 	}
@@ -72,6 +76,10 @@ type (
 		// staticGetMetadata returns a metadata object.
 		staticGetMetadata() interface{}
 
+		// staticOperation returns the name of the operation counter of the
+		// job.
+		staticOperation() string
+
 		// staticCanceled returns true if the job has been canceled, false
 		// otherwise.
 		staticCanceled() bool
@@ -94,6 +102,10 @@ type (
 		// callStatus returns the status of the queue
 		callStatus() workerJobQueueStatus
 
+		// staticOperation returns the name of the operation counter of the
+		// queue's jobs.
+		staticOperation() string
+
 		// staticWorker will return the worker of the job queue.
 		staticWorker() *worker
 	}
@@ -129,11 +141,14 @@ func newJobGeneric(ctx context.Context, queue workerJobQueue, metadata interface
 	}
 }
 
-// newJobGenericQueue will return an initialized generic job queue.
-func newJobGenericQueue(w *worker) *jobGenericQueue {
+// newJobGenericQueue will return an initialized generic job queue. The
+// operation is the name of the renter's operation counter for the queue's
+// jobs.
+func newJobGenericQueue(w *worker, operation string) *jobGenericQueue {
 	return &jobGenericQueue{
-		jobs:            list.New(),
-		staticWorkerObj: w,
+		jobs:                list.New(),
+		staticOperationName: operation,
+		staticWorkerObj:     w,
 	}
 }
 
@@ -152,6 +167,11 @@ func (j *jobGeneric) staticGetMetadata() interface{} {
 	return j.staticMetadata
 }
 
+// staticOperation returns the name of the operation counter of the job.
+func (j *jobGeneric) staticOperation() string {
+	return j.staticQueue.staticOperation()
+}
+
 // add will add a job to the queue.
 func (jq *jobGenericQueue) add(j workerJob) bool {
 	if jq.killed || jq.onCooldown() {
@@ -239,6 +259,7 @@ func (jq *jobGenericQueue) callReportFailure(err error) {
 	jq.consecutiveFailures++
 	jq.recentErr = err
 	jq.recentErrTime = time.Now()
+	jq.staticWorkerObj.renter.staticOperationCounters.RecordError(jq.staticOperationName)
 }
 
 // callReportSuccess lets the job queue know that there was a successsful job.
@@ -252,6 +273,12 @@ func (jq *jobGenericQueue) callReportSuccess() {
 	jq.mu.Unlock()
 }
 
+// staticOperation returns the name of the operation counter of the queue's
+// jobs.
+func (jq *jobGenericQueue) staticOperation() string {
+	return jq.staticOperationName
+}
+
 // callStatus returns the queue status
 func (jq *jobGenericQueue) callStatus() workerJobQueueStatus {
 	jq.mu.Lock()
@@ -129,7 +129,7 @@ func TestWorkerJobGeneric(t *testing.T) {
 	// Create a job queue.
 	w := new(worker)
 	w.renter = new(Renter)
-	jq := newJobGenericQueue(w)
+	jq := newJobGenericQueue(w, "test")
 	cancelCtx, cancel := context.WithCancel(context.Background())
 
 	// Create a job, add the job to the queue, and then ensure that the
@@ -488,7 +488,7 @@ func TestQueueMemoryLeak(t *testing.T) {
 	// Create queue.
 	w := new(worker)
 	w.renter = new(Renter)
-	jq := newJobGenericQueue(w)
+	jq := newJobGenericQueue(w, "test")
 
 	// Prepare a job.
 	cancelCtx, cancel := context.WithCancel(context.Background())
@@ -270,7 +270,7 @@ func (w *worker) initJobReadQueue() {
 		w.renter.log.Critical("incorret call on initJobReadQueue")
 	}
 	w.staticJobReadQueue = &jobReadQueue{
-		jobGenericQueue: newJobGenericQueue(w),
+		jobGenericQueue: newJobGenericQueue(w, "worker/read"),
 	}
 }
 
@@ -282,6 +282,6 @@ func (w *worker) initJobLowPrioReadQueue() {
 		w.renter.log.Critical("incorret call on initJobReadQueue")
 	}
 	w.staticJobLowPrioReadQueue = &jobReadQueue{
-		jobGenericQueue: newJobGenericQueue(w),
+		jobGenericQueue: newJobGenericQueue(w, "worker/lowprioread"),
 	}
 }
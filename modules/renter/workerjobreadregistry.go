@@ -73,7 +73,7 @@ func lookupRegistry(w *worker, spk types.SiaPublicKey, tweak crypto.Hash) (*modu
 	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since ReadRegistry doesn't depend on it.
 	var refund types.Currency
 	var err error
-	if build.VersionCmp(w.staticCache().staticHostVersion, "1.5.5") < 0 {
+	if !w.staticCache().staticHostCapabilities.Has(modules.CapabilityRegistryPricing) {
 		refund, err = pb.V154AddReadRegistryInstruction(spk, tweak)
 	} else {
 		refund, err = pb.AddReadRegistryInstruction(spk, tweak)
@@ -233,7 +233,7 @@ func (w *worker) initJobReadRegistryQueue() {
 	}
 
 	w.staticJobReadRegistryQueue = &jobReadRegistryQueue{
-		jobGenericQueue: newJobGenericQueue(w),
+		jobGenericQueue: newJobGenericQueue(w, "worker/readregistry"),
 	}
 }
 
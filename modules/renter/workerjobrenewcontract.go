@@ -120,7 +120,7 @@ func (w *worker) initJobRenewQueue() {
 	}
 
 	w.staticJobRenewQueue = &jobRenewQueue{
-		jobGenericQueue: newJobGenericQueue(w),
+		jobGenericQueue: newJobGenericQueue(w, "worker/renewcontract"),
 	}
 }
 
@@ -5,7 +5,6 @@ import (
 	"strings"
 	"time"
 
-	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/host/registry"
 	"go.sia.tech/siad/types"
@@ -181,7 +180,7 @@ func (j *jobUpdateRegistry) managedUpdateRegistry() (modules.SignedRegistryValue
 	// Create the program.
 	pt := w.staticPriceTable().staticPriceTable
 	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since UpdateRegistry doesn't depend on it.
-	if build.VersionCmp(w.staticCache().staticHostVersion, "1.5.5") < 0 {
+	if !w.staticCache().staticHostCapabilities.Has(modules.CapabilityRegistryPricing) {
 		pb.V154AddUpdateRegistryInstruction(j.staticSiaPublicKey, j.staticSignedRegistryValue)
 	} else {
 		pb.AddUpdateRegistryInstruction(j.staticSiaPublicKey, j.staticSignedRegistryValue)
@@ -236,7 +235,7 @@ func (w *worker) initJobUpdateRegistryQueue() {
 	}
 
 	w.staticJobUpdateRegistryQueue = &jobUpdateRegistryQueue{
-		jobGenericQueue: newJobGenericQueue(w),
+		jobGenericQueue: newJobGenericQueue(w, "worker/updateregistry"),
 	}
 }
 
@@ -205,7 +205,7 @@ func (w *worker) initJobUploadSnapshotQueue() {
 	}
 
 	w.staticJobUploadSnapshotQueue = &jobUploadSnapshotQueue{
-		jobGenericQueue: newJobGenericQueue(w),
+		jobGenericQueue: newJobGenericQueue(w, "worker/uploadsnapshot"),
 	}
 }
 
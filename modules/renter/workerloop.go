@@ -4,7 +4,7 @@ import (
 	"sync/atomic"
 	"time"
 
-	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -112,7 +112,7 @@ func (w *worker) externTryLaunchSerialJob() {
 	}
 	job := w.staticJobRenewQueue.callNext()
 	if job != nil {
-		w.externLaunchSerialJob(job.callExecute)
+		w.externLaunchSerialJob(w.externCountedJob(job))
 		return
 	}
 	if w.managedNeedsToRefillAccount() {
@@ -121,17 +121,17 @@ func (w *worker) externTryLaunchSerialJob() {
 	}
 	job = w.staticJobUploadSnapshotQueue.callNext()
 	if job != nil {
-		w.externLaunchSerialJob(job.callExecute)
+		w.externLaunchSerialJob(w.externCountedJob(job))
 		return
 	}
 	job = w.staticJobDownloadSnapshotQueue.callNext()
 	if job != nil {
-		w.externLaunchSerialJob(job.callExecute)
+		w.externLaunchSerialJob(w.externCountedJob(job))
 		return
 	}
 	job = w.staticJobUploadSnapshotQueue.callNext()
 	if job != nil {
-		w.externLaunchSerialJob(job.callExecute)
+		w.externLaunchSerialJob(w.externCountedJob(job))
 		return
 	}
 	if w.managedHasUploadJob() {
@@ -140,6 +140,22 @@ func (w *worker) externTryLaunchSerialJob() {
 	}
 }
 
+// externExecuteJob executes a job and adds it to the renter's operation
+// counters. Failures are counted by the job's queue when they are reported.
+func (w *worker) externExecuteJob(job workerJob) {
+	start := time.Now()
+	job.callExecute()
+	w.renter.staticOperationCounters.Record(job.staticOperation(), time.Since(start), nil)
+}
+
+// externCountedJob returns a function which executes the job using
+// externExecuteJob.
+func (w *worker) externCountedJob(job workerJob) func() {
+	return func() {
+		w.externExecuteJob(job)
+	}
+}
+
 // externLaunchAsyncJob accepts a function to retrieve a job and then uses that
 // to retrieve a job and launch it. The bandwidth consumption will be updated as
 // the job starts and finishes.
@@ -151,7 +167,7 @@ func (w *worker) externLaunchAsyncJob(job workerJob) bool {
 	atomic.AddUint64(&w.staticLoopState.atomicWriteDataOutstanding, uploadBandwidth)
 	atomic.AddUint64(&w.staticLoopState.atomicAsyncJobsRunning, 1)
 	fn := func() {
-		job.callExecute()
+		w.externExecuteJob(job)
 		// Subtract the outstanding data now that the job is complete. Atomic
 		// subtraction works by adding and using some bit tricks.
 		atomic.AddUint64(&w.staticLoopState.atomicReadDataOutstanding, -downloadBandwidth)
@@ -235,7 +251,7 @@ func (w *worker) externTryLaunchAsyncJob() bool {
 	}
 	// Check if registry jobs are supported.
 	cache := w.staticCache()
-	if build.VersionCmp(cache.staticHostVersion, minRegistryVersion) >= 0 {
+	if cache.staticHostCapabilities.Has(modules.CapabilityRegistry) {
 		job = w.staticJobUpdateRegistryQueue.callNext()
 		if job != nil {
 			w.externLaunchAsyncJob(job)
@@ -355,6 +371,10 @@ func (w *worker) threadedWorkLoop() {
 		// to build the cache object.
 		w.staticTryUpdateCache()
 
+		// Negotiate the capabilities with the host if they haven't been
+		// negotiated yet. The negotiation runs in the background.
+		w.staticTryNegotiateCapabilities()
+
 		// If the worker needs to sync the account balance, perform a sync
 		// operation. This should be attempted before launching any jobs.
 		if w.managedNeedsToSyncAccountBalanceToHost() {
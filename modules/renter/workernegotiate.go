@@ -0,0 +1,144 @@
+package renter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// workerNegotiationState tracks the negotiation of the protocol version and
+	// the capabilities with the worker's host. The negotiation runs as its own
+	// async job so that the cache update never blocks on the network. Failed
+	// negotiations are retried after a cooldown which grows with every
+	// consecutive failure.
+	workerNegotiationState struct {
+		// hostVersion is the host version the capabilities were negotiated
+		// for. A change of the host's version requires a new negotiation.
+		hostVersion     string
+		capabilities    modules.HostCapabilities
+		protocolVersion uint64
+		negotiated      bool
+
+		consecutiveFailures uint64
+		cooldownUntil       time.Time
+		running             bool
+		mu                  sync.Mutex
+	}
+)
+
+// applyToCache sets the negotiated capabilities on the cache if they were
+// negotiated for the cache's host version. It must be called with the mutex
+// held.
+func (wns *workerNegotiationState) applyToCache(cache *workerCache) {
+	if !wns.negotiated || wns.hostVersion != cache.staticHostVersion {
+		return
+	}
+	cache.staticHostCapabilities = wns.capabilities
+	cache.staticProtocolVersion = wns.protocolVersion
+	cache.staticCapabilitiesNegotiated = true
+}
+
+// staticTryNegotiateCapabilities launches the negotiation of the capabilities
+// with the worker's host unless they were already negotiated, a negotiation is
+// in progress or the negotiation is on cooldown.
+func (w *worker) staticTryNegotiateCapabilities() {
+	cache := w.staticCache()
+	if cache == nil || cache.staticCapabilitiesNegotiated || cache.staticHostMuxAddress == "" {
+		return
+	}
+	wns := &w.staticNegotiationState
+	wns.mu.Lock()
+	if wns.running || time.Now().Before(wns.cooldownUntil) {
+		wns.mu.Unlock()
+		return
+	}
+	wns.running = true
+	wns.mu.Unlock()
+
+	err := w.renter.tg.Launch(w.managedNegotiateCapabilities)
+	if err != nil {
+		wns.mu.Lock()
+		wns.running = false
+		wns.mu.Unlock()
+	}
+}
+
+// managedNegotiateCapabilities negotiates the protocol version and the
+// capabilities with the worker's host and stores them in the worker's cache.
+// Hosts which don't support the negotiation keep the capabilities derived from
+// their version. If the negotiation fails for any other reason, it is retried
+// once the cooldown has passed.
+//
+// NOTE: this method must only be launched by staticTryNegotiateCapabilities.
+func (w *worker) managedNegotiateCapabilities() {
+	wns := &w.staticNegotiationState
+	defer func() {
+		wns.mu.Lock()
+		wns.running = false
+		wns.mu.Unlock()
+	}()
+
+	cache := w.staticCache()
+	version, capabilities, err := w.managedNegotiate()
+	if modules.IsUnrecognizedRPCErr(err) {
+		version, capabilities, err = 0, modules.HostCapabilitiesFromVersion(cache.staticHostVersion), nil
+	}
+
+	wns.mu.Lock()
+	defer wns.mu.Unlock()
+	if err != nil {
+		wns.consecutiveFailures++
+		wns.cooldownUntil = cooldownUntil(wns.consecutiveFailures)
+		w.renter.log.Debugf("Worker %v failed to negotiate capabilities, retrying after %v: %v", w.staticHostPubKeyStr, wns.cooldownUntil, err)
+		return
+	}
+	wns.hostVersion = cache.staticHostVersion
+	wns.capabilities = capabilities
+	wns.protocolVersion = version
+	wns.negotiated = true
+	wns.consecutiveFailures = 0
+	wns.cooldownUntil = time.Time{}
+
+	// Update the current cache. The cache update holds the mutex while
+	// storing a new cache, so the result can't be overwritten by a concurrent
+	// update.
+	newCache := *w.staticCache()
+	wns.applyToCache(&newCache)
+	atomic.StorePointer(&w.atomicCache, unsafe.Pointer(&newCache))
+}
+
+// managedNegotiate executes RPCNegotiate on the worker's host.
+func (w *worker) managedNegotiate() (_ uint64, _ modules.HostCapabilities, err error) {
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return 0, 0, errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	err = modules.RPCWriteAll(stream, modules.RPCNegotiate, modules.RPCNegotiateRequest{
+		ProtocolVersion: modules.RPCProtocolVersion,
+		Capabilities:    modules.SupportedHostCapabilities,
+	})
+	if err != nil {
+		return 0, 0, errors.AddContext(err, "unable to write request")
+	}
+	var resp modules.RPCNegotiateResponse
+	err = modules.RPCRead(stream, &resp)
+	if err != nil {
+		return 0, 0, errors.AddContext(err, "unable to read response")
+	}
+
+	// Sanity check the response. The host can't use a newer protocol or more
+	// capabilities than the renter asked for.
+	if resp.ProtocolVersion > modules.RPCProtocolVersion {
+		return 0, 0, errors.New("host negotiated an unsupported protocol version")
+	}
+	return resp.ProtocolVersion, resp.Capabilities & modules.SupportedHostCapabilities, nil
+}
@@ -0,0 +1,168 @@
+package renter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// TestWorkerNegotiateCapabilities verifies that the worker negotiates the
+// capabilities with its host and falls back to the capabilities derived from
+// the host's version if the host doesn't support the negotiation.
+func TestWorkerNegotiateCapabilities(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	t.Run("Negotiated", func(t *testing.T) {
+		testWorkerNegotiateCapabilities(t, false)
+	})
+	t.Run("Legacy", func(t *testing.T) {
+		testWorkerNegotiateCapabilities(t, true)
+	})
+}
+
+// testWorkerNegotiateCapabilities runs the subtests of
+// TestWorkerNegotiateCapabilities.
+func testWorkerNegotiateCapabilities(t *testing.T, legacy bool) {
+	deps := dependencies.NewDependencyDisableRPCNegotiate()
+	if !legacy {
+		deps.Disable()
+	}
+	wt, err := newWorkerTesterCustomDependency(t.Name(), modules.ProdDependencies, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := wt.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+
+	// Wait for the negotiation to finish.
+	var cache *workerCache
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		cache = w.staticCache()
+		if !cache.staticCapabilitiesNegotiated {
+			return errors.New("capabilities weren't negotiated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedVersion := uint64(modules.RPCProtocolVersion)
+	expectedCapabilities := modules.SupportedHostCapabilities
+	if legacy {
+		expectedVersion = 0
+		expectedCapabilities = modules.HostCapabilitiesFromVersion(cache.staticHostVersion)
+	}
+	if cache.staticProtocolVersion != expectedVersion {
+		t.Fatalf("expected protocol version %v but got %v", expectedVersion, cache.staticProtocolVersion)
+	}
+	if cache.staticHostCapabilities != expectedCapabilities {
+		t.Fatalf("expected capabilities %v but got %v", expectedCapabilities, cache.staticHostCapabilities)
+	}
+
+	// The negotiated capabilities are kept by later cache updates.
+	lastUpdate := cache.staticLastUpdate
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		w.staticTryUpdateCache()
+		cache = w.staticCache()
+		if !cache.staticLastUpdate.After(lastUpdate) {
+			return errors.New("cache wasn't updated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cache.staticCapabilitiesNegotiated || cache.staticHostCapabilities != expectedCapabilities {
+		t.Fatal("capabilities weren't kept", cache.staticHostCapabilities)
+	}
+}
+
+// TestWorkerNegotiateCapabilitiesCooldown verifies that the worker doesn't
+// negotiate the capabilities while the negotiation is on cooldown.
+func TestWorkerNegotiateCapabilitiesCooldown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := wt.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+
+	// Wait for the negotiation to finish.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !w.staticCache().staticCapabilitiesNegotiated {
+			return errors.New("capabilities weren't negotiated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Forget the negotiation and put it on cooldown.
+	wns := &w.staticNegotiationState
+	wns.mu.Lock()
+	wns.negotiated = false
+	wns.cooldownUntil = time.Now().Add(time.Hour)
+	newCache := *w.staticCache()
+	newCache.staticCapabilitiesNegotiated = false
+	atomic.StorePointer(&w.atomicCache, unsafe.Pointer(&newCache))
+	wns.mu.Unlock()
+
+	// The capabilities shouldn't be negotiated while on cooldown, even though
+	// the cache keeps being updated.
+	lastUpdate := newCache.staticLastUpdate
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		w.staticTryUpdateCache()
+		w.staticTryNegotiateCapabilities()
+		if !w.staticCache().staticLastUpdate.After(lastUpdate) {
+			return errors.New("cache wasn't updated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.staticCache().staticCapabilitiesNegotiated {
+		t.Fatal("capabilities shouldn't be negotiated on cooldown")
+	}
+
+	// Once the cooldown is over, the capabilities are negotiated again.
+	wns.mu.Lock()
+	wns.cooldownUntil = time.Time{}
+	wns.mu.Unlock()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		w.staticTryNegotiateCapabilities()
+		if !w.staticCache().staticCapabilitiesNegotiated {
+			return errors.New("capabilities weren't negotiated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,69 @@
+package renter
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// threadedSendPrefetchHint sends a prefetch hint to the worker's host. Hints
+// are best effort, so failures are only logged.
+func (w *worker) threadedSendPrefetchHint(roots []crypto.Hash) {
+	if err := w.renter.tg.Add(); err != nil {
+		return
+	}
+	defer w.renter.tg.Done()
+
+	_, err := w.managedSendPrefetchHint(roots)
+	if err != nil {
+		w.renter.log.Debugf("Worker %v failed to send prefetch hint: %v", w.staticHostPubKeyStr, err)
+	}
+}
+
+// managedSendPrefetchHint executes RPCPrefetchHint on the worker's host to let
+// it know which sectors are going to be downloaded soon. Hosts which don't
+// support prefetch hints and workers on cooldown are skipped. It returns the
+// number of sectors the host is prefetching.
+func (w *worker) managedSendPrefetchHint(roots []crypto.Hash) (_ uint64, err error) {
+	if !w.staticCache().staticHostCapabilities.Has(modules.CapabilityPrefetchHints) || w.managedOnMaintenanceCooldown() {
+		return 0, nil
+	}
+	if len(roots) > modules.MaxPrefetchHintRoots {
+		roots = roots[:modules.MaxPrefetchHintRoots]
+	}
+
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	buffer := bytes.NewBuffer(nil)
+	err = modules.RPCWrite(buffer, modules.RPCPrefetchHint)
+	if err != nil {
+		return 0, err
+	}
+	err = w.staticWriteIngressProof(buffer, modules.RPCPrefetchHint)
+	if err != nil {
+		return 0, err
+	}
+	err = modules.RPCWrite(buffer, modules.RPCPrefetchHintRequest{Roots: roots})
+	if err != nil {
+		return 0, err
+	}
+	_, err = buffer.WriteTo(stream)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to write request")
+	}
+
+	var resp modules.RPCPrefetchHintResponse
+	err = modules.RPCRead(stream, &resp)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to read response")
+	}
+	return resp.Prefetching, nil
+}
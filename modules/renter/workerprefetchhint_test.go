@@ -0,0 +1,60 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestWorkerSendPrefetchHint verifies that the worker can send prefetch hints
+// to its host.
+func TestWorkerSendPrefetchHint(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := wt.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+
+	// Store a sector on the host.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+	if err := wt.host.AddSector(root, data); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the negotiation to finish.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !w.staticCache().staticCapabilitiesNegotiated {
+			return errors.New("capabilities weren't negotiated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the sector the host stores is prefetched.
+	prefetching, err := w.managedSendPrefetchHint([]crypto.Hash{root, {1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefetching != 1 {
+		t.Fatal("expected the host to prefetch 1 sector but got", prefetching)
+	}
+}
@@ -288,6 +288,11 @@ func (w *worker) staticUpdatePriceTable() {
 		err = errors.AddContext(err, "unable to write price table specifier")
 		return
 	}
+	err = w.staticWriteIngressProof(stream, modules.RPCUpdatePriceTable)
+	if err != nil {
+		err = errors.AddContext(err, "unable to write ingress proof")
+		return
+	}
 
 	// receive the price table
 	var uptr modules.RPCUpdatePriceTableResponse
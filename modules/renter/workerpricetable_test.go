@@ -64,7 +64,12 @@ func TestUpdatePriceTableHostHeightLeeway(t *testing.T) {
 		staticHostVersion:     wc.staticHostVersion,
 		staticRenterAllowance: wc.staticRenterAllowance,
 		staticSynced:          wc.staticSynced,
-		staticLastUpdate:      wc.staticLastUpdate,
+
+		staticHostCapabilities:       wc.staticHostCapabilities,
+		staticProtocolVersion:        wc.staticProtocolVersion,
+		staticCapabilitiesNegotiated: wc.staticCapabilitiesNegotiated,
+
+		staticLastUpdate: wc.staticLastUpdate,
 	})
 	atomic.StorePointer(&w.atomicCache, ptr)
 
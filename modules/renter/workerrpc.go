@@ -17,7 +17,8 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 )
 
-// defaultNewStreamTimeout is a default timeout for creating a new stream.
+// defaultNewStreamTimeout is a default timeout for creating a new stream. It is
+// the default of the interactive dial timeout.
 var defaultNewStreamTimeout = build.Select(build.Var{
 	Standard: 5 * time.Minute,
 	Testing:  10 * time.Second,
@@ -54,7 +55,11 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 		}
 	}()
 
-	// track the withdrawal
+	// check the spending cap and track the withdrawal
+	err = w.managedCheckAccountSpendingCap(cost)
+	if err != nil {
+		return
+	}
 	var refund types.Currency
 	w.staticAccount.managedTrackWithdrawal(cost)
 	defer func() {
@@ -62,8 +67,8 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 		w.staticAccount.managedCommitWithdrawal(category, withdrawn, refund, err == nil)
 	}()
 
-	// create a new stream
-	stream, err := w.staticNewStream()
+	// create a new stream from the stream pool
+	stream, err := w.staticNewPooledStream()
 	if err != nil {
 		err = errors.AddContext(err, "Unable to create a new stream")
 		return
@@ -74,8 +79,11 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 		}
 	}()
 
-	// set the limit return var.
+	// set the limit return var and meter the program's traffic.
 	limit = stream.Limit()
+	defer func() {
+		w.renter.staticBandwidthMeter.Record(category.bandwidthCategory(), limit.Uploaded(), limit.Downloaded())
+	}()
 
 	// prepare a buffer so we can optimize our writes
 	buffer := bytes.NewBuffer(nil)
@@ -85,6 +93,10 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 	if err != nil {
 		return
 	}
+	err = w.staticWriteIngressProof(buffer, modules.RPCExecuteProgram)
+	if err != nil {
+		return
+	}
 
 	// send price table uid
 	pt := w.staticPriceTable().staticPriceTable
@@ -162,16 +174,31 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 
 // staticNewStream returns a new stream to the worker's host
 func (w *worker) staticNewStream() (siamux.Stream, error) {
-	// If disrupt is called we sleep for the specified 'defaultNewStreamTimeout'
+	return w.staticNewStreamFromMux(w.renter.staticMux)
+}
+
+// staticNewPooledStream returns a new stream to the worker's host which is
+// opened using the next siamux of the renter's stream pool. Streams which
+// expect the host to open a response stream, e.g. subscriptions, need to use
+// staticNewStream instead since only the renter's siamux has the listeners
+// registered.
+func (w *worker) staticNewPooledStream() (siamux.Stream, error) {
+	return w.staticNewStreamFromMux(w.renter.staticStreamPool.callMux())
+}
+
+// staticNewStreamFromMux returns a new stream to the worker's host which is
+// opened using the provided siamux.
+func (w *worker) staticNewStreamFromMux(mux *siamux.SiaMux) (siamux.Stream, error) {
+	// If disrupt is called we sleep for the interactive dial timeout
 	// simulating how an unreachable host would behave in production.
-	timeout := defaultNewStreamTimeout
+	timeout := w.renter.staticTimeouts.callInteractiveDial()
 	if w.renter.deps.Disrupt("InterruptNewStreamTimeout") {
 		time.Sleep(timeout)
 		return nil, errors.New("InterruptNewStreamTimeout")
 	}
 
 	// Create a stream with a reasonable dial up timeout.
-	stream, err := w.renter.staticMux.NewStreamTimeout(modules.HostSiaMuxSubscriberName, w.staticCache().staticHostMuxAddress, timeout, modules.SiaPKToMuxPK(w.staticHostPubKey))
+	stream, err := mux.NewStreamTimeout(modules.HostSiaMuxSubscriberName, w.staticCache().staticHostMuxAddress, timeout, modules.SiaPKToMuxPK(w.staticHostPubKey))
 	if err != nil {
 		return nil, err
 	}
@@ -201,12 +228,20 @@ func (w *worker) managedRenew(fcid types.FileContractID, params modules.Contract
 		}
 	}()
 
+	// don't contact the host if contract formation reached its bandwidth cap.
+	bm := w.renter.staticBandwidthMeter
+	if err := bm.Check(modules.RenterBandwidthContractFormation); err != nil {
+		return modules.RenterContract{}, nil, errors.AddContext(err, "managedRenew")
+	}
+
 	// create a new stream
 	stream, err := w.staticNewStream()
 	if err != nil {
 		return modules.RenterContract{}, nil, errors.AddContext(err, "managedRenew: unable to create a new stream")
 	}
 	defer func() {
+		limit := stream.Limit()
+		bm.Record(modules.RenterBandwidthContractFormation, limit.Uploaded(), limit.Downloaded())
 		if err := stream.Close(); err != nil {
 			w.renter.log.Println("managedRenew: failed to close stream", err)
 		}
@@ -0,0 +1,192 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errSectorRootsInvalidProof is returned if the Merkle proof of the
+	// sector roots returned by a host doesn't match the contract's revision.
+	errSectorRootsInvalidProof = errors.New("host returned sector roots with an invalid proof")
+
+	// errSectorRootsMissing is returned if a host returned fewer sector roots
+	// than requested without reaching the end of the contract.
+	errSectorRootsMissing = errors.New("host returned fewer sector roots than the contract contains")
+
+	// errSectorRootsRevisionChanged is returned if a contract was revised
+	// while its sector roots were fetched.
+	errSectorRootsRevisionChanged = errors.New("contract was revised while fetching its sector roots")
+
+	// errSectorRootsUnsupported is returned if the worker's host doesn't
+	// support RPCSectorRoots.
+	errSectorRootsUnsupported = errors.New("host doesn't support fetching sector roots")
+
+	// errSectorRootsWrongContract is returned if a host returned the revision
+	// of a different contract than the requested one.
+	errSectorRootsWrongContract = errors.New("host returned the revision of a different contract")
+)
+
+// managedSectorRoots executes RPCSectorRoots on the worker's host to fetch up
+// to numRoots sector roots of the contract starting at the root with index
+// offset. The roots are verified against the returned revision before they are
+// returned.
+func (w *worker) managedSectorRoots(fcid types.FileContractID, offset, numRoots uint64) (_ types.FileContractRevision, _ []crypto.Hash, err error) {
+	if !w.staticCache().staticHostCapabilities.Has(modules.CapabilitySectorRoots) {
+		return types.FileContractRevision{}, nil, errSectorRootsUnsupported
+	}
+	if numRoots > modules.MaxSectorRootsPerRequest {
+		numRoots = modules.MaxSectorRootsPerRequest
+	}
+
+	// Defer a function that schedules a price table update in case we received
+	// an error that indicates the host deems our price table invalid.
+	defer func() {
+		if modules.IsPriceTableInvalidErr(err) {
+			w.staticTryForcePriceTableUpdate()
+		}
+	}()
+
+	// check the spending cap and track the withdrawal
+	pt := w.staticPriceTable().staticPriceTable
+	cost := modules.SectorRootsCost(&pt, numRoots)
+	err = w.managedCheckAccountSpendingCap(cost)
+	if err != nil {
+		return types.FileContractRevision{}, nil, err
+	}
+	var refund types.Currency
+	w.staticAccount.managedTrackWithdrawal(cost)
+	defer func() {
+		w.staticAccount.managedCommitWithdrawal(categoryDownload, cost.Sub(refund), refund, err == nil)
+	}()
+
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return types.FileContractRevision{}, nil, errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	// prepare a buffer so we can optimize our writes
+	buffer := bytes.NewBuffer(nil)
+	err = modules.RPCWrite(buffer, modules.RPCSectorRoots)
+	if err != nil {
+		return types.FileContractRevision{}, nil, err
+	}
+	err = w.staticWriteIngressProof(buffer, modules.RPCSectorRoots)
+	if err != nil {
+		return types.FileContractRevision{}, nil, err
+	}
+	err = modules.RPCWrite(buffer, modules.RPCSectorRootsRequest{
+		FileContractID: fcid,
+		RootOffset:     offset,
+		NumRoots:       numRoots,
+	})
+	if err != nil {
+		return types.FileContractRevision{}, nil, err
+	}
+	err = modules.RPCWrite(buffer, pt.UID)
+	if err != nil {
+		return types.FileContractRevision{}, nil, err
+	}
+	err = w.staticAccount.ProvidePayment(buffer, cost, pt.HostBlockHeight)
+	if err != nil {
+		return types.FileContractRevision{}, nil, err
+	}
+	_, err = buffer.WriteTo(stream)
+	if err != nil {
+		return types.FileContractRevision{}, nil, errors.AddContext(err, "unable to write request")
+	}
+
+	// The revision and the proof fit into RPCMinLen.
+	var resp modules.RPCSectorRootsResponse
+	err = modules.RPCReadMaxLen(stream, &resp, modules.RPCMinLen+numRoots*crypto.HashSize)
+	if err != nil {
+		return types.FileContractRevision{}, nil, errors.AddContext(err, "unable to read response")
+	}
+	rev, roots := resp.Revision, resp.SectorRoots
+
+	// Verify the response.
+	if rev.ParentID != fcid {
+		return types.FileContractRevision{}, nil, errSectorRootsWrongContract
+	}
+	contractRoots := rev.NewFileSize / modules.SectorSize
+	end := offset + uint64(len(roots))
+	if uint64(len(roots)) > numRoots || end > contractRoots {
+		return types.FileContractRevision{}, nil, fmt.Errorf("host returned %v roots at offset %v for a contract with %v roots", len(roots), offset, contractRoots)
+	}
+	if uint64(len(roots)) < numRoots && end < contractRoots {
+		return types.FileContractRevision{}, nil, errSectorRootsMissing
+	}
+	if len(roots) > 0 && !crypto.VerifySectorRangeProof(roots, resp.MerkleProof, int(offset), int(end), rev.NewFileMerkleRoot) {
+		return types.FileContractRevision{}, nil, errSectorRootsInvalidProof
+	}
+
+	// The host refunds the roots beyond the end of the contract.
+	refund = cost.Sub(modules.SectorRootsCost(&pt, uint64(len(roots))))
+	return rev, roots, nil
+}
+
+// managedSectorRootInventory fetches all sector roots of the contract from the
+// worker's host, one page of verified roots at a time. It fails if the
+// contract is revised in the meantime.
+func (w *worker) managedSectorRootInventory(fcid types.FileContractID) (types.FileContractRevision, []crypto.Hash, error) {
+	var rev types.FileContractRevision
+	var roots []crypto.Hash
+	for {
+		pageRev, page, err := w.managedSectorRoots(fcid, uint64(len(roots)), modules.MaxSectorRootsPerRequest)
+		if err != nil {
+			return types.FileContractRevision{}, nil, err
+		}
+		if len(roots) > 0 && (pageRev.NewRevisionNumber != rev.NewRevisionNumber || pageRev.NewFileMerkleRoot != rev.NewFileMerkleRoot) {
+			return types.FileContractRevision{}, nil, errSectorRootsRevisionChanged
+		}
+		rev = pageRev
+		roots = append(roots, page...)
+		if uint64(len(roots)) >= rev.NewFileSize/modules.SectorSize {
+			return rev, roots, nil
+		}
+	}
+}
+
+// ContractSectorRoots fetches the sector roots of the contract with the given
+// id from its host. The roots are verified against the contract's latest
+// revision, which the host returns alongside them.
+func (r *Renter) ContractSectorRoots(fcid types.FileContractID) (modules.ContractSectorRoots, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ContractSectorRoots{}, err
+	}
+	defer r.tg.Done()
+
+	// Find the host of the contract.
+	var hostKey types.SiaPublicKey
+	found := false
+	for _, c := range r.hostContractor.Contracts() {
+		if c.ID == fcid {
+			hostKey, found = c.HostPublicKey, true
+			break
+		}
+	}
+	if !found {
+		return modules.ContractSectorRoots{}, errors.New("no contract with id " + fcid.String())
+	}
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err != nil {
+		return modules.ContractSectorRoots{}, errors.AddContext(err, "unable to fetch sector roots from host "+hostKey.String())
+	}
+
+	rev, roots, err := w.managedSectorRootInventory(fcid)
+	if err != nil {
+		return modules.ContractSectorRoots{}, errors.AddContext(err, "unable to fetch sector roots")
+	}
+	return modules.ContractSectorRoots{
+		Revision:    rev,
+		SectorRoots: roots,
+	}, nil
+}
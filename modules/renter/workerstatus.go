@@ -37,6 +37,10 @@ func (w *worker) callStatus() modules.WorkerStatus {
 	}
 
 	// Update the worker cache before returning a status.
+	// Get the account status including the spending cap
+	accountStatus := w.staticAccount.managedStatus()
+	accountStatus.SpendingCap, _ = w.renter.staticAccountSpendingCaps.callCap(w.staticHostPubKey)
+
 	w.staticTryUpdateCache()
 	cache := w.staticCache()
 	return modules.WorkerStatus{
@@ -45,6 +49,10 @@ func (w *worker) callStatus() modules.WorkerStatus {
 		ContractUtility: cache.staticContractUtility,
 		HostPubKey:      w.staticHostPubKey,
 
+		// Negotiated protocol information
+		HostCapabilities: cache.staticHostCapabilities.Names(),
+		ProtocolVersion:  cache.staticProtocolVersion,
+
 		// Download information
 		DownloadCoolDownError: downloadCoolDownErr,
 		DownloadCoolDownTime:  downloadCoolDownTime,
@@ -70,7 +78,7 @@ func (w *worker) callStatus() modules.WorkerStatus {
 
 		// Account Information
 		AccountBalanceTarget: w.staticBalanceTarget,
-		AccountStatus:        w.staticAccount.managedStatus(),
+		AccountStatus:        accountStatus,
 
 		// Price Table Information
 		PriceTableStatus: w.staticPriceTableStatus(),
@@ -0,0 +1,125 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// streamPoolDir is the name of the directory within the renter's persist
+	// dir which contains the persistence of the pool's additional siamuxs.
+	streamPoolDir = "streampool"
+
+	// maxStreamPoolSize is the maximum number of connections a renter is
+	// allowed to open to a single host.
+	maxStreamPoolSize = 16
+)
+
+var (
+	// defaultStreamPoolSize is the default number of connections the renter
+	// opens to a single host. It can be overwritten using the
+	// SIA_RENTER_STREAM_POOL_SIZE environment variable.
+	defaultStreamPoolSize = build.Select(build.Var{
+		Dev:      2,
+		Standard: 2,
+		Testing:  2,
+	}).(int)
+)
+
+type (
+	// streamPool is a pool of siamuxs which is used by the workers to open
+	// streams to their hosts. A siamux only ever maintains a single connection
+	// to a host which means that all streams to a host share that connection.
+	// For large parallel downloads from a single host, that causes the streams
+	// to be serialized behind the connection's head-of-line blocking. Each
+	// siamux in the pool maintains its own connection to the host and the
+	// workers spread their streams across the pool.
+	//
+	// The first siamux of the pool is the renter's siamux. The others are only
+	// used for outgoing connections.
+	streamPool struct {
+		atomicNextMux uint64
+		staticMuxs    []*siamux.SiaMux
+	}
+)
+
+// parseStreamPoolSize parses the size of the renter's stream pool from the
+// value of the SIA_RENTER_STREAM_POOL_SIZE environment variable. If the
+// variable isn't set, the default is returned.
+func parseStreamPoolSize(sizeStr string) (int, error) {
+	if sizeStr == "" {
+		return defaultStreamPoolSize, nil
+	}
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to parse stream pool size")
+	}
+	if size < 1 || size > maxStreamPoolSize {
+		return 0, errors.New("stream pool size must be between 1 and " + strconv.Itoa(maxStreamPoolSize))
+	}
+	return size, nil
+}
+
+// newStreamPool creates a new stream pool of the given size which uses the
+// provided siamux as its first siamux.
+func newStreamPool(mux *siamux.SiaMux, size int, persistDir string, log *persist.Logger) (_ *streamPool, err error) {
+	sp := &streamPool{
+		staticMuxs: []*siamux.SiaMux{mux},
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, sp.Close())
+		}
+	}()
+	// Without a siamux there is nothing to pool.
+	if mux == nil {
+		return sp, nil
+	}
+	for i := 1; i < size; i++ {
+		dir := filepath.Join(persistDir, streamPoolDir, strconv.Itoa(i))
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to create stream pool dir")
+		}
+		// The additional siamuxs are only used for outgoing connections so
+		// they listen on a random local port.
+		var sm *siamux.SiaMux
+		sm, err = siamux.New("localhost:0", "localhost:0", log.Logger, dir)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to create siamux for stream pool")
+		}
+		sp.staticMuxs = append(sp.staticMuxs, sm)
+	}
+	return sp, nil
+}
+
+// callMux returns the siamux that should be used for the next stream. The
+// siamuxs are used in a round-robin fashion.
+func (sp *streamPool) callMux() *siamux.SiaMux {
+	next := atomic.AddUint64(&sp.atomicNextMux, 1)
+	return sp.staticMuxs[next%uint64(len(sp.staticMuxs))]
+}
+
+// Close closes the additional siamuxs of the pool. The renter's siamux is not
+// closed since it is owned by the node.
+func (sp *streamPool) Close() error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(sp.staticMuxs))
+	for i := 1; i < len(sp.staticMuxs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = sp.staticMuxs[i].Close()
+		}(i)
+	}
+	wg.Wait()
+	return errors.Compose(errs...)
+}
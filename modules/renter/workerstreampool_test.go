@@ -0,0 +1,96 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestParseStreamPoolSize is a unit test for parseStreamPoolSize.
+func TestParseStreamPoolSize(t *testing.T) {
+	tests := []struct {
+		sizeStr string
+		size    int
+		valid   bool
+	}{
+		{"", defaultStreamPoolSize, true},
+		{"1", 1, true},
+		{"4", 4, true},
+		{"16", 16, true},
+		{"0", 0, false},
+		{"-1", 0, false},
+		{"17", 0, false},
+		{"foo", 0, false},
+	}
+	for _, test := range tests {
+		size, err := parseStreamPoolSize(test.sizeStr)
+		if test.valid && err != nil {
+			t.Fatalf("%v: unexpected error %v", test.sizeStr, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("%v: expected error", test.sizeStr)
+		}
+		if size != test.size {
+			t.Fatalf("%v: expected %v but got %v", test.sizeStr, test.size, size)
+		}
+	}
+}
+
+// TestStreamPool verifies that the worker spreads its streams across the
+// renter's stream pool and that every siamux of the pool can be used to talk
+// to the host.
+func TestStreamPool(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := wt.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The pool should contain the renter's siamux and additional ones.
+	sp := wt.renter.staticStreamPool
+	if len(sp.staticMuxs) != defaultStreamPoolSize {
+		t.Fatalf("expected %v siamuxs but got %v", defaultStreamPoolSize, len(sp.staticMuxs))
+	}
+	if sp.staticMuxs[0] != wt.renter.staticMux {
+		t.Fatal("first siamux should be the renter's siamux")
+	}
+
+	// The siamuxs should be used in a round-robin fashion.
+	used := make(map[*siamux.SiaMux]struct{})
+	for i := 0; i < len(sp.staticMuxs); i++ {
+		used[sp.callMux()] = struct{}{}
+	}
+	if len(used) != len(sp.staticMuxs) {
+		t.Fatalf("expected %v different siamuxs to be used but got %v", len(sp.staticMuxs), len(used))
+	}
+
+	// Execute a program multiple times to make sure every siamux of the pool
+	// is used to connect to the host.
+	pt := wt.staticPriceTable().staticPriceTable
+	pb := modules.NewProgramBuilder(&pt, 0)
+	pb.AddHasSectorInstruction(crypto.Hash{})
+	p, data := pb.Program()
+	cost, _, _ := pb.Cost(true)
+	jhs := new(jobHasSector)
+	jhs.staticSectors = []crypto.Hash{{}}
+	ulBandwidth, dlBandwidth := jhs.callExpectedBandwidth()
+	cost = cost.Add(modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth))
+	for i := 0; i < 2*len(sp.staticMuxs); i++ {
+		_, _, err := wt.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -60,10 +60,6 @@ var (
 	priceTableRetryInterval = time.Second
 )
 
-// minSubscriptionVersion is the min version required for a host to support the
-// subscription protocol.
-const minSubscriptionVersion = "1.5.5"
-
 type (
 	// subscriptionInfos contains all of the registry subscription related
 	// information of a worker.
@@ -425,7 +421,10 @@ func (w *worker) managedExtendSubscriptionPeriod(stream siamux.Stream, budget *m
 func (w *worker) managedRefillSubscription(stream siamux.Stream, pt *modules.RPCPriceTable, expectedBudget types.Currency, budget *modules.RPCBudget) error {
 	fundAmt := expectedBudget.Sub(budget.Remaining())
 
-	// Track the withdrawal.
+	// Check the spending cap and track the withdrawal.
+	if err := w.managedCheckAccountSpendingCap(fundAmt); err != nil {
+		return errors.AddContext(err, "failed to refill subscription")
+	}
 	w.staticAccount.managedTrackWithdrawal(fundAmt)
 
 	// Fund the subscription.
@@ -697,7 +696,7 @@ func (w *worker) threadedSubscriptionLoop() {
 	defer w.staticTG.Done()
 
 	// No need to run loop if the host doesn't support it.
-	if build.VersionCmp(w.staticCache().staticHostVersion, minSubscriptionVersion) < 0 {
+	if !w.staticCache().staticHostCapabilities.Has(modules.CapabilityRegistrySubscription) {
 		return
 	}
 
@@ -755,7 +754,12 @@ func (w *worker) threadedSubscriptionLoop() {
 		initialBudget := initialSubscriptionBudget
 		budget := modules.NewBudget(initialBudget)
 
-		// Track the withdrawal.
+		// Check the spending cap and track the withdrawal.
+		if err := w.managedCheckAccountSpendingCap(initialBudget); err != nil {
+			w.renter.log.Printf("Worker %v: failed to begin subscription: %v", w.staticHostPubKeyStr, err)
+			subInfo.managedIncrementCooldown()
+			continue
+		}
 		w.staticAccount.managedTrackWithdrawal(initialBudget)
 
 		// Prepare a unique handler for the host to subscribe to.
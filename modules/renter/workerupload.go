@@ -1,15 +1,19 @@
 package renter
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
 )
 
 const (
@@ -119,7 +123,7 @@ func (w *worker) callQueueUploadChunk(uc *unfinishedUploadChunk) bool {
 	// worker lock.
 	cache := w.staticCache()
 	uc.mu.Lock()
-	_, candidateHost := uc.unusedHosts[w.staticHostPubKeyStr]
+	candidateHost := uc.candidateHost(w.staticHostPubKeyStr)
 	uc.mu.Unlock()
 	goodForUpload := cache.staticContractUtility.GoodForUpload
 	w.mu.Lock()
@@ -178,6 +182,11 @@ func (w *worker) managedPerformUploadChunkJob() {
 	if uc == nil {
 		return
 	}
+	// Don't upload the piece if uploads reached their bandwidth cap.
+	if err := w.renter.staticBandwidthMeter.Check(modules.RenterBandwidthUpload); err != nil {
+		w.managedUploadFailed(uc, pieceIndex, err)
+		return
+	}
 	// Open an editing connection to the host.
 	e, err := w.renter.hostContractor.Editor(w.staticHostPubKey, w.renter.tg.StopChan())
 	if err != nil {
@@ -213,17 +222,28 @@ func (w *worker) managedPerformUploadChunkJob() {
 		w.managedUploadFailed(uc, pieceIndex, failureErr)
 		return
 	}
+	w.renter.staticBandwidthMeter.Record(modules.RenterBandwidthUpload, uint64(len(uc.physicalChunkData[pieceIndex])), 0)
 	w.mu.Lock()
 	w.uploadConsecutiveFailures = 0
 	w.mu.Unlock()
 
-	// Add piece to renterFile
-	err = uc.fileEntry.AddPiece(w.staticHostPubKey, uc.staticIndex, pieceIndex, root)
+	// Add piece to renterFile. Pieces uploaded by a repair are added as
+	// unverified and only count towards the redundancy once the host proved
+	// that it stored them.
+	addPiece := uc.fileEntry.AddPiece
+	if uc.staticVerifyPieces {
+		addPiece = uc.fileEntry.AddUnverifiedPiece
+	}
+	err = addPiece(w.staticHostPubKey, uc.staticIndex, pieceIndex, root)
 	if err != nil {
 		failureErr := fmt.Errorf("Worker failed to add new piece to SiaFile: %v", err)
 		w.managedUploadFailed(uc, pieceIndex, failureErr)
 		return
 	}
+	if uc.staticVerifyPieces {
+		w.renter.uploadHeap.managedAddVerification(uc.id)
+		go w.threadedVerifyPiece(uc.fileEntry.Copy(), uc.id, pieceIndex, root)
+	}
 
 	id := w.renter.mu.Lock()
 	w.renter.mu.Unlock(id)
@@ -242,6 +262,47 @@ func (w *worker) managedPerformUploadChunkJob() {
 	w.renter.managedCleanUpUploadChunk(uc)
 }
 
+// threadedVerifyPiece reads a random segment of a piece which was uploaded by a
+// repair and marks the piece as verified if the host proves that it stores the
+// segment. If the verification fails, the piece keeps not counting towards the
+// redundancy of its chunk and will be repaired again.
+func (w *worker) threadedVerifyPiece(entry *filesystem.FileNode, id uploadChunkID, pieceIndex uint64, root crypto.Hash) {
+	defer w.renter.uploadHeap.managedVerificationDone(id)
+	defer func() {
+		if err := entry.Close(); err != nil {
+			w.renter.log.Println("WARN: failed to close file after verifying piece:", err)
+		}
+	}()
+	if err := w.renter.tg.Add(); err != nil {
+		return
+	}
+	defer w.renter.tg.Done()
+
+	// Read a random segment of the sector. The read job verifies the range
+	// proof of the host.
+	ctx, cancel := context.WithTimeout(w.renter.tg.StopCtx(), w.renter.staticTimeouts.callJob(operationClassRepair))
+	defer cancel()
+	offset := fastrand.Uint64n(modules.SectorSize/crypto.SegmentSize) * crypto.SegmentSize
+	_, err := w.ReadSectorLowPrio(ctx, categoryRepairDownload, root, offset, crypto.SegmentSize)
+	if err != nil {
+		w.renter.repairLog.Printf("Failed to verify piece %v of chunk %v of %v on host %v: %v", pieceIndex, id.index, entry.SiaFilePath(), w.staticHostPubKey, err)
+		return
+	}
+	err = entry.MarkPieceVerified(w.staticHostPubKey, id.index, pieceIndex, root)
+	if err != nil {
+		w.renter.log.Println("WARN: failed to mark piece as verified:", err)
+		return
+	}
+
+	// The health of the file changed.
+	dirSiaPath, err := w.renter.staticFileSystem.FileSiaPath(entry).Dir()
+	if err != nil {
+		w.renter.log.Println("WARN: unable to get directory SiaPath to bubble verified piece:", err)
+		return
+	}
+	_ = w.renter.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+}
+
 // onUploadCooldown returns true if the worker is on cooldown from failed
 // uploads and the amount of cooldown time remaining for the worker.
 func (w *worker) onUploadCooldown() (bool, time.Duration) {
@@ -263,7 +324,7 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 
 	// Determine what sort of help this chunk needs.
 	uc.mu.Lock()
-	_, candidateHost := uc.unusedHosts[w.staticHostPubKey.String()]
+	candidateHost := uc.candidateHost(w.staticHostPubKeyStr)
 	chunkComplete := uc.staticPiecesNeeded <= uc.piecesCompleted
 	// If the chunk does not need help from this worker, release the chunk.
 	if chunkComplete || !candidateHost || !goodForUpload || onCooldown {
@@ -307,6 +368,7 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 		return nil, 0
 	}
 	delete(uc.unusedHosts, w.staticHostPubKey.String())
+	uc.updateUsedNetworks(w.staticHostPubKeyStr, 1)
 	uc.piecesRegistered++
 	uc.workersRemaining--
 	uc.mu.Unlock()
@@ -331,6 +393,7 @@ func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint6
 	uc.mu.Lock()
 	uc.piecesRegistered--
 	uc.pieceUsage[pieceIndex] = false
+	uc.updateUsedNetworks(w.staticHostPubKeyStr, -1)
 	uc.chunkFailedProcessTimes = append(uc.chunkFailedProcessTimes, time.Now())
 	uc.mu.Unlock()
 
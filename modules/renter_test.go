@@ -163,6 +163,45 @@ func TestMaintenanceSpending_Sum(t *testing.T) {
 	}
 }
 
+// TestAdaptiveRepairThreshold is a unit test for AdaptiveRepairThreshold and
+// RepairUrgency.
+func TestAdaptiveRepairThreshold(t *testing.T) {
+	t.Parallel()
+
+	// A fresh host has the neutral threshold.
+	fresh := HostFailureRate(HostDBEntry{})
+	if AdaptiveRepairThreshold(fresh) != RepairThreshold {
+		t.Fatal("unexpected threshold for fresh host", AdaptiveRepairThreshold(fresh))
+	}
+	// Reliable and unreliable hosts are clamped.
+	if AdaptiveRepairThreshold(0) != MaxRepairThreshold {
+		t.Fatal("unexpected threshold for reliable host", AdaptiveRepairThreshold(0))
+	}
+	if AdaptiveRepairThreshold(1) != MinRepairThreshold {
+		t.Fatal("unexpected threshold for unreliable host", AdaptiveRepairThreshold(1))
+	}
+	// The threshold decreases with the failure rate.
+	for rate := 0.0; rate < 1; rate += 0.01 {
+		if AdaptiveRepairThreshold(rate+0.01) > AdaptiveRepairThreshold(rate) {
+			t.Fatal("threshold increases at failure rate", rate)
+		}
+	}
+	// A host with many failed interactions lowers the threshold.
+	flaky := HostDBEntry{HistoricSuccessfulInteractions: 50, HistoricFailedInteractions: 20}
+	if threshold := AdaptiveRepairThreshold(HostFailureRate(flaky)); threshold >= RepairThreshold {
+		t.Fatal("flaky host should lower the threshold", threshold)
+	}
+
+	// The urgency reaches 1 at the threshold and falls back to the
+	// RepairThreshold.
+	if RepairUrgency(MinRepairThreshold, MinRepairThreshold) != 1 || !NeedsAdaptiveRepair(MinRepairThreshold, MinRepairThreshold) {
+		t.Fatal("file at its threshold should need repair")
+	}
+	if RepairUrgency(RepairThreshold, 0) != 1 || NeedsAdaptiveRepair(MinRepairThreshold, 0) {
+		t.Fatal("unset threshold should fall back to RepairThreshold")
+	}
+}
+
 // BenchmarkMerkleRootSetEncode clocks how fast large MerkleRootSets can be
 // encoded and written to disk.
 func BenchmarkMerkleRootSetEncode(b *testing.B) {
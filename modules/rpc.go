@@ -23,6 +23,14 @@ const (
 	// RenewDecodeMaxLen is the maximum length for decoding received objects
 	// read during a contract renewal.
 	RenewDecodeMaxLen = 1 << 18 // 256 kib
+
+	// MaxPrefetchHintRoots is the maximum number of sector roots a renter
+	// can send in a single RPCPrefetchHint request.
+	MaxPrefetchHintRoots = 64
+
+	// MaxSectorRootsPerRequest is the maximum number of sector roots a renter
+	// can request in a single RPCSectorRoots request.
+	MaxSectorRootsPerRequest = 1 << 14
 )
 
 // Subcription request related enum.
@@ -194,6 +202,15 @@ var (
 
 	// RPCRenewContract specifier
 	RPCRenewContract = types.NewSpecifier("RenewContract")
+
+	// RPCNegotiate specifier
+	RPCNegotiate = types.NewSpecifier("Negotiate")
+
+	// RPCPrefetchHint specifier
+	RPCPrefetchHint = types.NewSpecifier("PrefetchHint")
+
+	// RPCSectorRoots specifier
+	RPCSectorRoots = types.NewSpecifier("SectorRoots")
 )
 
 type (
@@ -275,6 +292,52 @@ type (
 		Revision types.FileContractRevision
 	}
 
+	// RPCNegotiateRequest is sent by the renter to negotiate the protocol
+	// version and the capabilities to use with a host. It contains the
+	// highest version and the capabilities the renter supports.
+	RPCNegotiateRequest struct {
+		ProtocolVersion uint64
+		Capabilities    HostCapabilities
+	}
+
+	// RPCNegotiateResponse contains the negotiated protocol version and the
+	// capabilities supported by both the renter and the host.
+	RPCNegotiateResponse struct {
+		ProtocolVersion uint64
+		Capabilities    HostCapabilities
+	}
+
+	// RPCPrefetchHintRequest contains the roots of the sectors a renter is
+	// going to download soon in the order it is going to download them. The
+	// host uses them to warm its sector cache.
+	RPCPrefetchHintRequest struct {
+		Roots []crypto.Hash
+	}
+
+	// RPCPrefetchHintResponse contains the number of sectors of the request
+	// which the host is going to prefetch.
+	RPCPrefetchHintResponse struct {
+		Prefetching uint64
+	}
+
+	// RPCSectorRootsRequest requests up to NumRoots sector roots of a
+	// contract starting at the root with index RootOffset.
+	RPCSectorRootsRequest struct {
+		FileContractID types.FileContractID
+		RootOffset     uint64
+		NumRoots       uint64
+	}
+
+	// RPCSectorRootsResponse contains the requested sector roots, a Merkle
+	// range proof which proves that they are part of the contract's Merkle
+	// root and the revision containing that root. If the request exceeds the
+	// end of the contract, the response only contains the remaining roots.
+	RPCSectorRootsResponse struct {
+		Revision    types.FileContractRevision
+		SectorRoots []crypto.Hash
+		MerkleProof []crypto.Hash
+	}
+
 	// RPCRegistrySubscriptionRequest is a request to either add or remove a
 	// subscription.
 	RPCRegistrySubscriptionRequest struct {
@@ -508,3 +571,10 @@ func (uid *UniqueID) UnmarshalJSON(b []byte) error {
 func IsPriceTableInvalidErr(err error) bool {
 	return err != nil && (strings.Contains(err.Error(), ErrPriceTableExpired.Error()) || strings.Contains(err.Error(), ErrPriceTableNotFound.Error()))
 }
+
+// SectorRootsCost returns the cost of fetching numRoots sector roots using
+// RPCSectorRoots. It consists of the cost of fetching the latest revision and
+// the bandwidth of the roots.
+func SectorRootsCost(pt *RPCPriceTable, numRoots uint64) types.Currency {
+	return pt.LatestRevisionCost.Add(pt.DownloadBandwidthCost.Mul64(numRoots * crypto.HashSize))
+}
@@ -23,6 +23,18 @@ const (
 
 // NewSiaMux returns a new SiaMux object
 func NewSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress string) (*siamux.SiaMux, error) {
+	return newSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress, nil)
+}
+
+// NewSiaMuxWithKeyPair returns a new SiaMux object which uses the provided key
+// pair instead of the key pair it persisted.
+func NewSiaMuxWithKeyPair(siaMuxDir, siaDir, tcpaddress, wsaddress string, sk crypto.SecretKey) (*siamux.SiaMux, error) {
+	return newSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress, &sk)
+}
+
+// newSiaMux returns a new SiaMux object which uses the provided key pair if it
+// is not nil.
+func newSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress string, sk *crypto.SecretKey) (*siamux.SiaMux, error) {
 	// can't use relative path
 	if !filepath.IsAbs(siaMuxDir) || !filepath.IsAbs(siaDir) {
 		err := errors.New("paths need to be absolute")
@@ -51,6 +63,16 @@ func NewSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress string) (*siamux.SiaMux,
 		return nil, err
 	}
 
+	// create a siamux with the provided key pair
+	if sk != nil {
+		var pubKey mux.ED25519PublicKey
+		var privKey mux.ED25519SecretKey
+		pk := sk.PublicKey()
+		copy(pubKey[:], pk[:])
+		copy(privKey[:], sk[:])
+		return siamux.CompatV1421NewWithKeyPair(tcpaddress, wsaddress, logger.Logger, siaMuxDir, privKey, pubKey)
+	}
+
 	// create a siamux, if the host's persistence file is at v120 we want to
 	// recycle the host's key pair to use in the siamux
 	pubKey, privKey, compat := compatLoadKeysFromHost(siaDir)
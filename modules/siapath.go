@@ -30,6 +30,10 @@ var (
 	// SiaFileExtension is the extension for siafiles on disk
 	SiaFileExtension = ".sia"
 
+	// SiaAliasExtension is the extension for alias files on disk which point
+	// at another SiaPath.
+	SiaAliasExtension = ".sialias"
+
 	// PartialsSiaFileExtension is the extension for siafiles which contain
 	// combined chunks.
 	PartialsSiaFileExtension = ".csia"
@@ -238,6 +242,12 @@ func (sp SiaPath) SiaFileSysPath(dir string) string {
 	return filepath.Join(dir, filepath.FromSlash(sp.Path)+SiaFileExtension)
 }
 
+// SiaAliasSysPath returns the system path needed to read an alias from disk,
+// the input dir is the root siafile directory on disk
+func (sp SiaPath) SiaAliasSysPath(dir string) string {
+	return filepath.Join(dir, filepath.FromSlash(sp.Path)+SiaAliasExtension)
+}
+
 // SiaPartialsFileSysPath returns the system path needed to read the
 // PartialsSiaFile from disk, the input dir is the root siafile directory on
 // disk
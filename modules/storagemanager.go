@@ -42,6 +42,25 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// SectorCompaction is the result of compacting the sector references of
+	// a storage manager.
+	SectorCompaction struct {
+		// ReferencesRemoved is the number of stale references which were
+		// removed from the stored sectors.
+		ReferencesRemoved uint64 `json:"referencesremoved"`
+
+		// SectorsReclaimed is the number of physical sectors which weren't
+		// referenced anymore and BytesReclaimed the disk space which was
+		// freed by removing them.
+		SectorsReclaimed uint64 `json:"sectorsreclaimed"`
+		BytesReclaimed   uint64 `json:"bytesreclaimed"`
+
+		// MissingReferences is the number of references to sectors which
+		// aren't stored as often as they are referenced. Missing references
+		// can't be fixed by compacting.
+		MissingReferences uint64 `json:"missingreferences"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -78,6 +97,13 @@ type (
 		// The storage manager needs to be able to shut down.
 		Close() error
 
+		// CompactSectors reduces the number of references of every stored
+		// sector to the number of references provided for its root. Sectors
+		// without any references are removed and their space is reclaimed.
+		// Sectors which are referenced more often than they are stored are
+		// reported but left untouched.
+		CompactSectors(references map[crypto.Hash]uint64) (SectorCompaction, error)
+
 		// DeleteSector deletes a sector, meaning that the manager will be
 		// unable to upload that sector and be unable to provide a storage
 		// proof on that sector. DeleteSector is for removing the data
@@ -112,6 +138,12 @@ type (
 		// operation will be completed, meaning that data will be lost.
 		RemoveStorageFolder(index uint16, force bool) error
 
+		// RecoverStorageFolder adds an existing storage folder to the
+		// manager, e.g. after the manager's persist files were lost. The
+		// sectors of the folder are recovered from its files and the number
+		// of recovered sectors is returned.
+		RecoverStorageFolder(path string) (uint64, error)
+
 		// ResetStorageFolderHealth will reset the health statistics on a
 		// storage folder.
 		ResetStorageFolderHealth(index uint16) error
@@ -75,6 +75,18 @@ type (
 		RevertedTransactions []TransactionSetID
 	}
 
+	// TransactionPoolSettings contains the runtime-tunable admission and relay
+	// policy of the transaction pool. The fee floors are in currency per byte
+	// and are enforced in addition to the fees which are required because of
+	// the size of the pool. Transaction sets which spend an output of one of
+	// the exempt addresses, e.g. the addresses of the node's own wallet, are
+	// neither subject to the accept floor nor the relay floor.
+	TransactionPoolSettings struct {
+		MinAcceptFee    types.Currency     `json:"minacceptfee"`
+		MinRelayFee     types.Currency     `json:"minrelayfee"`
+		ExemptAddresses []types.UnlockHash `json:"exemptaddresses"`
+	}
+
 	// UnconfirmedTransactionSet defines a new unconfirmed transaction that has
 	// been added to the transaction pool. ID is the ID of the set, IDs contains
 	// an ID for each transaction, eliminating the need to recompute it (because
@@ -108,6 +120,11 @@ type (
 		// transactions.
 		AcceptTransactionSet([]types.Transaction) error
 
+		// AcceptTransactionPackage accepts a topologically sorted package of
+		// dependent transactions. The package is either accepted as a whole or
+		// not at all and is only relayed to peers if it was accepted.
+		AcceptTransactionPackage([]types.Transaction) error
+
 		// Broadcast broadcasts a transaction set to all of the transaction pool's
 		// peers.
 		Broadcast(ts []types.Transaction)
@@ -130,6 +147,14 @@ type (
 		// that make this condition necessary.
 		PurgeTransactionPool()
 
+		// SetSettings changes the admission and relay policy of the
+		// transaction pool.
+		SetSettings(TransactionPoolSettings) error
+
+		// Settings returns the admission and relay policy of the transaction
+		// pool.
+		Settings() TransactionPoolSettings
+
 		// Transaction returns the transaction and unconfirmed parents
 		// corresponding to the provided transaction id.
 		Transaction(id types.TransactionID) (txn types.Transaction, unconfirmedParents []types.Transaction, exists bool)
@@ -183,7 +183,7 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 
 	// Check that the transaction set has enough fees to justify adding it to
 	// the transaction list.
-	requiredFees := tp.requiredFeesToExtendTpool().Mul64(setSize)
+	requiredFees := tp.requiredFeeRate(superset).Mul64(setSize)
 	var setFees types.Currency
 	for _, txn := range superset {
 		for _, fee := range txn.MinerFees {
@@ -271,7 +271,7 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 
 	// Check that the transaction set has enough fees to justify adding it to
 	// the transaction list.
-	requiredFees := tp.requiredFeesToExtendTpool().Mul64(setSize)
+	requiredFees := tp.requiredFeeRate(ts).Mul64(setSize)
 	var setFees types.Currency
 	for _, txn := range ts {
 		for _, fee := range txn.MinerFees {
@@ -377,21 +377,16 @@ func (tp *TransactionPool) submitTransactionSet(ts []types.Transaction) ([]types
 	return minSuperSet, nil
 }
 
-// AcceptTransactionSet adds a transaction to the unconfirmed set of
-// transactions. If the transaction is accepted, it will be relayed to
-// connected peers.
-func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
-	if err := tp.tg.Add(); err != nil {
-		return err
-	}
-	defer tp.tg.Done()
-
+// managedAcceptTransactionSet submits a transaction set to the transaction
+// pool and relays it to connected peers if it was accepted and pays the
+// minimum relay fee. It is the shared accept path of transaction sets and
+// transaction packages.
+func (tp *TransactionPool) managedAcceptTransactionSet(ts []types.Transaction) error {
 	// Drop the transaction set and return ErrTxnSetNotAccepted
 	if tp.deps.Disrupt("DoNotAcceptTxnSet") {
 		return ErrTxnSetNotAccepted
 	}
 
-	tp.log.Debugln("Received a transaction (internal or external), attempting to broadcast")
 	minSuperSet, err := tp.submitTransactionSet(ts)
 	if errors.Contains(err, modules.ErrDuplicateTransactionSet) {
 		return err
@@ -400,11 +395,28 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 		tp.log.Debugln("Transaction set will not be broadcast due to an error:", err)
 		return err
 	}
+	if !tp.managedRelayAllowed(minSuperSet) {
+		tp.log.Debugln("Transaction set was accepted but pays too little fees to be relayed")
+		return nil
+	}
 	go tp.gateway.Broadcast("RelayTransactionSet", minSuperSet, tp.gateway.Peers())
 	tp.log.Debugln("Transaction set broadcast appears to have succeeded")
 	return nil
 }
 
+// AcceptTransactionSet adds a transaction to the unconfirmed set of
+// transactions. If the transaction is accepted and pays the minimum relay fee,
+// it will be relayed to connected peers.
+func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
+	if err := tp.tg.Add(); err != nil {
+		return err
+	}
+	defer tp.tg.Done()
+
+	tp.log.Debugln("Received a transaction (internal or external), attempting to broadcast")
+	return tp.managedAcceptTransactionSet(ts)
+}
+
 // relayTransactionSet is an RPC that accepts a transaction set from a peer. If
 // the accept is successful, the transaction will be relayed to the gateway's
 // other peers.
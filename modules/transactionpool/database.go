@@ -33,6 +33,10 @@ var (
 	// bucketRecentConsensusChange holds the most recent consensus change seen
 	// by the transaction pool.
 	bucketRecentConsensusChange = []byte("RecentConsensusChange")
+
+	// bucketSettings stores the admission and relay policy of the
+	// transaction pool.
+	bucketSettings = []byte("Settings")
 )
 
 // Explicitly named fields in the database.
@@ -52,6 +56,10 @@ var (
 	// fieldRecentConsensusChange is the field in bucketRecentConsensusChange
 	// that holds the value of the most recent consensus change.
 	fieldRecentConsensusChange = []byte("RecentConsensusChange")
+
+	// fieldSettings is the field in bucketSettings that holds the settings of
+	// the transaction pool.
+	fieldSettings = []byte("Settings")
 )
 
 // Errors relating to the database.
@@ -67,6 +75,10 @@ var (
 	// errNilRecentBlock is returned if there is no data stored in
 	// fieldRecentBlockID.
 	errNilRecentBlock = errors.New("no recent block found in the database")
+
+	// errNilSettings is returned if there are no settings stored in the
+	// database.
+	errNilSettings = errors.New("no settings found")
 )
 
 // Complex objects that get stored in database fields.
@@ -131,6 +143,22 @@ func (tp *TransactionPool) getRecentConsensusChange(tx *bolt.Tx) (cc modules.Con
 	return cc, nil
 }
 
+// getSettings returns the settings of the transaction pool stored in the
+// database.
+func (tp *TransactionPool) getSettings(tx *bolt.Tx) (modules.TransactionPoolSettings, error) {
+	settingsBytes := tx.Bucket(bucketSettings).Get(fieldSettings)
+	if settingsBytes == nil {
+		return modules.TransactionPoolSettings{}, errNilSettings
+	}
+
+	var settings modules.TransactionPoolSettings
+	err := json.Unmarshal(settingsBytes, &settings)
+	if err != nil {
+		return modules.TransactionPoolSettings{}, build.ExtendErr("unable to unmarshal settings:", err)
+	}
+	return settings, nil
+}
+
 // putBlockHeight updates the transaction pool's block height.
 func (tp *TransactionPool) putBlockHeight(tx *bolt.Tx, height types.BlockHeight) error {
 	tp.blockHeight = height
@@ -158,6 +186,15 @@ func (tp *TransactionPool) putRecentConsensusChange(tx *bolt.Tx, cc modules.Cons
 	return tx.Bucket(bucketRecentConsensusChange).Put(fieldRecentConsensusChange, cc[:])
 }
 
+// putSettings stores the settings of the transaction pool in the database.
+func (tp *TransactionPool) putSettings(tx *bolt.Tx, settings modules.TransactionPoolSettings) error {
+	objBytes, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketSettings).Put(fieldSettings, objBytes)
+}
+
 // putTransaction adds a transaction to the list of confirmed transactions.
 func (tp *TransactionPool) putTransaction(tx *bolt.Tx, id types.TransactionID) error {
 	return tx.Bucket(bucketConfirmedTransactions).Put(id[:], []byte{})
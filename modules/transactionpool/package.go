@@ -0,0 +1,95 @@
+package transactionpool
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errPackageDuplicateTransaction is returned if a transaction package
+	// contains the same transaction more than once.
+	errPackageDuplicateTransaction = errors.New("transaction package contains duplicate transactions")
+
+	// errPackageNotSorted is returned if a transaction of a package spends an
+	// object that is only created by a later transaction of the same package.
+	errPackageNotSorted = errors.New("transaction package is not sorted topologically, parents need to come before their children")
+)
+
+// checkPackageTopology checks that a transaction package doesn't contain
+// duplicates and that every transaction only depends on transactions that
+// precede it within the package.
+func checkPackageTopology(pkg []types.Transaction) error {
+	// Map every object created by the package to the index of the creating
+	// transaction.
+	created := make(map[ObjectID]int)
+	txnIDs := make(map[types.TransactionID]struct{})
+	for i, t := range pkg {
+		if _, exists := txnIDs[t.ID()]; exists {
+			return errPackageDuplicateTransaction
+		}
+		txnIDs[t.ID()] = struct{}{}
+		for j := range t.SiacoinOutputs {
+			created[ObjectID(t.SiacoinOutputID(uint64(j)))] = i
+		}
+		for j := range t.FileContracts {
+			created[ObjectID(t.FileContractID(uint64(j)))] = i
+		}
+		for j := range t.SiafundOutputs {
+			created[ObjectID(t.SiafundOutputID(uint64(j)))] = i
+		}
+	}
+
+	// Check that all the objects which are spent or referenced by a
+	// transaction were created by an earlier transaction, if they were created
+	// within the package.
+	dependsOnLater := func(i int, oid ObjectID) bool {
+		creator, exists := created[oid]
+		return exists && creator >= i
+	}
+	for i, t := range pkg {
+		for _, sci := range t.SiacoinInputs {
+			if dependsOnLater(i, ObjectID(sci.ParentID)) {
+				return errPackageNotSorted
+			}
+		}
+		for _, fcr := range t.FileContractRevisions {
+			if dependsOnLater(i, ObjectID(fcr.ParentID)) {
+				return errPackageNotSorted
+			}
+		}
+		for _, sp := range t.StorageProofs {
+			if dependsOnLater(i, ObjectID(sp.ParentID)) {
+				return errPackageNotSorted
+			}
+		}
+		for _, sfi := range t.SiafundInputs {
+			if dependsOnLater(i, ObjectID(sfi.ParentID)) {
+				return errPackageNotSorted
+			}
+		}
+	}
+	return nil
+}
+
+// AcceptTransactionPackage adds a package of dependent transactions to the
+// unconfirmed set of transactions. The package is either accepted as a whole
+// or not at all. Fees are evaluated for the package as a whole which allows
+// children to pay for their parents. Unlike a transaction set that is
+// broadcast manually, the package is only relayed to peers after it was
+// accepted by the transaction pool and if it pays the minimum relay fee.
+func (tp *TransactionPool) AcceptTransactionPackage(pkg []types.Transaction) error {
+	if err := tp.tg.Add(); err != nil {
+		return err
+	}
+	defer tp.tg.Done()
+
+	if len(pkg) == 0 {
+		return errEmptySet
+	}
+	if err := checkPackageTopology(pkg); err != nil {
+		return err
+	}
+
+	tp.log.Debugln("Received a transaction package, attempting to broadcast")
+	return tp.managedAcceptTransactionSet(pkg)
+}
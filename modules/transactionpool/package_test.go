@@ -0,0 +1,112 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestCheckPackageTopology is a unit test for checkPackageTopology.
+func TestCheckPackageTopology(t *testing.T) {
+	t.Parallel()
+
+	// Create a parent with an output and a child which spends it.
+	parent := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+		SiafundOutputs: []types.SiafundOutput{{Value: types.NewCurrency64(1)}},
+	}
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(0)}},
+		SiafundInputs: []types.SiafundInput{{ParentID: parent.SiafundOutputID(0)}},
+	}
+	unrelated := types.Transaction{
+		ArbitraryData: [][]byte{{1, 2, 3}},
+	}
+
+	tests := []struct {
+		pkg []types.Transaction
+		err error
+	}{
+		{[]types.Transaction{parent}, nil},
+		{[]types.Transaction{parent, child}, nil},
+		{[]types.Transaction{parent, unrelated, child}, nil},
+		{[]types.Transaction{unrelated, parent, child}, nil},
+		{[]types.Transaction{child, parent}, errPackageNotSorted},
+		{[]types.Transaction{parent, parent}, errPackageDuplicateTransaction},
+		{[]types.Transaction{parent, child, child}, errPackageDuplicateTransaction},
+	}
+	for i, test := range tests {
+		err := checkPackageTopology(test.pkg)
+		if !errors.Contains(err, test.err) && err != test.err {
+			t.Errorf("%v: expected error %v but got %v", i, test.err, err)
+		}
+	}
+}
+
+// TestAcceptTransactionPackage probes the AcceptTransactionPackage method of
+// the transaction pool.
+func TestAcceptTransactionPackage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// Create a transaction pool tester.
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// An empty package is rejected.
+	err = tpt.tpool.AcceptTransactionPackage(nil)
+	if !errors.Contains(err, errEmptySet) {
+		t.Fatal("expected errEmptySet but got", err)
+	}
+
+	// Create a valid transaction set using a transaction builder and a
+	// dependent child.
+	builder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := types.SiacoinPrecision
+	err = builder.FundSiacoins(fee.Mul64(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.AddMinerFee(fee)
+	builder.AddSiacoinOutput(types.SiacoinOutput{Value: fee})
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := txnSet[len(txnSet)-1]
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(uint64(len(parent.SiacoinOutputs) - 1))}},
+	}
+
+	// Submitting the child before the parent fails without adding anything
+	// to the pool.
+	pkg := append(append([]types.Transaction{}, txnSet[:len(txnSet)-1]...), child, parent)
+	err = tpt.tpool.AcceptTransactionPackage(pkg)
+	if !errors.Contains(err, errPackageNotSorted) {
+		t.Fatal("expected errPackageNotSorted but got", err)
+	}
+	if len(tpt.tpool.transactionSets) != 0 {
+		t.Fatal("transaction pool should be empty")
+	}
+
+	// Submitting the parents on their own succeeds.
+	err = tpt.tpool.AcceptTransactionPackage(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.transactionSets) != 1 {
+		t.Fatal("expected a single transaction set in the pool")
+	}
+}
@@ -132,6 +132,7 @@ func (tp *TransactionPool) initPersist() error {
 		bucketRecentConsensusChange,
 		bucketConfirmedTransactions,
 		bucketFeeMedian,
+		bucketSettings,
 	}
 	for _, bucket := range buckets {
 		_, err := tp.dbTx.CreateBucketIfNotExists(bucket)
@@ -178,6 +179,13 @@ func (tp *TransactionPool) initPersist() error {
 		tp.recentMedianFee = mp.RecentMedianFee
 	}
 
+	// Get the settings. The default settings are used if none were stored.
+	settings, err := tp.getSettings(tp.dbTx)
+	if err != nil && !errors.Contains(err, errNilSettings) {
+		return build.ExtendErr("unable to load the tpool settings", err)
+	}
+	tp.setSettings(settings)
+
 	// Subscribe to the consensus set using the most recent consensus change.
 	go func() {
 		err := tp.consensusSet.ConsensusSetSubscribe(tp, cc, tp.tg.StopChan())
@@ -0,0 +1,88 @@
+package transactionpool
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// setSettings updates the settings of the transaction pool in memory.
+func (tp *TransactionPool) setSettings(settings modules.TransactionPoolSettings) {
+	tp.settings = settings
+	tp.exemptAddresses = make(map[types.UnlockHash]struct{}, len(settings.ExemptAddresses))
+	for _, addr := range settings.ExemptAddresses {
+		tp.exemptAddresses[addr] = struct{}{}
+	}
+}
+
+// exempt returns whether a transaction set spends an output of an exempt
+// address and therefore isn't subject to the fee floors.
+func (tp *TransactionPool) exempt(ts []types.Transaction) bool {
+	if len(tp.exemptAddresses) == 0 {
+		return false
+	}
+	for _, txn := range ts {
+		for _, sci := range txn.SiacoinInputs {
+			if _, exists := tp.exemptAddresses[sci.UnlockConditions.UnlockHash()]; exists {
+				return true
+			}
+		}
+		for _, sfi := range txn.SiafundInputs {
+			if _, exists := tp.exemptAddresses[sfi.UnlockConditions.UnlockHash()]; exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requiredFeeRate returns the fee per byte a transaction set needs to pay to
+// be accepted into the transaction pool.
+func (tp *TransactionPool) requiredFeeRate(ts []types.Transaction) types.Currency {
+	rate := tp.requiredFeesToExtendTpool()
+	if tp.settings.MinAcceptFee.Cmp(rate) > 0 && !tp.exempt(ts) {
+		rate = tp.settings.MinAcceptFee
+	}
+	return rate
+}
+
+// managedRelayAllowed returns whether an accepted transaction set pays enough
+// fees to be relayed to the transaction pool's peers.
+func (tp *TransactionPool) managedRelayAllowed(ts []types.Transaction) bool {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	if tp.settings.MinRelayFee.IsZero() || tp.exempt(ts) {
+		return true
+	}
+	requiredFees := tp.settings.MinRelayFee.Mul64(uint64(len(encoding.Marshal(ts))))
+	return modules.CalculateFee(ts).Cmp(requiredFees) >= 0
+}
+
+// SetSettings changes the admission and relay policy of the transaction pool.
+// Transaction sets which are already in the pool are not affected.
+func (tp *TransactionPool) SetSettings(settings modules.TransactionPoolSettings) error {
+	if err := tp.tg.Add(); err != nil {
+		return err
+	}
+	defer tp.tg.Done()
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	err := tp.putSettings(tp.dbTx, settings)
+	if err != nil {
+		return err
+	}
+	tp.syncDB()
+	tp.setSettings(settings)
+	return nil
+}
+
+// Settings returns the admission and relay policy of the transaction pool.
+func (tp *TransactionPool) Settings() modules.TransactionPoolSettings {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	settings := tp.settings
+	settings.ExemptAddresses = append([]types.UnlockHash{}, tp.settings.ExemptAddresses...)
+	return settings
+}
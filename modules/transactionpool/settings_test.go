@@ -0,0 +1,104 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestTransactionPoolSettings checks that the fee floors and the exempt
+// addresses of the transaction pool are enforced and persisted.
+func TestTransactionPoolSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The default settings don't add any floors.
+	settings := tpt.tpool.Settings()
+	if !settings.MinAcceptFee.IsZero() || !settings.MinRelayFee.IsZero() || len(settings.ExemptAddresses) != 0 {
+		t.Fatal("unexpected default settings", settings)
+	}
+
+	// Create a transaction set which pays a small fee.
+	builder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := types.SiacoinPrecision
+	if err := builder.FundSiacoins(fee); err != nil {
+		t.Fatal(err)
+	}
+	builder.AddMinerFee(fee)
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := txnSet[len(txnSet)-1].SiacoinInputs[0].UnlockConditions.UnlockHash()
+
+	// Set an accept floor which the set doesn't pay.
+	settings.MinAcceptFee = types.SiacoinPrecision.Div64(10)
+	settings.MinRelayFee = types.SiacoinPrecision
+	if err := tpt.tpool.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := tpt.tpool.AcceptTransactionSet(txnSet); !errors.Contains(err, errLowMinerFees) {
+		t.Fatal("expected errLowMinerFees but got", err)
+	}
+	if tpt.tpool.managedRelayAllowed(txnSet) {
+		t.Fatal("set below the relay floor shouldn't be relayed")
+	}
+
+	// The recommended fee covers both floors.
+	if min, _ := tpt.tpool.FeeEstimation(); min.Cmp(settings.MinRelayFee) < 0 {
+		t.Fatal("fee estimation is below the relay floor", min)
+	}
+
+	// Once the spent address is exempt, the set is accepted and relayed.
+	settings.ExemptAddresses = []types.UnlockHash{addr}
+	if err := tpt.tpool.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if !tpt.tpool.managedRelayAllowed(txnSet) {
+		t.Fatal("exempt set should be relayed")
+	}
+	if err := tpt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings survive a restart.
+	if err := tpt.tpool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, tpt.tpool.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := tpt.tpool.Settings()
+	if !loaded.MinAcceptFee.Equals(settings.MinAcceptFee) || !loaded.MinRelayFee.Equals(settings.MinRelayFee) {
+		t.Fatal("fee floors weren't persisted", loaded)
+	}
+	if len(loaded.ExemptAddresses) != 1 || loaded.ExemptAddresses[0] != addr {
+		t.Fatal("exempt addresses weren't persisted", loaded.ExemptAddresses)
+	}
+
+	// Resetting the settings removes the floors.
+	if err := tpt.tpool.SetSettings(modules.TransactionPoolSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if !tpt.tpool.managedRelayAllowed(txnSet) {
+		t.Fatal("set should be relayed without a relay floor")
+	}
+}
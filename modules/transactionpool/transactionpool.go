@@ -60,6 +60,11 @@ type (
 		recentMedians   []types.Currency
 		recentMedianFee types.Currency // SC per byte
 
+		// The admission and relay policy of the transaction pool.
+		// exemptAddresses is the set of the settings' exempt addresses.
+		settings        modules.TransactionPoolSettings
+		exemptAddresses map[types.UnlockHash]struct{}
+
 		// The consensus change index tracks how many consensus changes have
 		// been sent to the transaction pool. When a new subscriber joins the
 		// transaction pool, all prior consensus changes are sent to the new
@@ -106,6 +111,8 @@ func NewCustomTPool(cs modules.ConsensusSet, g modules.Gateway, persistDir strin
 		transactionSets:     make(map[modules.TransactionSetID][]types.Transaction),
 		transactionSetDiffs: make(map[modules.TransactionSetID]*modules.ConsensusChange),
 
+		exemptAddresses: make(map[types.UnlockHash]struct{}),
+
 		deps:       deps,
 		persistDir: persistDir,
 	}
@@ -122,6 +129,13 @@ func NewCustomTPool(cs modules.ConsensusSet, g modules.Gateway, persistDir strin
 		tp.gateway.UnregisterRPC("RelayTransactionSet")
 	})
 
+	// Provide the unconfirmed transactions to the consensus set to let it
+	// reconstruct compact blocks.
+	cs.SetTransactionSource(tp)
+	tp.tg.OnStop(func() {
+		tp.consensusSet.SetTransactionSource(nil)
+	})
+
 	// Spin up a thread to periodically dump the tpool size. (debug mode)
 	if build.DEBUG {
 		go tp.threadedLogListSize()
@@ -190,6 +204,15 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	if min.Cmp(minEstimation) < 0 {
 		min = minEstimation
 	}
+
+	// Transactions also need to pay the configured fee floors to be accepted
+	// and relayed by this node.
+	if min.Cmp(tp.settings.MinAcceptFee) < 0 {
+		min = tp.settings.MinAcceptFee
+	}
+	if min.Cmp(tp.settings.MinRelayFee) < 0 {
+		min = tp.settings.MinRelayFee
+	}
 	max = min.Mul64(maxMultiplier)
 	return
 }
@@ -56,6 +56,20 @@ type (
 	// addresses.
 	Seed [crypto.EntropySize]byte
 
+	// SeedProvider stores the primary seed of a wallet outside of the wallet's
+	// database, e.g. in an external key management system or HSM. A wallet
+	// with a SeedProvider only stores an encryption verification for its
+	// primary seed on disk and loads the seed from the provider when it is
+	// unlocked.
+	SeedProvider interface {
+		// LoadSeed returns the seed which was previously stored with
+		// StoreSeed.
+		LoadSeed() (Seed, error)
+
+		// StoreSeed stores the seed, replacing any previously stored seed.
+		StoreSeed(Seed) error
+	}
+
 	// WalletTransactionID is a unique identifier for a wallet transaction.
 	WalletTransactionID crypto.Hash
 
@@ -128,6 +142,27 @@ type (
 		IsWatchOnly        bool              `json:"iswatchonly"`
 	}
 
+	// An ImmatureSiacoinOutput is a delayed siacoin output of the wallet which
+	// can't be spent until the blockchain reaches its maturity height. The
+	// fund type is 'MinerPayout', 'ClaimOutput', 'StorageProof' for contract
+	// payouts or 'Foundation' for the foundation subsidy.
+	ImmatureSiacoinOutput struct {
+		ID             types.SiacoinOutputID `json:"id"`
+		FundType       types.Specifier       `json:"fundtype"`
+		UnlockHash     types.UnlockHash      `json:"unlockhash"`
+		Value          types.Currency        `json:"value"`
+		MaturityHeight types.BlockHeight     `json:"maturityheight"`
+	}
+
+	// A SpendableBalanceProjection is the projected confirmed siacoin balance
+	// of the wallet once the immature outputs of a maturity height have
+	// matured, assuming that no other outputs are spent or received.
+	SpendableBalanceProjection struct {
+		Height            types.BlockHeight `json:"height"`
+		MaturingSiacoins  types.Currency    `json:"maturingsiacoins"`
+		SpendableSiacoins types.Currency    `json:"spendablesiacoins"`
+	}
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -433,6 +468,11 @@ type (
 		// the blockchain to search for transactions containing the addresses.
 		AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
+		// BumpFee raises the fee of the unconfirmed transaction with the
+		// provided id by submitting a child transaction which pays for it. The
+		// submitted transaction set is returned.
+		BumpFee(txid types.TransactionID) ([]types.Transaction, error)
+
 		// Close permits clean shutdown during testing and serving.
 		Close() error
 
@@ -448,6 +488,12 @@ type (
 		// not considered in the unconfirmed balance.
 		UnconfirmedBalance() (outgoingSiacoins types.Currency, incomingSiacoins types.Currency, err error)
 
+		// ImmatureBalance returns the immature siacoin outputs of the wallet
+		// sorted by maturity height, together with the projected confirmed
+		// siacoin balance at every height at which outputs mature. Outputs
+		// below the dust threshold don't count towards the balance.
+		ImmatureBalance() (outputs []ImmatureSiacoinOutput, timeline []SpendableBalanceProjection, err error)
+
 		// Height returns the wallet's internal processed consensus height
 		Height() (types.BlockHeight, error)
 
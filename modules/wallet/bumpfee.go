@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errBumpFeeNoOutput is returned by BumpFee if the transaction doesn't
+	// have an unspent wallet output which can pay for the higher fee.
+	errBumpFeeNoOutput = errors.New("transaction has no unspent wallet output which is large enough to pay for the fee bump")
+
+	// errBumpFeeNotFound is returned by BumpFee if the transaction is not in
+	// the transaction pool.
+	errBumpFeeNotFound = errors.New("transaction not found in the transaction pool")
+)
+
+// BumpFee raises the fee of an unconfirmed transaction by creating a child
+// transaction which spends a wallet output of the transaction or one of its
+// unconfirmed parents and pays a fee for the whole set (child pays for
+// parent). The fee is chosen so that the whole set pays the maximum fee
+// recommended by the transaction pool. The transaction pool doesn't replace
+// transactions which spend the same inputs, which is why the inputs of the
+// transaction can't be reused for a replacement with a higher fee. The
+// submitted transaction set, including the child, is returned.
+func (w *Wallet) BumpFee(txid types.TransactionID) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// Check if consensus is synced
+	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
+		return nil, errors.AddContext(modules.ErrNotSynced, "cannot bump fee until fully synced")
+	}
+
+	txn, parents, exists := w.tpool.Transaction(txid)
+	if !exists {
+		return nil, errBumpFeeNotFound
+	}
+	txnSet := append(parents, txn)
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, err
+	}
+
+	// Compute the fee of the child. The child needs to pay for itself and for
+	// the part of the set's fee which is missing to reach the recommended fee.
+	_, maxFee := w.tpool.FeeEstimation()
+	var setFees types.Currency
+	for _, t := range txnSet {
+		for _, fee := range t.MinerFees {
+			setFees = setFees.Add(fee)
+		}
+	}
+	fee := maxFee.Mul64(estimatedTransactionSize)
+	requiredFees := maxFee.Mul64(uint64(len(encoding.Marshal(txnSet)) + estimatedTransactionSize))
+	if requiredFees.Cmp(setFees.Add(fee)) > 0 {
+		fee = requiredFees.Sub(setFees)
+	}
+
+	child, refundUnlockConditions, err := w.managedCreateBumpFeeChild(txnSet, fee, dustThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	// Submit the set as a package to allow the child to pay for its parents.
+	txnSet = append(txnSet, child)
+	err = w.tpool.AcceptTransactionPackage(txnSet)
+	if err != nil {
+		w.managedMarkAddressUnused(refundUnlockConditions)
+		w.log.Println("Attempt to bump fee has failed - transaction pool rejected transaction:", err)
+		return nil, errors.AddContext(err, "unable to get transaction accepted")
+	}
+	w.log.Printf("Bumped fee of transaction %v with child %v paying %v", txid, child.ID(), fee.HumanString())
+	return txnSet, nil
+}
+
+// managedCreateBumpFeeChild creates and signs a transaction which spends the
+// largest unspent wallet output of txnSet, pays the provided fee and sends the
+// rest back to the wallet. The unlock conditions of the refund address are
+// returned as well.
+func (w *Wallet) managedCreateBumpFeeChild(txnSet []types.Transaction, fee, dustThreshold types.Currency) (types.Transaction, types.UnlockConditions, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.Transaction{}, types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.Transaction{}, types.UnlockConditions{}, err
+	}
+
+	// Find the largest wallet output of the set which isn't spent by another
+	// unconfirmed transaction yet.
+	spent := make(map[types.OutputID]struct{})
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		for _, input := range upt.Inputs {
+			spent[input.ParentID] = struct{}{}
+		}
+	}
+	for _, txn := range txnSet {
+		for _, sci := range txn.SiacoinInputs {
+			spent[types.OutputID(sci.ParentID)] = struct{}{}
+		}
+	}
+	var parentID types.SiacoinOutputID
+	var output types.SiacoinOutput
+	for _, txn := range txnSet {
+		for i, sco := range txn.SiacoinOutputs {
+			id := txn.SiacoinOutputID(uint64(i))
+			if _, isSpent := spent[types.OutputID(id)]; isSpent {
+				continue
+			}
+			if _, ok := w.keys[sco.UnlockHash]; ok && sco.Value.Cmp(output.Value) > 0 {
+				parentID, output = id, sco
+			}
+		}
+	}
+	if output.Value.Cmp(fee.Add(dustThreshold)) <= 0 {
+		return types.Transaction{}, types.UnlockConditions{}, errBumpFeeNoOutput
+	}
+
+	// Create the child which sends the output minus the fee back to the
+	// wallet.
+	refundUnlockConditions, err := w.nextPrimarySeedAddress(w.dbTx)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return types.Transaction{}, types.UnlockConditions{}, errors.AddContext(err, "failed to get refund address")
+	}
+	sk := w.keys[output.UnlockHash]
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         parentID,
+			UnlockConditions: sk.UnlockConditions,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      output.Value.Sub(fee),
+			UnlockHash: refundUnlockConditions.UnlockHash(),
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	addSignatures(&child, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(parentID), sk, consensusHeight)
+	return child, refundUnlockConditions, nil
+}
@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBumpFee probes the BumpFee method of the wallet.
+func TestBumpFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Unknown transactions can't be bumped.
+	if _, err := wt.wallet.BumpFee(types.TransactionID{1}); !errors.Contains(err, errBumpFeeNotFound) {
+		t.Fatal("expected errBumpFeeNotFound but got", err)
+	}
+
+	// Send some coins and bump the fee of the transaction.
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(3), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	set, err := wt.wallet.BumpFee(txid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The set should contain the transaction followed by a child which spends
+	// an output of the set.
+	child := set[len(set)-1]
+	if len(set) != len(txns)+1 || set[len(set)-2].ID() != txid {
+		t.Fatal("unexpected set", set)
+	}
+	created := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range set[:len(set)-1] {
+		for i := range txn.SiacoinOutputs {
+			created[txn.SiacoinOutputID(uint64(i))] = struct{}{}
+		}
+	}
+	if len(child.SiacoinInputs) != 1 {
+		t.Fatal("child should have a single input", child)
+	}
+	if _, exists := created[child.SiacoinInputs[0].ParentID]; !exists {
+		t.Fatal("child doesn't spend an output of the set")
+	}
+
+	// The set should pay the recommended fee.
+	_, maxFee := wt.tpool.FeeEstimation()
+	var fees types.Currency
+	for _, txn := range set {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	if fees.Cmp(maxFee.Mul64(uint64(len(encoding.Marshal(set))))) < 0 {
+		t.Fatal("set doesn't pay the recommended fee", fees)
+	}
+
+	// The child should be in the tpool and confirmed by the next block.
+	if _, _, exists := wt.tpool.Transaction(child.ID()); !exists {
+		t.Fatal("child is not in the tpool")
+	}
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if confirmed, err := wt.tpool.TransactionConfirmed(child.ID()); err != nil || !confirmed {
+		t.Fatal("child wasn't confirmed", err)
+	}
+}
@@ -26,6 +26,10 @@ var (
 	// bucketAddrTransactions maps an UnlockHash to the
 	// ProcessedTransactions that it appears in.
 	bucketAddrTransactions = []byte("bucketAddrTransactions")
+	// bucketDelayedSiacoinOutputs maps a SiacoinOutputID to a
+	// delayedSiacoinOutput. Only delayed outputs that the wallet controls are
+	// stored. The outputs are removed once they have matured.
+	bucketDelayedSiacoinOutputs = []byte("bucketDelayedSiacoinOutputs")
 	// bucketSiacoinOutputs maps a SiacoinOutputID to its SiacoinOutput. Only
 	// outputs that the wallet controls are stored. The wallet uses these
 	// outputs to fund transactions.
@@ -51,6 +55,7 @@ var (
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
+		bucketDelayedSiacoinOutputs,
 		bucketSiacoinOutputs,
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
@@ -211,6 +216,16 @@ func dbForEachSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.Si
 	return dbForEach(tx.Bucket(bucketSiacoinOutputs), fn)
 }
 
+func dbPutDelayedSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output delayedSiacoinOutput) error {
+	return dbPut(tx.Bucket(bucketDelayedSiacoinOutputs), id, output)
+}
+func dbDeleteDelayedSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
+	return dbDelete(tx.Bucket(bucketDelayedSiacoinOutputs), id)
+}
+func dbForEachDelayedSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, delayedSiacoinOutput)) error {
+	return dbForEach(tx.Bucket(bucketDelayedSiacoinOutputs), fn)
+}
+
 func dbPutSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, output types.SiafundOutput) error {
 	return dbPut(tx.Bucket(bucketSiafundOutputs), id, output)
 }
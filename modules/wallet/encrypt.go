@@ -80,8 +80,18 @@ func (w *Wallet) initEncryption(masterKey crypto.CipherKey, seed modules.Seed, p
 		return modules.Seed{}, errReencrypt
 	}
 
-	// create a seedFile for the seed
-	sf := createSeedFile(masterKey, seed)
+	// create a seedFile for the seed, only storing the encryption
+	// verification if the seed is stored by the seed provider
+	var sf seedFile
+	if w.staticSeedProvider != nil {
+		err := w.staticSeedProvider.StoreSeed(seed)
+		if err != nil {
+			return modules.Seed{}, errors.AddContext(err, "failed to store seed with seed provider")
+		}
+		sf = createExternalSeedFile(masterKey)
+	} else {
+		sf = createSeedFile(masterKey, seed)
+	}
 
 	// set this as the primary seedFile
 	err := wb.Put(keyPrimarySeedFile, encoding.Marshal(sf))
@@ -243,16 +253,27 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 		return modules.ConsensusChangeID{}, err
 	}
 
+	// Decrypt the primary seed before acquiring the lock since it might need
+	// to be loaded from the seed provider.
+	primarySeed, err := w.managedDecryptPrimarySeedFile(masterKey, primarySeedFile)
+	if err != nil {
+		return modules.ConsensusChangeID{}, err
+	}
+	// Move the primary seed to the seed provider if it is still stored in the
+	// wallet's database.
+	if w.staticSeedProvider != nil && !primarySeedFile.external() {
+		err = w.managedExternalizePrimarySeed(masterKey, primarySeed)
+		if err != nil {
+			return modules.ConsensusChangeID{}, err
+		}
+	}
+
 	// Decrypt + load keys.
 	err = func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
 
 		// primarySeedFile
-		primarySeed, err := decryptSeedFile(masterKey, primarySeedFile)
-		if err != nil {
-			return err
-		}
 		w.integrateSeed(primarySeed, primarySeedProgress)
 		w.primarySeed = primarySeed
 		w.regenerateLookahead(primarySeedProgress)
@@ -470,8 +491,8 @@ func (w *Wallet) InitFromSeed(masterKey crypto.CipherKey, seed modules.Seed) err
 	}
 	defer w.tg.Done()
 
-	if !w.cs.Synced() {
-		return errors.New("cannot init from seed until blockchain is synced")
+	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
+		return errors.AddContext(modules.ErrNotSynced, "cannot init from seed until blockchain is synced")
 	}
 
 	// If masterKey is blank, use the hash of the seed.
@@ -663,7 +684,7 @@ func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.Ciph
 	var auxiliarySeeds []modules.Seed
 	var spendableKeys []spendableKey
 
-	primarySeed, err = decryptSeedFile(masterKey, primarySeedFile)
+	primarySeed, err = w.managedDecryptPrimarySeedFile(masterKey, primarySeedFile)
 	if err != nil {
 		return errors.AddContext(err, "unable to decrypt primary seed file")
 	}
@@ -687,7 +708,11 @@ func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.Ciph
 	var newAuxiliarySeedFiles []seedFile
 	var newUnseededKeyFiles []spendableKeyFile
 
-	newPrimarySeedFile = createSeedFile(newKey, primarySeed)
+	if primarySeedFile.external() {
+		newPrimarySeedFile = createExternalSeedFile(newKey)
+	} else {
+		newPrimarySeedFile = createSeedFile(newKey, primarySeed)
+	}
 	for _, seed := range auxiliarySeeds {
 		sf := createSeedFile(newKey, seed)
 		newAuxiliarySeedFiles = append(newAuxiliarySeedFiles, sf)
@@ -1,6 +1,8 @@
 package wallet
 
 import (
+	"sort"
+
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -108,6 +110,69 @@ func (w *Wallet) UnconfirmedBalance() (outgoingSiacoins types.Currency, incoming
 	return
 }
 
+// ImmatureBalance returns the immature siacoin outputs of the wallet sorted by
+// maturity height, together with the projected confirmed siacoin balance at
+// every height at which outputs mature.
+func (w *Wallet) ImmatureBalance() (outputs []modules.ImmatureSiacoinOutput, timeline []modules.SpendableBalanceProjection, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, nil, modules.ErrWalletShutdown
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The confirmed balance and the immature outputs are read within the same
+	// db transaction so that matured outputs aren't counted twice.
+	var spendable types.Currency
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.Value.Cmp(dustThreshold) > 0 {
+			spendable = spendable.Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = dbForEachDelayedSiacoinOutput(w.dbTx, func(id types.SiacoinOutputID, dso delayedSiacoinOutput) {
+		outputs = append(outputs, modules.ImmatureSiacoinOutput{
+			ID:             id,
+			FundType:       dso.FundType,
+			UnlockHash:     dso.SiacoinOutput.UnlockHash,
+			Value:          dso.SiacoinOutput.Value,
+			MaturityHeight: dso.MaturityHeight,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].MaturityHeight < outputs[j].MaturityHeight
+	})
+
+	// Project the balance at every maturity height.
+	for _, output := range outputs {
+		if output.Value.Cmp(dustThreshold) <= 0 {
+			continue
+		}
+		spendable = spendable.Add(output.Value)
+		if len(timeline) == 0 || timeline[len(timeline)-1].Height != output.MaturityHeight {
+			timeline = append(timeline, modules.SpendableBalanceProjection{
+				Height: output.MaturityHeight,
+			})
+		}
+		projection := &timeline[len(timeline)-1]
+		projection.MaturingSiacoins = projection.MaturingSiacoins.Add(output.Value)
+		projection.SpendableSiacoins = spendable
+	}
+	return outputs, timeline, nil
+}
+
 // SendSiacoins creates a transaction sending 'amount' to 'dest'. The
 // transaction is submitted to the transaction pool and is also returned. Fees
 // are added to the amount sent.
@@ -149,7 +214,7 @@ func (w *Wallet) SendSiacoinsFeeIncluded(amount types.Currency, dest types.Unloc
 func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
 	// Check if consensus is synced
 	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
-		return nil, errors.New("cannot send siacoin until fully synced")
+		return nil, errors.AddContext(modules.ErrNotSynced, "cannot send siacoin until fully synced")
 	}
 
 	w.mu.RLock()
@@ -214,7 +279,7 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 
 	// Check if consensus is synced
 	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
-		return nil, errors.New("cannot send siacoin until fully synced")
+		return nil, errors.AddContext(modules.ErrNotSynced, "cannot send siacoin until fully synced")
 	}
 
 	w.mu.RLock()
@@ -294,7 +359,7 @@ func (w *Wallet) SendSiafunds(amount types.Currency, dest types.UnlockHash) (txn
 
 	// Check if consensus is synced
 	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
-		return nil, errors.New("cannot send siafunds until fully synced")
+		return nil, errors.AddContext(modules.ErrNotSynced, "cannot send siafunds until fully synced")
 	}
 
 	w.mu.RLock()
@@ -1,11 +1,14 @@
 package wallet
 
 import (
+	"path/filepath"
 	"sort"
 	"testing"
 
+	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
@@ -410,3 +413,100 @@ func TestSendSiacoinsAcceptTxnSetFailed(t *testing.T) {
 		t.Fatalf("SendSiacoins failed: %v", err)
 	}
 }
+
+// TestImmatureBalance probes the ImmatureBalance method of the wallet.
+func TestImmatureBalance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The miner payouts of the last MaturityDelay blocks should be immature
+	// and mature one after another.
+	checkImmatureBalance := func() {
+		t.Helper()
+		height, err := wt.wallet.Height()
+		if err != nil {
+			t.Fatal(err)
+		}
+		confirmedBal, _, _, err := wt.wallet.ConfirmedBalance()
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs, timeline, err := wt.wallet.ImmatureBalance()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outputs) != int(types.MaturityDelay) || len(timeline) != int(types.MaturityDelay) {
+			t.Fatalf("expected %v immature outputs, got %v outputs and %v projections", types.MaturityDelay, len(outputs), len(timeline))
+		}
+		spendable := confirmedBal
+		for i, output := range outputs {
+			payoutHeight := height - types.MaturityDelay + types.BlockHeight(i) + 1
+			if output.FundType != types.SpecifierMinerPayout {
+				t.Fatal("wrong fund type", output.FundType)
+			}
+			if output.MaturityHeight != payoutHeight+types.MaturityDelay {
+				t.Fatal("wrong maturity height", output.MaturityHeight, payoutHeight)
+			}
+			if !output.Value.Equals(types.CalculateCoinbase(payoutHeight)) {
+				t.Fatal("wrong value", output.Value)
+			}
+			spendable = spendable.Add(output.Value)
+			if timeline[i].Height != output.MaturityHeight || !timeline[i].MaturingSiacoins.Equals(output.Value) || !timeline[i].SpendableSiacoins.Equals(spendable) {
+				t.Fatal("wrong projection", timeline[i])
+			}
+		}
+	}
+	checkImmatureBalance()
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	checkImmatureBalance()
+
+	// Remove the delayed outputs bucket to simulate a wallet from before the
+	// outputs were tracked. The wallet should rescan the blockchain to find
+	// them without duplicating its transactions.
+	txns, err := wt.wallet.Transactions(0, types.BlockHeight(^uint64(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(wt.persistDir, modules.WalletDir)
+	db, err := persist.OpenDatabase(dbMetadata, filepath.Join(dir, dbFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket(bucketDelayedSiacoinOutputs)
+	})
+	if err := errors.Compose(err, db.Close()); err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet, err = New(wt.cs, wt.tpool, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	checkImmatureBalance()
+	rescannedTxns, err := wt.wallet.Transactions(0, types.BlockHeight(^uint64(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rescannedTxns) != len(txns) {
+		t.Fatalf("expected %v transactions after rescan, got %v", len(txns), len(rescannedTxns))
+	}
+}
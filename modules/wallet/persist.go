@@ -50,6 +50,9 @@ func (w *Wallet) openDB(filename string) (err error) {
 	err = w.db.Update(func(tx *bolt.Tx) error {
 		// check whether we need to init bucketAddrTransactions
 		buildAddrTxns := tx.Bucket(bucketAddrTransactions) == nil
+		// COMPATv1.5.7 check whether an existing wallet needs to rescan the
+		// blockchain to find its delayed siacoin outputs
+		scanDelayedOutputs := tx.Bucket(bucketWallet) != nil && tx.Bucket(bucketDelayedSiacoinOutputs) == nil
 		// ensure that all buckets exist
 		for _, b := range dbBuckets {
 			_, err := tx.CreateBucketIfNotExists(b)
@@ -92,6 +95,25 @@ func (w *Wallet) openDB(filename string) (err error) {
 			}
 		}
 
+		// reset the processed transactions and the consensus change ID to
+		// rescan the blockchain once the wallet is unlocked
+		if scanDelayedOutputs {
+			for _, b := range [][]byte{bucketProcessedTransactions, bucketProcessedTxnIndex, bucketAddrTransactions} {
+				if err := tx.DeleteBucket(b); err != nil {
+					return err
+				}
+				if _, err := tx.CreateBucket(b); err != nil {
+					return err
+				}
+			}
+			if err := dbPutConsensusChangeID(tx, modules.ConsensusChangeBeginning); err != nil {
+				return err
+			}
+			if err := dbPutConsensusHeight(tx, 0); err != nil {
+				return err
+			}
+		}
+
 		// check whether wallet is encrypted
 		w.encrypted = tx.Bucket(bucketWallet).Get(keyEncryptionVerification) != nil
 		return nil
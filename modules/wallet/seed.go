@@ -94,6 +94,72 @@ func decryptSeedFile(masterKey crypto.CipherKey, sf seedFile) (seed modules.Seed
 	return seed, nil
 }
 
+// createExternalSeedFile creates a seedFile for a seed which is stored by a
+// seed provider. The seedFile only contains the encryption verification.
+func createExternalSeedFile(masterKey crypto.CipherKey) seedFile {
+	var sf seedFile
+	fastrand.Read(sf.UID[:])
+	sek := saltedEncryptionKey(masterKey, sf.UID)
+	sf.EncryptionVerification = sek.EncryptBytes(verificationPlaintext)
+	return sf
+}
+
+// external returns whether the seed of the seedFile is stored by a seed
+// provider.
+func (sf seedFile) external() bool {
+	return len(sf.Seed) == 0
+}
+
+// managedExternalizePrimarySeed stores the primary seed with the wallet's seed
+// provider and removes it from the wallet's database.
+func (w *Wallet) managedExternalizePrimarySeed(masterKey crypto.CipherKey, seed modules.Seed) error {
+	err := w.staticSeedProvider.StoreSeed(seed)
+	if err != nil {
+		return errors.AddContext(err, "failed to store seed with seed provider")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sf := createExternalSeedFile(masterKey)
+	err = w.dbTx.Bucket(bucketWallet).Put(keyPrimarySeedFile, encoding.Marshal(sf))
+	if err != nil {
+		return errors.AddContext(err, "failed to replace primary seed file")
+	}
+	return nil
+}
+
+// managedDecryptPrimarySeedFile decrypts the primary seed file. If the primary
+// seed is stored externally, the master key is verified using the seed file
+// and the seed is loaded from the wallet's seed provider.
+func (w *Wallet) managedDecryptPrimarySeedFile(masterKey crypto.CipherKey, sf seedFile) (modules.Seed, error) {
+	if !sf.external() {
+		return decryptSeedFile(masterKey, sf)
+	}
+	if w.staticSeedProvider == nil {
+		return modules.Seed{}, errNoSeedProvider
+	}
+	err := verifyEncryption(saltedEncryptionKey(masterKey, sf.UID), sf.EncryptionVerification)
+	if err != nil {
+		return modules.Seed{}, err
+	}
+	seed, err := w.staticSeedProvider.LoadSeed()
+	if err != nil {
+		return modules.Seed{}, errors.AddContext(err, "failed to load seed from seed provider")
+	}
+
+	// The wallet password is encrypted with a key derived from the primary
+	// seed which allows for verifying the loaded seed.
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	encryptedPassword := w.dbTx.Bucket(bucketWallet).Get(keyWalletPassword)
+	if len(encryptedPassword) != 0 {
+		wpk := walletPasswordEncryptionKey(seed, dbGetWalletSalt(w.dbTx))
+		if _, err := wpk.DecryptBytes(encryptedPassword); err != nil {
+			return modules.Seed{}, errWrongExternalSeed
+		}
+	}
+	return seed, nil
+}
+
 // regenerateLookahead creates future keys up to a maximum of maxKeys keys
 func (w *Wallet) regenerateLookahead(start uint64) {
 	// Check how many keys need to be generated
@@ -303,8 +369,8 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 	}
 	defer w.tg.Done()
 
-	if !w.cs.Synced() {
-		return errors.New("cannot load seed until blockchain is synced")
+	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
+		return errors.AddContext(modules.ErrNotSynced, "cannot load seed until blockchain is synced")
 	}
 
 	if !w.scanLock.TryLock() {
@@ -425,8 +491,8 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 		return types.Currency{}, types.Currency{}, errors.New("cannot sweep primary seed")
 	}
 
-	if !w.cs.Synced() {
-		return types.Currency{}, types.Currency{}, errors.New("cannot sweep until blockchain is synced")
+	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
+		return types.Currency{}, types.Currency{}, errors.AddContext(modules.ErrNotSynced, "cannot sweep until blockchain is synced")
 	}
 
 	// get an address to spend into
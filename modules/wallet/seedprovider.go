@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errNoSeedProvider is returned when the primary seed of the wallet is
+	// stored externally but the wallet wasn't created with a seed provider.
+	errNoSeedProvider = errors.New("the primary seed is stored externally but no seed provider is configured")
+
+	// errWrongExternalSeed is returned when the seed provider returns a seed
+	// which doesn't belong to the wallet.
+	errWrongExternalSeed = errors.New("the seed provider returned a seed which doesn't belong to the wallet")
+
+	// seedCommandTimeout is the amount of time a seed command may take
+	// before it is killed.
+	seedCommandTimeout = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+)
+
+// commandSeedProvider is a modules.SeedProvider which loads and stores the
+// seed by running an external command. This allows for integrating the wallet
+// with secret managers, KMS and HSMs (e.g. through PKCS#11 or KMIP tooling)
+// without the seed ever being written to the wallet's database.
+//
+// The command is run with the additional argument "load" to load the seed,
+// upon which it is expected to print the seed's phrase to stdout. To store the
+// seed it is run with the additional argument "store" and receives the seed's
+// phrase on stdin.
+type commandSeedProvider struct {
+	staticName string
+	staticArgs []string
+}
+
+// NewCommandSeedProvider creates a seed provider which loads and stores the
+// primary seed of the wallet using the provided command.
+func NewCommandSeedProvider(name string, args ...string) modules.SeedProvider {
+	return &commandSeedProvider{
+		staticName: name,
+		staticArgs: append([]string(nil), args...),
+	}
+}
+
+// run runs the command with the provided action as the last argument.
+func (csp *commandSeedProvider) run(action string, stdin string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), seedCommandTimeout)
+	defer cancel()
+
+	args := append(append([]string(nil), csp.staticArgs...), action)
+	cmd := exec.CommandContext(ctx, csp.staticName, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.AddContext(err, msg)
+		}
+		return "", errors.AddContext(err, "seed command failed to "+action+" the seed")
+	}
+	return stdout.String(), nil
+}
+
+// LoadSeed implements modules.SeedProvider.
+func (csp *commandSeedProvider) LoadSeed() (modules.Seed, error) {
+	out, err := csp.run("load", "")
+	if err != nil {
+		return modules.Seed{}, err
+	}
+	seed, err := modules.StringToSeed(strings.TrimSpace(out), mnemonics.English)
+	if err != nil {
+		return modules.Seed{}, errors.AddContext(err, "seed command returned an invalid seed")
+	}
+	return seed, nil
+}
+
+// StoreSeed implements modules.SeedProvider.
+func (csp *commandSeedProvider) StoreSeed(seed modules.Seed) error {
+	phrase, err := modules.SeedToString(seed, mnemonics.English)
+	if err != nil {
+		return err
+	}
+	_, err = csp.run("store", phrase+"\n")
+	return err
+}
@@ -0,0 +1,242 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// memorySeedProvider is a seed provider which keeps the seed in memory.
+type memorySeedProvider struct {
+	seed   modules.Seed
+	stored bool
+}
+
+// LoadSeed implements modules.SeedProvider.
+func (msp *memorySeedProvider) LoadSeed() (modules.Seed, error) {
+	if !msp.stored {
+		return modules.Seed{}, errors.New("no seed stored")
+	}
+	return msp.seed, nil
+}
+
+// StoreSeed implements modules.SeedProvider.
+func (msp *memorySeedProvider) StoreSeed(seed modules.Seed) error {
+	msp.seed = seed
+	msp.stored = true
+	return nil
+}
+
+// primarySeedFile returns the primary seed file stored in the wallet's
+// database.
+func (w *Wallet) primarySeedFile() (sf seedFile, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err = encoding.Unmarshal(w.dbTx.Bucket(bucketWallet).Get(keyPrimarySeedFile), &sf)
+	return
+}
+
+// TestCommandSeedProvider tests storing and loading a seed using an external
+// command.
+func TestCommandSeedProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := build.TempDir(modules.WalletDir, t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	seedPath := filepath.Join(dir, "seed")
+	script := filepath.Join(dir, "seed.sh")
+	err := ioutil.WriteFile(script, []byte(`#!/bin/sh
+case "$2" in
+load) cat "$1" ;;
+store) cat > "$1" ;;
+*) echo "unknown action" >&2; exit 1 ;;
+esac
+`), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := NewCommandSeedProvider(script, seedPath)
+
+	// Loading fails before a seed was stored.
+	if _, err := sp.LoadSeed(); err == nil {
+		t.Fatal("expected loading a missing seed to fail")
+	}
+
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	if err := sp.StoreSeed(seed); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := sp.LoadSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != seed {
+		t.Fatal("loaded seed doesn't match stored seed")
+	}
+
+	// Invalid seeds are rejected.
+	if err := ioutil.WriteFile(seedPath, []byte("not a seed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sp.LoadSeed(); err == nil {
+		t.Fatal("expected loading an invalid seed to fail")
+	}
+}
+
+// TestWalletSeedProvider tests that a wallet with a seed provider doesn't store
+// its primary seed in its database.
+func TestWalletSeedProvider(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createBlankWalletTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dir := filepath.Join(wt.persistDir, "seedprovider")
+	var sp memorySeedProvider
+	w, err := NewCustomWalletWithSeedProvider(wt.cs, wt.tpool, dir, modules.ProdDependencies, &sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Encrypting the wallet stores the seed with the provider.
+	masterKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	seed, err := w.Encrypt(masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sp.stored || sp.seed != seed {
+		t.Fatal("seed wasn't stored with the seed provider")
+	}
+	sf, err := w.primarySeedFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf.external() {
+		t.Fatal("seed was stored in the database")
+	}
+
+	// The wallet can be unlocked and uses the provided seed.
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	primarySeed, _, err := w.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primarySeed != seed {
+		t.Fatal("wallet is using the wrong seed")
+	}
+
+	// Changing the key keeps the seed external.
+	newKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	if err := w.ChangeKey(masterKey, newKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(masterKey); err == nil {
+		t.Fatal("wallet was unlocked with the old key")
+	}
+	if sf, err := w.primarySeedFile(); err != nil || !sf.external() {
+		t.Fatal("seed file isn't external after changing the key", err)
+	}
+
+	// A wrong seed is detected.
+	var wrongSeed modules.Seed
+	fastrand.Read(wrongSeed[:])
+	sp.seed = wrongSeed
+	if err := w.Unlock(newKey); !errors.Contains(err, errWrongExternalSeed) {
+		t.Fatal("expected errWrongExternalSeed but got", err)
+	}
+	sp.seed = seed
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a seed provider the wallet can't be unlocked.
+	w, err = NewCustomWallet(wt.cs, wt.tpool, dir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(newKey); !errors.Contains(err, errNoSeedProvider) {
+		t.Fatal("expected errNoSeedProvider but got", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWalletSeedProviderMigration tests that the primary seed of an existing
+// wallet is moved to the seed provider when the wallet is unlocked.
+func TestWalletSeedProviderMigration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createBlankWalletTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dir := filepath.Join(wt.persistDir, "seedprovider")
+	w, err := NewCustomWallet(wt.cs, wt.tpool, dir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	masterKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	seed, err := w.Encrypt(masterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the wallet with a seed provider and unlock it.
+	var sp memorySeedProvider
+	w, err = NewCustomWalletWithSeedProvider(wt.cs, wt.tpool, dir, modules.ProdDependencies, &sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if !sp.stored || sp.seed != seed {
+		t.Fatal("seed wasn't moved to the seed provider")
+	}
+	if sf, err := w.primarySeedFile(); err != nil || !sf.external() {
+		t.Fatal("seed wasn't removed from the database", err)
+	}
+}
@@ -13,8 +13,35 @@ import (
 type (
 	spentSiacoinOutputSet map[types.SiacoinOutputID]types.SiacoinOutput
 	spentSiafundOutputSet map[types.SiafundOutputID]types.SiafundOutput
+
+	// delayedSiacoinOutput is a siacoin output of the wallet which can't be
+	// spent until the consensus set reaches its maturity height.
+	delayedSiacoinOutput struct {
+		SiacoinOutput  types.SiacoinOutput
+		FundType       types.Specifier
+		MaturityHeight types.BlockHeight
+	}
 )
 
+// delayedOutputFundTypes returns the fund types of the delayed siacoin outputs
+// created by the miner payouts, siafund claims and foundation subsidies of
+// the blocks.
+func delayedOutputFundTypes(blocks []types.Block) map[types.SiacoinOutputID]types.Specifier {
+	fundTypes := make(map[types.SiacoinOutputID]types.Specifier)
+	for _, block := range blocks {
+		for i := range block.MinerPayouts {
+			fundTypes[block.MinerPayoutID(uint64(i))] = types.SpecifierMinerPayout
+		}
+		for _, txn := range block.Transactions {
+			for _, sfi := range txn.SiafundInputs {
+				fundTypes[sfi.ParentID.SiaClaimOutputID()] = types.SpecifierClaimOutput
+			}
+		}
+		fundTypes[block.ID().FoundationSubsidyID()] = types.SpecifierFoundation
+	}
+	return fundTypes
+}
+
 // threadedResetSubscriptions unsubscribes the wallet from the consensus set and transaction pool
 // and subscribes again.
 func (w *Wallet) threadedResetSubscriptions() {
@@ -123,6 +150,38 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 			return err
 		}
 	}
+	fundTypes := delayedOutputFundTypes(cc.AppliedBlocks)
+	for _, diff := range cc.DelayedSiacoinOutputDiffs {
+		// Verify that the diff is relevant to the wallet.
+		if !w.isWalletAddress(diff.SiacoinOutput.UnlockHash) {
+			continue
+		}
+
+		var err error
+		if diff.Direction == modules.DiffApply {
+			// Delayed outputs which weren't created by a miner payout, claim
+			// or subsidy of the applied blocks are contract payouts. The only
+			// exceptions are matured outputs which are delayed again by a
+			// reorg, but those usually mature again within the same change.
+			fundType, exists := fundTypes[diff.ID]
+			if !exists {
+				fundType = types.SpecifierStorageProofOutput
+			}
+			w.log.Println("Wallet has gained a delayed siacoin output:", diff.ID, "::", diff.SiacoinOutput.Value.HumanString(), "maturing at", diff.MaturityHeight)
+			err = dbPutDelayedSiacoinOutput(tx, diff.ID, delayedSiacoinOutput{
+				SiacoinOutput:  diff.SiacoinOutput,
+				FundType:       fundType,
+				MaturityHeight: diff.MaturityHeight,
+			})
+		} else {
+			w.log.Println("A delayed siacoin output has matured or been reverted:", diff.ID, "::", diff.SiacoinOutput.Value.HumanString())
+			err = dbDeleteDelayedSiacoinOutput(tx, diff.ID)
+		}
+		if err != nil {
+			w.log.Severe("Could not update delayed siacoin output:", err)
+			return err
+		}
+	}
 	for _, diff := range cc.SiafundOutputDiffs {
 		// Verify that the diff is relevant to the wallet.
 		if !w.isWalletAddress(diff.SiafundOutput.UnlockHash) {
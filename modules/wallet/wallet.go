@@ -68,6 +68,10 @@ type Wallet struct {
 	tpool modules.TransactionPool
 	deps  modules.Dependencies
 
+	// staticSeedProvider stores the primary seed outside of the wallet's
+	// database if set.
+	staticSeedProvider modules.SeedProvider
+
 	// The following set of fields are responsible for tracking the confirmed
 	// outputs, and for being able to spend them. The seeds are used to derive
 	// the keys that are tracked on the blockchain. All keys are pregenerated
@@ -188,6 +192,13 @@ func New(cs modules.ConsensusSet, tpool modules.TransactionPool, persistDir stri
 
 // NewCustomWallet creates a new wallet using custom dependencies.
 func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, persistDir string, deps modules.Dependencies) (*Wallet, error) {
+	return NewCustomWalletWithSeedProvider(cs, tpool, persistDir, deps, nil)
+}
+
+// NewCustomWalletWithSeedProvider creates a new wallet using custom
+// dependencies which stores its primary seed with the provided seed provider
+// instead of its database. If sp is nil, the seed is stored in the database.
+func NewCustomWalletWithSeedProvider(cs modules.ConsensusSet, tpool modules.TransactionPool, persistDir string, deps modules.Dependencies, sp modules.SeedProvider) (*Wallet, error) {
 	// Check for nil dependencies.
 	if cs == nil {
 		return nil, errNilConsensusSet
@@ -211,6 +222,8 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		persistDir: persistDir,
 
 		deps: deps,
+
+		staticSeedProvider: sp,
 	}
 	err := w.initPersist()
 	if err != nil {
@@ -35,6 +35,10 @@ type Error struct {
 	// `err.Error()`. This field is required.
 	Message string `json:"message"`
 
+	// Code is a machine-readable code describing the cause of the error. If it
+	// isn't set, WriteError derives it from the http status.
+	Code ErrorCode `json:"code"`
+
 	// TODO: add a Param field with the (omitempty option in the json tag)
 	// to indicate that the error was caused by an invalid, missing, or
 	// incorrect parameter. This is not trivial as the API does not
@@ -112,6 +116,8 @@ type (
 		explorer            modules.Explorer
 		gateway             modules.Gateway
 		host                modules.Host
+		identity            modules.Identity
+		lightConsensus      modules.LightConsensusSet
 		miner               modules.Miner
 		renter              modules.Renter
 		tpool               modules.TransactionPool
@@ -129,7 +135,10 @@ type (
 		Shutdown          func() error
 		siadConfig        *modules.SiadConfig
 
-		staticStartTime time.Time
+		staticAuditLog    *auditLog
+		staticCORS        *corsConfig
+		staticRateLimiter *rateLimiter
+		staticStartTime   time.Time
 
 		staticDeps modules.Dependencies
 	}
@@ -142,6 +151,8 @@ type (
 		Explorer        bool `json:"explorer"`
 		Gateway         bool `json:"gateway"`
 		Host            bool `json:"host"`
+		Identity        bool `json:"identity"`
+		LightConsensus  bool `json:"lightconsensus"`
 		Miner           bool `json:"miner"`
 		Renter          bool `json:"renter"`
 		TransactionPool bool `json:"transactionpool"`
@@ -157,7 +168,7 @@ func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // SetModules allows for replacing the modules in the API at runtime.
-func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) {
+func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, id modules.Identity, ls modules.LightConsensusSet, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) {
 	if api.modulesSet {
 		build.Critical("can't call SetModules more than once")
 	}
@@ -166,6 +177,8 @@ func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e mo
 	api.explorer = e
 	api.gateway = g
 	api.host = h
+	api.identity = id
+	api.lightConsensus = ls
 	api.miner = m
 	api.renter = r
 	api.tpool = tp
@@ -176,6 +189,8 @@ func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e mo
 		Explorer:        api.explorer != nil,
 		Gateway:         api.gateway != nil,
 		Host:            api.host != nil,
+		Identity:        api.identity != nil,
+		LightConsensus:  api.lightConsensus != nil,
 		Miner:           api.miner != nil,
 		Renter:          api.renter != nil,
 		TransactionPool: api.tpool != nil,
@@ -218,8 +233,11 @@ func NewCustom(cfg *modules.SiadConfig, requiredUserAgent string, requiredPasswo
 		requiredPassword:  requiredPassword,
 		siadConfig:        cfg,
 
-		staticDeps:      deps,
-		staticStartTime: time.Now(),
+		staticAuditLog:    &auditLog{},
+		staticCORS:        &corsConfig{},
+		staticRateLimiter: &rateLimiter{},
+		staticDeps:        deps,
+		staticStartTime:   time.Now(),
 	}
 
 	// Register API handlers
@@ -233,15 +251,18 @@ func (api *API) UnrecognizedCallHandler(w http.ResponseWriter, _ *http.Request)
 	var errStr string
 	if api.modulesSet {
 		errStr = fmt.Sprintf("%d Module disabled - Refer to API.md", StatusModuleDisabled)
-		WriteError(w, Error{errStr}, StatusModuleDisabled)
+		WriteError(w, Error{Message: errStr}, StatusModuleDisabled)
 	} else {
 		errStr = fmt.Sprintf("%d Module not loaded - Refer to API.md", StatusModuleNotLoaded)
-		WriteError(w, Error{errStr}, StatusModuleNotLoaded)
+		WriteError(w, Error{Message: errStr}, StatusModuleNotLoaded)
 	}
 }
 
 // WriteError an error to the API caller.
 func WriteError(w http.ResponseWriter, err Error, code int) {
+	if err.Code == "" {
+		err.Code = statusErrorCode(code)
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(code)
 	encodingErr := json.NewEncoder(w).Encode(err)
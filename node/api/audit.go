@@ -0,0 +1,332 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+const (
+	// AuditLogFilename is the name of the file the audit log is written to.
+	// Rotated logs are suffixed with their index, e.g. audit.log.1.
+	AuditLogFilename = "audit.log"
+
+	// auditLogMaxRotatedFiles is the number of rotated audit log files that are
+	// kept on disk in addition to the active one.
+	auditLogMaxRotatedFiles = 5
+
+	// auditLogDefaultLimit is the number of entries returned by /daemon/audit
+	// if no limit is specified.
+	auditLogDefaultLimit = 100
+
+	// auditRedacted is the value that replaces redacted parameters in the
+	// audit log.
+	auditRedacted = "<redacted>"
+)
+
+var (
+	// auditLogMaxSize is the size in bytes at which the active audit log is
+	// rotated.
+	auditLogMaxSize = build.Select(build.Var{
+		Standard: int64(10 << 20), // 10 MiB
+		Dev:      int64(1 << 20),  // 1 MiB
+		Testing:  int64(4 << 10),  // 4 KiB
+	}).(int64)
+
+	// auditRedactedParams contains substrings of parameter names which might
+	// contain secrets and are therefore redacted in the audit log. They are
+	// deliberately broad, e.g. "key" also redacts public keys.
+	auditRedactedParams = []string{"key", "pass", "secret", "seed", "token"}
+
+	// auditedGETEndpoints contains the prefixes of the GET endpoints which
+	// change the state of the node and are therefore audited. All requests
+	// using a method other than GET, HEAD or OPTIONS are always audited.
+	auditedGETEndpoints = []string{"/daemon/stop", "/renter/download/", "/renter/downloadasync/"}
+
+	// errAuditLogDisabled is returned when the audit log is queried without
+	// being enabled.
+	errAuditLogDisabled = errors.New("the audit log is not enabled")
+)
+
+type (
+	// AuditEntry describes a single state-changing API call.
+	AuditEntry struct {
+		Timestamp  time.Time         `json:"timestamp"`
+		Method     string            `json:"method"`
+		Endpoint   string            `json:"endpoint"`
+		Params     map[string]string `json:"params,omitempty"`
+		User       string            `json:"user"`
+		RemoteAddr string            `json:"remoteaddr"`
+		StatusCode int               `json:"statuscode"`
+		Duration   time.Duration     `json:"duration"`
+	}
+
+	// DaemonAuditGET contains the entries of the audit log.
+	DaemonAuditGET struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+
+	// auditLog is a size-rotated log of AuditEntries which are persisted as
+	// JSON lines.
+	auditLog struct {
+		path string
+		mu   sync.Mutex
+	}
+
+	// auditResponseWriter is a http.ResponseWriter which remembers the status
+	// code of the response.
+	auditResponseWriter struct {
+		http.ResponseWriter
+		statusCode int
+	}
+)
+
+// WriteHeader implements http.ResponseWriter.
+func (w *auditResponseWriter) WriteHeader(code int) {
+	if w.statusCode == 0 {
+		w.statusCode = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter.
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// EnableAuditLog enables auditing of state-changing API calls. The audit log
+// is written to the provided directory.
+func (api *API) EnableAuditLog(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.AddContext(err, "failed to create audit log dir")
+	}
+	api.staticAuditLog.mu.Lock()
+	api.staticAuditLog.path = filepath.Join(dir, AuditLogFilename)
+	api.staticAuditLog.mu.Unlock()
+	return nil
+}
+
+// isAuditedRequest returns whether a request changes the state of the node
+// and therefore needs to be audited.
+func isAuditedRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet:
+		for _, prefix := range auditedGETEndpoints {
+			if strings.HasPrefix(req.URL.Path, prefix) {
+				return true
+			}
+		}
+		return false
+	case http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// auditParams returns the parameters of a request with all the secrets
+// redacted.
+func auditParams(values url.Values) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(values))
+	for key, vals := range values {
+		value := strings.Join(vals, ",")
+		lowerKey := strings.ToLower(key)
+		for _, redacted := range auditRedactedParams {
+			if strings.Contains(lowerKey, redacted) {
+				value = auditRedacted
+				break
+			}
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// withAudit is middleware that adds all state-changing requests to the audit
+// log once they are handled.
+func (al *auditLog) withAudit(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !al.enabled() || !isAuditedRequest(req) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		start := time.Now()
+		aw := &auditResponseWriter{ResponseWriter: w}
+		h.ServeHTTP(aw, req)
+
+		// Only use the parsed form if the handler parsed it already. Parsing
+		// it here would consume the body of streaming uploads.
+		values := req.Form
+		if values == nil {
+			values = req.URL.Query()
+		}
+		user, _, _ := req.BasicAuth()
+		statusCode := aw.statusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		entry := AuditEntry{
+			Timestamp:  start,
+			Method:     req.Method,
+			Endpoint:   req.URL.Path,
+			Params:     auditParams(values),
+			User:       user,
+			RemoteAddr: req.RemoteAddr,
+			StatusCode: statusCode,
+			Duration:   time.Since(start),
+		}
+		if err := al.managedAppend(entry); err != nil {
+			fmt.Fprintln(os.Stderr, "WARN: failed to write to audit log:", err)
+		}
+	})
+}
+
+// enabled returns whether the audit log was enabled.
+func (al *auditLog) enabled() bool {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return al.path != ""
+}
+
+// rotatedPath returns the path of the rotated log with the provided index.
+func (al *auditLog) rotatedPath(i int) string {
+	return fmt.Sprintf("%s.%d", al.path, i)
+}
+
+// rotate shifts all rotated logs by one index, dropping the oldest one, and
+// moves the active log to index 1.
+func (al *auditLog) rotate() error {
+	err := os.Remove(al.rotatedPath(auditLogMaxRotatedFiles))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := auditLogMaxRotatedFiles - 1; i > 0; i-- {
+		err := os.Rename(al.rotatedPath(i), al.rotatedPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(al.path, al.rotatedPath(1))
+}
+
+// managedAppend appends an entry to the audit log and rotates the log if it
+// grew too large.
+func (al *auditLog) managedAppend(entry AuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal audit entry")
+	}
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	f, err := os.OpenFile(al.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.AddContext(err, "failed to open audit log")
+	}
+	_, err = f.Write(append(b, '\n'))
+	err = errors.Compose(err, f.Sync())
+	if err != nil {
+		return errors.Compose(err, f.Close())
+	}
+	fi, err := f.Stat()
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return err
+	}
+	if fi.Size() < auditLogMaxSize {
+		return nil
+	}
+	return errors.AddContext(al.rotate(), "failed to rotate audit log")
+}
+
+// managedEntries returns the entries of the audit log, oldest first, which
+// were created at or after since and whose endpoint starts with the provided
+// prefix. At most limit of the most recent matching entries are returned.
+func (al *auditLog) managedEntries(since time.Time, endpointPrefix string, limit int) ([]AuditEntry, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if al.path == "" {
+		return nil, errAuditLogDisabled
+	}
+
+	paths := make([]string, 0, auditLogMaxRotatedFiles+1)
+	for i := auditLogMaxRotatedFiles; i > 0; i-- {
+		paths = append(paths, al.rotatedPath(i))
+	}
+	paths = append(paths, al.path)
+
+	entries := []AuditEntry{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.AddContext(err, "failed to open audit log")
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				return nil, errors.Compose(errors.AddContext(err, "failed to parse audit log"), f.Close())
+			}
+			if entry.Timestamp.Before(since) || !strings.HasPrefix(entry.Endpoint, endpointPrefix) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if err := errors.Compose(scanner.Err(), f.Close()); err != nil {
+			return nil, errors.AddContext(err, "failed to read audit log")
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// daemonAuditHandlerGET handles the API call to /daemon/audit.
+func (api *API) daemonAuditHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var since time.Time
+	if s := req.FormValue("since"); s != "" {
+		unix, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse since"), http.StatusBadRequest)
+			return
+		}
+		since = time.Unix(unix, 0)
+	}
+	limit := auditLogDefaultLimit
+	if l := req.FormValue("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			WriteError(w, Error{Message: fmt.Sprintf("unable to parse limit: %v", l)}, http.StatusBadRequest)
+			return
+		}
+	}
+	entries, err := api.staticAuditLog.managedEntries(since, req.FormValue("endpoint"), limit)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to read audit log"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, DaemonAuditGET{Entries: entries})
+}
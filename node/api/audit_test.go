@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+)
+
+// TestAuditParams probes the redaction of secrets by auditParams.
+func TestAuditParams(t *testing.T) {
+	t.Parallel()
+
+	values := url.Values{}
+	values.Set("encryptionpassword", "foo")
+	values.Set("newpassword", "foo")
+	values.Set("seed", "foo")
+	values.Set("privatekey", "foo")
+	values.Set("SkyKey", "foo")
+	values.Set("apitoken", "foo")
+	values.Set("clientsecret", "foo")
+	values.Set("period", "100")
+	values.Add("fields", "a")
+	values.Add("fields", "b")
+	params := auditParams(values)
+	for _, key := range []string{"encryptionpassword", "newpassword", "seed", "privatekey", "SkyKey", "apitoken", "clientsecret"} {
+		if params[key] != auditRedacted {
+			t.Errorf("%v wasn't redacted: %v", key, params[key])
+		}
+	}
+	if params["period"] != "100" {
+		t.Error("wrong period", params["period"])
+	}
+	if params["fields"] != "a,b" {
+		t.Error("wrong fields", params["fields"])
+	}
+	if auditParams(url.Values{}) != nil {
+		t.Error("expected nil params for empty values")
+	}
+}
+
+// TestAuditLog probes the auditing middleware as well as the rotation and
+// querying of the audit log.
+func TestAuditLog(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("api", t.Name())
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	api := &API{staticAuditLog: &auditLog{}}
+	handler := api.staticAuditLog.withAudit(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/fail") {
+			WriteError(w, Error{Message: "fail"}, http.StatusBadRequest)
+			return
+		}
+		_ = req.FormValue("foo")
+		WriteSuccess(w)
+	}))
+	request := func(method, endpoint, body string) {
+		req := httptest.NewRequest(method, endpoint, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("alice", "password")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Requests are not audited before the log is enabled.
+	request(http.MethodPost, "/renter", "foo=bar")
+	if _, err := api.staticAuditLog.managedEntries(time.Time{}, "", 0); err != errAuditLogDisabled {
+		t.Fatal("expected errAuditLogDisabled but got", err)
+	}
+	if err := api.EnableAuditLog(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only state-changing requests are audited.
+	request(http.MethodGet, "/renter", "")
+	request(http.MethodPost, "/renter", "foo=bar&seed=secret")
+	request(http.MethodGet, "/daemon/stop", "")
+	request(http.MethodPost, "/host/fail", "")
+	entries, err := api.staticAuditLog.managedEntries(time.Time{}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries but got %v", len(entries))
+	}
+	e := entries[0]
+	if e.Method != http.MethodPost || e.Endpoint != "/renter" || e.User != "alice" || e.StatusCode != http.StatusNoContent {
+		t.Fatal("wrong entry", e)
+	}
+	if e.Params["foo"] != "bar" || e.Params["seed"] != auditRedacted {
+		t.Fatal("wrong params", e.Params)
+	}
+	if entries[1].Endpoint != "/daemon/stop" || entries[2].StatusCode != http.StatusBadRequest {
+		t.Fatal("wrong entries", entries[1:])
+	}
+
+	// Filter by endpoint and limit.
+	entries, err = api.staticAuditLog.managedEntries(time.Time{}, "/host", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Endpoint != "/host/fail" {
+		t.Fatal("wrong entries", entries)
+	}
+	entries, err = api.staticAuditLog.managedEntries(time.Time{}, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Endpoint != "/host/fail" {
+		t.Fatal("wrong entries", entries)
+	}
+	entries, err = api.staticAuditLog.managedEntries(time.Now().Add(time.Hour), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatal("expected no entries", entries)
+	}
+
+	// Write enough entries to rotate the log multiple times. The number of
+	// entries on disk should be limited and the most recent entry should
+	// still be the last one.
+	for i := 0; i < 500; i++ {
+		request(http.MethodPost, "/renter", "foo=bar")
+	}
+	request(http.MethodPost, "/wallet", "")
+	if _, err := os.Stat(api.staticAuditLog.rotatedPath(auditLogMaxRotatedFiles)); err != nil {
+		t.Fatal("expected the log to be rotated", err)
+	}
+	if _, err := os.Stat(api.staticAuditLog.rotatedPath(auditLogMaxRotatedFiles + 1)); !os.IsNotExist(err) {
+		t.Fatal("expected old logs to be dropped", err)
+	}
+	entries, err = api.staticAuditLog.managedEntries(time.Time{}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) >= 503 {
+		t.Fatal("expected old entries to be dropped", len(entries))
+	}
+	if entries[len(entries)-1].Endpoint != "/wallet" {
+		t.Fatal("wrong last entry", entries[len(entries)-1])
+	}
+}
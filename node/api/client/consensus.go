@@ -2,16 +2,19 @@ package client
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
+	"golang.org/x/net/websocket"
 )
 
 // ConsensusGet requests the /consensus api resource
@@ -20,6 +23,69 @@ func (c *Client) ConsensusGet() (cg api.ConsensusGET, err error) {
 	return
 }
 
+// ConsensusAncestorGet requests the /consensus/ancestor api resource
+func (c *Client) ConsensusAncestorGet(a, b types.BlockID) (cag api.ConsensusAncestorGET, err error) {
+	err = c.get(fmt.Sprintf("/consensus/ancestor?a=%v&b=%v", a, b), &cag)
+	return
+}
+
+// ConsensusSnapshotPost writes a snapshot of the consensus database to dst on
+// the machine running siad and returns the checkpoint of the snapshot.
+func (c *Client) ConsensusSnapshotPost(dst string) (csp api.ConsensusSnapshotPOST, err error) {
+	values := url.Values{}
+	values.Set("destination", dst)
+	err = c.post("/consensus/snapshot", values.Encode(), &csp)
+	return
+}
+
+// ConsensusVerifyPost replays the stored diffs of the current path and
+// compares the result with the consensus state.
+func (c *Client) ConsensusVerifyPost() (cvp api.ConsensusVerifyPOST, err error) {
+	err = c.post("/consensus/verify", "", &cvp)
+	return
+}
+
+// ConsensusDiffsGet requests the /consensus/diffs/:height api resource
+func (c *Client) ConsensusDiffsGet(height types.BlockHeight) (cdg api.ConsensusDiffsGET, err error) {
+	err = c.get(fmt.Sprintf("/consensus/diffs/%d", height), &cdg)
+	return
+}
+
+// ConsensusForksGet requests the /consensus/forks api resource
+func (c *Client) ConsensusForksGet() (cfg api.ConsensusForksGET, err error) {
+	err = c.get("/consensus/forks", &cfg)
+	return
+}
+
+// ConsensusForkGet requests the /consensus/forks/:name api resource
+func (c *Client) ConsensusForkGet(name string) (cfg api.ConsensusForkGET, err error) {
+	err = c.get("/consensus/forks/"+name, &cfg)
+	return
+}
+
+// ConsensusStatsGet requests the /consensus/stats api resource for the window
+// blocks ending at the provided height.
+func (c *Client) ConsensusStatsGet(height, window types.BlockHeight) (csg api.ConsensusStatsGET, err error) {
+	values := url.Values{}
+	values.Set("height", fmt.Sprint(height))
+	values.Set("window", fmt.Sprint(window))
+	err = c.get("/consensus/stats?"+values.Encode(), &csg)
+	return
+}
+
+// ConsensusContractStatsGet requests the /consensus/contracts/stats api
+// resource
+func (c *Client) ConsensusContractStatsGet() (ccsg api.ConsensusContractStatsGET, err error) {
+	err = c.get("/consensus/contracts/stats", &ccsg)
+	return
+}
+
+// ConsensusSubscribersGet requests the /consensus/subscribers api resource
+func (c *Client) ConsensusSubscribersGet() (csg api.ConsensusSubscribersGET, err error) {
+	err = c.get("/consensus/subscribers", &csg)
+	return
+}
+
 // ConsensusBlocksIDGet requests the /consensus/blocks api resource
 func (c *Client) ConsensusBlocksIDGet(id types.BlockID) (cbg api.ConsensusBlocksGet, err error) {
 	err = c.get("/consensus/blocks?id="+id.String(), &cbg)
@@ -32,6 +98,38 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	return
 }
 
+// ConsensusBlocksDetailedGet requests the /consensus/blocks/:height api
+// resource with verbosity 2
+func (c *Client) ConsensusBlocksDetailedGet(height types.BlockHeight) (cbdg api.ConsensusBlocksDetailedGET, err error) {
+	err = c.get(fmt.Sprintf("/consensus/blocks/%d?verbosity=2", height), &cbdg)
+	return
+}
+
+// ConsensusChangesGet requests up to limit consensus changes which occurred
+// after the change with the provided ID from the /consensus/changes endpoint.
+// The returned cursor can be used to request the next batch.
+func (c *Client) ConsensusChangesGet(ccid modules.ConsensusChangeID, limit int) (batch modules.ConsensusChangeBatch, err error) {
+	_, body, err := c.getReaderResponse(fmt.Sprintf("/consensus/changes/%s?limit=%d", ccid, limit))
+	if err != nil {
+		return modules.ConsensusChangeBatch{}, err
+	}
+	defer drainAndClose(body)
+
+	if err := encoding.NewDecoder(body, encoding.DefaultAllocLimit).DecodeAll(&batch.Cursor, &batch.More); err != nil {
+		return modules.ConsensusChangeBatch{}, err
+	}
+	for {
+		dec := encoding.NewDecoder(body, 100e6) // consensus changes can be arbitrarily large
+		var cc modules.ConsensusChange
+		if err := dec.Decode(&cc); errors.Is(err, io.EOF) {
+			return batch, nil
+		} else if err != nil {
+			return modules.ConsensusChangeBatch{}, err
+		}
+		batch.Changes = append(batch.Changes, cc)
+	}
+}
+
 // ConsensusSubscribeSingle streams consensus changes from the
 // /consensus/subscribe endpoint to the provided subscriber. Multiple calls may
 // be required before the subscriber is fully caught up. It returns the latest
@@ -72,6 +170,57 @@ func (c *Client) ConsensusSubscribeSingle(subscriber modules.ConsensusSetSubscri
 	}
 }
 
+// ConsensusReorgsSubscribe connects to the /consensus/reorgs websocket
+// endpoint and sends every future reorg event to the subscriber until the
+// cancel channel is closed. The subscription is active once it returns. Any
+// error which ends the subscription is sent down the returned channel, or nil
+// if it was canceled.
+func (c *Client) ConsensusReorgsSubscribe(subscriber modules.ReorgSubscriber, cancel <-chan struct{}) (<-chan error, error) {
+	config, err := websocket.NewConfig("ws://"+c.Address+"/consensus/reorgs", "http://"+c.Address)
+	if err != nil {
+		return nil, err
+	}
+	agent := c.UserAgent
+	if agent == "" {
+		agent = "Sia-Agent"
+	}
+	config.Header.Set("User-Agent", agent)
+	if c.Password != "" {
+		config.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+c.Password)))
+	}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-cancel:
+		case <-done:
+		}
+		conn.Close()
+	}()
+	go func() {
+		defer close(done)
+		for {
+			var event modules.ReorgEvent
+			if err := websocket.JSON.Receive(conn, &event); err != nil {
+				select {
+				case <-cancel:
+					ch <- nil
+				default:
+					ch <- err
+				}
+				return
+			}
+			subscriber.ProcessReorg(event)
+		}
+	}()
+	return ch, nil
+}
+
 // ConsensusSetSubscribe polls the /consensus/subscribe endpoint, streaming
 // consensus changes to the subscriber indefinitely. First, it will stream
 // changes until the subscriber is fully caught up. It will send any error
@@ -3,6 +3,7 @@ package client
 import (
 	"net/url"
 	"strconv"
+	"time"
 
 	"go.sia.tech/siad/node/api"
 )
@@ -24,6 +25,12 @@ func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGet, err error) {
 	return
 }
 
+// DaemonCountersGet requests the /daemon/counters resource.
+func (c *Client) DaemonCountersGet() (dcg api.DaemonCountersGet, err error) {
+	err = c.get("/daemon/counters", &dcg)
+	return
+}
+
 // DaemonVersionGet requests the /daemon/version resource.
 func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
 	err = c.get("/daemon/version", &dvg)
@@ -74,3 +81,17 @@ func (c *Client) DaemonUpdatePost() (err error) {
 	err = c.post("/daemon/update", "", nil)
 	return
 }
+
+// DaemonAuditGet requests the /daemon/audit resource. Only entries created at
+// or after since for endpoints starting with the provided prefix are returned.
+// A limit of 0 returns all matching entries.
+func (c *Client) DaemonAuditGet(since time.Time, endpoint string, limit int) (dag api.DaemonAuditGET, err error) {
+	values := url.Values{}
+	if !since.IsZero() {
+		values.Set("since", strconv.FormatInt(since.Unix(), 10))
+	}
+	values.Set("endpoint", endpoint)
+	values.Set("limit", strconv.Itoa(limit))
+	err = c.get("/daemon/audit?"+values.Encode(), &dag)
+	return
+}
@@ -24,6 +24,37 @@ func (c *Client) GatewayBandwidthGet() (gbg api.GatewayBandwidthGET, err error)
 	return
 }
 
+// GatewayPeersGet requests the /gateway/peers api resource
+func (c *Client) GatewayPeersGet() (gpg api.GatewayPeersGET, err error) {
+	err = c.get("/gateway/peers", &gpg)
+	return
+}
+
+// GatewayDisconnectsGet requests the /gateway/disconnects api resource
+func (c *Client) GatewayDisconnectsGet() (gdg api.GatewayDisconnectsGET, err error) {
+	err = c.get("/gateway/disconnects", &gdg)
+	return
+}
+
+// GatewayBansGet requests the /gateway/bans api resource
+func (c *Client) GatewayBansGet() (gbg api.GatewayBansGET, err error) {
+	err = c.get("/gateway/bans", &gbg)
+	return
+}
+
+// GatewayBansRemovePost uses the /gateway/bans/remove/:host endpoint to lift
+// the ban of a peer.
+func (c *Client) GatewayBansRemovePost(host string) (err error) {
+	err = c.post("/gateway/bans/remove/"+host, "", nil)
+	return
+}
+
+// GatewayScoresGet requests the /gateway/scores api resource
+func (c *Client) GatewayScoresGet() (gsg api.GatewayScoresGET, err error) {
+	err = c.get("/gateway/scores", &gsg)
+	return
+}
+
 // GatewayConnectPost uses the /gateway/connect/:address endpoint to connect to
 // the gateway at address
 func (c *Client) GatewayConnectPost(address modules.NetAddress) (err error) {
@@ -58,6 +89,49 @@ func (c *Client) GatewayRateLimitPost(downloadSpeed, uploadSpeed int64) (err err
 	return
 }
 
+// GatewayPreferredAddressFamilyPost uses the /gateway endpoint to set the
+// address family the gateway prefers when dialing peers. An empty family
+// resets the preference.
+func (c *Client) GatewayPreferredAddressFamilyPost(family modules.AddressFamily) (err error) {
+	values := url.Values{}
+	values.Set("preferredaddressfamily", string(family))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayRequireEncryptionPost uses the /gateway endpoint to set whether the
+// gateway refuses plaintext sessions with peers which don't support
+// encryption.
+func (c *Client) GatewayRequireEncryptionPost(require bool) (err error) {
+	values := url.Values{}
+	values.Set("requireencryption", strconv.FormatBool(require))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayAllowlistGet uses the /gateway/allowlist endpoint to request the
+// Gateway's allowlist
+func (c *Client) GatewayAllowlistGet() (gag api.GatewayAllowlistGET, err error) {
+	err = c.get("/gateway/allowlist", &gag)
+	return
+}
+
+// GatewayAllowlistPost uses the /gateway/allowlist endpoint to set the
+// Gateway's allowlist and whether the gateway only connects to the peers on
+// it
+func (c *Client) GatewayAllowlistPost(enabled bool, addresses []string) (err error) {
+	gap := api.GatewayAllowlistPOST{
+		Enabled:   enabled,
+		Addresses: addresses,
+	}
+	data, err := json.Marshal(gap)
+	if err != nil {
+		return err
+	}
+	err = c.post("/gateway/allowlist", string(data), nil)
+	return
+}
+
 // GatewayBlocklistGet uses the /gateway/blocklist endpoint to request the
 // Gateway's blocklist
 func (c *Client) GatewayBlocklistGet() (gbg api.GatewayBlocklistGET, err error) {
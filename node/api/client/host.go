@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -62,12 +63,21 @@ const (
 	// HostParamMaxEphemeralAccountRisk is the maximum ephemeral account risk in
 	// hastings
 	HostParamMaxEphemeralAccountRisk = HostParam("maxephemeralaccountrisk")
+	// HostParamMaxEphemeralAccountSupply is the maximum aggregate balance of
+	// all ephemeral accounts in hastings
+	HostParamMaxEphemeralAccountSupply = HostParam("maxephemeralaccountsupply")
 	// HostParamRegistrySize is the preallocated size of the host's registry on
 	// disk.
 	HostParamRegistrySize = HostParam("registrysize")
 	// HostParamCustomRegistryPath is the locataion of the host's registry on
 	// disk.
 	HostParamCustomRegistryPath = HostParam("customregistrypath")
+	// HostParamSectorAuditLog indicates if the host records the sector
+	// accesses of renters.
+	HostParamSectorAuditLog = HostParam("sectorauditlog")
+	// HostParamIngressProtection indicates if the host requires an ingress
+	// proof for expensive RPCs.
+	HostParamIngressProtection = HostParam("ingressprotection")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -98,6 +108,16 @@ func (c *Client) HostContractGet(obligationID types.FileContractID) (cg api.Host
 	return
 }
 
+// HostContractsRecoverPost uses the /host/contracts/recover endpoint to
+// recover the host's storage obligations from the blockchain, starting at
+// startHeight.
+func (c *Client) HostContractsRecoverPost(startHeight types.BlockHeight) (hor modules.HostObligationRecovery, err error) {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(startHeight))
+	err = c.post("/host/contracts/recover", values.Encode(), &hor)
+	return
+}
+
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
 	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
@@ -117,12 +137,127 @@ func (c *Client) HostModifySettingPost(param HostParam, value interface{}) (err
 	return
 }
 
+// HostSettingsBandwidthGet requests the /host/settings/bandwidth api resource.
+func (c *Client) HostSettingsBandwidthGet() (hsbg api.HostSettingsBandwidthGET, err error) {
+	err = c.get("/host/settings/bandwidth", &hsbg)
+	return
+}
+
+// HostSettingsBandwidthPost uses the /host/settings/bandwidth endpoint to set
+// the host's bandwidth settings.
+func (c *Client) HostSettingsBandwidthPost(settings modules.HostBandwidthSettings) (err error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	err = c.post("/host/settings/bandwidth", string(data), nil)
+	return
+}
+
+// HostCapacityForecastGet requests the /host/capacityforecast api resource.
+func (c *Client) HostCapacityForecastGet() (hcfg api.HostCapacityForecastGET, err error) {
+	err = c.get("/host/capacityforecast", &hcfg)
+	return
+}
+
+// HostPayoutsGet requests the /host/payouts api resource.
+func (c *Client) HostPayoutsGet() (hpg api.HostPayoutsGET, err error) {
+	err = c.get("/host/payouts", &hpg)
+	return
+}
+
+// HostPayoutsHistoryGet requests the /host/payouts/history api resource.
+func (c *Client) HostPayoutsHistoryGet() (hphg api.HostPayoutsHistoryGET, err error) {
+	err = c.get("/host/payouts/history", &hphg)
+	return
+}
+
+// HostSettingsPayoutsGet requests the /host/settings/payouts api resource.
+func (c *Client) HostSettingsPayoutsGet() (hspg api.HostSettingsPayoutsGET, err error) {
+	err = c.get("/host/settings/payouts", &hspg)
+	return
+}
+
+// HostSettingsPayoutsPost uses the /host/settings/payouts endpoint to set the
+// host's payout address settings.
+func (c *Client) HostSettingsPayoutsPost(settings modules.HostPayoutSettings) (err error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	err = c.post("/host/settings/payouts", string(data), nil)
+	return
+}
+
+// HostCheckConnectivityGet requests the /host/checkconnectivity api resource.
+func (c *Client) HostCheckConnectivityGet() (hccg api.HostCheckConnectivityGET, err error) {
+	err = c.get("/host/checkconnectivity", &hccg)
+	return
+}
+
+// HostSectorAuditGet requests the /host/sectoraudit api resource.
+func (c *Client) HostSectorAuditGet(id types.FileContractID) (hsag api.HostSectorAuditGET, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	err = c.get("/host/sectoraudit?"+values.Encode(), &hsag)
+	return
+}
+
+// HostSectorAuditPurgePost uses the /host/sectoraudit/purge endpoint to
+// remove the recorded sector accesses of a contract.
+func (c *Client) HostSectorAuditPurgePost(id types.FileContractID) (err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	err = c.post("/host/sectoraudit/purge", values.Encode(), nil)
+	return
+}
+
+// HostSettingsHistoryGet requests the /host/settings/history api resource.
+func (c *Client) HostSettingsHistoryGet() (hshg api.HostSettingsHistoryGET, err error) {
+	err = c.get("/host/settings/history", &hshg)
+	return
+}
+
+// HostSettingsScheduleGet requests the /host/settings/schedule api resource.
+func (c *Client) HostSettingsScheduleGet() (hssg api.HostSettingsScheduleGET, err error) {
+	err = c.get("/host/settings/schedule", &hssg)
+	return
+}
+
+// HostSettingsSchedulePost uses the /host/settings/schedule endpoint to
+// schedule a change of the provided host settings at the given height.
+func (c *Client) HostSettingsSchedulePost(height types.BlockHeight, params map[HostParam]interface{}) (ss modules.HostScheduledSettings, err error) {
+	values := url.Values{}
+	values.Set("height", fmt.Sprint(height))
+	for param, value := range params {
+		values.Set(string(param), fmt.Sprint(value))
+	}
+	err = c.post("/host/settings/schedule", values.Encode(), &ss)
+	return
+}
+
+// HostSettingsScheduleCancelPost uses the /host/settings/schedule/cancel
+// endpoint to cancel a scheduled settings change.
+func (c *Client) HostSettingsScheduleCancelPost(id string) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	err = c.post("/host/settings/schedule/cancel", values.Encode(), nil)
+	return
+}
+
 // HostBandwidthGet requests the /host/bandwidth api resource
 func (c *Client) HostBandwidthGet() (gbg api.GatewayBandwidthGET, err error) {
 	err = c.get("/host/bandwidth", &gbg)
 	return
 }
 
+// HostStorageCompactPost uses the /host/storage/compact endpoint to compact
+// the sectors of the host.
+func (c *Client) HostStorageCompactPost() (hsc modules.HostSectorCompaction, err error) {
+	err = c.post("/host/storage/compact", "", &hsc)
+	return
+}
+
 // HostStorageFoldersAddPost uses the /host/storage/folders/add api endpoint to
 // add a storage folder to a host
 func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error) {
@@ -133,6 +268,16 @@ func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error)
 	return
 }
 
+// HostStorageFoldersRecoverPost uses the /host/storage/folders/recover api
+// endpoint to add an existing storage folder to a host and recover its
+// sectors.
+func (c *Client) HostStorageFoldersRecoverPost(path string) (sfrp api.StorageFoldersRecoverPOST, err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	err = c.post("/host/storage/folders/recover", values.Encode(), &sfrp)
+	return
+}
+
 // HostStorageFoldersRemovePost uses the /host/storage/folders/remove api
 // endpoint to remove a storage folder from a host.
 func (c *Client) HostStorageFoldersRemovePost(path string, force bool) (err error) {
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+)
+
+// IdentityGet requests the /identity api resource
+func (c *Client) IdentityGet() (ig api.IdentityGET, err error) {
+	err = c.get("/identity", &ig)
+	return
+}
+
+// IdentityRotatePost uses the /identity/rotate endpoint to replace the key for
+// the purpose with a new key.
+func (c *Client) IdentityRotatePost(purpose modules.KeyPurpose) (irp api.IdentityRotatePOST, err error) {
+	values := url.Values{}
+	values.Set("purpose", string(purpose))
+	err = c.post("/identity/rotate", values.Encode(), &irp)
+	return
+}
+
+// IdentityExportPost uses the /identity/export endpoint to export the keystore
+// encrypted with the password.
+func (c *Client) IdentityExportPost(password string) (iep api.IdentityExportPOST, err error) {
+	values := url.Values{}
+	values.Set("password", password)
+	err = c.post("/identity/export", values.Encode(), &iep)
+	return
+}
+
+// IdentityImportPost uses the /identity/import endpoint to import the keys of
+// an exported keystore. A different host key is only imported if
+// replaceHostKey is set.
+func (c *Client) IdentityImportPost(keystore, password string, replaceHostKey bool) error {
+	values := url.Values{}
+	values.Set("keystore", keystore)
+	values.Set("password", password)
+	values.Set("replacehostkey", strconv.FormatBool(replaceHostKey))
+	return c.post("/identity/import", values.Encode(), nil)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"encoding/json"
+
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
+)
+
+// LightConsensusGet requests the /consensus/light api resource
+func (c *Client) LightConsensusGet() (lcg api.LightConsensusGET, err error) {
+	err = c.get("/consensus/light", &lcg)
+	return
+}
+
+// LightConsensusWatchGet requests the /consensus/light/watch endpoint and
+// returns the set of currently watched addresses.
+func (c *Client) LightConsensusWatchGet() (lcwg api.LightConsensusWatchGET, err error) {
+	err = c.get("/consensus/light/watch", &lcwg)
+	return
+}
+
+// LightConsensusWatchAddPost uses the /consensus/light/watch endpoint to add a
+// set of addresses to the watched addresses. Their transactions are fetched
+// starting at the provided height.
+func (c *Client) LightConsensusWatchAddPost(addrs []types.UnlockHash, startHeight types.BlockHeight) error {
+	json, err := json.Marshal(api.LightConsensusWatchPOST{
+		Addresses:   addrs,
+		StartHeight: startHeight,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/consensus/light/watch", string(json), nil)
+}
+
+// LightConsensusWatchRemovePost uses the /consensus/light/watch endpoint to
+// remove a set of addresses from the watched addresses.
+func (c *Client) LightConsensusWatchRemovePost(addrs []types.UnlockHash) error {
+	json, err := json.Marshal(api.LightConsensusWatchPOST{
+		Addresses: addrs,
+		Remove:    true,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/consensus/light/watch", string(json), nil)
+}
+
+// LightConsensusTransactionsGet requests the /consensus/light/transactions
+// api resource
+func (c *Client) LightConsensusTransactionsGet() (lctg api.LightConsensusTransactionsGET, err error) {
+	err = c.get("/consensus/light/transactions", &lctg)
+	return
+}
+
+// LightConsensusOutputsGet requests the /consensus/light/outputs api resource
+func (c *Client) LightConsensusOutputsGet() (lcog api.LightConsensusOutputsGET, err error) {
+	err = c.get("/consensus/light/outputs", &lcog)
+	return
+}
+
+// LightConsensusFeeGet requests the /consensus/light/fee api resource
+func (c *Client) LightConsensusFeeGet() (lcfg api.LightConsensusFeeGET, err error) {
+	err = c.get("/consensus/light/fee", &lcfg)
+	return
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -12,6 +14,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
@@ -21,16 +24,25 @@ type (
 	// AllowanceRequestPost is a helper type to be able to build an allowance
 	// request.
 	AllowanceRequestPost struct {
-		c      *Client
-		sent   bool
-		values url.Values
+		c        *Client
+		resource string
+		sent     bool
+		values   url.Values
 	}
 )
 
 // RenterPostPartialAllowance starts an allowance request which can be extended
 // using its methods.
 func (c *Client) RenterPostPartialAllowance() *AllowanceRequestPost {
-	return &AllowanceRequestPost{c: c, values: make(url.Values)}
+	return &AllowanceRequestPost{c: c, resource: "/renter", values: make(url.Values)}
+}
+
+// RenterAllowanceProfilePartialPost starts a request to create or update an
+// allowance profile which can be extended using its methods.
+func (c *Client) RenterAllowanceProfilePartialPost(name string) *AllowanceRequestPost {
+	values := make(url.Values)
+	values.Set("name", name)
+	return &AllowanceRequestPost{c: c, resource: "/renter/allowanceprofiles", values: values}
 }
 
 // WithFunds adds the funds field to the request.
@@ -129,7 +141,7 @@ func (a *AllowanceRequestPost) Send() (err error) {
 		return errors.New("Error, request already sent")
 	}
 	a.sent = true
-	err = a.c.post("/renter", a.values.Encode(), nil)
+	err = a.c.post(a.resource, a.values.Encode(), nil)
 	return
 }
 
@@ -170,6 +182,48 @@ func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
 	return
 }
 
+// RenterContractSectorRootsGet uses the /renter/contract/sectorroots endpoint
+// to fetch the sector roots of a contract from its host.
+func (c *Client) RenterContractSectorRootsGet(id types.FileContractID) (rcsr api.RenterContractSectorRootsGET, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	err = c.get("/renter/contract/sectorroots?"+values.Encode(), &rcsr)
+	return
+}
+
+// RenterContractDelegatePost uses the /renter/contract/delegate endpoint to
+// authorize a delegate to download the sectors of a contract for the given
+// number of blocks.
+func (c *Client) RenterContractDelegatePost(id types.FileContractID, delegate types.SiaPublicKey, duration types.BlockHeight) (rcdp api.RenterContractDelegatePOST, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	values.Set("delegate", delegate.String())
+	values.Set("duration", fmt.Sprint(duration))
+	err = c.post("/renter/contract/delegate", values.Encode(), &rcdp)
+	return
+}
+
+// RenterDelegateKeyGet requests the /renter/delegatekey endpoint to fetch the
+// key which identifies the renter as a delegate.
+func (c *Client) RenterDelegateKeyGet() (rdkg api.RenterDelegateKeyGET, err error) {
+	err = c.get("/renter/delegatekey", &rdkg)
+	return
+}
+
+// RenterDelegatedReadPost uses the /renter/delegatedread endpoint to download
+// a sector of another renter's contract as a delegate.
+func (c *Client) RenterDelegatedReadPost(auth modules.DelegatedReadAuthorization, root crypto.Hash) ([]byte, error) {
+	data, err := json.Marshal(api.RenterDelegatedReadPOST{
+		Authorization: auth,
+		Root:          root,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, sector, err := c.postRawResponse("/renter/delegatedread", bytes.NewReader(data))
+	return sector, err
+}
+
 // RenterAllContractsGet requests the /renter/contracts resource with all
 // options set to true
 func (c *Client) RenterAllContractsGet() (rc api.RenterContracts, err error) {
@@ -289,6 +343,40 @@ func (c *Client) RenterDownloadGet(siaPath modules.SiaPath, destination string,
 	return modules.DownloadID(h.Get("ID")), nil
 }
 
+// RenterDownloadManifestGet uses the /renter/download endpoint to download a
+// file to a destination on disk and to store a manifest of the downloaded
+// data's hashes next to it.
+func (c *Client) RenterDownloadManifestGet(siaPath modules.SiaPath, destination string, offset, length uint64, async bool) (modules.DownloadID, error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("length", fmt.Sprint(length))
+	values.Set("async", fmt.Sprint(async))
+	values.Set("manifest", "true")
+	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return modules.DownloadID(h.Get("ID")), nil
+}
+
+// RenterDownloadVerifiedGet uses the /renter/download endpoint to download a
+// whole file to a destination on disk and to verify the downloaded data
+// against the file's checksum.
+func (c *Client) RenterDownloadVerifiedGet(siaPath modules.SiaPath, destination string, async bool) (modules.DownloadID, error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("async", fmt.Sprint(async))
+	values.Set("verifychecksum", "true")
+	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return modules.DownloadID(h.Get("ID")), nil
+}
+
 // RenterDownloadInfoGet uses the /renter/downloadinfo endpoint to fetch
 // information about a download from the history.
 func (c *Client) RenterDownloadInfoGet(uid modules.DownloadID) (di api.DownloadInfo, err error) {
@@ -328,6 +416,28 @@ func (c *Client) RenterRecoverBackupPost(name string) (err error) {
 	return
 }
 
+// RenterBackupContentsGet lists the files contained in the specified backup.
+func (c *Client) RenterBackupContentsGet(name string) (rbcg api.RenterBackupContentsGET, err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.get("/renter/backups/contents?"+values.Encode(), &rbcg)
+	return
+}
+
+// RenterRecoverBackupFilesPost downloads the specified backup and restores the
+// given files and directories from it.
+func (c *Client) RenterRecoverBackupFilesPost(name string, siaPaths []modules.SiaPath) (err error) {
+	strs := make([]string, 0, len(siaPaths))
+	for _, siaPath := range siaPaths {
+		strs = append(strs, siaPath.String())
+	}
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("siapaths", strings.Join(strs, ","))
+	err = c.post("/renter/backups/restorefiles", values.Encode(), nil)
+	return
+}
+
 // RenterCreateLocalBackupPost creates a local backup of the SiaFiles of the
 // renter.
 //
@@ -349,6 +459,73 @@ func (c *Client) RenterRecoverLocalBackupPost(src string) (err error) {
 	return
 }
 
+// RenterLegacyImportPost imports the files of a legacy .sia file at src into
+// the given directory.
+func (c *Client) RenterLegacyImportPost(src string, dir modules.SiaPath) (rlip api.RenterLegacyImportPOST, err error) {
+	values := url.Values{}
+	values.Set("source", src)
+	values.Set("siapath", dir.String())
+	err = c.post("/renter/legacyimport", values.Encode(), &rlip)
+	return
+}
+
+// RenterExportPost uses the /renter/export/:siapath endpoint to export the
+// siafile of a file to dst.
+func (c *Client) RenterExportPost(siaPath modules.SiaPath, dst string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", dst)
+	err = c.post("/renter/export/"+sp, values.Encode(), nil)
+	return
+}
+
+// RenterImportPost uses the /renter/import/:siapath endpoint to import an
+// exported siafile at src.
+func (c *Client) RenterImportPost(src string, siaPath modules.SiaPath, repair bool) (rsip api.RenterSiaFileImportPOST, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", src)
+	values.Set("repair", fmt.Sprint(repair))
+	err = c.post("/renter/import/"+sp, values.Encode(), &rsip)
+	return
+}
+
+// RenterFsckPost uses the /renter/fsck endpoint to check the consistency of
+// the renter's filesystem.
+func (c *Client) RenterFsckPost(repair, quarantine bool) (rfp api.RenterFsckPOST, err error) {
+	values := url.Values{}
+	values.Set("repair", fmt.Sprint(repair))
+	values.Set("quarantine", fmt.Sprint(quarantine))
+	err = c.post("/renter/fsck", values.Encode(), &rfp)
+	return
+}
+
+// RenterConvertPost uses the /renter/convert/:siapath endpoint to convert all
+// files within a directory to new erasure coding settings.
+func (c *Client) RenterConvertPost(siaPath modules.SiaPath, dataPieces, parityPieces uint64, maxSpeed int64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("maxspeed", strconv.FormatInt(maxSpeed, 10))
+	err = c.post("/renter/convert/"+sp, values.Encode(), nil)
+	return
+}
+
+// RenterConversionGet uses the /renter/conversion endpoint to get the
+// progress of the current or last directory conversion.
+func (c *Client) RenterConversionGet() (rdcg api.RenterDirConversionGET, err error) {
+	err = c.get("/renter/conversion", &rdcg)
+	return
+}
+
+// RenterConversionCancelPost uses the /renter/conversion/cancel endpoint to
+// cancel the current directory conversion.
+func (c *Client) RenterConversionCancelPost() (err error) {
+	err = c.post("/renter/conversion/cancel", "", nil)
+	return
+}
+
 // RenterDownloadFullGet uses the /renter/download endpoint to download a full
 // file.
 func (c *Client) RenterDownloadFullGet(siaPath modules.SiaPath, destination string, async, root bool) (modules.DownloadID, error) {
@@ -445,12 +622,81 @@ func (c *Client) RenterFileGet(siaPath modules.SiaPath) (rf api.RenterFile, err
 	return
 }
 
+// RenterFileLayoutGet uses the /renter/filelayout/:siapath endpoint to query
+// which hosts store which pieces of a file.
+func (c *Client) RenterFileLayoutGet(siaPath modules.SiaPath) (rfl api.RenterFileLayout, err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.get("/renter/filelayout/"+sp, &rfl)
+	return
+}
+
 // RenterFilesGet requests the /renter/files resource.
 func (c *Client) RenterFilesGet(cached bool) (rf api.RenterFiles, err error) {
 	err = c.get("/renter/files?cached="+fmt.Sprint(cached), &rf)
 	return
 }
 
+// RenterContractPricesGet requests the /renter/contractprices resource.
+func (c *Client) RenterContractPricesGet() (rcpg api.RenterContractPricesGET, err error) {
+	err = c.get("/renter/contractprices", &rcpg)
+	return
+}
+
+// RenterAllowanceProfilesGet requests the /renter/allowanceprofiles resource.
+func (c *Client) RenterAllowanceProfilesGet() (rapg api.RenterAllowanceProfilesGET, err error) {
+	err = c.get("/renter/allowanceprofiles", &rapg)
+	return
+}
+
+// RenterAllowanceProfilePost uses the /renter/allowanceprofiles endpoint to
+// create or update an allowance profile.
+func (c *Client) RenterAllowanceProfilePost(name string, allowance modules.Allowance) error {
+	a := c.RenterAllowanceProfilePartialPost(name)
+	a = a.WithFunds(allowance.Funds)
+	a = a.WithHosts(allowance.Hosts)
+	a = a.WithPeriod(allowance.Period)
+	a = a.WithRenewWindow(allowance.RenewWindow)
+	a = a.WithExpectedStorage(allowance.ExpectedStorage)
+	a = a.WithExpectedUpload(allowance.ExpectedUpload)
+	a = a.WithExpectedDownload(allowance.ExpectedDownload)
+	a = a.WithExpectedRedundancy(allowance.ExpectedRedundancy)
+	a = a.WithMaxPeriodChurn(allowance.MaxPeriodChurn)
+	return a.Send()
+}
+
+// RenterAllowanceProfileActivatePost uses the
+// /renter/allowanceprofiles/activate endpoint to replace the renter's
+// allowance with the allowance of a profile.
+func (c *Client) RenterAllowanceProfileActivatePost(name string) (err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.post("/renter/allowanceprofiles/activate", values.Encode(), nil)
+	return
+}
+
+// RenterAllowanceProfileDeletePost uses the /renter/allowanceprofiles/delete
+// endpoint to delete an allowance profile.
+func (c *Client) RenterAllowanceProfileDeletePost(name string) (err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.post("/renter/allowanceprofiles/delete", values.Encode(), nil)
+	return
+}
+
+// RenterAllowanceProfileDiffGet requests the /renter/allowanceprofiles/diff
+// resource.
+func (c *Client) RenterAllowanceProfileDiffGet(name string) (rapdg api.RenterAllowanceProfileDiffGET, err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.get("/renter/allowanceprofiles/diff?"+values.Encode(), &rapdg)
+	return
+}
+
+func (c *Client) RenterMigrationsGet() (rmg api.RenterMigrationsGET, err error) {
+	err = c.get("/renter/migrations", &rmg)
+	return
+}
+
 // RenterGet requests the /renter resource.
 func (c *Client) RenterGet() (rg api.RenterGET, err error) {
 	err = c.get("/renter", &rg)
@@ -608,6 +854,20 @@ func (c *Client) RenterUploadStreamPost(r io.Reader, siaPath modules.SiaPath, da
 	return err
 }
 
+// RenterUploadStreamChecksumPost uploads data using a stream and computes the
+// checksum of the data with the provided algorithm.
+func (c *Client) RenterUploadStreamChecksumPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, force bool, algorithm modules.ChecksumAlgorithm) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("stream", strconv.FormatBool(true))
+	values.Set("checksum", string(algorithm))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
 // RenterUploadStreamRepairPost a siafile using a stream. If the data provided
 // by r is not the same as the previously uploaded data, the data will be
 // corrupted.
@@ -620,6 +880,37 @@ func (c *Client) RenterUploadStreamRepairPost(r io.Reader, siaPath modules.SiaPa
 	return err
 }
 
+// RenterUploadURLPost uses the /renter/uploadurl endpoint to let the renter
+// fetch a file from a remote URL and upload it. The checksum is optional and
+// ignored if it is the empty string.
+func (c *Client) RenterUploadURLPost(rawURL string, siaPath modules.SiaPath, dataPieces, parityPieces, maxSize uint64, checksum string, force bool) (rup api.RenterUploadURLPOST, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("url", rawURL)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("maxsize", strconv.FormatUint(maxSize, 10))
+	values.Set("force", strconv.FormatBool(force))
+	if checksum != "" {
+		values.Set("sha256", checksum)
+	}
+	err = c.post(fmt.Sprintf("/renter/uploadurl/%s", sp), values.Encode(), &rup)
+	return
+}
+
+// RenterURLUploadInfoGet uses the /renter/uploadurlinfo endpoint to fetch
+// information about an upload from a URL given its id.
+func (c *Client) RenterURLUploadInfoGet(id modules.URLUploadID) (ui modules.URLUploadInfo, err error) {
+	err = c.get(fmt.Sprintf("/renter/uploadurlinfo/%s", id), &ui)
+	return
+}
+
+// RenterURLUploadsGet requests the /renter/uploadurls resource.
+func (c *Client) RenterURLUploadsGet() (rug api.RenterURLUploadsGET, err error) {
+	err = c.get("/renter/uploadurls", &rug)
+	return
+}
+
 // RenterDirCreatePost uses the /renter/dir/ endpoint to create a directory for the
 // renter
 func (c *Client) RenterDirCreatePost(siaPath modules.SiaPath) (err error) {
@@ -676,6 +967,31 @@ func (c *Client) RenterDirGet(siaPath modules.SiaPath) (rd api.RenterDirectory,
 	return
 }
 
+// RenterAliasGet uses the /renter/alias/ endpoint to query an alias.
+func (c *Client) RenterAliasGet(siaPath modules.SiaPath) (rag api.RenterAliasGET, err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.get(fmt.Sprintf("/renter/alias/%s", sp), &rag)
+	return
+}
+
+// RenterAliasCreatePost uses the /renter/alias/ endpoint to create an alias
+// at siaPath which points at target.
+func (c *Client) RenterAliasCreatePost(siaPath, target modules.SiaPath) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "create")
+	values.Set("target", target.String())
+	err = c.post(fmt.Sprintf("/renter/alias/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterAliasDeletePost uses the /renter/alias/ endpoint to delete an alias.
+func (c *Client) RenterAliasDeletePost(siaPath modules.SiaPath) (err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.post(fmt.Sprintf("/renter/alias/%s", sp), "action=delete", nil)
+	return
+}
+
 // RenterValidateSiaPathPost uses the /renter/validatesiapath endpoint to
 // validate a potential siapath
 //
@@ -733,6 +1049,198 @@ func (c *Client) RenterFuseUnmount(mount string) (err error) {
 	return
 }
 
+// RenterRestoreModeGet uses the /renter/restoremode endpoint to get the status
+// of the renter's restore mode.
+func (c *Client) RenterRestoreModeGet() (rm api.RenterRestoreModeGET, err error) {
+	err = c.get("/renter/restoremode", &rm)
+	return
+}
+
+// RenterRestoreModeStartPost uses the /renter/restoremode/start endpoint to
+// put the renter into restore mode for the provided dir.
+func (c *Client) RenterRestoreModeStartPost(siaPath modules.SiaPath) (err error) {
+	values := url.Values{}
+	values.Set("siapath", siaPath.String())
+	err = c.post("/renter/restoremode/start", values.Encode(), nil)
+	return
+}
+
+// RenterRestoreModeStopPost uses the /renter/restoremode/stop endpoint to take
+// the renter out of restore mode.
+func (c *Client) RenterRestoreModeStopPost() (err error) {
+	err = c.post("/renter/restoremode/stop", "", nil)
+	return
+}
+
+// RenterReadOnlyPost uses the /renter/readonly endpoint to enable or disable
+// the renter's read-only mode.
+func (c *Client) RenterReadOnlyPost(enabled bool) (err error) {
+	values := url.Values{}
+	values.Set("enabled", strconv.FormatBool(enabled))
+	err = c.post("/renter/readonly", values.Encode(), nil)
+	return
+}
+
+// RenterReplicationGet uses the /renter/replication endpoint to get the
+// status of the replication of a primary renter's metadata.
+func (c *Client) RenterReplicationGet() (rrg api.RenterReplicationGET, err error) {
+	err = c.get("/renter/replication", &rrg)
+	return
+}
+
+// RenterReplicationPromotePost uses the /renter/replication/promote endpoint
+// to promote a standby renter.
+func (c *Client) RenterReplicationPromotePost() (err error) {
+	err = c.post("/renter/replication/promote", "", nil)
+	return
+}
+
+// RenterReplicationStandbyPost uses the /renter/replication/standby endpoint
+// to make the renter a standby of the primary renter at address. password is
+// the API password of the primary.
+func (c *Client) RenterReplicationStandbyPost(address, password string) (err error) {
+	values := url.Values{}
+	values.Set("address", address)
+	values.Set("password", password)
+	err = c.post("/renter/replication/standby", values.Encode(), nil)
+	return
+}
+
+// RenterAccountCapsGet uses the /renter/accountcaps endpoint to get the caps
+// on the money the renter's workers may spend from ephemeral accounts.
+func (c *Client) RenterAccountCapsGet() (ac api.RenterAccountSpendingCapsGET, err error) {
+	err = c.get("/renter/accountcaps", &ac)
+	return
+}
+
+// RenterAccountCapsPost uses the /renter/accountcaps endpoint to set the
+// default spending cap and the window of the caps.
+func (c *Client) RenterAccountCapsPost(defaultCap types.Currency, window time.Duration) (err error) {
+	values := url.Values{}
+	values.Set("default", defaultCap.String())
+	values.Set("window", fmt.Sprint(uint64(window.Seconds())))
+	err = c.post("/renter/accountcaps", values.Encode(), nil)
+	return
+}
+
+// RenterAccountCapsHostPost uses the /renter/accountcaps endpoint to set the
+// spending cap of a single host.
+func (c *Client) RenterAccountCapsHostPost(hostKey types.SiaPublicKey, limit types.Currency) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	values.Set("cap", limit.String())
+	err = c.post("/renter/accountcaps", values.Encode(), nil)
+	return
+}
+
+// RenterAccountCapsHostRemovePost uses the /renter/accountcaps endpoint to
+// remove the spending cap of a single host, which makes the default cap apply
+// to the host again.
+func (c *Client) RenterAccountCapsHostRemovePost(hostKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	values.Set("remove", "true")
+	err = c.post("/renter/accountcaps", values.Encode(), nil)
+	return
+}
+
+// RenterConcurrencyGet uses the /renter/settings/concurrency endpoint to get
+// the configured and the effective concurrency limits of the renter.
+func (c *Client) RenterConcurrencyGet() (rc api.RenterConcurrencyGET, err error) {
+	err = c.get("/renter/settings/concurrency", &rc)
+	return
+}
+
+// RenterConcurrencyPost uses the /renter/settings/concurrency endpoint to set
+// the concurrency limits of the renter.
+func (c *Client) RenterConcurrencyPost(settings modules.RenterConcurrencySettings) (err error) {
+	values := url.Values{}
+	values.Set("repairuploadchunks", fmt.Sprint(settings.RepairUploadChunks))
+	values.Set("repairdownloads", fmt.Sprint(settings.RepairDownloads))
+	values.Set("healthcheckthreads", fmt.Sprint(settings.HealthCheckThreads))
+	values.Set("hostdbscanthreads", fmt.Sprint(settings.HostDBScanThreads))
+	err = c.post("/renter/settings/concurrency", values.Encode(), nil)
+	return
+}
+
+// RenterBandwidthGet uses the /renter/bandwidth endpoint to get the bandwidth
+// the renter used per category of traffic and the caps of the categories.
+func (c *Client) RenterBandwidthGet() (rb api.RenterBandwidthGET, err error) {
+	err = c.get("/renter/bandwidth", &rb)
+	return
+}
+
+// RenterBandwidthPost uses the /renter/bandwidth endpoint to set the bandwidth
+// cap of a category of the renter's traffic. A cap of 0 bytes removes the
+// category's cap.
+func (c *Client) RenterBandwidthPost(category modules.RenterBandwidthCategory, bc modules.RenterBandwidthCap) (err error) {
+	values := url.Values{}
+	values.Set("category", string(category))
+	values.Set("window", string(bc.Window))
+	values.Set("bytes", fmt.Sprint(bc.Bytes))
+	err = c.post("/renter/bandwidth", values.Encode(), nil)
+	return
+}
+
+// RenterPlacementGet uses the /renter/settings/placement endpoint to get the
+// anti-affinity constraints of the renter's piece placement.
+func (c *Client) RenterPlacementGet() (rp api.RenterPlacementGET, err error) {
+	err = c.get("/renter/settings/placement", &rp)
+	return
+}
+
+// RenterPlacementPost uses the /renter/settings/placement endpoint to set the
+// anti-affinity constraints of the renter's piece placement.
+func (c *Client) RenterPlacementPost(settings modules.RenterPlacementSettings) (err error) {
+	values := url.Values{}
+	values.Set("ipv4prefixlength", fmt.Sprint(settings.IPv4PrefixLength))
+	values.Set("ipv6prefixlength", fmt.Sprint(settings.IPv6PrefixLength))
+	err = c.post("/renter/settings/placement", values.Encode(), nil)
+	return
+}
+
+// RenterTimeoutsGet uses the /renter/settings/timeouts endpoint to get the
+// configured and the effective timeouts of the renter.
+func (c *Client) RenterTimeoutsGet() (rt api.RenterTimeoutsGET, err error) {
+	err = c.get("/renter/settings/timeouts", &rt)
+	return
+}
+
+// RenterTimeoutsPost uses the /renter/settings/timeouts endpoint to set the
+// timeouts of the renter. The timeouts are sent with a resolution of seconds.
+func (c *Client) RenterTimeoutsPost(settings modules.RenterTimeoutSettings) (err error) {
+	values := url.Values{}
+	for prefix, class := range map[string]modules.RenterOperationTimeouts{
+		"interactive": settings.Interactive,
+		"repair":      settings.Repair,
+		"background":  settings.Background,
+	} {
+		values.Set(prefix+"chunkdownload", fmt.Sprint(uint64(class.ChunkDownload.Seconds())))
+		values.Set(prefix+"job", fmt.Sprint(uint64(class.Job.Seconds())))
+		values.Set(prefix+"dial", fmt.Sprint(uint64(class.Dial.Seconds())))
+	}
+	err = c.post("/renter/settings/timeouts", values.Encode(), nil)
+	return
+}
+
+// RenterStreamCacheGet uses the /renter/streamcache endpoint to get the
+// settings and statistics of the renter's stream cache.
+func (c *Client) RenterStreamCacheGet() (sc api.RenterStreamCacheGET, err error) {
+	err = c.get("/renter/streamcache", &sc)
+	return
+}
+
+// RenterStreamCachePost uses the /renter/streamcache endpoint to set the
+// settings of the renter's stream cache.
+func (c *Client) RenterStreamCachePost(settings modules.StreamCacheSettings) (err error) {
+	values := url.Values{}
+	values.Set("maxbytes", fmt.Sprint(settings.MaxBytes))
+	values.Set("chunksperstream", fmt.Sprint(settings.ChunksPerStream))
+	values.Set("policy", settings.Policy)
+	err = c.post("/renter/streamcache", values.Encode(), nil)
+	return
+}
+
 // RenterUploadsPausePost uses the /renter/uploads/pause endpoint to pause the
 // renter's uploads and repairs
 func (c *Client) RenterUploadsPausePost(duration time.Duration) (err error) {
@@ -763,6 +1271,20 @@ func (c *Client) RenterWorkersGet() (wps modules.WorkerPoolStatus, err error) {
 	return
 }
 
+// RenterBenchmarkPost uses the /renter/benchmark endpoint to benchmark the
+// provided hosts. If no hosts are provided, all of the renter's hosts are
+// benchmarked.
+func (c *Client) RenterBenchmarkPost(hosts []types.SiaPublicKey) (rbp api.RenterBenchmarkPOST, err error) {
+	keys := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		keys = append(keys, host.String())
+	}
+	values := url.Values{}
+	values.Set("hosts", strings.Join(keys, ","))
+	err = c.post("/renter/benchmark", values.Encode(), &rbp)
+	return
+}
+
 // RenterBubblePost uses the /renter/bubble endpoint to manually trigger an
 // update to the directories metadata.
 func (c *Client) RenterBubblePost(siaPath modules.SiaPath, force, recursive bool) (err error) {
@@ -2,9 +2,11 @@ package client
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"net/url"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
 )
@@ -25,6 +27,34 @@ func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents []types.T
 	return
 }
 
+// TransactionPoolRawPackagePost uses the /tpool/raw endpoint to send a
+// package of dependent transactions to the transaction pool which is accepted
+// atomically.
+func (c *Client) TransactionPoolRawPackagePost(pkg []types.Transaction) (err error) {
+	values := url.Values{}
+	values.Set("package", base64.StdEncoding.EncodeToString(encoding.Marshal(pkg)))
+	err = c.post("/tpool/raw", values.Encode(), nil)
+	return
+}
+
+// TransactionPoolSettingsGet uses the /tpool/settings endpoint to get the
+// admission and relay policy of the tpool.
+func (c *Client) TransactionPoolSettingsGet() (tpsg api.TpoolSettingsGET, err error) {
+	err = c.get("/tpool/settings", &tpsg)
+	return
+}
+
+// TransactionPoolSettingsPost uses the /tpool/settings endpoint to set the
+// admission and relay policy of the tpool.
+func (c *Client) TransactionPoolSettingsPost(settings modules.TransactionPoolSettings) (err error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	err = c.post("/tpool/settings", string(data), nil)
+	return
+}
+
 // TransactionPoolTransactionsGet uses the /tpool/transactions endpoint to get the
 // transactions of the tpool
 func (c *Client) TransactionPoolTransactionsGet() (tptg api.TpoolTxnsGET, err error) {
@@ -97,6 +97,13 @@ func (c *Client) WalletGet() (wg api.WalletGET, err error) {
 	return
 }
 
+// WalletImmatureGet requests the /wallet/immature endpoint and returns the
+// immature siacoin outputs of the wallet and the projected spendable balance.
+func (c *Client) WalletImmatureGet() (wig api.WalletImmatureGET, err error) {
+	err = c.get("/wallet/immature", &wig)
+	return
+}
+
 // WalletLastAddressesGet returns the count last addresses generated by the
 // wallet in reverse order. That means the last generated address will be the
 // first one in the slice.
@@ -152,6 +159,13 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletBumpFeePost uses the /wallet/bumpfee/:txid endpoint to raise the fee
+// of an unconfirmed transaction.
+func (c *Client) WalletBumpFeePost(txid types.TransactionID) (wbp api.WalletBumpFeePOST, err error) {
+	err = c.post(fmt.Sprintf("/wallet/bumpfee/%v", txid), "", &wbp)
+	return
+}
+
 // WalletSignPost uses the /wallet/sign api endpoint to sign a transaction.
 func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
 	json, err := json.Marshal(api.WalletSignPOSTParams{
@@ -205,7 +219,7 @@ func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight
 // WalletTransactionGet requests the /wallet/transaction/:id api resource for a
 // certain TransactionID.
 func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTransactionGETid, err error) {
-	err = c.get("/wallet/transaction/"+id.String(), wtg)
+	err = c.get("/wallet/transaction/"+id.String(), &wtg)
 	return
 }
 
@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/big"
 	"net/http"
+	"path/filepath"
+	"sync"
 
 	"github.com/julienschmidt/httprouter"
+	"golang.org/x/net/websocket"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
@@ -26,6 +31,10 @@ type ConsensusGET struct {
 	Target       types.Target      `json:"target"`
 	Difficulty   types.Currency    `json:"difficulty"`
 
+	// StateCommitment is the commitment to the siacoin output, siafund
+	// output and file contract sets of the current block.
+	StateCommitment crypto.Hash `json:"statecommitment"`
+
 	// Foundation unlock hashes.
 	FoundationPrimaryUnlockHash  types.UnlockHash `json:"foundationprimaryunlockhash"`
 	FoundationFailsafeUnlockHash types.UnlockHash `json:"foundationfailsafeunlockhash"`
@@ -50,6 +59,108 @@ type ConsensusGET struct {
 	SiacoinPrecision types.Currency `json:"siacoinprecision"`
 }
 
+// ConsensusAncestorGET contains information about the ancestry of two blocks.
+type ConsensusAncestorGET struct {
+	IsAncestor           bool              `json:"isancestor"`
+	CommonAncestor       types.BlockID     `json:"commonancestor"`
+	CommonAncestorHeight types.BlockHeight `json:"commonancestorheight"`
+}
+
+// ConsensusSubscribersGET contains the processing statistics of the consensus
+// set's subscribers.
+type ConsensusSubscribersGET struct {
+	Subscribers []modules.ConsensusSubscriberStats `json:"subscribers"`
+}
+
+// ConsensusForksGET contains the status of the fork rules of the consensus
+// set.
+type ConsensusForksGET struct {
+	Rules []modules.ForkRuleStatus `json:"rules"`
+}
+
+// ConsensusForkGET contains the status of a single fork rule.
+type ConsensusForkGET struct {
+	modules.ForkRuleStatus
+}
+
+// ConsensusStatsGET contains the subsidy and fee statistics of a window of
+// blocks.
+type ConsensusStatsGET struct {
+	modules.ConsensusStats
+}
+
+// ConsensusContractStatsGET contains the statistics of the active file
+// contracts.
+type ConsensusContractStatsGET struct {
+	modules.ConsensusFileContractStats
+}
+
+// ConsensusDiffsGET contains the diffs which were created when the block at
+// Height was applied.
+type ConsensusDiffsGET struct {
+	Height                    types.BlockHeight                   `json:"height"`
+	BlockID                   types.BlockID                       `json:"blockid"`
+	SiacoinOutputDiffs        []ConsensusSiacoinOutputDiff        `json:"siacoinoutputdiffs"`
+	FileContractDiffs         []ConsensusFileContractDiff         `json:"filecontractdiffs"`
+	SiafundOutputDiffs        []ConsensusSiafundOutputDiff        `json:"siafundoutputdiffs"`
+	DelayedSiacoinOutputDiffs []ConsensusDelayedSiacoinOutputDiff `json:"delayedsiacoinoutputdiffs"`
+	SiafundPoolDiffs          []ConsensusSiafundPoolDiff          `json:"siafundpooldiffs"`
+}
+
+// ConsensusSiacoinOutputDiff is the API representation of a
+// modules.SiacoinOutputDiff. Direction is true if the output was created and
+// false if it was spent.
+type ConsensusSiacoinOutputDiff struct {
+	Direction     modules.DiffDirection `json:"direction"`
+	ID            types.SiacoinOutputID `json:"id"`
+	SiacoinOutput types.SiacoinOutput   `json:"siacoinoutput"`
+}
+
+// ConsensusFileContractDiff is the API representation of a
+// modules.FileContractDiff.
+type ConsensusFileContractDiff struct {
+	Direction    modules.DiffDirection `json:"direction"`
+	ID           types.FileContractID  `json:"id"`
+	FileContract types.FileContract    `json:"filecontract"`
+}
+
+// ConsensusSiafundOutputDiff is the API representation of a
+// modules.SiafundOutputDiff.
+type ConsensusSiafundOutputDiff struct {
+	Direction     modules.DiffDirection `json:"direction"`
+	ID            types.SiafundOutputID `json:"id"`
+	SiafundOutput types.SiafundOutput   `json:"siafundoutput"`
+}
+
+// ConsensusDelayedSiacoinOutputDiff is the API representation of a
+// modules.DelayedSiacoinOutputDiff.
+type ConsensusDelayedSiacoinOutputDiff struct {
+	Direction      modules.DiffDirection `json:"direction"`
+	ID             types.SiacoinOutputID `json:"id"`
+	SiacoinOutput  types.SiacoinOutput   `json:"siacoinoutput"`
+	MaturityHeight types.BlockHeight     `json:"maturityheight"`
+}
+
+// ConsensusSiafundPoolDiff is the API representation of a
+// modules.SiafundPoolDiff.
+type ConsensusSiafundPoolDiff struct {
+	Direction modules.DiffDirection `json:"direction"`
+	Previous  types.Currency        `json:"previous"`
+	Adjusted  types.Currency        `json:"adjusted"`
+}
+
+// ConsensusSnapshotPOST contains the checkpoint of a snapshot of the
+// consensus database.
+type ConsensusSnapshotPOST struct {
+	Checkpoint modules.ConsensusCheckpoint `json:"checkpoint"`
+}
+
+// ConsensusVerifyPOST contains the result of replaying the stored diffs of
+// the current path.
+type ConsensusVerifyPOST struct {
+	modules.ConsensusVerification
+}
+
 // ConsensusHeadersGET contains information from a blocks header.
 type ConsensusHeadersGET struct {
 	BlockID types.BlockID `json:"blockid"`
@@ -115,17 +226,108 @@ type ConsensusBlocksGetSiafundOutput struct {
 	UnlockHash types.UnlockHash      `json:"unlockhash"`
 }
 
+// ConsensusBlocksDetailedGET contains a block with the details of its
+// transactions, the IDs of the outputs it created and a summary of its diffs.
+type ConsensusBlocksDetailedGET struct {
+	ID           types.BlockID                     `json:"id"`
+	Height       types.BlockHeight                 `json:"height"`
+	ParentID     types.BlockID                     `json:"parentid"`
+	Nonce        types.BlockNonce                  `json:"nonce"`
+	Difficulty   types.Currency                    `json:"difficulty"`
+	Timestamp    types.Timestamp                   `json:"timestamp"`
+	MinerPayouts []ConsensusBlocksGetSiacoinOutput `json:"minerpayouts"`
+
+	// MaturityHeight is the height at which the miner payouts and the
+	// outputs of siafund claims and storage proofs become spendable.
+	MaturityHeight types.BlockHeight `json:"maturityheight"`
+
+	Transactions []ConsensusBlocksDetailedTxn `json:"transactions"`
+	DiffSummary  ConsensusBlockDiffSummary    `json:"diffsummary"`
+}
+
+// ConsensusBlocksDetailedTxn contains all fields of a ConsensusBlocksGetTxn
+// and the outputs its inputs and storage proofs resolve to.
+type ConsensusBlocksDetailedTxn struct {
+	ConsensusBlocksGetTxn
+
+	// Size is the encoded size of the transaction and Fees the sum of its
+	// miner fees.
+	Size uint64         `json:"size"`
+	Fees types.Currency `json:"fees"`
+
+	// SpentSiacoinOutputs and SpentSiafundOutputs are the outputs spent by
+	// the transaction's inputs, in the order of the inputs.
+	SpentSiacoinOutputs []ConsensusBlocksGetSiacoinOutput `json:"spentsiacoinoutputs"`
+	SpentSiafundOutputs []ConsensusBlocksGetSiafundOutput `json:"spentsiafundoutputs"`
+
+	// SiafundClaimOutputs are the delayed outputs created for the siafund
+	// claims of the transaction's siafund inputs and StorageProofOutputs the
+	// delayed valid proof outputs of the contracts proven by the transaction.
+	SiafundClaimOutputs []ConsensusBlocksGetSiacoinOutput `json:"siafundclaimoutputs"`
+	StorageProofOutputs []ConsensusBlocksGetSiacoinOutput `json:"storageproofoutputs"`
+}
+
+// ConsensusBlockDiffSummary contains the number of diffs of a block by type
+// and direction as well as the increase of the siafund pool.
+type ConsensusBlockDiffSummary struct {
+	SiacoinOutputsCreated        uint64         `json:"siacoinoutputscreated"`
+	SiacoinOutputsSpent          uint64         `json:"siacoinoutputsspent"`
+	SiafundOutputsCreated        uint64         `json:"siafundoutputscreated"`
+	SiafundOutputsSpent          uint64         `json:"siafundoutputsspent"`
+	FileContractsCreated         uint64         `json:"filecontractscreated"`
+	FileContractsRemoved         uint64         `json:"filecontractsremoved"`
+	DelayedSiacoinOutputsCreated uint64         `json:"delayedsiacoinoutputscreated"`
+	DelayedSiacoinOutputsMatured uint64         `json:"delayedsiacoinoutputsmatured"`
+	SiafundPoolIncrease          types.Currency `json:"siafundpoolincrease"`
+}
+
 // RegisterRoutesConsensus is a helper function to register all consensus routes.
-func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet) {
+func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet, requiredPassword string) {
 	router.GET("/consensus", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusHandler(cs, w, req, ps)
 	})
+	router.GET("/consensus/ancestor", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusAncestorHandler(cs, w, req, ps)
+	})
 	router.GET("/consensus/blocks", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusBlocksHandler(cs, w, req, ps)
 	})
+	router.GET("/consensus/blocks/:height", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusBlocksHeightHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/contracts/stats", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusContractStatsHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/diffs/:height", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusDiffsHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/forks", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusForksHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/forks/:name", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusForkHandler(cs, w, req, ps)
+	})
+	router.POST("/consensus/snapshot", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusSnapshotHandler(cs, w, req, ps)
+	}, requiredPassword))
+	router.GET("/consensus/reorgs", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusReorgsHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/stats", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusStatsHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/subscribers", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusSubscribersHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/changes/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusChangesHandler(cs, w, req, ps)
+	})
 	router.GET("/consensus/subscribe/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusSubscribeHandler(cs, w, req, ps)
 	})
+	router.POST("/consensus/verify", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusVerifyHandler(cs, w, req, ps)
+	}, requiredPassword))
 	router.POST("/consensus/validate/transactionset", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusValidateTransactionsetHandler(cs, w, req, ps)
 	})
@@ -215,19 +417,140 @@ func consensusBlocksGetFromBlock(b types.Block, h types.BlockHeight, d types.Cur
 	}
 }
 
+// consensusBlocksDetailedGetFromDiffs creates a ConsensusBlocksDetailedGET
+// object from a block and the diffs created when it was applied. The outputs
+// referenced by the block are resolved using the diffs.
+func consensusBlocksDetailedGetFromDiffs(b types.Block, d types.Currency, diffs modules.ConsensusBlockDiffs) ConsensusBlocksDetailedGET {
+	// Index the outputs and contracts of the diffs and summarize them.
+	var summary ConsensusBlockDiffSummary
+	spentSCOs := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	for _, scod := range diffs.SiacoinOutputDiffs {
+		if scod.Direction == modules.DiffApply {
+			summary.SiacoinOutputsCreated++
+			continue
+		}
+		summary.SiacoinOutputsSpent++
+		spentSCOs[scod.ID] = scod.SiacoinOutput
+	}
+	spentSFOs := make(map[types.SiafundOutputID]types.SiafundOutput)
+	for _, sfod := range diffs.SiafundOutputDiffs {
+		if sfod.Direction == modules.DiffApply {
+			summary.SiafundOutputsCreated++
+			continue
+		}
+		summary.SiafundOutputsSpent++
+		spentSFOs[sfod.ID] = sfod.SiafundOutput
+	}
+	removedFCs := make(map[types.FileContractID]types.FileContract)
+	for _, fcd := range diffs.FileContractDiffs {
+		if fcd.Direction == modules.DiffApply {
+			summary.FileContractsCreated++
+			continue
+		}
+		summary.FileContractsRemoved++
+		removedFCs[fcd.ID] = fcd.FileContract
+	}
+	delayedSCOs := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	for _, dscod := range diffs.DelayedSiacoinOutputDiffs {
+		if dscod.Direction == modules.DiffApply {
+			summary.DelayedSiacoinOutputsCreated++
+			delayedSCOs[dscod.ID] = dscod.SiacoinOutput
+			continue
+		}
+		summary.DelayedSiacoinOutputsMatured++
+	}
+	summary.SiafundPoolIncrease = types.ZeroCurrency
+	for _, sfpd := range diffs.SiafundPoolDiffs {
+		summary.SiafundPoolIncrease = summary.SiafundPoolIncrease.Add(sfpd.Adjusted.Sub(sfpd.Previous))
+	}
+	delayedOutput := func(id types.SiacoinOutputID) ConsensusBlocksGetSiacoinOutput {
+		sco := delayedSCOs[id]
+		return ConsensusBlocksGetSiacoinOutput{ID: id, Value: sco.Value, UnlockHash: sco.UnlockHash}
+	}
+
+	// Resolve the outputs referenced by the transactions.
+	cbg := consensusBlocksGetFromBlock(b, diffs.Height, d)
+	txns := make([]ConsensusBlocksDetailedTxn, 0, len(b.Transactions))
+	for i, t := range b.Transactions {
+		txn := ConsensusBlocksDetailedTxn{
+			ConsensusBlocksGetTxn: cbg.Transactions[i],
+			Size:                  uint64(t.MarshalSiaSize()),
+			Fees:                  types.ZeroCurrency,
+			SpentSiacoinOutputs:   make([]ConsensusBlocksGetSiacoinOutput, 0, len(t.SiacoinInputs)),
+			SpentSiafundOutputs:   make([]ConsensusBlocksGetSiafundOutput, 0, len(t.SiafundInputs)),
+			SiafundClaimOutputs:   make([]ConsensusBlocksGetSiacoinOutput, 0, len(t.SiafundInputs)),
+			StorageProofOutputs:   []ConsensusBlocksGetSiacoinOutput{},
+		}
+		for _, fee := range t.MinerFees {
+			txn.Fees = txn.Fees.Add(fee)
+		}
+		for _, sci := range t.SiacoinInputs {
+			sco := spentSCOs[sci.ParentID]
+			txn.SpentSiacoinOutputs = append(txn.SpentSiacoinOutputs, ConsensusBlocksGetSiacoinOutput{
+				ID:         sci.ParentID,
+				Value:      sco.Value,
+				UnlockHash: sco.UnlockHash,
+			})
+		}
+		for _, sfi := range t.SiafundInputs {
+			sfo := spentSFOs[sfi.ParentID]
+			txn.SpentSiafundOutputs = append(txn.SpentSiafundOutputs, ConsensusBlocksGetSiafundOutput{
+				ID:         sfi.ParentID,
+				Value:      sfo.Value,
+				UnlockHash: sfo.UnlockHash,
+			})
+			txn.SiafundClaimOutputs = append(txn.SiafundClaimOutputs, delayedOutput(sfi.ParentID.SiaClaimOutputID()))
+		}
+		for _, sp := range t.StorageProofs {
+			fc := removedFCs[sp.ParentID]
+			for j := range fc.ValidProofOutputs {
+				txn.StorageProofOutputs = append(txn.StorageProofOutputs, delayedOutput(sp.ParentID.StorageProofOutputID(types.ProofValid, uint64(j))))
+			}
+		}
+		txns = append(txns, txn)
+	}
+
+	// Resolve the IDs of the miner payouts.
+	payouts := make([]ConsensusBlocksGetSiacoinOutput, 0, len(b.MinerPayouts))
+	for i, mp := range b.MinerPayouts {
+		payouts = append(payouts, ConsensusBlocksGetSiacoinOutput{
+			ID:         b.MinerPayoutID(uint64(i)),
+			Value:      mp.Value,
+			UnlockHash: mp.UnlockHash,
+		})
+	}
+	return ConsensusBlocksDetailedGET{
+		ID:             cbg.ID,
+		Height:         cbg.Height,
+		ParentID:       cbg.ParentID,
+		Nonce:          cbg.Nonce,
+		Difficulty:     cbg.Difficulty,
+		Timestamp:      cbg.Timestamp,
+		MinerPayouts:   payouts,
+		MaturityHeight: diffs.Height + types.MaturityDelay,
+		Transactions:   txns,
+		DiffSummary:    summary,
+	}
+}
+
 // consensusHandler handles the API calls to /consensus.
 func consensusHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	height := cs.Height()
 	b, found := cs.BlockAtHeight(height)
 	if !found {
 		err := "Failed to fetch block for current height"
-		WriteError(w, Error{err}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: err}, http.StatusInternalServerError)
 		build.Critical(err)
 		return
 	}
 	cbid := b.ID()
 	currentTarget, _ := cs.ChildTarget(cbid)
 	primary, failsafe := cs.FoundationUnlockHashes()
+	commitment, err := cs.StateCommitment()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get state commitment"), http.StatusInternalServerError)
+		return
+	}
 	WriteJSON(w, ConsensusGET{
 		Synced:       cs.Synced(),
 		Height:       height,
@@ -235,6 +558,8 @@ func consensusHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Re
 		Target:       currentTarget,
 		Difficulty:   currentTarget.Difficulty(),
 
+		StateCommitment: commitment,
+
 		FoundationPrimaryUnlockHash:  primary,
 		FoundationFailsafeUnlockHash: failsafe,
 
@@ -264,11 +589,11 @@ func consensusBlocksHandler(cs modules.ConsensusSet, w http.ResponseWriter, req
 	// Get query params and check them.
 	id, height := req.FormValue("id"), req.FormValue("height")
 	if id != "" && height != "" {
-		WriteError(w, Error{"can't specify both id and height"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "can't specify both id and height"}, http.StatusBadRequest)
 		return
 	}
 	if id == "" && height == "" {
-		WriteError(w, Error{"either id or height has to be provided"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "either id or height has to be provided"}, http.StatusBadRequest)
 		return
 	}
 
@@ -280,7 +605,7 @@ func consensusBlocksHandler(cs modules.ConsensusSet, w http.ResponseWriter, req
 	if id != "" {
 		var bid types.BlockID
 		if err := bid.LoadString(id); err != nil {
-			WriteError(w, Error{"failed to unmarshal blockid"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "failed to unmarshal blockid"}, http.StatusBadRequest)
 			return
 		}
 		b, h, exists = cs.BlockByID(bid)
@@ -288,14 +613,14 @@ func consensusBlocksHandler(cs modules.ConsensusSet, w http.ResponseWriter, req
 	// Handle request by height
 	if height != "" {
 		if _, err := fmt.Sscan(height, &h); err != nil {
-			WriteError(w, Error{"failed to parse block height"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "failed to parse block height"}, http.StatusBadRequest)
 			return
 		}
 		b, exists = cs.BlockAtHeight(h)
 	}
 	// Check if block was found
 	if !exists {
-		WriteError(w, Error{"block doesn't exist"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "block doesn't exist"}, http.StatusBadRequest)
 		return
 	}
 
@@ -306,18 +631,230 @@ func consensusBlocksHandler(cs modules.ConsensusSet, w http.ResponseWriter, req
 	WriteJSON(w, consensusBlocksGetFromBlock(b, h, d))
 }
 
+// consensusBlocksHeightHandler handles the API calls to
+// /consensus/blocks/:height. Verbosity 1 returns the block like
+// /consensus/blocks, verbosity 2 adds the details of its transactions.
+func consensusBlocksHeightHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var height types.BlockHeight
+	if _, err := fmt.Sscan(ps.ByName("height"), &height); err != nil {
+		WriteError(w, Error{Message: "failed to parse block height"}, http.StatusBadRequest)
+		return
+	}
+	verbosity := 1
+	if v := req.FormValue("verbosity"); v != "" {
+		if _, err := fmt.Sscan(v, &verbosity); err != nil || verbosity < 1 || verbosity > 2 {
+			WriteError(w, Error{Message: "verbosity must be 1 or 2"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if verbosity == 1 {
+		b, exists := cs.BlockAtHeight(height)
+		if !exists {
+			WriteError(w, Error{Message: "block doesn't exist", Code: ErrorCodeNotFound}, http.StatusBadRequest)
+			return
+		}
+		target, _ := cs.ChildTarget(b.ID())
+		WriteJSON(w, consensusBlocksGetFromBlock(b, height, target.Difficulty()))
+		return
+	}
+
+	// Look up the block by the ID of the diffs to make sure that the block
+	// and its diffs match even if the current path changes in between.
+	diffs, err := cs.BlockDiffs(height)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get block diffs"), http.StatusBadRequest)
+		return
+	}
+	b, _, exists := cs.BlockByID(diffs.BlockID)
+	if !exists {
+		WriteError(w, Error{Message: "block doesn't exist", Code: ErrorCodeNotFound}, http.StatusBadRequest)
+		return
+	}
+	target, _ := cs.ChildTarget(b.ID())
+	WriteJSON(w, consensusBlocksDetailedGetFromDiffs(b, target.Difficulty(), diffs))
+}
+
+// consensusAncestorHandler handles the API calls to /consensus/ancestor
+// endpoint.
+func consensusAncestorHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var a, b types.BlockID
+	if err := a.LoadString(req.FormValue("a")); err != nil {
+		WriteError(w, Error{Message: "failed to unmarshal blockid a"}, http.StatusBadRequest)
+		return
+	}
+	if err := b.LoadString(req.FormValue("b")); err != nil {
+		WriteError(w, Error{Message: "failed to unmarshal blockid b"}, http.StatusBadRequest)
+		return
+	}
+	isAncestor, err := cs.IsAncestor(a, b)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to check ancestry"), http.StatusBadRequest)
+		return
+	}
+	id, height, err := cs.CommonAncestor(a, b)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to find common ancestor"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusAncestorGET{
+		IsAncestor:           isAncestor,
+		CommonAncestor:       id,
+		CommonAncestorHeight: height,
+	})
+}
+
+// consensusSnapshotHandler handles the API calls to /consensus/snapshot.
+func consensusSnapshotHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that destination was specified.
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{Message: "destination not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The destination needs to be an absolute path.
+	if !filepath.IsAbs(dst) {
+		WriteError(w, Error{Message: "destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	cp, err := cs.SnapshotDB(dst)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to create consensus snapshot"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, ConsensusSnapshotPOST{Checkpoint: cp})
+}
+
+// consensusVerifyHandler handles the API calls to /consensus/verify.
+func consensusVerifyHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	cv, err := cs.VerifyConsensus()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to verify consensus"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, ConsensusVerifyPOST{cv})
+}
+
+// consensusStatsHandler handles the API calls to /consensus/stats.
+func consensusStatsHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Default to the current height and a window of one day.
+	height := cs.Height()
+	window := types.BlocksPerDay
+	if h := req.FormValue("height"); h != "" {
+		if _, err := fmt.Sscan(h, &height); err != nil {
+			WriteError(w, Error{Message: "failed to parse block height"}, http.StatusBadRequest)
+			return
+		}
+	}
+	if wnd := req.FormValue("window"); wnd != "" {
+		if _, err := fmt.Sscan(wnd, &window); err != nil {
+			WriteError(w, Error{Message: "failed to parse window"}, http.StatusBadRequest)
+			return
+		}
+	}
+	stats, err := cs.BlockStats(height, window)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to compute block statistics"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusStatsGET{stats})
+}
+
+// consensusContractStatsHandler handles the API calls to
+// /consensus/contracts/stats.
+func consensusContractStatsHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	stats, err := cs.FileContractStats()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get file contract statistics"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, ConsensusContractStatsGET{stats})
+}
+
+// consensusDiffsGetFromDiffs converts the diffs of a block into their API
+// representation.
+func consensusDiffsGetFromDiffs(diffs modules.ConsensusBlockDiffs) ConsensusDiffsGET {
+	cdg := ConsensusDiffsGET{
+		Height:                    diffs.Height,
+		BlockID:                   diffs.BlockID,
+		SiacoinOutputDiffs:        make([]ConsensusSiacoinOutputDiff, 0, len(diffs.SiacoinOutputDiffs)),
+		FileContractDiffs:         make([]ConsensusFileContractDiff, 0, len(diffs.FileContractDiffs)),
+		SiafundOutputDiffs:        make([]ConsensusSiafundOutputDiff, 0, len(diffs.SiafundOutputDiffs)),
+		DelayedSiacoinOutputDiffs: make([]ConsensusDelayedSiacoinOutputDiff, 0, len(diffs.DelayedSiacoinOutputDiffs)),
+		SiafundPoolDiffs:          make([]ConsensusSiafundPoolDiff, 0, len(diffs.SiafundPoolDiffs)),
+	}
+	for _, d := range diffs.SiacoinOutputDiffs {
+		cdg.SiacoinOutputDiffs = append(cdg.SiacoinOutputDiffs, ConsensusSiacoinOutputDiff(d))
+	}
+	for _, d := range diffs.FileContractDiffs {
+		cdg.FileContractDiffs = append(cdg.FileContractDiffs, ConsensusFileContractDiff(d))
+	}
+	for _, d := range diffs.SiafundOutputDiffs {
+		cdg.SiafundOutputDiffs = append(cdg.SiafundOutputDiffs, ConsensusSiafundOutputDiff(d))
+	}
+	for _, d := range diffs.DelayedSiacoinOutputDiffs {
+		cdg.DelayedSiacoinOutputDiffs = append(cdg.DelayedSiacoinOutputDiffs, ConsensusDelayedSiacoinOutputDiff(d))
+	}
+	for _, d := range diffs.SiafundPoolDiffs {
+		cdg.SiafundPoolDiffs = append(cdg.SiafundPoolDiffs, ConsensusSiafundPoolDiff(d))
+	}
+	return cdg
+}
+
+// consensusDiffsHandler handles the API calls to /consensus/diffs/:height.
+func consensusDiffsHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var height types.BlockHeight
+	if _, err := fmt.Sscan(ps.ByName("height"), &height); err != nil {
+		WriteError(w, Error{Message: "failed to parse block height"}, http.StatusBadRequest)
+		return
+	}
+	diffs, err := cs.BlockDiffs(height)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get block diffs"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, consensusDiffsGetFromDiffs(diffs))
+}
+
+// consensusForksHandler handles the API calls to /consensus/forks.
+func consensusForksHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	rules, err := cs.ForkRules()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get fork rules"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, ConsensusForksGET{Rules: rules})
+}
+
+// consensusForkHandler handles the API calls to /consensus/forks/:name.
+func consensusForkHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	rule, err := cs.ForkRule(ps.ByName("name"))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get fork rule"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusForkGET{ForkRuleStatus: rule})
+}
+
+// consensusSubscribersHandler handles the API calls to /consensus/subscribers.
+func consensusSubscribersHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, ConsensusSubscribersGET{
+		Subscribers: cs.SubscriberStats(),
+	})
+}
+
 // consensusValidateTransactionsetHandler handles the API calls to
 // /consensus/validate/transactionset.
 func consensusValidateTransactionsetHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var txnset []types.Transaction
 	err := json.NewDecoder(req.Body).Decode(&txnset)
 	if err != nil {
-		WriteError(w, Error{"could not decode transaction set: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "could not decode transaction set"), http.StatusBadRequest)
 		return
 	}
 	_, err = cs.TryTransactionSet(txnset)
 	if err != nil {
-		WriteError(w, Error{"transaction set validation failed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "transaction set validation failed"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -328,7 +865,7 @@ func consensusValidateTransactionsetHandler(cs modules.ConsensusSet, w http.Resp
 func consensusSubscribeHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	var ccid modules.ConsensusChangeID
 	if err := (*crypto.Hash)(&ccid).LoadString(ps.ByName("id")); err != nil {
-		WriteError(w, Error{"could not decode ID: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "could not decode ID"), http.StatusBadRequest)
 		return
 	}
 
@@ -346,6 +883,108 @@ func consensusSubscribeHandler(cs modules.ConsensusSet, w http.ResponseWriter, r
 	}
 }
 
+// consensusChangesHandler handles the API calls to the /consensus/changes
+// endpoint. The response is Sia-encoded: the cursor and whether there are more
+// changes, followed by the consensus changes of the batch.
+func consensusChangesHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var ccid modules.ConsensusChangeID
+	if err := (*crypto.Hash)(&ccid).LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, NewErrorWithContext(err, "could not decode ID"), http.StatusBadRequest)
+		return
+	}
+	var limit int
+	if l := req.FormValue("limit"); l != "" {
+		if _, err := fmt.Sscan(l, &limit); err != nil {
+			WriteError(w, NewErrorWithContext(err, "could not decode limit"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	batch, err := cs.ConsensusChangesSince(ccid, limit)
+	if errors.Contains(err, modules.ErrInvalidConsensusChangeID) {
+		WriteError(w, NewErrorWithContext(err, "unknown consensus change ID"), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get consensus changes"), http.StatusInternalServerError)
+		return
+	}
+	e := encoding.NewEncoder(w)
+	if err := e.EncodeAll(batch.Cursor, batch.More); err != nil {
+		return
+	}
+	for _, cc := range batch.Changes {
+		if err := e.Encode(cc); err != nil {
+			return
+		}
+	}
+}
+
+// consensusReorgsHandler handles the API calls to the /consensus/reorgs
+// websocket endpoint. Every reorg of the consensus set is sent to the client as
+// a JSON message until the client closes the connection.
+func consensusReorgsHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	rs := newReorgStreamer()
+	if err := cs.ReorgSubscribe(rs); err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to subscribe to reorgs"), http.StatusInternalServerError)
+		return
+	}
+	defer cs.ReorgUnsubscribe(rs)
+
+	// websocket.Server is used instead of websocket.Handler since the latter
+	// rejects clients which don't send an Origin header.
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		// The client doesn't send any messages, reading only detects when it
+		// closes the connection.
+		closed := make(chan struct{})
+		go func() {
+			_, _ = io.Copy(ioutil.Discard, conn)
+			close(closed)
+		}()
+		for {
+			select {
+			case event := <-rs.events:
+				if err := websocket.JSON.Send(conn, event); err != nil {
+					return
+				}
+			case <-rs.lagging:
+				return
+			case <-closed:
+				return
+			}
+		}
+	}}.ServeHTTP(w, req)
+}
+
+// reorgStreamBuffer is the number of reorg events which are buffered for a
+// websocket client before its connection is closed.
+const reorgStreamBuffer = 16
+
+// reorgStreamer is a reorg subscriber which forwards the reorg events to a
+// websocket client.
+type reorgStreamer struct {
+	events      chan modules.ReorgEvent
+	lagging     chan struct{}
+	laggingOnce sync.Once
+}
+
+// ProcessReorg implements modules.ReorgSubscriber. The consensus set must not
+// be blocked by a slow client, so the connection of a client which doesn't keep
+// up is closed instead.
+func (rs *reorgStreamer) ProcessReorg(event modules.ReorgEvent) {
+	select {
+	case rs.events <- event:
+	default:
+		rs.laggingOnce.Do(func() { close(rs.lagging) })
+	}
+}
+
+func newReorgStreamer() *reorgStreamer {
+	return &reorgStreamer{
+		events:  make(chan modules.ReorgEvent, reorgStreamBuffer),
+		lagging: make(chan struct{}),
+	}
+}
+
 type consensusChangeStreamer struct {
 	e *encoding.Encoder
 }
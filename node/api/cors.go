@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// corsWildcard is the allowed origin which allows any origin.
+	corsWildcard = "*"
+
+	// corsMaxAge is the amount of time browsers may cache the result of a
+	// preflight request.
+	corsMaxAge = 10 * time.Minute
+)
+
+var (
+	// corsAllowedMethods are the methods browsers may use to access the API.
+	corsAllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodOptions}
+
+	// corsDefaultHeaders are the request headers which are always allowed in
+	// addition to the configured ones. They are required for authentication,
+	// posting forms and streaming.
+	corsDefaultHeaders = []string{"Authorization", "Content-Type", "Range"}
+
+	// corsExposedHeaders are the response headers which browsers expose to
+	// the scripts of an allowed origin.
+	corsExposedHeaders = []string{"Accept-Ranges", "Content-Disposition", "Content-Length", "Content-Range"}
+
+	// ErrCORSWildcardCredentials is returned by CORSSettings.Validate if
+	// credentials are allowed for any origin.
+	ErrCORSWildcardCredentials = errors.New("credentials can't be allowed for the '*' origin")
+)
+
+type (
+	// CORSSettings configures which browser origins may access the API.
+	CORSSettings struct {
+		// AllowedOrigins are the origins which may access the API. An origin
+		// of "*" allows any origin.
+		AllowedOrigins []string
+
+		// AllowedHeaders are request headers which are allowed in addition
+		// to the Authorization, Content-Type and Range headers.
+		AllowedHeaders []string
+
+		// AllowCredentials allows browsers to send credentials such as the
+		// API password along with cross-origin requests. It can't be combined
+		// with the "*" origin.
+		AllowCredentials bool
+	}
+
+	// corsConfig applies the API's CORS settings to requests.
+	corsConfig struct {
+		settings CORSSettings
+		mu       sync.Mutex
+	}
+)
+
+// EnableCORS enables cross-origin requests from the origins of the provided
+// settings. Requests from allowed origins still need to set the required user
+// agent.
+func (api *API) EnableCORS(settings CORSSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	api.staticCORS.mu.Lock()
+	api.staticCORS.settings = settings
+	api.staticCORS.mu.Unlock()
+	return nil
+}
+
+// Validate returns an error if the settings would allow any origin to send
+// credentials to the API.
+func (s CORSSettings) Validate() error {
+	if s.AllowCredentials && s.allowedOrigin(corsWildcard) {
+		return ErrCORSWildcardCredentials
+	}
+	return nil
+}
+
+// allowedOrigin returns whether the origin is allowed to access the API.
+func (s CORSSettings) allowedOrigin(origin string) bool {
+	for _, allowed := range s.AllowedOrigins {
+		if allowed == corsWildcard || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedHeaders returns the request headers allowed by the settings.
+func (s CORSSettings) allowedHeaders() []string {
+	return append(append([]string{}, corsDefaultHeaders...), s.AllowedHeaders...)
+}
+
+// withCORS sets the CORS headers of requests from allowed origins and answers
+// their preflight requests.
+func (c *corsConfig) withCORS(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.mu.Lock()
+		settings := c.settings
+		c.mu.Unlock()
+
+		origin := req.Header.Get("Origin")
+		if origin == "" || len(settings.AllowedOrigins) == 0 {
+			h.ServeHTTP(w, req)
+			return
+		}
+		preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+		if !settings.allowedOrigin(origin) {
+			if preflight {
+				WriteError(w, Error{Message: "origin not allowed"}, http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		// Validate ensures that credentials are never allowed together with
+		// the wildcard.
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if settings.allowedOrigin(corsWildcard) {
+			header.Set("Access-Control-Allow-Origin", corsWildcard)
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if settings.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		// Answer preflight requests without passing them on to the router.
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", strings.Join(corsAllowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(settings.allowedHeaders(), ", "))
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		header.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		h.ServeHTTP(w, req)
+	})
+}
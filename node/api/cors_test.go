@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCORS probes the CORS middleware of the API.
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	cors := &corsConfig{}
+	handler := cors.withCORS(RequireUserAgent(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), "Sia-Agent"))
+	request := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/renter/files", nil)
+		req.Header.Set("User-Agent", "Sia-Agent")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Without any allowed origins requests are passed through unchanged.
+	rec := request(http.MethodGet, "https://example.com")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("unexpected response", rec.Code, rec.Header())
+	}
+
+	// Allow a single origin.
+	cors.settings = CORSSettings{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedHeaders: []string{"X-Custom"},
+	}
+	rec = request(http.MethodOptions, "https://EXAMPLE.com")
+	if rec.Code != http.StatusNoContent {
+		t.Fatal("unexpected preflight status", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://EXAMPLE.com" {
+		t.Fatal("unexpected allowed origin", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type, Range, X-Custom" {
+		t.Fatal("unexpected allowed headers", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("credentials shouldn't be allowed")
+	}
+	rec = request(http.MethodGet, "https://example.com")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Fatal("unexpected response", rec.Code, rec.Header())
+	}
+
+	// Allowed origins still need to set the user agent.
+	req := httptest.NewRequest(http.MethodGet, "/renter/files", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatal("request without user agent was accepted", rec.Code)
+	}
+
+	// Other origins are rejected on preflight and passed through otherwise.
+	rec = request(http.MethodOptions, "https://evil.com")
+	if rec.Code != http.StatusForbidden {
+		t.Fatal("unexpected preflight status", rec.Code)
+	}
+	rec = request(http.MethodGet, "https://evil.com")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("unexpected response", rec.Code, rec.Header())
+	}
+	rec = request(http.MethodGet, "")
+	if rec.Code != http.StatusOK {
+		t.Fatal("unexpected status", rec.Code)
+	}
+
+	// The wildcard allows any origin but can't be combined with credentials.
+	cors.settings = CORSSettings{AllowedOrigins: []string{corsWildcard}}
+	rec = request(http.MethodGet, "https://evil.com")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != corsWildcard {
+		t.Fatal("unexpected allowed origin", got)
+	}
+	if err := cors.settings.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cors.settings.AllowCredentials = true
+	if err := cors.settings.Validate(); err != ErrCORSWildcardCredentials {
+		t.Fatal("expected ErrCORSWildcardCredentials, got", err)
+	}
+	settings := CORSSettings{AllowedOrigins: []string{"https://example.com", corsWildcard}, AllowCredentials: true}
+	if err := settings.Validate(); err != ErrCORSWildcardCredentials {
+		t.Fatal("expected ErrCORSWildcardCredentials, got", err)
+	}
+}
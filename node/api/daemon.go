@@ -73,6 +73,14 @@ type (
 		WarningAlerts  []modules.Alert `json:"warningalerts"`
 	}
 
+	// DaemonCountersGet contains the operation counters of the loaded modules
+	// which keep operation counters.
+	DaemonCountersGet struct {
+		Consensus map[string]modules.OperationStats `json:"consensus,omitempty"`
+		Host      map[string]modules.OperationStats `json:"host,omitempty"`
+		Renter    map[string]modules.OperationStats `json:"renter,omitempty"`
+	}
+
 	// DaemonVersionGet contains information about the running daemon's version.
 	DaemonVersionGet struct {
 		Version     string
@@ -101,6 +109,8 @@ type (
 		ExtremeFutureThreshold types.Timestamp   `json:"extremefuturethreshold"`
 		FutureThreshold        types.Timestamp   `json:"futurethreshold"`
 		GenesisTimestamp       types.Timestamp   `json:"genesistimestamp"`
+		GenesisID              types.BlockID     `json:"genesisid"`
+		NetworkID              string            `json:"networkid"`
 		MaturityDelay          types.BlockHeight `json:"maturitydelay"`
 		MedianTimestampWindow  uint64            `json:"mediantimestampwindow"`
 		SiafundCount           types.Currency    `json:"siafundcount"`
@@ -352,6 +362,22 @@ func (api *API) daemonAlertsHandlerGET(w http.ResponseWriter, _ *http.Request, _
 	})
 }
 
+// daemonCountersHandlerGET handles the API call that returns the operation
+// counters of all loaded modules.
+func (api *API) daemonCountersHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	var dcg DaemonCountersGet
+	if api.cs != nil {
+		dcg.Consensus = api.cs.OperationCounters()
+	}
+	if api.host != nil {
+		dcg.Host = api.host.OperationCounters()
+	}
+	if api.renter != nil {
+		dcg.Renter = api.renter.OperationCounters()
+	}
+	WriteJSON(w, dcg)
+}
+
 // daemonUpdateHandlerGET handles the API call that checks for an update.
 func (api *API) daemonUpdateHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	version, err := fetchLatestVersion()
@@ -395,6 +421,8 @@ func (api *API) daemonConstantsHandler(w http.ResponseWriter, _ *http.Request, _
 		ExtremeFutureThreshold: types.ExtremeFutureThreshold,
 		FutureThreshold:        types.FutureThreshold,
 		GenesisTimestamp:       types.GenesisTimestamp,
+		GenesisID:              types.GenesisID,
+		NetworkID:              types.NetworkID,
 		MaturityDelay:          types.MaturityDelay,
 		MedianTimestampWindow:  types.MedianTimestampWindow,
 		SiafundCount:           types.SiafundCount,
@@ -429,7 +457,7 @@ func (api *API) daemonStackHandlerGET(w http.ResponseWriter, _ *http.Request, _
 	stack := make([]byte, modules.StackSize)
 	n := runtime.Stack(stack, true)
 	if n == 0 {
-		WriteError(w, Error{"no stack trace pulled"}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "no stack trace pulled"}, http.StatusInternalServerError)
 		return
 	}
 
@@ -444,12 +472,12 @@ func (api *API) daemonStartProfileHandlerPOST(w http.ResponseWriter, req *http.R
 	// Parse profile string
 	profileStr := req.FormValue("profileFlags")
 	if profileStr == "" {
-		WriteError(w, Error{"profile flags cannot be blank"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "profile flags cannot be blank"}, http.StatusBadRequest)
 		return
 	}
 	profileStr, err := profile.ProcessProfileFlags(profileStr)
 	if err != nil {
-		WriteError(w, Error{"unable to process profile flags:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "unable to process profile flags:" + err.Error()}, http.StatusBadRequest)
 		return
 	}
 	profileCPU := strings.Contains(profileStr, "c")
@@ -463,7 +491,7 @@ func (api *API) daemonStartProfileHandlerPOST(w http.ResponseWriter, req *http.R
 	}
 	err = os.MkdirAll(profileDir, modules.DefaultDirPerm)
 	if err != nil {
-		WriteError(w, Error{"unable to create directory for profiles:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "unable to create directory for profiles:" + err.Error()}, http.StatusBadRequest)
 		return
 	}
 
@@ -520,7 +548,7 @@ func (api *API) daemonSettingsHandlerPOST(w http.ResponseWriter, req *http.Reque
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
 		var downloadSpeed int64
 		if _, err := fmt.Sscan(d, &downloadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse downloadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse downloadspeed"), http.StatusBadRequest)
 			return
 		}
 		maxDownloadSpeed = downloadSpeed
@@ -529,14 +557,14 @@ func (api *API) daemonSettingsHandlerPOST(w http.ResponseWriter, req *http.Reque
 	if u := req.FormValue("maxuploadspeed"); u != "" {
 		var uploadSpeed int64
 		if _, err := fmt.Sscan(u, &uploadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse uploadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse uploadspeed"), http.StatusBadRequest)
 			return
 		}
 		maxUploadSpeed = uploadSpeed
 	}
 	// Set the limit.
 	if err := api.siadConfig.SetRatelimit(maxDownloadSpeed, maxUploadSpeed); err != nil {
-		WriteError(w, Error{"unable to set limits: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to set limits"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"net/http"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
+	"go.sia.tech/siad/modules/renter/contractor"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+// ErrorCode is a machine-readable code describing the cause of an API error.
+// Clients should check the code of an error instead of matching its message.
+type ErrorCode string
+
+const (
+	// ErrorCodeBadRequest is the default code of errors returned with
+	// http.StatusBadRequest.
+	ErrorCodeBadRequest ErrorCode = "bad_request"
+
+	// ErrorCodeInternal is the default code of errors returned with
+	// http.StatusInternalServerError.
+	ErrorCodeInternal ErrorCode = "internal"
+
+	// ErrorCodeNotFound indicates that a requested object doesn't exist.
+	ErrorCodeNotFound ErrorCode = "not_found"
+
+	// ErrorCodeAlreadyExists indicates that an object already exists at the
+	// requested location.
+	ErrorCodeAlreadyExists ErrorCode = "already_exists"
+
+	// ErrorCodeInsufficientBalance indicates that the wallet doesn't have
+	// enough coins for the request.
+	ErrorCodeInsufficientBalance ErrorCode = "insufficient_balance"
+
+	// ErrorCodeWalletLocked indicates that the wallet needs to be unlocked
+	// first.
+	ErrorCodeWalletLocked ErrorCode = "wallet_locked"
+
+	// ErrorCodeBadEncryptionKey indicates that the provided wallet password or
+	// seed is incorrect.
+	ErrorCodeBadEncryptionKey ErrorCode = "bad_encryption_key"
+
+	// ErrorCodeNotSynced indicates that a module can't serve the request
+	// before it is synced with the network.
+	ErrorCodeNotSynced ErrorCode = "not_synced"
+
+	// ErrorCodeUnauthorized indicates that the API password was missing or
+	// wrong.
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+
+	// ErrorCodeTimeout indicates that the request took longer than the API
+	// allows.
+	ErrorCodeTimeout ErrorCode = "timeout"
+
+	// ErrorCodeModuleNotLoaded indicates that the module serving the endpoint
+	// isn't loaded.
+	ErrorCodeModuleNotLoaded ErrorCode = "module_not_loaded"
+
+	// ErrorCodeModuleDisabled indicates that the module serving the endpoint
+	// was disabled.
+	ErrorCodeModuleDisabled ErrorCode = "module_disabled"
+
+	// ErrorCodeRenterReadOnly indicates that the request would modify the
+	// renter while it is in read-only mode.
+	ErrorCodeRenterReadOnly ErrorCode = "renter_read_only"
+
+	// ErrorCodeRateLimited indicates that the client exceeded the rate limit
+	// of the endpoint.
+	ErrorCodeRateLimited ErrorCode = "rate_limited"
+)
+
+// moduleErrorCodes maps errors returned by the modules to the codes of the
+// API errors they cause.
+var moduleErrorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{filesystem.ErrNotExist, ErrorCodeNotFound},
+	{renter.ErrRegistryEntryNotFound, ErrorCodeNotFound},
+	{contractor.ErrAllowanceProfileNotFound, ErrorCodeNotFound},
+	{filesystem.ErrExists, ErrorCodeAlreadyExists},
+	{siafile.ErrPathOverload, ErrorCodeAlreadyExists},
+	{modules.ErrLowBalance, ErrorCodeInsufficientBalance},
+	{modules.ErrIncompleteTransactions, ErrorCodeInsufficientBalance},
+	{modules.ErrLockedWallet, ErrorCodeWalletLocked},
+	{modules.ErrBadEncryptionKey, ErrorCodeBadEncryptionKey},
+	{modules.ErrWithdrawalsInactive, ErrorCodeNotSynced},
+	{modules.ErrNotSynced, ErrorCodeNotSynced},
+	{modules.ErrRenterReadOnly, ErrorCodeRenterReadOnly},
+}
+
+// NewError creates an API error from an error returned by a module. If the
+// error is known, the API error carries its code.
+func NewError(err error) Error {
+	return Error{
+		Message: err.Error(),
+		Code:    moduleErrorCode(err),
+	}
+}
+
+// NewErrorWithContext is like NewError but prefixes the message with the
+// provided context.
+func NewErrorWithContext(err error, context string) Error {
+	apiErr := NewError(err)
+	apiErr.Message = context + ": " + apiErr.Message
+	return apiErr
+}
+
+// ErrorCodeOf returns the code of the API error within an error returned by
+// the client. An empty code is returned if there is none.
+func ErrorCodeOf(err error) ErrorCode {
+	switch e := err.(type) {
+	case Error:
+		return e.Code
+	case errors.Error:
+		for _, err := range e.ErrSet {
+			if code := ErrorCodeOf(err); code != "" {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// moduleErrorCode returns the code for an error returned by a module or an
+// empty code if the error is unknown.
+func moduleErrorCode(err error) ErrorCode {
+	for _, mec := range moduleErrorCodes {
+		if errors.Contains(err, mec.err) {
+			return mec.code
+		}
+	}
+	return ""
+}
+
+// statusErrorCode returns the default code of errors returned with the
+// provided http status.
+func statusErrorCode(status int) ErrorCode {
+	switch status {
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case StatusModuleNotLoaded:
+		return ErrorCodeModuleNotLoaded
+	case StatusModuleDisabled:
+		return ErrorCodeModuleDisabled
+	case http.StatusInternalServerError:
+		return ErrorCodeInternal
+	default:
+		return ErrorCodeBadRequest
+	}
+}
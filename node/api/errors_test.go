@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// TestWriteErrorCode checks that WriteError always sends an error code.
+func TestWriteErrorCode(t *testing.T) {
+	tests := []struct {
+		err    Error
+		status int
+		code   ErrorCode
+	}{
+		{Error{Message: "foo"}, http.StatusBadRequest, ErrorCodeBadRequest},
+		{Error{Message: "foo"}, http.StatusInternalServerError, ErrorCodeInternal},
+		{Error{Message: "foo"}, http.StatusNotFound, ErrorCodeNotFound},
+		{Error{Message: "foo"}, StatusModuleNotLoaded, ErrorCodeModuleNotLoaded},
+		{NewError(filesystem.ErrNotExist), http.StatusBadRequest, ErrorCodeNotFound},
+		{NewErrorWithContext(modules.ErrLowBalance, "foo"), http.StatusInternalServerError, ErrorCodeInsufficientBalance},
+		{NewError(errors.AddContext(modules.ErrNotSynced, "foo")), http.StatusBadRequest, ErrorCodeNotSynced},
+	}
+	for _, test := range tests {
+		rr := httptest.NewRecorder()
+		WriteError(rr, test.err, test.status)
+		if rr.Code != test.status {
+			t.Fatalf("expected status %v but got %v", test.status, rr.Code)
+		}
+		var apiErr Error
+		if err := json.NewDecoder(rr.Body).Decode(&apiErr); err != nil {
+			t.Fatal(err)
+		}
+		if apiErr.Code != test.code || apiErr.Message != test.err.Message {
+			t.Fatalf("expected %v (%v) but got %v (%v)", test.err.Message, test.code, apiErr.Message, apiErr.Code)
+		}
+	}
+}
+
+// TestNewError probes NewError and NewErrorWithContext.
+func TestNewError(t *testing.T) {
+	err := errors.AddContext(modules.ErrLockedWallet, "unable to send siacoins")
+	apiErr := NewError(err)
+	if apiErr.Code != ErrorCodeWalletLocked || apiErr.Message != err.Error() {
+		t.Fatal("unexpected error", apiErr)
+	}
+	apiErr = NewErrorWithContext(err, "error when calling /wallet/siacoins")
+	if apiErr.Code != ErrorCodeWalletLocked || apiErr.Message != "error when calling /wallet/siacoins: "+err.Error() {
+		t.Fatal("unexpected error", apiErr)
+	}
+	if apiErr := NewError(errors.New("unknown")); apiErr.Code != "" {
+		t.Fatal("unknown errors shouldn't have a code", apiErr)
+	}
+}
+
+// TestErrorCodeOf checks that the code of an API error can be retrieved after
+// it was wrapped by the client.
+func TestErrorCodeOf(t *testing.T) {
+	apiErr := Error{Message: "foo", Code: ErrorCodeNotFound}
+	if code := ErrorCodeOf(apiErr); code != ErrorCodeNotFound {
+		t.Fatal("wrong code", code)
+	}
+	err := errors.AddContext(apiErr, "GET request error")
+	err = errors.Compose(err, ErrAPICallNotRecognized)
+	if code := ErrorCodeOf(err); code != ErrorCodeNotFound {
+		t.Fatal("wrong code", code)
+	}
+	if code := ErrorCodeOf(errors.New("foo")); code != "" {
+		t.Fatal("wrong code", code)
+	}
+}
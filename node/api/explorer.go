@@ -216,14 +216,14 @@ func explorerBlocksHandler(e modules.Explorer, cs modules.ConsensusSet, w http.R
 	var height types.BlockHeight
 	_, err := fmt.Sscan(ps.ByName("height"), &height)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	// Fetch and return the explorer block.
 	block, exists := cs.BlockAtHeight(height)
 	if !exists {
-		WriteError(w, Error{"no block found at input height in call to /explorer/block"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "no block found at input height in call to /explorer/block"}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, ExplorerBlockGET{
@@ -266,7 +266,7 @@ func explorerHashHandler(explorer modules.Explorer, w http.ResponseWriter, _ *ht
 	if err != nil {
 		addr, err := scanAddress(ps.ByName("hash"))
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 		hash = crypto.Hash(addr)
@@ -275,7 +275,7 @@ func explorerHashHandler(explorer modules.Explorer, w http.ResponseWriter, _ *ht
 	// TODO: lookups on the zero hash are too expensive to allow. Need a
 	// better way to handle this case.
 	if hash == (crypto.Hash{}) {
-		WriteError(w, Error{"can't lookup the empty unlock hash"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "can't lookup the empty unlock hash"}, http.StatusBadRequest)
 		return
 	}
 
@@ -361,7 +361,7 @@ func explorerHashHandler(explorer modules.Explorer, w http.ResponseWriter, _ *ht
 	}
 
 	// Hash not found, return an error.
-	WriteError(w, Error{"unrecognized hash used as input to /explorer/hash"}, http.StatusBadRequest)
+	WriteError(w, Error{Message: "unrecognized hash used as input to /explorer/hash"}, http.StatusBadRequest)
 }
 
 // explorerHandler handles API calls to /explorer
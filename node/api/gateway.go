@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -20,6 +21,13 @@ type (
 
 		MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
 		MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+
+		Services modules.GatewayServices `json:"services"`
+
+		Addresses              []modules.NetAddress  `json:"addresses"`
+		PreferredAddressFamily modules.AddressFamily `json:"preferredaddressfamily"`
+
+		RequireEncryption bool `json:"requireencryption"`
 	}
 
 	// GatewayBandwidthGET contains the bandwidth usage of the gateway
@@ -29,6 +37,20 @@ type (
 		StartTime time.Time `json:"starttime"`
 	}
 
+	// GatewayAllowlistGET contains the allowlist of the gateway and whether
+	// the gateway only connects to the peers on it.
+	GatewayAllowlistGET struct {
+		Enabled   bool     `json:"enabled"`
+		Allowlist []string `json:"allowlist"`
+	}
+
+	// GatewayAllowlistPOST contains the information needed to set the
+	// allowlist of the gateway
+	GatewayAllowlistPOST struct {
+		Enabled   bool     `json:"enabled"`
+		Addresses []string `json:"addresses"`
+	}
+
 	// GatewayBlocklistPOST contains the information needed to set the Blocklist
 	// of the gateway
 	GatewayBlocklistPOST struct {
@@ -41,6 +63,29 @@ type (
 		Blacklist []string `json:"blacklist"` // deprecated, kept for backwards compatibility
 		Blocklist []string `json:"blocklist"`
 	}
+
+	// GatewayDisconnectsGET contains the peers the gateway disconnected from
+	// because of a failed RPC.
+	GatewayDisconnectsGET struct {
+		Disconnects []modules.PeerDisconnect `json:"disconnects"`
+	}
+
+	// GatewayPeersGET contains the connection metrics of the peers the
+	// gateway is connected to.
+	GatewayPeersGET struct {
+		Peers []modules.PeerStats `json:"peers"`
+	}
+
+	// GatewayBansGET contains the peers which are banned because of
+	// misbehavior.
+	GatewayBansGET struct {
+		Bans []modules.PeerBan `json:"bans"`
+	}
+
+	// GatewayScoresGET contains the misbehavior scores of the peers.
+	GatewayScoresGET struct {
+		Scores []modules.PeerScore `json:"scores"`
+	}
 )
 
 // RegisterRoutesGateway is a helper function to register all gateway routes.
@@ -54,12 +99,33 @@ func RegisterRoutesGateway(router *httprouter.Router, g modules.Gateway, require
 	router.GET("/gateway/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		gatewayBandwidthHandlerGET(g, w, req, ps)
 	})
+	router.GET("/gateway/disconnects", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayDisconnectsHandlerGET(g, w, req, ps)
+	})
+	router.GET("/gateway/peers", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayPeersHandlerGET(g, w, req, ps)
+	})
+	router.GET("/gateway/bans", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayBansHandlerGET(g, w, req, ps)
+	})
+	router.POST("/gateway/bans/remove/:host", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayBansRemoveHandlerPOST(g, w, req, ps)
+	}, requiredPassword))
+	router.GET("/gateway/scores", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayScoresHandlerGET(g, w, req, ps)
+	})
 	router.POST("/gateway/connect/:netaddress", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		gatewayConnectHandler(g, w, req, ps)
 	}, requiredPassword))
 	router.POST("/gateway/disconnect/:netaddress", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		gatewayDisconnectHandler(g, w, req, ps)
 	}, requiredPassword))
+	router.GET("/gateway/allowlist", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayAllowlistHandlerGET(g, w, req, ps)
+	})
+	router.POST("/gateway/allowlist", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayAllowlistHandlerPOST(g, w, req, ps)
+	}, requiredPassword))
 	router.GET("/gateway/blocklist", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		gatewayBlocklistHandlerGET(g, w, req, ps)
 	})
@@ -86,7 +152,19 @@ func gatewayHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.R
 	if peers == nil {
 		peers = make([]modules.Peer, 0)
 	}
-	WriteJSON(w, GatewayGET{gateway.Address(), peers, gateway.Online(), mds, mus})
+	WriteJSON(w, GatewayGET{
+		NetAddress:       gateway.Address(),
+		Peers:            peers,
+		Online:           gateway.Online(),
+		MaxDownloadSpeed: mds,
+		MaxUploadSpeed:   mus,
+		Services:         gateway.Services(),
+
+		Addresses:              gateway.Addresses(),
+		PreferredAddressFamily: gateway.PreferredAddressFamily(),
+
+		RequireEncryption: gateway.RequireEncryption(),
+	})
 }
 
 // gatewayHandlerPOST handles the API call changing gateway specific settings.
@@ -96,7 +174,7 @@ func gatewayHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *htt
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
 		var downloadSpeed int64
 		if _, err := fmt.Sscan(d, &downloadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse downloadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse downloadspeed"), http.StatusBadRequest)
 			return
 		}
 		maxDownloadSpeed = downloadSpeed
@@ -105,15 +183,47 @@ func gatewayHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *htt
 	if u := req.FormValue("maxuploadspeed"); u != "" {
 		var uploadSpeed int64
 		if _, err := fmt.Sscan(u, &uploadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse uploadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse uploadspeed"), http.StatusBadRequest)
 			return
 		}
 		maxUploadSpeed = uploadSpeed
 	}
+	// Parse the preferred address family. (optional parameter) An empty
+	// value resets the preference.
+	family := gateway.PreferredAddressFamily()
+	if _, ok := req.Form["preferredaddressfamily"]; ok {
+		family = modules.AddressFamily(req.FormValue("preferredaddressfamily"))
+		if err := family.Valid(); err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse preferredaddressfamily"), http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse whether encryption is required. (optional parameter)
+	requireEncryption := gateway.RequireEncryption()
+	if r := req.FormValue("requireencryption"); r != "" {
+		var err error
+		requireEncryption, err = strconv.ParseBool(r)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse requireencryption"), http.StatusBadRequest)
+			return
+		}
+	}
 	// Try to set the limits.
 	err := gateway.SetRateLimits(maxDownloadSpeed, maxUploadSpeed)
 	if err != nil {
-		WriteError(w, Error{"failed to set new rate limit: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to set new rate limit"), http.StatusBadRequest)
+		return
+	}
+	// Try to set the preferred address family.
+	err = gateway.SetPreferredAddressFamily(family)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set preferred address family"), http.StatusBadRequest)
+		return
+	}
+	// Try to set the encryption requirement.
+	err = gateway.SetRequireEncryption(requireEncryption)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set encryption requirement"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -124,7 +234,7 @@ func gatewayHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *htt
 func gatewayBandwidthHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	upload, download, startTime, err := gateway.BandwidthCounters()
 	if err != nil {
-		WriteError(w, Error{"failed to get gateway's bandwidth usage: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to get gateway's bandwidth usage"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, GatewayBandwidthGET{
@@ -134,12 +244,67 @@ func gatewayBandwidthHandlerGET(gateway modules.Gateway, w http.ResponseWriter,
 	})
 }
 
+// gatewayDisconnectsHandlerGET handles the API call asking for the peers the
+// gateway disconnected from because of a failed RPC.
+func gatewayDisconnectsHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	disconnects := gateway.PeerDisconnects()
+	if disconnects == nil {
+		disconnects = make([]modules.PeerDisconnect, 0)
+	}
+	WriteJSON(w, GatewayDisconnectsGET{
+		Disconnects: disconnects,
+	})
+}
+
+// gatewayPeersHandlerGET handles the API call asking for the connection
+// metrics of the gateway's peers.
+func gatewayPeersHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, GatewayPeersGET{
+		Peers: gateway.PeerStats(),
+	})
+}
+
+// gatewayBansHandlerGET handles the API call asking for the peers which are
+// banned because of misbehavior.
+func gatewayBansHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	bans := gateway.PeerBans()
+	if bans == nil {
+		bans = make([]modules.PeerBan, 0)
+	}
+	WriteJSON(w, GatewayBansGET{
+		Bans: bans,
+	})
+}
+
+// gatewayBansRemoveHandlerPOST handles the API call to lift the ban of a
+// peer.
+func gatewayBansRemoveHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	err := gateway.UnbanPeer(ps.ByName("host"))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to lift the ban"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// gatewayScoresHandlerGET handles the API call asking for the misbehavior
+// scores of the peers.
+func gatewayScoresHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	scores := gateway.PeerScores()
+	if scores == nil {
+		scores = make([]modules.PeerScore, 0)
+	}
+	WriteJSON(w, GatewayScoresGET{
+		Scores: scores,
+	})
+}
+
 // gatewayConnectHandler handles the API call to add a peer to the gateway.
 func gatewayConnectHandler(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	addr := modules.NetAddress(ps.ByName("netaddress"))
 	err := gateway.ConnectManual(addr)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -151,10 +316,40 @@ func gatewayDisconnectHandler(gateway modules.Gateway, w http.ResponseWriter, _
 	addr := modules.NetAddress(ps.ByName("netaddress"))
 	err := gateway.DisconnectManual(addr)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+
+	WriteSuccess(w)
+}
+
+// gatewayAllowlistHandlerGET handles the API call to get the gateway's
+// allowlist
+func gatewayAllowlistHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	enabled, allowlist, err := gateway.Allowlist()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to get allowlist"), http.StatusBadRequest)
 		return
 	}
+	WriteJSON(w, GatewayAllowlistGET{
+		Enabled:   enabled,
+		Allowlist: allowlist,
+	})
+}
 
+// gatewayAllowlistHandlerPOST handles the API call to replace the gateway's
+// allowlist and to enable or disable the allowlist-only mode
+func gatewayAllowlistHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params GatewayAllowlistPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
+		return
+	}
+	if err := gateway.SetAllowlist(params.Enabled, params.Addresses); err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set the allowlist"), http.StatusBadRequest)
+		return
+	}
 	WriteSuccess(w)
 }
 
@@ -164,7 +359,7 @@ func gatewayBlocklistHandlerGET(gateway modules.Gateway, w http.ResponseWriter,
 	// Get Blocklist
 	blocklist, err := gateway.Blocklist()
 	if err != nil {
-		WriteError(w, Error{"unable to get blocklist mode: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to get blocklist mode"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, GatewayBlocklistGET{
@@ -183,7 +378,7 @@ func gatewayBlocklistHandlerPOST(gateway modules.Gateway, w http.ResponseWriter,
 	var params GatewayBlocklistPOST
 	err := json.NewDecoder(req.Body).Decode(&params)
 	if err != nil {
-		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
 		return
 	}
 
@@ -191,33 +386,33 @@ func gatewayBlocklistHandlerPOST(gateway modules.Gateway, w http.ResponseWriter,
 	case "append":
 		// Check that addresses where submitted
 		if len(params.Addresses) == 0 {
-			WriteError(w, Error{"no addresses submitted to append or remove"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "no addresses submitted to append or remove"}, http.StatusBadRequest)
 			return
 		}
 		// Add addresses to Blocklist
 		if err := gateway.AddToBlocklist(params.Addresses); err != nil {
-			WriteError(w, Error{"failed to add addresses to the blocklist: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "failed to add addresses to the blocklist"), http.StatusBadRequest)
 			return
 		}
 	case "remove":
 		// Check that addresses where submitted
 		if len(params.Addresses) == 0 {
-			WriteError(w, Error{"no addresses submitted to append or remove"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "no addresses submitted to append or remove"}, http.StatusBadRequest)
 			return
 		}
 		// Remove addresses from the Blocklist
 		if err := gateway.RemoveFromBlocklist(params.Addresses); err != nil {
-			WriteError(w, Error{"failed to remove addresses from the blocklist: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "failed to remove addresses from the blocklist"), http.StatusBadRequest)
 			return
 		}
 	case "set":
 		// Set Blocklist
 		if err := gateway.SetBlocklist(params.Addresses); err != nil {
-			WriteError(w, Error{"failed to set the blocklist: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "failed to set the blocklist"), http.StatusBadRequest)
 			return
 		}
 	default:
-		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
 		return
 	}
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/gateway"
 )
 
@@ -117,3 +118,60 @@ func TestGatewayPeerDisconnect(t *testing.T) {
 		t.Fatal("/gateway/disconnect did not disconnect from peer", peer.Address())
 	}
 }
+
+// TestGatewayPeerBans checks that /gateway/bans and /gateway/scores list the
+// banned peers and that /gateway/bans/remove lifts bans.
+func TestGatewayPeerBans(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Nothing is listed without misbehavior.
+	var bans GatewayBansGET
+	if err := st.getAPI("/gateway/bans", &bans); err != nil {
+		t.Fatal(err)
+	}
+	var scores GatewayScoresGET
+	if err := st.getAPI("/gateway/scores", &scores); err != nil {
+		t.Fatal(err)
+	}
+	if bans.Bans == nil || len(bans.Bans) != 0 || scores.Scores == nil || len(scores.Scores) != 0 {
+		t.Fatal("expected empty lists", bans, scores)
+	}
+
+	// Ban a peer.
+	host := "1.2.3.4"
+	st.gateway.RecordPeerMisbehavior(modules.NetAddress(host+":9981"), modules.PeerMisbehaviorInvalidBlock)
+	if err := st.getAPI("/gateway/bans", &bans); err != nil {
+		t.Fatal(err)
+	}
+	if len(bans.Bans) != 1 || bans.Bans[0].Host != host || bans.Bans[0].Reason != modules.PeerMisbehaviorInvalidBlock {
+		t.Fatal("unexpected bans", bans.Bans)
+	}
+	if err := st.getAPI("/gateway/scores", &scores); err != nil {
+		t.Fatal(err)
+	}
+	if len(scores.Scores) != 1 || scores.Scores[0].Host != host || scores.Scores[0].Bans != 1 || scores.Scores[0].Misbehavior[modules.PeerMisbehaviorInvalidBlock] != 1 {
+		t.Fatal("unexpected scores", scores.Scores)
+	}
+
+	// Lift the ban. Lifting it again fails.
+	if err := st.stdPostAPI("/gateway/bans/remove/"+host, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.stdPostAPI("/gateway/bans/remove/"+host, nil); err == nil {
+		t.Fatal("lifting a non-existent ban succeeded")
+	}
+	if err := st.getAPI("/gateway/bans", &bans); err != nil {
+		t.Fatal(err)
+	}
+	if len(bans.Bans) != 0 {
+		t.Fatal("ban wasn't lifted", bans.Bans)
+	}
+}
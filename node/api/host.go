@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -16,7 +18,7 @@ import (
 var (
 	// errNoPath is returned when a call fails to provide a nonempty string
 	// for the path parameter.
-	errNoPath = Error{"path parameter is required"}
+	errNoPath = Error{Message: "path parameter is required"}
 
 	// errStorageFolderNotFound is returned if a call is made looking for a
 	// storage folder which does not appear to exist within the storage
@@ -67,6 +69,64 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostCapacityForecastGET contains the host's capacity forecast.
+	HostCapacityForecastGET struct {
+		modules.HostCapacityForecast
+	}
+
+	// HostPayoutsGET contains the payouts of the host's succeeded storage
+	// obligations which are yet to mature.
+	HostPayoutsGET struct {
+		modules.HostPayoutSchedule
+	}
+
+	// HostPayoutsHistoryGET contains the addresses the host's contract
+	// payouts were sent to.
+	HostPayoutsHistoryGET struct {
+		modules.HostPayoutHistory
+	}
+
+	// HostCheckConnectivityGET contains the result of the host's reachability
+	// self-test.
+	HostCheckConnectivityGET struct {
+		modules.HostConnectivity
+	}
+
+	// HostSettingsBandwidthGET contains the host's bandwidth settings and the
+	// limits it currently enforces.
+	HostSettingsBandwidthGET struct {
+		Settings modules.HostBandwidthSettings `json:"settings"`
+		Status   modules.HostBandwidthStatus   `json:"status"`
+	}
+
+	// HostSettingsPayoutsGET contains the host's payout address settings.
+	HostSettingsPayoutsGET struct {
+		Settings modules.HostPayoutSettings `json:"settings"`
+	}
+
+	// HostSectorAuditGET contains the recorded sector accesses of a contract.
+	HostSectorAuditGET struct {
+		Entries []modules.HostSectorAuditEntry `json:"entries"`
+	}
+
+	// HostSettingsHistoryGET contains the recorded changes to the host's
+	// internal settings.
+	HostSettingsHistoryGET struct {
+		Changes []modules.HostSettingsChange `json:"changes"`
+	}
+
+	// HostSettingsScheduleGET contains the pending scheduled changes to the
+	// host's internal settings.
+	HostSettingsScheduleGET struct {
+		Scheduled []modules.HostScheduledSettings `json:"scheduled"`
+	}
+
+	// StorageFoldersRecoverPOST contains the number of sectors recovered from
+	// a storage folder.
+	StorageFoldersRecoverPOST struct {
+		Sectors uint64 `json:"sectors"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -87,23 +147,74 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.POST("/host/announce", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostAnnounceHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.GET("/host/checkconnectivity", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostCheckConnectivityHandlerGET(h, w, req, ps)
+	})
 	router.GET("/host/contracts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostContractInfoHandler(h, w, req, ps)
 	})
+	router.POST("/host/contracts/recover", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostContractsRecoverHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 	router.GET("/host/contracts/:contractID", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostContractGetHandler(h, w, req, ps)
 	})
 	router.GET("/host/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostBandwidthHandlerGET(h, w, req, ps)
 	})
+	router.GET("/host/capacityforecast", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostCapacityForecastHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/payouts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPayoutsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/payouts/history", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPayoutsHistoryHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/sectoraudit", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSectorAuditHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/sectoraudit/purge", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSectorAuditPurgeHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/settings/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsBandwidthHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/settings/bandwidth", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsBandwidthHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/settings/history", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsHistoryHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/settings/payouts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsPayoutsHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/settings/payouts", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsPayoutsHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/settings/schedule", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsScheduleHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/settings/schedule", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsScheduleHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.POST("/host/settings/schedule/cancel", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsScheduleCancelHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 
 	// Calls pertaining to the storage manager that the host uses.
 	router.GET("/host/storage", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageHandler(h, w, req, ps)
 	})
+	router.POST("/host/storage/compact", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageCompactHandler(h, w, req, ps)
+	}, requiredPassword))
 	router.POST("/host/storage/folders/add", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersAddHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.POST("/host/storage/folders/recover", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageFoldersRecoverHandler(h, w, req, ps)
+	}, requiredPassword))
 	router.POST("/host/storage/folders/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersRemoveHandler(h, w, req, ps)
 	}, requiredPassword))
@@ -133,7 +244,7 @@ func hostContractGetHandler(host modules.Host, w http.ResponseWriter, _ *http.Re
 
 	buf, err := hex.DecodeString(contractIDStr)
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("error parsing storage contract id: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("error parsing storage contract id: %v", err)}, http.StatusBadRequest)
 		return
 	}
 
@@ -141,7 +252,7 @@ func hostContractGetHandler(host modules.Host, w http.ResponseWriter, _ *http.Re
 
 	contract, err := host.StorageObligation(obligationID)
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("error get storage contract: %v", err)}, http.StatusNotFound)
+		WriteError(w, Error{Message: fmt.Sprintf("error get storage contract: %v", err)}, http.StatusNotFound)
 		return
 	}
 
@@ -150,6 +261,25 @@ func hostContractGetHandler(host modules.Host, w http.ResponseWriter, _ *http.Re
 	})
 }
 
+// hostContractsRecoverHandlerPOST handles the API call to recover the host's
+// storage obligations from the blockchain.
+func hostContractsRecoverHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var startHeight types.BlockHeight
+	if sh := req.FormValue("startheight"); sh != "" {
+		_, err := fmt.Sscan(sh, &startHeight)
+		if err != nil {
+			WriteError(w, Error{Message: fmt.Sprintf("unable to parse startheight: %v", err)}, http.StatusBadRequest)
+			return
+		}
+	}
+	recovery, err := host.RecoverStorageObligations(startHeight)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to recover storage obligations"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, recovery)
+}
+
 // hostContractInfoHandler handles the API call to get the contract information of the host.
 // Information is retrieved via the storage obligations from the host database.
 func hostContractInfoHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -193,7 +323,7 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	sent, receive, startTime, err := host.BandwidthCounters()
 	if err != nil {
-		WriteError(w, Error{"failed to get hosts's bandwidth usage: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to get hosts's bandwidth usage"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, GatewayBandwidthGET{
@@ -203,6 +333,43 @@ func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.R
 	})
 }
 
+// hostCapacityForecastHandlerGET handles the API call to fetch the host's
+// capacity forecast.
+func hostCapacityForecastHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostCapacityForecastGET{
+		HostCapacityForecast: host.CapacityForecast(),
+	})
+}
+
+// hostPayoutsHandlerGET handles the API call to fetch the host's payouts
+// which are yet to mature.
+func hostPayoutsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPayoutsGET{
+		HostPayoutSchedule: host.PayoutSchedule(),
+	})
+}
+
+// hostPayoutsHistoryHandlerGET handles the API call to fetch the addresses
+// the host's contract payouts were sent to.
+func hostPayoutsHistoryHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPayoutsHistoryGET{
+		HostPayoutHistory: host.PayoutHistory(),
+	})
+}
+
+// hostCheckConnectivityHandlerGET handles the API call to check whether the
+// host is reachable by renters at the address it announces.
+func hostCheckConnectivityHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	hc, err := host.CheckConnectivity()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to check host connectivity"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostCheckConnectivityGET{
+		HostConnectivity: hc,
+	})
+}
+
 // parseHostSettings a request's query strings and returns a
 // modules.HostInternalSettings configured with the request's query string
 // parameters.
@@ -355,6 +522,14 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 		}
 		settings.MaxEphemeralAccountRisk = x
 	}
+	if req.FormValue("maxephemeralaccountsupply") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxephemeralaccountsupply"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxEphemeralAccountSupply = x
+	}
 	if req.FormValue("registrysize") != "" {
 		var x uint64
 		_, err := fmt.Sscan(req.FormValue("registrysize"), &x)
@@ -366,6 +541,30 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 	if req.FormValue("customregistrypath") != "" {
 		settings.CustomRegistryPath = req.FormValue("customregistrypath")
 	}
+	if req.FormValue("renewalhintwindow") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("renewalhintwindow"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RenewalHintWindow = x
+	}
+	if req.FormValue("sectorauditlog") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("sectorauditlog"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.SectorAuditLog = x
+	}
+	if req.FormValue("ingressprotection") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("ingressprotection"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.IngressProtection = x
+	}
 
 	// Validate the RPC, Sector Access, and Download Prices
 	minBaseRPCPrice := settings.MinBaseRPCPrice
@@ -387,13 +586,13 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 func hostEstimateScoreGET(host modules.Host, renter modules.Renter, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// This call requires a renter, check that it is present.
 	if renter == nil {
-		WriteError(w, Error{"cannot call /host/estimatescore without the renter module"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "cannot call /host/estimatescore without the renter module"}, http.StatusBadRequest)
 		return
 	}
 
 	settings, err := parseHostSettings(host, req)
 	if err != nil {
-		WriteError(w, Error{"error parsing host settings: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error parsing host settings"), http.StatusBadRequest)
 		return
 	}
 	var totalStorage, remainingStorage uint64
@@ -431,7 +630,7 @@ func hostEstimateScoreGET(host modules.Host, renter modules.Renter, w http.Respo
 	// allowance the renters may use to attempt to access this host.
 	estimatedScoreBreakdown, err := renter.EstimateHostScore(entry, modules.DefaultAllowance)
 	if err != nil {
-		WriteError(w, Error{"error estimating host score: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "error estimating host score"), http.StatusInternalServerError)
 		return
 	}
 	e := HostEstimateScoreGET{
@@ -446,13 +645,173 @@ func hostEstimateScoreGET(host modules.Host, renter modules.Renter, w http.Respo
 func hostHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	settings, err := parseHostSettings(host, req)
 	if err != nil {
-		WriteError(w, Error{"error parsing host settings: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error parsing host settings"), http.StatusBadRequest)
+		return
+	}
+
+	err = host.SetInternalSettingsFromSource(settings, hostSettingsSource(req))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostSettingsSource returns the source which is recorded in the host's
+// settings history for a settings change made through the API. Since the API
+// ignores the username of the basic auth, it can be used to label the client
+// that made the change.
+func hostSettingsSource(req *http.Request) string {
+	if user, _, ok := req.BasicAuth(); ok && user != "" {
+		return "api:" + user
+	}
+	return "api"
+}
+
+// hostSettingsBandwidthHandlerGET handles the API call to fetch the host's
+// bandwidth settings.
+func hostSettingsBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostSettingsBandwidthGET{
+		Settings: host.BandwidthSettings(),
+		Status:   host.BandwidthStatus(),
+	})
+}
+
+// hostSettingsBandwidthHandlerPOST handles the API call to set the host's
+// bandwidth settings. The settings are provided as JSON in the request body.
+func hostSettingsBandwidthHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings modules.HostBandwidthSettings
+	err := json.NewDecoder(req.Body).Decode(&settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
+		return
+	}
+	err = host.SetBandwidthSettings(settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set bandwidth settings"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostSettingsPayoutsHandlerGET handles the API call to fetch the host's
+// payout address settings.
+func hostSettingsPayoutsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostSettingsPayoutsGET{
+		Settings: host.PayoutSettings(),
+	})
+}
+
+// hostSettingsPayoutsHandlerPOST handles the API call to set the host's
+// payout address settings. The settings are provided as JSON in the request
+// body.
+func hostSettingsPayoutsHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings modules.HostPayoutSettings
+	err := json.NewDecoder(req.Body).Decode(&settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
+		return
+	}
+	err = host.SetPayoutSettings(settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set payout settings"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostSectorAuditHandlerGET handles the API call to export the recorded
+// sector accesses of a contract.
+func hostSectorAuditHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse id"), http.StatusBadRequest)
+		return
+	}
+	entries, err := host.SectorAudit(fcid)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get sector audit"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostSectorAuditGET{
+		Entries: entries,
+	})
+}
+
+// hostSectorAuditPurgeHandlerPOST handles the API call to remove the recorded
+// sector accesses of a contract.
+func hostSectorAuditPurgeHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse id"), http.StatusBadRequest)
+		return
+	}
+	err := host.PurgeSectorAudit(fcid)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to purge sector audit"), http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostSettingsHistoryHandlerGET handles the API call to fetch the host's
+// settings history.
+func hostSettingsHistoryHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostSettingsHistoryGET{
+		Changes: host.SettingsHistory(),
+	})
+}
+
+// hostSettingsScheduleHandlerGET handles the API call to fetch the host's
+// scheduled settings changes.
+func hostSettingsScheduleHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostSettingsScheduleGET{
+		Scheduled: host.ScheduledSettings(),
+	})
+}
+
+// hostSettingsScheduleHandlerPOST handles the API call to schedule a change of
+// the host's settings at a future block height. The settings are provided the
+// same way as for POST /host.
+func hostSettingsScheduleHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var height types.BlockHeight
+	_, err := fmt.Sscan(req.FormValue("height"), &height)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse height"), http.StatusBadRequest)
+		return
+	}
+	settings, err := parseHostSettings(host, req)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "error parsing host settings"), http.StatusBadRequest)
 		return
 	}
+	// Only the settings which were provided are part of the scheduled change.
+	var fields []string
+	for field := range req.Form {
+		if modules.IsHostInternalSettingsField(field) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	ss, err := host.ScheduleSettings(height, settings, fields, hostSettingsSource(req))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ss)
+}
 
-	err = host.SetInternalSettings(settings)
+// hostSettingsScheduleCancelHandlerPOST handles the API call to cancel a
+// scheduled settings change.
+func hostSettingsScheduleCancelHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := req.FormValue("id")
+	if id == "" {
+		WriteError(w, Error{Message: "id parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	err := host.CancelScheduledSettings(id)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -468,7 +827,7 @@ func hostAnnounceHandler(host modules.Host, w http.ResponseWriter, req *http.Req
 		err = host.Announce()
 	}
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -488,41 +847,70 @@ func storageFoldersAddHandler(host modules.Host, w http.ResponseWriter, req *htt
 	var folderSize uint64
 	_, err := fmt.Sscan(req.FormValue("size"), &folderSize)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	err = host.AddStorageFolder(folderPath, folderSize)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
+// storageFoldersRecoverHandler adds an existing storage folder to the storage
+// manager and recovers its sectors.
+func storageFoldersRecoverHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{Message: "path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	sectors, err := host.RecoverStorageFolder(folderPath)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to recover storage folder"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, StorageFoldersRecoverPOST{
+		Sectors: sectors,
+	})
+}
+
+// storageCompactHandler handles the API call to compact the sectors of the
+// host.
+func storageCompactHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	compaction, err := host.CompactSectors()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to compact sectors"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, compaction)
+}
+
 // storageFoldersResizeHandler resizes a storage folder in the storage manager.
 func storageFoldersResizeHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
 	if folderPath == "" {
-		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "path parameter is required"}, http.StatusBadRequest)
 		return
 	}
 
 	storageFolders := host.StorageFolders()
 	folderIndex, err := folderIndex(folderPath, storageFolders)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	var newSize uint64
 	_, err = fmt.Sscan(req.FormValue("newsize"), &newSize)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	err = host.ResizeStorageFolder(uint16(folderIndex), newSize, false)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -533,21 +921,21 @@ func storageFoldersResizeHandler(host modules.Host, w http.ResponseWriter, req *
 func storageFoldersRemoveHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
 	if folderPath == "" {
-		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "path parameter is required"}, http.StatusBadRequest)
 		return
 	}
 
 	storageFolders := host.StorageFolders()
 	folderIndex, err := folderIndex(folderPath, storageFolders)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	force := req.FormValue("force") == "true"
 	err = host.RemoveStorageFolder(uint16(folderIndex), force)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -558,12 +946,12 @@ func storageFoldersRemoveHandler(host modules.Host, w http.ResponseWriter, req *
 func storageSectorsDeleteHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	sectorRoot, err := scanHash(ps.ByName("merkleroot"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	err = host.DeleteSector(sectorRoot)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -62,7 +62,7 @@ type (
 func (api *API) hostdbHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	isc, err := api.renter.InitialScanComplete()
 	if err != nil {
-		WriteError(w, Error{"Failed to get initial scan status: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "Failed to get initial scan status"), http.StatusInternalServerError)
 		return
 	}
 	WriteJSON(w, HostdbGet{
@@ -76,7 +76,7 @@ func (api *API) hostdbActiveHandler(w http.ResponseWriter, req *http.Request, _
 	var numHosts uint64
 	hosts, err := api.renter.ActiveHosts()
 	if err != nil {
-		WriteError(w, Error{"unable to get active hosts: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to get active hosts"), http.StatusBadRequest)
 		return
 	}
 
@@ -87,7 +87,7 @@ func (api *API) hostdbActiveHandler(w http.ResponseWriter, req *http.Request, _
 		// Parse the value for 'numhosts'.
 		_, err := fmt.Sscan(req.FormValue("numhosts"), &numHosts)
 		if err != nil {
-			WriteError(w, Error{"unable to parse numhosts: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse numhosts"), http.StatusBadRequest)
 			return
 		}
 
@@ -116,7 +116,7 @@ func (api *API) hostdbAllHandler(w http.ResponseWriter, _ *http.Request, _ httpr
 	// Get the set of all hosts and convert them into extended hosts.
 	hosts, err := api.renter.AllHosts()
 	if err != nil {
-		WriteError(w, Error{"unable to get all hosts: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to get all hosts"), http.StatusBadRequest)
 		return
 	}
 	var extendedHosts []ExtendedHostDBEntry
@@ -140,16 +140,16 @@ func (api *API) hostdbHostsHandler(w http.ResponseWriter, _ *http.Request, ps ht
 
 	entry, exists, err := api.renter.Host(pk)
 	if err != nil {
-		WriteError(w, Error{"unable to get host: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to get host"), http.StatusBadRequest)
 		return
 	}
 	if !exists {
-		WriteError(w, Error{"requested host does not exist"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "requested host does not exist"}, http.StatusBadRequest)
 		return
 	}
 	breakdown, err := api.renter.ScoreBreakdown(entry)
 	if err != nil {
-		WriteError(w, Error{"error calculating score breakdown: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "error calculating score breakdown"), http.StatusInternalServerError)
 		return
 	}
 
@@ -170,7 +170,7 @@ func (api *API) hostdbFilterModeHandlerGET(w http.ResponseWriter, _ *http.Reques
 	// Get FilterMode
 	fm, hostMap, err := api.renter.Filter()
 	if err != nil {
-		WriteError(w, Error{"unable to get filter mode: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to get filter mode"), http.StatusBadRequest)
 		return
 	}
 	// Build Slice of PubKeys
@@ -191,19 +191,19 @@ func (api *API) hostdbFilterModeHandlerPOST(w http.ResponseWriter, req *http.Req
 	var params HostdbFilterModePOST
 	err := json.NewDecoder(req.Body).Decode(&params)
 	if err != nil {
-		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
 		return
 	}
 
 	var fm modules.FilterMode
 	if err = fm.FromString(params.FilterMode); err != nil {
-		WriteError(w, Error{"unable to load filter mode from string: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to load filter mode from string"), http.StatusBadRequest)
 		return
 	}
 
 	// Set list mode
 	if err := api.renter.SetFilterMode(fm, params.Hosts); err != nil {
-		WriteError(w, Error{"failed to set the list mode: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to set the list mode"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
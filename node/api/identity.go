@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+type (
+	// IdentityGET contains the public information about the keys of the
+	// node's identity.
+	IdentityGET struct {
+		Keys []modules.IdentityKeys `json:"keys"`
+	}
+
+	// IdentityRotatePOST contains the public key of the key which replaced
+	// the rotated key.
+	IdentityRotatePOST struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+	}
+
+	// IdentityExportPOST contains the exported keystore of the node's
+	// identity.
+	IdentityExportPOST struct {
+		Keystore string `json:"keystore"`
+	}
+)
+
+// RegisterRoutesIdentity is a helper function to register all identity
+// routes.
+func RegisterRoutesIdentity(router *httprouter.Router, id modules.Identity, requiredPassword string) {
+	router.GET("/identity", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		identityHandlerGET(id, w, req, ps)
+	}, requiredPassword))
+	router.POST("/identity/rotate", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		identityRotateHandlerPOST(id, w, req, ps)
+	}, requiredPassword))
+	router.POST("/identity/export", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		identityExportHandlerPOST(id, w, req, ps)
+	}, requiredPassword))
+	router.POST("/identity/import", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		identityImportHandlerPOST(id, w, req, ps)
+	}, requiredPassword))
+}
+
+// identityHandlerGET handles GET calls to /identity.
+func identityHandlerGET(id modules.Identity, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	keys, err := id.Keys()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get identity keys"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, IdentityGET{Keys: keys})
+}
+
+// identityRotateHandlerPOST handles POST calls to /identity/rotate.
+func identityRotateHandlerPOST(id modules.Identity, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	purpose := modules.KeyPurpose(req.FormValue("purpose"))
+	if err := purpose.IsValid(); err != nil {
+		WriteError(w, NewErrorWithContext(err, "invalid purpose"), http.StatusBadRequest)
+		return
+	}
+	pk, err := id.RotateKey(purpose)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to rotate key"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, IdentityRotatePOST{PublicKey: pk})
+}
+
+// identityExportHandlerPOST handles POST calls to /identity/export.
+func identityExportHandlerPOST(id modules.Identity, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	data, err := id.Export(req.FormValue("password"))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to export keystore"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, IdentityExportPOST{Keystore: string(data)})
+}
+
+// identityImportHandlerPOST handles POST calls to /identity/import.
+func identityImportHandlerPOST(id modules.Identity, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	keystore := req.FormValue("keystore")
+	if keystore == "" {
+		WriteError(w, Error{Message: "keystore must be provided"}, http.StatusBadRequest)
+		return
+	}
+	// Parse whether the host key may be replaced. (optional parameter)
+	var replaceHostKey bool
+	if r := req.FormValue("replacehostkey"); r != "" {
+		var err error
+		replaceHostKey, err = strconv.ParseBool(r)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse replacehostkey"), http.StatusBadRequest)
+			return
+		}
+	}
+	err := id.Import([]byte(keystore), req.FormValue("password"), replaceHostKey)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to import keystore"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
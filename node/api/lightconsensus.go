@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+type (
+	// LightConsensusGET contains the status of the light consensus set.
+	LightConsensusGET struct {
+		modules.LightConsensusStatus
+		Difficulty types.Currency `json:"difficulty"`
+	}
+
+	// LightConsensusWatchGET contains the addresses watched by the light
+	// consensus set.
+	LightConsensusWatchGET struct {
+		Addresses []types.UnlockHash `json:"addresses"`
+	}
+
+	// LightConsensusWatchPOST contains the addresses which should be added to
+	// or removed from the set of watched addresses. Added addresses are
+	// scanned starting at StartHeight.
+	LightConsensusWatchPOST struct {
+		Addresses   []types.UnlockHash `json:"addresses"`
+		StartHeight types.BlockHeight  `json:"startheight"`
+		Remove      bool               `json:"remove"`
+	}
+
+	// LightConsensusTransactionsGET contains the proven transactions of the
+	// watched addresses.
+	LightConsensusTransactionsGET struct {
+		Transactions []modules.TransactionProof `json:"transactions"`
+	}
+
+	// LightConsensusOutputsGET contains the unspent siacoin outputs of the
+	// watched addresses.
+	LightConsensusOutputsGET struct {
+		Outputs []modules.LightSiacoinOutput `json:"outputs"`
+	}
+
+	// LightConsensusFeeGET contains the fee estimation of the light consensus
+	// set.
+	LightConsensusFeeGET struct {
+		Minimum types.Currency `json:"minimum"`
+		Maximum types.Currency `json:"maximum"`
+	}
+)
+
+// RegisterRoutesLightConsensus is a helper function to register all light
+// consensus routes.
+func RegisterRoutesLightConsensus(router *httprouter.Router, ls modules.LightConsensusSet, requiredPassword string) {
+	router.GET("/consensus/light", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		lightConsensusHandler(ls, w, req, ps)
+	})
+	router.GET("/consensus/light/watch", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		lightConsensusWatchHandlerGET(ls, w, req, ps)
+	})
+	router.POST("/consensus/light/watch", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		lightConsensusWatchHandlerPOST(ls, w, req, ps)
+	}, requiredPassword))
+	router.GET("/consensus/light/transactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		lightConsensusTransactionsHandler(ls, w, req, ps)
+	})
+	router.GET("/consensus/light/outputs", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		lightConsensusOutputsHandler(ls, w, req, ps)
+	})
+	router.GET("/consensus/light/fee", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		lightConsensusFeeHandler(ls, w, req, ps)
+	})
+}
+
+// lightConsensusHandler handles the API calls to /consensus/light.
+func lightConsensusHandler(ls modules.LightConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status := ls.Status()
+	WriteJSON(w, LightConsensusGET{
+		LightConsensusStatus: status,
+		Difficulty:           status.Target.Difficulty(),
+	})
+}
+
+// lightConsensusWatchHandlerGET handles GET calls to /consensus/light/watch.
+func lightConsensusWatchHandlerGET(ls modules.LightConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	addrs, err := ls.WatchedAddresses()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get watched addresses"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, LightConsensusWatchGET{Addresses: addrs})
+}
+
+// lightConsensusWatchHandlerPOST handles POST calls to /consensus/light/watch.
+func lightConsensusWatchHandlerPOST(ls modules.LightConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var lcwp LightConsensusWatchPOST
+	err := json.NewDecoder(req.Body).Decode(&lcwp)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
+		return
+	}
+	if lcwp.Remove {
+		err = ls.UnwatchAddresses(lcwp.Addresses)
+	} else {
+		err = ls.WatchAddresses(lcwp.Addresses, lcwp.StartHeight)
+	}
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to update watched addresses"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// lightConsensusTransactionsHandler handles the API calls to
+// /consensus/light/transactions.
+func lightConsensusTransactionsHandler(ls modules.LightConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	txns, err := ls.Transactions()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get transactions"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, LightConsensusTransactionsGET{Transactions: txns})
+}
+
+// lightConsensusOutputsHandler handles the API calls to
+// /consensus/light/outputs.
+func lightConsensusOutputsHandler(ls modules.LightConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	outputs, err := ls.UnspentOutputs()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get unspent outputs"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, LightConsensusOutputsGET{Outputs: outputs})
+}
+
+// lightConsensusFeeHandler handles the API calls to /consensus/light/fee.
+func lightConsensusFeeHandler(ls modules.LightConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	min, max, err := ls.FeeEstimation()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to estimate fees"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, LightConsensusFeeGET{Minimum: min, Maximum: max})
+}
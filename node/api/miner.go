@@ -72,7 +72,7 @@ func minerStopHandler(miner modules.Miner, w http.ResponseWriter, _ *http.Reques
 func minerHeaderHandlerGET(miner modules.Miner, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	bhfw, target, err := miner.HeaderForWork()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	w.Write(encoding.MarshalAll(target, bhfw))
@@ -84,12 +84,12 @@ func minerHeaderHandlerPOST(miner modules.Miner, w http.ResponseWriter, req *htt
 	var bh types.BlockHeader
 	err := encoding.NewDecoder(req.Body, encoding.DefaultAllocLimit).Decode(&bh)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	err = miner.SubmitHeader(bh)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -101,12 +101,12 @@ func minerBlockHandlerPOST(miner modules.Miner, w http.ResponseWriter, req *http
 	var b types.Block
 	err := encoding.NewDecoder(req.Body, encoding.DefaultAllocLimit).Decode(&b)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	err = miner.SubmitBlock(b)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
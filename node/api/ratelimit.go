@@ -0,0 +1,208 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/build"
+)
+
+const (
+	// RateLimitRenterDownload is the group of the endpoints which download
+	// and stream files.
+	RateLimitRenterDownload RateLimitGroup = "renter-download"
+
+	// RateLimitRenterUpload is the group of the endpoints which upload files.
+	RateLimitRenterUpload RateLimitGroup = "renter-upload"
+
+	// RateLimitRenterAdmin is the group of all other renter endpoints.
+	RateLimitRenterAdmin RateLimitGroup = "renter-admin"
+
+	// RateLimitWallet is the group of the wallet endpoints.
+	RateLimitWallet RateLimitGroup = "wallet"
+)
+
+var (
+	// RateLimitGroups are the endpoint groups which can be rate limited.
+	RateLimitGroups = []RateLimitGroup{RateLimitRenterDownload, RateLimitRenterUpload, RateLimitRenterAdmin, RateLimitWallet}
+
+	// rateLimitDownloadPrefixes are the path prefixes of the endpoints in the
+	// RateLimitRenterDownload group.
+	rateLimitDownloadPrefixes = []string{"/renter/download/", "/renter/downloadasync/", "/renter/stream/"}
+
+	// rateLimitUploadPrefixes are the path prefixes of the endpoints in the
+	// RateLimitRenterUpload group.
+	rateLimitUploadPrefixes = []string{"/renter/upload/", "/renter/uploadstream/", "/renter/uploadurl/"}
+
+	// rateLimitMaxBuckets is the number of client buckets at which buckets
+	// which have been refilled completely are pruned.
+	rateLimitMaxBuckets = build.Select(build.Var{
+		Standard: 10000,
+		Dev:      1000,
+		Testing:  10,
+	}).(int)
+)
+
+type (
+	// RateLimitGroup is a group of API endpoints which share a rate limit.
+	RateLimitGroup string
+
+	// RateLimit is the rate limit of a group of endpoints. Every client gets
+	// its own bucket of Burst requests which is refilled at Rate requests per
+	// second.
+	RateLimit struct {
+		Rate  float64
+		Burst int
+	}
+
+	// RateLimitSettings maps the endpoint groups to their rate limits. Groups
+	// without a rate limit are unlimited.
+	RateLimitSettings map[RateLimitGroup]RateLimit
+
+	// rateLimiter applies the API's rate limits to requests.
+	rateLimiter struct {
+		settings RateLimitSettings
+		buckets  map[rateLimitKey]*rateLimitBucket
+		mu       sync.Mutex
+	}
+
+	// rateLimitKey identifies the bucket of a client within a group. Clients
+	// which authenticate with the API password share a bucket, all other
+	// clients are identified by their IP.
+	rateLimitKey struct {
+		group         RateLimitGroup
+		authenticated bool
+		ip            string
+	}
+
+	// rateLimitBucket is a token bucket which holds the remaining requests of
+	// a client.
+	rateLimitBucket struct {
+		tokens     float64
+		lastRefill time.Time
+	}
+)
+
+// SetRateLimits replaces the rate limits of the API. All clients start with a
+// full bucket.
+func (api *API) SetRateLimits(settings RateLimitSettings) {
+	api.staticRateLimiter.mu.Lock()
+	api.staticRateLimiter.settings = settings
+	api.staticRateLimiter.buckets = make(map[rateLimitKey]*rateLimitBucket)
+	api.staticRateLimiter.mu.Unlock()
+}
+
+// rateLimitGroupOf returns the rate limit group of the endpoint at the
+// provided path.
+func rateLimitGroupOf(path string) (RateLimitGroup, bool) {
+	hasPrefix := func(prefixes []string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case hasPrefix(rateLimitDownloadPrefixes):
+		return RateLimitRenterDownload, true
+	case hasPrefix(rateLimitUploadPrefixes):
+		return RateLimitRenterUpload, true
+	case path == "/renter" || strings.HasPrefix(path, "/renter/"):
+		return RateLimitRenterAdmin, true
+	case path == "/wallet" || strings.HasPrefix(path, "/wallet/"):
+		return RateLimitWallet, true
+	}
+	return "", false
+}
+
+// take refills the bucket and takes a token from it. If the bucket is empty,
+// it returns the time until the next token is available.
+func (b *rateLimitBucket) take(limit RateLimit, now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.lastRefill).Seconds()*limit.Rate)
+	b.lastRefill = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if limit.Rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+}
+
+// full returns whether the bucket would have been refilled completely at the
+// provided time.
+func (b *rateLimitBucket) full(limit RateLimit, now time.Time) bool {
+	return b.tokens+now.Sub(b.lastRefill).Seconds()*limit.Rate >= float64(limit.Burst)
+}
+
+// managedTake takes a token from the client's bucket. It returns the number of
+// remaining tokens and the time until the next token is available if the
+// bucket was empty.
+func (rl *rateLimiter) managedTake(key rateLimitKey, limit RateLimit, now time.Time) (bool, int, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	b, exists := rl.buckets[key]
+	if !exists {
+		// Prune the buckets which have been refilled since they are
+		// equivalent to new ones.
+		if len(rl.buckets) >= rateLimitMaxBuckets {
+			for k, bucket := range rl.buckets {
+				if bucket.full(rl.settings[k.group], now) {
+					delete(rl.buckets, k)
+				}
+			}
+		}
+		b = &rateLimitBucket{tokens: float64(limit.Burst), lastRefill: now}
+		rl.buckets[key] = b
+	}
+	ok, wait := b.take(limit, now)
+	return ok, int(b.tokens), wait
+}
+
+// withRateLimit is middleware that rejects requests with status 429 once the
+// client exceeded the rate limit of the endpoint's group. Clients which
+// authenticate with the provided password have their own bucket, which
+// prevents unauthenticated clients from starving them.
+func (rl *rateLimiter) withRateLimit(h http.Handler, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		group, ok := rateLimitGroupOf(req.URL.Path)
+		if !ok {
+			h.ServeHTTP(w, req)
+			return
+		}
+		rl.mu.Lock()
+		limit, limited := rl.settings[group]
+		rl.mu.Unlock()
+		if !limited {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		key := rateLimitKey{group: group}
+		if _, pass, ok := req.BasicAuth(); ok && password != "" && pass == password {
+			key.authenticated = true
+		} else if ip, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+			key.ip = ip
+		} else {
+			key.ip = req.RemoteAddr
+		}
+
+		allowed, remaining, wait := rl.managedTake(key, limit, time.Now())
+		header := w.Header()
+		header.Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			header.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+			WriteError(w, Error{Message: "rate limit exceeded for " + string(group) + " endpoints", Code: ErrorCodeRateLimited}, http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRateLimitGroupOf probes rateLimitGroupOf.
+func TestRateLimitGroupOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path  string
+		group RateLimitGroup
+	}{
+		{"/renter/download/foo", RateLimitRenterDownload},
+		{"/renter/downloadasync/foo", RateLimitRenterDownload},
+		{"/renter/stream/foo", RateLimitRenterDownload},
+		{"/renter/upload/foo", RateLimitRenterUpload},
+		{"/renter/uploadstream/foo", RateLimitRenterUpload},
+		{"/renter/uploadurl/foo", RateLimitRenterUpload},
+		{"/renter", RateLimitRenterAdmin},
+		{"/renter/files", RateLimitRenterAdmin},
+		{"/renter/downloads", RateLimitRenterAdmin},
+		{"/wallet", RateLimitWallet},
+		{"/wallet/seeds", RateLimitWallet},
+		{"/renterfoo", ""},
+		{"/daemon/version", ""},
+	}
+	for _, tt := range tests {
+		if group, _ := rateLimitGroupOf(tt.path); group != tt.group {
+			t.Errorf("%v: expected group %q, got %q", tt.path, tt.group, group)
+		}
+	}
+}
+
+// TestRateLimit probes the rate limit middleware of the API.
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	rl := &rateLimiter{}
+	handler := rl.withRateLimit(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), "foo")
+	request := func(path, remoteAddr, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if password != "" {
+			req.SetBasicAuth("", password)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Without rate limits requests are passed through unchanged.
+	for i := 0; i < 10; i++ {
+		rec := request("/renter/stream/foo", "1.2.3.4:1234", "")
+		if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatal("unexpected response", rec.Code, rec.Header())
+		}
+	}
+
+	// Limit downloads to a burst of 2 requests which is refilled very slowly.
+	rl.settings = RateLimitSettings{RateLimitRenterDownload: {Rate: 0.001, Burst: 2}}
+	rl.buckets = make(map[rateLimitKey]*rateLimitBucket)
+	for i := 0; i < 2; i++ {
+		rec := request("/renter/stream/foo", "1.2.3.4:1234", "")
+		if rec.Code != http.StatusOK {
+			t.Fatal("request was rejected", i, rec.Code)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "2" || rec.Header().Get("X-RateLimit-Remaining") != fmt.Sprint(1-i) {
+			t.Fatal("unexpected headers", rec.Header())
+		}
+	}
+	rec := request("/renter/stream/foo", "1.2.3.4:5678", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatal("request wasn't rejected", rec.Code)
+	}
+	if retry := rec.Header().Get("Retry-After"); retry != "1000" {
+		t.Fatal("unexpected Retry-After", retry)
+	}
+	var apiErr Error
+	if err := json.NewDecoder(rec.Body).Decode(&apiErr); err != nil || apiErr.Code != ErrorCodeRateLimited {
+		t.Fatal("unexpected error", apiErr, err)
+	}
+
+	// Other groups, other clients and authenticated clients aren't affected.
+	if rec := request("/renter/files", "1.2.3.4:1234", ""); rec.Code != http.StatusOK {
+		t.Fatal("admin request was rejected", rec.Code)
+	}
+	if rec := request("/renter/stream/foo", "5.6.7.8:1234", ""); rec.Code != http.StatusOK {
+		t.Fatal("request of another client was rejected", rec.Code)
+	}
+	if rec := request("/renter/stream/foo", "1.2.3.4:1234", "foo"); rec.Code != http.StatusOK {
+		t.Fatal("authenticated request was rejected", rec.Code)
+	}
+	// A wrong password doesn't grant access to the authenticated bucket.
+	if rec := request("/renter/stream/foo", "1.2.3.4:1234", "bar"); rec.Code != http.StatusTooManyRequests {
+		t.Fatal("request with a wrong password wasn't rejected", rec.Code)
+	}
+}
+
+// TestRateLimitBucket probes refilling rate limit buckets.
+func TestRateLimitBucket(t *testing.T) {
+	t.Parallel()
+
+	limit := RateLimit{Rate: 2, Burst: 3}
+	now := time.Now()
+	b := &rateLimitBucket{tokens: 3, lastRefill: now}
+	for i := 0; i < 3; i++ {
+		if ok, _ := b.take(limit, now); !ok {
+			t.Fatal("token wasn't taken", i)
+		}
+	}
+	ok, wait := b.take(limit, now)
+	if ok || wait != 500*time.Millisecond {
+		t.Fatal("unexpected result for empty bucket", ok, wait)
+	}
+	if b.full(limit, now.Add(time.Second)) || !b.full(limit, now.Add(1500*time.Millisecond)) {
+		t.Fatal("bucket refilled at the wrong rate")
+	}
+
+	// The bucket doesn't hold more than the burst.
+	if ok, _ := b.take(limit, now.Add(time.Hour)); !ok || b.tokens != 2 {
+		t.Fatal("unexpected tokens", b.tokens)
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -174,6 +176,11 @@ type (
 		File modules.FileInfo `json:"file"`
 	}
 
+	// RenterFileLayout contains the layout of the file queried.
+	RenterFileLayout struct {
+		Layout modules.FileLayout `json:"layout"`
+	}
+
 	// RenterFiles lists the files known to the renter.
 	RenterFiles struct {
 		Files []modules.FileInfo `json:"files"`
@@ -189,6 +196,29 @@ type (
 		FilesAdded []string `json:"filesadded"`
 	}
 
+	// RenterAllowanceProfilesGET contains the renter's allowance profiles.
+	RenterAllowanceProfilesGET struct {
+		Profiles []modules.AllowanceProfile `json:"profiles"`
+	}
+
+	// RenterAllowanceProfileDiffGET contains a preview of the changes to the
+	// renter's contracts which activating an allowance profile would cause.
+	RenterAllowanceProfileDiffGET struct {
+		Diff modules.AllowanceProfileDiff `json:"diff"`
+	}
+
+	// RenterContractPricesGET contains the latest comparison of the projected
+	// renewal costs of the renter's contracts against the market rate.
+	RenterContractPricesGET struct {
+		Report modules.ContractPriceReport `json:"report"`
+	}
+
+	// RenterMigrationsGET contains the renter's migrations of data away from
+	// blocklisted hosts and hosts which were removed from the hostdb.
+	RenterMigrationsGET struct {
+		Migrations []modules.HostMigration `json:"migrations"`
+	}
+
 	// RenterPricesGET lists the data that is returned when a GET call is made
 	// to /renter/prices.
 	RenterPricesGET struct {
@@ -201,6 +231,40 @@ type (
 		ScanInProgress bool              `json:"scaninprogress"`
 		ScannedHeight  types.BlockHeight `json:"scannedheight"`
 	}
+	// RenterRestoreModeGET contains the status of the renter's restore mode.
+	RenterRestoreModeGET struct {
+		modules.RestoreModeStatus
+	}
+	// RenterAccountSpendingCapsGET contains the caps on the money the
+	// renter's workers may spend from the ephemeral accounts of hosts.
+	RenterAccountSpendingCapsGET struct {
+		modules.AccountSpendingCaps
+	}
+	// RenterBandwidthGET contains the bandwidth the renter used per category
+	// of traffic and the caps of the categories.
+	RenterBandwidthGET struct {
+		Categories []modules.RenterBandwidthUsage `json:"categories"`
+	}
+	// RenterConcurrencyGET contains the configured and the effective
+	// concurrency limits of the renter.
+	RenterConcurrencyGET struct {
+		modules.RenterConcurrency
+	}
+	// RenterPlacementGET contains the anti-affinity constraints of the
+	// renter's piece placement.
+	RenterPlacementGET struct {
+		modules.RenterPlacementSettings
+	}
+	// RenterTimeoutsGET contains the configured and the effective timeouts
+	// of the renter.
+	RenterTimeoutsGET struct {
+		modules.RenterTimeouts
+	}
+	// RenterStreamCacheGET contains the settings and statistics of the
+	// renter's stream cache.
+	RenterStreamCacheGET struct {
+		modules.StreamCacheStats
+	}
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
 		ASCIIsia string `json:"asciisia"`
@@ -222,6 +286,42 @@ type (
 		UnsyncedHosts []types.SiaPublicKey   `json:"unsyncedhosts"`
 	}
 
+	// RenterBackupContentsGET lists the files contained in an uploaded
+	// backup.
+	RenterBackupContentsGET struct {
+		Files []modules.BackupFile `json:"files"`
+	}
+
+	// RenterLegacyImportPOST contains the files imported from a legacy .sia
+	// file.
+	RenterLegacyImportPOST struct {
+		Files []modules.LegacySiaFileImport `json:"files"`
+	}
+
+	// RenterDirConversionGET contains the progress of the current or last
+	// directory conversion.
+	RenterDirConversionGET struct {
+		Conversion modules.DirConversion `json:"conversion"`
+	}
+
+	// RenterFsckPOST contains the report of a check of the renter's
+	// filesystem.
+	RenterFsckPOST struct {
+		Report modules.FsckReport `json:"report"`
+	}
+
+	// RenterReplicationGET contains the status of the replication of a
+	// primary renter's metadata.
+	RenterReplicationGET struct {
+		modules.ReplicationStatus
+	}
+
+	// RenterSiaFileImportPOST contains information about a file imported from
+	// an exported siafile.
+	RenterSiaFileImportPOST struct {
+		Import modules.SiaFileImport `json:"import"`
+	}
+
 	// RenterUploadReadyGet lists the upload ready status of the renter
 	RenterUploadReadyGet struct {
 		// Ready indicates whether of not the renter is ready to successfully
@@ -238,6 +338,53 @@ type (
 		ParityPieces int `json:"paritypieces"`
 	}
 
+	// RenterUploadURLPOST is the response to a POST request to
+	// /renter/uploadurl.
+	RenterUploadURLPOST struct {
+		ID modules.URLUploadID `json:"id"`
+	}
+
+	// RenterAliasGET contains information about an alias in the renter's
+	// filesystem.
+	RenterAliasGET struct {
+		Alias modules.AliasInfo `json:"alias"`
+	}
+
+	// RenterBenchmarkPOST contains the results of benchmarking hosts.
+	RenterBenchmarkPOST struct {
+		Hosts []modules.HostBenchmark `json:"hosts"`
+	}
+
+	// RenterContractSectorRootsGET contains the sector roots of a contract
+	// fetched from its host.
+	RenterContractSectorRootsGET struct {
+		modules.ContractSectorRoots
+	}
+
+	// RenterDelegateKeyGET contains the public key which identifies the
+	// renter as a delegate of other renters.
+	RenterDelegateKeyGET struct {
+		DelegateKey types.SiaPublicKey `json:"delegatekey"`
+	}
+
+	// RenterContractDelegatePOST contains the authorization a renter issued
+	// to a delegate.
+	RenterContractDelegatePOST struct {
+		modules.DelegatedReadAuthorization
+	}
+
+	// RenterDelegatedReadPOST contains the parameters to download a sector of
+	// another renter's contract as a delegate.
+	RenterDelegatedReadPOST struct {
+		Authorization modules.DelegatedReadAuthorization `json:"authorization"`
+		Root          crypto.Hash                        `json:"root"`
+	}
+
+	// RenterURLUploadsGET lists the renter's uploads from remote URLs.
+	RenterURLUploadsGET struct {
+		URLUploads []modules.URLUploadInfo `json:"urluploads"`
+	}
+
 	// DownloadInfo contains all client-facing information of a file.
 	DownloadInfo struct {
 		Destination     string          `json:"destination"`     // The destination of the download.
@@ -254,6 +401,8 @@ type (
 		StartTime            time.Time `json:"starttime"`            // The time when the download was started.
 		StartTimeUnix        int64     `json:"starttimeunix"`        // The time when the download was started in unix format.
 		TotalDataTransferred uint64    `json:"totaldatatransferred"` // The total amount of data transferred, including negotiation, overdrive etc.
+
+		Manifest *modules.DownloadManifest `json:"manifest,omitempty"` // The hashes of the downloaded data if requested.
 	}
 )
 
@@ -320,6 +469,136 @@ func trimDownloadInfo(dis ...modules.DownloadInfo) (_ []modules.DownloadInfo, er
 	return dis, nil
 }
 
+// trimURLUploadInfo is a helper method to trim /home/siafiles off of the
+// siapaths of the urluploadinfos since the user expects a path relative to
+// /home/siafiles and not relative to root.
+func trimURLUploadInfo(uis ...modules.URLUploadInfo) (_ []modules.URLUploadInfo, err error) {
+	for i := range uis {
+		uis[i].SiaPath, err = uis[i].SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return uis, nil
+}
+
+// renterBenchmarkHandlerPOST handles the API calls to /renter/benchmark.
+func (api *API) renterBenchmarkHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the optional list of hosts.
+	var hosts []types.SiaPublicKey
+	if h := req.FormValue("hosts"); h != "" {
+		for _, str := range strings.Split(h, ",") {
+			var spk types.SiaPublicKey
+			if err := spk.LoadString(strings.TrimSpace(str)); err != nil {
+				WriteError(w, Error{Message: fmt.Sprintf("unable to parse host '%v': %v", str, err)}, http.StatusBadRequest)
+				return
+			}
+			hosts = append(hosts, spk)
+		}
+	}
+	results, err := api.renter.BenchmarkHosts(hosts)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to benchmark hosts"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterBenchmarkPOST{Hosts: results})
+}
+
+// parseAliasSiaPath parses a siapath for the /renter/alias endpoints and
+// rebases it to the user folder unless root is set.
+func parseAliasSiaPath(str string, root bool) (modules.SiaPath, error) {
+	siaPath, err := modules.NewSiaPath(str)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	if root {
+		return siaPath, nil
+	}
+	return rebaseInputSiaPath(siaPath)
+}
+
+// trimAliasInfo is a helper method to trim /home/siafiles off of the siapaths
+// of an alias. Siapaths outside of /home/siafiles are left untouched.
+func trimAliasInfo(ai modules.AliasInfo) modules.AliasInfo {
+	trim := func(sp modules.SiaPath) modules.SiaPath {
+		if !sp.Equals(modules.UserFolder) && !strings.HasPrefix(sp.String(), modules.UserFolder.String()+"/") {
+			return sp
+		}
+		trimmed, err := sp.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			return sp
+		}
+		return trimmed
+	}
+	ai.SiaPath = trim(ai.SiaPath)
+	ai.Target = trim(ai.Target)
+	ai.Resolved = trim(ai.Resolved)
+	return ai
+}
+
+// renterAliasHandlerGET handles the API call to GET /renter/alias.
+func (api *API) renterAliasHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	siaPath, err := parseAliasSiaPath(ps.ByName("siapath"), root)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	ai, err := api.renter.Alias(siaPath)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get alias"), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		ai = trimAliasInfo(ai)
+	}
+	WriteJSON(w, RenterAliasGET{Alias: ai})
+}
+
+// renterAliasHandlerPOST handles the API call to POST /renter/alias.
+func (api *API) renterAliasHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	siaPath, err := parseAliasSiaPath(ps.ByName("siapath"), root)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	switch action := req.FormValue("action"); action {
+	case "create":
+		target, err := parseAliasSiaPath(req.FormValue("target"), root)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "failed to parse target"), http.StatusBadRequest)
+			return
+		}
+		err = api.renter.CreateAlias(siaPath, target)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "failed to create alias"), http.StatusBadRequest)
+			return
+		}
+	case "delete":
+		err = api.renter.DeleteAlias(siaPath)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "failed to delete alias"), http.StatusBadRequest)
+			return
+		}
+	case "":
+		WriteError(w, Error{Message: "you must set the action you wish to execute"}, http.StatusBadRequest)
+		return
+	default:
+		WriteError(w, Error{Message: fmt.Sprintf("unknown action '%v'", action)}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterBubbleHandlerPOST handles the API calls to /renter/bubble.
 func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Parse the 'rootsiapath' parameter
@@ -328,7 +607,7 @@ func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request
 	if r := req.FormValue("rootsiapath"); r != "" {
 		rootSiaPath, err = scanBool(r)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'rootsiapath' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'rootsiapath' parameter"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -337,11 +616,11 @@ func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request
 	var siaPath modules.SiaPath
 	s := req.FormValue("siapath")
 	if rootSiaPath && s != "" {
-		WriteError(w, Error{"rootsiapath and non empty siapath cannot both be used"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "rootsiapath and non empty siapath cannot both be used"}, http.StatusBadRequest)
 		return
 	}
 	if !rootSiaPath && s == "" {
-		WriteError(w, Error{"rootsiapath should be true if no siapath is provided"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "rootsiapath should be true if no siapath is provided"}, http.StatusBadRequest)
 		return
 	}
 	if rootSiaPath {
@@ -349,7 +628,7 @@ func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request
 	} else {
 		err = siaPath.LoadString(s)
 		if err != nil {
-			WriteError(w, Error{"unable to parse siapath: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse siapath"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -359,7 +638,7 @@ func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request
 	if f := req.FormValue("force"); f != "" {
 		force, err = scanBool(f)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'force' parameter"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -368,7 +647,7 @@ func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request
 	if r := req.FormValue("recursive"); r != "" {
 		recursive, err = strconv.ParseBool(r)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'recursive' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'recursive' parameter"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -376,7 +655,7 @@ func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request
 	// Call bubble
 	err = api.renter.BubbleMetadata(siaPath, force, recursive)
 	if err != nil {
-		WriteError(w, Error{"unable to bubble directory: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "unable to bubble directory"), http.StatusInternalServerError)
 		return
 	}
 	WriteSuccess(w)
@@ -387,7 +666,7 @@ func (api *API) renterBubbleHandlerPOST(w http.ResponseWriter, req *http.Request
 func (api *API) renterBackupsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	backups, syncedHosts, err := api.renter.UploadedBackups()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	var unsyncedHosts []types.SiaPublicKey
@@ -406,12 +685,12 @@ outer:
 		var hostKey types.SiaPublicKey
 		hostKey.LoadString(req.FormValue("host"))
 		if hostKey.Key == nil {
-			WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "invalid host public key"}, http.StatusBadRequest)
 			return
 		}
 		backups, err = api.renter.BackupsOnHost(hostKey)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
@@ -437,14 +716,14 @@ func (api *API) renterBackupsCreateHandlerPOST(w http.ResponseWriter, req *http.
 	// Check that a name was specified.
 	name := req.FormValue("name")
 	if name == "" {
-		WriteError(w, Error{"name not specified"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "name not specified"}, http.StatusBadRequest)
 		return
 	}
 
 	// Write the backup to a temporary file and delete it after uploading.
 	tmpDir, err := ioutil.TempDir("", "sia-backup")
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	randomSuffix := persist.RandomSuffix()
@@ -458,7 +737,7 @@ func (api *API) renterBackupsCreateHandlerPOST(w http.ResponseWriter, req *http.
 	// Get the wallet seed.
 	ws, _, err := api.wallet.PrimarySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "failed to get wallet's primary seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -469,12 +748,12 @@ func (api *API) renterBackupsCreateHandlerPOST(w http.ResponseWriter, req *http.
 	defer fastrand.Read(secret[:])
 	// Create the backup.
 	if err := api.renter.CreateBackup(backupPath, secret[:32]); err != nil {
-		WriteError(w, Error{"failed to create backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to create backup"), http.StatusBadRequest)
 		return
 	}
 	// Upload the backup.
 	if err := api.renter.UploadBackup(backupPath, name); err != nil {
-		WriteError(w, Error{"failed to upload backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to upload backup"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -485,13 +764,13 @@ func (api *API) renterBackupsRestoreHandlerGET(w http.ResponseWriter, req *http.
 	// Check that a name was specified.
 	name := req.FormValue("name")
 	if name == "" {
-		WriteError(w, Error{"name not specified"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "name not specified"}, http.StatusBadRequest)
 		return
 	}
 	// Write the backup to a temporary file and delete it after loading.
 	tmpDir, err := ioutil.TempDir("", "sia-backup")
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	defer func() {
@@ -499,13 +778,13 @@ func (api *API) renterBackupsRestoreHandlerGET(w http.ResponseWriter, req *http.
 	}()
 	backupPath := filepath.Join(tmpDir, name)
 	if err := api.renter.DownloadBackup(backupPath, name); err != nil {
-		WriteError(w, Error{"failed to download backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to download backup"), http.StatusBadRequest)
 		return
 	}
 	// Get the wallet seed.
 	ws, _, err := api.wallet.PrimarySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "failed to get wallet's primary seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -516,29 +795,132 @@ func (api *API) renterBackupsRestoreHandlerGET(w http.ResponseWriter, req *http.
 	defer fastrand.Read(secret[:])
 	// Load the backup.
 	if err := api.renter.LoadBackup(backupPath, secret[:32]); err != nil {
-		WriteError(w, Error{"failed to load backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to load backup"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterBackupsContentsHandlerGET handles the API calls to
+// /renter/backups/contents
+func (api *API) renterBackupsContentsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	backupPath, secret, cleanup, err := api.managedDownloadBackup(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	defer cleanup()
+	files, err := api.renter.BackupContents(backupPath, secret)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to read backup"), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		for i := range files {
+			files[i].SiaPath = rebaseOutputSiaPath(files[i].SiaPath)
+		}
+	}
+	WriteJSON(w, RenterBackupContentsGET{Files: files})
+}
+
+// renterBackupsRestoreFilesHandlerPOST handles the API calls to
+// /renter/backups/restorefiles
+func (api *API) renterBackupsRestoreFilesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	// Parse the siapaths.
+	if req.FormValue("siapaths") == "" {
+		WriteError(w, Error{Message: "siapaths not specified"}, http.StatusBadRequest)
+		return
+	}
+	var siaPaths []modules.SiaPath
+	for _, str := range strings.Split(req.FormValue("siapaths"), ",") {
+		siaPath, err := modules.NewSiaPath(str)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+		if !root {
+			siaPath, err = rebaseInputSiaPath(siaPath)
+			if err != nil {
+				WriteError(w, NewError(err), http.StatusBadRequest)
+				return
+			}
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+	backupPath, secret, cleanup, err := api.managedDownloadBackup(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	defer cleanup()
+	if err := api.renter.LoadBackupFiles(backupPath, secret, siaPaths); err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to load files from backup"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
+// managedDownloadBackup downloads the uploaded backup with the given name to a
+// temporary file and derives the secret to decrypt it. The returned function
+// deletes the file and wipes the secret.
+func (api *API) managedDownloadBackup(name string) (backupPath string, secret []byte, cleanup func(), err error) {
+	if name == "" {
+		return "", nil, nil, errors.New("name not specified")
+	}
+	tmpDir, err := ioutil.TempDir("", "sia-backup")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	backupPath = filepath.Join(tmpDir, name)
+	if err := api.renter.DownloadBackup(backupPath, name); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", nil, nil, errors.AddContext(err, "failed to download backup")
+	}
+	// Get the wallet seed.
+	ws, _, err := api.wallet.PrimarySeed()
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", nil, nil, errors.New("failed to get wallet's primary seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	// Derive the secret.
+	key := crypto.HashAll(rs, modules.BackupKeySpecifier)
+	cleanup = func() {
+		fastrand.Read(key[:])
+		_ = os.RemoveAll(tmpDir)
+	}
+	return backupPath, key[:32], cleanup, nil
+}
+
 // renterBackupHandlerPOST handles the API calls to /renter/backup
 func (api *API) renterBackupHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Check that destination was specified.
 	dst := req.FormValue("destination")
 	if dst == "" {
-		WriteError(w, Error{"destination not specified"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "destination not specified"}, http.StatusBadRequest)
 		return
 	}
 	// The destination needs to be an absolute path.
 	if !filepath.IsAbs(dst) {
-		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "destination must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
 	// Get the wallet seed.
 	ws, _, err := api.wallet.PrimarySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "failed to get wallet's primary seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -549,7 +931,7 @@ func (api *API) renterBackupHandlerPOST(w http.ResponseWriter, req *http.Request
 	defer fastrand.Read(secret[:])
 	// Create the backup.
 	if err := api.renter.CreateBackup(dst, secret[:32]); err != nil {
-		WriteError(w, Error{"failed to create backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to create backup"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -560,18 +942,18 @@ func (api *API) renterLoadBackupHandlerPOST(w http.ResponseWriter, req *http.Req
 	// Check that source was specified.
 	src := req.FormValue("source")
 	if src == "" {
-		WriteError(w, Error{"source not specified"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "source not specified"}, http.StatusBadRequest)
 		return
 	}
 	// The source needs to be an absolute path.
 	if !filepath.IsAbs(src) {
-		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "source must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
 	// Get the wallet seed.
 	ws, _, err := api.wallet.PrimarySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "failed to get wallet's primary seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -582,96 +964,403 @@ func (api *API) renterLoadBackupHandlerPOST(w http.ResponseWriter, req *http.Req
 	defer fastrand.Read(secret[:])
 	// Load the backup.
 	if err := api.renter.LoadBackup(src, secret[:32]); err != nil {
-		WriteError(w, Error{"failed to load backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to load backup"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
-// parseErasureCodingParameters parses the supplied string values and creates
-// an erasure coder. If values haven't been supplied it will fill in sane
-// defaults.
-func parseErasureCodingParameters(strDataPieces, strParityPieces string) (modules.ErasureCoder, error) {
-	// Parse data and parity pieces
-	dataPieces, parityPieces, err := ParseDataAndParityPieces(strDataPieces, strParityPieces)
-	if err != nil {
-		return nil, err
+// renterLegacyImportHandlerPOST handles the API calls to /renter/legacyimport
+func (api *API) renterLegacyImportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that source was specified.
+	src := req.FormValue("source")
+	if src == "" {
+		WriteError(w, Error{Message: "source not specified"}, http.StatusBadRequest)
+		return
 	}
-
-	// Check if data and parity pieces were set
-	if dataPieces == 0 && parityPieces == 0 {
-		return nil, nil
+	// The source needs to be an absolute path.
+	if !filepath.IsAbs(src) {
+		WriteError(w, Error{Message: "source must be an absolute path"}, http.StatusBadRequest)
+		return
 	}
-
-	// Verify that sane values for parityPieces and redundancy are being
-	// supplied.
-	if parityPieces < requiredParityPieces {
-		err := fmt.Errorf("a minimum of %v parity pieces is required, but %v parity pieces requested", requiredParityPieces, parityPieces)
-		return nil, err
+	// Parse the optional directory to import the files into.
+	dir := modules.RootSiaPath()
+	if siaPath := req.FormValue("siapath"); siaPath != "" {
+		if err := dir.LoadString(siaPath); err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'siapath' parameter"), http.StatusBadRequest)
+			return
+		}
 	}
-	redundancy := float64(dataPieces+parityPieces) / float64(dataPieces)
-	if float64(dataPieces+parityPieces)/float64(dataPieces) < requiredRedundancy {
-		err := fmt.Errorf("a redundancy of %.2f is required, but redundancy of %.2f supplied", requiredRedundancy, redundancy)
-		return nil, err
+	// Import the files.
+	files, err := api.renter.ImportLegacySiaFiles(src, dir)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to import legacy .sia file"), http.StatusBadRequest)
+		return
 	}
-
-	// Create the erasure coder.
-	return modules.NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
+	WriteJSON(w, RenterLegacyImportPOST{Files: files})
 }
 
-// ParseDataAndParityPieces parse the numeric values for dataPieces and
-// parityPieces from the input strings
-func ParseDataAndParityPieces(strDataPieces, strParityPieces string) (dataPieces, parityPieces int, err error) {
-	// Check that both values have been supplied.
-	if (strDataPieces == "") != (strParityPieces == "") {
-		return 0, 0, errNeedBothDataAndParityPieces
-	}
-
-	// Check for blank strings.
-	if strDataPieces == "" && strParityPieces == "" {
-		return 0, 0, nil
-	}
-
-	// Parse dataPieces and Parity Pieces.
-	_, err = fmt.Sscan(strDataPieces, &dataPieces)
+// renterExportHandlerPOST handles the API calls to /renter/export/:siapath
+func (api *API) renterExportHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		err = errors.AddContext(err, "unable to read parameter 'datapieces'")
-		return 0, 0, err
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
 	}
-	_, err = fmt.Sscan(strParityPieces, &parityPieces)
+	root, err := isCalledWithRootFlag(req)
 	if err != nil {
-		err = errors.AddContext(err, "unable to read parameter 'paritypieces'")
-		return 0, 0, err
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
 	}
-
-	// Check that either both values are zero or neither are zero
-	if (dataPieces == 0) != (parityPieces == 0) {
-		return 0, 0, errNeedBothDataAndParityPieces
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
 	}
-
-	return dataPieces, parityPieces, nil
-}
-
-// renterHandlerGET handles the API call to /renter.
-func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	settings, err := api.renter.Settings()
-	if err != nil {
-		WriteError(w, Error{"unable able to get renter settings: " + err.Error()}, http.StatusBadRequest)
+	// The destination needs to be an absolute path.
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{Message: "destination not specified"}, http.StatusBadRequest)
 		return
 	}
-	spending, err := api.renter.PeriodSpending()
+	if !filepath.IsAbs(dst) {
+		WriteError(w, Error{Message: "destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.ExportSiaFile(siaPath, dst)
 	if err != nil {
-		WriteError(w, Error{"unable to get Period Spending: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to export siafile"), http.StatusBadRequest)
 		return
 	}
-	currentPeriod := api.renter.CurrentPeriod()
-	nextPeriod := currentPeriod + settings.Allowance.Period
-	memoryStatus, err := api.renter.MemoryStatus()
+	WriteSuccess(w)
+}
+
+// renterImportHandlerPOST handles the API calls to /renter/import/:siapath
+func (api *API) renterImportHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{"unable to get renter memory information: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
-	WriteJSON(w, RenterGET{
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+	}
+	// The source needs to be an absolute path.
+	src := req.FormValue("source")
+	if src == "" {
+		WriteError(w, Error{Message: "source not specified"}, http.StatusBadRequest)
+		return
+	}
+	if !filepath.IsAbs(src) {
+		WriteError(w, Error{Message: "source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Repair the file by default.
+	repair := true
+	if r := req.FormValue("repair"); r != "" {
+		repair, err = scanBool(r)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'repair' parameter"), http.StatusBadRequest)
+			return
+		}
+	}
+	imp, err := api.renter.ImportSiaFile(src, siaPath, repair)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to import siafile"), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		imp.SiaPath, err = imp.SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusInternalServerError)
+			return
+		}
+	}
+	WriteJSON(w, RenterSiaFileImportPOST{Import: imp})
+}
+
+// renterConvertHandlerPOST handles the API calls to /renter/convert/:siapath
+func (api *API) renterConvertHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+	}
+	// Both the data and parity pieces are required.
+	strDataPieces, strParityPieces := req.FormValue("datapieces"), req.FormValue("paritypieces")
+	if strDataPieces == "" || strParityPieces == "" {
+		WriteError(w, Error{Message: errNeedBothDataAndParityPieces.Error()}, http.StatusBadRequest)
+		return
+	}
+	dataPieces, parityPieces, err := ParseDataAndParityPieces(strDataPieces, strParityPieces)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	// The conversion is not throttled by default.
+	var maxSpeed int64
+	if ms := req.FormValue("maxspeed"); ms != "" {
+		_, err = fmt.Sscan(ms, &maxSpeed)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'maxspeed' parameter"), http.StatusBadRequest)
+			return
+		}
+	}
+	err = api.renter.ConvertDir(siaPath, dataPieces, parityPieces, maxSpeed)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to start directory conversion"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterConversionHandlerGET handles the API calls to /renter/conversion
+func (api *API) renterConversionHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	conversion, err := api.renter.DirConversion()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get directory conversion"), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		conversion.SiaPath = rebaseOutputSiaPath(conversion.SiaPath)
+		conversion.Cursor = rebaseOutputSiaPath(conversion.Cursor)
+		for i := range conversion.FailedFiles {
+			conversion.FailedFiles[i] = rebaseOutputSiaPath(conversion.FailedFiles[i])
+		}
+	}
+	WriteJSON(w, RenterDirConversionGET{Conversion: conversion})
+}
+
+// renterConversionCancelHandlerPOST handles the API calls to
+// /renter/conversion/cancel
+func (api *API) renterConversionCancelHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	err := api.renter.CancelDirConversion()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to cancel directory conversion"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterFsckHandlerPOST handles the API calls to /renter/fsck
+func (api *API) renterFsckHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var repair, quarantine bool
+	var err error
+	if r := req.FormValue("repair"); r != "" {
+		repair, err = scanBool(r)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'repair' parameter"), http.StatusBadRequest)
+			return
+		}
+	}
+	if q := req.FormValue("quarantine"); q != "" {
+		quarantine, err = scanBool(q)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'quarantine' parameter"), http.StatusBadRequest)
+			return
+		}
+	}
+	report, err := api.renter.Fsck(repair, quarantine)
+	if errors.Contains(err, modules.ErrRenterReadOnly) {
+		WriteError(w, NewError(err), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to check the filesystem"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterFsckPOST{Report: report})
+}
+
+// renterReplicationHandlerGET handles the API calls to /renter/replication
+func (api *API) renterReplicationHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterReplicationGET{api.renter.ReplicationStatus()})
+}
+
+// renterReplicationManifestHandlerGET handles the API calls to
+// /renter/replication/manifest
+func (api *API) renterReplicationManifestHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var since time.Time
+	if s := req.FormValue("since"); s != "" {
+		nanos, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'since' parameter"), http.StatusBadRequest)
+			return
+		}
+		if nanos != 0 {
+			since = time.Unix(0, nanos)
+		}
+	}
+	manifest, err := api.renter.ReplicationManifest(since)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to create replication manifest"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, manifest)
+}
+
+// renterReplicationFilesHandlerPOST handles the API calls to
+// /renter/replication/files
+func (api *API) renterReplicationFilesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var paths []string
+	if err := json.Unmarshal([]byte(req.FormValue("paths")), &paths); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse 'paths' parameter"), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-tar")
+	if err := api.renter.WriteReplicationFiles(w, paths); err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to write replication files"), http.StatusInternalServerError)
+		return
+	}
+}
+
+// renterReplicationPromoteHandlerPOST handles the API calls to
+// /renter/replication/promote
+func (api *API) renterReplicationPromoteHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := api.renter.PromoteReplicationStandby(); err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to promote standby"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterReplicationStandbyHandlerPOST handles the API calls to
+// /renter/replication/standby
+func (api *API) renterReplicationStandbyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	address := req.FormValue("address")
+	if address == "" {
+		WriteError(w, Error{Message: "address not specified"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.StartReplicationStandby(address, req.FormValue("password")); err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to start standby"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// rebaseOutputSiaPath rebases siapaths within the user folder to the root
+// folder. Other siapaths are returned unchanged.
+func rebaseOutputSiaPath(siaPath modules.SiaPath) modules.SiaPath {
+	rebased, err := siaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+	if err != nil {
+		return siaPath
+	}
+	return rebased
+}
+
+// parseErasureCodingParameters parses the supplied string values and creates
+// an erasure coder. If values haven't been supplied it will fill in sane
+// defaults.
+func parseErasureCodingParameters(strDataPieces, strParityPieces string) (modules.ErasureCoder, error) {
+	// Parse data and parity pieces
+	dataPieces, parityPieces, err := ParseDataAndParityPieces(strDataPieces, strParityPieces)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if data and parity pieces were set
+	if dataPieces == 0 && parityPieces == 0 {
+		return nil, nil
+	}
+
+	// Verify that sane values for parityPieces and redundancy are being
+	// supplied.
+	if parityPieces < requiredParityPieces {
+		err := fmt.Errorf("a minimum of %v parity pieces is required, but %v parity pieces requested", requiredParityPieces, parityPieces)
+		return nil, err
+	}
+	redundancy := float64(dataPieces+parityPieces) / float64(dataPieces)
+	if float64(dataPieces+parityPieces)/float64(dataPieces) < requiredRedundancy {
+		err := fmt.Errorf("a redundancy of %.2f is required, but redundancy of %.2f supplied", requiredRedundancy, redundancy)
+		return nil, err
+	}
+
+	// Create the erasure coder.
+	return modules.NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
+}
+
+// ParseDataAndParityPieces parse the numeric values for dataPieces and
+// parityPieces from the input strings
+func ParseDataAndParityPieces(strDataPieces, strParityPieces string) (dataPieces, parityPieces int, err error) {
+	// Check that both values have been supplied.
+	if (strDataPieces == "") != (strParityPieces == "") {
+		return 0, 0, errNeedBothDataAndParityPieces
+	}
+
+	// Check for blank strings.
+	if strDataPieces == "" && strParityPieces == "" {
+		return 0, 0, nil
+	}
+
+	// Parse dataPieces and Parity Pieces.
+	_, err = fmt.Sscan(strDataPieces, &dataPieces)
+	if err != nil {
+		err = errors.AddContext(err, "unable to read parameter 'datapieces'")
+		return 0, 0, err
+	}
+	_, err = fmt.Sscan(strParityPieces, &parityPieces)
+	if err != nil {
+		err = errors.AddContext(err, "unable to read parameter 'paritypieces'")
+		return 0, 0, err
+	}
+
+	// Check that either both values are zero or neither are zero
+	if (dataPieces == 0) != (parityPieces == 0) {
+		return 0, 0, errNeedBothDataAndParityPieces
+	}
+
+	return dataPieces, parityPieces, nil
+}
+
+// renterHandlerGET handles the API call to /renter.
+func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable able to get renter settings"), http.StatusBadRequest)
+		return
+	}
+	spending, err := api.renter.PeriodSpending()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to get Period Spending"), http.StatusBadRequest)
+		return
+	}
+	currentPeriod := api.renter.CurrentPeriod()
+	nextPeriod := currentPeriod + settings.Allowance.Period
+	memoryStatus, err := api.renter.MemoryStatus()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to get renter memory information"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterGET{
 		Settings:         settings,
 		FinancialMetrics: spending,
 		CurrentPeriod:    currentPeriod,
@@ -681,256 +1370,242 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httpr
 	})
 }
 
-// renterHandlerPOST handles the API call to set the Renter's settings. This API
-// call handles multiple settings and so each setting is optional on it's own.
-// Groups of settings, such as the allowance, have certain requirements if they
-// are being set in which case certain fields are no longer optional.
-func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	// Get the existing settings
-	settings, err := api.renter.Settings()
-	if err != nil {
-		WriteError(w, Error{"unable able to get renter settings: " + err.Error()}, http.StatusBadRequest)
-		return
-	}
-
-	// Scan for all allowance fields
+// scanAllowance applies the allowance fields of a request to the provided
+// allowance. Fields which are not set by the request keep their value or are
+// set to their defaults if the allowance is only set partially.
+func scanAllowance(req *http.Request, a modules.Allowance) (modules.Allowance, error) {
 	var hostsSet, renewWindowSet, expectedStorageSet,
 		expectedUploadSet, expectedDownloadSet, expectedRedundancySet, maxPeriodChurnSet bool
 	if f := req.FormValue("funds"); f != "" {
 		funds, ok := scanAmount(f)
 		if !ok {
-			WriteError(w, Error{"unable to parse funds"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse funds")
 		}
-		settings.Allowance.Funds = funds
+		a.Funds = funds
 	}
 	if h := req.FormValue("hosts"); h != "" {
 		var hosts uint64
 		if _, err := fmt.Sscan(h, &hosts); err != nil {
-			WriteError(w, Error{"unable to parse hosts: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse hosts")
 		} else if hosts != 0 && hosts < requiredHosts {
-			WriteError(w, Error{fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", requiredHosts, hosts)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("insufficient number of hosts, need at least %v but have %v", requiredHosts, hosts)
 		}
-		settings.Allowance.Hosts = hosts
+		a.Hosts = hosts
 		hostsSet = true
 	}
 	if p := req.FormValue("period"); p != "" {
 		var period types.BlockHeight
 		if _, err := fmt.Sscan(p, &period); err != nil {
-			WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse period")
 		}
-		settings.Allowance.Period = types.BlockHeight(period)
+		a.Period = types.BlockHeight(period)
 	}
 	if rw := req.FormValue("renewwindow"); rw != "" {
 		var renewWindow types.BlockHeight
 		if _, err := fmt.Sscan(rw, &renewWindow); err != nil {
-			WriteError(w, Error{"unable to parse renewwindow: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse renewwindow")
 		} else if renewWindow != 0 && types.BlockHeight(renewWindow) < requiredRenewWindow {
-			WriteError(w, Error{fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)
 		}
-		settings.Allowance.RenewWindow = types.BlockHeight(renewWindow)
+		a.RenewWindow = types.BlockHeight(renewWindow)
 		renewWindowSet = true
 	}
 	if es := req.FormValue("expectedstorage"); es != "" {
 		var expectedStorage uint64
 		if _, err := fmt.Sscan(es, &expectedStorage); err != nil {
-			WriteError(w, Error{"unable to parse expectedStorage: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedStorage")
 		}
-		settings.Allowance.ExpectedStorage = expectedStorage
+		a.ExpectedStorage = expectedStorage
 		expectedStorageSet = true
 	}
 	if euf := req.FormValue("expectedupload"); euf != "" {
 		var expectedUpload uint64
 		if _, err := fmt.Sscan(euf, &expectedUpload); err != nil {
-			WriteError(w, Error{"unable to parse expectedUpload: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedUpload")
 		}
-		settings.Allowance.ExpectedUpload = expectedUpload
+		a.ExpectedUpload = expectedUpload
 		expectedUploadSet = true
 	}
 	if edf := req.FormValue("expecteddownload"); edf != "" {
 		var expectedDownload uint64
 		if _, err := fmt.Sscan(edf, &expectedDownload); err != nil {
-			WriteError(w, Error{"unable to parse expectedDownload: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedDownload")
 		}
-		settings.Allowance.ExpectedDownload = expectedDownload
+		a.ExpectedDownload = expectedDownload
 		expectedDownloadSet = true
 	}
 	if er := req.FormValue("expectedredundancy"); er != "" {
 		var expectedRedundancy float64
 		if _, err := fmt.Sscan(er, &expectedRedundancy); err != nil {
-			WriteError(w, Error{"unable to parse expectedRedundancy: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse expectedRedundancy")
 		}
-		settings.Allowance.ExpectedRedundancy = expectedRedundancy
+		a.ExpectedRedundancy = expectedRedundancy
 		expectedRedundancySet = true
 	}
 	if mpc := req.FormValue("maxperiodchurn"); mpc != "" {
 		var maxPeriodChurn uint64
 		if _, err := fmt.Sscan(mpc, &maxPeriodChurn); err != nil {
-			WriteError(w, Error{"unable to parse new max churn per period: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.AddContext(err, "unable to parse new max churn per period")
 		}
-		settings.Allowance.MaxPeriodChurn = maxPeriodChurn
+		a.MaxPeriodChurn = maxPeriodChurn
 		maxPeriodChurnSet = true
 	}
 	if str := req.FormValue("maxrpcprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxrpcprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxrpcprice")
 		}
-		settings.Allowance.MaxRPCPrice = price
+		a.MaxRPCPrice = price
 	}
 	if str := req.FormValue("maxcontractprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxcontractprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxcontractprice")
 		}
-		settings.Allowance.MaxContractPrice = price
+		a.MaxContractPrice = price
 	}
 	if str := req.FormValue("maxdownloadbandwidthprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxdownloadbandwidthprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxdownloadbandwidthprice")
 		}
-		settings.Allowance.MaxDownloadBandwidthPrice = price
+		a.MaxDownloadBandwidthPrice = price
 	}
 	if str := req.FormValue("maxsectoraccessprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxsectoraccessprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxsectoraccessprice")
 		}
-		settings.Allowance.MaxSectorAccessPrice = price
+		a.MaxSectorAccessPrice = price
 	}
 	if str := req.FormValue("maxstorageprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxstorageprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxstorageprice")
 		}
-		settings.Allowance.MaxStoragePrice = price
+		a.MaxStoragePrice = price
 	}
 	if str := req.FormValue("maxuploadbandwidthprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxuploadbandwidthprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxuploadbandwidthprice")
 		}
-		settings.Allowance.MaxUploadBandwidthPrice = price
+		a.MaxUploadBandwidthPrice = price
 	}
 
 	// Validate any allowance changes. Funds and Period are the only required
 	// fields.
-	zeroFunds := settings.Allowance.Funds.Cmp(types.ZeroCurrency) == 0
-	zeroPeriod := settings.Allowance.Period == 0
+	zeroFunds := a.Funds.Cmp(types.ZeroCurrency) == 0
+	zeroPeriod := a.Period == 0
 	if zeroFunds && zeroPeriod {
 		// If both the funds and period are zero then the allowance should be
 		// cancelled. Make sure that the rest of the fields are zeroed out
-		settings.Allowance = modules.Allowance{}
-	} else if !reflect.DeepEqual(settings.Allowance, modules.Allowance{}) {
+		a = modules.Allowance{}
+	} else if !reflect.DeepEqual(a, modules.Allowance{}) {
 		// Allowance has been set at least partially. Validate that all fields
 		// are set correctly
 
 		// If Funds is still 0 return an error since we need the user to set the
 		// period initially
 		if zeroFunds {
-			WriteError(w, Error{ErrFundsNeedToBeSet.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, ErrFundsNeedToBeSet
 		}
 
 		// If Period is still 0 return an error since we need the user to set
 		// the period initially
 		if zeroPeriod {
-			WriteError(w, Error{ErrPeriodNeedToBeSet.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, ErrPeriodNeedToBeSet
 		}
 
 		// If the user set Hosts to 0 return an error, otherwise if Hosts was
 		// not set by the user then set it to the sane default
-		if settings.Allowance.Hosts == 0 && hostsSet {
-			WriteError(w, Error{contractor.ErrAllowanceNoHosts.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.Hosts == 0 {
-			settings.Allowance.Hosts = modules.DefaultAllowance.Hosts
+		if a.Hosts == 0 && hostsSet {
+			return modules.Allowance{}, contractor.ErrAllowanceNoHosts
+		} else if a.Hosts == 0 {
+			a.Hosts = modules.DefaultAllowance.Hosts
 		}
 
 		// If the user set the Renew Window to 0 return an error, otherwise if
 		// the Renew Window was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.RenewWindow == 0 && renewWindowSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroWindow.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.RenewWindow == 0 {
-			settings.Allowance.RenewWindow = settings.Allowance.Period / 2
+		if a.RenewWindow == 0 && renewWindowSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroWindow
+		} else if a.RenewWindow == 0 {
+			a.RenewWindow = a.Period / 2
 		}
 
 		// If the user set ExpectedStorage to 0 return an error, otherwise if
 		// ExpectedStorage was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedStorage == 0 && expectedStorageSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedStorage.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedStorage == 0 {
-			settings.Allowance.ExpectedStorage = modules.DefaultAllowance.ExpectedStorage
+		if a.ExpectedStorage == 0 && expectedStorageSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedStorage
+		} else if a.ExpectedStorage == 0 {
+			a.ExpectedStorage = modules.DefaultAllowance.ExpectedStorage
 		}
 
 		// If the user set ExpectedUpload to 0 return an error, otherwise if
 		// ExpectedUpload was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedUpload == 0 && expectedUploadSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedUpload.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedUpload == 0 {
-			settings.Allowance.ExpectedUpload = modules.DefaultAllowance.ExpectedUpload
+		if a.ExpectedUpload == 0 && expectedUploadSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedUpload
+		} else if a.ExpectedUpload == 0 {
+			a.ExpectedUpload = modules.DefaultAllowance.ExpectedUpload
 		}
 
 		// If the user set ExpectedDownload to 0 return an error, otherwise if
 		// ExpectedDownload was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedDownload == 0 && expectedDownloadSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedDownload.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedDownload == 0 {
-			settings.Allowance.ExpectedDownload = modules.DefaultAllowance.ExpectedDownload
+		if a.ExpectedDownload == 0 && expectedDownloadSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedDownload
+		} else if a.ExpectedDownload == 0 {
+			a.ExpectedDownload = modules.DefaultAllowance.ExpectedDownload
 		}
 
 		// If the user set ExpectedRedundancy to 0 return an error, otherwise if
 		// ExpectedRedundancy was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedRedundancy == 0 && expectedRedundancySet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedRedundancy.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedRedundancy == 0 {
-			settings.Allowance.ExpectedRedundancy = modules.DefaultAllowance.ExpectedRedundancy
+		if a.ExpectedRedundancy == 0 && expectedRedundancySet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedRedundancy
+		} else if a.ExpectedRedundancy == 0 {
+			a.ExpectedRedundancy = modules.DefaultAllowance.ExpectedRedundancy
 		}
 
 		// If the user set MaxPeriodChurn to 0 return an error, otherwise if
 		// MaxPeriodChurn was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.MaxPeriodChurn == 0 && maxPeriodChurnSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroMaxPeriodChurn.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.MaxPeriodChurn == 0 {
-			settings.Allowance.MaxPeriodChurn = modules.DefaultAllowance.MaxPeriodChurn
+		if a.MaxPeriodChurn == 0 && maxPeriodChurnSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroMaxPeriodChurn
+		} else if a.MaxPeriodChurn == 0 {
+			a.MaxPeriodChurn = modules.DefaultAllowance.MaxPeriodChurn
 		}
 	}
+	return a, nil
+}
+
+// renterHandlerPOST handles the API call to set the Renter's settings. This API
+// call handles multiple settings and so each setting is optional on it's own.
+// Groups of settings, such as the allowance, have certain requirements if they
+// are being set in which case certain fields are no longer optional.
+func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Get the existing settings
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable able to get renter settings"), http.StatusBadRequest)
+		return
+	}
+
+	// Scan for all allowance fields
+	settings.Allowance, err = scanAllowance(req, settings.Allowance)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
 
 	// Scan the download speed limit. (optional parameter)
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
 		var downloadSpeed int64
 		if _, err := fmt.Sscan(d, &downloadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse downloadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse downloadspeed"), http.StatusBadRequest)
 			return
 		}
 		settings.MaxDownloadSpeed = downloadSpeed
@@ -939,7 +1614,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if u := req.FormValue("maxuploadspeed"); u != "" {
 		var uploadSpeed int64
 		if _, err := fmt.Sscan(u, &uploadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse uploadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse uploadspeed"), http.StatusBadRequest)
 			return
 		}
 		settings.MaxUploadSpeed = uploadSpeed
@@ -949,7 +1624,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if ipc := req.FormValue("checkforipviolation"); ipc != "" {
 		var ipviolationcheck bool
 		if _, err := fmt.Sscan(ipc, &ipviolationcheck); err != nil {
-			WriteError(w, Error{"unable to parse ipviolationcheck: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse ipviolationcheck"), http.StatusBadRequest)
 			return
 		}
 		settings.IPViolationCheck = ipviolationcheck
@@ -958,7 +1633,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(settings)
 	if err != nil {
-		WriteError(w, Error{"unable to set renter settings: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to set renter settings"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -970,7 +1645,7 @@ func (api *API) renterAllowanceCancelHandlerPOST(w http.ResponseWriter, _ *http.
 	// Get the existing settings
 	settings, err := api.renter.Settings()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -980,12 +1655,86 @@ func (api *API) renterAllowanceCancelHandlerPOST(w http.ResponseWriter, _ *http.
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(settings)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterAllowanceProfilesHandlerGET handles the API call to
+// /renter/allowanceprofiles.
+func (api *API) renterAllowanceProfilesHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterAllowanceProfilesGET{
+		Profiles: api.renter.AllowanceProfiles(),
+	})
+}
+
+// renterAllowanceProfilesHandlerPOST handles the API call to create or update
+// an allowance profile. The allowance fields which are not set start from the
+// existing profile or, for a new profile, from the current allowance.
+func (api *API) renterAllowanceProfilesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	name := req.FormValue("name")
+	if name == "" {
+		WriteError(w, Error{Message: "name must be specified"}, http.StatusBadRequest)
+		return
+	}
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to get renter settings"), http.StatusBadRequest)
+		return
+	}
+	allowance := settings.Allowance
+	for _, profile := range api.renter.AllowanceProfiles() {
+		if profile.Name == name {
+			allowance = profile.Allowance
+		}
+	}
+	allowance, err = scanAllowance(req, allowance)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	err = api.renter.SaveAllowanceProfile(name, allowance)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to save allowance profile"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterAllowanceProfilesActivateHandlerPOST handles the API call to
+// /renter/allowanceprofiles/activate.
+func (api *API) renterAllowanceProfilesActivateHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.ActivateAllowanceProfile(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to activate allowance profile"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterAllowanceProfilesDeleteHandlerPOST handles the API call to
+// /renter/allowanceprofiles/delete.
+func (api *API) renterAllowanceProfilesDeleteHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.DeleteAllowanceProfile(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to delete allowance profile"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
+// renterAllowanceProfilesDiffHandlerGET handles the API call to
+// /renter/allowanceprofiles/diff.
+func (api *API) renterAllowanceProfilesDiffHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	diff, err := api.renter.AllowanceProfileDiff(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to preview allowance profile"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterAllowanceProfileDiffGET{Diff: diff})
+}
+
 // renterCleanHandlerPOST handles the API call to clean lost files from a Renter.
 func (api *API) renterCleanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	var deleteErrs error
@@ -998,7 +1747,7 @@ func (api *API) renterCleanHandlerPOST(w http.ResponseWriter, _ *http.Request, _
 	err := api.renter.FileList(modules.RootSiaPath(), true, false, cleanFunc)
 	err = errors.Compose(err, deleteErrs)
 	if err != nil {
-		WriteError(w, Error{"unable to clear lost files: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to clear lost files"), http.StatusBadRequest)
 		return
 	}
 
@@ -1009,17 +1758,88 @@ func (api *API) renterCleanHandlerPOST(w http.ResponseWriter, _ *http.Request, _
 func (api *API) renterContractCancelHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var fcid types.FileContractID
 	if err := fcid.LoadString(req.FormValue("id")); err != nil {
-		WriteError(w, Error{"unable to parse id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to parse id"), http.StatusBadRequest)
 		return
 	}
 	err := api.renter.CancelContract(fcid)
 	if err != nil {
-		WriteError(w, Error{"unable to cancel contract: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to cancel contract"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
+// renterContractSectorRootsHandlerGET handles the API call to fetch the sector
+// roots of a contract from its host.
+func (api *API) renterContractSectorRootsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse id"), http.StatusBadRequest)
+		return
+	}
+	csr, err := api.renter.ContractSectorRoots(fcid)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to fetch sector roots"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterContractSectorRootsGET{csr})
+}
+
+// renterContractDelegateHandlerPOST handles the API call to authorize a
+// delegate to download the sectors of a contract from its host.
+func (api *API) renterContractDelegateHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse id"), http.StatusBadRequest)
+		return
+	}
+	var delegate types.SiaPublicKey
+	if err := delegate.LoadString(req.FormValue("delegate")); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse delegate"), http.StatusBadRequest)
+		return
+	}
+	var duration types.BlockHeight
+	if _, err := fmt.Sscan(req.FormValue("duration"), &duration); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse duration"), http.StatusBadRequest)
+		return
+	}
+	auth, err := api.renter.AuthorizeDelegatedRead(fcid, delegate, duration)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to authorize delegate"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterContractDelegatePOST{auth})
+}
+
+// renterDelegateKeyHandlerGET handles the API call to fetch the public key
+// which identifies the renter as a delegate.
+func (api *API) renterDelegateKeyHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	key, err := api.renter.DelegateKey()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to derive delegate key"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterDelegateKeyGET{DelegateKey: key})
+}
+
+// renterDelegatedReadHandlerPOST handles the API call to download a sector of
+// another renter's contract as a delegate. The parameters are provided as JSON
+// in the request body and the raw sector data is returned.
+func (api *API) renterDelegatedReadHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params RenterDelegatedReadPOST
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
+		return
+	}
+	data, err := api.renter.DelegatedReadSector(params.Authorization, params.Root)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to download sector"), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
+
 // renterContractsHandler handles the API call to request the Renter's
 // contracts. Active and renewed contracts are returned by default
 //
@@ -1058,28 +1878,28 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 	if s := req.FormValue("disabled"); s != "" {
 		disabled, err = scanBool(s)
 		if err != nil {
-			WriteError(w, Error{"unable to parse disabled: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse disabled"), http.StatusBadRequest)
 			return
 		}
 	}
 	if s := req.FormValue("inactive"); s != "" {
 		inactive, err = scanBool(s)
 		if err != nil {
-			WriteError(w, Error{"unable to parse inactive: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse inactive"), http.StatusBadRequest)
 			return
 		}
 	}
 	if s := req.FormValue("expired"); s != "" {
 		expired, err = scanBool(s)
 		if err != nil {
-			WriteError(w, Error{"unable to parse expired: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse expired"), http.StatusBadRequest)
 			return
 		}
 	}
 	if s := req.FormValue("recoverable"); s != "" {
 		recoverable, err = scanBool(s)
 		if err != nil {
-			WriteError(w, Error{"unable to parse recoverable: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse recoverable"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -1236,194 +2056,615 @@ func (api *API) renterClearDownloadsHandler(w http.ResponseWriter, req *http.Req
 	if beforeStr != "" {
 		beforeInt, err := strconv.ParseInt(beforeStr, 10, 64)
 		if err != nil {
-			WriteError(w, Error{"parsing integer value for parameter `before` failed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "parsing integer value for parameter `before` failed"), http.StatusBadRequest)
+			return
+		}
+		beforeTime = time.Unix(0, beforeInt)
+	}
+	if afterStr != "" {
+		afterInt, err := strconv.ParseInt(afterStr, 10, 64)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "parsing integer value for parameter `after` failed"), http.StatusBadRequest)
+			return
+		}
+		afterTime = time.Unix(0, afterInt)
+	}
+
+	err := api.renter.ClearDownloadHistory(afterTime, beforeTime)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterContractorChurnStatus handles the API call to request the churn status
+// from the renter's contractor.
+func (api *API) renterContractorChurnStatus(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.ContractorChurnStatus())
+}
+
+// renterDownloadsHandler handles the API call to request the download queue.
+func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var downloads []DownloadInfo
+	var err error
+	dis := api.renter.DownloadHistory()
+	root, err := scanBool(req.FormValue("root"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		dis, err = trimDownloadInfo(dis...)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusInternalServerError)
+			return
+		}
+	}
+	for _, di := range dis {
+		downloads = append(downloads, DownloadInfo{
+			Destination:     di.Destination,
+			DestinationType: di.DestinationType,
+			Filesize:        di.Length,
+			Length:          di.Length,
+			Offset:          di.Offset,
+			SiaPath:         di.SiaPath,
+
+			Completed:            di.Completed,
+			EndTime:              di.EndTime,
+			Error:                di.Error,
+			Received:             di.Received,
+			StartTime:            di.StartTime,
+			StartTimeUnix:        di.StartTimeUnix,
+			TotalDataTransferred: di.TotalDataTransferred,
+
+			Manifest: di.Manifest,
+		})
+	}
+	WriteJSON(w, RenterDownloadQueue{
+		Downloads: downloads,
+	})
+}
+
+// renterDownloadByUIDHandlerGET handles the API call to /renter/downloadinfo.
+func (api *API) renterDownloadByUIDHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	uid := strings.TrimPrefix(ps.ByName("uid"), "/")
+	di, exists := api.renter.DownloadByUID(modules.DownloadID(uid))
+	if !exists {
+		WriteError(w, Error{Message: fmt.Sprintf("Download with id '%v' doesn't exist", string(uid))}, http.StatusBadRequest)
+		return
+	}
+	dis, err := trimDownloadInfo(di)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusInternalServerError)
+		return
+	}
+	di = dis[0]
+	WriteJSON(w, DownloadInfo{
+		Destination:     di.Destination,
+		DestinationType: di.DestinationType,
+		Filesize:        di.Length,
+		Length:          di.Length,
+		Offset:          di.Offset,
+		SiaPath:         di.SiaPath,
+
+		Completed:            di.Completed,
+		EndTime:              di.EndTime,
+		Error:                di.Error,
+		Received:             di.Received,
+		StartTime:            di.StartTime,
+		StartTimeUnix:        di.StartTimeUnix,
+		TotalDataTransferred: di.TotalDataTransferred,
+
+		Manifest: di.Manifest,
+	})
+}
+
+// renterFuseHandlerGET handles the API call to /renter/fuse.
+func (api *API) renterFuseHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	rfi := RenterFuseInfo{
+		MountPoints: api.renter.MountInfo(),
+	}
+	for i := 0; i < len(rfi.MountPoints); i++ {
+		rebased, err := rfi.MountPoints[i].SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+		rfi.MountPoints[i].SiaPath = rebased
+	}
+
+	WriteJSON(w, rfi)
+}
+
+// renterFuseMountHandlerPOST handles the API call to /renter/fuse/mount.
+func (api *API) renterFuseMountHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var siaPath modules.SiaPath
+	var err error
+	spfv := req.FormValue("siapath")
+	if spfv == "" {
+		siaPath = modules.RootSiaPath()
+	} else {
+		siaPath, err = modules.NewSiaPath(spfv)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+
+	mount := req.FormValue("mount")
+	var opts modules.MountOptions
+	if req.FormValue("readonly") != "" {
+		readOnly, err := scanBool(req.FormValue("readonly"))
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+		opts.ReadOnly = readOnly
+	}
+	if req.FormValue("allowother") != "" {
+		allowOther, err := scanBool(req.FormValue("allowother"))
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+		opts.AllowOther = allowOther
+	}
+	if err := api.renter.Mount(mount, siaPath, opts); err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterFuseUnmountHandlerPOST handles the API call to /renter/fuse/unmount.
+func (api *API) renterFuseUnmountHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.Unmount(req.FormValue("mount"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterContractPricesHandlerGET handles the API call to
+// /renter/contractprices.
+func (api *API) renterContractPricesHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterContractPricesGET{
+		Report: api.renter.ContractPriceReport(),
+	})
+}
+
+// renterMigrationsHandlerGET handles the API call to /renter/migrations.
+func (api *API) renterMigrationsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterMigrationsGET{
+		Migrations: api.renter.HostMigrations(),
+	})
+}
+
+// renterRecoveryScanHandlerPOST handles the API call to /renter/recoveryscan.
+func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := api.renter.InitRecoveryScan(); err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRecoveryScanHandlerGET handles the API call to /renter/recoveryscan.
+func (api *API) renterRecoveryScanHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	scanInProgress, height := api.renter.RecoveryScanStatus()
+	WriteJSON(w, RenterRecoveryStatusGET{
+		ScanInProgress: scanInProgress,
+		ScannedHeight:  height,
+	})
+}
+
+// renterRestoreModeHandlerGET handles the API call to /renter/restoremode.
+func (api *API) renterRestoreModeHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.RestoreModeStatus()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get restore mode status"), http.StatusBadRequest)
+		return
+	}
+	if status.Active {
+		status.SiaPath, err = status.SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+	}
+	WriteJSON(w, RenterRestoreModeGET{status})
+}
+
+// renterRestoreModeStartHandlerPOST handles the API call to
+// /renter/restoremode/start.
+func (api *API) renterRestoreModeStartHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var siaPath modules.SiaPath
+	var err error
+	spfv := req.FormValue("siapath")
+	if spfv == "" {
+		siaPath = modules.RootSiaPath()
+	} else {
+		siaPath, err = modules.NewSiaPath(spfv)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	err = api.renter.RestoreModeStart(siaPath)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to start restore mode"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRestoreModeStopHandlerPOST handles the API call to
+// /renter/restoremode/stop.
+func (api *API) renterRestoreModeStopHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	err := api.renter.RestoreModeStop()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to stop restore mode"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterAccountCapsHandlerGET handles the API call to /renter/accountcaps.
+func (api *API) renterAccountCapsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	caps, err := api.renter.AccountSpendingCaps()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get account spending caps"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterAccountSpendingCapsGET{caps})
+}
+
+// renterAccountCapsHandlerPOST handles the API call to set the caps on the
+// money the renter's workers may spend from the ephemeral accounts of hosts.
+// Caps which are not provided remain unchanged.
+func (api *API) renterAccountCapsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	caps, err := api.renter.AccountSpendingCaps()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get account spending caps"), http.StatusBadRequest)
+		return
+	}
+	if d := req.FormValue("default"); d != "" {
+		limit, ok := scanAmount(d)
+		if !ok {
+			WriteError(w, Error{Message: "unable to parse 'default' parameter"}, http.StatusBadRequest)
+			return
+		}
+		caps.Default = limit
+	}
+	if window := req.FormValue("window"); window != "" {
+		var seconds uint64
+		_, err := fmt.Sscan(window, &seconds)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'window' parameter"), http.StatusBadRequest)
+			return
+		}
+		caps.Window = time.Duration(seconds) * time.Second
+	}
+	if hostKey := req.FormValue("hostkey"); hostKey != "" {
+		var spk types.SiaPublicKey
+		if err := spk.LoadString(hostKey); err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'hostkey' parameter"), http.StatusBadRequest)
+			return
+		}
+		var remove bool
+		if r := req.FormValue("remove"); r != "" {
+			remove, err = scanBool(r)
+			if err != nil {
+				WriteError(w, NewErrorWithContext(err, "unable to parse 'remove' parameter"), http.StatusBadRequest)
+				return
+			}
+		}
+		limit, ok := scanAmount(req.FormValue("cap"))
+		switch {
+		case remove:
+			delete(caps.Hosts, spk.String())
+		case !ok:
+			WriteError(w, Error{Message: "'cap' parameter is required when setting the cap of a host"}, http.StatusBadRequest)
+			return
+		default:
+			caps.Hosts[spk.String()] = limit
+		}
+	}
+	err = api.renter.SetAccountSpendingCaps(caps)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set account spending caps"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterStreamCacheHandlerGET handles the API call to /renter/streamcache.
+func (api *API) renterStreamCacheHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	stats, err := api.renter.StreamCacheStats()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get stream cache stats"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterStreamCacheGET{stats})
+}
+
+// renterStreamCacheHandlerPOST handles the API call to set the renter's
+// stream cache settings. Settings which are not provided remain unchanged.
+func (api *API) renterStreamCacheHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	stats, err := api.renter.StreamCacheStats()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get stream cache settings"), http.StatusBadRequest)
+		return
+	}
+	settings := stats.StreamCacheSettings
+	if mb := req.FormValue("maxbytes"); mb != "" {
+		_, err := fmt.Sscan(mb, &settings.MaxBytes)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'maxbytes' parameter"), http.StatusBadRequest)
 			return
 		}
-		beforeTime = time.Unix(0, beforeInt)
 	}
-	if afterStr != "" {
-		afterInt, err := strconv.ParseInt(afterStr, 10, 64)
+	if cps := req.FormValue("chunksperstream"); cps != "" {
+		_, err := fmt.Sscan(cps, &settings.ChunksPerStream)
 		if err != nil {
-			WriteError(w, Error{"parsing integer value for parameter `after` failed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'chunksperstream' parameter"), http.StatusBadRequest)
 			return
 		}
-		afterTime = time.Unix(0, afterInt)
 	}
-
-	err := api.renter.ClearDownloadHistory(afterTime, beforeTime)
+	if policy := req.FormValue("policy"); policy != "" {
+		settings.Policy = policy
+	}
+	err = api.renter.SetStreamCacheSettings(settings)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to set stream cache settings"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
-// renterContractorChurnStatus handles the API call to request the churn status
-// from the renter's contractor.
-func (api *API) renterContractorChurnStatus(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	WriteJSON(w, api.renter.ContractorChurnStatus())
+// renterConcurrencyHandlerGET handles the API call to
+// /renter/settings/concurrency.
+func (api *API) renterConcurrencyHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	concurrency, err := api.renter.ConcurrencySettings()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get concurrency settings"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterConcurrencyGET{concurrency})
 }
 
-// renterDownloadsHandler handles the API call to request the download queue.
-func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	var downloads []DownloadInfo
-	var err error
-	dis := api.renter.DownloadHistory()
-	root, err := scanBool(req.FormValue("root"))
+// renterConcurrencyHandlerPOST handles the API call to set the renter's
+// concurrency limits. Limits which are not provided remain unchanged.
+func (api *API) renterConcurrencyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	concurrency, err := api.renter.ConcurrencySettings()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to get concurrency settings"), http.StatusBadRequest)
 		return
 	}
-	if !root {
-		dis, err = trimDownloadInfo(dis...)
+	settings := concurrency.Settings
+	params := []struct {
+		name  string
+		limit *uint64
+	}{
+		{"repairuploadchunks", &settings.RepairUploadChunks},
+		{"repairdownloads", &settings.RepairDownloads},
+		{"healthcheckthreads", &settings.HealthCheckThreads},
+		{"hostdbscanthreads", &settings.HostDBScanThreads},
+	}
+	for _, param := range params {
+		value := req.FormValue(param.name)
+		if value == "" {
+			continue
+		}
+		_, err := fmt.Sscan(value, param.limit)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+			WriteError(w, NewErrorWithContext(err, fmt.Sprintf("unable to parse '%v' parameter", param.name)), http.StatusBadRequest)
 			return
 		}
 	}
-	for _, di := range dis {
-		downloads = append(downloads, DownloadInfo{
-			Destination:     di.Destination,
-			DestinationType: di.DestinationType,
-			Filesize:        di.Length,
-			Length:          di.Length,
-			Offset:          di.Offset,
-			SiaPath:         di.SiaPath,
+	err = api.renter.SetConcurrencySettings(settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set concurrency settings"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
 
-			Completed:            di.Completed,
-			EndTime:              di.EndTime,
-			Error:                di.Error,
-			Received:             di.Received,
-			StartTime:            di.StartTime,
-			StartTimeUnix:        di.StartTimeUnix,
-			TotalDataTransferred: di.TotalDataTransferred,
-		})
+// renterBandwidthHandlerGET handles the API call to /renter/bandwidth.
+func (api *API) renterBandwidthHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	usage, err := api.renter.BandwidthUsage()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get bandwidth usage"), http.StatusBadRequest)
+		return
 	}
-	WriteJSON(w, RenterDownloadQueue{
-		Downloads: downloads,
-	})
+	WriteJSON(w, RenterBandwidthGET{Categories: usage})
 }
 
-// renterDownloadByUIDHandlerGET handles the API call to /renter/downloadinfo.
-func (api *API) renterDownloadByUIDHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
-	uid := strings.TrimPrefix(ps.ByName("uid"), "/")
-	di, exists := api.renter.DownloadByUID(modules.DownloadID(uid))
-	if !exists {
-		WriteError(w, Error{fmt.Sprintf("Download with id '%v' doesn't exist", string(uid))}, http.StatusBadRequest)
+// renterBandwidthHandlerPOST handles the API call to set the bandwidth cap of
+// a category of the renter's traffic. The caps of the other categories remain
+// unchanged. A cap of 0 bytes removes the category's cap.
+func (api *API) renterBandwidthHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	category := modules.RenterBandwidthCategory(req.FormValue("category"))
+	if category == "" {
+		WriteError(w, Error{Message: "'category' parameter is required"}, http.StatusBadRequest)
 		return
 	}
-	dis, err := trimDownloadInfo(di)
+	var bytes uint64
+	if _, err := fmt.Sscan(req.FormValue("bytes"), &bytes); err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse 'bytes' parameter"), http.StatusBadRequest)
+		return
+	}
+	usage, err := api.renter.BandwidthUsage()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "failed to get bandwidth caps"), http.StatusBadRequest)
 		return
 	}
-	di = dis[0]
-	WriteJSON(w, DownloadInfo{
-		Destination:     di.Destination,
-		DestinationType: di.DestinationType,
-		Filesize:        di.Length,
-		Length:          di.Length,
-		Offset:          di.Offset,
-		SiaPath:         di.SiaPath,
+	caps := make(map[modules.RenterBandwidthCategory]modules.RenterBandwidthCap)
+	for _, u := range usage {
+		if u.Cap.Bytes > 0 {
+			caps[u.Category] = u.Cap
+		}
+	}
+	caps[category] = modules.RenterBandwidthCap{
+		Window: modules.RenterBandwidthWindow(req.FormValue("window")),
+		Bytes:  bytes,
+	}
+	err = api.renter.SetBandwidthCaps(caps)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set bandwidth caps"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
 
-		Completed:            di.Completed,
-		EndTime:              di.EndTime,
-		Error:                di.Error,
-		Received:             di.Received,
-		StartTime:            di.StartTime,
-		StartTimeUnix:        di.StartTimeUnix,
-		TotalDataTransferred: di.TotalDataTransferred,
-	})
+// renterPlacementHandlerGET handles the API call to
+// /renter/settings/placement.
+func (api *API) renterPlacementHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.PlacementSettings()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get placement settings"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterPlacementGET{settings})
 }
 
-// renterFuseHandlerGET handles the API call to /renter/fuse.
-func (api *API) renterFuseHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	rfi := RenterFuseInfo{
-		MountPoints: api.renter.MountInfo(),
+// renterPlacementHandlerPOST handles the API call to set the anti-affinity
+// constraints of the renter's piece placement. Constraints which are not
+// provided remain unchanged.
+func (api *API) renterPlacementHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.PlacementSettings()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get placement settings"), http.StatusBadRequest)
+		return
 	}
-	for i := 0; i < len(rfi.MountPoints); i++ {
-		rebased, err := rfi.MountPoints[i].SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+	params := []struct {
+		name   string
+		prefix *uint64
+	}{
+		{"ipv4prefixlength", &settings.IPv4PrefixLength},
+		{"ipv6prefixlength", &settings.IPv6PrefixLength},
+	}
+	for _, param := range params {
+		value := req.FormValue(param.name)
+		if value == "" {
+			continue
+		}
+		_, err := fmt.Sscan(value, param.prefix)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, fmt.Sprintf("unable to parse '%v' parameter", param.name)), http.StatusBadRequest)
 			return
 		}
-		rfi.MountPoints[i].SiaPath = rebased
 	}
-
-	WriteJSON(w, rfi)
+	err = api.renter.SetPlacementSettings(settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set placement settings"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
 }
 
-// renterFuseMountHandlerPOST handles the API call to /renter/fuse/mount.
-func (api *API) renterFuseMountHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	var siaPath modules.SiaPath
-	var err error
-	spfv := req.FormValue("siapath")
-	if spfv == "" {
-		siaPath = modules.RootSiaPath()
-	} else {
-		siaPath, err = modules.NewSiaPath(spfv)
-		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
-			return
-		}
-	}
-	siaPath, err = rebaseInputSiaPath(siaPath)
+// renterTimeoutsHandlerGET handles the API call to /renter/settings/timeouts.
+func (api *API) renterTimeoutsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	timeouts, err := api.renter.TimeoutSettings()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to get timeout settings"), http.StatusBadRequest)
 		return
 	}
+	WriteJSON(w, RenterTimeoutsGET{timeouts})
+}
 
-	mount := req.FormValue("mount")
-	var opts modules.MountOptions
-	if req.FormValue("readonly") != "" {
-		readOnly, err := scanBool(req.FormValue("readonly"))
+// renterTimeoutsHandlerPOST handles the API call to set the renter's timeouts.
+// The timeouts are provided in seconds. Timeouts which are not provided remain
+// unchanged.
+func (api *API) renterTimeoutsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	timeouts, err := api.renter.TimeoutSettings()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to get timeout settings"), http.StatusBadRequest)
+		return
+	}
+	settings := timeouts.Settings
+	params := []struct {
+		name    string
+		timeout *time.Duration
+	}{
+		{"interactivechunkdownload", &settings.Interactive.ChunkDownload},
+		{"interactivejob", &settings.Interactive.Job},
+		{"interactivedial", &settings.Interactive.Dial},
+		{"repairchunkdownload", &settings.Repair.ChunkDownload},
+		{"repairjob", &settings.Repair.Job},
+		{"repairdial", &settings.Repair.Dial},
+		{"backgroundchunkdownload", &settings.Background.ChunkDownload},
+		{"backgroundjob", &settings.Background.Job},
+		{"backgrounddial", &settings.Background.Dial},
+	}
+	for _, param := range params {
+		value := req.FormValue(param.name)
+		if value == "" {
+			continue
+		}
+		var seconds uint64
+		_, err := fmt.Sscan(value, &seconds)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, fmt.Sprintf("unable to parse '%v' parameter", param.name)), http.StatusBadRequest)
 			return
 		}
-		opts.ReadOnly = readOnly
-	}
-	if req.FormValue("allowother") != "" {
-		allowOther, err := scanBool(req.FormValue("allowother"))
-		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		if seconds > uint64(math.MaxInt64/time.Second) {
+			WriteError(w, Error{Message: fmt.Sprintf("'%v' parameter is too large", param.name)}, http.StatusBadRequest)
 			return
 		}
-		opts.AllowOther = allowOther
+		*param.timeout = time.Duration(seconds) * time.Second
 	}
-	if err := api.renter.Mount(mount, siaPath, opts); err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+	err = api.renter.SetTimeoutSettings(settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set timeout settings"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
-// renterFuseUnmountHandlerPOST handles the API call to /renter/fuse/unmount.
-func (api *API) renterFuseUnmountHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	err := api.renter.Unmount(req.FormValue("mount"))
+// renterReadOnlyHandlerPOST handles the API call to enable or disable the
+// renter's read-only mode.
+func (api *API) renterReadOnlyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	enabled := req.FormValue("enabled")
+	if enabled == "" {
+		WriteError(w, Error{Message: "enabled not specified"}, http.StatusBadRequest)
+		return
+	}
+	readOnly, err := scanBool(enabled)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to parse 'enabled' parameter"), http.StatusBadRequest)
 		return
 	}
-	WriteSuccess(w)
-}
-
-// renterRecoveryScanHandlerPOST handles the API call to /renter/recoveryscan.
-func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	if err := api.renter.InitRecoveryScan(); err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+	err = api.renter.SetReadOnly(readOnly)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set read-only mode"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
-// renterRecoveryScanHandlerGET handles the API call to /renter/recoveryscan.
-func (api *API) renterRecoveryScanHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	scanInProgress, height := api.renter.RecoveryScanStatus()
-	WriteJSON(w, RenterRecoveryStatusGET{
-		ScanInProgress: scanInProgress,
-		ScannedHeight:  height,
-	})
+// requireWritableRenter rejects requests to handlers which modify the
+// renter's files or allowance while the renter is in read-only mode.
+func (api *API) requireWritableRenter(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		if api.renter.ReadOnly() {
+			WriteError(w, NewError(modules.ErrRenterReadOnly), http.StatusForbidden)
+			return
+		}
+		h(w, req, ps)
+	}
 }
 
 // renterRenameHandler handles the API call to rename a file entry in the
@@ -1432,37 +2673,37 @@ func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps
 	// Parse the siaPath and the newSiaPath
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	newSiaPath, err := modules.NewSiaPath(req.FormValue("newsiapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	// Determine whether the user is requesting a user siapath, or a root siapath.
 	root, err := isCalledWithRootFlag(req)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	// Rebase the user's input to the user folder if the user is requesting a user siapath.
 	if !root {
 		siaPath, err = rebaseInputSiaPath(siaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 		newSiaPath, err = rebaseInputSiaPath(newSiaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
 	err = api.renter.RenameFile(siaPath, newSiaPath)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -1473,21 +2714,21 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 	// Determine the siapath that the user wants to get the file from.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	// Determine whether the user is requesting a user siapath, or a root siapath.
 	root, err := isCalledWithRootFlag(req)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	// Rebase the user's input to the user folder if the user is requesting a user siapath.
 	if !root {
 		siaPath, err = rebaseInputSiaPath(siaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
@@ -1495,7 +2736,7 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 	// Fetch the file.
 	file, err := api.renter.File(siaPath)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -1504,7 +2745,7 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 	if !root {
 		files, err := trimSiaDirFolderOnFiles(file)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 		file = files[0]
@@ -1515,31 +2756,69 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 	})
 }
 
+// renterFileLayoutHandlerGET handles GET requests to the
+// /renter/filelayout/:siapath API endpoint.
+func (api *API) renterFileLayoutHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	layout, err := api.renter.FileLayout(siaPath)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		layout.SiaPath, err = layout.SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusBadRequest)
+			return
+		}
+	}
+	WriteJSON(w, RenterFileLayout{
+		Layout: layout,
+	})
+}
+
 // renterFileHandler handles POST requests to the /renter/file/:siapath API endpoint.
 func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	newTrackingPath := req.FormValue("trackingpath")
 	stuck := req.FormValue("stuck")
 	root, err := scanBool(req.FormValue("root"))
 	if err != nil {
-		WriteError(w, Error{"unable to parse root flag: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to parse root flag"), http.StatusBadRequest)
 		return
 	}
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{"unable to parse siapath: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to parse siapath"), http.StatusBadRequest)
 		return
 	}
 	if !root {
 		siaPath, err = rebaseInputSiaPath(siaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
 	// Handle changing the tracking path of a file.
 	if newTrackingPath != "" {
 		if err := api.renter.SetFileTrackingPath(siaPath, newTrackingPath); err != nil {
-			WriteError(w, Error{fmt.Sprintf("unable set tracking path: %v", err)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("unable set tracking path: %v", err)}, http.StatusBadRequest)
 			return
 		}
 	}
@@ -1547,11 +2826,11 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 	if stuck != "" {
 		s, err := strconv.ParseBool(stuck)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'stuck' arg"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse 'stuck' arg"}, http.StatusBadRequest)
 			return
 		}
 		if err := api.renter.SetFileStuck(siaPath, s); err != nil {
-			WriteError(w, Error{"failed to change file 'stuck' status: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "failed to change file 'stuck' status"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -1565,7 +2844,7 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	if cached := req.FormValue("cached"); cached != "" {
 		c, err = strconv.ParseBool(cached)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'cached' arg"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse 'cached' arg"}, http.StatusBadRequest)
 			return
 		}
 	}
@@ -1577,7 +2856,7 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 		mu.Unlock()
 	})
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	// Sort slices by SiaPath.
@@ -1586,7 +2865,7 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	})
 	files, err = trimSiaDirFolderOnFiles(files...)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewError(err), http.StatusInternalServerError)
 		return
 	}
 	WriteJSON(w, RenterFiles{
@@ -1602,7 +2881,7 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	if f := req.FormValue("funds"); f != "" {
 		funds, ok := scanAmount(f)
 		if !ok {
-			WriteError(w, Error{"unable to parse funds"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse funds"}, http.StatusBadRequest)
 			return
 		}
 		allowance.Funds = funds
@@ -1611,10 +2890,10 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	if h := req.FormValue("hosts"); h != "" {
 		var hosts uint64
 		if _, err := fmt.Sscan(h, &hosts); err != nil {
-			WriteError(w, Error{"unable to parse hosts: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse hosts"), http.StatusBadRequest)
 			return
 		} else if hosts != 0 && hosts < requiredHosts {
-			WriteError(w, Error{fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", modules.DefaultAllowance.Hosts, hosts)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", modules.DefaultAllowance.Hosts, hosts)}, http.StatusBadRequest)
 			return
 		} else {
 			allowance.Hosts = hosts
@@ -1624,7 +2903,7 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	if p := req.FormValue("period"); p != "" {
 		var period types.BlockHeight
 		if _, err := fmt.Sscan(p, &period); err != nil {
-			WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse period"), http.StatusBadRequest)
 			return
 		}
 		allowance.Period = types.BlockHeight(period)
@@ -1633,10 +2912,10 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	if rw := req.FormValue("renewwindow"); rw != "" {
 		var renewWindow types.BlockHeight
 		if _, err := fmt.Sscan(rw, &renewWindow); err != nil {
-			WriteError(w, Error{"unable to parse renewwindow: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse renewwindow"), http.StatusBadRequest)
 			return
 		} else if renewWindow != 0 && types.BlockHeight(renewWindow) < requiredRenewWindow {
-			WriteError(w, Error{fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)}, http.StatusBadRequest)
 			return
 		} else {
 			allowance.RenewWindow = types.BlockHeight(renewWindow)
@@ -1648,26 +2927,26 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	// above so that an empty allowance can still be submitted
 	if !reflect.DeepEqual(allowance, modules.Allowance{}) {
 		if allowance.Funds.Cmp(types.ZeroCurrency) == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `funds` parameter left empty")}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprint("Allowance not set correctly, `funds` parameter left empty")}, http.StatusBadRequest)
 			return
 		}
 		if allowance.Period == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `period` parameter left empty")}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprint("Allowance not set correctly, `period` parameter left empty")}, http.StatusBadRequest)
 			return
 		}
 		if allowance.Hosts == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `hosts` parameter left empty")}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprint("Allowance not set correctly, `hosts` parameter left empty")}, http.StatusBadRequest)
 			return
 		}
 		if allowance.RenewWindow == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `renewwindow` parameter left empty")}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprint("Allowance not set correctly, `renewwindow` parameter left empty")}, http.StatusBadRequest)
 			return
 		}
 	}
 
 	estimate, a, err := api.renter.PriceEstimation(allowance)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, RenterPricesGET{
@@ -1681,28 +2960,28 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	// Determine whether the user is requesting a user siapath, or a root siapath.
 	root, err := isCalledWithRootFlag(req)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	// Rebase the user's input to the user folder if the user is requesting a user siapath.
 	if !root {
 		siaPath, err = rebaseInputSiaPath(siaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
 
 	err = api.renter.DeleteFile(siaPath)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -1714,7 +2993,7 @@ func (api *API) renterCancelDownloadHandler(w http.ResponseWriter, req *http.Req
 	// Get the id.
 	id := modules.DownloadID(req.FormValue("id"))
 	if id == "" {
-		WriteError(w, Error{"id not specified"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "id not specified"}, http.StatusBadRequest)
 		return
 	}
 	// Get the download from the map and delete it.
@@ -1723,7 +3002,7 @@ func (api *API) renterCancelDownloadHandler(w http.ResponseWriter, req *http.Req
 	delete(api.downloads, id)
 	api.downloadMu.Unlock()
 	if !ok {
-		WriteError(w, Error{"download for id not found"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "download for id not found"}, http.StatusBadRequest)
 		return
 	}
 	// Cancel download and delete it from the map.
@@ -1735,7 +3014,7 @@ func (api *API) renterCancelDownloadHandler(w http.ResponseWriter, req *http.Req
 func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	params, err := parseDownloadParameters(w, req, ps)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	var id modules.DownloadID
@@ -1758,14 +3037,14 @@ func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request,
 		id, start, err = api.renter.Download(params)
 	}
 	if err != nil {
-		WriteError(w, Error{"download creation failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "download creation failed"), http.StatusInternalServerError)
 		return
 	}
 	// Set ID before starting download.
 	w.Header().Set("ID", string(id))
 	// Start download.
 	if err := start(); err != nil {
-		WriteError(w, Error{"download failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "download failed"), http.StatusInternalServerError)
 		return
 	}
 	if params.Httpwriter == nil {
@@ -1809,6 +3088,14 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 	// disk if available.
 	disablelocalfetchparam := req.FormValue("disablelocalfetch")
 
+	// Determines whether a manifest of the downloaded data's hashes is
+	// created.
+	manifestparam := req.FormValue("manifest")
+
+	// Determines whether the downloaded data is verified against the file's
+	// checksum.
+	verifychecksumparam := req.FormValue("verifychecksum")
+
 	// Parse the offset and length parameters.
 	var offset, length uint64
 	if len(offsetparam) > 0 {
@@ -1863,13 +3150,27 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	// Parse the manifest parameter.
+	manifest, err := scanBool(manifestparam)
+	if err != nil {
+		return modules.RenterDownloadParameters{}, errors.AddContext(err, "manifest parameter could not be parsed")
+	}
+
+	// Parse the verifychecksum parameter.
+	verifyChecksum, err := scanBool(verifychecksumparam)
+	if err != nil {
+		return modules.RenterDownloadParameters{}, errors.AddContext(err, "verifychecksum parameter could not be parsed")
+	}
+
 	dp := modules.RenterDownloadParameters{
 		Destination:      destination,
 		DisableDiskFetch: disableLocalFetch,
 		Async:            async,
 		Length:           length,
+		Manifest:         manifest,
 		Offset:           offset,
 		SiaPath:          siaPath,
+		VerifyChecksum:   verifyChecksum,
 	}
 	if httpresp {
 		dp.Httpwriter = w
@@ -1882,19 +3183,19 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	root, err := scanBool(req.FormValue("root"))
 	if err != nil {
 		err = errors.AddContext(err, "error parsing the root flag")
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	if !root {
 		siaPath, err = rebaseInputSiaPath(siaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
@@ -1904,13 +3205,13 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 		disableLocalFetch, err = scanBool(disablelocalfetchparam)
 		if err != nil {
 			err = errors.AddContext(err, "error parsing the disablelocalfetch flag")
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
 	fileName, streamer, err := api.renter.Streamer(siaPath, disableLocalFetch)
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("failed to create download streamer: %v", err)},
+		WriteError(w, Error{Message: fmt.Sprintf("failed to create download streamer: %v", err)},
 			http.StatusInternalServerError)
 		return
 	}
@@ -1926,7 +3227,7 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 	source := req.FormValue("source")
 	// Source must be absolute path.
 	if !filepath.IsAbs(source) {
-		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "source must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
 	// Check whether existing file should be overwritten
@@ -1935,26 +3236,26 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 	if f := req.FormValue("force"); f != "" {
 		force, err = strconv.ParseBool(f)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'force' parameter"), http.StatusBadRequest)
 			return
 		}
 	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
 	if err != nil {
-		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to parse erasure code settings"), http.StatusBadRequest)
 		return
 	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	siaPath, err = rebaseInputSiaPath(siaPath)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	err = api.renter.Upload(modules.FileUploadParams{
@@ -1968,7 +3269,7 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		CipherType: crypto.TypeDefaultRenter,
 	})
 	if err != nil {
-		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "upload failed"), http.StatusInternalServerError)
 		return
 	}
 	WriteSuccess(w)
@@ -1984,7 +3285,7 @@ func (api *API) renterUploadReadyHandler(w http.ResponseWriter, req *http.Reques
 	// Check params
 	dataPieces, parityPieces, err := ParseDataAndParityPieces(dataPiecesStr, parityPiecesStr)
 	if err != nil {
-		WriteError(w, Error{"failed to parse query params: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to parse query params"), http.StatusBadRequest)
 		return
 	}
 	// Check if we need to set to defaults
@@ -2014,7 +3315,7 @@ func (api *API) renterUploadsPauseHandler(w http.ResponseWriter, req *http.Reque
 	if durationStr != "" {
 		durationInt, err := strconv.ParseUint(durationStr, 10, 64)
 		if err != nil {
-			WriteError(w, Error{"failed to parse duration: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "failed to parse duration"), http.StatusBadRequest)
 			return
 		}
 		duration = time.Second * time.Duration(durationInt)
@@ -2022,7 +3323,7 @@ func (api *API) renterUploadsPauseHandler(w http.ResponseWriter, req *http.Reque
 
 	err = api.renter.PauseRepairsAndUploads(duration)
 	if err != nil {
-		WriteError(w, Error{"failed to pause uploads: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to pause uploads"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -2033,7 +3334,7 @@ func (api *API) renterUploadsPauseHandler(w http.ResponseWriter, req *http.Reque
 func (api *API) renterUploadsResumeHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	err := api.renter.ResumeRepairsAndUploads()
 	if err != nil {
-		WriteError(w, Error{"failed to resume uploads: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to resume uploads"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -2045,7 +3346,7 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 	// Parse the query params.
 	queryForm, err := url.ParseQuery(req.URL.RawQuery)
 	if err != nil {
-		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "failed to parse query params"}, http.StatusBadRequest)
 		return
 	}
 	// Check whether existing file should be overwritten
@@ -2053,7 +3354,7 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 	if f := queryForm.Get("force"); f != "" {
 		force, err = strconv.ParseBool(f)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'force' parameter"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -2062,30 +3363,38 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 	if r := queryForm.Get("repair"); r != "" {
 		repair, err = strconv.ParseBool(r)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'repair' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'repair' parameter"), http.StatusBadRequest)
 			return
 		}
 	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
 	if err != nil && !repair {
-		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to parse erasure code settings"), http.StatusBadRequest)
 		return
 	}
 	if repair && ec != nil {
-		WriteError(w, Error{"can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
 		return
 	}
+	// Parse the checksum algorithm.
+	checksumAlgorithm := modules.ChecksumAlgorithm(queryForm.Get("checksum"))
+	if checksumAlgorithm != "" {
+		if _, err := checksumAlgorithm.NewHash(); err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'checksum' parameter"), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	siaPath, err = rebaseInputSiaPath(siaPath)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	up := modules.FileUploadParams{
@@ -2096,21 +3405,136 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
+
+		ChecksumAlgorithm: checksumAlgorithm,
 	}
 	err = api.renter.UploadStreamFromReader(up, req.Body)
 	if err != nil {
-		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "upload failed"), http.StatusInternalServerError)
 		return
 	}
 	WriteSuccess(w)
 }
 
+// renterUploadURLHandler handles the API call to upload a file which is
+// fetched from a remote URL by the renter.
+func (api *API) renterUploadURLHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the url.
+	rawURL := req.FormValue("url")
+	if rawURL == "" {
+		WriteError(w, Error{Message: "'url' parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the optional size limit.
+	var err error
+	var maxSize uint64
+	if ms := req.FormValue("maxsize"); ms != "" {
+		maxSize, err = strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'maxsize' parameter"), http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the optional checksum.
+	var checksum []byte
+	if cs := req.FormValue("sha256"); cs != "" {
+		var h crypto.Hash
+		if err := h.LoadString(cs); err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'sha256' parameter"), http.StatusBadRequest)
+			return
+		}
+		checksum = h[:]
+	}
+	// Check whether existing file should be overwritten
+	force := false
+	if f := req.FormValue("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, NewErrorWithContext(err, "unable to parse 'force' parameter"), http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "unable to parse erasure code settings"), http.StatusBadRequest)
+		return
+	}
+
+	// Call the renter to upload the file.
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	id, err := api.renter.UploadFromURL(modules.FileUploadParams{
+		SiaPath:     siaPath,
+		ErasureCode: ec,
+		Force:       force,
+
+		// NOTE: can make this an optional param.
+		CipherType: crypto.TypeDefaultRenter,
+	}, modules.URLUploadParams{
+		URL:     rawURL,
+		MaxSize: maxSize,
+		SHA256:  checksum,
+	})
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "upload failed"), http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadURLPOST{
+		ID: id,
+	})
+}
+
+// renterURLUploadByIDHandlerGET handles the API call to /renter/uploadurlinfo.
+func (api *API) renterURLUploadByIDHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	id := strings.TrimPrefix(ps.ByName("id"), "/")
+	ui, exists := api.renter.URLUploadByID(modules.URLUploadID(id))
+	if !exists {
+		WriteError(w, Error{Message: fmt.Sprintf("URL upload with id '%v' doesn't exist", id)}, http.StatusBadRequest)
+		return
+	}
+	uis, err := trimURLUploadInfo(ui)
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, uis[0])
+}
+
+// renterURLUploadsHandlerGET handles the API call to /renter/uploadurls.
+func (api *API) renterURLUploadsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	uis := api.renter.URLUploadHistory()
+	root, err := scanBool(req.FormValue("root"))
+	if err != nil {
+		WriteError(w, NewError(err), http.StatusBadRequest)
+		return
+	}
+	if !root {
+		uis, err = trimURLUploadInfo(uis...)
+		if err != nil {
+			WriteError(w, NewError(err), http.StatusInternalServerError)
+			return
+		}
+	}
+	WriteJSON(w, RenterURLUploadsGET{
+		URLUploads: uis,
+	})
+}
+
 // renterValidateSiaPathHandler handles the API call that validates a siapath
 func (api *API) renterValidateSiaPathHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Try and create a new siapath, this will validate the potential siapath
 	_, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -2124,7 +3548,7 @@ func (api *API) renterDirHandlerGET(w http.ResponseWriter, req *http.Request, ps
 	// Check whether the user is requesting the directory from the root path.
 	root, err := isCalledWithRootFlag(req)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -2135,28 +3559,28 @@ func (api *API) renterDirHandlerGET(w http.ResponseWriter, req *http.Request, ps
 		siaPath, err = modules.NewSiaPath(str)
 	}
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	if !root {
 		siaPath, err = rebaseInputSiaPath(siaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
 
 	directories, err := api.renter.DirList(siaPath)
 	if err != nil {
-		WriteError(w, Error{"failed to get directory contents: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "failed to get directory contents"), http.StatusInternalServerError)
 		return
 	}
 
 	if !root {
 		directories, err = trimSiaDirFolder(directories...)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
@@ -2169,14 +3593,14 @@ func (api *API) renterDirHandlerGET(w http.ResponseWriter, req *http.Request, ps
 		mu.Unlock()
 	})
 	if err != nil {
-		WriteError(w, Error{"failed to get file infos: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "failed to get file infos"), http.StatusInternalServerError)
 		return
 	}
 
 	if !root {
 		files, err = trimSiaDirFolderOnFiles(files...)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
@@ -2194,7 +3618,7 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 	// Parse action
 	action := req.FormValue("action")
 	if action == "" {
-		WriteError(w, Error{"you must set the action you wish to execute"}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "you must set the action you wish to execute"}, http.StatusInternalServerError)
 		return
 	}
 	// Parse mode
@@ -2202,28 +3626,28 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 	if m := req.FormValue("mode"); m != "" {
 		mode64, err := strconv.ParseUint(m, 10, 32)
 		if err != nil {
-			WriteError(w, Error{fmt.Sprintf("failed to parse provided mode '%v'", m)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("failed to parse provided mode '%v'", m)}, http.StatusBadRequest)
 			return
 		}
 		mode = os.FileMode(mode64)
 	}
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
 	// Determine whether the user is requesting a user siapath, or a root siapath.
 	root, err := isCalledWithRootFlag(req)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	// Rebase the user's input to the user folder if the user is requesting a user siapath.
 	if !root {
 		siaPath, err = rebaseInputSiaPath(siaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 	}
@@ -2232,7 +3656,7 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		// Call the renter to create directory
 		err := api.renter.CreateDir(siaPath, mode)
 		if err != nil {
-			WriteError(w, Error{"failed to create directory: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, NewErrorWithContext(err, "failed to create directory"), http.StatusInternalServerError)
 			return
 		}
 		WriteSuccess(w)
@@ -2241,7 +3665,7 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 	if action == "delete" {
 		err := api.renter.DeleteDir(siaPath)
 		if err != nil {
-			WriteError(w, Error{"failed to delete directory: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, NewErrorWithContext(err, "failed to delete directory"), http.StatusInternalServerError)
 			return
 		}
 		WriteSuccess(w)
@@ -2250,17 +3674,17 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 	if action == "rename" {
 		newSiaPath, err := modules.NewSiaPath(req.FormValue("newsiapath"))
 		if err != nil {
-			WriteError(w, Error{"failed to parse newsiapath: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "failed to parse newsiapath"), http.StatusBadRequest)
 			return
 		}
 		newSiaPath, err = rebaseInputSiaPath(newSiaPath)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewError(err), http.StatusBadRequest)
 			return
 		}
 		err = api.renter.RenameDir(siaPath, newSiaPath)
 		if err != nil {
-			WriteError(w, Error{"failed to rename directory: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, NewErrorWithContext(err, "failed to rename directory"), http.StatusInternalServerError)
 			return
 		}
 		WriteSuccess(w)
@@ -2268,7 +3692,7 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 	}
 
 	// Report that no calls were made
-	WriteError(w, Error{"no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
+	WriteError(w, Error{Message: "no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
 	return
 }
 
@@ -2277,13 +3701,13 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 func (api *API) renterContractStatusHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var fcID types.FileContractID
 	if err := fcID.LoadString(req.FormValue("id")); err != nil {
-		WriteError(w, Error{"unable to parse id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "unable to parse id"), http.StatusBadRequest)
 		return
 	}
 
 	contractStatus, monitoringContract := api.renter.ContractStatus(fcID)
 	if !monitoringContract {
-		WriteError(w, Error{"renter unaware of contract"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "renter unaware of contract"}, http.StatusBadRequest)
 		return
 	}
 
@@ -2295,7 +3719,7 @@ func (api *API) renterContractStatusHandler(w http.ResponseWriter, req *http.Req
 func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	workerPoolStatus, err := api.renter.WorkerPoolStatus()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 
@@ -35,7 +35,9 @@ func (api *API) buildHTTPRoutes() {
 
 	// Daemon API Calls
 	router.GET("/daemon/alerts", api.daemonAlertsHandlerGET)
+	router.GET("/daemon/audit", RequirePassword(api.daemonAuditHandlerGET, requiredPassword))
 	router.GET("/daemon/constants", api.daemonConstantsHandler)
+	router.GET("/daemon/counters", api.daemonCountersHandlerGET)
 	router.GET("/daemon/settings", api.daemonSettingsHandlerGET)
 	router.POST("/daemon/settings", api.daemonSettingsHandlerPOST)
 	router.GET("/daemon/stack", api.daemonStackHandlerGET)
@@ -48,7 +50,12 @@ func (api *API) buildHTTPRoutes() {
 
 	// Consensus API Calls
 	if api.cs != nil {
-		RegisterRoutesConsensus(router, api.cs)
+		RegisterRoutesConsensus(router, api.cs, requiredPassword)
+	}
+
+	// Light Consensus API Calls
+	if api.lightConsensus != nil {
+		RegisterRoutesLightConsensus(router, api.lightConsensus, requiredPassword)
 	}
 
 	// Explorer API Calls
@@ -71,6 +78,11 @@ func (api *API) buildHTTPRoutes() {
 		})
 	}
 
+	// Identity API Calls
+	if api.identity != nil {
+		RegisterRoutesIdentity(router, api.identity, requiredPassword)
+	}
+
 	// Miner API Calls
 	if api.miner != nil {
 		RegisterRoutesMiner(router, api.miner, requiredPassword)
@@ -79,46 +91,93 @@ func (api *API) buildHTTPRoutes() {
 	// Renter API Calls
 	if api.renter != nil {
 		router.GET("/renter", api.renterHandlerGET)
-		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
-		router.POST("/renter/allowance/cancel", RequirePassword(api.renterAllowanceCancelHandlerPOST, requiredPassword))
+		router.POST("/renter", RequirePassword(api.requireWritableRenter(api.renterHandlerPOST), requiredPassword))
+		router.POST("/renter/allowance/cancel", RequirePassword(api.requireWritableRenter(api.renterAllowanceCancelHandlerPOST), requiredPassword))
+		router.GET("/renter/allowanceprofiles", api.renterAllowanceProfilesHandlerGET)
+		router.POST("/renter/allowanceprofiles", RequirePassword(api.requireWritableRenter(api.renterAllowanceProfilesHandlerPOST), requiredPassword))
+		router.POST("/renter/allowanceprofiles/activate", RequirePassword(api.requireWritableRenter(api.renterAllowanceProfilesActivateHandlerPOST), requiredPassword))
+		router.POST("/renter/allowanceprofiles/delete", RequirePassword(api.requireWritableRenter(api.renterAllowanceProfilesDeleteHandlerPOST), requiredPassword))
+		router.GET("/renter/allowanceprofiles/diff", api.renterAllowanceProfilesDiffHandlerGET)
+		router.POST("/renter/benchmark", RequirePassword(api.requireWritableRenter(api.renterBenchmarkHandlerPOST), requiredPassword))
 		router.POST("/renter/bubble", api.renterBubbleHandlerPOST)
 		router.GET("/renter/backups", RequirePassword(api.renterBackupsHandlerGET, requiredPassword))
-		router.POST("/renter/backups/create", RequirePassword(api.renterBackupsCreateHandlerPOST, requiredPassword))
-		router.POST("/renter/backups/restore", RequirePassword(api.renterBackupsRestoreHandlerGET, requiredPassword))
-		router.POST("/renter/clean", RequirePassword(api.renterCleanHandlerPOST, requiredPassword))
-		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
+		router.POST("/renter/backups/create", RequirePassword(api.requireWritableRenter(api.renterBackupsCreateHandlerPOST), requiredPassword))
+		router.POST("/renter/backups/restore", RequirePassword(api.requireWritableRenter(api.renterBackupsRestoreHandlerGET), requiredPassword))
+		router.GET("/renter/backups/contents", RequirePassword(api.renterBackupsContentsHandlerGET, requiredPassword))
+		router.POST("/renter/backups/restorefiles", RequirePassword(api.requireWritableRenter(api.renterBackupsRestoreFilesHandlerPOST), requiredPassword))
+		router.POST("/renter/clean", RequirePassword(api.requireWritableRenter(api.renterCleanHandlerPOST), requiredPassword))
+		router.POST("/renter/contract/cancel", RequirePassword(api.requireWritableRenter(api.renterContractCancelHandler), requiredPassword))
+		router.POST("/renter/contract/delegate", RequirePassword(api.renterContractDelegateHandlerPOST, requiredPassword))
+		router.GET("/renter/contract/sectorroots", RequirePassword(api.renterContractSectorRootsHandlerGET, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/contractprices", api.renterContractPricesHandlerGET)
 		router.GET("/renter/contractorchurnstatus", api.renterContractorChurnStatus)
+		router.GET("/renter/delegatekey", api.renterDelegateKeyHandlerGET)
+		router.POST("/renter/delegatedread", RequirePassword(api.renterDelegatedReadHandlerPOST, requiredPassword))
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
 		router.GET("/renter/files", api.renterFilesHandler)
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
-		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
+		router.POST("/renter/file/*siapath", RequirePassword(api.requireWritableRenter(api.renterFileHandlerPOST), requiredPassword))
+		router.GET("/renter/filelayout/*siapath", api.renterFileLayoutHandlerGET)
+		router.POST("/renter/fsck", RequirePassword(api.renterFsckHandlerPOST, requiredPassword))
+		router.GET("/renter/migrations", api.renterMigrationsHandlerGET)
 		router.GET("/renter/prices", api.renterPricesHandler)
-		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
+		router.POST("/renter/readonly", RequirePassword(api.renterReadOnlyHandlerPOST, requiredPassword))
+		router.GET("/renter/replication", api.renterReplicationHandlerGET)
+		router.POST("/renter/replication/files", RequirePassword(api.renterReplicationFilesHandlerPOST, requiredPassword))
+		router.GET("/renter/replication/manifest", RequirePassword(api.renterReplicationManifestHandlerGET, requiredPassword))
+		router.POST("/renter/replication/promote", RequirePassword(api.renterReplicationPromoteHandlerPOST, requiredPassword))
+		router.POST("/renter/replication/standby", RequirePassword(api.renterReplicationStandbyHandlerPOST, requiredPassword))
+		router.POST("/renter/recoveryscan", RequirePassword(api.requireWritableRenter(api.renterRecoveryScanHandlerPOST), requiredPassword))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
+		router.GET("/renter/restoremode", api.renterRestoreModeHandlerGET)
+		router.POST("/renter/restoremode/start", RequirePassword(api.renterRestoreModeStartHandlerPOST, requiredPassword))
+		router.POST("/renter/restoremode/stop", RequirePassword(api.renterRestoreModeStopHandlerPOST, requiredPassword))
+		router.GET("/renter/accountcaps", api.renterAccountCapsHandlerGET)
+		router.POST("/renter/accountcaps", RequirePassword(api.renterAccountCapsHandlerPOST, requiredPassword))
+		router.GET("/renter/bandwidth", api.renterBandwidthHandlerGET)
+		router.POST("/renter/bandwidth", RequirePassword(api.renterBandwidthHandlerPOST, requiredPassword))
+		router.GET("/renter/settings/concurrency", api.renterConcurrencyHandlerGET)
+		router.POST("/renter/settings/concurrency", RequirePassword(api.renterConcurrencyHandlerPOST, requiredPassword))
+		router.GET("/renter/settings/placement", api.renterPlacementHandlerGET)
+		router.POST("/renter/settings/placement", RequirePassword(api.renterPlacementHandlerPOST, requiredPassword))
+		router.GET("/renter/settings/timeouts", api.renterTimeoutsHandlerGET)
+		router.POST("/renter/settings/timeouts", RequirePassword(api.renterTimeoutsHandlerPOST, requiredPassword))
+		router.GET("/renter/streamcache", api.renterStreamCacheHandlerGET)
+		router.POST("/renter/streamcache", RequirePassword(api.renterStreamCacheHandlerPOST, requiredPassword))
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
 		router.POST("/renter/fuse/mount", RequirePassword(api.renterFuseMountHandlerPOST, requiredPassword))
 		router.POST("/renter/fuse/unmount", RequirePassword(api.renterFuseUnmountHandlerPOST, requiredPassword))
 
-		router.POST("/renter/delete/*siapath", RequirePassword(api.renterDeleteHandler, requiredPassword))
+		router.GET("/renter/conversion", RequirePassword(api.renterConversionHandlerGET, requiredPassword))
+		router.POST("/renter/conversion/cancel", RequirePassword(api.renterConversionCancelHandlerPOST, requiredPassword))
+		router.POST("/renter/convert/*siapath", RequirePassword(api.requireWritableRenter(api.renterConvertHandlerPOST), requiredPassword))
+		router.POST("/renter/delete/*siapath", RequirePassword(api.requireWritableRenter(api.renterDeleteHandler), requiredPassword))
 		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
 		router.POST("/renter/download/cancel", RequirePassword(api.renterCancelDownloadHandler, requiredPassword))
 		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
-		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
+		router.POST("/renter/export/*siapath", RequirePassword(api.renterExportHandlerPOST, requiredPassword))
+		router.POST("/renter/import/*siapath", RequirePassword(api.requireWritableRenter(api.renterImportHandlerPOST), requiredPassword))
+		router.POST("/renter/rename/*siapath", RequirePassword(api.requireWritableRenter(api.renterRenameHandler), requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
-		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.POST("/renter/upload/*siapath", RequirePassword(api.requireWritableRenter(api.renterUploadHandler), requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
-		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
-		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
-		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
+		router.POST("/renter/uploads/pause", RequirePassword(api.requireWritableRenter(api.renterUploadsPauseHandler), requiredPassword))
+		router.POST("/renter/uploads/resume", RequirePassword(api.requireWritableRenter(api.renterUploadsResumeHandler), requiredPassword))
+		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.requireWritableRenter(api.renterUploadStreamHandler), requiredPassword))
+		router.POST("/renter/uploadurl/*siapath", RequirePassword(api.requireWritableRenter(api.renterUploadURLHandler), requiredPassword))
+		router.GET("/renter/uploadurlinfo/*id", api.renterURLUploadByIDHandlerGET)
+		router.GET("/renter/uploadurls", api.renterURLUploadsHandlerGET)
 		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateSiaPathHandler, requiredPassword))
 		router.GET("/renter/workers", api.renterWorkersHandler)
 
 		// Directory endpoints
-		router.POST("/renter/dir/*siapath", RequirePassword(api.renterDirHandlerPOST, requiredPassword))
+		router.POST("/renter/dir/*siapath", RequirePassword(api.requireWritableRenter(api.renterDirHandlerPOST), requiredPassword))
 		router.GET("/renter/dir/*siapath", api.renterDirHandlerGET)
+		router.POST("/renter/alias/*siapath", RequirePassword(api.requireWritableRenter(api.renterAliasHandlerPOST), requiredPassword))
+		router.GET("/renter/alias/*siapath", api.renterAliasHandlerGET)
 
 		// HostDB endpoints.
 		router.GET("/hostdb", api.hostdbHandler)
@@ -132,13 +191,14 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/contractstatus", api.renterContractStatusHandler)
 
 		// Deprecated endpoints.
-		router.POST("/renter/backup", RequirePassword(api.renterBackupHandlerPOST, requiredPassword))
-		router.POST("/renter/recoverbackup", RequirePassword(api.renterLoadBackupHandlerPOST, requiredPassword))
+		router.POST("/renter/backup", RequirePassword(api.requireWritableRenter(api.renterBackupHandlerPOST), requiredPassword))
+		router.POST("/renter/recoverbackup", RequirePassword(api.requireWritableRenter(api.renterLoadBackupHandlerPOST), requiredPassword))
+		router.POST("/renter/legacyimport", RequirePassword(api.requireWritableRenter(api.renterLegacyImportHandlerPOST), requiredPassword))
 	}
 
 	// Transaction pool API Calls
 	if api.tpool != nil {
-		RegisterRoutesTransactionPool(router, api.tpool)
+		RegisterRoutesTransactionPool(router, api.tpool, requiredPassword)
 	}
 
 	// Wallet API Calls
@@ -146,24 +206,47 @@ func (api *API) buildHTTPRoutes() {
 		RegisterRoutesWallet(router, api.wallet, requiredPassword)
 	}
 
-	// Apply UserAgent middleware and return the Router
-	timeoutErr := Error{fmt.Sprintf("HTTP call exceeded the timeout of %v", httpServerTimeout)}
+	// Apply UserAgent, audit and rate limit middleware and return the Router
+	timeoutErr := Error{
+		Message: fmt.Sprintf("HTTP call exceeded the timeout of %v", httpServerTimeout),
+		Code:    ErrorCodeTimeout,
+	}
 	jsonErr, err := json.Marshal(timeoutErr)
 	if err != nil {
 		build.Critical("marshalling error on object that should be safe to marshal:", err)
 	}
 	api.routerMu.Lock()
-	api.router = http.TimeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout, string(jsonErr))
+	api.router = api.staticCORS.withCORS(api.staticRateLimiter.withRateLimit(withTimeout(RequireUserAgent(api.staticAuditLog.withAudit(router), requiredUserAgent), httpServerTimeout, string(jsonErr)), requiredPassword))
 	api.routerMu.Unlock()
 	return
 }
 
+// withTimeout is middleware that applies http.TimeoutHandler to all requests
+// except websocket upgrades. Websocket connections are long-lived and need to
+// hijack the connection, which the timeout handler doesn't support.
+func withTimeout(h http.Handler, dt time.Duration, msg string) http.Handler {
+	th := http.TimeoutHandler(h, dt, msg)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if isWebsocketRequest(req) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		th.ServeHTTP(w, req)
+	})
+}
+
+// isWebsocketRequest checks if a request asks to upgrade the connection to a
+// websocket.
+func isWebsocketRequest(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
 // RequireUserAgent is middleware that requires all requests to set a
 // UserAgent that contains the specified string.
 func RequireUserAgent(h http.Handler, ua string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if !strings.Contains(req.UserAgent(), ua) && !isUnrestricted(req) {
-			WriteError(w, Error{"Browser access disabled due to security vulnerability. Use Sia-UI or siac."}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "Browser access disabled due to security vulnerability. Use Sia-UI or siac."}, http.StatusBadRequest)
 			return
 		}
 		h.ServeHTTP(w, req)
@@ -182,7 +265,7 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 		_, pass, ok := req.BasicAuth()
 		if !ok || pass != password {
 			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
-			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
+			WriteError(w, Error{Message: "API authentication failed."}, http.StatusUnauthorized)
 			return
 		}
 		h(w, req, ps)
@@ -116,6 +116,16 @@ func (srv *Server) RenterSettings() (modules.RenterSettings, error) {
 	return srv.node.Renter.Settings()
 }
 
+// EnableCORS enables cross-origin requests to the server's API.
+func (srv *Server) EnableCORS(settings api.CORSSettings) error {
+	return srv.api.EnableCORS(settings)
+}
+
+// SetRateLimits sets the rate limits of the server's API.
+func (srv *Server) SetRateLimits(settings api.RateLimitSettings) {
+	srv.api.SetRateLimits(settings)
+}
+
 // ServeErr is a blocking call that will return the result of srv.serve after
 // the server stopped.
 func (srv *Server) ServeErr() <-chan error {
@@ -176,6 +186,11 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 
 		// Create the api for the server.
 		api := api.New(cfg, requiredUserAgent, requiredPassword, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		if nodeParams.Dir != "" {
+			if err := api.EnableAuditLog(nodeParams.Dir); err != nil {
+				return nil, errors.AddContext(err, "failed to enable audit log")
+			}
+		}
 		srv := &Server{
 			api: api,
 			apiServer: &http.Server{
@@ -236,7 +251,7 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 
 		// Server wasn't shut down. Add node and replace modules.
 		srv.node = n
-		api.SetModules(n.Accounting, n.ConsensusSet, n.Explorer, n.Gateway, n.Host, n.Miner, n.Renter, n.TransactionPool, n.Wallet)
+		api.SetModules(n.Accounting, n.ConsensusSet, n.Explorer, n.Gateway, n.Host, n.Identity, n.LightConsensusSet, n.Miner, n.Renter, n.TransactionPool, n.Wallet)
 		return srv, nil
 	}()
 	if err != nil {
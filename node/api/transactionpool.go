@@ -35,6 +35,11 @@ type (
 		Confirmed bool `json:"confirmed"`
 	}
 
+	// TpoolSettingsGET contains the admission and relay policy of the tpool.
+	TpoolSettingsGET struct {
+		Settings modules.TransactionPoolSettings `json:"settings"`
+	}
+
 	// TpoolTxnsGET contains the information about the tpool's transactions
 	TpoolTxnsGET struct {
 		Transactions []types.Transaction `json:"transactions"`
@@ -43,7 +48,7 @@ type (
 
 // RegisterRoutesTransactionPool is a helper function to register all
 // transaction pool routes.
-func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.TransactionPool) {
+func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.TransactionPool, requiredPassword string) {
 	router.GET("/tpool/fee", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolFeeHandlerGET(tpool, w, req, ps)
 	})
@@ -56,6 +61,12 @@ func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.Tran
 	router.GET("/tpool/confirmed/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolConfirmedGET(tpool, w, req, ps)
 	})
+	router.GET("/tpool/settings", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolSettingsHandlerGET(tpool, w, req, ps)
+	})
+	router.POST("/tpool/settings", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolSettingsHandlerPOST(tpool, w, req, ps)
+	}, requiredPassword))
 	router.GET("/tpool/transactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolTransactionsHandler(tpool, w, req, ps)
 	})
@@ -86,12 +97,12 @@ func tpoolFeeHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _
 func tpoolRawHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	txid, err := decodeTransactionID(ps.ByName("id"))
 	if err != nil {
-		WriteError(w, Error{"error decoding transaction id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error decoding transaction id"), http.StatusBadRequest)
 		return
 	}
 	txn, parents, exists := tpool.Transaction(txid)
 	if !exists {
-		WriteError(w, Error{"transaction not found in transaction pool"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "transaction not found in transaction pool"}, http.StatusBadRequest)
 		return
 	}
 
@@ -105,7 +116,15 @@ func tpoolRawHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _
 // tpoolRawHandlerPOST takes a raw encoded transaction set and posts
 // it to the transaction pool, relaying it to the transaction pool's peers
 // regardless of if the set is accepted.
+//
+// If the 'package' parameter is provided instead, the transactions are treated
+// as a package of dependent transactions which is accepted atomically and only
+// relayed if it was accepted.
 func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if req.FormValue("package") != "" {
+		tpoolRawPackageHandlerPOST(tpool, w, req)
+		return
+	}
 	var parents []types.Transaction
 	var txn types.Transaction
 
@@ -116,7 +135,7 @@ func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, r
 			rawParents = []byte(req.FormValue("parents"))
 		}
 		if err := encoding.Unmarshal(rawParents, &parents); err != nil {
-			WriteError(w, Error{"error decoding parents: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error decoding parents"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -126,7 +145,7 @@ func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, r
 			rawTransaction = []byte(req.FormValue("transaction"))
 		}
 		if err := encoding.Unmarshal(rawTransaction, &txn); err != nil {
-			WriteError(w, Error{"error decoding transaction: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error decoding transaction"), http.StatusBadRequest)
 			return
 		}
 	}
@@ -136,7 +155,31 @@ func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, r
 	tpool.Broadcast(txnSet)
 	err := tpool.AcceptTransactionSet(txnSet)
 	if err != nil && !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
-		WriteError(w, Error{"error accepting transaction set: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error accepting transaction set"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// tpoolRawPackageHandlerPOST takes a raw encoded transaction package and
+// submits it to the transaction pool.
+func tpoolRawPackageHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request) {
+	var pkg []types.Transaction
+
+	// JSON, base64, and raw binary are accepted
+	if err := json.Unmarshal([]byte(req.FormValue("package")), &pkg); err != nil {
+		rawPackage, err := base64.StdEncoding.DecodeString(req.FormValue("package"))
+		if err != nil {
+			rawPackage = []byte(req.FormValue("package"))
+		}
+		if err := encoding.Unmarshal(rawPackage, &pkg); err != nil {
+			WriteError(w, NewErrorWithContext(err, "error decoding package"), http.StatusBadRequest)
+			return
+		}
+	}
+	err := tpool.AcceptTransactionPackage(pkg)
+	if err != nil && !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+		WriteError(w, NewErrorWithContext(err, "error accepting transaction package"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -147,12 +190,12 @@ func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, r
 func tpoolConfirmedGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	txid, err := decodeTransactionID(ps.ByName("id"))
 	if err != nil {
-		WriteError(w, Error{"error decoding transaction id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error decoding transaction id"), http.StatusBadRequest)
 		return
 	}
 	confirmed, err := tpool.TransactionConfirmed(txid)
 	if err != nil {
-		WriteError(w, Error{"error fetching transaction status: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error fetching transaction status"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, TpoolConfirmedGET{
@@ -160,6 +203,31 @@ func tpoolConfirmedGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *
 	})
 }
 
+// tpoolSettingsHandlerGET returns the admission and relay policy of the
+// transaction pool.
+func tpoolSettingsHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, TpoolSettingsGET{
+		Settings: tpool.Settings(),
+	})
+}
+
+// tpoolSettingsHandlerPOST sets the admission and relay policy of the
+// transaction pool. The settings are provided as JSON in the request body.
+func tpoolSettingsHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings modules.TransactionPoolSettings
+	err := json.NewDecoder(req.Body).Decode(&settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
+		return
+	}
+	err = tpool.SetSettings(settings)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "failed to set tpool settings"), http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // tpoolTransactionsHandler returns the current transactions of the transaction
 // pool
 func tpoolTransactionsHandler(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
@@ -29,6 +29,7 @@ type (
 		ConfirmedSiacoinBalance     types.Currency `json:"confirmedsiacoinbalance"`
 		UnconfirmedOutgoingSiacoins types.Currency `json:"unconfirmedoutgoingsiacoins"`
 		UnconfirmedIncomingSiacoins types.Currency `json:"unconfirmedincomingsiacoins"`
+		ImmatureSiacoinBalance      types.Currency `json:"immaturesiacoinbalance"`
 
 		SiacoinClaimBalance types.Currency `json:"siacoinclaimbalance"`
 		SiafundBalance      types.Currency `json:"siafundbalance"`
@@ -48,12 +49,27 @@ type (
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
 
+	// WalletImmatureGET contains the immature siacoin outputs of the wallet and
+	// the projected spendable balance at each of their maturity heights.
+	WalletImmatureGET struct {
+		MaturityDelay types.BlockHeight                    `json:"maturitydelay"`
+		Outputs       []modules.ImmatureSiacoinOutput      `json:"outputs"`
+		Timeline      []modules.SpendableBalanceProjection `json:"timeline"`
+	}
+
 	// WalletInitPOST contains the primary seed that gets generated during a
 	// POST call to /wallet/init.
 	WalletInitPOST struct {
 		PrimarySeed string `json:"primaryseed"`
 	}
 
+	// WalletBumpFeePOST contains the transaction set submitted by the POST
+	// call to /wallet/bumpfee/:txid.
+	WalletBumpFeePOST struct {
+		Transactions   []types.Transaction   `json:"transactions"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
 	// WalletSiacoinsPOST contains the transaction sent in the POST call to
 	// /wallet/siacoins.
 	WalletSiacoinsPOST struct {
@@ -180,6 +196,9 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/backup", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletBackupHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/bumpfee/:txid", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletBumpFeeHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/init", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletInitHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -192,6 +211,9 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.POST("/wallet/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/immature", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletImmatureHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/seeds", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedsHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -268,37 +290,48 @@ func encryptionKeys(seedStr string) (validKeys []crypto.CipherKey, seeds []modul
 func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	siacoinBal, siafundBal, siaclaimBal, err := wallet.ConfirmedBalance()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	siacoinsOut, siacoinsIn, err := wallet.UnconfirmedBalance()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	dustThreshold, err := wallet.DustThreshold()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
+	immatureOutputs, _, err := wallet.ImmatureBalance()
+	if err != nil {
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	var immatureBal types.Currency
+	for _, output := range immatureOutputs {
+		if output.Value.Cmp(dustThreshold) > 0 {
+			immatureBal = immatureBal.Add(output.Value)
+		}
+	}
 	encrypted, err := wallet.Encrypted()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	unlocked, err := wallet.Unlocked()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	rescanning, err := wallet.Rescanning()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	height, err := wallet.Height()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletGET{
@@ -310,6 +343,7 @@ func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request
 		ConfirmedSiacoinBalance:     siacoinBal,
 		UnconfirmedOutgoingSiacoins: siacoinsOut,
 		UnconfirmedIncomingSiacoins: siacoinsIn,
+		ImmatureSiacoinBalance:      immatureBal,
 
 		SiafundBalance:      siafundBal,
 		SiacoinClaimBalance: siaclaimBal,
@@ -323,7 +357,7 @@ func wallet033xHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 	source := req.FormValue("source")
 	// Check that source is an absolute paths.
 	if !filepath.IsAbs(source) {
-		WriteError(w, Error{"error when calling /wallet/033x: source must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/033x: source must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
 	potentialKeys, _ := encryptionKeys(req.FormValue("encryptionpassword"))
@@ -334,18 +368,18 @@ func wallet033xHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 			return
 		}
 		if !errors.Contains(err, modules.ErrBadEncryptionKey) {
-			WriteError(w, Error{"error when calling /wallet/033x: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/033x"), http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, NewError(modules.ErrBadEncryptionKey), http.StatusBadRequest)
 }
 
 // walletAddressHandler handles API calls to /wallet/address.
 func walletAddressHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	unlockConditions, err := wallet.NextAddress()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/addresses: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/addresses"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletAddressGET{
@@ -362,14 +396,14 @@ func walletSeedAddressesHandler(wallet modules.Wallet, w http.ResponseWriter, re
 	if c != "" {
 		_, err := fmt.Sscan(c, &count)
 		if err != nil {
-			WriteError(w, Error{"Failed to parse count: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "Failed to parse count"), http.StatusBadRequest)
 			return
 		}
 	}
 	// Get the last count addresses.
 	addresses, err := wallet.LastAddresses(count)
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet/addresses: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet/addresses: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	// Send the response.
@@ -382,7 +416,7 @@ func walletSeedAddressesHandler(wallet modules.Wallet, w http.ResponseWriter, re
 func walletAddressesHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	addresses, err := wallet.AllAddresses()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet/addresses: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet/addresses: %v", err)}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletAddressesGET{
@@ -395,17 +429,40 @@ func walletBackupHandler(wallet modules.Wallet, w http.ResponseWriter, req *http
 	destination := req.FormValue("destination")
 	// Check that the destination is absolute.
 	if !filepath.IsAbs(destination) {
-		WriteError(w, Error{"error when calling /wallet/backup: destination must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/backup: destination must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
 	err := wallet.CreateBackup(destination)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/backup"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
+// walletBumpFeeHandler handles API calls to /wallet/bumpfee/:txid.
+func walletBumpFeeHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var txid types.TransactionID
+	err := txid.UnmarshalJSON([]byte("\"" + ps.ByName("txid") + "\""))
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/bumpfee"), http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.BumpFee(txid)
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/bumpfee"), http.StatusBadRequest)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletBumpFeePOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
 // walletInitHandler handles API calls to /wallet/init.
 func walletInitHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.CipherKey
@@ -416,13 +473,13 @@ func walletInitHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 	if req.FormValue("force") == "true" {
 		err := wallet.Reset()
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/init"), http.StatusBadRequest)
 			return
 		}
 	}
 	seed, err := wallet.Encrypt(encryptionKey)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/init"), http.StatusBadRequest)
 		return
 	}
 
@@ -432,7 +489,7 @@ func walletInitHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 	}
 	seedStr, err := modules.SeedToString(seed, dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/init"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletInitPOST{
@@ -452,21 +509,21 @@ func walletInitSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 	}
 	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/init/seed"), http.StatusBadRequest)
 		return
 	}
 
 	if req.FormValue("force") == "true" {
 		err = wallet.Reset()
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/init/seed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/init/seed"), http.StatusBadRequest)
 			return
 		}
 	}
 
 	err = wallet.InitFromSeed(encryptionKey, seed)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/init/seed"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -481,7 +538,7 @@ func walletSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 	}
 	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/seed"), http.StatusBadRequest)
 		return
 	}
 
@@ -493,11 +550,11 @@ func walletSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 			return
 		}
 		if !errors.Contains(err, modules.ErrBadEncryptionKey) {
-			WriteError(w, Error{"error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/seed"), http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{"error when calling /wallet/seed: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, NewErrorWithContext(modules.ErrBadEncryptionKey, "error when calling /wallet/seed"), http.StatusBadRequest)
 }
 
 // walletSiagkeyHandler handles API calls to /wallet/siagkey.
@@ -509,7 +566,7 @@ func walletSiagkeyHandler(wallet modules.Wallet, w http.ResponseWriter, req *htt
 	for _, keypath := range keyfiles {
 		// Check that all key paths are absolute paths.
 		if !filepath.IsAbs(keypath) {
-			WriteError(w, Error{"error when calling /wallet/siagkey: keyfiles contains a non-absolute path"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/siagkey: keyfiles contains a non-absolute path"}, http.StatusBadRequest)
 			return
 		}
 	}
@@ -521,18 +578,18 @@ func walletSiagkeyHandler(wallet modules.Wallet, w http.ResponseWriter, req *htt
 			return
 		}
 		if !errors.Contains(err, modules.ErrBadEncryptionKey) {
-			WriteError(w, Error{"error when calling /wallet/siagkey: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/siagkey"), http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{"error when calling /wallet/siagkey: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, NewErrorWithContext(modules.ErrBadEncryptionKey, "error when calling /wallet/siagkey"), http.StatusBadRequest)
 }
 
 // walletLockHandler handles API calls to /wallet/lock.
 func walletLockHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	err := wallet.Lock()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewError(err), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -548,26 +605,26 @@ func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.
 	// Get the primary seed information.
 	primarySeed, addrsRemaining, err := wallet.PrimarySeed()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/seeds"), http.StatusBadRequest)
 		return
 	}
 	primarySeedStr, err := modules.SeedToString(primarySeed, dictionary)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/seeds"), http.StatusBadRequest)
 		return
 	}
 
 	// Get the list of seeds known to the wallet.
 	allSeeds, err := wallet.AllSeeds()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/seeds"), http.StatusBadRequest)
 		return
 	}
 	var allSeedsStrs []string
 	for _, seed := range allSeeds {
 		str, err := modules.SeedToString(seed, dictionary)
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/seeds"), http.StatusBadRequest)
 			return
 		}
 		allSeedsStrs = append(allSeedsStrs, str)
@@ -585,36 +642,36 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
 		if req.FormValue("amount") != "" || req.FormValue("destination") != "" || req.FormValue("feeIncluded") != "" {
-			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair and/or feeIncluded parameter"}, http.StatusInternalServerError)
+			WriteError(w, Error{Message: "cannot supply both 'outputs' and single amount+destination pair and/or feeIncluded parameter"}, http.StatusInternalServerError)
 			return
 		}
 
 		var outputs []types.SiacoinOutput
 		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
 		if err != nil {
-			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, NewErrorWithContext(err, "could not decode outputs"), http.StatusInternalServerError)
 			return
 		}
 		txns, err = wallet.SendSiacoinsMulti(outputs)
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/siacoins"), http.StatusInternalServerError)
 			return
 		}
 	} else {
 		// single amount + destination
 		amount, ok := scanAmount(req.FormValue("amount"))
 		if !ok {
-			WriteError(w, Error{"could not read amount from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "could not read amount from POST call to /wallet/siacoins"}, http.StatusBadRequest)
 			return
 		}
 		dest, err := scanAddress(req.FormValue("destination"))
 		if err != nil {
-			WriteError(w, Error{"could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
 			return
 		}
 		feeIncluded, err := scanBool(req.FormValue("feeIncluded"))
 		if err != nil {
-			WriteError(w, Error{"could not read feeIncluded from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "could not read feeIncluded from POST call to /wallet/siacoins"}, http.StatusBadRequest)
 			return
 		}
 
@@ -624,7 +681,7 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			txns, err = wallet.SendSiacoins(amount, dest)
 		}
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, NewErrorWithContext(err, "error when calling /wallet/siacoins"), http.StatusInternalServerError)
 			return
 		}
 	}
@@ -643,18 +700,18 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 func walletSiafundsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	amount, ok := scanAmount(req.FormValue("amount"))
 	if !ok {
-		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/siafunds"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "could not read 'amount' from POST call to /wallet/siafunds"}, http.StatusBadRequest)
 		return
 	}
 	dest, err := scanAddress(req.FormValue("destination"))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/siafunds"), http.StatusBadRequest)
 		return
 	}
 
 	txns, err := wallet.SendSiafunds(amount, dest)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/siafunds"), http.StatusInternalServerError)
 		return
 	}
 	var txids []types.TransactionID
@@ -676,13 +733,13 @@ func walletSweepSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *h
 	}
 	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/sweep/seed"), http.StatusBadRequest)
 		return
 	}
 
 	coins, funds, err := wallet.SweepSeed(seed)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/sweep/seed"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletSweepPOST{
@@ -698,17 +755,17 @@ func walletTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, _ *h
 	jsonID := "\"" + ps.ByName("id") + "\""
 	err := id.UnmarshalJSON([]byte(jsonID))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transaction/id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/transaction/id"), http.StatusBadRequest)
 		return
 	}
 
 	txn, ok, err := wallet.Transaction(id)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transaction/id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/transaction/id"), http.StatusBadRequest)
 		return
 	}
 	if !ok {
-		WriteError(w, Error{"error when calling /wallet/transaction/id  :  transaction not found"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transaction/id  :  transaction not found"}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletTransactionGETid{
@@ -720,13 +777,13 @@ func walletTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, _ *h
 func walletTransactionsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
 	if startheightStr == "" || endheightStr == "" {
-		WriteError(w, Error{"startheight and endheight must be provided to a /wallet/transactions call."}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "startheight and endheight must be provided to a /wallet/transactions call."}, http.StatusBadRequest)
 		return
 	}
 	// Get the start and end blocks.
 	start, err := strconv.ParseUint(startheightStr, 10, 64)
 	if err != nil {
-		WriteError(w, Error{"parsing integer value for parameter `startheight` failed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "parsing integer value for parameter `startheight` failed"), http.StatusBadRequest)
 		return
 	}
 	// Check if endheightStr is set to -1. If it is, we use MaxUint64 as the
@@ -738,17 +795,17 @@ func walletTransactionsHandler(wallet modules.Wallet, w http.ResponseWriter, req
 		end, err = strconv.ParseUint(endheightStr, 10, 64)
 	}
 	if err != nil {
-		WriteError(w, Error{"parsing integer value for parameter `endheight` failed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "parsing integer value for parameter `endheight` failed"), http.StatusBadRequest)
 		return
 	}
 	confirmedTxns, err := wallet.Transactions(types.BlockHeight(start), types.BlockHeight(end))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/transactions"), http.StatusBadRequest)
 		return
 	}
 	unconfirmedTxns, err := wallet.UnconfirmedTransactions()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/transactions"), http.StatusBadRequest)
 		return
 	}
 
@@ -766,18 +823,18 @@ func walletTransactionsAddrHandler(wallet modules.Wallet, w http.ResponseWriter,
 	var addr types.UnlockHash
 	err := addr.UnmarshalJSON([]byte(jsonAddr))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/transactions"), http.StatusBadRequest)
 		return
 	}
 
 	confirmedATs, err := wallet.AddressTransactions(addr)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/transactions"), http.StatusBadRequest)
 		return
 	}
 	unconfirmedATs, err := wallet.AddressUnconfirmedTransactions(addr)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/transactions"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletTransactionsGETaddr{
@@ -803,7 +860,7 @@ func walletUnlockHandler(wallet modules.Wallet, w http.ResponseWriter, req *http
 		}
 		err = errors.Compose(err, unlockErr)
 	}
-	WriteError(w, Error{"error when calling /wallet/unlock: " + err.Error()}, http.StatusBadRequest)
+	WriteError(w, NewErrorWithContext(err, "error when calling /wallet/unlock"), http.StatusBadRequest)
 }
 
 // walletChangePasswordHandler handles API calls to /wallet/changepassword
@@ -811,7 +868,7 @@ func walletChangePasswordHandler(wallet modules.Wallet, w http.ResponseWriter, r
 	var newKey crypto.CipherKey
 	newPassword := req.FormValue("newpassword")
 	if newPassword == "" {
-		WriteError(w, Error{"a password must be provided to newpassword"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "a password must be provided to newpassword"}, http.StatusBadRequest)
 		return
 	}
 	newKey = crypto.NewWalletKey(crypto.HashObject(newPassword))
@@ -834,7 +891,7 @@ func walletChangePasswordHandler(wallet modules.Wallet, w http.ResponseWriter, r
 		}
 		err = errors.Compose(err, seedErr)
 	}
-	WriteError(w, Error{"error when calling /wallet/changepassword: " + err.Error()}, http.StatusBadRequest)
+	WriteError(w, NewErrorWithContext(err, "error when calling /wallet/changepassword"), http.StatusBadRequest)
 	return
 }
 
@@ -852,7 +909,7 @@ func walletVerifyPasswordHandler(wallet modules.Wallet, w http.ResponseWriter, r
 		}
 		err = errors.Compose(err, keyErr)
 	}
-	WriteError(w, Error{"error when calling /wallet/verifypassword: " + err.Error()}, http.StatusBadRequest)
+	WriteError(w, NewErrorWithContext(err, "error when calling /wallet/verifypassword"), http.StatusBadRequest)
 }
 
 // walletVerifyAddressHandler handles API calls to /wallet/verify/address/:addr.
@@ -868,12 +925,12 @@ func walletUnlockConditionsHandlerGET(wallet modules.Wallet, w http.ResponseWrit
 	var addr types.UnlockHash
 	err := addr.LoadString(ps.ByName("addr"))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/unlockconditions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/unlockconditions"), http.StatusBadRequest)
 		return
 	}
 	uc, err := wallet.UnlockConditions(addr)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/unlockconditions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/unlockconditions"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletUnlockConditionsGET{
@@ -886,12 +943,12 @@ func walletUnlockConditionsHandlerPOST(wallet modules.Wallet, w http.ResponseWri
 	var params WalletUnlockConditionsPOSTParams
 	err := json.NewDecoder(req.Body).Decode(&params)
 	if err != nil {
-		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
 		return
 	}
 	err = wallet.AddUnlockConditions(params.UnlockConditions)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/unlockconditions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/unlockconditions"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -901,7 +958,7 @@ func walletUnlockConditionsHandlerPOST(wallet modules.Wallet, w http.ResponseWri
 func walletUnspentHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	outputs, err := wallet.UnspentOutputs()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/unspent: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/unspent"), http.StatusInternalServerError)
 		return
 	}
 	WriteJSON(w, WalletUnspentGET{
@@ -909,17 +966,31 @@ func walletUnspentHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.
 	})
 }
 
+// walletImmatureHandler handles API calls to /wallet/immature.
+func walletImmatureHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	outputs, timeline, err := wallet.ImmatureBalance()
+	if err != nil {
+		WriteError(w, NewErrorWithContext(err, "error when calling /wallet/immature"), http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletImmatureGET{
+		MaturityDelay: types.MaturityDelay,
+		Outputs:       outputs,
+		Timeline:      timeline,
+	})
+}
+
 // walletSignHandler handles API calls to /wallet/sign.
 func walletSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var params WalletSignPOSTParams
 	err := json.NewDecoder(req.Body).Decode(&params)
 	if err != nil {
-		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
 		return
 	}
 	err = wallet.SignTransaction(&params.Transaction, params.ToSign)
 	if err != nil {
-		WriteError(w, Error{"failed to sign transaction: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to sign transaction"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletSignPOSTResp{
@@ -931,7 +1002,7 @@ func walletSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 func walletWatchHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	addrs, err := wallet.WatchAddresses()
 	if err != nil {
-		WriteError(w, Error{"failed to get watch addresses: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to get watch addresses"), http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletWatchGET{
@@ -944,7 +1015,7 @@ func walletWatchHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *h
 	var wwpp WalletWatchPOST
 	err := json.NewDecoder(req.Body).Decode(&wwpp)
 	if err != nil {
-		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "invalid parameters"), http.StatusBadRequest)
 		return
 	}
 	if wwpp.Remove {
@@ -953,7 +1024,7 @@ func walletWatchHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *h
 		err = wallet.AddWatchAddresses(wwpp.Addresses, wwpp.Unused)
 	}
 	if err != nil {
-		WriteError(w, Error{"failed to update watch set: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, NewErrorWithContext(err, "failed to update watch set"), http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -22,6 +22,7 @@ import (
 	"go.sia.tech/siad/modules/explorer"
 	"go.sia.tech/siad/modules/gateway"
 	"go.sia.tech/siad/modules/host"
+	"go.sia.tech/siad/modules/identity"
 	"go.sia.tech/siad/modules/miner"
 	"go.sia.tech/siad/modules/renter"
 	"go.sia.tech/siad/modules/renter/contractor"
@@ -67,6 +68,11 @@ type NodeParams struct {
 	CreateTransactionPool bool
 	CreateWallet          bool
 
+	// CreateLightConsensusSet creates a light consensus set which only
+	// tracks the block headers instead of a full consensus set. It can't be
+	// combined with a consensus set or the modules depending on it.
+	CreateLightConsensusSet bool
+
 	// Custom modules - if the modules is provided directly, the provided
 	// module will be used instead of creating a new one. If a custom module is
 	// provided, the 'omit' flag for that module must be set to false (which is
@@ -81,6 +87,12 @@ type NodeParams struct {
 	TransactionPool modules.TransactionPool
 	Wallet          modules.Wallet
 
+	LightConsensusSet modules.LightConsensusSet
+
+	// Identity is the identity which manages the node's keys. If it is nil,
+	// the identity is loaded from the node's directory.
+	Identity modules.Identity
+
 	// Dependencies for each module supporting dependency injection.
 	AccountingDeps   modules.Dependencies
 	ConsensusSetDeps modules.Dependencies
@@ -107,9 +119,33 @@ type NodeParams struct {
 	HostStorage uint64
 	RPCAddress  string
 
+	// DNSSeeds are the hostnames the gateway queries for nodes when
+	// bootstrapping. If nil, modules.DNSSeeds is used. DisableDNSSeeds
+	// prevents the gateway from querying any DNS seeds, which is useful for
+	// private networks.
+	DNSSeeds        []string
+	DisableDNSSeeds bool
+
+	// ConsensusSnapshot is the URL or path of a consensus snapshot the
+	// consensus set is initialized with if it has no database yet. The
+	// snapshot is verified against ConsensusCheckpoint, which is required if
+	// ConsensusSnapshot is set.
+	ConsensusSnapshot   string
+	ConsensusCheckpoint *modules.ConsensusCheckpoint
+
+	// PrivateNetwork creates a gateway for a private network which doesn't
+	// bootstrap, query DNS seeds or announce itself using public services.
+	// The genesis block of the network needs to be set using
+	// types.SetPrivateNetwork before the node is created.
+	PrivateNetwork bool
+
 	// Initialize node from existing seed.
 	PrimarySeed string
 
+	// WalletSeedProvider stores the primary seed of the wallet outside of the
+	// wallet's database if set.
+	WalletSeedProvider modules.SeedProvider
+
 	// The following fields are used to skip parts of the node set up
 	SkipSetAllowance     bool
 	SkipHostDiscovery    bool
@@ -126,6 +162,9 @@ type Node struct {
 	// The mux of the node.
 	Mux *siamux.SiaMux
 
+	// The identity which manages the keys of the node.
+	Identity modules.Identity
+
 	// The modules of the node. Modules that are not initialized will be nil.
 	Accounting      modules.Accounting
 	ConsensusSet    modules.ConsensusSet
@@ -137,6 +176,8 @@ type Node struct {
 	TransactionPool modules.TransactionPool
 	Wallet          modules.Wallet
 
+	LightConsensusSet modules.LightConsensusSet
+
 	// The high level directory where all the persistence gets stored for the
 	// modules.
 	Dir string
@@ -151,6 +192,9 @@ func (np NodeParams) NumModules() (n int) {
 	if np.CreateConsensusSet || np.ConsensusSet != nil {
 		n++
 	}
+	if np.CreateLightConsensusSet || np.LightConsensusSet != nil {
+		n++
+	}
 	if np.CreateTransactionPool || np.TransactionPool != nil {
 		n++
 	}
@@ -224,6 +268,10 @@ func (n *Node) Close() (err error) {
 		printlnRelease("Closing consensusset...")
 		err = errors.Compose(err, n.ConsensusSet.Close())
 	}
+	if n.LightConsensusSet != nil {
+		printlnRelease("Closing light consensusset...")
+		err = errors.Compose(err, n.LightConsensusSet.Close())
+	}
 	if n.Gateway != nil {
 		printlnRelease("Closing gateway...")
 		err = errors.Compose(err, n.Gateway.Close())
@@ -232,6 +280,10 @@ func (n *Node) Close() (err error) {
 		printlnRelease("Closing siamux...")
 		err = errors.Compose(err, n.Mux.Close())
 	}
+	if n.Identity != nil {
+		printlnRelease("Closing identity...")
+		err = errors.Compose(err, n.Identity.Close())
+	}
 	return err
 }
 
@@ -249,8 +301,39 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return nil, errChan
 	}
 
-	// Create the siamux.
-	mux, err := modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
+	// Load the identity.
+	id := params.Identity
+	if id == nil {
+		id, err = identity.New(filepath.Join(dir, modules.IdentityDir))
+		if err != nil {
+			errChan <- errors.Extend(err, errors.New("unable to load identity"))
+			return nil, errChan
+		}
+	}
+
+	// Create the siamux. It uses the host key of the identity. An identity
+	// without a host key adopts the key of the siamux, which keeps the host
+	// key of existing nodes.
+	mux, err := func() (*siamux.SiaMux, error) {
+		siaMuxDir := filepath.Join(dir, modules.SiaMuxDir)
+		sk, _, err := id.KeyPair(modules.KeyPurposeHost)
+		if err == nil {
+			return modules.NewSiaMuxWithKeyPair(siaMuxDir, dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress, sk)
+		} else if !errors.Contains(err, modules.ErrNoIdentityKey) {
+			return nil, err
+		}
+		mux, err := modules.NewSiaMux(siaMuxDir, dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
+		if err != nil {
+			return nil, err
+		}
+		msk := mux.PrivateKey()
+		copy(sk[:], msk[:])
+		err = id.SetKeyPair(modules.KeyPurposeHost, sk)
+		if err != nil {
+			return nil, errors.Compose(err, mux.Close())
+		}
+		return mux, nil
+	}()
 	if err != nil {
 		errChan <- errors.Extend(err, errors.New("unable to create siamux"))
 		return nil, errChan
@@ -278,9 +361,19 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if gatewayDeps == nil {
 			gatewayDeps = modules.ProdDependencies
 		}
+		dnsSeeds := params.DNSSeeds
+		if dnsSeeds == nil {
+			dnsSeeds = modules.DNSSeeds
+		}
+		if params.DisableDNSSeeds {
+			dnsSeeds = nil
+		}
 		i++
 		printfRelease("(%d/%d) Loading gateway...\n", i, numModules)
-		return gateway.NewCustomGateway(params.RPCAddress, params.Bootstrap, filepath.Join(dir, modules.GatewayDir), gatewayDeps)
+		if params.PrivateNetwork {
+			return gateway.NewPrivateGateway(params.RPCAddress, id, filepath.Join(dir, modules.GatewayDir), gatewayDeps)
+		}
+		return gateway.NewCustomGatewayWithDNSSeeds(params.RPCAddress, params.Bootstrap, dnsSeeds, id, filepath.Join(dir, modules.GatewayDir), gatewayDeps)
 	}()
 	if err != nil {
 		errChan <- errors.Extend(err, errors.New("unable to create gateway"))
@@ -307,6 +400,9 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if consensusSetDeps == nil {
 			consensusSetDeps = modules.ProdDependencies
 		}
+		if params.ConsensusSnapshot != "" {
+			return consensus.NewCustomConsensusSetFromSnapshot(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps, params.ConsensusSnapshot, params.ConsensusCheckpoint)
+		}
 		return consensus.NewCustomConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
 	}()
 	if err := modules.PeekErr(errChanCS); err != nil {
@@ -314,6 +410,29 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return nil, errChan
 	}
 
+	// Light Consensus.
+	ls, err := func() (modules.LightConsensusSet, error) {
+		if params.CreateLightConsensusSet && params.LightConsensusSet != nil {
+			return nil, errors.New("cannot both create light consensus and use passed in light consensus")
+		}
+		if params.LightConsensusSet != nil {
+			return params.LightConsensusSet, nil
+		}
+		if !params.CreateLightConsensusSet {
+			return nil, nil
+		}
+		if cs != nil {
+			return nil, errors.New("cannot use a light consensus set together with a consensus set")
+		}
+		i++
+		printfRelease("(%d/%d) Loading light consensus...\n", i, numModules)
+		return consensus.NewLightConsensusSet(g, filepath.Join(dir, modules.LightConsensusDir))
+	}()
+	if err != nil {
+		errChan <- errors.Extend(err, errors.New("unable to create light consensus set"))
+		return nil, errChan
+	}
+
 	// Explorer.
 	e, err := func() (modules.Explorer, error) {
 		if !params.CreateExplorer && params.Explorer != nil {
@@ -379,7 +498,7 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		}
 		i++
 		printfRelease("(%d/%d) Loading wallet...\n", i, numModules)
-		return wallet.NewCustomWallet(cs, tp, filepath.Join(dir, modules.WalletDir), walletDeps)
+		return wallet.NewCustomWalletWithSeedProvider(cs, tp, filepath.Join(dir, modules.WalletDir), walletDeps, params.WalletSeedProvider)
 	}()
 	if err != nil {
 		errChan <- errors.Extend(err, errors.New("unable to create wallet"))
@@ -434,7 +553,7 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		}
 		i++
 		printfRelease("(%d/%d) Loading host...\n", i, numModules)
-		host, err := host.NewCustomTestHost(hostDeps, smDeps, cs, g, tp, w, mux, params.HostAddress, filepath.Join(dir, modules.HostDir))
+		host, err := host.NewCustomTestHost(hostDeps, smDeps, cs, g, tp, w, mux, id, params.HostAddress, filepath.Join(dir, modules.HostDir))
 		return host, err
 	}()
 	if err != nil {
@@ -556,6 +675,24 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return nil, errChan
 	}
 
+	// Advertise the services of the node to its peers.
+	if g != nil {
+		var services modules.GatewayServices
+		if cs != nil {
+			services |= modules.ServiceFullNode | modules.ServiceArchivalConsensus
+		}
+		if ls != nil {
+			services |= modules.ServiceLightClient
+		}
+		if e != nil {
+			services |= modules.ServiceExplorer
+		}
+		if h != nil {
+			services |= modules.ServiceHost
+		}
+		g.SetServices(services)
+	}
+
 	// Setup complete
 	printfRelease("API is now available, synchronous startup completed in %.3f seconds\n", time.Since(loadStartTime).Seconds())
 	go func() {
@@ -564,7 +701,8 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 	}()
 
 	return &Node{
-		Mux: mux,
+		Mux:      mux,
+		Identity: id,
 
 		Accounting:      acc,
 		ConsensusSet:    cs,
@@ -576,6 +714,8 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		TransactionPool: tp,
 		Wallet:          w,
 
+		LightConsensusSet: ls,
+
 		Dir: dir,
 	}, errChan
 }
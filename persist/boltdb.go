@@ -1,6 +1,7 @@
 package persist
 
 import (
+	"os"
 	"time"
 
 	"gitlab.com/NebulousLabs/bolt"
@@ -29,19 +30,25 @@ func (db *BoltDatabase) checkMetadata(md Metadata) error {
 		}
 
 		// Verify that the metadata matches the expected metadata.
-		header := bucket.Get([]byte("Header"))
-		if string(header) != md.Header {
-			return ErrBadHeader
-		}
-		version := bucket.Get([]byte("Version"))
-		if string(version) != md.Version {
-			return ErrBadVersion
-		}
-		return nil
+		return verifyMetadata(bucket, md)
 	})
 	return err
 }
 
+// verifyMetadata confirms that the metadata bucket contains the expected
+// metadata.
+func verifyMetadata(bucket *bolt.Bucket, md Metadata) error {
+	header := bucket.Get([]byte("Header"))
+	if string(header) != md.Header {
+		return ErrBadHeader
+	}
+	version := bucket.Get([]byte("Version"))
+	if string(version) != md.Version {
+		return ErrBadVersion
+	}
+	return nil
+}
+
 // updateMetadata will set the contents of the metadata bucket to the values
 // in db.Metadata.
 func (db *BoltDatabase) updateMetadata(tx *bolt.Tx) error {
@@ -87,3 +94,35 @@ func OpenDatabase(md Metadata, filename string) (*BoltDatabase, error) {
 
 	return boltDB, nil
 }
+
+// OpenDatabaseReadOnly opens an existing database in read-only mode and
+// validates its metadata. Unlike OpenDatabase, it never modifies the database.
+// Multiple processes may open the same database in read-only mode, but not
+// while another process has it opened for writing.
+func OpenDatabaseReadOnly(md Metadata, filename string) (*BoltDatabase, error) {
+	// bolt creates missing files even in read-only mode.
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filename, defaultFilePermissions, &bolt.Options{Timeout: 3 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the metadata.
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte("Metadata"))
+		if bucket == nil {
+			return ErrBadHeader
+		}
+		return verifyMetadata(bucket, md)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltDatabase{
+		Metadata: md,
+		DB:       db,
+	}, nil
+}
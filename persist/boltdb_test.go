@@ -368,3 +368,60 @@ func TestErrIntegratedCheckMetadata(t *testing.T) {
 		}
 	}
 }
+
+// TestOpenDatabaseReadOnly probes opening databases in read-only mode.
+func TestOpenDatabaseReadOnly(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := build.TempDir(persistDir, t.Name())
+	err := os.MkdirAll(testDir, defaultDirPermissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := testInputs[0]
+	dbFilepath := filepath.Join(testDir, "readonly.db")
+
+	// Opening a database which doesn't exist fails without creating it.
+	_, err = OpenDatabaseReadOnly(in.md, dbFilepath)
+	if !os.IsNotExist(err) {
+		t.Fatal("expected not exist error", err)
+	}
+	if _, err := os.Stat(dbFilepath); !os.IsNotExist(err) {
+		t.Fatal("database was created", err)
+	}
+
+	// Create the database.
+	db, err := OpenDatabase(in.md, dbFilepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening the database with the wrong metadata fails.
+	_, err = OpenDatabaseReadOnly(in.newMd, dbFilepath)
+	if err != in.err {
+		t.Fatalf("expected error %v, got %v", in.err, err)
+	}
+
+	// Multiple read-only databases can be open at the same time.
+	db1, err := OpenDatabaseReadOnly(in.md, dbFilepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db2, err := OpenDatabaseReadOnly(in.md, dbFilepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db1.Update(func(tx *bolt.Tx) error {
+		return nil
+	})
+	if err != bolt.ErrDatabaseReadOnly {
+		t.Fatal("expected read-only error", err)
+	}
+	if err := errors.Compose(db1.Close(), db2.Close()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,134 @@
+package persist
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// walMetadataHeader and walMetadataVersion are the header and version
+	// the writeaheadlog package writes at the start of its files.
+	walMetadataHeader  = []byte("github.com/NebulousLabs/writeaheadlog\n")
+	walMetadataVersion = []byte("v1.0.0   \n")
+)
+
+const (
+	// walRecoveryStateClean is the recovery state the writeaheadlog package
+	// writes to the metadata of a WAL which was closed cleanly.
+	walRecoveryStateClean = 1
+)
+
+var (
+	// ErrBadChecksum is returned by VerifyJSONFile if the checksum of a
+	// persisted json file doesn't match its data.
+	ErrBadChecksum = errors.New("checksum doesn't match the persisted data")
+
+	// ErrNoBackup is returned by RestoreJSONFile if there is no valid backup
+	// of a persisted json file.
+	ErrNoBackup = errors.New("no valid backup available")
+)
+
+// VerifyJSONFile verifies that the checksum of a file saved with SaveJSON
+// matches its data. A missing file is considered valid.
+func VerifyJSONFile(filename string) error {
+	if strings.HasSuffix(filename, tempSuffix) {
+		return ErrBadFilenameSuffix
+	}
+	if !verifyChecksum(filename) {
+		return ErrBadChecksum
+	}
+	return nil
+}
+
+// RestoreJSONFile replaces a file saved with SaveJSON with the backup that
+// SaveJSON keeps next to it if the backup has a valid checksum.
+func RestoreJSONFile(filename string) error {
+	if strings.HasSuffix(filename, tempSuffix) {
+		return ErrBadFilenameSuffix
+	}
+	backup := filename + tempSuffix
+	if _, err := os.Stat(backup); os.IsNotExist(err) {
+		return ErrNoBackup
+	} else if err != nil {
+		return errors.AddContext(err, "unable to stat backup")
+	}
+	if !verifyChecksum(backup) {
+		return errors.AddContext(ErrNoBackup, "backup is corrupted as well")
+	}
+	data, err := ioutil.ReadFile(backup)
+	if err != nil {
+		return errors.AddContext(err, "unable to read backup")
+	}
+
+	// Write the backup to a new file first and rename it to replace the
+	// corrupted file atomically.
+	tmp := filename + RandomSuffix()
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_TRUNC|os.O_CREATE, defaultFilePermissions)
+	if err != nil {
+		return errors.AddContext(err, "unable to create restored file")
+	}
+	_, err = file.Write(data)
+	err = errors.Compose(err, file.Sync(), file.Close())
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to restore backup"), os.RemoveAll(tmp))
+	}
+	return nil
+}
+
+// VerifyBoltFile opens a bolt database in read-only mode and checks the
+// consistency of its pages. The database must not be opened by another
+// process for writing.
+func VerifyBoltFile(filename string) (err error) {
+	// bolt creates missing files even in read-only mode.
+	if _, err := os.Stat(filename); err != nil {
+		return err
+	}
+	db, err := bolt.Open(filename, defaultFilePermissions, &bolt.Options{Timeout: 3 * time.Second, ReadOnly: true})
+	if err != nil {
+		return errors.AddContext(err, "unable to open database")
+	}
+	defer func() {
+		err = errors.Compose(err, db.Close())
+	}()
+	return db.View(func(tx *bolt.Tx) error {
+		var errs []error
+		for err := range tx.Check() {
+			errs = append(errs, err)
+		}
+		return errors.Compose(errs...)
+	})
+}
+
+// VerifyWALFile checks the metadata of a file written by the writeaheadlog
+// package without modifying it. clean is false if the WAL wasn't closed
+// cleanly, in which case its transactions are recovered the next time it is
+// opened.
+func VerifyWALFile(filename string) (clean bool, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		err = errors.Compose(err, file.Close())
+	}()
+	data := make([]byte, len(walMetadataHeader)+len(walMetadataVersion)+2)
+	if _, err := file.ReadAt(data, 0); err != nil {
+		return false, errors.AddContext(err, "unable to read wal metadata")
+	}
+	if !bytes.Equal(data[:len(walMetadataHeader)], walMetadataHeader) {
+		return false, ErrBadHeader
+	}
+	if !bytes.Equal(data[len(walMetadataHeader):len(walMetadataHeader)+len(walMetadataVersion)], walMetadataVersion) {
+		return false, ErrBadVersion
+	}
+	return data[len(data)-2] == walRecoveryStateClean, nil
+}
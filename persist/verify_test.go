@@ -0,0 +1,160 @@
+package persist
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+	"go.sia.tech/siad/build"
+)
+
+// TestVerifyRestoreJSONFile probes VerifyJSONFile and RestoreJSONFile.
+func TestVerifyRestoreJSONFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := filepath.Join(build.TempDir(persistDir), t.Name())
+	if err := os.MkdirAll(dir, defaultDirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	meta := Metadata{"Test Struct", "v1.2.1"}
+	filename := filepath.Join(dir, "obj.json")
+
+	// A missing file is valid but can't be restored.
+	if err := VerifyJSONFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreJSONFile(filename); !errors.Contains(err, ErrNoBackup) {
+		t.Fatal("expected ErrNoBackup, got", err)
+	}
+	if err := VerifyJSONFile(filename + tempSuffix); !errors.Contains(err, ErrBadFilenameSuffix) {
+		t.Fatal("expected ErrBadFilenameSuffix, got", err)
+	}
+
+	// Save the object twice to create a backup.
+	for i := 0; i < 2; i++ {
+		if err := SaveJSON(meta, "dog", filename); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := VerifyJSONFile(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the file.
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-2]++
+	if err := ioutil.WriteFile(filename, data, defaultFilePermissions); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyJSONFile(filename); !errors.Contains(err, ErrBadChecksum) {
+		t.Fatal("expected ErrBadChecksum, got", err)
+	}
+
+	// Restore the backup.
+	if err := RestoreJSONFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyJSONFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	var obj string
+	if err := LoadJSON(meta, &obj, filename); err != nil || obj != "dog" {
+		t.Fatal("wrong object after restore", obj, err)
+	}
+
+	// A corrupted backup isn't restored.
+	if err := ioutil.WriteFile(filename+tempSuffix, data, defaultFilePermissions); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreJSONFile(filename); !errors.Contains(err, ErrNoBackup) {
+		t.Fatal("expected ErrNoBackup, got", err)
+	}
+}
+
+// TestVerifyBoltFile probes VerifyBoltFile.
+func TestVerifyBoltFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := filepath.Join(build.TempDir(persistDir), t.Name())
+	if err := os.MkdirAll(dir, defaultDirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "test.db")
+
+	// A missing database is an error.
+	if err := VerifyBoltFile(filename); !os.IsNotExist(err) {
+		t.Fatal("expected not exist error, got", err)
+	}
+
+	db, err := OpenDatabase(Metadata{"Test Database", "v1.0"}, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBoltFile(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overwrite the meta pages of the database.
+	f, err := os.OpenFile(filename, os.O_RDWR, defaultFilePermissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt(make([]byte, 8192), 0)
+	if err := errors.Compose(err, f.Close()); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBoltFile(filename); err == nil {
+		t.Fatal("expected corrupted database to fail verification")
+	}
+}
+
+// TestVerifyWALFile probes VerifyWALFile.
+func TestVerifyWALFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := filepath.Join(build.TempDir(persistDir), t.Name())
+	if err := os.MkdirAll(dir, defaultDirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "test.wal")
+
+	// An open WAL is unclean.
+	_, wal, err := writeaheadlog.New(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clean, err := VerifyWALFile(filename); err != nil || clean {
+		t.Fatal("expected unclean wal", clean, err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if clean, err := VerifyWALFile(filename); err != nil || !clean {
+		t.Fatal("expected clean wal", clean, err)
+	}
+
+	// Corrupt the header.
+	f, err := os.OpenFile(filename, os.O_RDWR, defaultFilePermissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt([]byte("gitlab"), 0)
+	if err := errors.Compose(err, f.Close()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyWALFile(filename); !errors.Contains(err, ErrBadHeader) {
+		t.Fatal("expected ErrBadHeader, got", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
@@ -59,6 +61,443 @@ func TestApiHeight(t *testing.T) {
 	}
 }
 
+// TestConsensusAncestorGet probes the /consensus/ancestor endpoint.
+func TestConsensusAncestorGet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := consensusTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a few blocks and get the current block.
+	for i := 0; i < 3; i++ {
+		if err := testNode.MineBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := testNode.ConsensusBlocksHeightGet(cg.Height - 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The older block is an ancestor of the current block and their common
+	// ancestor is the older block.
+	cag, err := testNode.ConsensusAncestorGet(parent.ID, cg.CurrentBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cag.IsAncestor || cag.CommonAncestor != parent.ID || cag.CommonAncestorHeight != parent.Height {
+		t.Fatal("wrong response", cag)
+	}
+	// The current block isn't an ancestor of the older one.
+	cag, err = testNode.ConsensusAncestorGet(cg.CurrentBlock, parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cag.IsAncestor || cag.CommonAncestor != parent.ID {
+		t.Fatal("wrong response", cag)
+	}
+	// Unknown blocks return an error.
+	if _, err := testNode.ConsensusAncestorGet(types.BlockID{1}, parent.ID); err == nil {
+		t.Fatal("expected error for unknown block")
+	}
+}
+
+// TestConsensusSubscribersGet probes the /consensus/subscribers endpoint.
+func TestConsensusSubscribersGet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := consensusTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All the modules should be caught up with the consensus set.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		csg, err := testNode.ConsensusSubscribersGet()
+		if err != nil {
+			return err
+		}
+		found := make(map[string]bool)
+		for _, s := range csg.Subscribers {
+			if s.Height != cg.Height || s.BlocksBehind != 0 || s.Updates == 0 {
+				return fmt.Errorf("subscriber isn't caught up: %v", s)
+			}
+			found[s.Module] = true
+		}
+		for _, module := range []string{"wallet", "transactionpool", "host", "renter"} {
+			if !found[module] {
+				return fmt.Errorf("%v is missing from the subscribers %v", module, found)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConsensusStatsGet probes the /consensus/stats endpoint.
+func TestConsensusStatsGet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := consensusTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block containing a transaction which pays a fee.
+	wag, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testNode.WalletSiacoinsPost(types.SiacoinPrecision, wag.Address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Request the statistics of the last two blocks.
+	csg, err := testNode.ConsensusStatsGet(cg.Height, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(csg.Blocks) != 2 {
+		t.Fatalf("expected 2 blocks but got %v", len(csg.Blocks))
+	}
+	if csg.Window.StartHeight != cg.Height-1 || csg.Window.EndHeight != cg.Height {
+		t.Fatal("wrong window", csg.Window.StartHeight, csg.Window.EndHeight)
+	}
+	last := csg.Blocks[1]
+	if last.Transactions == 0 || last.Fees.IsZero() {
+		t.Fatal("expected the last block to contain a transaction with fees", last)
+	}
+	expected := types.CalculateCoinbase(cg.Height - 1).Add(types.CalculateCoinbase(cg.Height))
+	if !csg.Window.Coinbase.Equals(expected) {
+		t.Fatal("wrong coinbase", csg.Window.Coinbase, expected)
+	}
+	if !csg.Window.Fees.Equals(csg.Blocks[0].Fees.Add(last.Fees)) {
+		t.Fatal("window fees don't match block fees")
+	}
+
+	// Requesting a height in the future should fail.
+	_, err = testNode.ConsensusStatsGet(cg.Height+1, 1)
+	if err == nil {
+		t.Fatal("expected request for future height to fail")
+	}
+}
+
+// TestConsensusContractStatsGet probes the /consensus/contracts/stats
+// endpoint.
+func TestConsensusContractStatsGet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// Create a testgroup whose renter forms a contract with the host.
+	groupParams := siatest.GroupParams{
+		Hosts:   1,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(consensusTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	testNode := tg.Miners()[0]
+
+	rcg, err := tg.Renters()[0].RenterAllContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rcg.ActiveContracts) == 0 {
+		t.Fatal("renter has no active contracts")
+	}
+	contract := rcg.ActiveContracts[0]
+
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccsg, err := testNode.ConsensusContractStatsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ccsg.Height != cg.Height {
+		t.Fatal("wrong height", ccsg.Height, cg.Height)
+	}
+	if ccsg.Contracts != uint64(len(rcg.ActiveContracts)) || ccsg.LockedCollateral.IsZero() {
+		t.Fatalf("unexpected statistics: %+v", ccsg)
+	}
+
+	// Every contract expires at the end of its proof window, which follows
+	// the end height of the renter's contract.
+	var expiring uint64
+	var found bool
+	for _, e := range ccsg.Expirations {
+		expiring += e.Contracts
+		found = found || e.Height > contract.EndHeight
+	}
+	if expiring != ccsg.Contracts || !found {
+		t.Fatalf("unexpected expirations: %+v", ccsg.Expirations)
+	}
+}
+
+// TestConsensusDiffsGet probes the /consensus/diffs/:height endpoint.
+func TestConsensusDiffsGet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := consensusTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block containing a transaction which sends siacoins.
+	wag, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsp, err := testNode.WalletSiacoinsPost(types.SiacoinPrecision, wag.Address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The diffs of the block should contain the created output.
+	cdg, err := testNode.ConsensusDiffsGet(cg.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cdg.Height != cg.Height || cdg.BlockID != cg.CurrentBlock {
+		t.Fatal("wrong block", cdg.Height, cg.Height)
+	}
+	txn := wsp.Transactions[len(wsp.Transactions)-1]
+	var found bool
+	for _, d := range cdg.SiacoinOutputDiffs {
+		if d.ID == txn.SiacoinOutputID(0) {
+			found = d.Direction == modules.DiffApply && d.SiacoinOutput.Value.Equals(txn.SiacoinOutputs[0].Value)
+		}
+	}
+	if !found {
+		t.Fatal("created output not found in the block's diffs")
+	}
+	if len(cdg.DelayedSiacoinOutputDiffs) == 0 {
+		t.Fatal("expected a delayed siacoin output for the miner payout")
+	}
+
+	// Requesting a height in the future should fail.
+	_, err = testNode.ConsensusDiffsGet(cg.Height + 1)
+	if err == nil {
+		t.Fatal("expected request for future height to fail")
+	}
+}
+
+// TestConsensusBlocksDetailedGet probes the /consensus/blocks/:height
+// endpoint with verbosity 2.
+func TestConsensusBlocksDetailedGet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := consensusTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block containing a transaction which sends siacoins.
+	wag, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsp, err := testNode.WalletSiacoinsPost(types.SiacoinPrecision, wag.Address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbdg, err := testNode.ConsensusBlocksDetailedGet(cg.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cbg, err := testNode.ConsensusBlocksHeightGet(cg.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cbdg.ID != cg.CurrentBlock || cbdg.Height != cg.Height || cbdg.ParentID != cbg.ParentID || !cbdg.Difficulty.Equals(cbg.Difficulty) {
+		t.Fatal("wrong block", cbdg.ID, cg.CurrentBlock)
+	}
+	if cbdg.MaturityHeight != cg.Height+types.MaturityDelay {
+		t.Fatal("wrong maturity height", cbdg.MaturityHeight)
+	}
+	if len(cbdg.MinerPayouts) != len(cbg.MinerPayouts) || cbdg.MinerPayouts[0].ID == (types.SiacoinOutputID{}) {
+		t.Fatal("miner payout IDs weren't resolved", cbdg.MinerPayouts)
+	}
+
+	// The spent outputs of the transaction are resolved and cover its
+	// outputs and fees.
+	txn := wsp.Transactions[len(wsp.Transactions)-1]
+	var found bool
+	for _, dt := range cbdg.Transactions {
+		if dt.ID != txn.ID() {
+			continue
+		}
+		found = true
+		if dt.Size != uint64(txn.MarshalSiaSize()) || len(dt.SpentSiacoinOutputs) != len(txn.SiacoinInputs) {
+			t.Fatal("wrong transaction details", dt.Size, len(dt.SpentSiacoinOutputs))
+		}
+		var in, out types.Currency
+		for i, sco := range dt.SpentSiacoinOutputs {
+			if sco.ID != txn.SiacoinInputs[i].ParentID {
+				t.Fatal("spent outputs aren't in the order of the inputs")
+			}
+			in = in.Add(sco.Value)
+		}
+		for _, sco := range txn.SiacoinOutputs {
+			out = out.Add(sco.Value)
+		}
+		if !in.Equals(out.Add(dt.Fees)) {
+			t.Fatal("spent outputs don't cover the outputs and fees", in, out, dt.Fees)
+		}
+	}
+	if !found {
+		t.Fatal("transaction not found in the block")
+	}
+
+	// The summary matches the diffs of the block.
+	cdg, err := testNode.ConsensusDiffsGet(cg.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created, spent uint64
+	for _, d := range cdg.SiacoinOutputDiffs {
+		if d.Direction == modules.DiffApply {
+			created++
+		} else {
+			spent++
+		}
+	}
+	if cbdg.DiffSummary.SiacoinOutputsCreated != created || cbdg.DiffSummary.SiacoinOutputsSpent != spent {
+		t.Fatalf("wrong diff summary: %+v", cbdg.DiffSummary)
+	}
+
+	// Requesting a height in the future should fail.
+	_, err = testNode.ConsensusBlocksDetailedGet(cg.Height + 1)
+	if err == nil {
+		t.Fatal("expected request for future height to fail")
+	}
+}
+
+// TestConsensusVerifyPost probes the /consensus/verify endpoint.
+func TestConsensusVerifyPost(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := consensusTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	cvp, err := testNode.ConsensusVerifyPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cvp.Consistent || len(cvp.DivergedBuckets) != 0 || cvp.StateHash != cvp.ReplayedStateHash {
+		t.Fatal("consensus should be consistent", cvp)
+	}
+	if cvp.Height != cg.Height || cvp.BlockID != cg.CurrentBlock {
+		t.Fatal("wrong block", cvp.Height, cg.Height)
+	}
+}
+
 // TestConsensusBlocksIDGet tests the /consensus/blocks endpoint
 func TestConsensusBlocksIDGet(t *testing.T) {
 	if testing.Short() {
@@ -293,6 +732,60 @@ func TestConsensusSubscribe(t *testing.T) {
 	}
 }
 
+// TestConsensusChanges tests the /consensus/changes endpoint
+func TestConsensusChanges(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create a testgroup
+	groupParams := siatest.GroupParams{
+		Miners: 1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(consensusTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	testNode := tg.Miners()[0]
+
+	// replay the history in batches
+	s := &testSubscriber{height: ^types.BlockHeight(0)}
+	ccid := modules.ConsensusChangeBeginning
+	for {
+		batch, err := testNode.ConsensusChangesGet(ccid, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(batch.Changes) > 10 {
+			t.Fatal("batch exceeds limit", len(batch.Changes))
+		}
+		for _, cc := range batch.Changes {
+			s.ProcessConsensusChange(cc)
+		}
+		ccid = batch.Cursor
+		if !batch.More {
+			break
+		}
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.height != cg.Height || s.ccid != ccid {
+		t.Fatal("history wasn't replayed", s.height, cg.Height)
+	}
+
+	// unknown ids are rejected
+	if _, err := testNode.ConsensusChangesGet(modules.ConsensusChangeID{255}, 10); err == nil {
+		t.Fatal("expected unknown ID to be rejected")
+	}
+}
+
 // TestFoundationHardfork tests the foundation hardfork, ensuring that upgraded
 // nodes have the ability to follow the hardfork, and ensuring that the
 // mechanisms for spending the foundation coins are functional.
@@ -1192,3 +1685,102 @@ func TestFoundationHardfork(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// reorgSubscriber records the reorg events it receives.
+type reorgSubscriber struct {
+	mu     sync.Mutex
+	events []modules.ReorgEvent
+}
+
+// ProcessReorg implements modules.ReorgSubscriber.
+func (rs *reorgSubscriber) ProcessReorg(event modules.ReorgEvent) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.events = append(rs.events, event)
+}
+
+// TestConsensusReorgs tests the /consensus/reorgs websocket endpoint.
+func TestConsensusReorgs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := consensusTestDir(t.Name())
+
+	// Create two miners which aren't connected.
+	miner1, err := siatest.NewNode(node.Miner(filepath.Join(testDir, "miner1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := miner1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	miner2, err := siatest.NewNode(node.Miner(filepath.Join(testDir, "miner2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := miner2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Subscribe to the reorgs of miner1.
+	rs := &reorgSubscriber{}
+	cancel := make(chan struct{})
+	errCh, err := miner1.ConsensusReorgsSubscribe(rs, cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// miner1 mines a block and miner2 mines a longer chain. Both miners
+	// already mined their own chains during setup.
+	if err := miner1.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	reverted, err := miner1.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := miner2.MineBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tip, err := miner2.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Connecting the miners causes miner1 to reorg to the chain of miner2.
+	if err := miner1.GatewayConnectPost(miner2.GatewayAddress()); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		if len(rs.events) == 0 {
+			return errors.New("no reorg event received")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.mu.Lock()
+	event := rs.events[0]
+	rs.mu.Unlock()
+	if event.OldTip != reverted.CurrentBlock || event.OldHeight != reverted.Height || event.NewTip != tip.CurrentBlock || event.NewHeight != tip.Height {
+		t.Fatal("unexpected reorg event", event)
+	}
+	if event.Depth == 0 || len(event.RevertedBlocks) != int(event.Depth) || event.RevertedBlocks[0] != event.OldTip {
+		t.Fatal("unexpected reorg event", event)
+	}
+
+	// Canceling the subscription closes the connection without an error.
+	close(cancel)
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
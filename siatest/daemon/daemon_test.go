@@ -114,6 +114,56 @@ func TestDaemonRatelimit(t *testing.T) {
 	}
 }
 
+// TestDaemonAudit makes sure that state-changing API calls are added to the
+// audit log and that the audit log survives restarts.
+func TestDaemonAudit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewCleanNode(node.Gateway(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// Read-only calls are not audited.
+	if _, err := testNode.DaemonSettingsGet(); err != nil {
+		t.Fatal(err)
+	}
+	dag, err := testNode.DaemonAuditGet(time.Time{}, "/daemon/settings", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dag.Entries) != 0 {
+		t.Fatal("expected no entries but got", len(dag.Entries))
+	}
+	// Change the ratelimits.
+	if err := testNode.DaemonGlobalRateLimitPost(100, 200); err != nil {
+		t.Fatal(err)
+	}
+	// Restart the node and check the audit log.
+	if err := testNode.RestartNode(); err != nil {
+		t.Fatal(err)
+	}
+	dag, err = testNode.DaemonAuditGet(time.Time{}, "/daemon/settings", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dag.Entries) != 1 {
+		t.Fatal("expected 1 entry but got", len(dag.Entries))
+	}
+	entry := dag.Entries[0]
+	if entry.Method != "POST" || entry.StatusCode != 204 || entry.Params["maxdownloadspeed"] != "100" {
+		t.Fatal("wrong entry", entry)
+	}
+}
+
 // TestGlobalRatelimitRenter makes sure that if multiple ratelimits are set, the
 // lower one is respected.
 func TestGlobalRatelimitRenter(t *testing.T) {
@@ -222,8 +272,8 @@ func TestAlertFields(t *testing.T) {
 	}
 
 	// Check alerts field
-	if len(dag.Alerts) != 12 {
-		t.Fatal("number of alerts is not 12")
+	if len(dag.Alerts) != 15 {
+		t.Fatal("number of alerts is not 15")
 	}
 
 	// Check criticalalerts field severity and total count
@@ -232,8 +282,8 @@ func TestAlertFields(t *testing.T) {
 			t.Fatal("criticalalerts field contains alert which has not critical severity")
 		}
 	}
-	if len(dag.CriticalAlerts) != 4 {
-		t.Fatal("number of critical alerts is not 4")
+	if len(dag.CriticalAlerts) != 5 {
+		t.Fatal("number of critical alerts is not 5")
 	}
 
 	// Check erroralerts field severity and total count
@@ -242,8 +292,8 @@ func TestAlertFields(t *testing.T) {
 			t.Fatal("erroralerts field contains alert which has not error severity")
 		}
 	}
-	if len(dag.ErrorAlerts) != 4 {
-		t.Fatal("number of error alerts is not 4")
+	if len(dag.ErrorAlerts) != 5 {
+		t.Fatal("number of error alerts is not 5")
 	}
 
 	// Check warningalerts field severity and total count
@@ -252,8 +302,8 @@ func TestAlertFields(t *testing.T) {
 			t.Fatal("warningalerts field contains alert which has not warning severity")
 		}
 	}
-	if len(dag.WarningAlerts) != 4 {
-		t.Fatal("number of warning alerts is not 4")
+	if len(dag.WarningAlerts) != 5 {
+		t.Fatal("number of warning alerts is not 5")
 	}
 }
 
@@ -437,3 +487,51 @@ func TestDaemonProfile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestDaemonCounters checks that the operation counters of a node are exposed
+// and persisted across restarts.
+func TestDaemonCounters(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new miner.
+	testNode, err := siatest.NewCleanNode(node.Miner(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block which should be counted by the consensus set.
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	dcg, err := testNode.DaemonCountersGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dcg.Host != nil || dcg.Renter != nil {
+		t.Fatal("expected no counters of modules which aren't loaded", dcg)
+	}
+	accepted := dcg.Consensus["acceptblocks"]
+	if accepted.Count == 0 || accepted.TotalLatency == 0 {
+		t.Fatal("block acceptance wasn't counted", accepted)
+	}
+
+	// Restart the node. The counters should be persisted.
+	if err := testNode.RestartNode(); err != nil {
+		t.Fatal(err)
+	}
+	dcg, err = testNode.DaemonCountersGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted := dcg.Consensus["acceptblocks"]; restarted.Count < accepted.Count {
+		t.Fatalf("counters weren't persisted: %v < %v", restarted.Count, accepted.Count)
+	}
+}
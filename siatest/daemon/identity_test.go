@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/siatest"
+)
+
+// TestIdentity tests that the host key of a node is managed by its identity
+// and that the identity's keys can be rotated, exported and imported.
+func TestIdentity(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a host and a gateway.
+	host, err := siatest.NewCleanNode(node.Host(filepath.Join(testDir, "host")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := host.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	gateway, err := siatest.NewCleanNode(node.Gateway(filepath.Join(testDir, "gateway")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := gateway.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The identity of the host has a key for every purpose and the host key
+	// is the key of the host.
+	ig, err := host.IdentityGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ig.Keys) != len(modules.KeyPurposes) {
+		t.Fatal("unexpected keys", ig.Keys)
+	}
+	hg, err := host.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey := ig.Keys[1].Current.PublicKey
+	if ig.Keys[1].Purpose != modules.KeyPurposeHost || !hostKey.Equals(hg.PublicKey) {
+		t.Fatal("identity's host key doesn't match the host's key", ig.Keys[1], hg.PublicKey)
+	}
+
+	// The gateway key can be rotated, the host key can't.
+	irp, err := host.IdentityRotatePost(modules.KeyPurposeGateway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := host.IdentityRotatePost(modules.KeyPurposeHost); err == nil {
+		t.Fatal("host key shouldn't be rotatable")
+	}
+
+	// The keys survive a restart.
+	if err := host.RestartNode(); err != nil {
+		t.Fatal(err)
+	}
+	ig, err = host.IdentityGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ig.Keys[0].Current.PublicKey.Equals(irp.PublicKey) || len(ig.Keys[0].Retired) != 1 {
+		t.Fatal("rotated gateway key wasn't persisted", ig.Keys[0])
+	}
+	hg, err = host.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hg.PublicKey.Equals(hostKey) {
+		t.Fatal("host key changed after restart")
+	}
+
+	// Move the host's keys to the gateway.
+	iep, err := host.IdentityExportPost("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gateway.IdentityImportPost(iep.Keystore, "wrong", true); err == nil {
+		t.Fatal("import with wrong password should fail")
+	}
+	if err := gateway.IdentityImportPost(iep.Keystore, "password", false); err == nil {
+		t.Fatal("import shouldn't replace the host key without confirmation")
+	}
+	if err := gateway.IdentityImportPost(iep.Keystore, "password", true); err != nil {
+		t.Fatal(err)
+	}
+	ig, err = gateway.IdentityGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ig.Keys[1].Current.PublicKey.Equals(hostKey) || !ig.Keys[0].Current.PublicKey.Equals(irp.PublicKey) {
+		t.Fatal("keys weren't imported", ig.Keys)
+	}
+}
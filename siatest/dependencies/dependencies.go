@@ -347,6 +347,12 @@ func NewDependencyHostLosePriceTable() *DependencyWithDisableAndEnable {
 	return newDependencywithDisableAndEnable("HostLosePriceTable")
 }
 
+// NewDependencyDisableRPCNegotiate creates a dependency, that causes the host
+// to act as if it doesn't support RPCNegotiate.
+func NewDependencyDisableRPCNegotiate() *DependencyWithDisableAndEnable {
+	return newDependencywithDisableAndEnable("DisableRPCNegotiate")
+}
+
 // NewDependencyRegistryUpdateNoOp creates a dependency, that causes
 // RegistryUpdate to be a no-op.
 func NewDependencyRegistryUpdateNoOp() *DependencyWithDisableAndEnable {
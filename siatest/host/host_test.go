@@ -404,6 +404,94 @@ func TestHostContracts(t *testing.T) {
 	}
 }
 
+// TestHostPayoutAddresses confirms that renters send the host's payouts to the
+// configured payout addresses and that the host records them.
+func TestHostPayoutAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	gp := siatest.GroupParams{
+		Hosts:   1,
+		Renters: 0,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(hostTestDir(t.Name()), gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Rotate the payouts between two fresh addresses.
+	hostNode := tg.Hosts()[0]
+	var addrs []types.UnlockHash
+	for i := 0; i < 2; i++ {
+		wag, err := hostNode.WalletAddressGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, wag.Address)
+	}
+	settings := modules.HostPayoutSettings{
+		Policy:    modules.HostPayoutPolicyRotate,
+		Addresses: []modules.HostPayoutAddress{{Address: addrs[0]}, {Address: addrs[1]}},
+	}
+	if err := hostNode.HostSettingsPayoutsPost(settings); err != nil {
+		t.Fatal(err)
+	}
+	hspg, err := hostNode.HostSettingsPayoutsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hspg.Settings, settings) {
+		t.Fatal("unexpected payout settings", hspg.Settings)
+	}
+	hg, err := hostNode.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hg.ExternalSettings.UnlockHash != addrs[0] {
+		t.Fatal("host doesn't advertise the first payout address", hg.ExternalSettings.UnlockHash)
+	}
+
+	// The renter's contract pays the first address and the host advertises
+	// the second one afterwards.
+	if _, err := tg.AddNodes(node.RenterTemplate); err != nil {
+		t.Fatal(err)
+	}
+	hc, err := hostNode.HostContractInfoGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hc.Contracts) != 1 || hc.Contracts[0].ValidProofOutputs[1].UnlockHash != addrs[0] {
+		t.Fatal("contract doesn't pay the first payout address", hc.Contracts)
+	}
+	hphg, err := hostNode.HostPayoutsHistoryGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hphg.NextAddress != addrs[1] {
+		t.Fatal("rotation didn't advance", hphg.NextAddress)
+	}
+	if len(hphg.Payouts) != 1 || hphg.Payouts[0].ContractID != hc.Contracts[0].ObligationId || hphg.Payouts[0].Address != addrs[0] {
+		t.Fatal("unexpected payout history", hphg.Payouts)
+	}
+	if len(hphg.Totals) != 1 || hphg.Totals[0].Address != addrs[0] || hphg.Totals[0].Contracts != 1 {
+		t.Fatal("unexpected payout totals", hphg.Totals)
+	}
+
+	// Invalid settings are rejected.
+	settings.Policy = modules.HostPayoutPolicySplit
+	if err := hostNode.HostSettingsPayoutsPost(settings); err == nil {
+		t.Fatal("split without percentages was accepted")
+	}
+}
+
 // TestHostContract confirms that the host contract endpoint returns the expected values
 func TestHostContract(t *testing.T) {
 	if testing.Short() {
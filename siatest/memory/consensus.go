@@ -0,0 +1,942 @@
+// Package memory contains in-memory implementations of the consensus set, the
+// transaction pool and the gateway. They conform to the module interfaces but
+// don't persist anything, don't mine and don't talk to the network, which
+// makes them a cheap replacement for the full modules in tests of the modules
+// built on top of them, e.g. the wallet, the miner, the host and the renter.
+// NodeParams swaps them into the params of a siatest node.
+//
+// The modules don't enforce all the rules of the full modules. Tests which
+// depend on any of the following rules need to use the full modules.
+//
+// ConsensusSet:
+//   - Blocks are not checked for proof of work, the child target, their
+//     timestamp, their size or the placement of their fork signals.
+//     ChildTarget always returns the root target and
+//     MinimumValidChildTimestamp the timestamp of the block itself.
+//   - Only blocks which extend the current block are accepted. There are no
+//     forks and therefore no reorgs, and reorg subscribers are never notified.
+//   - The Foundation subsidy isn't paid and Foundation unlock hash updates in
+//     the arbitrary data of transactions are neither validated nor applied.
+//   - Transactions are always validated with the storage proof rules after the
+//     storage proof hardfork.
+//   - Subscribers are updated synchronously while the block is accepted.
+//   - State commitments aren't maintained, StateCommitment returns the zero
+//     hash, and BlockStats, FileContractStats, ForkRule(s), SnapshotDB and
+//     VerifyConsensus are not supported.
+//   - The consensus set reports to be synced unless SetSynced is used.
+//
+// TransactionPool:
+//   - The minimum accept fee is applied to every transaction set which isn't
+//     exempt, regardless of the size of the pool. The fee floor which grows
+//     with the size of the full transaction pool isn't enforced and the pool
+//     has no size limit.
+//   - Transaction sets which conflict with the pool are rejected instead of
+//     being merged with the conflicting sets.
+//   - The minimum relay fee is stored but not enforced, every accepted set is
+//     broadcast.
+//   - Packages are accepted like transaction sets, their topology isn't
+//     checked.
+//   - FeeEstimation never reports congestion.
+//
+// Gateway:
+//   - Gateways can only connect to other in-memory gateways of the same
+//     process and don't bootstrap, discover nodes or dial real addresses.
+//   - RPCs run over synchronous in-memory pipes without encryption,
+//     deadlines, rate limits or bandwidth metering. RequireEncryption and the
+//     rate limits are stored but not enforced.
+//   - Peers are neither scored nor banned, so PeerBans, PeerScores and
+//     PeerDisconnects are always empty.
+package memory
+
+import (
+	"math/big"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	siasync "go.sia.tech/siad/sync"
+	"go.sia.tech/siad/types"
+)
+
+// maxConsensusChangeBatch is the maximum number of consensus changes returned
+// by ConsensusChangesSince.
+const maxConsensusChangeBatch = 100
+
+var (
+	errAlteredRevisionPayouts     = errors.New("file contract revision has altered payout volume")
+	errInvalidStorageProof        = errors.New("provided storage proof is invalid")
+	errLateRevision               = errors.New("file contract revision submitted after deadline")
+	errLowRevisionNumber          = errors.New("transaction has a file contract with an outdated revision number")
+	errMissingFileContract        = errors.New("transaction references a nonexisting file contract")
+	errMissingSiacoinOutput       = errors.New("transaction spends a nonexisting siacoin output")
+	errMissingSiafundOutput       = errors.New("transaction spends a nonexisting siafund output")
+	errNotSupported               = errors.New("not supported by the in-memory consensus set")
+	errOrphanBlock                = errors.New("block doesn't extend the current block of the in-memory consensus set")
+	errSiacoinInputOutputMismatch = errors.New("siacoin inputs do not equal siacoin outputs for transaction")
+	errSiafundInputOutputMismatch = errors.New("siafund inputs do not equal siafund outputs for transaction")
+	errUnfinishedFileContract     = errors.New("file contract window has not yet openend")
+	errWrongMinerPayouts          = errors.New("miner payouts don't add up to the block subsidy")
+	errWrongUnlockConditions      = errors.New("transaction contains incorrect unlock conditions")
+)
+
+type (
+	// ConsensusSet is an in-memory consensus set. It applies the transactions
+	// of blocks with the same rules as the full consensus set, but skips the
+	// proof of work, the difficulty and the timestamp rules and doesn't pay
+	// the Foundation subsidy. Only blocks which extend the current block are
+	// accepted, so there are no reorgs.
+	ConsensusSet struct {
+		blocks       []types.Block
+		blockHeights map[types.BlockID]types.BlockHeight
+		blockDiffs   []modules.ConsensusChangeDiffs
+		changes      []modules.ConsensusChange
+		synced       bool
+
+		siacoinOutputs map[types.SiacoinOutputID]types.SiacoinOutput
+		fileContracts  map[types.FileContractID]types.FileContract
+		siafundOutputs map[types.SiafundOutputID]types.SiafundOutput
+		delayedOutputs map[types.BlockHeight]map[types.SiacoinOutputID]types.SiacoinOutput
+		siafundPool    types.Currency
+
+		subscribers      []modules.ConsensusSetSubscriber
+		reorgSubscribers []modules.ReorgSubscriber
+		mu               sync.RWMutex
+
+		// subscriberMu serializes the updates of the subscribers. It is
+		// acquired before mu.
+		subscriberMu sync.Mutex
+	}
+)
+
+// NewConsensusSet returns an in-memory consensus set which contains the
+// genesis block.
+func NewConsensusSet() *ConsensusSet {
+	cs := &ConsensusSet{
+		blockHeights:   make(map[types.BlockID]types.BlockHeight),
+		synced:         true,
+		siacoinOutputs: make(map[types.SiacoinOutputID]types.SiacoinOutput),
+		fileContracts:  make(map[types.FileContractID]types.FileContract),
+		siafundOutputs: make(map[types.SiafundOutputID]types.SiafundOutput),
+		delayedOutputs: make(map[types.BlockHeight]map[types.SiacoinOutputID]types.SiacoinOutput),
+	}
+
+	// The transactions of the genesis block create the initial siafunds out
+	// of thin air, so they are applied without being validated.
+	var diffs modules.ConsensusChangeDiffs
+	for _, txn := range types.GenesisBlock.Transactions {
+		cs.applyTransaction(&diffs, txn, 0)
+	}
+	cs.appendBlock(types.GenesisBlock, diffs)
+	return cs
+}
+
+// height returns the height of the current block.
+func (cs *ConsensusSet) height() types.BlockHeight {
+	return types.BlockHeight(len(cs.blocks) - 1)
+}
+
+// currentBlock returns the current block.
+func (cs *ConsensusSet) currentBlock() types.Block {
+	return cs.blocks[len(cs.blocks)-1]
+}
+
+// appendBlock adds the block and its diffs to the current path and records the
+// consensus change.
+func (cs *ConsensusSet) appendBlock(b types.Block, diffs modules.ConsensusChangeDiffs) modules.ConsensusChange {
+	id := b.ID()
+	cs.blockHeights[id] = types.BlockHeight(len(cs.blocks))
+	cs.blocks = append(cs.blocks, b)
+	cs.blockDiffs = append(cs.blockDiffs, diffs)
+
+	cc := modules.ConsensusChange{
+		ID: modules.ConsensusChangeID(crypto.HashObject(struct {
+			RevertedBlocks []types.BlockID
+			AppliedBlocks  []types.BlockID
+		}{nil, []types.BlockID{id}})),
+		AppliedBlocks:              []types.Block{b},
+		AppliedDiffs:               []modules.ConsensusChangeDiffs{diffs},
+		ConsensusChangeDiffs:       diffs,
+		AppliedStateCommitments:    []crypto.Hash{{}},
+		ChildTarget:                types.RootTarget,
+		MinimumValidChildTimestamp: b.Timestamp,
+		Synced:                     cs.synced,
+		TryTransactionSet:          cs.TryTransactionSet,
+	}
+	cs.changes = append(cs.changes, cc)
+	return cc
+}
+
+// changesSince returns the consensus changes after the change with the
+// provided id.
+func (cs *ConsensusSet) changesSince(start modules.ConsensusChangeID) ([]modules.ConsensusChange, error) {
+	switch start {
+	case modules.ConsensusChangeBeginning:
+		return cs.changes, nil
+	case modules.ConsensusChangeRecent:
+		return nil, nil
+	}
+	for i, cc := range cs.changes {
+		if cc.ID == start {
+			return cs.changes[i+1:], nil
+		}
+	}
+	return nil, modules.ErrInvalidConsensusChangeID
+}
+
+// commitDiffs applies the diffs to the state in the provided direction. Diffs
+// are reverted in the opposite order in which they were applied.
+func (cs *ConsensusSet) commitDiffs(diffs modules.ConsensusChangeDiffs, dir modules.DiffDirection) {
+	if dir == modules.DiffApply {
+		for _, scod := range diffs.SiacoinOutputDiffs {
+			cs.commitSiacoinOutputDiff(scod, dir)
+		}
+		for _, fcd := range diffs.FileContractDiffs {
+			cs.commitFileContractDiff(fcd, dir)
+		}
+		for _, sfod := range diffs.SiafundOutputDiffs {
+			cs.commitSiafundOutputDiff(sfod, dir)
+		}
+		for _, dscod := range diffs.DelayedSiacoinOutputDiffs {
+			cs.commitDelayedSiacoinOutputDiff(dscod, dir)
+		}
+		for _, sfpd := range diffs.SiafundPoolDiffs {
+			cs.commitSiafundPoolDiff(sfpd, dir)
+		}
+		return
+	}
+	for i := len(diffs.SiafundPoolDiffs) - 1; i >= 0; i-- {
+		cs.commitSiafundPoolDiff(diffs.SiafundPoolDiffs[i], dir)
+	}
+	for i := len(diffs.DelayedSiacoinOutputDiffs) - 1; i >= 0; i-- {
+		cs.commitDelayedSiacoinOutputDiff(diffs.DelayedSiacoinOutputDiffs[i], dir)
+	}
+	for i := len(diffs.SiafundOutputDiffs) - 1; i >= 0; i-- {
+		cs.commitSiafundOutputDiff(diffs.SiafundOutputDiffs[i], dir)
+	}
+	for i := len(diffs.FileContractDiffs) - 1; i >= 0; i-- {
+		cs.commitFileContractDiff(diffs.FileContractDiffs[i], dir)
+	}
+	for i := len(diffs.SiacoinOutputDiffs) - 1; i >= 0; i-- {
+		cs.commitSiacoinOutputDiff(diffs.SiacoinOutputDiffs[i], dir)
+	}
+}
+
+// commitSiacoinOutputDiff applies or reverts a siacoin output diff.
+func (cs *ConsensusSet) commitSiacoinOutputDiff(scod modules.SiacoinOutputDiff, dir modules.DiffDirection) {
+	if scod.Direction == dir {
+		cs.siacoinOutputs[scod.ID] = scod.SiacoinOutput
+	} else {
+		delete(cs.siacoinOutputs, scod.ID)
+	}
+}
+
+// commitFileContractDiff applies or reverts a file contract diff.
+func (cs *ConsensusSet) commitFileContractDiff(fcd modules.FileContractDiff, dir modules.DiffDirection) {
+	if fcd.Direction == dir {
+		cs.fileContracts[fcd.ID] = fcd.FileContract
+	} else {
+		delete(cs.fileContracts, fcd.ID)
+	}
+}
+
+// commitSiafundOutputDiff applies or reverts a siafund output diff.
+func (cs *ConsensusSet) commitSiafundOutputDiff(sfod modules.SiafundOutputDiff, dir modules.DiffDirection) {
+	if sfod.Direction == dir {
+		cs.siafundOutputs[sfod.ID] = sfod.SiafundOutput
+	} else {
+		delete(cs.siafundOutputs, sfod.ID)
+	}
+}
+
+// commitDelayedSiacoinOutputDiff applies or reverts a delayed siacoin output
+// diff.
+func (cs *ConsensusSet) commitDelayedSiacoinOutputDiff(dscod modules.DelayedSiacoinOutputDiff, dir modules.DiffDirection) {
+	outputs, exists := cs.delayedOutputs[dscod.MaturityHeight]
+	if !exists {
+		outputs = make(map[types.SiacoinOutputID]types.SiacoinOutput)
+		cs.delayedOutputs[dscod.MaturityHeight] = outputs
+	}
+	if dscod.Direction == dir {
+		outputs[dscod.ID] = dscod.SiacoinOutput
+	} else {
+		delete(outputs, dscod.ID)
+	}
+	if len(outputs) == 0 {
+		delete(cs.delayedOutputs, dscod.MaturityHeight)
+	}
+}
+
+// commitSiafundPoolDiff applies or reverts a siafund pool diff.
+func (cs *ConsensusSet) commitSiafundPoolDiff(sfpd modules.SiafundPoolDiff, dir modules.DiffDirection) {
+	if sfpd.Direction == dir {
+		cs.siafundPool = sfpd.Adjusted
+	} else {
+		cs.siafundPool = sfpd.Previous
+	}
+}
+
+// apply commits the diffs and appends them to the provided diffs.
+func (cs *ConsensusSet) apply(diffs *modules.ConsensusChangeDiffs, d modules.ConsensusChangeDiffs) {
+	cs.commitDiffs(d, modules.DiffApply)
+	diffs.SiacoinOutputDiffs = append(diffs.SiacoinOutputDiffs, d.SiacoinOutputDiffs...)
+	diffs.FileContractDiffs = append(diffs.FileContractDiffs, d.FileContractDiffs...)
+	diffs.SiafundOutputDiffs = append(diffs.SiafundOutputDiffs, d.SiafundOutputDiffs...)
+	diffs.DelayedSiacoinOutputDiffs = append(diffs.DelayedSiacoinOutputDiffs, d.DelayedSiacoinOutputDiffs...)
+	diffs.SiafundPoolDiffs = append(diffs.SiafundPoolDiffs, d.SiafundPoolDiffs...)
+}
+
+// storageProofSegment returns the index of the segment that needs to be
+// proven for the file contract.
+func (cs *ConsensusSet) storageProofSegment(fcid types.FileContractID) (uint64, error) {
+	fc, exists := cs.fileContracts[fcid]
+	if !exists {
+		return 0, errMissingFileContract
+	}
+	triggerHeight := fc.WindowStart - 1
+	if triggerHeight > cs.height() {
+		return 0, errUnfinishedFileContract
+	}
+	seed := crypto.HashAll(cs.blocks[triggerHeight].ID(), fcid)
+	numSegments := int64(crypto.CalculateLeaves(fc.FileSize))
+	seedInt := new(big.Int).SetBytes(seed[:])
+	return seedInt.Mod(seedInt, big.NewInt(numSegments)).Uint64(), nil
+}
+
+// validTransaction checks that the transaction is valid in the context of the
+// current state. Like the full consensus set, the transactions of a block are
+// validated at the height of its parent.
+func (cs *ConsensusSet) validTransaction(t types.Transaction) error {
+	height := cs.height()
+	if err := t.StandaloneValid(height); err != nil {
+		return err
+	}
+
+	// Check the siacoins.
+	var inputSum types.Currency
+	for _, sci := range t.SiacoinInputs {
+		sco, exists := cs.siacoinOutputs[sci.ParentID]
+		if !exists {
+			return errMissingSiacoinOutput
+		}
+		if sci.UnlockConditions.UnlockHash() != sco.UnlockHash {
+			return errWrongUnlockConditions
+		}
+		inputSum = inputSum.Add(sco.Value)
+	}
+	if !inputSum.Equals(t.SiacoinOutputSum()) {
+		return errSiacoinInputOutputMismatch
+	}
+
+	// Check the storage proofs.
+	for _, sp := range t.StorageProofs {
+		segmentIndex, err := cs.storageProofSegment(sp.ParentID)
+		if err != nil {
+			return err
+		}
+		fc := cs.fileContracts[sp.ParentID]
+		leaves := crypto.CalculateLeaves(fc.FileSize)
+		segmentLen := uint64(crypto.SegmentSize)
+		if segmentIndex == leaves-1 && fc.FileSize%crypto.SegmentSize != 0 {
+			segmentLen = fc.FileSize % crypto.SegmentSize
+		}
+		verified := crypto.VerifySegment(sp.Segment[:segmentLen], sp.HashSet, leaves, segmentIndex, fc.FileMerkleRoot)
+		if !verified && fc.FileSize > 0 {
+			return errInvalidStorageProof
+		}
+	}
+
+	// Check the file contract revisions.
+	for _, fcr := range t.FileContractRevisions {
+		fc, exists := cs.fileContracts[fcr.ParentID]
+		if !exists {
+			return errMissingFileContract
+		}
+		if height > fc.WindowStart {
+			return errLateRevision
+		}
+		if fc.RevisionNumber >= fcr.NewRevisionNumber {
+			return errLowRevisionNumber
+		}
+		if fcr.UnlockConditions.UnlockHash() != fc.UnlockHash {
+			return errWrongUnlockConditions
+		}
+		validPayout, missedPayout := fcr.TotalPayout()
+		var oldPayout types.Currency
+		for _, output := range fc.ValidProofOutputs {
+			oldPayout = oldPayout.Add(output.Value)
+		}
+		if !validPayout.Equals(oldPayout) || !missedPayout.Equals(oldPayout) {
+			return errAlteredRevisionPayouts
+		}
+	}
+
+	// Check the siafunds.
+	var siafundInputSum, siafundOutputSum types.Currency
+	for _, sfi := range t.SiafundInputs {
+		sfo, exists := cs.siafundOutputs[sfi.ParentID]
+		if !exists {
+			return errMissingSiafundOutput
+		}
+		if sfi.UnlockConditions.UnlockHash() != sfo.UnlockHash {
+			return errWrongUnlockConditions
+		}
+		siafundInputSum = siafundInputSum.Add(sfo.Value)
+	}
+	for _, sfo := range t.SiafundOutputs {
+		siafundOutputSum = siafundOutputSum.Add(sfo.Value)
+	}
+	if !siafundInputSum.Equals(siafundOutputSum) {
+		return errSiafundInputOutputMismatch
+	}
+	return nil
+}
+
+// applyTransaction applies a valid transaction to the state and appends the
+// resulting diffs to the provided diffs.
+func (cs *ConsensusSet) applyTransaction(diffs *modules.ConsensusChangeDiffs, t types.Transaction, height types.BlockHeight) {
+	for _, sci := range t.SiacoinInputs {
+		cs.apply(diffs, modules.ConsensusChangeDiffs{SiacoinOutputDiffs: []modules.SiacoinOutputDiff{{
+			Direction:     modules.DiffRevert,
+			ID:            sci.ParentID,
+			SiacoinOutput: cs.siacoinOutputs[sci.ParentID],
+		}}})
+	}
+	for i, sco := range t.SiacoinOutputs {
+		cs.apply(diffs, modules.ConsensusChangeDiffs{SiacoinOutputDiffs: []modules.SiacoinOutputDiff{{
+			Direction:     modules.DiffApply,
+			ID:            t.SiacoinOutputID(uint64(i)),
+			SiacoinOutput: sco,
+		}}})
+	}
+	for i, fc := range t.FileContracts {
+		cs.apply(diffs, modules.ConsensusChangeDiffs{
+			FileContractDiffs: []modules.FileContractDiff{{
+				Direction:    modules.DiffApply,
+				ID:           t.FileContractID(uint64(i)),
+				FileContract: fc,
+			}},
+			SiafundPoolDiffs: []modules.SiafundPoolDiff{{
+				Direction: modules.DiffApply,
+				Previous:  cs.siafundPool,
+				Adjusted:  cs.siafundPool.Add(types.Tax(height, fc.Payout)),
+			}},
+		})
+	}
+	for _, fcr := range t.FileContractRevisions {
+		fc := cs.fileContracts[fcr.ParentID]
+		cs.apply(diffs, modules.ConsensusChangeDiffs{FileContractDiffs: []modules.FileContractDiff{{
+			Direction:    modules.DiffRevert,
+			ID:           fcr.ParentID,
+			FileContract: fc,
+		}, {
+			Direction: modules.DiffApply,
+			ID:        fcr.ParentID,
+			FileContract: types.FileContract{
+				FileSize:           fcr.NewFileSize,
+				FileMerkleRoot:     fcr.NewFileMerkleRoot,
+				WindowStart:        fcr.NewWindowStart,
+				WindowEnd:          fcr.NewWindowEnd,
+				Payout:             fc.Payout,
+				ValidProofOutputs:  fcr.NewValidProofOutputs,
+				MissedProofOutputs: fcr.NewMissedProofOutputs,
+				UnlockHash:         fcr.NewUnlockHash,
+				RevisionNumber:     fcr.NewRevisionNumber,
+			},
+		}}})
+	}
+	for _, sp := range t.StorageProofs {
+		fc := cs.fileContracts[sp.ParentID]
+		var d modules.ConsensusChangeDiffs
+		for i, vpo := range fc.ValidProofOutputs {
+			d.DelayedSiacoinOutputDiffs = append(d.DelayedSiacoinOutputDiffs, modules.DelayedSiacoinOutputDiff{
+				Direction:      modules.DiffApply,
+				ID:             sp.ParentID.StorageProofOutputID(types.ProofValid, uint64(i)),
+				SiacoinOutput:  vpo,
+				MaturityHeight: height + types.MaturityDelay,
+			})
+		}
+		d.FileContractDiffs = []modules.FileContractDiff{{
+			Direction:    modules.DiffRevert,
+			ID:           sp.ParentID,
+			FileContract: fc,
+		}}
+		cs.apply(diffs, d)
+	}
+	for _, sfi := range t.SiafundInputs {
+		sfo := cs.siafundOutputs[sfi.ParentID]
+		cs.apply(diffs, modules.ConsensusChangeDiffs{
+			DelayedSiacoinOutputDiffs: []modules.DelayedSiacoinOutputDiff{{
+				Direction: modules.DiffApply,
+				ID:        sfi.ParentID.SiaClaimOutputID(),
+				SiacoinOutput: types.SiacoinOutput{
+					Value:      cs.siafundPool.Sub(sfo.ClaimStart).Div(types.SiafundCount).Mul(sfo.Value),
+					UnlockHash: sfi.ClaimUnlockHash,
+				},
+				MaturityHeight: height + types.MaturityDelay,
+			}},
+			SiafundOutputDiffs: []modules.SiafundOutputDiff{{
+				Direction:     modules.DiffRevert,
+				ID:            sfi.ParentID,
+				SiafundOutput: sfo,
+			}},
+		})
+	}
+	for i, sfo := range t.SiafundOutputs {
+		sfo.ClaimStart = cs.siafundPool
+		cs.apply(diffs, modules.ConsensusChangeDiffs{SiafundOutputDiffs: []modules.SiafundOutputDiff{{
+			Direction:     modules.DiffApply,
+			ID:            t.SiafundOutputID(uint64(i)),
+			SiafundOutput: sfo,
+		}}})
+	}
+}
+
+// applyTransactions validates and applies the transactions one after another.
+// If a transaction is invalid, the state is restored and an error is
+// returned.
+func (cs *ConsensusSet) applyTransactions(diffs *modules.ConsensusChangeDiffs, txns []types.Transaction, height types.BlockHeight) error {
+	var applied modules.ConsensusChangeDiffs
+	for _, txn := range txns {
+		if err := cs.validTransaction(txn); err != nil {
+			cs.commitDiffs(applied, modules.DiffRevert)
+			return err
+		}
+		cs.applyTransaction(&applied, txn, height)
+	}
+	diffs.SiacoinOutputDiffs = append(diffs.SiacoinOutputDiffs, applied.SiacoinOutputDiffs...)
+	diffs.FileContractDiffs = append(diffs.FileContractDiffs, applied.FileContractDiffs...)
+	diffs.SiafundOutputDiffs = append(diffs.SiafundOutputDiffs, applied.SiafundOutputDiffs...)
+	diffs.DelayedSiacoinOutputDiffs = append(diffs.DelayedSiacoinOutputDiffs, applied.DelayedSiacoinOutputDiffs...)
+	diffs.SiafundPoolDiffs = append(diffs.SiafundPoolDiffs, applied.SiafundPoolDiffs...)
+	return nil
+}
+
+// applyMaintenance adds the miner payouts, matures the delayed outputs and
+// expires the file contracts without a storage proof.
+func (cs *ConsensusSet) applyMaintenance(diffs *modules.ConsensusChangeDiffs, b types.Block, height types.BlockHeight) {
+	var d modules.ConsensusChangeDiffs
+	for i, mp := range b.MinerPayouts {
+		d.DelayedSiacoinOutputDiffs = append(d.DelayedSiacoinOutputDiffs, modules.DelayedSiacoinOutputDiff{
+			Direction:      modules.DiffApply,
+			ID:             b.MinerPayoutID(uint64(i)),
+			SiacoinOutput:  mp,
+			MaturityHeight: height + types.MaturityDelay,
+		})
+	}
+	for id, sco := range cs.delayedOutputs[height] {
+		d.SiacoinOutputDiffs = append(d.SiacoinOutputDiffs, modules.SiacoinOutputDiff{
+			Direction:     modules.DiffApply,
+			ID:            id,
+			SiacoinOutput: sco,
+		})
+		d.DelayedSiacoinOutputDiffs = append(d.DelayedSiacoinOutputDiffs, modules.DelayedSiacoinOutputDiff{
+			Direction:      modules.DiffRevert,
+			ID:             id,
+			SiacoinOutput:  sco,
+			MaturityHeight: height,
+		})
+	}
+	for id, fc := range cs.fileContracts {
+		if fc.WindowEnd != height {
+			continue
+		}
+		for i, mpo := range fc.MissedProofOutputs {
+			d.DelayedSiacoinOutputDiffs = append(d.DelayedSiacoinOutputDiffs, modules.DelayedSiacoinOutputDiff{
+				Direction:      modules.DiffApply,
+				ID:             id.StorageProofOutputID(types.ProofMissed, uint64(i)),
+				SiacoinOutput:  mpo,
+				MaturityHeight: height + types.MaturityDelay,
+			})
+		}
+		d.FileContractDiffs = append(d.FileContractDiffs, modules.FileContractDiff{
+			Direction:    modules.DiffRevert,
+			ID:           id,
+			FileContract: fc,
+		})
+	}
+	cs.apply(diffs, d)
+}
+
+// AcceptBlock adds a block which extends the current block to the consensus
+// set and notifies the subscribers.
+func (cs *ConsensusSet) AcceptBlock(b types.Block) error {
+	cs.subscriberMu.Lock()
+	defer cs.subscriberMu.Unlock()
+
+	cs.mu.Lock()
+	if _, exists := cs.blockHeights[b.ID()]; exists {
+		cs.mu.Unlock()
+		return modules.ErrBlockKnown
+	}
+	if b.ParentID != cs.currentBlock().ID() {
+		cs.mu.Unlock()
+		return errOrphanBlock
+	}
+	height := cs.height() + 1
+	var payoutSum types.Currency
+	for _, mp := range b.MinerPayouts {
+		payoutSum = payoutSum.Add(mp.Value)
+	}
+	if !payoutSum.Equals(b.CalculateSubsidy(height)) {
+		cs.mu.Unlock()
+		return errWrongMinerPayouts
+	}
+	var diffs modules.ConsensusChangeDiffs
+	if err := cs.applyTransactions(&diffs, b.Transactions, height); err != nil {
+		cs.mu.Unlock()
+		return err
+	}
+	cs.applyMaintenance(&diffs, b, height)
+	cc := cs.appendBlock(b, diffs)
+	subscribers := append([]modules.ConsensusSetSubscriber(nil), cs.subscribers...)
+	cs.mu.Unlock()
+
+	for _, s := range subscribers {
+		s.ProcessConsensusChange(cc)
+	}
+	return nil
+}
+
+// MineBlock adds a block with the provided transactions which pays the block
+// subsidy to the provided address.
+func (cs *ConsensusSet) MineBlock(txns []types.Transaction, payout types.UnlockHash) (types.Block, error) {
+	cs.mu.RLock()
+	parent := cs.currentBlock()
+	height := cs.height() + 1
+	cs.mu.RUnlock()
+
+	b := types.Block{
+		ParentID:     parent.ID(),
+		Timestamp:    types.CurrentTimestamp(),
+		Transactions: txns,
+	}
+	if b.Timestamp <= parent.Timestamp {
+		b.Timestamp = parent.Timestamp + 1
+	}
+	b.MinerPayouts = []types.SiacoinOutput{{Value: b.CalculateSubsidy(height), UnlockHash: payout}}
+	return b, cs.AcceptBlock(b)
+}
+
+// SetSynced sets whether the consensus set reports to be synced.
+func (cs *ConsensusSet) SetSynced(synced bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.synced = synced
+}
+
+// Alerts implements the modules.Alerter interface. The in-memory consensus set
+// never registers any alerts.
+func (cs *ConsensusSet) Alerts() (crit, err, warn []modules.Alert) {
+	return
+}
+
+// OperationCounters returns the consensus set's operation counters. The
+// in-memory consensus set doesn't count any operations.
+func (cs *ConsensusSet) OperationCounters() map[string]modules.OperationStats {
+	return make(map[string]modules.OperationStats)
+}
+
+// BlockAtHeight returns the block at the provided height of the current path.
+func (cs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (types.Block, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if height > cs.height() {
+		return types.Block{}, false
+	}
+	return cs.blocks[height], true
+}
+
+// BlockByID returns the block with the provided id and its height.
+func (cs *ConsensusSet) BlockByID(id types.BlockID) (types.Block, types.BlockHeight, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	height, exists := cs.blockHeights[id]
+	if !exists {
+		return types.Block{}, 0, false
+	}
+	return cs.blocks[height], height, true
+}
+
+// BlockDiffs returns the diffs of the block at the provided height.
+func (cs *ConsensusSet) BlockDiffs(height types.BlockHeight) (modules.ConsensusBlockDiffs, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if height > cs.height() {
+		return modules.ConsensusBlockDiffs{}, errors.New("block height out of bounds")
+	}
+	return modules.ConsensusBlockDiffs{
+		Height:               height,
+		BlockID:              cs.blocks[height].ID(),
+		ConsensusChangeDiffs: cs.blockDiffs[height],
+	}, nil
+}
+
+// BlockStats is not supported by the in-memory consensus set.
+func (cs *ConsensusSet) BlockStats(height, window types.BlockHeight) (modules.ConsensusStats, error) {
+	return modules.ConsensusStats{}, errNotSupported
+}
+
+// ChildTarget returns the root target for any known block since the in-memory
+// consensus set doesn't check the proof of work.
+func (cs *ConsensusSet) ChildTarget(id types.BlockID) (types.Target, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	_, exists := cs.blockHeights[id]
+	return types.RootTarget, exists
+}
+
+// Close implements the modules.ConsensusSet interface.
+func (cs *ConsensusSet) Close() error {
+	return nil
+}
+
+// CommonAncestor returns the id and height of the latest common ancestor of
+// the two blocks. Since there are no forks, it is the lower of the two blocks.
+func (cs *ConsensusSet) CommonAncestor(a, b types.BlockID) (types.BlockID, types.BlockHeight, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	heightA, existsA := cs.blockHeights[a]
+	heightB, existsB := cs.blockHeights[b]
+	if !existsA || !existsB {
+		return types.BlockID{}, 0, errors.New("unknown block")
+	}
+	if heightB < heightA {
+		return b, heightB, nil
+	}
+	return a, heightA, nil
+}
+
+// ConsensusSetSubscribe sends the subscriber all consensus changes after the
+// change with the provided id and adds it to the subscribers.
+func (cs *ConsensusSet) ConsensusSetSubscribe(subscriber modules.ConsensusSetSubscriber, start modules.ConsensusChangeID, cancel <-chan struct{}) error {
+	cs.subscriberMu.Lock()
+	defer cs.subscriberMu.Unlock()
+
+	cs.mu.RLock()
+	changes, err := cs.changesSince(start)
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	for _, cc := range changes {
+		select {
+		case <-cancel:
+			return siasync.ErrStopped
+		default:
+		}
+		subscriber.ProcessConsensusChange(cc)
+	}
+
+	cs.mu.Lock()
+	cs.subscribers = append(cs.subscribers, subscriber)
+	cs.mu.Unlock()
+	return nil
+}
+
+// ConsensusChangesSince returns up to limit consensus changes after the change
+// with the provided id.
+func (cs *ConsensusSet) ConsensusChangesSince(start modules.ConsensusChangeID, limit int) (modules.ConsensusChangeBatch, error) {
+	if limit <= 0 || limit > maxConsensusChangeBatch {
+		limit = maxConsensusChangeBatch
+	}
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if start == modules.ConsensusChangeRecent {
+		return modules.ConsensusChangeBatch{Cursor: cs.changes[len(cs.changes)-1].ID}, nil
+	}
+	changes, err := cs.changesSince(start)
+	if err != nil {
+		return modules.ConsensusChangeBatch{}, err
+	}
+	batch := modules.ConsensusChangeBatch{Cursor: start}
+	if len(changes) > limit {
+		changes, batch.More = changes[:limit], true
+	}
+	batch.Changes = append(batch.Changes, changes...)
+	if len(changes) > 0 {
+		batch.Cursor = changes[len(changes)-1].ID
+	}
+	return batch, nil
+}
+
+// CurrentBlock returns the current block.
+func (cs *ConsensusSet) CurrentBlock() types.Block {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.currentBlock()
+}
+
+// FileContractStats is not supported by the in-memory consensus set.
+func (cs *ConsensusSet) FileContractStats() (modules.ConsensusFileContractStats, error) {
+	return modules.ConsensusFileContractStats{}, errNotSupported
+}
+
+// ForkRule is not supported by the in-memory consensus set.
+func (cs *ConsensusSet) ForkRule(name string) (modules.ForkRuleStatus, error) {
+	return modules.ForkRuleStatus{}, errNotSupported
+}
+
+// ForkRules is not supported by the in-memory consensus set.
+func (cs *ConsensusSet) ForkRules() ([]modules.ForkRuleStatus, error) {
+	return nil, errNotSupported
+}
+
+// FoundationUnlockHashes returns the initial Foundation unlock hashes. The
+// in-memory consensus set doesn't apply Foundation unlock hash updates.
+func (cs *ConsensusSet) FoundationUnlockHashes() (primary, failsafe types.UnlockHash) {
+	return types.InitialFoundationUnlockHash, types.InitialFoundationFailsafeUnlockHash
+}
+
+// Height returns the height of the current block.
+func (cs *ConsensusSet) Height() types.BlockHeight {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.height()
+}
+
+// InCurrentPath returns true if the block is part of the current path.
+func (cs *ConsensusSet) InCurrentPath(id types.BlockID) bool {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	_, exists := cs.blockHeights[id]
+	return exists
+}
+
+// IsAncestor returns true if the first block is an ancestor of the second
+// block.
+func (cs *ConsensusSet) IsAncestor(ancestor, descendant types.BlockID) (bool, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	heightA, existsA := cs.blockHeights[ancestor]
+	heightD, existsD := cs.blockHeights[descendant]
+	if !existsA || !existsD {
+		return false, errors.New("unknown block")
+	}
+	return heightA <= heightD, nil
+}
+
+// MinimumValidChildTimestamp returns the timestamp of the block since the
+// in-memory consensus set only requires timestamps to not decrease.
+func (cs *ConsensusSet) MinimumValidChildTimestamp(id types.BlockID) (types.Timestamp, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	height, exists := cs.blockHeights[id]
+	if !exists {
+		return 0, false
+	}
+	return cs.blocks[height].Timestamp, true
+}
+
+// ReorgSubscribe adds a reorg subscriber. Since the in-memory consensus set
+// doesn't reorg, the subscriber is never notified.
+func (cs *ConsensusSet) ReorgSubscribe(subscriber modules.ReorgSubscriber) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.reorgSubscribers = append(cs.reorgSubscribers, subscriber)
+	return nil
+}
+
+// ReorgUnsubscribe removes a reorg subscriber.
+func (cs *ConsensusSet) ReorgUnsubscribe(subscriber modules.ReorgSubscriber) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for i, s := range cs.reorgSubscribers {
+		if s == subscriber {
+			cs.reorgSubscribers = append(cs.reorgSubscribers[:i], cs.reorgSubscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetTransactionSource implements the modules.ConsensusSet interface. The
+// in-memory consensus set doesn't receive relayed blocks, so the source is
+// never used.
+func (cs *ConsensusSet) SetTransactionSource(modules.TransactionSource) {}
+
+// SnapshotDB is not supported by the in-memory consensus set.
+func (cs *ConsensusSet) SnapshotDB(dst string) (modules.ConsensusCheckpoint, error) {
+	return modules.ConsensusCheckpoint{}, errNotSupported
+}
+
+// StateCommitment returns the zero hash since the in-memory consensus set
+// doesn't maintain state commitments.
+func (cs *ConsensusSet) StateCommitment() (crypto.Hash, error) {
+	return crypto.Hash{}, nil
+}
+
+// StorageProofSegment returns the index of the segment that needs to be
+// proven for the file contract.
+func (cs *ConsensusSet) StorageProofSegment(fcid types.FileContractID) (uint64, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.storageProofSegment(fcid)
+}
+
+// SubscriberStats returns the subscribers of the consensus set. Since the
+// subscribers are updated synchronously, none of them is ever behind.
+func (cs *ConsensusSet) SubscriberStats() []modules.ConsensusSubscriberStats {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	stats := make([]modules.ConsensusSubscriberStats, len(cs.subscribers))
+	for i := range stats {
+		stats[i].Height = cs.height()
+	}
+	return stats
+}
+
+// Synced returns whether the consensus set reports to be synced.
+func (cs *ConsensusSet) Synced() bool {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.synced
+}
+
+// TryTransactionSet checks whether the transactions are valid and returns the resulting diffs without applying them.
+func (cs *ConsensusSet) TryTransactionSet(txns []types.Transaction) (modules.ConsensusChange, error) {
+	diffs, err := cs.tryTransactions(nil, txns)
+	if err != nil {
+		return modules.ConsensusChange{}, err
+	}
+	return modules.ConsensusChange{ConsensusChangeDiffs: diffs}, nil
+}
+
+// tryTransactions checks whether the transactions would be valid after the
+// parents and returns the diffs of the transactions without
+// the diffs of the parents.
+func (cs *ConsensusSet) tryTransactions(parents, txns []types.Transaction) (modules.ConsensusChangeDiffs, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	height := cs.height()
+	var parentDiffs, diffs modules.ConsensusChangeDiffs
+	if err := cs.applyTransactions(&parentDiffs, parents, height); err != nil {
+		return modules.ConsensusChangeDiffs{}, errors.AddContext(err, "invalid parents")
+	}
+	defer cs.commitDiffs(parentDiffs, modules.DiffRevert)
+	if err := cs.applyTransactions(&diffs, txns, height); err != nil {
+		return modules.ConsensusChangeDiffs{}, err
+	}
+	cs.commitDiffs(diffs, modules.DiffRevert)
+	return diffs, nil
+}
+
+// Unsubscribe removes a subscriber.
+func (cs *ConsensusSet) Unsubscribe(subscriber modules.ConsensusSetSubscriber) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for i, s := range cs.subscribers {
+		if s == subscriber {
+			cs.subscribers = append(cs.subscribers[:i], cs.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// VerifyConsensus is not supported by the in-memory consensus set.
+func (cs *ConsensusSet) VerifyConsensus() (modules.ConsensusVerification, error) {
+	return modules.ConsensusVerification{}, errNotSupported
+}
+
+// Enforce that ConsensusSet satisfies the modules.ConsensusSet interface.
+var _ modules.ConsensusSet = (*ConsensusSet)(nil)
@@ -0,0 +1,578 @@
+package memory
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	errNotAllowlisted = errors.New("peer is not on the allowlist")
+	errNotConnected   = errors.New("not connected to that peer")
+	errPeerBlocked    = errors.New("peer is on the blocklist")
+	errSelfConnect    = errors.New("can't connect to own address")
+	errUnknownAddress = errors.New("no in-memory gateway with that address")
+	errUnknownRPC     = errors.New("peer doesn't handle that RPC")
+)
+
+var (
+	// gateways contains all in-memory gateways which haven't been closed,
+	// indexed by their address.
+	gateways   = make(map[modules.NetAddress]*Gateway)
+	nextPort   = 1
+	gatewaysMu sync.Mutex
+)
+
+type (
+	// Gateway is an in-memory gateway. In-memory gateways can only connect to
+	// other in-memory gateways of the same process, and the RPCs between them
+	// are performed over synchronous in-memory pipes.
+	Gateway struct {
+		staticAddress modules.NetAddress
+
+		allowlist         map[string]struct{}
+		allowlistOnly     bool
+		blocklist         map[string]struct{}
+		connectCalls      map[string]modules.RPCFunc
+		handlers          map[string]modules.RPCFunc
+		peers             map[modules.NetAddress]modules.Peer
+		preferredFamily   modules.AddressFamily
+		rateLimits        [2]int64
+		requireEncryption bool
+		services          modules.GatewayServices
+		mu                sync.RWMutex
+	}
+
+	// peerConn is a pipe which knows the address of the gateway on the other
+	// end.
+	peerConn struct {
+		net.Conn
+		addr modules.NetAddress
+	}
+)
+
+// RPCAddr implements the modules.PeerConn interface.
+func (pc peerConn) RPCAddr() modules.NetAddress {
+	return pc.addr
+}
+
+// NewGateway returns an in-memory gateway with a unique address.
+func NewGateway() *Gateway {
+	gatewaysMu.Lock()
+	defer gatewaysMu.Unlock()
+	g := &Gateway{
+		staticAddress: modules.NetAddress(fmt.Sprintf("127.0.0.1:%d", nextPort)),
+
+		allowlist:    make(map[string]struct{}),
+		blocklist:    make(map[string]struct{}),
+		connectCalls: make(map[string]modules.RPCFunc),
+		handlers:     make(map[string]modules.RPCFunc),
+		peers:        make(map[modules.NetAddress]modules.Peer),
+	}
+	nextPort++
+	gateways[g.staticAddress] = g
+	return g
+}
+
+// lookupGateway returns the in-memory gateway with the provided address.
+func lookupGateway(addr modules.NetAddress) (*Gateway, error) {
+	gatewaysMu.Lock()
+	defer gatewaysMu.Unlock()
+	g, exists := gateways[addr]
+	if !exists {
+		return nil, errUnknownAddress
+	}
+	return g, nil
+}
+
+// managedAddPeer adds the remote gateway to the peers.
+func (g *Gateway) managedAddPeer(remote *Gateway, inbound bool) error {
+	remote.mu.RLock()
+	services := remote.services
+	remote.mu.RUnlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, blocked := g.blocklist[remote.staticAddress.Host()]; blocked {
+		return errPeerBlocked
+	}
+	if _, allowed := g.allowlist[remote.staticAddress.Host()]; g.allowlistOnly && !allowed {
+		return errNotAllowlisted
+	}
+	g.peers[remote.staticAddress] = modules.Peer{
+		Inbound:    inbound,
+		Local:      true,
+		NetAddress: remote.staticAddress,
+		Version:    build.NodeVersion,
+		Services:   services,
+	}
+	return nil
+}
+
+// managedConnectCalls returns the connect calls of the gateway.
+func (g *Gateway) managedConnectCalls() map[string]modules.RPCFunc {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	calls := make(map[string]modules.RPCFunc, len(g.connectCalls))
+	for name, fn := range g.connectCalls {
+		calls[name] = fn
+	}
+	return calls
+}
+
+// managedConnect connects the gateway to the gateway with the provided
+// address and performs the connect calls of both gateways.
+func (g *Gateway) managedConnect(addr modules.NetAddress) error {
+	if addr == g.staticAddress {
+		return errSelfConnect
+	}
+	remote, err := lookupGateway(addr)
+	if err != nil {
+		return err
+	}
+	if err := g.managedAddPeer(remote, false); err != nil {
+		return err
+	}
+	if err := remote.managedAddPeer(g, true); err != nil {
+		g.mu.Lock()
+		delete(g.peers, addr)
+		g.mu.Unlock()
+		return err
+	}
+
+	// Like the full gateway, the connect calls are performed in the
+	// background.
+	for name, fn := range g.managedConnectCalls() {
+		go g.RPC(addr, name, fn)
+	}
+	for name, fn := range remote.managedConnectCalls() {
+		go remote.RPC(g.staticAddress, name, fn)
+	}
+	return nil
+}
+
+// managedDisconnect removes the peer on both sides.
+func (g *Gateway) managedDisconnect(addr modules.NetAddress) error {
+	g.mu.Lock()
+	_, exists := g.peers[addr]
+	delete(g.peers, addr)
+	g.mu.Unlock()
+	if !exists {
+		return errNotConnected
+	}
+	if remote, err := lookupGateway(addr); err == nil {
+		remote.mu.Lock()
+		delete(remote.peers, g.staticAddress)
+		remote.mu.Unlock()
+	}
+	return nil
+}
+
+// Address returns the address of the gateway.
+func (g *Gateway) Address() modules.NetAddress {
+	return g.staticAddress
+}
+
+// Addresses returns the address of the gateway. The in-memory gateway only
+// has a single address.
+func (g *Gateway) Addresses() []modules.NetAddress {
+	return []modules.NetAddress{g.staticAddress}
+}
+
+// AddToBlocklist adds hosts to the blocklist and disconnects from them.
+func (g *Gateway) AddToBlocklist(addresses []string) error {
+	g.mu.Lock()
+	var disconnect []modules.NetAddress
+	for _, addr := range addresses {
+		host := modules.NetAddress(addr).Host()
+		if host == "" {
+			host = addr
+		}
+		g.blocklist[host] = struct{}{}
+		for peer := range g.peers {
+			if peer.Host() == host {
+				disconnect = append(disconnect, peer)
+			}
+		}
+	}
+	g.mu.Unlock()
+	for _, peer := range disconnect {
+		g.managedDisconnect(peer)
+	}
+	return nil
+}
+
+// Allowlist returns whether the gateway is in allowlist-only mode and the
+// hosts on the allowlist.
+func (g *Gateway) Allowlist() (bool, []string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	allowlist := make([]string, 0, len(g.allowlist))
+	for host := range g.allowlist {
+		allowlist = append(allowlist, host)
+	}
+	return g.allowlistOnly, allowlist, nil
+}
+
+// Alerts implements the modules.Alerter interface. The in-memory gateway never
+// registers any alerts.
+func (g *Gateway) Alerts() (crit, err, warn []modules.Alert) {
+	return
+}
+
+// BandwidthCounters returns zero counters since the in-memory gateway doesn't
+// meter its traffic.
+func (g *Gateway) BandwidthCounters() (uint64, uint64, time.Time, error) {
+	return 0, 0, time.Time{}, nil
+}
+
+// Blocklist returns the hosts on the blocklist.
+func (g *Gateway) Blocklist() ([]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	blocklist := make([]string, 0, len(g.blocklist))
+	for host := range g.blocklist {
+		blocklist = append(blocklist, host)
+	}
+	return blocklist, nil
+}
+
+// Broadcast writes the object to the provided peers in parallel.
+func (g *Gateway) Broadcast(name string, obj interface{}, peers []modules.Peer) {
+	fn := func(conn modules.PeerConn) error {
+		return encoding.WriteObject(conn, obj)
+	}
+	var wg sync.WaitGroup
+	for _, p := range peers {
+		wg.Add(1)
+		go func(addr modules.NetAddress) {
+			defer wg.Done()
+			g.RPC(addr, name, fn)
+		}(p.NetAddress)
+	}
+	wg.Wait()
+}
+
+// CheckReachability reports that no peer checked the address since the
+// in-memory gateway can't dial real hosts.
+func (g *Gateway) CheckReachability(addr modules.NetAddress, hostKey types.SiaPublicKey) []modules.ReachabilityReport {
+	var reports []modules.ReachabilityReport
+	for _, p := range g.Peers() {
+		reports = append(reports, modules.ReachabilityReport{
+			Peer:  p.NetAddress,
+			Error: "not supported by the in-memory gateway",
+		})
+	}
+	return reports
+}
+
+// Close disconnects the gateway from its peers and removes it from the known
+// in-memory gateways.
+func (g *Gateway) Close() error {
+	for _, p := range g.Peers() {
+		g.managedDisconnect(p.NetAddress)
+	}
+	gatewaysMu.Lock()
+	delete(gateways, g.staticAddress)
+	gatewaysMu.Unlock()
+	return nil
+}
+
+// Connect connects the gateway to the in-memory gateway with the provided
+// address.
+func (g *Gateway) Connect(addr modules.NetAddress) error {
+	return g.managedConnect(addr)
+}
+
+// ConnectManual connects the gateway to the in-memory gateway with the
+// provided address.
+func (g *Gateway) ConnectManual(addr modules.NetAddress) error {
+	return g.managedConnect(addr)
+}
+
+// ConnectServices returns the peers which advertise all of the provided
+// services. The in-memory gateway doesn't know any other nodes, so it fails
+// if it isn't already connected to enough of them.
+func (g *Gateway) ConnectServices(services modules.GatewayServices, n int) ([]modules.Peer, error) {
+	var peers []modules.Peer
+	for _, p := range g.Peers() {
+		if p.Services.Has(services) {
+			peers = append(peers, p)
+		}
+	}
+	if len(peers) < n {
+		return peers, fmt.Errorf("only connected to %v of %v peers with the requested services", len(peers), n)
+	}
+	return peers, nil
+}
+
+// Disconnect disconnects the gateway from the peer.
+func (g *Gateway) Disconnect(addr modules.NetAddress) error {
+	return g.managedDisconnect(addr)
+}
+
+// DisconnectManual disconnects the gateway from the peer.
+func (g *Gateway) DisconnectManual(addr modules.NetAddress) error {
+	return g.managedDisconnect(addr)
+}
+
+// DiscoverAddress returns the loopback address, which is where all in-memory
+// gateways live.
+func (g *Gateway) DiscoverAddress(cancel <-chan struct{}) (net.IP, error) {
+	return net.IPv4(127, 0, 0, 1), nil
+}
+
+// ForwardPort implements the modules.Gateway interface. There is no router to
+// forward ports on.
+func (g *Gateway) ForwardPort(port string) error {
+	return nil
+}
+
+// Online returns true if the gateway is connected to at least one peer.
+func (g *Gateway) Online() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.peers) > 0
+}
+
+// PeerBans returns no bans since the in-memory gateway doesn't score peers.
+func (g *Gateway) PeerBans() []modules.PeerBan {
+	return nil
+}
+
+// PeerDisconnects returns no disconnects since the RPCs of the in-memory
+// gateway have no deadlines.
+func (g *Gateway) PeerDisconnects() []modules.PeerDisconnect {
+	return nil
+}
+
+// PeerScores returns no scores since the in-memory gateway doesn't score
+// peers.
+func (g *Gateway) PeerScores() []modules.PeerScore {
+	return nil
+}
+
+// PreferredAddressFamily returns the address family the gateway prefers when
+// dialing peers.
+func (g *Gateway) PreferredAddressFamily() modules.AddressFamily {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.preferredFamily
+}
+
+// Peers returns the peers of the gateway.
+func (g *Gateway) Peers() []modules.Peer {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	peers := make([]modules.Peer, 0, len(g.peers))
+	for _, p := range g.peers {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// PeerStats returns the peers of the gateway without any connection metrics
+// since the in-memory gateway doesn't use real connections.
+func (g *Gateway) PeerStats() []modules.PeerStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	stats := make([]modules.PeerStats, 0, len(g.peers))
+	for _, p := range g.peers {
+		stats = append(stats, modules.PeerStats{Peer: p})
+	}
+	return stats
+}
+
+// RateLimits returns the rate limits of the gateway. They are stored but not
+// enforced.
+func (g *Gateway) RateLimits() (int64, int64) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.rateLimits[0], g.rateLimits[1]
+}
+
+// RecordPeerMisbehavior implements the modules.Gateway interface. The
+// in-memory gateway doesn't score peers.
+func (g *Gateway) RecordPeerMisbehavior(addr modules.NetAddress, misbehavior modules.PeerMisbehavior) {
+}
+
+// RegisterConnectCall registers an RPC which is called on every new peer.
+func (g *Gateway) RegisterConnectCall(name string, fn modules.RPCFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.connectCalls[name] = fn
+}
+
+// RegisterRPC registers the handler of an RPC.
+func (g *Gateway) RegisterRPC(name string, fn modules.RPCFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.handlers[name] = fn
+}
+
+// RemoveFromBlocklist removes hosts from the blocklist.
+func (g *Gateway) RemoveFromBlocklist(addresses []string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, addr := range addresses {
+		host := modules.NetAddress(addr).Host()
+		if host == "" {
+			host = addr
+		}
+		delete(g.blocklist, host)
+	}
+	return nil
+}
+
+// RequireEncryption returns true if the gateway refuses plaintext sessions.
+func (g *Gateway) RequireEncryption() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.requireEncryption
+}
+
+// RPC calls the RPC on the peer. The handler of the peer runs concurrently
+// on the other end of an in-memory pipe, and both ends are closed once they
+// returned.
+func (g *Gateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	g.mu.RLock()
+	_, connected := g.peers[addr]
+	g.mu.RUnlock()
+	if !connected {
+		return errNotConnected
+	}
+	remote, err := lookupGateway(addr)
+	if err != nil {
+		return err
+	}
+	remote.mu.RLock()
+	handler, exists := remote.handlers[name]
+	remote.mu.RUnlock()
+	if !exists {
+		return errUnknownRPC
+	}
+
+	local, remoteConn := net.Pipe()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- handler(peerConn{Conn: remoteConn, addr: g.staticAddress})
+		remoteConn.Close()
+	}()
+	err = fn(peerConn{Conn: local, addr: addr})
+	local.Close()
+	<-errChan
+	return err
+}
+
+// Services returns the services the gateway advertises.
+func (g *Gateway) Services() modules.GatewayServices {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.services
+}
+
+// SetPreferredAddressFamily sets the address family the gateway prefers when
+// dialing peers. The in-memory gateway doesn't dial, so it's only recorded.
+func (g *Gateway) SetPreferredAddressFamily(family modules.AddressFamily) error {
+	if err := family.Valid(); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.preferredFamily = family
+	return nil
+}
+
+// SetRequireEncryption sets whether the gateway refuses plaintext sessions.
+// The in-memory gateway has no sessions to encrypt, so it's only recorded.
+func (g *Gateway) SetRequireEncryption(require bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requireEncryption = require
+	return nil
+}
+
+// SetAllowlist replaces the allowlist and sets whether the gateway is in
+// allowlist-only mode. In allowlist-only mode, the gateway disconnects from
+// the peers which aren't on the allowlist.
+func (g *Gateway) SetAllowlist(enabled bool, addresses []string) error {
+	g.mu.Lock()
+	g.allowlist = make(map[string]struct{})
+	for _, addr := range addresses {
+		host := modules.NetAddress(addr).Host()
+		if host == "" {
+			host = addr
+		}
+		g.allowlist[host] = struct{}{}
+	}
+	g.allowlistOnly = enabled
+	var disconnect []modules.NetAddress
+	for peer := range g.peers {
+		if _, allowed := g.allowlist[peer.Host()]; enabled && !allowed {
+			disconnect = append(disconnect, peer)
+		}
+	}
+	g.mu.Unlock()
+	for _, peer := range disconnect {
+		g.managedDisconnect(peer)
+	}
+	return nil
+}
+
+// SetBlocklist replaces the blocklist.
+func (g *Gateway) SetBlocklist(addresses []string) error {
+	g.mu.Lock()
+	g.blocklist = make(map[string]struct{})
+	g.mu.Unlock()
+	return g.AddToBlocklist(addresses)
+}
+
+// SetRateLimits sets the rate limits of the gateway. They are stored but not
+// enforced.
+func (g *Gateway) SetRateLimits(downloadSpeed, uploadSpeed int64) error {
+	if downloadSpeed < 0 || uploadSpeed < 0 {
+		return errors.New("download/upload rate can't be below 0")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rateLimits = [2]int64{downloadSpeed, uploadSpeed}
+	return nil
+}
+
+// SetServices sets the services the gateway advertises to new peers.
+func (g *Gateway) SetServices(services modules.GatewayServices) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.services = services
+}
+
+// UnbanPeer implements the modules.Gateway interface. The in-memory gateway
+// never bans peers.
+func (g *Gateway) UnbanPeer(host string) error {
+	return nil
+}
+
+// UnregisterConnectCall removes an RPC which is called on every new peer.
+func (g *Gateway) UnregisterConnectCall(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.connectCalls, name)
+}
+
+// UnregisterRPC removes the handler of an RPC.
+func (g *Gateway) UnregisterRPC(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.handlers, name)
+}
+
+// Enforce that Gateway satisfies the modules.Gateway interface.
+var _ modules.Gateway = (*Gateway)(nil)
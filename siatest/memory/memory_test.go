@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/wallet"
+	"go.sia.tech/siad/types"
+)
+
+// TestMemoryModules runs a wallet on top of the in-memory modules and checks
+// that it can receive miner payouts and send siacoins to a peer.
+func TestMemoryModules(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create two peers which share a consensus set.
+	cs := NewConsensusSet()
+	g1, g2 := NewGateway(), NewGateway()
+	defer g1.Close()
+	defer g2.Close()
+	tp1, err := NewTransactionPool(cs, g1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp2, err := NewTransactionPool(cs, g2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if len(g2.Peers()) != 1 || !g2.Peers()[0].Inbound {
+		t.Fatal("peer wasn't added on both sides", g2.Peers())
+	}
+
+	// Create a wallet on top of the first peer.
+	w, err := wallet.New(cs, tp1, build.TempDir("memory", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	masterKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	if _, err := w.Encrypt(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	uc, err := w.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine until the first miner payout matures.
+	for i := types.BlockHeight(0); i <= types.MaturityDelay; i++ {
+		if _, err := cs.MineBlock(nil, uc.UnlockHash()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	balance, _, _, err := w.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Equals(types.CalculateCoinbase(1)) {
+		t.Fatalf("expected balance %v, got %v", types.CalculateCoinbase(1), balance)
+	}
+
+	// Blocks which don't pay the subsidy or don't extend the current block
+	// are rejected.
+	b := types.Block{ParentID: cs.CurrentBlock().ID()}
+	if err := cs.AcceptBlock(b); !errors.Contains(err, errWrongMinerPayouts) {
+		t.Fatal("expected errWrongMinerPayouts", err)
+	}
+	b = types.Block{ParentID: types.GenesisID}
+	if err := cs.AcceptBlock(b); !errors.Contains(err, errOrphanBlock) {
+		t.Fatal("expected errOrphanBlock", err)
+	}
+
+	// Send siacoins. The transaction set is relayed to the second peer.
+	var dest types.UnlockHash
+	fastrand.Read(dest[:])
+	txns, err := w.SendSiacoins(types.SiacoinPrecision, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnID := txns[len(txns)-1].ID()
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if _, _, exists := tp2.Transaction(txnID); !exists {
+			return errors.New("transaction wasn't relayed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Double spends are rejected.
+	if err := tp1.AcceptTransactionSet(txns); !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+		t.Fatal("expected ErrDuplicateTransactionSet", err)
+	}
+
+	// Confirm the transaction.
+	if _, err := cs.MineBlock(tp1.TransactionList(), types.UnlockHash{}); err != nil {
+		t.Fatal(err)
+	}
+	if confirmed, _ := tp1.TransactionConfirmed(txnID); !confirmed {
+		t.Fatal("transaction wasn't confirmed")
+	}
+	if len(tp1.TransactionList()) != 0 || len(tp2.TransactionList()) != 0 {
+		t.Fatal("confirmed transactions weren't removed from the pools")
+	}
+	newBalance, _, _, err := w.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The payout of the second block matured in the meantime.
+	if newBalance.Cmp(balance.Add(types.CalculateCoinbase(2)).Sub(types.SiacoinPrecision)) > 0 {
+		t.Fatal("balance didn't decrease", balance, newBalance)
+	}
+	var received bool
+	for _, txn := range txns {
+		for i, sco := range txn.SiacoinOutputs {
+			if sco.UnlockHash != dest {
+				continue
+			}
+			if _, exists := cs.siacoinOutputs[txn.SiacoinOutputID(uint64(i))]; exists {
+				received = true
+			}
+		}
+	}
+	if !received {
+		t.Fatal("destination didn't receive the siacoins")
+	}
+}
@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"go.sia.tech/siad/node"
+)
+
+// NodeParams returns the params with the consensus set, the gateway and the
+// transaction pool replaced by in-memory modules. The other modules of the
+// node are created as usual on top of them.
+func NodeParams(params node.NodeParams) (node.NodeParams, error) {
+	cs := NewConsensusSet()
+	g := NewGateway()
+	tp, err := NewTransactionPool(cs, g)
+	if err != nil {
+		return node.NodeParams{}, err
+	}
+	params.CreateConsensusSet = false
+	params.CreateGateway = false
+	params.CreateTransactionPool = false
+	params.ConsensusSet = cs
+	params.Gateway = g
+	params.TransactionPool = tp
+	return params, nil
+}
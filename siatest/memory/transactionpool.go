@@ -0,0 +1,395 @@
+package memory
+
+import (
+	"sync"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errLowMinerFees is returned if a transaction set doesn't pay the
+	// configured minimum accept fee.
+	errLowMinerFees = errors.New("transaction set needs more miner fees to be accepted")
+
+	// minEstimation is the fee per byte returned by FeeEstimation. It matches
+	// the absolute minimum of the full transaction pool.
+	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
+)
+
+type (
+	// TransactionPool is an in-memory transaction pool on top of an in-memory
+	// consensus set. Every transaction set which is valid in the next block
+	// is accepted as long as it pays the configured minimum accept fee, and
+	// accepted sets are broadcast to the peers of the gateway.
+	TransactionPool struct {
+		staticConsensusSet *ConsensusSet
+		staticGateway      modules.Gateway
+
+		transactionSets []*modules.UnconfirmedTransactionSet
+		transactions    map[types.TransactionID]struct{}
+		confirmed       map[types.TransactionID]struct{}
+		settings        modules.TransactionPoolSettings
+		subscribers     []modules.TransactionPoolSubscriber
+		mu              sync.Mutex
+	}
+)
+
+// NewTransactionPool returns an in-memory transaction pool which subscribes to
+// the provided consensus set. If a gateway is provided, the transaction pool
+// registers the RPC for relayed transaction sets and broadcasts accepted sets
+// to the gateway's peers.
+func NewTransactionPool(cs *ConsensusSet, g modules.Gateway) (*TransactionPool, error) {
+	tp := &TransactionPool{
+		staticConsensusSet: cs,
+		staticGateway:      g,
+
+		transactions: make(map[types.TransactionID]struct{}),
+		confirmed:    make(map[types.TransactionID]struct{}),
+	}
+	if err := cs.ConsensusSetSubscribe(tp, modules.ConsensusChangeBeginning, nil); err != nil {
+		return nil, err
+	}
+	if g != nil {
+		g.RegisterRPC("RelayTransactionSet", tp.relayTransactionSet)
+	}
+	return tp, nil
+}
+
+// exempt returns whether a transaction set spends an output of an exempt
+// address and therefore isn't subject to the minimum accept fee.
+func (tp *TransactionPool) exempt(ts []types.Transaction) bool {
+	for _, addr := range tp.settings.ExemptAddresses {
+		for _, txn := range ts {
+			for _, sci := range txn.SiacoinInputs {
+				if sci.UnlockConditions.UnlockHash() == addr {
+					return true
+				}
+			}
+			for _, sfi := range txn.SiafundInputs {
+				if sfi.UnlockConditions.UnlockHash() == addr {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// transactionList returns the transactions of the pool in the order in which
+// they can be put into a block.
+func (tp *TransactionPool) transactionList() []types.Transaction {
+	var txns []types.Transaction
+	for _, ts := range tp.transactionSets {
+		txns = append(txns, ts.Transactions...)
+	}
+	return txns
+}
+
+// newUnconfirmedSet validates the transaction set on top of the transactions
+// which are already in the pool and returns the resulting unconfirmed set.
+func (tp *TransactionPool) newUnconfirmedSet(parents, ts []types.Transaction) (*modules.UnconfirmedTransactionSet, error) {
+	diffs, err := tp.staticConsensusSet.tryTransactions(parents, ts)
+	if err != nil {
+		return nil, modules.NewConsensusConflict("provided transaction set is invalid: " + err.Error())
+	}
+	uts := &modules.UnconfirmedTransactionSet{
+		Change:       &modules.ConsensusChange{ConsensusChangeDiffs: diffs},
+		ID:           modules.TransactionSetID(crypto.HashObject(ts)),
+		Transactions: ts,
+	}
+	for _, txn := range ts {
+		uts.IDs = append(uts.IDs, txn.ID())
+		uts.Sizes = append(uts.Sizes, uint64(len(encoding.Marshal(txn))))
+	}
+	return uts, nil
+}
+
+// acceptTransactionSet adds a transaction set to the pool and returns the
+// resulting unconfirmed set.
+func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction) (*modules.UnconfirmedTransactionSet, error) {
+	if len(ts) == 0 {
+		return nil, errors.New("transaction set is empty")
+	}
+	size := len(encoding.Marshal(ts))
+	if size > modules.TransactionSetSizeLimit {
+		return nil, modules.ErrLargeTransactionSet
+	}
+	duplicate := true
+	for _, txn := range ts {
+		id := txn.ID()
+		_, inPool := tp.transactions[id]
+		_, confirmed := tp.confirmed[id]
+		if !inPool && !confirmed {
+			duplicate = false
+			break
+		}
+	}
+	if duplicate {
+		return nil, modules.ErrDuplicateTransactionSet
+	}
+	requiredFees := tp.settings.MinAcceptFee.Mul64(uint64(size))
+	if modules.CalculateFee(ts).Cmp(requiredFees) < 0 && !tp.exempt(ts) {
+		return nil, errLowMinerFees
+	}
+
+	uts, err := tp.newUnconfirmedSet(tp.transactionList(), ts)
+	if err != nil {
+		return nil, err
+	}
+	tp.transactionSets = append(tp.transactionSets, uts)
+	for _, id := range uts.IDs {
+		tp.transactions[id] = struct{}{}
+	}
+	return uts, nil
+}
+
+// updateSubscribers sends the diff to all subscribers.
+func (tp *TransactionPool) updateSubscribers(diff *modules.TransactionPoolDiff) {
+	for _, subscriber := range tp.subscribers {
+		subscriber.ReceiveUpdatedUnconfirmedTransactions(diff)
+	}
+}
+
+// relayTransactionSet is the RPC which receives transaction sets from peers.
+func (tp *TransactionPool) relayTransactionSet(conn modules.PeerConn) error {
+	var ts []types.Transaction
+	if err := encoding.ReadObject(conn, &ts, types.BlockSizeLimit); err != nil {
+		return err
+	}
+	return tp.AcceptTransactionSet(ts)
+}
+
+// AcceptTransactionSet adds a transaction set to the pool and broadcasts it
+// to the gateway's peers.
+func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
+	tp.mu.Lock()
+	uts, err := tp.acceptTransactionSet(ts)
+	if err != nil {
+		tp.mu.Unlock()
+		return err
+	}
+	tp.updateSubscribers(&modules.TransactionPoolDiff{
+		AppliedTransactions: []*modules.UnconfirmedTransactionSet{uts},
+	})
+	tp.mu.Unlock()
+
+	tp.Broadcast(ts)
+	return nil
+}
+
+// AcceptTransactionPackage adds a package of dependent transactions to the
+// pool. Since the in-memory transaction pool validates transaction sets as a
+// whole, a package is accepted the same way as a transaction set.
+func (tp *TransactionPool) AcceptTransactionPackage(ts []types.Transaction) error {
+	return tp.AcceptTransactionSet(ts)
+}
+
+// Alerts implements the modules.Alerter interface. The in-memory transaction
+// pool never registers any alerts.
+func (tp *TransactionPool) Alerts() (crit, err, warn []modules.Alert) {
+	return
+}
+
+// Broadcast broadcasts a transaction set to the peers of the gateway.
+func (tp *TransactionPool) Broadcast(ts []types.Transaction) {
+	if tp.staticGateway == nil {
+		return
+	}
+	go tp.staticGateway.Broadcast("RelayTransactionSet", ts, tp.staticGateway.Peers())
+}
+
+// Close unsubscribes the transaction pool from the consensus set.
+func (tp *TransactionPool) Close() error {
+	tp.staticConsensusSet.Unsubscribe(tp)
+	if tp.staticGateway != nil {
+		tp.staticGateway.UnregisterRPC("RelayTransactionSet")
+	}
+	return nil
+}
+
+// FeeEstimation returns the absolute minimum fee of the full transaction pool
+// or the minimum accept fee, whichever is higher. The in-memory transaction
+// pool is never congested.
+func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	min = minEstimation
+	if min.Cmp(tp.settings.MinAcceptFee) < 0 {
+		min = tp.settings.MinAcceptFee
+	}
+	return min, min.Mul64(3)
+}
+
+// ProcessConsensusChange removes the confirmed transactions from the pool and
+// revalidates the remaining transaction sets.
+func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	for _, b := range cc.AppliedBlocks {
+		for _, txn := range b.Transactions {
+			tp.confirmed[txn.ID()] = struct{}{}
+		}
+	}
+
+	diff := &modules.TransactionPoolDiff{}
+	oldSets := tp.transactionSets
+	tp.transactionSets = nil
+	tp.transactions = make(map[types.TransactionID]struct{})
+	for _, uts := range oldSets {
+		diff.RevertedTransactions = append(diff.RevertedTransactions, uts.ID)
+
+		// Drop the confirmed transactions and readd the remaining ones if
+		// they are still valid.
+		var ts []types.Transaction
+		for i, txn := range uts.Transactions {
+			if _, confirmed := tp.confirmed[uts.IDs[i]]; !confirmed {
+				ts = append(ts, txn)
+			}
+		}
+		if len(ts) == 0 {
+			continue
+		}
+		newSet, err := tp.newUnconfirmedSet(tp.transactionList(), ts)
+		if err != nil {
+			continue
+		}
+		tp.transactionSets = append(tp.transactionSets, newSet)
+		for _, id := range newSet.IDs {
+			tp.transactions[id] = struct{}{}
+		}
+		diff.AppliedTransactions = append(diff.AppliedTransactions, newSet)
+	}
+	if len(diff.RevertedTransactions) > 0 || len(diff.AppliedTransactions) > 0 {
+		tp.updateSubscribers(diff)
+	}
+}
+
+// PurgeTransactionPool removes all transactions from the pool.
+func (tp *TransactionPool) PurgeTransactionPool() {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	diff := &modules.TransactionPoolDiff{}
+	for _, uts := range tp.transactionSets {
+		diff.RevertedTransactions = append(diff.RevertedTransactions, uts.ID)
+	}
+	tp.transactionSets = nil
+	tp.transactions = make(map[types.TransactionID]struct{})
+	tp.updateSubscribers(diff)
+}
+
+// SetSettings changes the admission policy of the transaction pool. The relay
+// fee is stored but not enforced.
+func (tp *TransactionPool) SetSettings(settings modules.TransactionPoolSettings) error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.settings = settings
+	tp.settings.ExemptAddresses = append([]types.UnlockHash(nil), settings.ExemptAddresses...)
+	return nil
+}
+
+// Settings returns the admission policy of the transaction pool.
+func (tp *TransactionPool) Settings() modules.TransactionPoolSettings {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	settings := tp.settings
+	settings.ExemptAddresses = append([]types.UnlockHash(nil), tp.settings.ExemptAddresses...)
+	return settings
+}
+
+// Transaction returns the transaction with the provided id and the
+// transactions of its set which precede it.
+func (tp *TransactionPool) Transaction(id types.TransactionID) (types.Transaction, []types.Transaction, bool) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for _, uts := range tp.transactionSets {
+		for i, txnID := range uts.IDs {
+			if txnID == id {
+				return uts.Transactions[i], append([]types.Transaction(nil), uts.Transactions[:i]...), true
+			}
+		}
+	}
+	return types.Transaction{}, nil, false
+}
+
+// Transactions returns the transactions of the pool.
+func (tp *TransactionPool) Transactions() []types.Transaction {
+	return tp.TransactionList()
+}
+
+// TransactionConfirmed returns true if the transaction is part of a block.
+func (tp *TransactionPool) TransactionConfirmed(id types.TransactionID) (bool, error) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	_, confirmed := tp.confirmed[id]
+	return confirmed, nil
+}
+
+// TransactionList returns the transactions of the pool in the order in which
+// they can be put into a block.
+func (tp *TransactionPool) TransactionList() []types.Transaction {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return tp.transactionList()
+}
+
+// TransactionPoolSubscribe adds a subscriber and sends it the transaction sets
+// which are currently in the pool.
+func (tp *TransactionPool) TransactionPoolSubscribe(subscriber modules.TransactionPoolSubscriber) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.subscribers = append(tp.subscribers, subscriber)
+	subscriber.ReceiveUpdatedUnconfirmedTransactions(&modules.TransactionPoolDiff{
+		AppliedTransactions: append([]*modules.UnconfirmedTransactionSet(nil), tp.transactionSets...),
+	})
+}
+
+// TransactionSet returns the transaction set which contains the transaction
+// or creates the output with the provided id.
+func (tp *TransactionPool) TransactionSet(oid crypto.Hash) []types.Transaction {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for _, uts := range tp.transactionSets {
+		for i, txn := range uts.Transactions {
+			if crypto.Hash(uts.IDs[i]) == oid {
+				return append([]types.Transaction(nil), uts.Transactions...)
+			}
+			for j := range txn.SiacoinOutputs {
+				if crypto.Hash(txn.SiacoinOutputID(uint64(j))) == oid {
+					return append([]types.Transaction(nil), uts.Transactions...)
+				}
+			}
+			for j := range txn.FileContracts {
+				if crypto.Hash(txn.FileContractID(uint64(j))) == oid {
+					return append([]types.Transaction(nil), uts.Transactions...)
+				}
+			}
+			for j := range txn.SiafundOutputs {
+				if crypto.Hash(txn.SiafundOutputID(uint64(j))) == oid {
+					return append([]types.Transaction(nil), uts.Transactions...)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Unsubscribe removes a subscriber.
+func (tp *TransactionPool) Unsubscribe(subscriber modules.TransactionPoolSubscriber) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for i, s := range tp.subscribers {
+		if s == subscriber {
+			tp.subscribers = append(tp.subscribers[:i], tp.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Enforce that TransactionPool satisfies the modules.TransactionPool interface.
+var _ modules.TransactionPool = (*TransactionPool)(nil)
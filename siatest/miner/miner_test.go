@@ -3,9 +3,9 @@ package miner
 import (
 	"testing"
 
-	"go.sia.tech/siad/siatest"
-
 	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/siatest"
+	"go.sia.tech/siad/siatest/memory"
 )
 
 // TestMinerEmptyBlock tests if a miner can mine and submit an empty block.
@@ -14,11 +14,20 @@ func TestMinerEmptyBlock(t *testing.T) {
 		t.SkipNow()
 	}
 	t.Parallel()
-	// Create a miner for testing.
-	m, err := siatest.NewNode(node.AllModules(minerTestDir(t.Name())))
+	// Create a miner for testing on top of the in-memory modules.
+	params, err := memory.NodeParams(node.AllModules(minerTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := siatest.NewNode(params)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
 	// Get the current blockheight.
 	bh, err := m.BlockHeight()
 	if err != nil {
@@ -596,6 +596,159 @@ func TestRemoteBackup(t *testing.T) {
 	}
 }
 
+// TestBrowseRemoteBackup tests listing the files of a remote backup and
+// restoring individual files from it.
+func TestBrowseRemoteBackup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   5,
+		Miners:  1,
+		Renters: 1,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload two files into a subdir.
+	r := tg.Renters()[0]
+	subDir, err := r.FilesDir().CreateDir("subDir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataPieces := uint64(2)
+	parityPieces := uint64(1)
+	var lfs []*siatest.LocalFile
+	var rfs []*siatest.RemoteFile
+	for i := 0; i < 2; i++ {
+		lf, err := subDir.NewFile(100 + siatest.Fuzz())
+		if err != nil {
+			t.Fatal(err)
+		}
+		rf, err := r.UploadBlocking(lf, dataPieces, parityPieces, false)
+		if err != nil {
+			t.Fatal("Failed to upload a file for testing: ", err)
+		}
+		lfs = append(lfs, lf)
+		rfs = append(rfs, rf)
+	}
+
+	// Create a snapshot and wait for it to be uploaded.
+	if err := r.RenterCreateBackupPost("foo"); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(60, time.Second, func() error {
+		ubs, _ := r.RenterBackups()
+		if len(ubs.Backups) != 1 {
+			return fmt.Errorf("expected one backup for %v", len(ubs.Backups))
+		}
+		if ubs.Backups[0].UploadProgress != 100 {
+			return fmt.Errorf("backup not uploaded, upload progress is %v", ubs.Backups[0].UploadProgress)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The backup should contain both files.
+	rbcg, err := r.RenterBackupContentsGet("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbcg.Files) != len(rfs) {
+		t.Fatalf("expected %v files, got %v", len(rfs), len(rbcg.Files))
+	}
+	for i, rf := range rfs {
+		found := false
+		for _, f := range rbcg.Files {
+			if !f.SiaPath.Equals(rf.SiaPath()) {
+				continue
+			}
+			found = true
+			if f.Name != rf.SiaPath().Name() || f.Filesize != uint64(lfs[i].Size()) {
+				t.Fatal("unexpected file in backup", f)
+			}
+		}
+		if !found {
+			t.Fatal("file missing from backup", rf.SiaPath())
+		}
+	}
+	if _, err := r.RenterBackupContentsGet("bar"); err == nil {
+		t.Fatal("listing an unknown backup should fail")
+	}
+
+	// Delete the files and restore only the first one.
+	for _, rf := range rfs {
+		if err := r.RenterFileDeletePost(rf.SiaPath()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.RenterRecoverBackupFilesPost("foo", []modules.SiaPath{rfs[0].SiaPath()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterFileGet(rfs[0].SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterFileGet(rfs[1].SiaPath()); err == nil {
+		t.Fatal("second file shouldn't be restored")
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, _, err = r.DownloadToDisk(rfs[0], false)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restoring a file which isn't part of the backup fails.
+	if err := r.RenterRecoverBackupFilesPost("foo", []modules.SiaPath{modules.RandomSiaPath()}); err == nil {
+		t.Fatal("restoring an unknown file should fail")
+	}
+
+	// Restore the whole directory. The first file already exists and is
+	// restored with a suffix.
+	dir, err := rfs[0].SiaPath().Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterRecoverBackupFilesPost("foo", []modules.SiaPath{dir}); err != nil {
+		t.Fatal(err)
+	}
+	rd, err := r.RenterDirGet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd.Files) != len(rfs)+1 {
+		t.Fatalf("expected %v files, got %v", len(rfs)+1, len(rd.Files))
+	}
+	suffixed, err := modules.NewSiaPath(rfs[0].SiaPath().String() + "_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterFileGet(suffixed); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, _, err = r.DownloadToDisk(rfs[1], false)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestBackupRenew tests that a backup can be restored after a set of contract
 // has been renewed.
 func TestBackupRenew(t *testing.T) {
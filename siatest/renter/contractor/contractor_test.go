@@ -138,8 +138,8 @@ func testContractorIncompleteMaintenanceAlert(t *testing.T, tg *siatest.TestGrou
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(dag.Alerts) != 12 {
-		t.Fatal("number of alerts is not 12")
+	if len(dag.Alerts) != 15 {
+		t.Fatal("number of alerts is not 15")
 	}
 	// Save the seed for later.
 	wsg, err := r.WalletSeedsGet()
@@ -2259,7 +2259,7 @@ func TestFailedContractRenewalAlert(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(dag.Alerts) != 12 {
+	if len(dag.Alerts) != 15 {
 		t.Fatal("number of alerts is not 0", len(dag.Alerts))
 	}
 
@@ -2878,3 +2878,31 @@ func TestLargeRenewWindow(t *testing.T) {
 		t.Errorf("Expected NextPeriod to be %v but was %v", originalNextPeriod+allowance.Period, rg.NextPeriod)
 	}
 }
+
+// TestSetAllowanceUnsynced checks that setting an allowance before the
+// consensus set is synced fails with ErrorCodeNotSynced.
+func TestSetAllowanceUnsynced(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a renter with an unsynced consensus dependency.
+	renterParams := node.Renter(contractorTestDir(t.Name()))
+	renterParams.SkipSetAllowance = true
+	renterParams.ContractorDeps = &dependencies.DependencyUnsyncedConsensus{}
+	renter, err := siatest.NewCleanNode(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := renter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	err = renter.RenterPostAllowance(siatest.DefaultAllowance)
+	if code := api.ErrorCodeOf(err); code != api.ErrorCodeNotSynced {
+		t.Fatalf("expected error code %v but got %v (%v)", api.ErrorCodeNotSynced, code, err)
+	}
+}
@@ -1,6 +1,9 @@
 package renter
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -87,11 +90,21 @@ func TestRenterTwo(t *testing.T) {
 	// Specify subtests to run
 	subTests := []siatest.SubTest{
 		{Name: "TestReceivedFieldEqualsFileSize", Test: testReceivedFieldEqualsFileSize},
+		{Name: "TestBenchmarkHosts", Test: testBenchmarkHosts},
+		{Name: "TestContractSectorRoots", Test: testContractSectorRoots},
+		{Name: "TestDelegatedRead", Test: testDelegatedRead},
 		{Name: "TestRemoteRepair", Test: testRemoteRepair},
 		{Name: "TestSingleFileGet", Test: testSingleFileGet},
+		{Name: "TestFileLayout", Test: testFileLayout},
 		{Name: "TestSiaFileTimestamps", Test: testSiafileTimestamps},
 		{Name: "TestZeroByteFile", Test: testZeroByteFile},
 		{Name: "TestUploadWithAndWithoutForceParameter", Test: testUploadWithAndWithoutForceParameter},
+		{Name: "TestAliases", Test: testAliases},
+		{Name: "TestDownloadManifest", Test: testDownloadManifest},
+		{Name: "TestUploadChecksum", Test: testUploadChecksum},
+		{Name: "TestRestoreMode", Test: testRestoreMode},
+		{Name: "TestRenterReadOnly", Test: testRenterReadOnly},
+		{Name: "TestContractPrices", Test: testContractPrices}, // Runs last because it changes the prices of a host
 	}
 
 	// Run tests
@@ -100,6 +113,637 @@ func TestRenterTwo(t *testing.T) {
 	}
 }
 
+// testRestoreMode tests starting and stopping the renter's restore mode and
+// downloading a file while in restore mode.
+func testRestoreMode(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter.
+	r := tg.Renters()[0]
+
+	// Upload a file.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, rf, err := r.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Starting restore mode for a dir that doesn't exist should fail.
+	dir, err := modules.NewSiaPath("restoremode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterRestoreModeStartPost(dir); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Start restore mode for an existing dir.
+	if err := r.RenterDirCreatePost(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterRestoreModeStartPost(dir); err != nil {
+		t.Fatal(err)
+	}
+	rm, err := r.RenterRestoreModeGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rm.Active || !rm.SiaPath.Equals(dir) {
+		t.Fatal("unexpected restore mode status", rm)
+	}
+
+	// Switch to the root dir and download the file.
+	if err := r.RenterRestoreModeStartPost(modules.RootSiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.DownloadToDisk(rf, false); err != nil {
+		t.Fatal(err)
+	}
+	rm, err = r.RenterRestoreModeGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rm.Active || !rm.SiaPath.IsRoot() || rm.ActiveDownloads != 0 {
+		t.Fatal("unexpected restore mode status", rm)
+	}
+
+	// Stop restore mode. Stopping it a second time should fail.
+	if err := r.RenterRestoreModeStopPost(); err != nil {
+		t.Fatal(err)
+	}
+	rm, err = r.RenterRestoreModeGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rm.Active {
+		t.Fatal("restore mode should be inactive")
+	}
+	if err := r.RenterRestoreModeStopPost(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// testContractPrices tests that the renter flags contracts with hosts whose
+// prices are significantly higher than the market rate.
+func testContractPrices(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// checkReport checks that the report contains all active contracts and
+	// that only the contract with the provided host is overpriced.
+	checkReport := func(overpricedHost types.SiaPublicKey) error {
+		rcpg, err := r.RenterContractPricesGet()
+		if err != nil {
+			return err
+		}
+		report := rcpg.Report
+		if report.NumHosts != len(tg.Hosts()) {
+			return fmt.Errorf("expected median of %v hosts but got %v", len(tg.Hosts()), report.NumHosts)
+		}
+		if len(report.Contracts) != len(tg.Hosts()) {
+			return fmt.Errorf("expected %v contracts but got %v", len(tg.Hosts()), len(report.Contracts))
+		}
+		for _, c := range report.Contracts {
+			if c.Overpriced != c.HostPublicKey.Equals(overpricedHost) {
+				return fmt.Errorf("contract with host %v has ratio %v", c.HostPublicKey, c.CostRatio)
+			}
+		}
+		return nil
+	}
+	// checkAlert checks whether the alert for overpriced contracts is
+	// registered.
+	checkAlert := func(registered bool) error {
+		dag, err := r.DaemonAlertsGet()
+		if err != nil {
+			return err
+		}
+		var found bool
+		for _, alert := range dag.Alerts {
+			found = found || alert.Module == "renter" && strings.Contains(alert.Msg, renter.AlertMSGContractsOverpriced)
+		}
+		if found != registered {
+			return fmt.Errorf("expected alert to be registered %v", registered)
+		}
+		return nil
+	}
+
+	// All hosts charge the same prices.
+	err := build.Retry(100, 100*time.Millisecond, func() error {
+		return errors.Compose(checkReport(types.SiaPublicKey{}), checkAlert(false))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Triple the prices of a host.
+	h := tg.Hosts()[0]
+	hg, err := h.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	is, es := hg.InternalSettings, hg.ExternalSettings
+	setPrices := func(factor uint64) {
+		contractPrice := is.MinContractPrice
+		if factor > 1 {
+			// The host's contract price might be higher than its minimum.
+			contractPrice = es.ContractPrice.Mul64(factor)
+		}
+		err = errors.Compose(
+			h.HostModifySettingPost(client.HostParamMinContractPrice, contractPrice),
+			h.HostModifySettingPost(client.HostParamMinStoragePrice, is.MinStoragePrice.Mul64(factor)),
+			h.HostModifySettingPost(client.HostParamMinUploadBandwidthPrice, is.MinUploadBandwidthPrice.Mul64(factor)),
+			h.HostModifySettingPost(client.HostParamMinDownloadBandwidthPrice, is.MinDownloadBandwidthPrice.Mul64(factor)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	setPrices(3)
+	defer setPrices(1)
+	hpk, err := h.HostPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The contract with the host should be flagged once the renter learns
+	// about the new prices.
+	m := tg.Miners()[0]
+	err = build.Retry(100, 500*time.Millisecond, func() error {
+		if err := m.MineBlock(); err != nil {
+			return err
+		}
+		return errors.Compose(checkReport(hpk), checkAlert(true))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRenterReadOnly tests that the renter rejects modifications while in
+// read-only mode but still serves downloads.
+func testRenterReadOnly(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter.
+	r := tg.Renters()[0]
+
+	// Upload a file.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, rf, err := r.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Enable read-only mode.
+	if err := r.RenterReadOnlyPost(true); err != nil {
+		t.Fatal(err)
+	}
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rg.Settings.ReadOnly {
+		t.Fatal("read-only mode wasn't enabled")
+	}
+
+	// Modifications should be rejected.
+	if _, _, err := r.UploadNewFile(100+siatest.Fuzz(), dataPieces, parityPieces, false); api.ErrorCodeOf(err) != api.ErrorCodeRenterReadOnly {
+		t.Fatal("expected read-only error for upload", err)
+	}
+	if err := r.RenterFileDeletePost(rf.SiaPath()); api.ErrorCodeOf(err) != api.ErrorCodeRenterReadOnly {
+		t.Fatal("expected read-only error for delete", err)
+	}
+	if err := r.RenterPostAllowance(modules.DefaultAllowance); api.ErrorCodeOf(err) != api.ErrorCodeRenterReadOnly {
+		t.Fatal("expected read-only error for allowance change", err)
+	}
+
+	// Downloads and streams should still work.
+	if _, _, err := r.DownloadByStream(rf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Stream(rf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Disable read-only mode and delete the file.
+	if err := r.RenterReadOnlyPost(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterFileDeletePost(rf.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testDownloadManifest tests downloading a file with a manifest of the
+// downloaded data's hashes.
+func testDownloadManifest(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter.
+	r := tg.Renters()[0]
+
+	// Upload a file which spans multiple chunks.
+	lf, rf, err := r.UploadNewFileBlocking(int(3*modules.SectorSize)+siatest.Fuzz(), 2, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := lf.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Download it with a manifest.
+	dest := filepath.Join(r.DownloadDir().Path(), persist.RandomSuffix())
+	uid, err := r.RenterDownloadManifestGet(rf.SiaPath(), dest, 0, uint64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("downloaded data doesn't match")
+	}
+
+	// Check the manifest stored next to the file.
+	b, err := ioutil.ReadFile(dest + ".manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest modules.DownloadManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Chunks) != 2 {
+		t.Fatal("expected 2 chunks but got", len(manifest.Chunks))
+	}
+	var hashes []byte
+	var offset uint64
+	for i, chunk := range manifest.Chunks {
+		if chunk.Index != uint64(i) || chunk.Offset != offset {
+			t.Fatal("unexpected chunk", chunk)
+		}
+		if chunk.Hash != crypto.HashBytes(data[chunk.Offset:chunk.Offset+chunk.Length]) {
+			t.Fatal("wrong hash for chunk", i)
+		}
+		hashes = append(hashes, chunk.Hash[:]...)
+		offset += chunk.Length
+	}
+	if offset != uint64(len(data)) || manifest.Hash != crypto.HashBytes(hashes) {
+		t.Fatal("manifest doesn't cover the file", manifest)
+	}
+
+	// The manifest is also part of the download info.
+	di, err := r.RenterDownloadInfoGet(uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if di.Manifest == nil || !reflect.DeepEqual(*di.Manifest, manifest) {
+		t.Fatal("unexpected manifest in download info", di.Manifest)
+	}
+
+	// Downloads without a manifest don't have one.
+	uid, _, err = r.DownloadToDisk(rf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	di, err = r.RenterDownloadInfoGet(uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if di.Manifest != nil {
+		t.Fatal("download shouldn't have a manifest")
+	}
+}
+
+// testUploadChecksum tests computing the checksum of a streamed upload and
+// verifying a download against it.
+func testUploadChecksum(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter.
+	r := tg.Renters()[0]
+
+	// Upload a file which spans multiple chunks with a checksum.
+	data := fastrand.Bytes(int(3*modules.SectorSize) + siatest.Fuzz() + 2)
+	siaPath, err := modules.NewSiaPath(persist.RandomSuffix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadStreamChecksumPost(bytes.NewReader(data), siaPath, 2, 1, false, modules.ChecksumSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum := sha256.Sum256(data)
+	if rf.File.ChecksumAlgorithm != modules.ChecksumSHA256 || rf.File.Checksum != hex.EncodeToString(checksum[:]) {
+		t.Fatal("unexpected checksum", rf.File.ChecksumAlgorithm, rf.File.Checksum)
+	}
+
+	// Download and verify it.
+	dest := filepath.Join(r.DownloadDir().Path(), persist.RandomSuffix())
+	if _, err := r.RenterDownloadVerifiedGet(siaPath, dest, false); err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("downloaded data doesn't match")
+	}
+
+	// Files uploaded without a checksum can't be verified.
+	_, rf2, err := r.UploadNewFileBlocking(100+siatest.Fuzz(), 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterDownloadVerifiedGet(rf2.SiaPath(), dest, false); err == nil || !strings.Contains(err.Error(), "no checksum") {
+		t.Fatal("expected download without checksum to be rejected, got", err)
+	}
+}
+
+// testAliases tests accessing files through aliases using the /renter/alias
+// endpoints.
+func testAliases(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter.
+	r := tg.Renters()[0]
+
+	// Upload a file into a dir.
+	lf, rf, err := r.UploadNewFileBlocking(100+siatest.Fuzz(), 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := modules.RandomSiaPath()
+	filePath, err := dir.Join(rf.SiaPath().Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterRenamePost(rf.SiaPath(), filePath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create an alias for the dir and the file.
+	dirAlias := modules.RandomSiaPath()
+	fileAlias := modules.RandomSiaPath()
+	if err := r.RenterAliasCreatePost(dirAlias, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterAliasCreatePost(fileAlias, filePath); err != nil {
+		t.Fatal(err)
+	}
+	rag, err := r.RenterAliasGet(fileAlias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rag.Alias.SiaPath.Equals(fileAlias) || !rag.Alias.Target.Equals(filePath) || !rag.Alias.Resolved.Equals(filePath) {
+		t.Fatal("wrong alias", rag.Alias)
+	}
+
+	// Download and stream the file through both aliases.
+	aliasedFilePath, err := dirAlias.Join(rf.SiaPath().Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sp := range []modules.SiaPath{fileAlias, aliasedFilePath} {
+		_, data, err := r.RenterDownloadHTTPResponseGet(sp, 0, 0, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := lf.Equal(data); err != nil {
+			t.Fatal(err)
+		}
+		data, err = r.RenterStreamGet(sp, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := lf.Equal(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Listing the dir alias should list the dir's contents under the alias.
+	rd, err := r.RenterDirGet(dirAlias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd.Directories) != 1 || !rd.Directories[0].SiaPath.Equals(dirAlias) {
+		t.Fatal("wrong directories", rd.Directories)
+	}
+	if len(rd.Files) != 1 || !rd.Files[0].SiaPath.Equals(aliasedFilePath) {
+		t.Fatal("wrong files", rd.Files)
+	}
+
+	// Listing the root should contain the aliases.
+	rd, err = r.RenterDirGet(modules.RootSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var foundDir, foundFile bool
+	for _, di := range rd.Directories {
+		foundDir = foundDir || di.SiaPath.Equals(dirAlias)
+	}
+	for _, fi := range rd.Files {
+		foundFile = foundFile || fi.SiaPath.Equals(fileAlias)
+	}
+	if !foundDir || !foundFile {
+		t.Fatal("aliases missing from listing", foundDir, foundFile)
+	}
+
+	// Delete the file alias.
+	if err := r.RenterAliasDeletePost(fileAlias); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterAliasGet(fileAlias); err == nil {
+		t.Fatal("alias should be gone")
+	}
+	if _, err := r.RenterStreamGet(fileAlias, true, false); err == nil {
+		t.Fatal("streaming a deleted alias should fail")
+	}
+}
+
+// testBenchmarkHosts tests benchmarking the renter's hosts using the
+// /renter/benchmark endpoint.
+func testBenchmarkHosts(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter.
+	r := tg.Renters()[0]
+
+	// Upload a file to all hosts to make sure that there is data to download.
+	numHosts := len(tg.Hosts())
+	_, _, err := r.UploadNewFileBlocking(100+siatest.Fuzz(), 1, uint64(numHosts-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Benchmark all hosts.
+	rbp, err := r.RenterBenchmarkPost(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbp.Hosts) != numHosts {
+		t.Fatalf("expected %v results but got %v", numHosts, len(rbp.Hosts))
+	}
+	for i, hb := range rbp.Hosts {
+		if hb.Error != "" {
+			t.Fatal("benchmark failed", hb.Error)
+		}
+		if hb.Latency == 0 || hb.TTFB == 0 || hb.Throughput == 0 || hb.DownloadSize == 0 {
+			t.Fatal("benchmark is missing results", hb)
+		}
+		if i > 0 && hb.Throughput > rbp.Hosts[i-1].Throughput {
+			t.Fatal("results are not sorted by throughput")
+		}
+	}
+
+	// Benchmark a single host.
+	host := rbp.Hosts[0].HostPubKey
+	rbp, err = r.RenterBenchmarkPost([]types.SiaPublicKey{host})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbp.Hosts) != 1 || !rbp.Hosts[0].HostPubKey.Equals(host) {
+		t.Fatal("wrong result", rbp.Hosts)
+	}
+
+	// Benchmarking an unknown host fails.
+	_, err = r.RenterBenchmarkPost([]types.SiaPublicKey{{}})
+	if err == nil {
+		t.Fatal("expected benchmarking an unknown host to fail")
+	}
+}
+
+// testContractSectorRoots tests fetching the sector roots of the renter's
+// contracts from their hosts using the /renter/contract/sectorroots endpoint.
+func testContractSectorRoots(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter.
+	r := tg.Renters()[0]
+
+	// Upload a file to make sure that the contracts contain sectors.
+	_, _, err := r.UploadNewFileBlocking(int(2*modules.SectorSize)+siatest.Fuzz(), 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The roots of every contract match its size.
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.ActiveContracts) == 0 {
+		t.Fatal("renter has no active contracts")
+	}
+	for _, c := range rc.ActiveContracts {
+		rcsr, err := r.RenterContractSectorRootsGet(c.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rcsr.Revision.ParentID != c.ID {
+			t.Fatal("wrong revision", rcsr.Revision.ParentID, c.ID)
+		}
+		if c.Size == 0 || uint64(len(rcsr.SectorRoots))*modules.SectorSize != c.Size {
+			t.Fatalf("expected %v bytes of sectors but got %v roots", c.Size, len(rcsr.SectorRoots))
+		}
+	}
+
+	// Fetching the roots of an unknown contract fails.
+	_, err = r.RenterContractSectorRootsGet(types.FileContractID{})
+	if err == nil {
+		t.Fatal("expected fetching the roots of an unknown contract to fail")
+	}
+}
+
+// testDelegatedRead tests authorizing a second renter to download the sectors
+// of a contract and downloading them as the delegate.
+func testDelegatedRead(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the renter and upload a file to make sure that the contracts
+	// contain sectors.
+	r := tg.Renters()[0]
+	_, _, err := r.UploadNewFileBlocking(int(modules.SectorSize), 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contract api.RenterContract
+	for _, c := range rc.ActiveContracts {
+		if c.Size > 0 {
+			contract = c
+			break
+		}
+	}
+	if contract.Size == 0 {
+		t.Fatal("renter has no contract with sectors")
+	}
+	rcsr, err := r.RenterContractSectorRootsGet(contract.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := rcsr.SectorRoots[0]
+
+	// Add a second renter which acts as the delegate.
+	nodes, err := tg.AddNodes(node.Renter(filepath.Join(renterTestDir(t.Name()), "delegate")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	delegate := nodes[0]
+	defer func() {
+		if err := tg.RemoveNode(delegate); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	rdkg, err := delegate.RenterDelegateKeyGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token issued to a different key can't be used.
+	rcdp, err := r.RenterContractDelegatePost(contract.ID, contract.HostPublicKey, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = delegate.RenterDelegatedReadPost(rcdp.DelegatedReadAuthorization, root)
+	if err == nil {
+		t.Fatal("expected download with a token of a different delegate to fail")
+	}
+
+	// Authorize the delegate and download the sector.
+	rcdp, err = r.RenterContractDelegatePost(contract.ID, rdkg.DelegateKey, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rcdp.HostPublicKey.Equals(contract.HostPublicKey) || rcdp.Token.FileContractID != contract.ID {
+		t.Fatal("wrong authorization", rcdp)
+	}
+	var sector []byte
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		sector, err = delegate.RenterDelegatedReadPost(rcdp.DelegatedReadAuthorization, root)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.MerkleRoot(sector) != root {
+		t.Fatal("wrong sector data")
+	}
+
+	// The token expires.
+	for i := 0; i < 3; i++ {
+		if err := tg.Miners()[0].MineBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, err = delegate.RenterDelegatedReadPost(rcdp.DelegatedReadAuthorization, root)
+		if err == nil || !strings.Contains(err.Error(), modules.ErrDelegatedReadTokenExpired.Error()) {
+			return fmt.Errorf("expected ErrDelegatedReadTokenExpired, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // testSiafileTimestamps tests if timestamps are set correctly when creating,
 // uploading, downloading and modifying a file.
 func testSiafileTimestamps(t *testing.T, tg *siatest.TestGroup) {
@@ -244,6 +888,10 @@ func TestRenterThree(t *testing.T) {
 	// Specify subtests to run
 	subTests := []siatest.SubTest{
 		{Name: "TestAllowanceDefaultSet", Test: testAllowanceDefaultSet},
+		{Name: "TestAllowanceProfiles", Test: testAllowanceProfiles},
+		{Name: "TestExportImportSiaFile", Test: testExportImportSiaFile},
+		{Name: "TestConvertDir", Test: testConvertDir},
+		{Name: "TestAccountSpendingCaps", Test: testAccountSpendingCaps},
 		{Name: "TestFileAvailableAndRecoverable", Test: testFileAvailableAndRecoverable},
 		{Name: "TestSetFileStuck", Test: testSetFileStuck},
 		{Name: "TestCancelAsyncDownload", Test: testCancelAsyncDownload},
@@ -288,26 +936,383 @@ func TestRenterFour(t *testing.T) {
 	}
 }
 
-// testAllowanceDefaultSet tests that a renter's allowance is correctly set to
-// the defaults after creating it and therefore confirming that the API
-// endpoint and siatest package both work.
-func testAllowanceDefaultSet(t *testing.T, tg *siatest.TestGroup) {
+// testAllowanceDefaultSet tests that a renter's allowance is correctly set to
+// the defaults after creating it and therefore confirming that the API
+// endpoint and siatest package both work.
+func testAllowanceDefaultSet(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	// Get allowance.
+	r := tg.Renters()[0]
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make sure that the allowance was set correctly.
+	if !reflect.DeepEqual(rg.Settings.Allowance, siatest.DefaultAllowance) {
+		expected, _ := json.Marshal(siatest.DefaultAllowance)
+		was, _ := json.Marshal(rg.Settings.Allowance)
+		t.Log("Expected", string(expected))
+		t.Log("Was", string(was))
+		t.Fatal("Renter's allowance doesn't match siatest.DefaultAllowance")
+	}
+}
+
+// testAllowanceProfiles tests creating, previewing, activating and deleting
+// allowance profiles.
+func testAllowanceProfiles(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	r := tg.Renters()[0]
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := rg.Settings.Allowance
+
+	// Create a profile for the current allowance and a partial profile which
+	// only changes the funds.
+	if err := r.RenterAllowanceProfilePost("original", original); err != nil {
+		t.Fatal(err)
+	}
+	funds := original.Funds.Mul64(2)
+	if err := r.RenterAllowanceProfilePartialPost("funds").WithFunds(funds).Send(); err != nil {
+		t.Fatal(err)
+	}
+	rapg, err := r.RenterAllowanceProfilesGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rapg.Profiles) != 2 || rapg.Profiles[0].Name != "funds" || rapg.Profiles[1].Name != "original" {
+		t.Fatal("unexpected profiles", rapg.Profiles)
+	}
+
+	// The diff should only contain the funds and not change any contracts.
+	rapdg, err := r.RenterAllowanceProfileDiffGet("funds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := rapdg.Diff
+	if !reflect.DeepEqual(diff.ChangedFields, []string{"funds"}) {
+		t.Fatal("unexpected changed fields", diff.ChangedFields)
+	}
+	if diff.ContractsToForm != 0 || diff.ContractsToDisable != 0 {
+		t.Fatal("unexpected contract changes", diff)
+	}
+	if _, err := r.RenterAllowanceProfileDiffGet("missing"); err == nil {
+		t.Fatal("expected diff of missing profile to fail")
+	}
+
+	// Activate the profile.
+	if err := r.RenterAllowanceProfileActivatePost("funds"); err != nil {
+		t.Fatal(err)
+	}
+	rg, err = r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rg.Settings.Allowance.Funds.Equals(funds) {
+		t.Fatal("allowance wasn't changed", rg.Settings.Allowance.Funds, funds)
+	}
+	rapg, err = r.RenterAllowanceProfilesGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rapg.Profiles[0].Active || rapg.Profiles[1].Active {
+		t.Fatal("expected funds profile to be active", rapg.Profiles)
+	}
+
+	// Switch back to the original allowance and delete the profiles.
+	if err := r.RenterAllowanceProfileActivatePost("original"); err != nil {
+		t.Fatal(err)
+	}
+	rg, err = r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rg.Settings.Allowance.Funds.Equals(original.Funds) {
+		t.Fatal("allowance wasn't restored", rg.Settings.Allowance.Funds, original.Funds)
+	}
+	for _, name := range []string{"funds", "original"} {
+		if err := r.RenterAllowanceProfileDeletePost(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rapg, err = r.RenterAllowanceProfilesGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rapg.Profiles) != 0 {
+		t.Fatal("profiles weren't deleted", rapg.Profiles)
+	}
+}
+
+// testExportImportSiaFile tests exporting a siafile and importing it again at
+// a different siapath without uploading the file again.
+func testExportImportSiaFile(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	r := tg.Renters()[0]
+
+	// Upload a file.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, rf, err := r.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := r.RenterStreamGet(rf.SiaPath(), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Export the siafile. The destination needs to be absolute and existing
+	// files are not overwritten.
+	dir := renterTestDir(t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "exported.sia")
+	if err := r.RenterExportPost(rf.SiaPath(), "exported.sia"); err == nil {
+		t.Fatal("export to a relative path should fail")
+	}
+	if err := r.RenterExportPost(rf.SiaPath(), dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterExportPost(rf.SiaPath(), dst); err == nil {
+		t.Fatal("export shouldn't overwrite an existing file")
+	}
+
+	// Importing at an existing siapath fails.
+	if _, err := r.RenterImportPost(dst, rf.SiaPath(), true); err == nil {
+		t.Fatal("import at an existing siapath should fail")
+	}
+
+	// Import the siafile at a new siapath. All pieces should be available.
+	siaPath, err := modules.NewSiaPath("imported-" + rf.SiaPath().Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsip, err := r.RenterImportPost(dst, siaPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := rsip.Import
+	if !imp.SiaPath.Equals(siaPath) {
+		t.Fatalf("expected siapath %v, got %v", siaPath, imp.SiaPath)
+	}
+	if imp.AvailablePieces != dataPieces+parityPieces || imp.UnavailablePieces != 0 {
+		t.Fatalf("unexpected pieces: %v available, %v unavailable", imp.AvailablePieces, imp.UnavailablePieces)
+	}
+	if !imp.Recoverable || imp.RepairQueued {
+		t.Fatal("unexpected import", imp)
+	}
+
+	// The imported file can be downloaded without a local copy.
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.LocalPath != "" {
+		t.Fatal("imported file shouldn't have a local path", rfg.File.LocalPath)
+	}
+	importedData, err := r.RenterStreamGet(siaPath, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, importedData) {
+		t.Fatal("imported file has different data")
+	}
+}
+
+// testAccountSpendingCaps tests that the renter stops paying a host from its
+// ephemeral account once the host's spending cap is reached.
+func testAccountSpendingCaps(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Check the defaults.
+	ac, err := r.RenterAccountCapsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ac.Default.IsZero() || len(ac.Hosts) != 0 || ac.Window == 0 {
+		t.Fatal("unexpected default caps", ac.AccountSpendingCaps)
+	}
+
+	// Limit the spending of a single host to a single hasting.
+	hpk, err := tg.Hosts()[0].HostPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterAccountCapsHostPost(hpk, types.NewCurrency64(1)); err != nil {
+		t.Fatal(err)
+	}
+	ac, err = r.RenterAccountCapsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit, exists := ac.Hosts[hpk.String()]; !exists || !limit.Equals64(1) {
+		t.Fatal("host cap wasn't set", ac.AccountSpendingCaps)
+	}
+
+	// Upload and download a file. The download should succeed using the
+	// other hosts while the capped host's account reaches its cap.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, rf, err := r.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if _, _, err := r.DownloadByStream(rf); err != nil {
+			return err
+		}
+		dag, err := r.DaemonAlertsGet()
+		if err != nil {
+			return err
+		}
+		for _, alert := range dag.Alerts {
+			if alert.Msg == renter.AlertMSGAccountSpendingCap && strings.Contains(alert.Cause, hpk.String()) {
+				return nil
+			}
+		}
+		return errors.New("spending cap alert wasn't registered")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The worker should report the cap.
+	rwg, err := r.RenterWorkersGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range rwg.Workers {
+		if w.HostPubKey.Equals(hpk) && !w.AccountStatus.SpendingCap.Equals64(1) {
+			t.Fatal("worker doesn't report the cap", w.AccountStatus)
+		}
+	}
+
+	// Remove the cap again. The alert should be unregistered once the worker
+	// pays the host again.
+	if err := r.RenterAccountCapsHostRemovePost(hpk); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if _, _, err := r.DownloadByStream(rf); err != nil {
+			return err
+		}
+		dag, err := r.DaemonAlertsGet()
+		if err != nil {
+			return err
+		}
+		for _, alert := range dag.Alerts {
+			if alert.Msg == renter.AlertMSGAccountSpendingCap {
+				return errors.New("spending cap alert is still registered")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testConvertDir tests converting the files of a directory to new erasure
+// coding settings.
+func testConvertDir(t *testing.T, tg *siatest.TestGroup) {
 	if len(tg.Renters()) == 0 {
 		t.Fatal("Test requires at least 1 renter")
 	}
-	// Get allowance.
 	r := tg.Renters()[0]
-	rg, err := r.RenterGet()
+
+	// Upload two files into a new directory. The second file already uses
+	// the new settings.
+	dir := modules.RandomSiaPath()
+	oldDataPieces, oldParityPieces := uint64(1), uint64(len(tg.Hosts())-1)
+	newDataPieces, newParityPieces := uint64(2), uint64(len(tg.Hosts())-2)
+	var files []*siatest.RemoteFile
+	var data [][]byte
+	for _, ec := range [][2]uint64{{oldDataPieces, oldParityPieces}, {newDataPieces, newParityPieces}} {
+		lf, err := r.FilesDir().NewFile(100 + siatest.Fuzz())
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := dir.Join(lf.FileName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		rf, err := r.Upload(lf, siaPath, ec[0], ec[1], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.WaitForUploadHealth(rf); err != nil {
+			t.Fatal(err)
+		}
+		d, err := r.RenterStreamGet(rf.SiaPath(), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, rf)
+		data = append(data, d)
+	}
+
+	// Invalid settings are rejected.
+	if err := r.RenterConvertPost(dir, 0, 0, 0); err == nil {
+		t.Fatal("conversion with invalid settings should fail")
+	}
+
+	// Convert the directory and wait for the conversion to complete.
+	if err := r.RenterConvertPost(dir, newDataPieces, newParityPieces, 0); err != nil {
+		t.Fatal(err)
+	}
+	var conversion modules.DirConversion
+	err := build.Retry(100, 100*time.Millisecond, func() error {
+		rdcg, err := r.RenterConversionGet()
+		if err != nil {
+			return err
+		}
+		conversion = rdcg.Conversion
+		if !conversion.Completed {
+			return errors.New("conversion not completed")
+		}
+		return nil
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	// Make sure that the allowance was set correctly.
-	if !reflect.DeepEqual(rg.Settings.Allowance, siatest.DefaultAllowance) {
-		expected, _ := json.Marshal(siatest.DefaultAllowance)
-		was, _ := json.Marshal(rg.Settings.Allowance)
-		t.Log("Expected", string(expected))
-		t.Log("Was", string(was))
-		t.Fatal("Renter's allowance doesn't match siatest.DefaultAllowance")
+	if !conversion.SiaPath.Equals(dir) {
+		t.Fatalf("expected siapath %v, got %v", dir, conversion.SiaPath)
+	}
+	if conversion.FilesConverted != 1 || conversion.FilesSkipped != 1 || conversion.FilesFailed != 0 {
+		t.Fatal("unexpected conversion", conversion)
+	}
+
+	// The files use the new settings and still contain the same data.
+	for i, rf := range files {
+		rfg, err := r.RenterFileGet(rf.SiaPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := float64(newDataPieces+newParityPieces) / float64(newDataPieces)
+		if rfg.File.Redundancy > expected {
+			t.Fatalf("expected redundancy of at most %v, got %v", expected, rfg.File.Redundancy)
+		}
+		d, err := r.RenterStreamGet(rf.SiaPath(), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data[i], d) {
+			t.Fatal("converted file has different data")
+		}
+	}
+
+	// There is no conversion to cancel anymore.
+	if err := r.RenterConversionCancelPost(); err == nil {
+		t.Fatal("cancelling a completed conversion should fail")
 	}
 }
 
@@ -1342,6 +2347,68 @@ func testSingleFileGet(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testFileLayout tests that the file layout endpoint reports the hosts of a
+// fully uploaded file's pieces.
+func testFileLayout(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+	// Upload file, creating a piece for each host in the group
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	fileSize := int(2 * modules.SectorSize)
+	_, remoteFile, err := renter.UploadNewFileBlocking(fileSize, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal("Failed to upload a file for testing: ", err)
+	}
+
+	rfl, err := renter.RenterFileLayoutGet(remoteFile.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := rfl.Layout
+	if layout.SiaPath != remoteFile.SiaPath() {
+		t.Fatalf("expected siapath %v but got %v", remoteFile.SiaPath(), layout.SiaPath)
+	}
+	if layout.DataPieces != int(dataPieces) || layout.ParityPieces != int(parityPieces) {
+		t.Fatalf("unexpected erasure coding %v-of-%v", layout.DataPieces, layout.DataPieces+layout.ParityPieces)
+	}
+	if len(layout.Hosts) != len(tg.Hosts()) {
+		t.Fatalf("expected %v hosts but got %v", len(tg.Hosts()), len(layout.Hosts))
+	}
+	for _, host := range layout.Hosts {
+		if !host.Online || !host.GoodForRenew {
+			t.Fatal("expected host to be online and good for renew", host)
+		}
+	}
+	if len(layout.Chunks) != 2 {
+		t.Fatalf("expected 2 chunks but got %v", len(layout.Chunks))
+	}
+	for i, chunk := range layout.Chunks {
+		if chunk.Stuck || chunk.LastRepair.IsZero() {
+			t.Fatalf("chunk %v: unexpected stuck status %v or last repair %v", i, chunk.Stuck, chunk.LastRepair)
+		}
+		if len(chunk.Pieces) != int(dataPieces+parityPieces) {
+			t.Fatalf("chunk %v: expected %v pieces but got %v", i, dataPieces+parityPieces, len(chunk.Pieces))
+		}
+		for _, pieceSet := range chunk.Pieces {
+			if len(pieceSet) == 0 {
+				t.Fatalf("chunk %v: missing piece", i)
+			}
+			for _, piece := range pieceSet {
+				if piece.Host < 0 || piece.Host >= len(layout.Hosts) {
+					t.Fatalf("chunk %v: invalid host index %v", i, piece.Host)
+				}
+			}
+		}
+	}
+
+	// Requesting the layout of an unknown file should fail.
+	_, err = renter.RenterFileLayoutGet(modules.RandomSiaPath())
+	if err == nil {
+		t.Fatal("expected error for unknown file")
+	}
+}
+
 // testCancelAsyncDownload tests that cancelling an async download aborts the
 // download and sets the correct fields.
 func testCancelAsyncDownload(t *testing.T, tg *siatest.TestGroup) {
@@ -5746,3 +6813,252 @@ func TestRenterBubble(t *testing.T) {
 		}
 	}
 }
+
+// TestRenterHostMigration tests that the renter migrates the pieces stored on
+// a blocklisted host to a different host.
+func TestRenterHostMigration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with a spare host.
+	groupParams := siatest.GroupParams{
+		Hosts:  4,
+		Miners: 1,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group:", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	renterParams := node.Renter(filepath.Join(testDir, "renter"))
+	renterParams.Allowance = siatest.DefaultAllowance
+	renterParams.Allowance.Hosts = 3
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal("Failed to add renter:", err)
+	}
+	r := nodes[0]
+
+	// Upload a file with a piece on every host.
+	_, rf, err := r.UploadNewFileBlocking(100, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// There are no migrations yet.
+	rmg, err := r.RenterMigrationsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rmg.Migrations) != 0 {
+		t.Fatal("expected no migrations", rmg.Migrations)
+	}
+
+	// Blocklist one of the hosts.
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.ActiveContracts) == 0 {
+		t.Fatal("no active contracts")
+	}
+	pk := rc.ActiveContracts[0].HostPublicKey
+	if err := r.HostDbFilterModePost(modules.HostDBActivateBlacklist, []types.SiaPublicKey{pk}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The migration should eventually complete.
+	m := tg.Miners()[0]
+	err = build.Retry(100, 500*time.Millisecond, func() error {
+		if err := m.MineBlock(); err != nil {
+			return err
+		}
+		rmg, err := r.RenterMigrationsGet()
+		if err != nil {
+			return err
+		}
+		if len(rmg.Migrations) != 1 {
+			return fmt.Errorf("expected 1 migration but got %v", len(rmg.Migrations))
+		}
+		migration := rmg.Migrations[0]
+		if !migration.HostPubKey.Equals(pk) || migration.Reason == "" {
+			return fmt.Errorf("unexpected migration %v", migration)
+		}
+		if !migration.Completed {
+			return errors.New("migration isn't completed yet")
+		}
+		if len(migration.Files) != 1 || migration.Files[0].MigratedChunks != migration.Files[0].Chunks {
+			return fmt.Errorf("unexpected files %v", migration.Files)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should no longer depend on the blocklisted host.
+	fi, err := r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Redundancy < 3 {
+		t.Fatal("file should be fully redundant", fi.Redundancy)
+	}
+	if _, _, err := r.DownloadToDisk(rf, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRenterReplication tests replicating the metadata of a renter to a
+// standby and promoting the standby after the primary failed.
+func TestRenterReplication(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group:", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	primary := tg.Renters()[0]
+
+	// Upload a file to the primary.
+	_, rf, err := primary.UploadNewFileBlocking(100+siatest.Fuzz(), 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := primary.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a standby without an allowance.
+	renterParams := node.Renter(filepath.Join(testDir, "standby"))
+	renterParams.SkipSetAllowance = true
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	standby := nodes[0]
+	if err := standby.RenterReplicationStandbyPost(primary.Client.Address, primary.Client.Password); err != nil {
+		t.Fatal(err)
+	}
+	rg, err := standby.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rg.Settings.ReadOnly {
+		t.Fatal("standby isn't read-only")
+	}
+
+	// Wait for the standby to replicate the file and the contracts.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rrg, err := standby.RenterReplicationGet()
+		if err != nil {
+			return err
+		}
+		if rrg.LastError != "" {
+			return errors.New(rrg.LastError)
+		}
+		if rrg.LastSync.IsZero() || rrg.Files == 0 || rrg.Contracts != uint64(len(rc.ActiveContracts)) {
+			return fmt.Errorf("replication incomplete: %v", rrg)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The primary's password isn't persisted. After a restart the standby
+	// waits for it before it resumes the replication.
+	if err := standby.RestartNode(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rrg, err := standby.RenterReplicationGet()
+		if err != nil {
+			return err
+		}
+		if !rrg.Standby || !strings.Contains(rrg.LastError, "password") {
+			return fmt.Errorf("standby isn't waiting for the password: %v", rrg)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := standby.RenterReplicationStandbyPost(primary.Client.Address, primary.Client.Password); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rrg, err := standby.RenterReplicationGet()
+		if err != nil {
+			return err
+		}
+		if rrg.LastError != "" {
+			return errors.New(rrg.LastError)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Shut down the primary and promote the standby.
+	if err := tg.RemoveNode(primary); err != nil {
+		t.Fatal(err)
+	}
+	if err := standby.RenterReplicationPromotePost(); err != nil {
+		t.Fatal(err)
+	}
+	rrg, err := standby.RenterReplicationGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rrg.Standby {
+		t.Fatal("renter is still a standby after the promotion")
+	}
+	rg, err = standby.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rg.Settings.ReadOnly || !rg.Settings.Allowance.Active() {
+		t.Fatal("promoted renter didn't take over the primary's settings", rg.Settings)
+	}
+
+	// The promoted renter should have the contracts and be able to download
+	// the file.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		src, err := standby.RenterContractsGet()
+		if err != nil {
+			return err
+		}
+		if len(src.ActiveContracts) != len(rc.ActiveContracts) {
+			return fmt.Errorf("expected %v contracts but got %v", len(rc.ActiveContracts), len(src.ActiveContracts))
+		}
+		_, _, err = standby.DownloadByStream(rf)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -178,6 +178,8 @@ func TestRenterStream(t *testing.T) {
 
 	// Specify subtests to run
 	subTests := []siatest.SubTest{
+		{Name: "TestStreamBandwidth", Test: testStreamBandwidth},
+		{Name: "TestStreamCacheSettings", Test: testStreamCacheSettings},
 		{Name: "TestStreamLargeFile", Test: testStreamLargeFile},
 		{Name: "TestStreamRepair", Test: testStreamRepair},
 		{Name: "TestUploadStreaming", Test: testUploadStreaming},
@@ -190,6 +192,137 @@ func TestRenterStream(t *testing.T) {
 	}
 }
 
+// testStreamBandwidth tests that the bandwidth of uploads and streams is
+// metered and that streams pause once they reached their bandwidth cap.
+func testStreamBandwidth(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+
+	// Upload and stream a file.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, rf, err := renter.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renter.Stream(rf); err != nil {
+		t.Fatal(err)
+	}
+	rb, err := renter.RenterBandwidthGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := make(map[modules.RenterBandwidthCategory]modules.RenterBandwidthUsage)
+	for _, u := range rb.Categories {
+		usage[u.Category] = u
+	}
+	if len(usage) != len(modules.RenterBandwidthCategories) {
+		t.Fatal("unexpected categories", rb.Categories)
+	}
+	for _, category := range []modules.RenterBandwidthCategory{modules.RenterBandwidthUpload, modules.RenterBandwidthStream, modules.RenterBandwidthHostDBScan, modules.RenterBandwidthContractFormation} {
+		if u := usage[category]; u.Hour.Uploaded+u.Hour.Downloaded == 0 || u.Total.Uploaded+u.Total.Downloaded == 0 {
+			t.Fatalf("%v traffic wasn't metered: %+v", category, u)
+		}
+	}
+	if usage[modules.RenterBandwidthUpload].Hour.Uploaded < modules.SectorSize*(dataPieces+parityPieces) {
+		t.Fatal("not all pieces were metered", usage[modules.RenterBandwidthUpload])
+	}
+
+	// A cap without a valid window is rejected.
+	if err := renter.RenterBandwidthPost(modules.RenterBandwidthStream, modules.RenterBandwidthCap{Bytes: 1}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Cap streams. Streaming another file fails.
+	_, rf2, err := renter.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := modules.RenterBandwidthCap{Window: modules.RenterBandwidthWindowHour, Bytes: 1}
+	if err := renter.RenterBandwidthPost(modules.RenterBandwidthStream, bc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renter.Stream(rf2); err == nil {
+		t.Fatal("stream succeeded despite the bandwidth cap")
+	}
+	rb, err = renter.RenterBandwidthGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range rb.Categories {
+		if u.Category == modules.RenterBandwidthStream && (u.Cap != bc || !u.CapReached) {
+			t.Fatal("cap wasn't applied", u)
+		}
+	}
+
+	// Removing the cap resumes streams.
+	if err := renter.RenterBandwidthPost(modules.RenterBandwidthStream, modules.RenterBandwidthCap{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renter.Stream(rf2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testStreamCacheSettings tests setting the stream cache settings and that the
+// stream cache records the reads of a stream.
+func testStreamCacheSettings(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+
+	// Check the defaults.
+	sc, err := renter.RenterStreamCacheGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Policy != modules.StreamCachePolicyAffinity || sc.MaxBytes != 0 || sc.ChunksPerStream != 0 {
+		t.Fatal("unexpected default settings", sc.StreamCacheSettings)
+	}
+
+	// Setting an invalid policy should fail.
+	settings := modules.StreamCacheSettings{
+		MaxBytes:        1 << 30,
+		ChunksPerStream: 4,
+		Policy:          "fifo",
+	}
+	if err := renter.RenterStreamCachePost(settings); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Set valid settings.
+	settings.Policy = modules.StreamCachePolicyLRU
+	if err := renter.RenterStreamCachePost(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload and stream a file. The read should be recorded.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, rf, err := renter.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := renter.Stream(rf); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = renter.RenterStreamCacheGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.StreamCacheSettings != settings {
+		t.Fatal("settings weren't applied", sc.StreamCacheSettings)
+	}
+	if sc.Hits+sc.Misses == 0 {
+		t.Fatal("stream reads weren't recorded")
+	}
+
+	// Reset the settings for the other subtests.
+	settings = modules.StreamCacheSettings{Policy: modules.StreamCachePolicyAffinity}
+	if err := renter.RenterStreamCachePost(settings); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // testStreamLargeFile tests that using the streaming endpoint to download
 // multiple chunks works.
 func testStreamLargeFile(t *testing.T, tg *siatest.TestGroup) {
@@ -2,8 +2,10 @@ package transactionpool
 
 import (
 	"path/filepath"
+	"reflect"
 	"testing"
 
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/types"
@@ -72,3 +74,59 @@ func TestTpoolTransactionsGet(t *testing.T) {
 		t.Fatal("expected no transactions got", len(tptg.Transactions))
 	}
 }
+
+// TestTpoolSettings probes the /tpool/settings endpoints.
+func TestTpoolSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create testing directory.
+	testdir := tpoolTestDir(t.Name())
+
+	// Create a miner
+	miner, err := siatest.NewNode(node.Miner(filepath.Join(testdir, "miner")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := miner.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Set the fee floors and an exempt address.
+	uc, err := miner.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := modules.TransactionPoolSettings{
+		MinAcceptFee:    types.SiacoinPrecision.Div64(1e3),
+		MinRelayFee:     types.SiacoinPrecision.Div64(1e2),
+		ExemptAddresses: []types.UnlockHash{uc.Address},
+	}
+	if err := miner.TransactionPoolSettingsPost(settings); err != nil {
+		t.Fatal(err)
+	}
+	tpsg, err := miner.TransactionPoolSettingsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tpsg.Settings, settings) {
+		t.Fatal("settings don't match", tpsg.Settings, settings)
+	}
+
+	// The fee estimation covers the floors, so the wallet's transactions are
+	// still accepted.
+	tfg, err := miner.TransactionPoolFeeGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tfg.Minimum.Cmp(settings.MinRelayFee) < 0 {
+		t.Fatal("fee estimation is below the relay floor", tfg.Minimum)
+	}
+	_, err = miner.WalletSiacoinsPost(types.SiacoinPrecision, uc.Address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/siatest/dependencies"
 	"go.sia.tech/siad/types"
@@ -738,7 +739,8 @@ func TestWalletSend(t *testing.T) {
 }
 
 // TestWalletSendUnsynced confirms that the wallet will return an error when
-// trying to send siacoins or siafunds if the consensus is not fully synced
+// trying to send siacoins or siafunds if the consensus is not fully synced and
+// that the routes which require a synced wallet report ErrorCodeNotSynced.
 func TestWalletSendUnsynced(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -753,6 +755,11 @@ func TestWalletSendUnsynced(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer func() {
+		if err := wallet.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
 
 	// Check error returned from siacoins multi post
 	_, err = wallet.WalletSiacoinsMultiPost([]types.SiacoinOutput{})
@@ -762,6 +769,9 @@ func TestWalletSendUnsynced(t *testing.T) {
 	if !strings.Contains(err.Error(), "cannot send siacoin until fully synced") {
 		t.Fatal("expected to get synced error but got:", err)
 	}
+	if code := api.ErrorCodeOf(err); code != api.ErrorCodeNotSynced {
+		t.Fatal("wrong error code", code)
+	}
 
 	// Check error returned from single siacoin post
 	_, err = wallet.WalletSiacoinsPost(types.ZeroCurrency, types.UnlockHash{}, false)
@@ -771,6 +781,9 @@ func TestWalletSendUnsynced(t *testing.T) {
 	if !strings.Contains(err.Error(), "cannot send siacoin until fully synced") {
 		t.Fatal("expected to get synced error but got:", err)
 	}
+	if code := api.ErrorCodeOf(err); code != api.ErrorCodeNotSynced {
+		t.Fatal("wrong error code", code)
+	}
 
 	// Check error returned from siafund post
 	_, err = wallet.WalletSiafundsPost(types.ZeroCurrency, types.UnlockHash{})
@@ -780,6 +793,33 @@ func TestWalletSendUnsynced(t *testing.T) {
 	if !strings.Contains(err.Error(), "cannot send siafunds until fully synced") {
 		t.Fatal("expected to get synced error but got:", err)
 	}
+	if code := api.ErrorCodeOf(err); code != api.ErrorCodeNotSynced {
+		t.Fatal("wrong error code", code)
+	}
+
+	// Check the remaining routes which require a synced wallet.
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	seedStr, err := modules.SeedToString(seed, mnemonics.English)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bumpErr := wallet.WalletBumpFeePost(types.TransactionID{})
+	_, sweepErr := wallet.WalletSweepPost(seedStr)
+	tests := []struct {
+		route string
+		err   error
+	}{
+		{"/wallet/bumpfee", bumpErr},
+		{"/wallet/init/seed", wallet.WalletInitSeedPost(seedStr, "", false)},
+		{"/wallet/seed", wallet.WalletSeedPost(seedStr, "")},
+		{"/wallet/sweep/seed", sweepErr},
+	}
+	for _, test := range tests {
+		if code := api.ErrorCodeOf(test.err); code != api.ErrorCodeNotSynced {
+			t.Fatalf("%v: expected error code %v but got %v (%v)", test.route, api.ErrorCodeNotSynced, code, test.err)
+		}
+	}
 }
 
 // TestWalletChangePasswordWithSeed initializes a wallet with a custom password
@@ -969,3 +1009,65 @@ func TestWalletVerifyPassword(t *testing.T) {
 		t.Error("Password should not be valid")
 	}
 }
+
+// TestWalletBumpFee tests bumping the fee of an unconfirmed transaction using
+// the /wallet/bumpfee endpoint.
+func TestWalletBumpFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup
+	groupParams := siatest.GroupParams{
+		Miners: 1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(walletTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	miner := tg.Miners()[0]
+
+	// Unknown transactions can't be bumped.
+	if _, err := miner.WalletBumpFeePost(types.TransactionID{1}); err == nil {
+		t.Fatal("expected bumping an unknown transaction to fail")
+	}
+
+	// Send some coins and bump the fee of the transaction.
+	wsp, err := miner.WalletSiacoinsPost(types.SiacoinPrecision, types.UnlockHash{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	wbp, err := miner.WalletBumpFeePost(txid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wbp.TransactionIDs) != len(wsp.TransactionIDs)+1 || wbp.TransactionIDs[len(wbp.TransactionIDs)-2] != txid {
+		t.Fatal("unexpected transactions", wbp.TransactionIDs)
+	}
+	childID := wbp.TransactionIDs[len(wbp.TransactionIDs)-1]
+
+	// Mine a block and check that the child was confirmed.
+	if err := miner.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		wtg, err := miner.WalletTransactionGet(childID)
+		if err != nil {
+			return err
+		}
+		if wtg.Transaction.ConfirmationHeight == types.BlockHeight(math.MaxUint64) {
+			return errors.New("child wasn't confirmed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// SpecifierForkSignal prefixes arbitrary data which signals support for fork
+// rules. It is followed by a little-endian uint64 in which every set bit
+// signals the fork rule which was assigned that bit.
+var SpecifierForkSignal = NewSpecifier("ForkSignal")
+
+// ForkSignalData returns arbitrary data which signals the provided bits.
+func ForkSignalData(bits uint64) []byte {
+	data := make([]byte, SpecifierLen+8)
+	copy(data, SpecifierForkSignal[:])
+	binary.LittleEndian.PutUint64(data[SpecifierLen:], bits)
+	return data
+}
+
+// ForkSignals returns the bits signaled by the block. Only the first
+// transaction of a block can signal, since the miner decides which transaction
+// comes first. Signals in other transactions could be added by anyone and make
+// the block invalid, see MisplacedForkSignals.
+func (b Block) ForkSignals() (bits uint64) {
+	if len(b.Transactions) == 0 {
+		return 0
+	}
+	for _, data := range b.Transactions[0].ArbitraryData {
+		if len(data) == SpecifierLen+8 && bytes.HasPrefix(data, SpecifierForkSignal[:]) {
+			bits |= binary.LittleEndian.Uint64(data[SpecifierLen:])
+		}
+	}
+	return bits
+}
+
+// MisplacedForkSignals returns true if a transaction other than the first
+// transaction of the block contains arbitrary data with the fork signal
+// prefix.
+func (b Block) MisplacedForkSignals() bool {
+	for i := 1; i < len(b.Transactions); i++ {
+		for _, data := range b.Transactions[i].ArbitraryData {
+			if bytes.HasPrefix(data, SpecifierForkSignal[:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
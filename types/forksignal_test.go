@@ -0,0 +1,38 @@
+package types
+
+import "testing"
+
+// TestForkSignals tests that the fork signals of a block are the union of the
+// signals of its first transaction, that malformed signals are ignored and
+// that signals in other transactions are detected.
+func TestForkSignals(t *testing.T) {
+	b := Block{
+		Transactions: []Transaction{{
+			ArbitraryData: [][]byte{
+				ForkSignalData(1 << 2),
+				[]byte("foo"),
+				ForkSignalData(1<<5 | 1<<2),
+				append(ForkSignalData(1<<7), 0),
+				SpecifierForkSignal[:],
+			},
+		}},
+	}
+	if bits := b.ForkSignals(); bits != 1<<2|1<<5 {
+		t.Fatalf("expected signals %b, got %b", 1<<2|1<<5, bits)
+	}
+	if b.MisplacedForkSignals() {
+		t.Fatal("signals of the first transaction aren't misplaced")
+	}
+	if (Block{}).ForkSignals() != 0 {
+		t.Fatal("empty block shouldn't signal")
+	}
+
+	// Signals of other transactions don't count and are misplaced.
+	b.Transactions = append(b.Transactions, Transaction{ArbitraryData: [][]byte{ForkSignalData(1 << 9)}})
+	if bits := b.ForkSignals(); bits != 1<<2|1<<5 {
+		t.Fatalf("expected signals %b, got %b", 1<<2|1<<5, bits)
+	}
+	if !b.MisplacedForkSignals() {
+		t.Fatal("signal of the second transaction should be misplaced")
+	}
+}
@@ -0,0 +1,70 @@
+package types
+
+// privatenetwork.go contains the helpers for running a private network with
+// the same binary as the public network. A private network is identified by
+// its network ID which is part of the genesis block. Nodes of different
+// networks therefore have different genesis IDs and refuse to connect to each
+// other.
+
+import (
+	"errors"
+)
+
+const (
+	// maxNetworkIDLen is the maximum length of a private network's ID.
+	maxNetworkIDLen = 64
+)
+
+var (
+	// NetworkID is the ID of the private network the node is part of. It is
+	// empty for the public network.
+	NetworkID string
+
+	// PrivateNetworkRootTarget is the target of the genesis block of private
+	// networks. Private networks don't have the hashrate of the public
+	// network, so standard CPUs need to be able to mine blocks.
+	PrivateNetworkRootTarget = Target{0, 0, 2}
+
+	// SpecifierPrivateNetwork is the prefix of the arbitrary data in the
+	// genesis block of a private network which contains the network ID.
+	SpecifierPrivateNetwork = NewSpecifier("PrivateNetwork")
+)
+
+var (
+	// ErrInvalidNetworkID is returned if the ID of a private network is empty
+	// or too long.
+	ErrInvalidNetworkID = errors.New("network id needs to be between 1 and 64 bytes long")
+)
+
+// PrivateNetworkGenesisBlock returns the genesis block of the private network
+// with the provided ID. The block uses the genesis allocation of the current
+// build and the provided timestamp.
+func PrivateNetworkGenesisBlock(networkID string, timestamp Timestamp) Block {
+	return Block{
+		Timestamp: timestamp,
+		Transactions: []Transaction{
+			{
+				SiafundOutputs: GenesisSiafundAllocation,
+				ArbitraryData:  [][]byte{append(SpecifierPrivateNetwork[:], networkID...)},
+			},
+		},
+	}
+}
+
+// SetPrivateNetwork replaces the genesis block and the root target with the
+// ones of the private network with the provided ID. If timestamp is zero, the
+// genesis timestamp of the current build is used. It needs to be called before
+// any modules are created.
+func SetPrivateNetwork(networkID string, timestamp Timestamp) error {
+	if len(networkID) == 0 || len(networkID) > maxNetworkIDLen {
+		return ErrInvalidNetworkID
+	}
+	if timestamp != 0 {
+		GenesisTimestamp = timestamp
+	}
+	NetworkID = networkID
+	RootTarget = PrivateNetworkRootTarget
+	GenesisBlock = PrivateNetworkGenesisBlock(networkID, GenesisTimestamp)
+	GenesisID = GenesisBlock.ID()
+	return nil
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"testing"
+)
+
+// TestPrivateNetworkGenesisBlock checks that private networks have unique
+// genesis IDs.
+func TestPrivateNetworkGenesisBlock(t *testing.T) {
+	a := PrivateNetworkGenesisBlock("a", GenesisTimestamp)
+	b := PrivateNetworkGenesisBlock("b", GenesisTimestamp)
+	if a.ID() == GenesisID || b.ID() == GenesisID {
+		t.Fatal("private network shouldn't share the public genesis ID")
+	}
+	if a.ID() == b.ID() {
+		t.Fatal("private networks with different IDs should have different genesis IDs")
+	}
+	if a.ID() != PrivateNetworkGenesisBlock("a", GenesisTimestamp).ID() {
+		t.Fatal("genesis ID of a private network should be deterministic")
+	}
+	if a.ID() == PrivateNetworkGenesisBlock("a", GenesisTimestamp+1).ID() {
+		t.Fatal("genesis ID should depend on the timestamp")
+	}
+}
+
+// TestSetPrivateNetworkInvalidID checks that SetPrivateNetwork rejects invalid
+// network IDs without changing the genesis block.
+func TestSetPrivateNetworkInvalidID(t *testing.T) {
+	genesisID := GenesisID
+	tooLong := string(make([]byte, maxNetworkIDLen+1))
+	for _, id := range []string{"", tooLong} {
+		if err := SetPrivateNetwork(id, 0); err != ErrInvalidNetworkID {
+			t.Fatal("expected ErrInvalidNetworkID, got", err)
+		}
+	}
+	if GenesisID != genesisID || NetworkID != "" {
+		t.Fatal("invalid network ID changed the genesis block")
+	}
+}